@@ -0,0 +1,189 @@
+// Package artifacts provides artifact storage.
+// This file contains the AzureBucket molecule which stores objects as block
+// blobs in an Azure Blob Storage container, authorized by a SAS token.
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// azureAPIVersion is the Blob service REST API version requested.
+const azureAPIVersion = "2021-08-06"
+
+// AzureConfig configures an AzureBucket.
+type AzureConfig struct {
+	// ContainerURL is the container URL with a SAS token granting read,
+	// write, delete and list, e.g.
+	// "https://account.blob.core.windows.net/artifacts?sv=...&sig=..."
+	ContainerURL string
+
+	// Prefix is prepended to every blob name, e.g. "canvusllm/"
+	Prefix string
+
+	// HTTPClient for requests (default: client with a 60s timeout)
+	HTTPClient *http.Client
+}
+
+// AzureBucket stores objects as block blobs in an Azure container.
+type AzureBucket struct {
+	config    AzureConfig
+	container *url.URL
+	sas       url.Values
+	client    *http.Client
+}
+
+// NewAzureBucket creates an AzureBucket.
+func NewAzureBucket(config AzureConfig) (*AzureBucket, error) {
+	container, err := url.Parse(config.ContainerURL)
+	if err != nil || container.Host == "" || strings.Trim(container.Path, "/") == "" {
+		return nil, errors.New("artifacts: Azure container URL must look like https://<account>.blob.core.windows.net/<container>?<sas>")
+	}
+	sas := container.Query()
+	if sas.Get("sig") == "" {
+		return nil, errors.New("artifacts: Azure container URL has no SAS token")
+	}
+	container.RawQuery = ""
+	container.Path = strings.TrimRight(container.Path, "/")
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &AzureBucket{config: config, container: container, sas: sas, client: config.HTTPClient}, nil
+}
+
+// Name returns "azure".
+func (b *AzureBucket) Name() string {
+	return "azure"
+}
+
+// Put uploads the object with Put Blob.
+func (b *AzureBucket) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	header := http.Header{"X-Ms-Blob-Type": {"BlockBlob"}}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := b.do(ctx, http.MethodPut, b.config.Prefix+key, nil, header, data)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads the object with Get Blob.
+func (b *AzureBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := b.do(ctx, http.MethodGet, b.config.Prefix+key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// List returns the blob names under the prefix with List Blobs, without the prefix.
+func (b *AzureBucket) List(ctx context.Context) ([]string, error) {
+	var keys []string
+	marker := ""
+	for {
+		query := url.Values{"restype": {"container"}, "comp": {"list"}}
+		if b.config.Prefix != "" {
+			query.Set("prefix", b.config.Prefix)
+		}
+		if marker != "" {
+			query.Set("marker", marker)
+		}
+		resp, err := b.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Blobs struct {
+				Blob []struct {
+					Name string `xml:"Name"`
+				} `xml:"Blob"`
+			} `xml:"Blobs"`
+			NextMarker string `xml:"NextMarker"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid List Blobs response: %w", err)
+		}
+
+		for _, blob := range result.Blobs.Blob {
+			keys = append(keys, strings.TrimPrefix(blob.Name, b.config.Prefix))
+		}
+		if result.NextMarker == "" {
+			return keys, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// Delete removes the object with Delete Blob.
+func (b *AzureBucket) Delete(ctx context.Context, key string) error {
+	resp, err := b.do(ctx, http.MethodDelete, b.config.Prefix+key, nil, nil, nil)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends a request for blob name (empty for the container itself) with
+// the SAS token. Responses other than 2xx are returned as errors; 404 is
+// ErrNotFound.
+func (b *AzureBucket) do(ctx context.Context, method, name string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u := *b.container
+	if name != "" {
+		u.Path += "/" + name
+	}
+	values := url.Values{}
+	for k, v := range b.sas {
+		values[k] = v
+	}
+	for k, v := range query {
+		values[k] = v
+	}
+	u.RawQuery = values.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("X-Ms-Version", azureAPIVersion)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		var azureErr struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&azureErr)
+		return nil, fmt.Errorf("Azure %s %s failed: %s %s %s", method, u.Path, resp.Status, azureErr.Code,
+			strings.SplitN(azureErr.Message, "\n", 2)[0])
+	}
+	return resp, nil
+}
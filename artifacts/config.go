@@ -0,0 +1,105 @@
+package artifacts
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go_backend/core"
+)
+
+// Backend names accepted by ARTIFACT_STORE.
+const (
+	BackendNone  = ""
+	BackendLocal = "local"
+	BackendS3    = "s3"
+	BackendAzure = "azure"
+)
+
+// DefaultDir is the local artifact directory.
+const DefaultDir = "artifacts"
+
+// Config selects and configures the artifact store.
+type Config struct {
+	// Backend is BackendNone (artifacts are not kept), BackendLocal,
+	// BackendS3 or BackendAzure
+	Backend string
+
+	// Dir is the directory of the local backend (default: artifacts)
+	Dir string
+
+	// S3 configures the s3 backend
+	S3 S3Config
+
+	// Azure configures the azure backend
+	Azure AzureConfig
+
+	// Retention limits the kept artifacts
+	Retention Retention
+}
+
+// ConfigFromEnv loads the artifact configuration from environment variables:
+//   - ARTIFACT_STORE: local, s3 or azure (default: empty, artifacts are deleted)
+//   - ARTIFACT_DIR: directory of the local store (default: artifacts)
+//   - ARTIFACT_PREFIX: key prefix in S3 and Azure (default: empty)
+//   - ARTIFACT_RETENTION_DAYS: delete artifacts older than this (default: 0, keep)
+//   - ARTIFACT_MAX_COUNT: keep only the newest artifacts (default: 0, unlimited)
+//   - ARTIFACT_S3_BUCKET, ARTIFACT_S3_REGION, ARTIFACT_S3_ENDPOINT
+//   - ARTIFACT_S3_ACCESS_KEY_ID, ARTIFACT_S3_SECRET_ACCESS_KEY (default:
+//     AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN)
+//   - ARTIFACT_AZURE_CONTAINER_URL: container URL with a SAS token
+func ConfigFromEnv() Config {
+	prefix := os.Getenv("ARTIFACT_PREFIX")
+	accessKey := core.GetEnvOrDefault("ARTIFACT_S3_ACCESS_KEY_ID", os.Getenv("AWS_ACCESS_KEY_ID"))
+	secretKey := core.GetEnvOrDefault("ARTIFACT_S3_SECRET_ACCESS_KEY", os.Getenv("AWS_SECRET_ACCESS_KEY"))
+
+	return Config{
+		Backend: strings.ToLower(strings.TrimSpace(os.Getenv("ARTIFACT_STORE"))),
+		Dir:     core.GetEnvOrDefault("ARTIFACT_DIR", DefaultDir),
+		S3: S3Config{
+			Bucket:          os.Getenv("ARTIFACT_S3_BUCKET"),
+			Region:          core.GetEnvOrDefault("ARTIFACT_S3_REGION", os.Getenv("AWS_REGION")),
+			Endpoint:        os.Getenv("ARTIFACT_S3_ENDPOINT"),
+			Prefix:          prefix,
+			AccessKeyID:     accessKey,
+			SecretAccessKey: secretKey,
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		Azure: AzureConfig{
+			ContainerURL: os.Getenv("ARTIFACT_AZURE_CONTAINER_URL"),
+			Prefix:       prefix,
+		},
+		Retention: Retention{
+			MaxAge:   time.Duration(core.ParseIntEnv("ARTIFACT_RETENTION_DAYS", 0)) * 24 * time.Hour,
+			MaxCount: core.ParseIntEnv("ARTIFACT_MAX_COUNT", 0),
+		},
+	}
+}
+
+// NewStoreFromConfig creates the configured store. It returns nil for
+// BackendNone.
+func NewStoreFromConfig(config Config) (*Store, error) {
+	var bucket Bucket
+	var err error
+	switch config.Backend {
+	case BackendNone:
+		return nil, nil
+	case BackendLocal:
+		dir := config.Dir
+		if dir == "" {
+			dir = DefaultDir
+		}
+		bucket, err = NewDirBucket(dir)
+	case BackendS3:
+		bucket, err = NewS3Bucket(config.S3)
+	case BackendAzure:
+		bucket, err = NewAzureBucket(config.Azure)
+	default:
+		return nil, fmt.Errorf("artifacts: unknown ARTIFACT_STORE %q (use local, s3 or azure)", config.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(bucket), nil
+}
@@ -0,0 +1,101 @@
+// Package artifacts provides artifact storage.
+// This file contains the DirBucket molecule which stores objects as files
+// in a local directory.
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirBucket stores objects as files in a directory.
+type DirBucket struct {
+	dir string
+}
+
+// NewDirBucket creates a DirBucket in dir, creating the directory if needed.
+func NewDirBucket(dir string) (*DirBucket, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("artifacts: failed to create %s: %w", dir, err)
+	}
+	return &DirBucket{dir: dir}, nil
+}
+
+// Name returns "local".
+func (b *DirBucket) Name() string {
+	return "local"
+}
+
+// Put writes the object atomically through a temporary file.
+func (b *DirBucket) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(b.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get opens the object's file.
+func (b *DirBucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := b.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// List returns the names of the files in the directory.
+func (b *DirBucket) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+			keys = append(keys, entry.Name())
+		}
+	}
+	return keys, nil
+}
+
+// Delete removes the object's file.
+func (b *DirBucket) Delete(ctx context.Context, key string) error {
+	path, err := b.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// path returns the file path of key, which must be a plain file name.
+func (b *DirBucket) path(key string) (string, error) {
+	if key == "" || key != filepath.Base(key) || strings.HasPrefix(key, ".") {
+		return "", fmt.Errorf("artifacts: invalid object key %q", key)
+	}
+	return filepath.Join(b.dir, key), nil
+}
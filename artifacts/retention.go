@@ -0,0 +1,112 @@
+// Package artifacts provides artifact storage.
+// This file contains the Pruner organism which enforces the retention
+// policy by deleting old artifacts.
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go_backend/core/clock"
+
+	"go.uber.org/zap"
+)
+
+// DefaultPruneInterval is how often the Pruner applies the retention policy.
+const DefaultPruneInterval = time.Hour
+
+// Retention limits how many artifacts are kept.
+type Retention struct {
+	// MaxAge deletes artifacts older than this (0 keeps them forever)
+	MaxAge time.Duration
+
+	// MaxCount keeps only the newest MaxCount artifacts (0 is unlimited)
+	MaxCount int
+}
+
+// Enabled reports whether the policy deletes anything.
+func (r Retention) Enabled() bool {
+	return r.MaxAge > 0 || r.MaxCount > 0
+}
+
+// Pruner is an organism that periodically deletes the artifacts outside
+// the retention policy.
+//
+// Usage:
+//
+//	p := artifacts.NewPruner(store, retention, logger)
+//	go p.Run(ctx)
+type Pruner struct {
+	store     *Store
+	retention Retention
+	interval  time.Duration
+	logger    *zap.Logger
+	clock     clock.Clock
+}
+
+// NewPruner creates a Pruner for store.
+func NewPruner(store *Store, retention Retention, logger *zap.Logger) *Pruner {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Pruner{
+		store:     store,
+		retention: retention,
+		interval:  DefaultPruneInterval,
+		logger:    logger,
+		clock:     clock.Real(),
+	}
+}
+
+// SetClock replaces the clock used for the age cutoff and the prune
+// interval. Call before Run.
+func (p *Pruner) SetClock(c clock.Clock) {
+	p.clock = clock.OrReal(c)
+}
+
+// Prune deletes the artifacts outside the retention policy and returns how
+// many were deleted.
+func (p *Pruner) Prune(ctx context.Context) (int, error) {
+	list, err := p.store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	cutoff := p.clock.Now().Add(-p.retention.MaxAge)
+	deleted := 0
+	// list is newest first
+	for i, a := range list {
+		expired := p.retention.MaxAge > 0 && a.CreatedAt.Before(cutoff)
+		excess := p.retention.MaxCount > 0 && i >= p.retention.MaxCount
+		if !expired && !excess {
+			continue
+		}
+		if err := p.store.Delete(ctx, a.ID); err != nil && !errors.Is(err, ErrNotFound) {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Run prunes at startup and then every interval until ctx is done.
+func (p *Pruner) Run(ctx context.Context) {
+	ticker := p.clock.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := p.Prune(ctx)
+		if err != nil {
+			p.logger.Warn("Artifact pruning failed", zap.Error(err))
+		} else if deleted > 0 {
+			p.logger.Info("Pruned expired artifacts", zap.Int("deleted", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
@@ -0,0 +1,303 @@
+// Package artifacts provides artifact storage.
+// This file contains the S3Bucket molecule which stores objects in Amazon
+// S3 or an S3-compatible service, signing requests with AWS Signature V4.
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// S3Config configures an S3Bucket.
+type S3Config struct {
+	// Bucket name
+	Bucket string
+
+	// Region of the bucket (default: us-east-1)
+	Region string
+
+	// Endpoint of an S3-compatible service such as MinIO, e.g.
+	// "http://minio:9000". Empty uses AWS. A custom endpoint uses path-style
+	// URLs, AWS uses virtual-hosted-style URLs.
+	Endpoint string
+
+	// Prefix is prepended to every object key, e.g. "canvusllm/"
+	Prefix string
+
+	// AccessKeyID and SecretAccessKey are the credentials
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// SessionToken for temporary credentials (optional)
+	SessionToken string
+
+	// HTTPClient for requests (default: client with a 60s timeout)
+	HTTPClient *http.Client
+}
+
+// S3Bucket stores objects in an S3 bucket.
+type S3Bucket struct {
+	config  S3Config
+	baseURL *url.URL
+	client  *http.Client
+	clock   clock.Clock
+}
+
+// NewS3Bucket creates an S3Bucket.
+func NewS3Bucket(config S3Config) (*S3Bucket, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("artifacts: S3 bucket name is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, errors.New("artifacts: S3 access key ID and secret access key are required")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 60 * time.Second}
+	}
+
+	var base string
+	if config.Endpoint != "" {
+		base = strings.TrimRight(config.Endpoint, "/") + "/" + config.Bucket
+	} else {
+		base = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", config.Bucket, config.Region)
+	}
+	baseURL, err := url.Parse(base)
+	if err != nil || baseURL.Host == "" {
+		return nil, fmt.Errorf("artifacts: invalid S3 endpoint %q", config.Endpoint)
+	}
+
+	return &S3Bucket{config: config, baseURL: baseURL, client: config.HTTPClient, clock: clock.Real()}, nil
+}
+
+// Name returns "s3".
+func (b *S3Bucket) Name() string {
+	return "s3"
+}
+
+// Put uploads the object with PutObject.
+func (b *S3Bucket) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	resp, err := b.do(ctx, http.MethodPut, b.config.Prefix+key, nil, data, contentType)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads the object with GetObject.
+func (b *S3Bucket) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	resp, err := b.do(ctx, http.MethodGet, b.config.Prefix+key, nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// List returns the keys under the prefix with ListObjectsV2, without the prefix.
+func (b *S3Bucket) List(ctx context.Context) ([]string, error) {
+	var keys []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}}
+		if b.config.Prefix != "" {
+			query.Set("prefix", b.config.Prefix)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := b.do(ctx, http.MethodGet, "", query, nil, "")
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid ListObjectsV2 response: %w", err)
+		}
+
+		for _, c := range result.Contents {
+			keys = append(keys, strings.TrimPrefix(c.Key, b.config.Prefix))
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return keys, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// Delete removes the object with DeleteObject.
+func (b *S3Bucket) Delete(ctx context.Context, key string) error {
+	resp, err := b.do(ctx, http.MethodDelete, b.config.Prefix+key, nil, nil, "")
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// do sends a signed request for key (empty for the bucket itself). Responses
+// other than 2xx are returned as errors; 404 is ErrNotFound.
+func (b *S3Bucket) do(ctx context.Context, method, key string, query url.Values, body []byte, contentType string) (*http.Response, error) {
+	u := *b.baseURL
+	if key != "" {
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + key
+	} else if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	b.sign(req, body)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		var s3Err struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&s3Err)
+		return nil, fmt.Errorf("S3 %s %s failed: %s %s %s", method, u.Path, resp.Status, s3Err.Code, s3Err.Message)
+	}
+	return resp, nil
+}
+
+// sign adds AWS Signature Version 4 headers to req. The host, Content-Type,
+// Range and x-amz-* headers are signed.
+func (b *S3Bucket) sign(req *http.Request, body []byte) {
+	now := b.clock.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if b.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", b.config.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "range" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + b.config.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+b.config.SecretAccessKey), date)
+	key = hmacSHA256(key, b.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s",
+		b.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes query sorted by key with RFC 3986 escaping, as
+// Signature V4 requires.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode escapes every byte except the RFC 3986 unreserved characters.
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,318 @@
+// Package artifacts keeps generated images and downloaded PDFs after the
+// task that produced them finishes, so they can be listed, downloaded and
+// re-uploaded to a canvas later.
+//
+// The Store organism saves each artifact as two objects in a Bucket: the
+// content and a JSON metadata object. Buckets are available for a local
+// directory, Amazon S3 (or any S3-compatible service) and Azure Blob Storage.
+// This file contains the Artifact atoms and the Store.
+package artifacts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// Kind identifies what produced an artifact.
+type Kind string
+
+const (
+	// KindImage is a generated image
+	KindImage Kind = "image"
+
+	// KindPDF is a PDF downloaded from a canvas for analysis
+	KindPDF Kind = "pdf"
+
+	// KindExport is a canvas exported as a Markdown or HTML document
+	KindExport Kind = "export"
+)
+
+// metaSuffix is appended to the artifact ID to form the metadata object key.
+const metaSuffix = ".json"
+
+// ErrNotFound is returned for an artifact that does not exist.
+var ErrNotFound = errors.New("artifact not found")
+
+// validID matches the IDs generated by newID. IDs are used in object keys
+// and file names, so anything else is rejected.
+var validID = regexp.MustCompile(`^[0-9]{8}T[0-9]{6}-[0-9a-f]{8}$`)
+
+// Artifact describes a stored artifact.
+type Artifact struct {
+	// ID is assigned by the store, sortable by creation time
+	ID string `json:"id"`
+
+	// Kind of artifact
+	Kind Kind `json:"kind"`
+
+	// Name is the file name used when downloading or re-uploading
+	Name string `json:"name"`
+
+	// ContentType of the content (detected if empty)
+	ContentType string `json:"content_type"`
+
+	// Size of the content in bytes
+	Size int64 `json:"size_bytes"`
+
+	// CreatedAt is when the artifact was stored
+	CreatedAt time.Time `json:"created_at"`
+
+	// CanvasID is the canvas the artifact belongs to
+	CanvasID string `json:"canvas_id,omitempty"`
+
+	// SourceWidgetID is the widget that triggered the task
+	SourceWidgetID string `json:"source_widget_id,omitempty"`
+
+	// Prompt that generated an image
+	Prompt string `json:"prompt,omitempty"`
+
+	// Width and Height of an image in pixels
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+
+	// Thumbnail is set when a thumbnail of an image was stored
+	Thumbnail bool `json:"thumbnail,omitempty"`
+
+	// Generation records how an image was generated (optional)
+	Generation *Generation `json:"generation,omitempty"`
+}
+
+// Generation records the parameters of a generated image, so it can be
+// generated again.
+type Generation struct {
+	// Seed, Steps and CFGScale are set for Stable Diffusion images
+	Seed     *int64  `json:"seed,omitempty"`
+	Steps    int     `json:"steps,omitempty"`
+	CFGScale float64 `json:"cfg_scale,omitempty"`
+
+	// Width and Height are the generated size, before any scaling for
+	// upload
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+}
+
+// Bucket is a flat object store. Implemented by DirBucket, S3Bucket and
+// AzureBucket.
+type Bucket interface {
+	// Name identifies the backend in logs and API responses
+	Name() string
+
+	// Put creates or replaces the object key
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+
+	// Get opens the object key; returns ErrNotFound if it does not exist
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// List returns the keys of every object
+	List(ctx context.Context) ([]string, error)
+
+	// Delete removes the object key; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// Store is an organism that saves artifacts in a Bucket.
+//
+// Usage:
+//
+//	bucket, _ := artifacts.NewDirBucket("artifacts")
+//	store := artifacts.NewStore(bucket)
+//	a, err := store.Put(ctx, artifacts.Artifact{Kind: artifacts.KindImage, Name: "cat.png"}, data)
+type Store struct {
+	bucket Bucket
+	clock  clock.Clock
+}
+
+// NewStore creates a Store saving to bucket.
+func NewStore(bucket Bucket) *Store {
+	return &Store{bucket: bucket, clock: clock.Real()}
+}
+
+// SetClock sets the clock that names and stamps stored artifacts (default:
+// clock.Real). Call it before the store is used.
+func (s *Store) SetClock(c clock.Clock) {
+	s.clock = clock.OrReal(c)
+}
+
+// Backend returns the name of the bucket backend.
+func (s *Store) Backend() string {
+	return s.bucket.Name()
+}
+
+// Put stores data and returns the artifact with its ID, size, content type
+// and creation time filled in. Images also get a thumbnail and their
+// pixel size; an image that cannot be decoded is stored without them.
+func (s *Store) Put(ctx context.Context, a Artifact, data []byte) (Artifact, error) {
+	id, err := newID(s.clock.Now())
+	if err != nil {
+		return Artifact{}, err
+	}
+	a.ID = id
+	a.Name = filepath.Base(a.Name)
+	if a.Name == "." || a.Name == string(filepath.Separator) {
+		a.Name = id
+	}
+	a.Size = int64(len(data))
+	a.CreatedAt = s.clock.Now().UTC()
+	if a.ContentType == "" {
+		a.ContentType = http.DetectContentType(data)
+	}
+
+	var thumb []byte
+	if a.Kind == KindImage {
+		if t, width, height, err := Thumbnail(data); err == nil {
+			thumb, a.Width, a.Height, a.Thumbnail = t, width, height, true
+		}
+	}
+
+	if err := s.bucket.Put(ctx, a.ID, data, a.ContentType); err != nil {
+		return Artifact{}, fmt.Errorf("artifacts: failed to store %s: %w", a.Name, err)
+	}
+	if thumb != nil {
+		if err := s.bucket.Put(ctx, a.ID+thumbSuffix, thumb, "image/jpeg"); err != nil {
+			s.bucket.Delete(ctx, a.ID)
+			return Artifact{}, fmt.Errorf("artifacts: failed to store thumbnail of %s: %w", a.Name, err)
+		}
+	}
+	// The metadata is written last; content without metadata is not listed
+	meta, err := json.Marshal(a)
+	if err != nil {
+		s.deleteContent(ctx, a.ID)
+		return Artifact{}, fmt.Errorf("artifacts: failed to encode metadata: %w", err)
+	}
+	if err := s.bucket.Put(ctx, a.ID+metaSuffix, meta, "application/json"); err != nil {
+		s.deleteContent(ctx, a.ID)
+		return Artifact{}, fmt.Errorf("artifacts: failed to store metadata of %s: %w", a.Name, err)
+	}
+	return a, nil
+}
+
+// PutFile stores the file at path. Name defaults to the file's base name.
+func (s *Store) PutFile(ctx context.Context, a Artifact, path string) (Artifact, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Artifact{}, fmt.Errorf("artifacts: failed to read %s: %w", path, err)
+	}
+	if a.Name == "" {
+		a.Name = filepath.Base(path)
+	}
+	return s.Put(ctx, a, data)
+}
+
+// Get returns the metadata of artifact id.
+func (s *Store) Get(ctx context.Context, id string) (Artifact, error) {
+	if !validID.MatchString(id) {
+		return Artifact{}, ErrNotFound
+	}
+	r, err := s.bucket.Get(ctx, id+metaSuffix)
+	if err != nil {
+		return Artifact{}, err
+	}
+	defer r.Close()
+
+	var a Artifact
+	if err := json.NewDecoder(r).Decode(&a); err != nil {
+		return Artifact{}, fmt.Errorf("artifacts: invalid metadata for %s: %w", id, err)
+	}
+	return a, nil
+}
+
+// Open returns the metadata and content of artifact id. The caller must
+// close the content.
+func (s *Store) Open(ctx context.Context, id string) (Artifact, io.ReadCloser, error) {
+	a, err := s.Get(ctx, id)
+	if err != nil {
+		return Artifact{}, nil, err
+	}
+	r, err := s.bucket.Get(ctx, id)
+	if err != nil {
+		return Artifact{}, nil, err
+	}
+	return a, r, nil
+}
+
+// OpenThumbnail returns the JPEG thumbnail of image artifact id. It
+// returns ErrNotFound if the artifact has none. The caller must close it.
+func (s *Store) OpenThumbnail(ctx context.Context, id string) (io.ReadCloser, error) {
+	a, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !a.Thumbnail {
+		return nil, ErrNotFound
+	}
+	return s.bucket.Get(ctx, id+thumbSuffix)
+}
+
+// List returns every artifact, newest first.
+func (s *Store) List(ctx context.Context) ([]Artifact, error) {
+	keys, err := s.bucket.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("artifacts: failed to list %s bucket: %w", s.bucket.Name(), err)
+	}
+
+	var list []Artifact
+	for _, key := range keys {
+		id, ok := strings.CutSuffix(key, metaSuffix)
+		if !ok || !validID.MatchString(id) {
+			continue
+		}
+		a, err := s.Get(ctx, id)
+		if errors.Is(err, ErrNotFound) {
+			// Deleted while listing
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, a)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].ID > list[j].ID })
+	return list, nil
+}
+
+// Delete removes artifact id.
+func (s *Store) Delete(ctx context.Context, id string) error {
+	if _, err := s.Get(ctx, id); err != nil {
+		return err
+	}
+	// Metadata first, so a partly deleted artifact is no longer listed
+	if err := s.bucket.Delete(ctx, id+metaSuffix); err != nil {
+		return fmt.Errorf("artifacts: failed to delete %s: %w", id, err)
+	}
+	if err := s.deleteContent(ctx, id); err != nil {
+		return fmt.Errorf("artifacts: failed to delete %s: %w", id, err)
+	}
+	return nil
+}
+
+// deleteContent removes the content and thumbnail of artifact id.
+func (s *Store) deleteContent(ctx context.Context, id string) error {
+	if err := s.bucket.Delete(ctx, id+thumbSuffix); err != nil {
+		return err
+	}
+	return s.bucket.Delete(ctx, id)
+}
+
+// newID returns a unique ID that sorts by creation time.
+func newID(now time.Time) (string, error) {
+	var suffix [4]byte
+	if _, err := rand.Read(suffix[:]); err != nil {
+		return "", fmt.Errorf("artifacts: failed to generate ID: %w", err)
+	}
+	return now.UTC().Format("20060102T150405") + "-" + hex.EncodeToString(suffix[:]), nil
+}
@@ -0,0 +1,284 @@
+package artifacts
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	bucket, err := NewDirBucket(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirBucket failed: %v", err)
+	}
+	return NewStore(bucket)
+}
+
+func TestStorePutOpenListDelete(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	png := []byte("\x89PNG\r\n\x1a\nimage")
+	image, err := store.Put(ctx, Artifact{Kind: KindImage, Name: "../../cat.png", Prompt: "a cat"}, png)
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if !validID.MatchString(image.ID) {
+		t.Errorf("ID = %q, want a generated ID", image.ID)
+	}
+	if image.Name != "cat.png" || image.Size != int64(len(png)) || image.ContentType != "image/png" {
+		t.Errorf("artifact = %+v", image)
+	}
+
+	store.clock = clock.NewFake(time.Now().Add(time.Second))
+	pdf, err := store.Put(ctx, Artifact{Kind: KindPDF, Name: "report.pdf"}, []byte("%PDF-1.7"))
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	a, r, err := store.Open(ctx, image.ID)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	data, _ := io.ReadAll(r)
+	r.Close()
+	if string(data) != string(png) || a.Prompt != "a cat" {
+		t.Errorf("Open = %+v %q", a, data)
+	}
+
+	list, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != pdf.ID || list[1].ID != image.ID {
+		t.Fatalf("List = %+v, want newest first", list)
+	}
+
+	if err := store.Delete(ctx, image.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, err := store.Get(ctx, image.ID); err != ErrNotFound {
+		t.Errorf("Get after delete = %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, image.ID); err != ErrNotFound {
+		t.Errorf("second Delete = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStoreImageThumbnail(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	src := image.NewRGBA(image.Rect(0, 0, 1024, 512))
+	for x := 0; x < 1024; x++ {
+		src.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+	seed := int64(42)
+	a, err := store.Put(ctx, Artifact{
+		Kind:       KindImage,
+		Name:       "wide.png",
+		Prompt:     "a wide field",
+		Generation: &Generation{Seed: &seed, Steps: 20, CFGScale: 7, Width: 1024, Height: 512},
+	}, buf.Bytes())
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if !a.Thumbnail || a.Width != 1024 || a.Height != 512 {
+		t.Errorf("artifact = %+v, want a thumbnail and size 1024x512", a)
+	}
+
+	got, err := store.Get(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if got.Generation == nil || got.Generation.Seed == nil || *got.Generation.Seed != 42 || got.Generation.Steps != 20 {
+		t.Errorf("Generation = %+v", got.Generation)
+	}
+
+	r, err := store.OpenThumbnail(ctx, a.ID)
+	if err != nil {
+		t.Fatalf("OpenThumbnail failed: %v", err)
+	}
+	thumb, err := jpeg.Decode(r)
+	r.Close()
+	if err != nil {
+		t.Fatalf("thumbnail is not a JPEG: %v", err)
+	}
+	if b := thumb.Bounds(); b.Dx() != ThumbnailSize || b.Dy() != ThumbnailSize/2 {
+		t.Errorf("thumbnail size = %v, want %dx%d", b, ThumbnailSize, ThumbnailSize/2)
+	}
+
+	// Images that cannot be decoded are stored without a thumbnail
+	broken, err := store.Put(ctx, Artifact{Kind: KindImage, Name: "broken.png"}, []byte("\x89PNG\r\n\x1a\nimage"))
+	if err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	if broken.Thumbnail {
+		t.Error("broken image has a thumbnail")
+	}
+	if _, err := store.OpenThumbnail(ctx, broken.ID); err != ErrNotFound {
+		t.Errorf("OpenThumbnail(broken) = %v, want ErrNotFound", err)
+	}
+
+	if err := store.Delete(ctx, a.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	list, err := store.List(ctx)
+	if err != nil || len(list) != 1 {
+		t.Errorf("List after delete = %+v, %v", list, err)
+	}
+	if _, err := os.Stat(filepath.Join(store.bucket.(*DirBucket).dir, a.ID+thumbSuffix)); !os.IsNotExist(err) {
+		t.Errorf("thumbnail left behind after Delete: %v", err)
+	}
+}
+
+func TestStoreRejectsInvalidIDs(t *testing.T) {
+	store := newTestStore(t)
+	for _, id := range []string{"", "../secret", "20260101T000000-zzzzzzzz", "20260101T000000-0123abcd.json"} {
+		if _, err := store.Get(context.Background(), id); err != ErrNotFound {
+			t.Errorf("Get(%q) = %v, want ErrNotFound", id, err)
+		}
+	}
+}
+
+func TestStoreListSkipsContentWithoutMetadata(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	bucket, _ := NewDirBucket(dir)
+	store := NewStore(bucket)
+
+	if _, err := store.Put(ctx, Artifact{Kind: KindImage, Name: "a.png"}, []byte("a")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+	// An interrupted Put leaves content without metadata
+	os.WriteFile(filepath.Join(dir, "20260101T000000-0123abcd"), []byte("orphan"), 0644)
+
+	list, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 1 {
+		t.Errorf("List returned %d artifacts, want 1", len(list))
+	}
+}
+
+func TestStorePutFile(t *testing.T) {
+	store := newTestStore(t)
+	path := filepath.Join(t.TempDir(), "pdf_analysis_1.pdf")
+	os.WriteFile(path, []byte("%PDF-1.4 test"), 0644)
+
+	a, err := store.PutFile(context.Background(), Artifact{Kind: KindPDF}, path)
+	if err != nil {
+		t.Fatalf("PutFile failed: %v", err)
+	}
+	if a.Name != "pdf_analysis_1.pdf" || a.ContentType != "application/pdf" {
+		t.Errorf("artifact = %+v", a)
+	}
+}
+
+func TestDirBucketRejectsPaths(t *testing.T) {
+	bucket, _ := NewDirBucket(t.TempDir())
+	for _, key := range []string{"../x", "a/b", ".hidden", ""} {
+		if err := bucket.Put(context.Background(), key, nil, ""); err == nil {
+			t.Errorf("Put(%q) succeeded, want error", key)
+		}
+	}
+}
+
+func TestNewStoreFromConfig(t *testing.T) {
+	store, err := NewStoreFromConfig(Config{})
+	if store != nil || err != nil {
+		t.Errorf("NewStoreFromConfig(none) = %v, %v; want nil, nil", store, err)
+	}
+
+	store, err = NewStoreFromConfig(Config{Backend: BackendLocal, Dir: t.TempDir()})
+	if err != nil || store.Backend() != "local" {
+		t.Errorf("NewStoreFromConfig(local) = %v, %v", store, err)
+	}
+
+	if _, err := NewStoreFromConfig(Config{Backend: BackendS3}); err == nil {
+		t.Error("expected error for s3 without bucket")
+	}
+	if _, err := NewStoreFromConfig(Config{Backend: "ftp"}); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+func TestPrunerRetention(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+	now := time.Now()
+
+	var ids []string
+	for _, age := range []time.Duration{72 * time.Hour, 48 * time.Hour, time.Hour, 0} {
+		store.clock = clock.NewFake(now.Add(-age))
+		a, err := store.Put(ctx, Artifact{Kind: KindImage, Name: "x.png"}, []byte("x"))
+		if err != nil {
+			t.Fatalf("Put failed: %v", err)
+		}
+		ids = append(ids, a.ID)
+	}
+
+	// Max age removes the 72h artifact, max count the 48h one
+	pruner := NewPruner(store, Retention{MaxAge: 60 * time.Hour, MaxCount: 2}, nil)
+	pruner.SetClock(clock.NewFake(now))
+	deleted, err := pruner.Prune(ctx)
+	if err != nil {
+		t.Fatalf("Prune failed: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted = %d, want 2", deleted)
+	}
+
+	list, _ := store.List(ctx)
+	if len(list) != 2 || list[0].ID != ids[3] || list[1].ID != ids[2] {
+		t.Errorf("remaining = %+v, want the two newest", list)
+	}
+}
+
+func TestPrunerRunPrunesEachInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store := newTestStore(t)
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	store.clock = fake
+	if _, err := store.Put(ctx, Artifact{Kind: KindImage, Name: "x.png"}, []byte("x")); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	pruner := NewPruner(store, Retention{MaxAge: 90 * time.Minute}, nil)
+	pruner.SetClock(fake)
+	go pruner.Run(ctx)
+
+	// The artifact expires between the first and second hourly prune
+	fake.BlockUntil(1)
+	fake.Advance(DefaultPruneInterval)
+	fake.Advance(DefaultPruneInterval)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		list, _ := store.List(ctx)
+		if len(list) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expired artifact was not pruned")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
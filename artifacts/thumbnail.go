@@ -0,0 +1,56 @@
+package artifacts
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // decode generated PNGs
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // decode IMAGE_OUTPUT_FORMAT=webp images
+)
+
+// ThumbnailSize is the longest side of image thumbnails in pixels.
+const ThumbnailSize = 256
+
+// thumbSuffix is appended to the artifact ID to form the thumbnail object
+// key. It does not end in metaSuffix, so thumbnails are never listed.
+const thumbSuffix = ".thumb.jpg"
+
+// thumbnailQuality is the JPEG quality of thumbnails.
+const thumbnailQuality = 80
+
+// Thumbnail decodes a PNG, JPEG or WebP image and returns a JPEG at most
+// ThumbnailSize pixels on its longest side, with the size of the original.
+// Smaller images keep their size.
+func Thumbnail(data []byte) (thumb []byte, width, height int, err error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("artifacts: failed to decode image: %w", err)
+	}
+	bounds := src.Bounds()
+	width, height = bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, 0, 0, fmt.Errorf("artifacts: image is empty")
+	}
+
+	tw, th := width, height
+	if tw > ThumbnailSize || th > ThumbnailSize {
+		if tw >= th {
+			tw, th = ThumbnailSize, max(1, height*ThumbnailSize/width)
+		} else {
+			tw, th = max(1, width*ThumbnailSize/height), ThumbnailSize
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	// Transparent areas become white rather than black in the JPEG
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, 0, 0, fmt.Errorf("artifacts: failed to encode thumbnail: %w", err)
+	}
+	return buf.Bytes(), width, height, nil
+}
@@ -0,0 +1,102 @@
+// Package audit provides the tamper-evident audit trail of AI actions on the
+// canvas. This file contains the Entry type and the hash chain atoms.
+//
+// Every entry stores the hash of the entry before it, and its own hash covers
+// all of its fields and that previous hash. Changing, removing or reordering
+// any stored entry therefore breaks the chain from that entry on, which
+// Verify reports.
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Action is what an AI task did to a widget.
+type Action string
+
+// Audited actions.
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+)
+
+// GenesisHash is the previous hash of the first entry.
+var GenesisHash = strings.Repeat("0", 64)
+
+// Entry is one audited widget creation or modification.
+type Entry struct {
+	// Seq numbers entries from 1 without gaps
+	Seq  int64     `json:"seq"`
+	Time time.Time `json:"time"`
+
+	CanvasID      string `json:"canvas_id"`
+	CorrelationID string `json:"correlation_id"`
+
+	Action     Action `json:"action"`
+	WidgetType string `json:"widget_type"`
+	WidgetID   string `json:"widget_id"`
+
+	// TriggerWidgetID and TriggerType identify the widget that started the
+	// task, e.g. the note holding a {{ }} prompt or an AI icon
+	TriggerWidgetID string `json:"trigger_widget_id"`
+	TriggerType     string `json:"trigger_type"`
+
+	// Operation is the task type, as in processing_history (e.g. "pdf_analysis")
+	Operation string `json:"operation"`
+	Model     string `json:"model"`
+
+	// ContentSHA256 is the hash of the text or file written to the widget
+	ContentSHA256 string `json:"content_sha256"`
+
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// ContentHash returns the hex SHA-256 of content.
+func ContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// ComputeHash returns the hash of e, covering every field except Hash.
+// Fields are length-prefixed so no two entries encode the same way.
+func ComputeHash(e Entry) string {
+	h := sha256.New()
+	for _, field := range []string{
+		strconv.FormatInt(e.Seq, 10),
+		e.Time.UTC().Format(time.RFC3339Nano),
+		e.CanvasID,
+		e.CorrelationID,
+		string(e.Action),
+		e.WidgetType,
+		e.WidgetID,
+		e.TriggerWidgetID,
+		e.TriggerType,
+		e.Operation,
+		e.Model,
+		e.ContentSHA256,
+		e.PrevHash,
+	} {
+		fmt.Fprintf(h, "%d:%s;", len(field), field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// checkLink reports why e does not follow an entry with sequence prevSeq
+// and hash prevHash, or "" if it does.
+func checkLink(e Entry, prevSeq int64, prevHash string) string {
+	switch {
+	case e.Seq != prevSeq+1:
+		return fmt.Sprintf("expected seq %d, found %d", prevSeq+1, e.Seq)
+	case e.PrevHash != prevHash:
+		return "previous hash does not match the entry before it"
+	case e.Hash != ComputeHash(e):
+		return "hash does not match the entry contents"
+	}
+	return ""
+}
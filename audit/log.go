@@ -0,0 +1,131 @@
+// Package audit provides the tamper-evident audit trail of AI actions on the
+// canvas. This file contains the Log organism, which appends entries to the
+// chain and verifies it.
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go_backend/core/clock"
+)
+
+// verifyPageSize is the number of entries read at a time by Verify.
+const verifyPageSize = 500
+
+// Storage persists audit entries. It must only ever append; entries are
+// never updated or deleted. Implemented by db.Repository.
+type Storage interface {
+	// LastAuditEntry returns the newest entry, or nil if there is none.
+	LastAuditEntry(ctx context.Context) (*Entry, error)
+	// InsertAuditEntry appends e.
+	InsertAuditEntry(ctx context.Context, e Entry) error
+	// ListAuditEntries returns up to limit entries with Seq > afterSeq,
+	// oldest first.
+	ListAuditEntries(ctx context.Context, afterSeq int64, limit int) ([]Entry, error)
+}
+
+// VerifyResult is the outcome of walking the whole chain.
+type VerifyResult struct {
+	Valid    bool   `json:"valid"`
+	Entries  int64  `json:"entries"`
+	LastHash string `json:"last_hash"`
+	// FirstBadSeq is the first entry that breaks the chain (0 if valid)
+	FirstBadSeq int64  `json:"first_bad_seq,omitempty"`
+	Problem     string `json:"problem,omitempty"`
+}
+
+// Log appends entries to the hash chain.
+//
+// Thread-Safety: Log is safe for concurrent use. Appends are serialized so
+// each entry links to the one recorded before it.
+type Log struct {
+	storage Storage
+	clock   clock.Clock
+
+	mu       sync.Mutex
+	loaded   bool
+	lastSeq  int64
+	lastHash string
+}
+
+// NewLog creates a Log backed by storage.
+func NewLog(storage Storage) *Log {
+	return &Log{storage: storage, clock: clock.Real()}
+}
+
+// SetClock sets the clock that stamps recorded entries (default: clock.Real).
+func (l *Log) SetClock(c clock.Clock) {
+	l.mu.Lock()
+	l.clock = clock.OrReal(c)
+	l.mu.Unlock()
+}
+
+// Record fills in the sequence number, time and hashes of e and appends it.
+// The stored entry is returned.
+func (l *Log) Record(ctx context.Context, e Entry) (Entry, error) {
+	if l.storage == nil {
+		return e, errors.New("audit: no storage")
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.loaded {
+		last, err := l.storage.LastAuditEntry(ctx)
+		if err != nil {
+			return e, fmt.Errorf("audit: failed to read last entry: %w", err)
+		}
+		l.lastSeq, l.lastHash = 0, GenesisHash
+		if last != nil {
+			l.lastSeq, l.lastHash = last.Seq, last.Hash
+		}
+		l.loaded = true
+	}
+
+	e.Seq = l.lastSeq + 1
+	e.Time = l.clock.Now().UTC()
+	e.PrevHash = l.lastHash
+	e.Hash = ComputeHash(e)
+
+	if err := l.storage.InsertAuditEntry(ctx, e); err != nil {
+		// Another writer may have appended; re-read before the next record
+		l.loaded = false
+		return e, fmt.Errorf("audit: failed to append entry: %w", err)
+	}
+	l.lastSeq, l.lastHash = e.Seq, e.Hash
+	return e, nil
+}
+
+// Entries returns up to limit entries with Seq > afterSeq, oldest first.
+func (l *Log) Entries(ctx context.Context, afterSeq int64, limit int) ([]Entry, error) {
+	return l.storage.ListAuditEntries(ctx, afterSeq, limit)
+}
+
+// Verify walks the whole chain and reports the first entry that does not
+// link to the one before it.
+func (l *Log) Verify(ctx context.Context) (VerifyResult, error) {
+	result := VerifyResult{Valid: true, LastHash: GenesisHash}
+	var lastSeq int64
+	for {
+		page, err := l.storage.ListAuditEntries(ctx, lastSeq, verifyPageSize)
+		if err != nil {
+			return result, fmt.Errorf("audit: failed to read entries: %w", err)
+		}
+		for _, e := range page {
+			if problem := checkLink(e, lastSeq, result.LastHash); problem != "" {
+				result.Valid = false
+				result.FirstBadSeq = e.Seq
+				result.Problem = problem
+				return result, nil
+			}
+			lastSeq, result.LastHash = e.Seq, e.Hash
+			result.Entries++
+		}
+		if len(page) < verifyPageSize {
+			return result, nil
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// memoryStorage keeps entries in a slice
+type memoryStorage struct {
+	mu        sync.Mutex
+	entries   []Entry
+	insertErr error
+}
+
+func (m *memoryStorage) LastAuditEntry(ctx context.Context) (*Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.entries) == 0 {
+		return nil, nil
+	}
+	e := m.entries[len(m.entries)-1]
+	return &e, nil
+}
+
+func (m *memoryStorage) InsertAuditEntry(ctx context.Context, e Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.insertErr != nil {
+		return m.insertErr
+	}
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+func (m *memoryStorage) ListAuditEntries(ctx context.Context, afterSeq int64, limit int) ([]Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Entry
+	for _, e := range m.entries {
+		if e.Seq > afterSeq && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func recordN(t *testing.T, log *Log, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		_, err := log.Record(context.Background(), Entry{
+			CanvasID:   "canvas-1",
+			Action:     ActionCreate,
+			WidgetType: "Note",
+			WidgetID:   "w",
+			Operation:  "text_generation",
+			Model:      "gpt-4o",
+		})
+		if err != nil {
+			t.Fatalf("Record() error: %v", err)
+		}
+	}
+}
+
+func TestRecord_ChainsEntries(t *testing.T) {
+	storage := &memoryStorage{}
+	log := NewLog(storage)
+	recordN(t, log, 3)
+
+	if len(storage.entries) != 3 {
+		t.Fatalf("stored %d entries, want 3", len(storage.entries))
+	}
+	prev := GenesisHash
+	for i, e := range storage.entries {
+		if e.Seq != int64(i+1) || e.PrevHash != prev || e.Hash != ComputeHash(e) {
+			t.Errorf("entry %d = %+v, does not chain", i, e)
+		}
+		prev = e.Hash
+	}
+
+	result, err := log.Verify(context.Background())
+	if err != nil || !result.Valid || result.Entries != 3 || result.LastHash != prev {
+		t.Errorf("Verify() = %+v, %v", result, err)
+	}
+}
+
+func TestRecord_ContinuesExistingChain(t *testing.T) {
+	storage := &memoryStorage{}
+	recordN(t, NewLog(storage), 2)
+
+	// A new Log, as after a restart, picks up from the stored entries
+	recordN(t, NewLog(storage), 1)
+
+	result, err := NewLog(storage).Verify(context.Background())
+	if err != nil || !result.Valid || result.Entries != 3 {
+		t.Errorf("Verify() = %+v, %v", result, err)
+	}
+}
+
+func TestSetClock_RepeatsHashes(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var runs [2]*memoryStorage
+	for i := range runs {
+		runs[i] = &memoryStorage{}
+		log := NewLog(runs[i])
+		log.SetClock(clock.NewFake(epoch))
+		recordN(t, log, 2)
+	}
+	if a, b := runs[0].entries[1], runs[1].entries[1]; !a.Time.Equal(epoch) || a.Hash != b.Hash {
+		t.Errorf("entries with a fixed clock differ: %+v and %+v", a, b)
+	}
+}
+
+func TestRecord_InsertFails(t *testing.T) {
+	storage := &memoryStorage{insertErr: errors.New("disk full")}
+	log := NewLog(storage)
+	if _, err := log.Record(context.Background(), Entry{Action: ActionCreate}); err == nil {
+		t.Fatal("Record() should fail when storage fails")
+	}
+
+	storage.insertErr = nil
+	e, err := log.Record(context.Background(), Entry{Action: ActionCreate})
+	if err != nil || e.Seq != 1 {
+		t.Errorf("Record() after failure = %+v, %v; want seq 1", e, err)
+	}
+}
+
+func TestVerify_DetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(entries []Entry) []Entry
+		badSeq int64
+	}{
+		{"modified field", func(es []Entry) []Entry {
+			es[1].Model = "other-model"
+			return es
+		}, 2},
+		{"modified time", func(es []Entry) []Entry {
+			es[2].Time = es[2].Time.Add(time.Second)
+			return es
+		}, 3},
+		{"rehashed entry", func(es []Entry) []Entry {
+			es[1].WidgetID = "forged"
+			es[1].Hash = ComputeHash(es[1])
+			return es
+		}, 3},
+		{"removed entry", func(es []Entry) []Entry {
+			return append(es[:1], es[2:]...)
+		}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage := &memoryStorage{}
+			log := NewLog(storage)
+			recordN(t, log, 4)
+			storage.entries = tt.tamper(storage.entries)
+
+			result, err := log.Verify(context.Background())
+			if err != nil {
+				t.Fatalf("Verify() error: %v", err)
+			}
+			if result.Valid || result.FirstBadSeq != tt.badSeq || result.Problem == "" {
+				t.Errorf("Verify() = %+v, want first bad seq %d", result, tt.badSeq)
+			}
+		})
+	}
+}
+
+func TestComputeHash_FieldBoundaries(t *testing.T) {
+	a := Entry{WidgetType: "Note", WidgetID: "x"}
+	b := Entry{WidgetType: "Not", WidgetID: "ex"}
+	if ComputeHash(a) == ComputeHash(b) {
+		t.Error("ComputeHash() should not collide when text moves between fields")
+	}
+}
@@ -0,0 +1,142 @@
+// Package bench provides standardized text and image generation benchmarks
+// for sizing hardware. A run measures tokens/sec, images/min and peak VRAM,
+// and derives recommended concurrency settings that are written to a JSON
+// report for support.
+//
+// This file contains the report types and the pure recommendation atoms.
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// MaxRecommendedSDConcurrent caps the SD_MAX_CONCURRENT recommendation.
+// Beyond this, a single GPU gains no throughput from more parallel renders.
+const MaxRecommendedSDConcurrent = 4
+
+// Report is the result of a benchmark run.
+type Report struct {
+	Timestamp time.Time `json:"timestamp"`
+	Hostname  string    `json:"hostname,omitempty"`
+	Duration  string    `json:"duration"`
+
+	GPU   *GPUInfo     `json:"gpu,omitempty"`
+	Text  *TextResult  `json:"text,omitempty"`
+	Image *ImageResult `json:"image,omitempty"`
+
+	// PeakVRAMBytes is the highest VRAM usage observed during the run.
+	PeakVRAMBytes int64 `json:"peak_vram_bytes"`
+
+	Recommendations Recommendations `json:"recommendations"`
+
+	// Errors lists workloads that failed or were skipped.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// GPUInfo describes the GPU the benchmark ran on.
+type GPUInfo struct {
+	Name           string `json:"name"`
+	DriverVersion  string `json:"driver_version,omitempty"`
+	TotalVRAMBytes int64  `json:"total_vram_bytes"`
+}
+
+// TextResult holds text generation measurements.
+type TextResult struct {
+	Model           string  `json:"model,omitempty"`
+	Runs            int     `json:"runs"`
+	TokensGenerated int     `json:"tokens_generated"`
+	TokensPerSecond float64 `json:"tokens_per_second"`
+	AvgLatencySecs  float64 `json:"avg_latency_secs"`
+
+	// Concurrency holds aggregate throughput per number of parallel requests.
+	Concurrency []ConcurrencyResult `json:"concurrency,omitempty"`
+
+	// PeakVRAMBytes is the highest VRAM usage during the text workload.
+	PeakVRAMBytes int64 `json:"peak_vram_bytes"`
+}
+
+// ConcurrencyResult is the aggregate throughput at one concurrency level.
+type ConcurrencyResult struct {
+	Parallel        int     `json:"parallel"`
+	TokensPerSecond float64 `json:"tokens_per_second"`
+}
+
+// ImageResult holds image generation measurements.
+type ImageResult struct {
+	Model           string  `json:"model,omitempty"`
+	Runs            int     `json:"runs"`
+	Size            int     `json:"size"`
+	Steps           int     `json:"steps"`
+	ImagesPerMinute float64 `json:"images_per_minute"`
+	AvgSecsPerImage float64 `json:"avg_secs_per_image"`
+
+	// VRAMPerImageBytes is the VRAM growth observed while rendering one image.
+	VRAMPerImageBytes int64 `json:"vram_per_image_bytes"`
+
+	// PeakVRAMBytes is the highest VRAM usage during the image workload.
+	PeakVRAMBytes int64 `json:"peak_vram_bytes"`
+}
+
+// Recommendations are suggested settings derived from the measurements.
+// Zero values mean no recommendation could be made.
+type Recommendations struct {
+	SDMaxConcurrent int      `json:"sd_max_concurrent,omitempty"`
+	LlamaContexts   int      `json:"llama_contexts,omitempty"`
+	Notes           []string `json:"notes,omitempty"`
+}
+
+// WriteJSON writes the report as indented JSON to path.
+func (r *Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+// RecommendSDConcurrent returns how many images can render in parallel
+// given the VRAM left over at peak usage and the VRAM cost of one image.
+// Always recommends at least 1 and at most MaxRecommendedSDConcurrent.
+func RecommendSDConcurrent(totalVRAM, peakVRAM, perImage int64) int {
+	if totalVRAM <= 0 || perImage <= 0 {
+		return 1
+	}
+	free := totalVRAM - peakVRAM
+	if free < 0 {
+		free = 0
+	}
+	n := 1 + int(free/perImage)
+	if n > MaxRecommendedSDConcurrent {
+		n = MaxRecommendedSDConcurrent
+	}
+	return n
+}
+
+// RecommendContexts returns the smallest concurrency level that reaches at
+// least 90% of the best measured throughput. More contexts than that cost
+// VRAM without a meaningful throughput gain.
+func RecommendContexts(results []ConcurrencyResult) int {
+	best := 0.0
+	for _, r := range results {
+		if r.TokensPerSecond > best {
+			best = r.TokensPerSecond
+		}
+	}
+	if best <= 0 {
+		return 0
+	}
+
+	recommended := 0
+	for _, r := range results {
+		if r.TokensPerSecond >= best*0.9 && (recommended == 0 || r.Parallel < recommended) {
+			recommended = r.Parallel
+		}
+	}
+	return recommended
+}
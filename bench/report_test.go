@@ -0,0 +1,85 @@
+package bench
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const gb = int64(1024 * 1024 * 1024)
+
+func TestRecommendSDConcurrent(t *testing.T) {
+	tests := []struct {
+		name     string
+		total    int64
+		peak     int64
+		perImage int64
+		want     int
+	}{
+		{"unknown VRAM", 0, 0, 2 * gb, 1},
+		{"unknown per-image cost", 24 * gb, 10 * gb, 0, 1},
+		{"no room for a second image", 12 * gb, 11 * gb, 3 * gb, 1},
+		{"room for two more", 24 * gb, 16 * gb, 4 * gb, 3},
+		{"capped", 80 * gb, 10 * gb, 2 * gb, MaxRecommendedSDConcurrent},
+		{"peak above total", 8 * gb, 9 * gb, 2 * gb, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RecommendSDConcurrent(tt.total, tt.peak, tt.perImage)
+			if got != tt.want {
+				t.Errorf("RecommendSDConcurrent(%d, %d, %d) = %d, want %d",
+					tt.total, tt.peak, tt.perImage, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecommendContexts(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []ConcurrencyResult
+		want    int
+	}{
+		{"no results", nil, 0},
+		{"no scaling", []ConcurrencyResult{{1, 50}, {2, 51}, {4, 50}}, 1},
+		{"scales to two", []ConcurrencyResult{{1, 50}, {2, 95}, {4, 100}}, 2},
+		{"scales to four", []ConcurrencyResult{{1, 50}, {2, 70}, {4, 120}}, 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RecommendContexts(tt.results); got != tt.want {
+				t.Errorf("RecommendContexts() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReport_WriteJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	report := &Report{
+		Text:            &TextResult{Runs: 3, TokensPerSecond: 42.5},
+		Recommendations: Recommendations{SDMaxConcurrent: 2, LlamaContexts: 1},
+	}
+
+	if err := report.WriteJSON(path); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if decoded.Text == nil || decoded.Text.TokensPerSecond != 42.5 {
+		t.Errorf("decoded text = %+v, want tokens_per_second 42.5", decoded.Text)
+	}
+	if decoded.Recommendations.SDMaxConcurrent != 2 {
+		t.Errorf("decoded sd_max_concurrent = %d, want 2", decoded.Recommendations.SDMaxConcurrent)
+	}
+}
@@ -0,0 +1,422 @@
+// Package bench provides standardized text and image generation benchmarks.
+// This file contains the Runner organism that executes the workloads.
+package bench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"go_backend/llamaruntime"
+	"go_backend/metrics"
+	"go_backend/sdruntime"
+)
+
+// benchPrompt is the fixed text prompt, long enough to exercise prompt
+// processing without dominating the measurement.
+const benchPrompt = "Summarize the benefits of collaborative whiteboards for distributed teams in three short paragraphs."
+
+// benchImagePrompt is the fixed image prompt.
+const benchImagePrompt = "a watercolor painting of a lighthouse on a rocky coast at sunset"
+
+// benchImageSeed keeps image runs reproducible across machines.
+const benchImageSeed = 42
+
+// TextGenerator runs text inference (implemented by *llamaruntime.Client).
+type TextGenerator interface {
+	Infer(ctx context.Context, params llamaruntime.InferenceParams) (*llamaruntime.InferenceResult, error)
+}
+
+// ImageGenerator renders images (implemented by *sdruntime.ContextPool).
+type ImageGenerator interface {
+	Generate(ctx context.Context, params sdruntime.GenerateParams) ([]byte, error)
+}
+
+// GPUReader reads GPU metrics (implemented by *metrics.NVMLReader).
+type GPUReader interface {
+	ReadGPUMetrics() (metrics.GPUMetrics, error)
+}
+
+// Config configures a benchmark run. Workloads with a nil generator are skipped.
+type Config struct {
+	// Text is the text generator; nil skips the text workload.
+	Text TextGenerator
+
+	// TextModel is the text model name recorded in the report.
+	TextModel string
+
+	// TextRuns is the number of sequential text generations (default: 5).
+	TextRuns int
+
+	// MaxTokens is the token budget per text generation (default: 128).
+	MaxTokens int
+
+	// MaxParallel is the highest concurrency level measured (default: 4).
+	// Levels 1, 2, 4, ... up to MaxParallel are measured.
+	MaxParallel int
+
+	// Image is the image generator; nil skips the image workload.
+	Image ImageGenerator
+
+	// ImageModel is the image model name recorded in the report.
+	ImageModel string
+
+	// ImageRuns is the number of sequential image generations (default: 3).
+	ImageRuns int
+
+	// ImageSize is the image width and height in pixels (default: 512).
+	ImageSize int
+
+	// ImageSteps is the number of inference steps per image (default: 20).
+	ImageSteps int
+
+	// GPU reads VRAM usage; nil disables VRAM measurements.
+	GPU GPUReader
+
+	// SampleInterval is how often VRAM is sampled (default: 200ms).
+	SampleInterval time.Duration
+
+	// Logf receives progress messages; may be nil.
+	Logf func(format string, args ...interface{})
+}
+
+// withDefaults returns a copy of the config with defaults applied.
+func (c Config) withDefaults() Config {
+	if c.TextRuns <= 0 {
+		c.TextRuns = 5
+	}
+	if c.MaxTokens <= 0 {
+		c.MaxTokens = 128
+	}
+	if c.MaxParallel <= 0 {
+		c.MaxParallel = 4
+	}
+	if c.ImageRuns <= 0 {
+		c.ImageRuns = 3
+	}
+	if c.ImageSize <= 0 {
+		c.ImageSize = sdruntime.DefaultImageSize
+	}
+	if c.ImageSteps <= 0 {
+		c.ImageSteps = sdruntime.DefaultInferenceSteps
+	}
+	if c.SampleInterval <= 0 {
+		c.SampleInterval = 200 * time.Millisecond
+	}
+	if c.Logf == nil {
+		c.Logf = func(string, ...interface{}) {}
+	}
+	return c
+}
+
+// Run executes the configured workloads and returns the report.
+// A failing workload is recorded in Report.Errors rather than aborting the
+// run; an error is returned only if no workload is configured or ctx ends.
+func Run(ctx context.Context, config Config) (*Report, error) {
+	config = config.withDefaults()
+	if config.Text == nil && config.Image == nil {
+		return nil, errors.New("bench: no workloads configured")
+	}
+
+	start := time.Now()
+	report := &Report{Timestamp: start}
+	if hostname, err := os.Hostname(); err == nil {
+		report.Hostname = hostname
+	}
+
+	sampler := newVRAMSampler(config.GPU, config.SampleInterval)
+	if m, ok := sampler.read(); ok {
+		report.GPU = &GPUInfo{
+			Name:           m.Name,
+			DriverVersion:  m.DriverVersion,
+			TotalVRAMBytes: m.MemoryTotal,
+		}
+	}
+	sampler.start()
+	defer sampler.stop()
+
+	if config.Text != nil {
+		sampler.resetPeak()
+		text, err := runText(ctx, config)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("text: %v", err))
+		}
+		if text != nil {
+			text.PeakVRAMBytes = sampler.peak()
+			report.Text = text
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if config.Image != nil {
+		baseline, _ := sampler.current()
+		sampler.resetPeak()
+		image, err := runImage(ctx, config)
+		if err != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("image: %v", err))
+		}
+		if image != nil {
+			image.PeakVRAMBytes = sampler.peak()
+			if baseline > 0 && image.PeakVRAMBytes > baseline {
+				image.VRAMPerImageBytes = image.PeakVRAMBytes - baseline
+			}
+			report.Image = image
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	report.PeakVRAMBytes = sampler.overallPeak()
+	report.Recommendations = recommend(report)
+	report.Duration = time.Since(start).Round(time.Millisecond).String()
+	return report, nil
+}
+
+// runText runs the sequential and concurrent text workloads.
+func runText(ctx context.Context, config Config) (*TextResult, error) {
+	params := llamaruntime.InferenceParams{
+		Prompt:      benchPrompt,
+		MaxTokens:   config.MaxTokens,
+		Temperature: 0.1,
+	}
+
+	// Warm-up run is excluded from the measurements
+	config.Logf("text: warm-up")
+	if _, err := config.Text.Infer(ctx, params); err != nil {
+		return nil, err
+	}
+
+	result := &TextResult{Model: config.TextModel, Runs: config.TextRuns}
+	var elapsed time.Duration
+	for i := 0; i < config.TextRuns; i++ {
+		config.Logf("text: run %d/%d", i+1, config.TextRuns)
+		runStart := time.Now()
+		r, err := config.Text.Infer(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		elapsed += time.Since(runStart)
+		result.TokensGenerated += r.TokensGenerated
+	}
+	if elapsed > 0 {
+		result.TokensPerSecond = float64(result.TokensGenerated) / elapsed.Seconds()
+		result.AvgLatencySecs = elapsed.Seconds() / float64(config.TextRuns)
+	}
+
+	for parallel := 1; parallel <= config.MaxParallel; parallel *= 2 {
+		config.Logf("text: %d parallel requests", parallel)
+		tps, err := measureParallel(ctx, config.Text, params, parallel)
+		if err != nil {
+			return result, err
+		}
+		result.Concurrency = append(result.Concurrency, ConcurrencyResult{
+			Parallel:        parallel,
+			TokensPerSecond: tps,
+		})
+	}
+
+	return result, nil
+}
+
+// measureParallel runs parallel requests at once and returns the aggregate
+// tokens per second.
+func measureParallel(ctx context.Context, gen TextGenerator, params llamaruntime.InferenceParams, parallel int) (float64, error) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		tokens   int
+		firstErr error
+	)
+
+	start := time.Now()
+	for i := 0; i < parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r, err := gen.Infer(ctx, params)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			tokens += r.TokensGenerated
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if firstErr != nil {
+		return 0, firstErr
+	}
+	if elapsed <= 0 {
+		return 0, nil
+	}
+	return float64(tokens) / elapsed.Seconds(), nil
+}
+
+// runImage runs the sequential image workload.
+func runImage(ctx context.Context, config Config) (*ImageResult, error) {
+	params := sdruntime.GenerateParams{
+		Prompt:   benchImagePrompt,
+		Width:    config.ImageSize,
+		Height:   config.ImageSize,
+		Steps:    config.ImageSteps,
+		CFGScale: sdruntime.DefaultGuidanceScale,
+		Seed:     benchImageSeed,
+	}
+
+	// Warm-up run is excluded from the measurements
+	config.Logf("image: warm-up")
+	if _, err := config.Image.Generate(ctx, params); err != nil {
+		return nil, err
+	}
+
+	result := &ImageResult{
+		Model: config.ImageModel,
+		Runs:  config.ImageRuns,
+		Size:  config.ImageSize,
+		Steps: config.ImageSteps,
+	}
+	var elapsed time.Duration
+	for i := 0; i < config.ImageRuns; i++ {
+		config.Logf("image: run %d/%d", i+1, config.ImageRuns)
+		runStart := time.Now()
+		if _, err := config.Image.Generate(ctx, params); err != nil {
+			return nil, err
+		}
+		elapsed += time.Since(runStart)
+	}
+	if elapsed > 0 {
+		result.AvgSecsPerImage = elapsed.Seconds() / float64(config.ImageRuns)
+		result.ImagesPerMinute = float64(config.ImageRuns) / elapsed.Minutes()
+	}
+	return result, nil
+}
+
+// recommend derives settings from the measurements in report.
+func recommend(report *Report) Recommendations {
+	var rec Recommendations
+
+	if report.Text != nil {
+		rec.LlamaContexts = RecommendContexts(report.Text.Concurrency)
+		if rec.LlamaContexts == 1 && len(report.Text.Concurrency) > 1 {
+			rec.Notes = append(rec.Notes, "text throughput does not scale with parallel requests; extra contexts only cost VRAM")
+		}
+	}
+
+	if report.Image != nil {
+		if report.GPU == nil || report.Image.VRAMPerImageBytes == 0 {
+			rec.SDMaxConcurrent = 1
+			rec.Notes = append(rec.Notes, "VRAM per image unknown; SD_MAX_CONCURRENT defaults to 1")
+		} else {
+			rec.SDMaxConcurrent = RecommendSDConcurrent(
+				report.GPU.TotalVRAMBytes,
+				report.PeakVRAMBytes,
+				report.Image.VRAMPerImageBytes,
+			)
+		}
+	}
+
+	return rec
+}
+
+// vramSampler polls GPU memory usage in the background and tracks peaks.
+type vramSampler struct {
+	gpu      GPUReader
+	interval time.Duration
+
+	mu       sync.Mutex
+	peakUsed int64
+	maxUsed  int64
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newVRAMSampler(gpu GPUReader, interval time.Duration) *vramSampler {
+	return &vramSampler{gpu: gpu, interval: interval, done: make(chan struct{})}
+}
+
+// read returns one metrics sample, recording it toward the peaks.
+func (s *vramSampler) read() (metrics.GPUMetrics, bool) {
+	if s.gpu == nil {
+		return metrics.GPUMetrics{}, false
+	}
+	m, err := s.gpu.ReadGPUMetrics()
+	if err != nil {
+		return metrics.GPUMetrics{}, false
+	}
+	s.mu.Lock()
+	if m.MemoryUsed > s.peakUsed {
+		s.peakUsed = m.MemoryUsed
+	}
+	if m.MemoryUsed > s.maxUsed {
+		s.maxUsed = m.MemoryUsed
+	}
+	s.mu.Unlock()
+	return m, true
+}
+
+// current returns the VRAM currently in use.
+func (s *vramSampler) current() (int64, bool) {
+	m, ok := s.read()
+	return m.MemoryUsed, ok
+}
+
+func (s *vramSampler) start() {
+	if s.gpu == nil {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				s.read()
+			}
+		}
+	}()
+}
+
+func (s *vramSampler) stop() {
+	close(s.done)
+	s.wg.Wait()
+}
+
+// resetPeak starts a new workload peak measurement.
+func (s *vramSampler) resetPeak() {
+	s.mu.Lock()
+	s.peakUsed = 0
+	s.mu.Unlock()
+	s.read()
+}
+
+// peak returns the peak since the last resetPeak, sampling once more so
+// short workloads are not missed between ticks.
+func (s *vramSampler) peak() int64 {
+	s.read()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.peakUsed
+}
+
+// overallPeak returns the peak over the whole run.
+func (s *vramSampler) overallPeak() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.maxUsed
+}
@@ -0,0 +1,128 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"go_backend/llamaruntime"
+	"go_backend/metrics"
+	"go_backend/sdruntime"
+)
+
+type fakeText struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (f *fakeText) Infer(ctx context.Context, params llamaruntime.InferenceParams) (*llamaruntime.InferenceResult, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &llamaruntime.InferenceResult{TokensGenerated: params.MaxTokens}, nil
+}
+
+// fakeImage reports growing VRAM through gpu while it renders.
+type fakeImage struct {
+	gpu   *fakeGPU
+	calls int
+}
+
+func (f *fakeImage) Generate(ctx context.Context, params sdruntime.GenerateParams) ([]byte, error) {
+	f.calls++
+	f.gpu.setUsed(6 * gb)
+	return []byte("png"), nil
+}
+
+type fakeGPU struct {
+	mu   sync.Mutex
+	used int64
+}
+
+func (f *fakeGPU) setUsed(used int64) {
+	f.mu.Lock()
+	f.used = used
+	f.mu.Unlock()
+}
+
+func (f *fakeGPU) ReadGPUMetrics() (metrics.GPUMetrics, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return metrics.GPUMetrics{Name: "Test GPU", MemoryTotal: 16 * gb, MemoryUsed: f.used}, nil
+}
+
+func TestRun_NoWorkloads(t *testing.T) {
+	if _, err := Run(context.Background(), Config{}); err == nil {
+		t.Error("Run() with no workloads should fail")
+	}
+}
+
+func TestRun_TextAndImage(t *testing.T) {
+	gpu := &fakeGPU{used: 4 * gb}
+	text := &fakeText{}
+	image := &fakeImage{gpu: gpu}
+
+	report, err := Run(context.Background(), Config{
+		Text:        text,
+		TextRuns:    2,
+		MaxTokens:   16,
+		MaxParallel: 4,
+		Image:       image,
+		ImageRuns:   2,
+		GPU:         gpu,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// warm-up + 2 runs + parallel levels 1, 2, 4
+	if text.calls != 1+2+1+2+4 {
+		t.Errorf("text calls = %d, want 10", text.calls)
+	}
+	if image.calls != 1+2 {
+		t.Errorf("image calls = %d, want 3", image.calls)
+	}
+
+	if report.GPU == nil || report.GPU.Name != "Test GPU" {
+		t.Errorf("GPU = %+v, want Test GPU", report.GPU)
+	}
+	if report.Text == nil || report.Text.TokensGenerated != 32 {
+		t.Errorf("Text = %+v, want 32 tokens generated", report.Text)
+	}
+	if len(report.Text.Concurrency) != 3 {
+		t.Errorf("Concurrency levels = %d, want 3", len(report.Text.Concurrency))
+	}
+	if report.Image == nil || report.Image.VRAMPerImageBytes != 2*gb {
+		t.Errorf("Image = %+v, want 2GB per image", report.Image)
+	}
+	if report.PeakVRAMBytes != 6*gb {
+		t.Errorf("PeakVRAMBytes = %d, want %d", report.PeakVRAMBytes, 6*gb)
+	}
+	// 10GB free at peak, 2GB per image: capped at the maximum
+	if report.Recommendations.SDMaxConcurrent != MaxRecommendedSDConcurrent {
+		t.Errorf("SDMaxConcurrent = %d, want %d", report.Recommendations.SDMaxConcurrent, MaxRecommendedSDConcurrent)
+	}
+	if report.Recommendations.LlamaContexts == 0 {
+		t.Error("LlamaContexts should be recommended")
+	}
+}
+
+func TestRun_TextFailureIsRecorded(t *testing.T) {
+	report, err := Run(context.Background(), Config{
+		Text: &fakeText{err: errors.New("model not loaded")},
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Errors) != 1 {
+		t.Errorf("Errors = %v, want one text error", report.Errors)
+	}
+	if report.Text != nil {
+		t.Errorf("Text = %+v, want nil after failure", report.Text)
+	}
+}
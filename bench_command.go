@@ -0,0 +1,153 @@
+// Package main provides the bench subcommand for hardware sizing.
+//
+// Usage:
+//
+//	canvuslocallm bench [-output bench-report.json] [-text-runs 5] [-image-runs 3]
+//
+// The command loads the models configured by LLAMA_MODEL_PATH and
+// SD_MODEL_PATH, runs the standardized workloads from the bench package,
+// prints a summary and writes the full JSON report for support.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"go_backend/bench"
+	"go_backend/core"
+	"go_backend/llamaruntime"
+	"go_backend/metrics"
+	"go_backend/sdruntime"
+)
+
+// runBenchCommand runs the bench subcommand and returns the process exit code.
+func runBenchCommand(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	output := fs.String("output", "bench-report.json", "path of the JSON report")
+	textRuns := fs.Int("text-runs", 5, "number of sequential text generations")
+	maxTokens := fs.Int("max-tokens", 128, "tokens per text generation")
+	maxParallel := fs.Int("max-parallel", 4, "highest number of parallel text requests to measure")
+	imageRuns := fs.Int("image-runs", 3, "number of sequential image generations")
+	imageSize := fs.Int("image-size", core.ParseIntEnv("SD_IMAGE_SIZE", sdruntime.DefaultImageSize), "image width and height in pixels")
+	imageSteps := fs.Int("steps", core.ParseIntEnv("SD_INFERENCE_STEPS", sdruntime.DefaultInferenceSteps), "inference steps per image")
+	if err := fs.Parse(args); err != nil {
+		return core.ExitCodeError
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	config := bench.Config{
+		TextRuns:    *textRuns,
+		MaxTokens:   *maxTokens,
+		MaxParallel: *maxParallel,
+		ImageRuns:   *imageRuns,
+		ImageSize:   *imageSize,
+		ImageSteps:  *imageSteps,
+		Logf: func(format string, args ...interface{}) {
+			fmt.Printf("  "+format+"\n", args...)
+		},
+	}
+
+	if reader, err := metrics.NewNVMLReader(); err != nil {
+		fmt.Printf("VRAM measurements disabled: %v\n", err)
+	} else {
+		defer reader.Close()
+		config.GPU = reader
+	}
+
+	if modelPath := os.Getenv("LLAMA_MODEL_PATH"); modelPath != "" {
+		fmt.Printf("Loading text model %s...\n", modelPath)
+		clientConfig := llamaruntime.DefaultClientConfig()
+		clientConfig.ModelPath = modelPath
+		clientConfig.NumContexts = *maxParallel
+		clientConfig.AutoOffload = core.ParseBoolEnv("LLAMA_AUTO_OFFLOAD", true)
+		client, err := llamaruntime.NewClient(clientConfig)
+		if err != nil {
+			fmt.Printf("Text benchmark skipped: %v\n", err)
+		} else {
+			defer client.Close()
+			config.Text = client
+			config.TextModel = filepath.Base(modelPath)
+		}
+	}
+
+	if modelPath := os.Getenv("SD_MODEL_PATH"); modelPath != "" {
+		pool, err := sdruntime.NewContextPool(1, modelPath)
+		if err != nil {
+			fmt.Printf("Image benchmark skipped: %v\n", err)
+		} else {
+			defer pool.Close()
+			config.Image = pool
+			config.ImageModel = filepath.Base(modelPath)
+		}
+	}
+
+	if config.Text == nil && config.Image == nil {
+		fmt.Println("Nothing to benchmark: set LLAMA_MODEL_PATH and/or SD_MODEL_PATH")
+		return core.ExitCodeError
+	}
+
+	fmt.Println("Running benchmark...")
+	report, err := bench.Run(ctx, config)
+	if err != nil {
+		fmt.Printf("Benchmark failed: %v\n", err)
+		return core.ExitCodeError
+	}
+
+	printBenchSummary(report)
+
+	if err := report.WriteJSON(*output); err != nil {
+		fmt.Printf("Failed to write report: %v\n", err)
+		return core.ExitCodeError
+	}
+	fmt.Printf("\nReport written to %s\n", *output)
+	return core.ExitCodeSuccess
+}
+
+// printBenchSummary prints the headline numbers of a benchmark report.
+func printBenchSummary(report *bench.Report) {
+	const mb = 1024 * 1024
+
+	fmt.Println()
+	if report.GPU != nil {
+		fmt.Printf("GPU:              %s (%d MB)\n", report.GPU.Name, report.GPU.TotalVRAMBytes/mb)
+	}
+	if report.Text != nil {
+		fmt.Printf("Text:             %.1f tokens/sec (%.2fs avg latency)\n",
+			report.Text.TokensPerSecond, report.Text.AvgLatencySecs)
+		for _, c := range report.Text.Concurrency {
+			fmt.Printf("  %d parallel:     %.1f tokens/sec\n", c.Parallel, c.TokensPerSecond)
+		}
+	}
+	if report.Image != nil {
+		fmt.Printf("Image:            %.2f images/min (%.1fs per %dx%d image)\n",
+			report.Image.ImagesPerMinute, report.Image.AvgSecsPerImage, report.Image.Size, report.Image.Size)
+	}
+	if report.PeakVRAMBytes > 0 {
+		fmt.Printf("Peak VRAM:        %d MB\n", report.PeakVRAMBytes/mb)
+	}
+	for _, e := range report.Errors {
+		fmt.Printf("Error:            %s\n", e)
+	}
+
+	fmt.Println()
+	fmt.Println("Recommended settings:")
+	if rec := report.Recommendations; rec.SDMaxConcurrent > 0 || rec.LlamaContexts > 0 {
+		if rec.SDMaxConcurrent > 0 {
+			fmt.Printf("  SD_MAX_CONCURRENT=%d\n", rec.SDMaxConcurrent)
+		}
+		if rec.LlamaContexts > 0 {
+			fmt.Printf("  llama contexts: %d\n", rec.LlamaContexts)
+		}
+		for _, note := range rec.Notes {
+			fmt.Printf("  note: %s\n", note)
+		}
+	} else {
+		fmt.Println("  none (insufficient measurements)")
+	}
+}
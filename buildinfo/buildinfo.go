@@ -0,0 +1,129 @@
+// Package buildinfo provides the build and capability report served at
+// /api/buildinfo: how the running binary was built, which native libraries
+// it links and which optional subsystems were compiled in, for diagnosing
+// reports that differ between machines.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"go_backend/core"
+)
+
+// Native library versions, set at build time via ldflags:
+//
+//	go build -ldflags "-X go_backend/buildinfo.LlamaCppCommit=$(git -C deps/llama.cpp rev-parse --short HEAD) \
+//	  -X go_backend/buildinfo.StableDiffusionCommit=$(git -C deps/stable-diffusion.cpp rev-parse --short HEAD) \
+//	  -X go_backend/buildinfo.CUDAVersion=12.4 -X go_backend/buildinfo.CuDNNVersion=9.1.0" .
+//
+// Values not set at build time are "unknown", or empty for CUDA and cuDNN
+// in builds without them.
+var (
+	LlamaCppCommit        = "unknown"
+	StableDiffusionCommit = "unknown"
+	CUDAVersion           = ""
+	CuDNNVersion          = ""
+)
+
+// Report describes the running binary.
+type Report struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"git_commit"`
+	// Modified is set when the binary was built from a tree with
+	// uncommitted changes
+	Modified  bool   `json:"modified,omitempty"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	// Platform is GOOS-GOARCH, e.g. "windows-amd64"
+	Platform string `json:"platform"`
+	CGO      bool   `json:"cgo"`
+	// Tags are the -tags the binary was built with, e.g. ["sd"] or ["nocgo"]
+	Tags       []string    `json:"tags"`
+	Native     Native      `json:"native"`
+	Subsystems []Subsystem `json:"subsystems"`
+}
+
+// Native lists the native library versions the binary was built against.
+type Native struct {
+	LlamaCpp           string `json:"llama_cpp"`
+	StableDiffusionCpp string `json:"stable_diffusion_cpp"`
+	CUDA               string `json:"cuda,omitempty"`
+	CuDNN              string `json:"cudnn,omitempty"`
+}
+
+// Subsystem is an optional part of the server that depends on build tags
+// or linked libraries.
+type Subsystem struct {
+	Name     string `json:"name"`
+	Compiled bool   `json:"compiled"`
+	// Detail is what the subsystem reports about itself, such as the
+	// compute backend of a native library
+	Detail string `json:"detail,omitempty"`
+}
+
+// Read returns the report for the running binary with the given
+// subsystems. The git commit falls back to the one Go records from the
+// source tree when it was not set through core.GitCommit.
+func Read(subsystems ...Subsystem) Report {
+	report := Report{
+		Version:   core.GetVersion(),
+		GitCommit: core.GetGitCommit(),
+		BuildTime: core.GetBuildTime(),
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "-" + runtime.GOARCH,
+		Tags:      []string{},
+		Native: Native{
+			LlamaCpp:           LlamaCppCommit,
+			StableDiffusionCpp: StableDiffusionCommit,
+			CUDA:               CUDAVersion,
+			CuDNN:              CuDNNVersion,
+		},
+		Subsystems: subsystems,
+	}
+	if report.Subsystems == nil {
+		report.Subsystems = []Subsystem{}
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return report
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "CGO_ENABLED":
+			report.CGO = s.Value == "1"
+		case "-tags":
+			report.Tags = splitTags(s.Value)
+		case "vcs.revision":
+			if report.GitCommit == "" || report.GitCommit == "unknown" {
+				report.GitCommit = s.Value
+			}
+		case "vcs.modified":
+			report.Modified = s.Value == "true"
+		}
+	}
+	return report
+}
+
+// splitTags splits a -tags value, which is comma-separated in current Go
+// versions and space-separated in old ones.
+func splitTags(value string) []string {
+	tags := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+// Compiled returns the names of the subsystems compiled in.
+func (r Report) Compiled() []string {
+	var names []string
+	for _, s := range r.Subsystems {
+		if s.Compiled {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,33 @@
+package buildinfo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitTags(t *testing.T) {
+	tests := map[string][]string{
+		"":           {},
+		"sd":         {"sd"},
+		"sd,nocgo":   {"sd", "nocgo"},
+		"sd sdfake ": {"sd", "sdfake"},
+	}
+	for value, want := range tests {
+		if got := splitTags(value); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitTags(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	report := Read(Subsystem{Name: "llama.cpp"}, Subsystem{Name: "nvml", Compiled: true})
+	if report.Version == "" || report.GoVersion == "" || report.Platform == "" {
+		t.Errorf("Read() = %+v", report)
+	}
+	if got := report.Compiled(); !reflect.DeepEqual(got, []string{"nvml"}) {
+		t.Errorf("Compiled() = %v, want [nvml]", got)
+	}
+	if Read().Subsystems == nil {
+		t.Error("Read() without subsystems has nil Subsystems, which encodes as null")
+	}
+}
@@ -128,9 +128,13 @@ func NewAnalyzerWithComponents(
 	}
 }
 
-// SetProgressCallback sets a callback function for progress updates.
+// SetProgressCallback sets a callback function for progress updates,
+// including the group summaries of canvases too large for one request.
 func (a *Analyzer) SetProgressCallback(callback ProgressCallback) {
 	a.progress = callback
+	if a.processor != nil {
+		a.processor.SetProgressCallback(callback)
+	}
 }
 
 // Analyze performs a complete canvas analysis.
@@ -8,6 +8,7 @@
 //   - atoms.go: Pure utility functions (filtering, formatting)
 //   - fetcher.go: Fetcher molecule for retrieving widgets with retry logic
 //   - processor.go: Processor molecule for AI-powered analysis generation
+//   - mapreduce.go: Processor's group-summary path for canvases too large for one request
 //   - analyzer.go: Analyzer organism that orchestrates the complete analysis pipeline
 package canvasanalyzer
 
@@ -101,6 +102,54 @@ func FilterWidgetsByType(widgets []Widget, types ...string) []Widget {
 	return result
 }
 
+// SanitizeWidgets returns widgets with their titles and texts replaced by
+// what sanitize returns for them; widgets that change are copied first. sanitize receives every
+// title and text at once, in widget order, and must return a slice of the
+// same length. A nil sanitize returns widgets unchanged.
+func SanitizeWidgets(widgets []Widget, sanitize func([]string) []string) []Widget {
+	if sanitize == nil {
+		return widgets
+	}
+
+	type field struct {
+		widget int
+		key    string
+	}
+	var fields []field
+	var texts []string
+	for i, w := range widgets {
+		for _, key := range []string{"title", "text"} {
+			if text, ok := w[key].(string); ok && text != "" {
+				fields = append(fields, field{i, key})
+				texts = append(texts, text)
+			}
+		}
+	}
+	if len(texts) == 0 {
+		return widgets
+	}
+
+	cleaned := sanitize(texts)
+	result := append([]Widget(nil), widgets...)
+	copied := make([]bool, len(widgets))
+	for j, f := range fields {
+		if cleaned[j] == texts[j] {
+			continue
+		}
+		if !copied[f.widget] {
+			// Copy before the first change so the caller's widget is kept
+			w := make(Widget, len(widgets[f.widget]))
+			for k, v := range widgets[f.widget] {
+				w[k] = v
+			}
+			result[f.widget] = w
+			copied[f.widget] = true
+		}
+		result[f.widget][f.key] = cleaned[j]
+	}
+	return result
+}
+
 // WidgetsToJSON marshals widgets to JSON for AI processing.
 // Returns an error if marshaling fails.
 func WidgetsToJSON(widgets []Widget) (string, error) {
@@ -111,6 +160,55 @@ func WidgetsToJSON(widgets []Widget) (string, error) {
 	return string(data), nil
 }
 
+// BatchWidgets splits widgets, in order, into groups whose JSON is at most
+// maxTokens (estimated at 4 characters per token). A widget larger than
+// maxTokens on its own gets a group of its own. maxTokens <= 0 returns all
+// widgets in one group.
+//
+// Example:
+//
+//	for _, group := range BatchWidgets(widgets, 6000) {
+//	    groupJSON, _ := WidgetsToJSON(group)
+//	}
+func BatchWidgets(widgets []Widget, maxTokens int) [][]Widget {
+	sizes := make([]int, len(widgets))
+	for i, w := range widgets {
+		// Widgets that cannot be marshaled fail later in WidgetsToJSON
+		data, _ := json.Marshal(w)
+		sizes[i] = len(data) + 1 // The separating comma
+	}
+
+	var batches [][]Widget
+	for _, group := range groupBySize(sizes, maxTokens) {
+		batches = append(batches, widgets[group[0]:group[1]])
+	}
+	return batches
+}
+
+// groupBySize splits items with the given sizes in characters, in order,
+// into [start, end) ranges of at most maxTokens (estimated at 4 characters
+// per token). An item larger than maxTokens gets a range of its own, and
+// maxTokens <= 0 puts all items in one range.
+func groupBySize(sizes []int, maxTokens int) [][2]int {
+	if len(sizes) == 0 {
+		return nil
+	}
+	if maxTokens <= 0 {
+		return [][2]int{{0, len(sizes)}}
+	}
+
+	var groups [][2]int
+	start, total := 0, 2 // The enclosing brackets
+	for i, size := range sizes {
+		if i > start && (total+size)/4 > maxTokens {
+			groups = append(groups, [2]int{start, i})
+			start, total = i, 2
+		}
+		total += size
+	}
+	return append(groups, [2]int{start, len(sizes)})
+}
+
 // CountWidgetsByType returns a map of widget type to count.
 //
 // Example:
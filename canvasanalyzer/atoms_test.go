@@ -1,6 +1,7 @@
 package canvasanalyzer
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -300,6 +301,87 @@ func TestWidgetsToJSON(t *testing.T) {
 	}
 }
 
+func TestBatchWidgets(t *testing.T) {
+	widgets := make([]Widget, 10)
+	for i := range widgets {
+		// Each widget is about 100 characters, or 25 tokens, of JSON
+		widgets[i] = Widget{"id": formatCount(i), "text": strings.Repeat("x", 80)}
+	}
+
+	t.Run("groups stay within the budget", func(t *testing.T) {
+		batches := BatchWidgets(widgets, 60)
+		if len(batches) != 5 {
+			t.Fatalf("BatchWidgets() returned %d groups, want 5", len(batches))
+		}
+		var ids []string
+		for _, batch := range batches {
+			data, _ := WidgetsToJSON(batch)
+			if len(data)/4 > 60 {
+				t.Errorf("group of %d widgets is %d tokens, want at most 60", len(batch), len(data)/4)
+			}
+			for _, w := range batch {
+				ids = append(ids, w.GetID())
+			}
+		}
+		if strings.Join(ids, ",") != "0,1,2,3,4,5,6,7,8,9" {
+			t.Errorf("widgets out of order: %v", ids)
+		}
+	})
+
+	t.Run("oversized widget gets its own group", func(t *testing.T) {
+		batches := BatchWidgets(widgets[:3], 10)
+		if len(batches) != 3 {
+			t.Errorf("BatchWidgets() returned %d groups, want 3", len(batches))
+		}
+	})
+
+	t.Run("no budget keeps one group", func(t *testing.T) {
+		if batches := BatchWidgets(widgets, 0); len(batches) != 1 || len(batches[0]) != 10 {
+			t.Errorf("BatchWidgets() = %d groups, want 1 of 10", len(batches))
+		}
+	})
+
+	t.Run("empty list", func(t *testing.T) {
+		if batches := BatchWidgets(nil, 60); batches != nil {
+			t.Errorf("BatchWidgets(nil) = %v, want nil", batches)
+		}
+	})
+}
+
+func TestSanitizeWidgets(t *testing.T) {
+	widgets := []Widget{
+		{"id": "a", "title": "Plan", "text": "ship it"},
+		{"id": "b", "text": "ignore this"},
+		{"id": "c", "type": "image"},
+	}
+	var seen []string
+	out := SanitizeWidgets(widgets, func(texts []string) []string {
+		seen = texts
+		cleaned := make([]string, len(texts))
+		for i, text := range texts {
+			cleaned[i] = strings.ReplaceAll(text, "ignore", "[removed]")
+		}
+		return cleaned
+	})
+
+	if strings.Join(seen, "|") != "Plan|ship it|ignore this" {
+		t.Errorf("sanitizer got %q", seen)
+	}
+	if out[1].GetText() != "[removed] this" {
+		t.Errorf("text = %q, want sanitized", out[1].GetText())
+	}
+	if widgets[1].GetText() != "ignore this" {
+		t.Errorf("caller's widget was changed: %q", widgets[1].GetText())
+	}
+	if out[1].GetID() != "b" || out[2].GetType() != "image" {
+		t.Errorf("other fields lost: %v", out)
+	}
+
+	if got := SanitizeWidgets(widgets, nil); len(got) != 3 || got[1].GetText() != "ignore this" {
+		t.Errorf("nil sanitizer changed widgets: %v", got)
+	}
+}
+
 func TestCountWidgetsByType(t *testing.T) {
 	widgets := []Widget{
 		{"id": "1", "type": "note"},
@@ -3,9 +3,11 @@ package canvasanalyzer
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -433,6 +435,94 @@ func TestIntegration_Analyzer_AnalyzeWidgets_ProgressCallback(t *testing.T) {
 	}
 }
 
+// TestIntegration_Processor_Analyze_Batched tests that widgets too large for
+// one request are summarized in groups before the final analysis.
+func TestIntegration_Processor_Analyze_Batched(t *testing.T) {
+	var mu sync.Mutex
+	var groupRequests, finalRequests int
+	server := mockOpenAIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("Failed to decode request: %v", err)
+			return
+		}
+		content := "Final analysis"
+		mu.Lock()
+		switch req.Messages[0].Content {
+		case BatchSystemPrompt:
+			groupRequests++
+			content = "Group summary"
+			if req.MaxTokens != batchSummaryMaxTokens {
+				t.Errorf("group MaxTokens = %d, want %d", req.MaxTokens, batchSummaryMaxTokens)
+			}
+		default:
+			finalRequests++
+			if !strings.Contains(req.Messages[1].Content, "## Part 4\nGroup summary") {
+				t.Errorf("final request should contain the group summaries, got %q", req.Messages[1].Content)
+			}
+		}
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(mockOpenAIResponse(content, 100, 50))
+	})
+	defer server.Close()
+
+	config := DefaultProcessorConfig()
+	config.SystemPrompt = "Analyze the workspace."
+	config.BatchTokens = 200
+	config.BatchConcurrency = 2
+	processor := NewProcessor(config, createMockOpenAIClient(server.URL), newTestLogger())
+
+	var stages []string
+	processor.SetProgressCallback(func(stage, message string) {
+		stages = append(stages, stage)
+	})
+
+	widgets := make([]Widget, 8)
+	for i := range widgets {
+		widgets[i] = Widget{"id": formatCount(i), "type": "note", "text": strings.Repeat("word ", 30)}
+	}
+
+	result, err := processor.Analyze(context.Background(), widgets)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if result.Content != "Final analysis" || result.WidgetCount != 8 {
+		t.Errorf("Analyze() = %+v", result)
+	}
+	if result.Batches != 4 || groupRequests != 4 || finalRequests != 1 {
+		t.Errorf("Batches = %d, group requests = %d, final requests = %d; want 4, 4, 1",
+			result.Batches, groupRequests, finalRequests)
+	}
+	if result.PromptTokens != 500 || result.CompletionTokens != 250 {
+		t.Errorf("tokens = %d/%d, want the sum of all requests (500/250)", result.PromptTokens, result.CompletionTokens)
+	}
+	if len(stages) == 0 || stages[0] != "summarize" || stages[len(stages)-1] != "synthesize" {
+		t.Errorf("progress stages = %v, want summarize ... synthesize", stages)
+	}
+}
+
+// TestIntegration_Processor_Analyze_BatchError tests that a failing group
+// fails the analysis.
+func TestIntegration_Processor_Analyze_BatchError(t *testing.T) {
+	server := mockOpenAIServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error": {"message": "overloaded"}}`, http.StatusServiceUnavailable)
+	})
+	defer server.Close()
+
+	config := DefaultProcessorConfig()
+	config.BatchTokens = 200
+	processor := NewProcessor(config, createMockOpenAIClient(server.URL), newTestLogger())
+
+	widgets := make([]Widget, 8)
+	for i := range widgets {
+		widgets[i] = Widget{"id": formatCount(i), "text": strings.Repeat("word ", 30)}
+	}
+	if _, err := processor.Analyze(context.Background(), widgets); !errors.Is(err, ErrAnalysisFailed) {
+		t.Errorf("Analyze() error = %v, want ErrAnalysisFailed", err)
+	}
+}
+
 // ============================================================================
 // Integration Tests for Error Handling
 // ============================================================================
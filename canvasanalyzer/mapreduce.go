@@ -0,0 +1,182 @@
+package canvasanalyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// BatchSystemPrompt is the prompt for summarizing one group of widgets of a
+// canvas too large to analyze in one request.
+const BatchSystemPrompt = `You are an assistant summarizing part of a collaborative workspace.
+The items below are one group of many from the same workspace.
+Summarize their content, themes, and how they relate to each other in a few short paragraphs.
+Keep names, numbers, and decisions that an overview of the whole workspace would need.
+Avoid mentioning technical details like IDs or coordinates.`
+
+// CombineSystemPrompt is the prompt for merging group summaries that are
+// still too large for the final analysis request.
+const CombineSystemPrompt = `You are an assistant condensing summaries of parts of a collaborative workspace.
+Merge the summaries below into one summary of a few short paragraphs.
+Keep names, numbers, and decisions that an overview of the whole workspace would need.`
+
+// batchSummaryMaxTokens bounds each group summary, so the summaries of a
+// large canvas still fit the final analysis request.
+const batchSummaryMaxTokens = 512
+
+// maxCombineRounds bounds how often summaries are merged before the final
+// analysis.
+const maxCombineRounds = 3
+
+// analyzeBatched analyzes widgets too large for one request: groups of
+// widgets are summarized (BatchConcurrency at a time), summaries are merged
+// while they are still too large, and the analysis is written from the
+// summaries with the system prompt. Progress is reported for each stage.
+func (p *Processor) analyzeBatched(ctx context.Context, widgets []Widget) (*AnalysisResult, error) {
+	start := time.Now()
+
+	// Leave room for the group prompt, but keep groups reasonably large
+	budget := p.config.BatchTokens - estimateTokens(BatchSystemPrompt)
+	if budget < p.config.BatchTokens/2 {
+		budget = p.config.BatchTokens / 2
+	}
+	batches := BatchWidgets(widgets, budget)
+
+	p.logger.Info("canvas too large for one request, summarizing in groups",
+		zap.Int("widget_count", len(widgets)),
+		zap.Int("groups", len(batches)),
+		zap.Int("batch_tokens", p.config.BatchTokens),
+		zap.Int("concurrency", p.config.BatchConcurrency),
+		zap.String("model", p.config.Model))
+
+	result := &AnalysisResult{
+		WidgetCount: len(widgets),
+		Model:       p.config.Model,
+		Batches:     len(batches),
+	}
+
+	inputs := make([]string, len(batches))
+	for i, batch := range batches {
+		batchJSON, err := WidgetsToJSON(batch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to serialize widgets: %v", ErrAnalysisFailed, err)
+		}
+		inputs[i] = batchJSON
+	}
+	summaries, err := p.summarizeAll(ctx, "summarize", "widget groups", BatchSystemPrompt, inputs, result)
+	if err != nil {
+		return nil, err
+	}
+
+	for round := 0; round < maxCombineRounds && len(summaries) > 1; round++ {
+		if estimateTokens(p.config.SystemPrompt)+estimateTokens(joinSummaries(summaries)) <= p.config.BatchTokens {
+			break
+		}
+		sizes := make([]int, len(summaries))
+		for i, summary := range summaries {
+			sizes[i] = len(summary)
+		}
+		groups := groupBySize(sizes, budget)
+		if len(groups) == len(summaries) {
+			// Every summary fills a request on its own; merging cannot help
+			break
+		}
+		inputs = make([]string, len(groups))
+		for i, group := range groups {
+			inputs[i] = joinSummaries(summaries[group[0]:group[1]])
+		}
+		if summaries, err = p.summarizeAll(ctx, "combine", "summary groups", CombineSystemPrompt, inputs, result); err != nil {
+			return nil, err
+		}
+	}
+
+	p.reportProgress("synthesize", fmt.Sprintf("Writing the analysis from %d summaries...", len(summaries)))
+	reply, err := p.complete(ctx, p.config.SystemPrompt, joinSummaries(summaries), p.config.MaxTokens)
+	if err != nil {
+		return nil, err
+	}
+	result.Content = p.extractContent(reply.content)
+	result.RawResponse = reply.content
+	result.PromptTokens += reply.promptTokens
+	result.CompletionTokens += reply.completionTokens
+	result.Duration = time.Since(start)
+
+	p.logger.Info("canvas analysis completed",
+		zap.Int("groups", result.Batches),
+		zap.Int("prompt_tokens", result.PromptTokens),
+		zap.Int("completion_tokens", result.CompletionTokens),
+		zap.Duration("duration", result.Duration))
+
+	return result, nil
+}
+
+// summarizeAll summarizes each input with systemPrompt, BatchConcurrency at
+// a time, and returns the summaries in input order. Progress is reported
+// under stage, counting the inputs as what. Token counts are added to
+// result. The first failure cancels the remaining requests.
+func (p *Processor) summarizeAll(ctx context.Context, stage, what, systemPrompt string, inputs []string, result *AnalysisResult) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p.reportProgress(stage, fmt.Sprintf("Summarizing %d %s...", len(inputs), what))
+
+	summaries := make([]string, len(inputs))
+	sem := make(chan struct{}, p.config.BatchConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var firstErr error
+	done := 0
+
+	for i, input := range inputs {
+		wg.Add(1)
+		go func(i int, input string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			reply, err := p.complete(ctx, systemPrompt, input, batchSummaryMaxTokens)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("group %d of %d: %w", i+1, len(inputs), err)
+					cancel()
+				}
+				return
+			}
+			summaries[i] = strings.TrimSpace(reply.content)
+			result.PromptTokens += reply.promptTokens
+			result.CompletionTokens += reply.completionTokens
+			done++
+			p.reportProgress(stage, fmt.Sprintf("Summarized %d of %d %s...", done, len(inputs), what))
+		}(i, input)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// joinSummaries formats group summaries as the input of the next request.
+func joinSummaries(summaries []string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "The workspace is too large to show at once. These are summaries of its items in %d parts.\n", len(summaries))
+	for i, summary := range summaries {
+		fmt.Fprintf(&sb, "\n## Part %d\n%s\n", i+1, summary)
+	}
+	return sb.String()
+}
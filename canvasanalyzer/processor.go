@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sashabaranov/go-openai"
@@ -53,17 +54,28 @@ type ProcessorConfig struct {
 
 	// BaseURL is the OpenAI API base URL (empty = default OpenAI)
 	BaseURL string
+
+	// BatchTokens is the estimated request size, in tokens, above which
+	// widgets are summarized in groups of about this size before the final
+	// analysis (0 = always analyze in one request; default: 6000)
+	BatchTokens int
+
+	// BatchConcurrency is how many widget groups are summarized at once
+	// (default: 1)
+	BatchConcurrency int
 }
 
 // DefaultProcessorConfig returns sensible default configuration.
 func DefaultProcessorConfig() ProcessorConfig {
 	return ProcessorConfig{
-		Model:        "gpt-4",
-		SystemPrompt: DefaultSystemPrompt,
-		MaxTokens:    4096,
-		Temperature:  0.7,
-		Timeout:      2 * time.Minute,
-		BaseURL:      "",
+		Model:            "gpt-4",
+		SystemPrompt:     DefaultSystemPrompt,
+		MaxTokens:        4096,
+		Temperature:      0.7,
+		Timeout:          2 * time.Minute,
+		BaseURL:          "",
+		BatchTokens:      6000,
+		BatchConcurrency: 1,
 	}
 }
 
@@ -89,13 +101,29 @@ type AnalysisResult struct {
 
 	// Model is the model used for analysis
 	Model string
+
+	// Batches is the number of widget groups summarized before the final
+	// analysis (0 if the widgets were analyzed in one request)
+	Batches int
 }
 
+// CompleteFunc sends one request to a model other than the OpenAI client,
+// such as the local llama.cpp model, and returns its reply.
+type CompleteFunc func(ctx context.Context, systemPrompt, userContent string, maxTokens int) (string, error)
+
 // Processor generates AI-powered analysis from canvas widgets.
 type Processor struct {
 	config ProcessorConfig
 	client *openai.Client
 	logger *zap.Logger
+
+	// completeFunc, if set, is used instead of client
+	completeFunc CompleteFunc
+
+	progressMu sync.Mutex
+	progress   ProgressCallback
+
+	sanitize func([]string) []string
 }
 
 // NewProcessor creates a new Processor with the given configuration and OpenAI client.
@@ -121,6 +149,12 @@ func NewProcessor(config ProcessorConfig, client *openai.Client, logger *zap.Log
 	if config.Timeout <= 0 {
 		config.Timeout = 2 * time.Minute
 	}
+	if config.BatchTokens < 0 {
+		config.BatchTokens = 0
+	}
+	if config.BatchConcurrency <= 0 {
+		config.BatchConcurrency = 1
+	}
 
 	return &Processor{
 		config: config,
@@ -129,16 +163,34 @@ func NewProcessor(config ProcessorConfig, client *openai.Client, logger *zap.Log
 	}
 }
 
+// NewLocalProcessor creates a Processor that sends its requests to
+// complete instead of an OpenAI client.
+//
+// Example:
+//
+//	processor := NewLocalProcessor(config, func(ctx context.Context, system, user string, maxTokens int) (string, error) {
+//	    return llamaClient.Generate(ctx, user, llamaruntime.GenerationParams{SystemPrompt: &system, MaxTokens: maxTokens})
+//	}, logger)
+func NewLocalProcessor(config ProcessorConfig, complete CompleteFunc, logger *zap.Logger) *Processor {
+	p := NewProcessor(config, nil, logger)
+	p.completeFunc = complete
+	return p
+}
+
 // Analyze generates an AI analysis of the provided widgets.
 //
 // The widgets are serialized to JSON and sent to the AI model along with
-// the system prompt. The response is parsed to extract the content.
+// the system prompt. The response is parsed to extract the content. Widgets
+// too large for one request (see BatchTokens) are summarized in groups
+// first, and the analysis is written from the group summaries. Widget
+// titles and texts go through the sanitizer first, if one is set.
 //
 // Returns ErrAnalysisFailed if the AI request fails.
 // Returns ErrEmptyResponse if the AI returns no content.
 // Returns ErrInvalidResponse if the response cannot be parsed.
 func (p *Processor) Analyze(ctx context.Context, widgets []Widget) (*AnalysisResult, error) {
 	start := time.Now()
+	widgets = SanitizeWidgets(widgets, p.sanitize)
 
 	// Serialize widgets to JSON
 	widgetsJSON, err := WidgetsToJSON(widgets)
@@ -146,29 +198,70 @@ func (p *Processor) Analyze(ctx context.Context, widgets []Widget) (*AnalysisRes
 		return nil, fmt.Errorf("%w: failed to serialize widgets: %v", ErrAnalysisFailed, err)
 	}
 
+	if p.config.BatchTokens > 0 && estimateTokens(p.config.SystemPrompt)+estimateTokens(widgetsJSON) > p.config.BatchTokens {
+		return p.analyzeBatched(ctx, widgets)
+	}
+
 	p.logger.Info("starting canvas analysis",
 		zap.Int("widget_count", len(widgets)),
 		zap.Int("json_length", len(widgetsJSON)),
 		zap.String("model", p.config.Model))
 
+	reply, err := p.complete(ctx, p.config.SystemPrompt, widgetsJSON, p.config.MaxTokens)
+	if err != nil {
+		return nil, err
+	}
+
+	p.logger.Info("canvas analysis completed",
+		zap.Int("prompt_tokens", reply.promptTokens),
+		zap.Int("completion_tokens", reply.completionTokens),
+		zap.Duration("duration", time.Since(start)))
+
+	return &AnalysisResult{
+		Content:          p.extractContent(reply.content),
+		RawResponse:      reply.content,
+		PromptTokens:     reply.promptTokens,
+		CompletionTokens: reply.completionTokens,
+		Duration:         time.Since(start),
+		WidgetCount:      len(widgets),
+		Model:            p.config.Model,
+	}, nil
+}
+
+// completion is the reply to a single AI request.
+type completion struct {
+	content          string
+	promptTokens     int
+	completionTokens int
+}
+
+// complete sends one chat completion request and returns the reply with
+// its token counts (estimated if the server does not report usage).
+func (p *Processor) complete(ctx context.Context, systemPrompt, userContent string, maxTokens int) (*completion, error) {
+	start := time.Now()
+
 	// Create context with timeout
 	timeoutCtx, cancel := context.WithTimeout(ctx, p.config.Timeout)
 	defer cancel()
 
+	if p.completeFunc != nil {
+		return p.completeLocal(timeoutCtx, systemPrompt, userContent, maxTokens, start)
+	}
+
 	// Build request
 	request := openai.ChatCompletionRequest{
 		Model: p.config.Model,
 		Messages: []openai.ChatCompletionMessage{
 			{
 				Role:    openai.ChatMessageRoleSystem,
-				Content: p.config.SystemPrompt,
+				Content: systemPrompt,
 			},
 			{
 				Role:    openai.ChatMessageRoleUser,
-				Content: widgetsJSON,
+				Content: userContent,
 			},
 		},
-		MaxTokens:   p.config.MaxTokens,
+		MaxTokens:   maxTokens,
 		Temperature: p.config.Temperature,
 	}
 
@@ -193,34 +286,39 @@ func (p *Processor) Analyze(ctx context.Context, widgets []Widget) (*AnalysisRes
 		return nil, ErrEmptyResponse
 	}
 
-	// Parse the response to extract content
-	content := p.extractContent(rawResponse)
-
-	// Calculate token estimates
-	promptTokens := estimateTokens(p.config.SystemPrompt) + estimateTokens(widgetsJSON)
-	completionTokens := estimateTokens(rawResponse)
-
-	// Use actual usage if available
+	// Calculate token estimates, using actual usage if available
+	reply := &completion{
+		content:          rawResponse,
+		promptTokens:     estimateTokens(systemPrompt) + estimateTokens(userContent),
+		completionTokens: estimateTokens(rawResponse),
+	}
 	if response.Usage.PromptTokens > 0 {
-		promptTokens = response.Usage.PromptTokens
+		reply.promptTokens = response.Usage.PromptTokens
 	}
 	if response.Usage.CompletionTokens > 0 {
-		completionTokens = response.Usage.CompletionTokens
+		reply.completionTokens = response.Usage.CompletionTokens
 	}
+	return reply, nil
+}
 
-	p.logger.Info("canvas analysis completed",
-		zap.Int("prompt_tokens", promptTokens),
-		zap.Int("completion_tokens", completionTokens),
-		zap.Duration("duration", time.Since(start)))
-
-	return &AnalysisResult{
-		Content:          content,
-		RawResponse:      rawResponse,
-		PromptTokens:     promptTokens,
-		CompletionTokens: completionTokens,
-		Duration:         time.Since(start),
-		WidgetCount:      len(widgets),
-		Model:            p.config.Model,
+// completeLocal sends one request to completeFunc. Token counts are
+// estimated.
+func (p *Processor) completeLocal(ctx context.Context, systemPrompt, userContent string, maxTokens int, start time.Time) (*completion, error) {
+	content, err := p.completeFunc(ctx, systemPrompt, userContent, maxTokens)
+	if err != nil {
+		p.logger.Error("AI request failed",
+			zap.Error(err),
+			zap.Duration("duration", time.Since(start)))
+		return nil, fmt.Errorf("%w: %w", ErrAnalysisFailed, err)
+	}
+	if content == "" {
+		p.logger.Error("AI returned empty content")
+		return nil, ErrEmptyResponse
+	}
+	return &completion{
+		content:          content,
+		promptTokens:     estimateTokens(systemPrompt) + estimateTokens(userContent),
+		completionTokens: estimateTokens(content),
 	}, nil
 }
 
@@ -304,6 +402,32 @@ func (p *Processor) SetMaxTokens(maxTokens int) {
 	}
 }
 
+// SetProgressCallback sets a callback for progress updates while widgets
+// are summarized in groups. It may be called from several goroutines, but
+// never concurrently.
+func (p *Processor) SetProgressCallback(callback ProgressCallback) {
+	p.progressMu.Lock()
+	defer p.progressMu.Unlock()
+	p.progress = callback
+}
+
+// SetSanitizer sets a function run on the titles and texts of widgets
+// before they are sent to the model, such as a prompt-injection guard. It
+// receives all of them at once and returns them in the same order. A nil
+// sanitizer sends widgets unchanged.
+func (p *Processor) SetSanitizer(sanitize func(texts []string) []string) {
+	p.sanitize = sanitize
+}
+
+// reportProgress calls the progress callback if set.
+func (p *Processor) reportProgress(stage, message string) {
+	p.progressMu.Lock()
+	defer p.progressMu.Unlock()
+	if p.progress != nil {
+		p.progress(stage, message)
+	}
+}
+
 // SetTemperature updates the temperature setting.
 func (p *Processor) SetTemperature(temperature float32) {
 	if temperature >= 0 && temperature <= 2 {
@@ -2,6 +2,8 @@ package canvasanalyzer
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -348,3 +350,30 @@ func init() {
 	// Logger is shared across test files
 	_ = zap.NewNop()
 }
+
+func TestLocalProcessor_Analyze(t *testing.T) {
+	var gotSystem, gotUser string
+	var gotMaxTokens int
+	processor := NewLocalProcessor(ProcessorConfig{SystemPrompt: "Analyze.", MaxTokens: 600}, func(ctx context.Context, system, user string, maxTokens int) (string, error) {
+		gotSystem, gotUser, gotMaxTokens = system, user, maxTokens
+		return "# Overview\nOne note.", nil
+	}, newTestLogger())
+
+	result, err := processor.Analyze(context.Background(), []Widget{{"id": "1", "type": "note", "text": "Test note"}})
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if gotSystem != "Analyze." || gotMaxTokens != 600 || !strings.Contains(gotUser, "Test note") {
+		t.Errorf("request = %q, %q, %d", gotSystem, gotUser, gotMaxTokens)
+	}
+	if result.Content != "# Overview\nOne note." || result.WidgetCount != 1 || result.CompletionTokens == 0 {
+		t.Errorf("result = %+v", result)
+	}
+
+	failing := NewLocalProcessor(DefaultProcessorConfig(), func(ctx context.Context, system, user string, maxTokens int) (string, error) {
+		return "", errors.New("model unloaded")
+	}, newTestLogger())
+	if _, err := failing.Analyze(context.Background(), []Widget{{"id": "1"}}); !errors.Is(err, ErrAnalysisFailed) {
+		t.Errorf("err = %v, want ErrAnalysisFailed", err)
+	}
+}
@@ -0,0 +1,253 @@
+package canvasexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pngHeader is enough of a PNG for content type detection
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func widget(id, kind string, x, y float64, fields map[string]interface{}) map[string]interface{} {
+	w := map[string]interface{}{
+		"id":          id,
+		"widget_type": kind,
+		"location":    map[string]interface{}{"x": x, "y": y},
+		"size":        map[string]interface{}{"width": 100.0, "height": 100.0},
+	}
+	for k, v := range fields {
+		w[k] = v
+	}
+	return w
+}
+
+func testWidgets() []map[string]interface{} {
+	return []map[string]interface{}{
+		widget("canvas", "SharedCanvas", 0, 0, nil),
+		widget("zone", "Anchor", 0, 0, map[string]interface{}{
+			"anchor_name": "Sprint Board",
+			"size":        map[string]interface{}{"width": 1000.0, "height": 1000.0},
+		}),
+		widget("goals", "Note", 100, 100, map[string]interface{}{"text": "# Goals\nShip the importer"}),
+		widget("risks", "Note", 500, 100, map[string]interface{}{"title": "Risks", "text": "Hiring"}),
+		widget("diagram", "Image", 100, 400, map[string]interface{}{"title": "Architecture"}),
+		widget("empty", "Note", 300, 300, map[string]interface{}{"text": "  "}),
+		widget("trigger", "Note", 50, 50, map[string]interface{}{"text": "{{export}}"}),
+		widget("outside", "Note", 5000, 5000, map[string]interface{}{"text": "Parking lot"}),
+		widget("link", "Connector", 0, 0, map[string]interface{}{
+			"src": map[string]interface{}{"id": "goals"},
+			"dst": map[string]interface{}{"id": "risks"},
+		}),
+	}
+}
+
+func TestBuild(t *testing.T) {
+	doc, err := Build(testWidgets(), "", "trigger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, s := range doc.Sections {
+		titles = append(titles, s.Title)
+	}
+	if got := strings.Join(titles, "|"); got != "Goals|Risks|Architecture|Parking lot" {
+		t.Errorf("sections = %s", got)
+	}
+	goals := doc.Sections[0]
+	if goals.Text != "Ship the importer" || len(goals.Links) != 1 || goals.Links[0].Title != "Risks" {
+		t.Errorf("goals = %+v", goals)
+	}
+
+	doc, err = Build(testWidgets(), "sprint board", "trigger")
+	if err != nil || doc.Zone != "Sprint Board" || len(doc.Sections) != 3 {
+		t.Fatalf("zone export = %+v, %v", doc, err)
+	}
+	if len(doc.Zones) != 1 || doc.Zones[0].Bounds.Width != 1000 {
+		t.Errorf("zones = %+v", doc.Zones)
+	}
+	if _, err := Build(testWidgets(), "missing"); !errors.Is(err, ErrZoneNotFound) {
+		t.Errorf("Build(missing zone) = %v, want ErrZoneNotFound", err)
+	}
+}
+
+func TestRender(t *testing.T) {
+	doc, _ := Build(testWidgets(), "", "trigger")
+	doc.Title = "Retro <1>"
+	doc.Created = time.Date(2026, 10, 16, 14, 25, 1, 0, time.UTC)
+	doc.Sections[2].Image = &Image{Data: pngHeader, ContentType: "image/png"}
+
+	md := string(RenderMarkdown(doc))
+	for _, want := range []string{"# Retro <1>", `<a id="w-goals"></a>`, "## Goals", "→ [Risks](#w-risks)", "![Architecture](data:image/png;base64,", "4 items"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown is missing %q:\n%s", want, md)
+		}
+	}
+
+	html, err := RenderHTML(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<title>Retro &lt;1&gt;</title>", `<section id="w-goals">`, `<a href="#w-risks">Risks</a>`, `<img src="data:image/png;base64,`} {
+		if !strings.Contains(string(html), want) {
+			t.Errorf("HTML is missing %q:\n%s", want, html)
+		}
+	}
+
+	if got := FileName(doc, FormatHTML); got != "retro-1-20261016-142501.html" {
+		t.Errorf("FileName() = %q", got)
+	}
+}
+
+func TestSlides(t *testing.T) {
+	widgets := append(testWidgets(),
+		widget("backlog", "Note", 5000, 4700, map[string]interface{}{"text": "Backlog"}),
+		widget("later", "Note", 5300, 5300, map[string]interface{}{"text": "Later\nmaybe"}),
+		widget("far", "Note", 9000, 0, map[string]interface{}{"text": "Far away"}),
+	)
+	for i := 0; i < maxBulletsPerSlide+1; i++ {
+		widgets = append(widgets, widget(string(rune('a'+i)), "Note", 100+float64(i)*50, 700, map[string]interface{}{"text": "Idea"}))
+	}
+	doc, err := Build(widgets, "", "trigger")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, s := range Slides(doc) {
+		got = append(got, fmt.Sprintf("%s/%d", s.Title, len(s.Items)))
+	}
+	// The zone needs two slides; "Backlog" heads its cluster and is not a bullet
+	want := "Sprint Board/8|Sprint Board (cont.)/4|Far away/1|Backlog/2"
+	if strings.Join(got, "|") != want {
+		t.Errorf("slides = %s, want %s", strings.Join(got, "|"), want)
+	}
+}
+
+func TestRenderPPTX(t *testing.T) {
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 40, 20))); err != nil {
+		t.Fatal(err)
+	}
+	doc, _ := Build(testWidgets(), "", "trigger")
+	doc.Title = "Retro <1>"
+	doc.Sections[2].Image = &Image{Data: img.Bytes(), ContentType: "image/png"}
+
+	data, err := Render(doc, FormatPPTX)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if zr.File[0].Name != "[Content_Types].xml" {
+		t.Errorf("first part = %s", zr.File[0].Name)
+	}
+
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(b)
+		if strings.HasSuffix(f.Name, ".xml") || strings.HasSuffix(f.Name, ".rels") {
+			dec := xml.NewDecoder(bytes.NewReader(b))
+			for {
+				if _, err := dec.Token(); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("%s is not well-formed: %v", f.Name, err)
+				}
+			}
+		}
+	}
+
+	// Title slide, the zone, and the note outside it
+	if !strings.Contains(parts["ppt/presentation.xml"], `r:id="rId5"`) || parts["ppt/slides/slide4.xml"] != "" {
+		t.Errorf("want 3 slides, presentation = %s", parts["ppt/presentation.xml"])
+	}
+	for _, want := range []string{"Retro &lt;1&gt;", "Exported"} {
+		if !strings.Contains(parts["ppt/slides/slide1.xml"], want) {
+			t.Errorf("title slide is missing %q", want)
+		}
+	}
+	zone := parts["ppt/slides/slide2.xml"]
+	for _, want := range []string{"Sprint Board", "Goals", "Ship the importer", "→ Risks", `r:embed="rId2"`, `descr="Architecture"`} {
+		if !strings.Contains(zone, want) {
+			t.Errorf("zone slide is missing %q:\n%s", want, zone)
+		}
+	}
+	if parts["ppt/media/image1.png"] != img.String() || !strings.Contains(parts["ppt/slides/_rels/slide2.xml.rels"], "../media/image1.png") {
+		t.Error("image not embedded")
+	}
+	if got := FileName(doc, FormatPPTX); !strings.HasSuffix(got, ".pptx") {
+		t.Errorf("FileName() = %q", got)
+	}
+}
+
+// fakeClient serves testWidgets and writes pngHeader for every image
+type fakeClient struct{}
+
+func (fakeClient) GetCanvasInfo() (map[string]interface{}, error) {
+	return map[string]interface{}{"name": "Team Space"}, nil
+}
+
+func (fakeClient) GetWidgets(subscribe bool) ([]map[string]interface{}, error) {
+	return testWidgets(), nil
+}
+
+func (fakeClient) DownloadImage(imageID, localPath string) error {
+	return os.WriteFile(localPath, pngHeader, 0644)
+}
+
+func TestExporter(t *testing.T) {
+	exporter := NewExporter(fakeClient{}, t.TempDir(), nil)
+	doc, err := exporter.Export(context.Background(), Options{Zone: "Sprint Board", ExcludeIDs: []string{"trigger"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "Team Space – Sprint Board" || doc.Created.IsZero() {
+		t.Errorf("doc = %+v", doc)
+	}
+	if img := doc.Sections[2].Image; img == nil || img.ContentType != "image/png" {
+		t.Errorf("image not embedded: %+v", doc.Sections[2])
+	}
+}
+
+func TestParseTrigger(t *testing.T) {
+	tests := []struct {
+		in     string
+		format Format
+		zone   string
+		ok     bool
+	}{
+		{"export", FormatMarkdown, "", true},
+		{" EXPORT:html ", FormatHTML, "", true},
+		{"export: Sprint Board", FormatMarkdown, "Sprint Board", true},
+		{"export:md Sprint Board", FormatMarkdown, "Sprint Board", true},
+		{"export:html Sprint Board", FormatHTML, "Sprint Board", true},
+		{"export:pptx Sprint Board", FormatPPTX, "Sprint Board", true},
+		{"exporting ideas for the fair", "", "", false},
+		{"what is an export?", "", "", false},
+	}
+	for _, tt := range tests {
+		format, zone, ok := ParseTrigger(tt.in)
+		if format != tt.format || zone != tt.zone || ok != tt.ok {
+			t.Errorf("ParseTrigger(%q) = %q, %q, %v", tt.in, format, zone, ok)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+// Package canvasexport converts a canvas, or one zone of it, into a
+// Markdown or HTML document or a PowerPoint deck: notes become sections,
+// images are embedded and connectors become links between sections.
+//
+// Architecture (Atomic Design):
+//   - document.go: Document atoms and Build, which arranges widgets into sections
+//   - render.go: Markdown and HTML renderers
+//   - pptx.go: Slides, which groups sections by zone and cluster, and the PowerPoint renderer
+//   - exporter.go: Exporter organism that fetches a canvas and its images
+package canvasexport
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go_backend/handlers"
+)
+
+// ErrZoneNotFound is returned when no anchor matches the requested zone.
+var ErrZoneNotFound = errors.New("canvasexport: zone not found")
+
+// Widget types exported as sections. Connectors become links; anchors
+// only select a zone.
+const (
+	KindNote    = "Note"
+	KindImage   = "Image"
+	KindPDF     = "Pdf"
+	KindVideo   = "Video"
+	KindBrowser = "Browser"
+)
+
+// maxTitleLength bounds a section title taken from the first line of a note.
+const maxTitleLength = 80
+
+// Format is an export document format.
+type Format string
+
+const (
+	FormatMarkdown Format = "markdown"
+	FormatHTML     Format = "html"
+	FormatPPTX     Format = "pptx"
+)
+
+// ParseFormat returns the format called s: "markdown" (or "md", or empty),
+// "html" or "pptx" (or "powerpoint").
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "md", "markdown":
+		return FormatMarkdown, nil
+	case "html", "htm":
+		return FormatHTML, nil
+	case "pptx", "powerpoint":
+		return FormatPPTX, nil
+	}
+	return "", fmt.Errorf("canvasexport: unknown format %q (use markdown, html or pptx)", s)
+}
+
+// Extension returns the file extension of the format, with its dot.
+func (f Format) Extension() string {
+	switch f {
+	case FormatHTML:
+		return ".html"
+	case FormatPPTX:
+		return ".pptx"
+	}
+	return ".md"
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	case FormatPPTX:
+		return "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+	}
+	return "text/markdown; charset=utf-8"
+}
+
+// Image is an image embedded in a section.
+type Image struct {
+	Data        []byte
+	ContentType string
+}
+
+// Link is a connector from a section to another section.
+type Link struct {
+	TargetID string
+	Title    string
+}
+
+// Section is one widget of the document.
+type Section struct {
+	ID    string
+	Kind  string
+	Title string
+	// Text is the note text, without the line used as the title
+	Text string
+	// URL of a browser widget
+	URL string
+	// Image is the embedded image (nil if it could not be downloaded)
+	Image *Image
+	Links []Link
+	// Bounds places the section in reading order
+	Bounds handlers.Rect
+}
+
+// Zone is the area of an anchor.
+type Zone struct {
+	Name   string
+	Bounds handlers.Rect
+}
+
+// Document is an exported canvas.
+type Document struct {
+	Title    string
+	Zone     string
+	Created  time.Time
+	Sections []Section
+	// Zones are the anchors of the exported area in reading order: the
+	// exported zone, or every named anchor of the canvas
+	Zones []Zone
+}
+
+// Build arranges canvas widgets into sections in reading order (top to
+// bottom, then left to right). If zone is set, only widgets whose center
+// lies inside the anchor with that name or ID are included. Widgets with
+// an ID in excludeIDs (e.g. the trigger note) are left out. Anchors are
+// not sections; they are listed in the document's zones.
+//
+// This is a pure function (molecule) with no external dependencies.
+func Build(widgets []map[string]interface{}, zone string, excludeIDs ...string) (*Document, error) {
+	byID := make(map[string]map[string]interface{}, len(widgets))
+	for _, w := range widgets {
+		if id := handlers.GetStringField(w, "id", ""); id != "" {
+			byID[id] = w
+		}
+	}
+	excluded := make(map[string]bool, len(excludeIDs))
+	for _, id := range excludeIDs {
+		excluded[id] = true
+	}
+
+	doc := &Document{}
+	var area *handlers.Rect
+	if zone != "" {
+		anchor := findAnchor(widgets, zone)
+		if anchor == nil {
+			return nil, fmt.Errorf("%w: %q", ErrZoneNotFound, zone)
+		}
+		bounds := widgetBounds(anchor, byID)
+		area = &bounds
+		doc.Zone = handlers.GetStringField(anchor, "anchor_name", zone)
+		doc.Zones = []Zone{{Name: doc.Zone, Bounds: bounds}}
+	} else {
+		for _, w := range widgets {
+			name := strings.TrimSpace(handlers.GetStringField(w, "anchor_name", ""))
+			if widgetType(w) == "Anchor" && name != "" {
+				doc.Zones = append(doc.Zones, Zone{Name: name, Bounds: widgetBounds(w, byID)})
+			}
+		}
+	}
+
+	for _, w := range widgets {
+		id := handlers.GetStringField(w, "id", "")
+		if id == "" || excluded[id] {
+			continue
+		}
+		section, ok := newSection(w)
+		if !ok {
+			continue
+		}
+		section.Bounds = widgetBounds(w, byID)
+		if area != nil && !contains(*area, section.Bounds) {
+			continue
+		}
+		doc.Sections = append(doc.Sections, section)
+	}
+
+	sort.SliceStable(doc.Sections, func(i, j int) bool {
+		return readingOrder(doc.Sections[i].Bounds, doc.Sections[j].Bounds)
+	})
+	sort.SliceStable(doc.Zones, func(i, j int) bool {
+		return readingOrder(doc.Zones[i].Bounds, doc.Zones[j].Bounds)
+	})
+
+	addLinks(doc, widgets)
+	return doc, nil
+}
+
+// newSection creates the section of a widget; ok is false for widgets that
+// are not exported.
+func newSection(w map[string]interface{}) (Section, bool) {
+	s := Section{
+		ID:    handlers.GetStringField(w, "id", ""),
+		Kind:  widgetType(w),
+		Title: strings.TrimSpace(handlers.GetStringField(w, "title", "")),
+	}
+	switch s.Kind {
+	case KindNote:
+		text := strings.TrimSpace(handlers.GetStringField(w, "text", ""))
+		if s.Title == "" {
+			s.Title, text = splitTitle(text)
+		}
+		if s.Title == "" {
+			return s, false // Empty notes add nothing to the document
+		}
+		s.Text = text
+	case KindImage:
+		if s.Title == "" {
+			s.Title = handlers.GetStringField(w, "original_filename", "Image")
+		}
+	case KindPDF:
+		if s.Title == "" {
+			s.Title = handlers.GetStringField(w, "original_filename", "PDF")
+		}
+	case KindVideo:
+		if s.Title == "" {
+			s.Title = handlers.GetStringField(w, "original_filename", "Video")
+		}
+	case KindBrowser:
+		s.URL = handlers.GetStringField(w, "url", "")
+		if s.Title == "" {
+			s.Title = s.URL
+		}
+		if s.Title == "" {
+			return s, false
+		}
+	default:
+		return s, false
+	}
+	return s, true
+}
+
+// addLinks adds each connector between two sections to its source section.
+func addLinks(doc *Document, widgets []map[string]interface{}) {
+	index := make(map[string]int, len(doc.Sections))
+	for i, s := range doc.Sections {
+		index[s.ID] = i
+	}
+	for _, w := range widgets {
+		if widgetType(w) != "Connector" {
+			continue
+		}
+		srcID := handlers.GetStringField(handlers.GetMapField(w, "src"), "id", "")
+		dstID := handlers.GetStringField(handlers.GetMapField(w, "dst"), "id", "")
+		src, okSrc := index[srcID]
+		dst, okDst := index[dstID]
+		if !okSrc || !okDst || src == dst {
+			continue
+		}
+		doc.Sections[src].Links = append(doc.Sections[src].Links, Link{TargetID: dstID, Title: doc.Sections[dst].Title})
+	}
+}
+
+// findAnchor returns the anchor whose name (ignoring case) or ID is zone.
+func findAnchor(widgets []map[string]interface{}, zone string) map[string]interface{} {
+	for _, w := range widgets {
+		if widgetType(w) != "Anchor" {
+			continue
+		}
+		if strings.EqualFold(handlers.GetStringField(w, "anchor_name", ""), zone) || handlers.GetStringField(w, "id", "") == zone {
+			return w
+		}
+	}
+	return nil
+}
+
+// widgetBounds returns a widget's rectangle in canvas coordinates,
+// resolving a location relative to a parent widget.
+func widgetBounds(w map[string]interface{}, byID map[string]map[string]interface{}) handlers.Rect {
+	parentID := handlers.GetStringField(w, "parent_id", "")
+	if parent, ok := byID[parentID]; ok && widgetType(parent) != "SharedCanvas" {
+		return handlers.WidgetBounds(w, parent)
+	}
+	return handlers.WidgetBounds(w, nil)
+}
+
+// contains reports whether the center of r lies inside area.
+func contains(area, r handlers.Rect) bool {
+	cx, cy := r.X+r.Width/2, r.Y+r.Height/2
+	return cx >= area.X && cx <= area.X+area.Width && cy >= area.Y && cy <= area.Y+area.Height
+}
+
+// readingOrder reports whether a comes before b from top to bottom, then
+// left to right.
+func readingOrder(a, b handlers.Rect) bool {
+	if a.Y != b.Y {
+		return a.Y < b.Y
+	}
+	return a.X < b.X
+}
+
+// splitTitle returns the first line of text as a title, and the rest.
+func splitTitle(text string) (title, rest string) {
+	title, rest, _ = strings.Cut(text, "\n")
+	title = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(title), "#"))
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		// Keep the whole line in the text when the title has to be cut
+		return strings.TrimSpace(string(runes[:maxTitleLength])) + "...", text
+	}
+	return title, strings.TrimSpace(rest)
+}
+
+func widgetType(w map[string]interface{}) string {
+	return handlers.GetStringField(w, "widget_type", handlers.GetStringField(w, "type", ""))
+}
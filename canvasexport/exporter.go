@@ -0,0 +1,190 @@
+// Package canvasexport provides the Exporter organism. This file contains
+// the Exporter, which fetches a canvas and its images and builds the
+// document, and the {{export}} trigger syntax.
+package canvasexport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"go_backend/core/clock"
+)
+
+// MaxImageBytes is the largest image embedded in a document. Larger
+// images are listed without their content.
+const MaxImageBytes = 10 << 20
+
+// Client reads a canvas. Implemented by canvusapi.Client.
+type Client interface {
+	GetCanvasInfo() (map[string]interface{}, error)
+	GetWidgets(subscribe bool) ([]map[string]interface{}, error)
+	DownloadImage(imageID string, localPath string) error
+}
+
+// Options select what is exported.
+type Options struct {
+	// Title of the document (default: the canvas name)
+	Title string
+	// Zone is the name or ID of the anchor to export (default: the whole canvas)
+	Zone string
+	// ExcludeIDs are widgets left out of the document
+	ExcludeIDs []string
+}
+
+// Exporter is an organism that exports a canvas.
+//
+// Usage:
+//
+//	exporter := canvasexport.NewExporter(client, downloadsDir, logger)
+//	doc, err := exporter.Export(ctx, canvasexport.Options{Zone: "Sprint board"})
+//	data, err := canvasexport.Render(doc, canvasexport.FormatHTML)
+type Exporter struct {
+	client  Client
+	tempDir string
+	logger  *zap.Logger
+	clock   clock.Clock
+}
+
+// NewExporter creates an Exporter reading from client. Images are
+// downloaded to a temporary directory under tempDir (default: the system
+// temporary directory) and removed after embedding.
+func NewExporter(client Client, tempDir string, logger *zap.Logger) *Exporter {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Exporter{client: client, tempDir: tempDir, logger: logger, clock: clock.Real()}
+}
+
+// SetClock sets the clock that stamps exported documents (default:
+// clock.Real). Call it before the exporter is used.
+func (e *Exporter) SetClock(c clock.Clock) {
+	e.clock = clock.OrReal(c)
+}
+
+// Export fetches the canvas and builds its document with the images
+// embedded. An image that cannot be downloaded is listed without its
+// content rather than failing the export.
+func (e *Exporter) Export(ctx context.Context, opts Options) (*Document, error) {
+	widgets, err := e.client.GetWidgets(false)
+	if err != nil {
+		return nil, fmt.Errorf("canvasexport: failed to fetch widgets: %w", err)
+	}
+	doc, err := Build(widgets, opts.Zone, opts.ExcludeIDs...)
+	if err != nil {
+		return nil, err
+	}
+	doc.Created = e.clock.Now()
+	doc.Title = opts.Title
+	if doc.Title == "" {
+		doc.Title = e.canvasName()
+	}
+	if doc.Zone != "" {
+		doc.Title += " – " + doc.Zone
+	}
+
+	if err := e.embedImages(ctx, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// canvasName returns the name of the canvas, or "Canvas" if it cannot be read.
+func (e *Exporter) canvasName() string {
+	info, err := e.client.GetCanvasInfo()
+	if err != nil {
+		e.logger.Debug("Failed to read canvas name", zap.Error(err))
+		return "Canvas"
+	}
+	if name, ok := info["name"].(string); ok && name != "" {
+		return name
+	}
+	return "Canvas"
+}
+
+// embedImages downloads the image of every image section.
+func (e *Exporter) embedImages(ctx context.Context, doc *Document) error {
+	var dir string
+	for i := range doc.Sections {
+		s := &doc.Sections[i]
+		if s.Kind != KindImage {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if dir == "" {
+			if e.tempDir != "" {
+				if err := os.MkdirAll(e.tempDir, 0755); err != nil {
+					return fmt.Errorf("canvasexport: failed to create temp directory: %w", err)
+				}
+			}
+			var err error
+			dir, err = os.MkdirTemp(e.tempDir, "export-")
+			if err != nil {
+				return fmt.Errorf("canvasexport: failed to create temp directory: %w", err)
+			}
+			defer os.RemoveAll(dir)
+		}
+
+		img, err := e.downloadImage(s.ID, filepath.Join(dir, s.ID))
+		if err != nil {
+			e.logger.Warn("Image left out of export", zap.String("widget_id", s.ID), zap.Error(err))
+			continue
+		}
+		s.Image = img
+	}
+	return nil
+}
+
+// downloadImage downloads an image widget to path and reads it back.
+func (e *Exporter) downloadImage(widgetID, path string) (*Image, error) {
+	if err := e.client.DownloadImage(widgetID, path); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > MaxImageBytes {
+		return nil, fmt.Errorf("image is larger than %d bytes", MaxImageBytes)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	contentType := http.DetectContentType(data)
+	if !strings.HasPrefix(contentType, "image/") {
+		return nil, fmt.Errorf("content is %s, not an image", contentType)
+	}
+	return &Image{Data: data, ContentType: contentType}, nil
+}
+
+// ParseTrigger parses the content of an export trigger: "export",
+// "export:html", "export: Sprint board" or "export:html Sprint board".
+// ok is false if content is not an export trigger.
+func ParseTrigger(content string) (format Format, zone string, ok bool) {
+	content = strings.TrimSpace(content)
+	if len(content) < len("export") || !strings.EqualFold(content[:len("export")], "export") {
+		return "", "", false
+	}
+	rest := content[len("export"):]
+	if rest == "" {
+		return FormatMarkdown, "", true
+	}
+	if rest[0] != ':' {
+		return "", "", false // e.g. "exporting ideas" is an ordinary prompt
+	}
+	rest = strings.TrimSpace(rest[1:])
+
+	word, remainder, _ := strings.Cut(rest, " ")
+	if f, err := ParseFormat(word); err == nil && word != "" {
+		return f, strings.TrimSpace(remainder), true
+	}
+	return FormatMarkdown, rest, true
+}
@@ -0,0 +1,527 @@
+// Package canvasexport provides the PowerPoint renderer. This file contains
+// Slides, which groups the sections of a document into slides, and
+// RenderPPTX, which writes them as an Office Open XML presentation.
+package canvasexport
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/gif" // Register decoders for the image sizes
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+
+	"go_backend/handlers"
+)
+
+// Slide layout limits. Longer groups continue on further slides.
+const (
+	maxBulletsPerSlide = 8
+	maxImagesPerSlide  = 4
+	maxNoteLines       = 5
+	maxBulletLength    = 160
+)
+
+// clusterGap is the largest distance, in canvas units, between two widgets
+// outside any zone that still puts them on the same slide.
+const clusterGap = 300.0
+
+// Slide is one slide of a deck: the bullets and images of a zone or cluster.
+type Slide struct {
+	Title string
+	// Items are shown as bullets
+	Items []Section
+	// Images are image sections with their content, placed beside the bullets
+	Images []Section
+}
+
+// Slides groups the sections of doc into slides: one per zone, then one per
+// cluster of nearby widgets outside any zone. A cluster headed by a note
+// without text is titled by that note. Groups with more bullets or images
+// than fit continue on further slides.
+//
+// This is a pure function (molecule) with no external dependencies.
+func Slides(doc *Document) []Slide {
+	groups := make([][]Section, len(doc.Zones))
+	var rest []Section
+	for _, s := range doc.Sections {
+		zone := -1
+		for i, z := range doc.Zones {
+			if contains(z.Bounds, s.Bounds) {
+				zone = i
+				break
+			}
+		}
+		if zone < 0 {
+			rest = append(rest, s)
+			continue
+		}
+		groups[zone] = append(groups[zone], s)
+	}
+
+	var slides []Slide
+	for i, sections := range groups {
+		slides = append(slides, paginate(doc.Zones[i].Name, sections)...)
+	}
+	for _, sections := range clusters(rest) {
+		title := sections[0].Title
+		if sections[0].Kind == KindNote && sections[0].Text == "" && len(sections) > 1 {
+			sections = sections[1:] // The heading note is the title, not a bullet
+		}
+		slides = append(slides, paginate(title, sections)...)
+	}
+	return slides
+}
+
+// clusters splits sections into groups of widgets at most clusterGap apart,
+// keeping reading order within and between groups.
+func clusters(sections []Section) [][]Section {
+	group := make([]int, len(sections))
+	for i := range group {
+		group[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if group[i] != i {
+			group[i] = find(group[i])
+		}
+		return group[i]
+	}
+	for i := range sections {
+		for j := i + 1; j < len(sections); j++ {
+			if gap(sections[i].Bounds, sections[j].Bounds) <= clusterGap {
+				a, b := find(i), find(j)
+				if a > b {
+					a, b = b, a
+				}
+				group[b] = a // The first section in reading order is the root
+			}
+		}
+	}
+
+	var out [][]Section
+	index := make(map[int]int)
+	for i, s := range sections {
+		root := find(i)
+		n, ok := index[root]
+		if !ok {
+			n = len(out)
+			index[root] = n
+			out = append(out, nil)
+		}
+		out[n] = append(out[n], s)
+	}
+	return out
+}
+
+// gap returns the distance between the edges of two rectangles, or zero if
+// they overlap.
+func gap(a, b handlers.Rect) float64 {
+	dx := max(0, max(a.X, b.X)-min(a.X+a.Width, b.X+b.Width))
+	dy := max(0, max(a.Y, b.Y)-min(a.Y+a.Height, b.Y+b.Height))
+	return max(dx, dy)
+}
+
+// paginate splits a group of sections into as many slides as its bullets
+// and images need.
+func paginate(title string, sections []Section) []Slide {
+	var items, images []Section
+	for _, s := range sections {
+		if s.Kind == KindImage && pptxImageExtension(s.Image) != "" {
+			images = append(images, s)
+		} else {
+			items = append(items, s)
+		}
+	}
+	if len(items) == 0 && len(images) == 0 {
+		return nil
+	}
+
+	var slides []Slide
+	for len(items) > 0 || len(images) > 0 {
+		slide := Slide{Title: title}
+		if len(slides) > 0 {
+			slide.Title += " (cont.)"
+		}
+		n := min(len(items), maxBulletsPerSlide)
+		slide.Items, items = items[:n], items[n:]
+		n = min(len(images), maxImagesPerSlide)
+		slide.Images, images = images[:n], images[n:]
+		slides = append(slides, slide)
+	}
+	return slides
+}
+
+// Slide geometry in EMU (914400 per inch) for a 16:9 slide.
+const (
+	slideWidth   = 12192000
+	slideHeight  = 6858000
+	slideMargin  = 457200
+	titleHeight  = 914400
+	contentTop   = slideMargin + titleHeight + 91440
+	contentSplit = slideWidth / 2
+	imagePadding = 91440
+)
+
+// RenderPPTX renders doc as a PowerPoint presentation: a title slide, then
+// the slides returned by Slides.
+func RenderPPTX(doc *Document) ([]byte, error) {
+	slides := Slides(doc)
+	w := &pptxWriter{doc: doc}
+	w.add("ppt/slides/slide1.xml", titleSlideXML(doc.Title, summary(doc)))
+	w.add("ppt/slides/_rels/slide1.xml.rels", relationshipsXML([]string{layoutRel}))
+	for i, slide := range slides {
+		n := i + 2
+		body, rels := w.slideXML(slide)
+		w.add(fmt.Sprintf("ppt/slides/slide%d.xml", n), body)
+		w.add(fmt.Sprintf("ppt/slides/_rels/slide%d.xml.rels", n), relationshipsXML(rels))
+	}
+	slideCount := len(slides) + 1
+
+	w.add("[Content_Types].xml", contentTypesXML(slideCount))
+	w.add("_rels/.rels", rootRelsXML)
+	w.add("docProps/core.xml", fmt.Sprintf(coreXML, xmlText(doc.Title), doc.Created.UTC().Format("2006-01-02T15:04:05Z")))
+	w.add("docProps/app.xml", fmt.Sprintf(appXML, slideCount))
+	w.add("ppt/presentation.xml", presentationXML(slideCount))
+	w.add("ppt/_rels/presentation.xml.rels", presentationRelsXML(slideCount))
+	w.add("ppt/slideMasters/slideMaster1.xml", slideMasterXML)
+	w.add("ppt/slideMasters/_rels/slideMaster1.xml.rels", relationshipsXML([]string{
+		rel("slideLayout", "../slideLayouts/slideLayout1.xml"),
+		rel("theme", "../theme/theme1.xml"),
+	}))
+	w.add("ppt/slideLayouts/slideLayout1.xml", slideLayoutXML)
+	w.add("ppt/slideLayouts/_rels/slideLayout1.xml.rels", relationshipsXML([]string{
+		rel("slideMaster", "../slideMasters/slideMaster1.xml"),
+	}))
+	w.add("ppt/theme/theme1.xml", themeXML)
+	return w.bytes()
+}
+
+// pptxWriter collects the parts of a presentation.
+type pptxWriter struct {
+	doc    *Document
+	parts  []pptxPart
+	images int
+}
+
+type pptxPart struct {
+	name string
+	data []byte
+}
+
+func (w *pptxWriter) add(name, content string) {
+	w.parts = append(w.parts, pptxPart{name: name, data: []byte(content)})
+}
+
+// bytes zips the parts, content types first as the format expects.
+func (w *pptxWriter) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels"} {
+		for _, p := range w.parts {
+			if p.name == name {
+				if err := w.write(zw, p); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	for _, p := range w.parts {
+		if p.name == "[Content_Types].xml" || p.name == "_rels/.rels" {
+			continue
+		}
+		if err := w.write(zw, p); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("canvasexport: failed to write presentation: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (w *pptxWriter) write(zw *zip.Writer, p pptxPart) error {
+	f, err := zw.CreateHeader(&zip.FileHeader{Name: p.name, Method: zip.Deflate, Modified: w.doc.Created})
+	if err == nil {
+		_, err = f.Write(p.data)
+	}
+	if err != nil {
+		return fmt.Errorf("canvasexport: failed to write presentation: %w", err)
+	}
+	return nil
+}
+
+// slideXML renders a slide, adding its images as media parts. It returns
+// the slide and its relationships.
+func (w *pptxWriter) slideXML(slide Slide) (string, []string) {
+	rels := []string{layoutRel}
+	var shapes strings.Builder
+	shapes.WriteString(textShapeXML(2, "Title", slideMargin, slideMargin, slideWidth-2*slideMargin, titleHeight,
+		paragraphXML(0, slide.Title, 3200, true, false)))
+
+	textWidth := slideWidth - 2*slideMargin
+	imageLeft := slideMargin
+	if len(slide.Images) > 0 && len(slide.Items) > 0 {
+		textWidth = contentSplit - slideMargin
+		imageLeft = contentSplit
+	}
+	contentHeight := slideHeight - contentTop - slideMargin
+
+	if len(slide.Items) > 0 {
+		var paragraphs strings.Builder
+		for _, s := range slide.Items {
+			paragraphs.WriteString(bulletsXML(s))
+		}
+		shapes.WriteString(textShapeXML(3, "Content", slideMargin, contentTop, textWidth, contentHeight, paragraphs.String()))
+	}
+
+	cols := min(len(slide.Images), 2)
+	rows := (len(slide.Images) + 1) / 2
+	for i, s := range slide.Images {
+		w.images++
+		ext := pptxImageExtension(s.Image)
+		media := fmt.Sprintf("image%d%s", w.images, ext)
+		w.add("ppt/media/"+media, string(s.Image.Data))
+		rels = append(rels, rel("image", "../media/"+media))
+
+		cellWidth := (slideWidth - slideMargin - imageLeft) / cols
+		cellHeight := contentHeight / rows
+		x := imageLeft + (i%cols)*cellWidth
+		y := contentTop + (i/cols)*cellHeight
+		cx, cy := fitImage(s.Image, cellWidth-2*imagePadding, cellHeight-2*imagePadding)
+		shapes.WriteString(fmt.Sprintf(pictureXML, 4+i, i+1, xmlText(s.Title), len(rels),
+			x+(cellWidth-cx)/2, y+(cellHeight-cy)/2, cx, cy))
+	}
+	return fmt.Sprintf(slideXML, shapes.String()), rels
+}
+
+// bulletsXML renders a section as a bullet with the note text, browser URL
+// and links indented below it.
+func bulletsXML(s Section) string {
+	var b strings.Builder
+	switch s.Kind {
+	case KindNote, KindBrowser:
+		b.WriteString(paragraphXML(0, s.Title, 2000, true, true))
+	default:
+		b.WriteString(paragraphXML(0, kindLabel(s.Kind)+": "+s.Title, 2000, true, true))
+	}
+	var lines []string
+	if s.Kind == KindNote {
+		for _, line := range strings.Split(s.Text, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > maxNoteLines {
+			lines = append(lines[:maxNoteLines-1], "…")
+		}
+	}
+	if s.Kind == KindBrowser && s.URL != "" && s.URL != s.Title {
+		lines = append(lines, s.URL)
+	}
+	if len(s.Links) > 0 {
+		titles := make([]string, len(s.Links))
+		for i, l := range s.Links {
+			titles[i] = l.Title
+		}
+		lines = append(lines, "→ "+strings.Join(titles, ", "))
+	}
+	for _, line := range lines {
+		b.WriteString(paragraphXML(1, line, 1600, false, true))
+	}
+	return b.String()
+}
+
+// paragraphXML renders a paragraph of text at an indent level.
+func paragraphXML(level int, text string, size int, bold, bullet bool) string {
+	if runes := []rune(text); len(runes) > maxBulletLength {
+		text = string(runes[:maxBulletLength-1]) + "…"
+	}
+	props := `<a:pPr><a:buNone/></a:pPr>`
+	if bullet {
+		props = fmt.Sprintf(`<a:pPr marL="%d" lvl="%d" indent="-285750"><a:buFont typeface="Arial"/><a:buChar char="•"/></a:pPr>`,
+			285750+level*457200, level)
+	}
+	b := ""
+	if bold {
+		b = ` b="1"`
+	}
+	return fmt.Sprintf(`<a:p>%s<a:r><a:rPr lang="en-US" sz="%d"%s dirty="0"/><a:t>%s</a:t></a:r></a:p>`, props, size, b, xmlText(text))
+}
+
+// textShapeXML renders a text box.
+func textShapeXML(id int, name string, x, y, cx, cy int, paragraphs string) string {
+	return fmt.Sprintf(textShapeTemplate, id, name, x, y, cx, cy, paragraphs)
+}
+
+// titleSlideXML renders the first slide of the deck.
+func titleSlideXML(title, subtitle string) string {
+	return fmt.Sprintf(slideXML,
+		textShapeXML(2, "Title", slideMargin, slideHeight/3, slideWidth-2*slideMargin, 1371600, paragraphXML(0, title, 4000, true, false))+
+			textShapeXML(3, "Subtitle", slideMargin, slideHeight/3+1371600, slideWidth-2*slideMargin, 685800, paragraphXML(0, subtitle, 1800, false, false)))
+}
+
+// pptxImageExtension returns the media file extension of an image that
+// PowerPoint can show, or "" if the image cannot be placed.
+func pptxImageExtension(img *Image) string {
+	if img == nil {
+		return ""
+	}
+	switch img.ContentType {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpeg"
+	case "image/gif":
+		return ".gif"
+	}
+	return ""
+}
+
+// fitImage returns the largest size with the image's aspect ratio that fits
+// in maxWidth by maxHeight. Images whose size cannot be read are square.
+func fitImage(img *Image, maxWidth, maxHeight int) (int, int) {
+	width, height := 1, 1
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(img.Data)); err == nil && cfg.Width > 0 && cfg.Height > 0 {
+		width, height = cfg.Width, cfg.Height
+	}
+	if maxWidth*height <= maxHeight*width {
+		return maxWidth, maxWidth * height / width
+	}
+	return maxHeight * width / height, maxHeight
+}
+
+// xmlText escapes s for XML text and attributes.
+func xmlText(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// Relationship types and XML namespaces of the presentation parts.
+const (
+	relTypeBase = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/"
+	nsDecl      = `xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+		`xmlns:p="http://schemas.openxmlformats.org/presentationml/2006/main"`
+	xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+)
+
+// layoutRel links a slide to the only slide layout.
+var layoutRel = rel("slideLayout", "../slideLayouts/slideLayout1.xml")
+
+// rel renders the body of a relationship; relationshipsXML numbers them.
+func rel(kind, target string) string {
+	return fmt.Sprintf(`Type="%s%s" Target="%s"`, relTypeBase, kind, target)
+}
+
+func relationshipsXML(rels []string) string {
+	var b strings.Builder
+	b.WriteString(xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, r := range rels {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" %s/>`, i+1, r)
+	}
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+func contentTypesXML(slides int) string {
+	var b strings.Builder
+	b.WriteString(xmlHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Default Extension="png" ContentType="image/png"/>` +
+		`<Default Extension="jpeg" ContentType="image/jpeg"/>` +
+		`<Default Extension="gif" ContentType="image/gif"/>` +
+		`<Override PartName="/ppt/presentation.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"/>` +
+		`<Override PartName="/ppt/slideMasters/slideMaster1.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slideMaster+xml"/>` +
+		`<Override PartName="/ppt/slideLayouts/slideLayout1.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slideLayout+xml"/>` +
+		`<Override PartName="/ppt/theme/theme1.xml" ContentType="application/vnd.openxmlformats-officedocument.theme+xml"/>` +
+		`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>` +
+		`<Override PartName="/docProps/app.xml" ContentType="application/vnd.openxmlformats-officedocument.extended-properties+xml"/>`)
+	for i := 1; i <= slides; i++ {
+		fmt.Fprintf(&b, `<Override PartName="/ppt/slides/slide%d.xml" ContentType="application/vnd.openxmlformats-officedocument.presentationml.slide+xml"/>`, i)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+// presentationXML lists the slides; the master is rId1, the theme rId2 and
+// the slides follow from rId3.
+func presentationXML(slides int) string {
+	var b strings.Builder
+	b.WriteString(xmlHeader + `<p:presentation ` + nsDecl + `>` +
+		`<p:sldMasterIdLst><p:sldMasterId id="2147483648" r:id="rId1"/></p:sldMasterIdLst><p:sldIdLst>`)
+	for i := 0; i < slides; i++ {
+		fmt.Fprintf(&b, `<p:sldId id="%d" r:id="rId%d"/>`, 256+i, 3+i)
+	}
+	fmt.Fprintf(&b, `</p:sldIdLst><p:sldSz cx="%d" cy="%d"/><p:notesSz cx="6858000" cy="9144000"/></p:presentation>`, slideWidth, slideHeight)
+	return b.String()
+}
+
+func presentationRelsXML(slides int) string {
+	rels := []string{rel("slideMaster", "slideMasters/slideMaster1.xml"), rel("theme", "theme/theme1.xml")}
+	for i := 1; i <= slides; i++ {
+		rels = append(rels, rel("slide", fmt.Sprintf("slides/slide%d.xml", i)))
+	}
+	return relationshipsXML(rels)
+}
+
+const rootRelsXML = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="` + relTypeBase + `officeDocument" Target="ppt/presentation.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+	`<Relationship Id="rId3" Type="` + relTypeBase + `extended-properties" Target="docProps/app.xml"/>` +
+	`</Relationships>`
+
+const coreXML = xmlHeader + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" ` +
+	`xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+	`<dc:title>%s</dc:title><dcterms:created xsi:type="dcterms:W3CDTF">%s</dcterms:created></cp:coreProperties>`
+
+const appXML = xmlHeader + `<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties">` +
+	`<Application>CanvusLocalLLM</Application><Slides>%d</Slides></Properties>`
+
+// emptyTree starts the shape tree of a slide, layout or master.
+const emptyTree = `<p:nvGrpSpPr><p:cNvPr id="1" name=""/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr>` +
+	`<p:grpSpPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="0" cy="0"/><a:chOff x="0" y="0"/><a:chExt cx="0" cy="0"/></a:xfrm></p:grpSpPr>`
+
+const slideXML = xmlHeader + `<p:sld ` + nsDecl + `><p:cSld><p:spTree>` + emptyTree +
+	`%s</p:spTree></p:cSld><p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sld>`
+
+const textShapeTemplate = `<p:sp><p:nvSpPr><p:cNvPr id="%d" name="%s"/><p:cNvSpPr txBox="1"/><p:nvPr/></p:nvSpPr>` +
+	`<p:spPr><a:xfrm><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr>` +
+	`<p:txBody><a:bodyPr wrap="square" rtlCol="0"><a:normAutofit/></a:bodyPr><a:lstStyle/>%s</p:txBody></p:sp>`
+
+const pictureXML = `<p:pic><p:nvPicPr><p:cNvPr id="%d" name="Image %d" descr="%s"/><p:cNvPicPr><a:picLocks noChangeAspect="1"/></p:cNvPicPr><p:nvPr/></p:nvPicPr>` +
+	`<p:blipFill><a:blip r:embed="rId%d"/><a:stretch><a:fillRect/></a:stretch></p:blipFill>` +
+	`<p:spPr><a:xfrm><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr></p:pic>`
+
+const slideMasterXML = xmlHeader + `<p:sldMaster ` + nsDecl + `><p:cSld><p:bg><p:bgRef idx="1001"><a:schemeClr val="bg1"/></p:bgRef></p:bg><p:spTree>` + emptyTree + `</p:spTree></p:cSld>` +
+	`<p:clrMap bg1="lt1" tx1="dk1" bg2="lt2" tx2="dk2" accent1="accent1" accent2="accent2" accent3="accent3" accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/>` +
+	`<p:sldLayoutIdLst><p:sldLayoutId id="2147483649" r:id="rId1"/></p:sldLayoutIdLst></p:sldMaster>`
+
+const slideLayoutXML = xmlHeader + `<p:sldLayout ` + nsDecl + ` type="blank" preserve="1"><p:cSld name="Blank"><p:spTree>` + emptyTree + `</p:spTree></p:cSld>` +
+	`<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sldLayout>`
+
+// themeXML is a plain Office theme: the master needs one.
+const themeXML = xmlHeader + `<a:theme xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" name="Canvus"><a:themeElements>` +
+	`<a:clrScheme name="Canvus">` +
+	`<a:dk1><a:srgbClr val="000000"/></a:dk1><a:lt1><a:srgbClr val="FFFFFF"/></a:lt1>` +
+	`<a:dk2><a:srgbClr val="1F2937"/></a:dk2><a:lt2><a:srgbClr val="F3F4F6"/></a:lt2>` +
+	`<a:accent1><a:srgbClr val="2563EB"/></a:accent1><a:accent2><a:srgbClr val="16A34A"/></a:accent2>` +
+	`<a:accent3><a:srgbClr val="F59E0B"/></a:accent3><a:accent4><a:srgbClr val="DC2626"/></a:accent4>` +
+	`<a:accent5><a:srgbClr val="7C3AED"/></a:accent5><a:accent6><a:srgbClr val="0891B2"/></a:accent6>` +
+	`<a:hlink><a:srgbClr val="2563EB"/></a:hlink><a:folHlink><a:srgbClr val="7C3AED"/></a:folHlink>` +
+	`</a:clrScheme>` +
+	`<a:fontScheme name="Canvus">` +
+	`<a:majorFont><a:latin typeface="Calibri"/><a:ea typeface=""/><a:cs typeface=""/></a:majorFont>` +
+	`<a:minorFont><a:latin typeface="Calibri"/><a:ea typeface=""/><a:cs typeface=""/></a:minorFont>` +
+	`</a:fontScheme>` +
+	`<a:fmtScheme name="Canvus">` +
+	`<a:fillStyleLst><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:fillStyleLst>` +
+	`<a:lnStyleLst><a:ln w="6350"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln><a:ln w="12700"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln><a:ln w="19050"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln></a:lnStyleLst>` +
+	`<a:effectStyleLst><a:effectStyle><a:effectLst/></a:effectStyle><a:effectStyle><a:effectLst/></a:effectStyle><a:effectStyle><a:effectLst/></a:effectStyle></a:effectStyleLst>` +
+	`<a:bgFillStyleLst><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"/></a:solidFill><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:bgFillStyleLst>` +
+	`</a:fmtScheme></a:themeElements><a:objectDefaults/><a:extraClrSchemeLst/></a:theme>`
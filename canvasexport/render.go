@@ -0,0 +1,154 @@
+// Package canvasexport provides the export document renderers. This file
+// contains the Markdown and HTML renderers and the download file name.
+package canvasexport
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// timeLayout formats the export time in documents.
+const timeLayout = "2006-01-02 15:04 MST"
+
+// slugPattern matches the runs of characters replaced in file names.
+var slugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Render renders doc in the given format.
+func Render(doc *Document, format Format) ([]byte, error) {
+	switch format {
+	case FormatHTML:
+		return RenderHTML(doc)
+	case FormatPPTX:
+		return RenderPPTX(doc)
+	}
+	return RenderMarkdown(doc), nil
+}
+
+// RenderMarkdown renders doc as Markdown. Each section starts with an HTML
+// anchor so connectors can link to it; images are embedded as data URLs.
+func RenderMarkdown(doc *Document) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", doc.Title)
+	fmt.Fprintf(&b, "_%s_\n", summary(doc))
+
+	for _, s := range doc.Sections {
+		fmt.Fprintf(&b, "\n<a id=\"%s\"></a>\n\n## %s\n\n", anchorID(s.ID), s.Title)
+		switch s.Kind {
+		case KindNote:
+			if s.Text != "" {
+				fmt.Fprintf(&b, "%s\n\n", s.Text)
+			}
+		case KindImage:
+			if s.Image != nil {
+				fmt.Fprintf(&b, "![%s](%s)\n\n", linkText(s.Title), dataURL(s.Image))
+			} else {
+				b.WriteString("_Image not available_\n\n")
+			}
+		case KindBrowser:
+			fmt.Fprintf(&b, "<%s>\n\n", s.URL)
+		default:
+			fmt.Fprintf(&b, "_%s on the canvas_\n\n", kindLabel(s.Kind))
+		}
+		if len(s.Links) > 0 {
+			links := make([]string, len(s.Links))
+			for i, l := range s.Links {
+				links[i] = fmt.Sprintf("[%s](#%s)", linkText(l.Title), anchorID(l.TargetID))
+			}
+			fmt.Fprintf(&b, "→ %s\n\n", strings.Join(links, ", "))
+		}
+	}
+	return []byte(strings.TrimRight(b.String(), "\n") + "\n")
+}
+
+// htmlTemplate renders a Document as a standalone HTML page.
+var htmlTemplate = template.Must(template.New("export").Funcs(template.FuncMap{
+	"anchor":  anchorID,
+	"dataURL": func(img *Image) template.URL { return template.URL(dataURL(img)) },
+	"label":   kindLabel,
+	"summary": summary,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 50em; margin: 2em auto; padding: 0 1em; line-height: 1.5; }
+section { border-top: 1px solid #ddd; padding: 0.5em 0; }
+.text { white-space: pre-wrap; }
+.meta, .links { color: #666; font-size: 0.9em; }
+img { max-width: 100%; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">{{summary .}}</p>
+{{range .Sections}}<section id="{{anchor .ID}}">
+<h2>{{.Title}}</h2>
+{{if eq .Kind "Note"}}{{if .Text}}<p class="text">{{.Text}}</p>
+{{end}}{{else if eq .Kind "Image"}}{{if .Image}}<img src="{{dataURL .Image}}" alt="{{.Title}}">
+{{else}}<p class="meta">Image not available</p>
+{{end}}{{else if eq .Kind "Browser"}}<p><a href="{{.URL}}">{{.URL}}</a></p>
+{{else}}<p class="meta">{{label .Kind}} on the canvas</p>
+{{end}}{{if .Links}}<p class="links">→ {{range $i, $l := .Links}}{{if $i}}, {{end}}<a href="#{{anchor $l.TargetID}}">{{$l.Title}}</a>{{end}}</p>
+{{end}}</section>
+{{end}}</body>
+</html>
+`))
+
+// RenderHTML renders doc as a standalone HTML page with embedded images.
+func RenderHTML(doc *Document) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, doc); err != nil {
+		return nil, fmt.Errorf("canvasexport: failed to render HTML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// FileName returns the download file name of doc, e.g.
+// "sprint-board-20261016-142501.md".
+func FileName(doc *Document, format Format) string {
+	slug := strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(doc.Title), "-"), "-")
+	if len(slug) > 40 {
+		slug = strings.TrimRight(slug[:40], "-")
+	}
+	if slug == "" {
+		slug = "canvas"
+	}
+	return slug + "-" + doc.Created.UTC().Format("20060102-150405") + format.Extension()
+}
+
+// summary describes when and what was exported.
+func summary(doc *Document) string {
+	s := fmt.Sprintf("Exported %s · %d items", doc.Created.UTC().Format(timeLayout), len(doc.Sections))
+	if doc.Zone != "" {
+		s += " · zone " + doc.Zone
+	}
+	return s
+}
+
+// anchorID is the HTML id of the section of a widget.
+func anchorID(widgetID string) string {
+	return "w-" + widgetID
+}
+
+// dataURL embeds an image in a URL.
+func dataURL(img *Image) string {
+	return "data:" + img.ContentType + ";base64," + base64.StdEncoding.EncodeToString(img.Data)
+}
+
+// linkText escapes the brackets that would end Markdown link text.
+func linkText(s string) string {
+	return strings.NewReplacer("[", `\[`, "]", `\]`).Replace(s)
+}
+
+// kindLabel names a widget type in a document.
+func kindLabel(kind string) string {
+	if kind == KindPDF {
+		return "PDF"
+	}
+	return kind
+}
@@ -0,0 +1,200 @@
+// Package canvaspreview provides the dashboard's canvas map, which shows
+// where on the wall AI activity is happening.
+//
+// Architecture:
+//   - NewLayout (atom): widget bounding boxes in canvas coordinates
+//   - Tracker (molecule): in-flight AI tasks and recently written AI widgets
+//   - Previewer (organism): combines layouts and activity per canvas and
+//     publishes them to the dashboard as they change
+package canvaspreview
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"time"
+
+	"go_backend/handlers"
+)
+
+// Layout limits.
+const (
+	// MaxWidgets bounds the boxes in one layout; larger canvases are truncated
+	MaxWidgets = 2000
+	// maxTitleLength bounds box titles
+	maxTitleLength = 40
+)
+
+// Rect is a rectangle in canvas coordinates.
+type Rect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// union returns the smallest rectangle holding r and o. An empty r is
+// replaced by o.
+func (r Rect) union(o Rect) Rect {
+	if r.Width == 0 && r.Height == 0 {
+		return o
+	}
+	minX, minY := math.Min(r.X, o.X), math.Min(r.Y, o.Y)
+	maxX, maxY := math.Max(r.X+r.Width, o.X+o.Width), math.Max(r.Y+r.Height, o.Y+o.Height)
+	return Rect{X: minX, Y: minY, Width: maxX - minX, Height: maxY - minY}
+}
+
+// Box is a widget on the map.
+type Box struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title,omitempty"`
+	Rect
+}
+
+// Layout is the simplified map of a canvas: the bounding box of every
+// widget that has an area.
+type Layout struct {
+	Bounds    Rect  `json:"bounds"`
+	Widgets   []Box `json:"widgets"`
+	Truncated bool  `json:"truncated,omitempty"`
+
+	byID      map[string]Rect
+	signature uint64
+}
+
+// NewLayout builds the layout of widgets as returned by canvusapi. Locations
+// relative to a parent widget are resolved to canvas coordinates. The shared
+// canvas itself and connectors are left out.
+func NewLayout(widgets []map[string]interface{}) Layout {
+	byID := make(map[string]map[string]interface{}, len(widgets))
+	for _, w := range widgets {
+		byID[handlers.GetStringField(w, "id", "")] = w
+	}
+
+	layout := Layout{Widgets: []Box{}, byID: make(map[string]Rect, len(widgets))}
+	h := fnv.New64a()
+	for _, w := range widgets {
+		typ := widgetType(w)
+		if typ == "SharedCanvas" || typ == "Connector" {
+			continue
+		}
+		var bounds handlers.Rect
+		if parent, ok := byID[handlers.GetStringField(w, "parent_id", "")]; ok && widgetType(parent) != "SharedCanvas" {
+			bounds = handlers.WidgetBounds(w, parent)
+		} else {
+			bounds = handlers.WidgetBounds(w, nil)
+		}
+		if bounds.Width <= 0 || bounds.Height <= 0 {
+			continue
+		}
+		if len(layout.Widgets) == MaxWidgets {
+			layout.Truncated = true
+			break
+		}
+
+		box := Box{
+			ID:    handlers.GetStringField(w, "id", ""),
+			Type:  typ,
+			Title: widgetTitle(w),
+			Rect:  Rect{X: bounds.X, Y: bounds.Y, Width: bounds.Width, Height: bounds.Height},
+		}
+		layout.Widgets = append(layout.Widgets, box)
+		layout.byID[box.ID] = box.Rect
+		layout.Bounds = layout.Bounds.union(box.Rect)
+		fmt.Fprintf(h, "%s|%s|%s|%g,%g,%g,%g\n", box.ID, box.Type, box.Title, box.X, box.Y, box.Width, box.Height)
+	}
+	layout.signature = h.Sum64()
+	return layout
+}
+
+// Locate returns the bounds of a widget on the map.
+func (l Layout) Locate(widgetID string) (Rect, bool) {
+	r, ok := l.byID[widgetID]
+	return r, ok
+}
+
+// TaskMarker is an AI task in progress, at the widget that triggered it.
+type TaskMarker struct {
+	TaskID   string    `json:"task_id"`
+	TaskType string    `json:"task_type"`
+	WidgetID string    `json:"widget_id,omitempty"`
+	Since    time.Time `json:"since"`
+	// Placed is false when the widget is not on the map; Rect is then empty
+	Placed bool `json:"placed"`
+	Rect
+}
+
+// WidgetMarker is a widget an AI task recently created or wrote to.
+type WidgetMarker struct {
+	WidgetID   string    `json:"widget_id"`
+	WidgetType string    `json:"widget_type"`
+	Operation  string    `json:"operation"`
+	Action     string    `json:"action"`
+	Time       time.Time `json:"time"`
+	Placed     bool      `json:"placed"`
+	Rect
+}
+
+// Activity is the AI activity on one canvas.
+type Activity struct {
+	CanvasID  string         `json:"canvas_id"`
+	Tasks     []TaskMarker   `json:"tasks"`
+	AIWidgets []WidgetMarker `json:"ai_widgets"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// place positions the markers of a on layout.
+func (a Activity) place(layout Layout) Activity {
+	placed := Activity{CanvasID: a.CanvasID, UpdatedAt: a.UpdatedAt,
+		Tasks: make([]TaskMarker, len(a.Tasks)), AIWidgets: make([]WidgetMarker, len(a.AIWidgets))}
+	for i, t := range a.Tasks {
+		t.Rect, t.Placed = layout.Locate(t.WidgetID)
+		placed.Tasks[i] = t
+	}
+	for i, w := range a.AIWidgets {
+		w.Rect, w.Placed = layout.Locate(w.WidgetID)
+		placed.AIWidgets[i] = w
+	}
+	return placed
+}
+
+// missing reports whether a refers to widgets that are not on layout.
+func (a Activity) missing(layout Layout) bool {
+	for _, t := range a.Tasks {
+		if _, ok := layout.Locate(t.WidgetID); t.WidgetID != "" && !ok {
+			return true
+		}
+	}
+	for _, w := range a.AIWidgets {
+		if _, ok := layout.Locate(w.WidgetID); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Preview is a canvas map with its AI activity.
+type Preview struct {
+	Layout
+	Activity
+}
+
+// widgetTitle returns a short label for a widget: its title, or the first
+// line of a note.
+func widgetTitle(w map[string]interface{}) string {
+	title := strings.TrimSpace(handlers.GetStringField(w, "title", ""))
+	if title == "" && widgetType(w) == "Note" {
+		title, _, _ = strings.Cut(strings.TrimSpace(handlers.GetStringField(w, "text", "")), "\n")
+		title = strings.TrimSpace(title)
+	}
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = strings.TrimSpace(string(runes[:maxTitleLength])) + "..."
+	}
+	return title
+}
+
+func widgetType(w map[string]interface{}) string {
+	return handlers.GetStringField(w, "widget_type", handlers.GetStringField(w, "type", ""))
+}
@@ -0,0 +1,79 @@
+package canvaspreview
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func widget(id, typ string, x, y, w, h float64) map[string]interface{} {
+	return map[string]interface{}{
+		"id":          id,
+		"widget_type": typ,
+		"location":    map[string]interface{}{"x": x, "y": y},
+		"size":        map[string]interface{}{"width": w, "height": h},
+		"scale":       1.0,
+	}
+}
+
+func TestNewLayout(t *testing.T) {
+	note := widget("n1", "Note", 100, 50, 200, 100)
+	note["text"] = "Quarterly plan\nwith details"
+	child := widget("c1", "Note", 10, 10, 20, 20)
+	child["parent_id"] = "n1"
+	widgets := []map[string]interface{}{
+		widget("canvas", "SharedCanvas", 0, 0, 5000, 5000),
+		note,
+		child,
+		widget("img", "Image", -100, 300, 50, 50),
+		widget("conn", "Connector", 0, 0, 10, 10),
+		widget("empty", "Note", 0, 0, 0, 0),
+	}
+
+	layout := NewLayout(widgets)
+	if len(layout.Widgets) != 3 {
+		t.Fatalf("widgets = %+v, want n1, c1 and img", layout.Widgets)
+	}
+	if layout.Widgets[0].Title != "Quarterly plan" {
+		t.Errorf("title = %q, want first line of note", layout.Widgets[0].Title)
+	}
+	if r, ok := layout.Locate("c1"); !ok || r.X != 110 || r.Y != 60 {
+		t.Errorf("child = %+v %v, want resolved to (110, 60)", r, ok)
+	}
+	want := Rect{X: -100, Y: 50, Width: 400, Height: 300}
+	if layout.Bounds != want {
+		t.Errorf("bounds = %+v, want %+v", layout.Bounds, want)
+	}
+
+	if NewLayout(widgets).signature != layout.signature {
+		t.Error("signature differs for the same widgets")
+	}
+	note["text"] = "Renamed"
+	if NewLayout(widgets).signature == layout.signature {
+		t.Error("signature unchanged after a title change")
+	}
+}
+
+func TestNewLayoutTruncates(t *testing.T) {
+	widgets := make([]map[string]interface{}, MaxWidgets+1)
+	for i := range widgets {
+		widgets[i] = widget(fmt.Sprint(i), "Note", float64(i), 0, 1, 1)
+	}
+	layout := NewLayout(widgets)
+	if !layout.Truncated || len(layout.Widgets) != MaxWidgets {
+		t.Errorf("truncated = %v with %d widgets", layout.Truncated, len(layout.Widgets))
+	}
+}
+
+func TestWidgetTitle(t *testing.T) {
+	long := widget("a", "Note", 0, 0, 1, 1)
+	long["title"] = strings.Repeat("x", 50)
+	if got := widgetTitle(long); got != strings.Repeat("x", maxTitleLength)+"..." {
+		t.Errorf("title = %q", got)
+	}
+	image := widget("b", "Image", 0, 0, 1, 1)
+	image["text"] = "ignored"
+	if got := widgetTitle(image); got != "" {
+		t.Errorf("image title = %q, want empty", got)
+	}
+}
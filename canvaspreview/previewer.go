@@ -0,0 +1,377 @@
+package canvaspreview
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go_backend/core/clock"
+	"go_backend/metrics"
+
+	"go.uber.org/zap"
+)
+
+// ErrUnknownCanvas is returned for canvases that are not monitored.
+var ErrUnknownCanvas = errors.New("canvaspreview: canvas is not monitored")
+
+// Activity limits.
+const (
+	// RecentWidgetAge is how long AI-written widgets stay marked
+	RecentWidgetAge = 10 * time.Minute
+	// maxRecentWidgets bounds the marked AI widgets per canvas
+	maxRecentWidgets = 50
+	// staleTaskAge drops tasks whose completion was never reported
+	staleTaskAge = 30 * time.Minute
+)
+
+// Tracker is a molecule that follows AI activity per canvas: the tasks in
+// progress, from the task updates sent to the dashboard, and the widgets AI
+// tasks recently created or wrote to.
+//
+// Thread-Safety: Tracker is safe for concurrent use.
+type Tracker struct {
+	mu      sync.Mutex
+	tasks   map[string]trackedTask
+	widgets map[string][]WidgetMarker
+
+	// clock is replaced in tests
+	clock clock.Clock
+}
+
+// trackedTask is a task in progress and its canvas.
+type trackedTask struct {
+	canvasID string
+	marker   TaskMarker
+}
+
+// NewTracker creates an empty Tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		tasks:   make(map[string]trackedTask),
+		widgets: make(map[string][]WidgetMarker),
+		clock:   clock.Real(),
+	}
+}
+
+// TaskUpdate records a task starting or finishing. It reports the canvas
+// whose activity changed, or "" if none did.
+func (t *Tracker) TaskUpdate(data metrics.TaskBroadcastData) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if data.Status == metrics.TaskStatusProcessing {
+		t.tasks[data.TaskID] = trackedTask{canvasID: data.CanvasID, marker: TaskMarker{
+			TaskID:   data.TaskID,
+			TaskType: data.TaskType,
+			WidgetID: data.WidgetID,
+			Since:    t.clock.Now(),
+		}}
+		return data.CanvasID
+	}
+	task, ok := t.tasks[data.TaskID]
+	if !ok {
+		return ""
+	}
+	delete(t.tasks, data.TaskID)
+	return task.canvasID
+}
+
+// WidgetWritten records that an AI task created (action "create") or wrote
+// to (action "update") a widget.
+func (t *Tracker) WidgetWritten(canvasID, widgetID, widgetType, operation, action string) {
+	if widgetID == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	marker := WidgetMarker{WidgetID: widgetID, WidgetType: widgetType, Operation: operation, Action: action, Time: t.clock.Now()}
+	kept := []WidgetMarker{marker}
+	for _, w := range t.widgets[canvasID] {
+		if w.WidgetID != widgetID && len(kept) < maxRecentWidgets {
+			kept = append(kept, w)
+		}
+	}
+	t.widgets[canvasID] = kept
+}
+
+// Activity returns the unplaced activity of a canvas, newest first.
+// Expired entries are dropped.
+func (t *Tracker) Activity(canvasID string) Activity {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.clock.Now()
+	activity := Activity{CanvasID: canvasID, Tasks: []TaskMarker{}, AIWidgets: []WidgetMarker{}, UpdatedAt: now}
+	for id, task := range t.tasks {
+		if now.Sub(task.marker.Since) > staleTaskAge {
+			delete(t.tasks, id)
+			continue
+		}
+		if task.canvasID == canvasID {
+			activity.Tasks = append(activity.Tasks, task.marker)
+		}
+	}
+	sort.Slice(activity.Tasks, func(i, j int) bool {
+		return activity.Tasks[i].Since.After(activity.Tasks[j].Since)
+	})
+
+	widgets := t.widgets[canvasID]
+	for len(widgets) > 0 && now.Sub(widgets[len(widgets)-1].Time) > RecentWidgetAge {
+		widgets = widgets[:len(widgets)-1]
+	}
+	t.widgets[canvasID] = widgets
+	activity.AIWidgets = append(activity.AIWidgets, widgets...)
+	return activity
+}
+
+// Client reads the widgets of a canvas. Implemented by *canvusapi.Client.
+type Client interface {
+	GetWidgets(subscribe bool) ([]map[string]interface{}, error)
+}
+
+// Publisher sends previews to the dashboard. Implemented by
+// webui.WebSocketBroadcaster.
+type Publisher interface {
+	// BroadcastCanvasPreview sends a map with its activity; sent when the
+	// layout changed
+	BroadcastCanvasPreview(preview Preview)
+	// BroadcastCanvasActivity sends the activity alone, placed on the
+	// layout last sent
+	BroadcastCanvasActivity(activity Activity)
+}
+
+// Config configures a Previewer.
+type Config struct {
+	// RefreshInterval is the longest a layout is reused (default: 30s)
+	RefreshInterval time.Duration
+	// MinRefreshInterval is the shortest time between two reads of a
+	// canvas, even when activity refers to widgets not on its map
+	// (default: 5s)
+	MinRefreshInterval time.Duration
+	// Debounce groups activity changes before publishing (default: 500ms)
+	Debounce time.Duration
+	Logger   *zap.Logger
+}
+
+// cachedLayout is the last layout read for a canvas.
+type cachedLayout struct {
+	layout  Layout
+	fetched time.Time
+	// published is the signature last sent to the dashboard
+	published uint64
+}
+
+// Previewer is an organism that keeps the map of each monitored canvas and
+// publishes it with the AI activity on it. Canvases are only read when
+// their activity changes or a preview is requested, and at most every
+// MinRefreshInterval, so idle canvases cost nothing.
+//
+// It implements metrics.TaskBroadcaster to follow tasks.
+//
+// Usage:
+//
+//	p := canvaspreview.New(config, clientFor, canvasIDs, broadcaster)
+//	events.BroadcastTasks(monitor.Events(), "canvas-preview", p)
+//	go p.Run(ctx)
+type Previewer struct {
+	config    Config
+	logger    *zap.Logger
+	tracker   *Tracker
+	clientFor func(canvasID string) Client
+	canvasIDs []string
+	publisher Publisher
+
+	mu      sync.Mutex
+	layouts map[string]*cachedLayout
+	dirty   map[string]bool
+	wake    chan struct{}
+
+	// clock is replaced in tests
+	clock clock.Clock
+}
+
+// New creates a Previewer for canvasIDs, reading each canvas with the
+// client clientFor returns and publishing changes to publisher.
+func New(config Config, clientFor func(canvasID string) Client, canvasIDs []string, publisher Publisher) *Previewer {
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = 30 * time.Second
+	}
+	if config.MinRefreshInterval <= 0 {
+		config.MinRefreshInterval = 5 * time.Second
+	}
+	if config.MinRefreshInterval > config.RefreshInterval {
+		config.MinRefreshInterval = config.RefreshInterval
+	}
+	if config.Debounce <= 0 {
+		config.Debounce = 500 * time.Millisecond
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Previewer{
+		config:    config,
+		logger:    logger,
+		tracker:   NewTracker(),
+		clientFor: clientFor,
+		canvasIDs: canvasIDs,
+		publisher: publisher,
+		layouts:   make(map[string]*cachedLayout),
+		dirty:     make(map[string]bool),
+		wake:      make(chan struct{}, 1),
+		clock:     clock.Real(),
+	}
+}
+
+// CanvasIDs returns the canvases that can be previewed.
+func (p *Previewer) CanvasIDs() []string {
+	return append([]string(nil), p.canvasIDs...)
+}
+
+// BroadcastTaskUpdateFromMetrics follows a task starting or finishing.
+// Implements metrics.TaskBroadcaster.
+func (p *Previewer) BroadcastTaskUpdateFromMetrics(data metrics.TaskBroadcastData) {
+	if canvasID := p.tracker.TaskUpdate(data); canvasID != "" {
+		p.markDirty(canvasID)
+	}
+}
+
+// WidgetWritten marks a widget an AI task created or wrote to.
+func (p *Previewer) WidgetWritten(canvasID, widgetID, widgetType, operation, action string) {
+	p.tracker.WidgetWritten(canvasID, widgetID, widgetType, operation, action)
+	p.markDirty(canvasID)
+}
+
+// Preview returns the map of a monitored canvas with its activity. The
+// canvas is read again unless it was read within MinRefreshInterval.
+func (p *Previewer) Preview(ctx context.Context, canvasID string) (*Preview, error) {
+	if !p.monitored(canvasID) {
+		return nil, ErrUnknownCanvas
+	}
+	activity := p.tracker.Activity(canvasID)
+	cached, err := p.layout(canvasID, true)
+	if err != nil {
+		return nil, err
+	}
+	return &Preview{Layout: cached.layout, Activity: activity.place(cached.layout)}, nil
+}
+
+// Run publishes the canvases whose activity changed until ctx is
+// cancelled.
+func (p *Previewer) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.wake:
+		}
+
+		// Let a burst of updates settle into one message per canvas
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.config.Debounce):
+		}
+
+		p.mu.Lock()
+		dirty := make([]string, 0, len(p.dirty))
+		for id := range p.dirty {
+			dirty = append(dirty, id)
+		}
+		p.dirty = make(map[string]bool)
+		p.mu.Unlock()
+
+		sort.Strings(dirty)
+		for _, canvasID := range dirty {
+			p.publish(canvasID)
+		}
+	}
+}
+
+// publish sends the activity of a canvas, with the whole map when its
+// layout changed since it was last sent.
+func (p *Previewer) publish(canvasID string) {
+	activity := p.tracker.Activity(canvasID)
+
+	p.mu.Lock()
+	cached := p.layouts[canvasID]
+	p.mu.Unlock()
+	// Read the canvas early when markers point at widgets not yet on the map
+	eager := cached == nil || activity.missing(cached.layout)
+
+	cached, err := p.layout(canvasID, eager)
+	if err != nil {
+		p.logger.Debug("Failed to read canvas for preview", zap.String("canvas_id", canvasID), zap.Error(err))
+	}
+
+	p.mu.Lock()
+	changed := cached.layout.signature != cached.published
+	cached.published = cached.layout.signature
+	p.mu.Unlock()
+
+	if changed {
+		p.publisher.BroadcastCanvasPreview(Preview{Layout: cached.layout, Activity: activity.place(cached.layout)})
+		return
+	}
+	p.publisher.BroadcastCanvasActivity(activity.place(cached.layout))
+}
+
+// layout returns the layout of a canvas, reading the canvas again when
+// the cached layout is older than RefreshInterval, or than
+// MinRefreshInterval when eager is set. A failed read returns the cached
+// layout, if any, with the error.
+func (p *Previewer) layout(canvasID string, eager bool) (*cachedLayout, error) {
+	p.mu.Lock()
+	cached := p.layouts[canvasID]
+	p.mu.Unlock()
+
+	maxAge := p.config.RefreshInterval
+	if eager {
+		maxAge = p.config.MinRefreshInterval
+	}
+	if cached != nil && p.clock.Now().Sub(cached.fetched) < maxAge {
+		return cached, nil
+	}
+
+	widgets, err := p.clientFor(canvasID).GetWidgets(false)
+	if err != nil {
+		if cached == nil {
+			cached = &cachedLayout{layout: NewLayout(nil)}
+		}
+		return cached, err
+	}
+	fresh := &cachedLayout{layout: NewLayout(widgets), fetched: p.clock.Now()}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if old := p.layouts[canvasID]; old != nil {
+		fresh.published = old.published
+	}
+	p.layouts[canvasID] = fresh
+	return fresh, nil
+}
+
+func (p *Previewer) markDirty(canvasID string) {
+	if !p.monitored(canvasID) {
+		return
+	}
+	p.mu.Lock()
+	p.dirty[canvasID] = true
+	p.mu.Unlock()
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Previewer) monitored(canvasID string) bool {
+	for _, id := range p.canvasIDs {
+		if id == canvasID {
+			return true
+		}
+	}
+	return false
+}
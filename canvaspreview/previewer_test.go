@@ -0,0 +1,176 @@
+package canvaspreview
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+	"go_backend/metrics"
+)
+
+type fakeClient struct {
+	mu      sync.Mutex
+	widgets []map[string]interface{}
+	err     error
+	reads   int
+}
+
+func (c *fakeClient) GetWidgets(bool) ([]map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reads++
+	return c.widgets, c.err
+}
+
+type fakePublisher struct {
+	mu         sync.Mutex
+	previews   []Preview
+	activities []Activity
+}
+
+func (p *fakePublisher) BroadcastCanvasPreview(preview Preview) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.previews = append(p.previews, preview)
+}
+
+func (p *fakePublisher) BroadcastCanvasActivity(activity Activity) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.activities = append(p.activities, activity)
+}
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+	fake := clock.NewFake(time.Now())
+	tracker.clock = fake
+
+	start := metrics.TaskBroadcastData{TaskID: "t1", TaskType: "note", Status: metrics.TaskStatusProcessing, CanvasID: "c", WidgetID: "n1"}
+	if got := tracker.TaskUpdate(start); got != "c" {
+		t.Errorf("start changed %q, want c", got)
+	}
+	tracker.WidgetWritten("c", "w1", "Note", "text_generation", "create")
+	tracker.WidgetWritten("c", "w2", "Image", "image_generation", "create")
+	tracker.WidgetWritten("c", "w1", "Note", "text_generation", "update")
+
+	activity := tracker.Activity("c")
+	if len(activity.Tasks) != 1 || activity.Tasks[0].WidgetID != "n1" {
+		t.Errorf("tasks = %+v", activity.Tasks)
+	}
+	if len(activity.AIWidgets) != 2 || activity.AIWidgets[0].WidgetID != "w1" || activity.AIWidgets[0].Action != "update" {
+		t.Errorf("widgets = %+v, want w1 updated first", activity.AIWidgets)
+	}
+	if other := tracker.Activity("other"); len(other.Tasks)+len(other.AIWidgets) != 0 {
+		t.Errorf("other canvas = %+v", other)
+	}
+
+	done := start
+	done.Status = metrics.TaskStatusSuccess
+	if got := tracker.TaskUpdate(done); got != "c" {
+		t.Errorf("finish changed %q, want c", got)
+	}
+	if got := tracker.TaskUpdate(done); got != "" {
+		t.Errorf("unknown task changed %q", got)
+	}
+
+	fake.Advance(RecentWidgetAge + time.Second)
+	tracker.TaskUpdate(start)
+	fake.Advance(staleTaskAge + time.Second)
+	if activity := tracker.Activity("c"); len(activity.Tasks)+len(activity.AIWidgets) != 0 {
+		t.Errorf("expired activity kept: %+v", activity)
+	}
+}
+
+func TestPreviewerPublish(t *testing.T) {
+	client := &fakeClient{widgets: []map[string]interface{}{widget("n1", "Note", 0, 0, 100, 100)}}
+	publisher := &fakePublisher{}
+	p := New(Config{RefreshInterval: time.Minute, MinRefreshInterval: 5 * time.Second},
+		func(string) Client { return client }, []string{"c"}, publisher)
+	fake := clock.NewFake(time.Now())
+	p.clock = fake
+	p.tracker.clock = fake
+
+	p.BroadcastTaskUpdateFromMetrics(metrics.TaskBroadcastData{TaskID: "t1", Status: metrics.TaskStatusProcessing, CanvasID: "c", WidgetID: "n1"})
+	p.publish("c")
+	if len(publisher.previews) != 1 || len(publisher.previews[0].Tasks) != 1 || !publisher.previews[0].Tasks[0].Placed {
+		t.Fatalf("first publish = %+v, want a preview with the placed task", publisher.previews)
+	}
+
+	// Unchanged layout: activity only, without reading the canvas again
+	p.WidgetWritten("c", "n1", "Note", "text_generation", "update")
+	p.publish("c")
+	if len(publisher.previews) != 1 || len(publisher.activities) != 1 || client.reads != 1 {
+		t.Errorf("previews=%d activities=%d reads=%d, want 1 1 1", len(publisher.previews), len(publisher.activities), client.reads)
+	}
+
+	// A widget missing from the map is read early, once MinRefreshInterval passed
+	client.widgets = append(client.widgets, widget("n2", "Note", 200, 0, 100, 100))
+	p.WidgetWritten("c", "n2", "Note", "text_generation", "create")
+	p.publish("c")
+	if client.reads != 1 {
+		t.Errorf("canvas read within MinRefreshInterval")
+	}
+	fake.Advance(6 * time.Second)
+	p.publish("c")
+	if client.reads != 2 || len(publisher.previews) != 2 || !publisher.previews[1].AIWidgets[0].Placed {
+		t.Errorf("reads=%d previews=%d, want the new layout published", client.reads, len(publisher.previews))
+	}
+
+	// A failed read keeps publishing on the last layout
+	client.err = errors.New("offline")
+	fake.Advance(2 * time.Minute)
+	p.publish("c")
+	if len(publisher.activities) != 3 {
+		t.Errorf("activities = %d, want 3", len(publisher.activities))
+	}
+}
+
+func TestPreviewerPreview(t *testing.T) {
+	client := &fakeClient{widgets: []map[string]interface{}{widget("n1", "Note", 0, 0, 100, 100)}}
+	p := New(Config{}, func(string) Client { return client }, []string{"c"}, &fakePublisher{})
+
+	if _, err := p.Preview(context.Background(), "other"); !errors.Is(err, ErrUnknownCanvas) {
+		t.Errorf("err = %v, want ErrUnknownCanvas", err)
+	}
+	preview, err := p.Preview(context.Background(), "c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preview.CanvasID != "c" || len(preview.Widgets) != 1 {
+		t.Errorf("preview = %+v", preview)
+	}
+
+	// Previews for the API do not count as published
+	publisher := p.publisher.(*fakePublisher)
+	p.publish("c")
+	if len(publisher.previews) != 1 {
+		t.Errorf("previews = %d, want the layout published once", len(publisher.previews))
+	}
+}
+
+func TestPreviewerRun(t *testing.T) {
+	client := &fakeClient{}
+	publisher := &fakePublisher{}
+	p := New(Config{Debounce: time.Millisecond}, func(string) Client { return client }, []string{"c"}, publisher)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Run(ctx)
+
+	p.WidgetWritten("ignored", "w", "Note", "text_generation", "create")
+	p.WidgetWritten("c", "w", "Note", "text_generation", "create")
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		publisher.mu.Lock()
+		n := len(publisher.previews)
+		publisher.mu.Unlock()
+		if n > 0 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("no preview published")
+}
@@ -0,0 +1,116 @@
+// Package canvassettings provides per-canvas overrides of the server
+// configuration. This file contains the Policy, the allow and deny lists
+// of features per canvas read from CANVAS_POLICY_FILE.
+package canvassettings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Rule lists the features a canvas may and may not use. An empty Allow
+// permits every feature not in Deny.
+type Rule struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// Permits reports whether the rule lets feature run. Deny wins over Allow.
+func (r Rule) Permits(feature string) bool {
+	if contains(r.Deny, feature) {
+		return false
+	}
+	return len(r.Allow) == 0 || contains(r.Allow, feature)
+}
+
+// validate checks that the rule only names known features.
+func (r Rule) validate(name string) error {
+	for _, list := range [][]string{r.Allow, r.Deny} {
+		for _, f := range list {
+			if !isFeature(f) {
+				return fmt.Errorf("canvas policy %s: unknown feature %q (valid: %s)", name, f, strings.Join(AllFeatures, ", "))
+			}
+		}
+	}
+	return nil
+}
+
+// Policy is the operator's allow and deny lists of features per canvas.
+// Unlike Settings, which are edited on the dashboard, the policy comes
+// from a file, so it cannot be loosened from the web UI. A nil Policy
+// permits everything.
+//
+// Example file:
+//
+//	{
+//	  "default": {"deny": ["export"]},
+//	  "canvases": {
+//	    "lobby-wall-id": {"deny": ["image_generation"]},
+//	    "kiosk-id": {"allow": ["notes", "pdf_precis"]}
+//	  }
+//	}
+type Policy struct {
+	// Default applies to canvases without a rule of their own
+	Default Rule `json:"default"`
+
+	// Canvases are the rules of individual canvases, by canvas ID. A
+	// canvas rule replaces Default.
+	Canvases map[string]Rule `json:"canvases,omitempty"`
+}
+
+// LoadPolicy reads a policy from a JSON file. An empty path returns nil,
+// which permits everything.
+func LoadPolicy(path string) (*Policy, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read canvas policy: %w", err)
+	}
+	return ParsePolicy(data)
+}
+
+// ParsePolicy parses and validates a JSON policy.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("parse canvas policy: %w", err)
+	}
+	if err := policy.Default.validate("default"); err != nil {
+		return nil, err
+	}
+	for canvasID, rule := range policy.Canvases {
+		if err := rule.validate(fmt.Sprintf("canvas %q", canvasID)); err != nil {
+			return nil, err
+		}
+	}
+	return &policy, nil
+}
+
+// Rule returns the rule that applies to canvasID.
+func (p *Policy) Rule(canvasID string) Rule {
+	if p == nil {
+		return Rule{}
+	}
+	if rule, ok := p.Canvases[canvasID]; ok {
+		return rule
+	}
+	return p.Default
+}
+
+// Permits reports whether feature may run on canvasID.
+func (p *Policy) Permits(canvasID, feature string) bool {
+	return p.Rule(canvasID).Permits(feature)
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
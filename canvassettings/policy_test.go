@@ -0,0 +1,78 @@
+package canvassettings
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPolicy_Permits(t *testing.T) {
+	policy, err := ParsePolicy([]byte(`{
+		"default": {"deny": ["export"]},
+		"canvases": {
+			"lobby": {"deny": ["image_generation"]},
+			"kiosk": {"allow": ["notes", "pdf_precis"], "deny": ["pdf_precis"]}
+		}
+	}`))
+	if err != nil {
+		t.Fatalf("ParsePolicy() error: %v", err)
+	}
+
+	tests := []struct {
+		canvas, feature string
+		want            bool
+	}{
+		{"other", FeatureExport, false},
+		{"other", FeatureNotes, true},
+		{"lobby", FeatureImageGeneration, false},
+		{"lobby", FeatureExport, true}, // the canvas rule replaces the default
+		{"kiosk", FeatureNotes, true},
+		{"kiosk", FeatureStickyWall, false},
+		{"kiosk", FeaturePDFPrecis, false}, // deny wins over allow
+	}
+	for _, tt := range tests {
+		if got := policy.Permits(tt.canvas, tt.feature); got != tt.want {
+			t.Errorf("Permits(%q, %q) = %v, want %v", tt.canvas, tt.feature, got, tt.want)
+		}
+	}
+
+	var none *Policy
+	if !none.Permits("lobby", FeatureImageGeneration) {
+		t.Error("nil policy refused a feature")
+	}
+}
+
+func TestParsePolicy_Invalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"syntax":          `{"default": `,
+		"default feature": `{"default": {"allow": ["images"]}}`,
+		"canvas feature":  `{"canvases": {"lobby": {"deny": ["video"]}}}`,
+	} {
+		if _, err := ParsePolicy([]byte(data)); err == nil {
+			t.Errorf("%s: ParsePolicy() returned no error", name)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	if policy, err := LoadPolicy(""); policy != nil || err != nil {
+		t.Errorf("LoadPolicy(\"\") = %v, %v; want nil, nil", policy, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := os.WriteFile(path, []byte(`{"default": {"deny": ["sticky_wall"]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadPolicy(path)
+	if err != nil {
+		t.Fatalf("LoadPolicy() error: %v", err)
+	}
+	if policy.Permits("any", FeatureStickyWall) {
+		t.Error("loaded policy permits a denied feature")
+	}
+
+	if _, err := LoadPolicy(filepath.Join(t.TempDir(), "missing.json")); err == nil || !strings.Contains(err.Error(), "read canvas policy") {
+		t.Errorf("LoadPolicy(missing) error = %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+// Package canvassettings provides per-canvas overrides of the server
+// configuration, so one server can apply different policies to different
+// canvases. This file contains the Settings type and its atoms.
+package canvassettings
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go_backend/core"
+	"go_backend/i18n"
+)
+
+// Features that can be turned off per canvas.
+const (
+	FeatureNotes           = "notes"            // {{ }} prompts answered with text
+	FeatureImageGeneration = "image_generation" // {{image: }} prompts and image answers
+	FeatureHandwriting     = "handwriting"      // Snapshot handwriting recognition
+	FeaturePDFPrecis       = "pdf_precis"       // AI_Icon_PDFPrecis
+	FeatureCanvasPrecis    = "canvas_precis"    // AI_Icon_CanvusPrecis
+	FeatureImageAnalysis   = "image_analysis"   // AI_Icon_Image_Analysis, including comparisons
+	FeatureImageExtraction = "image_extraction" // AI_Icon_Image_Extract
+	FeatureStickyWall      = "sticky_wall"      // AI_Icon_Sticky_Wall
+	FeatureExport          = "export"           // {{export}} document exports
+	FeatureImageVariants   = "image_variants"   // {{variants}} earlier images of a note
+	FeatureCollage         = "collage"          // {{collage}} and {{moodboard}} zone collages
+	FeatureNoteFix         = "note_fix"         // {{fix}} spelling and grammar fixes
+	FeatureRewrite         = "rewrite"          // {{rewrite}}, {{shorten}} and {{expand}}
+	FeatureGroupSummary    = "group_summary"    // {{summarize}} in a group or anchor
+	FeatureAutoTags        = "auto_tags"        // Background tagging of notes (AUTO_TAGS)
+	FeatureVoiceNotes      = "voice_notes"      // Transcripts of uploaded audio files
+	FeatureLiveTranscript  = "live_transcript"  // {{live}} meeting transcripts
+	FeatureHandwritingAll  = "handwriting_all"  // {{handwriting}} on all snapshots of a canvas
+)
+
+// AllFeatures lists every feature.
+var AllFeatures = []string{
+	FeatureNotes, FeatureImageGeneration, FeatureHandwriting, FeaturePDFPrecis,
+	FeatureCanvasPrecis, FeatureImageAnalysis, FeatureImageExtraction, FeatureStickyWall,
+	FeatureExport, FeatureImageVariants, FeatureCollage, FeatureNoteFix,
+	FeatureRewrite, FeatureGroupSummary, FeatureAutoTags, FeatureVoiceNotes,
+	FeatureLiveTranscript, FeatureHandwritingAll,
+}
+
+// LocalModel is the name under which AllowedModels allows tasks that have
+// no model configured and so run on the local model.
+const LocalModel = "local"
+
+// colorPattern matches #RRGGBB and #RRGGBBAA colors.
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}([0-9A-Fa-f]{2})?$`)
+
+// Settings are the overrides for one canvas. Empty fields keep the server
+// configuration.
+type Settings struct {
+	CanvasID string `json:"canvas_id"`
+
+	// DisabledFeatures are not processed on this canvas
+	DisabledFeatures []string `json:"disabled_features,omitempty"`
+
+	// AllowedModels restricts the models tasks may use (empty allows all).
+	// Use LocalModel to allow tasks without a configured model.
+	AllowedModels []string `json:"allowed_models,omitempty"`
+
+	NoteModel   string `json:"note_model,omitempty"`
+	CanvasModel string `json:"canvas_model,omitempty"`
+	PDFModel    string `json:"pdf_model,omitempty"`
+	ImageModel  string `json:"image_model,omitempty"`
+
+	// TriggerOpen and TriggerClose replace {{ and }}; set both or neither
+	TriggerOpen  string `json:"trigger_open,omitempty"`
+	TriggerClose string `json:"trigger_close,omitempty"`
+
+	NoteColor     string `json:"note_color,omitempty"`
+	NoteTextColor string `json:"note_text_color,omitempty"`
+
+	// Language of canvas messages and AI responses (e.g. "de")
+	Language string `json:"language,omitempty"`
+
+	// DailyTaskLimit caps the AI tasks started per day (0 = no limit)
+	DailyTaskLimit int `json:"daily_task_limit,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at,omitempty"`
+}
+
+// ErrInvalidSettings wraps the problems reported by Validate.
+var ErrInvalidSettings = errors.New("invalid canvas settings")
+
+// invalid returns an ErrInvalidSettings error describing a problem.
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSettings, fmt.Sprintf(format, args...))
+}
+
+// Validate checks the settings and returns the first problem found.
+func (s Settings) Validate() error {
+	if strings.TrimSpace(s.CanvasID) == "" {
+		return invalid("canvas_id is required")
+	}
+	for _, f := range s.DisabledFeatures {
+		if !isFeature(f) {
+			return invalid("unknown feature %q (valid: %s)", f, strings.Join(AllFeatures, ", "))
+		}
+	}
+	if (s.TriggerOpen == "") != (s.TriggerClose == "") {
+		return invalid("trigger_open and trigger_close must be set together")
+	}
+	if s.TriggerOpen != "" && s.TriggerOpen == s.TriggerClose {
+		return invalid("trigger_open and trigger_close must differ")
+	}
+	if strings.ContainsAny(s.TriggerOpen+s.TriggerClose, " \t\n") {
+		return invalid("trigger markers must not contain whitespace")
+	}
+	for name, color := range map[string]string{"note_color": s.NoteColor, "note_text_color": s.NoteTextColor} {
+		if color != "" && !colorPattern.MatchString(color) {
+			return invalid("%s must be #RRGGBB or #RRGGBBAA, got %q", name, color)
+		}
+	}
+	if s.Language != "" && !i18n.Supported(s.Language) {
+		return invalid("language must be one of %s, got %q", strings.Join(i18n.Languages(), ", "), s.Language)
+	}
+	if s.DailyTaskLimit < 0 {
+		return invalid("daily_task_limit must not be negative, got %d", s.DailyTaskLimit)
+	}
+	for _, model := range []string{s.NoteModel, s.CanvasModel, s.PDFModel, s.ImageModel} {
+		if model != "" && !s.ModelAllowed(model) {
+			return invalid("model %q is not in allowed_models", model)
+		}
+	}
+	return nil
+}
+
+// FeatureEnabled reports whether feature is processed on the canvas.
+func (s Settings) FeatureEnabled(feature string) bool {
+	for _, f := range s.DisabledFeatures {
+		if f == feature {
+			return false
+		}
+	}
+	return true
+}
+
+// ModelAllowed reports whether tasks may use model. An empty model means
+// the local model and is checked as LocalModel.
+func (s Settings) ModelAllowed(model string) bool {
+	if len(s.AllowedModels) == 0 {
+		return true
+	}
+	if model == "" {
+		model = LocalModel
+	}
+	for _, m := range s.AllowedModels {
+		if m == model {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns a copy of base with the overrides applied.
+func (s Settings) Apply(base *core.Config) *core.Config {
+	cfg := *base
+	override := func(dst *string, value string) {
+		if value != "" {
+			*dst = value
+		}
+	}
+	override(&cfg.OpenAINoteModel, s.NoteModel)
+	override(&cfg.OpenAICanvasModel, s.CanvasModel)
+	override(&cfg.OpenAIPDFModel, s.PDFModel)
+	override(&cfg.OpenAIImageModel, s.ImageModel)
+	override(&cfg.TriggerOpen, s.TriggerOpen)
+	override(&cfg.TriggerClose, s.TriggerClose)
+	override(&cfg.NoteColor, s.NoteColor)
+	override(&cfg.NoteTextColor, s.NoteTextColor)
+	override(&cfg.Language, s.Language)
+	return &cfg
+}
+
+// FeatureModel returns the model cfg configures for feature, or "" if
+// the feature runs on the local model only.
+func FeatureModel(cfg *core.Config, feature string) string {
+	switch feature {
+	case FeatureNotes:
+		return cfg.OpenAINoteModel
+	case FeatureImageGeneration:
+		return cfg.OpenAIImageModel
+	case FeaturePDFPrecis:
+		return cfg.OpenAIPDFModel
+	case FeatureCanvasPrecis:
+		return cfg.OpenAICanvasModel
+	}
+	return ""
+}
+
+// normalize trims the settings and drops empty and duplicate list entries.
+func (s Settings) normalize() Settings {
+	s.CanvasID = strings.TrimSpace(s.CanvasID)
+	s.DisabledFeatures = cleanList(s.DisabledFeatures)
+	s.AllowedModels = cleanList(s.AllowedModels)
+	for _, v := range []*string{&s.NoteModel, &s.CanvasModel, &s.PDFModel, &s.ImageModel,
+		&s.TriggerOpen, &s.TriggerClose, &s.NoteColor, &s.NoteTextColor} {
+		*v = strings.TrimSpace(*v)
+	}
+	if s.Language != "" {
+		s.Language = i18n.Normalize(s.Language)
+	}
+	return s
+}
+
+func cleanList(values []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func isFeature(f string) bool {
+	for _, feature := range AllFeatures {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
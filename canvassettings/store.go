@@ -0,0 +1,152 @@
+// Package canvassettings provides per-canvas overrides of the server
+// configuration. This file contains the Store organism, which caches the
+// settings of every canvas and enforces daily task budgets.
+package canvassettings
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go_backend/core"
+	"go_backend/core/clock"
+	"go_backend/core/errs"
+)
+
+// ErrBudgetExceeded is returned by CheckBudget once a canvas has used its
+// daily task limit.
+var ErrBudgetExceeded = errs.New(errs.CodeBudgetExceeded, "daily AI task limit reached for this canvas")
+
+// Storage persists canvas settings. Implemented by db.Repository.
+type Storage interface {
+	ListCanvasSettings(ctx context.Context) ([]Settings, error)
+	SaveCanvasSettings(ctx context.Context, s Settings) error
+	DeleteCanvasSettings(ctx context.Context, canvasID string) error
+}
+
+// TaskCounter counts the AI tasks started on a canvas. Implemented by
+// db.Repository.
+type TaskCounter interface {
+	CountCanvasTasksSince(ctx context.Context, canvasID string, since time.Time) (int, error)
+}
+
+// Store holds the settings of every canvas in memory and writes changes
+// through to Storage.
+//
+// Thread-Safety: Store is safe for concurrent use.
+type Store struct {
+	storage Storage
+	counter TaskCounter
+	clock   clock.Clock
+
+	mu       sync.RWMutex
+	settings map[string]Settings
+}
+
+// NewStore creates a Store and loads the saved settings. counter may be
+// nil, in which case daily task limits are not enforced.
+func NewStore(ctx context.Context, storage Storage, counter TaskCounter) (*Store, error) {
+	s := &Store{
+		storage:  storage,
+		counter:  counter,
+		clock:    clock.Real(),
+		settings: make(map[string]Settings),
+	}
+	saved, err := storage.ListCanvasSettings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load canvas settings: %w", err)
+	}
+	for _, settings := range saved {
+		s.settings[settings.CanvasID] = settings
+	}
+	return s, nil
+}
+
+// SetClock sets the clock that stamps saved settings and starts the day of
+// daily task limits (default: clock.Real). Call it before the store is
+// used.
+func (s *Store) SetClock(c clock.Clock) {
+	s.clock = clock.OrReal(c)
+}
+
+// Get returns the settings of canvasID. Canvases without overrides get
+// empty settings, which keep the server configuration.
+func (s *Store) Get(canvasID string) Settings {
+	if s == nil {
+		return Settings{CanvasID: canvasID}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if settings, ok := s.settings[canvasID]; ok {
+		return settings
+	}
+	return Settings{CanvasID: canvasID}
+}
+
+// List returns the settings of every canvas with overrides, by canvas ID.
+func (s *Store) List() []Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Settings, 0, len(s.settings))
+	for _, settings := range s.settings {
+		out = append(out, settings)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CanvasID < out[j].CanvasID })
+	return out
+}
+
+// Put validates and saves settings, replacing any earlier overrides for
+// the canvas. The saved settings are returned.
+func (s *Store) Put(ctx context.Context, settings Settings) (Settings, error) {
+	settings = settings.normalize()
+	if err := settings.Validate(); err != nil {
+		return settings, err
+	}
+	settings.UpdatedAt = s.clock.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.storage.SaveCanvasSettings(ctx, settings); err != nil {
+		return settings, fmt.Errorf("failed to save canvas settings: %w", err)
+	}
+	s.settings[settings.CanvasID] = settings
+	return settings, nil
+}
+
+// Delete removes the overrides of canvasID, so it uses the server
+// configuration again.
+func (s *Store) Delete(ctx context.Context, canvasID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.storage.DeleteCanvasSettings(ctx, canvasID); err != nil {
+		return fmt.Errorf("failed to delete canvas settings: %w", err)
+	}
+	delete(s.settings, canvasID)
+	return nil
+}
+
+// Config returns base with the overrides of canvasID applied.
+func (s *Store) Config(canvasID string, base *core.Config) *core.Config {
+	return s.Get(canvasID).Apply(base)
+}
+
+// CheckBudget returns ErrBudgetExceeded if canvasID has a daily task limit
+// and has started that many tasks since local midnight.
+func (s *Store) CheckBudget(ctx context.Context, canvasID string) error {
+	settings := s.Get(canvasID)
+	if s == nil || s.counter == nil || settings.DailyTaskLimit == 0 {
+		return nil
+	}
+	now := s.clock.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	used, err := s.counter.CountCanvasTasksSince(ctx, canvasID, midnight)
+	if err != nil {
+		return fmt.Errorf("failed to count today's tasks: %w", err)
+	}
+	if used >= settings.DailyTaskLimit {
+		return fmt.Errorf("%w (%d)", ErrBudgetExceeded, settings.DailyTaskLimit)
+	}
+	return nil
+}
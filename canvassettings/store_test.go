@@ -0,0 +1,167 @@
+package canvassettings
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_backend/core"
+	"go_backend/core/clock"
+)
+
+// memoryStorage keeps settings in a map
+type memoryStorage struct {
+	saved map[string]Settings
+}
+
+func (m *memoryStorage) ListCanvasSettings(ctx context.Context) ([]Settings, error) {
+	var out []Settings
+	for _, s := range m.saved {
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (m *memoryStorage) SaveCanvasSettings(ctx context.Context, s Settings) error {
+	m.saved[s.CanvasID] = s
+	return nil
+}
+
+func (m *memoryStorage) DeleteCanvasSettings(ctx context.Context, canvasID string) error {
+	delete(m.saved, canvasID)
+	return nil
+}
+
+// fixedCounter reports a fixed number of tasks and records the window start
+type fixedCounter struct {
+	count int
+	since time.Time
+}
+
+func (f *fixedCounter) CountCanvasTasksSince(ctx context.Context, canvasID string, since time.Time) (int, error) {
+	f.since = since
+	return f.count, nil
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings Settings
+		wantErr  bool
+	}{
+		{"empty overrides", Settings{CanvasID: "c"}, false},
+		{"all overrides", Settings{
+			CanvasID: "c", DisabledFeatures: []string{FeaturePDFPrecis}, AllowedModels: []string{"gpt-4o", LocalModel},
+			NoteModel: "gpt-4o", TriggerOpen: "[[", TriggerClose: "]]", NoteColor: "#FFEE00", NoteTextColor: "#000000FF",
+			DailyTaskLimit: 10,
+		}, false},
+		{"missing canvas", Settings{}, true},
+		{"unknown feature", Settings{CanvasID: "c", DisabledFeatures: []string{"telepathy"}}, true},
+		{"half trigger", Settings{CanvasID: "c", TriggerOpen: "[["}, true},
+		{"same markers", Settings{CanvasID: "c", TriggerOpen: "%%", TriggerClose: "%%"}, true},
+		{"bad color", Settings{CanvasID: "c", NoteColor: "yellow"}, true},
+		{"negative limit", Settings{CanvasID: "c", DailyTaskLimit: -1}, true},
+		{"supported language", Settings{CanvasID: "c", Language: "de"}, false},
+		{"unsupported language", Settings{CanvasID: "c", Language: "tlh"}, true},
+		{"model not allowed", Settings{CanvasID: "c", AllowedModels: []string{"gpt-4o-mini"}, PDFModel: "gpt-4o"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.settings.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestModelAllowed(t *testing.T) {
+	s := Settings{AllowedModels: []string{"gpt-4o-mini"}}
+	if !s.ModelAllowed("gpt-4o-mini") || s.ModelAllowed("gpt-4o") || s.ModelAllowed("") {
+		t.Error("ModelAllowed() should only allow listed models")
+	}
+	s.AllowedModels = append(s.AllowedModels, LocalModel)
+	if !s.ModelAllowed("") {
+		t.Error("ModelAllowed(\"\") should be allowed when local is listed")
+	}
+	if !(Settings{}).ModelAllowed("anything") {
+		t.Error("an empty allow list should allow every model")
+	}
+}
+
+func TestApply(t *testing.T) {
+	base := &core.Config{OpenAINoteModel: "gpt-4o", OpenAIPDFModel: "gpt-4o", NoteColor: "#FFFFFF"}
+	cfg := Settings{NoteModel: "gpt-4o-mini", NoteColor: "#FFEE00", TriggerOpen: "[[", TriggerClose: "]]", Language: "fr"}.Apply(base)
+
+	if cfg.OpenAINoteModel != "gpt-4o-mini" || cfg.OpenAIPDFModel != "gpt-4o" || cfg.NoteColor != "#FFEE00" ||
+		cfg.TriggerOpen != "[[" || cfg.TriggerClose != "]]" || cfg.Language != "fr" {
+		t.Errorf("Apply() = %+v", cfg)
+	}
+	if base.OpenAINoteModel != "gpt-4o" || base.NoteColor != "#FFFFFF" {
+		t.Error("Apply() must not modify the base config")
+	}
+}
+
+func TestStore(t *testing.T) {
+	storage := &memoryStorage{saved: map[string]Settings{"prod": {CanvasID: "prod", NoteColor: "#000000"}}}
+	store, err := NewStore(context.Background(), storage, nil)
+	if err != nil {
+		t.Fatalf("NewStore() error: %v", err)
+	}
+	if got := store.Get("prod"); got.NoteColor != "#000000" {
+		t.Errorf("Get(prod) = %+v, want the saved settings", got)
+	}
+	if got := store.Get("other"); got.CanvasID != "other" || !got.FeatureEnabled(FeatureNotes) {
+		t.Errorf("Get(other) = %+v, want empty overrides", got)
+	}
+
+	saved, err := store.Put(context.Background(), Settings{
+		CanvasID:         " demo ",
+		DisabledFeatures: []string{"pdf_precis", "", "pdf_precis"},
+	})
+	if err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if saved.CanvasID != "demo" || len(saved.DisabledFeatures) != 1 || saved.UpdatedAt.IsZero() {
+		t.Errorf("Put() = %+v, want normalized settings", saved)
+	}
+	if store.Get("demo").FeatureEnabled(FeaturePDFPrecis) || storage.saved["demo"].CanvasID != "demo" {
+		t.Error("Put() should update the cache and the storage")
+	}
+
+	if _, err := store.Put(context.Background(), Settings{CanvasID: "demo", NoteColor: "red"}); err == nil {
+		t.Error("Put() should reject invalid settings")
+	}
+	if store.Get("demo").FeatureEnabled(FeaturePDFPrecis) {
+		t.Error("a rejected Put() must not change the settings")
+	}
+
+	if err := store.Delete(context.Background(), "demo"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if len(store.List()) != 1 || !store.Get("demo").FeatureEnabled(FeaturePDFPrecis) {
+		t.Errorf("after Delete() List() = %+v", store.List())
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	counter := &fixedCounter{count: 5}
+	storage := &memoryStorage{saved: map[string]Settings{"demo": {CanvasID: "demo", DailyTaskLimit: 5}}}
+	store, _ := NewStore(context.Background(), storage, counter)
+	store.clock = clock.NewFake(time.Date(2026, 3, 1, 15, 30, 0, 0, time.UTC))
+
+	if err := store.CheckBudget(context.Background(), "demo"); !errors.Is(err, ErrBudgetExceeded) {
+		t.Errorf("CheckBudget() = %v, want ErrBudgetExceeded", err)
+	}
+	if want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC); !counter.since.Equal(want) {
+		t.Errorf("counted since %v, want midnight %v", counter.since, want)
+	}
+
+	counter.count = 4
+	if err := store.CheckBudget(context.Background(), "demo"); err != nil {
+		t.Errorf("CheckBudget() under the limit = %v", err)
+	}
+	if err := store.CheckBudget(context.Background(), "unlimited"); err != nil {
+		t.Errorf("CheckBudget() without a limit = %v", err)
+	}
+}
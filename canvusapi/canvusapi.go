@@ -13,6 +13,14 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
+	"time"
+
+	"go_backend/core/errs"
+	"go_backend/netdial"
+	"go_backend/netproxy"
+
+	"go.uber.org/zap"
 )
 
 // Core types and interfaces at the top
@@ -39,14 +47,88 @@ func (e *APIError) Error() string {
 	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Message)
 }
 
-// createHTTPClient creates an HTTP client with optional TLS configuration
+// ErrCode classifies the error by its HTTP status for error notes.
+func (e *APIError) ErrCode() errs.ErrCode {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden:
+		return errs.CodeCanvusAuth
+	case e.StatusCode == http.StatusNotFound:
+		return errs.CodeWidgetNotFound
+	case e.StatusCode >= 500:
+		return errs.CodeCanvusServer
+	}
+	return errs.CodeInternal
+}
+
+// maxLoggedBody is the most of a request or response body that is logged.
+const maxLoggedBody = 4096
+
+// requestLogger logs API requests of all clients; see SetLogger.
+type requestLogger struct {
+	logger    *zap.Logger
+	logBodies func() bool
+}
+
+var requestLog atomic.Pointer[requestLogger]
+
+// SetLogger makes every client log its JSON API requests to logger at
+// debug level. While logBodies returns true, requests are logged at info
+// level with their request and response bodies, so they show without
+// changing the log level. logBodies may be nil.
+func SetLogger(logger *zap.Logger, logBodies func() bool) {
+	if logBodies == nil {
+		logBodies = func() bool { return false }
+	}
+	requestLog.Store(&requestLogger{logger: logger, logBodies: logBodies})
+}
+
+// logRequest logs a finished request. The API key is sent as a header and
+// is never logged.
+func logRequest(method, endpoint string, status int, duration time.Duration, reqBody, respBody []byte, withBodies bool) {
+	rl := requestLog.Load()
+	if rl == nil {
+		return
+	}
+	fields := []zap.Field{
+		zap.String("method", method),
+		zap.String("endpoint", endpoint),
+		zap.Int("status", status),
+		zap.Duration("duration", duration),
+	}
+	if !withBodies {
+		rl.logger.Debug("Canvus API request", fields...)
+		return
+	}
+	fields = append(fields,
+		zap.String("request_body", truncateBody(reqBody)),
+		zap.String("response_body", truncateBody(respBody)))
+	rl.logger.Info("Canvus API request", fields...)
+}
+
+// logBodies reports whether request and response bodies are logged now.
+func logBodies() bool {
+	rl := requestLog.Load()
+	return rl != nil && rl.logBodies()
+}
+
+// truncateBody returns body as text, cut to maxLoggedBody bytes.
+func truncateBody(body []byte) string {
+	if len(body) > maxLoggedBody {
+		return string(body[:maxLoggedBody]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+// createHTTPClient creates an HTTP client with optional TLS configuration.
+// Requests go through the proxy netproxy chooses for the Canvus server, and
+// dual-stack addresses are dialed as configured by CANVUS_IP_FAMILY.
 func createHTTPClient(allowSelfSigned bool) *http.Client {
-	client := &http.Client{}
+	transport := netproxy.NewTransport()
+	transport.DialContext = netdial.FromEnvironment().DialContext
+	client := &http.Client{Transport: transport}
 
 	if allowSelfSigned {
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 		log.Println("⚠️ Warning: SSL certificate verification is DISABLED. This is not recommended for production environments.")
 	}
 
@@ -94,8 +176,10 @@ func (c *Client) Request(method, endpoint string, payload interface{}, out inter
 	}
 
 	var body io.Reader
+	var jsonData []byte
 	if payload != nil {
-		jsonData, err := json.Marshal(payload)
+		var err error
+		jsonData, err = json.Marshal(payload)
 		if err != nil {
 			return fmt.Errorf("failed to marshal payload: %w", err)
 		}
@@ -112,20 +196,39 @@ func (c *Client) Request(method, endpoint string, payload interface{}, out inter
 		req.Header.Set("Content-Type", "application/json")
 	}
 
+	start := time.Now()
 	resp, err := c.HTTP.Do(req)
 	if err != nil {
+		logRequest(method, endpoint, 0, time.Since(start), jsonData, nil, logBodies())
 		return fmt.Errorf("request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		bodyBytes, _ := io.ReadAll(resp.Body)
+		logRequest(method, endpoint, resp.StatusCode, time.Since(start), jsonData, bodyBytes, logBodies())
 		return &APIError{
 			StatusCode: resp.StatusCode,
 			Message:    string(bodyBytes),
 		}
 	}
 
+	// Subscriptions stream until closed, so their body is never read here
+	if logBodies() && !subscribe {
+		bodyBytes, err := io.ReadAll(resp.Body)
+		logRequest(method, endpoint, resp.StatusCode, time.Since(start), jsonData, bodyBytes, true)
+		if err != nil {
+			return fmt.Errorf("failed to read response: %w", err)
+		}
+		if out != nil {
+			if err := json.Unmarshal(bodyBytes, out); err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+		}
+		return nil
+	}
+	logRequest(method, endpoint, resp.StatusCode, time.Since(start), jsonData, nil, false)
+
 	if out != nil {
 		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
 			return fmt.Errorf("failed to decode response: %w", err)
@@ -0,0 +1,149 @@
+package canvusapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// replayClient returns a client that answers from a fixture in testdata.
+func replayClient(t *testing.T, fixture string) (*Client, *Recorder) {
+	t.Helper()
+	rec, err := NewRecorder(filepath.Join("testdata", fixture), ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	client := NewClient("https://canvus.invalid", "canvas", "key", false)
+	client.HTTP.Transport = rec
+	return client, rec
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', 0xff, 0x00}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/download"):
+			w.Write(png)
+		case r.Method == http.MethodPatch:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"id":"n1","text":"updated"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	fixture := filepath.Join(t.TempDir(), "fixture.json")
+	rec, err := NewRecorder(fixture, ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	client := NewClient(srv.URL, "canvas", "secret-api-key", false)
+	client.HTTP.Transport = rec
+
+	if _, err := client.UpdateNote("n1", map[string]interface{}{"text": "updated"}); err != nil {
+		t.Fatalf("UpdateNote() error = %v", err)
+	}
+	if err := client.DownloadImage("i1", filepath.Join(t.TempDir(), "i1.png")); err != nil {
+		t.Fatalf("DownloadImage() error = %v", err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("secret-api-key")) || bytes.Contains(data, []byte(srv.URL)) {
+		t.Errorf("fixture leaks the API key or server address:\n%s", data)
+	}
+
+	// Replay against a server that does not exist
+	replay, err := NewRecorder(fixture, ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	offline := NewClient("https://canvus.invalid", "canvas", "key", false)
+	offline.HTTP.Transport = replay
+
+	note, err := offline.UpdateNote("n1", map[string]interface{}{"text": "updated"})
+	if err != nil || note["text"] != "updated" {
+		t.Errorf("replayed UpdateNote() = %v, %v", note, err)
+	}
+	out := filepath.Join(t.TempDir(), "replayed.png")
+	if err := offline.DownloadImage("i1", out); err != nil {
+		t.Fatalf("replayed DownloadImage() error = %v", err)
+	}
+	if got, _ := os.ReadFile(out); !bytes.Equal(got, png) {
+		t.Errorf("replayed download = %x, want %x", got, png)
+	}
+	if unused := replay.Unused(); len(unused) != 0 {
+		t.Errorf("Unused() = %v", unused)
+	}
+
+	// Each interaction answers once
+	if _, err := offline.UpdateNote("n1", nil); !errors.Is(err, ErrNoRecording) {
+		t.Errorf("second UpdateNote() error = %v, want ErrNoRecording", err)
+	}
+}
+
+func TestReplayWidgetPayloads(t *testing.T) {
+	client, _ := replayClient(t, "widget_payloads.json")
+
+	widgets, err := client.GetWidgets(false)
+	if err != nil {
+		t.Fatalf("GetWidgets() error = %v", err)
+	}
+	if len(widgets) != 4 {
+		t.Fatalf("GetWidgets() returned %d widgets, want 4", len(widgets))
+	}
+
+	note := widgets[0]
+	if note["text"] != "{{ summarise this }}\nline two — 🚀" {
+		t.Errorf("note text = %q", note["text"])
+	}
+	if loc := note["location"].(map[string]interface{}); loc["x"] != -120.5 || loc["y"] != 400.0 {
+		t.Errorf("note location = %v", loc)
+	}
+	if image := widgets[1]; image["parent_id"] != nil || image["title"] != nil {
+		t.Errorf("null fields should decode as nil: %v", image)
+	}
+	if src := widgets[2]["src"].(map[string]interface{}); src["id"] != note["id"] {
+		t.Errorf("connector src = %v", src)
+	}
+}
+
+func TestReplayWidgetStream(t *testing.T) {
+	client, _ := replayClient(t, "widget_payloads.json")
+
+	var lines []string
+	err := client.StreamWidgets(context.Background(), StreamOptions{}, func(line string) {
+		lines = append(lines, line)
+	})
+	if !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("StreamWidgets() error = %v, want ErrStreamClosed", err)
+	}
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "[") || !strings.Contains(lines[2], `"deleted"`) {
+		t.Errorf("lines = %q", lines)
+	}
+}
+
+func TestReplayAPIError(t *testing.T) {
+	client, rec := replayClient(t, "widget_payloads.json")
+
+	err := client.DeleteWidget("c0ffee00-0000-4000-8000-000000000009")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("DeleteWidget() error = %v, want a 404 APIError", err)
+	}
+	if unused := rec.Unused(); len(unused) != 2 {
+		t.Errorf("Unused() returned %d interactions, want 2", len(unused))
+	}
+}
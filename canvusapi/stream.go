@@ -0,0 +1,184 @@
+package canvusapi
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// ErrStreamStalled is returned by StreamWidgets when the stream stopped
+// delivering data or the server stopped answering pings, e.g. after a VPN
+// drop left the connection half-open.
+var ErrStreamStalled = errors.New("widget stream stalled")
+
+// ErrStreamClosed is returned by StreamWidgets when the server ended the
+// stream.
+var ErrStreamClosed = errors.New("widget stream closed by the server")
+
+// defaultPingTimeout bounds a ping when StreamOptions.PingTimeout is unset.
+const defaultPingTimeout = 10 * time.Second
+
+// StreamOptions controls how StreamWidgets watches the stream for silent
+// drops.
+type StreamOptions struct {
+	// IdleTimeout ends the stream when nothing, not even a keep-alive line,
+	// arrives for this long; zero disables the check
+	IdleTimeout time.Duration
+	// PingInterval is how often the server is pinged over a separate
+	// request while the stream is open; zero disables pings
+	PingInterval time.Duration
+	// PingTimeout bounds each ping; zero uses 10 seconds
+	PingTimeout time.Duration
+	// OnConnected is called once the server accepted the subscription
+	OnConnected func()
+	// OnDegraded is called when the stream has been silent for half of
+	// IdleTimeout, and OnRecovered when data arrives again afterwards
+	OnDegraded  func(reason string)
+	OnRecovered func()
+}
+
+// StreamWidgets subscribes to the widget stream and calls handle with each
+// non-empty line until ctx ends or the stream fails. The first line holds
+// all widgets; later lines hold updates. A stream that stays silent for
+// IdleTimeout, or whose server does not answer a ping, is closed with
+// ErrStreamStalled so the caller can reconnect instead of waiting for the
+// TCP timeout.
+func (c *Client) StreamWidgets(ctx context.Context, opts StreamOptions, handle func(line string)) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	body, err := c.SubscribeToWidgets(ctx)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	if opts.OnConnected != nil {
+		opts.OnConnected()
+	}
+
+	timed := &timedReader{r: body, onRecovered: opts.OnRecovered}
+	if opts.IdleTimeout > 0 {
+		go watchIdle(ctx, cancel, timed, opts.IdleTimeout, opts.OnDegraded)
+	}
+	if opts.PingInterval > 0 {
+		go c.watchPings(ctx, cancel, opts)
+	}
+
+	reader := bufio.NewReader(timed)
+	for {
+		line, err := reader.ReadString('\n')
+		if line = strings.TrimSpace(line); line != "" {
+			handle(line)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return context.Cause(ctx)
+			}
+			if errors.Is(err, io.EOF) {
+				return ErrStreamClosed
+			}
+			return fmt.Errorf("widget stream read failed: %w", err)
+		}
+	}
+}
+
+// Ping checks that the Canvus server answers a request for the canvas. Any
+// HTTP response counts; only a failed or timed out request is an error.
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.buildURL(""), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Private-Token", c.ApiKey)
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// timedReader records when each read of the stream began, and reports
+// data arriving after the stream was marked degraded.
+type timedReader struct {
+	r io.Reader
+	// readStart is when the pending read began, or 0 while lines are handled
+	readStart   atomic.Int64
+	degraded    atomic.Bool
+	onRecovered func()
+}
+
+func (t *timedReader) Read(p []byte) (int, error) {
+	t.readStart.Store(time.Now().UnixNano())
+	n, err := t.r.Read(p)
+	t.readStart.Store(0)
+	if n > 0 && t.degraded.CompareAndSwap(true, false) && t.onRecovered != nil {
+		t.onRecovered()
+	}
+	return n, err
+}
+
+// watchIdle marks the stream degraded when a read has waited for half of
+// idle, and cancels it when the read has waited longer than idle. Time
+// spent handling lines does not count.
+func watchIdle(ctx context.Context, cancel context.CancelCauseFunc, timed *timedReader, idle time.Duration, onDegraded func(string)) {
+	interval := idle / 4
+	if interval > time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			started := timed.readStart.Load()
+			if started == 0 {
+				continue
+			}
+			waited := time.Since(time.Unix(0, started))
+			if waited > idle {
+				cancel(fmt.Errorf("%w: no data for %v", ErrStreamStalled, idle))
+				return
+			}
+			if waited > idle/2 && timed.degraded.CompareAndSwap(false, true) && onDegraded != nil {
+				onDegraded(fmt.Sprintf("no data for %v", waited.Round(time.Second)))
+			}
+		}
+	}
+}
+
+// watchPings pings the server every PingInterval and cancels the stream
+// when a ping fails.
+func (c *Client) watchPings(ctx context.Context, cancel context.CancelCauseFunc, opts StreamOptions) {
+	timeout := opts.PingTimeout
+	if timeout <= 0 {
+		timeout = defaultPingTimeout
+	}
+	ticker := time.NewTicker(opts.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			pingCtx, pingCancel := context.WithTimeout(ctx, timeout)
+			err := c.Ping(pingCtx)
+			pingCancel()
+			if err != nil && ctx.Err() == nil {
+				cancel(fmt.Errorf("%w: ping failed: %v", ErrStreamStalled, err))
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package canvusapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// streamServer serves a widget stream that writes lines and then holds the
+// connection open until the test ends. Other requests answer 200.
+func streamServer(t *testing.T, lines []string, keepalive time.Duration) *httptest.Server {
+	t.Helper()
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.URL.Query()["subscribe"]; !ok {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		flusher := w.(http.Flusher)
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+		flusher.Flush()
+
+		var tick <-chan time.Time
+		if keepalive > 0 {
+			ticker := time.NewTicker(keepalive)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+		for {
+			select {
+			case <-done:
+				return
+			case <-r.Context().Done():
+				return
+			case <-tick:
+				fmt.Fprintln(w)
+				flusher.Flush()
+			}
+		}
+	}))
+	t.Cleanup(func() {
+		close(done)
+		srv.Close()
+	})
+	return srv
+}
+
+func TestStreamWidgetsDeliversLines(t *testing.T) {
+	srv := streamServer(t, []string{`[{"id":"a"}]`, "", `{"id":"b"}`}, 0)
+	client := NewClient(srv.URL, "canvas", "key", false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var mu sync.Mutex
+	var got []string
+	connected := false
+	err := client.StreamWidgets(ctx, StreamOptions{OnConnected: func() { connected = true }}, func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, line)
+		if len(got) == 2 {
+			cancel()
+		}
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("StreamWidgets() error = %v, want context.Canceled", err)
+	}
+	if !connected {
+		t.Error("OnConnected was not called")
+	}
+	if strings.Join(got, " ") != `[{"id":"a"}] {"id":"b"}` {
+		t.Errorf("lines = %q", got)
+	}
+}
+
+func TestStreamWidgetsDetectsSilentStream(t *testing.T) {
+	srv := streamServer(t, []string{`[]`}, 0)
+	client := NewClient(srv.URL, "canvas", "key", false)
+
+	start := time.Now()
+	err := client.StreamWidgets(context.Background(), StreamOptions{IdleTimeout: 200 * time.Millisecond}, func(string) {})
+	if !errors.Is(err, ErrStreamStalled) {
+		t.Fatalf("StreamWidgets() error = %v, want ErrStreamStalled", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("stall detected after %v", elapsed)
+	}
+}
+
+func TestStreamWidgetsKeepaliveResetsIdleTimer(t *testing.T) {
+	srv := streamServer(t, []string{`[]`}, 50*time.Millisecond)
+	client := NewClient(srv.URL, "canvas", "key", false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+	err := client.StreamWidgets(ctx, StreamOptions{IdleTimeout: 200 * time.Millisecond}, func(string) {})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("StreamWidgets() error = %v, want the context deadline", err)
+	}
+}
+
+func TestStreamWidgetsSlowHandlerIsNotIdle(t *testing.T) {
+	srv := streamServer(t, []string{`[]`, `{"id":"a"}`}, 0)
+	client := NewClient(srv.URL, "canvas", "key", false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var handled atomic.Int32
+	err := client.StreamWidgets(ctx, StreamOptions{IdleTimeout: 100 * time.Millisecond}, func(string) {
+		if handled.Add(1) == 2 {
+			time.Sleep(300 * time.Millisecond)
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("StreamWidgets() error = %v, want context.Canceled", err)
+	}
+}
+
+func TestStreamWidgetsFailedPing(t *testing.T) {
+	srv := streamServer(t, []string{`[]`}, 20*time.Millisecond)
+	client := NewClient(srv.URL, "canvas", "key", false)
+
+	// Pings go to a server that never answers in time
+	var pings atomic.Int32
+	client.HTTP.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if _, ok := req.URL.Query()["subscribe"]; ok {
+			return http.DefaultTransport.RoundTrip(req)
+		}
+		pings.Add(1)
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+
+	err := client.StreamWidgets(context.Background(), StreamOptions{
+		PingInterval: 50 * time.Millisecond,
+		PingTimeout:  50 * time.Millisecond,
+	}, func(string) {})
+	if !errors.Is(err, ErrStreamStalled) || !strings.Contains(err.Error(), "ping failed") {
+		t.Errorf("StreamWidgets() error = %v, want a failed ping", err)
+	}
+	if pings.Load() == 0 {
+		t.Error("no ping was sent")
+	}
+}
+
+func TestStreamWidgetsReportsDegraded(t *testing.T) {
+	srv := streamServer(t, []string{`[]`}, 0)
+	client := NewClient(srv.URL, "canvas", "key", false)
+
+	var degraded atomic.Int32
+	err := client.StreamWidgets(context.Background(), StreamOptions{
+		IdleTimeout: 300 * time.Millisecond,
+		OnDegraded:  func(string) { degraded.Add(1) },
+	}, func(string) {})
+	if !errors.Is(err, ErrStreamStalled) {
+		t.Fatalf("StreamWidgets() error = %v, want ErrStreamStalled", err)
+	}
+	if degraded.Load() != 1 {
+		t.Errorf("OnDegraded called %d times, want 1", degraded.Load())
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
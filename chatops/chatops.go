@@ -0,0 +1,222 @@
+// Package chatops connects chat platforms to canvases, so a team can ask
+// the canvas AI a question without leaving Slack or Microsoft Teams.
+//
+// Architecture:
+//   - ParseCommand (atom): the subcommand and arguments of "/canvus ..."
+//   - VerifySlack, VerifyTeams (atoms): request signature checks
+//   - Asker (organism): posts a question to a canvas as a note, answers it
+//     with the note model and posts the answer beside it
+package chatops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go_backend/handlers"
+
+	"go.uber.org/zap"
+)
+
+// ErrBadSignature is returned for requests that are not signed by the
+// chat platform.
+var ErrBadSignature = errors.New("chatops: invalid request signature")
+
+// Config configures the chat integrations.
+type Config struct {
+	// SlackSigningSecret verifies Slack slash commands; empty disables Slack
+	SlackSigningSecret string
+	// TeamsSecret is the base64 security token of the Teams outgoing
+	// webhook; empty disables Teams
+	TeamsSecret string
+	// CanvasID receives the questions (default: the first monitored canvas)
+	CanvasID string
+}
+
+// ConfigFromEnv loads SLACK_SIGNING_SECRET, TEAMS_WEBHOOK_SECRET and
+// CHATOPS_CANVAS_ID.
+func ConfigFromEnv() Config {
+	return Config{
+		SlackSigningSecret: strings.TrimSpace(os.Getenv("SLACK_SIGNING_SECRET")),
+		TeamsSecret:        strings.TrimSpace(os.Getenv("TEAMS_WEBHOOK_SECRET")),
+		CanvasID:           strings.TrimSpace(os.Getenv("CHATOPS_CANVAS_ID")),
+	}
+}
+
+// Enabled reports whether either integration is configured.
+func (c Config) Enabled() bool {
+	return c.SlackSigningSecret != "" || c.TeamsSecret != ""
+}
+
+// Commands understood after "/canvus".
+const (
+	CommandAsk  = "ask"
+	CommandHelp = "help"
+)
+
+// HelpText describes the commands.
+const HelpText = "Usage:\n" +
+	"/canvus ask <question> - post the question to the canvas and answer it\n" +
+	"/canvus help - show this message"
+
+// Command is a parsed chat command.
+type Command struct {
+	Name string
+	Args string
+}
+
+// ParseCommand splits "ask what next?" into its subcommand and arguments.
+// Empty text is the help command.
+func ParseCommand(text string) Command {
+	name, args, _ := strings.Cut(strings.TrimSpace(text), " ")
+	if name == "" {
+		name = CommandHelp
+	}
+	return Command{Name: strings.ToLower(name), Args: strings.TrimSpace(args)}
+}
+
+// Layout of a question and its answer.
+const (
+	noteWidth     = 400.0
+	noteHeight    = 300.0
+	answerGap     = 100.0
+	placementGap  = 200.0
+	questionColor = "#FFF2CC"
+	answerColor   = "#D9EAD3"
+)
+
+// Client writes to a canvas. Implemented by canvusapi.Client.
+type Client interface {
+	GetWidgets(subscribe bool) ([]map[string]interface{}, error)
+	CreateNote(payload map[string]interface{}) (map[string]interface{}, error)
+	CreateConnector(payload map[string]interface{}) (map[string]interface{}, error)
+}
+
+// RespondFunc answers a note prompt the way the live note handler does.
+// An empty model uses the model the live handler would use.
+type RespondFunc func(ctx context.Context, prompt, model string) (string, error)
+
+// Question is a question asked from a chat.
+type Question struct {
+	Text string
+	// User who asked and Source chat ("Slack #design"), shown on the note
+	User   string
+	Source string
+}
+
+// Answer is the reply to a Question.
+type Answer struct {
+	Text       string
+	QuestionID string
+	AnswerID   string
+}
+
+// Asker is an organism that answers chat questions on a canvas.
+//
+// Usage:
+//
+//	asker := chatops.NewAsker(client, monitor.ReplayNotePrompt, logger)
+//	answer, err := asker.Ask(ctx, chatops.Question{Text: "What next?", User: "sam", Source: "Slack #design"})
+type Asker struct {
+	client  Client
+	respond RespondFunc
+	logger  *zap.Logger
+}
+
+// NewAsker creates an Asker posting to client and answering with respond.
+func NewAsker(client Client, respond RespondFunc, logger *zap.Logger) *Asker {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Asker{client: client, respond: respond, logger: logger}
+}
+
+// Ask posts the question as a note right of the canvas content, answers it
+// and posts the answer to its right, connected to the question. When the
+// model fails the question note stays on the canvas.
+func (a *Asker) Ask(ctx context.Context, q Question) (*Answer, error) {
+	loc, err := a.freeLocation()
+	if err != nil {
+		return nil, err
+	}
+
+	title := "Question"
+	if q.User != "" {
+		title += " from " + q.User
+	}
+	if q.Source != "" {
+		title += " (" + q.Source + ")"
+	}
+	question, err := a.client.CreateNote(map[string]interface{}{
+		"title":            title,
+		"text":             q.Text,
+		"location":         handlers.LocationToMap(loc),
+		"size":             handlers.SizeToMap(handlers.NoteSize{Width: noteWidth, Height: noteHeight}),
+		"background_color": questionColor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("chatops: failed to post question: %w", err)
+	}
+	answer := &Answer{}
+	answer.QuestionID, _ = question["id"].(string)
+
+	text, err := a.respond(ctx, q.Text, "")
+	if err != nil {
+		return answer, fmt.Errorf("chatops: failed to answer: %w", err)
+	}
+	answer.Text = strings.TrimSpace(text)
+
+	note, err := a.client.CreateNote(map[string]interface{}{
+		"title":            "Answer",
+		"text":             answer.Text,
+		"location":         handlers.LocationToMap(handlers.Location{X: loc.X + noteWidth + answerGap, Y: loc.Y}),
+		"size":             handlers.SizeToMap(handlers.NoteSize{Width: noteWidth, Height: noteHeight}),
+		"background_color": answerColor,
+	})
+	if err != nil {
+		return answer, fmt.Errorf("chatops: failed to post answer: %w", err)
+	}
+	answer.AnswerID, _ = note["id"].(string)
+
+	if answer.QuestionID != "" && answer.AnswerID != "" {
+		if _, err := a.client.CreateConnector(map[string]interface{}{
+			"src": map[string]interface{}{"id": answer.QuestionID, "auto_location": true, "tip": "none"},
+			"dst": map[string]interface{}{"id": answer.AnswerID, "auto_location": true, "tip": "solid-equilateral-triangle"},
+		}); err != nil {
+			a.logger.Warn("Failed to connect question and answer", zap.Error(err))
+		}
+	}
+
+	a.logger.Info("Chat question answered",
+		zap.String("source", q.Source),
+		zap.String("question_id", answer.QuestionID),
+		zap.String("answer_id", answer.AnswerID))
+	return answer, nil
+}
+
+// freeLocation returns a location right of every widget on the canvas, level
+// with the topmost one.
+func (a *Asker) freeLocation() (handlers.Location, error) {
+	widgets, err := a.client.GetWidgets(false)
+	if err != nil {
+		return handlers.Location{}, fmt.Errorf("chatops: failed to fetch widgets: %w", err)
+	}
+	var loc handlers.Location
+	found := false
+	for _, w := range widgets {
+		if handlers.GetStringField(w, "widget_type", "") == "SharedCanvas" {
+			continue
+		}
+		b := handlers.WidgetBounds(w, nil)
+		if !found || b.X+b.Width+placementGap > loc.X {
+			loc.X = b.X + b.Width + placementGap
+		}
+		if !found || b.Y < loc.Y {
+			loc.Y = b.Y
+		}
+		found = true
+	}
+	return loc, nil
+}
@@ -0,0 +1,149 @@
+package chatops
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseCommand(t *testing.T) {
+	for text, want := range map[string]Command{
+		"ask  What should we build next? ": {Name: CommandAsk, Args: "What should we build next?"},
+		"ASK":                              {Name: CommandAsk},
+		"":                                 {Name: CommandHelp},
+		"help me":                          {Name: CommandHelp, Args: "me"},
+	} {
+		if got := ParseCommand(text); got != want {
+			t.Errorf("ParseCommand(%q) = %+v, want %+v", text, got, want)
+		}
+	}
+}
+
+// signSlack signs body the way Slack does.
+func signSlack(secret string, ts int64, body string) http.Header {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + strconv.FormatInt(ts, 10) + ":" + body))
+	h := http.Header{}
+	h.Set("X-Slack-Request-Timestamp", strconv.FormatInt(ts, 10))
+	h.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+	return h
+}
+
+func TestVerifySlack(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte("command=%2Fcanvus&text=ask+hi")
+	if err := VerifySlack("secret", signSlack("secret", now.Unix(), string(body)), body, now); err != nil {
+		t.Errorf("valid request: %v", err)
+	}
+	for name, h := range map[string]http.Header{
+		"wrong secret": signSlack("other", now.Unix(), string(body)),
+		"stale":        signSlack("secret", now.Add(-10*time.Minute).Unix(), string(body)),
+		"unsigned":     {},
+	} {
+		if err := VerifySlack("secret", h, body, now); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("%s: err = %v, want ErrBadSignature", name, err)
+		}
+	}
+}
+
+func TestValidSlackResponseURL(t *testing.T) {
+	for u, want := range map[string]bool{
+		"https://hooks.slack.com/commands/T1/2/abc": true,
+		"http://hooks.slack.com/commands":           false,
+		"https://slack.com.evil.example/commands":   false,
+		"https://169.254.169.254/latest":            false,
+	} {
+		if got := ValidSlackResponseURL(u); got != want {
+			t.Errorf("ValidSlackResponseURL(%q) = %v", u, got)
+		}
+	}
+}
+
+func TestVerifyTeams(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	secret := base64.StdEncoding.EncodeToString(key)
+	body := []byte(`{"type":"message","text":"<at>Canvus</at> ask hi"}`)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	auth := "HMAC " + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	if err := VerifyTeams(secret, auth, body); err != nil {
+		t.Errorf("valid request: %v", err)
+	}
+	if err := VerifyTeams(secret, auth, append(body, ' ')); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("tampered body: err = %v", err)
+	}
+	if err := VerifyTeams("not base64!", auth, body); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("bad secret: err = %v", err)
+	}
+}
+
+func TestTeamsCommandText(t *testing.T) {
+	a := TeamsActivity{Text: "<at>Canvus</at>&nbsp;ask <b>What</b> next?\n"}
+	if got := a.CommandText(); got != "ask What next?" {
+		t.Errorf("CommandText() = %q", got)
+	}
+	a.Text = "<at>Canvus</at> /canvus help"
+	if got := a.CommandText(); got != "help" {
+		t.Errorf("CommandText() = %q", got)
+	}
+}
+
+// fakeCanvas records created widgets
+type fakeCanvas struct {
+	notes, connectors []map[string]interface{}
+}
+
+func (c *fakeCanvas) GetWidgets(subscribe bool) ([]map[string]interface{}, error) {
+	return []map[string]interface{}{
+		{"id": "n1", "widget_type": "Note", "location": map[string]interface{}{"x": 100.0, "y": 50.0}, "size": map[string]interface{}{"width": 300.0, "height": 300.0}},
+	}, nil
+}
+
+func (c *fakeCanvas) CreateNote(payload map[string]interface{}) (map[string]interface{}, error) {
+	c.notes = append(c.notes, payload)
+	return map[string]interface{}{"id": "note-" + strconv.Itoa(len(c.notes))}, nil
+}
+
+func (c *fakeCanvas) CreateConnector(payload map[string]interface{}) (map[string]interface{}, error) {
+	c.connectors = append(c.connectors, payload)
+	return map[string]interface{}{"id": "connector"}, nil
+}
+
+func TestAsker(t *testing.T) {
+	canvas := &fakeCanvas{}
+	respond := func(ctx context.Context, prompt, model string) (string, error) {
+		return " Ship the beta. ", nil
+	}
+	answer, err := NewAsker(canvas, respond, nil).Ask(context.Background(), Question{Text: "What next?", User: "sam", Source: "Slack #design"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer.Text != "Ship the beta." || answer.QuestionID != "note-1" || answer.AnswerID != "note-2" || len(canvas.connectors) != 1 {
+		t.Errorf("answer = %+v, %d connectors", answer, len(canvas.connectors))
+	}
+	q, a := canvas.notes[0], canvas.notes[1]
+	if q["title"] != "Question from sam (Slack #design)" || q["text"] != "What next?" {
+		t.Errorf("question note = %+v", q)
+	}
+	qLoc, aLoc := q["location"].(map[string]float64), a["location"].(map[string]float64)
+	if qLoc["x"] != 100+300+placementGap || qLoc["y"] != 50 || aLoc["x"] != qLoc["x"]+noteWidth+answerGap {
+		t.Errorf("question at %+v, answer at %+v", qLoc, aLoc)
+	}
+
+	failing := func(ctx context.Context, prompt, model string) (string, error) {
+		return "", errors.New("model offline")
+	}
+	canvas = &fakeCanvas{}
+	answer, err = NewAsker(canvas, failing, nil).Ask(context.Background(), Question{Text: "Hi"})
+	if err == nil || answer.QuestionID != "note-1" || len(canvas.notes) != 1 || canvas.notes[0]["title"] != "Question" {
+		t.Errorf("failed answer = %+v, %v, notes %+v", answer, err, canvas.notes)
+	}
+}
@@ -0,0 +1,90 @@
+package chatops
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slackMaxSkew is how old a signed Slack request may be, against replays.
+const slackMaxSkew = 5 * time.Minute
+
+// Slack response types.
+const (
+	SlackInChannel = "in_channel"
+	SlackEphemeral = "ephemeral"
+)
+
+// SlackMessage is a slash command response.
+type SlackMessage struct {
+	ResponseType string `json:"response_type"`
+	Text         string `json:"text"`
+}
+
+// VerifySlack checks the X-Slack-Signature of a request body against the
+// app's signing secret, rejecting requests signed more than five minutes
+// from now.
+func VerifySlack(secret string, header http.Header, body []byte, now time.Time) error {
+	ts := header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrBadSignature
+	}
+	if skew := now.Sub(time.Unix(seconds, 0)); skew > slackMaxSkew || skew < -slackMaxSkew {
+		return fmt.Errorf("%w: timestamp is %s off", ErrBadSignature, skew.Round(time.Second))
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + ts + ":"))
+	mac.Write(body)
+	want := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(header.Get("X-Slack-Signature"))) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// ValidSlackResponseURL reports whether u is a Slack response URL, so
+// answers are only ever posted back to Slack.
+func ValidSlackResponseURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	return host == "slack.com" || strings.HasSuffix(host, ".slack.com")
+}
+
+// PostSlack sends a delayed response to a slash command's response_url.
+func PostSlack(ctx context.Context, client *http.Client, responseURL string, msg SlackMessage) error {
+	if !ValidSlackResponseURL(responseURL) {
+		return fmt.Errorf("chatops: %q is not a Slack response URL", responseURL)
+	}
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("chatops: post to Slack: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("chatops: Slack returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package chatops
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// TeamsActivity is the message a Teams outgoing webhook posts.
+type TeamsActivity struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+	From struct {
+		Name string `json:"name"`
+	} `json:"from"`
+	Conversation struct {
+		Name string `json:"name"`
+	} `json:"conversation"`
+}
+
+// TeamsMessage is the reply to an outgoing webhook.
+type TeamsMessage struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// NewTeamsMessage returns a text reply.
+func NewTeamsMessage(text string) TeamsMessage {
+	return TeamsMessage{Type: "message", Text: text}
+}
+
+// VerifyTeams checks the "HMAC <signature>" Authorization header of an
+// outgoing webhook request against the webhook's base64 security token.
+func VerifyTeams(secret, authorization string, body []byte) error {
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return ErrBadSignature
+	}
+	got, ok := strings.CutPrefix(authorization, "HMAC ")
+	if !ok {
+		return ErrBadSignature
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(body)
+	want := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(want), []byte(strings.TrimSpace(got))) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+var (
+	teamsMention = regexp.MustCompile(`(?s)<at>.*?</at>`)
+	teamsTags    = regexp.MustCompile(`<[^>]*>`)
+)
+
+// CommandText returns the text of an activity without the @mention of the
+// webhook and the HTML Teams wraps it in.
+func (a TeamsActivity) CommandText() string {
+	text := teamsMention.ReplaceAllString(a.Text, " ")
+	text = html.UnescapeString(teamsTags.ReplaceAllString(text, " "))
+	text = strings.TrimSpace(strings.Join(strings.Fields(text), " "))
+	// "/canvus ask ..." typed after the mention
+	return strings.TrimSpace(strings.TrimPrefix(text, "/canvus"))
+}
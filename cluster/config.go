@@ -0,0 +1,122 @@
+package cluster
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go_backend/core"
+
+	"go.uber.org/zap"
+)
+
+// Role is a kind of work a node takes on.
+type Role string
+
+const (
+	// RoleMonitor follows the node's canvas and dispatches the AI tasks its
+	// triggers start
+	RoleMonitor Role = "monitor"
+	// RoleImage generates images for any node of the cluster
+	RoleImage Role = "image"
+	// RoleTasks runs the AI tasks (notes, PDFs, canvas analysis,
+	// handwriting, icons) that monitor nodes put on the task queue
+	RoleTasks Role = "tasks"
+)
+
+// AllRoles are the roles of a node that does not list its own.
+var AllRoles = []Role{RoleMonitor, RoleImage, RoleTasks}
+
+// DefaultTTL is how long a node stays a member without a heartbeat.
+const DefaultTTL = 15 * time.Second
+
+// ParseRoles parses a comma-separated CLUSTER_ROLES value. An empty value
+// is every role.
+func ParseRoles(value string) ([]Role, error) {
+	var roles []Role
+	seen := make(map[Role]bool)
+	for _, part := range strings.Split(value, ",") {
+		role := Role(strings.ToLower(strings.TrimSpace(part)))
+		if role == "" || seen[role] {
+			continue
+		}
+		if role != RoleMonitor && role != RoleImage && role != RoleTasks {
+			return nil, fmt.Errorf("cluster: unknown role %q (use monitor, image, tasks)", part)
+		}
+		seen[role] = true
+		roles = append(roles, role)
+	}
+	if len(roles) == 0 {
+		return AllRoles, nil
+	}
+	return roles, nil
+}
+
+// Config configures the Node.
+type Config struct {
+	// URL of the shared queue server: redis://[user:password@]host[:port][/db]
+	// or rediss:// for TLS. Empty disables clustering.
+	URL string
+
+	// Name prefixes every key, so several clusters can share a server
+	// (default: canvuslocallm)
+	Name string
+
+	// Roles this node takes on (default: all)
+	Roles []Role
+
+	// TTL is how long the node stays a member, and the leader keeps its
+	// lead, without a heartbeat; heartbeats are sent every third of it
+	// (default: 15s)
+	TTL time.Duration
+
+	// ID identifies this node; Host and PID are reported to other nodes
+	ID   string
+	Host string
+	PID  int
+
+	// CanvasID is the canvas the node monitors, reported to other nodes
+	CanvasID string
+
+	// Logger for diagnostic output (optional)
+	Logger *zap.Logger
+}
+
+// Enabled reports whether clustering is configured.
+func (c Config) Enabled() bool {
+	return c.URL != ""
+}
+
+// ConfigFromEnv reads CLUSTER_URL, CLUSTER_NAME, CLUSTER_ROLES and
+// CLUSTER_TTL (seconds). An invalid value is returned as an error together
+// with a config that has clustering disabled.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		URL:  strings.TrimSpace(core.GetEnvOrDefault("CLUSTER_URL", "")),
+		Name: core.GetEnvOrDefault("CLUSTER_NAME", "canvuslocallm"),
+		TTL:  core.ParseDurationEnv("CLUSTER_TTL", int(DefaultTTL/time.Second)),
+	}
+	if cfg.URL == "" {
+		return cfg, nil
+	}
+
+	roles, err := ParseRoles(core.GetEnvOrDefault("CLUSTER_ROLES", ""))
+	if err != nil {
+		return Config{}, err
+	}
+	cfg.Roles = roles
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return Config{}, fmt.Errorf("cluster: invalid CLUSTER_URL: %w", err)
+	}
+	switch u.Scheme {
+	case "redis", "rediss":
+	case "postgres", "postgresql":
+		return Config{}, fmt.Errorf("cluster: Postgres is not supported as a cluster queue yet; use redis://")
+	default:
+		return Config{}, fmt.Errorf("cluster: unsupported CLUSTER_URL scheme %q (use redis:// or rediss://)", u.Scheme)
+	}
+	return cfg, nil
+}
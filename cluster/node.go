@@ -0,0 +1,407 @@
+// Package cluster lets several backend instances share work through a
+// Redis server.
+//
+// Every instance is a node with roles. A node with the monitor role follows
+// its canvas and puts the AI tasks its triggers start on a shared queue; a
+// node with the tasks role runs them, and a node with the image role takes
+// image generation jobs. A deployment can so keep the stream monitor on one
+// host and run the GPU-heavy work on others, and queued tasks survive a
+// crash of the node that queued them.
+// Canvases are partitioned by pointing monitor nodes at different canvases;
+// the instance lease keeps two monitors of one canvas from answering twice.
+//
+// Nodes send heartbeats and elect a leader. The leader puts jobs back on
+// the queue when the node working on them stops sending heartbeats, so a
+// job runs at least once even if a worker crashes.
+//
+// This file contains the Node organism: membership and leader election.
+package cluster
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"go_backend/core/clock"
+)
+
+// Backend is the shared store the nodes coordinate through. Implemented by
+// RedisBackend.
+type Backend interface {
+	// Set sets key to value, expiring after ttl
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	// SetIfAbsent sets key if it does not exist and reports whether it did
+	SetIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// RenewIfEqual resets the expiry of key if it holds value
+	RenewIfEqual(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	// DeleteIfEqual deletes key if it holds value
+	DeleteIfEqual(ctx context.Context, key, value string) error
+	Get(ctx context.Context, key string) (string, bool, error)
+	Delete(ctx context.Context, key string) error
+	Expire(ctx context.Context, key string, ttl time.Duration) error
+	// Keys lists the keys starting with prefix
+	Keys(ctx context.Context, prefix string) ([]string, error)
+
+	// Push adds value to the head of list. Its error wraps ErrNotSent when
+	// value certainly did not reach the list
+	Push(ctx context.Context, list, value string) error
+	// Pop takes a value from the tail of list, waiting up to wait for one
+	Pop(ctx context.Context, list string, wait time.Duration) (string, bool, error)
+	// Move takes a value from the tail of src onto the head of dst,
+	// waiting up to wait for one
+	Move(ctx context.Context, src, dst string, wait time.Duration) (string, bool, error)
+	// Remove removes one occurrence of value from list
+	Remove(ctx context.Context, list, value string) error
+	Range(ctx context.Context, list string) ([]string, error)
+	// Length returns the number of values in list
+	Length(ctx context.Context, list string) (int, error)
+}
+
+// Member is a node of the cluster as other nodes see it.
+type Member struct {
+	ID        string    `json:"id"`
+	Host      string    `json:"host,omitempty"`
+	PID       int       `json:"pid,omitempty"`
+	Roles     []Role    `json:"roles"`
+	CanvasID  string    `json:"canvas_id,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	// Jobs counts the jobs the member ran, as of its last heartbeat
+	Jobs JobStats `json:"jobs"`
+}
+
+// JobStats counts the jobs a node ran.
+type JobStats struct {
+	Running   int       `json:"running"`
+	Completed int       `json:"completed"`
+	Failed    int       `json:"failed"`
+	LastJobAt time.Time `json:"last_job_at,omitempty"`
+}
+
+// add adds the counts of other.
+func (s *JobStats) add(other JobStats) {
+	s.Running += other.Running
+	s.Completed += other.Completed
+	s.Failed += other.Failed
+	if other.LastJobAt.After(s.LastJobAt) {
+		s.LastJobAt = other.LastJobAt
+	}
+}
+
+// HasRole reports whether the member takes on role.
+func (m Member) HasRole(role Role) bool {
+	for _, r := range m.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Status is a snapshot of the node's view of the cluster.
+type Status struct {
+	Name     string   `json:"name"`
+	Node     string   `json:"node"`
+	Roles    []Role   `json:"roles"`
+	Leader   string   `json:"leader,omitempty"`
+	IsLeader bool     `json:"is_leader"`
+	Members  []Member `json:"members"`
+	// Queued is the number of jobs waiting on the queue of each role
+	Queued map[Role]int `json:"queued"`
+	// Jobs adds up the jobs of every member
+	Jobs JobStats `json:"jobs"`
+	// Requeued counts jobs the leader put back after their node left
+	Requeued  int       `json:"requeued,omitempty"`
+	LastCheck time.Time `json:"last_check,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Node is an organism that keeps this instance a member of the cluster.
+//
+// Usage:
+//
+//	node := cluster.New(backend, cfg)
+//	if err := node.Join(ctx); err != nil {
+//	    // the queue server is unreachable
+//	}
+//	go node.Run(ctx) // heartbeats, leader election, requeues orphaned jobs
+//	defer node.Leave()
+//	if node.HasRole(cluster.RoleImage) {
+//	    go node.Serve(ctx, cluster.RoleImage, 2, handler)
+//	}
+type Node struct {
+	backend Backend
+	config  Config
+	self    Member
+	logger  *zap.Logger
+	clock   clock.Clock
+
+	mu     sync.Mutex
+	status Status
+	jobs   JobStats // Jobs run by this node
+}
+
+// New creates a Node. Missing Name, Roles, TTL, ID, Host and PID are filled
+// in with defaults and from the process.
+func New(backend Backend, cfg Config) *Node {
+	if cfg.Name == "" {
+		cfg.Name = "canvuslocallm"
+	}
+	if len(cfg.Roles) == 0 {
+		cfg.Roles = AllRoles
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	if cfg.Host == "" {
+		cfg.Host, _ = os.Hostname()
+	}
+	if cfg.PID == 0 {
+		cfg.PID = os.Getpid()
+	}
+	if cfg.ID == "" {
+		b := make([]byte, 4)
+		rand.Read(b)
+		cfg.ID = fmt.Sprintf("%s-%d-%s", cfg.Host, cfg.PID, hex.EncodeToString(b))
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	self := Member{
+		ID:        cfg.ID,
+		Host:      cfg.Host,
+		PID:       cfg.PID,
+		Roles:     cfg.Roles,
+		CanvasID:  cfg.CanvasID,
+		StartedAt: time.Now().UTC(),
+	}
+	return &Node{
+		backend: backend,
+		config:  cfg,
+		self:    self,
+		logger:  logger,
+		clock:   clock.Real(),
+		status:  Status{Name: cfg.Name, Node: cfg.ID, Roles: cfg.Roles},
+	}
+}
+
+// ID returns the node ID.
+func (n *Node) ID() string {
+	return n.config.ID
+}
+
+// HasRole reports whether this node takes on role. A nil Node, i.e. no
+// cluster, takes on every role.
+func (n *Node) HasRole(role Role) bool {
+	if n == nil {
+		return true
+	}
+	return n.self.HasRole(role)
+}
+
+// HasWorkers reports whether another member took on role at the last
+// heartbeat.
+func (n *Node) HasWorkers(role Role) bool {
+	if n == nil {
+		return false
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, m := range n.status.Members {
+		if m.ID != n.config.ID && m.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns a snapshot of the node's view of the cluster.
+func (n *Node) Status() Status {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	status := n.status
+	status.Members = append([]Member(nil), n.status.Members...)
+	status.Queued = make(map[Role]int, len(n.status.Queued))
+	for role, count := range n.status.Queued {
+		status.Queued[role] = count
+	}
+	return status
+}
+
+// Join announces the node and takes part in one leader election.
+func (n *Node) Join(ctx context.Context) error {
+	err := n.tick(ctx)
+	if err == nil {
+		status := n.Status()
+		n.logger.Info("Joined cluster",
+			zap.String("cluster", n.config.Name),
+			zap.String("node", n.config.ID),
+			zap.Any("roles", n.config.Roles),
+			zap.Int("members", len(status.Members)),
+			zap.String("leader", status.Leader))
+	}
+	return err
+}
+
+// Run sends heartbeats every third of the TTL until ctx is done. The leader
+// also puts back the jobs of nodes that left.
+func (n *Node) Run(ctx context.Context) {
+	ticker := time.NewTicker(n.config.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := n.tick(ctx); err != nil && ctx.Err() == nil {
+				n.logger.Warn("Cluster heartbeat failed", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Leave removes the node from the cluster and gives up the lead, so other
+// nodes do not wait for the TTL.
+func (n *Node) Leave() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := n.backend.DeleteIfEqual(ctx, n.key("leader"), n.config.ID)
+	if delErr := n.backend.Delete(ctx, n.key("node", n.config.ID)); err == nil {
+		err = delErr
+	}
+	return err
+}
+
+// tick sends a heartbeat, takes part in the leader election and refreshes
+// the member list.
+func (n *Node) tick(ctx context.Context) error {
+	err := n.heartbeat(ctx)
+	if err == nil {
+		err = n.refresh(ctx)
+	}
+
+	n.mu.Lock()
+	n.status.LastCheck = n.clock.Now()
+	n.status.LastError = ""
+	if err != nil {
+		n.status.LastError = err.Error()
+	}
+	n.mu.Unlock()
+	return err
+}
+
+// heartbeat renews the membership and the lead, or tries to take the lead.
+func (n *Node) heartbeat(ctx context.Context) error {
+	n.mu.Lock()
+	member := n.self
+	member.Jobs = n.jobs
+	n.mu.Unlock()
+	self, _ := json.Marshal(member)
+	if err := n.backend.Set(ctx, n.key("node", n.config.ID), string(self), n.config.TTL); err != nil {
+		return err
+	}
+
+	leaderKey := n.key("leader")
+	leading, err := n.backend.RenewIfEqual(ctx, leaderKey, n.config.ID, n.config.TTL)
+	if err != nil {
+		return err
+	}
+	if !leading {
+		if leading, err = n.backend.SetIfAbsent(ctx, leaderKey, n.config.ID, n.config.TTL); err != nil {
+			return err
+		}
+	}
+	leader := n.config.ID
+	if !leading {
+		leader, _, err = n.backend.Get(ctx, leaderKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	n.mu.Lock()
+	wasLeading := n.status.IsLeader
+	n.status.Leader = leader
+	n.status.IsLeader = leading
+	n.mu.Unlock()
+	if leading && !wasLeading {
+		n.logger.Info("Leading the cluster", zap.String("node", n.config.ID))
+	}
+	return nil
+}
+
+// refresh reloads the member list and queue lengths; the leader then
+// requeues orphaned jobs.
+func (n *Node) refresh(ctx context.Context) error {
+	members, err := n.members(ctx)
+	if err != nil {
+		return err
+	}
+	queued := make(map[Role]int, len(AllRoles))
+	for _, role := range []Role{RoleImage, RoleTasks} {
+		if queued[role], err = n.backend.Length(ctx, n.key("queue", string(role))); err != nil {
+			return err
+		}
+	}
+	var jobs JobStats
+	for _, m := range members {
+		jobs.add(m.Jobs)
+	}
+
+	n.mu.Lock()
+	n.status.Members = members
+	n.status.Queued = queued
+	n.status.Jobs = jobs
+	leading := n.status.IsLeader
+	n.mu.Unlock()
+
+	if !leading {
+		return nil
+	}
+	requeued, err := n.requeueOrphans(ctx, members)
+	if requeued > 0 {
+		n.mu.Lock()
+		n.status.Requeued += requeued
+		n.mu.Unlock()
+	}
+	return err
+}
+
+// members lists the live members, ordered by ID.
+func (n *Node) members(ctx context.Context) ([]Member, error) {
+	prefix := n.key("node", "")
+	keys, err := n.backend.Keys(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var members []Member
+	for _, key := range keys {
+		value, ok, err := n.backend.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		var m Member
+		if !ok || json.Unmarshal([]byte(value), &m) != nil || m.ID == "" {
+			continue
+		}
+		members = append(members, m)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+	return members, nil
+}
+
+// key joins parts into a key under the cluster name.
+func (n *Node) key(parts ...string) string {
+	return n.config.Name + ":" + strings.Join(parts, ":")
+}
@@ -0,0 +1,319 @@
+package cluster
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// memBackend is an in-memory Backend shared by the nodes under test. Keys
+// expire on the shared test clock.
+type memBackend struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	values  map[string]string
+	expires map[string]time.Time
+	lists   map[string][]string
+	pushed  chan struct{}
+}
+
+func newMemBackend(c clock.Clock) *memBackend {
+	return &memBackend{
+		clock:   c,
+		values:  make(map[string]string),
+		expires: make(map[string]time.Time),
+		lists:   make(map[string][]string),
+		pushed:  make(chan struct{}, 100),
+	}
+}
+
+// live drops key if it expired. The caller holds b.mu.
+func (b *memBackend) live(key string) bool {
+	if at, ok := b.expires[key]; ok && !b.clock.Now().Before(at) {
+		delete(b.values, key)
+		delete(b.expires, key)
+		delete(b.lists, key)
+	}
+	_, isValue := b.values[key]
+	return isValue || len(b.lists[key]) > 0
+}
+
+func (b *memBackend) Set(_ context.Context, key, value string, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.values[key] = value
+	b.expires[key] = b.clock.Now().Add(ttl)
+	return nil
+}
+
+func (b *memBackend) SetIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	if b.live(key) {
+		b.mu.Unlock()
+		return false, nil
+	}
+	b.mu.Unlock()
+	return true, b.Set(ctx, key, value, ttl)
+}
+
+func (b *memBackend) RenewIfEqual(_ context.Context, key, value string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.live(key) || b.values[key] != value {
+		return false, nil
+	}
+	b.expires[key] = b.clock.Now().Add(ttl)
+	return true, nil
+}
+
+func (b *memBackend) DeleteIfEqual(ctx context.Context, key, value string) error {
+	b.mu.Lock()
+	equal := b.live(key) && b.values[key] == value
+	b.mu.Unlock()
+	if equal {
+		return b.Delete(ctx, key)
+	}
+	return nil
+}
+
+func (b *memBackend) Get(_ context.Context, key string) (string, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.live(key) {
+		return "", false, nil
+	}
+	v, ok := b.values[key]
+	return v, ok, nil
+}
+
+func (b *memBackend) Delete(_ context.Context, key string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.values, key)
+	delete(b.expires, key)
+	delete(b.lists, key)
+	return nil
+}
+
+func (b *memBackend) Expire(_ context.Context, key string, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expires[key] = b.clock.Now().Add(ttl)
+	return nil
+}
+
+func (b *memBackend) Keys(_ context.Context, prefix string) ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var keys []string
+	for key := range b.values {
+		if strings.HasPrefix(key, prefix) && b.live(key) {
+			keys = append(keys, key)
+		}
+	}
+	for key := range b.lists {
+		if strings.HasPrefix(key, prefix) && b.live(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (b *memBackend) Push(_ context.Context, list, value string) error {
+	b.mu.Lock()
+	b.lists[list] = append([]string{value}, b.lists[list]...)
+	b.mu.Unlock()
+	select {
+	case b.pushed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// take removes the tail of list. The caller holds b.mu.
+func (b *memBackend) take(list string) (string, bool) {
+	if !b.live(list) {
+		return "", false
+	}
+	items := b.lists[list]
+	value := items[len(items)-1]
+	b.lists[list] = items[:len(items)-1]
+	return value, true
+}
+
+// wait polls for a value on list until wait passes in real time.
+func (b *memBackend) wait(ctx context.Context, list string, wait time.Duration, take func() (string, bool)) (string, bool, error) {
+	deadline := time.After(wait)
+	for {
+		if value, ok := take(); ok {
+			return value, true, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", false, ctx.Err()
+		case <-deadline:
+			return "", false, nil
+		case <-b.pushed:
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func (b *memBackend) Pop(ctx context.Context, list string, wait time.Duration) (string, bool, error) {
+	return b.wait(ctx, list, wait, func() (string, bool) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return b.take(list)
+	})
+}
+
+func (b *memBackend) Move(ctx context.Context, src, dst string, wait time.Duration) (string, bool, error) {
+	return b.wait(ctx, src, wait, func() (string, bool) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		value, ok := b.take(src)
+		if ok {
+			b.lists[dst] = append([]string{value}, b.lists[dst]...)
+		}
+		return value, ok
+	})
+}
+
+func (b *memBackend) Remove(_ context.Context, list, value string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	items := b.lists[list]
+	for i, item := range items {
+		if item == value {
+			b.lists[list] = append(items[:i:i], items[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (b *memBackend) Range(_ context.Context, list string) ([]string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]string(nil), b.lists[list]...), nil
+}
+
+func (b *memBackend) Length(_ context.Context, list string) (int, error) {
+	return b.length(list), nil
+}
+
+func (b *memBackend) length(list string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.lists[list])
+}
+
+// newTestCluster returns a backend and the clock shared by the nodes under
+// test.
+func newTestCluster() (*memBackend, *clock.Fake) {
+	fake := clock.NewFake(time.Unix(1000, 0))
+	return newMemBackend(fake), fake
+}
+
+func newTestNode(backend Backend, fake *clock.Fake, id string, roles ...Role) *Node {
+	n := New(backend, Config{Name: "test", ID: id, Roles: roles, TTL: 15 * time.Second, Host: "host", PID: 1})
+	n.clock = fake
+	return n
+}
+
+func TestLeaderElection(t *testing.T) {
+	backend, fake := newTestCluster()
+	ctx := context.Background()
+	a := newTestNode(backend, fake, "a")
+	b := newTestNode(backend, fake, "b")
+
+	if err := a.Join(ctx); err != nil {
+		t.Fatalf("a.Join: %v", err)
+	}
+	if err := b.Join(ctx); err != nil {
+		t.Fatalf("b.Join: %v", err)
+	}
+	if s := a.Status(); !s.IsLeader || s.Leader != "a" {
+		t.Errorf("a status = %+v, want leader", s)
+	}
+	if s := b.Status(); s.IsLeader || s.Leader != "a" || len(s.Members) != 2 {
+		t.Errorf("b status = %+v, want follower of a with 2 members", s)
+	}
+
+	// The leader keeps the lead while it sends heartbeats
+	for i := 0; i < 3; i++ {
+		fake.Advance(5 * time.Second)
+		a.tick(ctx)
+		b.tick(ctx)
+	}
+	if !a.Status().IsLeader || b.Status().IsLeader {
+		t.Error("lead changed while the leader sent heartbeats")
+	}
+
+	// a stops sending heartbeats; b takes over after the TTL
+	fake.Advance(16 * time.Second)
+	b.tick(ctx)
+	if s := b.Status(); !s.IsLeader || len(s.Members) != 1 {
+		t.Errorf("b status = %+v, want leader and sole member", s)
+	}
+}
+
+func TestLeaveHandsOverLead(t *testing.T) {
+	backend, fake := newTestCluster()
+	ctx := context.Background()
+	a := newTestNode(backend, fake, "a")
+	b := newTestNode(backend, fake, "b")
+	a.Join(ctx)
+	b.Join(ctx)
+
+	if err := a.Leave(); err != nil {
+		t.Fatalf("Leave: %v", err)
+	}
+	b.tick(ctx)
+	if s := b.Status(); !s.IsLeader || len(s.Members) != 1 {
+		t.Errorf("b status = %+v, want leader and sole member right after a left", s)
+	}
+}
+
+func TestNilNodeHasEveryRole(t *testing.T) {
+	var n *Node
+	if !n.HasRole(RoleMonitor) || !n.HasRole(RoleImage) || n.HasWorkers(RoleImage) {
+		t.Error("nil node: want every role and no workers")
+	}
+}
+
+func TestParseRoles(t *testing.T) {
+	roles, err := ParseRoles(" Image , image")
+	if err != nil || len(roles) != 1 || roles[0] != RoleImage {
+		t.Errorf("ParseRoles = %v, %v; want [image]", roles, err)
+	}
+	if roles, err := ParseRoles(""); err != nil || len(roles) != len(AllRoles) {
+		t.Errorf("ParseRoles(\"\") = %v, %v; want every role", roles, err)
+	}
+	if _, err := ParseRoles("monitor,gpu"); err == nil {
+		t.Error("ParseRoles accepted an unknown role")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CLUSTER_URL", "")
+	if cfg, err := ConfigFromEnv(); err != nil || cfg.Enabled() {
+		t.Errorf("without CLUSTER_URL: %+v, %v; want disabled", cfg, err)
+	}
+
+	t.Setenv("CLUSTER_URL", "redis://queue.local:6380/2")
+	t.Setenv("CLUSTER_ROLES", "image")
+	cfg, err := ConfigFromEnv()
+	if err != nil || !cfg.Enabled() || len(cfg.Roles) != 1 || cfg.Roles[0] != RoleImage {
+		t.Errorf("ConfigFromEnv = %+v, %v", cfg, err)
+	}
+
+	t.Setenv("CLUSTER_URL", "postgres://db.local/queue")
+	if cfg, err := ConfigFromEnv(); err == nil || cfg.Enabled() {
+		t.Errorf("postgres URL: %+v, %v; want an error with clustering disabled", cfg, err)
+	}
+}
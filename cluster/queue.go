@@ -0,0 +1,283 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// MaxAttempts is how often a job is handed out before it fails; a job is
+// handed out again when its node leaves while working on it.
+const MaxAttempts = 3
+
+// resultTTL is how long an unclaimed result is kept.
+const resultTTL = 10 * time.Minute
+
+// pollInterval bounds how long a blocking queue read waits, so Submit and
+// Serve notice a cancelled context.
+const pollInterval = 2 * time.Second
+
+// ErrNoWorkers is returned by Submit when no other node takes on the role.
+var ErrNoWorkers = errors.New("cluster: no node takes on this role")
+
+// ErrNotSent is wrapped by the errors of requests that never reached the
+// queue server, e.g. because it could not be reached. A job whose Enqueue
+// or Submit fails with it is certainly not queued; after any other error
+// it may be.
+var ErrNotSent = errors.New("cluster: not sent to the queue server")
+
+// Job is a unit of work on the queue of a role.
+type Job struct {
+	ID          string          `json:"id"`
+	Role        Role            `json:"role"`
+	Payload     json.RawMessage `json:"payload"`
+	SubmittedBy string          `json:"submitted_by"`
+	SubmittedAt time.Time       `json:"submitted_at"`
+	Attempts    int             `json:"attempts"`
+	// Detached jobs send no result; the submitter does not wait for them
+	Detached bool `json:"detached,omitempty"`
+}
+
+// Result is a job's outcome, sent back to the submitting node.
+type Result struct {
+	JobID   string          `json:"job_id"`
+	Worker  string          `json:"worker"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Handler runs a job and returns its result payload.
+type Handler func(ctx context.Context, job Job) (interface{}, error)
+
+// Submit queues payload for a node with role and waits until ctx is done
+// for the result, which is decoded into out. It returns ErrNoWorkers if no
+// other node takes on role.
+func (n *Node) Submit(ctx context.Context, role Role, payload, out interface{}) error {
+	if !n.HasWorkers(role) {
+		return ErrNoWorkers
+	}
+	job, err := n.push(ctx, role, payload, false)
+	if err != nil {
+		return err
+	}
+
+	resultKey := n.key("result", job.ID)
+	for {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("cluster: waiting for %s job %s: %w", role, job.ID, err)
+		}
+		value, ok, err := n.backend.Pop(ctx, resultKey, pollInterval)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		var result Result
+		if err := json.Unmarshal([]byte(value), &result); err != nil {
+			return fmt.Errorf("cluster: decode result: %w", err)
+		}
+		if result.Error != "" {
+			return fmt.Errorf("cluster: %s job failed on %s: %s", role, result.Worker, result.Error)
+		}
+		if out == nil || len(result.Payload) == 0 {
+			return nil
+		}
+		return json.Unmarshal(result.Payload, out)
+	}
+}
+
+// Enqueue queues payload for a node with role, this one included, and
+// returns the job ID without waiting for the job to run. The job stays on
+// the queue until a node takes it, even if this node stops. An error
+// wrapping ErrNotSent means the job is certainly not queued.
+func (n *Node) Enqueue(ctx context.Context, role Role, payload interface{}) (string, error) {
+	job, err := n.push(ctx, role, payload, true)
+	return job.ID, err
+}
+
+// push puts a new job with payload on the queue of role.
+func (n *Node) push(ctx context.Context, role Role, payload interface{}, detached bool) (Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, fmt.Errorf("%w: encode job: %w", ErrNotSent, err)
+	}
+	job := Job{
+		ID:          uuid.New().String(),
+		Role:        role,
+		Payload:     data,
+		SubmittedBy: n.config.ID,
+		SubmittedAt: n.clock.Now().UTC(),
+		Detached:    detached,
+	}
+	encoded, _ := json.Marshal(job)
+	if err := n.backend.Push(ctx, n.key("queue", string(role)), string(encoded)); err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// Serve takes jobs for role from the queue and runs them with handler, up
+// to concurrency at a time, until ctx is done. A job stays on this node's
+// work list while it runs, so the leader can hand it out again if this node
+// dies.
+func (n *Node) Serve(ctx context.Context, role Role, concurrency int, handler Handler) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	n.logger.Info("Serving cluster jobs", zap.String("role", string(role)), zap.Int("concurrency", concurrency))
+
+	done := make(chan struct{})
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for ctx.Err() == nil {
+				if !n.serveOne(ctx, role, handler) {
+					// Back off while the queue server is unreachable
+					select {
+					case <-ctx.Done():
+					case <-time.After(pollInterval):
+					}
+				}
+			}
+		}()
+	}
+	for i := 0; i < concurrency; i++ {
+		<-done
+	}
+}
+
+// serveOne waits for one job and runs it. It reports false if the queue
+// could not be read.
+func (n *Node) serveOne(ctx context.Context, role Role, handler Handler) bool {
+	working := n.key("working", n.config.ID)
+	value, ok, err := n.backend.Move(ctx, n.key("queue", string(role)), working, pollInterval)
+	if err != nil {
+		if ctx.Err() == nil {
+			n.logger.Warn("Failed to read cluster queue", zap.String("role", string(role)), zap.Error(err))
+		}
+		return false
+	}
+	if !ok {
+		return true
+	}
+
+	// Finish with a fresh context: the result must reach the submitter
+	// even while this node shuts down
+	finish, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	defer n.backend.Remove(finish, working, value)
+
+	var job Job
+	if err := json.Unmarshal([]byte(value), &job); err != nil {
+		n.logger.Warn("Dropping malformed cluster job", zap.Error(err))
+		return true
+	}
+
+	start := n.clock.Now()
+	n.countJob(1, 0, 0)
+	result := Result{JobID: job.ID, Worker: n.config.ID}
+	payload, err := handler(ctx, job)
+	if err == nil {
+		result.Payload, err = json.Marshal(payload)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		n.countJob(-1, 0, 1)
+	} else {
+		n.countJob(-1, 1, 0)
+	}
+	n.logger.Info("Cluster job finished",
+		zap.String("job_id", job.ID),
+		zap.String("role", string(job.Role)),
+		zap.String("submitted_by", job.SubmittedBy),
+		zap.Duration("duration", n.clock.Since(start)),
+		zap.String("error", result.Error))
+	if !job.Detached {
+		n.reply(finish, result)
+	}
+	return true
+}
+
+// countJob adjusts this node's job counts.
+func (n *Node) countJob(running, completed, failed int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.jobs.Running += running
+	n.jobs.Completed += completed
+	n.jobs.Failed += failed
+	n.jobs.LastJobAt = n.clock.Now()
+}
+
+// reply sends result to the node that submitted the job.
+func (n *Node) reply(ctx context.Context, result Result) {
+	encoded, _ := json.Marshal(result)
+	key := n.key("result", result.JobID)
+	if err := n.backend.Push(ctx, key, string(encoded)); err != nil {
+		n.logger.Warn("Failed to send cluster job result", zap.String("job_id", result.JobID), zap.Error(err))
+		return
+	}
+	if err := n.backend.Expire(ctx, key, resultTTL); err != nil {
+		n.logger.Debug("Failed to expire cluster job result", zap.String("job_id", result.JobID), zap.Error(err))
+	}
+}
+
+// requeueOrphans puts the jobs of nodes that are no longer members back on
+// their queues, or fails them after MaxAttempts. It returns how many were
+// put back.
+func (n *Node) requeueOrphans(ctx context.Context, members []Member) (int, error) {
+	live := make(map[string]bool, len(members))
+	for _, m := range members {
+		live[m.ID] = true
+	}
+	prefix := n.key("working", "")
+	lists, err := n.backend.Keys(ctx, prefix)
+	if err != nil {
+		return 0, err
+	}
+
+	requeued := 0
+	for _, list := range lists {
+		owner := strings.TrimPrefix(list, prefix)
+		if live[owner] {
+			continue
+		}
+		values, err := n.backend.Range(ctx, list)
+		if err != nil {
+			return requeued, err
+		}
+		for _, value := range values {
+			var job Job
+			if json.Unmarshal([]byte(value), &job) != nil {
+				continue
+			}
+			job.Attempts++
+			if job.Attempts >= MaxAttempts {
+				n.logger.Warn("Cluster job failed on every attempt",
+					zap.String("job_id", job.ID), zap.Int("attempts", job.Attempts))
+				if !job.Detached {
+					n.reply(ctx, Result{JobID: job.ID, Worker: owner,
+						Error: fmt.Sprintf("its node left the cluster %d times", job.Attempts)})
+				}
+				continue
+			}
+			encoded, _ := json.Marshal(job)
+			if err := n.backend.Push(ctx, n.key("queue", string(job.Role)), string(encoded)); err != nil {
+				return requeued, err
+			}
+			requeued++
+		}
+		if err := n.backend.Delete(ctx, list); err != nil {
+			return requeued, err
+		}
+		n.logger.Warn("Requeued the jobs of a node that left the cluster",
+			zap.String("node", owner), zap.Int("jobs", len(values)))
+	}
+	return requeued, nil
+}
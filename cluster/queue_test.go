@@ -0,0 +1,182 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+type echoPayload struct {
+	Text string `json:"text"`
+}
+
+func TestSubmitRunsJobOnWorker(t *testing.T) {
+	backend, fake := newTestCluster()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	monitor := newTestNode(backend, fake, "monitor", RoleMonitor)
+	gpu := newTestNode(backend, fake, "gpu", RoleImage)
+	gpu.Join(ctx)
+	monitor.Join(ctx)
+
+	go gpu.Serve(ctx, RoleImage, 2, func(ctx context.Context, job Job) (interface{}, error) {
+		if job.SubmittedBy != "monitor" {
+			t.Errorf("job submitted by %q, want monitor", job.SubmittedBy)
+		}
+		return echoPayload{Text: strings.ToUpper(string(job.Payload))}, nil
+	})
+
+	var out echoPayload
+	if err := monitor.Submit(ctx, RoleImage, echoPayload{Text: "cat"}, &out); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if out.Text != `{"TEXT":"CAT"}` {
+		t.Errorf("result = %q", out.Text)
+	}
+	if n := backend.length("test:working:gpu"); n != 0 {
+		t.Errorf("%d jobs left on the work list", n)
+	}
+}
+
+func TestSubmitReportsWorkerError(t *testing.T) {
+	backend, fake := newTestCluster()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	monitor := newTestNode(backend, fake, "monitor", RoleMonitor)
+	gpu := newTestNode(backend, fake, "gpu", RoleImage)
+	gpu.Join(ctx)
+	monitor.Join(ctx)
+
+	go gpu.Serve(ctx, RoleImage, 1, func(context.Context, Job) (interface{}, error) {
+		return nil, errors.New("out of VRAM")
+	})
+	err := monitor.Submit(ctx, RoleImage, echoPayload{Text: "cat"}, nil)
+	if err == nil || !strings.Contains(err.Error(), "out of VRAM") || !strings.Contains(err.Error(), "gpu") {
+		t.Errorf("Submit = %v, want the worker's error", err)
+	}
+}
+
+func TestSubmitWithoutWorkers(t *testing.T) {
+	backend, fake := newTestCluster()
+	monitor := newTestNode(backend, fake, "monitor")
+	monitor.Join(context.Background())
+
+	// The node's own image role does not count: it generates locally
+	if err := monitor.Submit(context.Background(), RoleImage, echoPayload{}, nil); !errors.Is(err, ErrNoWorkers) {
+		t.Errorf("Submit = %v, want ErrNoWorkers", err)
+	}
+}
+
+func TestLeaderRequeuesJobsOfDeadWorker(t *testing.T) {
+	backend, fake := newTestCluster()
+	ctx := context.Background()
+	leader := newTestNode(backend, fake, "leader", RoleMonitor)
+	worker := newTestNode(backend, fake, "worker", RoleImage)
+	leader.Join(ctx)
+	worker.Join(ctx)
+
+	// The worker takes a job and dies
+	backend.Push(ctx, "test:queue:image", `{"id":"job-1","role":"image","payload":{}}`)
+	if _, ok, _ := backend.Move(ctx, "test:queue:image", "test:working:worker", time.Second); !ok {
+		t.Fatal("worker did not take the job")
+	}
+	leader.tick(ctx)
+	if backend.length("test:queue:image") != 0 {
+		t.Fatal("job requeued while its worker is alive")
+	}
+
+	fake.Advance(16 * time.Second)
+	leader.tick(ctx)
+	if backend.length("test:queue:image") != 1 || backend.length("test:working:worker") != 0 {
+		t.Errorf("queue %d, work list %d; want the job back on the queue",
+			backend.length("test:queue:image"), backend.length("test:working:worker"))
+	}
+	if s := leader.Status(); s.Requeued != 1 {
+		t.Errorf("requeued = %d, want 1", s.Requeued)
+	}
+}
+
+func TestRequeueGivesUpAfterMaxAttempts(t *testing.T) {
+	backend, fake := newTestCluster()
+	ctx := context.Background()
+	leader := newTestNode(backend, fake, "leader", RoleMonitor)
+	leader.Join(ctx)
+
+	backend.Push(ctx, "test:working:gone", `{"id":"job-1","role":"image","payload":{},"attempts":2}`)
+	leader.tick(ctx)
+	if backend.length("test:queue:image") != 0 {
+		t.Error("job requeued after its last attempt")
+	}
+	if backend.length("test:result:job-1") != 1 {
+		t.Error("submitter not told the job failed")
+	}
+}
+
+func TestEnqueueSurvivesSubmitterAndCountsJobs(t *testing.T) {
+	backend, fake := newTestCluster()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	monitor := newTestNode(backend, fake, "monitor", RoleMonitor)
+	monitor.Join(ctx)
+
+	// Queued while no worker is up; the monitor then goes away
+	for i := 0; i < 2; i++ {
+		if _, err := monitor.Enqueue(ctx, RoleTasks, echoPayload{Text: "note"}); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+	monitor.tick(ctx)
+	if q := monitor.Status().Queued[RoleTasks]; q != 2 {
+		t.Errorf("queued = %d, want 2", q)
+	}
+	monitor.Leave()
+
+	worker := newTestNode(backend, fake, "worker", RoleTasks)
+	worker.Join(ctx)
+	ran := make(chan string, 2)
+	serveCtx, stop := context.WithCancel(ctx)
+	jobs := 0
+	go worker.Serve(serveCtx, RoleTasks, 1, func(_ context.Context, job Job) (interface{}, error) {
+		ran <- job.SubmittedBy
+		if jobs++; jobs == 2 {
+			return nil, errors.New("model not loaded")
+		}
+		return nil, nil
+	})
+	for i := 0; i < 2; i++ {
+		select {
+		case by := <-ran:
+			if by != "monitor" {
+				t.Errorf("job submitted by %q", by)
+			}
+		case <-ctx.Done():
+			t.Fatal("queued jobs never ran")
+		}
+	}
+	stop()
+
+	// Detached jobs leave no results behind
+	waitFor(t, func() bool {
+		return backend.length("test:working:worker") == 0 && worker.Status().Jobs.Running == 0
+	})
+	if keys, _ := backend.Keys(ctx, "test:result:"); len(keys) != 0 {
+		t.Errorf("results %v left for detached jobs", keys)
+	}
+	worker.tick(ctx)
+	if s := worker.Status(); s.Jobs.Completed != 1 || s.Jobs.Failed != 1 || s.Jobs.Running != 0 || s.Queued[RoleTasks] != 0 {
+		t.Errorf("status = %+v, want 1 completed and 1 failed", s)
+	}
+}
+
+// waitFor polls cond until it holds or a second passes.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatal("condition not met within a second")
+}
@@ -0,0 +1,427 @@
+package cluster
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisError is an error reply from the Redis server. The connection is
+// still usable after one.
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// redisConn is one connection speaking the Redis protocol (RESP2).
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+}
+
+// do sends a command and reads its reply: a string, int64, nil, []interface{}
+// or redisError.
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	fmt.Fprintf(c.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(c.r)
+}
+
+// readReply reads one RESP2 reply.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return redisError(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: bad bulk length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("redis: bad array length %q", line)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected reply %q", line)
+	}
+}
+
+// redisOptions is a parsed redis:// or rediss:// URL.
+type redisOptions struct {
+	addr     string
+	username string
+	password string
+	db       int
+	tls      bool
+}
+
+// parseRedisURL parses redis://[user:password@]host[:port][/db]; rediss://
+// connects with TLS.
+func parseRedisURL(raw string) (redisOptions, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redisOptions{}, fmt.Errorf("cluster: invalid CLUSTER_URL: %w", err)
+	}
+	opts := redisOptions{addr: u.Host, tls: u.Scheme == "rediss"}
+	if u.Scheme != "redis" && u.Scheme != "rediss" {
+		return redisOptions{}, fmt.Errorf("cluster: unsupported CLUSTER_URL scheme %q (use redis:// or rediss://)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return redisOptions{}, fmt.Errorf("cluster: CLUSTER_URL has no host")
+	}
+	if u.Port() == "" {
+		opts.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		opts.password, _ = u.User.Password()
+		if opts.password == "" {
+			// redis://password@host is a common shorthand
+			opts.password = u.User.Username()
+		} else {
+			opts.username = u.User.Username()
+		}
+	}
+	if db := strings.Trim(u.Path, "/"); db != "" {
+		if opts.db, err = strconv.Atoi(db); err != nil {
+			return redisOptions{}, fmt.Errorf("cluster: invalid database %q in CLUSTER_URL", db)
+		}
+	}
+	return opts, nil
+}
+
+// redisClient is a small pool of Redis connections.
+type redisClient struct {
+	opts    redisOptions
+	timeout time.Duration
+
+	mu   sync.Mutex
+	idle []*redisConn
+}
+
+// maxIdleConns is how many idle connections the pool keeps.
+const maxIdleConns = 4
+
+func newRedisClient(opts redisOptions, timeout time.Duration) *redisClient {
+	return &redisClient{opts: opts, timeout: timeout}
+}
+
+// dial opens and authenticates a connection.
+func (c *redisClient) dial(ctx context.Context) (*redisConn, error) {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	var conn net.Conn
+	var err error
+	if c.opts.tls {
+		host, _, _ := net.SplitHostPort(c.opts.addr)
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", c.opts.addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", c.opts.addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cluster: connect to %s: %w", c.opts.addr, err)
+	}
+
+	rc := &redisConn{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+	conn.SetDeadline(time.Now().Add(c.timeout))
+	var setup [][]string
+	switch {
+	case c.opts.username != "":
+		setup = append(setup, []string{"AUTH", c.opts.username, c.opts.password})
+	case c.opts.password != "":
+		setup = append(setup, []string{"AUTH", c.opts.password})
+	}
+	if c.opts.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(c.opts.db)})
+	}
+	for _, args := range setup {
+		reply, err := rc.do(args...)
+		if err == nil {
+			if rerr, ok := reply.(redisError); ok {
+				err = rerr
+			}
+		}
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("cluster: %s: %w", args[0], err)
+		}
+	}
+	return rc, nil
+}
+
+// Do runs a command on a pooled connection. block is how long a blocking
+// command may wait on the server, on top of the client timeout. Errors from
+// before the command is written wrap ErrNotSent; after that the server may
+// have run it.
+func (c *redisClient) Do(ctx context.Context, block time.Duration, args ...string) (interface{}, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNotSent, err)
+	}
+	if err := ctx.Err(); err != nil {
+		c.put(conn)
+		return nil, fmt.Errorf("%w: %s: %w", ErrNotSent, args[0], err)
+	}
+	deadline := time.Now().Add(c.timeout + block)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) && block == 0 {
+		deadline = d
+	}
+	conn.conn.SetDeadline(deadline)
+
+	// Closing the connection when ctx is done ends a blocking BRPOP or
+	// BRPOPLPUSH at shutdown instead of after its wait
+	stop := context.AfterFunc(ctx, func() { conn.conn.Close() })
+	reply, err := conn.do(args...)
+	if !stop() {
+		return nil, fmt.Errorf("cluster: %s: %w", args[0], ctx.Err())
+	}
+	if err != nil {
+		conn.conn.Close()
+		return nil, fmt.Errorf("cluster: %s: %w", args[0], err)
+	}
+	c.put(conn)
+	if rerr, ok := reply.(redisError); ok {
+		return nil, fmt.Errorf("cluster: %s: %w", args[0], rerr)
+	}
+	return reply, nil
+}
+
+func (c *redisClient) get(ctx context.Context) (*redisConn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		conn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return conn, nil
+	}
+	c.mu.Unlock()
+	return c.dial(ctx)
+}
+
+func (c *redisClient) put(conn *redisConn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.idle) >= maxIdleConns {
+		conn.conn.Close()
+		return
+	}
+	c.idle = append(c.idle, conn)
+}
+
+// Close closes the idle connections.
+func (c *redisClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, conn := range c.idle {
+		conn.conn.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+// RedisBackend is the Backend on a Redis server (5.0 or later).
+type RedisBackend struct {
+	client *redisClient
+}
+
+// NewRedisBackend connects to the Redis server at rawURL
+// (redis://[user:password@]host[:port][/db], or rediss:// for TLS) and
+// checks it answers.
+func NewRedisBackend(ctx context.Context, rawURL string, timeout time.Duration) (*RedisBackend, error) {
+	opts, err := parseRedisURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	b := &RedisBackend{client: newRedisClient(opts, timeout)}
+	if _, err := b.client.Do(ctx, 0, "PING"); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Close closes the connections to the server.
+func (b *RedisBackend) Close() error {
+	return b.client.Close()
+}
+
+// ttlMillis formats ttl for PX and PEXPIRE.
+func ttlMillis(ttl time.Duration) string {
+	return strconv.FormatInt(ttl.Milliseconds(), 10)
+}
+
+// blockSeconds formats wait for BRPOP and BRPOPLPUSH; 0 would block forever.
+func blockSeconds(wait time.Duration) string {
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return strconv.Itoa(int(wait / time.Second))
+}
+
+// Lua scripts comparing a key's value before changing it, so only the
+// owner renews or drops a lease.
+const (
+	renewIfEqualScript  = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`
+	deleteIfEqualScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+)
+
+func (b *RedisBackend) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	_, err := b.client.Do(ctx, 0, "SET", key, value, "PX", ttlMillis(ttl))
+	return err
+}
+
+func (b *RedisBackend) SetIfAbsent(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	reply, err := b.client.Do(ctx, 0, "SET", key, value, "NX", "PX", ttlMillis(ttl))
+	return reply != nil, err
+}
+
+func (b *RedisBackend) RenewIfEqual(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	reply, err := b.client.Do(ctx, 0, "EVAL", renewIfEqualScript, "1", key, value, ttlMillis(ttl))
+	n, _ := reply.(int64)
+	return n == 1, err
+}
+
+func (b *RedisBackend) DeleteIfEqual(ctx context.Context, key, value string) error {
+	_, err := b.client.Do(ctx, 0, "EVAL", deleteIfEqualScript, "1", key, value)
+	return err
+}
+
+func (b *RedisBackend) Get(ctx context.Context, key string) (string, bool, error) {
+	reply, err := b.client.Do(ctx, 0, "GET", key)
+	if err != nil || reply == nil {
+		return "", false, err
+	}
+	s, _ := reply.(string)
+	return s, true, nil
+}
+
+func (b *RedisBackend) Delete(ctx context.Context, key string) error {
+	_, err := b.client.Do(ctx, 0, "DEL", key)
+	return err
+}
+
+func (b *RedisBackend) Expire(ctx context.Context, key string, ttl time.Duration) error {
+	_, err := b.client.Do(ctx, 0, "PEXPIRE", key, ttlMillis(ttl))
+	return err
+}
+
+func (b *RedisBackend) Keys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	cursor := "0"
+	for {
+		reply, err := b.client.Do(ctx, 0, "SCAN", cursor, "MATCH", prefix+"*", "COUNT", "100")
+		if err != nil {
+			return nil, err
+		}
+		page, ok := reply.([]interface{})
+		if !ok || len(page) != 2 {
+			return nil, errors.New("cluster: SCAN: unexpected reply")
+		}
+		cursor, _ = page[0].(string)
+		items, _ := page[1].([]interface{})
+		for _, item := range items {
+			if key, ok := item.(string); ok {
+				keys = append(keys, key)
+			}
+		}
+		if cursor == "0" || cursor == "" {
+			return keys, nil
+		}
+	}
+}
+
+func (b *RedisBackend) Push(ctx context.Context, list, value string) error {
+	_, err := b.client.Do(ctx, 0, "LPUSH", list, value)
+	return err
+}
+
+func (b *RedisBackend) Pop(ctx context.Context, list string, wait time.Duration) (string, bool, error) {
+	reply, err := b.client.Do(ctx, wait, "BRPOP", list, blockSeconds(wait))
+	if err != nil || reply == nil {
+		return "", false, err
+	}
+	pair, _ := reply.([]interface{})
+	if len(pair) != 2 {
+		return "", false, errors.New("cluster: BRPOP: unexpected reply")
+	}
+	value, _ := pair[1].(string)
+	return value, true, nil
+}
+
+func (b *RedisBackend) Move(ctx context.Context, src, dst string, wait time.Duration) (string, bool, error) {
+	reply, err := b.client.Do(ctx, wait, "BRPOPLPUSH", src, dst, blockSeconds(wait))
+	if err != nil || reply == nil {
+		return "", false, err
+	}
+	value, _ := reply.(string)
+	return value, true, nil
+}
+
+func (b *RedisBackend) Remove(ctx context.Context, list, value string) error {
+	_, err := b.client.Do(ctx, 0, "LREM", list, "1", value)
+	return err
+}
+
+func (b *RedisBackend) Range(ctx context.Context, list string) ([]string, error) {
+	reply, err := b.client.Do(ctx, 0, "LRANGE", list, "0", "-1")
+	if err != nil {
+		return nil, err
+	}
+	items, _ := reply.([]interface{})
+	values := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			values = append(values, s)
+		}
+	}
+	return values, nil
+}
+
+func (b *RedisBackend) Length(ctx context.Context, list string) (int, error) {
+	reply, err := b.client.Do(ctx, 0, "LLEN", list)
+	n, _ := reply.(int64)
+	return int(n), err
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"go_backend/cluster"
+	"go_backend/core"
+	"go_backend/imagegen"
+	"go_backend/logging"
+
+	"go.uber.org/zap"
+)
+
+// imageJob is an image generation handed to an image node of the cluster.
+type imageJob struct {
+	CanvasID string                `json:"canvas_id"`
+	Prompt   string                `json:"prompt"`
+	Parent   imagegen.CanvasWidget `json:"parent"`
+	Options  imagegen.ImageOptions `json:"options"`
+}
+
+// generateImageFunc generates the images a trigger widget asks for with
+// the options of its inline flags and places them on the canvas.
+type generateImageFunc func(ctx context.Context, prompt string, parent imagegen.ParentWidget, options imagegen.ImageOptions) (*imagegen.ProcessResult, error)
+
+// imageGenerator returns how this instance generates images on canvasID:
+// with its own SD runtime when it has the image role, otherwise on an image
+// node of the cluster. It returns nil when neither is available.
+func (m *Monitor) imageGenerator(canvasID string) generateImageFunc {
+	node := m.getCluster()
+	proc := m.getImagegenProcessor()
+	if proc != nil && canvasID != m.client.CanvasID {
+		proc = proc.ForCanvas(canvasID)
+	}
+	if proc != nil && node.HasRole(cluster.RoleImage) {
+		return proc.ProcessImagePromptWithOptions
+	}
+	if node.HasWorkers(cluster.RoleImage) {
+		return func(ctx context.Context, prompt string, parent imagegen.ParentWidget, options imagegen.ImageOptions) (*imagegen.ProcessResult, error) {
+			job := imageJob{
+				CanvasID: canvasID,
+				Prompt:   prompt,
+				Options:  options,
+				Parent: imagegen.CanvasWidget{
+					ID:       parent.GetID(),
+					Location: parent.GetLocation(),
+					Size:     parent.GetSize(),
+					Scale:    parent.GetScale(),
+					Depth:    parent.GetDepth(),
+				},
+			}
+			var result imagegen.ProcessResult
+			if err := node.Submit(ctx, cluster.RoleImage, job, &result); err != nil {
+				return nil, err
+			}
+			return &result, nil
+		}
+	}
+	if proc != nil {
+		return proc.ProcessImagePromptWithOptions
+	}
+	return nil
+}
+
+// serveImageJobs generates the images other nodes of the cluster submit,
+// on the canvas each job names, until ctx is done.
+func serveImageJobs(ctx context.Context, node *cluster.Node, proc *imagegen.Processor, config *core.Config, logger *logging.Logger) {
+	var mu sync.Mutex
+	processors := map[string]*imagegen.Processor{config.CanvasID: proc}
+
+	node.Serve(ctx, cluster.RoleImage, config.SDMaxConcurrent, func(ctx context.Context, job cluster.Job) (interface{}, error) {
+		var req imageJob
+		if err := json.Unmarshal(job.Payload, &req); err != nil {
+			return nil, fmt.Errorf("decode image job: %w", err)
+		}
+		if req.CanvasID == "" {
+			return nil, fmt.Errorf("image job has no canvas")
+		}
+
+		mu.Lock()
+		p, ok := processors[req.CanvasID]
+		if !ok {
+			p = proc.ForCanvas(req.CanvasID)
+			processors[req.CanvasID] = p
+		}
+		mu.Unlock()
+
+		logger.Info("Generating image for cluster node",
+			zap.String("job_id", job.ID),
+			zap.String("submitted_by", job.SubmittedBy),
+			zap.String("canvas_id", req.CanvasID))
+		ctx, cancel := context.WithTimeout(ctx, config.AITimeout)
+		defer cancel()
+		return p.ProcessImagePromptWithOptions(ctx, req.Prompt, req.Parent, req.Options)
+	})
+}
+
+// serveTasks runs the AI tasks monitor nodes put on the cluster's task
+// queue, up to concurrency at a time, until ctx is done. Each task reports
+// its outcome on its canvas, so the queue gets no result back.
+func serveTasks(ctx context.Context, node *cluster.Node, monitor *Monitor, concurrency int) {
+	node.Serve(ctx, cluster.RoleTasks, concurrency, func(ctx context.Context, job cluster.Job) (interface{}, error) {
+		var task aiTask
+		if err := json.Unmarshal(job.Payload, &task); err != nil {
+			return nil, fmt.Errorf("decode task: %w", err)
+		}
+		monitor.runTask(task)
+		return nil, nil
+	})
+}
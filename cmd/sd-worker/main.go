@@ -0,0 +1,93 @@
+// Command sd-worker generates Stable Diffusion images for CanvusLocalLLM
+// instances on other hosts, so the canvas monitor can run on the Canvus VM
+// while the model runs on a GPU box.
+//
+// It reads the SD_* settings of the main application (SD_MODEL_PATH,
+// SD_MAX_CONCURRENT, SD_TIMEOUT_SECONDS) and SD_WORKER_ADDR and
+// SD_WORKER_TOKEN from the environment or ./.env. Point instances at it with
+// SD_WORKER_URL=http://<host>:8091.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go_backend/core"
+	"go_backend/logging"
+	"go_backend/sdruntime"
+	"go_backend/sdworker"
+
+	"github.com/joho/godotenv"
+	"go.uber.org/zap"
+)
+
+func main() {
+	// Load .env file if it exists
+	_ = godotenv.Load()
+
+	logger, err := logging.NewLoggerWithConfig(os.Getenv("DEV_MODE") == "true", "sd-worker.log", logging.FileWriterConfigFromEnv())
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		os.Exit(core.ExitCodeError)
+	}
+	defer logger.Sync()
+
+	if err := run(logger); err != nil {
+		logger.Error("SD worker stopped", zap.Error(err))
+		logger.Sync()
+		os.Exit(core.ExitCodeError)
+	}
+}
+
+// run serves the SD context pool until SIGINT or SIGTERM.
+func run(logger *logging.Logger) error {
+	sdConfig := sdruntime.LoadSDConfig()
+	if sdConfig.ModelPath == "" {
+		return errors.New("SD_MODEL_PATH is not set")
+	}
+	if _, err := os.Stat(sdConfig.ModelPath); err != nil {
+		return fmt.Errorf("SD model file: %w", err)
+	}
+	if err := sdruntime.VerifyModelChecksum(sdConfig.ModelPath); errors.Is(err, sdruntime.ErrModelCorrupted) {
+		return fmt.Errorf("SD model file corrupted: %w", err)
+	} else if err != nil {
+		logger.Warn("SD model checksum verification skipped", zap.Error(err))
+	}
+
+	workerConfig, err := sdworker.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	if workerConfig.Token == "" {
+		logger.Warn("SD_WORKER_TOKEN is not set; any host that reaches the worker can generate images")
+	}
+
+	pool, err := sdruntime.NewContextPool(sdConfig.MaxConcurrent, sdConfig.ModelPath)
+	if err != nil {
+		return fmt.Errorf("create SD context pool: %w", err)
+	}
+	defer pool.Close()
+
+	server := sdworker.NewServer(workerConfig, pool, logger.Zap())
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.Start() }()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case err := <-errCh:
+		return err
+	case sig := <-signals:
+		logger.Info("Shutting down SD worker", zap.String("signal", sig.String()))
+	}
+
+	// Let images in progress finish
+	ctx, cancel := context.WithTimeout(context.Background(), sdConfig.Timeout+5*time.Second)
+	defer cancel()
+	return server.Shutdown(ctx)
+}
@@ -0,0 +1,175 @@
+// Package collage composes the images of a canvas zone into one picture:
+// a grid of the images in reading order, optionally headed by a title, for
+// the {{collage}} and {{moodboard}} triggers. This file contains the
+// layout and the renderer.
+package collage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // decode GIF images placed on the canvas
+	"image/jpeg"
+	_ "image/png" // decode PNG images placed on the canvas
+	"math"
+	"strings"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+	_ "golang.org/x/image/webp" // decode WebP images placed on the canvas
+)
+
+// DefaultCellSize is the side of a grid cell when Options leave it unset.
+const DefaultCellSize = 512
+
+// MaxColumns bounds the columns a trigger can ask for.
+const MaxColumns = 12
+
+// quality is the JPEG quality of collages.
+const quality = 90
+
+// ErrNoImages is returned when none of the images could be decoded.
+var ErrNoImages = errors.New("collage: no images to compose")
+
+// background is the color behind the images and the title.
+var background = color.White
+
+// Options control the layout of a collage.
+type Options struct {
+	// CellSize is the side of the square each image is fitted into, in
+	// pixels (default: DefaultCellSize)
+	CellSize int
+	// Columns of the grid (default: about as many as rows)
+	Columns int
+	// Title is drawn above the grid when set
+	Title string
+}
+
+// Result is a composed collage.
+type Result struct {
+	// Data is the collage as a JPEG
+	Data   []byte
+	Width  int
+	Height int
+	// Images is the number of images in the collage, and Skipped the
+	// number that could not be decoded
+	Images  int
+	Skipped int
+}
+
+// Compose lays out images (PNG, JPEG, GIF or WebP) in a grid, in the order
+// given, each fitted into a square cell without cropping. Images that
+// cannot be decoded are skipped.
+//
+// This is a pure function (molecule) with no external dependencies.
+func Compose(images [][]byte, opts Options) (*Result, error) {
+	var decoded []image.Image
+	skipped := 0
+	for _, data := range images {
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil || img.Bounds().Empty() {
+			skipped++
+			continue
+		}
+		decoded = append(decoded, img)
+	}
+	if len(decoded) == 0 {
+		return nil, ErrNoImages
+	}
+
+	cell := opts.CellSize
+	if cell <= 0 {
+		cell = DefaultCellSize
+	}
+	columns, rows := grid(len(decoded), opts.Columns)
+	gap := max(1, cell/16)
+	width := columns*cell + (columns+1)*gap
+
+	title := strings.TrimSpace(opts.Title)
+	var face font.Face
+	top := gap
+	if title != "" {
+		var err error
+		face, err = titleFace(title, cell/6, width-2*gap)
+		if err != nil {
+			return nil, err
+		}
+		defer face.Close()
+		top += face.Metrics().Height.Ceil() + gap
+	}
+	height := top + rows*cell + rows*gap
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+	if face != nil {
+		drawTitle(dst, face, title, gap)
+	}
+	for i, img := range decoded {
+		x := gap + (i%columns)*(cell+gap)
+		y := top + (i/columns)*(cell+gap)
+		draw.CatmullRom.Scale(dst, fit(img.Bounds(), image.Rect(x, y, x+cell, y+cell)), img, img.Bounds(), draw.Over, nil)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("collage: failed to encode image: %w", err)
+	}
+	return &Result{Data: buf.Bytes(), Width: width, Height: height, Images: len(decoded), Skipped: skipped}, nil
+}
+
+// grid returns the columns and rows for n images. Without a column count
+// the grid is as square as possible, wider rather than taller.
+func grid(n, columns int) (int, int) {
+	if columns <= 0 {
+		columns = int(math.Ceil(math.Sqrt(float64(n))))
+	}
+	columns = min(columns, n, MaxColumns)
+	return columns, (n + columns - 1) / columns
+}
+
+// fit returns the largest rectangle with the aspect ratio of src centered
+// in cell.
+func fit(src, cell image.Rectangle) image.Rectangle {
+	sw, sh := src.Dx(), src.Dy()
+	cw, ch := cell.Dx(), cell.Dy()
+	w, h := cw, max(1, sh*cw/sw)
+	if h > ch {
+		w, h = max(1, sw*ch/sh), ch
+	}
+	x := cell.Min.X + (cw-w)/2
+	y := cell.Min.Y + (ch-h)/2
+	return image.Rect(x, y, x+w, y+h)
+}
+
+// titleFace returns the title font at size pixels, made smaller until the
+// title fits in width.
+func titleFace(title string, size, width int) (font.Face, error) {
+	f, err := opentype.Parse(gobold.TTF)
+	if err != nil {
+		return nil, fmt.Errorf("collage: failed to load title font: %w", err)
+	}
+	for size = max(size, 12); ; size = size * 9 / 10 {
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: float64(size), DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			return nil, fmt.Errorf("collage: failed to load title font: %w", err)
+		}
+		if size <= 12 || font.MeasureString(face, title).Ceil() <= width {
+			return face, nil
+		}
+		face.Close()
+	}
+}
+
+// drawTitle draws title centered at the top of dst, gap pixels from the
+// edge. A title too long for the smallest font is cut off.
+func drawTitle(dst *image.RGBA, face font.Face, title string, gap int) {
+	d := &font.Drawer{Dst: dst, Src: image.Black, Face: face}
+	x := (dst.Bounds().Dx() - d.MeasureString(title).Ceil()) / 2
+	d.Dot = fixed.P(max(gap, x), gap+face.Metrics().Ascent.Ceil())
+	d.DrawString(title)
+}
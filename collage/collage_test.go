@@ -0,0 +1,128 @@
+package collage
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"go_backend/canvasexport"
+)
+
+func testPNG(t *testing.T, w, h int, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseTrigger(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Request
+		ok   bool
+	}{
+		{"collage", Request{}, true},
+		{" Collage: Sprint Board ", Request{Zone: "Sprint Board"}, true},
+		{"collage: Ideas --title Summer campaign", Request{Zone: "Ideas", Title: "Summer campaign"}, true},
+		{"collage: Ideas --title", Request{Zone: "Ideas", AutoTitle: true}, true},
+		{"collage: --columns 4 --title Wall", Request{Columns: 4, Title: "Wall"}, true},
+		{"collage: Ideas --columns 40", Request{Zone: "Ideas", Columns: MaxColumns}, true},
+		{"moodboard: Ideas", Request{Zone: "Ideas", AutoTitle: true}, true},
+		{"mood board: Ideas --title Dusk", Request{Zone: "Ideas", Title: "Dusk"}, true},
+		{"collage of beach photos", Request{}, false},
+		{"collages", Request{}, false},
+		{"image: a collage", Request{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseTrigger(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseTrigger(%q) = %+v, %v, want %+v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestCompose(t *testing.T) {
+	images := [][]byte{
+		testPNG(t, 200, 100, color.RGBA{255, 0, 0, 255}),
+		testPNG(t, 100, 200, color.RGBA{0, 0, 255, 255}),
+		testPNG(t, 50, 50, color.RGBA{0, 255, 0, 255}),
+		[]byte("not an image"),
+	}
+	result, err := Compose(images, Options{CellSize: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Images != 3 || result.Skipped != 1 {
+		t.Errorf("images = %d, skipped = %d, want 3 and 1", result.Images, result.Skipped)
+	}
+	// Two columns and two rows of 64 pixel cells with 4 pixel gaps
+	if result.Width != 2*64+3*4 || result.Height != 2*64+3*4 {
+		t.Errorf("size = %dx%d, want 140x140", result.Width, result.Height)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The wide red image is centered in the first cell; above it is background
+	if r, g, b, _ := img.At(4+32, 4+32).RGBA(); r>>8 < 200 || g>>8 > 60 || b>>8 > 60 {
+		t.Errorf("first cell center = %d,%d,%d, want red", r>>8, g>>8, b>>8)
+	}
+	if r, g, b, _ := img.At(4+32, 4+4).RGBA(); r>>8 < 240 || g>>8 < 240 || b>>8 < 240 {
+		t.Errorf("above the wide image = %d,%d,%d, want white", r>>8, g>>8, b>>8)
+	}
+
+	titled, err := Compose(images, Options{CellSize: 64, Columns: 3, Title: "A title far too long to fit above three small cells"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if titled.Width != 3*64+4*4 || titled.Height <= 64+2*4 {
+		t.Errorf("titled size = %dx%d", titled.Width, titled.Height)
+	}
+
+	if _, err := Compose([][]byte{[]byte("nope")}, Options{}); !errors.Is(err, ErrNoImages) {
+		t.Errorf("Compose(no images) = %v, want ErrNoImages", err)
+	}
+}
+
+func TestTitlePromptAndCleanTitle(t *testing.T) {
+	doc := &canvasexport.Document{
+		Zone: "Ideas",
+		Sections: []canvasexport.Section{
+			{Kind: canvasexport.KindImage, Title: "beach.png"},
+			{Kind: canvasexport.KindNote, Title: "Warm colors"},
+			{Kind: canvasexport.KindBrowser, Title: "https://example.com"},
+		},
+	}
+	prompt := TitlePrompt(doc)
+	for _, want := range []string{"Zone: Ideas", "Image: beach.png", "Note: Warm colors"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("prompt is missing %q:\n%s", want, prompt)
+		}
+	}
+	if strings.Contains(prompt, "example.com") {
+		t.Errorf("prompt includes a browser widget:\n%s", prompt)
+	}
+
+	for in, want := range map[string]string{
+		"\"Golden Hour\"\nThis title evokes...": "Golden Hour",
+		"Title: **Salt and Sand**":              "Salt and Sand",
+		strings.Repeat("x", 80):                 strings.Repeat("x", 59) + "…",
+	} {
+		if got := CleanTitle(in); got != want {
+			t.Errorf("CleanTitle(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Package collage composes the images of a canvas zone into one picture.
+// This file contains the upload of a collage next to the images it shows.
+package collage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go_backend/canvasexport"
+	"go_backend/handlers"
+)
+
+// placementGap is the canvas distance between a collage and the area it shows.
+const placementGap = 40
+
+// Canvas uploads images. Implemented by canvusapi.Client.
+type Canvas interface {
+	CreateImage(filePath string, metadata map[string]interface{}) (map[string]interface{}, error)
+}
+
+// Area returns the area a collage of doc shows: the zone, or for a whole
+// canvas the bounds of its images. ok is false if doc has neither.
+func Area(doc *canvasexport.Document) (area handlers.Rect, ok bool) {
+	if doc.Zone != "" && len(doc.Zones) > 0 {
+		return doc.Zones[0].Bounds, true
+	}
+	for _, s := range doc.Sections {
+		if s.Kind != canvasexport.KindImage || s.Image == nil {
+			continue
+		}
+		if !ok {
+			area, ok = s.Bounds, true
+			continue
+		}
+		right := max(area.X+area.Width, s.Bounds.X+s.Bounds.Width)
+		bottom := max(area.Y+area.Height, s.Bounds.Y+s.Bounds.Height)
+		area.X, area.Y = min(area.X, s.Bounds.X), min(area.Y, s.Bounds.Y)
+		area.Width, area.Height = right-area.X, bottom-area.Y
+	}
+	return area, ok
+}
+
+// Post uploads result to the right of area, as tall as the area, staging
+// it in dir, and returns the widget ID.
+func Post(canvas Canvas, result *Result, title string, area handlers.Rect, depth float64, dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	staging, err := os.MkdirTemp(dir, "collage-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(staging)
+
+	path := filepath.Join(staging, "collage.jpg")
+	if err := os.WriteFile(path, result.Data, 0644); err != nil {
+		return "", err
+	}
+	if title == "" {
+		title = "Collage"
+	}
+	scale := 1.0
+	if area.Height > 0 {
+		scale = area.Height / float64(result.Height)
+	}
+	response, err := canvas.CreateImage(path, map[string]interface{}{
+		"title":    title,
+		"location": map[string]float64{"x": area.X + area.Width + placementGap, "y": area.Y},
+		"size":     map[string]interface{}{"width": float64(result.Width), "height": float64(result.Height)},
+		"scale":    scale,
+		"depth":    depth + 10,
+	})
+	if err != nil {
+		return "", fmt.Errorf("collage: failed to upload collage: %w", err)
+	}
+	id, _ := response["id"].(string)
+	return id, nil
+}
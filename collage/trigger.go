@@ -0,0 +1,123 @@
+// Package collage composes the images of a canvas zone into one picture.
+// This file contains the trigger syntax and the prompt for AI titles.
+package collage
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go_backend/canvasexport"
+)
+
+// TitleSystemPrompt asks a model for the title of a mood board.
+const TitleSystemPrompt = `You name mood boards from creative workshops. You are given the name of the board's zone and the titles of its images and notes. Reply with one short, evocative title of at most six words. Reply with the title only, without quotes or explanation.`
+
+// maxTitleLength bounds a title, whether written or generated.
+const maxTitleLength = 60
+
+// maxPromptItems bounds the image and note titles sent for an AI title.
+const maxPromptItems = 40
+
+// Request is a parsed collage trigger.
+type Request struct {
+	// Zone is the name or ID of the anchor whose images are collected
+	// (empty: the whole canvas)
+	Zone string
+	// Title is drawn above the images
+	Title string
+	// AutoTitle asks a model for the title when Title is empty
+	AutoTitle bool
+	// Columns of the grid (0: about as many as rows)
+	Columns int
+}
+
+// ParseTrigger parses the content of a collage trigger:
+//
+//	collage                          every image of the canvas
+//	collage: Sprint Board            the images in the zone "Sprint Board"
+//	collage: Ideas --title Summer    with the title "Summer"
+//	collage: Ideas --title           with a title written by the AI
+//	collage: Ideas --columns 4       in four columns
+//	moodboard: Ideas                 a collage with a title written by the AI
+//
+// "mood board" is accepted for "moodboard". ok is false if content is not
+// a collage trigger.
+func ParseTrigger(content string) (req Request, ok bool) {
+	content = strings.TrimSpace(content)
+	var rest string
+	for _, keyword := range []string{"collage", "moodboard", "mood board"} {
+		if len(content) >= len(keyword) && strings.EqualFold(content[:len(keyword)], keyword) {
+			rest, ok = content[len(keyword):], true
+			req.AutoTitle = keyword != "collage"
+			break
+		}
+	}
+	if !ok {
+		return Request{}, false
+	}
+	if rest != "" && rest[0] != ':' {
+		return Request{}, false // e.g. "collage of beach photos" is an ordinary prompt
+	}
+	rest = strings.TrimPrefix(rest, ":")
+
+	zone, options := rest, ""
+	if i := strings.Index(" "+rest, " --"); i >= 0 {
+		zone, options = rest[:max(0, i-1)], rest[i:]
+	}
+	req.Zone = strings.TrimSpace(zone)
+
+	// Options are "--name value"; a value runs up to the next option
+	for _, option := range strings.Split(options, " --") {
+		name, value, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(option), "--")), " ")
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(name) {
+		case "title":
+			req.Title = CleanTitle(value)
+			req.AutoTitle = req.Title == ""
+		case "columns", "cols":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				req.Columns = min(n, MaxColumns)
+			}
+		}
+	}
+	return req, true
+}
+
+// TitlePrompt returns the prompt for the title of a collage of the images
+// of doc: the zone name and the titles of its images and notes.
+func TitlePrompt(doc *canvasexport.Document) string {
+	var b strings.Builder
+	if doc.Zone != "" {
+		fmt.Fprintf(&b, "Zone: %s\n", doc.Zone)
+	}
+	items := 0
+	for _, s := range doc.Sections {
+		if items == maxPromptItems {
+			break
+		}
+		text := strings.TrimSpace(s.Title)
+		if text == "" || (s.Kind != canvasexport.KindImage && s.Kind != canvasexport.KindNote) {
+			continue
+		}
+		kind := "Image"
+		if s.Kind == canvasexport.KindNote {
+			kind = "Note"
+		}
+		fmt.Fprintf(&b, "%s: %s\n", kind, text)
+		items++
+	}
+	return b.String()
+}
+
+// CleanTitle trims a title to its first line without surrounding quotes,
+// at most maxTitleLength characters long.
+func CleanTitle(title string) string {
+	title, _, _ = strings.Cut(strings.TrimSpace(title), "\n")
+	title = strings.TrimPrefix(strings.TrimSpace(title), "Title:")
+	title = strings.TrimSpace(strings.Trim(strings.TrimSpace(title), "\"'“”«»`*#"))
+	if runes := []rune(title); len(runes) > maxTitleLength {
+		title = strings.TrimSpace(string(runes[:maxTitleLength-1])) + "…"
+	}
+	return title
+}
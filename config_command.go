@@ -0,0 +1,135 @@
+// Package main provides the config subcommand for checking .env files.
+//
+// Usage:
+//
+//	canvuslocallm config print [-all] [-env .env]
+//	canvuslocallm config validate [-env .env]
+//	canvuslocallm config docs
+//
+// print shows the effective value of each setting and whether it came from
+// the environment, the .env file or the default; secrets are redacted.
+// validate reports errors and warnings and exits non-zero on errors. docs
+// writes the configuration reference in Markdown.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+
+	"go_backend/core"
+)
+
+// runConfigCommand runs the config subcommand and returns the process exit code.
+func runConfigCommand(args []string) int {
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	envPath := fs.String("env", ".env", "env file to check")
+	all := fs.Bool("all", false, "print unset settings too")
+	fs.Usage = func() {
+		fmt.Println("Usage: canvuslocallm config print [-all] [-env FILE] | validate [-env FILE] | docs")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return core.ExitCodeError
+	}
+
+	action := "print"
+	if fs.NArg() > 0 {
+		action = fs.Arg(0)
+		// Allow flags after the action, e.g. "config print -all"
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return core.ExitCodeError
+		}
+	}
+
+	if action == "docs" {
+		fmt.Print(core.ConfigReference())
+		return core.ExitCodeSuccess
+	}
+	if action != "print" && action != "validate" {
+		fs.Usage()
+		return core.ExitCodeError
+	}
+
+	env, fileValues, err := configEnvironment(*envPath)
+	if err != nil {
+		fmt.Printf("Failed to read %s: %v\n", *envPath, err)
+		return core.ExitCodeError
+	}
+	report := core.InspectConfig(env, fileValues)
+
+	if action == "print" {
+		printEffectiveConfig(report, *all)
+		fmt.Println()
+	}
+	return printConfigProblems(report)
+}
+
+// configEnvironment returns the environment as the server would see it with
+// envPath loaded, and the assignments of envPath. main has already loaded
+// ./.env, so its values are taken out again first; like godotenv.Load,
+// the file never overrides variables set in the environment.
+func configEnvironment(envPath string) (env, fileValues map[string]string, err error) {
+	env = core.EnvironMap()
+	if loaded, err := godotenv.Read(); err == nil {
+		for key, value := range loaded {
+			if env[key] == value {
+				delete(env, key)
+			}
+		}
+	}
+
+	fileValues, err = godotenv.Read(envPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, nil, err
+		}
+		fmt.Printf("Warning: %s not found, checking the environment only\n", envPath)
+	}
+	for key, value := range fileValues {
+		if _, set := env[key]; !set {
+			env[key] = value
+		}
+	}
+	return env, fileValues, nil
+}
+
+// printEffectiveConfig prints each setting with its value and source,
+// grouped as in the configuration reference.
+func printEffectiveConfig(report *core.ConfigReport, all bool) {
+	group := ""
+	for _, e := range report.Settings {
+		if e.Source == core.SourceUnset && !all {
+			continue
+		}
+		if e.Group != group {
+			group = e.Group
+			fmt.Printf("\n# %s\n", group)
+		}
+		source := string(e.Source)
+		if e.SetAs != "" {
+			source += " as " + e.SetAs
+		}
+		fmt.Printf("%-30s = %-40s (%s)\n", e.Name, e.DisplayValue(), source)
+	}
+}
+
+// printConfigProblems prints the errors and warnings of report and returns
+// the exit code of the config command.
+func printConfigProblems(report *core.ConfigReport) int {
+	errors, warnings := report.Errors(), report.Warnings()
+	for _, p := range errors {
+		fmt.Printf("ERROR    %s\n", p.Message)
+	}
+	for _, p := range warnings {
+		fmt.Printf("WARNING  %s\n", p.Message)
+	}
+	if len(errors) > 0 {
+		fmt.Printf("\nConfiguration is invalid: %d error(s), %d warning(s)\n", len(errors), len(warnings))
+		return core.ExitCodeError
+	}
+	fmt.Printf("Configuration is valid (%d warning(s))\n", len(warnings))
+	return core.ExitCodeSuccess
+}
@@ -9,7 +9,7 @@ import (
 
 // TestAIResponse generates a response using the OpenAI API
 func TestAIResponse(ctx context.Context, cfg *Config, prompt string) (string, error) {
-	client := createOpenAIClient(cfg)
+	client := CreateOpenAIClient(cfg)
 
 	resp, err := client.CreateChatCompletion(
 		ctx,
@@ -35,20 +35,3 @@ func TestAIResponse(ctx context.Context, cfg *Config, prompt string) (string, er
 
 	return resp.Choices[0].Message.Content, nil
 }
-
-func createOpenAIClient(cfg *Config) *openai.Client {
-	// Create client with configuration
-	clientConfig := openai.DefaultConfig(cfg.OpenAIAPIKey)
-
-	// Use TextLLMURL if set, otherwise fall back to BaseLLMURL
-	if cfg.TextLLMURL != "" {
-		clientConfig.BaseURL = cfg.TextLLMURL
-	} else if cfg.BaseLLMURL != "" {
-		clientConfig.BaseURL = cfg.BaseLLMURL
-	}
-
-	// Configure HTTP client with TLS settings
-	clientConfig.HTTPClient = GetHTTPClient(cfg, cfg.AITimeout)
-
-	return openai.NewClientWithConfig(clientConfig)
-}
@@ -0,0 +1,113 @@
+// Package clock provides the time source of schedulers, health checkers,
+// metrics and retention jobs. Production code uses Real; tests use a Fake
+// and advance it instead of sleeping, and the stress harness uses one to
+// simulate long runs in moments.
+//
+// Example usage:
+//
+//	type Checker struct{ clock clock.Clock }
+//
+//	func (c *Checker) run(ctx context.Context) {
+//		ticker := c.clock.NewTicker(time.Minute)
+//		defer ticker.Stop()
+//		for {
+//			select {
+//			case <-ctx.Done():
+//				return
+//			case <-ticker.C():
+//				c.check(c.clock.Now())
+//			}
+//		}
+//	}
+package clock
+
+import "time"
+
+// Clock tells the time and schedules wake-ups.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// Since returns the time elapsed since t.
+	Since(t time.Time) time.Duration
+
+	// After returns a channel that receives the time once d has passed.
+	After(d time.Duration) <-chan time.Time
+
+	// Sleep blocks until d has passed.
+	Sleep(d time.Duration)
+
+	// NewTimer returns a timer that fires once after d.
+	NewTimer(d time.Duration) Timer
+
+	// NewTicker returns a ticker that fires every d, dropping ticks for
+	// slow receivers like time.Ticker. d must be positive.
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is a time.Timer of a Clock.
+type Timer interface {
+	// C returns the channel the time is sent on.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing. It returns false if the timer
+	// already fired or was stopped.
+	Stop() bool
+
+	// Reset changes the timer to fire after d. It returns whether the timer
+	// was active.
+	Reset(d time.Duration) bool
+}
+
+// Ticker is a time.Ticker of a Clock.
+type Ticker interface {
+	// C returns the channel the ticks are sent on.
+	C() <-chan time.Time
+
+	// Stop turns the ticker off.
+	Stop()
+
+	// Reset changes the period to d and restarts it from now.
+	Reset(d time.Duration)
+}
+
+// Real returns the clock of the time package.
+func Real() Clock {
+	return realClock{}
+}
+
+// OrReal returns c, or Real if c is nil, for optional Clock fields.
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real()
+	}
+	return c
+}
+
+// realClock implements Clock with the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTimer struct{ t *time.Timer }
+
+func (r realTimer) C() <-chan time.Time        { return r.t.C }
+func (r realTimer) Stop() bool                 { return r.t.Stop() }
+func (r realTimer) Reset(d time.Duration) bool { return r.t.Reset(d) }
+
+type realTicker struct{ t *time.Ticker }
+
+func (r realTicker) C() <-chan time.Time   { return r.t.C }
+func (r realTicker) Stop()                 { r.t.Stop() }
+func (r realTicker) Reset(d time.Duration) { r.t.Reset(d) }
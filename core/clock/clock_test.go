@@ -0,0 +1,142 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestOrReal(t *testing.T) {
+	if OrReal(nil) != Real() {
+		t.Error("OrReal(nil) is not the real clock")
+	}
+	fake := NewFake(epoch)
+	if OrReal(fake) != fake {
+		t.Error("OrReal(fake) did not return fake")
+	}
+}
+
+func TestFakeNowAndAdvance(t *testing.T) {
+	fake := NewFake(epoch)
+	fake.Advance(90 * time.Second)
+	if got := fake.Now(); !got.Equal(epoch.Add(90 * time.Second)) {
+		t.Errorf("Now() = %v", got)
+	}
+	if got := fake.Since(epoch); got != 90*time.Second {
+		t.Errorf("Since(epoch) = %v", got)
+	}
+	fake.Set(epoch)
+	if got := fake.Now(); !got.Equal(epoch.Add(90 * time.Second)) {
+		t.Errorf("Set to an earlier time moved the clock to %v", got)
+	}
+}
+
+func TestFakeTimer(t *testing.T) {
+	fake := NewFake(epoch)
+	timer := fake.NewTimer(time.Minute)
+
+	fake.Advance(59 * time.Second)
+	select {
+	case <-timer.C():
+		t.Fatal("timer fired early")
+	default:
+	}
+
+	fake.Advance(time.Hour)
+	select {
+	case at := <-timer.C():
+		if !at.Equal(epoch.Add(time.Minute)) {
+			t.Errorf("timer fired at %v", at)
+		}
+	default:
+		t.Fatal("timer did not fire")
+	}
+	if timer.Stop() {
+		t.Error("Stop() = true after the timer fired")
+	}
+
+	if timer.Reset(time.Second) {
+		t.Error("Reset() = true for a fired timer")
+	}
+	if !timer.Stop() {
+		t.Error("Stop() = false for a pending timer")
+	}
+	fake.Advance(time.Minute)
+	select {
+	case <-timer.C():
+		t.Error("stopped timer fired")
+	default:
+	}
+	if n := fake.Waiters(); n != 0 {
+		t.Errorf("Waiters() = %d", n)
+	}
+}
+
+func TestFakeTickerDropsTicks(t *testing.T) {
+	fake := NewFake(epoch)
+	ticker := fake.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	fake.Advance(10 * time.Second)
+	if at := <-ticker.C(); !at.Equal(epoch.Add(10 * time.Second)) {
+		t.Errorf("first tick at %v", at)
+	}
+
+	// Three ticks pass unreceived; only the first is kept
+	fake.Advance(30 * time.Second)
+	if at := <-ticker.C(); !at.Equal(epoch.Add(20 * time.Second)) {
+		t.Errorf("buffered tick at %v", at)
+	}
+	select {
+	case at := <-ticker.C():
+		t.Errorf("unexpected tick at %v", at)
+	default:
+	}
+
+	ticker.Reset(time.Minute)
+	fake.Advance(59 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Error("reset ticker fired early")
+	default:
+	}
+	fake.Advance(time.Second)
+	if at := <-ticker.C(); !at.Equal(epoch.Add(100 * time.Second)) {
+		t.Errorf("reset ticker fired at %v", at)
+	}
+}
+
+func TestFakeFiresInTimeOrder(t *testing.T) {
+	fake := NewFake(epoch)
+	late := fake.NewTimer(2 * time.Minute)
+	early := fake.NewTimer(time.Minute)
+
+	fake.Advance(5 * time.Minute)
+	if at := <-early.C(); !at.Equal(epoch.Add(time.Minute)) {
+		t.Errorf("early timer fired at %v", at)
+	}
+	if at := <-late.C(); !at.Equal(epoch.Add(2 * time.Minute)) {
+		t.Errorf("late timer fired at %v", at)
+	}
+}
+
+func TestFakeSleepAndBlockUntil(t *testing.T) {
+	fake := NewFake(epoch)
+	done := make(chan time.Time)
+	go func() {
+		fake.Sleep(time.Hour)
+		done <- fake.Now()
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Hour)
+	select {
+	case at := <-done:
+		if !at.Equal(epoch.Add(time.Hour)) {
+			t.Errorf("woke at %v", at)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("sleeper did not wake")
+	}
+}
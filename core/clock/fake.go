@@ -0,0 +1,238 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock that stands still until it is advanced. Timers, tickers
+// and sleepers fire when Advance moves past their time, in time order.
+//
+// A test typically starts the code under test, waits with BlockUntil until
+// it is waiting on the clock, then advances it:
+//
+//	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	unloader.SetClock(fake)
+//	unloader.Start(ctx)
+//	fake.BlockUntil(1)
+//	fake.Advance(time.Minute)
+//
+// Thread-Safety: Fake is safe for concurrent use.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*waiter
+	// changed is closed and replaced whenever waiters changes
+	changed chan struct{}
+}
+
+// waiter is a pending timer or ticker of a Fake.
+type waiter struct {
+	at time.Time
+	// period is the interval of a ticker, 0 for a timer
+	period time.Duration
+	ch     chan time.Time
+}
+
+// NewFake returns a Fake clock reading start.
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start, changed: make(chan struct{})}
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// Since returns the fake time elapsed since t.
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+// After returns a channel that receives the time once the clock has been
+// advanced by d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	return f.NewTimer(d).C()
+}
+
+// Sleep blocks until the clock has been advanced by d.
+func (f *Fake) Sleep(d time.Duration) {
+	<-f.After(d)
+}
+
+// NewTimer returns a timer firing once the clock has been advanced by d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	w := &waiter{ch: make(chan time.Time, 1)}
+	f.mu.Lock()
+	f.schedule(w, f.now.Add(d))
+	f.mu.Unlock()
+	if d <= 0 {
+		f.Advance(0)
+	}
+	return &fakeTimer{clock: f, w: w}
+}
+
+// NewTicker returns a ticker firing each time the clock passes another d.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	w := &waiter{period: d, ch: make(chan time.Time, 1)}
+	f.mu.Lock()
+	f.schedule(w, f.now.Add(d))
+	f.mu.Unlock()
+	return &fakeTicker{clock: f, w: w}
+}
+
+// Advance moves the clock forward by d. Each timer and tick due on the way
+// fires at its own time; like time.Ticker, a tick is dropped if the
+// previous one has not been received.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	end := f.now.Add(d)
+	for {
+		next := -1
+		for i, w := range f.waiters {
+			if !w.at.After(end) && (next < 0 || w.at.Before(f.waiters[next].at)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+		w := f.waiters[next]
+		if w.at.After(f.now) {
+			f.now = w.at
+		}
+		select {
+		case w.ch <- w.at:
+		default:
+		}
+		if w.period > 0 {
+			w.at = w.at.Add(w.period)
+		} else {
+			f.unschedule(w)
+		}
+	}
+	if end.After(f.now) {
+		f.now = end
+	}
+}
+
+// Set moves the clock forward to t, as Advance. Earlier times are ignored.
+func (f *Fake) Set(t time.Time) {
+	f.Advance(t.Sub(f.Now()))
+}
+
+// Waiters returns the number of pending timers, tickers and sleepers.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil waits until at least n timers, tickers or sleepers are
+// pending, so a test knows the code under test is waiting on the clock.
+func (f *Fake) BlockUntil(n int) {
+	for {
+		f.mu.Lock()
+		pending, changed := len(f.waiters), f.changed
+		f.mu.Unlock()
+		if pending >= n {
+			return
+		}
+		<-changed
+	}
+}
+
+// schedule (re)arms w for at. Callers hold f.mu.
+func (f *Fake) schedule(w *waiter, at time.Time) {
+	w.at = at
+	for _, pending := range f.waiters {
+		if pending == w {
+			return
+		}
+	}
+	f.waiters = append(f.waiters, w)
+	f.notify()
+}
+
+// unschedule removes w and reports whether it was pending. Callers hold
+// f.mu.
+func (f *Fake) unschedule(w *waiter) bool {
+	for i, pending := range f.waiters {
+		if pending == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			f.notify()
+			return true
+		}
+	}
+	return false
+}
+
+// notify wakes BlockUntil. Callers hold f.mu.
+func (f *Fake) notify() {
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// drain discards a fired but unreceived time, as Stop and Reset do for
+// time.Timer since Go 1.23.
+func drain(ch chan time.Time) {
+	select {
+	case <-ch:
+	default:
+	}
+}
+
+type fakeTimer struct {
+	clock *Fake
+	w     *waiter
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.w.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	drain(t.w.ch)
+	return t.clock.unschedule(t.w)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	drain(t.w.ch)
+	active := t.clock.unschedule(t.w)
+	t.clock.schedule(t.w, t.clock.now.Add(d))
+	t.clock.mu.Unlock()
+	if d <= 0 {
+		t.clock.Advance(0)
+	}
+	return active
+}
+
+type fakeTicker struct {
+	clock *Fake
+	w     *waiter
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.w.ch }
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.clock.unschedule(t.w)
+}
+
+func (t *fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.w.period = d
+	t.clock.schedule(t.w, t.clock.now.Add(d))
+}
@@ -1,13 +1,18 @@
 package core
 
 import (
-	"crypto/tls"
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"go_backend/i18n"
+	"go_backend/llmcapture"
+	"go_backend/netdial"
+	"go_backend/netproxy"
 )
 
 // CanvasConfig holds configuration for a single canvas
@@ -21,9 +26,11 @@ type CanvasConfig struct {
 // Config holds all configuration values
 type Config struct {
 	// API Keys (all optional - cloud fallback only)
-	OpenAIAPIKey    string
-	GoogleVisionKey string
-	CanvusAPIKey    string
+	OpenAIAPIKey      string
+	GoogleVisionKey   string
+	CanvusAPIKey      string
+	StabilityAPIKey   string // Stability AI key for "stability:" image models
+	ReplicateAPIToken string // Replicate token for "replicate:" image models
 
 	// Server Configuration
 	CanvusServerURL      string
@@ -32,6 +39,7 @@ type Config struct {
 	CanvasConfigs        []CanvasConfig // Multi-canvas configuration
 	WebUIPassword        string
 	Port                 int
+	WebUIPublicURL       string // Address canvas users reach the WebUI at (links in notes)
 	AllowSelfSignedCerts bool
 
 	// LLM API Configuration (defaults to local inference)
@@ -40,20 +48,33 @@ type Config struct {
 	ImageLLMURL string // Optional override for image generation
 
 	// Local LLM (llama.cpp) Configuration
-	LlamaModelPath    string // Path to GGUF model file for local inference
-	LlamaModelURL     string // Optional URL to download model if not found
-	LlamaModelsDir    string // Directory for storing models (default: ./models)
-	LlamaAutoDownload bool   // Enable auto-download of model if not found
+	LlamaModelPath    string        // Path to GGUF model file for local inference
+	LlamaModelURL     string        // Optional URL to download model if not found
+	LlamaModelsDir    string        // Directory for storing models (default: ./models)
+	LlamaAutoDownload bool          // Enable auto-download of model if not found
+	LlamaIdleTimeout  time.Duration // Unload the llama model after this long idle (0 = never)
+	VisionModel       string        // Model name recorded for local vision tasks (default: model file name)
 
 	// Stable Diffusion (local image generation) Configuration
-	SDModelPath      string  // Path to SD model file (.safetensors, .ckpt, or .gguf)
-	SDImageSize      int     // Image output size in pixels (default: 512, must be divisible by 8)
-	SDInferenceSteps int     // Number of denoising steps (default: 20, range: 1-100)
-	SDGuidanceScale  float64 // CFG scale (default: 7.0, range: 1.0-30.0)
-	SDNegativePrompt string  // Default negative prompt for generation
-	SDTimeoutSeconds int     // Generation timeout in seconds (default: 120)
-	SDMaxConcurrent  int     // Maximum concurrent generations (default: 2, adjust for VRAM)
-	SDMaxImageSize   int     // Maximum image size in pixels (default: 1024)
+	SDModelPath      string        // Path to SD model file (.safetensors, .ckpt, or .gguf)
+	SDImageSize      int           // Image output size in pixels (default: 512, must be divisible by 8)
+	SDInferenceSteps int           // Number of denoising steps (default: 20, range: 1-100)
+	SDGuidanceScale  float64       // CFG scale (default: 7.0, range: 1.0-30.0)
+	SDNegativePrompt string        // Default negative prompt for generation
+	SDTimeoutSeconds int           // Generation timeout in seconds (default: 120)
+	SDMaxConcurrent  int           // Maximum concurrent generations (default: 2, adjust for VRAM)
+	SDMaxImageSize   int           // Maximum image size in pixels (default: 1024)
+	SDMaxSteps       int           // Most inference steps a trigger's --steps may ask for (default: 50)
+	SDIdleTimeout    time.Duration // Free SD contexts after this long idle (0 = never)
+
+	// Run a tiny generation on each local model at startup (/health/ready waits for it)
+	WarmupOnStartup bool
+
+	// Generated Image Output (applied before upload to the canvas)
+	ImageOutputFormat    string // png, jpeg or webp (default: png; webp is lossless)
+	ImageOutputQuality   int    // JPEG quality 1-100 (default: 90)
+	ImageMaxUploadWidth  int    // Scale down wider images before upload (0 = no limit)
+	ImageMaxUploadHeight int    // Scale down taller images before upload (0 = no limit)
 
 	// Azure OpenAI Configuration (optional cloud fallback)
 	AzureOpenAIEndpoint   string // Azure OpenAI endpoint (e.g., https://your-resource.openai.azure.com/)
@@ -66,6 +87,12 @@ type Config struct {
 	OpenAIPDFModel    string
 	OpenAIImageModel  string
 
+	// Cloud image parameters (OpenAI and Azure), overridable per trigger
+	ImageGenSize    string // e.g. 1024x1024, 1792x1024 (default: 1024x1024)
+	ImageGenQuality string // standard or hd (DALL-E 3); low, medium, high or auto (gpt-image-1)
+	ImageGenStyle   string // vivid or natural, DALL-E 3 only (default: vivid)
+	ImageMaxCount   int    // Most images a trigger's --n may ask for (default: 4)
+
 	// Token Limits (sensible defaults for local inference)
 	PDFPrecisTokens       int64
 	CanvasPrecisTokens    int64
@@ -76,6 +103,15 @@ type Config struct {
 	PDFMaxChunksTokens    int64
 	PDFSummaryRatioTokens float64
 
+	// Canvas Analysis Batching (canvases larger than CanvasBatchTokens are
+	// summarized in groups before the analysis is written)
+	CanvasBatchTokens      int // Estimated request size that triggers batching (default: 6000, 0 = never)
+	CanvasBatchConcurrency int // Widget groups summarized at once (default: 1)
+
+	// Zone Collages ({{collage}} and {{moodboard}} triggers)
+	CollageCellSize  int // Side of the square each image is fitted into, in pixels (default: 512)
+	CollageMaxImages int // Images in one collage, in reading order (default: 36)
+
 	// Processing Configuration (optimized for local GPU)
 	MaxRetries        int
 	RetryDelay        time.Duration
@@ -84,6 +120,42 @@ type Config struct {
 	MaxConcurrent     int
 	MaxFileSize       int64
 	DownloadsDir      string
+
+	// Connection Pool (shared keep-alive connections for LLM and API requests)
+	HTTPMaxIdleConnsPerHost int           // Idle connections kept per host (default: 16)
+	HTTPIdleConnTimeout     time.Duration // Close idle connections after this long (default: 90s)
+	HTTPConnectTimeout      time.Duration // Connect and TLS handshake timeout (default: 10s)
+
+	// Widget Stream Keepalive (silent streams are reconnected instead of
+	// waiting for the TCP timeout)
+	StreamIdleTimeout  time.Duration // Reconnect after this long without data (default: 60s, 0 = never)
+	StreamPingInterval time.Duration // Ping the server this often while streaming (default: 30s, 0 = never)
+
+	// Malformed JSON replies are repaired locally, then the model is asked
+	// to fix them this many times before they are treated as text (0 = never ask)
+	JSONRepairAttempts int
+
+	// OCR Configuration
+	OCRPreserveLayout bool // Create one note per detected text block at its position (default: true)
+
+	// Cloud Vision Privacy Configuration
+	CloudVisionStripMetadata bool // Remove EXIF/GPS/device metadata before images are sent to cloud vision APIs (default: true)
+	CloudVisionMaxDimension  int  // Scale images down to this many pixels on the longest side before sending (0 = no limit)
+
+	// AI Response Notes
+	NoteColor     string // Background color of AI response notes (default: #FFFFFF)
+	NoteTextColor string // Text color of AI response notes (default: #000000)
+
+	// AI Trigger Syntax (empty uses {{ and }}; set per canvas via canvas settings)
+	TriggerOpen  string
+	TriggerClose string
+
+	// Language of canvas messages and AI responses (default: en)
+	Language string
+
+	// Problems that did not stop startup, e.g. deprecated or misspelled
+	// settings (see InspectConfig)
+	Warnings []string
 }
 
 // Helper function to get environment variable with default value
@@ -94,6 +166,15 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// modelName returns the file name of a model path without its extension,
+// or "local" when no path is set.
+func modelName(path string) string {
+	if path == "" {
+		return "local"
+	}
+	return strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+}
+
 // Helper function to parse integer environment variable with default value
 func parseIntEnv(key string, defaultValue int) int {
 	if value := os.Getenv(key); value != "" {
@@ -158,7 +239,10 @@ func LoadConfig() (*Config, error) {
 
 	// Load LLM URLs with local-first defaults
 	// Default to local llamaruntime server for all operations
-	baseLLMURL := getEnvOrDefault("BASE_LLM_URL", "http://127.0.0.1:1234/v1")
+	baseLLMURL := os.Getenv("BASE_LLM_URL")
+	if baseLLMURL == "" {
+		baseLLMURL = getEnvOrDefault("OPENAI_API_BASE", "http://127.0.0.1:1234/v1") // OpenAI SDK convention
+	}
 	textLLMURL := os.Getenv("TEXT_LLM_URL") // Optional override
 	// Default to empty for image generation (triggers local SD generation)
 	imageLLMURL := os.Getenv("IMAGE_LLM_URL")
@@ -172,7 +256,10 @@ func LoadConfig() (*Config, error) {
 	llamaModelPath := os.Getenv("LLAMA_MODEL_PATH")
 	llamaModelURL := os.Getenv("LLAMA_MODEL_URL")
 	llamaModelsDir := getEnvOrDefault("LLAMA_MODELS_DIR", "./models")
+	visionModel := getEnvOrDefault("LLAMA_VISION_MODEL", modelName(llamaModelPath))
 	llamaAutoDownload := getEnvOrDefault("LLAMA_AUTO_DOWNLOAD", "false") == "true"
+	// Idle timeouts in minutes; 0 keeps models resident
+	llamaIdleTimeout := time.Duration(parseIntEnv("LLAMA_IDLE_TIMEOUT", 0)) * time.Minute
 
 	// Load Stable Diffusion configuration
 	sdModelPath := os.Getenv("SD_MODEL_PATH")
@@ -183,6 +270,51 @@ func LoadConfig() (*Config, error) {
 	sdTimeoutSeconds := parseIntEnv("SD_TIMEOUT_SECONDS", 120)
 	sdMaxConcurrent := parseIntEnv("SD_MAX_CONCURRENT", 2)
 	sdMaxImageSize := parseIntEnv("SD_MAX_IMAGE_SIZE", 1024)
+	sdMaxSteps := parseIntEnv("SD_MAX_STEPS", 50)
+	sdIdleTimeout := time.Duration(parseIntEnv("SD_IDLE_TIMEOUT", 0)) * time.Minute
+
+	warmupOnStartup := getEnvOrDefault("WARMUP_ON_STARTUP", "false") == "true"
+
+	// Load generated image output options
+	imageOutputFormat := strings.ToLower(getEnvOrDefault("IMAGE_OUTPUT_FORMAT", "png"))
+	if imageOutputFormat == "jpg" {
+		imageOutputFormat = "jpeg"
+	}
+	if imageOutputFormat != "png" && imageOutputFormat != "jpeg" && imageOutputFormat != "webp" {
+		return nil, fmt.Errorf("IMAGE_OUTPUT_FORMAT must be png, jpeg or webp, got %q", imageOutputFormat)
+	}
+	imageOutputQuality := parseIntEnv("IMAGE_OUTPUT_QUALITY", 90)
+	if imageOutputQuality < 1 || imageOutputQuality > 100 {
+		return nil, fmt.Errorf("IMAGE_OUTPUT_QUALITY must be between 1 and 100, got %d", imageOutputQuality)
+	}
+	// Load cloud image parameters; the API rejects combinations a model
+	// does not support, e.g. hd quality with gpt-image-1
+	imageGenSize := strings.ToLower(getEnvOrDefault("IMAGE_GEN_SIZE", "1024x1024"))
+	switch imageGenSize {
+	case "256x256", "512x512", "1024x1024", "1792x1024", "1024x1792", "1536x1024", "1024x1536", "auto":
+	default:
+		return nil, fmt.Errorf("IMAGE_GEN_SIZE must be a size the image model supports, e.g. 1024x1024 or 1792x1024, got %q", imageGenSize)
+	}
+	imageGenQuality := strings.ToLower(os.Getenv("IMAGE_GEN_QUALITY"))
+	switch imageGenQuality {
+	case "", "standard", "hd", "low", "medium", "high", "auto":
+	default:
+		return nil, fmt.Errorf("IMAGE_GEN_QUALITY must be standard, hd, low, medium, high or auto, got %q", imageGenQuality)
+	}
+	imageGenStyle := strings.ToLower(getEnvOrDefault("IMAGE_GEN_STYLE", "vivid"))
+	if imageGenStyle != "vivid" && imageGenStyle != "natural" {
+		return nil, fmt.Errorf("IMAGE_GEN_STYLE must be vivid or natural, got %q", imageGenStyle)
+	}
+	// Trigger flags such as --n 4 are checked against these limits
+	imageMaxCount := parseIntEnv("IMAGE_MAX_COUNT", 4)
+	if imageMaxCount < 1 || imageMaxCount > 10 {
+		return nil, fmt.Errorf("IMAGE_MAX_COUNT must be between 1 and 10, got %d", imageMaxCount)
+	}
+	imageMaxUploadWidth := parseIntEnv("IMAGE_MAX_UPLOAD_WIDTH", 0)
+	imageMaxUploadHeight := parseIntEnv("IMAGE_MAX_UPLOAD_HEIGHT", 0)
+	if imageMaxUploadWidth < 0 || imageMaxUploadHeight < 0 {
+		return nil, fmt.Errorf("IMAGE_MAX_UPLOAD_WIDTH and IMAGE_MAX_UPLOAD_HEIGHT must not be negative")
+	}
 
 	// Validate SD configuration if model path is set
 	if sdModelPath != "" {
@@ -198,6 +330,9 @@ func LoadConfig() (*Config, error) {
 		if sdInferenceSteps < 1 || sdInferenceSteps > 150 {
 			return nil, fmt.Errorf("SD_INFERENCE_STEPS must be between 1 and 150, got %d", sdInferenceSteps)
 		}
+		if sdMaxSteps < 1 || sdMaxSteps > 150 {
+			return nil, fmt.Errorf("SD_MAX_STEPS must be between 1 and 150, got %d", sdMaxSteps)
+		}
 		// Validate guidance scale
 		if sdGuidanceScale < 1.0 || sdGuidanceScale > 20.0 {
 			return nil, fmt.Errorf("SD_GUIDANCE_SCALE must be between 1.0 and 20.0, got %.2f", sdGuidanceScale)
@@ -231,11 +366,59 @@ func LoadConfig() (*Config, error) {
 	pdfChunkSizeTokens := parseInt64Env("OPENAI_PDF_CHUNK_SIZE_TOKENS", 20000)
 	pdfMaxChunksTokens := parseInt64Env("OPENAI_PDF_MAX_CHUNKS_TOKENS", 10)
 	pdfSummaryRatio := parseFloat64Env("OPENAI_PDF_SUMMARY_RATIO", 0.3)
+	// Large canvases are summarized in groups so no request outgrows the context
+	canvasBatchTokens := parseIntEnv("CANVAS_ANALYSIS_BATCH_TOKENS", 6000)
+	if canvasBatchTokens < 0 {
+		return nil, fmt.Errorf("CANVAS_ANALYSIS_BATCH_TOKENS must not be negative, got %d", canvasBatchTokens)
+	}
+	canvasBatchConcurrency := parseIntEnv("CANVAS_ANALYSIS_CONCURRENCY", 1)
+	if canvasBatchConcurrency < 1 {
+		return nil, fmt.Errorf("CANVAS_ANALYSIS_CONCURRENCY must be at least 1, got %d", canvasBatchConcurrency)
+	}
+	collageCellSize := parseIntEnv("COLLAGE_CELL_SIZE", 512)
+	if collageCellSize < 64 || collageCellSize > 2048 {
+		return nil, fmt.Errorf("COLLAGE_CELL_SIZE must be between 64 and 2048, got %d", collageCellSize)
+	}
+	collageMaxImages := parseIntEnv("COLLAGE_MAX_IMAGES", 36)
+	if collageMaxImages < 1 {
+		return nil, fmt.Errorf("COLLAGE_MAX_IMAGES must be at least 1, got %d", collageMaxImages)
+	}
 
 	// Load processing configuration optimized for local GPU inference
 	// 3 retries with 1s delay handles transient issues without excessive wait
 	maxRetries := parseIntEnv("MAX_RETRIES", 3)
 	retryDelay := time.Duration(parseIntEnv("RETRY_DELAY", 1)) * time.Second
+	// Keep-alive connections spare a TCP and TLS handshake on every request
+	httpMaxIdleConnsPerHost := parseIntEnv("HTTP_MAX_IDLE_CONNS_PER_HOST", DefaultMaxIdleConnsPerHost)
+	httpIdleConnTimeout := time.Duration(parseIntEnv("HTTP_IDLE_CONN_TIMEOUT", int(DefaultIdleConnTimeout/time.Second))) * time.Second
+	httpConnectTimeout := time.Duration(parseIntEnv("HTTP_CONNECT_TIMEOUT", int(DefaultConnectTimeout/time.Second))) * time.Second
+	// Per-destination proxies are applied by netproxy; reject typos at startup
+	if _, err := netproxy.ParseOverrides(os.Getenv("PROXY_OVERRIDES")); err != nil {
+		return nil, fmt.Errorf("PROXY_OVERRIDES: %w", err)
+	}
+	// Dual-stack dialing of the Canvus server is applied by netdial
+	if _, err := netdial.ParseFamily(os.Getenv("CANVUS_IP_FAMILY")); err != nil {
+		return nil, fmt.Errorf("CANVUS_IP_FAMILY: %w", err)
+	}
+	if delay := parseIntEnv("CANVUS_HAPPY_EYEBALLS_DELAY", int(netdial.DefaultFallbackDelay/time.Millisecond)); delay < 1 {
+		return nil, fmt.Errorf("CANVUS_HAPPY_EYEBALLS_DELAY must be at least 1 millisecond, got %d", delay)
+	}
+	// Canvus sends keep-alive lines on an idle stream, so a minute of silence
+	// means the connection is gone; pings catch drops between keep-alives
+	streamIdleTimeout := parseIntEnv("WIDGET_STREAM_IDLE_TIMEOUT", 60)
+	if streamIdleTimeout < 0 {
+		return nil, fmt.Errorf("WIDGET_STREAM_IDLE_TIMEOUT must not be negative, got %d", streamIdleTimeout)
+	}
+	streamPingInterval := parseIntEnv("WIDGET_STREAM_PING_INTERVAL", 30)
+	if streamPingInterval < 0 {
+		return nil, fmt.Errorf("WIDGET_STREAM_PING_INTERVAL must not be negative, got %d", streamPingInterval)
+	}
+	// One follow-up request fixes most malformed replies; JSON that only
+	// needs a local repair never costs a request
+	jsonRepairAttempts := parseIntEnv("JSON_REPAIR_ATTEMPTS", 1)
+	if jsonRepairAttempts < 0 || jsonRepairAttempts > 3 {
+		return nil, fmt.Errorf("JSON_REPAIR_ATTEMPTS must be between 0 and 3, got %d", jsonRepairAttempts)
+	}
 	// 60s AI timeout accommodates slower models while preventing hangs
 	aiTimeout := time.Duration(parseIntEnv("AI_TIMEOUT", 60)) * time.Second
 	// 300s processing timeout allows complex multi-step operations to complete
@@ -246,6 +429,20 @@ func LoadConfig() (*Config, error) {
 	maxFileSize := parseInt64Env("MAX_FILE_SIZE", 52428800)
 	downloadsDir := getEnvOrDefault("DOWNLOADS_DIR", "./downloads")
 	allowSelfSignedCerts := getEnvOrDefault("ALLOW_SELF_SIGNED_CERTS", "false") == "true"
+	// Layout-preserving OCR keeps handwriting blocks where they were written
+	ocrPreserveLayout := getEnvOrDefault("OCR_PRESERVE_LAYOUT", "true") == "true"
+	// Images are scrubbed of EXIF/GPS metadata before leaving the machine
+	cloudVisionStripMetadata := getEnvOrDefault("CLOUD_VISION_STRIP_METADATA", "true") == "true"
+	cloudVisionMaxDimension := parseIntEnv("CLOUD_VISION_MAX_DIMENSION", 0)
+	if cloudVisionMaxDimension < 0 {
+		return nil, fmt.Errorf("CLOUD_VISION_MAX_DIMENSION must not be negative, got %d", cloudVisionMaxDimension)
+	}
+	// LANGUAGE is also the gettext language list (e.g. "de_DE:de"), so
+	// unsupported values fall back to English rather than failing startup
+	language := i18n.Normalize(getEnvOrDefault("LANGUAGE", i18n.English))
+	if !i18n.Supported(language) {
+		language = i18n.English
+	}
 
 	// Parse multi-canvas configuration
 	canvasIDs := parseCanvasIDs("CANVAS_IDS")
@@ -298,11 +495,28 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("missing required environment variables: %v. See .env.example for configuration template", missingVars)
 	}
 
+	// Check the remaining constraints of the schema; values the lenient
+	// parsers above would silently replace with defaults are errors here
+	report := InspectConfig(EnvironMap(), nil)
+	if problems := report.Errors(); len(problems) > 0 {
+		messages := make([]string, len(problems))
+		for i, p := range problems {
+			messages[i] = p.Message
+		}
+		return nil, fmt.Errorf("invalid configuration: %s. Run 'canvuslocallm config validate' for details", strings.Join(messages, "; "))
+	}
+	var warnings []string
+	for _, p := range report.Warnings() {
+		warnings = append(warnings, p.Message)
+	}
+
 	return &Config{
 		// API Keys (optional - cloud fallback only)
-		OpenAIAPIKey:    openAIKey,
-		GoogleVisionKey: os.Getenv("GOOGLE_VISION_API_KEY"),
-		CanvusAPIKey:    canvusAPIKey,
+		OpenAIAPIKey:      openAIKey,
+		GoogleVisionKey:   os.Getenv("GOOGLE_VISION_API_KEY"),
+		CanvusAPIKey:      canvusAPIKey,
+		StabilityAPIKey:   os.Getenv("STABILITY_API_KEY"),
+		ReplicateAPIToken: os.Getenv("REPLICATE_API_TOKEN"),
 
 		// Server Configuration
 		CanvusServerURL:      canvusServerURL,
@@ -311,6 +525,7 @@ func LoadConfig() (*Config, error) {
 		CanvasConfigs:        canvasConfigs,
 		WebUIPassword:        os.Getenv("WEBUI_PWD"),
 		Port:                 parseIntEnv("PORT", 3000),
+		WebUIPublicURL:       strings.TrimRight(os.Getenv("WEBUI_PUBLIC_URL"), "/"),
 		AllowSelfSignedCerts: allowSelfSignedCerts,
 
 		// LLM Configuration (defaults to local inference)
@@ -323,6 +538,8 @@ func LoadConfig() (*Config, error) {
 		LlamaModelURL:     llamaModelURL,
 		LlamaModelsDir:    llamaModelsDir,
 		LlamaAutoDownload: llamaAutoDownload,
+		LlamaIdleTimeout:  llamaIdleTimeout,
+		VisionModel:       visionModel,
 
 		// Stable Diffusion Configuration
 		SDModelPath:      sdModelPath,
@@ -333,6 +550,16 @@ func LoadConfig() (*Config, error) {
 		SDTimeoutSeconds: sdTimeoutSeconds,
 		SDMaxConcurrent:  sdMaxConcurrent,
 		SDMaxImageSize:   sdMaxImageSize,
+		SDMaxSteps:       sdMaxSteps,
+		SDIdleTimeout:    sdIdleTimeout,
+
+		WarmupOnStartup: warmupOnStartup,
+
+		// Generated Image Output
+		ImageOutputFormat:    imageOutputFormat,
+		ImageOutputQuality:   imageOutputQuality,
+		ImageMaxUploadWidth:  imageMaxUploadWidth,
+		ImageMaxUploadHeight: imageMaxUploadHeight,
 
 		// Azure OpenAI Configuration (optional cloud fallback)
 		AzureOpenAIEndpoint:   azureOpenAIEndpoint,
@@ -345,6 +572,12 @@ func LoadConfig() (*Config, error) {
 		OpenAIPDFModel:    pdfModel,
 		OpenAIImageModel:  imageModel,
 
+		// Cloud image parameters
+		ImageGenSize:    imageGenSize,
+		ImageGenQuality: imageGenQuality,
+		ImageGenStyle:   imageGenStyle,
+		ImageMaxCount:   imageMaxCount,
+
 		// Token Limits (sensible defaults for local inference)
 		PDFPrecisTokens:       pdfPrecisTokens,
 		CanvasPrecisTokens:    canvasPrecisTokens,
@@ -355,6 +588,14 @@ func LoadConfig() (*Config, error) {
 		PDFMaxChunksTokens:    pdfMaxChunksTokens,
 		PDFSummaryRatioTokens: pdfSummaryRatio,
 
+		// Canvas Analysis Batching
+		CanvasBatchTokens:      canvasBatchTokens,
+		CanvasBatchConcurrency: canvasBatchConcurrency,
+
+		// Zone Collages
+		CollageCellSize:  collageCellSize,
+		CollageMaxImages: collageMaxImages,
+
 		// Processing Configuration (optimized for local GPU)
 		MaxRetries:        maxRetries,
 		RetryDelay:        retryDelay,
@@ -363,23 +604,44 @@ func LoadConfig() (*Config, error) {
 		MaxConcurrent:     maxConcurrent,
 		MaxFileSize:       maxFileSize,
 		DownloadsDir:      downloadsDir,
+
+		// Connection Pool
+		HTTPMaxIdleConnsPerHost: httpMaxIdleConnsPerHost,
+		HTTPIdleConnTimeout:     httpIdleConnTimeout,
+		HTTPConnectTimeout:      httpConnectTimeout,
+
+		// Widget Stream Keepalive
+		StreamIdleTimeout:  time.Duration(streamIdleTimeout) * time.Second,
+		StreamPingInterval: time.Duration(streamPingInterval) * time.Second,
+
+		JSONRepairAttempts: jsonRepairAttempts,
+
+		// OCR Configuration
+		OCRPreserveLayout: ocrPreserveLayout,
+
+		// Cloud Vision Privacy Configuration
+		CloudVisionStripMetadata: cloudVisionStripMetadata,
+		CloudVisionMaxDimension:  cloudVisionMaxDimension,
+
+		// AI Response Notes
+		NoteColor:     getEnvOrDefault("NOTE_COLOR", "#FFFFFF"),
+		NoteTextColor: getEnvOrDefault("NOTE_TEXT_COLOR", "#000000"),
+
+		Language: language,
+
+		Warnings: warnings,
 	}, nil
 }
 
 // GetHTTPClient returns an HTTP client configured with TLS settings based on AllowSelfSignedCerts
-// This should be used for all HTTP requests to external APIs to ensure TLS configuration is respected
+// This should be used for all HTTP requests to external APIs to ensure TLS configuration is respected.
+// Clients share a pool of keep-alive connections (see sharedTransport), so creating one per
+// request is cheap. Completion requests sent with it are captured while LLM capture mode is on.
 func GetHTTPClient(cfg *Config, timeout time.Duration) *http.Client {
-	client := &http.Client{
-		Timeout: timeout,
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: llmcapture.WrapTransport(sharedTransport(cfg)),
 	}
-
-	if cfg.AllowSelfSignedCerts {
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-	}
-
-	return client
 }
 
 // GetDefaultHTTPClient returns an HTTP client with default timeout (30s) configured with TLS settings
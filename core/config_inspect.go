@@ -0,0 +1,353 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Source says where the effective value of a setting came from.
+type Source string
+
+const (
+	SourceEnvironment Source = "environment"
+	SourceEnvFile     Source = ".env"
+	SourceDefault     Source = "default"
+	SourceUnset       Source = "unset"
+)
+
+// Severity of a configuration problem. Errors stop startup; warnings are
+// logged.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// ConfigProblem is one finding of InspectConfig.
+type ConfigProblem struct {
+	Setting  string
+	Severity Severity
+	Message  string
+}
+
+// EffectiveSetting is a setting with the value the application will use.
+type EffectiveSetting struct {
+	Setting
+	Value  string // Empty when unset
+	Source Source
+	// SetAs is the deprecated alias the value was read from, if any
+	SetAs string
+}
+
+// DisplayValue returns the value for printing, with secrets redacted.
+func (e EffectiveSetting) DisplayValue() string {
+	if e.Secret && e.Value != "" {
+		return "********"
+	}
+	return e.Value
+}
+
+// ConfigReport is the result of InspectConfig.
+type ConfigReport struct {
+	Settings []EffectiveSetting
+	Problems []ConfigProblem
+}
+
+// Errors returns the problems that stop startup.
+func (r *ConfigReport) Errors() []ConfigProblem {
+	return r.filter(SeverityError)
+}
+
+// Warnings returns the problems that are only logged.
+func (r *ConfigReport) Warnings() []ConfigProblem {
+	return r.filter(SeverityWarning)
+}
+
+func (r *ConfigReport) filter(severity Severity) []ConfigProblem {
+	var problems []ConfigProblem
+	for _, p := range r.Problems {
+		if p.Severity == severity {
+			problems = append(problems, p)
+		}
+	}
+	return problems
+}
+
+// EnvironMap returns the process environment as a map.
+func EnvironMap() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		if key, value, ok := strings.Cut(kv, "="); ok {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// InspectConfig resolves every schema setting against env and checks it.
+// fileValues holds the assignments of the .env file, used to tell values
+// loaded from it apart from the environment; it may be nil. Empty values
+// count as unset, as they do for LoadConfig.
+func InspectConfig(env, fileValues map[string]string) *ConfigReport {
+	report := &ConfigReport{}
+	problem := func(name string, severity Severity, format string, args ...interface{}) {
+		report.Problems = append(report.Problems, ConfigProblem{
+			Setting:  name,
+			Severity: severity,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, s := range configSchema {
+		e := EffectiveSetting{Setting: s, Value: env[s.Name], Source: SourceUnset}
+		for _, alias := range s.Aliases {
+			if env[alias] == "" {
+				continue
+			}
+			if e.Value != "" {
+				problem(alias, SeverityWarning, "%s is ignored because %s is set", alias, s.Name)
+				continue
+			}
+			e.Value, e.SetAs = env[alias], alias
+			problem(alias, SeverityWarning, "%s is deprecated; rename it to %s", alias, s.Name)
+		}
+
+		switch {
+		case e.Value != "":
+			name := s.Name
+			if e.SetAs != "" {
+				name = e.SetAs
+			}
+			e.Source = SourceEnvironment
+			if fileValue, ok := fileValues[name]; ok && fileValue == e.Value {
+				e.Source = SourceEnvFile
+			}
+		case s.Default != "":
+			e.Value, e.Source = s.Default, SourceDefault
+		}
+		report.Settings = append(report.Settings, e)
+
+		if e.Source == SourceUnset {
+			if s.Required {
+				problem(s.Name, SeverityError, "%s is required", s.Name)
+			}
+			continue
+		}
+		if e.Source == SourceDefault {
+			continue
+		}
+		if s.DependsOn != "" && env[s.DependsOn] == "" {
+			problem(s.Name, SeverityWarning, "%s has no effect unless %s is set", s.Name, s.DependsOn)
+			continue
+		}
+		if msg := checkSetting(s, e.Value); msg != "" {
+			severity := SeverityError
+			if s.Type == TypeBool && s.StrictBool {
+				severity = SeverityWarning
+			}
+			problem(s.Name, severity, "%s %s", s.Name, msg)
+		}
+	}
+
+	crossCheckConfig(report, problem)
+
+	for _, name := range unknownSettings(env) {
+		if suggestion := closestSetting(name); suggestion != "" {
+			problem(name, SeverityWarning, "%s is not a known setting (did you mean %s?)", name, suggestion)
+		} else {
+			problem(name, SeverityWarning, "%s is not a known setting", name)
+		}
+	}
+	return report
+}
+
+// Value returns the effective value of the named setting.
+func (r *ConfigReport) Value(name string) string {
+	for _, e := range r.Settings {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+// checkSetting checks value against the type and constraints of s. It
+// returns the end of an error message, or "" if the value is valid.
+func checkSetting(s Setting, value string) string {
+	var number float64
+	switch s.Type {
+	case TypeInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Sprintf("must be a whole number, got %q", value)
+		}
+		number = float64(n)
+	case TypeFloat:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Sprintf("must be a number, got %q", value)
+		}
+		number = f
+	case TypeBool:
+		if s.StrictBool {
+			if value != "true" && value != "false" {
+				return fmt.Sprintf("is only enabled by exactly \"true\"; %q is read as false", value)
+			}
+			return ""
+		}
+		switch strings.ToLower(strings.TrimSpace(value)) {
+		case "true", "1", "yes", "on", "false", "0", "no", "off":
+			return ""
+		}
+		return fmt.Sprintf("must be true or false, got %q", value)
+	case TypeURL:
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Sprintf("must be an http:// or https:// URL, got %q", value)
+		}
+	case TypeEnum:
+		found := false
+		for _, choice := range s.Choices {
+			if strings.EqualFold(strings.TrimSpace(value), choice) {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Sprintf("must be one of %s, got %q", strings.Join(s.Choices, ", "), value)
+		}
+	}
+
+	if s.Type == TypeInt || s.Type == TypeFloat {
+		if msg := checkRange(s, number); msg != "" {
+			return fmt.Sprintf("%s, got %s", msg, value)
+		}
+		if s.MultipleOf > 0 && int(number)%s.MultipleOf != 0 {
+			return fmt.Sprintf("must be divisible by %d, got %s", s.MultipleOf, value)
+		}
+	}
+
+	if s.Check != nil {
+		if err := s.Check(value); err != nil {
+			return fmt.Sprintf("is invalid: %v", err)
+		}
+	}
+	return ""
+}
+
+// checkRange returns the violated bound of s, or "".
+func checkRange(s Setting, number float64) string {
+	outside := (s.Min != nil && number < *s.Min) || (s.Max != nil && number > *s.Max)
+	if !outside {
+		return ""
+	}
+	return rangeText(s)
+}
+
+// rangeText describes the bounds of s, e.g. "must be between 1 and 10".
+func rangeText(s Setting) string {
+	switch {
+	case s.Min != nil && s.Max != nil:
+		return fmt.Sprintf("must be between %s and %s", formatBound(*s.Min), formatBound(*s.Max))
+	case s.Min != nil && *s.Min == 0:
+		return "must not be negative"
+	case s.Min != nil:
+		return fmt.Sprintf("must be at least %s", formatBound(*s.Min))
+	case s.Max != nil:
+		return fmt.Sprintf("must be at most %s", formatBound(*s.Max))
+	}
+	return ""
+}
+
+func formatBound(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// crossCheckConfig checks constraints between settings.
+func crossCheckConfig(report *ConfigReport, problem func(string, Severity, string, ...interface{})) {
+	canvasID, canvasIDs := report.Value("CANVAS_ID"), strings.Trim(report.Value("CANVAS_IDS"), ", ")
+	switch {
+	case canvasID == "" && canvasIDs == "":
+		problem("CANVAS_ID", SeverityError, "CANVAS_ID or CANVAS_IDS is required")
+	case canvasID != "" && canvasIDs != "":
+		problem("CANVAS_ID", SeverityWarning, "CANVAS_ID is ignored because CANVAS_IDS is set")
+	}
+
+	if report.Value("SD_MODEL_PATH") != "" {
+		size, err1 := strconv.Atoi(report.Value("SD_IMAGE_SIZE"))
+		maxSize, err2 := strconv.Atoi(report.Value("SD_MAX_IMAGE_SIZE"))
+		if err1 == nil && err2 == nil && size > maxSize {
+			problem("SD_IMAGE_SIZE", SeverityError, "SD_IMAGE_SIZE must not exceed SD_MAX_IMAGE_SIZE (%d), got %d", maxSize, size)
+		}
+	}
+
+	// llama.cpp needs flash attention to quantize the V cache
+	typeV := strings.ToLower(strings.TrimSpace(report.Value("LLAMA_TYPE_V")))
+	if flashAttn, err := strconv.ParseBool(report.Value("LLAMA_FLASH_ATTN")); err == nil && !flashAttn &&
+		typeV != "" && typeV != "f16" && typeV != "f32" {
+		problem("LLAMA_TYPE_V", SeverityError,
+			"LLAMA_TYPE_V=%s needs flash attention (set LLAMA_FLASH_ATTN=true or LLAMA_TYPE_V=f16)", typeV)
+	}
+}
+
+// unknownSettings returns the set variables that use a schema prefix but
+// are not in the schema, sorted.
+func unknownSettings(env map[string]string) []string {
+	var names []string
+	for name, value := range env {
+		if value == "" || !hasConfigPrefix(name) {
+			continue
+		}
+		if _, ok := LookupSetting(name); !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func hasConfigPrefix(name string) bool {
+	for _, prefix := range configPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// closestSetting returns the schema setting nearest to name by edit
+// distance, or "" if none is close enough to be a likely typo.
+func closestSetting(name string) string {
+	best, bestDistance := "", 4
+	for _, s := range configSchema {
+		if d := editDistance(name, s.Name); d < bestDistance {
+			best, bestDistance = s.Name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,252 @@
+package core
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// validEnv returns the minimal environment LoadConfig accepts.
+func validEnv() map[string]string {
+	return map[string]string{
+		"CANVUS_SERVER":  "https://canvus.example.com",
+		"CANVUS_API_KEY": "key",
+		"CANVAS_ID":      "canvas-1",
+		"WEBUI_PWD":      "secret",
+	}
+}
+
+// problemFor returns the first problem reported for setting.
+func problemFor(report *ConfigReport, setting string) (ConfigProblem, bool) {
+	for _, p := range report.Problems {
+		if p.Setting == setting {
+			return p, true
+		}
+	}
+	return ConfigProblem{}, false
+}
+
+func TestInspectConfigValid(t *testing.T) {
+	report := InspectConfig(validEnv(), nil)
+	if len(report.Problems) != 0 {
+		t.Fatalf("Problems = %v, want none", report.Problems)
+	}
+	if got := report.Value("PORT"); got != "3000" {
+		t.Errorf("PORT = %q, want default 3000", got)
+	}
+}
+
+func TestInspectConfigSources(t *testing.T) {
+	env := validEnv()
+	env["PORT"] = "8080"
+	env["MAX_RETRIES"] = "5"
+	report := InspectConfig(env, map[string]string{"PORT": "8080", "MAX_RETRIES": "2"})
+
+	want := map[string]Source{
+		"PORT":          SourceEnvFile,
+		"MAX_RETRIES":   SourceEnvironment, // Set in both; the environment wins
+		"AI_TIMEOUT":    SourceDefault,
+		"SD_MODEL_PATH": SourceUnset,
+	}
+	for _, e := range report.Settings {
+		if source, ok := want[e.Name]; ok && e.Source != source {
+			t.Errorf("%s source = %s, want %s", e.Name, e.Source, source)
+		}
+	}
+}
+
+func TestInspectConfigErrors(t *testing.T) {
+	tests := []struct {
+		name, key, value, want string
+	}{
+		{"not a number", "MAX_RETRIES", "three", `MAX_RETRIES must be a whole number, got "three"`},
+		{"below minimum", "MAX_CONCURRENT", "0", "MAX_CONCURRENT must be at least 1, got 0"},
+		{"negative", "RETRY_DELAY", "-1", "RETRY_DELAY must not be negative, got -1"},
+		{"out of range", "PORT", "70000", "PORT must be between 1 and 65535, got 70000"},
+		{"bad url", "CANVUS_SERVER", "canvus.example.com", "CANVUS_SERVER must be an http:// or https:// URL"},
+		{"bad choice", "LLAMA_TYPE_K", "q3", "LLAMA_TYPE_K must be one of f32, f16"},
+		{"bad bool", "LLAMA_AUTO_OFFLOAD", "maybe", `LLAMA_AUTO_OFFLOAD must be true or false, got "maybe"`},
+		{"custom check", "PROXY_OVERRIDES", "canvus.example.com", "PROXY_OVERRIDES is invalid: netproxy: invalid override"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := validEnv()
+			env[tt.key] = tt.value
+			p, ok := problemFor(InspectConfig(env, nil), tt.key)
+			if !ok || p.Severity != SeverityError || !strings.Contains(p.Message, tt.want) {
+				t.Errorf("problem = %+v, want error containing %q", p, tt.want)
+			}
+		})
+	}
+}
+
+func TestInspectConfigRequired(t *testing.T) {
+	report := InspectConfig(map[string]string{}, nil)
+	for _, name := range []string{"CANVUS_SERVER", "CANVUS_API_KEY", "WEBUI_PWD", "CANVAS_ID"} {
+		if p, ok := problemFor(report, name); !ok || p.Severity != SeverityError {
+			t.Errorf("%s: problem = %+v, want required error", name, p)
+		}
+	}
+}
+
+func TestInspectConfigStableDiffusion(t *testing.T) {
+	env := validEnv()
+	env["SD_IMAGE_SIZE"] = "500"
+	if p, _ := problemFor(InspectConfig(env, nil), "SD_IMAGE_SIZE"); p.Severity != SeverityWarning {
+		t.Errorf("without SD_MODEL_PATH: problem = %+v, want no-effect warning", p)
+	}
+
+	env["SD_MODEL_PATH"] = "model.safetensors"
+	if p, _ := problemFor(InspectConfig(env, nil), "SD_IMAGE_SIZE"); !strings.Contains(p.Message, "divisible by 8") {
+		t.Errorf("with SD_MODEL_PATH: problem = %+v, want divisible by 8 error", p)
+	}
+
+	env["SD_IMAGE_SIZE"] = "2048"
+	if p, _ := problemFor(InspectConfig(env, nil), "SD_IMAGE_SIZE"); !strings.Contains(p.Message, "SD_MAX_IMAGE_SIZE") {
+		t.Errorf("above maximum: problem = %+v, want SD_MAX_IMAGE_SIZE error", p)
+	}
+}
+
+func TestInspectConfigRemoteWorker(t *testing.T) {
+	env := validEnv()
+	env["SD_WORKER_URL"] = "http://gpu-host:8091"
+	env["SD_WORKER_TOKEN"] = "worker-secret"
+	env["SD_WORKER_TIMEOUT"] = "300"
+	report := InspectConfig(env, nil)
+	if len(report.Problems) != 0 {
+		t.Fatalf("Problems = %v, want none", report.Problems)
+	}
+	for _, e := range report.Settings {
+		if e.Name == "SD_WORKER_TOKEN" && e.DisplayValue() == e.Value {
+			t.Error("secret SD_WORKER_TOKEN was not redacted")
+		}
+	}
+}
+
+func TestInspectConfigImageBackend(t *testing.T) {
+	env := validEnv()
+	env["SD_BACKEND"] = "ComfyUI"
+	env["SD_BACKEND_URL"] = "http://127.0.0.1:8188"
+	env["SD_BACKEND_CHECKPOINT"] = "sdxl.safetensors"
+	env["SD_BACKEND_TEMPLATE"] = "workflow.json"
+	env["SD_BACKEND_TIMEOUT"] = "180"
+	if report := InspectConfig(env, nil); len(report.Problems) != 0 {
+		t.Fatalf("Problems = %v, want none", report.Problems)
+	}
+
+	env["SD_BACKEND"] = "invokeai"
+	if p, _ := problemFor(InspectConfig(env, nil), "SD_BACKEND"); !strings.Contains(p.Message, "must be one of local, comfyui, a1111") {
+		t.Errorf("problem = %+v, want choice error", p)
+	}
+}
+
+func TestInspectConfigFlashAttention(t *testing.T) {
+	env := validEnv()
+	env["LLAMA_TYPE_V"] = "q8_0"
+	if _, ok := problemFor(InspectConfig(env, nil), "LLAMA_TYPE_V"); ok {
+		t.Error("quantized V cache with default flash attention reported a problem")
+	}
+	env["LLAMA_FLASH_ATTN"] = "false"
+	if p, _ := problemFor(InspectConfig(env, nil), "LLAMA_TYPE_V"); p.Severity != SeverityError {
+		t.Errorf("problem = %+v, want flash attention error", p)
+	}
+}
+
+func TestInspectConfigWarnings(t *testing.T) {
+	env := validEnv()
+	env["OPENAI_KEY"] = "sk-legacy"
+	env["OCR_PRESERVE_LAYOUT"] = "True"
+	env["SD_INFERENCE_STEP"] = "30"
+	env["CANVAS_IDS"] = "a,b"
+	env["CANVAS_POLICY_FILE"] = "policy.json"
+	report := InspectConfig(env, nil)
+
+	if errs := report.Errors(); len(errs) != 0 {
+		t.Fatalf("Errors = %v, want none", errs)
+	}
+	for setting, want := range map[string]string{
+		"OPENAI_KEY":          "rename it to OPENAI_API_KEY",
+		"OCR_PRESERVE_LAYOUT": `"True" is read as false`,
+		"SD_INFERENCE_STEP":   "did you mean SD_INFERENCE_STEPS?",
+		"CANVAS_ID":           "ignored because CANVAS_IDS is set",
+	} {
+		if p, _ := problemFor(report, setting); !strings.Contains(p.Message, want) {
+			t.Errorf("%s: problem = %+v, want warning containing %q", setting, p, want)
+		}
+	}
+
+	if p, ok := problemFor(report, "CANVAS_POLICY_FILE"); ok {
+		t.Errorf("CANVAS_POLICY_FILE: problem = %+v, want none", p)
+	}
+
+	for _, e := range report.Settings {
+		if e.Name == "OPENAI_API_KEY" {
+			if e.Value != "sk-legacy" || e.SetAs != "OPENAI_KEY" {
+				t.Errorf("OPENAI_API_KEY = %q set as %q, want value of OPENAI_KEY", e.Value, e.SetAs)
+			}
+			if e.DisplayValue() == e.Value {
+				t.Error("secret OPENAI_API_KEY was not redacted")
+			}
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"PORT", "PORT", 0},
+		{"PROT", "PORT", 2},
+		{"SD_IMAGE_SIZ", "SD_IMAGE_SIZE", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSchemaDefaultsAreValid(t *testing.T) {
+	for _, s := range ConfigSchema() {
+		if s.Default == "" {
+			continue
+		}
+		if msg := checkSetting(s, s.Default); msg != "" {
+			t.Errorf("default of %s: %s %s", s.Name, s.Name, msg)
+		}
+	}
+}
+
+// The reference in docs must be regenerated whenever the schema changes.
+func TestConfigReferenceUpToDate(t *testing.T) {
+	data, err := os.ReadFile("../docs/configuration-reference.md")
+	if err != nil {
+		t.Fatalf("read reference: %v", err)
+	}
+	if string(data) != ConfigReference() {
+		t.Error("docs/configuration-reference.md is out of date; regenerate it with: canvuslocallm config docs > docs/configuration-reference.md")
+	}
+}
+
+// Every setting example.env documents, set or commented out, must be in
+// the schema so that config check and the reference cover it.
+func TestExampleEnvInSchema(t *testing.T) {
+	data, err := os.ReadFile("../example.env")
+	if err != nil {
+		t.Fatalf("read example.env: %v", err)
+	}
+	assignment := regexp.MustCompile(`^#?\s*([A-Z][A-Z0-9_]+)=`)
+	for _, line := range strings.Split(string(data), "\n") {
+		m := assignment.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		if _, ok := LookupSetting(m[1]); !ok {
+			t.Errorf("example.env documents %s, which is missing from configSchema", m[1])
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConfigReference renders the schema as the Markdown document in
+// docs/configuration-reference.md.
+func ConfigReference() string {
+	var b strings.Builder
+	b.WriteString("# Configuration Reference\n\n")
+	b.WriteString("<!-- Generated by `canvuslocallm config docs` from core/config_schema.go. Do not edit by hand. -->\n\n")
+	b.WriteString("All settings are environment variables, usually set in `.env`. ")
+	b.WriteString("Run `canvuslocallm config validate` to check a configuration and ")
+	b.WriteString("`canvuslocallm config print` to see the effective values and where they came from.\n")
+
+	group := ""
+	for _, s := range configSchema {
+		if s.Group != group {
+			group = s.Group
+			fmt.Fprintf(&b, "\n## %s\n\n", group)
+			b.WriteString("| Variable | Default | Description |\n")
+			b.WriteString("|----------|---------|-------------|\n")
+		}
+		def := "-"
+		if s.Default != "" {
+			def = "`" + s.Default + "`"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", s.Name, def, strings.ReplaceAll(settingNotes(s), "|", `\|`))
+	}
+	return b.String()
+}
+
+// settingNotes returns the description of s followed by its constraints.
+func settingNotes(s Setting) string {
+	notes := []string{s.Description}
+	if s.Required {
+		notes = append(notes, "Required")
+	}
+	if s.Unit != "" {
+		notes = append(notes, "In "+s.Unit)
+	}
+	if len(s.Choices) > 0 {
+		notes = append(notes, "One of "+strings.Join(s.Choices, ", "))
+	}
+	if r := rangeText(s); r != "" {
+		notes = append(notes, strings.ToUpper(r[:1])+r[1:])
+	}
+	if s.MultipleOf > 0 {
+		notes = append(notes, fmt.Sprintf("Must be divisible by %d", s.MultipleOf))
+	}
+	if s.StrictBool {
+		notes = append(notes, `Only exactly "true" enables it`)
+	}
+	if s.DependsOn != "" {
+		notes = append(notes, "Checked when "+s.DependsOn+" is set")
+	}
+	if len(s.Aliases) > 0 {
+		notes = append(notes, "Deprecated name: "+strings.Join(s.Aliases, ", "))
+	}
+	return strings.Join(notes, ". ") + "."
+}
@@ -0,0 +1,652 @@
+package core
+
+import (
+	"go_backend/i18n"
+	"go_backend/netproxy"
+)
+
+// SettingType is the kind of value a setting holds.
+type SettingType string
+
+const (
+	TypeString SettingType = "string"
+	TypeInt    SettingType = "int"
+	TypeFloat  SettingType = "float"
+	TypeBool   SettingType = "bool"
+	TypeURL    SettingType = "url"
+	TypeEnum   SettingType = "enum"
+	TypeList   SettingType = "list"
+)
+
+// Setting describes one environment variable: its type, default and the
+// constraints LoadConfig and `config validate` check it against.
+type Setting struct {
+	Name        string
+	Group       string
+	Type        SettingType
+	Default     string // As written in .env; empty means unset
+	Unit        string // e.g. seconds, minutes, bytes, tokens
+	Description string
+	Required    bool
+	Secret      bool // Value is redacted by `config print`
+
+	// Constraints; Min and Max are nil when unbounded
+	Choices    []string // Allowed values of a TypeEnum, compared case-insensitively
+	Min, Max   *float64
+	MultipleOf int
+	// StrictBool marks booleans read as `value == "true"`, so that "True"
+	// or "1" silently mean false
+	StrictBool bool
+	// Check validates values the type cannot express, e.g. proxy rules
+	Check func(value string) error
+	// DependsOn names a setting that must be set for the constraints to
+	// apply, e.g. SD_* limits only matter with SD_MODEL_PATH
+	DependsOn string
+
+	// Aliases are deprecated names still read when Name is unset
+	Aliases []string
+}
+
+// bound returns a pointer to v for Setting.Min and Setting.Max.
+func bound(v float64) *float64 {
+	return &v
+}
+
+// configPrefixes are the prefixes of variables the schema owns. Unknown
+// variables with these prefixes are reported as likely typos.
+var configPrefixes = []string{
+	"CANVUS_", "CANVAS_", "OPENAI_", "AZURE_OPENAI_", "LLAMA_", "SD_",
+	"GPU_WORKER_", "CLUSTER_", "INSTANCE_LEASE", "WEBHOOK_", "MAILIN_", "ARTIFACT_", "UPDATE_",
+	"THERMAL_", "WHISPER_", "LIVE_TRANSCRIPT_", "AUTO_TAG", "TEST_DETERMINISTIC",
+}
+
+// configSchema lists every setting the service reads, in documentation
+// order. example.env documents the same settings; TestExampleEnvInSchema
+// keeps the two in step.
+var configSchema = []Setting{
+	// Canvus Server
+	{Name: "CANVUS_SERVER", Group: "Canvus Server", Type: TypeURL, Required: true,
+		Description: "URL of the Canvus server, including https://"},
+	{Name: "CANVUS_API_KEY", Group: "Canvus Server", Type: TypeString, Required: true, Secret: true,
+		Description: "Canvus API key"},
+	{Name: "CANVAS_ID", Group: "Canvus Server", Type: TypeString,
+		Description: "Canvas to monitor; CANVAS_ID or CANVAS_IDS is required"},
+	{Name: "CANVAS_IDS", Group: "Canvus Server", Type: TypeList,
+		Description: "Comma-separated canvases to monitor; overrides CANVAS_ID"},
+	{Name: "CANVAS_NAME", Group: "Canvus Server", Type: TypeString,
+		Description: "Display name of CANVAS_ID"},
+	{Name: "CANVUS_USERNAME", Group: "Canvus Server", Type: TypeString,
+		Description: "Canvus user for startup validation when no API key is set"},
+	{Name: "CANVUS_PASSWORD", Group: "Canvus Server", Type: TypeString, Secret: true,
+		Description: "Password of CANVUS_USERNAME"},
+	{Name: "ALLOW_SELF_SIGNED_CERTS", Group: "Canvus Server", Type: TypeBool, Default: "false", StrictBool: true,
+		Description: "Skip TLS certificate verification (development only)"},
+	{Name: "CANVUS_IP_FAMILY", Group: "Canvus Server", Type: TypeEnum, Default: "auto",
+		Choices:     []string{"auto", "prefer-ipv4", "prefer-ipv6", "ipv4", "ipv6"},
+		Description: "Address family used to reach a dual-stack Canvus server"},
+	{Name: "CANVUS_HAPPY_EYEBALLS_DELAY", Group: "Canvus Server", Type: TypeInt, Default: "250", Unit: "milliseconds", Min: bound(1),
+		Description: "Head start of the preferred address family before the other is tried"},
+	{Name: "WIDGET_STREAM_IDLE_TIMEOUT", Group: "Canvus Server", Type: TypeInt, Default: "60", Unit: "seconds", Min: bound(0),
+		Description: "Reconnect the widget stream after this long without data (0 = never)"},
+	{Name: "WIDGET_STREAM_PING_INTERVAL", Group: "Canvus Server", Type: TypeInt, Default: "30", Unit: "seconds", Min: bound(0),
+		Description: "Ping the server this often while streaming (0 = never)"},
+	{Name: "CANVAS_PREVIEW_REFRESH", Group: "Canvus Server", Type: TypeInt, Default: "30", Unit: "seconds", Min: bound(0),
+		Description: "Refresh interval of the dashboard canvas preview"},
+
+	// Web UI
+	{Name: "WEBUI_PWD", Group: "Web UI", Type: TypeString, Required: true, Secret: true,
+		Description: "Dashboard password"},
+	{Name: "PORT", Group: "Web UI", Type: TypeInt, Default: "3000", Min: bound(1), Max: bound(65535),
+		Description: "Dashboard port"},
+	{Name: "WEBUI_PUBLIC_URL", Group: "Web UI", Type: TypeURL,
+		Description: "Address canvas users reach the dashboard at, used for links in notes"},
+	{Name: "LANGUAGE", Group: "Web UI", Type: TypeString, Default: i18n.English,
+		Description: "Language of canvas messages and AI responses; unsupported values fall back to English"},
+	{Name: "PROMPTS_DIR", Group: "Web UI", Type: TypeString,
+		Description: "Directory of system prompts per language, as <dir>/<language>/<prompt>.txt"},
+
+	// LLM Endpoints
+	{Name: "BASE_LLM_URL", Group: "LLM Endpoints", Type: TypeURL, Default: "http://127.0.0.1:1234/v1",
+		Aliases:     []string{"OPENAI_API_BASE"},
+		Description: "OpenAI-compatible endpoint for all LLM requests"},
+	{Name: "TEXT_LLM_URL", Group: "LLM Endpoints", Type: TypeURL,
+		Description: "Endpoint for text generation; defaults to BASE_LLM_URL"},
+	{Name: "IMAGE_LLM_URL", Group: "LLM Endpoints", Type: TypeURL,
+		Description: "Endpoint for image generation; empty uses local Stable Diffusion"},
+	{Name: "OPENAI_API_KEY", Group: "LLM Endpoints", Type: TypeString, Secret: true,
+		Aliases:     []string{"OPENAI_KEY"},
+		Description: "API key for cloud LLM fallback"},
+	{Name: "GOOGLE_VISION_API_KEY", Group: "LLM Endpoints", Type: TypeString, Secret: true,
+		Description: "Google Vision API key for cloud OCR"},
+	{Name: "AZURE_OPENAI_ENDPOINT", Group: "LLM Endpoints", Type: TypeURL,
+		Description: "Azure OpenAI endpoint for cloud image generation"},
+	{Name: "AZURE_OPENAI_DEPLOYMENT", Group: "LLM Endpoints", Type: TypeString,
+		Description: "Azure OpenAI deployment name"},
+	{Name: "AZURE_OPENAI_API_VERSION", Group: "LLM Endpoints", Type: TypeString, Default: "2024-02-15-preview",
+		Description: "Azure OpenAI API version"},
+	{Name: "OPENAI_NOTE_MODEL", Group: "LLM Endpoints", Type: TypeString,
+		Description: "Model for note responses (cloud endpoints only)"},
+	{Name: "OPENAI_CANVAS_MODEL", Group: "LLM Endpoints", Type: TypeString,
+		Description: "Model for canvas analysis (cloud endpoints only)"},
+	{Name: "OPENAI_PDF_MODEL", Group: "LLM Endpoints", Type: TypeString,
+		Description: "Model for PDF precis (cloud endpoints only)"},
+	{Name: "IMAGE_GEN_MODEL", Group: "LLM Endpoints", Type: TypeString,
+		Description: "Model for cloud image generation; stability:... or replicate:... models use those services"},
+	{Name: "IMAGE_GEN_SIZE", Group: "LLM Endpoints", Type: TypeEnum, Default: "1024x1024",
+		Choices:     []string{"256x256", "512x512", "1024x1024", "1792x1024", "1024x1792", "1536x1024", "1024x1536", "auto"},
+		Description: "Size of cloud-generated images; 1792x1024 and 1024x1792 need dall-e-3"},
+	{Name: "IMAGE_GEN_QUALITY", Group: "LLM Endpoints", Type: TypeEnum,
+		Choices:     []string{"standard", "hd", "low", "medium", "high", "auto"},
+		Description: "Quality of cloud-generated images: standard or hd for dall-e-3, low to high for gpt-image-1"},
+	{Name: "IMAGE_GEN_STYLE", Group: "LLM Endpoints", Type: TypeEnum, Default: "vivid",
+		Choices:     []string{"vivid", "natural"},
+		Description: "Style of dall-e-3 images"},
+	{Name: "IMAGE_MAX_COUNT", Group: "LLM Endpoints", Type: TypeInt, Default: "4",
+		Min: bound(1), Max: bound(10),
+		Description: "Most images one trigger may ask for with --n"},
+	{Name: "STABILITY_API_KEY", Group: "LLM Endpoints", Type: TypeString, Secret: true,
+		Description: "Stability AI API key for stability: image models"},
+	{Name: "REPLICATE_API_TOKEN", Group: "LLM Endpoints", Type: TypeString, Secret: true,
+		Description: "Replicate API token for replicate: image models"},
+
+	// Token Limits
+	{Name: "OPENAI_NOTE_RESPONSE_TOKENS", Group: "Token Limits", Type: TypeInt, Default: "400", Unit: "tokens", Min: bound(1),
+		Description: "Maximum length of a note response"},
+	{Name: "OPENAI_PDF_PRECIS_TOKENS", Group: "Token Limits", Type: TypeInt, Default: "1000", Unit: "tokens", Min: bound(1),
+		Description: "Maximum length of a PDF precis"},
+	{Name: "OPENAI_CANVAS_PRECIS_TOKENS", Group: "Token Limits", Type: TypeInt, Default: "600", Unit: "tokens", Min: bound(1),
+		Description: "Maximum length of a canvas analysis"},
+	{Name: "OPENAI_IMAGE_ANALYSIS_TOKENS", Group: "Token Limits", Type: TypeInt, Default: "16384", Unit: "tokens", Min: bound(1),
+		Description: "Maximum length of an image analysis"},
+	{Name: "OPENAI_ERROR_RESPONSE_TOKENS", Group: "Token Limits", Type: TypeInt, Default: "200", Unit: "tokens", Min: bound(1),
+		Description: "Maximum length of an error explanation"},
+	{Name: "OPENAI_PDF_CHUNK_SIZE_TOKENS", Group: "Token Limits", Type: TypeInt, Default: "20000", Unit: "tokens", Min: bound(1),
+		Description: "Size of the chunks a PDF is split into"},
+	{Name: "OPENAI_PDF_MAX_CHUNKS_TOKENS", Group: "Token Limits", Type: TypeInt, Default: "10", Min: bound(1),
+		Description: "Maximum number of PDF chunks summarized"},
+	{Name: "OPENAI_PDF_SUMMARY_RATIO", Group: "Token Limits", Type: TypeFloat, Default: "0.3", Min: bound(0), Max: bound(1),
+		Description: "Length of a chunk summary relative to the chunk"},
+	{Name: "CANVAS_ANALYSIS_BATCH_TOKENS", Group: "Token Limits", Type: TypeInt, Default: "6000", Unit: "tokens", Min: bound(0),
+		Description: "Canvas analyses larger than this are summarized in groups (0 = never)"},
+	{Name: "CANVAS_ANALYSIS_CONCURRENCY", Group: "Token Limits", Type: TypeInt, Default: "1", Min: bound(1),
+		Description: "Widget groups summarized at once"},
+	{Name: "JSON_REPAIR_ATTEMPTS", Group: "Token Limits", Type: TypeInt, Default: "1", Min: bound(0), Max: bound(3),
+		Description: "Times the model is asked to fix a malformed JSON reply"},
+
+	// Local LLM (llama.cpp)
+	{Name: "LLAMA_MODEL_PATH", Group: "Local LLM (llama.cpp)", Type: TypeString,
+		Description: "GGUF model for local text generation"},
+	{Name: "LLAMA_MODEL_URL", Group: "Local LLM (llama.cpp)", Type: TypeURL,
+		Description: "Download URL used when LLAMA_MODEL_PATH does not exist"},
+	{Name: "LLAMA_MODELS_DIR", Group: "Local LLM (llama.cpp)", Type: TypeString, Default: "./models",
+		Description: "Directory models are stored in"},
+	{Name: "LLAMA_VISION_MODEL", Group: "Local LLM (llama.cpp)", Type: TypeString,
+		Description: "Model name recorded in history and the audit log for image analysis; the LLAMA_MODEL_PATH file name by default"},
+	{Name: "LLAMA_AUTO_DOWNLOAD", Group: "Local LLM (llama.cpp)", Type: TypeBool, Default: "false", StrictBool: true,
+		Description: "Download the model from LLAMA_MODEL_URL when it is missing"},
+	{Name: "LLAMA_IDLE_TIMEOUT", Group: "Local LLM (llama.cpp)", Type: TypeInt, Default: "0", Unit: "minutes", Min: bound(0),
+		Description: "Unload the model after this long idle (0 = never)"},
+	{Name: "LLAMA_AUTO_OFFLOAD", Group: "Local LLM (llama.cpp)", Type: TypeBool, Default: "true",
+		Description: "Size GPU layer offload to the free VRAM"},
+	{Name: "LLAMA_VRAM_HEADROOM_MB", Group: "Local LLM (llama.cpp)", Type: TypeInt, Unit: "MB", Min: bound(0),
+		Description: "VRAM left free by auto offload; 1024 by default, 4096 when SD_MODEL_PATH is set"},
+	{Name: "LLAMA_MIN_CONTEXT_SIZE", Group: "Local LLM (llama.cpp)", Type: TypeInt, Default: "512", Unit: "tokens", Min: bound(1),
+		Description: "Smallest context window to fall back to when the default does not fit"},
+	{Name: "LLAMA_FLASH_ATTN", Group: "Local LLM (llama.cpp)", Type: TypeBool, Default: "true",
+		Description: "Use flash attention; required for a quantized LLAMA_TYPE_V"},
+	{Name: "LLAMA_TYPE_K", Group: "Local LLM (llama.cpp)", Type: TypeEnum, Default: "f16",
+		Choices:     []string{"f32", "f16", "q8_0", "q5_1", "q5_0", "q4_1", "q4_0"},
+		Description: "KV cache type of keys"},
+	{Name: "LLAMA_TYPE_V", Group: "Local LLM (llama.cpp)", Type: TypeEnum, Default: "f16",
+		Choices:     []string{"f32", "f16", "q8_0", "q5_1", "q5_0", "q4_1", "q4_0"},
+		Description: "KV cache type of values"},
+	{Name: "LLAMA_N_BATCH", Group: "Local LLM (llama.cpp)", Type: TypeInt, Default: "512", Unit: "tokens", Min: bound(1), Max: bound(2048),
+		Description: "Prompt tokens processed per batch"},
+	{Name: "LLAMA_CHAT_TEMPLATE", Group: "Local LLM (llama.cpp)", Type: TypeString,
+		Description: "Chat template override; detected from the model when unset"},
+	{Name: "LLAMA_PARALLEL_SEQUENCES", Group: "Local LLM (llama.cpp)", Type: TypeInt, Default: "0", Min: bound(0),
+		Description: "Decode this many concurrent requests together (0 or 1 = off)"},
+
+	// Model Catalog
+	{Name: "LOCAL_MODEL_DIR", Group: "Model Catalog", Type: TypeString, Default: "./models",
+		Description: "Directory models downloaded from the dashboard catalog are saved in"},
+	{Name: "MODEL_CATALOG_PATH", Group: "Model Catalog", Type: TypeString,
+		Description: "Custom catalog manifest; the built-in catalog when empty"},
+	{Name: "REQUIRED_MODELS", Group: "Model Catalog", Type: TypeList,
+		Description: "Models checked at startup: registered names or hf://org/repo[@revision]/file URIs"},
+	{Name: "HF_TOKEN", Group: "Model Catalog", Type: TypeString, Secret: true,
+		Description: "Hugging Face access token for gated or private models, only sent to the Hub"},
+	{Name: "HF_ENDPOINT", Group: "Model Catalog", Type: TypeURL, Default: "https://huggingface.co",
+		Description: "Hugging Face Hub mirror for hf:// model URLs"},
+
+	// Stable Diffusion
+	{Name: "SD_MODEL_PATH", Group: "Stable Diffusion", Type: TypeString,
+		Description: "Model for local image generation; empty disables it"},
+	{Name: "SD_IMAGE_SIZE", Group: "Stable Diffusion", Type: TypeInt, Default: "512", Unit: "pixels",
+		Min: bound(128), MultipleOf: 8, DependsOn: "SD_MODEL_PATH",
+		Description: "Width and height of generated images, at most SD_MAX_IMAGE_SIZE"},
+	{Name: "SD_MAX_IMAGE_SIZE", Group: "Stable Diffusion", Type: TypeInt, Default: "1024", Unit: "pixels",
+		Min: bound(128), DependsOn: "SD_MODEL_PATH",
+		Description: "Largest image size a request may ask for"},
+	{Name: "SD_MAX_STEPS", Group: "Stable Diffusion", Type: TypeInt, Default: "50",
+		Min: bound(1), Max: bound(150), DependsOn: "SD_MODEL_PATH",
+		Description: "Most denoising steps a trigger may ask for with --steps"},
+	{Name: "SD_INFERENCE_STEPS", Group: "Stable Diffusion", Type: TypeInt, Default: "20",
+		Min: bound(1), Max: bound(150), DependsOn: "SD_MODEL_PATH",
+		Description: "Denoising steps per image"},
+	{Name: "SD_GUIDANCE_SCALE", Group: "Stable Diffusion", Type: TypeFloat, Default: "7.0",
+		Min: bound(1), Max: bound(20), DependsOn: "SD_MODEL_PATH",
+		Description: "How closely images follow the prompt (CFG scale)"},
+	{Name: "SD_NEGATIVE_PROMPT", Group: "Stable Diffusion", Type: TypeString,
+		Description: "What generated images should avoid"},
+	{Name: "SD_TIMEOUT_SECONDS", Group: "Stable Diffusion", Type: TypeInt, Default: "120", Unit: "seconds",
+		Min: bound(10), DependsOn: "SD_MODEL_PATH",
+		Description: "Generation timeout"},
+	{Name: "SD_MAX_CONCURRENT", Group: "Stable Diffusion", Type: TypeInt, Default: "2",
+		Min: bound(1), Max: bound(10), DependsOn: "SD_MODEL_PATH",
+		Description: "Images generated at once; each costs VRAM"},
+	{Name: "SD_IDLE_TIMEOUT", Group: "Stable Diffusion", Type: TypeInt, Default: "0", Unit: "minutes", Min: bound(0),
+		Description: "Free the model after this long idle (0 = never)"},
+	{Name: "SD_VRAM_RETRY_LADDER", Group: "Stable Diffusion", Type: TypeString,
+		Description: "Smaller size:steps pairs retried when VRAM runs out, e.g. 512:20,384:15"},
+	{Name: "WARMUP_ON_STARTUP", Group: "Stable Diffusion", Type: TypeBool, Default: "false", StrictBool: true,
+		Description: "Run a tiny generation on each local model at startup"},
+
+	// Remote GPU Worker
+	{Name: "SD_WORKER_URL", Group: "Remote GPU Worker", Type: TypeURL,
+		Description: "sd-worker to generate images on instead of loading SD_MODEL_PATH here (empty = generate locally)"},
+	{Name: "SD_WORKER_TOKEN", Group: "Remote GPU Worker", Type: TypeString, Secret: true,
+		Description: "Bearer token shared with the sd-worker"},
+	{Name: "SD_WORKER_TIMEOUT", Group: "Remote GPU Worker", Type: TypeInt, Unit: "seconds", Min: bound(1),
+		Description: "Time one remote image may take; defaults to SD_TIMEOUT_SECONDS"},
+	{Name: "SD_WORKER_ADDR", Group: "Remote GPU Worker", Type: TypeString, Default: ":8091",
+		Description: "Listen address of the sd-worker itself"},
+
+	// ComfyUI / AUTOMATIC1111
+	{Name: "SD_BACKEND", Group: "ComfyUI / AUTOMATIC1111", Type: TypeEnum, Default: "local",
+		Choices:     []string{"local", "comfyui", "a1111"},
+		Description: "Generate images with the embedded runtime or on an existing ComfyUI or AUTOMATIC1111 server"},
+	{Name: "SD_BACKEND_URL", Group: "ComfyUI / AUTOMATIC1111", Type: TypeURL, DependsOn: "SD_BACKEND",
+		Description: "Address of the ComfyUI or AUTOMATIC1111 server"},
+	{Name: "SD_BACKEND_CHECKPOINT", Group: "ComfyUI / AUTOMATIC1111", Type: TypeString,
+		Description: "Checkpoint for the built-in ComfyUI workflow (required there) or AUTOMATIC1111's override_settings"},
+	{Name: "SD_BACKEND_TEMPLATE", Group: "ComfyUI / AUTOMATIC1111", Type: TypeString,
+		Description: "JSON file: a ComfyUI workflow in API format, or extra txt2img fields, with {{prompt}}, {{width}}... placeholders"},
+	{Name: "SD_BACKEND_TIMEOUT", Group: "ComfyUI / AUTOMATIC1111", Type: TypeInt, Unit: "seconds", Min: bound(1),
+		Description: "Time one image may take; defaults to SD_TIMEOUT_SECONDS"},
+
+	// GPU Process Isolation
+	{Name: "GPU_ISOLATION", Group: "GPU Process Isolation", Type: TypeEnum, Default: "off",
+		Choices:     []string{"off", "process"},
+		Description: "process runs the local model runtimes in a child process that is restarted when it crashes"},
+	{Name: "GPU_WORKER_ADDR", Group: "GPU Process Isolation", Type: TypeString, Default: "127.0.0.1:8092",
+		Description: "Loopback address the GPU worker listens on"},
+	{Name: "GPU_WORKER_START_TIMEOUT", Group: "GPU Process Isolation", Type: TypeInt, Default: "120", Unit: "seconds", Min: bound(1),
+		Description: "Time the GPU worker may take to load its models on startup"},
+	{Name: "GPU_WORKER_MAX_RESTART_DELAY", Group: "GPU Process Isolation", Type: TypeInt, Default: "60", Unit: "seconds", Min: bound(1),
+		Description: "Cap of the restart backoff while the GPU worker keeps crashing"},
+
+	// Image Output
+	{Name: "IMAGE_OUTPUT_FORMAT", Group: "Image Output", Type: TypeEnum, Default: "png",
+		Choices:     []string{"png", "jpeg", "jpg", "webp"},
+		Description: "Format of generated images uploaded to the canvas"},
+	{Name: "IMAGE_OUTPUT_QUALITY", Group: "Image Output", Type: TypeInt, Default: "90", Min: bound(1), Max: bound(100),
+		Description: "JPEG quality"},
+	{Name: "IMAGE_MAX_UPLOAD_WIDTH", Group: "Image Output", Type: TypeInt, Default: "0", Unit: "pixels", Min: bound(0),
+		Description: "Scale down wider images before upload (0 = no limit)"},
+	{Name: "IMAGE_MAX_UPLOAD_HEIGHT", Group: "Image Output", Type: TypeInt, Default: "0", Unit: "pixels", Min: bound(0),
+		Description: "Scale down taller images before upload (0 = no limit)"},
+
+	// Processing
+	{Name: "MAX_RETRIES", Group: "Processing", Type: TypeInt, Default: "3", Min: bound(0),
+		Description: "Retries of a failed AI request"},
+	{Name: "RETRY_DELAY", Group: "Processing", Type: TypeInt, Default: "1", Unit: "seconds", Min: bound(0),
+		Description: "Delay between retries"},
+	{Name: "AI_TIMEOUT", Group: "Processing", Type: TypeInt, Default: "60", Unit: "seconds", Min: bound(1),
+		Description: "Timeout of one AI request"},
+	{Name: "PROCESSING_TIMEOUT", Group: "Processing", Type: TypeInt, Default: "300", Unit: "seconds", Min: bound(1),
+		Description: "Timeout of a whole operation"},
+	{Name: "MAX_CONCURRENT", Group: "Processing", Type: TypeInt, Default: "5", Min: bound(1),
+		Description: "Operations processed at once"},
+	{Name: "MAX_FILE_SIZE", Group: "Processing", Type: TypeInt, Default: "52428800", Unit: "bytes", Min: bound(1),
+		Description: "Largest file downloaded for processing"},
+	{Name: "DOWNLOADS_DIR", Group: "Processing", Type: TypeString, Default: "./downloads",
+		Description: "Directory for downloaded files"},
+	{Name: "OCR_PRESERVE_LAYOUT", Group: "Processing", Type: TypeBool, Default: "true", StrictBool: true,
+		Description: "Create one note per detected text block at its position"},
+	{Name: "CLOUD_VISION_STRIP_METADATA", Group: "Processing", Type: TypeBool, Default: "true", StrictBool: true,
+		Description: "Remove EXIF, GPS and device metadata before images go to cloud vision"},
+	{Name: "CLOUD_VISION_MAX_DIMENSION", Group: "Processing", Type: TypeInt, Default: "0", Unit: "pixels", Min: bound(0),
+		Description: "Scale images down before cloud vision (0 = no limit)"},
+	{Name: "NOTE_COLOR", Group: "Processing", Type: TypeString, Default: "#FFFFFF",
+		Description: "Background color of AI response notes"},
+	{Name: "NOTE_TEXT_COLOR", Group: "Processing", Type: TypeString, Default: "#000000",
+		Description: "Text color of AI response notes"},
+
+	// Network
+	{Name: "HTTP_MAX_IDLE_CONNS_PER_HOST", Group: "Network", Type: TypeInt, Default: "16", Min: bound(1),
+		Description: "Idle keep-alive connections kept per host"},
+	{Name: "HTTP_IDLE_CONN_TIMEOUT", Group: "Network", Type: TypeInt, Default: "90", Unit: "seconds", Min: bound(1),
+		Description: "Close idle connections after this long"},
+	{Name: "HTTP_CONNECT_TIMEOUT", Group: "Network", Type: TypeInt, Default: "10", Unit: "seconds", Min: bound(1),
+		Description: "Connect and TLS handshake timeout"},
+	{Name: "HTTP_PROXY", Group: "Network", Type: TypeURL,
+		Description: "Proxy for outbound http requests; local endpoints are always reached directly"},
+	{Name: "HTTPS_PROXY", Group: "Network", Type: TypeURL,
+		Description: "Proxy for outbound https requests"},
+	{Name: "NO_PROXY", Group: "Network", Type: TypeList,
+		Description: "Hosts reached without the proxy"},
+	{Name: "PROXY_OVERRIDES", Group: "Network", Type: TypeList,
+		Check:       func(v string) error { _, err := netproxy.ParseOverrides(v); return err },
+		Description: "Per-host proxy rules, e.g. canvus.example.com=direct"},
+
+	// Canvas Policy
+	{Name: "CANVAS_POLICY_FILE", Group: "Canvas Policy", Type: TypeString,
+		Description: "JSON file of the features each canvas may use; empty allows every feature"},
+	{Name: "FEATURE_FLAGS", Group: "Canvas Policy", Type: TypeList,
+		Description: "Experimental features on for every canvas; prefix a flag with - to turn it off"},
+
+	// Service
+	{Name: "DATABASE_PATH", Group: "Service", Type: TypeString,
+		Description: "SQLite database file; in the user's data directory by default"},
+	{Name: "DEV_MODE", Group: "Service", Type: TypeBool, Default: "false", StrictBool: true,
+		Description: "Human-readable debug logging"},
+	{Name: "LOG_MAX_SIZE_MB", Group: "Service", Type: TypeInt, Default: "100", Unit: "MB", Min: bound(1),
+		Description: "Rotate app.log when it reaches this size"},
+	{Name: "LOG_MAX_BACKUPS", Group: "Service", Type: TypeInt, Default: "5", Min: bound(0),
+		Description: "Rotated log files kept"},
+	{Name: "LOG_MAX_AGE_DAYS", Group: "Service", Type: TypeInt, Default: "30", Unit: "days", Min: bound(0),
+		Description: "Delete rotated log files older than this"},
+	{Name: "LOG_COMPRESS", Group: "Service", Type: TypeBool, Default: "true",
+		Description: "Gzip rotated log files"},
+	{Name: "LOG_ROTATE_INTERVAL", Group: "Service", Type: TypeString,
+		Description: "Also rotate app.log this often, e.g. 24h (empty = by size only)"},
+	{Name: "METRICS_PERSIST_INTERVAL", Group: "Service", Type: TypeInt, Default: "60", Unit: "seconds", Min: bound(0),
+		Description: "Save the dashboard task history and totals to the database this often"},
+	{Name: "TEMP_DIR", Group: "Service", Type: TypeString,
+		Description: "Directory for files being processed, emptied at startup; <DOWNLOADS_DIR>/tmp by default"},
+	{Name: "TEMP_QUOTA_MB", Group: "Service", Type: TypeInt, Default: "2048", Unit: "MB", Min: bound(0),
+		Description: "Disk quota of temporary files (0 = unlimited)"},
+	{Name: "RECOVERY_MAX_ATTEMPTS", Group: "Service", Type: TypeInt, Default: "2", Min: bound(1),
+		Description: "Times a trigger may be interrupted by a crash before the next start fails it"},
+
+	// Canvus Server Watchdog
+	{Name: "WATCHDOG_FAILURE_THRESHOLD", Group: "Canvus Server Watchdog", Type: TypeInt, Default: "3", Min: bound(1),
+		Description: "Consecutive stream failures before the Canvus server is marked down"},
+	{Name: "WATCHDOG_PAUSE_PROCESSING", Group: "Canvus Server Watchdog", Type: TypeBool, Default: "false",
+		Description: "Skip AI processing while the Canvus server is down"},
+	{Name: "WATCHDOG_WEBHOOK_URL", Group: "Canvus Server Watchdog", Type: TypeURL,
+		Description: "JSON webhook sent down and recovered alerts"},
+	{Name: "WATCHDOG_ALERT_EMAIL", Group: "Canvus Server Watchdog", Type: TypeList,
+		Description: "Recipients of down and recovered alerts, sent through the SMTP_* settings"},
+	{Name: "SMTP_HOST", Group: "Canvus Server Watchdog", Type: TypeString,
+		Description: "Mail server for alerts, the digest and email gateway replies"},
+	{Name: "SMTP_PORT", Group: "Canvus Server Watchdog", Type: TypeInt, Default: "587", Min: bound(1), Max: bound(65535),
+		Description: "Port of SMTP_HOST"},
+	{Name: "SMTP_USERNAME", Group: "Canvus Server Watchdog", Type: TypeString,
+		Description: "SMTP user"},
+	{Name: "SMTP_PASSWORD", Group: "Canvus Server Watchdog", Type: TypeString, Secret: true,
+		Description: "Password of SMTP_USERNAME"},
+	{Name: "SMTP_FROM", Group: "Canvus Server Watchdog", Type: TypeString,
+		Description: "Sender address of outgoing mail"},
+
+	// Multiple Instances
+	{Name: "INSTANCE_LEASE", Group: "Multiple Instances", Type: TypeEnum, Default: "defer",
+		Choices:     []string{"defer", "refuse", "off"},
+		Description: "What a second instance on the same canvas does: stand by, exit at startup, or nothing"},
+	{Name: "INSTANCE_LEASE_TTL", Group: "Multiple Instances", Type: TypeInt, Default: "60", Unit: "seconds", Min: bound(1),
+		Description: "Time a lease lasts without renewal"},
+
+	// Scale-Out
+	{Name: "CLUSTER_URL", Group: "Scale-Out", Type: TypeString,
+		Description: "Redis shared with other instances, redis://[user:password@]host[:port][/db] (empty = run alone)"},
+	{Name: "CLUSTER_NAME", Group: "Scale-Out", Type: TypeString, Default: "canvuslocallm",
+		Description: "Name of the cluster; instances with the same name share work"},
+	{Name: "CLUSTER_ROLES", Group: "Scale-Out", Type: TypeList, Default: "monitor,image,tasks",
+		Description: "Roles of this instance: monitor, image, tasks"},
+	{Name: "CLUSTER_TTL", Group: "Scale-Out", Type: TypeInt, Default: "15", Unit: "seconds", Min: bound(1),
+		Description: "Time a node stays a member without a heartbeat"},
+
+	// Webhook Notifications
+	{Name: "WEBHOOK_URLS", Group: "Webhook Notifications", Type: TypeList,
+		Description: "Slack, Teams or generic JSON webhooks for failures, budget, GPU, SLO and stream alerts"},
+	{Name: "WEBHOOK_EVENTS", Group: "Webhook Notifications", Type: TypeList,
+		Description: "Only send these events; all when empty"},
+	{Name: "WEBHOOK_FORMAT", Group: "Webhook Notifications", Type: TypeEnum,
+		Choices:     []string{"json", "slack", "teams"},
+		Description: "Payload format of every webhook; detected per URL when empty"},
+	{Name: "WEBHOOK_MAX_ATTEMPTS", Group: "Webhook Notifications", Type: TypeInt, Default: "3", Min: bound(1),
+		Description: "Delivery attempts of one event"},
+	{Name: "WEBHOOK_GPU_TEMP_THRESHOLD", Group: "Webhook Notifications", Type: TypeFloat, Default: "85", Unit: "°C", Min: bound(0),
+		Description: "GPU temperature alert threshold (0 = off)"},
+	{Name: "WEBHOOK_GPU_MEMORY_THRESHOLD", Group: "Webhook Notifications", Type: TypeFloat, Default: "95", Unit: "percent", Min: bound(0), Max: bound(100),
+		Description: "GPU memory alert threshold (0 = off)"},
+	{Name: "DAILY_TASK_BUDGET", Group: "Webhook Notifications", Type: TypeInt, Default: "0", Min: bound(0),
+		Description: "AI tasks expected per day; alerts at 80% and 100% (0 = off)"},
+
+	// Daily Email Digest
+	{Name: "DIGEST_EMAIL", Group: "Daily Email Digest", Type: TypeList,
+		Description: "Recipients of a daily activity summary, sent through the SMTP_* settings"},
+	{Name: "DIGEST_TIME", Group: "Daily Email Digest", Type: TypeString, Default: "08:00",
+		Description: "Local time of day the digest is sent, HH:MM"},
+	{Name: "DIGEST_COST_PER_1K_TOKENS", Group: "Daily Email Digest", Type: TypeFloat, Default: "0", Min: bound(0),
+		Description: "Price per 1,000 tokens for the estimated cost line (0 = omitted)"},
+
+	// Email Gateway
+	{Name: "MAILIN_IMAP_HOST", Group: "Email Gateway", Type: TypeString,
+		Description: "Mailbox polled for email to post to canvases (empty = off)"},
+	{Name: "MAILIN_IMAP_PORT", Group: "Email Gateway", Type: TypeInt, Default: "993", Min: bound(1), Max: bound(65535),
+		Description: "IMAP port"},
+	{Name: "MAILIN_IMAP_TLS", Group: "Email Gateway", Type: TypeBool, Default: "true",
+		Description: "Connect to the IMAP server over TLS"},
+	{Name: "MAILIN_IMAP_USERNAME", Group: "Email Gateway", Type: TypeString,
+		Description: "IMAP user"},
+	{Name: "MAILIN_IMAP_PASSWORD", Group: "Email Gateway", Type: TypeString, Secret: true,
+		Description: "Password of MAILIN_IMAP_USERNAME"},
+	{Name: "MAILIN_IMAP_MAILBOX", Group: "Email Gateway", Type: TypeString, Default: "INBOX",
+		Description: "Mailbox polled"},
+	{Name: "MAILIN_POLL_INTERVAL", Group: "Email Gateway", Type: TypeInt, Default: "60", Unit: "seconds", Min: bound(1),
+		Description: "Time between mailbox checks"},
+	{Name: "MAILIN_ALLOWED_SENDERS", Group: "Email Gateway", Type: TypeList,
+		Description: "Addresses and @domains mail is accepted from (empty = anyone)"},
+	{Name: "MAILIN_MAX_ATTACHMENT_MB", Group: "Email Gateway", Type: TypeInt, Default: "20", Unit: "MB", Min: bound(1),
+		Description: "Largest attachment uploaded"},
+	{Name: "MAILIN_REPLY", Group: "Email Gateway", Type: TypeBool, Default: "true",
+		Description: "Reply to senders through the SMTP_* settings"},
+
+	// Chat Integrations
+	{Name: "SLACK_SIGNING_SECRET", Group: "Chat Integrations", Type: TypeString, Secret: true,
+		Description: "Signing secret of the Slack app with the /canvus slash command"},
+	{Name: "TEAMS_WEBHOOK_SECRET", Group: "Chat Integrations", Type: TypeString, Secret: true,
+		Description: "Security token of the Teams outgoing webhook"},
+	{Name: "CHATOPS_CANVAS_ID", Group: "Chat Integrations", Type: TypeString,
+		Description: "Canvas chat questions are posted to; the first monitored canvas by default"},
+
+	// Service Levels
+	{Name: "LATENCY_P95_THRESHOLDS", Group: "Service Levels", Type: TypeList,
+		Description: "p95 latency alert thresholds per task type, e.g. image_gen=45s,*=30s (empty = off)"},
+	{Name: "SLO_OBJECTIVES", Group: "Service Levels", Type: TypeList,
+		Description: "Service level objectives, e.g. note:p95<20s,*:errors<2% (empty = off)"},
+	{Name: "SLO_BURN_RATE_THRESHOLD", Group: "Service Levels", Type: TypeFloat, Default: "2", Min: bound(0),
+		Description: "Error budget burn rate that raises an SLO alert"},
+
+	// gRPC API
+	{Name: "GRPC_PORT", Group: "gRPC API", Type: TypeInt, Default: "0", Min: bound(0), Max: bound(65535),
+		Description: "Port of the gRPC API for programmatic clients (0 = off)"},
+	{Name: "GRPC_HOST", Group: "gRPC API", Type: TypeString,
+		Description: "Bind address of the gRPC API; all interfaces when empty"},
+	{Name: "GRPC_TOKEN", Group: "gRPC API", Type: TypeString, Secret: true,
+		Description: "Bearer token every gRPC call must send (empty = no authentication)"},
+
+	// Artifact Storage
+	{Name: "ARTIFACT_STORE", Group: "Artifact Storage", Type: TypeEnum,
+		Choices:     []string{"local", "s3", "azure"},
+		Description: "Keep generated images and downloaded PDFs (empty = delete them after upload)"},
+	{Name: "ARTIFACT_DIR", Group: "Artifact Storage", Type: TypeString, Default: "artifacts",
+		Description: "Directory of the local store"},
+	{Name: "ARTIFACT_RETENTION_DAYS", Group: "Artifact Storage", Type: TypeInt, Default: "0", Unit: "days", Min: bound(0),
+		Description: "Delete artifacts older than this (0 = keep)"},
+	{Name: "ARTIFACT_MAX_COUNT", Group: "Artifact Storage", Type: TypeInt, Default: "0", Min: bound(0),
+		Description: "Keep only the newest N artifacts (0 = unlimited)"},
+	{Name: "IMAGE_VARIANTS_KEEP", Group: "Artifact Storage", Type: TypeInt, Default: "8", Min: bound(0),
+		Description: "Images remembered per trigger note for {{variants}} (0 = off)"},
+	{Name: "ARTIFACT_PREFIX", Group: "Artifact Storage", Type: TypeString,
+		Description: "Object key prefix for S3 and Azure"},
+	{Name: "ARTIFACT_S3_BUCKET", Group: "Artifact Storage", Type: TypeString,
+		Description: "S3 bucket"},
+	{Name: "ARTIFACT_S3_REGION", Group: "Artifact Storage", Type: TypeString,
+		Description: "S3 region"},
+	{Name: "ARTIFACT_S3_ENDPOINT", Group: "Artifact Storage", Type: TypeURL,
+		Description: "Endpoint of MinIO or another S3-compatible service"},
+	{Name: "ARTIFACT_S3_ACCESS_KEY_ID", Group: "Artifact Storage", Type: TypeString,
+		Description: "S3 access key; AWS_ACCESS_KEY_ID by default"},
+	{Name: "ARTIFACT_S3_SECRET_ACCESS_KEY", Group: "Artifact Storage", Type: TypeString, Secret: true,
+		Description: "S3 secret key; AWS_SECRET_ACCESS_KEY by default"},
+	{Name: "ARTIFACT_AZURE_CONTAINER_URL", Group: "Artifact Storage", Type: TypeURL, Secret: true,
+		Description: "Azure container URL with a SAS token (read, write, delete, list)"},
+
+	// Self-Update
+	{Name: "UPDATE_MANIFEST_URL", Group: "Self-Update", Type: TypeURL,
+		Description: "Release manifest to update from (empty = updates off)"},
+	{Name: "UPDATE_PUBLIC_KEY", Group: "Self-Update", Type: TypeString, DependsOn: "UPDATE_MANIFEST_URL",
+		Description: "Ed25519 key releases are signed with, base64 or hex; required with UPDATE_MANIFEST_URL"},
+	{Name: "UPDATE_CHANNEL", Group: "Self-Update", Type: TypeEnum, Default: "stable",
+		Choices:     []string{"stable", "beta"},
+		Description: "Release channel"},
+	{Name: "UPDATE_CHECK_HOURS", Group: "Self-Update", Type: TypeInt, Default: "6", Unit: "hours", Min: bound(0),
+		Description: "Time between update checks (0 = at startup only)"},
+	{Name: "UPDATE_AUTO_DOWNLOAD", Group: "Self-Update", Type: TypeBool, Default: "true",
+		Description: "Download new releases when found; they are installed when the server next stops"},
+
+	// Safety
+	{Name: "PII_REDACTION", Group: "Safety", Type: TypeBool, Default: "false",
+		Description: "Mask emails, phone numbers and names before text is sent to a cloud LLM"},
+	{Name: "PII_REDACTION_KINDS", Group: "Safety", Type: TypeList, Default: "email,phone,name",
+		Description: "Kinds to mask: email, phone, name (names need the local model)"},
+	{Name: "PROMPT_GUARD", Group: "Safety", Type: TypeBool, Default: "true",
+		Description: "Remove instructions aimed at the AI from canvas text before it reaches the LLM"},
+	{Name: "PROMPT_GUARD_MODE", Group: "Safety", Type: TypeEnum, Default: "neutralize",
+		Choices:     []string{"neutralize", "log"},
+		Description: "neutralize removes what is found; log only reports it"},
+	{Name: "OUTPUT_FILTER", Group: "Safety", Type: TypeBool, Default: "false",
+		Description: "Check AI text before it is written to the canvas"},
+	{Name: "OUTPUT_FILTER_MODE", Group: "Safety", Type: TypeEnum, Default: "mask",
+		Choices:     []string{"mask", "block"},
+		Description: "mask replaces listed terms with asterisks; block withholds the response"},
+	{Name: "OUTPUT_FILTER_WORDS", Group: "Safety", Type: TypeList,
+		Description: "Terms and phrases to filter"},
+	{Name: "OUTPUT_FILTER_WORDLISTS", Group: "Safety", Type: TypeList,
+		Description: "Word list files, one term or phrase per line"},
+	{Name: "OUTPUT_FILTER_CLASSIFIER", Group: "Safety", Type: TypeBool, Default: "false",
+		Description: "Block responses the local model judges unsafe"},
+	{Name: "AUDIT_LOG", Group: "Safety", Type: TypeBool, Default: "false",
+		Description: "Record every widget AI tasks create or modify in a tamper-evident log"},
+	{Name: "SESSION_RECORDING", Group: "Safety", Type: TypeBool, Default: "false",
+		Description: "Record each canvas session's triggers and AI responses for replay"},
+	{Name: "SESSION_IDLE_GAP_MINUTES", Group: "Safety", Type: TypeInt, Default: "30", Unit: "minutes", Min: bound(1),
+		Description: "Idle time before a canvas starts a new session"},
+
+	// Note Intent and Prompt Expansion
+	{Name: "INTENT_CLASSIFIER", Group: "Note Intent and Prompt Expansion", Type: TypeEnum, Default: "heuristic",
+		Choices:     []string{"heuristic", "local", "llm"},
+		Description: "How notes are sorted into text and image requests before the LLM is asked"},
+	{Name: "PROMPT_EXPANSION", Group: "Note Intent and Prompt Expansion", Type: TypeBool, Default: "true",
+		Description: "Expand image requests from notes into detailed prompts"},
+	{Name: "PROMPT_EXPANSION_CREATIVITY", Group: "Note Intent and Prompt Expansion", Type: TypeEnum, Default: "medium",
+		Choices:     []string{"low", "medium", "high"},
+		Description: "How much an expansion adds to the request"},
+	{Name: "PROMPT_EXPANSION_MAX_LENGTH", Group: "Note Intent and Prompt Expansion", Type: TypeInt, Default: "1000", Unit: "characters",
+		Min: bound(1), Max: bound(4000),
+		Description: "Longest expanded prompt"},
+
+	// Thermal Throttling
+	{Name: "THERMAL_THROTTLE", Group: "Thermal Throttling", Type: TypeBool, Default: "false",
+		Description: "Slow local image generation while the GPU runs hot"},
+	{Name: "THERMAL_TEMP_LIMIT", Group: "Thermal Throttling", Type: TypeFloat, Default: "83", Unit: "°C", Min: bound(1),
+		Description: "GPU temperature at which throttling starts"},
+	{Name: "THERMAL_HYSTERESIS", Group: "Thermal Throttling", Type: TypeFloat, Default: "5", Unit: "°C", Min: bound(0),
+		Description: "Degrees the GPU must cool below the limit before throttling stops"},
+	{Name: "THERMAL_POWER_LIMIT_PERCENT", Group: "Thermal Throttling", Type: TypeFloat, Default: "95", Unit: "percent",
+		Min: bound(0), Max: bound(100),
+		Description: "Power draw relative to the GPU power limit that starts throttling (0 ignores power)"},
+	{Name: "THERMAL_THROTTLE_CONCURRENCY", Group: "Thermal Throttling", Type: TypeInt, Default: "1", Min: bound(1),
+		Description: "Images generated at once while throttled"},
+	{Name: "THERMAL_THROTTLE_DELAY", Group: "Thermal Throttling", Type: TypeInt, Default: "5", Unit: "seconds", Min: bound(0),
+		Description: "Wait before each image while throttled"},
+
+	// Canvas Features
+	{Name: "COLLAGE_CELL_SIZE", Group: "Canvas Features", Type: TypeInt, Default: "512", Unit: "pixels", Min: bound(16),
+		Description: "Square each image of a {{collage}} is fitted into"},
+	{Name: "COLLAGE_MAX_IMAGES", Group: "Canvas Features", Type: TypeInt, Default: "36", Min: bound(1),
+		Description: "Images in one collage"},
+	{Name: "NOTE_FIX_UNDO_KEEP", Group: "Canvas Features", Type: TypeInt, Default: "10", Min: bound(0),
+		Description: "{{fix}} corrections that can be undone per note (0 = no undo)"},
+	{Name: "AUTO_TAGS", Group: "Canvas Features", Type: TypeEnum, Default: "off",
+		Choices:     []string{"off", "store", "append", "color"},
+		Description: "Tag notes by topic in the background"},
+	{Name: "AUTO_TAG_MAX", Group: "Canvas Features", Type: TypeInt, Default: "3", Min: bound(1),
+		Description: "Tags per note"},
+	{Name: "AUTO_TAG_DELAY", Group: "Canvas Features", Type: TypeInt, Default: "20", Unit: "seconds", Min: bound(0),
+		Description: "Time a note must stay unchanged before it is tagged"},
+	{Name: "AUTO_TAG_MIN_LENGTH", Group: "Canvas Features", Type: TypeInt, Default: "40", Unit: "characters", Min: bound(0),
+		Description: "Shortest note tagged"},
+	{Name: "HANDWRITING_BATCH_CONCURRENCY", Group: "Canvas Features", Type: TypeInt, Default: "3", Min: bound(1),
+		Description: "Snapshots sent to Google Vision at a time by {{handwriting}}"},
+	{Name: "HANDWRITING_BATCH_MAX", Group: "Canvas Features", Type: TypeInt, Default: "50", Min: bound(1),
+		Description: "Snapshots read per {{handwriting}} trigger"},
+
+	// Voice Notes and Live Transcripts
+	{Name: "WHISPER_URL", Group: "Voice Notes and Live Transcripts", Type: TypeURL,
+		Description: "OpenAI-compatible transcription endpoint, e.g. a whisper.cpp server (empty = off)"},
+	{Name: "WHISPER_MODEL", Group: "Voice Notes and Live Transcripts", Type: TypeString, Default: "whisper-1",
+		Description: "Model name sent to the endpoint"},
+	{Name: "WHISPER_API_KEY", Group: "Voice Notes and Live Transcripts", Type: TypeString, Secret: true,
+		Description: "API key of the endpoint; OPENAI_API_KEY by default"},
+	{Name: "WHISPER_LANGUAGE", Group: "Voice Notes and Live Transcripts", Type: TypeString,
+		Description: "Language of recordings, e.g. de; detected when empty"},
+	{Name: "WHISPER_TIMEOUT", Group: "Voice Notes and Live Transcripts", Type: TypeInt, Default: "600", Unit: "seconds", Min: bound(1),
+		Description: "Time one transcription may take"},
+	{Name: "WHISPER_MAX_FILE_MB", Group: "Voice Notes and Live Transcripts", Type: TypeInt, Default: "25", Unit: "MB", Min: bound(1),
+		Description: "Largest recording sent to the endpoint"},
+	{Name: "VOICE_NOTE_SUMMARY_MINUTES", Group: "Voice Notes and Live Transcripts", Type: TypeInt, Default: "0", Unit: "minutes", Min: bound(0),
+		Description: "Summarize recordings at least this long (0 = never)"},
+	{Name: "LIVE_TRANSCRIPT_SOURCE", Group: "Voice Notes and Live Transcripts", Type: TypeString,
+		Description: "Audio input of {{live}}: mic, mic:<device>, an rtp://, udp:// or srt:// address, or an .sdp file (empty = off)"},
+	{Name: "LIVE_TRANSCRIPT_CHUNK_SECONDS", Group: "Voice Notes and Live Transcripts", Type: TypeInt, Default: "15", Unit: "seconds", Min: bound(1),
+		Description: "Audio transcribed at a time"},
+	{Name: "LIVE_TRANSCRIPT_SUMMARY_MINUTES", Group: "Voice Notes and Live Transcripts", Type: TypeInt, Default: "5", Unit: "minutes", Min: bound(0),
+		Description: "Audio between rolling summaries (0 = none)"},
+	{Name: "LIVE_TRANSCRIPT_MAX_MINUTES", Group: "Voice Notes and Live Transcripts", Type: TypeInt, Default: "240", Unit: "minutes", Min: bound(1),
+		Description: "Time after which a session ends"},
+	{Name: "FFMPEG_PATH", Group: "Voice Notes and Live Transcripts", Type: TypeString, Default: "ffmpeg",
+		Description: "ffmpeg executable"},
+
+	// Deterministic Test Mode
+	{Name: "TEST_DETERMINISTIC", Group: "Deterministic Test Mode", Type: TypeBool, Default: "false",
+		Description: "Fix seeds, correlation IDs and timestamps so runs repeat (not for production)"},
+	{Name: "TEST_DETERMINISTIC_SEED", Group: "Deterministic Test Mode", Type: TypeInt, Default: "42",
+		Description: "Seed of image and text generation"},
+	{Name: "TEST_DETERMINISTIC_EPOCH", Group: "Deterministic Test Mode", Type: TypeString, Default: "2024-01-01T00:00:00Z",
+		Description: "First timestamp of the test clock, RFC 3339"},
+}
+
+// ConfigSchema returns the settings the schema describes, in documentation
+// order. The slice is shared; do not modify it.
+func ConfigSchema() []Setting {
+	return configSchema
+}
+
+// LookupSetting returns the setting named name, or an alias of it.
+func LookupSetting(name string) (Setting, bool) {
+	for _, s := range configSchema {
+		if s.Name == name {
+			return s, true
+		}
+		for _, alias := range s.Aliases {
+			if alias == name {
+				return s, true
+			}
+		}
+	}
+	return Setting{}, false
+}
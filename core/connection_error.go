@@ -0,0 +1,69 @@
+package core
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+)
+
+// ConnectionErrorKind classifies why a request to a server failed.
+type ConnectionErrorKind string
+
+const (
+	// ConnErrorNone means there was no error
+	ConnErrorNone ConnectionErrorKind = ""
+	// ConnErrorRefused means nothing accepted the connection on the port
+	ConnErrorRefused ConnectionErrorKind = "refused"
+	// ConnErrorDNS means the host name could not be resolved
+	ConnErrorDNS ConnectionErrorKind = "dns"
+	// ConnErrorTimeout means the server did not answer in time
+	ConnErrorTimeout ConnectionErrorKind = "timeout"
+	// ConnErrorTLS means the server certificate was rejected
+	ConnErrorTLS ConnectionErrorKind = "tls"
+	// ConnErrorProtocol means the client and server disagree on HTTP vs HTTPS
+	ConnErrorProtocol ConnectionErrorKind = "protocol"
+	// ConnErrorReset means the connection was dropped mid-request
+	ConnErrorReset ConnectionErrorKind = "reset"
+	// ConnErrorOther is any other failure
+	ConnErrorOther ConnectionErrorKind = "other"
+)
+
+// ClassifyConnectionError returns the kind of network failure behind err.
+// Wrapped errors are inspected first; the message is matched as a fallback
+// for errors that lost their type, such as Windows' "actively refused".
+func ClassifyConnectionError(err error) ConnectionErrorKind {
+	if err == nil {
+		return ConnErrorNone
+	}
+
+	msg := strings.ToLower(err.Error())
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certInvalid x509.CertificateInvalidError
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED) ||
+		strings.Contains(msg, "connection refused") || strings.Contains(msg, "actively refused"):
+		return ConnErrorRefused
+	case errors.As(err, &dnsErr) || strings.Contains(msg, "no such host"):
+		return ConnErrorDNS
+	case errors.As(err, &unknownAuthority) || errors.As(err, &hostnameErr) ||
+		errors.As(err, &certInvalid) || strings.Contains(msg, "x509:"):
+		return ConnErrorTLS
+	case strings.Contains(msg, "http response to https client") ||
+		strings.Contains(msg, "first record does not look like a tls handshake"):
+		return ConnErrorProtocol
+	case errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()):
+		return ConnErrorTimeout
+	case errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		strings.Contains(msg, "connection reset") || strings.Contains(msg, "forcibly closed") ||
+		strings.Contains(msg, "unexpected eof"):
+		return ConnErrorReset
+	}
+	return ConnErrorOther
+}
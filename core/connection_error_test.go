@@ -0,0 +1,45 @@
+package core
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+)
+
+func TestClassifyConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ConnectionErrorKind
+	}{
+		{"nil", nil, ConnErrorNone},
+		{"refused errno", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, ConnErrorRefused},
+		{"windows refused text", errors.New("connectex: No connection could be made because the target machine actively refused it."), ConnErrorRefused},
+		{"dns", &net.DNSError{Err: "no such host", Name: "canvus.invalid"}, ConnErrorDNS},
+		{"dns text", errors.New("dial tcp: lookup canvus.invalid: no such host"), ConnErrorDNS},
+		{"deadline", context.DeadlineExceeded, ConnErrorTimeout},
+		{"self-signed", x509.UnknownAuthorityError{}, ConnErrorTLS},
+		{"hostname mismatch", x509.HostnameError{Host: "canvus.local", Certificate: &x509.Certificate{}}, ConnErrorTLS},
+		{"https to http", errors.New("http: server gave HTTP response to HTTPS client"), ConnErrorProtocol},
+		{"http to https", errors.New("tls: first record does not look like a TLS handshake"), ConnErrorProtocol},
+		{"reset", syscall.ECONNRESET, ConnErrorReset},
+		{"eof", errors.New("Get \"https://canvus/api\": unexpected EOF"), ConnErrorReset},
+		{"other", errors.New("boom"), ConnErrorOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err
+			if err != nil {
+				err = fmt.Errorf("request failed: %w", err)
+			}
+			if got := ClassifyConnectionError(err); got != tt.want {
+				t.Errorf("ClassifyConnectionError() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
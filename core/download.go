@@ -24,6 +24,8 @@ type DownloadOptions struct {
 	OnProgress func(ProgressInfo)
 	// Resume enables resuming from partial downloads if the file exists
 	Resume bool
+	// Headers are added to the request (e.g., Authorization)
+	Headers http.Header
 }
 
 // DownloadResult contains information about a completed download.
@@ -97,6 +99,12 @@ func DownloadWithProgress(ctx context.Context, opts DownloadOptions) (*DownloadR
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
+	for key, values := range opts.Headers {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
 	// Add Range header if resuming
 	if resumeFrom > 0 {
 		req.Header.Set("Range", BuildRangeHeader(resumeFrom))
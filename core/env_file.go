@@ -0,0 +1,90 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SetEnvFileValue sets key=value in the .env file at path, replacing an
+// existing assignment or appending a new one. Comments, blank lines and
+// other settings are preserved. The file is created if it does not exist.
+//
+// Values containing whitespace, '#', '$' or quotes are wrapped in single quotes,
+// which godotenv reads literally (so Windows paths keep their backslashes).
+func SetEnvFileValue(path, key, value string) error {
+	if key == "" || strings.ContainsAny(key, "= \t\n") {
+		return fmt.Errorf("invalid env key %q", key)
+	}
+	if strings.ContainsAny(value, "\n'") {
+		return fmt.Errorf("env value for %s cannot contain newlines or single quotes", key)
+	}
+
+	var lines []string
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read env file: %w", err)
+	}
+	if len(data) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	assignment := key + "=" + quoteEnvValue(value)
+	replaced := false
+	for i, line := range lines {
+		if envLineKey(line) == key {
+			lines[i] = assignment
+			replaced = true
+		}
+	}
+	if !replaced {
+		lines = append(lines, assignment)
+	}
+
+	// Write atomically so a crash cannot leave a truncated .env behind
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".env-*")
+	if err != nil {
+		return fmt.Errorf("create temp env file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strings.Join(lines, "\n") + "\n"); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write env file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write env file: %w", err)
+	}
+	if info, err := os.Stat(path); err == nil {
+		os.Chmod(tmp.Name(), info.Mode().Perm())
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("replace env file: %w", err)
+	}
+	return nil
+}
+
+// envLineKey returns the key assigned on a .env line, or "" for comments,
+// blank lines and malformed lines. An "export " prefix is ignored.
+func envLineKey(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ""
+	}
+	line = strings.TrimPrefix(line, "export ")
+	key, _, ok := strings.Cut(line, "=")
+	if !ok {
+		return ""
+	}
+	return strings.TrimSpace(key)
+}
+
+// quoteEnvValue single-quotes values that godotenv would otherwise split
+// or interpret.
+func quoteEnvValue(value string) string {
+	if strings.ContainsAny(value, " \t#\"$") {
+		return "'" + value + "'"
+	}
+	return value
+}
@@ -0,0 +1,88 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetEnvFileValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial string
+		key     string
+		value   string
+		want    string
+	}{
+		{
+			name:    "replaces existing value",
+			initial: "# Model\nLLAMA_MODEL_PATH=old.gguf\nPORT=8080\n",
+			key:     "LLAMA_MODEL_PATH",
+			value:   "models/new.gguf",
+			want:    "# Model\nLLAMA_MODEL_PATH=models/new.gguf\nPORT=8080\n",
+		},
+		{
+			name:    "appends missing key",
+			initial: "PORT=8080",
+			key:     "SD_MODEL_PATH",
+			value:   "sd.safetensors",
+			want:    "PORT=8080\nSD_MODEL_PATH=sd.safetensors\n",
+		},
+		{
+			name:    "replaces exported value",
+			initial: "export LLAMA_MODEL_PATH=old.gguf\n",
+			key:     "LLAMA_MODEL_PATH",
+			value:   "new.gguf",
+			want:    "LLAMA_MODEL_PATH=new.gguf\n",
+		},
+		{
+			name:    "ignores commented assignment",
+			initial: "# LLAMA_MODEL_PATH=old.gguf\n",
+			key:     "LLAMA_MODEL_PATH",
+			value:   "new.gguf",
+			want:    "# LLAMA_MODEL_PATH=old.gguf\nLLAMA_MODEL_PATH=new.gguf\n",
+		},
+		{
+			name:    "quotes values with spaces",
+			initial: "",
+			key:     "LLAMA_MODEL_PATH",
+			value:   `C:\My Models\model.gguf`,
+			want:    "LLAMA_MODEL_PATH='C:\\My Models\\model.gguf'\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if tt.initial != "" {
+				if err := os.WriteFile(path, []byte(tt.initial), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := SetEnvFileValue(path, tt.key, tt.value); err != nil {
+				t.Fatalf("SetEnvFileValue() error = %v", err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("file = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetEnvFileValue_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := SetEnvFileValue(path, "BAD KEY", "x"); err == nil {
+		t.Error("expected error for key with space")
+	}
+	if err := SetEnvFileValue(path, "KEY", "line1\nline2"); err == nil {
+		t.Error("expected error for value with newline")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("file should not be created for invalid input")
+	}
+}
@@ -0,0 +1,95 @@
+package errs
+
+// Error codes. Codes are CV-<AREA>-<NN>; a code never changes meaning once
+// released, so documentation and stored error_log entries stay valid.
+// docs/error-codes.md describes each one.
+const (
+	// Connections to the Canvus server or an AI endpoint
+	CodeUnreachable      ErrCode = "CV-CONN-01"
+	CodeConnTimeout      ErrCode = "CV-CONN-02"
+	CodeTLS              ErrCode = "CV-CONN-03"
+	CodeProtocolMismatch ErrCode = "CV-CONN-04"
+	CodeWidgetNotFound   ErrCode = "CV-CONN-05"
+	CodeCanvusServer     ErrCode = "CV-CONN-06"
+
+	// Credentials
+	CodeCanvusAuth   ErrCode = "CV-AUTH-01"
+	CodeProviderAuth ErrCode = "CV-AUTH-02"
+
+	// Language models
+	CodeInference      ErrCode = "CV-LLM-01"
+	CodeTimeout        ErrCode = "CV-LLM-02"
+	CodeModelMissing   ErrCode = "CV-LLM-03"
+	CodeRateLimited    ErrCode = "CV-LLM-04"
+	CodeBadAIResponse  ErrCode = "CV-LLM-05"
+	CodeProviderServer ErrCode = "CV-LLM-06"
+
+	// GPU
+	CodeOutOfVRAM      ErrCode = "CV-GPU-01"
+	CodeGPUUnavailable ErrCode = "CV-GPU-02"
+
+	// Image generation and images sent to vision models
+	CodeImageGeneration ErrCode = "CV-IMG-01"
+	CodeInvalidImage    ErrCode = "CV-IMG-02"
+
+	// Widget content
+	CodeInvalidPrompt ErrCode = "CV-INPUT-01"
+	CodeNoContent     ErrCode = "CV-INPUT-02"
+
+	// Canvas settings
+	CodeModelNotAllowed ErrCode = "CV-POL-01"
+	CodeBudgetExceeded  ErrCode = "CV-POL-02"
+
+	// Anything not classified otherwise
+	CodeInternal ErrCode = "CV-INT-01"
+)
+
+// catalog describes every code, in documentation order.
+var catalog = []Info{
+	{CodeUnreachable, "A server could not be reached.",
+		"Check that CANVUS_SERVER and the AI endpoint are correct and that the servers are running."},
+	{CodeConnTimeout, "A server did not answer in time.",
+		"Check the network and the server load, then try again."},
+	{CodeTLS, "The server certificate was rejected.",
+		"Install a trusted certificate on the server, or set ALLOW_SELF_SIGNED_CERTS=true for self-signed certificates."},
+	{CodeProtocolMismatch, "The server URL uses the wrong protocol.",
+		"Use https:// or http:// in CANVUS_SERVER to match the server."},
+	{CodeWidgetNotFound, "The widget or canvas no longer exists.",
+		"The widget may have been deleted while it was processed; write the trigger again."},
+	{CodeCanvusServer, "The Canvus server reported an error.",
+		"Check the Canvus server logs, then try again."},
+	{CodeCanvusAuth, "The Canvus server rejected the API key.",
+		"Check CANVUS_API_KEY and that its user can access the canvas."},
+	{CodeProviderAuth, "The AI provider rejected the API key.",
+		"Check OPENAI_API_KEY (or the key of the configured AI endpoint)."},
+	{CodeInference, "The AI model failed to answer.",
+		"Try again; if it keeps failing, check app.log for the model error."},
+	{CodeTimeout, "The AI request timed out.",
+		"Try a shorter prompt, or raise AI_TIMEOUT."},
+	{CodeModelMissing, "The AI model is not available.",
+		"Check the model name in the configuration, or download the model from the dashboard."},
+	{CodeRateLimited, "The AI provider is rate limiting requests.",
+		"Wait a minute and try again, or raise the provider's rate limit."},
+	{CodeBadAIResponse, "The AI answer could not be used.",
+		"Try again or rephrase the prompt."},
+	{CodeProviderServer, "The AI provider reported an error.",
+		"Try again later; check the provider's status page if it persists."},
+	{CodeOutOfVRAM, "The GPU ran out of memory.",
+		"Close other GPU applications, use smaller image sizes, or a smaller model."},
+	{CodeGPUUnavailable, "No usable GPU was found.",
+		"Check the NVIDIA driver and that CUDA is installed."},
+	{CodeImageGeneration, "The image could not be generated.",
+		"Try again or adjust the prompt."},
+	{CodeInvalidImage, "The image could not be read.",
+		"Use a PNG or JPEG image."},
+	{CodeInvalidPrompt, "The prompt is empty or invalid.",
+		"Write a prompt between the trigger markers."},
+	{CodeNoContent, "There is nothing to process.",
+		"Check that the document or canvas has text."},
+	{CodeModelNotAllowed, "The model is not allowed on this canvas.",
+		"Allow the model in the canvas settings on the dashboard."},
+	{CodeBudgetExceeded, "The canvas used up its daily AI task limit.",
+		"Wait until tomorrow, or raise the limit in the canvas settings."},
+	{CodeInternal, "An unexpected error occurred.",
+		"Look up the error in app.log by its correlation ID and report it if it persists."},
+}
@@ -0,0 +1,170 @@
+// Package errs is the error taxonomy shared by all packages. Every failure
+// shown to users maps to an ErrCode such as CV-CONN-01, with a short
+// user-facing message and a remediation hint. Error notes on the canvas
+// show the code, and the error_log entry of the failure is filed under it,
+// so a code read off the wall leads to both the documentation and the log.
+package errs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+
+	"go_backend/core"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ErrCode identifies a kind of failure.
+type ErrCode string
+
+// Info describes an error code to users.
+type Info struct {
+	Code ErrCode `json:"code"`
+	// Message says what went wrong, in terms of what the user sees
+	Message string `json:"message"`
+	// Hint says what to do about it
+	Hint string `json:"hint"`
+}
+
+// Coder is implemented by errors that know their code.
+type Coder interface {
+	ErrCode() ErrCode
+}
+
+// codePattern matches well-formed codes.
+var codePattern = regexp.MustCompile(`^CV-[A-Z]+-\d{2}$`)
+
+// Valid reports whether code is well formed.
+func Valid(code ErrCode) bool {
+	return codePattern.MatchString(string(code))
+}
+
+// Lookup returns the description of code. Unknown codes are described as
+// CodeInternal, keeping their code.
+func Lookup(code ErrCode) Info {
+	for _, info := range catalog {
+		if info.Code == code {
+			return info
+		}
+	}
+	info := catalog[len(catalog)-1] // CodeInternal
+	info.Code = code
+	return info
+}
+
+// All returns every code's description, in documentation order.
+func All() []Info {
+	return append([]Info(nil), catalog...)
+}
+
+// Error is an error tagged with a code.
+type Error struct {
+	Code ErrCode
+	Err  error
+}
+
+// Error returns the message of the wrapped error; the code is shown
+// separately.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrCode returns the error's code.
+func (e *Error) ErrCode() ErrCode {
+	return e.Code
+}
+
+// New returns an error with message text and code. Packages use it for
+// their sentinel errors, which stay comparable with errors.Is.
+func New(code ErrCode, text string) error {
+	return &Error{Code: code, Err: errors.New(text)}
+}
+
+// Wrap tags err with code. It returns nil if err is nil.
+func Wrap(code ErrCode, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// CodeOf classifies err. The outermost error in the chain that knows its
+// code decides; otherwise AI provider and network failures are recognized,
+// and anything else is CodeInternal. A nil error has no code.
+func CodeOf(err error) ErrCode {
+	if err == nil {
+		return ""
+	}
+
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.ErrCode()
+	}
+	if code := providerCode(err); code != "" {
+		return code
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return CodeTimeout
+	}
+
+	switch core.ClassifyConnectionError(err) {
+	case core.ConnErrorRefused, core.ConnErrorDNS, core.ConnErrorReset:
+		return CodeUnreachable
+	case core.ConnErrorTimeout:
+		return CodeConnTimeout
+	case core.ConnErrorTLS:
+		return CodeTLS
+	case core.ConnErrorProtocol:
+		return CodeProtocolMismatch
+	}
+	return CodeInternal
+}
+
+// providerCode classifies an error returned by an OpenAI-compatible API,
+// or returns "" for other errors.
+func providerCode(err error) ErrCode {
+	status := 0
+	var apiErr *openai.APIError
+	var reqErr *openai.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		status = apiErr.HTTPStatusCode
+	case errors.As(err, &reqErr):
+		status = reqErr.HTTPStatusCode
+	default:
+		return ""
+	}
+
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return CodeProviderAuth
+	case status == http.StatusNotFound:
+		return CodeModelMissing
+	case status == http.StatusTooManyRequests:
+		return CodeRateLimited
+	case status >= 500:
+		return CodeProviderServer
+	}
+	return CodeInference
+}
+
+// CodeOr classifies err like CodeOf, but returns fallback instead of
+// CodeInternal, for callers that know what kind of step failed.
+func CodeOr(err error, fallback ErrCode) ErrCode {
+	if code := CodeOf(err); code != CodeInternal {
+		return code
+	}
+	return fallback
+}
+
+// Describe returns the code and description of err.
+func Describe(err error) Info {
+	return Lookup(CodeOf(err))
+}
@@ -0,0 +1,79 @@
+package errs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestCatalog(t *testing.T) {
+	doc, err := os.ReadFile("../../docs/error-codes.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := map[ErrCode]bool{}
+	for _, info := range All() {
+		if !Valid(info.Code) {
+			t.Errorf("%s: malformed code", info.Code)
+		}
+		if seen[info.Code] {
+			t.Errorf("%s: duplicate code", info.Code)
+		}
+		seen[info.Code] = true
+		if info.Message == "" || info.Hint == "" {
+			t.Errorf("%s: missing message or hint", info.Code)
+		}
+		if !strings.Contains(string(doc), "`"+string(info.Code)+"`") {
+			t.Errorf("%s: not documented in docs/error-codes.md", info.Code)
+		}
+	}
+
+	if info := Lookup("CV-NEW-99"); info.Code != "CV-NEW-99" || info.Hint != Lookup(CodeInternal).Hint {
+		t.Errorf("Lookup(unknown) = %+v, want the CodeInternal description", info)
+	}
+}
+
+func TestCodeOf(t *testing.T) {
+	sentinel := New(CodeOutOfVRAM, "out of VRAM")
+	tests := []struct {
+		name string
+		err  error
+		want ErrCode
+	}{
+		{"nil", nil, ""},
+		{"sentinel", fmt.Errorf("generate: %w", sentinel), CodeOutOfVRAM},
+		{"outermost code wins", Wrap(CodeImageGeneration, sentinel), CodeImageGeneration},
+		{"provider auth", &openai.APIError{HTTPStatusCode: 401}, CodeProviderAuth},
+		{"provider rate limit", fmt.Errorf("chat: %w", &openai.RequestError{HTTPStatusCode: 429}), CodeRateLimited},
+		{"provider outage", &openai.APIError{HTTPStatusCode: 503}, CodeProviderServer},
+		{"deadline", fmt.Errorf("infer: %w", context.DeadlineExceeded), CodeTimeout},
+		{"refused", &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, CodeUnreachable},
+		{"tls", errors.New("x509: certificate signed by unknown authority"), CodeTLS},
+		{"other", errors.New("boom"), CodeInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CodeOf(tt.err); got != tt.want {
+				t.Errorf("CodeOf() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if !errors.Is(fmt.Errorf("x: %w", sentinel), sentinel) || sentinel.Error() != "out of VRAM" {
+		t.Error("sentinel lost its identity or message")
+	}
+	if got := CodeOr(errors.New("boom"), CodeImageGeneration); got != CodeImageGeneration {
+		t.Errorf("CodeOr() = %q, want fallback", got)
+	}
+	if Wrap(CodeInternal, nil) != nil {
+		t.Error("Wrap(nil) != nil")
+	}
+}
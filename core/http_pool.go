@@ -0,0 +1,154 @@
+package core
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"go_backend/netproxy"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Connection pool defaults for LLM and API requests.
+const (
+	DefaultMaxIdleConnsPerHost = 16
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultConnectTimeout      = 10 * time.Second
+)
+
+// transportKey identifies a shared transport by the settings it was built with.
+type transportKey struct {
+	insecure       bool
+	maxIdle        int
+	idleTimeout    time.Duration
+	connectTimeout time.Duration
+}
+
+// openAIClientKey identifies a cached OpenAI client.
+type openAIClientKey struct {
+	baseURL   string
+	apiKey    string
+	timeout   time.Duration
+	transport transportKey
+}
+
+var (
+	poolMu        sync.Mutex
+	transports    = make(map[transportKey]*http.Transport)
+	openAIClients = make(map[openAIClientKey]*openai.Client)
+)
+
+// newTransportKey returns the transport settings of cfg, with defaults for
+// unset values.
+func newTransportKey(cfg *Config) transportKey {
+	key := transportKey{
+		insecure:       cfg.AllowSelfSignedCerts,
+		maxIdle:        cfg.HTTPMaxIdleConnsPerHost,
+		idleTimeout:    cfg.HTTPIdleConnTimeout,
+		connectTimeout: cfg.HTTPConnectTimeout,
+	}
+	if key.maxIdle <= 0 {
+		key.maxIdle = DefaultMaxIdleConnsPerHost
+	}
+	if key.idleTimeout <= 0 {
+		key.idleTimeout = DefaultIdleConnTimeout
+	}
+	if key.connectTimeout <= 0 {
+		key.connectTimeout = DefaultConnectTimeout
+	}
+	return key
+}
+
+// sharedTransport returns the keep-alive transport for the settings of cfg,
+// creating it on first use. Requests go through the proxy netproxy chooses
+// (PROXY_OVERRIDES, then HTTP_PROXY, HTTPS_PROXY and NO_PROXY).
+func sharedTransport(cfg *Config) *http.Transport {
+	key := newTransportKey(cfg)
+
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	if t, ok := transports[key]; ok {
+		return t
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   key.connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}
+	t := &http.Transport{
+		Proxy:                 netproxy.FromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   key.maxIdle,
+		IdleConnTimeout:       key.idleTimeout,
+		TLSHandshakeTimeout:   key.connectTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if key.insecure {
+		t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	transports[key] = t
+	return t
+}
+
+// CreateOpenAIClient returns the OpenAI-compatible client for the text
+// endpoint of cfg (TextLLMURL, else BaseLLMURL) with cfg.AITimeout.
+// Clients are cached, so calling it for every request reuses connections
+// instead of opening new ones. It is safe for concurrent use.
+func CreateOpenAIClient(cfg *Config) *openai.Client {
+	baseURL := cfg.TextLLMURL
+	if baseURL == "" {
+		baseURL = cfg.BaseLLMURL
+	}
+	return OpenAIClient(cfg, baseURL, cfg.OpenAIAPIKey, cfg.AITimeout)
+}
+
+// OpenAIClient returns the cached OpenAI-compatible client for baseURL and
+// apiKey, creating it on first use. An empty baseURL uses the OpenAI API.
+// All clients with the same connection settings share one pool of
+// keep-alive connections.
+func OpenAIClient(cfg *Config, baseURL, apiKey string, timeout time.Duration) *openai.Client {
+	key := openAIClientKey{
+		baseURL:   baseURL,
+		apiKey:    apiKey,
+		timeout:   timeout,
+		transport: newTransportKey(cfg),
+	}
+
+	poolMu.Lock()
+	client, ok := openAIClients[key]
+	poolMu.Unlock()
+	if ok {
+		return client
+	}
+
+	clientConfig := openai.DefaultConfig(apiKey)
+	if baseURL != "" {
+		clientConfig.BaseURL = baseURL
+	}
+	clientConfig.HTTPClient = GetHTTPClient(cfg, timeout)
+	client = openai.NewClientWithConfig(clientConfig)
+
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	// Another request may have created it meanwhile; keep the first
+	if existing, ok := openAIClients[key]; ok {
+		return existing
+	}
+	openAIClients[key] = client
+	return client
+}
+
+// CloseIdleConnections closes the idle connections of all shared
+// transports, e.g. at shutdown.
+func CloseIdleConnections() {
+	poolMu.Lock()
+	defer poolMu.Unlock()
+	for _, t := range transports {
+		t.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCreateOpenAIClient_Cached(t *testing.T) {
+	cfg := &Config{BaseLLMURL: "http://127.0.0.1:1234/v1", OpenAIAPIKey: "key", AITimeout: time.Minute}
+
+	if CreateOpenAIClient(cfg) != CreateOpenAIClient(cfg) {
+		t.Error("CreateOpenAIClient() should return the cached client for the same config")
+	}
+
+	other := *cfg
+	other.TextLLMURL = "http://127.0.0.1:5678/v1"
+	if CreateOpenAIClient(cfg) == CreateOpenAIClient(&other) {
+		t.Error("CreateOpenAIClient() should return a different client for a different endpoint")
+	}
+}
+
+func TestCreateOpenAIClient_Concurrent(t *testing.T) {
+	cfg := &Config{BaseLLMURL: "http://127.0.0.1:4321/v1", AITimeout: time.Minute}
+
+	var wg sync.WaitGroup
+	first := CreateOpenAIClient(cfg)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if CreateOpenAIClient(cfg) != first {
+				t.Error("concurrent CreateOpenAIClient() returned a different client")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSharedTransport(t *testing.T) {
+	cfg := &Config{}
+	transport := sharedTransport(cfg)
+	if transport != sharedTransport(&Config{}) {
+		t.Error("sharedTransport() should share one transport per setting")
+	}
+	if transport.MaxIdleConnsPerHost != DefaultMaxIdleConnsPerHost || transport.IdleConnTimeout != DefaultIdleConnTimeout {
+		t.Errorf("sharedTransport() = %d idle per host, %v idle timeout; want the defaults",
+			transport.MaxIdleConnsPerHost, transport.IdleConnTimeout)
+	}
+	if transport.Proxy == nil {
+		t.Error("sharedTransport() should use the proxy environment variables")
+	}
+
+	insecure := sharedTransport(&Config{AllowSelfSignedCerts: true})
+	if insecure == transport || insecure.TLSClientConfig == nil || !insecure.TLSClientConfig.InsecureSkipVerify {
+		t.Error("sharedTransport() should use a separate transport that skips verification for self-signed certs")
+	}
+}
+
+func TestGetHTTPClient_ReusesConnections(t *testing.T) {
+	var conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	cfg := &Config{HTTPMaxIdleConnsPerHost: 3}
+	for i := 0; i < 5; i++ {
+		resp, err := GetHTTPClient(cfg, 5*time.Second).Get(server.URL)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if got := atomic.LoadInt32(&conns); got != 1 {
+		t.Errorf("5 requests with new clients opened %d connections, want 1", got)
+	}
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// IdleResource is a GPU-resident resource that can be released when idle
+// and lazily reloaded on next use (e.g., an SD context pool or llama model).
+type IdleResource interface {
+	// IsLoaded reports whether the resource currently holds memory.
+	IsLoaded() bool
+
+	// LastUsed returns when the resource was last used.
+	LastUsed() time.Time
+
+	// Unload releases the resource's memory. Returns true if anything was
+	// freed; returns false if the resource is busy or already unloaded.
+	Unload() bool
+}
+
+// ClockedResource is an IdleResource that stamps LastUsed with a clock it
+// is given. Register hands it the unloader's clock, so use times and idle
+// times are read from the same clock.
+type ClockedResource interface {
+	IdleResource
+
+	// SetClock sets the clock that stamps LastUsed.
+	SetClock(c clock.Clock)
+}
+
+// IdleUnloader periodically unloads registered resources that have not been
+// used for longer than their idle timeout, so an idle server releases VRAM
+// for other workloads.
+//
+// Example usage:
+//
+//	unloader := core.NewIdleUnloader(time.Minute, func(name string) {
+//	    log.Printf("unloaded %s after idle timeout", name)
+//	})
+//	unloader.Register("llama", llamaClient, 30*time.Minute)
+//	unloader.Start(ctx)
+//	defer unloader.Stop()
+type IdleUnloader struct {
+	mu        sync.Mutex
+	resources []idleEntry
+	interval  time.Duration
+	onUnload  func(name string)
+	clock     clock.Clock
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type idleEntry struct {
+	name     string
+	resource IdleResource
+	timeout  time.Duration
+}
+
+// NewIdleUnloader creates an IdleUnloader that checks resources every interval.
+// onUnload is called with the resource name after each unload; may be nil.
+func NewIdleUnloader(interval time.Duration, onUnload func(name string)) *IdleUnloader {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &IdleUnloader{
+		interval: interval,
+		onUnload: onUnload,
+		clock:    clock.Real(),
+	}
+}
+
+// SetClock replaces the clock used for idle times and check intervals,
+// and the clock of registered ClockedResources. Call before Start.
+func (u *IdleUnloader) SetClock(c clock.Clock) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.clock = clock.OrReal(c)
+	for _, e := range u.resources {
+		if r, ok := e.resource.(ClockedResource); ok {
+			r.SetClock(u.clock)
+		}
+	}
+}
+
+// Register adds a resource to be unloaded after timeout of inactivity.
+// A non-positive timeout disables unloading for that resource. A
+// ClockedResource is given the unloader's clock.
+func (u *IdleUnloader) Register(name string, resource IdleResource, timeout time.Duration) {
+	if resource == nil || timeout <= 0 {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if r, ok := resource.(ClockedResource); ok {
+		r.SetClock(u.clock)
+	}
+	u.resources = append(u.resources, idleEntry{name: name, resource: resource, timeout: timeout})
+}
+
+// Len returns the number of registered resources.
+func (u *IdleUnloader) Len() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.resources)
+}
+
+// Start begins periodic idle checks in a background goroutine.
+func (u *IdleUnloader) Start(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	u.cancel = cancel
+
+	u.wg.Add(1)
+	go func() {
+		defer u.wg.Done()
+		ticker := u.clock.NewTicker(u.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C():
+				u.CheckOnce()
+			}
+		}
+	}()
+}
+
+// Stop halts the background checks and waits for the goroutine to exit.
+// Safe to call multiple times.
+func (u *IdleUnloader) Stop() {
+	if u.cancel != nil {
+		u.cancel()
+	}
+	u.wg.Wait()
+}
+
+// CheckOnce unloads every loaded resource whose idle time exceeds its timeout.
+// Returns the names of the resources that were unloaded.
+func (u *IdleUnloader) CheckOnce() []string {
+	u.mu.Lock()
+	entries := append([]idleEntry(nil), u.resources...)
+	u.mu.Unlock()
+
+	var unloaded []string
+	now := u.clock.Now()
+	for _, e := range entries {
+		if !e.resource.IsLoaded() || now.Sub(e.resource.LastUsed()) < e.timeout {
+			continue
+		}
+		if e.resource.Unload() {
+			unloaded = append(unloaded, e.name)
+			if u.onUnload != nil {
+				u.onUnload(e.name)
+			}
+		}
+	}
+	return unloaded
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+type fakeIdleResource struct {
+	mu       sync.Mutex
+	loaded   bool
+	busy     bool
+	lastUsed time.Time
+	unloads  int
+}
+
+func (f *fakeIdleResource) IsLoaded() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.loaded
+}
+
+func (f *fakeIdleResource) LastUsed() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lastUsed
+}
+
+func (f *fakeIdleResource) Unload() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.loaded || f.busy {
+		return false
+	}
+	f.loaded = false
+	f.unloads++
+	return true
+}
+
+func TestIdleUnloader_CheckOnce(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	idle := &fakeIdleResource{loaded: true, lastUsed: now.Add(-time.Hour)}
+	recent := &fakeIdleResource{loaded: true, lastUsed: now.Add(-time.Minute)}
+	busy := &fakeIdleResource{loaded: true, busy: true, lastUsed: now.Add(-time.Hour)}
+	unloaded := &fakeIdleResource{loaded: false, lastUsed: now.Add(-time.Hour)}
+
+	var notified []string
+	u := NewIdleUnloader(time.Minute, func(name string) {
+		notified = append(notified, name)
+	})
+	u.SetClock(clock.NewFake(now))
+
+	u.Register("idle", idle, 30*time.Minute)
+	u.Register("recent", recent, 30*time.Minute)
+	u.Register("busy", busy, 30*time.Minute)
+	u.Register("unloaded", unloaded, 30*time.Minute)
+	u.Register("disabled", idle, 0)
+
+	if u.Len() != 4 {
+		t.Errorf("Len() = %d, want 4 (zero timeout is ignored)", u.Len())
+	}
+
+	got := u.CheckOnce()
+	if len(got) != 1 || got[0] != "idle" {
+		t.Errorf("CheckOnce() = %v, want [idle]", got)
+	}
+	if len(notified) != 1 || notified[0] != "idle" {
+		t.Errorf("onUnload called with %v, want [idle]", notified)
+	}
+	if idle.unloads != 1 || recent.unloads != 0 || busy.unloads != 0 || unloaded.unloads != 0 {
+		t.Errorf("unexpected unload counts: idle=%d recent=%d busy=%d unloaded=%d",
+			idle.unloads, recent.unloads, busy.unloads, unloaded.unloads)
+	}
+
+	// Already unloaded resources are not unloaded again
+	if got := u.CheckOnce(); len(got) != 0 {
+		t.Errorf("second CheckOnce() = %v, want none", got)
+	}
+}
+
+func TestIdleUnloader_StartStop(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	res := &fakeIdleResource{loaded: true, lastUsed: fake.Now()}
+
+	done := make(chan string, 1)
+	u := NewIdleUnloader(time.Minute, func(name string) {
+		done <- name
+	})
+	u.SetClock(fake)
+	u.Register("model", res, 2*time.Minute)
+	u.Start(context.Background())
+	defer u.Stop()
+
+	// Two check intervals pass before the model reaches its idle timeout
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	fake.Advance(time.Minute)
+
+	select {
+	case name := <-done:
+		if name != "model" {
+			t.Errorf("unloaded %q, want %q", name, "model")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("resource was not unloaded by background loop")
+	}
+
+	u.Stop()
+	u.Stop() // idempotent
+}
+
+// clockedIdleResource stamps its use with the clock it is given.
+type clockedIdleResource struct {
+	fakeIdleResource
+	clock clock.Clock
+}
+
+func (f *clockedIdleResource) SetClock(c clock.Clock) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clock = c
+	f.lastUsed = c.Now()
+}
+
+func (f *clockedIdleResource) use() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.lastUsed = f.clock.Now()
+}
+
+func TestIdleUnloader_SharesClockWithResources(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+	res := &clockedIdleResource{fakeIdleResource: fakeIdleResource{loaded: true}}
+
+	u := NewIdleUnloader(time.Minute, nil)
+	u.Register("model", res, 30*time.Minute)
+	u.SetClock(fake)
+	if !res.LastUsed().Equal(fake.Now()) {
+		t.Fatalf("LastUsed() = %v, want the unloader's clock %v", res.LastUsed(), fake.Now())
+	}
+
+	// Use on the shared clock keeps the resource loaded
+	fake.Advance(20 * time.Minute)
+	res.use()
+	fake.Advance(20 * time.Minute)
+	if got := u.CheckOnce(); len(got) != 0 {
+		t.Errorf("CheckOnce() = %v, want none 20 minutes after use", got)
+	}
+	fake.Advance(10 * time.Minute)
+	if got := u.CheckOnce(); len(got) != 1 {
+		t.Errorf("CheckOnce() = %v, want the model unloaded after 30 idle minutes", got)
+	}
+}
@@ -1,6 +1,8 @@
 // Package core provides shared interfaces for CanvusLocalLLM components.
 package core
 
+import "context"
+
 // ProgressReporter is the interface for reporting progress during AI operations.
 // Implementations should handle updating a UI element (typically a canvas note)
 // with status updates during long-running operations.
@@ -40,3 +42,9 @@ type ProgressReporter interface {
 	// Returns an error if deletion fails.
 	Cleanup() error
 }
+
+// GenerateFunc runs a prompt through a model and returns its reply. It is
+// how features that need a short completion (name detection, safety and
+// intent classification, document and email summaries) reach the local or
+// cloud model without depending on either.
+type GenerateFunc func(ctx context.Context, prompt string) (string, error)
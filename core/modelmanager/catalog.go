@@ -0,0 +1,142 @@
+package modelmanager
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Catalog model kinds.
+const (
+	// KindText is a GGUF language model served by llamaruntime.
+	KindText = "text"
+	// KindVision is a vision projector (mmproj) paired with a text model.
+	KindVision = "vision"
+	// KindImage is a Stable Diffusion checkpoint served by sdruntime.
+	KindImage = "image"
+)
+
+//go:embed catalog.json
+var defaultCatalogJSON []byte
+
+// CatalogEntry describes a recommended model that can be downloaded.
+// This is a data structure loaded from the JSON catalog manifest.
+type CatalogEntry struct {
+	// ID uniquely identifies the entry (e.g., "qwen2.5-3b-instruct")
+	ID string `json:"id"`
+	// Name is the display name
+	Name string `json:"name"`
+	// Kind is one of KindText, KindVision or KindImage
+	Kind string `json:"kind"`
+	// Description explains what the model is good for
+	Description string `json:"description,omitempty"`
+	// URL is the download URL for the model file or an hf:// URI
+	URL string `json:"url"`
+	// Revision pins the Hub revision of an hf:// URL (optional)
+	Revision string `json:"revision,omitempty"`
+	// Filename is the local filename in the model directory
+	Filename string `json:"filename"`
+	// SHA256 is the expected checksum (optional)
+	SHA256 string `json:"sha256,omitempty"`
+	// SizeBytes is the approximate download size
+	SizeBytes int64 `json:"size_bytes"`
+	// MinVRAMMB is the VRAM needed to run the model fully on the GPU
+	MinVRAMMB int64 `json:"min_vram_mb,omitempty"`
+	// EnvVar is the setting that points at the model after download
+	// (e.g., "LLAMA_MODEL_PATH"). Empty if no setting applies.
+	EnvVar string `json:"env_var,omitempty"`
+	// Recommended marks the default choice for its kind
+	Recommended bool `json:"recommended,omitempty"`
+}
+
+// ModelConfig converts the entry to a ModelConfig for downloading.
+func (e CatalogEntry) ModelConfig() ModelConfig {
+	return ModelConfig{
+		Name:           e.ID,
+		URL:            e.URL,
+		Revision:       e.Revision,
+		Filename:       e.Filename,
+		ExpectedSHA256: e.SHA256,
+		SizeBytes:      e.SizeBytes,
+	}
+}
+
+// Catalog is a curated list of downloadable models.
+type Catalog struct {
+	Version int            `json:"version"`
+	Models  []CatalogEntry `json:"models"`
+}
+
+// DefaultCatalog returns the catalog embedded in the binary.
+func DefaultCatalog() (*Catalog, error) {
+	return ParseCatalog(defaultCatalogJSON)
+}
+
+// LoadCatalog reads a catalog manifest from path.
+// An empty path returns the embedded default catalog.
+func LoadCatalog(path string) (*Catalog, error) {
+	if path == "" {
+		return DefaultCatalog()
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read catalog: %w", err)
+	}
+	return ParseCatalog(data)
+}
+
+// ParseCatalog parses and validates a JSON catalog manifest.
+func ParseCatalog(data []byte) (*Catalog, error) {
+	var catalog Catalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("parse catalog: %w", err)
+	}
+	if err := catalog.Validate(); err != nil {
+		return nil, err
+	}
+	return &catalog, nil
+}
+
+// Validate checks that every entry is complete and IDs are unique.
+func (c *Catalog) Validate() error {
+	seen := make(map[string]bool, len(c.Models))
+	for i, e := range c.Models {
+		if e.ID == "" {
+			return fmt.Errorf("catalog entry %d: id is required", i)
+		}
+		if seen[e.ID] {
+			return fmt.Errorf("catalog entry %q: duplicate id", e.ID)
+		}
+		seen[e.ID] = true
+
+		if e.URL == "" {
+			return fmt.Errorf("catalog entry %q: url is required", e.ID)
+		}
+		if IsHFURI(e.URL) {
+			if _, err := ParseHFURI(e.URL); err != nil {
+				return fmt.Errorf("catalog entry %q: %w", e.ID, err)
+			}
+		}
+		if e.Filename == "" || filepath.Base(e.Filename) != e.Filename {
+			return fmt.Errorf("catalog entry %q: filename must be a plain file name", e.ID)
+		}
+		switch e.Kind {
+		case KindText, KindVision, KindImage:
+		default:
+			return fmt.Errorf("catalog entry %q: unknown kind %q", e.ID, e.Kind)
+		}
+	}
+	return nil
+}
+
+// Get returns the entry with the given ID.
+func (c *Catalog) Get(id string) (CatalogEntry, bool) {
+	for _, e := range c.Models {
+		if e.ID == id {
+			return e, true
+		}
+	}
+	return CatalogEntry{}, false
+}
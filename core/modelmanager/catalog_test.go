@@ -0,0 +1,91 @@
+package modelmanager
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDefaultCatalog(t *testing.T) {
+	catalog, err := DefaultCatalog()
+	if err != nil {
+		t.Fatalf("DefaultCatalog() error = %v", err)
+	}
+	if len(catalog.Models) == 0 {
+		t.Fatal("default catalog has no models")
+	}
+
+	recommended := make(map[string]bool)
+	for _, e := range catalog.Models {
+		if e.SizeBytes <= 0 {
+			t.Errorf("entry %q: size_bytes must be set", e.ID)
+		}
+		if !strings.HasPrefix(e.URL, "https://") {
+			t.Errorf("entry %q: url %q is not https", e.ID, e.URL)
+		}
+		if e.Recommended {
+			recommended[e.Kind] = true
+		}
+	}
+	for _, kind := range []string{KindText, KindImage} {
+		if !recommended[kind] {
+			t.Errorf("no recommended %s model", kind)
+		}
+	}
+}
+
+func TestParseCatalog_Validation(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantErr string
+	}{
+		{"invalid json", `{`, "parse catalog"},
+		{"missing id", `{"models":[{"kind":"text","url":"u","filename":"a.gguf"}]}`, "id is required"},
+		{"duplicate id", `{"models":[{"id":"a","kind":"text","url":"u","filename":"a.gguf"},{"id":"a","kind":"text","url":"u","filename":"b.gguf"}]}`, "duplicate id"},
+		{"missing url", `{"models":[{"id":"a","kind":"text","filename":"a.gguf"}]}`, "url is required"},
+		{"path in filename", `{"models":[{"id":"a","kind":"text","url":"u","filename":"../a.gguf"}]}`, "plain file name"},
+		{"unknown kind", `{"models":[{"id":"a","kind":"audio","url":"u","filename":"a.gguf"}]}`, "unknown kind"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseCatalog([]byte(tt.json))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseCatalog() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadCatalog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "catalog.json")
+	data := `{"version":1,"models":[{"id":"custom","name":"Custom","kind":"image","url":"https://example.com/m.safetensors","filename":"m.safetensors","sha256":"abc","size_bytes":42,"env_var":"SD_MODEL_PATH"}]}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, err := LoadCatalog(path)
+	if err != nil {
+		t.Fatalf("LoadCatalog() error = %v", err)
+	}
+	entry, ok := catalog.Get("custom")
+	if !ok {
+		t.Fatal("Get(custom) not found")
+	}
+	if _, ok := catalog.Get("missing"); ok {
+		t.Error("Get(missing) found an entry")
+	}
+
+	cfg := entry.ModelConfig()
+	if cfg.Name != "custom" || cfg.Filename != "m.safetensors" || cfg.ExpectedSHA256 != "abc" || cfg.SizeBytes != 42 {
+		t.Errorf("ModelConfig() = %+v", cfg)
+	}
+
+	if _, err := LoadCatalog(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadCatalog(missing) expected error")
+	}
+	if catalog, err := LoadCatalog(""); err != nil || len(catalog.Models) == 0 {
+		t.Errorf("LoadCatalog(\"\") = %v, %v; want default catalog", catalog, err)
+	}
+}
@@ -0,0 +1,166 @@
+package modelmanager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Errors returned by DeleteLocalModel.
+var (
+	// ErrModelInUse indicates a model path setting points at the file
+	ErrModelInUse = errors.New("model is in use")
+	// ErrInvalidModelFile indicates a name that is not a model file in the model directory
+	ErrInvalidModelFile = errors.New("invalid model file name")
+)
+
+// ModelPathEnvVars are the settings that point at model files in use.
+var ModelPathEnvVars = []string{"LLAMA_MODEL_PATH", "SD_MODEL_PATH"}
+
+// modelFileExtensions are the file types treated as models in the model
+// directory. Other files are never listed or deleted.
+var modelFileExtensions = []string{".gguf", ".safetensors", ".ckpt", ".bin", PartialSuffix}
+
+// LocalModel describes a model file in the model directory.
+// This is a data structure returned by ListLocalModels.
+type LocalModel struct {
+	// Filename is the file name within the model directory
+	Filename string `json:"filename"`
+	// Path is the full path to the file
+	Path string `json:"path"`
+	// SizeBytes is the file size
+	SizeBytes int64 `json:"size_bytes"`
+	// ModTime is the last modification time
+	ModTime time.Time `json:"mod_time"`
+	// Partial is true for an unfinished download
+	Partial bool `json:"partial"`
+	// InUse is true if a model path setting points at the file
+	InUse bool `json:"in_use"`
+}
+
+// InUseModelPaths returns the model paths configured by ModelPathEnvVars.
+func InUseModelPaths() []string {
+	var paths []string
+	for _, key := range ModelPathEnvVars {
+		if p := os.Getenv(key); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// ListLocalModels returns the model files in the model directory, largest
+// first. Files matching one of inUse are marked InUse. A missing model
+// directory yields an empty list.
+func (mm *ModelManager) ListLocalModels(inUse []string) ([]LocalModel, error) {
+	entries, err := os.ReadDir(mm.modelDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read model directory: %w", err)
+	}
+
+	inUseSet := make(map[string]bool, len(inUse))
+	for _, p := range inUse {
+		inUseSet[absPath(p)] = true
+	}
+
+	var models []LocalModel
+	for _, entry := range entries {
+		if entry.IsDir() || !isModelFile(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed while listing
+		}
+		path := filepath.Join(mm.modelDir, entry.Name())
+		models = append(models, LocalModel{
+			Filename:  entry.Name(),
+			Path:      path,
+			SizeBytes: info.Size(),
+			ModTime:   info.ModTime(),
+			Partial:   strings.HasSuffix(entry.Name(), PartialSuffix),
+			InUse:     inUseSet[absPath(path)],
+		})
+	}
+
+	sort.Slice(models, func(i, j int) bool {
+		return models[i].SizeBytes > models[j].SizeBytes
+	})
+	return models, nil
+}
+
+// DeleteLocalModel deletes a model file from the model directory and
+// returns the number of bytes freed. Files in use cannot be deleted.
+func (mm *ModelManager) DeleteLocalModel(filename string, inUse []string) (int64, error) {
+	if filename == "" || filepath.Base(filename) != filename || !isModelFile(filename) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidModelFile, filename)
+	}
+
+	models, err := mm.ListLocalModels(inUse)
+	if err != nil {
+		return 0, err
+	}
+	for _, m := range models {
+		if m.Filename != filename {
+			continue
+		}
+		if m.InUse {
+			return 0, fmt.Errorf("%s: %w", filename, ErrModelInUse)
+		}
+		if err := os.Remove(m.Path); err != nil {
+			return 0, fmt.Errorf("delete model: %w", err)
+		}
+		return m.SizeBytes, nil
+	}
+	return 0, fmt.Errorf("model %s not found in %s: %w", filename, mm.modelDir, os.ErrNotExist)
+}
+
+// CleanupUnusedModels deletes every model file not in inUse, including
+// partial downloads, and returns the files removed. With dryRun nothing is
+// deleted and the files that would be removed are returned.
+func (mm *ModelManager) CleanupUnusedModels(inUse []string, dryRun bool) ([]LocalModel, error) {
+	models, err := mm.ListLocalModels(inUse)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []LocalModel
+	for _, m := range models {
+		if m.InUse {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(m.Path); err != nil {
+				return removed, fmt.Errorf("delete model %s: %w", m.Filename, err)
+			}
+		}
+		removed = append(removed, m)
+	}
+	return removed, nil
+}
+
+// isModelFile reports whether name has a model file extension.
+func isModelFile(name string) bool {
+	lower := strings.ToLower(name)
+	for _, ext := range modelFileExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// absPath returns the absolute form of p, or p cleaned if that fails.
+func absPath(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return filepath.Clean(p)
+}
@@ -0,0 +1,197 @@
+package modelmanager
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go_backend/core"
+)
+
+// writeModelFiles creates files of the given sizes in dir.
+func writeModelFiles(t *testing.T, dir string, files map[string]int) {
+	t.Helper()
+	for name, size := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestModelManager_ListLocalModels(t *testing.T) {
+	dir := t.TempDir()
+	writeModelFiles(t, dir, map[string]int{
+		"active.gguf":        30,
+		"old.safetensors":    20,
+		"new.gguf.part":      10,
+		"README.md":          5,
+		"catalog-notes.json": 5,
+	})
+	if err := os.Mkdir(filepath.Join(dir, "sub.gguf"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	mm := NewModelManager(dir, nil)
+	models, err := mm.ListLocalModels([]string{filepath.Join(dir, "active.gguf")})
+	if err != nil {
+		t.Fatalf("ListLocalModels() error = %v", err)
+	}
+
+	var names []string
+	for _, m := range models {
+		names = append(names, m.Filename)
+	}
+	if got := strings.Join(names, ","); got != "active.gguf,old.safetensors,new.gguf.part" {
+		t.Fatalf("ListLocalModels() = %s, want largest first and model files only", got)
+	}
+	if !models[0].InUse || models[1].InUse {
+		t.Error("InUse not set from inUse paths")
+	}
+	if !models[2].Partial || models[0].Partial {
+		t.Error("Partial not set for .part files")
+	}
+
+	missing := NewModelManager(filepath.Join(dir, "missing"), nil)
+	if models, err := missing.ListLocalModels(nil); err != nil || len(models) != 0 {
+		t.Errorf("ListLocalModels(missing dir) = %v, %v; want empty", models, err)
+	}
+}
+
+func TestModelManager_DeleteLocalModel(t *testing.T) {
+	dir := t.TempDir()
+	writeModelFiles(t, dir, map[string]int{"active.gguf": 30, "old.gguf": 20, "notes.txt": 1})
+	inUse := []string{filepath.Join(dir, "active.gguf")}
+	mm := NewModelManager(dir, nil)
+
+	freed, err := mm.DeleteLocalModel("old.gguf", inUse)
+	if err != nil || freed != 20 {
+		t.Fatalf("DeleteLocalModel() = %d, %v; want 20, nil", freed, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.gguf")); !os.IsNotExist(err) {
+		t.Error("old.gguf was not deleted")
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		wantErr  string
+	}{
+		{"in use", "active.gguf", "is in use"},
+		{"not found", "old.gguf", "not found"},
+		{"path traversal", "../active.gguf", "invalid"},
+		{"not a model", "notes.txt", "invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := mm.DeleteLocalModel(tt.filename, inUse)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("DeleteLocalModel(%q) error = %v, want containing %q", tt.filename, err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := mm.DeleteLocalModel("old.gguf", inUse); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("missing model error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestModelManager_CleanupUnusedModels(t *testing.T) {
+	dir := t.TempDir()
+	writeModelFiles(t, dir, map[string]int{"active.gguf": 30, "old.gguf": 20, "new.gguf.part": 10, "notes.txt": 1})
+	inUse := []string{filepath.Join(dir, "active.gguf")}
+	mm := NewModelManager(dir, nil)
+
+	planned, err := mm.CleanupUnusedModels(inUse, true)
+	if err != nil || len(planned) != 2 {
+		t.Fatalf("dry run = %v, %v; want 2 files", planned, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "old.gguf")); err != nil {
+		t.Error("dry run deleted a file")
+	}
+
+	removed, err := mm.CleanupUnusedModels(inUse, false)
+	if err != nil || len(removed) != 2 {
+		t.Fatalf("CleanupUnusedModels() = %v, %v; want 2 files", removed, err)
+	}
+	for name, want := range map[string]bool{"active.gguf": true, "old.gguf": false, "new.gguf.part": false, "notes.txt": true} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != want {
+			t.Errorf("%s exists = %v, want %v", name, exists, want)
+		}
+	}
+}
+
+func TestInUseModelPaths(t *testing.T) {
+	t.Setenv("LLAMA_MODEL_PATH", "/models/text.gguf")
+	t.Setenv("SD_MODEL_PATH", "")
+
+	paths := InUseModelPaths()
+	if len(paths) != 1 || paths[0] != "/models/text.gguf" {
+		t.Errorf("InUseModelPaths() = %v", paths)
+	}
+}
+
+func TestModelManager_DiskSpacePreflight(t *testing.T) {
+	// Larger than any test machine's disk, small enough not to overflow the buffer math
+	const hugeSize = int64(1) << 55
+
+	t.Run("configured size", func(t *testing.T) {
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+		}))
+		defer server.Close()
+
+		mm := NewModelManager(t.TempDir(), nil)
+		_, err := mm.EnsureModel(context.Background(), ModelConfig{
+			Name:      "huge-model",
+			URL:       server.URL + "/huge.gguf",
+			Filename:  "huge.gguf",
+			SizeBytes: hugeSize,
+		}, nil)
+
+		var spaceErr *core.DiskSpaceError
+		if !errors.As(err, &spaceErr) {
+			t.Fatalf("EnsureModel() error = %v, want DiskSpaceError", err)
+		}
+		if !strings.Contains(err.Error(), "insufficient disk space") || !strings.Contains(err.Error(), "models clean") {
+			t.Errorf("error message not actionable: %v", err)
+		}
+		if requests != 0 {
+			t.Errorf("requests = %d, want none before the preflight passes", requests)
+		}
+	})
+
+	t.Run("size from HEAD request", func(t *testing.T) {
+		var gets int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodHead {
+				w.Header().Set("Content-Length", strconv.FormatInt(hugeSize, 10))
+				return
+			}
+			gets++
+		}))
+		defer server.Close()
+
+		mm := NewModelManager(t.TempDir(), nil)
+		_, err := mm.EnsureModel(context.Background(), ModelConfig{
+			Name:     "unsized-model",
+			URL:      server.URL + "/huge.gguf",
+			Filename: "huge.gguf",
+		}, nil)
+
+		var spaceErr *core.DiskSpaceError
+		if !errors.As(err, &spaceErr) {
+			t.Fatalf("EnsureModel() error = %v, want DiskSpaceError", err)
+		}
+		if gets != 0 {
+			t.Errorf("GET requests = %d, want none", gets)
+		}
+	})
+}
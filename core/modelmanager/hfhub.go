@@ -0,0 +1,130 @@
+package modelmanager
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// HFScheme is the URI scheme for files hosted on the Hugging Face Hub.
+const HFScheme = "hf://"
+
+// DefaultHFEndpoint is the Hugging Face Hub base URL.
+const DefaultHFEndpoint = "https://huggingface.co"
+
+// DefaultHFRevision is the revision used when a URI does not pin one.
+const DefaultHFRevision = "main"
+
+// HFFile identifies a file in a Hugging Face Hub model repository.
+// This is a data structure parsed from an hf:// URI.
+type HFFile struct {
+	// Repo is the repository ID (e.g., "Qwen/Qwen2.5-3B-Instruct-GGUF")
+	Repo string
+	// Revision is a branch, tag or commit hash (empty means DefaultHFRevision)
+	Revision string
+	// Path is the file path within the repository
+	Path string
+}
+
+// IsHFURI reports whether uri uses the hf:// scheme.
+func IsHFURI(uri string) bool {
+	return strings.HasPrefix(uri, HFScheme)
+}
+
+// ParseHFURI parses a URI of the form hf://org/repo[@revision]/path/to/file.
+// Pinning a commit hash as the revision guarantees the same file on every
+// download, which also keeps resumed downloads consistent.
+func ParseHFURI(uri string) (HFFile, error) {
+	if !IsHFURI(uri) {
+		return HFFile{}, fmt.Errorf("not an hf:// URI: %q", uri)
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(uri, HFScheme), "/", 3)
+	if len(parts) < 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return HFFile{}, fmt.Errorf("invalid hf:// URI %q: expected hf://org/repo[@revision]/file", uri)
+	}
+
+	name, revision, _ := strings.Cut(parts[1], "@")
+	if name == "" || strings.HasSuffix(parts[1], "@") {
+		return HFFile{}, fmt.Errorf("invalid hf:// URI %q: empty repository or revision", uri)
+	}
+
+	path := parts[2]
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return HFFile{}, fmt.Errorf("invalid hf:// URI %q: bad file path", uri)
+		}
+	}
+
+	return HFFile{
+		Repo:     parts[0] + "/" + name,
+		Revision: revision,
+		Path:     path,
+	}, nil
+}
+
+// String returns the hf:// URI of the file.
+func (f HFFile) String() string {
+	repo := f.Repo
+	if f.Revision != "" {
+		repo += "@" + f.Revision
+	}
+	return HFScheme + repo + "/" + f.Path
+}
+
+// ResolveURL returns the download URL of the file on the Hub at endpoint
+// (DefaultHFEndpoint if empty).
+func (f HFFile) ResolveURL(endpoint string) string {
+	if endpoint == "" {
+		endpoint = DefaultHFEndpoint
+	}
+	revision := f.Revision
+	if revision == "" {
+		revision = DefaultHFRevision
+	}
+
+	segments := strings.Split(f.Path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+
+	// Revisions such as refs/pr/1 contain slashes and are escaped as one segment
+	return fmt.Sprintf("%s/%s/resolve/%s/%s",
+		strings.TrimSuffix(endpoint, "/"), f.Repo, url.PathEscape(revision), strings.Join(segments, "/"))
+}
+
+// resolveDownloadURL returns the HTTP URL to download modelCfg from, resolving
+// hf:// URIs against the manager's Hub endpoint. A Revision set on the config
+// pins URIs that do not name one.
+func (mm *ModelManager) resolveDownloadURL(modelCfg ModelConfig) (string, error) {
+	if !IsHFURI(modelCfg.URL) {
+		return modelCfg.URL, nil
+	}
+
+	file, err := ParseHFURI(modelCfg.URL)
+	if err != nil {
+		return "", err
+	}
+	if modelCfg.Revision != "" {
+		if file.Revision != "" && file.Revision != modelCfg.Revision {
+			return "", fmt.Errorf("model %s: revision %q conflicts with %q in %s",
+				modelCfg.Name, modelCfg.Revision, file.Revision, modelCfg.URL)
+		}
+		file.Revision = modelCfg.Revision
+	}
+	return file.ResolveURL(mm.hfEndpoint), nil
+}
+
+// isHFURL reports whether rawURL points at the manager's Hub endpoint, so
+// the access token is only ever sent to the Hub.
+func (mm *ModelManager) isHFURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	hub, err := url.Parse(mm.hfEndpoint)
+	if err != nil {
+		return false
+	}
+	return u.Scheme == hub.Scheme && u.Host == hub.Host
+}
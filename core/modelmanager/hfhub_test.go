@@ -0,0 +1,251 @@
+package modelmanager
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseHFURI(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		want    HFFile
+		wantErr bool
+	}{
+		{
+			name: "default revision",
+			uri:  "hf://Qwen/Qwen2.5-3B-Instruct-GGUF/qwen2.5-3b-instruct-q4_k_m.gguf",
+			want: HFFile{Repo: "Qwen/Qwen2.5-3B-Instruct-GGUF", Path: "qwen2.5-3b-instruct-q4_k_m.gguf"},
+		},
+		{
+			name: "pinned revision and nested path",
+			uri:  "hf://org/repo@0123abcd/gguf/model Q4.gguf",
+			want: HFFile{Repo: "org/repo", Revision: "0123abcd", Path: "gguf/model Q4.gguf"},
+		},
+		{name: "wrong scheme", uri: "https://huggingface.co/org/repo/file", wantErr: true},
+		{name: "missing file", uri: "hf://org/repo", wantErr: true},
+		{name: "missing repo", uri: "hf://org//file.gguf", wantErr: true},
+		{name: "empty revision", uri: "hf://org/repo@/file.gguf", wantErr: true},
+		{name: "path traversal", uri: "hf://org/repo/../file.gguf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseHFURI(tt.uri)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseHFURI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("ParseHFURI() = %+v, want %+v", got, tt.want)
+			}
+			if err == nil && got.String() != tt.uri {
+				t.Errorf("String() = %q, want %q", got.String(), tt.uri)
+			}
+		})
+	}
+}
+
+func TestHFFile_ResolveURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     HFFile
+		endpoint string
+		want     string
+	}{
+		{
+			name: "default endpoint and revision",
+			file: HFFile{Repo: "org/repo", Path: "model.gguf"},
+			want: "https://huggingface.co/org/repo/resolve/main/model.gguf",
+		},
+		{
+			name:     "mirror endpoint with pinned commit",
+			file:     HFFile{Repo: "org/repo", Revision: "0123abcd", Path: "sub/model Q4.gguf"},
+			endpoint: "https://hf-mirror.example.com/",
+			want:     "https://hf-mirror.example.com/org/repo/resolve/0123abcd/sub/model%20Q4.gguf",
+		},
+		{
+			name: "pull request revision",
+			file: HFFile{Repo: "org/repo", Revision: "refs/pr/1", Path: "model.gguf"},
+			want: "https://huggingface.co/org/repo/resolve/refs%2Fpr%2F1/model.gguf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.file.ResolveURL(tt.endpoint); got != tt.want {
+				t.Errorf("ResolveURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelManager_resolveDownloadURL(t *testing.T) {
+	mm := NewModelManager(t.TempDir(), nil, WithHFEndpoint("https://hub.example.com"))
+
+	tests := []struct {
+		name    string
+		cfg     ModelConfig
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "plain URL unchanged",
+			cfg:  ModelConfig{URL: "https://example.com/model.gguf", Revision: "v1"},
+			want: "https://example.com/model.gguf",
+		},
+		{
+			name: "config revision pins URI",
+			cfg:  ModelConfig{URL: "hf://org/repo/model.gguf", Revision: "v1.0"},
+			want: "https://hub.example.com/org/repo/resolve/v1.0/model.gguf",
+		},
+		{
+			name: "matching revisions",
+			cfg:  ModelConfig{URL: "hf://org/repo@v1.0/model.gguf", Revision: "v1.0"},
+			want: "https://hub.example.com/org/repo/resolve/v1.0/model.gguf",
+		},
+		{
+			name:    "conflicting revisions",
+			cfg:     ModelConfig{URL: "hf://org/repo@v1.0/model.gguf", Revision: "v2.0"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid URI",
+			cfg:     ModelConfig{URL: "hf://org/repo"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mm.resolveDownloadURL(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveDownloadURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveDownloadURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelManager_EnsureModel_HFHub(t *testing.T) {
+	content := []byte("gguf model from the hub")
+
+	t.Run("downloads with token and pinned revision", func(t *testing.T) {
+		var gotPath, gotAuth string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.EscapedPath()
+			gotAuth = r.Header.Get("Authorization")
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content)
+		}))
+		defer server.Close()
+
+		tmpDir := t.TempDir()
+		mm := NewModelManager(tmpDir, nil, WithHFEndpoint(server.URL), WithHFToken("hf_secret"))
+		path, err := mm.EnsureModel(context.Background(), ModelConfig{
+			Name:     "hub-model",
+			URL:      "hf://org/repo@abc123/model.gguf",
+			Filename: "model.gguf",
+		}, nil)
+		if err != nil {
+			t.Fatalf("EnsureModel() error = %v", err)
+		}
+
+		if gotPath != "/org/repo/resolve/abc123/model.gguf" {
+			t.Errorf("request path = %q", gotPath)
+		}
+		if gotAuth != "Bearer hf_secret" {
+			t.Errorf("Authorization = %q, want bearer token", gotAuth)
+		}
+		if data, _ := os.ReadFile(path); string(data) != string(content) {
+			t.Errorf("downloaded content = %q", data)
+		}
+		if _, err := os.Stat(path + PartialSuffix); !os.IsNotExist(err) {
+			t.Error("partial file left behind")
+		}
+	})
+
+	t.Run("token not sent to other hosts", func(t *testing.T) {
+		var gotAuth string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotAuth = r.Header.Get("Authorization")
+			w.Write(content)
+		}))
+		defer server.Close()
+
+		mm := NewModelManager(t.TempDir(), nil, WithHFToken("hf_secret"))
+		if _, err := mm.EnsureModel(context.Background(), ModelConfig{
+			Name:     "plain-model",
+			URL:      server.URL + "/model.gguf",
+			Filename: "model.gguf",
+		}, nil); err != nil {
+			t.Fatalf("EnsureModel() error = %v", err)
+		}
+		if gotAuth != "" {
+			t.Errorf("Authorization = %q, want none", gotAuth)
+		}
+	})
+
+	t.Run("resumes partial download", func(t *testing.T) {
+		var gotRange string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotRange = r.Header.Get("Range")
+			w.Header().Set("Content-Range", "bytes 5-"+strconv.Itoa(len(content)-1)+"/"+strconv.Itoa(len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(content[5:])
+		}))
+		defer server.Close()
+
+		tmpDir := t.TempDir()
+		destPath := filepath.Join(tmpDir, "model.gguf")
+		if err := os.WriteFile(destPath+PartialSuffix, content[:5], 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		mm := NewModelManager(tmpDir, nil, WithHFEndpoint(server.URL))
+		cfg := ModelConfig{Name: "hub-model", URL: "hf://org/repo/model.gguf", Filename: "model.gguf"}
+		if mm.HasModel(cfg) {
+			t.Fatal("partial download reported as installed")
+		}
+		if _, err := mm.EnsureModel(context.Background(), cfg, nil); err != nil {
+			t.Fatalf("EnsureModel() error = %v", err)
+		}
+		if gotRange != "bytes=5-" {
+			t.Errorf("Range = %q, want bytes=5-", gotRange)
+		}
+		if data, _ := os.ReadFile(destPath); string(data) != string(content) {
+			t.Errorf("resumed content = %q", data)
+		}
+	})
+
+	t.Run("gated repo without token fails fast", func(t *testing.T) {
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodGet {
+				requests++
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		mm := NewModelManager(t.TempDir(), nil, WithHFEndpoint(server.URL))
+		_, err := mm.EnsureModel(context.Background(), ModelConfig{
+			Name:     "gated-model",
+			URL:      "hf://org/gated/model.gguf",
+			Filename: "model.gguf",
+		}, nil)
+		if err == nil || !strings.Contains(err.Error(), "HF_TOKEN") {
+			t.Errorf("EnsureModel() error = %v, want HF_TOKEN hint", err)
+		}
+		if requests != 1 {
+			t.Errorf("requests = %d, want 1 (auth errors are not retried)", requests)
+		}
+	})
+}
@@ -2,6 +2,7 @@ package modelmanager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -17,8 +18,12 @@ import (
 type ModelConfig struct {
 	// Name is the friendly name of the model (e.g., "bunny-v1.1")
 	Name string
-	// URL is the download URL for the model file
+	// URL is the download URL for the model file, or an hf:// URI
+	// (hf://org/repo[@revision]/file) for files on the Hugging Face Hub
 	URL string
+	// Revision pins the Hub revision (branch, tag or commit) of an hf:// URL
+	// that does not name one. Ignored for other URLs.
+	Revision string
 	// Filename is the local filename for the model
 	Filename string
 	// ExpectedSHA256 is the expected SHA256 checksum for verification
@@ -55,6 +60,9 @@ var DefaultSDModel = ModelConfig{
 	SizeBytes:      2 * core.BytesPerGB, // ~2GB for SD Turbo
 }
 
+// PartialSuffix is appended to the model filename while it downloads.
+const PartialSuffix = ".part"
+
 // ModelManager manages AI model availability and downloading.
 // This is an organism that composes download molecules and disk space atoms
 // to provide model lifecycle management.
@@ -73,6 +81,10 @@ type ModelManager struct {
 	diskSpaceBuffer int
 	// onProgress is called during downloads to report progress (optional)
 	onProgress func(core.ProgressInfo)
+	// hfEndpoint is the Hugging Face Hub base URL for hf:// URIs
+	hfEndpoint string
+	// hfToken is the Hugging Face access token for gated or private repos
+	hfToken string
 }
 
 // ModelManagerOption is a functional option for configuring ModelManager.
@@ -120,6 +132,23 @@ func WithOnProgress(callback func(core.ProgressInfo)) ModelManagerOption {
 	}
 }
 
+// WithHFToken sets the Hugging Face access token used to download gated or
+// private models. The token is only sent to the Hub endpoint.
+func WithHFToken(token string) ModelManagerOption {
+	return func(mm *ModelManager) {
+		mm.hfToken = token
+	}
+}
+
+// WithHFEndpoint overrides the Hugging Face Hub base URL (e.g., a mirror).
+func WithHFEndpoint(endpoint string) ModelManagerOption {
+	return func(mm *ModelManager) {
+		if endpoint != "" {
+			mm.hfEndpoint = strings.TrimSuffix(endpoint, "/")
+		}
+	}
+}
+
 // NewModelManager creates a new ModelManager with the given configuration.
 // The modelDir parameter specifies where models are stored.
 // The httpClient parameter is used for downloads (if nil, a default client is created).
@@ -129,6 +158,7 @@ func WithOnProgress(callback func(core.ProgressInfo)) ModelManagerOption {
 //   - 10% disk space buffer
 //   - Default text, vision, and SD models registered
 //   - No progress callback (silent downloads)
+//   - hf:// URIs resolved against DefaultHFEndpoint without a token
 func NewModelManager(modelDir string, httpClient *http.Client, opts ...ModelManagerOption) *ModelManager {
 	if httpClient == nil {
 		httpClient = &http.Client{
@@ -144,6 +174,7 @@ func NewModelManager(modelDir string, httpClient *http.Client, opts ...ModelMana
 		baseRetryDelay:  2 * time.Second,
 		diskSpaceBuffer: core.DefaultBufferPercent,
 		onProgress:      nil, // No progress callback by default
+		hfEndpoint:      DefaultHFEndpoint,
 	}
 
 	// Register default models
@@ -194,7 +225,48 @@ func (mm *ModelManager) EnsureModelAvailable(ctx context.Context, modelName stri
 	}
 
 	// Download the model
-	return mm.downloadModel(ctx, modelCfg, modelPath)
+	return mm.downloadModel(ctx, modelCfg, modelPath, mm.onProgress)
+}
+
+// EnsureModel makes a model available, downloading it if missing, and
+// returns its local path. Unlike EnsureModelAvailable the model does not
+// need to be registered, and onProgress (if non-nil) receives this
+// download's progress instead of the manager-wide callback.
+func (mm *ModelManager) EnsureModel(ctx context.Context, modelCfg ModelConfig, onProgress func(core.ProgressInfo)) (string, error) {
+	modelPath := mm.ModelPath(modelCfg)
+
+	exists, err := mm.checkModelExists(modelPath, modelCfg.ExpectedSHA256)
+	if err != nil {
+		return "", fmt.Errorf("check model exists: %w", err)
+	}
+	if exists {
+		return modelPath, nil
+	}
+
+	if onProgress == nil {
+		onProgress = mm.onProgress
+	}
+	if err := mm.downloadModel(ctx, modelCfg, modelPath, onProgress); err != nil {
+		return "", err
+	}
+	return modelPath, nil
+}
+
+// HasModel reports whether the model file is present in the model
+// directory. The checksum is not verified.
+func (mm *ModelManager) HasModel(modelCfg ModelConfig) bool {
+	exists, err := mm.checkModelExists(mm.ModelPath(modelCfg), "")
+	return err == nil && exists
+}
+
+// ModelDir returns the directory where models are stored.
+func (mm *ModelManager) ModelDir() string {
+	return mm.modelDir
+}
+
+// ModelPath returns the local path of a model file in the model directory.
+func (mm *ModelManager) ModelPath(modelCfg ModelConfig) string {
+	return filepath.Join(mm.modelDir, modelCfg.Filename)
 }
 
 // checkModelExists verifies if a model file exists and optionally validates checksum.
@@ -249,21 +321,25 @@ func (mm *ModelManager) checkModelExists(modelPath string, expectedChecksum stri
 //   - ctx: context for cancellation
 //   - modelCfg: model configuration with URL, checksum, etc.
 //   - destPath: destination path for the downloaded file
+//   - onProgress: progress callback (optional)
 //
 // Returns:
 //   - error: if download fails after all retries
-func (mm *ModelManager) downloadModel(ctx context.Context, modelCfg ModelConfig, destPath string) error {
-	// Check disk space before download
-	if modelCfg.SizeBytes > 0 {
-		if err := core.CheckDiskSpaceForModel(mm.modelDir, modelCfg.SizeBytes, mm.diskSpaceBuffer); err != nil {
-			return &ModelDownloadError{
-				ModelName: modelCfg.Name,
-				Cause:     err,
-				Message:   fmt.Sprintf("insufficient disk space: need %s with %d%% buffer", core.FormatBytes(modelCfg.SizeBytes), mm.diskSpaceBuffer),
-			}
+func (mm *ModelManager) downloadModel(ctx context.Context, modelCfg ModelConfig, destPath string, onProgress func(core.ProgressInfo)) error {
+	downloadURL, err := mm.resolveDownloadURL(modelCfg)
+	if err != nil {
+		return &ModelDownloadError{
+			ModelName: modelCfg.Name,
+			Cause:     err,
+			Message:   "invalid model URL",
 		}
 	}
 
+	// Check disk space before download
+	if err := mm.preflightDiskSpace(ctx, modelCfg, downloadURL, destPath); err != nil {
+		return err
+	}
+
 	// Ensure model directory exists
 	if err := os.MkdirAll(mm.modelDir, 0755); err != nil {
 		return fmt.Errorf("create model directory: %w", err)
@@ -290,7 +366,7 @@ func (mm *ModelManager) downloadModel(ctx context.Context, modelCfg ModelConfig,
 		}
 
 		// Attempt download
-		err := mm.attemptDownload(ctx, modelCfg, destPath, attempt)
+		err := mm.attemptDownload(ctx, modelCfg, downloadURL, destPath, onProgress)
 		if err == nil {
 			return nil // Success
 		}
@@ -303,31 +379,119 @@ func (mm *ModelManager) downloadModel(ctx context.Context, modelCfg ModelConfig,
 		}
 	}
 
+	message := fmt.Sprintf("download failed after %d attempts", mm.maxRetries)
+	if isAuthError(lastErr) {
+		message = "access denied: gated or private Hugging Face models require an access token (HF_TOKEN) with access to the repository"
+	}
+
 	// All retries exhausted
 	return &ModelDownloadError{
 		ModelName: modelCfg.Name,
 		Cause:     lastErr,
-		Message:   fmt.Sprintf("download failed after %d attempts", mm.maxRetries),
-		URL:       modelCfg.URL,
+		Message:   message,
+		URL:       downloadURL,
 		DestPath:  destPath,
 		Checksum:  modelCfg.ExpectedSHA256,
 	}
 }
 
 // attemptDownload performs a single download attempt.
-// If onProgress callback is configured, it will receive progress updates.
-func (mm *ModelManager) attemptDownload(ctx context.Context, modelCfg ModelConfig, destPath string, attempt int) error {
+// If onProgress is non-nil, it will receive progress updates.
+//
+// The file is downloaded to destPath + PartialSuffix and renamed once
+// complete, so an interrupted download is never mistaken for a model and
+// the next attempt (or the next run) resumes where it stopped.
+func (mm *ModelManager) attemptDownload(ctx context.Context, modelCfg ModelConfig, downloadURL, destPath string, onProgress func(core.ProgressInfo)) error {
+	partialPath := destPath + PartialSuffix
 	opts := core.DownloadOptions{
-		URL:            modelCfg.URL,
-		DestPath:       destPath,
+		URL:            downloadURL,
+		DestPath:       partialPath,
 		ExpectedSHA256: modelCfg.ExpectedSHA256,
 		HTTPClient:     mm.httpClient,
-		Resume:         true,           // Enable resume for large model files
-		OnProgress:     mm.onProgress, // Pass through progress callback
+		Resume:         true,       // Enable resume for large model files
+		OnProgress:     onProgress, // Pass through progress callback
+	}
+	opts.Headers = mm.requestHeaders(downloadURL)
+
+	if _, err := core.DownloadWithProgress(ctx, opts); err != nil {
+		if strings.Contains(err.Error(), "checksum mismatch") {
+			// Resuming a corrupt file cannot succeed
+			os.Remove(partialPath)
+		}
+		return err
+	}
+
+	if err := os.Rename(partialPath, destPath); err != nil {
+		return fmt.Errorf("move downloaded model into place: %w", err)
+	}
+	return nil
+}
+
+// preflightDiskSpace verifies the model directory has room for the rest of
+// the download plus the configured buffer. The size comes from the model
+// config or, if unset, from a HEAD request; bytes already downloaded to the
+// partial file are not counted again. Unknown sizes skip the check.
+func (mm *ModelManager) preflightDiskSpace(ctx context.Context, modelCfg ModelConfig, downloadURL, destPath string) error {
+	size := modelCfg.SizeBytes
+	if size <= 0 {
+		size = mm.remoteSize(ctx, downloadURL)
+	}
+	if info, err := os.Stat(destPath + PartialSuffix); err == nil {
+		size -= info.Size()
+	}
+	if size <= 0 {
+		return nil
+	}
+
+	err := core.CheckDiskSpaceForModel(mm.modelDir, size, mm.diskSpaceBuffer)
+	if err == nil {
+		return nil
+	}
+
+	message := fmt.Sprintf("cannot check disk space in %s", mm.modelDir)
+	var spaceErr *core.DiskSpaceError
+	if errors.As(err, &spaceErr) {
+		message = fmt.Sprintf("insufficient disk space in %s: download needs %s (including %d%% buffer), only %s free; "+
+			"free up space or delete unused models with 'canvuslocallm models clean'",
+			mm.modelDir, core.FormatBytes(spaceErr.Required), mm.diskSpaceBuffer, core.FormatBytes(spaceErr.Available))
+	}
+	return &ModelDownloadError{
+		ModelName: modelCfg.Name,
+		Cause:     err,
+		Message:   message,
+	}
+}
+
+// remoteSize returns the size of the file at downloadURL from a HEAD
+// request, or 0 if the server does not report it.
+func (mm *ModelManager) remoteSize(ctx context.Context, downloadURL string) int64 {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, downloadURL, nil)
+	if err != nil {
+		return 0
+	}
+	req.Header = mm.requestHeaders(downloadURL)
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+
+	resp, err := mm.httpClient.Do(req)
+	if err != nil {
+		return 0
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0
 	}
+	return resp.ContentLength
+}
 
-	_, err := core.DownloadWithProgress(ctx, opts)
-	return err
+// requestHeaders returns the headers for requests to downloadURL: the Hub
+// access token when downloading from the Hub, otherwise none.
+func (mm *ModelManager) requestHeaders(downloadURL string) http.Header {
+	if mm.hfToken != "" && mm.isHFURL(downloadURL) {
+		return http.Header{"Authorization": {"Bearer " + mm.hfToken}}
+	}
+	return nil
 }
 
 // isRetryableError determines if an error is worth retrying.
@@ -353,10 +517,24 @@ func (mm *ModelManager) isRetryableError(err error) bool {
 		return false
 	}
 
+	// Authorization failures repeat until a (valid) token is configured
+	if isAuthError(err) {
+		return false
+	}
+
 	// Most other errors (network, HTTP) are retryable
 	return true
 }
 
+// isAuthError reports whether err is an HTTP 401 or 403 response.
+func isAuthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	errStr := err.Error()
+	return strings.Contains(errStr, "status code: 401") || strings.Contains(errStr, "status code: 403")
+}
+
 // availableModelNames returns the names of all registered models.
 func (mm *ModelManager) availableModelNames() []string {
 	names := make([]string, 0, len(mm.models))
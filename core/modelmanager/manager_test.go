@@ -311,6 +311,54 @@ func TestModelManager_EnsureModelAvailable(t *testing.T) {
 	})
 }
 
+func TestModelManager_EnsureModel(t *testing.T) {
+	content := []byte("catalog model content")
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requests++
+		}
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(content)))
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	mm := NewModelManager(tmpDir, nil)
+	cfg := ModelConfig{
+		Name:     "catalog-model",
+		URL:      server.URL + "/model.gguf",
+		Filename: "catalog-model.gguf",
+	}
+
+	if mm.HasModel(cfg) {
+		t.Fatal("HasModel() = true before download")
+	}
+
+	var progressCalls int
+	path, err := mm.EnsureModel(context.Background(), cfg, func(core.ProgressInfo) { progressCalls++ })
+	if err != nil {
+		t.Fatalf("EnsureModel() error = %v", err)
+	}
+	if want := filepath.Join(tmpDir, "catalog-model.gguf"); path != want || mm.ModelPath(cfg) != want {
+		t.Errorf("EnsureModel() path = %q, want %q", path, want)
+	}
+	if progressCalls == 0 {
+		t.Error("progress callback was not called")
+	}
+	if !mm.HasModel(cfg) {
+		t.Error("HasModel() = false after download")
+	}
+
+	// A second call finds the existing file without downloading
+	if _, err := mm.EnsureModel(context.Background(), cfg, nil); err != nil {
+		t.Fatalf("second EnsureModel() error = %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+}
+
 func TestModelManager_RetryLogic(t *testing.T) {
 	t.Run("retries on transient failure", func(t *testing.T) {
 		var requestCount int32
@@ -543,6 +591,8 @@ func TestModelManager_isRetryableError(t *testing.T) {
 		{"disk space error", &core.DiskSpaceError{Message: "insufficient space"}, false},
 		{"network error", fmt.Errorf("connection refused"), true},
 		{"http error", fmt.Errorf("HTTP 500"), true},
+		{"unauthorized", fmt.Errorf("unexpected status code: 401 401 Unauthorized"), false},
+		{"forbidden", fmt.Errorf("unexpected status code: 403 403 Forbidden"), false},
 		{"generic error", fmt.Errorf("something went wrong"), true},
 	}
 
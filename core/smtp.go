@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultSMTPPort is the SMTP submission port used when SMTP_PORT is unset.
+const DefaultSMTPPort = 587
+
+// SMTPConfig holds the outgoing mail server settings shared by alert and
+// digest emails.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// From is the sender address (default: Username)
+	From string
+}
+
+// SMTPConfigFromEnv loads SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD
+// and SMTP_FROM.
+func SMTPConfigFromEnv() SMTPConfig {
+	return SMTPConfig{
+		Host:     strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		Port:     ParseIntEnv("SMTP_PORT", DefaultSMTPPort),
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+// Enabled reports whether a mail server is configured.
+func (c SMTPConfig) Enabled() bool {
+	return c.Host != ""
+}
+
+// Sender returns the From address.
+func (c SMTPConfig) Sender() string {
+	if c.From != "" {
+		return c.From
+	}
+	return c.Username
+}
+
+// SendMail sends a plain text email to the recipients. PLAIN auth is used
+// when Username is set; net/smtp upgrades to TLS when the server offers
+// STARTTLS.
+func SendMail(ctx context.Context, cfg SMTPConfig, to []string, subject, body string) error {
+	if !cfg.Enabled() {
+		return fmt.Errorf("SMTP_HOST is not set")
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("no email recipients")
+	}
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+
+	from := cfg.Sender()
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	msg := BuildMailMessage(from, to, subject, body)
+
+	// smtp.SendMail has no context; run it so cancellation is not blocked
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, from, to, msg)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// BuildMailMessage formats a plain text email with CRLF line endings.
+func BuildMailMessage(from string, to []string, subject, body string) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
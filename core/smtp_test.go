@@ -0,0 +1,54 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSMTPConfigFromEnv(t *testing.T) {
+	t.Setenv("SMTP_HOST", " smtp.example.com ")
+	t.Setenv("SMTP_PORT", "")
+	t.Setenv("SMTP_USERNAME", "alerts@example.com")
+	t.Setenv("SMTP_PASSWORD", "secret")
+	t.Setenv("SMTP_FROM", "")
+
+	cfg := SMTPConfigFromEnv()
+	if !cfg.Enabled() || cfg.Host != "smtp.example.com" || cfg.Port != DefaultSMTPPort {
+		t.Errorf("SMTPConfigFromEnv() = %+v", cfg)
+	}
+	if cfg.Sender() != "alerts@example.com" {
+		t.Errorf("Sender() = %q, want username", cfg.Sender())
+	}
+
+	cfg.From = "llm@example.com"
+	if cfg.Sender() != "llm@example.com" {
+		t.Errorf("Sender() = %q, want From", cfg.Sender())
+	}
+}
+
+func TestBuildMailMessage(t *testing.T) {
+	msg := string(BuildMailMessage("llm@example.com", []string{"a@example.com", "b@example.com"},
+		"Daily digest", "line one\nline two\n"))
+
+	for _, want := range []string{
+		"From: llm@example.com\r\n",
+		"To: a@example.com, b@example.com\r\n",
+		"Subject: Daily digest\r\n",
+		"\r\n\r\nline one\r\nline two\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestSendMailRequiresConfig(t *testing.T) {
+	if err := SendMail(context.Background(), SMTPConfig{}, []string{"a@example.com"}, "s", "b"); err == nil {
+		t.Error("SendMail() without host should fail")
+	}
+	cfg := SMTPConfig{Host: "smtp.example.com", Port: 25}
+	if err := SendMail(context.Background(), cfg, nil, "s", "b"); err == nil {
+		t.Error("SendMail() without recipients should fail")
+	}
+}
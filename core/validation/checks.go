@@ -0,0 +1,91 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Check is a validation step contributed by a module outside this package.
+// Modules such as llamaruntime and sdruntime expose their checks so startup
+// validation can cover them without this package importing the modules.
+type Check struct {
+	// Name is shown in the progress output (e.g., "LLM Model File")
+	Name string
+	// Module identifies the contributing package (e.g., "llamaruntime")
+	Module string
+	// Run performs the check. The context carries the suite timeout.
+	Run func(ctx context.Context) CheckResult
+}
+
+// CheckResult is the outcome of a Check.
+type CheckResult struct {
+	Status  StepStatus
+	Message string
+	Error   error
+	// Hint is an actionable suggestion shown for failures and warnings
+	Hint string
+}
+
+// Passed returns a passing CheckResult.
+func Passed(message string) CheckResult {
+	return CheckResult{Status: StepPassed, Message: message}
+}
+
+// Skipped returns a CheckResult for a check that does not apply.
+func Skipped(message string) CheckResult {
+	return CheckResult{Status: StepSkipped, Message: message}
+}
+
+// Warning returns a non-fatal CheckResult with a hint.
+func Warning(message, hint string) CheckResult {
+	return CheckResult{Status: StepWarning, Message: message, Hint: hint}
+}
+
+// Failed returns a failing CheckResult with a hint.
+func Failed(message string, err error, hint string) CheckResult {
+	return CheckResult{Status: StepFailed, Message: message, Error: err, Hint: hint}
+}
+
+// Register adds module checks that Validate runs after the built-in steps,
+// in registration order.
+func (s *ValidationSuite) Register(checks ...Check) *ValidationSuite {
+	s.checks = append(s.checks, checks...)
+	return s
+}
+
+// runCheck executes a registered check with timing and progress output.
+// A check that returns no status, or panics, is reported as failed.
+func (s *ValidationSuite) runCheck(check Check) (step ValidationStep) {
+	step = ValidationStep{Name: check.Name, Status: StepRunning}
+
+	if s.showProgress {
+		s.printStepStart(check.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			step.Status = StepFailed
+			step.Message = "Check panicked"
+			step.Error = fmt.Errorf("%s check %q panicked: %v", check.Module, check.Name, r)
+		}
+		step.Latency = time.Since(startTime)
+		if s.showProgress {
+			s.printStep(step)
+		}
+	}()
+
+	result := check.Run(ctx)
+	step.Status = result.Status
+	step.Message = result.Message
+	step.Error = result.Error
+	step.Hint = result.Hint
+	if step.Status == StepPending || step.Status == StepRunning {
+		step.Status = StepFailed
+	}
+	return step
+}
@@ -0,0 +1,131 @@
+package validation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidationSuite_Register(t *testing.T) {
+	a := Check{Name: "A", Module: "test", Run: func(ctx context.Context) CheckResult { return Passed("ok") }}
+	b := Check{Name: "B", Module: "test", Run: func(ctx context.Context) CheckResult { return Skipped("n/a") }}
+
+	suite := NewValidationSuite().Register(a).Register(b)
+	if len(suite.checks) != 2 || suite.checks[0].Name != "A" || suite.checks[1].Name != "B" {
+		t.Errorf("Register() kept checks %+v, want A then B", suite.checks)
+	}
+}
+
+func TestValidationSuite_RunCheck(t *testing.T) {
+	suite := NewValidationSuite().WithShowProgress(false).WithTimeout(time.Second)
+
+	tests := []struct {
+		name       string
+		run        func(ctx context.Context) CheckResult
+		wantStatus StepStatus
+		wantHint   string
+	}{
+		{
+			name:       "passed",
+			run:        func(ctx context.Context) CheckResult { return Passed("fine") },
+			wantStatus: StepPassed,
+		},
+		{
+			name: "failed keeps hint",
+			run: func(ctx context.Context) CheckResult {
+				return Failed("broken", errors.New("boom"), "fix it")
+			},
+			wantStatus: StepFailed,
+			wantHint:   "fix it",
+		},
+		{
+			name:       "warning",
+			run:        func(ctx context.Context) CheckResult { return Warning("meh", "look") },
+			wantStatus: StepWarning,
+			wantHint:   "look",
+		},
+		{
+			name:       "missing status fails",
+			run:        func(ctx context.Context) CheckResult { return CheckResult{Message: "?"} },
+			wantStatus: StepFailed,
+		},
+		{
+			name:       "panic fails",
+			run:        func(ctx context.Context) CheckResult { panic("bad plugin") },
+			wantStatus: StepFailed,
+		},
+		{
+			name: "context has timeout",
+			run: func(ctx context.Context) CheckResult {
+				if _, ok := ctx.Deadline(); !ok {
+					return Failed("no deadline", nil, "")
+				}
+				return Passed("deadline set")
+			},
+			wantStatus: StepPassed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step := suite.runCheck(Check{Name: tt.name, Module: "test", Run: tt.run})
+			if step.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v", step.Status, tt.wantStatus)
+			}
+			if step.Hint != tt.wantHint {
+				t.Errorf("Hint = %q, want %q", step.Hint, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestValidationSuite_PrintsHints(t *testing.T) {
+	var buf bytes.Buffer
+	suite := NewValidationSuite().WithOutput(&buf)
+
+	suite.printStep(ValidationStep{Name: "Failing", Status: StepFailed, Error: errors.New("boom"), Hint: "restart it"})
+	suite.printStep(ValidationStep{Name: "Passing", Status: StepPassed, Hint: "never shown"})
+
+	output := buf.String()
+	if !strings.Contains(output, "restart it") {
+		t.Errorf("output missing hint for failed step: %q", output)
+	}
+	if strings.Contains(output, "never shown") {
+		t.Errorf("output shows hint for passed step: %q", output)
+	}
+}
+
+func TestValidationSuite_RegisteredChecksRunAfterBuiltins(t *testing.T) {
+	// Config errors skip the network steps, keeping the test offline
+	t.Setenv("CANVUS_SERVER", "")
+	t.Setenv("TEXT_LLM_URL", "")
+	t.Setenv("BASE_LLM_URL", closedPortURL(t))
+
+	var ran []string
+	record := func(name string, result CheckResult) Check {
+		return Check{Name: name, Module: "test", Run: func(ctx context.Context) CheckResult {
+			ran = append(ran, name)
+			return result
+		}}
+	}
+
+	result := NewValidationSuite().
+		WithShowProgress(false).
+		WithEnvPath("/nonexistent/.env").
+		Register(record("Module A", Passed("ok")), record("Module B", Warning("careful", "hint"))).
+		Validate()
+
+	if len(ran) != 2 || ran[0] != "Module A" || ran[1] != "Module B" {
+		t.Fatalf("registered checks ran as %v, want [Module A Module B]", ran)
+	}
+	last := result.Steps[len(result.Steps)-1]
+	if last.Name != "Module B" || last.Hint != "hint" {
+		t.Errorf("last step = %+v, want Module B with hint", last)
+	}
+	if result.Warnings < 1 {
+		t.Errorf("Warnings = %d, want at least 1", result.Warnings)
+	}
+}
@@ -2,6 +2,8 @@ package validation
 
 import (
 	"go_backend/core"
+	"go_backend/netdial"
+	"go_backend/netproxy"
 	"context"
 	"crypto/tls"
 	"fmt"
@@ -16,6 +18,8 @@ type ConnectivityResult struct {
 	Message    string
 	Latency    time.Duration
 	Error      error
+	// Hint is an actionable suggestion when the server is unreachable
+	Hint string
 }
 
 // ConnectivityChecker provides methods to verify network connectivity.
@@ -92,6 +96,7 @@ func (c *ConnectivityChecker) CheckServerConnectivity(serverURL string) Connecti
 				Message:   "Connection timed out",
 				Latency:   latency,
 				Error:     core.ErrServerUnreachable(serverURL, fmt.Sprintf("connection timed out after %v", c.timeout)),
+				Hint:      DiagnoseConnectionError(ctx.Err(), serverURL),
 			}
 		}
 		return ConnectivityResult{
@@ -99,6 +104,7 @@ func (c *ConnectivityChecker) CheckServerConnectivity(serverURL string) Connecti
 			Message:   "Connection failed",
 			Latency:   latency,
 			Error:     core.ErrServerUnreachable(serverURL, err.Error()),
+			Hint:      DiagnoseConnectionError(err, serverURL),
 		}
 	}
 	defer resp.Body.Close()
@@ -158,6 +164,7 @@ func (c *ConnectivityChecker) CheckServerConnectivityWithContext(ctx context.Con
 			Message:   "Connection failed",
 			Latency:   latency,
 			Error:     core.ErrServerUnreachable(serverURL, err.Error()),
+			Hint:      DiagnoseConnectionError(err, serverURL),
 		}
 	}
 	defer resp.Body.Close()
@@ -176,11 +183,13 @@ func (c *ConnectivityChecker) createHTTPClient() *http.Client {
 		Timeout: c.timeout,
 	}
 
+	// Probe through the same proxy and dialer the application will use
+	transport := netproxy.NewTransport()
+	transport.DialContext = netdial.FromEnvironment().DialContext
 	if c.allowSelfSignedCerts {
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
 	}
+	client.Transport = transport
 
 	return client
 }
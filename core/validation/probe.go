@@ -0,0 +1,224 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"go_backend/core"
+	"go_backend/netdial"
+)
+
+// DefaultLLMURL is the LLM endpoint used when none is configured.
+const DefaultLLMURL = "http://127.0.0.1:1234/v1"
+
+// DiagnoseConnectionError returns an actionable hint for a failed request
+// to target, or "" if the failure is not recognised. It covers the common
+// network failures: refused connections ("No connection could be made
+// because the target machine actively refused it" on Windows), DNS errors,
+// timeouts, certificate problems and TLS/plain HTTP mismatches. When the
+// host had several addresses, the hint lists the addresses tried.
+func DiagnoseConnectionError(err error, target string) string {
+	hint := diagnoseConnectionKind(err, target)
+	if hint == "" {
+		return ""
+	}
+	return hint + addressHint(err)
+}
+
+// diagnoseConnectionKind returns the hint for the kind of failure of err.
+func diagnoseConnectionKind(err error, target string) string {
+	host, port := splitTarget(target)
+
+	switch core.ClassifyConnectionError(err) {
+	case core.ConnErrorRefused:
+		if isLoopbackHost(host) {
+			return fmt.Sprintf("Nothing is listening on %s:%s. Start the server or correct the port. "+
+				"When running in Docker or WSL, localhost is the container itself: use the host's "+
+				"address (e.g., host.docker.internal) instead", host, port)
+		}
+		return fmt.Sprintf("%s is reachable but refused the connection on port %s. Check that the "+
+			"server is running, listens on 0.0.0.0 rather than 127.0.0.1, and that a firewall "+
+			"allows the port", host, port)
+
+	case core.ConnErrorDNS:
+		return fmt.Sprintf("Host %q could not be resolved. Check the hostname for typos and that "+
+			"DNS or /etc/hosts can resolve it", host)
+
+	case core.ConnErrorTimeout:
+		return fmt.Sprintf("No response from %s:%s. Check that the address is correct, the server "+
+			"is not overloaded, and no firewall or VPN is silently dropping traffic", host, port)
+
+	case core.ConnErrorTLS:
+		return "The server certificate is not trusted. Install a valid certificate, or set " +
+			"ALLOW_SELF_SIGNED_CERTS=true for self-signed certificates on trusted networks"
+
+	case core.ConnErrorProtocol:
+		if strings.Contains(strings.ToLower(err.Error()), "http response to https client") {
+			return fmt.Sprintf("%s:%s serves plain HTTP. Change the URL scheme from https:// to http://", host, port)
+		}
+		return fmt.Sprintf("%s:%s did not answer with TLS. Check the port, or change the URL "+
+			"scheme to http://", host, port)
+
+	case core.ConnErrorReset:
+		return "The connection was reset by the server or a proxy. Check proxy settings " +
+			"(HTTP_PROXY, HTTPS_PROXY, NO_PROXY) and the server logs"
+	}
+	return ""
+}
+
+// ProbeEndpoint sends a GET request to endpoint and reports whether it
+// responded. Unlike CheckServerConnectivity it accepts extra headers (e.g.,
+// an API key) and fills in Hint when the request fails.
+func (c *ConnectivityChecker) ProbeEndpoint(ctx context.Context, endpoint string, header http.Header) ConnectivityResult {
+	if err := core.ValidateServerURL(endpoint); err != nil {
+		return ConnectivityResult{
+			Message: "Invalid URL format",
+			Error:   core.ErrInvalidServerURL(endpoint, err.Error()),
+			Hint:    "Use a full URL including the scheme, e.g. http://127.0.0.1:1234/v1",
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return ConnectivityResult{
+			Message: "Failed to create request",
+			Error:   core.ErrServerUnreachable(endpoint, err.Error()),
+		}
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	startTime := time.Now()
+	resp, err := c.createHTTPClient().Do(req)
+	latency := time.Since(startTime)
+	if err != nil {
+		return ConnectivityResult{
+			Message: "Connection failed",
+			Latency: latency,
+			Error:   core.ErrServerUnreachable(endpoint, err.Error()),
+			Hint:    DiagnoseConnectionError(err, endpoint),
+		}
+	}
+	defer resp.Body.Close()
+
+	return ConnectivityResult{
+		Reachable:  true,
+		StatusCode: resp.StatusCode,
+		Message:    fmt.Sprintf("Server reachable (status: %d)", resp.StatusCode),
+		Latency:    latency,
+	}
+}
+
+// LLMEndpointURL returns the OpenAI-compatible endpoint used for text
+// requests: TEXT_LLM_URL, then BASE_LLM_URL, then OPENAI_API_BASE, then
+// DefaultLLMURL.
+func LLMEndpointURL() string {
+	for _, key := range []string{"TEXT_LLM_URL", "BASE_LLM_URL", "OPENAI_API_BASE"} {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			return v
+		}
+	}
+	return DefaultLLMURL
+}
+
+// checkLLMEndpoint probes the models list of the configured LLM endpoint.
+// An unreachable endpoint is a warning, since local models may run in
+// process; rejected credentials are a failure.
+func (s *ValidationSuite) checkLLMEndpoint(ctx context.Context) CheckResult {
+	base := LLMEndpointURL()
+	endpoint := strings.TrimSuffix(base, "/") + "/models"
+
+	header := http.Header{}
+	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
+		header.Set("Authorization", "Bearer "+key)
+	}
+
+	result := s.connectivityChecker.ProbeEndpoint(ctx, endpoint, header)
+	if !result.Reachable {
+		hint := result.Hint
+		if hint == "" && result.Error != nil {
+			hint = "Check BASE_LLM_URL (or TEXT_LLM_URL) and that the LLM server is running"
+		}
+		return CheckResult{
+			Status:  StepWarning,
+			Message: fmt.Sprintf("%s not reachable", base),
+			Error:   result.Error,
+			Hint:    hint,
+		}
+	}
+
+	latency := result.Latency.Round(time.Millisecond)
+	switch {
+	case result.StatusCode == http.StatusUnauthorized || result.StatusCode == http.StatusForbidden:
+		return Failed(fmt.Sprintf("%s rejected the request (status: %d)", base, result.StatusCode),
+			core.ErrAuthFailed("LLM endpoint", http.StatusText(result.StatusCode)),
+			"Set OPENAI_API_KEY to a key accepted by this endpoint")
+	case result.StatusCode == http.StatusNotFound:
+		return Warning(fmt.Sprintf("%s has no /models route (status: 404)", base),
+			"OpenAI-compatible servers expect a base URL ending in /v1, e.g. http://127.0.0.1:1234/v1")
+	case result.StatusCode >= 500:
+		return Warning(fmt.Sprintf("%s returned status %d", base, result.StatusCode),
+			"The LLM server is up but failing; check its logs and that a model is loaded")
+	}
+	return Passed(fmt.Sprintf("%s (latency: %v)", base, latency))
+}
+
+// splitTarget returns the host and port of target, defaulting the port from
+// the scheme.
+func splitTarget(target string) (string, string) {
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return target, "?"
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return u.Hostname(), port
+}
+
+// addressHint lists the addresses tried when err is a netdial.Error and,
+// when both address families were tried, suggests pinning one.
+func addressHint(err error) string {
+	var dialErr *netdial.Error
+	if !errors.As(err, &dialErr) {
+		return ""
+	}
+	hint := ". Addresses tried: " + strings.Join(dialErr.Addresses(), ", ")
+
+	var v4, v6 bool
+	for _, addr := range dialErr.Addresses() {
+		host, _, _ := net.SplitHostPort(addr)
+		if ip := net.ParseIP(host); ip != nil && ip.To4() != nil {
+			v4 = true
+		} else {
+			v6 = true
+		}
+	}
+	if v4 && v6 {
+		hint += ". If the server listens on one address family only, set CANVUS_IP_FAMILY=ipv4 (or ipv6)"
+	} else if v6 && dialErr.Family == netdial.FamilyAuto {
+		hint += ". Only IPv6 addresses were tried; if the server listens on IPv4, check DNS or use its IPv4 address"
+	}
+	return hint
+}
+
+// isLoopbackHost reports whether host refers to the local machine.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.IsUnspecified())
+}
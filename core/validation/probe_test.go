@@ -0,0 +1,197 @@
+package validation
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go_backend/netdial"
+)
+
+// closedPortURL returns a URL on a loopback port with no listener.
+func closedPortURL(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return "http://" + addr
+}
+
+func TestDiagnoseConnectionError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		target   string
+		contains string
+	}{
+		{"nil error", nil, "http://localhost:1234", ""},
+		{"refused loopback", syscall.ECONNREFUSED, "http://127.0.0.1:1234/v1", "Nothing is listening on 127.0.0.1:1234"},
+		{"refused localhost suggests docker host", syscall.ECONNREFUSED, "http://localhost:1234", "host.docker.internal"},
+		{"refused remote", syscall.ECONNREFUSED, "https://canvus.example.com", "listens on 0.0.0.0"},
+		{"refused remote default port", syscall.ECONNREFUSED, "https://canvus.example.com", "port 443"},
+		{"windows actively refused", errors.New("dial tcp 10.0.0.5:1234: connectex: No connection could be made because the target machine actively refused it."), "http://10.0.0.5:1234", "refused the connection on port 1234"},
+		{"dns", &net.DNSError{Err: "no such host", Name: "canvus.invalid"}, "https://canvus.invalid", "could not be resolved"},
+		{"timeout", context.DeadlineExceeded, "http://10.0.0.5:1234", "No response from 10.0.0.5:1234"},
+		{"self-signed", x509.UnknownAuthorityError{}, "https://canvus.local", "ALLOW_SELF_SIGNED_CERTS=true"},
+		{"https to http server", errors.New("http: server gave HTTP response to HTTPS client"), "https://localhost:1234", "https:// to http://"},
+		{"reset", syscall.ECONNRESET, "http://localhost:1234", "HTTP_PROXY"},
+		{"unknown", errors.New("something else"), "http://localhost:1234", ""},
+		{"dual-stack lists addresses", &netdial.Error{Address: "canvus.local:80", Attempts: []netdial.Attempt{
+			{Addr: "[fe80::1]:80", Err: syscall.ECONNREFUSED},
+			{Addr: "10.0.0.5:80", Err: syscall.ECONNREFUSED},
+		}}, "http://canvus.local", "Addresses tried: [fe80::1]:80, 10.0.0.5:80. If the server listens on one address family only, set CANVUS_IP_FAMILY=ipv4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.err
+			if err != nil {
+				err = fmt.Errorf("Get %q: %w", tt.target, err)
+			}
+			hint := DiagnoseConnectionError(err, tt.target)
+			if tt.contains == "" {
+				if hint != "" {
+					t.Errorf("DiagnoseConnectionError() = %q, want empty", hint)
+				}
+				return
+			}
+			if !strings.Contains(hint, tt.contains) {
+				t.Errorf("DiagnoseConnectionError() = %q, want it to contain %q", hint, tt.contains)
+			}
+		})
+	}
+}
+
+func TestConnectivityChecker_HintOnRefusedConnection(t *testing.T) {
+	target := closedPortURL(t)
+
+	result := NewConnectivityChecker().WithTimeout(2 * time.Second).CheckServerConnectivity(target)
+	if result.Reachable {
+		t.Fatal("expected unreachable server")
+	}
+	if !strings.Contains(result.Hint, "Nothing is listening") {
+		t.Errorf("Hint = %q, want refused-connection hint", result.Hint)
+	}
+}
+
+func TestConnectivityChecker_ProbeEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("Expected GET request, got %s", r.Method)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer secret")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer secret")
+	result := NewConnectivityChecker().ProbeEndpoint(context.Background(), server.URL, header)
+	if !result.Reachable || result.StatusCode != http.StatusOK {
+		t.Errorf("ProbeEndpoint() = %+v, want reachable with 200", result)
+	}
+
+	result = NewConnectivityChecker().ProbeEndpoint(context.Background(), "not-a-url", nil)
+	if result.Reachable || result.Hint == "" {
+		t.Errorf("ProbeEndpoint(invalid) = %+v, want unreachable with hint", result)
+	}
+}
+
+func TestLLMEndpointURL(t *testing.T) {
+	for _, key := range []string{"TEXT_LLM_URL", "BASE_LLM_URL", "OPENAI_API_BASE"} {
+		t.Setenv(key, "")
+	}
+	if got := LLMEndpointURL(); got != DefaultLLMURL {
+		t.Errorf("LLMEndpointURL() = %q, want default %q", got, DefaultLLMURL)
+	}
+
+	t.Setenv("OPENAI_API_BASE", "http://openai-base/v1")
+	if got := LLMEndpointURL(); got != "http://openai-base/v1" {
+		t.Errorf("LLMEndpointURL() = %q, want OPENAI_API_BASE", got)
+	}
+
+	t.Setenv("BASE_LLM_URL", "http://base/v1")
+	if got := LLMEndpointURL(); got != "http://base/v1" {
+		t.Errorf("LLMEndpointURL() = %q, want BASE_LLM_URL to take precedence", got)
+	}
+
+	t.Setenv("TEXT_LLM_URL", "http://text/v1")
+	if got := LLMEndpointURL(); got != "http://text/v1" {
+		t.Errorf("LLMEndpointURL() = %q, want TEXT_LLM_URL to take precedence", got)
+	}
+}
+
+func TestValidationSuite_CheckLLMEndpoint(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	t.Setenv("TEXT_LLM_URL", "")
+	t.Setenv("OPENAI_API_KEY", "")
+	suite := NewValidationSuite().WithShowProgress(false)
+
+	tests := []struct {
+		name       string
+		baseURL    string
+		status     int
+		wantStatus StepStatus
+		wantHint   string
+	}{
+		{"reachable", server.URL + "/v1", http.StatusOK, StepPassed, ""},
+		{"unauthorized", server.URL + "/v1", http.StatusUnauthorized, StepFailed, "OPENAI_API_KEY"},
+		{"missing v1", server.URL, http.StatusOK, StepWarning, "/v1"},
+		{"server error", server.URL + "/v1", http.StatusInternalServerError, StepWarning, "logs"},
+		{"refused", closedPortURL(t) + "/v1", http.StatusOK, StepWarning, "Nothing is listening"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status = tt.status
+			t.Setenv("BASE_LLM_URL", tt.baseURL)
+
+			result := suite.checkLLMEndpoint(context.Background())
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v (%s)", result.Status, tt.wantStatus, result.Message)
+			}
+			if !strings.Contains(result.Hint, tt.wantHint) {
+				t.Errorf("Hint = %q, want it to contain %q", result.Hint, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestSplitTarget(t *testing.T) {
+	tests := []struct {
+		target, host, port string
+	}{
+		{"http://localhost:1234/v1", "localhost", "1234"},
+		{"https://canvus.example.com", "canvus.example.com", "443"},
+		{"http://[::1]:8080", "::1", "8080"},
+		{"http://example.com", "example.com", "80"},
+	}
+	for _, tt := range tests {
+		host, port := splitTarget(tt.target)
+		if host != tt.host || port != tt.port {
+			t.Errorf("splitTarget(%q) = (%q, %q), want (%q, %q)", tt.target, host, port, tt.host, tt.port)
+		}
+	}
+}
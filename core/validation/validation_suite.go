@@ -1,6 +1,7 @@
 package validation
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -17,6 +18,8 @@ type ValidationStep struct {
 	Message string
 	Error   error
 	Latency time.Duration
+	// Hint is an actionable suggestion shown for failures and warnings
+	Hint string
 }
 
 // StepStatus represents the status of a validation step.
@@ -75,6 +78,7 @@ type ValidationSuite struct {
 	timeout              time.Duration
 	showProgress         bool
 	failFast             bool
+	checks               []Check
 }
 
 // NewValidationSuite creates a new ValidationSuite with default settings.
@@ -138,7 +142,7 @@ func (s *ValidationSuite) WithEnvPath(path string) *ValidationSuite {
 // Returns a SuiteResult with complete validation results.
 func (s *ValidationSuite) Validate() SuiteResult {
 	startTime := time.Now()
-	steps := make([]ValidationStep, 0, 6)
+	steps := make([]ValidationStep, 0, 7+len(s.checks))
 
 	// Header
 	if s.showProgress {
@@ -187,14 +191,17 @@ func (s *ValidationSuite) Validate() SuiteResult {
 
 	// Step 5: Check server connectivity (only if config is valid)
 	if s.hasAllPassed(steps) {
-		step = s.runStep("Server Connectivity", func() (bool, string, error) {
+		step = s.runCheck(Check{Name: "Server Connectivity", Run: func(ctx context.Context) CheckResult {
 			result := s.connectivityChecker.CheckCanvusServerConnectivity()
 			msg := result.Message
 			if result.Latency > 0 {
 				msg = fmt.Sprintf("%s (latency: %v)", msg, result.Latency.Round(time.Millisecond))
 			}
-			return result.Reachable, msg, result.Error
-		})
+			if !result.Reachable {
+				return Failed(msg, result.Error, result.Hint)
+			}
+			return Passed(msg)
+		}})
 	} else {
 		step = ValidationStep{
 			Name:    "Server Connectivity",
@@ -227,6 +234,21 @@ func (s *ValidationSuite) Validate() SuiteResult {
 		}
 	}
 	steps = append(steps, step)
+	if s.failFast && step.Status == StepFailed {
+		return s.buildResult(steps, startTime)
+	}
+
+	// Step 7: Probe the LLM endpoint (unreachable only warns; local models may run in process)
+	steps = append(steps, s.runCheck(Check{Name: "LLM Endpoint", Run: s.checkLLMEndpoint}))
+
+	// Module checks registered via Register
+	for _, check := range s.checks {
+		step = s.runCheck(check)
+		steps = append(steps, step)
+		if s.failFast && step.Status == StepFailed {
+			break
+		}
+	}
 
 	result := s.buildResult(steps, startTime)
 
@@ -393,6 +415,12 @@ func (s *ValidationSuite) printStep(step ValidationStep) {
 		errColor := color.New(color.FgRed)
 		errColor.Fprintf(s.output, "    └─ %s\n", step.Error.Error())
 	}
+
+	// Print hints for failed and warning steps
+	if step.Hint != "" && (step.Status == StepFailed || step.Status == StepWarning) {
+		hintColor := color.New(color.FgYellow)
+		hintColor.Fprintf(s.output, "    → %s\n", step.Hint)
+	}
 }
 
 // printSummary prints the validation summary.
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go_backend/audit"
+)
+
+// auditLogSchema creates the append-only audit trail. It is kept out of
+// the retention cleanup, and triggers reject any UPDATE or DELETE so rows
+// can only be removed by editing the database file directly, which the
+// hash chain then exposes.
+const auditLogSchema = `
+CREATE TABLE IF NOT EXISTS audit_log (
+    seq INTEGER PRIMARY KEY,
+    recorded_at TEXT NOT NULL,
+    canvas_id TEXT NOT NULL DEFAULT '',
+    correlation_id TEXT NOT NULL DEFAULT '',
+    action TEXT NOT NULL,
+    widget_type TEXT NOT NULL DEFAULT '',
+    widget_id TEXT NOT NULL DEFAULT '',
+    trigger_widget_id TEXT NOT NULL DEFAULT '',
+    trigger_type TEXT NOT NULL DEFAULT '',
+    operation TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    content_sha256 TEXT NOT NULL DEFAULT '',
+    prev_hash TEXT NOT NULL,
+    hash TEXT NOT NULL
+);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update
+BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete
+BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+`
+
+const auditLogColumns = `seq, recorded_at, canvas_id, correlation_id, action, widget_type, widget_id,
+	trigger_widget_id, trigger_type, operation, model, content_sha256, prev_hash, hash`
+
+// ensureAuditSchema creates the audit_log table and its triggers if needed.
+func (r *Repository) ensureAuditSchema() error {
+	if _, err := r.db.Exec(auditLogSchema); err != nil {
+		return fmt.Errorf("failed to create audit log table: %w", err)
+	}
+	return nil
+}
+
+// InsertAuditEntry appends an entry to the audit log.
+// Implements audit.Storage.
+func (r *Repository) InsertAuditEntry(ctx context.Context, e audit.Entry) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureAuditSchema(); err != nil {
+		return err
+	}
+
+	_, err := r.db.DB().ExecContext(ctx, `INSERT INTO audit_log (`+auditLogColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.Seq, e.Time.UTC().Format(time.RFC3339Nano), e.CanvasID, e.CorrelationID,
+		string(e.Action), e.WidgetType, e.WidgetID, e.TriggerWidgetID, e.TriggerType,
+		e.Operation, e.Model, e.ContentSHA256, e.PrevHash, e.Hash)
+	if err != nil {
+		return fmt.Errorf("failed to insert audit entry: %w", err)
+	}
+	return nil
+}
+
+// LastAuditEntry returns the newest audit entry, or nil if the log is
+// empty. Implements audit.Storage.
+func (r *Repository) LastAuditEntry(ctx context.Context) (*audit.Entry, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureAuditSchema(); err != nil {
+		return nil, err
+	}
+
+	row := r.db.DB().QueryRowContext(ctx,
+		`SELECT `+auditLogColumns+` FROM audit_log ORDER BY seq DESC LIMIT 1`)
+	e, err := scanAuditEntry(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read last audit entry: %w", err)
+	}
+	return &e, nil
+}
+
+// ListAuditEntries returns up to limit audit entries with seq > afterSeq,
+// oldest first. Implements audit.Storage.
+func (r *Repository) ListAuditEntries(ctx context.Context, afterSeq int64, limit int) ([]audit.Entry, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureAuditSchema(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx,
+		`SELECT `+auditLogColumns+` FROM audit_log WHERE seq > ? ORDER BY seq LIMIT ?`,
+		afterSeq, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []audit.Entry
+	for rows.Next() {
+		e, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// scanAuditEntry reads one audit_log row selected with auditLogColumns.
+func scanAuditEntry(row interface{ Scan(...interface{}) error }) (audit.Entry, error) {
+	var e audit.Entry
+	var recordedAt, action string
+	err := row.Scan(&e.Seq, &recordedAt, &e.CanvasID, &e.CorrelationID, &action,
+		&e.WidgetType, &e.WidgetID, &e.TriggerWidgetID, &e.TriggerType,
+		&e.Operation, &e.Model, &e.ContentSHA256, &e.PrevHash, &e.Hash)
+	if err != nil {
+		return e, err
+	}
+	e.Action = audit.Action(action)
+	e.Time = parseSnapshotTime(recordedAt)
+	return e, nil
+}
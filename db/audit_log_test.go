@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"go_backend/audit"
+)
+
+// TestAuditLog tests appending, listing and verifying audit entries.
+func TestAuditLog(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if last, err := repo.LastAuditEntry(ctx); last != nil || err != nil {
+		t.Fatalf("LastAuditEntry() on empty db = %v, %v; want nil", last, err)
+	}
+
+	log := audit.NewLog(repo)
+	for _, widgetID := range []string{"w1", "w2", "w3"} {
+		if _, err := log.Record(ctx, audit.Entry{
+			CanvasID:   "canvas-1",
+			Action:     audit.ActionCreate,
+			WidgetType: "Note",
+			WidgetID:   widgetID,
+			Model:      "gpt-4o",
+		}); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	entries, err := repo.ListAuditEntries(ctx, 1, 10)
+	if err != nil {
+		t.Fatalf("ListAuditEntries() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].WidgetID != "w2" || entries[1].Seq != 3 {
+		t.Errorf("ListAuditEntries(after 1) = %+v", entries)
+	}
+
+	// Stored times must round-trip exactly for the hashes to verify
+	result, err := audit.NewLog(repo).Verify(ctx)
+	if err != nil || !result.Valid || result.Entries != 3 {
+		t.Errorf("Verify() = %+v, %v", result, err)
+	}
+}
+
+// TestAuditLogAppendOnly tests that stored entries cannot be changed.
+func TestAuditLogAppendOnly(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, err := audit.NewLog(repo).Record(ctx, audit.Entry{Action: audit.ActionUpdate}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	if _, err := repo.db.Exec(`UPDATE audit_log SET model = 'forged'`); err == nil {
+		t.Error("UPDATE on audit_log should be rejected")
+	}
+	if _, err := repo.db.Exec(`DELETE FROM audit_log`); err == nil {
+		t.Error("DELETE on audit_log should be rejected")
+	}
+}
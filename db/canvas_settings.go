@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go_backend/canvassettings"
+)
+
+// canvasSettingsSchema creates the per-canvas overrides table. Settings
+// are stored as JSON so new overrides need no migration.
+const canvasSettingsSchema = `
+CREATE TABLE IF NOT EXISTS canvas_settings (
+    canvas_id TEXT PRIMARY KEY,
+    settings TEXT NOT NULL,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// ensureCanvasSettingsSchema creates the canvas_settings table if needed.
+func (r *Repository) ensureCanvasSettingsSchema() error {
+	if _, err := r.db.Exec(canvasSettingsSchema); err != nil {
+		return fmt.Errorf("failed to create canvas settings table: %w", err)
+	}
+	return nil
+}
+
+// ListCanvasSettings returns the overrides of every canvas.
+// Implements canvassettings.Storage.
+func (r *Repository) ListCanvasSettings(ctx context.Context) ([]canvassettings.Settings, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureCanvasSettingsSchema(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, `SELECT canvas_id, settings FROM canvas_settings ORDER BY canvas_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query canvas settings: %w", err)
+	}
+	defer rows.Close()
+
+	var list []canvassettings.Settings
+	for rows.Next() {
+		var canvasID, data string
+		if err := rows.Scan(&canvasID, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan canvas settings: %w", err)
+		}
+		var s canvassettings.Settings
+		if err := json.Unmarshal([]byte(data), &s); err != nil {
+			return nil, fmt.Errorf("invalid settings for canvas %s: %w", canvasID, err)
+		}
+		s.CanvasID = canvasID
+		list = append(list, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating canvas settings: %w", err)
+	}
+	return list, nil
+}
+
+// SaveCanvasSettings inserts or replaces the overrides of a canvas.
+// Implements canvassettings.Storage.
+func (r *Repository) SaveCanvasSettings(ctx context.Context, s canvassettings.Settings) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureCanvasSettingsSchema(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode canvas settings: %w", err)
+	}
+	if _, err := r.db.DB().ExecContext(ctx, `
+		INSERT OR REPLACE INTO canvas_settings (canvas_id, settings, updated_at)
+		VALUES (?, ?, CURRENT_TIMESTAMP)`,
+		s.CanvasID, string(data)); err != nil {
+		return fmt.Errorf("failed to save canvas settings: %w", err)
+	}
+	return nil
+}
+
+// DeleteCanvasSettings removes the overrides of a canvas. Deleting a
+// canvas without overrides is not an error.
+// Implements canvassettings.Storage.
+func (r *Repository) DeleteCanvasSettings(ctx context.Context, canvasID string) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureCanvasSettingsSchema(); err != nil {
+		return err
+	}
+
+	if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM canvas_settings WHERE canvas_id = ?`, canvasID); err != nil {
+		return fmt.Errorf("failed to delete canvas settings: %w", err)
+	}
+	return nil
+}
+
+// CountCanvasTasksSince returns the number of AI tasks recorded in
+// processing history for a canvas since the given time. Records sharing a
+// correlation ID (e.g. a redaction and its task) count once.
+// Implements canvassettings.TaskCounter.
+func (r *Repository) CountCanvasTasksSince(ctx context.Context, canvasID string, since time.Time) (int, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database connection is nil")
+	}
+
+	var count int
+	err := r.db.DB().QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT correlation_id) FROM processing_history
+		WHERE canvas_id = ? AND created_at >= ?`,
+		canvasID, sqliteTime(since)).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count canvas tasks: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/canvassettings"
+)
+
+// TestCanvasSettingsRoundTrip tests saving, listing and deleting canvas settings.
+func TestCanvasSettingsRoundTrip(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if list, err := repo.ListCanvasSettings(ctx); len(list) != 0 || err != nil {
+		t.Fatalf("ListCanvasSettings() on empty db = %v, %v", list, err)
+	}
+
+	demo := canvassettings.Settings{
+		CanvasID:         "demo",
+		DisabledFeatures: []string{canvassettings.FeaturePDFPrecis},
+		TriggerOpen:      "[[",
+		TriggerClose:     "]]",
+		DailyTaskLimit:   50,
+	}
+	if err := repo.SaveCanvasSettings(ctx, demo); err != nil {
+		t.Fatalf("SaveCanvasSettings() error = %v", err)
+	}
+	demo.NoteColor = "#FFEE00"
+	if err := repo.SaveCanvasSettings(ctx, demo); err != nil {
+		t.Fatalf("SaveCanvasSettings() replace error = %v", err)
+	}
+	if err := repo.SaveCanvasSettings(ctx, canvassettings.Settings{CanvasID: "prod"}); err != nil {
+		t.Fatalf("SaveCanvasSettings() error = %v", err)
+	}
+
+	list, err := repo.ListCanvasSettings(ctx)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("ListCanvasSettings() = %v, %v", list, err)
+	}
+	if got := list[0]; got.CanvasID != "demo" || got.NoteColor != "#FFEE00" || got.TriggerOpen != "[[" ||
+		got.DailyTaskLimit != 50 || len(got.DisabledFeatures) != 1 {
+		t.Errorf("demo settings = %+v", got)
+	}
+
+	if err := repo.DeleteCanvasSettings(ctx, "demo"); err != nil {
+		t.Fatalf("DeleteCanvasSettings() error = %v", err)
+	}
+	if list, _ := repo.ListCanvasSettings(ctx); len(list) != 1 || list[0].CanvasID != "prod" {
+		t.Errorf("after delete = %+v", list)
+	}
+}
+
+// TestCountCanvasTasksSince tests counting a canvas's tasks by correlation ID.
+func TestCountCanvasTasksSince(t *testing.T) {
+	repo, database, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	for _, rec := range []ProcessingRecord{
+		{CorrelationID: "a", CanvasID: "demo", OperationType: "pii_redaction", Status: "success"},
+		{CorrelationID: "a", CanvasID: "demo", OperationType: "text_generation", Status: "success"},
+		{CorrelationID: "b", CanvasID: "demo", OperationType: "pdf_analysis", Status: "error"},
+		{CorrelationID: "c", CanvasID: "prod", OperationType: "text_generation", Status: "success"},
+	} {
+		rec.WidgetID = "w"
+		if _, err := repo.InsertProcessingHistory(ctx, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id, err := repo.InsertProcessingHistory(ctx, ProcessingRecord{
+		CorrelationID: "old", CanvasID: "demo", WidgetID: "w", OperationType: "text_generation", Status: "success",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec("UPDATE processing_history SET created_at = ? WHERE id = ?",
+		sqliteTime(time.Now().Add(-48*time.Hour)), id); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := repo.CountCanvasTasksSince(ctx, "demo", time.Now().Add(-time.Hour))
+	if err != nil || count != 2 {
+		t.Errorf("CountCanvasTasksSince() = %d, %v; want 2", count, err)
+	}
+}
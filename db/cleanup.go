@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"go_backend/core/clock"
 )
 
 // CleanupResult contains statistics about a cleanup operation.
@@ -178,6 +180,8 @@ type CleanupSchedulerConfig struct {
 	// OnCleanup is called after each cleanup run (optional)
 	// Useful for logging or metrics
 	OnCleanup func(result CleanupResult, err error)
+	// Clock schedules the runs (optional, default: the system clock)
+	Clock clock.Clock
 }
 
 // DefaultCleanupSchedulerConfig returns sensible defaults for the cleanup scheduler.
@@ -242,14 +246,14 @@ func (d *Database) StartCleanupSchedulerWithConfig(ctx context.Context, config C
 		}
 
 		// Set up ticker for periodic cleanup
-		ticker := time.NewTicker(config.Interval)
+		ticker := clock.OrReal(config.Clock).NewTicker(config.Interval)
 		defer ticker.Stop()
 
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case <-ticker.C:
+			case <-ticker.C():
 				result, err := d.CleanupWithContext(ctx, config.RetentionDays)
 				if config.OnCleanup != nil {
 					config.OnCleanup(result, err)
@@ -4,9 +4,10 @@ import (
 	"context"
 	"os"
 	"path/filepath"
-	"sync"
 	"testing"
 	"time"
+
+	"go_backend/core/clock"
 )
 
 // setupCleanupTestMigrations creates temporary migrations directory with tables needed for cleanup tests.
@@ -369,25 +370,56 @@ func TestCleanupVacuum(t *testing.T) {
 
 // TestCleanupScheduler tests the background cleanup scheduler.
 func TestCleanupScheduler(t *testing.T) {
+	epoch := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// startScheduler starts the scheduler on a fake clock and returns the
+	// channel its cleanup results arrive on.
+	startScheduler := func(ctx context.Context, db *Database, fake *clock.Fake, interval time.Duration) <-chan CleanupResult {
+		results := make(chan CleanupResult, 10)
+		db.StartCleanupSchedulerWithConfig(ctx, CleanupSchedulerConfig{
+			RetentionDays: 30,
+			Interval:      interval,
+			OnCleanup: func(result CleanupResult, err error) {
+				if err != nil {
+					t.Errorf("cleanup error = %v", err)
+				}
+				results <- result
+			},
+			Clock: fake,
+		})
+		return results
+	}
+
+	waitResult := func(t *testing.T, results <-chan CleanupResult) CleanupResult {
+		t.Helper()
+		select {
+		case result := <-results:
+			return result
+		case <-time.After(5 * time.Second):
+			t.Fatal("cleanup did not run")
+			return CleanupResult{}
+		}
+	}
+
 	t.Run("scheduler starts and stops cleanly", func(t *testing.T) {
 		db := setupTestDatabaseWithData(t)
 		defer db.Close()
 
+		fake := clock.NewFake(epoch)
 		ctx, cancel := context.WithCancel(context.Background())
+		results := startScheduler(ctx, db, fake, time.Hour)
+		waitResult(t, results)
+		fake.BlockUntil(1)
 
-		// Start scheduler with short interval for testing
-		db.StartCleanupScheduler(ctx, 30, 100*time.Millisecond)
-
-		// Let it run for a bit
-		time.Sleep(50 * time.Millisecond)
-
-		// Cancel and verify it stops
+		// Cancelling stops the ticker
 		cancel()
-
-		// Give it time to stop
-		time.Sleep(50 * time.Millisecond)
-
-		// No assertion needed - if we get here without deadlock/panic, it works
+		deadline := time.Now().Add(5 * time.Second)
+		for fake.Waiters() > 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("scheduler did not stop")
+			}
+			time.Sleep(time.Millisecond)
+		}
 	})
 
 	t.Run("scheduler runs cleanup on start", func(t *testing.T) {
@@ -406,11 +438,8 @@ func TestCleanupScheduler(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		// Start scheduler with long interval (we only care about initial run)
-		db.StartCleanupScheduler(ctx, 30, 1*time.Hour)
-
-		// Give the initial cleanup time to run
-		time.Sleep(100 * time.Millisecond)
+		// The clock never advances, so only the initial run happens
+		waitResult(t, startScheduler(ctx, db, clock.NewFake(epoch), time.Hour))
 
 		// Verify records were deleted
 		finalCount := countTableRecords(t, db, "processing_history")
@@ -425,37 +454,12 @@ func TestCleanupScheduler(t *testing.T) {
 
 		insertTestRecords(t, db, 45, 2)
 
-		var mu sync.Mutex
-		var callbackCalled bool
-		var receivedResult CleanupResult
-
-		config := CleanupSchedulerConfig{
-			RetentionDays: 30,
-			Interval:      1 * time.Hour,
-			OnCleanup: func(result CleanupResult, err error) {
-				mu.Lock()
-				defer mu.Unlock()
-				callbackCalled = true
-				receivedResult = result
-			},
-		}
-
 		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 
-		db.StartCleanupSchedulerWithConfig(ctx, config)
-
-		// Wait for callback
-		time.Sleep(100 * time.Millisecond)
-
-		mu.Lock()
-		defer mu.Unlock()
-
-		if !callbackCalled {
-			t.Error("Callback was not called")
-		}
-		if receivedResult.TotalDeleted != 10 { // 2 records * 5 tables
-			t.Errorf("Callback received TotalDeleted = %d, want 10", receivedResult.TotalDeleted)
+		result := waitResult(t, startScheduler(ctx, db, clock.NewFake(epoch), time.Hour))
+		if result.TotalDeleted != 10 { // 2 records * 5 tables
+			t.Errorf("Callback received TotalDeleted = %d, want 10", result.TotalDeleted)
 		}
 	})
 
@@ -463,35 +467,21 @@ func TestCleanupScheduler(t *testing.T) {
 		db := setupTestDatabaseWithData(t)
 		defer db.Close()
 
-		var mu sync.Mutex
-		var callCount int
-
-		config := CleanupSchedulerConfig{
-			RetentionDays: 30,
-			Interval:      50 * time.Millisecond,
-			OnCleanup: func(result CleanupResult, err error) {
-				mu.Lock()
-				defer mu.Unlock()
-				callCount++
-			},
-		}
-
+		fake := clock.NewFake(epoch)
 		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 
-		db.StartCleanupSchedulerWithConfig(ctx, config)
-
-		// Wait for multiple runs (initial + 2 periodic)
-		time.Sleep(150 * time.Millisecond)
-
-		cancel()
-
-		mu.Lock()
-		finalCount := callCount
-		mu.Unlock()
+		results := startScheduler(ctx, db, fake, 24*time.Hour)
+		waitResult(t, results)
+		fake.BlockUntil(1)
 
-		// Should have at least 2 runs (initial + 1 periodic)
-		if finalCount < 2 {
-			t.Errorf("Callback count = %d, want >= 2", finalCount)
+		// Each interval runs another cleanup
+		for i := 0; i < 2; i++ {
+			insertTestRecords(t, db, 45, 1)
+			fake.Advance(24 * time.Hour)
+			if result := waitResult(t, results); result.TotalDeleted != 5 {
+				t.Errorf("run %d TotalDeleted = %d, want 5", i+2, result.TotalDeleted)
+			}
 		}
 	})
 }
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"go_backend/featureflags"
+)
+
+// featureFlagsSchema creates the feature flag overrides table. An empty
+// canvas_id holds the override for every canvas.
+const featureFlagsSchema = `
+CREATE TABLE IF NOT EXISTS feature_flag_overrides (
+    flag TEXT NOT NULL,
+    canvas_id TEXT NOT NULL DEFAULT '',
+    enabled INTEGER NOT NULL,
+    updated_at TEXT NOT NULL,
+    PRIMARY KEY (flag, canvas_id)
+);
+`
+
+// ensureFeatureFlagsSchema creates the feature_flag_overrides table if needed.
+func (r *Repository) ensureFeatureFlagsSchema() error {
+	if _, err := r.db.Exec(featureFlagsSchema); err != nil {
+		return fmt.Errorf("failed to create feature flag overrides table: %w", err)
+	}
+	return nil
+}
+
+// ListFlagOverrides returns every saved feature flag override.
+// Implements featureflags.Storage.
+func (r *Repository) ListFlagOverrides(ctx context.Context) ([]featureflags.Override, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureFeatureFlagsSchema(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT flag, canvas_id, enabled, updated_at FROM feature_flag_overrides
+		ORDER BY flag, canvas_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query feature flag overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []featureflags.Override
+	for rows.Next() {
+		var o featureflags.Override
+		var updatedAt string
+		if err := rows.Scan(&o.Flag, &o.CanvasID, &o.Enabled, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag override: %w", err)
+		}
+		o.UpdatedAt = parseSnapshotTime(updatedAt)
+		overrides = append(overrides, o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating feature flag overrides: %w", err)
+	}
+	return overrides, nil
+}
+
+// SaveFlagOverride inserts or replaces a feature flag override.
+// Implements featureflags.Storage.
+func (r *Repository) SaveFlagOverride(ctx context.Context, o featureflags.Override) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureFeatureFlagsSchema(); err != nil {
+		return err
+	}
+
+	if _, err := r.db.DB().ExecContext(ctx, `
+		INSERT OR REPLACE INTO feature_flag_overrides (flag, canvas_id, enabled, updated_at)
+		VALUES (?, ?, ?, ?)`,
+		o.Flag, o.CanvasID, o.Enabled, formatSnapshotTime(o.UpdatedAt)); err != nil {
+		return fmt.Errorf("failed to save feature flag override: %w", err)
+	}
+	return nil
+}
+
+// DeleteFlagOverride removes a feature flag override. Deleting an override
+// that does not exist is not an error.
+// Implements featureflags.Storage.
+func (r *Repository) DeleteFlagOverride(ctx context.Context, flag, canvasID string) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureFeatureFlagsSchema(); err != nil {
+		return err
+	}
+
+	if _, err := r.db.DB().ExecContext(ctx,
+		`DELETE FROM feature_flag_overrides WHERE flag = ? AND canvas_id = ?`, flag, canvasID); err != nil {
+		return fmt.Errorf("failed to delete feature flag override: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/featureflags"
+)
+
+// TestFlagOverridesRoundTrip tests saving, listing and deleting feature flag overrides.
+func TestFlagOverridesRoundTrip(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for _, o := range []featureflags.Override{
+		{Flag: featureflags.FlagMindMap, Enabled: true, UpdatedAt: now},
+		{Flag: featureflags.FlagMindMap, CanvasID: "prod", Enabled: true, UpdatedAt: now},
+		{Flag: featureflags.FlagMindMap, CanvasID: "prod", Enabled: false, UpdatedAt: now},
+	} {
+		if err := repo.SaveFlagOverride(ctx, o); err != nil {
+			t.Fatalf("SaveFlagOverride() error = %v", err)
+		}
+	}
+
+	overrides, err := repo.ListFlagOverrides(ctx)
+	if err != nil || len(overrides) != 2 {
+		t.Fatalf("ListFlagOverrides() = %+v, %v", overrides, err)
+	}
+	if o := overrides[0]; o.CanvasID != "" || !o.Enabled || !o.UpdatedAt.Equal(now) {
+		t.Errorf("global override = %+v", o)
+	}
+	if o := overrides[1]; o.CanvasID != "prod" || o.Enabled {
+		t.Errorf("replaced canvas override = %+v", o)
+	}
+
+	if err := repo.DeleteFlagOverride(ctx, featureflags.FlagMindMap, "prod"); err != nil {
+		t.Fatalf("DeleteFlagOverride() error = %v", err)
+	}
+	if overrides, _ := repo.ListFlagOverrides(ctx); len(overrides) != 1 || overrides[0].CanvasID != "" {
+		t.Errorf("after delete = %+v", overrides)
+	}
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"go_backend/fewshot"
+)
+
+// fewShotExamplesSchema creates the few-shot example table.
+const fewShotExamplesSchema = `
+CREATE TABLE IF NOT EXISTS fewshot_examples (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task TEXT NOT NULL,
+    input TEXT NOT NULL,
+    output TEXT NOT NULL,
+    disabled INTEGER NOT NULL DEFAULT 0,
+    updated_at TEXT NOT NULL
+);
+`
+
+// ensureFewShotExamplesSchema creates the fewshot_examples table if needed.
+func (r *Repository) ensureFewShotExamplesSchema() error {
+	if _, err := r.db.Exec(fewShotExamplesSchema); err != nil {
+		return fmt.Errorf("failed to create few-shot examples table: %w", err)
+	}
+	return nil
+}
+
+// ListFewShotExamples returns every few-shot example, by task and ID.
+// Implements fewshot.Storage.
+func (r *Repository) ListFewShotExamples(ctx context.Context) ([]fewshot.Example, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureFewShotExamplesSchema(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT id, task, input, output, disabled, updated_at
+		FROM fewshot_examples ORDER BY task, id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query few-shot examples: %w", err)
+	}
+	defer rows.Close()
+
+	var list []fewshot.Example
+	for rows.Next() {
+		var e fewshot.Example
+		var updatedAt string
+		if err := rows.Scan(&e.ID, &e.Task, &e.Input, &e.Output, &e.Disabled, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan few-shot example: %w", err)
+		}
+		e.UpdatedAt = parseSnapshotTime(updatedAt)
+		list = append(list, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating few-shot examples: %w", err)
+	}
+	return list, nil
+}
+
+// SaveFewShotExample inserts e if its ID is 0, otherwise replaces the
+// example with that ID, and returns its ID.
+// Implements fewshot.Storage.
+func (r *Repository) SaveFewShotExample(ctx context.Context, e fewshot.Example) (int64, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureFewShotExamplesSchema(); err != nil {
+		return 0, err
+	}
+
+	if e.ID == 0 {
+		result, err := r.db.DB().ExecContext(ctx, `
+			INSERT INTO fewshot_examples (task, input, output, disabled, updated_at)
+			VALUES (?, ?, ?, ?, ?)`,
+			e.Task, e.Input, e.Output, e.Disabled, formatSnapshotTime(e.UpdatedAt))
+		if err != nil {
+			return 0, fmt.Errorf("failed to save few-shot example: %w", err)
+		}
+		return result.LastInsertId()
+	}
+
+	if _, err := r.db.DB().ExecContext(ctx, `
+		INSERT OR REPLACE INTO fewshot_examples (id, task, input, output, disabled, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		e.ID, e.Task, e.Input, e.Output, e.Disabled, formatSnapshotTime(e.UpdatedAt)); err != nil {
+		return 0, fmt.Errorf("failed to save few-shot example: %w", err)
+	}
+	return e.ID, nil
+}
+
+// DeleteFewShotExample removes a few-shot example. Deleting an example
+// that does not exist is not an error.
+// Implements fewshot.Storage.
+func (r *Repository) DeleteFewShotExample(ctx context.Context, id int64) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureFewShotExamplesSchema(); err != nil {
+		return err
+	}
+
+	if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM fewshot_examples WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete few-shot example: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/fewshot"
+)
+
+// TestFewShotExamplesRoundTrip tests saving, listing and deleting few-shot examples.
+func TestFewShotExamplesRoundTrip(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if list, err := repo.ListFewShotExamples(ctx); len(list) != 0 || err != nil {
+		t.Fatalf("ListFewShotExamples() on empty db = %v, %v", list, err)
+	}
+
+	updated := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	note := fewshot.Example{Task: fewshot.TaskNote, Input: "draw a cat", Output: `{"type":"image","content":"a cat"}`, UpdatedAt: updated}
+	id, err := repo.SaveFewShotExample(ctx, note)
+	if err != nil || id == 0 {
+		t.Fatalf("SaveFewShotExample() = %d, %v", id, err)
+	}
+	note.ID = id
+	note.Disabled = true
+	if got, err := repo.SaveFewShotExample(ctx, note); err != nil || got != id {
+		t.Fatalf("SaveFewShotExample() replace = %d, %v", got, err)
+	}
+	if _, err := repo.SaveFewShotExample(ctx, fewshot.Example{Task: fewshot.TaskCanvasAnalysis, Input: "x", Output: "y", UpdatedAt: updated}); err != nil {
+		t.Fatalf("SaveFewShotExample() error = %v", err)
+	}
+
+	list, err := repo.ListFewShotExamples(ctx)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("ListFewShotExamples() = %v, %v", list, err)
+	}
+	if got := list[1]; got.ID != id || got.Task != fewshot.TaskNote || !got.Disabled ||
+		got.Output != note.Output || !got.UpdatedAt.Equal(updated) {
+		t.Errorf("note example = %+v", got)
+	}
+
+	if err := repo.DeleteFewShotExample(ctx, id); err != nil {
+		t.Fatalf("DeleteFewShotExample() error = %v", err)
+	}
+	if list, _ := repo.ListFewShotExamples(ctx); len(list) != 1 || list[0].Task != fewshot.TaskCanvasAnalysis {
+		t.Errorf("after delete = %+v", list)
+	}
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go_backend/metrics"
+)
+
+// gpuSamplesSchema creates the table holding the persisted GPU history.
+// Samples are keyed by their step and start time, both in seconds. Like
+// the metrics snapshot tables it is created on demand.
+const gpuSamplesSchema = `
+CREATE TABLE IF NOT EXISTS gpu_samples (
+    step INTEGER NOT NULL,
+    bucket INTEGER NOT NULL,
+    samples INTEGER NOT NULL,
+    utilization REAL NOT NULL,
+    utilization_max REAL NOT NULL,
+    temperature REAL NOT NULL,
+    temperature_max REAL NOT NULL,
+    memory_used INTEGER NOT NULL,
+    memory_total INTEGER NOT NULL,
+    power_draw REAL NOT NULL DEFAULT 0,
+    PRIMARY KEY (step, bucket)
+);
+`
+
+// ensureGPUSamplesSchema creates the GPU samples table if needed.
+func (r *Repository) ensureGPUSamplesSchema() error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if _, err := r.db.Exec(gpuSamplesSchema); err != nil {
+		return fmt.Errorf("failed to create gpu_samples table: %w", err)
+	}
+	return nil
+}
+
+// SaveGPUSamples stores samples in a single transaction. Implements
+// metrics.GPUHistoryStorage.
+func (r *Repository) SaveGPUSamples(ctx context.Context, samples []metrics.GPUSample) error {
+	if err := r.ensureGPUSamplesSchema(); err != nil {
+		return err
+	}
+
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, s := range samples {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR REPLACE INTO gpu_samples (
+				step, bucket, samples, utilization, utilization_max,
+				temperature, temperature_max, memory_used, memory_total, power_draw
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			int64(s.Step/time.Second), s.Time.Unix(), s.Samples, s.Utilization, s.UtilizationMax,
+			s.Temperature, s.TemperatureMax, s.MemoryUsed, s.MemoryTotal, s.PowerDraw); err != nil {
+			return fmt.Errorf("failed to save GPU sample: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit GPU samples: %w", err)
+	}
+	return nil
+}
+
+// RollupGPUSamples recomputes the samples at step to from those at step
+// from, starting at since. Averages are weighted by the samples behind
+// each row. Implements metrics.GPUHistoryStorage.
+func (r *Repository) RollupGPUSamples(ctx context.Context, from, to time.Duration, since time.Time) error {
+	if err := r.ensureGPUSamplesSchema(); err != nil {
+		return err
+	}
+	toSecs := int64(to / time.Second)
+	if _, err := r.db.DB().ExecContext(ctx, `
+		INSERT OR REPLACE INTO gpu_samples (
+			step, bucket, samples, utilization, utilization_max,
+			temperature, temperature_max, memory_used, memory_total, power_draw
+		)
+		SELECT ?, (bucket / ?) * ?, SUM(samples),
+			SUM(utilization * samples) / SUM(samples), MAX(utilization_max),
+			SUM(temperature * samples) / SUM(samples), MAX(temperature_max),
+			SUM(memory_used * samples) / SUM(samples), MAX(memory_total),
+			SUM(power_draw * samples) / SUM(samples)
+		FROM gpu_samples
+		WHERE step = ? AND bucket >= ?
+		GROUP BY bucket / ?`,
+		toSecs, toSecs, toSecs, int64(from/time.Second), since.Truncate(to).Unix(), toSecs); err != nil {
+		return fmt.Errorf("failed to roll up GPU samples: %w", err)
+	}
+	return nil
+}
+
+// DeleteGPUSamples deletes the samples at step that start before before.
+// Implements metrics.GPUHistoryStorage.
+func (r *Repository) DeleteGPUSamples(ctx context.Context, step time.Duration, before time.Time) error {
+	if err := r.ensureGPUSamplesSchema(); err != nil {
+		return err
+	}
+	if _, err := r.db.DB().ExecContext(ctx,
+		`DELETE FROM gpu_samples WHERE step = ? AND bucket < ?`,
+		int64(step/time.Second), before.Unix()); err != nil {
+		return fmt.Errorf("failed to delete GPU samples: %w", err)
+	}
+	return nil
+}
+
+// QueryGPUSamples returns the samples at step starting in [since, until),
+// oldest first. Implements metrics.GPUHistoryStorage.
+func (r *Repository) QueryGPUSamples(ctx context.Context, step time.Duration, since, until time.Time) ([]metrics.GPUSample, error) {
+	if err := r.ensureGPUSamplesSchema(); err != nil {
+		return nil, err
+	}
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT bucket, samples, utilization, utilization_max,
+			temperature, temperature_max, memory_used, memory_total, power_draw
+		FROM gpu_samples
+		WHERE step = ? AND bucket >= ? AND bucket < ?
+		ORDER BY bucket`,
+		int64(step/time.Second), since.Unix(), until.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query GPU samples: %w", err)
+	}
+	defer rows.Close()
+
+	samples := []metrics.GPUSample{}
+	for rows.Next() {
+		s := metrics.GPUSample{Step: step}
+		var bucket int64
+		if err := rows.Scan(&bucket, &s.Samples, &s.Utilization, &s.UtilizationMax,
+			&s.Temperature, &s.TemperatureMax, &s.MemoryUsed, &s.MemoryTotal, &s.PowerDraw); err != nil {
+			return nil, fmt.Errorf("failed to scan GPU sample: %w", err)
+		}
+		s.Time = time.Unix(bucket, 0).UTC()
+		samples = append(samples, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating GPU samples: %w", err)
+	}
+	return samples, nil
+}
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"go_backend/metrics"
+)
+
+// TestGPUSamples tests saving, rolling up, querying and deleting GPU samples.
+func TestGPUSamples(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	// Ten minutes at 10% to 100% utilization; the last minute has 6 samples
+	var samples []metrics.GPUSample
+	for i := 0; i < 10; i++ {
+		n := 12
+		if i == 9 {
+			n = 6
+		}
+		samples = append(samples, metrics.GPUSample{
+			Time: base.Add(time.Duration(i) * time.Minute), Step: time.Minute, Samples: n,
+			Utilization: float64(10 * (i + 1)), UtilizationMax: float64(10*(i+1) + 5),
+			Temperature: 60, TemperatureMax: 70 + float64(i),
+			MemoryUsed: 1000, MemoryTotal: 8000,
+		})
+	}
+	if err := repo.SaveGPUSamples(ctx, samples); err != nil {
+		t.Fatalf("SaveGPUSamples() error = %v", err)
+	}
+	// Saving a minute again replaces it
+	if err := repo.SaveGPUSamples(ctx, samples[:1]); err != nil {
+		t.Fatalf("second SaveGPUSamples() error = %v", err)
+	}
+
+	got, err := repo.QueryGPUSamples(ctx, time.Minute, base.Add(2*time.Minute), base.Add(4*time.Minute))
+	if err != nil {
+		t.Fatalf("QueryGPUSamples() error = %v", err)
+	}
+	if len(got) != 2 || !got[0].Time.Equal(base.Add(2*time.Minute)) || got[0].Utilization != 30 || got[0].Samples != 12 {
+		t.Errorf("minute samples = %+v", got)
+	}
+
+	if err := repo.RollupGPUSamples(ctx, time.Minute, 5*time.Minute, base.Add(3*time.Minute)); err != nil {
+		t.Fatalf("RollupGPUSamples() error = %v", err)
+	}
+	rolled, err := repo.QueryGPUSamples(ctx, 5*time.Minute, base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("QueryGPUSamples() error = %v", err)
+	}
+	if len(rolled) != 2 {
+		t.Fatalf("rollup = %+v, want 2 samples", rolled)
+	}
+	first, second := rolled[0], rolled[1]
+	if first.Samples != 60 || first.Utilization != 30 || first.UtilizationMax != 55 || first.TemperatureMax != 74 || first.MemoryUsed != 1000 {
+		t.Errorf("first rollup = %+v", first)
+	}
+	// (60+70+80+90)*12 + 100*6 over 54 samples
+	if second.Samples != 54 || math.Abs(second.Utilization-4200.0/54) > 1e-9 {
+		t.Errorf("second rollup = %+v", second)
+	}
+
+	if err := repo.DeleteGPUSamples(ctx, time.Minute, base.Add(5*time.Minute)); err != nil {
+		t.Fatalf("DeleteGPUSamples() error = %v", err)
+	}
+	left, _ := repo.QueryGPUSamples(ctx, time.Minute, base, base.Add(time.Hour))
+	kept, _ := repo.QueryGPUSamples(ctx, 5*time.Minute, base, base.Add(time.Hour))
+	if len(left) != 5 || len(kept) != 2 {
+		t.Errorf("after delete: %d minute samples, %d rollups; want 5, 2", len(left), len(kept))
+	}
+}
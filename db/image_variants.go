@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"go_backend/variants"
+)
+
+// imageVariantsSchema creates the image variant table. Rows are kept per
+// trigger note, newest by id.
+const imageVariantsSchema = `
+CREATE TABLE IF NOT EXISTS image_variants (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    canvas_id TEXT NOT NULL,
+    note_id TEXT NOT NULL,
+    widget_id TEXT NOT NULL DEFAULT '',
+    artifact_id TEXT NOT NULL DEFAULT '',
+    prompt TEXT NOT NULL DEFAULT '',
+    seed INTEGER,
+    width INTEGER NOT NULL DEFAULT 0,
+    height INTEGER NOT NULL DEFAULT 0,
+    thumbnail BLOB,
+    created_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_image_variants_note ON image_variants(canvas_id, note_id, id);
+`
+
+// ensureImageVariantsSchema creates the image_variants table if needed.
+func (r *Repository) ensureImageVariantsSchema() error {
+	if _, err := r.db.Exec(imageVariantsSchema); err != nil {
+		return fmt.Errorf("failed to create image variants table: %w", err)
+	}
+	return nil
+}
+
+// AddImageVariant stores v and removes the oldest variants of its note
+// beyond keep, in one transaction.
+// Implements variants.Storage.
+func (r *Repository) AddImageVariant(ctx context.Context, v variants.Variant, keep int) (int64, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureImageVariantsSchema(); err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var seed sql.NullInt64
+	if v.Seed != nil {
+		seed = sql.NullInt64{Int64: *v.Seed, Valid: true}
+	}
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO image_variants (
+			canvas_id, note_id, widget_id, artifact_id, prompt, seed, width, height, thumbnail, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		v.CanvasID, v.NoteID, v.WidgetID, v.ArtifactID, v.Prompt, seed, v.Width, v.Height, v.Thumbnail,
+		formatSnapshotTime(v.CreatedAt))
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert image variant: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get image variant id: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM image_variants
+		WHERE canvas_id = ? AND note_id = ? AND id NOT IN (
+			SELECT id FROM image_variants WHERE canvas_id = ? AND note_id = ?
+			ORDER BY id DESC LIMIT ?
+		)`,
+		v.CanvasID, v.NoteID, v.CanvasID, v.NoteID, keep); err != nil {
+		return 0, fmt.Errorf("failed to prune image variants: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit image variant: %w", err)
+	}
+	return id, nil
+}
+
+// ListImageVariants returns the variants of a note, newest first.
+// Implements variants.Storage.
+func (r *Repository) ListImageVariants(ctx context.Context, canvasID, noteID string) ([]variants.Variant, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureImageVariantsSchema(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT id, canvas_id, note_id, widget_id, artifact_id, prompt, seed, width, height, thumbnail, created_at
+		FROM image_variants WHERE canvas_id = ? AND note_id = ? ORDER BY id DESC`,
+		canvasID, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query image variants: %w", err)
+	}
+	defer rows.Close()
+
+	var list []variants.Variant
+	for rows.Next() {
+		var v variants.Variant
+		var seed sql.NullInt64
+		var createdAt string
+		if err := rows.Scan(&v.ID, &v.CanvasID, &v.NoteID, &v.WidgetID, &v.ArtifactID, &v.Prompt,
+			&seed, &v.Width, &v.Height, &v.Thumbnail, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan image variant: %w", err)
+		}
+		if seed.Valid {
+			v.Seed = &seed.Int64
+		}
+		v.CreatedAt = parseSnapshotTime(createdAt)
+		list = append(list, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating image variants: %w", err)
+	}
+	return list, nil
+}
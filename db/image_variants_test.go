@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/variants"
+)
+
+// TestImageVariantsKeepLatest tests that only the latest variants of a note are kept.
+func TestImageVariantsKeepLatest(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	created := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	for i := int64(1); i <= 4; i++ {
+		seed := i * 100
+		v := variants.Variant{
+			CanvasID:  "canvas-1",
+			NoteID:    "note-1",
+			WidgetID:  "image-" + string(rune('0'+i)),
+			Prompt:    "a fox",
+			Seed:      &seed,
+			Width:     512,
+			Height:    512,
+			Thumbnail: []byte{0xff, 0xd8, byte(i)},
+			CreatedAt: created.Add(time.Duration(i) * time.Minute),
+		}
+		if _, err := repo.AddImageVariant(ctx, v, 3); err != nil {
+			t.Fatalf("AddImageVariant() error = %v", err)
+		}
+	}
+	if _, err := repo.AddImageVariant(ctx, variants.Variant{CanvasID: "canvas-1", NoteID: "note-2", CreatedAt: created}, 3); err != nil {
+		t.Fatalf("AddImageVariant() error = %v", err)
+	}
+
+	list, err := repo.ListImageVariants(ctx, "canvas-1", "note-1")
+	if err != nil || len(list) != 3 {
+		t.Fatalf("ListImageVariants() = %+v, %v", list, err)
+	}
+	newest := list[0]
+	if newest.WidgetID != "image-4" || newest.Seed == nil || *newest.Seed != 400 ||
+		newest.Thumbnail[2] != 4 || !newest.CreatedAt.Equal(created.Add(4*time.Minute)) {
+		t.Errorf("newest variant = %+v", newest)
+	}
+	if list[2].WidgetID != "image-2" {
+		t.Errorf("oldest kept variant = %s, want image-2", list[2].WidgetID)
+	}
+
+	other, err := repo.ListImageVariants(ctx, "canvas-1", "note-2")
+	if err != nil || len(other) != 1 || other[0].Seed != nil {
+		t.Errorf("note-2 variants = %+v, %v", other, err)
+	}
+}
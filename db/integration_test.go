@@ -7,6 +7,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"go_backend/core/clock"
 )
 
 // TestDatabaseOrganismIntegration tests the full database organism working together.
@@ -667,31 +669,42 @@ func TestCleanupSchedulerIntegration(t *testing.T) {
 	var mu sync.Mutex
 	var cleanupResults []CleanupResult
 	var cleanupErrors []error
+	runs := make(chan struct{}, 10)
 
+	fake := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
 	config := CleanupSchedulerConfig{
 		RetentionDays: 30,
-		Interval:      100 * time.Millisecond,
+		Interval:      time.Hour,
 		OnCleanup: func(result CleanupResult, err error) {
 			mu.Lock()
-			defer mu.Unlock()
 			cleanupResults = append(cleanupResults, result)
 			if err != nil {
 				cleanupErrors = append(cleanupErrors, err)
 			}
+			mu.Unlock()
+			runs <- struct{}{}
 		},
+		Clock: fake,
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	// Start scheduler
 	db.StartCleanupSchedulerWithConfig(ctx, config)
 
-	// Let it run for at least 2 cleanup cycles
-	time.Sleep(250 * time.Millisecond)
-
-	// Stop scheduler
-	cancel()
-	time.Sleep(50 * time.Millisecond) // Let it clean up
+	// Run the initial cleanup and two scheduled ones
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			fake.BlockUntil(1)
+			fake.Advance(config.Interval)
+		}
+		select {
+		case <-runs:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("cleanup run %d did not happen", i+1)
+		}
+	}
 
 	mu.Lock()
 	resultsCount := len(cleanupResults)
@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go_backend/llmcapture"
+)
+
+// llmCapturesSchema creates the table of captured LLM requests.
+const llmCapturesSchema = `
+CREATE TABLE IF NOT EXISTS llm_captures (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    captured_at TEXT NOT NULL,
+    canvas_id TEXT NOT NULL DEFAULT '',
+    correlation_id TEXT NOT NULL DEFAULT '',
+    widget_id TEXT NOT NULL DEFAULT '',
+    operation TEXT NOT NULL DEFAULT '',
+    backend TEXT NOT NULL,
+    model TEXT NOT NULL DEFAULT '',
+    endpoint TEXT NOT NULL DEFAULT '',
+    request TEXT NOT NULL DEFAULT '',
+    response TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    redacted INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_captures_captured_at ON llm_captures(captured_at);
+CREATE INDEX IF NOT EXISTS idx_llm_captures_correlation ON llm_captures(correlation_id);
+`
+
+// captureTimeFormat stores capture times with a fixed width, so they sort
+// and compare as text.
+const captureTimeFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+const llmCaptureColumns = `id, captured_at, canvas_id, correlation_id, widget_id, operation,
+	backend, model, endpoint, request, response, error, duration_ms, redacted`
+
+// ensureLLMCaptureSchema creates the llm_captures table if needed.
+func (r *Repository) ensureLLMCaptureSchema() error {
+	if _, err := r.db.Exec(llmCapturesSchema); err != nil {
+		return fmt.Errorf("failed to create LLM captures table: %w", err)
+	}
+	return nil
+}
+
+// InsertLLMCapture stores a captured LLM request and returns its ID.
+// Implements llmcapture.Storage.
+func (r *Repository) InsertLLMCapture(ctx context.Context, c llmcapture.Capture) (int64, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureLLMCaptureSchema(); err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.DB().ExecContext(ctx, `INSERT INTO llm_captures (
+		captured_at, canvas_id, correlation_id, widget_id, operation, backend,
+		model, endpoint, request, response, error, duration_ms, redacted
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.Time.UTC().Format(captureTimeFormat), c.CanvasID, c.CorrelationID, c.WidgetID,
+		c.Operation, string(c.Backend), c.Model, c.Endpoint, c.Request, c.Response,
+		c.Error, c.DurationMS, c.Redacted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert LLM capture: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get LLM capture ID: %w", err)
+	}
+	return id, nil
+}
+
+// ListLLMCaptures returns the captures matching q, newest first.
+// Implements llmcapture.Storage.
+func (r *Repository) ListLLMCaptures(ctx context.Context, q llmcapture.Query) ([]llmcapture.Capture, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureLLMCaptureSchema(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, `SELECT `+llmCaptureColumns+` FROM llm_captures
+		WHERE (? = '' OR canvas_id = ?)
+		  AND (? = '' OR correlation_id = ?)
+		  AND (? = '' OR backend = ?)
+		ORDER BY id DESC
+		LIMIT ?`,
+		q.CanvasID, q.CanvasID, q.CorrelationID, q.CorrelationID,
+		string(q.Backend), string(q.Backend), q.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query LLM captures: %w", err)
+	}
+	defer rows.Close()
+
+	captures := []llmcapture.Capture{}
+	for rows.Next() {
+		var c llmcapture.Capture
+		var capturedAt, backend string
+		if err := rows.Scan(&c.ID, &capturedAt, &c.CanvasID, &c.CorrelationID, &c.WidgetID,
+			&c.Operation, &backend, &c.Model, &c.Endpoint, &c.Request, &c.Response,
+			&c.Error, &c.DurationMS, &c.Redacted); err != nil {
+			return nil, fmt.Errorf("failed to scan LLM capture: %w", err)
+		}
+		c.Time = parseSnapshotTime(capturedAt)
+		c.Backend = llmcapture.Backend(backend)
+		captures = append(captures, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating LLM captures: %w", err)
+	}
+	return captures, nil
+}
+
+// DeleteLLMCaptures deletes the captures taken before t and returns how
+// many were deleted. Implements llmcapture.Storage.
+func (r *Repository) DeleteLLMCaptures(ctx context.Context, before time.Time) (int64, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureLLMCaptureSchema(); err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.DB().ExecContext(ctx, `DELETE FROM llm_captures WHERE captured_at < ?`,
+		before.UTC().Format(captureTimeFormat))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete LLM captures: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/llmcapture"
+)
+
+// TestLLMCaptures tests storing, filtering and pruning LLM captures.
+func TestLLMCaptures(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i, c := range []llmcapture.Capture{
+		{CorrelationID: "a", Backend: llmcapture.BackendLocal, Request: "prompt", Response: `{"type":`, Time: base},
+		{CorrelationID: "b", Backend: llmcapture.BackendCloud, Model: "gpt-4o", Redacted: true, Time: base.Add(time.Second)},
+		{CorrelationID: "b", Backend: llmcapture.BackendCloud, Error: "429 Too Many Requests", Time: base.Add(1500 * time.Millisecond)},
+	} {
+		if _, err := repo.InsertLLMCapture(ctx, c); err != nil {
+			t.Fatalf("InsertLLMCapture(%d) error = %v", i, err)
+		}
+	}
+
+	all, err := repo.ListLLMCaptures(ctx, llmcapture.Query{Limit: 10})
+	if err != nil {
+		t.Fatalf("ListLLMCaptures() error = %v", err)
+	}
+	if len(all) != 3 || all[0].Error == "" || !all[1].Redacted || all[2].Response != `{"type":` || !all[2].Time.Equal(base) {
+		t.Fatalf("unexpected captures: %+v", all)
+	}
+
+	cloud, _ := repo.ListLLMCaptures(ctx, llmcapture.Query{CorrelationID: "b", Backend: llmcapture.BackendCloud, Limit: 1})
+	if len(cloud) != 1 || cloud[0].CorrelationID != "b" {
+		t.Errorf("filtered captures = %+v", cloud)
+	}
+
+	// The whole-second capture sorts before the later fractional one
+	deleted, err := repo.DeleteLLMCaptures(ctx, base.Add(1200*time.Millisecond))
+	if err != nil || deleted != 2 {
+		t.Errorf("DeleteLLMCaptures() = %d, %v; want 2", deleted, err)
+	}
+}
@@ -0,0 +1,200 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go_backend/metrics"
+)
+
+// metricsSnapshotSchema creates the tables holding the persisted
+// MetricsStore. They are created on demand (IF NOT EXISTS) so metrics
+// persistence works on databases created before these tables existed.
+const metricsSnapshotSchema = `
+CREATE TABLE IF NOT EXISTS metrics_totals (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    total_tasks INTEGER NOT NULL DEFAULT 0,
+    total_success INTEGER NOT NULL DEFAULT 0,
+    total_errors INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS metrics_task_types (
+    task_type TEXT PRIMARY KEY,
+    task_count INTEGER NOT NULL DEFAULT 0,
+    success_count INTEGER NOT NULL DEFAULT 0,
+    total_duration_ms INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS metrics_task_history (
+    position INTEGER PRIMARY KEY,
+    task_id TEXT,
+    task_type TEXT NOT NULL,
+    canvas_id TEXT,
+    status TEXT NOT NULL,
+    start_time TEXT,
+    end_time TEXT,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT
+);
+`
+
+// ensureMetricsSnapshotSchema creates the metrics snapshot tables if needed.
+func (r *Repository) ensureMetricsSnapshotSchema() error {
+	if _, err := r.db.Exec(metricsSnapshotSchema); err != nil {
+		return fmt.Errorf("failed to create metrics snapshot tables: %w", err)
+	}
+	return nil
+}
+
+// SaveMetricsSnapshot replaces the persisted metrics snapshot in a single
+// transaction. Implements metrics.SnapshotStorage.
+func (r *Repository) SaveMetricsSnapshot(ctx context.Context, snap metrics.StoreSnapshot) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureMetricsSnapshotSchema(); err != nil {
+		return err
+	}
+
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR REPLACE INTO metrics_totals (id, total_tasks, total_success, total_errors, updated_at)
+		VALUES (1, ?, ?, ?, CURRENT_TIMESTAMP)`,
+		snap.TotalTasks, snap.TotalSuccess, snap.TotalErrors); err != nil {
+		return fmt.Errorf("failed to save metrics totals: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM metrics_task_types`); err != nil {
+		return fmt.Errorf("failed to clear task type counters: %w", err)
+	}
+	for taskType, totals := range snap.ByType {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO metrics_task_types (task_type, task_count, success_count, total_duration_ms)
+			VALUES (?, ?, ?, ?)`,
+			taskType, totals.Count, totals.SuccessCount, totals.TotalDuration.Milliseconds()); err != nil {
+			return fmt.Errorf("failed to save task type counters: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM metrics_task_history`); err != nil {
+		return fmt.Errorf("failed to clear task history: %w", err)
+	}
+	for i, task := range snap.Tasks {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO metrics_task_history (
+				position, task_id, task_type, canvas_id, status,
+				start_time, end_time, duration_ms, error_message
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			i, task.ID, task.Type, task.CanvasID, task.Status,
+			formatSnapshotTime(task.StartTime), formatSnapshotTime(task.EndTime),
+			task.Duration.Milliseconds(), nullString(task.ErrorMsg)); err != nil {
+			return fmt.Errorf("failed to save task history: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit metrics snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadMetricsSnapshot returns the persisted metrics snapshot. found is false
+// if no snapshot has been saved. Implements metrics.SnapshotStorage.
+func (r *Repository) LoadMetricsSnapshot(ctx context.Context) (metrics.StoreSnapshot, bool, error) {
+	snap := metrics.StoreSnapshot{ByType: make(map[string]metrics.TaskTypeTotals)}
+	if r.db == nil {
+		return snap, false, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureMetricsSnapshotSchema(); err != nil {
+		return snap, false, err
+	}
+
+	err := r.db.DB().QueryRowContext(ctx,
+		`SELECT total_tasks, total_success, total_errors FROM metrics_totals WHERE id = 1`,
+	).Scan(&snap.TotalTasks, &snap.TotalSuccess, &snap.TotalErrors)
+	if err == sql.ErrNoRows {
+		return snap, false, nil
+	}
+	if err != nil {
+		return snap, false, fmt.Errorf("failed to load metrics totals: %w", err)
+	}
+
+	typeRows, err := r.db.DB().QueryContext(ctx,
+		`SELECT task_type, task_count, success_count, total_duration_ms FROM metrics_task_types`)
+	if err != nil {
+		return snap, false, fmt.Errorf("failed to load task type counters: %w", err)
+	}
+	for typeRows.Next() {
+		var taskType string
+		var totals metrics.TaskTypeTotals
+		var durationMS int64
+		if err := typeRows.Scan(&taskType, &totals.Count, &totals.SuccessCount, &durationMS); err != nil {
+			typeRows.Close()
+			return snap, false, fmt.Errorf("failed to scan task type counters: %w", err)
+		}
+		totals.TotalDuration = time.Duration(durationMS) * time.Millisecond
+		snap.ByType[taskType] = totals
+	}
+	// Close before the next query; the pool may hold a single connection
+	typeRows.Close()
+	if err := typeRows.Err(); err != nil {
+		return snap, false, fmt.Errorf("error iterating task type counters: %w", err)
+	}
+
+	taskRows, err := r.db.DB().QueryContext(ctx, `
+		SELECT task_id, task_type, canvas_id, status, start_time, end_time, duration_ms, error_message
+		FROM metrics_task_history
+		ORDER BY position`)
+	if err != nil {
+		return snap, false, fmt.Errorf("failed to load task history: %w", err)
+	}
+	defer taskRows.Close()
+	for taskRows.Next() {
+		var task metrics.TaskRecord
+		var id, canvasID, startTime, endTime, errorMsg sql.NullString
+		var durationMS int64
+		if err := taskRows.Scan(&id, &task.Type, &canvasID, &task.Status,
+			&startTime, &endTime, &durationMS, &errorMsg); err != nil {
+			return snap, false, fmt.Errorf("failed to scan task history row: %w", err)
+		}
+		task.ID = id.String
+		task.CanvasID = canvasID.String
+		task.StartTime = parseSnapshotTime(startTime.String)
+		task.EndTime = parseSnapshotTime(endTime.String)
+		task.Duration = time.Duration(durationMS) * time.Millisecond
+		task.ErrorMsg = errorMsg.String
+		snap.Tasks = append(snap.Tasks, task)
+	}
+	if err := taskRows.Err(); err != nil {
+		return snap, false, fmt.Errorf("error iterating task history: %w", err)
+	}
+
+	return snap, true, nil
+}
+
+// formatSnapshotTime stores t with full precision; the zero time is stored
+// as an empty string.
+func formatSnapshotTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseSnapshotTime reverses formatSnapshotTime; unparsable values yield
+// the zero time.
+func parseSnapshotTime(s string) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
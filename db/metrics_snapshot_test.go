@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/metrics"
+)
+
+// TestMetricsSnapshotRoundTrip tests saving and loading the metrics snapshot.
+func TestMetricsSnapshotRoundTrip(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if _, found, err := repo.LoadMetricsSnapshot(ctx); found || err != nil {
+		t.Fatalf("LoadMetricsSnapshot() on empty db = %v, %v; want not found", found, err)
+	}
+
+	start := time.Date(2026, 3, 1, 10, 0, 0, 123456789, time.UTC)
+	snap := metrics.StoreSnapshot{
+		Tasks: []metrics.TaskRecord{
+			{ID: "t1", Type: "note", CanvasID: "c1", Status: metrics.TaskStatusSuccess, StartTime: start, EndTime: start.Add(2 * time.Second), Duration: 2 * time.Second},
+			{ID: "t2", Type: "pdf", Status: metrics.TaskStatusError, StartTime: start, Duration: 500 * time.Millisecond, ErrorMsg: "parse failed"},
+		},
+		TotalTasks:   42,
+		TotalSuccess: 40,
+		TotalErrors:  2,
+		ByType: map[string]metrics.TaskTypeTotals{
+			"note": {Count: 30, SuccessCount: 30, TotalDuration: time.Minute},
+			"pdf":  {Count: 12, SuccessCount: 10, TotalDuration: 6 * time.Second},
+		},
+	}
+	if err := repo.SaveMetricsSnapshot(ctx, snap); err != nil {
+		t.Fatalf("SaveMetricsSnapshot() error = %v", err)
+	}
+
+	// A second save replaces the first rather than appending
+	if err := repo.SaveMetricsSnapshot(ctx, snap); err != nil {
+		t.Fatalf("second SaveMetricsSnapshot() error = %v", err)
+	}
+
+	got, found, err := repo.LoadMetricsSnapshot(ctx)
+	if err != nil || !found {
+		t.Fatalf("LoadMetricsSnapshot() = %v, %v", found, err)
+	}
+	if got.TotalTasks != 42 || got.TotalSuccess != 40 || got.TotalErrors != 2 {
+		t.Errorf("totals = %d/%d/%d, want 42/40/2", got.TotalTasks, got.TotalSuccess, got.TotalErrors)
+	}
+	if len(got.ByType) != 2 || got.ByType["pdf"] != snap.ByType["pdf"] {
+		t.Errorf("ByType = %+v", got.ByType)
+	}
+	if len(got.Tasks) != 2 {
+		t.Fatalf("len(Tasks) = %d, want 2", len(got.Tasks))
+	}
+	first := got.Tasks[0]
+	if first.ID != "t1" || first.CanvasID != "c1" || !first.StartTime.Equal(start) || first.Duration != 2*time.Second {
+		t.Errorf("Tasks[0] = %+v", first)
+	}
+	second := got.Tasks[1]
+	if second.ErrorMsg != "parse failed" || !second.EndTime.IsZero() {
+		t.Errorf("Tasks[1] = %+v", second)
+	}
+}
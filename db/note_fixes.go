@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"go_backend/notefix"
+)
+
+// noteFixesSchema creates the table of {{fix}} corrections kept for undo.
+// Rows are kept per note, newest by id.
+const noteFixesSchema = `
+CREATE TABLE IF NOT EXISTS note_fixes (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    canvas_id TEXT NOT NULL,
+    note_id TEXT NOT NULL,
+    original TEXT NOT NULL,
+    fixed TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_note_fixes_note ON note_fixes(canvas_id, note_id, id);
+`
+
+// ensureNoteFixesSchema creates the note_fixes table if needed.
+func (r *Repository) ensureNoteFixesSchema() error {
+	if _, err := r.db.Exec(noteFixesSchema); err != nil {
+		return fmt.Errorf("failed to create note fixes table: %w", err)
+	}
+	return nil
+}
+
+// AddNoteFix stores f and removes the oldest fixes of its note beyond
+// keep, in one transaction.
+// Implements notefix.Storage.
+func (r *Repository) AddNoteFix(ctx context.Context, f notefix.Fix, keep int) (int64, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureNoteFixesSchema(); err != nil {
+		return 0, err
+	}
+
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO note_fixes (canvas_id, note_id, original, fixed, created_at)
+		VALUES (?, ?, ?, ?, ?)`,
+		f.CanvasID, f.NoteID, f.Original, f.Fixed, formatSnapshotTime(f.CreatedAt))
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert note fix: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get note fix id: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM note_fixes
+		WHERE canvas_id = ? AND note_id = ? AND id NOT IN (
+			SELECT id FROM note_fixes WHERE canvas_id = ? AND note_id = ?
+			ORDER BY id DESC LIMIT ?
+		)`,
+		f.CanvasID, f.NoteID, f.CanvasID, f.NoteID, keep); err != nil {
+		return 0, fmt.Errorf("failed to prune note fixes: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit note fix: %w", err)
+	}
+	return id, nil
+}
+
+// LatestNoteFix returns the newest fix of a note, or nil if it has none.
+// Implements notefix.Storage.
+func (r *Repository) LatestNoteFix(ctx context.Context, canvasID, noteID string) (*notefix.Fix, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureNoteFixesSchema(); err != nil {
+		return nil, err
+	}
+
+	var f notefix.Fix
+	var createdAt string
+	err := r.db.DB().QueryRowContext(ctx, `
+		SELECT id, canvas_id, note_id, original, fixed, created_at
+		FROM note_fixes WHERE canvas_id = ? AND note_id = ? ORDER BY id DESC LIMIT 1`,
+		canvasID, noteID).Scan(&f.ID, &f.CanvasID, &f.NoteID, &f.Original, &f.Fixed, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query note fix: %w", err)
+	}
+	f.CreatedAt = parseSnapshotTime(createdAt)
+	return &f, nil
+}
+
+// DeleteNoteFix removes a fix.
+// Implements notefix.Storage.
+func (r *Repository) DeleteNoteFix(ctx context.Context, id int64) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureNoteFixesSchema(); err != nil {
+		return err
+	}
+	if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM note_fixes WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete note fix: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/notefix"
+)
+
+// TestNoteFixesUndoOrder tests that fixes are kept per note and undone newest first.
+func TestNoteFixesUndoOrder(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	created := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	texts := []string{"teh fox", "the fox", "The fox.", "The fox!"}
+	for i := 1; i < len(texts); i++ {
+		f := notefix.Fix{
+			CanvasID:  "canvas-1",
+			NoteID:    "note-1",
+			Original:  texts[i-1],
+			Fixed:     texts[i],
+			CreatedAt: created.Add(time.Duration(i) * time.Minute),
+		}
+		if _, err := repo.AddNoteFix(ctx, f, 2); err != nil {
+			t.Fatalf("AddNoteFix() error = %v", err)
+		}
+	}
+
+	latest, err := repo.LatestNoteFix(ctx, "canvas-1", "note-1")
+	if err != nil || latest == nil {
+		t.Fatalf("LatestNoteFix() = %+v, %v", latest, err)
+	}
+	if latest.Original != "The fox." || latest.Fixed != "The fox!" || !latest.CreatedAt.Equal(created.Add(3*time.Minute)) {
+		t.Errorf("latest fix = %+v", latest)
+	}
+
+	if err := repo.DeleteNoteFix(ctx, latest.ID); err != nil {
+		t.Fatalf("DeleteNoteFix() error = %v", err)
+	}
+	latest, err = repo.LatestNoteFix(ctx, "canvas-1", "note-1")
+	if err != nil || latest == nil || latest.Original != "the fox" {
+		t.Fatalf("LatestNoteFix() after delete = %+v, %v", latest, err)
+	}
+
+	// keep is 2, so the first fix was pruned
+	if err := repo.DeleteNoteFix(ctx, latest.ID); err != nil {
+		t.Fatalf("DeleteNoteFix() error = %v", err)
+	}
+	if latest, err := repo.LatestNoteFix(ctx, "canvas-1", "note-1"); err != nil || latest != nil {
+		t.Errorf("LatestNoteFix() with no fixes = %+v, %v, want nil", latest, err)
+	}
+}
@@ -0,0 +1,185 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go_backend/notetags"
+)
+
+// noteTagsSchema creates the tables of automatic note tags: one row per
+// tagged note, and one row per tag of a note for filtering by tag.
+const noteTagsSchema = `
+CREATE TABLE IF NOT EXISTS tagged_notes (
+    canvas_id TEXT NOT NULL,
+    note_id TEXT NOT NULL,
+    content_hash TEXT NOT NULL,
+    excerpt TEXT NOT NULL,
+    tags TEXT NOT NULL,
+    model TEXT NOT NULL DEFAULT '',
+    tagged_at TEXT NOT NULL,
+    PRIMARY KEY (canvas_id, note_id)
+);
+CREATE TABLE IF NOT EXISTS note_tags (
+    canvas_id TEXT NOT NULL,
+    note_id TEXT NOT NULL,
+    tag TEXT NOT NULL,
+    PRIMARY KEY (canvas_id, note_id, tag)
+);
+CREATE INDEX IF NOT EXISTS idx_note_tags_tag ON note_tags(canvas_id, tag);
+`
+
+// ensureNoteTagsSchema creates the note tag tables if needed.
+func (r *Repository) ensureNoteTagsSchema() error {
+	if _, err := r.db.Exec(noteTagsSchema); err != nil {
+		return fmt.Errorf("failed to create note tags tables: %w", err)
+	}
+	return nil
+}
+
+// SaveNoteTags replaces the tags of a note, in one transaction.
+// Implements notetags.Storage.
+func (r *Repository) SaveNoteTags(ctx context.Context, n notetags.TaggedNote) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureNoteTagsSchema(); err != nil {
+		return err
+	}
+	tags, err := json.Marshal(n.Tags)
+	if err != nil {
+		return fmt.Errorf("failed to encode note tags: %w", err)
+	}
+
+	tx, err := r.db.DB().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO tagged_notes (canvas_id, note_id, content_hash, excerpt, tags, model, tagged_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(canvas_id, note_id) DO UPDATE SET
+			content_hash = excluded.content_hash,
+			excerpt = excluded.excerpt,
+			tags = excluded.tags,
+			model = excluded.model,
+			tagged_at = excluded.tagged_at`,
+		n.CanvasID, n.NoteID, n.ContentHash, n.Excerpt, string(tags), n.Model, formatSnapshotTime(n.TaggedAt)); err != nil {
+		return fmt.Errorf("failed to save tagged note: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM note_tags WHERE canvas_id = ? AND note_id = ?`, n.CanvasID, n.NoteID); err != nil {
+		return fmt.Errorf("failed to clear note tags: %w", err)
+	}
+	for _, tag := range n.Tags {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO note_tags (canvas_id, note_id, tag) VALUES (?, ?, ?)`,
+			n.CanvasID, n.NoteID, tag); err != nil {
+			return fmt.Errorf("failed to insert note tag: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit note tags: %w", err)
+	}
+	return nil
+}
+
+// NoteTagHash returns the content hash of a tagged note, or "" if the note
+// has not been tagged.
+// Implements notetags.Storage.
+func (r *Repository) NoteTagHash(ctx context.Context, canvasID, noteID string) (string, error) {
+	if r.db == nil {
+		return "", fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureNoteTagsSchema(); err != nil {
+		return "", err
+	}
+
+	var hash string
+	err := r.db.DB().QueryRowContext(ctx, `
+		SELECT content_hash FROM tagged_notes WHERE canvas_id = ? AND note_id = ?`,
+		canvasID, noteID).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to query tagged note: %w", err)
+	}
+	return hash, nil
+}
+
+// ListNoteTags returns the tags of a canvas, or of all canvases if
+// canvasID is empty, with their note counts, most used first.
+// Implements notetags.Storage.
+func (r *Repository) ListNoteTags(ctx context.Context, canvasID string) ([]notetags.TagCount, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureNoteTagsSchema(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT tag, COUNT(*) AS notes FROM note_tags
+		WHERE ? = '' OR canvas_id = ?
+		GROUP BY tag ORDER BY notes DESC, tag`,
+		canvasID, canvasID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query note tags: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []notetags.TagCount
+	for rows.Next() {
+		var c notetags.TagCount
+		if err := rows.Scan(&c.Tag, &c.Notes); err != nil {
+			return nil, fmt.Errorf("failed to scan note tag: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// FindTaggedNotes returns up to limit notes with tag on a canvas, or on
+// all canvases if canvasID is empty, most recently tagged first.
+// Implements notetags.Storage.
+func (r *Repository) FindTaggedNotes(ctx context.Context, canvasID, tag string, limit int) ([]notetags.TaggedNote, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureNoteTagsSchema(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT n.canvas_id, n.note_id, n.content_hash, n.excerpt, n.tags, n.model, n.tagged_at
+		FROM note_tags t
+		JOIN tagged_notes n ON n.canvas_id = t.canvas_id AND n.note_id = t.note_id
+		WHERE t.tag = ? AND (? = '' OR t.canvas_id = ?)
+		ORDER BY n.tagged_at DESC
+		LIMIT ?`,
+		tag, canvasID, canvasID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tagged notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []notetags.TaggedNote
+	for rows.Next() {
+		var n notetags.TaggedNote
+		var tags, taggedAt string
+		if err := rows.Scan(&n.CanvasID, &n.NoteID, &n.ContentHash, &n.Excerpt, &tags, &n.Model, &taggedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan tagged note: %w", err)
+		}
+		if err := json.Unmarshal([]byte(tags), &n.Tags); err != nil {
+			return nil, fmt.Errorf("failed to decode note tags: %w", err)
+		}
+		n.TaggedAt = parseSnapshotTime(taggedAt)
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/notetags"
+)
+
+// TestNoteTagsFilter tests that tags replace earlier tags and filter notes by canvas.
+func TestNoteTagsFilter(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	tagged := time.Date(2026, 5, 4, 10, 0, 0, 0, time.UTC)
+	notes := []notetags.TaggedNote{
+		{CanvasID: "canvas-1", NoteID: "note-1", Tags: []string{"budget", "hiring"}, ContentHash: "h1", Excerpt: "Budget", TaggedAt: tagged},
+		{CanvasID: "canvas-1", NoteID: "note-2", Tags: []string{"budget"}, ContentHash: "h2", Excerpt: "Costs", TaggedAt: tagged.Add(time.Minute)},
+		{CanvasID: "canvas-2", NoteID: "note-3", Tags: []string{"budget"}, ContentHash: "h3", Excerpt: "Other", TaggedAt: tagged},
+		// Retagging replaces the tags of note-1
+		{CanvasID: "canvas-1", NoteID: "note-1", Tags: []string{"budget", "roadmap"}, ContentHash: "h4", Excerpt: "Budget v2", Model: "local", TaggedAt: tagged.Add(2 * time.Minute)},
+	}
+	for _, n := range notes {
+		if err := repo.SaveNoteTags(ctx, n); err != nil {
+			t.Fatalf("SaveNoteTags() error = %v", err)
+		}
+	}
+
+	if hash, err := repo.NoteTagHash(ctx, "canvas-1", "note-1"); err != nil || hash != "h4" {
+		t.Errorf("NoteTagHash() = %q, %v, want h4", hash, err)
+	}
+	if hash, err := repo.NoteTagHash(ctx, "canvas-1", "missing"); err != nil || hash != "" {
+		t.Errorf("NoteTagHash(missing) = %q, %v, want empty", hash, err)
+	}
+
+	counts, err := repo.ListNoteTags(ctx, "canvas-1")
+	if err != nil {
+		t.Fatalf("ListNoteTags() error = %v", err)
+	}
+	if len(counts) != 2 || counts[0] != (notetags.TagCount{Tag: "budget", Notes: 2}) || counts[1].Tag != "roadmap" {
+		t.Errorf("ListNoteTags(canvas-1) = %+v", counts)
+	}
+	if counts, _ := repo.ListNoteTags(ctx, ""); len(counts) != 2 || counts[0].Notes != 3 {
+		t.Errorf("ListNoteTags(all) = %+v", counts)
+	}
+
+	found, err := repo.FindTaggedNotes(ctx, "canvas-1", "budget", 10)
+	if err != nil {
+		t.Fatalf("FindTaggedNotes() error = %v", err)
+	}
+	if len(found) != 2 || found[0].NoteID != "note-1" || found[0].Excerpt != "Budget v2" || len(found[0].Tags) != 2 || !found[0].TaggedAt.Equal(tagged.Add(2*time.Minute)) {
+		t.Errorf("FindTaggedNotes() = %+v", found)
+	}
+	if found, _ := repo.FindTaggedNotes(ctx, "", "budget", 1); len(found) != 1 {
+		t.Errorf("FindTaggedNotes(limit 1) = %+v", found)
+	}
+	if found, _ := repo.FindTaggedNotes(ctx, "canvas-1", "hiring", 10); len(found) != 0 {
+		t.Errorf("FindTaggedNotes(removed tag) = %+v", found)
+	}
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"go_backend/promptlib"
+)
+
+// promptTemplatesSchema creates the prompt library table.
+const promptTemplatesSchema = `
+CREATE TABLE IF NOT EXISTS prompt_templates (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    body TEXT NOT NULL,
+    updated_at TEXT NOT NULL
+);
+`
+
+// ensurePromptTemplatesSchema creates the prompt_templates table if needed.
+func (r *Repository) ensurePromptTemplatesSchema() error {
+	if _, err := r.db.Exec(promptTemplatesSchema); err != nil {
+		return fmt.Errorf("failed to create prompt templates table: %w", err)
+	}
+	return nil
+}
+
+// ListPromptTemplates returns every prompt template, by name.
+// Implements promptlib.Storage.
+func (r *Repository) ListPromptTemplates(ctx context.Context) ([]promptlib.Template, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensurePromptTemplatesSchema(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, `SELECT name, description, body, updated_at FROM prompt_templates ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt templates: %w", err)
+	}
+	defer rows.Close()
+
+	var list []promptlib.Template
+	for rows.Next() {
+		var t promptlib.Template
+		var updatedAt string
+		if err := rows.Scan(&t.Name, &t.Description, &t.Body, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan prompt template: %w", err)
+		}
+		t.UpdatedAt = parseSnapshotTime(updatedAt)
+		list = append(list, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating prompt templates: %w", err)
+	}
+	return list, nil
+}
+
+// SavePromptTemplate inserts or replaces a prompt template.
+// Implements promptlib.Storage.
+func (r *Repository) SavePromptTemplate(ctx context.Context, t promptlib.Template) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensurePromptTemplatesSchema(); err != nil {
+		return err
+	}
+
+	if _, err := r.db.DB().ExecContext(ctx, `
+		INSERT OR REPLACE INTO prompt_templates (name, description, body, updated_at)
+		VALUES (?, ?, ?, ?)`,
+		t.Name, t.Description, t.Body, formatSnapshotTime(t.UpdatedAt)); err != nil {
+		return fmt.Errorf("failed to save prompt template: %w", err)
+	}
+	return nil
+}
+
+// DeletePromptTemplate removes a prompt template. Deleting a template that
+// does not exist is not an error.
+// Implements promptlib.Storage.
+func (r *Repository) DeletePromptTemplate(ctx context.Context, name string) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensurePromptTemplatesSchema(); err != nil {
+		return err
+	}
+
+	if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM prompt_templates WHERE name = ?`, name); err != nil {
+		return fmt.Errorf("failed to delete prompt template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/promptlib"
+)
+
+// TestPromptTemplatesRoundTrip tests saving, listing and deleting prompt templates.
+func TestPromptTemplatesRoundTrip(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if list, err := repo.ListPromptTemplates(ctx); len(list) != 0 || err != nil {
+		t.Fatalf("ListPromptTemplates() on empty db = %v, %v", list, err)
+	}
+
+	updated := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	review := promptlib.Template{Name: "weekly-review", Body: "Review week {week}: {text}", UpdatedAt: updated}
+	if err := repo.SavePromptTemplate(ctx, review); err != nil {
+		t.Fatalf("SavePromptTemplate() error = %v", err)
+	}
+	review.Description = "Friday wrap-up"
+	if err := repo.SavePromptTemplate(ctx, review); err != nil {
+		t.Fatalf("SavePromptTemplate() replace error = %v", err)
+	}
+	if err := repo.SavePromptTemplate(ctx, promptlib.Template{Name: "standup", Body: "{text}", UpdatedAt: updated}); err != nil {
+		t.Fatalf("SavePromptTemplate() error = %v", err)
+	}
+
+	list, err := repo.ListPromptTemplates(ctx)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("ListPromptTemplates() = %v, %v", list, err)
+	}
+	if got := list[1]; got.Name != "weekly-review" || got.Description != "Friday wrap-up" ||
+		got.Body != review.Body || !got.UpdatedAt.Equal(updated) {
+		t.Errorf("weekly-review = %+v", got)
+	}
+
+	if err := repo.DeletePromptTemplate(ctx, "standup"); err != nil {
+		t.Fatalf("DeletePromptTemplate() error = %v", err)
+	}
+	if list, _ := repo.ListPromptTemplates(ctx); len(list) != 1 || list[0].Name != "weekly-review" {
+		t.Errorf("after delete = %+v", list)
+	}
+}
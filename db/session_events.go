@@ -0,0 +1,184 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go_backend/sessions"
+)
+
+// sessionEventsSchema creates the table of recorded canvas sessions.
+const sessionEventsSchema = `
+CREATE TABLE IF NOT EXISTS session_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id TEXT NOT NULL,
+    canvas_id TEXT NOT NULL DEFAULT '',
+    recorded_at TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    correlation_id TEXT NOT NULL DEFAULT '',
+    operation TEXT NOT NULL DEFAULT '',
+    widget_id TEXT NOT NULL DEFAULT '',
+    widget_type TEXT NOT NULL DEFAULT '',
+    text TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    x REAL NOT NULL DEFAULT 0,
+    y REAL NOT NULL DEFAULT 0,
+    width REAL NOT NULL DEFAULT 0,
+    height REAL NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_events_session ON session_events(session_id, id);
+CREATE INDEX IF NOT EXISTS idx_session_events_canvas ON session_events(canvas_id, id);
+`
+
+const sessionEventColumns = `id, session_id, canvas_id, recorded_at, kind, correlation_id, operation,
+	widget_id, widget_type, text, model, x, y, width, height`
+
+// ensureSessionSchema creates the session_events table if needed.
+func (r *Repository) ensureSessionSchema() error {
+	if _, err := r.db.Exec(sessionEventsSchema); err != nil {
+		return fmt.Errorf("failed to create session events table: %w", err)
+	}
+	return nil
+}
+
+// InsertSessionEvent stores a recorded trigger or response and returns its
+// ID. Implements sessions.Storage.
+func (r *Repository) InsertSessionEvent(ctx context.Context, e sessions.Event) (int64, error) {
+	if r.db == nil {
+		return 0, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureSessionSchema(); err != nil {
+		return 0, err
+	}
+
+	result, err := r.db.DB().ExecContext(ctx, `INSERT INTO session_events (
+		session_id, canvas_id, recorded_at, kind, correlation_id, operation,
+		widget_id, widget_type, text, model, x, y, width, height
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		e.SessionID, e.CanvasID, e.Time.UTC().Format(time.RFC3339Nano), string(e.Kind),
+		e.CorrelationID, e.Operation, e.WidgetID, e.WidgetType, e.Text, e.Model,
+		e.X, e.Y, e.Width, e.Height)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert session event: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get session event ID: %w", err)
+	}
+	return id, nil
+}
+
+// LastSessionEvent returns the newest event recorded on canvasID, or nil if
+// there is none. Implements sessions.Storage.
+func (r *Repository) LastSessionEvent(ctx context.Context, canvasID string) (*sessions.Event, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureSessionSchema(); err != nil {
+		return nil, err
+	}
+
+	row := r.db.DB().QueryRowContext(ctx,
+		`SELECT `+sessionEventColumns+` FROM session_events WHERE canvas_id = ? ORDER BY id DESC LIMIT 1`,
+		canvasID)
+	e, err := scanSessionEvent(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read last session event: %w", err)
+	}
+	return &e, nil
+}
+
+// ListSessions returns up to limit recorded sessions, newest first. An
+// empty canvasID lists every canvas. Implements sessions.Storage.
+func (r *Repository) ListSessions(ctx context.Context, canvasID string, limit int) ([]sessions.Session, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureSessionSchema(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT session_id, canvas_id, MIN(recorded_at), MAX(recorded_at),
+			SUM(kind = 'trigger'), SUM(kind = 'response')
+		FROM session_events
+		WHERE ? = '' OR canvas_id = ?
+		GROUP BY session_id, canvas_id
+		ORDER BY MIN(id) DESC
+		LIMIT ?`,
+		canvasID, canvasID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	result := []sessions.Session{}
+	for rows.Next() {
+		var s sessions.Session
+		var started, ended string
+		if err := rows.Scan(&s.ID, &s.CanvasID, &started, &ended, &s.Triggers, &s.Responses); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		s.Started = parseSnapshotTime(started)
+		s.Ended = parseSnapshotTime(ended)
+		result = append(result, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sessions: %w", err)
+	}
+	return result, nil
+}
+
+// ListSessionEvents returns the events of sessionID, oldest first.
+// Implements sessions.Storage.
+func (r *Repository) ListSessionEvents(ctx context.Context, sessionID string) ([]sessions.Event, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureSessionSchema(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx,
+		`SELECT `+sessionEventColumns+` FROM session_events WHERE session_id = ? ORDER BY id`,
+		sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []sessions.Event
+	for rows.Next() {
+		e, err := scanSessionEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session event: %w", err)
+		}
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating session events: %w", err)
+	}
+	return events, nil
+}
+
+// scanSessionEvent reads one session_events row selected with
+// sessionEventColumns.
+func scanSessionEvent(row interface{ Scan(...interface{}) error }) (sessions.Event, error) {
+	var e sessions.Event
+	var recordedAt, kind string
+	err := row.Scan(&e.ID, &e.SessionID, &e.CanvasID, &recordedAt, &kind, &e.CorrelationID,
+		&e.Operation, &e.WidgetID, &e.WidgetType, &e.Text, &e.Model,
+		&e.X, &e.Y, &e.Width, &e.Height)
+	if err != nil {
+		return e, err
+	}
+	e.Kind = sessions.Kind(kind)
+	e.Time = parseSnapshotTime(recordedAt)
+	return e, nil
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/sessions"
+)
+
+// TestSessionEvents tests recording, listing and grouping session events.
+func TestSessionEvents(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if last, err := repo.LastSessionEvent(ctx, "canvas-1"); last != nil || err != nil {
+		t.Fatalf("LastSessionEvent() on empty db = %v, %v; want nil", last, err)
+	}
+
+	recorder := sessions.NewRecorder(repo, time.Hour)
+	events := []sessions.Event{
+		{CanvasID: "canvas-1", Kind: sessions.KindTrigger, CorrelationID: "c1", Operation: sessions.OperationNote, Text: "hello?", X: 10, Width: 300},
+		{CanvasID: "canvas-1", Kind: sessions.KindResponse, CorrelationID: "c1", Operation: sessions.OperationNote, Text: "hi", Model: "gpt-4o"},
+		{CanvasID: "canvas-2", Kind: sessions.KindTrigger, CorrelationID: "c2", Operation: "pdf_analysis"},
+	}
+	var sessionID string
+	for _, e := range events {
+		stored, err := recorder.Record(ctx, e)
+		if err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+		if e.CanvasID == "canvas-1" {
+			sessionID = stored.SessionID
+		}
+	}
+
+	got, err := repo.ListSessionEvents(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("ListSessionEvents() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Text != "hello?" || got[0].X != 10 || got[1].Kind != sessions.KindResponse || got[1].Time.IsZero() {
+		t.Errorf("ListSessionEvents() = %+v", got)
+	}
+
+	list, err := repo.ListSessions(ctx, "canvas-1", 10)
+	if err != nil {
+		t.Fatalf("ListSessions() error = %v", err)
+	}
+	if len(list) != 1 || list[0].ID != sessionID || list[0].Triggers != 1 || list[0].Responses != 1 {
+		t.Errorf("ListSessions(canvas-1) = %+v", list)
+	}
+	if all, _ := repo.ListSessions(ctx, "", 10); len(all) != 2 || all[0].CanvasID != "canvas-2" {
+		t.Errorf("ListSessions() = %+v, want both canvases newest first", all)
+	}
+
+	last, err := repo.LastSessionEvent(ctx, "canvas-1")
+	if err != nil || last == nil || last.Text != "hi" {
+		t.Errorf("LastSessionEvent() = %+v, %v", last, err)
+	}
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// sqliteTimeFormat is the layout of CURRENT_TIMESTAMP values (UTC).
+const sqliteTimeFormat = "2006-01-02 15:04:05"
+
+// OperationSummary aggregates the processing history of one operation type.
+type OperationSummary struct {
+	OperationType string  // Type of operation (e.g., "text_generation")
+	Total         int64   // Number of records
+	Success       int64   // Records with status "success"
+	Errors        int64   // Records with status "error"
+	InputTokens   int64   // Sum of input tokens
+	OutputTokens  int64   // Sum of output tokens
+	AvgDurationMS float64 // Average processing duration in milliseconds
+}
+
+// ErrorCount is an error message and the number of times it occurred.
+type ErrorCount struct {
+	Message string
+	Count   int64
+}
+
+// SummarizeProcessingHistory aggregates processing history created in
+// [since, until) by operation type, ordered by volume.
+func (r *Repository) SummarizeProcessingHistory(ctx context.Context, since, until time.Time) ([]OperationSummary, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	query := `
+		SELECT operation_type,
+			   COUNT(*),
+			   COALESCE(SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END), 0),
+			   COALESCE(SUM(CASE WHEN status = 'error' THEN 1 ELSE 0 END), 0),
+			   COALESCE(SUM(input_tokens), 0),
+			   COALESCE(SUM(output_tokens), 0),
+			   COALESCE(AVG(duration_ms), 0)
+		FROM processing_history
+		WHERE created_at >= ? AND created_at < ?
+		GROUP BY operation_type
+		ORDER BY COUNT(*) DESC, operation_type`
+
+	rows, err := r.db.Query(query, sqliteTime(since), sqliteTime(until))
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize processing history: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []OperationSummary
+	for rows.Next() {
+		var s OperationSummary
+		if err := rows.Scan(&s.OperationType, &s.Total, &s.Success, &s.Errors,
+			&s.InputTokens, &s.OutputTokens, &s.AvgDurationMS); err != nil {
+			return nil, fmt.Errorf("failed to scan processing summary row: %w", err)
+		}
+		summaries = append(summaries, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating processing summary rows: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// TopProcessingErrors returns the most frequent error messages of failed
+// processing records created in [since, until).
+func (r *Repository) TopProcessingErrors(ctx context.Context, since, until time.Time, limit int) ([]ErrorCount, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+
+	if limit <= 0 {
+		limit = 5 // Default limit
+	}
+
+	query := `
+		SELECT COALESCE(NULLIF(error_message, ''), '(no message)'), COUNT(*)
+		FROM processing_history
+		WHERE status = 'error' AND created_at >= ? AND created_at < ?
+		GROUP BY 1
+		ORDER BY COUNT(*) DESC, 1
+		LIMIT ?`
+
+	rows, err := r.db.Query(query, sqliteTime(since), sqliteTime(until), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query top errors: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []ErrorCount
+	for rows.Next() {
+		var c ErrorCount
+		if err := rows.Scan(&c.Message, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan error count row: %w", err)
+		}
+		counts = append(counts, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating error count rows: %w", err)
+	}
+
+	return counts, nil
+}
+
+// sqliteTime formats t like CURRENT_TIMESTAMP so it compares with created_at.
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeFormat)
+}
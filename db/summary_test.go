@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestSummarizeProcessingHistory tests per-operation aggregation and top errors.
+func TestSummarizeProcessingHistory(t *testing.T) {
+	repo, database, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	records := []ProcessingRecord{
+		{OperationType: "text_generation", Status: "success", InputTokens: 10, OutputTokens: 20, DurationMS: 100},
+		{OperationType: "text_generation", Status: "success", InputTokens: 30, OutputTokens: 40, DurationMS: 300},
+		{OperationType: "text_generation", Status: "error", ErrorMessage: "model timeout"},
+		{OperationType: "image_generation", Status: "error", ErrorMessage: "model timeout"},
+		{OperationType: "image_generation", Status: "error", ErrorMessage: "out of memory"},
+	}
+	for i, rec := range records {
+		rec.CorrelationID = "corr"
+		rec.CanvasID = "canvas"
+		rec.WidgetID = "widget"
+		if _, err := repo.InsertProcessingHistory(ctx, rec); err != nil {
+			t.Fatalf("insert %d: %v", i, err)
+		}
+	}
+
+	// One record from two days ago falls outside the window
+	old := ProcessingRecord{CorrelationID: "old", CanvasID: "c", WidgetID: "w", OperationType: "pdf_analysis", Status: "error", ErrorMessage: "old"}
+	id, err := repo.InsertProcessingHistory(ctx, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := database.Exec("UPDATE processing_history SET created_at = ? WHERE id = ?",
+		sqliteTime(time.Now().Add(-48*time.Hour)), id); err != nil {
+		t.Fatal(err)
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	until := time.Now().Add(time.Minute)
+
+	t.Run("summary by operation", func(t *testing.T) {
+		summaries, err := repo.SummarizeProcessingHistory(ctx, since, until)
+		if err != nil {
+			t.Fatalf("SummarizeProcessingHistory() error = %v", err)
+		}
+		if len(summaries) != 2 {
+			t.Fatalf("len(summaries) = %d, want 2", len(summaries))
+		}
+
+		text := summaries[0]
+		if text.OperationType != "text_generation" || text.Total != 3 || text.Success != 2 || text.Errors != 1 {
+			t.Errorf("text summary = %+v", text)
+		}
+		if text.InputTokens != 40 || text.OutputTokens != 60 {
+			t.Errorf("text tokens = %d/%d, want 40/60", text.InputTokens, text.OutputTokens)
+		}
+		if summaries[1].OperationType != "image_generation" || summaries[1].Errors != 2 {
+			t.Errorf("image summary = %+v", summaries[1])
+		}
+	})
+
+	t.Run("top errors", func(t *testing.T) {
+		errs, err := repo.TopProcessingErrors(ctx, since, until, 5)
+		if err != nil {
+			t.Fatalf("TopProcessingErrors() error = %v", err)
+		}
+		if len(errs) != 2 {
+			t.Fatalf("len(errors) = %d, want 2", len(errs))
+		}
+		if errs[0].Message != "model timeout" || errs[0].Count != 2 {
+			t.Errorf("top error = %+v, want model timeout x2", errs[0])
+		}
+	})
+
+	t.Run("empty window", func(t *testing.T) {
+		summaries, err := repo.SummarizeProcessingHistory(ctx, until, until.Add(time.Hour))
+		if err != nil || len(summaries) != 0 {
+			t.Errorf("SummarizeProcessingHistory() = %v, %v; want empty", summaries, err)
+		}
+	})
+}
+
+// TestSummaryNilDatabase tests the summary methods without a connection.
+func TestSummaryNilDatabase(t *testing.T) {
+	repo := &Repository{}
+	if _, err := repo.SummarizeProcessingHistory(context.Background(), time.Now(), time.Now()); err == nil {
+		t.Error("SummarizeProcessingHistory() with nil db should fail")
+	}
+	if _, err := repo.TopProcessingErrors(context.Background(), time.Now(), time.Now(), 5); err == nil {
+		t.Error("TopProcessingErrors() with nil db should fail")
+	}
+}
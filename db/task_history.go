@@ -0,0 +1,164 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Sort orders for QueryProcessingHistory.
+const (
+	SortByCreatedAt = "created_at"
+	SortByDuration  = "duration"
+)
+
+// ProcessingCursor marks the last record of a page. The next page starts
+// after it in the query's sort order.
+type ProcessingCursor struct {
+	ID         int64     `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	DurationMS int       `json:"duration_ms"`
+}
+
+// CursorFor returns the cursor that continues after rec.
+func CursorFor(rec ProcessingRecord) ProcessingCursor {
+	return ProcessingCursor{ID: rec.ID, CreatedAt: rec.CreatedAt, DurationMS: rec.DurationMS}
+}
+
+// ProcessingQuery filters, sorts and pages processing history. Empty
+// fields do not filter.
+type ProcessingQuery struct {
+	CanvasID      string
+	WidgetID      string
+	OperationType string
+	Status        string
+	// Since and Until bound created_at to [Since, Until)
+	Since time.Time
+	Until time.Time
+
+	// SortBy is SortByCreatedAt (default) or SortByDuration; records are
+	// newest or longest first unless Ascending is set. Ties are broken by ID.
+	SortBy    string
+	Ascending bool
+
+	// After continues from the last record of the previous page
+	After *ProcessingCursor
+	Limit int
+}
+
+// QueryProcessingHistory returns a page of processing history matching q.
+// Paging uses the sort key and ID of the last record (keyset pagination),
+// so pages stay consistent while new records are written.
+func (r *Repository) QueryProcessingHistory(ctx context.Context, q ProcessingQuery) ([]ProcessingRecord, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if q.Limit <= 0 {
+		q.Limit = 10
+	}
+
+	var column string
+	switch q.SortBy {
+	case "", SortByCreatedAt:
+		column = "created_at"
+	case SortByDuration:
+		column = "COALESCE(duration_ms, 0)"
+	default:
+		return nil, fmt.Errorf("unknown sort %q", q.SortBy)
+	}
+	order, cmp := "DESC", "<"
+	if q.Ascending {
+		order, cmp = "ASC", ">"
+	}
+
+	var where []string
+	var args []interface{}
+	for _, f := range []struct{ column, value string }{
+		{"canvas_id", q.CanvasID},
+		{"widget_id", q.WidgetID},
+		{"operation_type", q.OperationType},
+		{"status", q.Status},
+	} {
+		if f.value != "" {
+			where = append(where, f.column+" = ?")
+			args = append(args, f.value)
+		}
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, sqliteTime(q.Since))
+	}
+	if !q.Until.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, sqliteTime(q.Until))
+	}
+	if q.After != nil {
+		var key interface{} = sqliteTime(q.After.CreatedAt)
+		if q.SortBy == SortByDuration {
+			key = q.After.DurationMS
+		}
+		where = append(where, fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, cmp, column, cmp))
+		args = append(args, key, key, q.After.ID)
+	}
+
+	query := `
+		SELECT id, correlation_id, canvas_id, widget_id, operation_type,
+			   COALESCE(prompt, ''), COALESCE(response, ''), COALESCE(model_name, ''),
+			   COALESCE(input_tokens, 0), COALESCE(output_tokens, 0),
+			   COALESCE(duration_ms, 0), status, COALESCE(error_message, ''),
+			   created_at
+		FROM processing_history`
+	if len(where) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(where, " AND ")
+	}
+	query += fmt.Sprintf("\n\t\tORDER BY %s %s, id %s\n\t\tLIMIT ?", column, order, order)
+	args = append(args, q.Limit)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query processing history: %w", err)
+	}
+	defer rows.Close()
+
+	var records []ProcessingRecord
+	for rows.Next() {
+		var rec ProcessingRecord
+		var createdAt string
+		if err := rows.Scan(
+			&rec.ID,
+			&rec.CorrelationID,
+			&rec.CanvasID,
+			&rec.WidgetID,
+			&rec.OperationType,
+			&rec.Prompt,
+			&rec.Response,
+			&rec.ModelName,
+			&rec.InputTokens,
+			&rec.OutputTokens,
+			&rec.DurationMS,
+			&rec.Status,
+			&rec.ErrorMessage,
+			&createdAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan processing history row: %w", err)
+		}
+		rec.CreatedAt = parseSQLiteTime(createdAt)
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating processing history rows: %w", err)
+	}
+	return records, nil
+}
+
+// parseSQLiteTime parses a CURRENT_TIMESTAMP value, which the driver may
+// return in RFC 3339 form.
+func parseSQLiteTime(s string) time.Time {
+	for _, layout := range []string{sqliteTimeFormat, time.RFC3339Nano} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestQueryProcessingHistory tests filtering, sorting and keyset paging.
+func TestQueryProcessingHistory(t *testing.T) {
+	repo, database, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	// Records 1-6 alternate canvases; 3 and 4 share a timestamp
+	for i, rec := range []ProcessingRecord{
+		{CanvasID: "a", OperationType: "text_generation", Status: "success", DurationMS: 300},
+		{CanvasID: "b", WidgetID: "img", OperationType: "image_generation", Status: "error", DurationMS: 900},
+		{CanvasID: "a", OperationType: "text_generation", Status: "success", DurationMS: 100},
+		{CanvasID: "b", OperationType: "text_generation", Status: "success", DurationMS: 100},
+		{CanvasID: "a", OperationType: "pdf_analysis", Status: "error", DurationMS: 500},
+		{CanvasID: "b", OperationType: "text_generation", Status: "success", DurationMS: 200},
+	} {
+		rec.CorrelationID = fmt.Sprintf("c%d", i+1)
+		if rec.WidgetID == "" {
+			rec.WidgetID = "w"
+		}
+		id, err := repo.InsertProcessingHistory(ctx, rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		minutes := i
+		if i >= 3 {
+			minutes = i - 1
+		}
+		if _, err := database.Exec("UPDATE processing_history SET created_at = ? WHERE id = ?",
+			sqliteTime(base.Add(time.Duration(minutes)*time.Minute)), id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := func(records []ProcessingRecord) string {
+		s := ""
+		for _, r := range records {
+			s += fmt.Sprint(r.ID)
+		}
+		return s
+	}
+
+	t.Run("pages newest first", func(t *testing.T) {
+		var pages []string
+		var after *ProcessingCursor
+		for {
+			page, err := repo.QueryProcessingHistory(ctx, ProcessingQuery{Limit: 2, After: after})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page) == 0 {
+				break
+			}
+			pages = append(pages, ids(page))
+			cursor := CursorFor(page[len(page)-1])
+			after = &cursor
+		}
+		if fmt.Sprint(pages) != "[65 43 21]" {
+			t.Errorf("pages = %v, want [65 43 21]", pages)
+		}
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		for name, tt := range map[string]struct {
+			q    ProcessingQuery
+			want string
+		}{
+			"canvas":    {ProcessingQuery{CanvasID: "a"}, "531"},
+			"widget":    {ProcessingQuery{WidgetID: "img"}, "2"},
+			"type":      {ProcessingQuery{OperationType: "text_generation", Status: "success"}, "6431"},
+			"status":    {ProcessingQuery{Status: "error"}, "52"},
+			"range":     {ProcessingQuery{Since: base.Add(time.Minute), Until: base.Add(3 * time.Minute)}, "432"},
+			"ascending": {ProcessingQuery{CanvasID: "b", Ascending: true}, "246"},
+		} {
+			got, err := repo.QueryProcessingHistory(ctx, tt.q)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if ids(got) != tt.want {
+				t.Errorf("%s: ids = %s, want %s", name, ids(got), tt.want)
+			}
+		}
+	})
+
+	t.Run("pages by duration", func(t *testing.T) {
+		q := ProcessingQuery{SortBy: SortByDuration, Limit: 4}
+		first, err := repo.QueryProcessingHistory(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cursor := CursorFor(first[len(first)-1])
+		q.After = &cursor
+		rest, err := repo.QueryProcessingHistory(ctx, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids(first)+"|"+ids(rest) != "2516|43" {
+			t.Errorf("pages = %s|%s, want 2516|43", ids(first), ids(rest))
+		}
+		if first[0].CreatedAt.IsZero() {
+			t.Error("created_at not parsed")
+		}
+	})
+
+	if _, err := repo.QueryProcessingHistory(ctx, ProcessingQuery{SortBy: "prompt"}); err == nil {
+		t.Error("unknown sort accepted")
+	}
+}
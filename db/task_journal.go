@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go_backend/recovery"
+)
+
+// taskJournalSchema creates the tables of the task journal: the tasks
+// running now, and the interrupted runs of triggers the recovery pass
+// requeued.
+const taskJournalSchema = `
+CREATE TABLE IF NOT EXISTS task_journal (
+    task_id TEXT PRIMARY KEY,
+    task_type TEXT NOT NULL,
+    canvas_id TEXT NOT NULL,
+    widget_id TEXT NOT NULL,
+    processing_note_id TEXT NOT NULL DEFAULT '',
+    started_at TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS task_recovery (
+    widget_id TEXT PRIMARY KEY,
+    attempts INTEGER NOT NULL
+);
+`
+
+// ensureTaskJournalSchema creates the task journal tables if needed.
+func (r *Repository) ensureTaskJournalSchema() error {
+	if _, err := r.db.Exec(taskJournalSchema); err != nil {
+		return fmt.Errorf("failed to create task journal tables: %w", err)
+	}
+	return nil
+}
+
+// StartJournalTask records a task as running.
+// Implements recovery.Storage.
+func (r *Repository) StartJournalTask(ctx context.Context, entry recovery.Entry) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureTaskJournalSchema(); err != nil {
+		return err
+	}
+
+	if _, err := r.db.DB().ExecContext(ctx, `
+		INSERT OR REPLACE INTO task_journal (task_id, task_type, canvas_id, widget_id, processing_note_id, started_at)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		entry.TaskID, entry.TaskType, entry.CanvasID, entry.WidgetID, entry.ProcessingNoteID, sqliteTime(entry.StartedAt)); err != nil {
+		return fmt.Errorf("failed to record running task: %w", err)
+	}
+	return nil
+}
+
+// SetJournalProcessingNote records the processing note of a running task.
+// Implements recovery.Storage.
+func (r *Repository) SetJournalProcessingNote(ctx context.Context, taskID, noteID string) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureTaskJournalSchema(); err != nil {
+		return err
+	}
+
+	if _, err := r.db.DB().ExecContext(ctx,
+		`UPDATE task_journal SET processing_note_id = ? WHERE task_id = ?`, noteID, taskID); err != nil {
+		return fmt.Errorf("failed to record processing note: %w", err)
+	}
+	return nil
+}
+
+// FinishJournalTask removes a finished task and the interrupted runs
+// counted for its trigger.
+// Implements recovery.Storage.
+func (r *Repository) FinishJournalTask(ctx context.Context, taskID, widgetID string) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureTaskJournalSchema(); err != nil {
+		return err
+	}
+
+	if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM task_journal WHERE task_id = ?`, taskID); err != nil {
+		return fmt.Errorf("failed to remove finished task: %w", err)
+	}
+	if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM task_recovery WHERE widget_id = ?`, widgetID); err != nil {
+		return fmt.Errorf("failed to clear interrupted runs: %w", err)
+	}
+	return nil
+}
+
+// ListJournalTasks returns the tasks recorded as running, oldest first,
+// with the interrupted runs of their triggers in Attempts.
+// Implements recovery.Storage.
+func (r *Repository) ListJournalTasks(ctx context.Context) ([]recovery.Entry, error) {
+	if r.db == nil {
+		return nil, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureTaskJournalSchema(); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, `
+		SELECT j.task_id, j.task_type, j.canvas_id, j.widget_id, j.processing_note_id, j.started_at,
+		       COALESCE(rc.attempts, 0)
+		FROM task_journal j
+		LEFT JOIN task_recovery rc ON rc.widget_id = j.widget_id
+		ORDER BY j.started_at, j.task_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query task journal: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []recovery.Entry
+	for rows.Next() {
+		var e recovery.Entry
+		var startedAt string
+		if err := rows.Scan(&e.TaskID, &e.TaskType, &e.CanvasID, &e.WidgetID, &e.ProcessingNoteID, &startedAt, &e.Attempts); err != nil {
+			return nil, fmt.Errorf("failed to scan task journal: %w", err)
+		}
+		e.StartedAt, _ = time.Parse(sqliteTimeFormat, startedAt)
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating task journal: %w", err)
+	}
+	return entries, nil
+}
+
+// ResolveJournalTask removes an interrupted task. A requeued task's
+// trigger keeps entry.Attempts interrupted runs; otherwise the count is
+// cleared.
+// Implements recovery.Storage.
+func (r *Repository) ResolveJournalTask(ctx context.Context, entry recovery.Entry, requeued bool) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureTaskJournalSchema(); err != nil {
+		return err
+	}
+
+	if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM task_journal WHERE task_id = ?`, entry.TaskID); err != nil {
+		return fmt.Errorf("failed to remove interrupted task: %w", err)
+	}
+	if !requeued {
+		if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM task_recovery WHERE widget_id = ?`, entry.WidgetID); err != nil {
+			return fmt.Errorf("failed to clear interrupted runs: %w", err)
+		}
+		return nil
+	}
+	if _, err := r.db.DB().ExecContext(ctx, `
+		INSERT OR REPLACE INTO task_recovery (widget_id, attempts) VALUES (?, ?)`,
+		entry.WidgetID, entry.Attempts); err != nil {
+		return fmt.Errorf("failed to count interrupted run: %w", err)
+	}
+	return nil
+}
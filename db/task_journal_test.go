@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/recovery"
+)
+
+// TestTaskJournal tests recording, finishing and resolving journal tasks.
+func TestTaskJournal(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	if list, err := repo.ListJournalTasks(ctx); len(list) != 0 || err != nil {
+		t.Fatalf("ListJournalTasks() on empty db = %v, %v", list, err)
+	}
+
+	start := time.Now().Add(-time.Minute)
+	for _, e := range []recovery.Entry{
+		{TaskID: "note-1", TaskType: "note", CanvasID: "c", WidgetID: "note-1", StartedAt: start},
+		{TaskID: "corr-2", TaskType: "pdf", CanvasID: "c", WidgetID: "icon-2", StartedAt: start.Add(time.Second)},
+		{TaskID: "corr-3", TaskType: "canvas", CanvasID: "c", WidgetID: "icon-3", StartedAt: start.Add(2 * time.Second)},
+	} {
+		if err := repo.StartJournalTask(ctx, e); err != nil {
+			t.Fatalf("StartJournalTask(%s) error = %v", e.TaskID, err)
+		}
+	}
+	if err := repo.SetJournalProcessingNote(ctx, "corr-2", "processing-2"); err != nil {
+		t.Fatalf("SetJournalProcessingNote() error = %v", err)
+	}
+	if err := repo.FinishJournalTask(ctx, "corr-3", "icon-3"); err != nil {
+		t.Fatalf("FinishJournalTask() error = %v", err)
+	}
+
+	list, err := repo.ListJournalTasks(ctx)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("ListJournalTasks() = %v, %v", list, err)
+	}
+	if got := list[1]; got.TaskID != "corr-2" || got.ProcessingNoteID != "processing-2" || got.Attempts != 0 ||
+		got.StartedAt.Unix() != start.Add(time.Second).Unix() {
+		t.Errorf("corr-2 entry = %+v", got)
+	}
+
+	// A requeued trigger keeps its count for its next run
+	requeued := list[0]
+	requeued.Attempts = 1
+	if err := repo.ResolveJournalTask(ctx, requeued, true); err != nil {
+		t.Fatalf("ResolveJournalTask(requeued) error = %v", err)
+	}
+	if err := repo.ResolveJournalTask(ctx, list[1], false); err != nil {
+		t.Fatalf("ResolveJournalTask(failed) error = %v", err)
+	}
+	if list, err := repo.ListJournalTasks(ctx); len(list) != 0 || err != nil {
+		t.Fatalf("ListJournalTasks() after resolving = %v, %v", list, err)
+	}
+
+	if err := repo.StartJournalTask(ctx, recovery.Entry{TaskID: "note-1", TaskType: "note", CanvasID: "c", WidgetID: "note-1", StartedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	list, err = repo.ListJournalTasks(ctx)
+	if err != nil || len(list) != 1 || list[0].Attempts != 1 {
+		t.Fatalf("ListJournalTasks() after rerun = %+v, %v; want 1 earlier attempt", list, err)
+	}
+
+	// Finishing the rerun clears the count
+	if err := repo.FinishJournalTask(ctx, "note-1", "note-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.StartJournalTask(ctx, recovery.Entry{TaskID: "note-1", TaskType: "note", CanvasID: "c", WidgetID: "note-1", StartedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := repo.ListJournalTasks(ctx); len(list) != 1 || list[0].Attempts != 0 {
+		t.Errorf("attempts after a finished run = %+v, want 0", list)
+	}
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"go_backend/voicenote"
+)
+
+// voiceNotesSchema creates the table of transcribed audio widgets. A row
+// is added when transcription starts, so each recording is transcribed
+// once, and completed with the transcript note when it ends.
+const voiceNotesSchema = `
+CREATE TABLE IF NOT EXISTS voice_notes (
+    canvas_id TEXT NOT NULL,
+    widget_id TEXT NOT NULL,
+    note_id TEXT NOT NULL DEFAULT '',
+    title TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    language TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL,
+    PRIMARY KEY (canvas_id, widget_id)
+);
+`
+
+// ensureVoiceNotesSchema creates the voice_notes table if needed.
+func (r *Repository) ensureVoiceNotesSchema() error {
+	if _, err := r.db.Exec(voiceNotesSchema); err != nil {
+		return fmt.Errorf("failed to create voice notes table: %w", err)
+	}
+	return nil
+}
+
+// ClaimVoiceNote records n unless its widget is already recorded, and
+// reports whether it did.
+// Implements voicenote.Storage.
+func (r *Repository) ClaimVoiceNote(ctx context.Context, n voicenote.VoiceNote) (bool, error) {
+	if r.db == nil {
+		return false, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureVoiceNotesSchema(); err != nil {
+		return false, err
+	}
+	result, err := r.db.DB().ExecContext(ctx, `
+		INSERT OR IGNORE INTO voice_notes (canvas_id, widget_id, note_id, title, duration_ms, language, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		n.CanvasID, n.WidgetID, n.NoteID, n.Title, n.Duration.Milliseconds(), n.Language, formatSnapshotTime(n.CreatedAt))
+	if err != nil {
+		return false, fmt.Errorf("failed to claim voice note: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim voice note: %w", err)
+	}
+	return rows == 1, nil
+}
+
+// HasVoiceNote reports whether a widget is recorded.
+// Implements voicenote.Storage.
+func (r *Repository) HasVoiceNote(ctx context.Context, canvasID, widgetID string) (bool, error) {
+	if r.db == nil {
+		return false, fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureVoiceNotesSchema(); err != nil {
+		return false, err
+	}
+	var n int
+	if err := r.db.DB().QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM voice_notes WHERE canvas_id = ? AND widget_id = ?`,
+		canvasID, widgetID).Scan(&n); err != nil {
+		return false, fmt.Errorf("failed to query voice note: %w", err)
+	}
+	return n > 0, nil
+}
+
+// SaveVoiceNote updates the record of a claimed widget with its
+// transcript note.
+// Implements voicenote.Storage.
+func (r *Repository) SaveVoiceNote(ctx context.Context, n voicenote.VoiceNote) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureVoiceNotesSchema(); err != nil {
+		return err
+	}
+	if _, err := r.db.DB().ExecContext(ctx, `
+		INSERT INTO voice_notes (canvas_id, widget_id, note_id, title, duration_ms, language, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(canvas_id, widget_id) DO UPDATE SET
+			note_id = excluded.note_id,
+			title = excluded.title,
+			duration_ms = excluded.duration_ms,
+			language = excluded.language`,
+		n.CanvasID, n.WidgetID, n.NoteID, n.Title, n.Duration.Milliseconds(), n.Language, formatSnapshotTime(n.CreatedAt)); err != nil {
+		return fmt.Errorf("failed to save voice note: %w", err)
+	}
+	return nil
+}
+
+// DeleteVoiceNote removes the record of a widget.
+// Implements voicenote.Storage.
+func (r *Repository) DeleteVoiceNote(ctx context.Context, canvasID, widgetID string) error {
+	if r.db == nil {
+		return fmt.Errorf("database connection is nil")
+	}
+	if err := r.ensureVoiceNotesSchema(); err != nil {
+		return err
+	}
+	if _, err := r.db.DB().ExecContext(ctx, `DELETE FROM voice_notes WHERE canvas_id = ? AND widget_id = ?`, canvasID, widgetID); err != nil {
+		return fmt.Errorf("failed to delete voice note: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/voicenote"
+)
+
+// TestVoiceNotesClaimOnce tests that a recording is claimed once until it is released.
+func TestVoiceNotesClaimOnce(t *testing.T) {
+	repo, _, cleanup := setupTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	n := voicenote.VoiceNote{CanvasID: "canvas-1", WidgetID: "audio-1", Title: "standup.m4a", CreatedAt: time.Now()}
+	if ok, err := repo.ClaimVoiceNote(ctx, n); err != nil || !ok {
+		t.Fatalf("ClaimVoiceNote() = %v, %v, want true", ok, err)
+	}
+	if ok, err := repo.ClaimVoiceNote(ctx, n); err != nil || ok {
+		t.Fatalf("second ClaimVoiceNote() = %v, %v, want false", ok, err)
+	}
+	if ok, err := repo.HasVoiceNote(ctx, "canvas-1", "audio-1"); err != nil || !ok {
+		t.Errorf("HasVoiceNote() = %v, %v, want true", ok, err)
+	}
+	// Other canvases are separate
+	other := n
+	other.CanvasID = "canvas-2"
+	if ok, _ := repo.ClaimVoiceNote(ctx, other); !ok {
+		t.Error("ClaimVoiceNote() on another canvas = false")
+	}
+
+	n.NoteID, n.Duration, n.Language = "note-1", 90*time.Second, "en"
+	if err := repo.SaveVoiceNote(ctx, n); err != nil {
+		t.Fatalf("SaveVoiceNote() error = %v", err)
+	}
+	var noteID string
+	var durationMS int64
+	if err := repo.db.DB().QueryRowContext(ctx, `SELECT note_id, duration_ms FROM voice_notes WHERE canvas_id = ? AND widget_id = ?`,
+		"canvas-1", "audio-1").Scan(&noteID, &durationMS); err != nil || noteID != "note-1" || durationMS != 90000 {
+		t.Errorf("saved voice note = %q, %d, %v", noteID, durationMS, err)
+	}
+
+	if err := repo.DeleteVoiceNote(ctx, "canvas-1", "audio-1"); err != nil {
+		t.Fatalf("DeleteVoiceNote() error = %v", err)
+	}
+	if ok, _ := repo.HasVoiceNote(ctx, "canvas-1", "audio-1"); ok {
+		t.Error("HasVoiceNote() after delete = true")
+	}
+	if ok, err := repo.ClaimVoiceNote(ctx, n); err != nil || !ok {
+		t.Errorf("ClaimVoiceNote() after delete = %v, %v, want true", ok, err)
+	}
+}
@@ -0,0 +1,109 @@
+// Package deterministic provides the TEST_DETERMINISTIC mode, in which the
+// values that otherwise differ between runs are fixed: image and text
+// sampling seeds, correlation IDs and timestamps. With the same models,
+// canvas and triggers, two runs produce the same notes, images and
+// processing records, so handler output can be compared with golden files
+// and a bug report can be replayed.
+//
+// The package holds no global state; the server passes Clock and IDs to
+// the subsystems that take a clock.Clock or an ID source.
+package deterministic
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go_backend/core"
+	"go_backend/core/clock"
+)
+
+// Defaults.
+const (
+	DefaultSeed  = 42
+	DefaultEpoch = "2024-01-01T00:00:00Z"
+	DefaultStep  = time.Second
+)
+
+// Config holds the deterministic mode settings.
+type Config struct {
+	// Enabled turns the mode on
+	Enabled bool
+	// Seed is used for every image and text generation
+	Seed int64
+	// Epoch is the first time the clock reports
+	Epoch time.Time
+	// Step is how far the clock advances with each reading
+	Step time.Duration
+}
+
+// ConfigFromEnv reads TEST_DETERMINISTIC, TEST_DETERMINISTIC_SEED and
+// TEST_DETERMINISTIC_EPOCH (RFC 3339). An invalid epoch is returned as an
+// error together with a config using DefaultEpoch.
+func ConfigFromEnv() (Config, error) {
+	epoch, _ := time.Parse(time.RFC3339, DefaultEpoch)
+	cfg := Config{
+		Enabled: core.ParseBoolEnv("TEST_DETERMINISTIC", false),
+		Seed:    core.ParseInt64Env("TEST_DETERMINISTIC_SEED", DefaultSeed),
+		Epoch:   epoch,
+		Step:    DefaultStep,
+	}
+	if cfg.Seed < 0 {
+		cfg.Seed = DefaultSeed
+	}
+	if value := strings.TrimSpace(core.GetEnvOrDefault("TEST_DETERMINISTIC_EPOCH", "")); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return cfg, fmt.Errorf("deterministic: invalid TEST_DETERMINISTIC_EPOCH %q (use RFC 3339, e.g. %s)", value, DefaultEpoch)
+		}
+		cfg.Epoch = parsed.UTC()
+	}
+	return cfg, nil
+}
+
+// SamplerSeed returns Seed as a llama.cpp sampler seed.
+func (c Config) SamplerSeed() uint32 {
+	return uint32(c.Seed)
+}
+
+// Clock returns a clock that starts at Epoch and advances by Step with
+// every reading, so timestamps and the durations between them repeat
+// from run to run. Timers, tickers and sleeps run on the real clock, so
+// schedulers keep working. It is safe for concurrent use.
+func (c Config) Clock() clock.Clock {
+	step := c.Step
+	if step <= 0 {
+		step = DefaultStep
+	}
+	return &stepClock{Clock: clock.Real(), epoch: c.Epoch, step: step}
+}
+
+// stepClock is the clock of Config.Clock.
+type stepClock struct {
+	// Clock schedules timers, tickers and sleeps
+	clock.Clock
+	epoch    time.Time
+	step     time.Duration
+	readings atomic.Int64
+}
+
+// Now returns the next timestamp.
+func (c *stepClock) Now() time.Time {
+	return c.epoch.Add(time.Duration(c.readings.Add(1)-1) * c.step)
+}
+
+// Since returns the time from t to the next timestamp.
+func (c *stepClock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// IDs returns a source of 8-character IDs counting up from 00000001, in
+// the format of handlers.GenerateCorrelationID. It is safe for concurrent
+// use.
+func IDs() func() string {
+	var n atomic.Uint32
+	return func() string {
+		return fmt.Sprintf("%08x", n.Add(1))
+	}
+}
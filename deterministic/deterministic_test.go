@@ -0,0 +1,72 @@
+package deterministic
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	cfg, err := ConfigFromEnv()
+	if err != nil || cfg.Enabled || cfg.Seed != DefaultSeed || cfg.Epoch.Format(time.RFC3339) != DefaultEpoch {
+		t.Errorf("ConfigFromEnv() = %+v, %v", cfg, err)
+	}
+
+	t.Setenv("TEST_DETERMINISTIC", "true")
+	t.Setenv("TEST_DETERMINISTIC_SEED", "7")
+	t.Setenv("TEST_DETERMINISTIC_EPOCH", "2025-03-01T09:30:00+01:00")
+	cfg, err = ConfigFromEnv()
+	if err != nil || !cfg.Enabled || cfg.Seed != 7 || cfg.SamplerSeed() != 7 {
+		t.Errorf("ConfigFromEnv() = %+v, %v", cfg, err)
+	}
+	if want := time.Date(2025, 3, 1, 8, 30, 0, 0, time.UTC); !cfg.Epoch.Equal(want) || cfg.Epoch.Location() != time.UTC {
+		t.Errorf("Epoch = %v, want %v", cfg.Epoch, want)
+	}
+
+	t.Setenv("TEST_DETERMINISTIC_EPOCH", "yesterday")
+	if cfg, err := ConfigFromEnv(); err == nil || cfg.Epoch.Format(time.RFC3339) != DefaultEpoch {
+		t.Errorf("ConfigFromEnv() = %+v, %v, want error and default epoch", cfg, err)
+	}
+}
+
+func TestClock(t *testing.T) {
+	cfg, _ := ConfigFromEnv()
+	c := cfg.Clock()
+	first, second := c.Now(), c.Now()
+	if !first.Equal(cfg.Epoch) || second.Sub(first) != DefaultStep {
+		t.Errorf("clock read %v then %v", first, second)
+	}
+	if elapsed := c.Since(first); elapsed != 2*DefaultStep {
+		t.Errorf("Since() = %v, want two steps", elapsed)
+	}
+
+	// Another clock starts over
+	if again := cfg.Clock().Now(); !again.Equal(cfg.Epoch) {
+		t.Errorf("new clock starts at %v", again)
+	}
+}
+
+func TestIDs(t *testing.T) {
+	next := IDs()
+	if a, b := next(), next(); a != "00000001" || b != "00000002" {
+		t.Errorf("IDs() = %q, %q", a, b)
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := next()
+			mu.Lock()
+			seen[id] = true
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 50 {
+		t.Errorf("%d distinct IDs from 50 concurrent calls", len(seen))
+	}
+}
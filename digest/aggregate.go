@@ -0,0 +1,84 @@
+package digest
+
+import (
+	"sort"
+
+	"go_backend/db"
+	"go_backend/metrics"
+)
+
+// gpuAccumulator collects GPU samples for the digest period.
+type gpuAccumulator struct {
+	samples     int
+	utilSum     float64
+	peakUtil    float64
+	peakTemp    float64
+	peakMemPct  float64
+	tempSamples int
+}
+
+// add records one sample.
+func (a *gpuAccumulator) add(m metrics.GPUMetrics) {
+	a.samples++
+	a.utilSum += m.Utilization
+	if m.Utilization > a.peakUtil {
+		a.peakUtil = m.Utilization
+	}
+	if m.Temperature > 0 {
+		a.tempSamples++
+		if m.Temperature > a.peakTemp {
+			a.peakTemp = m.Temperature
+		}
+	}
+	if m.MemoryTotal > 0 {
+		if pct := float64(m.MemoryUsed) * 100 / float64(m.MemoryTotal); pct > a.peakMemPct {
+			a.peakMemPct = pct
+		}
+	}
+}
+
+// summary returns the collected statistics.
+func (a *gpuAccumulator) summary() GPUSummary {
+	s := GPUSummary{
+		Samples:            a.samples,
+		PeakUtilization:    a.peakUtil,
+		PeakTemperature:    a.peakTemp,
+		PeakMemoryPercent:  a.peakMemPct,
+		HasTemperatureData: a.tempSamples > 0,
+	}
+	if a.samples > 0 {
+		s.AvgUtilization = a.utilSum / float64(a.samples)
+	}
+	return s
+}
+
+// sortOperations orders summaries by volume, then name.
+func sortOperations(ops []db.OperationSummary) {
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Total != ops[j].Total {
+			return ops[i].Total > ops[j].Total
+		}
+		return ops[i].OperationType < ops[j].OperationType
+	})
+}
+
+// topErrors returns the limit most frequent messages.
+func topErrors(counts map[string]int64, limit int) []db.ErrorCount {
+	out := make([]db.ErrorCount, 0, len(counts))
+	for msg, n := range counts {
+		if msg == "" {
+			msg = "(no message)"
+		}
+		out = append(out, db.ErrorCount{Message: msg, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Message < out[j].Message
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
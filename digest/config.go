@@ -0,0 +1,83 @@
+package digest
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go_backend/core"
+	"go_backend/core/clock"
+
+	"go.uber.org/zap"
+)
+
+// DefaultSendAt is the local time of day the digest is sent.
+const DefaultSendAt = "08:00"
+
+// Config configures the Digest.
+type Config struct {
+	// Recipients of the digest email; empty disables the digest
+	Recipients []string
+
+	// SMTP server used to send the digest
+	SMTP core.SMTPConfig
+
+	// Hour and Minute of the local time the digest is sent (default: 08:00)
+	Hour   int
+	Minute int
+
+	// CostPer1KTokens estimates the cost of the reported tokens
+	// (0 omits the cost line)
+	CostPer1KTokens float64
+
+	// Logger for diagnostic output (optional)
+	Logger *zap.Logger
+
+	// Clock schedules the sends (optional, default: the system clock)
+	Clock clock.Clock
+}
+
+// Enabled reports whether the digest has recipients and a mail server.
+func (c Config) Enabled() bool {
+	return len(c.Recipients) > 0 && c.SMTP.Enabled()
+}
+
+// ConfigFromEnv loads the digest configuration from environment variables:
+//   - DIGEST_EMAIL: comma-separated digest recipients
+//   - DIGEST_TIME: local time of day to send, HH:MM (default: 08:00)
+//   - DIGEST_COST_PER_1K_TOKENS: price used to estimate cost (default: 0, omitted)
+//   - SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		SMTP:            core.SMTPConfigFromEnv(),
+		CostPer1KTokens: core.ParseFloat64Env("DIGEST_COST_PER_1K_TOKENS", 0),
+	}
+	for _, part := range strings.Split(os.Getenv("DIGEST_EMAIL"), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			cfg.Recipients = append(cfg.Recipients, part)
+		}
+	}
+
+	sendAt := strings.TrimSpace(os.Getenv("DIGEST_TIME"))
+	if sendAt == "" {
+		sendAt = DefaultSendAt
+	}
+	hour, minute, err := parseTimeOfDay(sendAt)
+	if err != nil {
+		return cfg, fmt.Errorf("DIGEST_TIME: %w", err)
+	}
+	cfg.Hour, cfg.Minute = hour, minute
+	return cfg, nil
+}
+
+// parseTimeOfDay parses "HH:MM" in 24-hour format.
+func parseTimeOfDay(s string) (int, int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(s, "%d:%d", &hour, &minute); err != nil {
+		return 0, 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	if hour < 0 || hour > 23 || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid time %q, want HH:MM between 00:00 and 23:59", s)
+	}
+	return hour, minute, nil
+}
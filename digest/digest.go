@@ -0,0 +1,217 @@
+// Package digest emails a daily summary of CanvusLocalLLM activity.
+//
+// The Digest organism builds a report of the previous day (tasks processed,
+// failures, top errors, GPU utilization, token usage and estimated cost)
+// from the SQLite processing history, falling back to the in-memory metrics
+// store, and emails it to the configured recipients at a fixed time of day.
+package digest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go_backend/core"
+	"go_backend/core/clock"
+	"go_backend/db"
+	"go_backend/metrics"
+
+	"go.uber.org/zap"
+)
+
+// topErrorLimit is the number of distinct errors listed in the digest.
+const topErrorLimit = 5
+
+// recentTaskLimit bounds the tasks read from the metrics store fallback.
+const recentTaskLimit = 10000
+
+// HistorySource provides aggregated processing history.
+// Implemented by db.Repository.
+type HistorySource interface {
+	SummarizeProcessingHistory(ctx context.Context, since, until time.Time) ([]db.OperationSummary, error)
+	TopProcessingErrors(ctx context.Context, since, until time.Time, limit int) ([]db.ErrorCount, error)
+}
+
+// Digest is an organism that builds and sends the daily activity email.
+//
+// Usage:
+//
+//	d := digest.New(cfg, repository, metricsStore)
+//	go d.Run(ctx)
+//	// from the GPU collector callback:
+//	d.RecordGPU(gpuMetrics)
+type Digest struct {
+	config  Config
+	history HistorySource
+	store   metrics.MetricsCollector
+	logger  *zap.Logger
+	clock   clock.Clock
+
+	// sendMail delivers the email; replaced in tests
+	sendMail func(ctx context.Context, subject, body string) error
+
+	mu  sync.Mutex
+	gpu gpuAccumulator
+}
+
+// New creates a Digest. history and store are optional; without history
+// the report is built from the tasks still held by store.
+func New(config Config, history HistorySource, store metrics.MetricsCollector) *Digest {
+	logger := config.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	d := &Digest{
+		config:  config,
+		history: history,
+		store:   store,
+		logger:  logger,
+		clock:   clock.OrReal(config.Clock),
+	}
+	d.sendMail = func(ctx context.Context, subject, body string) error {
+		return core.SendMail(ctx, d.config.SMTP, d.config.Recipients, subject, body)
+	}
+	return d
+}
+
+// RecordGPU adds a GPU sample to the current period. It is safe to call on
+// a nil Digest.
+func (d *Digest) RecordGPU(m metrics.GPUMetrics) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.gpu.add(m)
+}
+
+// Build creates the report for [from, to).
+func (d *Digest) Build(ctx context.Context, from, to time.Time) (Report, error) {
+	report := Report{From: from, To: to}
+
+	if d.history != nil {
+		ops, err := d.history.SummarizeProcessingHistory(ctx, from, to)
+		if err != nil {
+			return report, fmt.Errorf("summarize history: %w", err)
+		}
+		errs, err := d.history.TopProcessingErrors(ctx, from, to, topErrorLimit)
+		if err != nil {
+			return report, fmt.Errorf("query top errors: %w", err)
+		}
+		report.Operations = ops
+		report.TopErrors = errs
+	} else if d.store != nil {
+		report.Operations, report.TopErrors = summarizeTasks(d.store.GetRecentTasks(recentTaskLimit), from, to)
+	}
+
+	for _, op := range report.Operations {
+		report.Total += op.Total
+		report.Success += op.Success
+		report.Errors += op.Errors
+		report.InputTokens += op.InputTokens
+		report.OutputTokens += op.OutputTokens
+	}
+	if d.config.CostPer1KTokens > 0 {
+		tokens := float64(report.InputTokens + report.OutputTokens)
+		report.EstimatedCost = tokens / 1000 * d.config.CostPer1KTokens
+	}
+
+	d.mu.Lock()
+	report.GPU = d.gpu.summary()
+	d.mu.Unlock()
+
+	return report, nil
+}
+
+// Send builds the report for [from, to), emails it and starts a new GPU
+// sampling period.
+func (d *Digest) Send(ctx context.Context, from, to time.Time) error {
+	report, err := d.Build(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	if err := d.sendMail(ctx, report.Subject(), report.Body()); err != nil {
+		return fmt.Errorf("send digest: %w", err)
+	}
+
+	d.mu.Lock()
+	d.gpu = gpuAccumulator{}
+	d.mu.Unlock()
+
+	d.logger.Info("Daily digest sent",
+		zap.Int("recipients", len(d.config.Recipients)),
+		zap.Int64("tasks", report.Total),
+		zap.Int64("errors", report.Errors),
+	)
+	return nil
+}
+
+// Run sends the digest every day at the configured time until ctx is
+// cancelled. Each digest covers the 24 hours before it is sent.
+func (d *Digest) Run(ctx context.Context) {
+	for {
+		now := d.clock.Now()
+		next := nextRun(now, d.config.Hour, d.config.Minute)
+		timer := d.clock.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, 2*time.Minute)
+		if err := d.Send(sendCtx, next.Add(-24*time.Hour), next); err != nil {
+			d.logger.Warn("Failed to send daily digest", zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// nextRun returns the first hour:minute local time strictly after now.
+func nextRun(now time.Time, hour, minute int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// summarizeTasks aggregates in-memory task records ending in [from, to).
+func summarizeTasks(tasks []metrics.TaskRecord, from, to time.Time) ([]db.OperationSummary, []db.ErrorCount) {
+	byType := make(map[string]*db.OperationSummary)
+	var order []string
+	errCounts := make(map[string]int64)
+	totalDuration := make(map[string]time.Duration)
+
+	for _, t := range tasks {
+		if t.EndTime.Before(from) || !t.EndTime.Before(to) {
+			continue
+		}
+		op, ok := byType[t.Type]
+		if !ok {
+			op = &db.OperationSummary{OperationType: t.Type}
+			byType[t.Type] = op
+			order = append(order, t.Type)
+		}
+		op.Total++
+		totalDuration[t.Type] += t.Duration
+		switch t.Status {
+		case metrics.TaskStatusSuccess:
+			op.Success++
+		case metrics.TaskStatusError:
+			op.Errors++
+			errCounts[t.ErrorMsg]++
+		}
+	}
+
+	ops := make([]db.OperationSummary, 0, len(order))
+	for _, name := range order {
+		op := byType[name]
+		op.AvgDurationMS = float64(totalDuration[name].Milliseconds()) / float64(op.Total)
+		ops = append(ops, *op)
+	}
+	sortOperations(ops)
+	return ops, topErrors(errCounts, topErrorLimit)
+}
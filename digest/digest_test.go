@@ -0,0 +1,217 @@
+package digest
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+	"go_backend/db"
+	"go_backend/metrics"
+)
+
+// fakeHistory is a test implementation of HistorySource.
+type fakeHistory struct {
+	ops  []db.OperationSummary
+	errs []db.ErrorCount
+	err  error
+}
+
+func (f *fakeHistory) SummarizeProcessingHistory(ctx context.Context, since, until time.Time) ([]db.OperationSummary, error) {
+	return f.ops, f.err
+}
+
+func (f *fakeHistory) TopProcessingErrors(ctx context.Context, since, until time.Time, limit int) ([]db.ErrorCount, error) {
+	return f.errs, f.err
+}
+
+func TestBuildFromHistory(t *testing.T) {
+	history := &fakeHistory{
+		ops: []db.OperationSummary{
+			{OperationType: "text_generation", Total: 8, Success: 7, Errors: 1, InputTokens: 4000, OutputTokens: 1000, AvgDurationMS: 1500},
+			{OperationType: "image_generation", Total: 2, Success: 1, Errors: 1},
+		},
+		errs: []db.ErrorCount{{Message: "model timeout", Count: 2}},
+	}
+	d := New(Config{CostPer1KTokens: 0.002}, history, nil)
+	d.RecordGPU(metrics.GPUMetrics{Utilization: 20, Temperature: 60, MemoryTotal: 100, MemoryUsed: 50})
+	d.RecordGPU(metrics.GPUMetrics{Utilization: 80, Temperature: 75, MemoryTotal: 100, MemoryUsed: 90})
+
+	from := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	report, err := d.Build(context.Background(), from, from.Add(24*time.Hour))
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if report.Total != 10 || report.Success != 8 || report.Errors != 2 {
+		t.Errorf("totals = %d/%d/%d, want 10/8/2", report.Total, report.Success, report.Errors)
+	}
+	if report.SuccessRate() != 80 {
+		t.Errorf("SuccessRate() = %v, want 80", report.SuccessRate())
+	}
+	if report.EstimatedCost != 0.01 {
+		t.Errorf("EstimatedCost = %v, want 0.01", report.EstimatedCost)
+	}
+	if report.GPU.Samples != 2 || report.GPU.AvgUtilization != 50 || report.GPU.PeakMemoryPercent != 90 {
+		t.Errorf("GPU = %+v", report.GPU)
+	}
+
+	body := report.Body()
+	for _, want := range []string{
+		"Processed: 10",
+		"Succeeded: 8 (80.0%)",
+		"text_generation",
+		"2x model timeout",
+		"Utilization: avg 50%, peak 80%",
+		"Peak temperature: 75°C",
+		"Tokens: 4000 in, 1000 out",
+		"Estimated cost: $0.01",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q:\n%s", want, body)
+		}
+	}
+	if got := report.Subject(); got != "[CanvusLocalLLM] Daily digest 2026-03-01: 10 tasks, 2 failed" {
+		t.Errorf("Subject() = %q", got)
+	}
+}
+
+func TestBuildHistoryError(t *testing.T) {
+	d := New(Config{}, &fakeHistory{err: errors.New("database locked")}, nil)
+	if _, err := d.Build(context.Background(), time.Now(), time.Now()); err == nil {
+		t.Error("Build() should fail when history fails")
+	}
+}
+
+func TestBuildFromMetricsStore(t *testing.T) {
+	from := time.Now().Add(-time.Hour)
+	store := metrics.NewMetricsStore(metrics.StoreConfig{TaskHistoryCapacity: 10}, from)
+	store.RecordTask(metrics.TaskRecord{Type: "note", Status: metrics.TaskStatusSuccess, EndTime: from.Add(time.Minute), Duration: time.Second})
+	store.RecordTask(metrics.TaskRecord{Type: "note", Status: metrics.TaskStatusError, ErrorMsg: "boom", EndTime: from.Add(2 * time.Minute)})
+	store.RecordTask(metrics.TaskRecord{Type: "pdf", Status: metrics.TaskStatusSuccess, EndTime: from.Add(-time.Minute)})
+
+	report, err := New(Config{}, nil, store).Build(context.Background(), from, time.Now())
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	if report.Total != 2 || report.Errors != 1 || len(report.Operations) != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(report.TopErrors) != 1 || report.TopErrors[0].Message != "boom" {
+		t.Errorf("TopErrors = %+v", report.TopErrors)
+	}
+	if !strings.Contains(report.Body(), "No GPU data") {
+		t.Error("body should report missing GPU data")
+	}
+}
+
+func TestSendResetsGPUPeriod(t *testing.T) {
+	d := New(Config{}, &fakeHistory{}, nil)
+	var subject string
+	d.sendMail = func(ctx context.Context, s, body string) error {
+		subject = s
+		return nil
+	}
+	d.RecordGPU(metrics.GPUMetrics{Utilization: 50})
+
+	if err := d.Send(context.Background(), time.Now().Add(-24*time.Hour), time.Now()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if !strings.Contains(subject, "Daily digest") {
+		t.Errorf("subject = %q", subject)
+	}
+
+	report, _ := d.Build(context.Background(), time.Now(), time.Now())
+	if report.GPU.Samples != 0 {
+		t.Errorf("GPU samples after send = %d, want 0", report.GPU.Samples)
+	}
+}
+
+func TestSendKeepsGPUDataOnFailure(t *testing.T) {
+	d := New(Config{}, &fakeHistory{}, nil)
+	d.sendMail = func(ctx context.Context, s, body string) error { return errors.New("smtp down") }
+	d.RecordGPU(metrics.GPUMetrics{Utilization: 50})
+
+	if err := d.Send(context.Background(), time.Now(), time.Now()); err == nil {
+		t.Fatal("Send() should fail")
+	}
+	report, _ := d.Build(context.Background(), time.Now(), time.Now())
+	if report.GPU.Samples != 1 {
+		t.Errorf("GPU samples = %d, want 1", report.GPU.Samples)
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	loc := time.UTC
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2026, 3, 1, 7, 0, 0, 0, loc), time.Date(2026, 3, 1, 8, 0, 0, 0, loc)},
+		{time.Date(2026, 3, 1, 8, 0, 0, 0, loc), time.Date(2026, 3, 2, 8, 0, 0, 0, loc)},
+		{time.Date(2026, 3, 1, 23, 0, 0, 0, loc), time.Date(2026, 3, 2, 8, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if got := nextRun(tt.now, 8, 0); !got.Equal(tt.want) {
+			t.Errorf("nextRun(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("DIGEST_EMAIL", "ops@example.com, dev@example.com")
+	t.Setenv("DIGEST_TIME", "18:30")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv() error = %v", err)
+	}
+	if !cfg.Enabled() || len(cfg.Recipients) != 2 || cfg.Hour != 18 || cfg.Minute != 30 {
+		t.Errorf("ConfigFromEnv() = %+v", cfg)
+	}
+
+	t.Setenv("DIGEST_TIME", "25:00")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("ConfigFromEnv() should reject 25:00")
+	}
+
+	t.Setenv("DIGEST_TIME", "")
+	t.Setenv("SMTP_HOST", "")
+	cfg, err = ConfigFromEnv()
+	if err != nil || cfg.Hour != 8 || cfg.Enabled() {
+		t.Errorf("defaults = %+v, %v; want 08:00 and disabled without SMTP_HOST", cfg, err)
+	}
+}
+
+func TestRunSendsDaily(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 3, 1, 7, 0, 0, 0, time.UTC))
+	d := New(Config{Hour: 8, Clock: fake}, &fakeHistory{}, nil)
+	sent := make(chan time.Time, 1)
+	d.sendMail = func(ctx context.Context, s, body string) error {
+		sent <- fake.Now()
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go d.Run(ctx)
+
+	for _, want := range []time.Time{
+		time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+		time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC),
+	} {
+		fake.BlockUntil(1)
+		fake.Set(want)
+		select {
+		case at := <-sent:
+			if !at.Equal(want) {
+				t.Errorf("digest sent at %v, want %v", at, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no digest sent at %v", want)
+		}
+	}
+}
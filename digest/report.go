@@ -0,0 +1,109 @@
+package digest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go_backend/db"
+)
+
+// GPUSummary is the GPU utilization observed during the digest period.
+type GPUSummary struct {
+	Samples            int
+	AvgUtilization     float64
+	PeakUtilization    float64
+	PeakTemperature    float64
+	PeakMemoryPercent  float64
+	HasTemperatureData bool
+}
+
+// Report is the content of one digest email.
+type Report struct {
+	From time.Time
+	To   time.Time
+
+	Operations []db.OperationSummary
+	TopErrors  []db.ErrorCount
+
+	Total   int64
+	Success int64
+	Errors  int64
+
+	InputTokens   int64
+	OutputTokens  int64
+	EstimatedCost float64
+
+	GPU GPUSummary
+}
+
+// SuccessRate returns the percentage of successful tasks (0 with no tasks).
+func (r Report) SuccessRate() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Success) * 100 / float64(r.Total)
+}
+
+// Subject returns the email subject line.
+func (r Report) Subject() string {
+	return fmt.Sprintf("[CanvusLocalLLM] Daily digest %s: %d tasks, %d failed",
+		r.From.Format("2006-01-02"), r.Total, r.Errors)
+}
+
+// Body renders the report as plain text.
+func (r Report) Body() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "CanvusLocalLLM activity from %s to %s\n\n",
+		r.From.Format("2006-01-02 15:04"), r.To.Format("2006-01-02 15:04 MST"))
+
+	b.WriteString("Tasks\n-----\n")
+	fmt.Fprintf(&b, "Processed: %d\n", r.Total)
+	fmt.Fprintf(&b, "Succeeded: %d (%.1f%%)\n", r.Success, r.SuccessRate())
+	fmt.Fprintf(&b, "Failed:    %d\n", r.Errors)
+	if len(r.Operations) > 0 {
+		b.WriteString("\n")
+		for _, op := range r.Operations {
+			fmt.Fprintf(&b, "  %-20s %5d tasks, %4d failed, avg %s\n", op.OperationType, op.Total, op.Errors,
+				(time.Duration(op.AvgDurationMS) * time.Millisecond).Round(time.Millisecond))
+		}
+	}
+
+	b.WriteString("\nTop errors\n----------\n")
+	if len(r.TopErrors) == 0 {
+		b.WriteString("None\n")
+	}
+	for _, e := range r.TopErrors {
+		fmt.Fprintf(&b, "  %4dx %s\n", e.Count, truncate(e.Message, 120))
+	}
+
+	b.WriteString("\nGPU\n---\n")
+	if r.GPU.Samples == 0 {
+		b.WriteString("No GPU data\n")
+	} else {
+		fmt.Fprintf(&b, "Utilization: avg %.0f%%, peak %.0f%%\n", r.GPU.AvgUtilization, r.GPU.PeakUtilization)
+		fmt.Fprintf(&b, "Peak memory: %.0f%%\n", r.GPU.PeakMemoryPercent)
+		if r.GPU.HasTemperatureData {
+			fmt.Fprintf(&b, "Peak temperature: %.0f°C\n", r.GPU.PeakTemperature)
+		}
+	}
+
+	b.WriteString("\nUsage\n-----\n")
+	fmt.Fprintf(&b, "Tokens: %d in, %d out\n", r.InputTokens, r.OutputTokens)
+	if r.EstimatedCost > 0 {
+		fmt.Fprintf(&b, "Estimated cost: $%.2f\n", r.EstimatedCost)
+	}
+
+	return b.String()
+}
+
+// truncate shortens s to max runes, adding an ellipsis.
+func truncate(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,249 @@
+package docimport
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go_backend/handlers"
+)
+
+func TestSplitSections(t *testing.T) {
+	text := "Some preamble.\n1. Introduction\nWhy we did it.\n2. Results\nIt worked.\n2.1 Details\nNumbers.\nMETHODS\nHow."
+	var got []string
+	for _, s := range splitSections(text) {
+		got = append(got, s.Heading+"="+s.Text)
+	}
+	want := "Introduction=Some preamble.|1. Introduction=Why we did it.|2. Results=It worked.|2.1 Details=Numbers.|METHODS=How."
+	if strings.Join(got, "|") != want {
+		t.Errorf("sections = %s", strings.Join(got, "|"))
+	}
+
+	plain := splitSections("Just one paragraph.\n\n2026 was a good year.")
+	if len(plain) != 1 || plain[0].Heading != "Content" {
+		t.Errorf("plain text sections = %+v", plain)
+	}
+
+	for _, line := range []string{"1. Buy milk, eggs and bread.", "NOTE:", "OK", "The results section"} {
+		if isHeading(line) {
+			t.Errorf("isHeading(%q) = true", line)
+		}
+	}
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testDOCX builds a minimal Word document with a title, two headings and a figure.
+func testDOCX(t *testing.T) []byte {
+	t.Helper()
+	para := func(style, text string) string {
+		p := "<w:p>"
+		if style != "" {
+			p += `<w:pPr><w:pStyle w:val="` + style + `"/></w:pPr>`
+		}
+		return p + "<w:r><w:t>" + text + "</w:t></w:r></w:p>"
+	}
+	body := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+		`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><w:body>` +
+		para("Title", "Field Study") +
+		para("", "Opening remarks.") +
+		para("Heading1", "Findings") +
+		para("", "Users liked it.") +
+		`<w:p><w:r><w:drawing><a:graphic><a:graphicData><a:blip r:embed="rId5"/></a:graphicData></a:graphic></w:drawing></w:r></w:p>` +
+		para("", "They asked for more.") +
+		para("Heading2", "Next steps") +
+		para("", "Ship it.") +
+		`</w:body></w:document>`
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string][]byte{
+		"word/document.xml":            []byte(body),
+		"word/_rels/document.xml.rels": []byte(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId5" Type="image" Target="media/image1.png"/></Relationships>`),
+		"word/media/image1.png":        testPNG(t, 200, 100),
+	} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(content)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParseDOCX(t *testing.T) {
+	data := testDOCX(t)
+	doc, err := ParseDOCX(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "Field Study" || len(doc.Sections) != 3 {
+		t.Fatalf("doc = %+v", doc)
+	}
+	if s := doc.Sections[1]; s.Heading != "Findings" || s.Text != "Users liked it.\nThey asked for more." {
+		t.Errorf("section = %+v", s)
+	}
+	if len(doc.Figures) != 1 || doc.Figures[0].ContentType != "image/png" || doc.Figures[0].Name != "image1.png" {
+		t.Errorf("figures = %+v", doc.Figures)
+	}
+
+	if _, err := ParseDOCX(bytes.NewReader([]byte("not a zip")), 9); err == nil {
+		t.Error("ParseDOCX(garbage) succeeded")
+	}
+}
+
+func TestParsePDF(t *testing.T) {
+	doc, err := ParsePDF("../test_files/test_pdf.pdf")
+	if err != nil {
+		t.Skipf("test PDF not readable: %v", err)
+	}
+	if doc.Format != FormatPDF || doc.Pages == 0 || len(doc.Sections) == 0 {
+		t.Errorf("doc = %+v", doc)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	if f, err := DetectFormat("Report.PDF"); err != nil || f != FormatPDF {
+		t.Errorf("DetectFormat(pdf) = %q, %v", f, err)
+	}
+	if _, err := DetectFormat("notes.txt"); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("DetectFormat(txt) = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	doc := &Document{
+		Title:    "Study",
+		Sections: []Section{{Heading: "A", Summary: "a"}, {Heading: "B", Text: strings.Repeat("b", 1000)}, {Heading: "C"}, {Heading: "D"}},
+		Figures:  []Figure{{Name: "wide.png", Data: testPNG(t, 200, 100)}},
+	}
+	plan := Layout(doc, handlers.Location{X: 1000, Y: 500})
+	if len(plan.Widgets) != 6 || plan.Widgets[0].Role != RoleTitle {
+		t.Fatalf("widgets = %+v", plan.Widgets)
+	}
+	if got := len([]rune(plan.Widgets[2].Text)); got != maxNoteRunes {
+		t.Errorf("section B text is %d runes, want %d", got, maxNoteRunes)
+	}
+	// Section D wraps to the second row; the figure goes below it
+	d, fig := plan.Widgets[4].Bounds, plan.Widgets[5].Bounds
+	if d.X != plan.Widgets[1].Bounds.X || d.Y <= plan.Widgets[1].Bounds.Y {
+		t.Errorf("section D at %+v", d)
+	}
+	if fig.Y <= d.Y+d.Height || fig.Width != noteWidth || fig.Height != noteWidth/2 {
+		t.Errorf("figure at %+v", fig)
+	}
+	for _, w := range plan.Widgets {
+		b := w.Bounds
+		if b.X < plan.Area.X || b.Y < plan.Area.Y || b.X+b.Width > plan.Area.X+plan.Area.Width || b.Y+b.Height > plan.Area.Y+plan.Area.Height {
+			t.Errorf("%s %q at %+v is outside %+v", w.Role, w.Title, b, plan.Area)
+		}
+	}
+}
+
+// fakeClient records the widgets created
+type fakeClient struct {
+	anchors, notes, images []map[string]interface{}
+}
+
+func (c *fakeClient) GetWidgets(subscribe bool) ([]map[string]interface{}, error) {
+	return []map[string]interface{}{
+		{"id": "canvas", "widget_type": "SharedCanvas", "location": map[string]interface{}{"x": 0.0, "y": 0.0}, "size": map[string]interface{}{"width": 100000.0, "height": 100000.0}},
+		{"id": "n1", "widget_type": "Note", "location": map[string]interface{}{"x": 100.0, "y": -50.0}, "size": map[string]interface{}{"width": 300.0, "height": 300.0}},
+	}, nil
+}
+
+func (c *fakeClient) CreateAnchor(payload map[string]interface{}) (map[string]interface{}, error) {
+	c.anchors = append(c.anchors, payload)
+	return map[string]interface{}{"id": "anchor"}, nil
+}
+
+func (c *fakeClient) CreateNote(payload map[string]interface{}) (map[string]interface{}, error) {
+	c.notes = append(c.notes, payload)
+	return map[string]interface{}{"id": "note"}, nil
+}
+
+func (c *fakeClient) CreateImage(filePath string, metadata map[string]interface{}) (map[string]interface{}, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, err
+	}
+	c.images = append(c.images, metadata)
+	return map[string]interface{}{"id": "image"}, nil
+}
+
+func (c *fakeClient) CreatePDF(filePath string, metadata map[string]interface{}) (map[string]interface{}, error) {
+	return nil, errors.New("not expected")
+}
+
+func TestImporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "upload")
+	if err := os.WriteFile(path, testDOCX(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var prompts []string
+	generate := func(ctx context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		if strings.Contains(prompt, "Section: Next steps") {
+			return "", errors.New("model busy")
+		}
+		return "- summary", nil
+	}
+
+	client := &fakeClient{}
+	result, err := NewImporter(client, generate, t.TempDir(), nil).Import(context.Background(), path, "field-study.docx", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.AnchorID != "anchor" || result.Sections != 3 || result.Figures != 1 || !result.Summarized || len(result.WidgetIDs) != 6 {
+		t.Errorf("result = %+v", result)
+	}
+	if len(prompts) != 4 {
+		t.Errorf("%d prompts, want 3 sections and the overview", len(prompts))
+	}
+
+	anchor := client.anchors[0]
+	if anchor["anchor_name"] != "Field Study" {
+		t.Errorf("anchor = %+v", anchor)
+	}
+	// Right of the existing note, level with it
+	if loc := anchor["location"].(map[string]float64); loc["x"] != 100+300+placementGap || loc["y"] != -50 {
+		t.Errorf("anchor location = %+v", loc)
+	}
+	title := client.notes[0]
+	if title["background_color"] != titleNoteColor || !strings.Contains(title["text"].(string), "Imported from field-study.docx · 3 sections · 1 figure") {
+		t.Errorf("title note = %+v", title)
+	}
+	if text := client.notes[3]["text"]; text != "Ship it." {
+		t.Errorf("failed summary shows %q, want the section start", text)
+	}
+	if client.images[0]["title"] != "image1.png" {
+		t.Errorf("image = %+v", client.images[0])
+	}
+
+	if _, err := NewImporter(client, nil, "", nil).Import(context.Background(), path, "notes.txt", Options{}); !errors.Is(err, ErrUnsupportedFormat) {
+		t.Errorf("Import(txt) = %v, want ErrUnsupportedFormat", err)
+	}
+}
+
+func TestLeadSummary(t *testing.T) {
+	if got := leadSummary("First one.  Second\none! Third one."); got != "First one. Second one!" {
+		t.Errorf("leadSummary() = %q", got)
+	}
+}
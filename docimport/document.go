@@ -0,0 +1,205 @@
+// Package docimport lays out a PDF or Word document on a canvas as a
+// structured set of widgets: a title note with an overview, one summarized
+// note per section and the document's figures, grouped in an anchor.
+//
+// Architecture (Atomic Design):
+//   - document.go: Document atoms and splitSections, which finds the sections of plain text
+//   - docx.go: Word (.docx) parser
+//   - pdf.go: PDF parser built on pdfprocessor
+//   - layout.go: Layout, which places the widgets of a document
+//   - importer.go: Importer organism that summarizes and creates the widgets
+package docimport
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"go_backend/core/errs"
+)
+
+var (
+	// ErrUnsupportedFormat is returned for files that are not PDF or DOCX.
+	ErrUnsupportedFormat = errors.New("docimport: unsupported document format (use .pdf or .docx)")
+	// ErrNoContent is returned when a document has no text.
+	ErrNoContent = errs.New(errs.CodeNoContent, "docimport: document has no text")
+)
+
+// Format is an importable document format.
+type Format string
+
+const (
+	FormatPDF  Format = "pdf"
+	FormatDOCX Format = "docx"
+)
+
+// DetectFormat returns the format of a file from its name.
+func DetectFormat(name string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf":
+		return FormatPDF, nil
+	case ".docx":
+		return FormatDOCX, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedFormat, filepath.Base(name))
+}
+
+// Section and size limits keep the canvas layout readable.
+const (
+	maxSections     = 24
+	maxFigures      = 12
+	maxHeadingRunes = 80
+	// fallbackSectionRunes is the size of the parts text without headings is split into
+	fallbackSectionRunes = 4000
+)
+
+// Figure is an image extracted from a document.
+type Figure struct {
+	Name        string
+	Data        []byte
+	ContentType string
+}
+
+// Section is a part of a document under one heading.
+type Section struct {
+	Heading string
+	Text    string
+	// Summary is shown on the canvas instead of the full text
+	Summary string
+}
+
+// Document is a parsed document.
+type Document struct {
+	Title  string
+	Format Format
+	// Pages is the number of pages of a PDF (0 for DOCX)
+	Pages    int
+	Sections []Section
+	Figures  []Figure
+	// Overview summarizes the whole document on the title note
+	Overview string
+}
+
+// numberedHeading matches lowercased headings such as "2. results",
+// "3.1 method", "iv. discussion" or "chapter 5".
+var numberedHeading = regexp.MustCompile(`^((\d+\.(\d+\.?)*)|([ivxlc]+\.)|((chapter|section|part)\s+\d+[.:]?))\s+\S`)
+
+// splitSections splits plain text into sections at lines that look like
+// headings: numbered lines and short lines in capitals. Text without
+// headings is split into parts of about fallbackSectionRunes.
+//
+// This is a pure function (molecule) with no external dependencies.
+func splitSections(text string) []Section {
+	var sections []Section
+	current := Section{}
+	var body strings.Builder
+	flush := func() {
+		current.Text = strings.TrimSpace(body.String())
+		if current.Heading != "" || current.Text != "" {
+			sections = append(sections, current)
+		}
+		body.Reset()
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if isHeading(trimmed) {
+			flush()
+			current = Section{Heading: trimmed}
+			continue
+		}
+		body.WriteString(line)
+		body.WriteString("\n")
+	}
+	flush()
+
+	headings := 0
+	for _, s := range sections {
+		if s.Heading != "" {
+			headings++
+		}
+	}
+	if headings < 2 {
+		return splitParts(text)
+	}
+	if sections[0].Heading == "" {
+		sections[0].Heading = "Introduction"
+	}
+	return capSections(sections)
+}
+
+// isHeading reports whether a line looks like a heading.
+func isHeading(line string) bool {
+	n := len([]rune(line))
+	if n < 3 || n > maxHeadingRunes || strings.ContainsAny(line[len(line)-1:], ".,;:") {
+		return false
+	}
+	if numberedHeading.MatchString(strings.ToLower(line)) {
+		// Numbered list items are sentences: long, or ending in punctuation
+		return len(strings.Fields(line)) <= 10
+	}
+	letters := 0
+	for _, r := range line {
+		if unicode.IsLetter(r) {
+			if !unicode.IsUpper(r) {
+				return false
+			}
+			letters++
+		}
+	}
+	return letters >= 3
+}
+
+// splitParts splits text without headings into numbered parts at
+// paragraph breaks.
+func splitParts(text string) []Section {
+	var sections []Section
+	var body strings.Builder
+	flush := func() {
+		if t := strings.TrimSpace(body.String()); t != "" {
+			sections = append(sections, Section{Heading: fmt.Sprintf("Part %d", len(sections)+1), Text: t})
+		}
+		body.Reset()
+	}
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		if body.Len() > 0 && len([]rune(body.String()))+len([]rune(paragraph)) > fallbackSectionRunes {
+			flush()
+		}
+		body.WriteString(paragraph)
+		body.WriteString("\n\n")
+	}
+	flush()
+	if len(sections) == 1 {
+		sections[0].Heading = "Content"
+	}
+	return capSections(sections)
+}
+
+// capSections folds the sections past maxSections into the last one.
+func capSections(sections []Section) []Section {
+	if len(sections) <= maxSections {
+		return sections
+	}
+	last := &sections[maxSections-1]
+	var b strings.Builder
+	b.WriteString(last.Text)
+	for _, s := range sections[maxSections:] {
+		fmt.Fprintf(&b, "\n\n%s\n%s", s.Heading, s.Text)
+	}
+	last.Heading = "Further sections"
+	last.Text = strings.TrimSpace(b.String())
+	return sections[:maxSections]
+}
+
+// titleFromName turns a file name into a document title.
+func titleFromName(name string) string {
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	base = strings.TrimSpace(strings.NewReplacer("_", " ", "-", " ").Replace(base))
+	if base == "" {
+		return "Document"
+	}
+	return base
+}
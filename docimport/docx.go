@@ -0,0 +1,236 @@
+// Package docimport provides the Word document parser. This file contains
+// ParseDOCX, which reads the paragraphs, headings and images of a .docx file.
+package docimport
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// maxFigureBytes is the largest image extracted from a document.
+const maxFigureBytes = 10 << 20
+
+// ParseDOCX parses a Word document. Heading 1 and Heading 2 paragraphs start
+// sections; a Title paragraph, or else the document properties, give the
+// title. Images are extracted as figures in document order.
+func ParseDOCX(r io.ReaderAt, size int64) (*Document, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("docimport: not a .docx file: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	body, ok := files["word/document.xml"]
+	if !ok {
+		return nil, fmt.Errorf("docimport: not a .docx file: word/document.xml is missing")
+	}
+
+	doc := &Document{Format: FormatDOCX}
+	var images []string
+	if err := readXML(body, func(dec *xml.Decoder) error {
+		var err error
+		images, err = parseDocumentXML(dec, doc)
+		return err
+	}); err != nil {
+		return nil, fmt.Errorf("docimport: failed to read document: %w", err)
+	}
+	if len(doc.Sections) == 0 {
+		return nil, ErrNoContent
+	}
+
+	if doc.Title == "" {
+		if f, ok := files["docProps/core.xml"]; ok {
+			_ = readXML(f, func(dec *xml.Decoder) error {
+				doc.Title = coreTitle(dec)
+				return nil
+			})
+		}
+	}
+
+	targets := map[string]string{}
+	if f, ok := files["word/_rels/document.xml.rels"]; ok {
+		_ = readXML(f, func(dec *xml.Decoder) error {
+			targets = relationshipTargets(dec)
+			return nil
+		})
+	}
+	for _, id := range images {
+		if len(doc.Figures) == maxFigures {
+			break
+		}
+		f, ok := files[path.Join("word", targets[id])]
+		if !ok || targets[id] == "" || f.UncompressedSize64 > maxFigureBytes {
+			continue
+		}
+		if fig, err := readFigure(f); err == nil {
+			doc.Figures = append(doc.Figures, fig)
+		}
+	}
+	return doc, nil
+}
+
+// parseDocumentXML reads the paragraphs of word/document.xml into doc and
+// returns the relationship IDs of its images. A document without headings
+// is split like plain text.
+func parseDocumentXML(dec *xml.Decoder, doc *Document) ([]string, error) {
+	var (
+		images   []string
+		all      strings.Builder
+		headings int
+		style    string
+		text     strings.Builder
+		inText   bool
+	)
+	endParagraph := func() {
+		line := strings.TrimSpace(text.String())
+		s := strings.ToLower(style)
+		text.Reset()
+		style = ""
+		switch {
+		case line == "":
+			return
+		case s == "title" && doc.Title == "":
+			doc.Title = line
+			return
+		case s == "heading1" || s == "heading2":
+			doc.Sections = append(doc.Sections, Section{Heading: line})
+			headings++
+			return
+		}
+		all.WriteString(line + "\n\n")
+		if len(doc.Sections) == 0 {
+			doc.Sections = append(doc.Sections, Section{Heading: "Introduction"})
+		}
+		last := &doc.Sections[len(doc.Sections)-1]
+		if last.Text != "" {
+			last.Text += "\n"
+		}
+		last.Text += line
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "pStyle":
+				style = attr(t, "val")
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteString("\t")
+			case "br", "cr":
+				text.WriteString("\n")
+			case "blip":
+				if id := attr(t, "embed"); id != "" {
+					images = append(images, id)
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				endParagraph()
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		}
+	}
+
+	if headings == 0 {
+		doc.Sections = nil
+		if all.Len() > 0 {
+			doc.Sections = splitParts(all.String())
+		}
+		return images, nil
+	}
+	doc.Sections = capSections(doc.Sections)
+	return images, nil
+}
+
+// coreTitle reads dc:title from docProps/core.xml.
+func coreTitle(dec *xml.Decoder) string {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return ""
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "title" {
+			var title string
+			if dec.DecodeElement(&title, &start) == nil {
+				return strings.TrimSpace(title)
+			}
+			return ""
+		}
+	}
+}
+
+// relationshipTargets maps relationship IDs to their targets.
+func relationshipTargets(dec *xml.Decoder) map[string]string {
+	targets := map[string]string{}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return targets
+		}
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "Relationship" && attr(start, "TargetMode") != "External" {
+			targets[attr(start, "Id")] = attr(start, "Target")
+		}
+	}
+}
+
+// readFigure reads an image part of the document.
+func readFigure(f *zip.File) (Figure, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return Figure{}, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxFigureBytes))
+	if err != nil {
+		return Figure{}, err
+	}
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/png", "image/jpeg", "image/gif":
+	default:
+		return Figure{}, fmt.Errorf("unsupported image type %s", contentType)
+	}
+	return Figure{Name: path.Base(f.Name), Data: data, ContentType: contentType}, nil
+}
+
+// readXML decodes a part of the document.
+func readXML(f *zip.File, read func(*xml.Decoder) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return read(xml.NewDecoder(rc))
+}
+
+// attr returns the value of the attribute with the given local name.
+func attr(e xml.StartElement, local string) string {
+	for _, a := range e.Attr {
+		if a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,349 @@
+// Package docimport provides the Importer organism. This file contains the
+// Importer, which parses a document, summarizes its sections and creates
+// the widgets of its layout on a canvas.
+package docimport
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go_backend/core"
+	"go_backend/handlers"
+
+	"go.uber.org/zap"
+)
+
+// Client writes to a canvas. Implemented by canvusapi.Client.
+type Client interface {
+	GetWidgets(subscribe bool) ([]map[string]interface{}, error)
+	CreateAnchor(payload map[string]interface{}) (map[string]interface{}, error)
+	CreateNote(payload map[string]interface{}) (map[string]interface{}, error)
+	CreateImage(filePath string, metadata map[string]interface{}) (map[string]interface{}, error)
+	CreatePDF(filePath string, metadata map[string]interface{}) (map[string]interface{}, error)
+}
+
+// Layout placement and summary limits.
+const (
+	// placementGap separates an import from the widgets already on the canvas
+	placementGap = 200.0
+	// maxPromptRunes bounds the section text sent to the model
+	maxPromptRunes = 6000
+	// leadSummaryRunes bounds a summary taken from the start of a section
+	leadSummaryRunes = 300
+	titleNoteColor   = "#FFE599"
+)
+
+const sectionPrompt = `Summarize this section of the document "%s" in at most three short bullet points.
+Reply with the bullet points only.
+
+Section: %s
+
+%s`
+
+const overviewPrompt = `Write a two-sentence overview of the document "%s" from the summaries of its sections below.
+Reply with the overview only.
+
+%s`
+
+// Options control an import.
+type Options struct {
+	// Title of the layout (default: the document title, or the file name)
+	Title string
+	// SkipSummaries shows the start of each section instead of a model summary
+	SkipSummaries bool
+	// Location of the top-left corner (default: right of the existing widgets)
+	Location *handlers.Location
+}
+
+// Result describes an imported document.
+type Result struct {
+	Title      string   `json:"title"`
+	AnchorID   string   `json:"anchor_id"`
+	Sections   int      `json:"sections"`
+	Figures    int      `json:"figures"`
+	Summarized bool     `json:"summarized"`
+	WidgetIDs  []string `json:"widget_ids"`
+}
+
+// Importer is an organism that lays out documents on a canvas.
+//
+// Usage:
+//
+//	importer := docimport.NewImporter(client, generate, downloadsDir, logger)
+//	result, err := importer.Import(ctx, "/tmp/report.pdf", "Q3 report.pdf", docimport.Options{})
+type Importer struct {
+	client   Client
+	generate core.GenerateFunc
+	tempDir  string
+	logger   *zap.Logger
+}
+
+// NewImporter creates an Importer writing to client. Sections are
+// summarized with generate; when it is nil the start of each section is
+// shown. Figures are written to a temporary directory under tempDir
+// (default: the system temporary directory) for upload.
+func NewImporter(client Client, generate core.GenerateFunc, tempDir string, logger *zap.Logger) *Importer {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Importer{client: client, generate: generate, tempDir: tempDir, logger: logger}
+}
+
+// Parse parses the document at path. name is its original file name, which
+// selects the format.
+func Parse(path, name string) (*Document, error) {
+	format, err := DetectFormat(name)
+	if err != nil {
+		return nil, err
+	}
+	if format == FormatPDF {
+		return ParsePDF(path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("docimport: failed to open document: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("docimport: failed to open document: %w", err)
+	}
+	return ParseDOCX(f, info.Size())
+}
+
+// Import parses the document at path, summarizes it and creates its layout
+// on the canvas: an anchor named after the document holding the title note,
+// the section notes and the figures. A PDF is also uploaded beside the
+// anchor. Figures that fail to upload are skipped; a failure to create a
+// note stops the import.
+func (im *Importer) Import(ctx context.Context, path, name string, opts Options) (*Result, error) {
+	doc, err := Parse(path, name)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Title != "" {
+		doc.Title = opts.Title
+	}
+	if doc.Title == "" {
+		doc.Title = titleFromName(name)
+	}
+
+	summarized := im.generate != nil && !opts.SkipSummaries
+	if summarized {
+		if err := im.summarize(ctx, doc); err != nil {
+			return nil, err
+		}
+	} else {
+		for i := range doc.Sections {
+			doc.Sections[i].Summary = leadSummary(doc.Sections[i].Text)
+		}
+	}
+	doc.Overview = strings.TrimSpace(doc.Overview + "\n\n" + sourceLine(doc, name))
+
+	origin := opts.Location
+	if origin == nil {
+		loc, err := im.freeLocation()
+		if err != nil {
+			return nil, err
+		}
+		origin = &loc
+	}
+	plan := Layout(doc, *origin)
+
+	result := &Result{Title: doc.Title, Sections: len(doc.Sections), Summarized: summarized}
+	anchor, err := im.client.CreateAnchor(map[string]interface{}{
+		"anchor_name": doc.Title,
+		"location":    handlers.LocationToMap(handlers.Location{X: plan.Area.X, Y: plan.Area.Y}),
+		"size":        handlers.SizeToMap(handlers.NoteSize{Width: plan.Area.Width, Height: plan.Area.Height}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("docimport: failed to create anchor: %w", err)
+	}
+	result.AnchorID, _ = anchor["id"].(string)
+	result.WidgetIDs = append(result.WidgetIDs, result.AnchorID)
+
+	var figureDir string
+	for _, p := range plan.Widgets {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		if p.Role == RoleFigure {
+			if figureDir == "" {
+				if figureDir, err = im.mkdirTemp(); err != nil {
+					return result, err
+				}
+				defer os.RemoveAll(figureDir)
+			}
+			if id, err := im.uploadFigure(figureDir, p); err != nil {
+				im.logger.Warn("Figure left out of import", zap.String("figure", p.Title), zap.Error(err))
+			} else {
+				result.Figures++
+				result.WidgetIDs = append(result.WidgetIDs, id)
+			}
+			continue
+		}
+
+		payload := map[string]interface{}{
+			"title":    p.Title,
+			"text":     p.Text,
+			"location": handlers.LocationToMap(handlers.Location{X: p.Bounds.X, Y: p.Bounds.Y}),
+			"size":     handlers.SizeToMap(handlers.NoteSize{Width: p.Bounds.Width, Height: p.Bounds.Height}),
+		}
+		if p.Role == RoleTitle {
+			payload["background_color"] = titleNoteColor
+		}
+		note, err := im.client.CreateNote(payload)
+		if err != nil {
+			return result, fmt.Errorf("docimport: failed to create note %q: %w", p.Title, err)
+		}
+		id, _ := note["id"].(string)
+		result.WidgetIDs = append(result.WidgetIDs, id)
+	}
+
+	if doc.Format == FormatPDF {
+		pdf, err := im.client.CreatePDF(path, map[string]interface{}{
+			"title":    name,
+			"location": handlers.LocationToMap(handlers.Location{X: plan.Area.X + plan.Area.Width + layoutGap, Y: plan.Area.Y}),
+			"size":     handlers.SizeToMap(handlers.NoteSize{Width: noteWidth, Height: noteWidth * 1.3}),
+		})
+		if err != nil {
+			im.logger.Warn("Source PDF not uploaded", zap.String("file", name), zap.Error(err))
+		} else if id, ok := pdf["id"].(string); ok {
+			result.WidgetIDs = append(result.WidgetIDs, id)
+		}
+	}
+
+	im.logger.Info("Document imported",
+		zap.String("file", name),
+		zap.String("anchor_id", result.AnchorID),
+		zap.Int("sections", result.Sections),
+		zap.Int("figures", result.Figures),
+		zap.Bool("summarized", summarized))
+	return result, nil
+}
+
+// summarize fills in the section summaries and the overview. A section the
+// model fails on shows its start instead; only cancellation stops the import.
+func (im *Importer) summarize(ctx context.Context, doc *Document) error {
+	var summaries strings.Builder
+	for i := range doc.Sections {
+		s := &doc.Sections[i]
+		reply, err := im.generate(ctx, fmt.Sprintf(sectionPrompt, doc.Title, s.Heading, truncateRunes(s.Text, maxPromptRunes)))
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if reply = strings.TrimSpace(reply); err != nil || reply == "" {
+			im.logger.Warn("Section summary failed, showing its start", zap.String("section", s.Heading), zap.Error(err))
+			reply = leadSummary(s.Text)
+		}
+		s.Summary = reply
+		fmt.Fprintf(&summaries, "%s:\n%s\n\n", s.Heading, reply)
+	}
+
+	overview, err := im.generate(ctx, fmt.Sprintf(overviewPrompt, doc.Title, truncateRunes(summaries.String(), maxPromptRunes)))
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		im.logger.Warn("Document overview failed", zap.Error(err))
+		return nil
+	}
+	doc.Overview = strings.TrimSpace(overview)
+	return nil
+}
+
+// freeLocation returns a location right of every widget on the canvas, level
+// with the topmost one.
+func (im *Importer) freeLocation() (handlers.Location, error) {
+	widgets, err := im.client.GetWidgets(false)
+	if err != nil {
+		return handlers.Location{}, fmt.Errorf("docimport: failed to fetch widgets: %w", err)
+	}
+	var loc handlers.Location
+	found := false
+	for _, w := range widgets {
+		if handlers.GetStringField(w, "widget_type", "") == "SharedCanvas" {
+			continue
+		}
+		b := handlers.WidgetBounds(w, nil)
+		if !found || b.X+b.Width+placementGap > loc.X {
+			loc.X = b.X + b.Width + placementGap
+		}
+		if !found || b.Y < loc.Y {
+			loc.Y = b.Y
+		}
+		found = true
+	}
+	return loc, nil
+}
+
+// mkdirTemp creates the directory figures are uploaded from.
+func (im *Importer) mkdirTemp() (string, error) {
+	if im.tempDir != "" {
+		if err := os.MkdirAll(im.tempDir, 0755); err != nil {
+			return "", fmt.Errorf("docimport: failed to create temp directory: %w", err)
+		}
+	}
+	dir, err := os.MkdirTemp(im.tempDir, "import-")
+	if err != nil {
+		return "", fmt.Errorf("docimport: failed to create temp directory: %w", err)
+	}
+	return dir, nil
+}
+
+// uploadFigure uploads a figure as an image widget and returns its ID.
+func (im *Importer) uploadFigure(dir string, p Placement) (string, error) {
+	path := filepath.Join(dir, filepath.Base(p.Figure.Name))
+	if err := os.WriteFile(path, p.Figure.Data, 0644); err != nil {
+		return "", err
+	}
+	img, err := im.client.CreateImage(path, map[string]interface{}{
+		"title":    p.Title,
+		"location": handlers.LocationToMap(handlers.Location{X: p.Bounds.X, Y: p.Bounds.Y}),
+		"size":     handlers.SizeToMap(handlers.NoteSize{Width: p.Bounds.Width, Height: p.Bounds.Height}),
+	})
+	if err != nil {
+		return "", err
+	}
+	id, _ := img["id"].(string)
+	return id, nil
+}
+
+// leadSummary returns the first two sentences of text, at most
+// leadSummaryRunes long.
+func leadSummary(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	sentences := 0
+	for i, r := range text {
+		if (r == '.' || r == '!' || r == '?') && (i+1 == len(text) || text[i+1] == ' ') {
+			if sentences++; sentences == 2 {
+				text = text[:i+1]
+				break
+			}
+		}
+	}
+	return truncateRunes(text, leadSummaryRunes)
+}
+
+// sourceLine describes where the layout came from.
+func sourceLine(doc *Document, name string) string {
+	parts := []string{"Imported from " + filepath.Base(name)}
+	if doc.Pages > 0 {
+		parts = append(parts, plural(doc.Pages, "page"))
+	}
+	parts = append(parts, plural(len(doc.Sections), "section"))
+	if len(doc.Figures) > 0 {
+		parts = append(parts, plural(len(doc.Figures), "figure"))
+	}
+	return strings.Join(parts, " · ")
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,127 @@
+// Package docimport provides the canvas layout of an imported document.
+// This file contains Layout, which places the title note, the section
+// notes and the figures in a grid inside the document's anchor.
+package docimport
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif" // Register decoders for the figure sizes
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+
+	"go_backend/handlers"
+)
+
+// Widget roles in a layout.
+const (
+	RoleTitle   = "title"
+	RoleSection = "section"
+	RoleFigure  = "figure"
+)
+
+// Grid geometry in canvas units.
+const (
+	layoutColumns = 3
+	noteWidth     = 400.0
+	noteHeight    = 300.0
+	titleHeight   = 260.0
+	layoutGap     = 40.0
+	layoutPadding = 80.0
+	// maxNoteRunes bounds the text of a section note without a summary
+	maxNoteRunes = 600
+)
+
+// Placement is one widget of a layout.
+type Placement struct {
+	Role   string
+	Title  string
+	Text   string
+	Figure *Figure
+	Bounds handlers.Rect
+}
+
+// Plan is the layout of a document: the area of its anchor and the widgets
+// inside it.
+type Plan struct {
+	Area    handlers.Rect
+	Widgets []Placement
+}
+
+// Layout places a document with its top-left corner at origin: the title
+// note across the top, the sections in a grid of layoutColumns below it,
+// then the figures in the same grid, each scaled to fit its cell.
+//
+// This is a pure function (molecule) with no external dependencies.
+func Layout(doc *Document, origin handlers.Location) Plan {
+	contentWidth := layoutColumns*noteWidth + (layoutColumns-1)*layoutGap
+	left := origin.X + layoutPadding
+	y := origin.Y + layoutPadding
+
+	plan := Plan{}
+	plan.Widgets = append(plan.Widgets, Placement{
+		Role:   RoleTitle,
+		Title:  doc.Title,
+		Text:   doc.Overview,
+		Bounds: handlers.Rect{X: left, Y: y, Width: contentWidth, Height: titleHeight},
+	})
+	y += titleHeight + layoutGap
+
+	cell := func(i int) handlers.Rect {
+		return handlers.Rect{
+			X:      left + float64(i%layoutColumns)*(noteWidth+layoutGap),
+			Y:      y + float64(i/layoutColumns)*(noteHeight+layoutGap),
+			Width:  noteWidth,
+			Height: noteHeight,
+		}
+	}
+	rows := func(n int) float64 {
+		return float64((n + layoutColumns - 1) / layoutColumns)
+	}
+
+	for i, s := range doc.Sections {
+		text := s.Summary
+		if text == "" {
+			text = truncateRunes(s.Text, maxNoteRunes)
+		}
+		plan.Widgets = append(plan.Widgets, Placement{Role: RoleSection, Title: s.Heading, Text: text, Bounds: cell(i)})
+	}
+	y += rows(len(doc.Sections)) * (noteHeight + layoutGap)
+
+	for i := range doc.Figures {
+		fig := &doc.Figures[i]
+		plan.Widgets = append(plan.Widgets, Placement{Role: RoleFigure, Title: fig.Name, Figure: fig, Bounds: fitFigure(fig, cell(i))})
+	}
+	y += rows(len(doc.Figures)) * (noteHeight + layoutGap)
+
+	plan.Area = handlers.Rect{
+		X:      origin.X,
+		Y:      origin.Y,
+		Width:  contentWidth + 2*layoutPadding,
+		Height: y - layoutGap + layoutPadding - origin.Y,
+	}
+	return plan
+}
+
+// fitFigure centers the largest rectangle with the figure's aspect ratio in
+// cell. Figures whose size cannot be read fill the cell.
+func fitFigure(fig *Figure, cell handlers.Rect) handlers.Rect {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(fig.Data))
+	if err != nil || cfg.Width == 0 || cfg.Height == 0 {
+		return cell
+	}
+	w, h := cell.Width, cell.Width*float64(cfg.Height)/float64(cfg.Width)
+	if h > cell.Height {
+		w, h = cell.Height*float64(cfg.Width)/float64(cfg.Height), cell.Height
+	}
+	return handlers.Rect{X: cell.X + (cell.Width-w)/2, Y: cell.Y + (cell.Height-h)/2, Width: w, Height: h}
+}
+
+// truncateRunes cuts s to at most n runes, ending in "..." when cut.
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return strings.TrimSpace(string(runes[:n-3])) + "..."
+	}
+	return s
+}
@@ -0,0 +1,52 @@
+// Package docimport provides the PDF parser. This file contains ParsePDF,
+// which extracts the text of a PDF with pdfprocessor and splits it into
+// sections.
+package docimport
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go_backend/pdfprocessor"
+)
+
+// ParsePDF parses the PDF at path. A short first line is taken as the title;
+// sections start at lines that look like headings. Figures are not
+// extracted from PDFs.
+func ParsePDF(path string) (doc *Document, err error) {
+	// The PDF reader panics on some malformed files
+	defer func() {
+		if r := recover(); r != nil {
+			doc, err = nil, fmt.Errorf("docimport: failed to read PDF: %v", r)
+		}
+	}()
+
+	result, err := pdfprocessor.NewDefaultExtractor().Extract(path)
+	if errors.Is(err, pdfprocessor.ErrNoPDFContent) {
+		return nil, ErrNoContent
+	}
+	if err != nil {
+		return nil, fmt.Errorf("docimport: failed to read PDF: %w", err)
+	}
+	doc = parseText(result.Text)
+	doc.Format = FormatPDF
+	doc.Pages = result.TotalPages
+	if len(doc.Sections) == 0 {
+		return nil, ErrNoContent
+	}
+	return doc, nil
+}
+
+// parseText splits extracted text into a title and sections.
+func parseText(text string) *Document {
+	doc := &Document{}
+	text = strings.TrimSpace(text)
+	first, rest, _ := strings.Cut(text, "\n")
+	if first = strings.TrimSpace(first); first != "" && len([]rune(first)) <= maxHeadingRunes && strings.TrimSpace(rest) != "" {
+		doc.Title = first
+		text = rest
+	}
+	doc.Sections = splitSections(text)
+	return doc
+}
@@ -0,0 +1,132 @@
+// Package events provides the internal event bus.
+//
+// The Monitor, the handlers and the system monitors publish what happens
+// (widget changes, trigger decisions, task lifecycle, alerts) as typed
+// events; the metrics store, the dashboard broadcasters, webhooks and the
+// database subscribe to the events they need. A new consumer subscribes in
+// main.go without touching the publishers.
+package events
+
+import (
+	"reflect"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// Event is implemented by every event published on the bus.
+type Event interface {
+	// EventName identifies the event in logs, e.g. "task.completed"
+	EventName() string
+}
+
+// Bus delivers published events to the subscribers of their type.
+//
+// Delivery is synchronous, in the order of subscription, on the publisher's
+// goroutine: a subscriber doing slow work (network calls) hands it off
+// itself, as the webhook dispatcher and the broadcasters already do. A
+// panicking subscriber is logged and does not stop delivery to the others.
+//
+// Usage:
+//
+//	bus := events.New(logger)
+//	events.Subscribe(bus, "webhooks", func(e events.TaskCompleted) { ... })
+//	events.Publish(bus, events.TaskCompleted{Record: record})
+//
+// A nil *Bus drops published events and ignores subscriptions.
+//
+// Thread-Safety: Bus is safe for concurrent use.
+type Bus struct {
+	logger *zap.Logger
+
+	mu     sync.RWMutex
+	nextID uint64
+	subs   map[reflect.Type][]subscriber
+}
+
+// subscriber is one subscription; fn takes the event as an Event.
+type subscriber struct {
+	id   uint64
+	name string
+	fn   func(Event)
+}
+
+// New creates a Bus. A nil logger discards subscriber panics.
+func New(logger *zap.Logger) *Bus {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Bus{
+		logger: logger,
+		subs:   make(map[reflect.Type][]subscriber),
+	}
+}
+
+// Subscribe calls fn with every event of type E published on b. name
+// identifies the subscriber in logs. It returns a function that removes
+// the subscription.
+func Subscribe[E Event](b *Bus, name string, fn func(E)) (unsubscribe func()) {
+	if b == nil || fn == nil {
+		return func() {}
+	}
+	key := reflect.TypeFor[E]()
+
+	b.mu.Lock()
+	b.nextID++
+	id := b.nextID
+	b.subs[key] = append(b.subs[key], subscriber{
+		id:   id,
+		name: name,
+		fn:   func(e Event) { fn(e.(E)) },
+	})
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		subs := b.subs[key]
+		for i, s := range subs {
+			if s.id == id {
+				b.subs[key] = append(subs[:i:i], subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers e to the subscribers of its type.
+func Publish[E Event](b *Bus, e E) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	subs := b.subs[reflect.TypeFor[E]()]
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		b.deliver(s, e)
+	}
+}
+
+// Subscribers returns the number of subscribers to events of type E.
+func Subscribers[E Event](b *Bus) int {
+	if b == nil {
+		return 0
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs[reflect.TypeFor[E]()])
+}
+
+// deliver calls s with e, recovering a panic.
+func (b *Bus) deliver(s subscriber, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Error("event subscriber panicked",
+				zap.String("event", e.EventName()),
+				zap.String("subscriber", s.name),
+				zap.Any("panic", r))
+		}
+	}()
+	s.fn(e)
+}
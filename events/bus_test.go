@@ -0,0 +1,132 @@
+package events
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"go_backend/metrics"
+)
+
+func TestPublishDeliversByType(t *testing.T) {
+	bus := New(nil)
+	var order []string
+	Subscribe(bus, "first", func(e TaskCompleted) { order = append(order, "first:"+e.Record.ID) })
+	Subscribe(bus, "second", func(e TaskCompleted) { order = append(order, "second:"+e.Record.ID) })
+	Subscribe(bus, "started", func(e TaskStarted) { order = append(order, "started:"+e.Record.ID) })
+
+	Publish(bus, TaskCompleted{Record: metrics.TaskRecord{ID: "t1"}})
+
+	if len(order) != 2 || order[0] != "first:t1" || order[1] != "second:t1" {
+		t.Errorf("delivered %q, want the TaskCompleted subscribers in order", order)
+	}
+	if n := Subscribers[TaskCompleted](bus); n != 2 {
+		t.Errorf("Subscribers[TaskCompleted]() = %d", n)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	bus := New(nil)
+	calls := 0
+	unsubscribe := Subscribe(bus, "counter", func(SLOChanged) { calls++ })
+
+	Publish(bus, SLOChanged{})
+	unsubscribe()
+	unsubscribe()
+	Publish(bus, SLOChanged{})
+
+	if calls != 1 {
+		t.Errorf("subscriber called %d times, want 1", calls)
+	}
+	if n := Subscribers[SLOChanged](bus); n != 0 {
+		t.Errorf("Subscribers[SLOChanged]() = %d after unsubscribe", n)
+	}
+}
+
+func TestPanickingSubscriber(t *testing.T) {
+	bus := New(nil)
+	delivered := false
+	Subscribe(bus, "broken", func(WorkerCrashed) { panic("boom") })
+	Subscribe(bus, "healthy", func(WorkerCrashed) { delivered = true })
+
+	Publish(bus, WorkerCrashed{})
+
+	if !delivered {
+		t.Error("a panicking subscriber stopped delivery to the next one")
+	}
+}
+
+func TestNilBus(t *testing.T) {
+	var bus *Bus
+	unsubscribe := Subscribe(bus, "ignored", func(TaskStarted) { t.Error("nil bus delivered an event") })
+	Publish(bus, TaskStarted{})
+	unsubscribe()
+	if n := Subscribers[TaskStarted](bus); n != 0 {
+		t.Errorf("Subscribers() = %d on a nil bus", n)
+	}
+}
+
+func TestConcurrentPublishAndSubscribe(t *testing.T) {
+	bus := New(nil)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			unsubscribe := Subscribe(bus, "transient", func(WidgetChanged) {})
+			unsubscribe()
+		}()
+		go func() {
+			defer wg.Done()
+			Publish(bus, WidgetChanged{Time: time.Now()})
+		}()
+	}
+	wg.Wait()
+}
+
+type recordingBroadcaster struct {
+	updates []metrics.TaskBroadcastData
+}
+
+func (b *recordingBroadcaster) BroadcastTaskUpdateFromMetrics(data metrics.TaskBroadcastData) {
+	b.updates = append(b.updates, data)
+}
+
+func TestRecordAndBroadcastTasks(t *testing.T) {
+	bus := New(nil)
+	store := metrics.NewMetricsStore(metrics.DefaultStoreConfig(), time.Now())
+	broadcaster := &recordingBroadcaster{}
+	RecordTasks(bus, "metrics", store)
+	unsubscribe := BroadcastTasks(bus, "dashboard", broadcaster)
+
+	record := metrics.TaskRecord{ID: "t1", Type: "note", CanvasID: "c1", WidgetID: "w1", Status: metrics.TaskStatusProcessing}
+	Publish(bus, TaskStarted{Record: record})
+	record.Status = metrics.TaskStatusError
+	record.ErrorMsg = "timeout"
+	record.Duration = time.Second
+	Publish(bus, TaskCompleted{Record: record})
+
+	if got := store.GetTaskMetrics().TotalProcessed; got != 1 {
+		t.Errorf("store recorded %d tasks, want 1", got)
+	}
+	if len(broadcaster.updates) != 2 {
+		t.Fatalf("broadcast %d updates, want 2", len(broadcaster.updates))
+	}
+	if got := broadcaster.updates[1]; got.Status != metrics.TaskStatusError || got.Error != "timeout" ||
+		got.WidgetID != "w1" || got.Duration != time.Second {
+		t.Errorf("completion update = %+v", got)
+	}
+
+	unsubscribe()
+	Publish(bus, TaskStarted{Record: record})
+	if len(broadcaster.updates) != 2 {
+		t.Error("broadcaster still subscribed after unsubscribe")
+	}
+
+	// Nil consumers subscribe nothing
+	RecordTasks(bus, "none", nil)
+	BroadcastTasks(bus, "none", nil)
+	if n := Subscribers[TaskStarted](bus); n != 0 {
+		t.Errorf("Subscribers[TaskStarted]() = %d", n)
+	}
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"time"
+
+	"go_backend/metrics"
+	"go_backend/workerproc"
+)
+
+// Widget events
+
+// WidgetChanged is published for every relevant Note, Image or Video
+// update from the widget stream, before it is routed to a handler.
+type WidgetChanged struct {
+	CanvasID string
+	// Widget is the update as received; subscribers must not modify it
+	Widget map[string]interface{}
+	Time   time.Time
+}
+
+// EventName implements Event.
+func (WidgetChanged) EventName() string { return "widget.changed" }
+
+// Outcomes of a trigger.
+const (
+	TriggerTriggered = "triggered"
+	TriggerSkipped   = "skipped"
+	TriggerRefused   = "refused"
+)
+
+// TriggerHandled is published when a widget update reaches an AI feature:
+// it ran, was skipped by the canvas settings or refused by the canvas
+// policy, a model restriction or the daily budget.
+type TriggerHandled struct {
+	CanvasID   string
+	WidgetID   string
+	WidgetType string
+	Feature    string
+	// Outcome is TriggerTriggered, TriggerSkipped or TriggerRefused
+	Outcome string
+	// Detail is the widget content for a triggered update, the reason otherwise
+	Detail string
+	Time   time.Time
+}
+
+// EventName implements Event.
+func (TriggerHandled) EventName() string { return "trigger.handled" }
+
+// Task lifecycle
+
+// TaskStarted is published when a handler starts an AI task. Record has
+// status processing.
+type TaskStarted struct {
+	Record metrics.TaskRecord
+}
+
+// EventName implements Event.
+func (TaskStarted) EventName() string { return "task.started" }
+
+// TaskCompleted is published when an AI task ends. Record has status
+// success or error, the end time and the duration.
+type TaskCompleted struct {
+	Record metrics.TaskRecord
+}
+
+// EventName implements Event.
+func (TaskCompleted) EventName() string { return "task.completed" }
+
+// System alerts
+
+// LatencyChanged is published when a task type's p95 latency crosses its
+// threshold in either direction.
+type LatencyChanged struct {
+	Alert metrics.LatencyAlert
+}
+
+// EventName implements Event.
+func (LatencyChanged) EventName() string { return "alert.latency" }
+
+// SLOChanged is published when an SLO becomes violated or recovers.
+type SLOChanged struct {
+	Status metrics.SLOStatus
+}
+
+// EventName implements Event.
+func (SLOChanged) EventName() string { return "alert.slo" }
+
+// WorkerCrashed is published when a supervised GPU worker process crashes,
+// before it is restarted.
+type WorkerCrashed struct {
+	Crash workerproc.Crash
+}
+
+// EventName implements Event.
+func (WorkerCrashed) EventName() string { return "alert.worker_crashed" }
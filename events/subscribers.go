@@ -0,0 +1,50 @@
+package events
+
+import "go_backend/metrics"
+
+// TaskRecorder records completed tasks, e.g. *metrics.MetricsStore.
+type TaskRecorder interface {
+	RecordTask(task metrics.TaskRecord)
+}
+
+// RecordTasks subscribes recorder to completed tasks. A nil recorder
+// subscribes nothing.
+func RecordTasks(b *Bus, name string, recorder TaskRecorder) (unsubscribe func()) {
+	if recorder == nil {
+		return func() {}
+	}
+	return Subscribe(b, name, func(e TaskCompleted) {
+		recorder.RecordTask(e.Record)
+	})
+}
+
+// BroadcastTasks subscribes broadcaster to the start and end of every
+// task. A nil broadcaster subscribes nothing.
+func BroadcastTasks(b *Bus, name string, broadcaster metrics.TaskBroadcaster) (unsubscribe func()) {
+	if broadcaster == nil {
+		return func() {}
+	}
+	started := Subscribe(b, name, func(e TaskStarted) {
+		broadcaster.BroadcastTaskUpdateFromMetrics(broadcastData(e.Record))
+	})
+	completed := Subscribe(b, name, func(e TaskCompleted) {
+		broadcaster.BroadcastTaskUpdateFromMetrics(broadcastData(e.Record))
+	})
+	return func() {
+		started()
+		completed()
+	}
+}
+
+// broadcastData converts a task record to a task update.
+func broadcastData(record metrics.TaskRecord) metrics.TaskBroadcastData {
+	return metrics.TaskBroadcastData{
+		TaskID:   record.ID,
+		TaskType: record.Type,
+		Status:   record.Status,
+		CanvasID: record.CanvasID,
+		WidgetID: record.WidgetID,
+		Duration: record.Duration,
+		Error:    record.ErrorMsg,
+	}
+}
@@ -0,0 +1,78 @@
+// Package featureflags provides the feature flag registry that gates
+// experimental handlers, so new capabilities can be rolled out one canvas
+// at a time without separate builds. This file contains the flag
+// definitions and the FEATURE_FLAGS parser.
+package featureflags
+
+import (
+	"strings"
+	"time"
+)
+
+// Experimental features gated by flags.
+const (
+	FlagImg2Img    = "img2img"    // Image-to-image generation from an existing image
+	FlagMindMap    = "mind_map"   // Mind map layout of a note and its answers
+	FlagClustering = "clustering" // Grouping of related notes into clusters
+)
+
+// Flag describes an experimental feature.
+type Flag struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Default     bool   `json:"default"`
+}
+
+// Flags lists every flag. Experimental features are off by default.
+var Flags = []Flag{
+	{Name: FlagImg2Img, Description: "Image-to-image generation from an existing image"},
+	{Name: FlagMindMap, Description: "Mind map layout of a note and its answers"},
+	{Name: FlagClustering, Description: "Grouping of related notes into clusters"},
+}
+
+// Override turns a flag on or off for one canvas, or for every canvas
+// when CanvasID is empty.
+type Override struct {
+	Flag      string    `json:"flag"`
+	CanvasID  string    `json:"canvas_id,omitempty"`
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ParseEnv parses a FEATURE_FLAGS value such as "img2img,-clustering":
+// listed flags are turned on and flags prefixed with "-" are turned off.
+// Names that are not flags are returned in unknown.
+func ParseEnv(value string) (flags map[string]bool, unknown []string) {
+	flags = make(map[string]bool)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		enabled := !strings.HasPrefix(item, "-")
+		name := strings.TrimSpace(strings.TrimPrefix(item, "-"))
+		if name == "" {
+			continue
+		}
+		if _, ok := lookup(name); !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		flags[name] = enabled
+	}
+	return flags, unknown
+}
+
+// Gates reports whether feature is an experimental feature behind a flag
+// of the same name.
+func Gates(feature string) bool {
+	_, ok := lookup(feature)
+	return ok
+}
+
+// lookup returns the definition of a flag.
+func lookup(name string) (Flag, bool) {
+	for _, f := range Flags {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Flag{}, false
+}
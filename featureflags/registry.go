@@ -0,0 +1,142 @@
+// Package featureflags provides the feature flag registry that gates
+// experimental handlers. This file contains the Registry organism, which
+// resolves each flag from the database overrides, FEATURE_FLAGS and the
+// flag defaults.
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrUnknownFlag is returned when an override names a flag that does not exist.
+var ErrUnknownFlag = errors.New("unknown feature flag")
+
+// Sources of a flag's state, from lowest to highest precedence.
+const (
+	SourceDefault = "default" // Flag definition
+	SourceEnv     = "env"     // FEATURE_FLAGS
+	SourceGlobal  = "global"  // Database override for every canvas
+	SourceCanvas  = "canvas"  // Database override for the canvas
+)
+
+// Storage persists flag overrides. Implemented by db.Repository.
+type Storage interface {
+	ListFlagOverrides(ctx context.Context) ([]Override, error)
+	SaveFlagOverride(ctx context.Context, o Override) error
+	DeleteFlagOverride(ctx context.Context, flag, canvasID string) error
+}
+
+// State is the resolved state of a flag on a canvas.
+type State struct {
+	Flag
+	Enabled bool   `json:"enabled"`
+	Source  string `json:"source"`
+}
+
+// overrideKey identifies an override.
+type overrideKey struct {
+	flag     string
+	canvasID string
+}
+
+// Registry resolves feature flags and writes overrides through to Storage.
+// A nil Registry reports every flag at its default.
+//
+// Thread-Safety: Registry is safe for concurrent use.
+type Registry struct {
+	storage Storage
+	env     map[string]bool
+
+	mu        sync.RWMutex
+	overrides map[overrideKey]Override
+}
+
+// NewRegistry creates a Registry and loads the saved overrides. env holds
+// the flags set by FEATURE_FLAGS (see ParseEnv).
+func NewRegistry(ctx context.Context, storage Storage, env map[string]bool) (*Registry, error) {
+	r := &Registry{
+		storage:   storage,
+		env:       env,
+		overrides: make(map[overrideKey]Override),
+	}
+	saved, err := storage.ListFlagOverrides(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feature flag overrides: %w", err)
+	}
+	for _, o := range saved {
+		r.overrides[overrideKey{o.Flag, o.CanvasID}] = o
+	}
+	return r, nil
+}
+
+// Enabled reports whether flag is on for canvasID.
+func (r *Registry) Enabled(flag, canvasID string) bool {
+	enabled, _ := r.resolve(flag, canvasID)
+	return enabled
+}
+
+// States returns the state of every flag on canvasID. An empty canvasID
+// gives the state shared by canvases without their own overrides.
+func (r *Registry) States(canvasID string) []State {
+	states := make([]State, 0, len(Flags))
+	for _, f := range Flags {
+		enabled, source := r.resolve(f.Name, canvasID)
+		states = append(states, State{Flag: f, Enabled: enabled, Source: source})
+	}
+	return states
+}
+
+// Set saves an override, replacing any earlier one for the same flag and canvas.
+func (r *Registry) Set(ctx context.Context, o Override) (Override, error) {
+	if _, ok := lookup(o.Flag); !ok {
+		return o, fmt.Errorf("%w: %q", ErrUnknownFlag, o.Flag)
+	}
+	o.UpdatedAt = time.Now().UTC()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.storage.SaveFlagOverride(ctx, o); err != nil {
+		return o, fmt.Errorf("failed to save feature flag override: %w", err)
+	}
+	r.overrides[overrideKey{o.Flag, o.CanvasID}] = o
+	return o, nil
+}
+
+// Clear removes the override of flag for canvasID, so the next source in
+// precedence order applies again.
+func (r *Registry) Clear(ctx context.Context, flag, canvasID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.storage.DeleteFlagOverride(ctx, flag, canvasID); err != nil {
+		return fmt.Errorf("failed to delete feature flag override: %w", err)
+	}
+	delete(r.overrides, overrideKey{flag, canvasID})
+	return nil
+}
+
+// resolve returns the state of flag on canvasID and where it came from.
+func (r *Registry) resolve(flag, canvasID string) (bool, string) {
+	def, _ := lookup(flag)
+	if r == nil {
+		return def.Default, SourceDefault
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if canvasID != "" {
+		if o, ok := r.overrides[overrideKey{flag, canvasID}]; ok {
+			return o.Enabled, SourceCanvas
+		}
+	}
+	if o, ok := r.overrides[overrideKey{flag, ""}]; ok {
+		return o.Enabled, SourceGlobal
+	}
+	if enabled, ok := r.env[flag]; ok {
+		return enabled, SourceEnv
+	}
+	return def.Default, SourceDefault
+}
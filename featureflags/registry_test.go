@@ -0,0 +1,127 @@
+package featureflags
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// memoryStorage keeps overrides in a map
+type memoryStorage struct {
+	saved map[overrideKey]Override
+}
+
+func newMemoryStorage(overrides ...Override) *memoryStorage {
+	m := &memoryStorage{saved: make(map[overrideKey]Override)}
+	for _, o := range overrides {
+		m.saved[overrideKey{o.Flag, o.CanvasID}] = o
+	}
+	return m
+}
+
+func (m *memoryStorage) ListFlagOverrides(ctx context.Context) ([]Override, error) {
+	var out []Override
+	for _, o := range m.saved {
+		out = append(out, o)
+	}
+	return out, nil
+}
+
+func (m *memoryStorage) SaveFlagOverride(ctx context.Context, o Override) error {
+	m.saved[overrideKey{o.Flag, o.CanvasID}] = o
+	return nil
+}
+
+func (m *memoryStorage) DeleteFlagOverride(ctx context.Context, flag, canvasID string) error {
+	delete(m.saved, overrideKey{flag, canvasID})
+	return nil
+}
+
+func TestParseEnv(t *testing.T) {
+	flags, unknown := ParseEnv(" img2img, -clustering ,,teleport")
+	want := map[string]bool{FlagImg2Img: true, FlagClustering: false}
+	if !reflect.DeepEqual(flags, want) {
+		t.Errorf("flags = %v, want %v", flags, want)
+	}
+	if !reflect.DeepEqual(unknown, []string{"teleport"}) {
+		t.Errorf("unknown = %v", unknown)
+	}
+}
+
+func TestRegistryPrecedence(t *testing.T) {
+	storage := newMemoryStorage(
+		Override{Flag: FlagMindMap, Enabled: true},
+		Override{Flag: FlagMindMap, CanvasID: "prod", Enabled: false},
+	)
+	env := map[string]bool{FlagImg2Img: true, FlagMindMap: false}
+	r, err := NewRegistry(context.Background(), storage, env)
+	if err != nil {
+		t.Fatalf("NewRegistry() error: %v", err)
+	}
+
+	tests := []struct {
+		flag, canvas string
+		want         bool
+		source       string
+	}{
+		{FlagClustering, "demo", false, SourceDefault},
+		{FlagImg2Img, "demo", true, SourceEnv},
+		{FlagMindMap, "demo", true, SourceGlobal},
+		{FlagMindMap, "prod", false, SourceCanvas},
+		{FlagMindMap, "", true, SourceGlobal},
+	}
+	for _, tt := range tests {
+		enabled, source := r.resolve(tt.flag, tt.canvas)
+		if enabled != tt.want || source != tt.source {
+			t.Errorf("resolve(%s, %q) = %v, %s; want %v, %s", tt.flag, tt.canvas, enabled, source, tt.want, tt.source)
+		}
+	}
+
+	var nilRegistry *Registry
+	if nilRegistry.Enabled(FlagImg2Img, "demo") {
+		t.Error("a nil registry should report flag defaults")
+	}
+}
+
+func TestRegistrySetAndClear(t *testing.T) {
+	storage := newMemoryStorage()
+	r, _ := NewRegistry(context.Background(), storage, nil)
+
+	if _, err := r.Set(context.Background(), Override{Flag: FlagClustering, CanvasID: "demo", Enabled: true}); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+	if !r.Enabled(FlagClustering, "demo") || r.Enabled(FlagClustering, "prod") {
+		t.Error("Set() should only enable the flag on demo")
+	}
+	if _, ok := storage.saved[overrideKey{FlagClustering, "demo"}]; !ok {
+		t.Error("Set() should save the override")
+	}
+
+	if _, err := r.Set(context.Background(), Override{Flag: "teleport"}); !errors.Is(err, ErrUnknownFlag) {
+		t.Errorf("Set(unknown) = %v, want ErrUnknownFlag", err)
+	}
+
+	if err := r.Clear(context.Background(), FlagClustering, "demo"); err != nil {
+		t.Fatalf("Clear() error: %v", err)
+	}
+	if r.Enabled(FlagClustering, "demo") || len(storage.saved) != 0 {
+		t.Error("Clear() should remove the override")
+	}
+
+	states := r.States("demo")
+	if len(states) != len(Flags) || states[0].Name != FlagImg2Img || states[0].Source != SourceDefault {
+		t.Errorf("States() = %+v", states)
+	}
+}
+
+func TestGates(t *testing.T) {
+	for _, feature := range []string{FlagImg2Img, FlagMindMap, FlagClustering} {
+		if !Gates(feature) {
+			t.Errorf("Gates(%q) = false", feature)
+		}
+	}
+	if Gates("notes") {
+		t.Error("Gates(notes) = true for a feature without a flag")
+	}
+}
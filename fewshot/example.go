@@ -0,0 +1,88 @@
+// Package fewshot provides few-shot examples: sample requests and the
+// responses a model should give, kept in the database per task type and
+// edited from the dashboard, that are attached to the system prompts of
+// the AI tasks. They help small local models keep to the expected JSON and
+// style without code changes. This file contains the Example type.
+package fewshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Task types that take few-shot examples. They match the prompt names of
+// the i18n package.
+const (
+	TaskNote             = "note"              // Note trigger classification and answers
+	TaskImageDescription = "image_description" // AI_Icon_Image_Analysis on one image
+	TaskImageComparison  = "image_comparison"  // AI_Icon_Image_Analysis on two images
+	TaskCanvasAnalysis   = "canvas_analysis"   // AI_Icon_CanvusPrecis
+)
+
+// Tasks lists every task type, in display order.
+var Tasks = []string{TaskNote, TaskImageDescription, TaskImageComparison, TaskCanvasAnalysis}
+
+// jsonTasks are the task types whose responses must be JSON.
+var jsonTasks = map[string]bool{TaskNote: true}
+
+// ErrInvalidExample is returned for examples that cannot be saved.
+var ErrInvalidExample = errors.New("invalid few-shot example")
+
+// maxTextBytes bounds the input and the output of an example.
+const maxTextBytes = 4 << 10
+
+// Example is one sample request and the response expected for it.
+type Example struct {
+	// ID is 0 for an example not saved yet
+	ID   int64  `json:"id"`
+	Task string `json:"task"`
+	// Input is the request as the model sees it, e.g. the note prompt
+	Input string `json:"input"`
+	// Output is the response the model should give
+	Output string `json:"output"`
+	// Disabled examples are kept but not attached to prompts
+	Disabled  bool      `json:"disabled,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// IsTask reports whether name is a task type that takes examples.
+func IsTask(name string) bool {
+	for _, t := range Tasks {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize trims the fields.
+func (e Example) normalize() Example {
+	e.Task = strings.ToLower(strings.TrimSpace(e.Task))
+	e.Input = strings.TrimSpace(e.Input)
+	e.Output = strings.TrimSpace(e.Output)
+	return e
+}
+
+// Validate reports the first problem with the example. Examples of tasks
+// that answer in JSON must have a JSON object as output.
+func (e Example) Validate() error {
+	if !IsTask(e.Task) {
+		return fmt.Errorf("%w: unknown task %q (use %s)", ErrInvalidExample, e.Task, strings.Join(Tasks, ", "))
+	}
+	if e.Input == "" || e.Output == "" {
+		return fmt.Errorf("%w: input and output are required", ErrInvalidExample)
+	}
+	if len(e.Input) > maxTextBytes || len(e.Output) > maxTextBytes {
+		return fmt.Errorf("%w: input and output must be at most %d bytes each", ErrInvalidExample, maxTextBytes)
+	}
+	if jsonTasks[e.Task] {
+		var obj map[string]interface{}
+		if err := json.Unmarshal([]byte(e.Output), &obj); err != nil {
+			return fmt.Errorf("%w: output of %s examples must be a JSON object: %v", ErrInvalidExample, e.Task, err)
+		}
+	}
+	return nil
+}
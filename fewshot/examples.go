@@ -0,0 +1,150 @@
+// Package fewshot provides few-shot examples for the system prompts of AI
+// tasks. This file contains the Set organism, which caches the examples,
+// writes changes through to the database and attaches them to prompts.
+package fewshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"go_backend/core/clock"
+)
+
+// MaxPerTask is the most examples attached to one prompt; further
+// examples are kept but not used.
+const MaxPerTask = 5
+
+// ErrUnknownExample is returned for an ID that has no example.
+var ErrUnknownExample = errors.New("unknown few-shot example")
+
+// Storage persists few-shot examples. Implemented by db.Repository.
+type Storage interface {
+	ListFewShotExamples(ctx context.Context) ([]Example, error)
+	// SaveFewShotExample inserts e if its ID is 0, otherwise replaces it,
+	// and returns its ID.
+	SaveFewShotExample(ctx context.Context, e Example) (int64, error)
+	DeleteFewShotExample(ctx context.Context, id int64) error
+}
+
+// Set holds every example in memory and writes changes through to Storage.
+//
+// Thread-Safety: Set is safe for concurrent use.
+type Set struct {
+	storage Storage
+	clock   clock.Clock
+
+	mu       sync.RWMutex
+	examples map[int64]Example
+}
+
+// NewSet creates a Set and loads the saved examples.
+func NewSet(ctx context.Context, storage Storage) (*Set, error) {
+	s := &Set{
+		storage:  storage,
+		clock:    clock.Real(),
+		examples: make(map[int64]Example),
+	}
+	saved, err := storage.ListFewShotExamples(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load few-shot examples: %w", err)
+	}
+	for _, e := range saved {
+		s.examples[e.ID] = e
+	}
+	return s, nil
+}
+
+// SetClock sets the clock that stamps saved examples (default:
+// clock.Real). Call it before the set is used.
+func (s *Set) SetClock(c clock.Clock) {
+	s.clock = clock.OrReal(c)
+}
+
+// List returns the examples of task, or of every task if task is empty,
+// by task and then in the order they were added.
+func (s *Set) List(task string) []Example {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]Example, 0, len(s.examples))
+	for _, e := range s.examples {
+		if task == "" || e.Task == task {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Task != out[j].Task {
+			return out[i].Task < out[j].Task
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Put validates and saves e. An example with ID 0 is added; otherwise the
+// example with that ID is replaced. The saved example is returned.
+func (s *Set) Put(ctx context.Context, e Example) (Example, error) {
+	e = e.normalize()
+	if err := e.Validate(); err != nil {
+		return e, err
+	}
+	e.UpdatedAt = s.clock.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.examples[e.ID]; e.ID != 0 && !ok {
+		return e, fmt.Errorf("%w: %d", ErrUnknownExample, e.ID)
+	}
+	id, err := s.storage.SaveFewShotExample(ctx, e)
+	if err != nil {
+		return e, fmt.Errorf("failed to save few-shot example: %w", err)
+	}
+	e.ID = id
+	s.examples[id] = e
+	return e, nil
+}
+
+// Delete removes the example with id.
+func (s *Set) Delete(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.examples[id]; !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownExample, id)
+	}
+	if err := s.storage.DeleteFewShotExample(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete few-shot example: %w", err)
+	}
+	delete(s.examples, id)
+	return nil
+}
+
+// Apply returns systemPrompt with the enabled examples of task appended,
+// at most MaxPerTask of them. Without examples systemPrompt is returned
+// unchanged. A nil Set attaches nothing.
+func (s *Set) Apply(task, systemPrompt string) string {
+	if s == nil {
+		return systemPrompt
+	}
+	var b strings.Builder
+	n := 0
+	for _, e := range s.List(task) {
+		if e.Disabled {
+			continue
+		}
+		if n == 0 {
+			b.WriteString(systemPrompt)
+			b.WriteString("\n\nExamples of requests and the expected responses:")
+		}
+		fmt.Fprintf(&b, "\n\nRequest:\n%s\nResponse:\n%s", e.Input, e.Output)
+		if n++; n == MaxPerTask {
+			break
+		}
+	}
+	if n == 0 {
+		return systemPrompt
+	}
+	return b.String()
+}
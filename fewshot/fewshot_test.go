@@ -0,0 +1,121 @@
+package fewshot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// memStorage keeps examples in memory
+type memStorage struct {
+	examples map[int64]Example
+	nextID   int64
+}
+
+func (m *memStorage) ListFewShotExamples(ctx context.Context) ([]Example, error) {
+	var out []Example
+	for _, e := range m.examples {
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func (m *memStorage) SaveFewShotExample(ctx context.Context, e Example) (int64, error) {
+	if e.ID == 0 {
+		m.nextID++
+		e.ID = m.nextID
+	}
+	m.examples[e.ID] = e
+	return e.ID, nil
+}
+
+func (m *memStorage) DeleteFewShotExample(ctx context.Context, id int64) error {
+	delete(m.examples, id)
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		example Example
+		ok      bool
+	}{
+		{Example{Task: TaskNote, Input: "draw a cat", Output: `{"type":"image","content":"a cat"}`}, true},
+		{Example{Task: TaskNote, Input: "draw a cat", Output: "a cat"}, false},
+		{Example{Task: TaskCanvasAnalysis, Input: "notes", Output: "# Overview"}, true},
+		{Example{Task: "pdf", Input: "x", Output: "y"}, false},
+		{Example{Task: TaskImageDescription, Input: "x"}, false},
+		{Example{Task: TaskImageDescription, Input: "x", Output: strings.Repeat("y", maxTextBytes+1)}, false},
+	}
+	for _, tt := range tests {
+		err := tt.example.Validate()
+		if (err == nil) != tt.ok {
+			t.Errorf("Validate(%+v) = %v, want ok=%v", tt.example, err, tt.ok)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidExample) {
+			t.Errorf("Validate() error %v is not ErrInvalidExample", err)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	ctx := context.Background()
+	storage := &memStorage{examples: map[int64]Example{}}
+	s, err := NewSet(ctx, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.Apply(TaskNote, "base"); got != "base" {
+		t.Errorf("Apply() without examples = %q", got)
+	}
+
+	first, err := s.Put(ctx, Example{Task: " Note ", Input: " draw a cat ", Output: `{"type":"image","content":"a cat"}`})
+	if err != nil || first.ID == 0 || first.Task != TaskNote || first.Input != "draw a cat" {
+		t.Fatalf("Put() = %+v, %v", first, err)
+	}
+	if _, err := s.Put(ctx, Example{Task: TaskNote, Input: "hi", Output: `{"type":"text","content":"Hello!"}`, Disabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Put(ctx, Example{ID: 99, Task: TaskNote, Input: "x", Output: "{}"}); !errors.Is(err, ErrUnknownExample) {
+		t.Errorf("Put(unknown id) = %v", err)
+	}
+
+	got := s.Apply(TaskNote, "base")
+	want := "base\n\nExamples of requests and the expected responses:\n\nRequest:\ndraw a cat\nResponse:\n{\"type\":\"image\",\"content\":\"a cat\"}"
+	if got != want {
+		t.Errorf("Apply() = %q, want %q", got, want)
+	}
+	if got := s.Apply(TaskCanvasAnalysis, "base"); got != "base" {
+		t.Errorf("Apply(other task) = %q", got)
+	}
+
+	for i := 0; i < MaxPerTask+2; i++ {
+		s.Put(ctx, Example{Task: TaskImageDescription, Input: fmt.Sprintf("in%d", i), Output: "out"})
+	}
+	if n := strings.Count(s.Apply(TaskImageDescription, "base"), "Request:"); n != MaxPerTask {
+		t.Errorf("Apply() attached %d examples, want %d", n, MaxPerTask)
+	}
+
+	// Examples survive a reload
+	reloaded, _ := NewSet(ctx, storage)
+	if len(reloaded.List("")) != len(s.List("")) {
+		t.Errorf("reloaded %d examples, want %d", len(reloaded.List("")), len(s.List("")))
+	}
+
+	if err := s.Delete(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, first.ID); !errors.Is(err, ErrUnknownExample) {
+		t.Errorf("Delete() twice = %v", err)
+	}
+	if got := s.Apply(TaskNote, "base"); got != "base" {
+		t.Errorf("Apply() with only a disabled example = %q", got)
+	}
+
+	var nilSet *Set
+	if got := nilSet.Apply(TaskNote, "base"); got != "base" {
+		t.Errorf("nil Apply() = %q", got)
+	}
+}
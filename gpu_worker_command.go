@@ -0,0 +1,148 @@
+// Package main provides the gpu-worker subcommand, which runs Stable
+// Diffusion and llama.cpp in a child process of the server when
+// GPU_ISOLATION=process.
+//
+// Usage (started by the server, not by hand):
+//
+//	canvuslocallm gpu-worker
+//
+// The worker loads the models whose paths the server passes it
+// (SD_MODEL_PATH, LLAMA_MODEL_PATH) and serves the sd-worker and
+// llamaworker protocols on GPU_WORKER_ADDR, which the server sets to a
+// loopback address with a GPU_WORKER_TOKEN of its own. It exits when its
+// standard input closes, so it never outlives the server.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go_backend/core"
+	"go_backend/deterministic"
+	"go_backend/llamaruntime"
+	"go_backend/llamaworker"
+	"go_backend/logging"
+	"go_backend/sdruntime"
+	"go_backend/sdworker"
+	"go_backend/workerproc"
+
+	"go.uber.org/zap"
+)
+
+// runGPUWorkerCommand runs the gpu-worker subcommand and returns the
+// process exit code.
+func runGPUWorkerCommand(args []string) int {
+	logger, err := logging.NewLoggerWithConfig(os.Getenv("DEV_MODE") == "true", "gpu-worker.log", logging.FileWriterConfigFromEnv())
+	if err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+		return core.ExitCodeError
+	}
+	defer logger.Sync()
+
+	// Ctrl+C reaches the whole process group; the server decides when the
+	// worker stops, after the requests in progress
+	signal.Ignore(os.Interrupt, syscall.SIGTERM)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		io.Copy(io.Discard, os.Stdin)
+		cancel()
+	}()
+
+	if err := serveGPUWorker(ctx, logger); err != nil {
+		logger.Error("GPU worker stopped", zap.Error(err))
+		return core.ExitCodeError
+	}
+	return core.ExitCodeSuccess
+}
+
+// serveGPUWorker serves the SD context pool and the llama client until
+// ctx is done.
+func serveGPUWorker(ctx context.Context, logger *logging.Logger) error {
+	isolation, _ := workerproc.ConfigFromEnv()
+	sdConfig := sdruntime.LoadSDConfig()
+	if sdConfig.ModelPath == "" && os.Getenv("LLAMA_MODEL_PATH") == "" {
+		return errors.New("neither SD_MODEL_PATH nor LLAMA_MODEL_PATH is set")
+	}
+	if mode, _ := deterministic.ConfigFromEnv(); mode.Enabled {
+		sdruntime.SetFixedSeed(mode.Seed)
+		llamaruntime.SetSamplerSeed(mode.SamplerSeed())
+	}
+
+	mux := http.NewServeMux()
+	idleUnloader := core.NewIdleUnloader(time.Minute, func(name string) {
+		logger.Info("Unloaded idle model from VRAM", zap.String("model", name))
+	})
+	drain := time.Duration(0)
+
+	if sdConfig.ModelPath != "" {
+		pool, err := sdruntime.NewContextPool(sdConfig.MaxConcurrent, sdConfig.ModelPath)
+		if err != nil {
+			return fmt.Errorf("create SD context pool: %w", err)
+		}
+		defer pool.Close()
+		idleUnloader.Register("stable-diffusion", pool, time.Duration(core.ParseIntEnv("SD_IDLE_TIMEOUT", 0))*time.Minute)
+
+		sdServer := sdworker.NewServer(sdworker.Config{Token: isolation.Token, Timeout: sdConfig.Timeout}, pool, logger.Zap())
+		mux.Handle(sdworker.PathGenerate, sdServer)
+		mux.Handle(sdworker.PathHealth, sdServer)
+		drain = sdConfig.Timeout
+	}
+
+	// The model is loaded before the worker listens, so the server sees it
+	// ready only once it can answer
+	llamaClient, healthChecker, gpuMonitor, err := initializeLlamaRuntime(logger, ctx)
+	if err != nil {
+		return fmt.Errorf("initialize llamaruntime: %w", err)
+	}
+	if llamaClient != nil {
+		defer llamaClient.Close()
+		if healthChecker != nil {
+			defer healthChecker.Stop()
+		}
+		if gpuMonitor != nil {
+			defer gpuMonitor.Stop()
+		}
+		idleUnloader.Register("llama", llamaClient, time.Duration(core.ParseIntEnv("LLAMA_IDLE_TIMEOUT", 0))*time.Minute)
+
+		mux.Handle(llamaworker.PathPrefix, llamaworker.NewServer(llamaworker.Config{Token: isolation.Token}, llamaClient, logger.Zap()))
+		drain = max(drain, llamaruntime.DefaultTimeout)
+	}
+
+	if idleUnloader.Len() > 0 {
+		idleUnloader.Start(ctx)
+		defer idleUnloader.Stop()
+	}
+
+	server := &http.Server{
+		Addr:              isolation.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.ListenAndServe() }()
+	logger.Info("GPU worker listening",
+		zap.String("addr", isolation.Addr),
+		zap.Bool("stable_diffusion", sdConfig.ModelPath != ""),
+		zap.Bool("llama", llamaClient != nil))
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		logger.Info("Server closed the GPU worker's input, shutting down")
+	}
+
+	// Let requests in progress finish
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), drain+5*time.Second)
+	defer shutdownCancel()
+	return server.Shutdown(shutdownCtx)
+}
@@ -0,0 +1,208 @@
+// Package groupsummary provides the {{summarize}} trigger, which summarizes
+// the notes grouped with the trigger note: the children of the widget it is
+// attached to, or the notes in the anchor it lies in. This file contains
+// the lookup of the group.
+package groupsummary
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"go_backend/handlers"
+)
+
+// Kinds of group.
+const (
+	// KindParent is a group of widgets attached to the same parent widget
+	KindParent = "parent"
+	// KindAnchor is a group of notes whose centers lie in the same anchor
+	KindAnchor = "anchor"
+)
+
+// ErrTriggerNotFound is returned by Find when the trigger note is not
+// among the widgets, e.g. because it was deleted.
+var ErrTriggerNotFound = errors.New("groupsummary: trigger note not found")
+
+// ErrNotInGroup is returned by Find when the trigger note is neither
+// attached to a widget nor inside an anchor.
+var ErrNotInGroup = errors.New("groupsummary: the note is not in a group or anchor")
+
+// Member is a note of a group.
+type Member struct {
+	ID    string
+	Title string
+	Text  string
+	// Bounds places the member in reading order
+	Bounds handlers.Rect
+}
+
+// Group is the notes grouped with a trigger note.
+type Group struct {
+	Kind string
+	// ID of the parent widget or anchor
+	ID string
+	// Name of the anchor, or title of the parent widget (may be empty)
+	Name string
+	// Bounds covers the parent or anchor and its members; the summary is
+	// placed to the right of it
+	Bounds handlers.Rect
+	// Members are the notes with text other than the trigger, in reading
+	// order
+	Members []Member
+}
+
+// Find returns the group of the note triggerID among widgets. A note
+// attached to a widget other than an anchor is grouped with the other notes
+// attached to it, and with the widget itself if it is a note; otherwise it
+// is grouped with the notes inside its anchor, or the smallest anchor it
+// lies in.
+//
+// This is a pure function (molecule) with no external dependencies.
+func Find(widgets []map[string]interface{}, triggerID string) (*Group, error) {
+	byID := make(map[string]map[string]interface{}, len(widgets))
+	for _, w := range widgets {
+		if id := handlers.GetStringField(w, "id", ""); id != "" {
+			byID[id] = w
+		}
+	}
+	trigger, ok := byID[triggerID]
+	if !ok {
+		return nil, ErrTriggerNotFound
+	}
+
+	var group *Group
+	parent, attached := byID[handlers.GetStringField(trigger, "parent_id", "")]
+	switch {
+	case attached && widgetType(parent) == "Anchor":
+		// Notes placed in an anchor need not be attached to it
+		group = anchorGroup(parent, widgets, byID, triggerID)
+	case attached && widgetType(parent) != "SharedCanvas":
+		group = parentGroup(parent, widgets, byID, triggerID)
+	default:
+		anchor := smallestAnchor(widgets, byID, bounds(trigger, byID))
+		if anchor == nil {
+			return nil, ErrNotInGroup
+		}
+		group = anchorGroup(anchor, widgets, byID, triggerID)
+	}
+
+	sort.SliceStable(group.Members, func(i, j int) bool {
+		a, b := group.Members[i].Bounds, group.Members[j].Bounds
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+	return group, nil
+}
+
+// parentGroup groups the notes attached to parent, and parent itself.
+func parentGroup(parent map[string]interface{}, widgets []map[string]interface{}, byID map[string]map[string]interface{}, triggerID string) *Group {
+	parentID := handlers.GetStringField(parent, "id", "")
+	g := &Group{
+		Kind:   KindParent,
+		ID:     parentID,
+		Name:   strings.TrimSpace(handlers.GetStringField(parent, "title", handlers.GetStringField(parent, "anchor_name", ""))),
+		Bounds: bounds(parent, byID),
+	}
+	if m, ok := member(parent, byID); ok {
+		g.Members = append(g.Members, m)
+	}
+	for _, w := range widgets {
+		if handlers.GetStringField(w, "parent_id", "") != parentID || handlers.GetStringField(w, "id", "") == triggerID {
+			continue
+		}
+		if m, ok := member(w, byID); ok {
+			g.add(m)
+		}
+	}
+	return g
+}
+
+// anchorGroup groups the notes whose centers lie inside anchor.
+func anchorGroup(anchor map[string]interface{}, widgets []map[string]interface{}, byID map[string]map[string]interface{}, triggerID string) *Group {
+	area := bounds(anchor, byID)
+	g := &Group{
+		Kind:   KindAnchor,
+		ID:     handlers.GetStringField(anchor, "id", ""),
+		Name:   strings.TrimSpace(handlers.GetStringField(anchor, "anchor_name", "")),
+		Bounds: area,
+	}
+	for _, w := range widgets {
+		if handlers.GetStringField(w, "id", "") == triggerID {
+			continue
+		}
+		if m, ok := member(w, byID); ok && contains(area, m.Bounds) {
+			g.add(m)
+		}
+	}
+	return g
+}
+
+// add appends m, growing the group bounds to cover it.
+func (g *Group) add(m Member) {
+	g.Members = append(g.Members, m)
+	b := g.Bounds
+	right, bottom := max(b.X+b.Width, m.Bounds.X+m.Bounds.Width), max(b.Y+b.Height, m.Bounds.Y+m.Bounds.Height)
+	b.X, b.Y = min(b.X, m.Bounds.X), min(b.Y, m.Bounds.Y)
+	b.Width, b.Height = right-b.X, bottom-b.Y
+	g.Bounds = b
+}
+
+// smallestAnchor returns the smallest anchor containing the center of r,
+// or nil if none does.
+func smallestAnchor(widgets []map[string]interface{}, byID map[string]map[string]interface{}, r handlers.Rect) map[string]interface{} {
+	var best map[string]interface{}
+	bestArea := 0.0
+	for _, w := range widgets {
+		if widgetType(w) != "Anchor" {
+			continue
+		}
+		area := bounds(w, byID)
+		if !contains(area, r) {
+			continue
+		}
+		if size := area.Width * area.Height; best == nil || size < bestArea {
+			best, bestArea = w, size
+		}
+	}
+	return best
+}
+
+// member returns the member of a note with text; ok is false for other
+// widgets.
+func member(w map[string]interface{}, byID map[string]map[string]interface{}) (Member, bool) {
+	if widgetType(w) != "Note" {
+		return Member{}, false
+	}
+	text := strings.TrimSpace(handlers.GetStringField(w, "text", ""))
+	if text == "" {
+		return Member{}, false
+	}
+	return Member{
+		ID:     handlers.GetStringField(w, "id", ""),
+		Title:  strings.TrimSpace(handlers.GetStringField(w, "title", "")),
+		Text:   text,
+		Bounds: bounds(w, byID),
+	}, true
+}
+
+// bounds returns a widget's rectangle in canvas coordinates, resolving a
+// location relative to a parent widget.
+func bounds(w map[string]interface{}, byID map[string]map[string]interface{}) handlers.Rect {
+	if parent, ok := byID[handlers.GetStringField(w, "parent_id", "")]; ok && widgetType(parent) != "SharedCanvas" {
+		return handlers.WidgetBounds(w, parent)
+	}
+	return handlers.WidgetBounds(w, nil)
+}
+
+// contains reports whether the center of r lies inside area.
+func contains(area, r handlers.Rect) bool {
+	cx, cy := r.X+r.Width/2, r.Y+r.Height/2
+	return cx >= area.X && cx <= area.X+area.Width && cy >= area.Y && cy <= area.Y+area.Height
+}
+
+func widgetType(w map[string]interface{}) string {
+	return handlers.GetStringField(w, "widget_type", handlers.GetStringField(w, "type", ""))
+}
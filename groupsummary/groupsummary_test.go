@@ -0,0 +1,104 @@
+package groupsummary
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// widget builds a widget with a location and size
+func widget(id, kind, parentID string, x, y, w, h float64, fields ...string) map[string]interface{} {
+	m := map[string]interface{}{
+		"id":          id,
+		"widget_type": kind,
+		"parent_id":   parentID,
+		"location":    map[string]interface{}{"x": x, "y": y},
+		"size":        map[string]interface{}{"width": w, "height": h},
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		m[fields[i]] = fields[i+1]
+	}
+	return m
+}
+
+func TestFindAnchorGroup(t *testing.T) {
+	widgets := []map[string]interface{}{
+		widget("canvas", "SharedCanvas", "", 0, 0, 10000, 10000),
+		widget("board", "Anchor", "canvas", 0, 0, 2000, 2000, "anchor_name", "Board"),
+		widget("ideas", "Anchor", "canvas", 100, 100, 1000, 1000, "anchor_name", "Ideas"),
+		widget("trigger", "Note", "canvas", 500, 500, 100, 100, "text", "{{summarize}}"),
+		widget("b", "Note", "canvas", 600, 200, 100, 100, "text", "Second"),
+		widget("a", "Note", "canvas", 200, 200, 100, 100, "text", "First"),
+		widget("empty", "Note", "canvas", 300, 300, 100, 100, "text", " "),
+		widget("outside", "Note", "canvas", 1500, 1500, 100, 100, "text", "In Board only"),
+	}
+	g, err := Find(widgets, "trigger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The smallest anchor wins, and members are in reading order
+	if g.Kind != KindAnchor || g.Name != "Ideas" || len(g.Members) != 2 || g.Members[0].ID != "a" || g.Members[1].ID != "b" {
+		t.Errorf("Find() = %+v", g)
+	}
+
+	if _, err := Find(widgets, "missing"); !errors.Is(err, ErrTriggerNotFound) {
+		t.Errorf("Find(missing) = %v, want ErrTriggerNotFound", err)
+	}
+	widgets = append(widgets, widget("alone", "Note", "canvas", 5000, 5000, 100, 100, "text", "{{summarize}}"))
+	if _, err := Find(widgets, "alone"); !errors.Is(err, ErrNotInGroup) {
+		t.Errorf("Find(alone) = %v, want ErrNotInGroup", err)
+	}
+}
+
+func TestFindParentGroup(t *testing.T) {
+	widgets := []map[string]interface{}{
+		widget("canvas", "SharedCanvas", "", 0, 0, 10000, 10000),
+		widget("topic", "Note", "canvas", 1000, 1000, 400, 200, "text", "Topic"),
+		// Children are placed relative to their parent
+		widget("trigger", "Note", "topic", 0, 300, 100, 100, "text", "{{summarize}}"),
+		widget("c1", "Note", "topic", 500, 0, 100, 100, "text", "Child"),
+		widget("other", "Note", "canvas", 1100, 1100, 100, 100, "text", "Not attached"),
+	}
+	g, err := Find(widgets, "trigger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Kind != KindParent || g.ID != "topic" || len(g.Members) != 2 || g.Members[0].ID != "topic" || g.Members[1].ID != "c1" {
+		t.Errorf("Find() = %+v", g)
+	}
+	// The bounds grow to cover the children
+	if right := g.Bounds.X + g.Bounds.Width; right < 1600 {
+		t.Errorf("group bounds %+v do not cover the child", g.Bounds)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	g := &Group{Name: "Ideas", Members: []Member{
+		{Text: "First idea"},
+		{Title: "Risks", Text: "Second idea"},
+		{Text: strings.Repeat("x", 50)},
+	}}
+	prompt, included := Prompt(g, nil, 0)
+	if included != 3 || !strings.HasPrefix(prompt, "Group: Ideas") || !strings.Contains(prompt, "## Note 2\nRisks\nSecond idea") {
+		t.Errorf("Prompt() = %q, %d", prompt, included)
+	}
+
+	prompt, included = Prompt(g, []string{"clean", "clean", "clean"}, 0)
+	if strings.Contains(prompt, "idea") || included != 3 {
+		t.Errorf("Prompt(texts) = %q", prompt)
+	}
+
+	// Notes that do not fit are left out
+	if _, included := Prompt(g, nil, 30); included != 2 {
+		t.Errorf("Prompt(30 runes) included %d notes, want 2", included)
+	}
+	if prompt, included := Prompt(&Group{Members: []Member{{Text: strings.Repeat("y", 100)}}}, nil, 10); included != 1 || strings.Count(prompt, "y") != 10 {
+		t.Errorf("Prompt(long note) = %q, %d", prompt, included)
+	}
+
+	for in, want := range map[string]bool{"summarize": true, " Summarise ": true, "summarize the PDF": false, "summary": false} {
+		if got := ParseTrigger(in); got != want {
+			t.Errorf("ParseTrigger(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+// Package grpcapi provides the CanvusLLM gRPC service.
+// This file contains the message atoms of canvusllm.proto with their
+// protobuf encoding. Field numbers must match the .proto file.
+package grpcapi
+
+// message is a protobuf message of canvusllm.proto.
+type message interface {
+	marshal() []byte
+	unmarshal(data []byte) error
+}
+
+// SubmitTaskRequest asks for a prompt note to be created on a canvas.
+type SubmitTaskRequest struct {
+	Prompt   string
+	CanvasID string
+	X, Y     float64
+}
+
+func (m *SubmitTaskRequest) marshal() []byte {
+	var e encoder
+	e.string(1, m.Prompt)
+	e.string(2, m.CanvasID)
+	e.double(3, m.X)
+	e.double(4, m.Y)
+	return e.buf
+}
+
+func (m *SubmitTaskRequest) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		switch {
+		case f.num == 1 && f.isBytes():
+			m.Prompt = f.string()
+		case f.num == 2 && f.isBytes():
+			m.CanvasID = f.string()
+		case f.num == 3 && f.isFixed64():
+			m.X = f.double()
+		case f.num == 4 && f.isFixed64():
+			m.Y = f.double()
+		}
+		return nil
+	})
+}
+
+// SubmitTaskResponse identifies a submitted task.
+type SubmitTaskResponse struct {
+	TaskID   string
+	CanvasID string
+}
+
+func (m *SubmitTaskResponse) marshal() []byte {
+	var e encoder
+	e.string(1, m.TaskID)
+	e.string(2, m.CanvasID)
+	return e.buf
+}
+
+func (m *SubmitTaskResponse) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		switch {
+		case f.num == 1 && f.isBytes():
+			m.TaskID = f.string()
+		case f.num == 2 && f.isBytes():
+			m.CanvasID = f.string()
+		}
+		return nil
+	})
+}
+
+// GetTaskRequest asks for the state of one task.
+type GetTaskRequest struct {
+	TaskID string
+}
+
+func (m *GetTaskRequest) marshal() []byte {
+	var e encoder
+	e.string(1, m.TaskID)
+	return e.buf
+}
+
+func (m *GetTaskRequest) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		if f.num == 1 && f.isBytes() {
+			m.TaskID = f.string()
+		}
+		return nil
+	})
+}
+
+// Task is the state of a task.
+type Task struct {
+	ID              string
+	Type            string
+	CanvasID        string
+	Status          string
+	StartTimeUnixMS int64
+	DurationMS      int64
+	Error           string
+}
+
+func (m *Task) marshal() []byte {
+	var e encoder
+	e.string(1, m.ID)
+	e.string(2, m.Type)
+	e.string(3, m.CanvasID)
+	e.string(4, m.Status)
+	e.int64(5, m.StartTimeUnixMS)
+	e.int64(6, m.DurationMS)
+	e.string(7, m.Error)
+	return e.buf
+}
+
+func (m *Task) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		switch {
+		case f.num == 1 && f.isBytes():
+			m.ID = f.string()
+		case f.num == 2 && f.isBytes():
+			m.Type = f.string()
+		case f.num == 3 && f.isBytes():
+			m.CanvasID = f.string()
+		case f.num == 4 && f.isBytes():
+			m.Status = f.string()
+		case f.num == 5 && f.isVarint():
+			m.StartTimeUnixMS = f.int64()
+		case f.num == 6 && f.isVarint():
+			m.DurationMS = f.int64()
+		case f.num == 7 && f.isBytes():
+			m.Error = f.string()
+		}
+		return nil
+	})
+}
+
+// WatchTasksRequest filters the streamed task updates.
+type WatchTasksRequest struct {
+	CanvasID string
+	TaskID   string
+}
+
+func (m *WatchTasksRequest) marshal() []byte {
+	var e encoder
+	e.string(1, m.CanvasID)
+	e.string(2, m.TaskID)
+	return e.buf
+}
+
+func (m *WatchTasksRequest) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		switch {
+		case f.num == 1 && f.isBytes():
+			m.CanvasID = f.string()
+		case f.num == 2 && f.isBytes():
+			m.TaskID = f.string()
+		}
+		return nil
+	})
+}
+
+// GetMetricsRequest has no fields.
+type GetMetricsRequest struct{}
+
+func (m *GetMetricsRequest) marshal() []byte             { return nil }
+func (m *GetMetricsRequest) unmarshal(data []byte) error { return decodeFields(data, ignoreField) }
+
+// Metrics are the task processing statistics and GPU metrics.
+type Metrics struct {
+	TotalProcessed int64
+	TotalSuccess   int64
+	TotalErrors    int64
+	ByType         []TaskTypeMetrics
+	GPU            *GPUMetrics
+}
+
+func (m *Metrics) marshal() []byte {
+	var e encoder
+	e.int64(1, m.TotalProcessed)
+	e.int64(2, m.TotalSuccess)
+	e.int64(3, m.TotalErrors)
+	for i := range m.ByType {
+		e.message(4, &m.ByType[i])
+	}
+	if m.GPU != nil {
+		e.message(5, m.GPU)
+	}
+	return e.buf
+}
+
+func (m *Metrics) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		switch {
+		case f.num == 1 && f.isVarint():
+			m.TotalProcessed = f.int64()
+		case f.num == 2 && f.isVarint():
+			m.TotalSuccess = f.int64()
+		case f.num == 3 && f.isVarint():
+			m.TotalErrors = f.int64()
+		case f.num == 4 && f.isBytes():
+			var t TaskTypeMetrics
+			if err := t.unmarshal(f.embedded()); err != nil {
+				return err
+			}
+			m.ByType = append(m.ByType, t)
+		case f.num == 5 && f.isBytes():
+			m.GPU = &GPUMetrics{}
+			return m.GPU.unmarshal(f.embedded())
+		}
+		return nil
+	})
+}
+
+// TaskTypeMetrics are the statistics of one task type.
+type TaskTypeMetrics struct {
+	Type          string
+	Count         int64
+	SuccessRate   float64
+	AvgDurationMS int64
+	P50MS         int64
+	P95MS         int64
+	P99MS         int64
+	LatencyWindow string
+}
+
+func (m *TaskTypeMetrics) marshal() []byte {
+	var e encoder
+	e.string(1, m.Type)
+	e.int64(2, m.Count)
+	e.double(3, m.SuccessRate)
+	e.int64(4, m.AvgDurationMS)
+	e.int64(5, m.P50MS)
+	e.int64(6, m.P95MS)
+	e.int64(7, m.P99MS)
+	e.string(8, m.LatencyWindow)
+	return e.buf
+}
+
+func (m *TaskTypeMetrics) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		switch {
+		case f.num == 1 && f.isBytes():
+			m.Type = f.string()
+		case f.num == 2 && f.isVarint():
+			m.Count = f.int64()
+		case f.num == 3 && f.isFixed64():
+			m.SuccessRate = f.double()
+		case f.num == 4 && f.isVarint():
+			m.AvgDurationMS = f.int64()
+		case f.num == 5 && f.isVarint():
+			m.P50MS = f.int64()
+		case f.num == 6 && f.isVarint():
+			m.P95MS = f.int64()
+		case f.num == 7 && f.isVarint():
+			m.P99MS = f.int64()
+		case f.num == 8 && f.isBytes():
+			m.LatencyWindow = f.string()
+		}
+		return nil
+	})
+}
+
+// GPUMetrics are the current metrics of the primary GPU.
+type GPUMetrics struct {
+	Utilization float64
+	Temperature float64
+	MemoryUsed  int64
+	MemoryTotal int64
+	Name        string
+}
+
+func (m *GPUMetrics) marshal() []byte {
+	var e encoder
+	e.double(1, m.Utilization)
+	e.double(2, m.Temperature)
+	e.int64(3, m.MemoryUsed)
+	e.int64(4, m.MemoryTotal)
+	e.string(5, m.Name)
+	return e.buf
+}
+
+func (m *GPUMetrics) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		switch {
+		case f.num == 1 && f.isFixed64():
+			m.Utilization = f.double()
+		case f.num == 2 && f.isFixed64():
+			m.Temperature = f.double()
+		case f.num == 3 && f.isVarint():
+			m.MemoryUsed = f.int64()
+		case f.num == 4 && f.isVarint():
+			m.MemoryTotal = f.int64()
+		case f.num == 5 && f.isBytes():
+			m.Name = f.string()
+		}
+		return nil
+	})
+}
+
+// ListModelsRequest has no fields.
+type ListModelsRequest struct{}
+
+func (m *ListModelsRequest) marshal() []byte             { return nil }
+func (m *ListModelsRequest) unmarshal(data []byte) error { return decodeFields(data, ignoreField) }
+
+// ListModelsResponse is the model catalog.
+type ListModelsResponse struct {
+	Models []Model
+}
+
+func (m *ListModelsResponse) marshal() []byte {
+	var e encoder
+	for i := range m.Models {
+		e.message(1, &m.Models[i])
+	}
+	return e.buf
+}
+
+func (m *ListModelsResponse) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		if f.num == 1 && f.isBytes() {
+			var model Model
+			if err := model.unmarshal(f.embedded()); err != nil {
+				return err
+			}
+			m.Models = append(m.Models, model)
+		}
+		return nil
+	})
+}
+
+// Model is a catalog model with its install and download state.
+type Model struct {
+	ID              string
+	Name            string
+	SizeBytes       int64
+	Installed       bool
+	Active          bool
+	DownloadState   string
+	DownloadPercent float64
+	DownloadError   string
+}
+
+func (m *Model) marshal() []byte {
+	var e encoder
+	e.string(1, m.ID)
+	e.string(2, m.Name)
+	e.int64(3, m.SizeBytes)
+	e.bool(4, m.Installed)
+	e.bool(5, m.Active)
+	e.string(6, m.DownloadState)
+	e.double(7, m.DownloadPercent)
+	e.string(8, m.DownloadError)
+	return e.buf
+}
+
+func (m *Model) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		switch {
+		case f.num == 1 && f.isBytes():
+			m.ID = f.string()
+		case f.num == 2 && f.isBytes():
+			m.Name = f.string()
+		case f.num == 3 && f.isVarint():
+			m.SizeBytes = f.int64()
+		case f.num == 4 && f.isVarint():
+			m.Installed = f.bool()
+		case f.num == 5 && f.isVarint():
+			m.Active = f.bool()
+		case f.num == 6 && f.isBytes():
+			m.DownloadState = f.string()
+		case f.num == 7 && f.isFixed64():
+			m.DownloadPercent = f.double()
+		case f.num == 8 && f.isBytes():
+			m.DownloadError = f.string()
+		}
+		return nil
+	})
+}
+
+// DownloadModelRequest identifies the catalog model to download.
+type DownloadModelRequest struct {
+	ID string
+}
+
+func (m *DownloadModelRequest) marshal() []byte {
+	var e encoder
+	e.string(1, m.ID)
+	return e.buf
+}
+
+func (m *DownloadModelRequest) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		if f.num == 1 && f.isBytes() {
+			m.ID = f.string()
+		}
+		return nil
+	})
+}
+
+// DeleteModelRequest identifies the model file to delete.
+type DeleteModelRequest struct {
+	Filename string
+}
+
+func (m *DeleteModelRequest) marshal() []byte {
+	var e encoder
+	e.string(1, m.Filename)
+	return e.buf
+}
+
+func (m *DeleteModelRequest) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		if f.num == 1 && f.isBytes() {
+			m.Filename = f.string()
+		}
+		return nil
+	})
+}
+
+// DeleteModelResponse reports the deleted file and the space freed.
+type DeleteModelResponse struct {
+	Filename   string
+	FreedBytes int64
+}
+
+func (m *DeleteModelResponse) marshal() []byte {
+	var e encoder
+	e.string(1, m.Filename)
+	e.int64(2, m.FreedBytes)
+	return e.buf
+}
+
+func (m *DeleteModelResponse) unmarshal(data []byte) error {
+	return decodeFields(data, func(f field) error {
+		switch {
+		case f.num == 1 && f.isBytes():
+			m.Filename = f.string()
+		case f.num == 2 && f.isVarint():
+			m.FreedBytes = f.int64()
+		}
+		return nil
+	})
+}
+
+// ignoreField skips every field of a message without fields.
+func ignoreField(field) error { return nil }
@@ -0,0 +1,175 @@
+package grpcapi
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// compileProto compiles canvusllm.proto and returns its descriptor.
+func compileProto(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{}),
+	}
+	files, err := compiler.Compile(context.Background(), "canvusllm.proto")
+	if err != nil {
+		t.Fatalf("compiling canvusllm.proto failed: %v", err)
+	}
+	return files[0]
+}
+
+// TestMessagesMatchProto cross-checks the hand-written encoding against
+// canvusllm.proto: each message encoded here must decode with the
+// generated descriptor to the same values, and the descriptor's encoding
+// must decode here to the same struct. Every field of every message is
+// set, so a wrong field number or wire type fails.
+func TestMessagesMatchProto(t *testing.T) {
+	file := compileProto(t)
+
+	tests := []struct {
+		name string // Message name in canvusllm.proto
+		msg  message
+		json string // The same message in protobuf JSON
+	}{
+		{
+			name: "SubmitTaskRequest",
+			msg:  &SubmitTaskRequest{Prompt: "summarise", CanvasID: "c1", X: 12.5, Y: -4},
+			json: `{"prompt": "summarise", "canvas_id": "c1", "x": 12.5, "y": -4}`,
+		},
+		{
+			name: "SubmitTaskResponse",
+			msg:  &SubmitTaskResponse{TaskID: "n1", CanvasID: "c1"},
+			json: `{"task_id": "n1", "canvas_id": "c1"}`,
+		},
+		{
+			name: "GetTaskRequest",
+			msg:  &GetTaskRequest{TaskID: "n1"},
+			json: `{"task_id": "n1"}`,
+		},
+		{
+			name: "Task",
+			msg:  &Task{ID: "n1", Type: "note", CanvasID: "c1", Status: "error", StartTimeUnixMS: 1700000000000, DurationMS: 250, Error: "boom"},
+			json: `{"id": "n1", "type": "note", "canvas_id": "c1", "status": "error", "start_time_unix_ms": 1700000000000, "duration_ms": 250, "error": "boom"}`,
+		},
+		{
+			name: "WatchTasksRequest",
+			msg:  &WatchTasksRequest{CanvasID: "c1", TaskID: "n1"},
+			json: `{"canvas_id": "c1", "task_id": "n1"}`,
+		},
+		{
+			name: "GetMetricsRequest",
+			msg:  &GetMetricsRequest{},
+			json: `{}`,
+		},
+		{
+			name: "Metrics",
+			msg: &Metrics{
+				TotalProcessed: 3,
+				TotalSuccess:   2,
+				TotalErrors:    1,
+				ByType:         []TaskTypeMetrics{{Type: "note", Count: 2}, {Type: "pdf", Count: 1}},
+				GPU:            &GPUMetrics{Utilization: 42.5, Name: "RTX"},
+			},
+			json: `{"total_processed": 3, "total_success": 2, "total_errors": 1,
+				"by_type": [{"type": "note", "count": 2}, {"type": "pdf", "count": 1}],
+				"gpu": {"utilization": 42.5, "name": "RTX"}}`,
+		},
+		{
+			name: "TaskTypeMetrics",
+			msg:  &TaskTypeMetrics{Type: "note", Count: 2, SuccessRate: 50, AvgDurationMS: 700, P50MS: 600, P95MS: 900, P99MS: 1200, LatencyWindow: "5m"},
+			json: `{"type": "note", "count": 2, "success_rate": 50, "avg_duration_ms": 700, "p50_ms": 600, "p95_ms": 900, "p99_ms": 1200, "latency_window": "5m"}`,
+		},
+		{
+			name: "GPUMetrics",
+			msg:  &GPUMetrics{Utilization: 42.5, Temperature: 71, MemoryUsed: 1 << 30, MemoryTotal: 8 << 30, Name: "RTX"},
+			json: `{"utilization": 42.5, "temperature": 71, "memory_used": 1073741824, "memory_total": 8589934592, "name": "RTX"}`,
+		},
+		{
+			name: "ListModelsRequest",
+			msg:  &ListModelsRequest{},
+			json: `{}`,
+		},
+		{
+			name: "ListModelsResponse",
+			msg:  &ListModelsResponse{Models: []Model{{ID: "a", Installed: true}, {ID: "b"}}},
+			json: `{"models": [{"id": "a", "installed": true}, {"id": "b"}]}`,
+		},
+		{
+			name: "Model",
+			msg:  &Model{ID: "a", Name: "A", SizeBytes: 100, Installed: true, Active: true, DownloadState: "failed", DownloadPercent: 12.5, DownloadError: "disk full"},
+			json: `{"id": "a", "name": "A", "size_bytes": 100, "installed": true, "active": true, "download_state": "failed", "download_percent": 12.5, "download_error": "disk full"}`,
+		},
+		{
+			name: "DownloadModelRequest",
+			msg:  &DownloadModelRequest{ID: "a"},
+			json: `{"id": "a"}`,
+		},
+		{
+			name: "DeleteModelRequest",
+			msg:  &DeleteModelRequest{Filename: "a.gguf"},
+			json: `{"filename": "a.gguf"}`,
+		},
+		{
+			name: "DeleteModelResponse",
+			msg:  &DeleteModelResponse{Filename: "a.gguf", FreedBytes: 4 << 30},
+			json: `{"filename": "a.gguf", "freed_bytes": 4294967296}`,
+		},
+	}
+
+	tested := make(map[protoreflect.Name]bool)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			desc := file.Messages().ByName(protoreflect.Name(tt.name))
+			if desc == nil {
+				t.Fatalf("canvusllm.proto has no message %s", tt.name)
+			}
+			tested[desc.Name()] = true
+			want := dynamicpb.NewMessage(desc)
+			if err := protojson.Unmarshal([]byte(tt.json), want); err != nil {
+				t.Fatalf("invalid test JSON: %v", err)
+			}
+			fields := desc.Fields()
+			for i := 0; i < fields.Len(); i++ {
+				if !want.Has(fields.Get(i)) {
+					t.Fatalf("test does not set field %s", fields.Get(i).Name())
+				}
+			}
+
+			// Hand-written encoding, decoded with the descriptor
+			got := dynamicpb.NewMessage(desc)
+			if err := proto.Unmarshal(tt.msg.marshal(), got); err != nil {
+				t.Fatalf("descriptor cannot decode the message: %v", err)
+			}
+			if !proto.Equal(got, want) {
+				t.Errorf("descriptor decoded %v, want %v", got, want)
+			}
+
+			// Descriptor encoding, decoded by hand
+			data, err := proto.Marshal(want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			decoded := reflect.New(reflect.TypeOf(tt.msg).Elem()).Interface().(message)
+			if err := decoded.unmarshal(data); err != nil {
+				t.Fatalf("cannot decode the descriptor's encoding: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tt.msg) {
+				t.Errorf("decoded %+v, want %+v", decoded, tt.msg)
+			}
+		})
+	}
+
+	messages := file.Messages()
+	for i := 0; i < messages.Len(); i++ {
+		if name := messages.Get(i).Name(); !tested[name] {
+			t.Errorf("message %s is not cross-checked", name)
+		}
+	}
+}
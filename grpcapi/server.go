@@ -0,0 +1,609 @@
+// Package grpcapi provides the CanvusLLM gRPC service.
+// This file contains the Server organism which serves canvusllm.proto over
+// unencrypted HTTP/2 and streams task updates to watchers.
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go_backend/core/clock"
+	"go_backend/core/modelmanager"
+	"go_backend/metrics"
+	"go_backend/webui"
+
+	"go.uber.org/zap"
+)
+
+// ServicePrefix is the path prefix of every CanvusLLM method.
+const ServicePrefix = "/canvusllm.v1.CanvusLLM/"
+
+// TaskStatusQueued is the status of a submitted task the monitor has not
+// picked up yet.
+const TaskStatusQueued = "queued"
+
+const (
+	// maxTrackedTasks bounds the queued and processing tasks kept for GetTask
+	maxTrackedTasks = 1000
+
+	// taskLookupLimit is how many completed tasks GetTask searches
+	taskLookupLimit = 1000
+
+	// watcherBufferSize is the per-watcher update buffer; updates to a
+	// watcher that falls further behind are dropped
+	watcherBufferSize = 64
+)
+
+// TaskSubmitter submits tasks for processing. Implemented by NoteSubmitter.
+type TaskSubmitter interface {
+	SubmitTask(ctx context.Context, req SubmitTaskRequest) (SubmitTaskResponse, error)
+}
+
+// ModelCatalog manages catalog models. Implemented by webui.ModelCatalogAPI.
+type ModelCatalog interface {
+	Models() webui.ModelCatalogResponse
+	StartDownload(id string) (webui.ModelDownloadStatus, error)
+	DeleteLocalModel(filename string) (webui.DeleteModelResponse, error)
+}
+
+// Config configures the gRPC server.
+type Config struct {
+	// Host to bind (empty binds all interfaces)
+	Host string
+
+	// Port to listen on
+	Port int
+
+	// Token, if set, must be sent as "authorization: Bearer <token>"
+	Token string
+}
+
+// unaryMethod handles one request message and returns the response.
+type unaryMethod func(ctx context.Context, body []byte) (message, error)
+
+// Server is an organism that serves the CanvusLLM gRPC service for
+// programmatic clients. It implements metrics.TaskBroadcaster so the canvas
+// monitor's task updates reach WatchTasks streams.
+//
+// Usage:
+//
+//	s := grpcapi.NewServer(cfg, store, logger)
+//	s.SetTaskSubmitter(grpcapi.NewNoteSubmitter(client, canvasID))
+//	events.BroadcastTasks(monitor.Events(), "grpc", s)
+//	go s.Start()
+type Server struct {
+	config  Config
+	store   metrics.MetricsCollector
+	logger  *zap.Logger
+	methods map[string]unaryMethod
+
+	mu        sync.RWMutex
+	submitter TaskSubmitter
+	catalog   ModelCatalog
+	clock     clock.Clock
+
+	tasksMu  sync.Mutex
+	tasks    map[string]Task
+	watchers map[chan Task]struct{}
+
+	httpServer *http.Server
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// NewServer creates a gRPC server reading metrics from store.
+func NewServer(config Config, store metrics.MetricsCollector, logger *zap.Logger) *Server {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	s := &Server{
+		config:   config,
+		store:    store,
+		logger:   logger,
+		clock:    clock.Real(),
+		tasks:    make(map[string]Task),
+		watchers: make(map[chan Task]struct{}),
+		done:     make(chan struct{}),
+	}
+	s.methods = map[string]unaryMethod{
+		"SubmitTask":    s.submitTask,
+		"GetTask":       s.getTask,
+		"GetMetrics":    s.getMetrics,
+		"ListModels":    s.listModels,
+		"DownloadModel": s.downloadModel,
+		"DeleteModel":   s.deleteModel,
+	}
+
+	// gRPC clients connect with HTTP/2 prior knowledge (h2c)
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	s.httpServer = &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", config.Host, config.Port),
+		Handler:           s,
+		Protocols:         &protocols,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// SetTaskSubmitter sets the submitter used by SubmitTask.
+func (s *Server) SetTaskSubmitter(submitter TaskSubmitter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.submitter = submitter
+}
+
+// SetClock sets the clock that stamps task start times (default:
+// clock.Real).
+func (s *Server) SetClock(c clock.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock.OrReal(c)
+}
+
+// now reads the server's clock.
+func (s *Server) now() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clock.Now()
+}
+
+// SetModelCatalog sets the catalog used by the model management methods.
+func (s *Server) SetModelCatalog(catalog ModelCatalog) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.catalog = catalog
+}
+
+// Addr returns the listen address.
+func (s *Server) Addr() string {
+	return s.httpServer.Addr
+}
+
+// Start listens and serves until Shutdown is called.
+func (s *Server) Start() error {
+	s.logger.Info("gRPC server starting",
+		zap.String("addr", s.httpServer.Addr),
+		zap.Bool("auth_enabled", s.config.Token != ""),
+	)
+	err := s.httpServer.ListenAndServe()
+	if err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("grpc server error: %w", err)
+	}
+	return nil
+}
+
+// Shutdown ends open WatchTasks streams and gracefully stops the server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.closeOnce.Do(func() { close(s.done) })
+	if err := s.httpServer.Shutdown(ctx); err != nil {
+		return fmt.Errorf("grpc shutdown error: %w", err)
+	}
+	return nil
+}
+
+// ServeHTTP serves one gRPC call.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 {
+		http.Error(w, "gRPC requires HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "not a gRPC request", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+
+	if !s.authorized(r) {
+		s.writeStatus(w, statusErrorf(CodeUnauthenticated, "missing or invalid bearer token"))
+		return
+	}
+
+	name, ok := strings.CutPrefix(r.URL.Path, ServicePrefix)
+	if !ok {
+		s.writeStatus(w, statusErrorf(CodeUnimplemented, "unknown service for %s", r.URL.Path))
+		return
+	}
+
+	body, err := readFrame(r.Body)
+	if err != nil && err != io.EOF {
+		s.writeStatus(w, statusErrorf(CodeInvalidArgument, "invalid request: %v", err))
+		return
+	}
+
+	if name == "WatchTasks" {
+		s.watchTasks(w, r, body)
+		return
+	}
+	method, ok := s.methods[name]
+	if !ok {
+		s.writeStatus(w, statusErrorf(CodeUnimplemented, "unknown method %s", name))
+		return
+	}
+
+	resp, err := method(r.Context(), body)
+	if err != nil {
+		if code, _ := statusOf(err); code == CodeInternal {
+			s.logger.Error("gRPC call failed", zap.String("method", name), zap.Error(err))
+		}
+		s.writeStatus(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	if err := writeFrame(w, resp.marshal()); err != nil {
+		return
+	}
+	s.writeStatus(w, nil)
+}
+
+// authorized checks the bearer token if one is configured.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.config.Token == "" {
+		return true
+	}
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.config.Token)) == 1
+}
+
+// writeStatus ends the call with the status of err (OK if nil) in the
+// grpc-status and grpc-message trailers.
+func (s *Server) writeStatus(w http.ResponseWriter, err error) {
+	code, msg := CodeOK, ""
+	if err != nil {
+		code, msg = statusOf(err)
+	}
+	// Headers must be sent before the trailers
+	w.WriteHeader(http.StatusOK)
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(int(code)))
+	if msg != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", encodeStatusMessage(msg))
+	}
+}
+
+// submitTask handles SubmitTask.
+func (s *Server) submitTask(ctx context.Context, body []byte) (message, error) {
+	var req SubmitTaskRequest
+	if err := req.unmarshal(body); err != nil {
+		return nil, statusErrorf(CodeInvalidArgument, "%v", err)
+	}
+	if strings.TrimSpace(req.Prompt) == "" {
+		return nil, statusErrorf(CodeInvalidArgument, "prompt is required")
+	}
+
+	s.mu.RLock()
+	submitter := s.submitter
+	s.mu.RUnlock()
+	if submitter == nil {
+		return nil, statusErrorf(CodeUnavailable, "task submission is not configured")
+	}
+
+	resp, err := submitter.SubmitTask(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	s.publish(Task{
+		ID:              resp.TaskID,
+		Type:            metrics.TaskTypeNote,
+		CanvasID:        resp.CanvasID,
+		Status:          TaskStatusQueued,
+		StartTimeUnixMS: s.now().UnixMilli(),
+	})
+	return &resp, nil
+}
+
+// getTask handles GetTask.
+func (s *Server) getTask(ctx context.Context, body []byte) (message, error) {
+	var req GetTaskRequest
+	if err := req.unmarshal(body); err != nil {
+		return nil, statusErrorf(CodeInvalidArgument, "%v", err)
+	}
+	if req.TaskID == "" {
+		return nil, statusErrorf(CodeInvalidArgument, "task_id is required")
+	}
+	task, ok := s.lookupTask(req.TaskID)
+	if !ok {
+		return nil, statusErrorf(CodeNotFound, "task %s not found", req.TaskID)
+	}
+	return &task, nil
+}
+
+// lookupTask returns a queued or processing task, or else the most recent
+// completed task with the ID.
+func (s *Server) lookupTask(id string) (Task, bool) {
+	s.tasksMu.Lock()
+	task, ok := s.tasks[id]
+	s.tasksMu.Unlock()
+	if ok {
+		return task, true
+	}
+
+	var latest *metrics.TaskRecord
+	records := s.store.GetRecentTasks(taskLookupLimit)
+	for i := range records {
+		if records[i].ID == id && (latest == nil || records[i].EndTime.After(latest.EndTime)) {
+			latest = &records[i]
+		}
+	}
+	if latest == nil {
+		return Task{}, false
+	}
+	return taskFromRecord(*latest), true
+}
+
+// getMetrics handles GetMetrics.
+func (s *Server) getMetrics(ctx context.Context, body []byte) (message, error) {
+	taskMetrics := s.store.GetTaskMetrics()
+	resp := &Metrics{
+		TotalProcessed: taskMetrics.TotalProcessed,
+		TotalSuccess:   taskMetrics.TotalSuccess,
+		TotalErrors:    taskMetrics.TotalErrors,
+	}
+	for taskType, typeMetrics := range taskMetrics.ByType {
+		m := TaskTypeMetrics{
+			Type:          taskType,
+			Count:         typeMetrics.Count,
+			SuccessRate:   typeMetrics.SuccessRate,
+			AvgDurationMS: typeMetrics.AvgDuration.Milliseconds(),
+		}
+		if len(typeMetrics.Latency) > 0 {
+			latency := typeMetrics.Latency[0]
+			m.P50MS = latency.P50.Milliseconds()
+			m.P95MS = latency.P95.Milliseconds()
+			m.P99MS = latency.P99.Milliseconds()
+			m.LatencyWindow = latency.Window
+		}
+		resp.ByType = append(resp.ByType, m)
+	}
+
+	if gpu := s.store.GetGPUMetrics(); gpu.MemoryTotal > 0 {
+		resp.GPU = &GPUMetrics{
+			Utilization: gpu.Utilization,
+			Temperature: gpu.Temperature,
+			MemoryUsed:  gpu.MemoryUsed,
+			MemoryTotal: gpu.MemoryTotal,
+			Name:        gpu.Name,
+		}
+	}
+	return resp, nil
+}
+
+// modelCatalog returns the catalog or an Unavailable error.
+func (s *Server) modelCatalog() (ModelCatalog, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.catalog == nil {
+		return nil, statusErrorf(CodeUnavailable, "model catalog is not available")
+	}
+	return s.catalog, nil
+}
+
+// listModels handles ListModels.
+func (s *Server) listModels(ctx context.Context, body []byte) (message, error) {
+	catalog, err := s.modelCatalog()
+	if err != nil {
+		return nil, err
+	}
+	resp := &ListModelsResponse{}
+	for _, model := range catalog.Models().Models {
+		m := Model{
+			ID:        model.ID,
+			Name:      model.Name,
+			SizeBytes: model.SizeBytes,
+			Installed: model.Installed,
+			Active:    model.Active,
+		}
+		if model.Download != nil {
+			m.DownloadState = model.Download.State
+			m.DownloadPercent = model.Download.Percent
+			m.DownloadError = model.Download.Error
+		}
+		resp.Models = append(resp.Models, m)
+	}
+	return resp, nil
+}
+
+// downloadModel handles DownloadModel.
+func (s *Server) downloadModel(ctx context.Context, body []byte) (message, error) {
+	var req DownloadModelRequest
+	if err := req.unmarshal(body); err != nil {
+		return nil, statusErrorf(CodeInvalidArgument, "%v", err)
+	}
+	catalog, err := s.modelCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := catalog.StartDownload(req.ID)
+	switch {
+	case errors.Is(err, webui.ErrUnknownModel):
+		return nil, statusErrorf(CodeNotFound, "%v", err)
+	case errors.Is(err, webui.ErrDownloadInProgress):
+		return nil, statusErrorf(CodeAlreadyExists, "%v", err)
+	case err != nil:
+		return nil, err
+	}
+	return &Model{
+		ID:              req.ID,
+		DownloadState:   status.State,
+		DownloadPercent: status.Percent,
+	}, nil
+}
+
+// deleteModel handles DeleteModel.
+func (s *Server) deleteModel(ctx context.Context, body []byte) (message, error) {
+	var req DeleteModelRequest
+	if err := req.unmarshal(body); err != nil {
+		return nil, statusErrorf(CodeInvalidArgument, "%v", err)
+	}
+	catalog, err := s.modelCatalog()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := catalog.DeleteLocalModel(req.Filename)
+	switch {
+	case errors.Is(err, modelmanager.ErrInvalidModelFile):
+		return nil, statusErrorf(CodeInvalidArgument, "%v", err)
+	case errors.Is(err, modelmanager.ErrModelInUse):
+		return nil, statusErrorf(CodeFailedPrecondition, "%v", err)
+	case errors.Is(err, os.ErrNotExist):
+		return nil, statusErrorf(CodeNotFound, "%v", err)
+	case err != nil:
+		return nil, err
+	}
+	return &DeleteModelResponse{Filename: resp.Filename, FreedBytes: resp.FreedBytes}, nil
+}
+
+// watchTasks handles WatchTasks, streaming matching task updates until the
+// client cancels, the watched task completes or the server shuts down.
+func (s *Server) watchTasks(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req WatchTasksRequest
+	if err := req.unmarshal(body); err != nil {
+		s.writeStatus(w, statusErrorf(CodeInvalidArgument, "%v", err))
+		return
+	}
+
+	updates := make(chan Task, watcherBufferSize)
+	s.tasksMu.Lock()
+	s.watchers[updates] = struct{}{}
+	s.tasksMu.Unlock()
+	defer func() {
+		s.tasksMu.Lock()
+		delete(s.watchers, updates)
+		s.tasksMu.Unlock()
+	}()
+
+	rc := http.NewResponseController(w)
+	w.WriteHeader(http.StatusOK)
+	rc.Flush()
+
+	send := func(task Task) bool {
+		if err := writeFrame(w, task.marshal()); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	// A watched task's current state comes first; a completed task ends the stream
+	if req.TaskID != "" {
+		if task, ok := s.lookupTask(req.TaskID); ok {
+			if !send(task) {
+				return
+			}
+			if isTerminal(task.Status) {
+				s.writeStatus(w, nil)
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			s.writeStatus(w, statusErrorf(CodeUnavailable, "server shutting down"))
+			return
+		case task := <-updates:
+			if (req.CanvasID != "" && task.CanvasID != req.CanvasID) ||
+				(req.TaskID != "" && task.ID != req.TaskID) {
+				continue
+			}
+			if !send(task) {
+				return
+			}
+			if req.TaskID != "" && isTerminal(task.Status) {
+				s.writeStatus(w, nil)
+				return
+			}
+		}
+	}
+}
+
+// BroadcastTaskUpdateFromMetrics records a task update and sends it to
+// WatchTasks streams. Implements metrics.TaskBroadcaster.
+func (s *Server) BroadcastTaskUpdateFromMetrics(data metrics.TaskBroadcastData) {
+	task := Task{
+		ID:         data.TaskID,
+		Type:       data.TaskType,
+		CanvasID:   data.CanvasID,
+		Status:     data.Status,
+		DurationMS: data.Duration.Milliseconds(),
+		Error:      data.Error,
+	}
+	s.tasksMu.Lock()
+	if previous, ok := s.tasks[task.ID]; ok {
+		task.StartTimeUnixMS = previous.StartTimeUnixMS
+	}
+	s.tasksMu.Unlock()
+	if task.StartTimeUnixMS == 0 {
+		task.StartTimeUnixMS = s.now().Add(-data.Duration).UnixMilli()
+	}
+	s.publish(task)
+}
+
+// publish tracks task until it completes and sends it to every watcher.
+func (s *Server) publish(task Task) {
+	s.tasksMu.Lock()
+	defer s.tasksMu.Unlock()
+
+	if isTerminal(task.Status) {
+		delete(s.tasks, task.ID)
+	} else {
+		if _, ok := s.tasks[task.ID]; !ok && len(s.tasks) >= maxTrackedTasks {
+			s.dropOldestTaskLocked()
+		}
+		s.tasks[task.ID] = task
+	}
+
+	for updates := range s.watchers {
+		select {
+		case updates <- task:
+		default:
+			s.logger.Debug("gRPC watcher too slow, dropping task update", zap.String("task_id", task.ID))
+		}
+	}
+}
+
+// dropOldestTaskLocked removes the longest-tracked task; tasksMu must be held.
+func (s *Server) dropOldestTaskLocked() {
+	var oldestID string
+	var oldest int64
+	for id, task := range s.tasks {
+		if oldestID == "" || task.StartTimeUnixMS < oldest {
+			oldestID, oldest = id, task.StartTimeUnixMS
+		}
+	}
+	delete(s.tasks, oldestID)
+}
+
+// taskFromRecord converts a completed task record.
+func taskFromRecord(record metrics.TaskRecord) Task {
+	task := Task{
+		ID:         record.ID,
+		Type:       record.Type,
+		CanvasID:   record.CanvasID,
+		Status:     record.Status,
+		DurationMS: record.Duration.Milliseconds(),
+		Error:      record.ErrorMsg,
+	}
+	if !record.StartTime.IsZero() {
+		task.StartTimeUnixMS = record.StartTime.UnixMilli()
+	}
+	return task
+}
+
+// isTerminal reports whether status is final.
+func isTerminal(status string) bool {
+	return status == metrics.TaskStatusSuccess || status == metrics.TaskStatusError
+}
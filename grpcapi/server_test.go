@@ -0,0 +1,376 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+	"go_backend/core/modelmanager"
+	"go_backend/metrics"
+	"go_backend/webui"
+)
+
+// fakeSubmitter records submitted tasks and returns sequential task IDs
+type fakeSubmitter struct {
+	requests []SubmitTaskRequest
+}
+
+func (f *fakeSubmitter) SubmitTask(ctx context.Context, req SubmitTaskRequest) (SubmitTaskResponse, error) {
+	f.requests = append(f.requests, req)
+	return SubmitTaskResponse{TaskID: fmt.Sprintf("note-%d", len(f.requests)), CanvasID: "canvas-1"}, nil
+}
+
+// fakeCatalog serves one installed model
+type fakeCatalog struct{}
+
+func (fakeCatalog) Models() webui.ModelCatalogResponse {
+	return webui.ModelCatalogResponse{Models: []webui.CatalogModel{{
+		CatalogEntry: modelmanager.CatalogEntry{ID: "tiny", Name: "Tiny"},
+		Installed:    true,
+	}}}
+}
+
+func (fakeCatalog) StartDownload(id string) (webui.ModelDownloadStatus, error) {
+	if id != "tiny" {
+		return webui.ModelDownloadStatus{}, fmt.Errorf("%w: %s", webui.ErrUnknownModel, id)
+	}
+	return webui.ModelDownloadStatus{State: "downloading"}, nil
+}
+
+func (fakeCatalog) DeleteLocalModel(filename string) (webui.DeleteModelResponse, error) {
+	return webui.DeleteModelResponse{}, modelmanager.ErrModelInUse
+}
+
+// startTestServer serves s over h2c and returns an h2c client for it
+func startTestServer(t *testing.T, s *Server) (*httptest.Server, *http.Client) {
+	t.Helper()
+	ts := httptest.NewUnstartedServer(s)
+	ts.Config.Protocols = s.httpServer.Protocols
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+	return ts, client
+}
+
+// grpcCall starts a call and returns the response with its body unread
+func grpcCall(t *testing.T, ts *httptest.Server, client *http.Client, method string, req message, token string) *http.Response {
+	t.Helper()
+	var body bytes.Buffer
+	if err := writeFrame(&body, req.marshal()); err != nil {
+		t.Fatalf("writeFrame failed: %v", err)
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, ts.URL+ServicePrefix+method, &body)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/grpc")
+	httpReq.Header.Set("TE", "trailers")
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		t.Fatalf("%s call failed: %v", method, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// unary makes a unary call, decoding the response into out on success
+func unary(t *testing.T, ts *httptest.Server, client *http.Client, method string, req, out message, token string) Code {
+	t.Helper()
+	resp := grpcCall(t, ts, client, method, req, token)
+	data, err := readFrame(resp.Body)
+	if err == nil {
+		if err := out.unmarshal(data); err != nil {
+			t.Fatalf("decoding %s response failed: %v", method, err)
+		}
+		_, err = io.Copy(io.Discard, resp.Body)
+	}
+	if err != nil && err != io.EOF {
+		t.Fatalf("reading %s response failed: %v", method, err)
+	}
+	return grpcStatus(t, resp)
+}
+
+// grpcStatus returns the grpc-status trailer of a fully read response
+func grpcStatus(t *testing.T, resp *http.Response) Code {
+	t.Helper()
+	code, err := strconv.Atoi(resp.Trailer.Get("Grpc-Status"))
+	if err != nil {
+		t.Fatalf("missing grpc-status trailer: %v", resp.Trailer)
+	}
+	return Code(code)
+}
+
+func newTestServer(token string) (*Server, *metrics.MetricsStore) {
+	store := metrics.NewMetricsStore(metrics.DefaultStoreConfig(), time.Now())
+	return NewServer(Config{Token: token}, store, nil), store
+}
+
+func TestServerUnaryCalls(t *testing.T) {
+	s, store := newTestServer("")
+	submitter := &fakeSubmitter{}
+	s.SetTaskSubmitter(submitter)
+	s.SetModelCatalog(fakeCatalog{})
+	ts, client := startTestServer(t, s)
+
+	t.Run("submit and get queued task", func(t *testing.T) {
+		var resp SubmitTaskResponse
+		code := unary(t, ts, client, "SubmitTask", &SubmitTaskRequest{Prompt: "hello", X: 10}, &resp, "")
+		if code != CodeOK || resp.TaskID != "note-1" {
+			t.Fatalf("SubmitTask = %d %+v", code, resp)
+		}
+		if submitter.requests[0].X != 10 {
+			t.Errorf("submitter got %+v", submitter.requests[0])
+		}
+
+		var task Task
+		if code := unary(t, ts, client, "GetTask", &GetTaskRequest{TaskID: "note-1"}, &task, ""); code != CodeOK {
+			t.Fatalf("GetTask status = %d", code)
+		}
+		if task.Status != TaskStatusQueued {
+			t.Errorf("task status = %q, want %q", task.Status, TaskStatusQueued)
+		}
+	})
+
+	t.Run("empty prompt", func(t *testing.T) {
+		var resp SubmitTaskResponse
+		if code := unary(t, ts, client, "SubmitTask", &SubmitTaskRequest{}, &resp, ""); code != CodeInvalidArgument {
+			t.Errorf("status = %d, want InvalidArgument", code)
+		}
+	})
+
+	t.Run("completed task from metrics", func(t *testing.T) {
+		store.RecordTask(metrics.TaskRecord{ID: "done-1", Type: "pdf", Status: metrics.TaskStatusSuccess, Duration: 2 * time.Second})
+		var task Task
+		if code := unary(t, ts, client, "GetTask", &GetTaskRequest{TaskID: "done-1"}, &task, ""); code != CodeOK {
+			t.Fatalf("GetTask status = %d", code)
+		}
+		if task.Status != metrics.TaskStatusSuccess || task.DurationMS != 2000 {
+			t.Errorf("task = %+v", task)
+		}
+	})
+
+	t.Run("unknown task", func(t *testing.T) {
+		var task Task
+		if code := unary(t, ts, client, "GetTask", &GetTaskRequest{TaskID: "missing"}, &task, ""); code != CodeNotFound {
+			t.Errorf("status = %d, want NotFound", code)
+		}
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		var m Metrics
+		if code := unary(t, ts, client, "GetMetrics", &GetMetricsRequest{}, &m, ""); code != CodeOK {
+			t.Fatalf("GetMetrics status = %d", code)
+		}
+		if m.TotalProcessed != 1 || len(m.ByType) != 1 || m.ByType[0].Type != "pdf" {
+			t.Errorf("metrics = %+v", m)
+		}
+	})
+
+	t.Run("models", func(t *testing.T) {
+		var list ListModelsResponse
+		if code := unary(t, ts, client, "ListModels", &ListModelsRequest{}, &list, ""); code != CodeOK {
+			t.Fatalf("ListModels status = %d", code)
+		}
+		if len(list.Models) != 1 || list.Models[0].ID != "tiny" || !list.Models[0].Installed {
+			t.Errorf("models = %+v", list.Models)
+		}
+
+		var model Model
+		if code := unary(t, ts, client, "DownloadModel", &DownloadModelRequest{ID: "huge"}, &model, ""); code != CodeNotFound {
+			t.Errorf("DownloadModel unknown status = %d, want NotFound", code)
+		}
+		var deleted DeleteModelResponse
+		if code := unary(t, ts, client, "DeleteModel", &DeleteModelRequest{Filename: "tiny.gguf"}, &deleted, ""); code != CodeFailedPrecondition {
+			t.Errorf("DeleteModel in use status = %d, want FailedPrecondition", code)
+		}
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		var m Metrics
+		if code := unary(t, ts, client, "Explode", &GetMetricsRequest{}, &m, ""); code != CodeUnimplemented {
+			t.Errorf("status = %d, want Unimplemented", code)
+		}
+	})
+}
+
+func TestServerUnavailableWithoutDependencies(t *testing.T) {
+	s, _ := newTestServer("")
+	ts, client := startTestServer(t, s)
+
+	var resp SubmitTaskResponse
+	if code := unary(t, ts, client, "SubmitTask", &SubmitTaskRequest{Prompt: "hi"}, &resp, ""); code != CodeUnavailable {
+		t.Errorf("SubmitTask status = %d, want Unavailable", code)
+	}
+	var list ListModelsResponse
+	if code := unary(t, ts, client, "ListModels", &ListModelsRequest{}, &list, ""); code != CodeUnavailable {
+		t.Errorf("ListModels status = %d, want Unavailable", code)
+	}
+}
+
+func TestServerToken(t *testing.T) {
+	s, _ := newTestServer("secret")
+	ts, client := startTestServer(t, s)
+
+	var m Metrics
+	if code := unary(t, ts, client, "GetMetrics", &GetMetricsRequest{}, &m, ""); code != CodeUnauthenticated {
+		t.Errorf("status without token = %d, want Unauthenticated", code)
+	}
+	if code := unary(t, ts, client, "GetMetrics", &GetMetricsRequest{}, &m, "wrong"); code != CodeUnauthenticated {
+		t.Errorf("status with wrong token = %d, want Unauthenticated", code)
+	}
+	if code := unary(t, ts, client, "GetMetrics", &GetMetricsRequest{}, &m, "secret"); code != CodeOK {
+		t.Errorf("status with token = %d, want OK", code)
+	}
+}
+
+func TestServerRejectsHTTP1(t *testing.T) {
+	s, _ := newTestServer("")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, ServicePrefix+"GetMetrics", nil))
+	if rec.Code != http.StatusHTTPVersionNotSupported {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusHTTPVersionNotSupported)
+	}
+}
+
+func TestServerWatchTasks(t *testing.T) {
+	s, _ := newTestServer("")
+	ts, client := startTestServer(t, s)
+
+	s.BroadcastTaskUpdateFromMetrics(metrics.TaskBroadcastData{TaskID: "t1", TaskType: "note", Status: metrics.TaskStatusProcessing})
+	resp := grpcCall(t, ts, client, "WatchTasks", &WatchTasksRequest{TaskID: "t1"}, "")
+
+	readTask := func() Task {
+		t.Helper()
+		data, err := readFrame(resp.Body)
+		if err != nil {
+			t.Fatalf("reading task update failed: %v", err)
+		}
+		var task Task
+		if err := task.unmarshal(data); err != nil {
+			t.Fatalf("decoding task update failed: %v", err)
+		}
+		return task
+	}
+
+	if task := readTask(); task.Status != metrics.TaskStatusProcessing {
+		t.Fatalf("first update = %+v, want current processing state", task)
+	}
+
+	s.BroadcastTaskUpdateFromMetrics(metrics.TaskBroadcastData{TaskID: "other", Status: metrics.TaskStatusSuccess})
+	s.BroadcastTaskUpdateFromMetrics(metrics.TaskBroadcastData{TaskID: "t1", Status: metrics.TaskStatusError, Error: "failed", Duration: time.Second})
+
+	task := readTask()
+	if task.ID != "t1" || task.Status != metrics.TaskStatusError || task.Error != "failed" {
+		t.Errorf("final update = %+v", task)
+	}
+	if _, err := readFrame(resp.Body); err != io.EOF {
+		t.Fatalf("stream did not end after terminal update: %v", err)
+	}
+	if code := grpcStatus(t, resp); code != CodeOK {
+		t.Errorf("stream status = %d, want OK", code)
+	}
+
+	// Completed tasks are no longer tracked
+	if _, ok := s.lookupTask("t1"); ok {
+		t.Error("completed task still tracked")
+	}
+}
+
+func TestServerStampsStartTimesWithClock(t *testing.T) {
+	s, _ := newTestServer("")
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.SetClock(clock.NewFake(now))
+
+	// A task first seen when it finishes started its duration ago
+	s.BroadcastTaskUpdateFromMetrics(metrics.TaskBroadcastData{TaskID: "t1", Status: metrics.TaskStatusProcessing, Duration: 3 * time.Second})
+	task, ok := s.lookupTask("t1")
+	if !ok {
+		t.Fatal("task not tracked")
+	}
+	if want := now.Add(-3 * time.Second).UnixMilli(); task.StartTimeUnixMS != want {
+		t.Errorf("StartTimeUnixMS = %d, want %d", task.StartTimeUnixMS, want)
+	}
+}
+
+func TestServerShutdownEndsWatchers(t *testing.T) {
+	s, _ := newTestServer("")
+	ts, client := startTestServer(t, s)
+
+	resp := grpcCall(t, ts, client, "WatchTasks", &WatchTasksRequest{}, "")
+	s.closeOnce.Do(func() { close(s.done) })
+
+	if _, err := readFrame(resp.Body); err != io.EOF {
+		t.Fatalf("stream did not end on shutdown: %v", err)
+	}
+	if code := grpcStatus(t, resp); code != CodeUnavailable {
+		t.Errorf("stream status = %d, want Unavailable", code)
+	}
+}
+
+func TestNoteSubmitter(t *testing.T) {
+	creator := &fakeNoteCreator{}
+	submitter := NewNoteSubmitter(creator, "canvas-1")
+
+	resp, err := submitter.SubmitTask(context.Background(), SubmitTaskRequest{Prompt: "write a haiku", X: 5, Y: 6})
+	if err != nil {
+		t.Fatalf("SubmitTask failed: %v", err)
+	}
+	if resp.TaskID != "new-note" || resp.CanvasID != "canvas-1" {
+		t.Errorf("response = %+v", resp)
+	}
+	if creator.payload["text"] != "{{ write a haiku }}" {
+		t.Errorf("note text = %v", creator.payload["text"])
+	}
+
+	submitter.SetTriggerMarkers(func() (string, string) { return "[[", "]]" })
+	if _, err := submitter.SubmitTask(context.Background(), SubmitTaskRequest{Prompt: "write a haiku"}); err != nil {
+		t.Fatalf("SubmitTask with custom markers failed: %v", err)
+	}
+	if creator.payload["text"] != "[[ write a haiku ]]" {
+		t.Errorf("note text with custom markers = %v", creator.payload["text"])
+	}
+
+	var statusErr *StatusError
+	_, err = submitter.SubmitTask(context.Background(), SubmitTaskRequest{Prompt: "x ]]"})
+	if !errors.As(err, &statusErr) || statusErr.Code != CodeInvalidArgument {
+		t.Errorf("closing marker error = %v, want InvalidArgument", err)
+	}
+	_, err = submitter.SubmitTask(context.Background(), SubmitTaskRequest{Prompt: "x", CanvasID: "elsewhere"})
+	if !errors.As(err, &statusErr) || statusErr.Code != CodeInvalidArgument {
+		t.Errorf("other canvas error = %v, want InvalidArgument", err)
+	}
+
+	creator.err = errors.New("canvas offline")
+	_, err = submitter.SubmitTask(context.Background(), SubmitTaskRequest{Prompt: "x"})
+	if !errors.As(err, &statusErr) || statusErr.Code != CodeUnavailable {
+		t.Errorf("create failure error = %v, want Unavailable", err)
+	}
+}
+
+// fakeNoteCreator records the last note payload
+type fakeNoteCreator struct {
+	payload map[string]interface{}
+	err     error
+}
+
+func (f *fakeNoteCreator) CreateNote(payload map[string]interface{}) (map[string]interface{}, error) {
+	f.payload = payload
+	if f.err != nil {
+		return nil, f.err
+	}
+	return map[string]interface{}{"id": "new-note"}, nil
+}
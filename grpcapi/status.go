@@ -0,0 +1,67 @@
+// Package grpcapi provides the CanvusLLM gRPC service.
+// This file contains the gRPC status code atoms returned in the
+// grpc-status trailer.
+package grpcapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Code is a gRPC status code.
+type Code int
+
+// Status codes used by the CanvusLLM service.
+const (
+	CodeOK                 Code = 0
+	CodeCanceled           Code = 1
+	CodeInvalidArgument    Code = 3
+	CodeNotFound           Code = 5
+	CodeAlreadyExists      Code = 6
+	CodeFailedPrecondition Code = 9
+	CodeUnimplemented      Code = 12
+	CodeInternal           Code = 13
+	CodeUnavailable        Code = 14
+	CodeUnauthenticated    Code = 16
+)
+
+// StatusError is an error with a gRPC status code.
+type StatusError struct {
+	Code    Code
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("grpc status %d: %s", e.Code, e.Message)
+}
+
+// statusErrorf creates a StatusError with a formatted message.
+func statusErrorf(code Code, format string, args ...interface{}) error {
+	return &StatusError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// statusOf returns the code and message of err; errors without a status
+// are internal errors.
+func statusOf(err error) (Code, string) {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code, statusErr.Message
+	}
+	return CodeInternal, err.Error()
+}
+
+// encodeStatusMessage percent-encodes a status message for the
+// grpc-message trailer, as required by the gRPC HTTP/2 protocol.
+func encodeStatusMessage(msg string) string {
+	var b strings.Builder
+	for i := 0; i < len(msg); i++ {
+		c := msg[i]
+		if c >= ' ' && c <= '~' && c != '%' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,66 @@
+// Package grpcapi provides the CanvusLLM gRPC service.
+// This file contains the NoteSubmitter molecule which turns submitted
+// tasks into prompt notes for the canvas monitor.
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// NoteCreator creates notes on a canvas. Implemented by canvusapi.Client.
+type NoteCreator interface {
+	CreateNote(payload map[string]interface{}) (map[string]interface{}, error)
+}
+
+// NoteSubmitter submits tasks by creating "{{ prompt }}" notes on the
+// monitored canvas. The canvas monitor processes them like notes typed by
+// a user, so the note ID is the task ID reported in metrics and updates.
+type NoteSubmitter struct {
+	client   NoteCreator
+	canvasID string
+	markers  func() (open, close string)
+}
+
+// NewNoteSubmitter creates a NoteSubmitter for the canvas client is bound to.
+func NewNoteSubmitter(client NoteCreator, canvasID string) *NoteSubmitter {
+	return &NoteSubmitter{client: client, canvasID: canvasID}
+}
+
+// SetTriggerMarkers sets a function returning the trigger markers of the
+// canvas, for canvases whose settings replace {{ and }}.
+func (n *NoteSubmitter) SetTriggerMarkers(markers func() (open, close string)) {
+	n.markers = markers
+}
+
+// SubmitTask creates the prompt note. Implements TaskSubmitter.
+func (n *NoteSubmitter) SubmitTask(ctx context.Context, req SubmitTaskRequest) (SubmitTaskResponse, error) {
+	if req.CanvasID != "" && req.CanvasID != n.canvasID {
+		return SubmitTaskResponse{}, statusErrorf(CodeInvalidArgument, "canvas %s is not monitored", req.CanvasID)
+	}
+	openMarker, closeMarker := "{{", "}}"
+	if n.markers != nil {
+		openMarker, closeMarker = n.markers()
+	}
+	if strings.Contains(req.Prompt, closeMarker) {
+		return SubmitTaskResponse{}, statusErrorf(CodeInvalidArgument, "prompt must not contain %s", closeMarker)
+	}
+
+	response, err := n.client.CreateNote(map[string]interface{}{
+		"title": "API task",
+		"text":  openMarker + " " + req.Prompt + " " + closeMarker,
+		"location": map[string]float64{
+			"x": req.X,
+			"y": req.Y,
+		},
+	})
+	if err != nil {
+		return SubmitTaskResponse{}, statusErrorf(CodeUnavailable, "failed to create prompt note: %v", err)
+	}
+	noteID, ok := response["id"].(string)
+	if !ok {
+		return SubmitTaskResponse{}, fmt.Errorf("prompt note response missing id")
+	}
+	return SubmitTaskResponse{TaskID: noteID, CanvasID: n.canvasID}, nil
+}
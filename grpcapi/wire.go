@@ -0,0 +1,186 @@
+// Package grpcapi serves the CanvusLLM gRPC service (canvusllm.proto) for
+// programmatic clients: task submission, task status streaming, metrics and
+// model management.
+//
+// The service is served by the Server organism over unencrypted HTTP/2 using
+// only the standard library. This file contains the wire atoms: protobuf
+// field encoding and gRPC message framing.
+package grpcapi
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Protobuf wire types used by canvusllm.proto.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// maxMessageSize is the largest gRPC message accepted from a client.
+const maxMessageSize = 4 << 20
+
+// errTruncated reports a protobuf message that ends inside a field.
+var errTruncated = errors.New("truncated protobuf message")
+
+// encoder appends protobuf fields to a buffer. Fields holding their zero
+// value are omitted, as in proto3.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) tag(field, wireType int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field)<<3|uint64(wireType))
+}
+
+func (e *encoder) string(field int, s string) {
+	if s == "" {
+		return
+	}
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *encoder) int64(field int, v int64) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, wireVarint)
+	e.buf = binary.AppendUvarint(e.buf, uint64(v))
+}
+
+func (e *encoder) bool(field int, v bool) {
+	if !v {
+		return
+	}
+	e.tag(field, wireVarint)
+	e.buf = append(e.buf, 1)
+}
+
+func (e *encoder) double(field int, v float64) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, wireFixed64)
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+}
+
+// message appends an embedded message; nil messages are omitted.
+func (e *encoder) message(field int, m message) {
+	if m == nil {
+		return
+	}
+	data := m.marshal()
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(data)))
+	e.buf = append(e.buf, data...)
+}
+
+// field is one decoded protobuf field.
+type field struct {
+	num      int
+	wireType int
+	// varint holds varint and fixed values
+	varint uint64
+	// bytes holds length-delimited values
+	bytes []byte
+}
+
+func (f field) string() string   { return string(f.bytes) }
+func (f field) int64() int64     { return int64(f.varint) }
+func (f field) bool() bool       { return f.varint != 0 }
+func (f field) double() float64  { return math.Float64frombits(f.varint) }
+func (f field) isBytes() bool    { return f.wireType == wireBytes }
+func (f field) isFixed64() bool  { return f.wireType == wireFixed64 }
+func (f field) isVarint() bool   { return f.wireType == wireVarint }
+func (f field) embedded() []byte { return f.bytes }
+
+// decodeFields calls fn for each field of a protobuf message. Unknown
+// fields are passed to fn too and should be ignored.
+func decodeFields(data []byte, fn func(f field) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errTruncated
+		}
+		data = data[n:]
+		f := field{num: int(key >> 3), wireType: int(key & 7)}
+
+		switch f.wireType {
+		case wireVarint:
+			f.varint, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errTruncated
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return errTruncated
+			}
+			f.varint = binary.LittleEndian.Uint64(data)
+			data = data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return errTruncated
+			}
+			f.varint = uint64(binary.LittleEndian.Uint32(data))
+			data = data[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return errTruncated
+			}
+			f.bytes = data[n : n+int(length)]
+			data = data[n+int(length):]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", f.wireType)
+		}
+
+		if f.num <= 0 {
+			return fmt.Errorf("invalid protobuf field number %d", f.num)
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFrame reads one length-prefixed gRPC message. io.EOF means the client
+// sent no further messages.
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if header[0] != 0 {
+		return nil, errors.New("compressed messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes exceeds the %d byte limit", length, maxMessageSize)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return data, nil
+}
+
+// writeFrame writes one uncompressed length-prefixed gRPC message.
+func writeFrame(w io.Writer, data []byte) error {
+	var header [5]byte
+	binary.BigEndian.PutUint32(header[1:], uint32(len(data)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
@@ -0,0 +1,112 @@
+package grpcapi
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		in   message
+		out  message
+	}{
+		{
+			name: "submit request",
+			in:   &SubmitTaskRequest{Prompt: "summarise", CanvasID: "c1", X: 12.5, Y: -4},
+			out:  &SubmitTaskRequest{},
+		},
+		{
+			name: "task",
+			in:   &Task{ID: "n1", Type: "note", Status: "error", StartTimeUnixMS: 1700000000000, DurationMS: 250, Error: "boom"},
+			out:  &Task{},
+		},
+		{
+			name: "metrics with nested messages",
+			in: &Metrics{
+				TotalProcessed: 3,
+				TotalSuccess:   2,
+				TotalErrors:    1,
+				ByType: []TaskTypeMetrics{
+					{Type: "note", Count: 2, SuccessRate: 50, P95MS: 900, LatencyWindow: "5m"},
+					{Type: "pdf", Count: 1, SuccessRate: 100},
+				},
+				GPU: &GPUMetrics{Utilization: 42.5, MemoryUsed: 1 << 30, MemoryTotal: 8 << 30, Name: "RTX"},
+			},
+			out: &Metrics{},
+		},
+		{
+			name: "models",
+			in: &ListModelsResponse{Models: []Model{
+				{ID: "a", Name: "A", SizeBytes: 100, Installed: true, Active: true},
+				{ID: "b", DownloadState: "downloading", DownloadPercent: 12.5},
+			}},
+			out: &ListModelsResponse{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.out.unmarshal(tt.in.marshal()); err != nil {
+				t.Fatalf("unmarshal failed: %v", err)
+			}
+			if !reflect.DeepEqual(tt.in, tt.out) {
+				t.Errorf("round trip = %+v, want %+v", tt.out, tt.in)
+			}
+		})
+	}
+}
+
+func TestDecodeFieldsTruncated(t *testing.T) {
+	data := (&Task{ID: "note-1", Status: "success"}).marshal()
+	var task Task
+	if err := task.unmarshal(data[:len(data)-2]); err != errTruncated {
+		t.Errorf("unmarshal of truncated message = %v, want errTruncated", err)
+	}
+}
+
+func TestDecodeFieldsIgnoresUnknownFields(t *testing.T) {
+	var e encoder
+	e.string(99, "from a newer client")
+	e.string(1, "task-1")
+	var req GetTaskRequest
+	if err := req.unmarshal(e.buf); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if req.TaskID != "task-1" {
+		t.Errorf("TaskID = %q, want task-1", req.TaskID)
+	}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	payload := (&GetTaskRequest{TaskID: "abc"}).marshal()
+	if err := writeFrame(&buf, payload); err != nil {
+		t.Fatalf("writeFrame failed: %v", err)
+	}
+	got, err := readFrame(&buf)
+	if err != nil {
+		t.Fatalf("readFrame failed: %v", err)
+	}
+	if !bytes.Equal(got, payload) {
+		t.Errorf("readFrame = %x, want %x", got, payload)
+	}
+	if _, err := readFrame(&buf); err != io.EOF {
+		t.Errorf("readFrame at end = %v, want io.EOF", err)
+	}
+}
+
+func TestReadFrameRejectsCompressed(t *testing.T) {
+	frame := []byte{1, 0, 0, 0, 0}
+	if _, err := readFrame(bytes.NewReader(frame)); err == nil {
+		t.Error("expected error for compressed message")
+	}
+}
+
+func TestEncodeStatusMessage(t *testing.T) {
+	if got := encodeStatusMessage("50% done\n"); got != "50%25 done%0A" {
+		t.Errorf("encodeStatusMessage = %q", got)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
 	"io"
 	"net/http"
 	"os"
@@ -15,29 +17,53 @@ import (
 	"sync/atomic"
 	"time"
 
+	"go_backend/artifacts"
+	"go_backend/audit"
 	"go_backend/canvasanalyzer"
+	"go_backend/canvasexport"
+	"go_backend/canvassettings"
 	"go_backend/canvusapi"
+	"go_backend/collage"
 	"go_backend/core"
+	"go_backend/core/clock"
+	"go_backend/core/errs"
 	"go_backend/db"
+	"go_backend/events"
+	"go_backend/fewshot"
+	"go_backend/groupsummary"
 	"go_backend/handlers"
+	"go_backend/handwritingbatch"
+	"go_backend/i18n"
 	"go_backend/imagegen"
+	"go_backend/intent"
+	"go_backend/livetranscript"
 	"go_backend/llamaruntime"
+	"go_backend/llmcapture"
 	"go_backend/logging"
 	"go_backend/metrics"
+	"go_backend/notefix"
 	"go_backend/ocrprocessor"
+	"go_backend/outputfilter"
 	"go_backend/pdfprocessor"
+	"go_backend/promptexpander"
+	"go_backend/promptguard"
+	"go_backend/promptlib"
+	"go_backend/recovery"
+	"go_backend/redact"
+	"go_backend/rewrite"
+	"go_backend/sessions"
+	"go_backend/tempfiles"
+	"go_backend/variants"
+	"go_backend/vision"
+	"go_backend/voicenote"
+	"go_backend/widgettemplates"
 
-	"github.com/ledongthuc/pdf"
 	"github.com/sashabaranov/go-openai"
 	"go.uber.org/zap"
 )
 
 // Add constants at the top
 const (
-	processingNoteTitle     = "AI Processing"
-	processingNoteColor     = "#8B0000" // Dark blood red
-	processingNoteTextColor = "#FFFFFF"
-
 	// Google Vision API constants
 	visionAPIEndpoint = "https://vision.googleapis.com/v1/images:annotate"
 	visionFeatureType = "DOCUMENT_TEXT_DETECTION"
@@ -46,11 +72,25 @@ const (
 	noteSystemMessage = `You are an assistant capable of interpreting structured text triggers from a Note widget. ` +
 		`Evaluate whether the content in the Note is better suited for generating text or creating an image. ` +
 		`If generating text, respond with a JSON object like: {"type": "text", "content": "..."}. ` +
-		`If creating an image, respond with a JSON object like: {"type": "image", "content": "..."}. ` +
-		`For image requests, you must craft a vivid, imaginative, and highly detailed prompt for an AI image generator. ` +
-		`Do NOT simply repeat or rephrase the user's input. Instead, expand it into a unique, creative, and visually rich scene, including style, mood, composition, and any relevant artistic details. ` +
-		`The response should be rich and meaningful. Never just a repeat of the user's input. ` +
+		`If creating an image, respond with a JSON object like: {"type": "image", "content": "..."}, ` +
+		`where content describes the requested image in the user's own words. ` +
+		`Text responses should be rich and meaningful. ` +
 		`Do not include any additional text or explanations.`
+
+	// noteTextSystemMessage answers notes whose intent is already known to
+	// be text, so the reply needs no JSON wrapper.
+	noteTextSystemMessage = `You are an assistant answering a prompt written on a Note widget. ` +
+		`Reply with the answer only, as plain text, without any preamble.`
+
+	// noteRepairPrompt asks the model to fix a reply to the note system
+	// message that could not be read. It is filled with the problem and the
+	// previous reply.
+	noteRepairPrompt = `Your previous reply could not be used: %v. ` +
+		`Rewrite it as a single JSON object of the form {"type": "text" or "image", "content": "..."}, ` +
+		`keeping its meaning, with no other text.
+
+Previous reply:
+%s`
 )
 
 // Core types and configuration
@@ -62,10 +102,13 @@ type AINoteResponse struct {
 // HandlerDependencies holds dependencies injected into handler functions.
 // This eliminates global state and enables proper dependency injection.
 type HandlerDependencies struct {
-	// Metrics integration for dashboard
-	metricsStore    metrics.MetricsCollector
-	taskBroadcaster metrics.TaskBroadcaster
-	metricsMux      sync.RWMutex
+	// Task lifecycle events for the metrics store, dashboard and webhooks
+	events    *events.Bus
+	eventsMux sync.RWMutex
+
+	// Metrics store for the output filter and structured response counters
+	metricsStore metrics.MetricsCollector
+	metricsMux   sync.RWMutex
 
 	// Handler-level metrics tracking
 	handlerMetrics struct {
@@ -79,61 +122,786 @@ type HandlerDependencies struct {
 
 	// Serialization for image operations (prevents overwhelming downloads dir or API)
 	downloadsMutex sync.Mutex
+
+	// Keeps generated images and downloaded PDFs (nil keeps nothing)
+	artifactStore *artifacts.Store
+	artifactsMux  sync.RWMutex
+
+	// Records the images generated from each trigger note (nil records nothing)
+	imageVariants    *variants.History
+	imageVariantsMux sync.RWMutex
+
+	// Keeps the note text before each {{fix}} for undo (nil keeps nothing)
+	noteFixes    *notefix.History
+	noteFixesMux sync.RWMutex
+
+	// Transcribes uploaded audio files (nil transcribes nothing)
+	voiceNotes    *voicenote.Transcriber
+	voiceNotesMux sync.RWMutex
+
+	// Reads the snapshots of a canvas for {{handwriting}} (nil reads one
+	// at a time)
+	handwriting    *handwritingbatch.Reader
+	handwritingMux sync.RWMutex
+
+	// Runs {{live}} meeting transcripts (nil runs none)
+	liveTranscripts    *livetranscript.Manager
+	liveTranscriptsMux sync.RWMutex
+
+	// Stamps task records and times handlers (nil uses the real clock)
+	clock    clock.Clock
+	clockMux sync.RWMutex
+
+	// Holds downloaded and generated files while they are processed
+	// (nil falls back to unmanaged files in DownloadsDir)
+	tempFiles    *tempfiles.TempFileManager
+	tempFilesMux sync.RWMutex
+
+	// Masks personal data in text sent to a cloud LLM (nil sends text unchanged)
+	redactor    *redact.Redactor
+	redactorMux sync.RWMutex
+
+	// Neutralizes prompt-injection phrases in canvas and PDF text (nil sends text unchanged)
+	promptGuard    *promptguard.Guard
+	promptGuardMux sync.RWMutex
+
+	// Records AI widget changes in the tamper-evident audit trail (nil records nothing)
+	auditLog    *audit.Log
+	auditLogMux sync.RWMutex
+
+	// Told about the widgets AI tasks create or write to (nil tells no one)
+	widgetObserver    WidgetObserver
+	widgetObserverMux sync.RWMutex
+
+	// Masks or blocks unsafe LLM text before it reaches the canvas (nil shows text unchanged)
+	outputFilter    *outputfilter.Filter
+	outputFilterMux sync.RWMutex
+
+	// Records each canvas session's triggers and AI responses for replay (nil records nothing)
+	sessionRecorder    *sessions.Recorder
+	sessionRecorderMux sync.RWMutex
+
+	// Expands {{use:name}} triggers (nil fails them with an error note)
+	promptLibrary    *promptlib.Library
+	promptLibraryMux sync.RWMutex
+
+	// Settles obvious note intents without the LLM (nil lets the LLM decide every note)
+	intentClassifier    *intent.Classifier
+	intentClassifierMux sync.RWMutex
+
+	// Expands image requests from notes into detailed prompts (nil uses them as written)
+	promptExpander    *promptexpander.Expander
+	promptExpanderMux sync.RWMutex
+
+	// Few-shot examples attached to system prompts (nil attaches none)
+	fewShot    *fewshot.Set
+	fewShotMux sync.RWMutex
+
+	// Records running tasks so the next start can recover them (nil records nothing)
+	taskJournal    *recovery.Journal
+	taskJournalMux sync.RWMutex
 }
 
-// NewHandlerDependencies creates a new HandlerDependencies with optional metrics.
-func NewHandlerDependencies(store metrics.MetricsCollector, broadcaster metrics.TaskBroadcaster) *HandlerDependencies {
+// NewHandlerDependencies creates a new HandlerDependencies publishing task
+// lifecycle events on bus (nil publishes nothing).
+func NewHandlerDependencies(bus *events.Bus) *HandlerDependencies {
 	return &HandlerDependencies{
-		metricsStore:    store,
-		taskBroadcaster: broadcaster,
+		events: bus,
 	}
 }
 
-// SetMetrics updates the metrics store and broadcaster.
-// This is called from main.go when wiring the dashboard.
-func (d *HandlerDependencies) SetMetrics(store metrics.MetricsCollector, broadcaster metrics.TaskBroadcaster) {
+// SetEventBus sets the bus task starts and completions are published on.
+func (d *HandlerDependencies) SetEventBus(bus *events.Bus) {
+	d.eventsMux.Lock()
+	defer d.eventsMux.Unlock()
+	d.events = bus
+}
+
+// getEventBus returns the event bus, or nil if none is set.
+func (d *HandlerDependencies) getEventBus() *events.Bus {
+	d.eventsMux.RLock()
+	defer d.eventsMux.RUnlock()
+	return d.events
+}
+
+// SetMetrics sets the metrics store counting filtered and repaired
+// responses. Tasks reach it through the event bus.
+func (d *HandlerDependencies) SetMetrics(store metrics.MetricsCollector) {
 	d.metricsMux.Lock()
 	defer d.metricsMux.Unlock()
 	d.metricsStore = store
-	d.taskBroadcaster = broadcaster
 }
 
-// GetMetrics returns the current metrics store and broadcaster.
-func (d *HandlerDependencies) GetMetrics() (metrics.MetricsCollector, metrics.TaskBroadcaster) {
+// GetMetrics returns the current metrics store.
+func (d *HandlerDependencies) GetMetrics() metrics.MetricsCollector {
 	d.metricsMux.RLock()
 	defer d.metricsMux.RUnlock()
-	return d.metricsStore, d.taskBroadcaster
+	return d.metricsStore
+}
+
+// SetArtifactStore sets the store that keeps generated images and
+// downloaded PDFs. A nil store keeps nothing.
+func (d *HandlerDependencies) SetArtifactStore(store *artifacts.Store) {
+	d.artifactsMux.Lock()
+	defer d.artifactsMux.Unlock()
+	d.artifactStore = store
+}
+
+// getArtifactStore returns the artifact store, or nil if none is set.
+func (d *HandlerDependencies) getArtifactStore() *artifacts.Store {
+	d.artifactsMux.RLock()
+	defer d.artifactsMux.RUnlock()
+	return d.artifactStore
+}
+
+// SetImageVariants sets the history of the images generated from trigger
+// notes. A nil history records nothing.
+func (d *HandlerDependencies) SetImageVariants(history *variants.History) {
+	d.imageVariantsMux.Lock()
+	defer d.imageVariantsMux.Unlock()
+	d.imageVariants = history
+}
+
+// getImageVariants returns the image variant history, or nil if none is set.
+func (d *HandlerDependencies) getImageVariants() *variants.History {
+	d.imageVariantsMux.RLock()
+	defer d.imageVariantsMux.RUnlock()
+	return d.imageVariants
+}
+
+// SetNoteFixes sets the history of {{fix}} corrections. A nil history
+// keeps nothing, so fixes cannot be undone.
+func (d *HandlerDependencies) SetNoteFixes(history *notefix.History) {
+	d.noteFixesMux.Lock()
+	defer d.noteFixesMux.Unlock()
+	d.noteFixes = history
+}
+
+// getNoteFixes returns the note fix history, or nil if none is set.
+func (d *HandlerDependencies) getNoteFixes() *notefix.History {
+	d.noteFixesMux.RLock()
+	defer d.noteFixesMux.RUnlock()
+	return d.noteFixes
+}
+
+// SetVoiceNotes sets the transcriber of uploaded audio files. A nil
+// transcriber transcribes nothing.
+func (d *HandlerDependencies) SetVoiceNotes(t *voicenote.Transcriber) {
+	d.voiceNotesMux.Lock()
+	defer d.voiceNotesMux.Unlock()
+	d.voiceNotes = t
+}
+
+// getVoiceNotes returns the audio transcriber, or nil if none is set.
+func (d *HandlerDependencies) getVoiceNotes() *voicenote.Transcriber {
+	if d == nil {
+		return nil
+	}
+	d.voiceNotesMux.RLock()
+	defer d.voiceNotesMux.RUnlock()
+	return d.voiceNotes
+}
+
+// SetHandwriting sets the reader of {{handwriting}} batches, which bounds
+// the snapshots sent to the Vision API at a time.
+func (d *HandlerDependencies) SetHandwriting(r *handwritingbatch.Reader) {
+	d.handwritingMux.Lock()
+	defer d.handwritingMux.Unlock()
+	d.handwriting = r
+}
+
+// getHandwriting returns the handwriting batch reader, or nil if none is
+// set.
+func (d *HandlerDependencies) getHandwriting() *handwritingbatch.Reader {
+	if d == nil {
+		return nil
+	}
+	d.handwritingMux.RLock()
+	defer d.handwritingMux.RUnlock()
+	return d.handwriting
+}
+
+// SetLiveTranscripts sets the manager of live meeting transcripts. A nil
+// manager runs none.
+func (d *HandlerDependencies) SetLiveTranscripts(m *livetranscript.Manager) {
+	d.liveTranscriptsMux.Lock()
+	defer d.liveTranscriptsMux.Unlock()
+	d.liveTranscripts = m
+}
+
+// getLiveTranscripts returns the live transcript manager, or nil if none
+// is set.
+func (d *HandlerDependencies) getLiveTranscripts() *livetranscript.Manager {
+	if d == nil {
+		return nil
+	}
+	d.liveTranscriptsMux.RLock()
+	defer d.liveTranscriptsMux.RUnlock()
+	return d.liveTranscripts
+}
+
+// SetClock sets the clock of task records and handler durations, e.g. the
+// clock of TEST_DETERMINISTIC. nil uses the real clock.
+func (d *HandlerDependencies) SetClock(c clock.Clock) {
+	d.clockMux.Lock()
+	defer d.clockMux.Unlock()
+	d.clock = c
+}
+
+// getClock returns the handlers' clock.
+func (d *HandlerDependencies) getClock() clock.Clock {
+	if d == nil {
+		return clock.Real()
+	}
+	d.clockMux.RLock()
+	defer d.clockMux.RUnlock()
+	return clock.OrReal(d.clock)
+}
+
+// now returns the time on the handlers' clock.
+func (d *HandlerDependencies) now() time.Time {
+	return d.getClock().Now()
+}
+
+// since returns the time elapsed since start on the handlers' clock.
+func (d *HandlerDependencies) since(start time.Time) time.Duration {
+	return d.getClock().Since(start)
+}
+
+// keepArtifact copies the file at path to the artifact store, if one is
+// set. Failures are logged; the task itself is not affected.
+func (d *HandlerDependencies) keepArtifact(ctx context.Context, a artifacts.Artifact, path string, log *logging.Logger) {
+	store := d.getArtifactStore()
+	if store == nil {
+		return
+	}
+	kept, err := store.PutFile(ctx, a, path)
+	if err != nil {
+		log.Warn("failed to keep artifact", zap.String("file", path), zap.Error(err))
+		return
+	}
+	log.Debug("artifact kept",
+		zap.String("artifact_id", kept.ID),
+		zap.String("backend", store.Backend()))
+}
+
+// SetTempFiles sets the manager that holds temporary files.
+func (d *HandlerDependencies) SetTempFiles(m *tempfiles.TempFileManager) {
+	d.tempFilesMux.Lock()
+	defer d.tempFilesMux.Unlock()
+	d.tempFiles = m
+}
+
+// storeTempFile saves r as a temporary file with extension ext. The caller
+// must Release the returned file.
+func (d *HandlerDependencies) storeTempFile(r io.Reader, ext string, config *core.Config) (*tempfiles.File, error) {
+	d.tempFilesMux.RLock()
+	m := d.tempFiles
+	d.tempFilesMux.RUnlock()
+	if m != nil {
+		return m.Store(r, ext)
+	}
+	return tempfiles.StoreUnmanaged(config.DownloadsDir, r, ext)
+}
+
+// SetRedactor sets the redactor applied to text sent to a cloud LLM.
+// A nil redactor sends text unchanged.
+func (d *HandlerDependencies) SetRedactor(r *redact.Redactor) {
+	d.redactorMux.Lock()
+	defer d.redactorMux.Unlock()
+	d.redactor = r
+}
+
+// cloudRedactor returns the redactor to apply to text sent to the text
+// endpoint, or nil if there is none or the endpoint is local.
+func (d *HandlerDependencies) cloudRedactor(config *core.Config) *redact.Redactor {
+	d.redactorMux.RLock()
+	r := d.redactor
+	d.redactorMux.RUnlock()
+	if r == nil || handlers.IsLocalEndpoint(handlers.ResolveBaseURL(config.TextLLMURL, config.BaseLLMURL)) {
+		return nil
+	}
+	return r
+}
+
+// pdfChunkRedactor adapts the prompt guard and a Redactor to
+// pdfprocessor.ChunkRedactor and keeps their results for processing
+// history. Either may be nil.
+type pdfChunkRedactor struct {
+	guard      *promptguard.Guard
+	redactor   *redact.Redactor
+	injections *promptguard.Result
+	result     *redact.Result
+}
+
+func (p *pdfChunkRedactor) RedactChunks(ctx context.Context, chunks []string) ([]string, error) {
+	if p.guard != nil {
+		chunks, p.injections = p.guard.SanitizeAll(chunks)
+	}
+	if p.redactor == nil {
+		return chunks, nil
+	}
+	out, result, err := p.redactor.RedactAll(ctx, chunks)
+	p.result = result
+	return out, err
+}
+
+// recordRedaction records what was masked before a cloud LLM call as a
+// "pii_redaction" processing history entry with the same correlation ID.
+// Only counts per kind are stored, never the masked values.
+func recordRedaction(ctx context.Context, repo *db.Repository, correlationID, canvasID, widgetID string, result *redact.Result, log *logging.Logger) {
+	if result == nil || result.Total() == 0 {
+		return
+	}
+	log.Info("personal data redacted before cloud LLM call",
+		zap.String("redacted", result.Summary()),
+		zap.Int("occurrences", result.Occurrences))
+	recordProcessingHistory(
+		ctx, repo, correlationID, canvasID, widgetID,
+		"pii_redaction", result.Summary(), "", "",
+		0, 0, 0,
+		"success", "", log,
+	)
+}
+
+// SetTaskJournal sets the journal that records running tasks for the
+// startup recovery pass. A nil journal records nothing.
+func (d *HandlerDependencies) SetTaskJournal(j *recovery.Journal) {
+	d.taskJournalMux.Lock()
+	defer d.taskJournalMux.Unlock()
+	d.taskJournal = j
+}
+
+// getTaskJournal returns the task journal, or nil if none is set.
+func (d *HandlerDependencies) getTaskJournal() *recovery.Journal {
+	if d == nil {
+		return nil
+	}
+	d.taskJournalMux.RLock()
+	defer d.taskJournalMux.RUnlock()
+	return d.taskJournal
+}
+
+// recordProcessingNote records the processing note of a running task, so
+// the startup recovery pass can clean it up if the task is interrupted.
+func (d *HandlerDependencies) recordProcessingNote(record metrics.TaskRecord, noteID string) {
+	d.getTaskJournal().SetProcessingNote(record.ID, noteID)
+}
+
+// SetPromptGuard sets the guard applied to note, PDF and canvas text
+// before it is placed in an LLM prompt. A nil guard sends text unchanged.
+func (d *HandlerDependencies) SetPromptGuard(g *promptguard.Guard) {
+	d.promptGuardMux.Lock()
+	defer d.promptGuardMux.Unlock()
+	d.promptGuard = g
+}
+
+// getPromptGuard returns the prompt guard, or nil if none is set.
+func (d *HandlerDependencies) getPromptGuard() *promptguard.Guard {
+	if d == nil {
+		return nil
+	}
+	d.promptGuardMux.RLock()
+	defer d.promptGuardMux.RUnlock()
+	return d.promptGuard
+}
+
+// guardPrompt runs the prompt guard over text taken from the canvas and
+// returns the text to send to the LLM. Detections are logged and recorded.
+func (d *HandlerDependencies) guardPrompt(ctx context.Context, repo *db.Repository, correlationID, canvasID, widgetID, text string, log *logging.Logger) string {
+	g := d.getPromptGuard()
+	if g == nil {
+		return text
+	}
+	out, result := g.Sanitize(text)
+	recordInjection(ctx, repo, correlationID, canvasID, widgetID, result, log)
+	return out
+}
+
+// recordInjection logs prompt-injection patterns found in canvas or PDF
+// text and records them as a "prompt_injection" processing history entry
+// with the same correlation ID. Only counts per category and the action
+// taken are stored, never the matched text.
+func recordInjection(ctx context.Context, repo *db.Repository, correlationID, canvasID, widgetID string, result *promptguard.Result, log *logging.Logger) {
+	if result.Total() == 0 {
+		return
+	}
+	log.Warn("possible prompt injection in canvas content",
+		zap.String("patterns", result.Summary()),
+		zap.String("action", result.Action()))
+	recordProcessingHistory(
+		ctx, repo, correlationID, canvasID, widgetID,
+		"prompt_injection", result.Summary(), result.Action(), "",
+		0, 0, 0,
+		"success", "", log,
+	)
+}
+
+// SetOutputFilter sets the filter applied to LLM text before it is written
+// to the canvas. A nil filter shows text unchanged.
+func (d *HandlerDependencies) SetOutputFilter(f *outputfilter.Filter) {
+	d.outputFilterMux.Lock()
+	defer d.outputFilterMux.Unlock()
+	d.outputFilter = f
+}
+
+// filterResponse runs the output filter over an LLM response and returns
+// the text to write to the canvas; a blocked response becomes a notice.
+// Filtered responses are counted in the dashboard metrics and recorded as
+// an "output_filter" processing history entry. The matched terms are never
+// stored.
+func (d *HandlerDependencies) filterResponse(ctx context.Context, repo *db.Repository, correlationID, widgetID, text string, config *core.Config, log *logging.Logger) string {
+	if d == nil {
+		return text
+	}
+	d.outputFilterMux.RLock()
+	f := d.outputFilter
+	d.outputFilterMux.RUnlock()
+	if f == nil {
+		return text
+	}
+
+	out, result, err := f.Check(ctx, text)
+	if err != nil {
+		log.Warn("output filter classifier failed, blocking response", zap.Error(err))
+	}
+	if !result.Filtered() {
+		return out
+	}
+
+	log.Info("LLM response filtered before writing to canvas",
+		zap.String("filter", result.Summary()))
+	if store := d.GetMetrics(); store != nil {
+		if recorder, ok := store.(metrics.FilterRecorder); ok {
+			recorder.RecordFilteredResponse(string(result.Action))
+		}
+	}
+	recordProcessingHistory(
+		ctx, repo, correlationID, config.CanvasID, widgetID,
+		"output_filter", result.Summary(), "", "",
+		0, 0, 0,
+		"success", "", log,
+	)
+	if result.Action == outputfilter.ActionBlocked {
+		return i18n.T(config.Language, i18n.MsgResponseBlocked)
+	}
+	return out
+}
+
+// recordStructuredResponse counts how a structured LLM response was read
+// in the dashboard metrics; outcome is "valid", "repaired", "retried" or
+// "failed".
+func (d *HandlerDependencies) recordStructuredResponse(outcome string) {
+	if d == nil {
+		return
+	}
+	if store := d.GetMetrics(); store != nil {
+		if recorder, ok := store.(metrics.RepairRecorder); ok {
+			recorder.RecordStructuredResponse(outcome)
+		}
+	}
+}
+
+// SetAuditLog sets the audit trail that records AI widget changes.
+// A nil log records nothing.
+func (d *HandlerDependencies) SetAuditLog(l *audit.Log) {
+	d.auditLogMux.Lock()
+	defer d.auditLogMux.Unlock()
+	d.auditLog = l
+}
+
+// WidgetObserver is told about the widgets AI tasks create or write to.
+// Implemented by canvaspreview.Previewer.
+type WidgetObserver interface {
+	WidgetWritten(canvasID, widgetID, widgetType, operation, action string)
+}
+
+// SetWidgetObserver sets the observer told about the widgets AI tasks
+// create or write to. A nil observer is told nothing.
+func (d *HandlerDependencies) SetWidgetObserver(o WidgetObserver) {
+	d.widgetObserverMux.Lock()
+	defer d.widgetObserverMux.Unlock()
+	d.widgetObserver = o
+}
+
+// newAuditTrail returns the audit trail for one task started by trigger,
+// or nil if neither auditing nor a widget observer is set. A nil
+// *auditTrail records nothing.
+func (d *HandlerDependencies) newAuditTrail(config *core.Config, correlationID string, trigger Update, operation, model string, log *logging.Logger) *auditTrail {
+	if d == nil {
+		return nil
+	}
+	d.auditLogMux.RLock()
+	l := d.auditLog
+	d.auditLogMux.RUnlock()
+	d.widgetObserverMux.RLock()
+	observer := d.widgetObserver
+	d.widgetObserverMux.RUnlock()
+	if l == nil && observer == nil {
+		return nil
+	}
+	triggerID, _ := trigger["id"].(string)
+	triggerType, _ := trigger["widget_type"].(string)
+	return &auditTrail{
+		log:      l,
+		observer: observer,
+		base: audit.Entry{
+			CanvasID:        config.CanvasID,
+			CorrelationID:   correlationID,
+			TriggerWidgetID: triggerID,
+			TriggerType:     triggerType,
+			Operation:       operation,
+			Model:           model,
+		},
+		logger: log,
+	}
+}
+
+// auditTrail records the widgets one AI task creates or modifies.
+// Either log or observer may be nil.
+type auditTrail struct {
+	log      *audit.Log
+	observer WidgetObserver
+	base     audit.Entry
+	logger   *logging.Logger
+}
+
+// created records that the task created a widget holding content. A nil
+// content leaves the content hash empty.
+func (t *auditTrail) created(ctx context.Context, widgetType, widgetID string, content []byte) {
+	t.record(ctx, audit.ActionCreate, widgetType, widgetID, content)
+}
+
+// updated records that the task wrote content to an existing widget.
+func (t *auditTrail) updated(ctx context.Context, widgetType, widgetID string, content []byte) {
+	t.record(ctx, audit.ActionUpdate, widgetType, widgetID, content)
+}
+
+// record appends an entry. Failures are logged as errors; the widget has
+// already been written, so the task itself is not affected.
+func (t *auditTrail) record(ctx context.Context, action audit.Action, widgetType, widgetID string, content []byte) {
+	if t == nil {
+		return
+	}
+	if t.observer != nil {
+		t.observer.WidgetWritten(t.base.CanvasID, widgetID, widgetType, t.base.Operation, string(action))
+	}
+	if t.log == nil {
+		return
+	}
+	e := t.base
+	e.Action = action
+	e.WidgetType = widgetType
+	e.WidgetID = widgetID
+	if content != nil {
+		e.ContentSHA256 = audit.ContentHash(content)
+	}
+	if _, err := t.log.Record(ctx, e); err != nil {
+		t.logger.Error("failed to record audit entry",
+			zap.String("action", string(action)),
+			zap.String("audited_widget_id", widgetID),
+			zap.Error(err))
+	}
+}
+
+// auditFileContent returns the contents of a generated file for hashing,
+// or nil if it can no longer be read.
+func auditFileContent(path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// SetSessionRecorder sets the recorder that keeps each canvas session's
+// triggers and AI responses for replay. A nil recorder records nothing.
+func (d *HandlerDependencies) SetSessionRecorder(r *sessions.Recorder) {
+	d.sessionRecorderMux.Lock()
+	defer d.sessionRecorderMux.Unlock()
+	d.sessionRecorder = r
+}
+
+// SetPromptLibrary sets the templates that {{use:name}} triggers expand.
+func (d *HandlerDependencies) SetPromptLibrary(l *promptlib.Library) {
+	d.promptLibraryMux.Lock()
+	defer d.promptLibraryMux.Unlock()
+	d.promptLibrary = l
+}
+
+// SetIntentClassifier sets the classifier that decides obvious note
+// intents before the LLM is asked. A nil classifier lets the LLM decide
+// every note.
+func (d *HandlerDependencies) SetIntentClassifier(c *intent.Classifier) {
+	d.intentClassifierMux.Lock()
+	defer d.intentClassifierMux.Unlock()
+	d.intentClassifier = c
+}
+
+// classifyIntent decides the intent of a note prompt without the LLM
+// where it can. A failing local classifier is logged and leaves the
+// decision to the LLM.
+func (d *HandlerDependencies) classifyIntent(ctx context.Context, prompt string, log *logging.Logger) intent.Decision {
+	if d == nil {
+		return intent.Decision{Prompt: prompt}
+	}
+	d.intentClassifierMux.RLock()
+	c := d.intentClassifier
+	d.intentClassifierMux.RUnlock()
+
+	decision, err := c.Classify(ctx, prompt)
+	if err != nil {
+		log.Warn("intent classifier failed, asking the LLM", zap.Error(err))
+	}
+	return decision
+}
+
+// SetPromptExpander sets the stage that expands image requests from notes
+// into detailed image prompts. A nil expander uses requests as written.
+func (d *HandlerDependencies) SetPromptExpander(e *promptexpander.Expander) {
+	d.promptExpanderMux.Lock()
+	defer d.promptExpanderMux.Unlock()
+	d.promptExpander = e
+}
+
+// getPromptExpander returns the prompt expander, or nil if none is set.
+func (d *HandlerDependencies) getPromptExpander() *promptexpander.Expander {
+	if d == nil {
+		return nil
+	}
+	d.promptExpanderMux.RLock()
+	defer d.promptExpanderMux.RUnlock()
+	return d.promptExpander
+}
+
+// SetFewShotExamples sets the few-shot examples attached to the system
+// prompts of AI tasks. A nil set attaches none.
+func (d *HandlerDependencies) SetFewShotExamples(s *fewshot.Set) {
+	d.fewShotMux.Lock()
+	defer d.fewShotMux.Unlock()
+	d.fewShot = s
+}
+
+// withExamples returns prompt with the few-shot examples of task attached.
+func (d *HandlerDependencies) withExamples(task, prompt string) string {
+	if d == nil {
+		return prompt
+	}
+	d.fewShotMux.RLock()
+	s := d.fewShot
+	d.fewShotMux.RUnlock()
+	return s.Apply(task, prompt)
+}
+
+// expandPromptTemplate expands a {{use:name}} trigger in noteText with the
+// prompt library; the rest of the note fills the template's variables. ok
+// is false if the note does not use a template.
+func (d *HandlerDependencies) expandPromptTemplate(syntax handlers.TriggerSyntax, noteText string) (prompt string, ok bool, err error) {
+	content, rest, found := syntax.Cut(noteText)
+	if !found {
+		return "", false, nil
+	}
+	name, ok := promptlib.ParseUse(content)
+	if !ok {
+		return "", false, nil
+	}
+
+	var library *promptlib.Library
+	if d != nil {
+		d.promptLibraryMux.RLock()
+		library = d.promptLibrary
+		d.promptLibraryMux.RUnlock()
+	}
+	if library == nil {
+		return "", true, fmt.Errorf("%s%s%s needs the prompt library, which is unavailable (database disabled)", syntax.Open, content, syntax.Close)
+	}
+	prompt, err = library.Expand(name, rest)
+	return prompt, true, err
+}
+
+// recordSession records a trigger or response of the task correlationID,
+// which trigger started. text is the prompt of a trigger or the AI text of
+// a response. Failures are logged; the task itself is not affected.
+func (d *HandlerDependencies) recordSession(ctx context.Context, config *core.Config, kind sessions.Kind, correlationID, operation string, trigger Update, text, model string, log *logging.Logger) {
+	if d == nil {
+		return
+	}
+	d.sessionRecorderMux.RLock()
+	r := d.sessionRecorder
+	d.sessionRecorderMux.RUnlock()
+	if r == nil {
+		return
+	}
+
+	triggerID, _ := trigger["id"].(string)
+	triggerType, _ := trigger["widget_type"].(string)
+	location, _ := trigger["location"].(map[string]interface{})
+	size, _ := trigger["size"].(map[string]interface{})
+	loc := handlers.ExtractLocation(location)
+	sz := handlers.ExtractSize(size)
+	_, err := r.Record(ctx, sessions.Event{
+		CanvasID:      config.CanvasID,
+		Kind:          kind,
+		CorrelationID: correlationID,
+		Operation:     operation,
+		WidgetID:      triggerID,
+		WidgetType:    triggerType,
+		Text:          text,
+		Model:         model,
+		X:             loc.X,
+		Y:             loc.Y,
+		Width:         sz.Width,
+		Height:        sz.Height,
+	})
+	if err != nil {
+		log.Error("failed to record session event", zap.String("kind", string(kind)), zap.Error(err))
+	}
+}
+
+// pdfArtifactName returns the artifact name for a PDF widget title.
+func pdfArtifactName(title string) string {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "document.pdf"
+	}
+	if strings.HasSuffix(strings.ToLower(title), ".pdf") {
+		return title
+	}
+	return title + ".pdf"
 }
 
-// recordTaskStart records that a handler task has started processing.
+// recordTaskStart records that a handler task triggered by widgetID has
+// started processing and publishes events.TaskStarted. The task stays in
+// the task journal until recordTaskComplete.
 // Returns a TaskRecord that should be passed to recordTaskComplete.
-func (d *HandlerDependencies) recordTaskStart(taskID, taskType, canvasID string) metrics.TaskRecord {
+func (d *HandlerDependencies) recordTaskStart(taskID, taskType, canvasID, widgetID string) metrics.TaskRecord {
 	record := metrics.TaskRecord{
 		ID:        taskID,
 		Type:      taskType,
 		CanvasID:  canvasID,
+		WidgetID:  widgetID,
 		Status:    metrics.TaskStatusProcessing,
-		StartTime: time.Now(),
-	}
-
-	// Broadcast the "processing" status
-	_, broadcaster := d.GetMetrics()
-	if broadcaster != nil {
-		broadcaster.BroadcastTaskUpdateFromMetrics(metrics.TaskBroadcastData{
-			TaskID:   record.ID,
-			TaskType: record.Type,
-			Status:   record.Status,
-			CanvasID: record.CanvasID,
-		})
+		StartTime: d.now(),
 	}
+	d.getTaskJournal().Start(recovery.Entry{
+		TaskID:    taskID,
+		TaskType:  taskType,
+		CanvasID:  canvasID,
+		WidgetID:  widgetID,
+		StartedAt: record.StartTime,
+	})
 
+	events.Publish(d.getEventBus(), events.TaskStarted{Record: record})
 	return record
 }
 
-// recordTaskComplete records that a handler task has completed.
+// recordTaskComplete records that a handler task has completed and
+// publishes events.TaskCompleted, which the metrics store, the dashboard
+// and webhooks subscribe to.
 // If errMsg is non-empty, the task is marked as failed; otherwise successful.
 func (d *HandlerDependencies) recordTaskComplete(record metrics.TaskRecord, errMsg string) {
-	record.EndTime = time.Now()
+	d.getTaskJournal().Finish(record.ID, record.WidgetID)
+	record.EndTime = d.now()
 	record.Duration = record.EndTime.Sub(record.StartTime)
 
 	if errMsg != "" {
@@ -143,24 +911,7 @@ func (d *HandlerDependencies) recordTaskComplete(record metrics.TaskRecord, errM
 		record.Status = metrics.TaskStatusSuccess
 	}
 
-	store, broadcaster := d.GetMetrics()
-
-	// Record to metrics store
-	if store != nil {
-		store.RecordTask(record)
-	}
-
-	// Broadcast the completion status
-	if broadcaster != nil {
-		broadcaster.BroadcastTaskUpdateFromMetrics(metrics.TaskBroadcastData{
-			TaskID:   record.ID,
-			TaskType: record.Type,
-			Status:   record.Status,
-			CanvasID: record.CanvasID,
-			Duration: record.Duration,
-			Error:    record.ErrorMsg,
-		})
-	}
+	events.Publish(d.getEventBus(), events.TaskCompleted{Record: record})
 }
 
 // recordMetrics updates handler-level metrics (processed counts, duration).
@@ -216,6 +967,16 @@ func truncateText(text string, length int) string {
 	return handlers.TruncateText(text, length)
 }
 
+// estimateTokenCount estimates the tokens of a text (delegated to handlers package)
+func estimateTokenCount(text string) int {
+	return handlers.EstimateTokenCount(text)
+}
+
+// splitIntoChunks splits a text into chunks of at most maxChunkSize tokens (delegated to handlers package)
+func splitIntoChunks(text string, maxChunkSize int) []string {
+	return handlers.SplitIntoChunks(text, maxChunkSize)
+}
+
 // recordProcessingHistory records an AI processing operation to the database.
 // This is a helper function to avoid code duplication across handlers.
 // It performs async database write via the repository.
@@ -268,23 +1029,63 @@ func recordProcessingHistory(
 	}
 }
 
+// recordErrorLog records a failure shown on the canvas to the error log,
+// filed under its error code so the code on the note finds the entry.
+func recordErrorLog(ctx context.Context, repo *db.Repository, correlationID, canvasID, widgetID string, info errs.Info, err error, log *logging.Logger) {
+	if repo == nil {
+		return
+	}
+
+	details, _ := json.Marshal(map[string]string{
+		"canvas_id": canvasID,
+		"widget_id": widgetID,
+		"message":   info.Message,
+	})
+	entry := db.ErrorLogEntry{
+		CorrelationID: correlationID,
+		ErrorType:     string(info.Code),
+		ErrorMessage:  err.Error(),
+		Context:       string(details),
+	}
+	if _, err := repo.InsertErrorLog(ctx, entry); err != nil {
+		log.Warn("failed to record error log entry",
+			zap.Error(err),
+			zap.String("correlation_id", correlationID))
+	}
+}
+
+// LlamaModel runs inference on the local llama.cpp model: a
+// *llamaruntime.Client in this process, or a *llamaworker.Client when the
+// model runs in the GPU worker (GPU_ISOLATION=process).
+type LlamaModel interface {
+	Generate(ctx context.Context, prompt string, params llamaruntime.GenerationParams) (string, error)
+	InferVision(ctx context.Context, params llamaruntime.VisionParams) (*llamaruntime.InferenceResult, error)
+	Warmup(ctx context.Context) error
+	IsLoaded() bool
+	ContextDowngrade() (requested, actual int)
+}
+
 // handleNote processes Note widget updates.
 // If llamaClient is provided, it uses local inference; otherwise falls back to cloud API.
 //
 // Atomic design: Organism (orchestrates AI inference, Canvus API, and response creation)
-func handleNote(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient *llamaruntime.Client, deps *HandlerDependencies) {
+func handleNote(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient LlamaModel, deps *HandlerDependencies) {
 	noteID, _ := update["id"].(string)
+	correlationID := generateCorrelationID()
 	log := logger.With(
-		zap.String("correlation_id", generateCorrelationID()),
+		zap.String("correlation_id", correlationID),
 		zap.String("widget_id", noteID),
 		zap.String("widget_type", "Note"),
 	)
 
-	ctx := context.Background()
-	start := time.Now()
+	// LLM capture mode attributes the intent classification to this note
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: noteID, Operation: "text_generation",
+	})
+	start := deps.now()
 
 	// Record task start for dashboard metrics
-	taskRecord := deps.recordTaskStart(noteID, metrics.TaskTypeNote, config.CanvasID)
+	taskRecord := deps.recordTaskStart(noteID, metrics.TaskTypeNote, config.CanvasID, noteID)
 
 	// Create noteProcessingContext to reduce parameter passing
 	npc := &noteProcessingContext{
@@ -297,7 +1098,7 @@ func handleNote(update Update, client *canvusapi.Client, config *core.Config, lo
 		deps:          deps,
 		update:        update,
 		noteID:        noteID,
-		correlationID: log.GetCorrelationID(),
+		correlationID: correlationID,
 		start:         start,
 		taskRecord:    taskRecord,
 	}
@@ -310,8 +1111,10 @@ func handleNote(update Update, client *canvusapi.Client, config *core.Config, lo
 		return
 	}
 
-	// Detect AI prompt (supports both {{ }} and {{image:}} formats)
-	aiPrompt := handlers.ExtractAIPrompt(noteText)
+	// Detect AI prompt (supports both {{ }} and {{image:}} formats, or the
+	// canvas's own trigger markers)
+	syntax := handlers.NewTriggerSyntax(config.TriggerOpen, config.TriggerClose)
+	aiPrompt := syntax.ExtractPrompt(noteText)
 	if aiPrompt == "" {
 		log.Debug("no AI trigger found in note")
 		deps.recordTaskComplete(taskRecord, "no AI trigger")
@@ -319,25 +1122,64 @@ func handleNote(update Update, client *canvusapi.Client, config *core.Config, lo
 	}
 
 	npc.aiPrompt = aiPrompt
+	// {{use:name}} replaces the prompt with a template from the prompt library
+	if expanded, ok, err := deps.expandPromptTemplate(syntax, noteText); ok {
+		if err != nil {
+			log.Warn("prompt template expansion failed", zap.Error(err))
+			recordNoteError(npc, err)
+			return
+		}
+		log.Info("prompt template expanded", zap.String("template_prompt", truncateText(expanded, 100)))
+		aiPrompt = expanded
+		npc.aiPrompt = aiPrompt
+	}
 	log.Info("processing AI note",
 		zap.String("prompt_preview", truncateText(aiPrompt, 100)))
-
-	// Check for {{image:}} directive first
-	if strings.HasPrefix(strings.ToLower(aiPrompt), "image:") {
-		// Extract the actual image prompt
-		imagePrompt := strings.TrimSpace(strings.TrimPrefix(strings.ToLower(aiPrompt), "image:"))
-		imagePrompt = strings.TrimSpace(strings.TrimPrefix(aiPrompt, aiPrompt[:len("image:")]))
+	deps.recordSession(ctx, config, sessions.KindTrigger, npc.correlationID, sessions.OperationNote, update, aiPrompt, config.OpenAINoteModel, log)
+
+	// Check for {{image:}} and {{image@model:}} directives first
+	if imagePrompt, model, ok := imagegen.ParseImageDirective(aiPrompt); ok {
+		// --model, --size, --quality and --style flags override the
+		// configured image parameters; a model named with image@ wins.
+		// Without --size, a leading ratio such as 16:9 or the note's
+		// shape picks the size, and --n asks for several images
+		var options imagegen.ImageOptions
+		imagePrompt, options = imagegen.ParseImageFlags(imagePrompt)
+		if model != "" && model != canvassettings.LocalModel {
+			options.Model = model
+		}
 		log.Info("direct image generation request detected",
-			zap.String("image_prompt", truncateText(imagePrompt, 100)))
-
-		// Process as image directly
-		if err := processAIImage(ctx, client, imagePrompt, update, config, log, deps); err != nil {
-			log.Error("image generation failed", zap.Error(err))
+			zap.String("image_prompt", truncateText(imagePrompt, 100)),
+			zap.Any("options", options))
+		if err := options.Validate(config); err != nil {
+			log.Warn("invalid image options", zap.Error(err))
 			recordNoteError(npc, err)
 			return
 		}
+		noteSize, _ := update["size"].(map[string]interface{})
+		width, _ := noteSize["width"].(float64)
+		height, _ := noteSize["height"].(float64)
+		config = options.Apply(config)
+		if fitted := options.FitAspect(imagegen.WidgetSize{Width: width, Height: height}, config.OpenAIImageModel); fitted.Size != "" {
+			config.ImageGenSize = fitted.Size
+		}
 
-		// Record success
+		// Process as image directly, placing a series side by side
+		trail := deps.newAuditTrail(config, npc.correlationID, update, "image_generation", config.OpenAIImageModel, log)
+		imageUpdate := update
+		for i := 0; i < options.Count(); i++ {
+			imageStart := deps.now()
+			revisedPrompt, err := processAIImage(ctx, client, imagePrompt, imageUpdate, config, log, deps, trail)
+			if err != nil {
+				log.Error("image generation failed", zap.Error(err))
+				recordNoteError(npc, err)
+				return
+			}
+			recordImageGeneration(npc, config, imagePrompt, revisedPrompt, imageStart)
+			imageUpdate = nextImageUpdate(imageUpdate, config.ImageGenSize)
+		}
+
+		// Record success
 		recordNoteSuccess(npc)
 		return
 	}
@@ -354,7 +1196,7 @@ type noteProcessingContext struct {
 	config        *core.Config
 	log           *logging.Logger
 	repo          *db.Repository
-	llamaClient   *llamaruntime.Client
+	llamaClient   LlamaModel
 	deps          *HandlerDependencies
 	update        Update
 	noteID        string
@@ -366,8 +1208,9 @@ type noteProcessingContext struct {
 
 // processNoteWithAI uses AI to classify the prompt and generate appropriate response.
 func processNoteWithAI(npc *noteProcessingContext) {
-	// Use AI to determine if this is a text or image request
-	aiResp, err := classifyNoteIntent(npc)
+	// Settle obvious intents first; only ambiguous prompts are classified
+	// by the LLM
+	aiResp, err := resolveNoteIntent(npc)
 	if err != nil {
 		npc.log.Error("AI classification failed", zap.Error(err))
 		recordNoteError(npc, err)
@@ -383,11 +1226,18 @@ func processNoteWithAI(npc *noteProcessingContext) {
 			return
 		}
 	case "image":
-		if err := processAIImage(npc.ctx, npc.client, aiResp.Content, npc.update, npc.config, npc.log, npc.deps); err != nil {
+		imagePrompt := expandImagePrompt(npc, aiResp.Content)
+		npc.deps.recordSession(npc.ctx, npc.config, sessions.KindResponse, npc.correlationID, sessions.OperationNote, npc.update,
+			"image: "+imagePrompt, npc.config.OpenAINoteModel, npc.log)
+		imageStart := npc.deps.now()
+		trail := npc.deps.newAuditTrail(npc.config, npc.correlationID, npc.update, "image_generation", npc.config.OpenAIImageModel, npc.log)
+		revisedPrompt, err := processAIImage(npc.ctx, npc.client, imagePrompt, npc.update, npc.config, npc.log, npc.deps, trail)
+		if err != nil {
 			npc.log.Error("image generation failed", zap.Error(err))
 			recordNoteError(npc, err)
 			return
 		}
+		recordImageGeneration(npc, npc.config, imagePrompt, revisedPrompt, imageStart)
 	default:
 		err := fmt.Errorf("unknown AI response type: %s", aiResp.Type)
 		npc.log.Error("invalid AI response", zap.Error(err))
@@ -399,103 +1249,256 @@ func processNoteWithAI(npc *noteProcessingContext) {
 	recordNoteSuccess(npc)
 }
 
-// classifyNoteIntent uses AI to determine if the prompt is for text or image generation.
-func classifyNoteIntent(npc *noteProcessingContext) (*AINoteResponse, error) {
-	// Prepare the AI request with the system message
-	messages := []openai.ChatCompletionMessage{
-		{Role: "system", Content: noteSystemMessage},
-		{Role: "user", Content: npc.aiPrompt},
+// expandImagePrompt turns an image request into the prompt for the image
+// generator with the prompt expander. If expansion is off or fails, the
+// request is used as written.
+func expandImagePrompt(npc *noteProcessingContext, request string) string {
+	expander := npc.deps.getPromptExpander()
+	if !expander.Enabled() {
+		return request
+	}
+	prompt, err := expander.Expand(npc.ctx, request, func(ctx context.Context, systemPrompt, prompt string, temperature float32) (string, error) {
+		return generateNoteReply(npc, systemPrompt, prompt, temperature)
+	})
+	if err != nil {
+		npc.log.Warn("image prompt expansion failed, using the request as written", zap.Error(err))
+		return request
 	}
+	npc.log.Info("image prompt expanded",
+		zap.String("creativity", string(expander.Config().Creativity)),
+		zap.String("image_prompt", truncateText(prompt, 100)))
+	return prompt
+}
 
-	// Use local inference if available, otherwise cloud API
-	var responseText string
-	var err error
+// resolveNoteIntent decides whether the note asks for text or an image.
+// Intents the classifier settles skip the LLM classification: image
+// prompts go straight to image generation, text prompts are answered
+// directly. Ambiguous prompts are classified by the LLM.
+func resolveNoteIntent(npc *noteProcessingContext) (*AINoteResponse, error) {
+	decision := npc.deps.classifyIntent(npc.ctx, npc.aiPrompt, npc.log)
+	if decision.Intent != intent.Unknown {
+		npc.log.Info("note intent decided without LLM",
+			zap.String("intent", string(decision.Intent)),
+			zap.String("source", decision.Source),
+			zap.String("rule", decision.Rule))
+	}
+
+	switch decision.Intent {
+	case intent.Image:
+		return &AINoteResponse{Type: "image", Content: decision.Prompt}, nil
+	case intent.Text:
+		systemMessage := i18n.Prompt(npc.config.Language, i18n.PromptNote, noteTextSystemMessage)
+		text, err := generateNoteReply(npc, systemMessage, decision.Prompt, 0.7)
+		if err != nil {
+			return nil, err
+		}
+		return &AINoteResponse{Type: "text", Content: text}, nil
+	default:
+		return classifyNoteIntent(npc)
+	}
+}
 
+// generateNoteReply runs prompt with systemMessage on the local model if
+// available, otherwise on the cloud API.
+func generateNoteReply(npc *noteProcessingContext, systemMessage, prompt string, temperature float32) (string, error) {
+	prompt = npc.deps.guardPrompt(npc.ctx, npc.repo, npc.correlationID, npc.config.CanvasID, npc.noteID, prompt, npc.log)
 	if npc.llamaClient != nil {
-		npc.log.Info("using local LLM for intent classification")
-		responseText, err = npc.llamaClient.Generate(npc.ctx, npc.aiPrompt, llamaruntime.GenerationParams{
-			MaxTokens:   500,
-			Temperature: 0.7,
-			SystemPrompt: &noteSystemMessage,
-		})
-	} else {
-		npc.log.Info("using cloud API for intent classification")
-		aiClient := core.CreateOpenAIClient(npc.config)
-		resp, apiErr := aiClient.CreateChatCompletion(npc.ctx, openai.ChatCompletionRequest{
-			Model:       npc.config.OpenAINoteModel,
-			Messages:    messages,
-			MaxTokens:   500,
-			Temperature: 0.7,
+		npc.log.Info("using local LLM for note")
+		text, err := npc.llamaClient.Generate(npc.ctx, prompt, llamaruntime.GenerationParams{
+			MaxTokens:    500,
+			Temperature:  temperature,
+			SystemPrompt: &systemMessage,
 		})
-		if apiErr != nil {
-			return nil, fmt.Errorf("OpenAI API error: %w", apiErr)
+		if err != nil {
+			return "", fmt.Errorf("AI generation error: %w", err)
 		}
-		if len(resp.Choices) == 0 {
-			return nil, fmt.Errorf("no response from AI")
+		return text, nil
+	}
+
+	npc.log.Info("using cloud API for note")
+	if redactor := npc.deps.cloudRedactor(npc.config); redactor != nil {
+		redacted, result, err := redactor.Redact(npc.ctx, prompt)
+		if err != nil {
+			return "", fmt.Errorf("PII redaction failed: %w", err)
 		}
-		responseText = resp.Choices[0].Message.Content
+		recordRedaction(npc.ctx, npc.repo, npc.correlationID, npc.config.CanvasID, npc.noteID, result, npc.log)
+		prompt = redacted
+	}
+	aiClient := core.CreateOpenAIClient(npc.config)
+	resp, err := aiClient.CreateChatCompletion(npc.ctx, openai.ChatCompletionRequest{
+		Model: npc.config.OpenAINoteModel,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: systemMessage},
+			{Role: "user", Content: prompt},
+		},
+		MaxTokens:   500,
+		Temperature: temperature,
+	})
+	if err != nil {
+		return "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("no response from AI")
 	}
+	return resp.Choices[0].Message.Content, nil
+}
 
+// classifyNoteIntent uses AI to determine if the prompt is for text or image generation.
+func classifyNoteIntent(npc *noteProcessingContext) (*AINoteResponse, error) {
+	// Prepare the AI request with the system message in the canvas's language
+	systemMessage := npc.deps.withExamples(fewshot.TaskNote, i18n.Prompt(npc.config.Language, i18n.PromptNote, noteSystemMessage))
+	responseText, err := generateNoteReply(npc, systemMessage, npc.aiPrompt, 0.7)
 	if err != nil {
-		return nil, fmt.Errorf("AI generation error: %w", err)
+		return nil, err
 	}
 
 	npc.log.Debug("AI classification response",
 		zap.String("response", truncateText(responseText, 200)))
 
-	// Parse the JSON response
-	var aiResp AINoteResponse
-	if err := json.Unmarshal([]byte(responseText), &aiResp); err != nil {
-		// If JSON parsing fails, treat it as a text response
-		npc.log.Warn("failed to parse AI response as JSON, treating as text",
-			zap.Error(err),
-			zap.String("response", truncateText(responseText, 200)))
-		return &AINoteResponse{
-			Type:    "text",
-			Content: responseText,
-		}, nil
+	return readNoteResponse(npc, systemMessage, responseText), nil
+}
+
+// readNoteResponse reads the JSON reply to the note system message. A reply
+// that is malformed or does not match the schema is repaired locally, then
+// the model is asked to fix it up to config.JSONRepairAttempts times. If
+// that fails too, the reply is treated as a text response. Each outcome is
+// counted in the dashboard metrics.
+func readNoteResponse(npc *noteProcessingContext, systemMessage, responseText string) *AINoteResponse {
+	resp, repaired, err := handlers.ParseNoteResponse(responseText)
+	if err == nil {
+		if repaired {
+			npc.log.Info("repaired malformed JSON in AI response")
+			npc.deps.recordStructuredResponse("repaired")
+		} else {
+			npc.deps.recordStructuredResponse("valid")
+		}
+		return &AINoteResponse{Type: resp.Type, Content: resp.Content}
+	}
+
+	reply := responseText
+	for attempt := 1; attempt <= npc.config.JSONRepairAttempts; attempt++ {
+		npc.log.Info("asking the model to fix its response",
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+		var genErr error
+		reply, genErr = generateNoteReply(npc, systemMessage, fmt.Sprintf(noteRepairPrompt, err, reply), 0)
+		if genErr != nil {
+			npc.log.Warn("repair request failed", zap.Error(genErr))
+			break
+		}
+		if resp, _, err = handlers.ParseNoteResponse(reply); err == nil {
+			npc.deps.recordStructuredResponse("retried")
+			return &AINoteResponse{Type: resp.Type, Content: resp.Content}
+		}
 	}
 
-	return &aiResp, nil
+	// If the response still cannot be read, treat it as a text response
+	npc.log.Warn("failed to parse AI response as JSON, treating as text",
+		zap.Error(err),
+		zap.String("response", truncateText(responseText, 200)))
+	npc.deps.recordStructuredResponse("failed")
+	return &AINoteResponse{
+		Type:    "text",
+		Content: responseText,
+	}
 }
 
-// createAITextNote creates a note widget with the AI-generated text response.
-func createAITextNote(npc *noteProcessingContext, content string) error {
-	location := npc.update["location"].(map[string]interface{})
-	size := npc.update["size"].(map[string]interface{})
+// replayModelLocal asks replayNotePrompt to use the local model.
+const replayModelLocal = "local"
 
-	// Calculate position for the response note (to the right of the trigger)
-	newLocation := handlers.CalculateNoteLocation(location, size, npc.config.NoteSpacing)
+// replayNotePrompt answers a recorded note prompt the way
+// classifyNoteIntent does, for session replay. An empty model uses the
+// model the live handler would, "local" the local model and any other
+// model that cloud model. Image answers are returned as "image: <prompt>"
+// without generating the image.
+func replayNotePrompt(ctx context.Context, config *core.Config, llamaClient LlamaModel, deps *HandlerDependencies, prompt, model string) (string, error) {
+	systemMessage := deps.withExamples(fewshot.TaskNote, i18n.Prompt(config.Language, i18n.PromptNote, noteSystemMessage))
+	prompt, _ = deps.getPromptGuard().Sanitize(prompt)
 
-	note := canvusapi.CreateNoteRequest{
-		Location: canvusapi.WidgetLocation{
-			X: newLocation["x"].(float64),
-			Y: newLocation["y"].(float64),
-		},
-		Size: canvusapi.WidgetSize{
-			Width:  npc.config.NoteWidth,
-			Height: npc.config.NoteHeight,
-		},
-		BackgroundColor: npc.config.NoteColor,
-		TextColor:       npc.config.NoteTextColor,
-		Text:            content,
+	var responseText string
+	switch {
+	case model == replayModelLocal && llamaClient == nil:
+		return "", fmt.Errorf("no local model is loaded")
+	case llamaClient != nil && (model == "" || model == replayModelLocal):
+		var err error
+		responseText, err = llamaClient.Generate(ctx, prompt, llamaruntime.GenerationParams{
+			MaxTokens:    500,
+			Temperature:  0.7,
+			SystemPrompt: &systemMessage,
+		})
+		if err != nil {
+			return "", fmt.Errorf("AI generation error: %w", err)
+		}
+	default:
+		if model == "" {
+			model = config.OpenAINoteModel
+		}
+		if redactor := deps.cloudRedactor(config); redactor != nil {
+			redacted, _, err := redactor.Redact(ctx, prompt)
+			if err != nil {
+				return "", fmt.Errorf("PII redaction failed: %w", err)
+			}
+			prompt = redacted
+		}
+		resp, err := core.CreateOpenAIClient(config).CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+			Model: model,
+			Messages: []openai.ChatCompletionMessage{
+				{Role: "system", Content: systemMessage},
+				{Role: "user", Content: prompt},
+			},
+			MaxTokens:   500,
+			Temperature: 0.7,
+		})
+		if err != nil {
+			return "", fmt.Errorf("OpenAI API error: %w", err)
+		}
+		if len(resp.Choices) == 0 {
+			return "", fmt.Errorf("no response from AI")
+		}
+		responseText = resp.Choices[0].Message.Content
+	}
+
+	aiResp, _, err := handlers.ParseNoteResponse(responseText)
+	if err != nil {
+		return responseText, nil
 	}
+	if aiResp.Type == "image" {
+		return "image: " + aiResp.Content, nil
+	}
+	return aiResp.Content, nil
+}
 
-	result, err := npc.client.CreateNote(note)
+// createAITextNote creates a note widget with the AI-generated text response.
+func createAITextNote(npc *noteProcessingContext, content string) error {
+	npc.deps.recordSession(npc.ctx, npc.config, sessions.KindResponse, npc.correlationID, sessions.OperationNote, npc.update,
+		content, npc.config.OpenAINoteModel, npc.log)
+	content = npc.deps.filterResponse(npc.ctx, npc.repo, npc.correlationID, npc.noteID, content, npc.config, npc.log) +
+		contextReducedFooter(npc.llamaClient, npc.config)
+
+	// The response note goes to the right of the trigger
+	widgets := widgettemplates.Response.Instantiate(
+		widgettemplates.Content{Body: content},
+		widgettemplates.Beside(handlers.WidgetBounds(npc.update, nil)),
+		widgettemplates.ThemeFromConfig(npc.config),
+	)
+	created, err := widgettemplates.Create(npc.client, widgets)
 	if err != nil {
 		return fmt.Errorf("failed to create note: %w", err)
 	}
+	noteID := widgettemplates.ID(created, widgettemplates.RoleBody)
 
 	npc.log.Info("AI note created",
-		zap.String("note_id", result.ID),
+		zap.String("note_id", noteID),
 		zap.Int("content_length", len(content)))
+	npc.deps.newAuditTrail(npc.config, npc.correlationID, npc.update, "text_generation", npc.config.OpenAINoteModel, npc.log).
+		created(npc.ctx, "Note", noteID, []byte(content))
 
 	return nil
 }
 
 // recordNoteSuccess records successful note processing to database and metrics.
 func recordNoteSuccess(npc *noteProcessingContext) {
-	duration := time.Since(npc.start)
+	duration := npc.deps.since(npc.start)
 	recordProcessingHistory(
 		npc.ctx, npc.repo, npc.correlationID, npc.config.CanvasID, npc.noteID,
 		"text_generation", npc.aiPrompt, "", npc.config.OpenAINoteModel,
@@ -507,19 +1510,31 @@ func recordNoteSuccess(npc *noteProcessingContext) {
 	npc.deps.recordTaskComplete(npc.taskRecord, "") // Empty string = success
 }
 
+// recordImageGeneration records an image generated for a note to the
+// processing history. The response is the prompt the provider revised the
+// image prompt to (DALL-E 3), or empty if it used the prompt as written.
+func recordImageGeneration(npc *noteProcessingContext, config *core.Config, prompt, revisedPrompt string, start time.Time) {
+	recordProcessingHistory(
+		npc.ctx, npc.repo, npc.correlationID, config.CanvasID, npc.noteID,
+		"image_generation", prompt, revisedPrompt, config.OpenAIImageModel,
+		0, 0, int(npc.deps.since(start).Milliseconds()),
+		"success", "", npc.log,
+	)
+}
+
 // recordNoteError records failed note processing to the database and dashboard metrics.
 func recordNoteError(npc *noteProcessingContext, err error) {
 	recordProcessingHistory(
 		npc.ctx, npc.repo, npc.correlationID, npc.config.CanvasID, npc.noteID,
 		"text_generation", npc.aiPrompt, "", npc.config.OpenAINoteModel,
-		0, 0, int(time.Since(npc.start).Milliseconds()),
+		0, 0, int(npc.deps.since(npc.start).Milliseconds()),
 		"error", err.Error(), npc.log,
 	)
-	npc.deps.recordMetrics("error", time.Since(npc.start))
+	npc.deps.recordMetrics("error", npc.deps.since(npc.start))
 	npc.deps.recordTaskComplete(npc.taskRecord, err.Error())
 
 	// Try to notify the user via error note
-	if notifyErr := handleAIError(npc.ctx, npc.client, npc.update, err, "", npc.config, npc.log); notifyErr != nil {
+	if notifyErr := handleAIError(npc.ctx, npc.client, npc.repo, npc.correlationID, npc.update, err, "", npc.config, npc.log); notifyErr != nil {
 		npc.log.Error("failed to create error note", zap.Error(notifyErr))
 	}
 }
@@ -535,27 +1550,32 @@ func isAzureOpenAIEndpoint(endpoint string) bool {
 	return handlers.IsAzureOpenAIEndpoint(endpoint)
 }
 
-// processAIImage generates and uploads an image from the AI's response using imagegen package
-func processAIImage(ctx context.Context, client *canvusapi.Client, prompt string, update Update, config *core.Config, log *logging.Logger, deps *HandlerDependencies) error {
+// processAIImage generates and uploads an image from the AI's response using imagegen package.
+// It returns the prompt the provider revised the image prompt to, or "".
+func processAIImage(ctx context.Context, client *canvusapi.Client, prompt string, update Update, config *core.Config, log *logging.Logger, deps *HandlerDependencies, trail *auditTrail) (string, error) {
 	deps.downloadsMutex.Lock()
 	defer deps.downloadsMutex.Unlock()
 
 	log.Info("generating AI image via imagegen",
 		zap.String("prompt_preview", truncateText(prompt, 50)))
 
-	// Check for local endpoint (not supported for cloud image generation)
-	if imagegen.IsLocalEndpoint(config.ImageLLMURL) || imagegen.IsLocalEndpoint(config.BaseLLMURL) {
+	// Check for local endpoint (not supported for cloud image generation);
+	// hosted models don't use the LLM endpoints
+	if !imagegen.IsHostedModel(config.OpenAIImageModel) &&
+		(imagegen.IsLocalEndpoint(config.ImageLLMURL) || imagegen.IsLocalEndpoint(config.BaseLLMURL)) {
 		// For local endpoints, fall back to the original implementation
 		// since imagegen.Generator is for cloud providers only
-		return processAIImageFallback(ctx, client, prompt, update, config, log)
+		return "", processAIImageFallback(ctx, client, prompt, update, config, log, deps, trail)
 	}
 
 	// Create the generator using the convenience constructor
 	generator, err := imagegen.NewGeneratorFromConfig(config, client, log)
 	if err != nil {
 		log.Error("failed to create image generator", zap.Error(err))
-		return fmt.Errorf("failed to create image generator: %w", err)
+		return "", fmt.Errorf("failed to create image generator: %w", err)
 	}
+	generator.SetArtifactStore(deps.getArtifactStore(), config.CanvasID)
+	generator.SetImageVariants(deps.getImageVariants())
 
 	// Convert Update map to ParentWidget interface
 	parentWidget := updateToParentWidget(update)
@@ -564,13 +1584,14 @@ func processAIImage(ctx context.Context, client *canvusapi.Client, prompt string
 	result, err := generator.Generate(ctx, prompt, parentWidget)
 	if err != nil {
 		log.Error("image generation failed", zap.Error(err))
-		return err
+		return "", err
 	}
 
 	log.Info("image generation completed",
 		zap.String("widget_id", result.WidgetID))
+	trail.created(ctx, "Image", result.WidgetID, auditFileContent(result.ImagePath))
 
-	return nil
+	return result.RevisedPrompt, nil
 }
 
 // updateToParentWidget converts a handler Update map to an imagegen.ParentWidget
@@ -593,8 +1614,29 @@ func updateToParentWidget(update Update) imagegen.ParentWidget {
 	}
 }
 
+// nextImageUpdate returns a copy of update moved right past an image of the
+// given size, so the next image of a --n series is placed beside the
+// previous one.
+func nextImageUpdate(update Update, size string) Update {
+	width, _, ok := imagegen.ParseImageSize(size)
+	if !ok {
+		// "auto" images are at most 1536 pixels wide
+		width = 1536
+	}
+	next := imagegen.NextInSeries(updateToParentWidget(update), float64(width))
+	moved := make(Update, len(update))
+	for k, v := range update {
+		moved[k] = v
+	}
+	moved["location"] = map[string]interface{}{
+		"x": next.GetLocation().X,
+		"y": next.GetLocation().Y,
+	}
+	return moved
+}
+
 // processAIImageFallback is the original implementation for local endpoints
-func processAIImageFallback(ctx context.Context, client *canvusapi.Client, prompt string, update Update, config *core.Config, log *logging.Logger) error {
+func processAIImageFallback(ctx context.Context, client *canvusapi.Client, prompt string, update Update, config *core.Config, log *logging.Logger, deps *HandlerDependencies, trail *auditTrail) error {
 	// Ensure downloads directory exists
 	if err := os.MkdirAll(config.DownloadsDir, 0755); err != nil {
 		return fmt.Errorf("failed to create downloads directory: %w", err)
@@ -626,20 +1668,20 @@ func processAIImageFallback(ctx context.Context, client *canvusapi.Client, promp
 
 	// Generate the image using the appropriate API
 	if isAzure {
-		return processAIImageAzure(ctx, client, prompt, update, config, endpoint, log)
+		return processAIImageAzure(ctx, client, prompt, update, config, endpoint, log, deps, trail)
 	} else {
-		return processAIImageOpenAI(ctx, client, prompt, update, config, endpoint, log)
+		return processAIImageOpenAI(ctx, client, prompt, update, config, endpoint, log, deps, trail)
 	}
 }
 
 // processAIImageOpenAI generates images using standard OpenAI API
-func processAIImageOpenAI(ctx context.Context, client *canvusapi.Client, prompt string, update Update, config *core.Config, endpoint string, log *logging.Logger) error {
+func processAIImageOpenAI(ctx context.Context, client *canvusapi.Client, prompt string, update Update, config *core.Config, endpoint string, log *logging.Logger, deps *HandlerDependencies, trail *auditTrail) error {
 	// Generate the image using the configured API endpoint
 	imageConfig := openai.DefaultConfig(config.OpenAIAPIKey)
 	imageConfig.BaseURL = endpoint
 
 	// Create HTTP client with proper TLS configuration
-	imageConfig.HTTPClient = core.GetHTTPClient(config.AllowSelfSignedCerts)
+	imageConfig.HTTPClient = core.GetHTTPClient(config, config.AITimeout)
 
 	imageClient := openai.NewClientWithConfig(imageConfig)
 
@@ -665,11 +1707,11 @@ func processAIImageOpenAI(ctx context.Context, client *canvusapi.Client, prompt
 		zap.String("prompt_preview", truncateText(prompt, 50)))
 
 	// Download and upload the image
-	return downloadAndUploadImage(ctx, client, imageURL, update, config, log)
+	return downloadAndUploadImage(ctx, client, imageURL, prompt, update, config, log, deps, trail)
 }
 
 // processAIImageAzure generates images using Azure OpenAI API
-func processAIImageAzure(ctx context.Context, client *canvusapi.Client, prompt string, update Update, config *core.Config, endpoint string, log *logging.Logger) error {
+func processAIImageAzure(ctx context.Context, client *canvusapi.Client, prompt string, update Update, config *core.Config, endpoint string, log *logging.Logger, deps *HandlerDependencies, trail *auditTrail) error {
 	if config.AzureOpenAIDeployment == "" {
 		return fmt.Errorf("Azure OpenAI deployment name not configured")
 	}
@@ -678,7 +1720,7 @@ func processAIImageAzure(ctx context.Context, client *canvusapi.Client, prompt s
 	azureURL := fmt.Sprintf("%s/openai/deployments/%s/images/generations?api-version=%s",
 		strings.TrimSuffix(endpoint, "/"),
 		config.AzureOpenAIDeployment,
-		config.AzureOpenAIAPIVersion)
+		config.AzureOpenAIApiVersion)
 
 	// Create the request body
 	reqBody := map[string]interface{}{
@@ -702,7 +1744,7 @@ func processAIImageAzure(ctx context.Context, client *canvusapi.Client, prompt s
 	req.Header.Set("api-key", config.OpenAIAPIKey)
 
 	// Use configured HTTP client with proper TLS settings
-	httpClient := core.GetHTTPClient(config.AllowSelfSignedCerts)
+	httpClient := core.GetHTTPClient(config, config.AITimeout)
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("Azure request failed: %w", err)
@@ -735,13 +1777,14 @@ func processAIImageAzure(ctx context.Context, client *canvusapi.Client, prompt s
 		zap.String("prompt_preview", truncateText(prompt, 50)))
 
 	// Download and upload the image
-	return downloadAndUploadImage(ctx, client, imageURL, update, config, log)
+	return downloadAndUploadImage(ctx, client, imageURL, prompt, update, config, log, deps, trail)
 }
 
-// downloadAndUploadImage downloads an image from a URL and uploads it to the canvas
-func downloadAndUploadImage(ctx context.Context, client *canvusapi.Client, imageURL string, update Update, config *core.Config, log *logging.Logger) error {
+// downloadAndUploadImage downloads an image from a URL and uploads it to the canvas.
+// The image is kept in the artifact store, if one is configured.
+func downloadAndUploadImage(ctx context.Context, client *canvusapi.Client, imageURL, prompt string, update Update, config *core.Config, log *logging.Logger, deps *HandlerDependencies, trail *auditTrail) error {
 	// Download the image
-	httpClient := core.GetHTTPClient(config.AllowSelfSignedCerts)
+	httpClient := core.GetHTTPClient(config, config.AITimeout)
 	resp, err := httpClient.Get(imageURL)
 	if err != nil {
 		return fmt.Errorf("failed to download image: %w", err)
@@ -752,64 +1795,65 @@ func downloadAndUploadImage(ctx context.Context, client *canvusapi.Client, image
 		return fmt.Errorf("image download failed with status: %d", resp.StatusCode)
 	}
 
-	// Save to temporary file
-	tempFile := filepath.Join(config.DownloadsDir, fmt.Sprintf("ai_image_%s.png", generateCorrelationID()))
-	outFile, err := os.Create(tempFile)
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to download image: %w", err)
+	}
+
+	// Apply the configured output format and size
+	encoded, err := imagegen.EncodeForUpload(data, imagegen.OutputOptionsFromConfig(config))
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %w", err)
+		log.Warn("failed to apply image output options, uploading original", zap.Error(err))
+		encoded = &imagegen.EncodedImage{Data: data, Format: imagegen.FormatPNG}
 	}
-	defer outFile.Close()
-	defer os.Remove(tempFile) // Clean up after upload
 
-	_, err = io.Copy(outFile, resp.Body)
+	// Save to temporary file
+	imageFile, err := deps.storeTempFile(bytes.NewReader(encoded.Data), encoded.Format.Ext(), config)
 	if err != nil {
 		return fmt.Errorf("failed to save image: %w", err)
 	}
-	outFile.Close()
+	defer imageFile.Release() // Clean up after upload
+	tempFile := imageFile.Path
 
 	log.Info("image downloaded",
 		zap.String("file", tempFile))
 
-	// Calculate position for the image (below and to the right of the trigger note)
-	location := update["location"].(map[string]interface{})
-	size := update["size"].(map[string]interface{})
-
-	// Use imagegen placement calculation
-	placement := imagegen.CalculatePlacement(
-		imagegen.WidgetLocation{
-			X: location["x"].(float64),
-			Y: location["y"].(float64),
-		},
-		imagegen.WidgetSize{
-			Width:  size["width"].(float64),
-			Height: size["height"].(float64),
-		},
-		imagegen.ImageSize{Width: 1024, Height: 1024},
-		update["scale"].(float64),
-	)
+	// Place the image below and to the right of the trigger note
+	parent := updateToParentWidget(update)
+	x, y := imagegen.CalculatePlacement(parent)
 
-	// Upload the image to the canvas
-	uploadReq := canvusapi.UploadImageRequest{
-		FilePath: tempFile,
-		Location: canvusapi.WidgetLocation{
-			X: placement.X,
-			Y: placement.Y,
+	result, err := client.CreateImage(tempFile, map[string]interface{}{
+		"title": fmt.Sprintf("AI Generated: %s", truncateText(prompt, 50)),
+		"location": map[string]float64{
+			"x": x,
+			"y": y,
 		},
-		Size: canvusapi.WidgetSize{
-			Width:  placement.Width,
-			Height: placement.Height,
+		"size": map[string]interface{}{
+			"width":  1024,
+			"height": 1024,
 		},
-	}
-
-	result, err := client.UploadImage(uploadReq)
+		"depth": parent.GetDepth() + 10,
+		"scale": parent.GetScale() / 3,
+	})
 	if err != nil {
 		return fmt.Errorf("failed to upload image to canvas: %w", err)
 	}
+	imageID, _ := result["id"].(string)
 
 	log.Info("image uploaded to canvas",
-		zap.String("image_id", result.ID),
-		zap.Float64("x", placement.X),
-		zap.Float64("y", placement.Y))
+		zap.String("image_id", imageID),
+		zap.Float64("x", x),
+		zap.Float64("y", y))
+	trail.created(ctx, "Image", imageID, encoded.Data)
+
+	sourceID, _ := update["id"].(string)
+	deps.keepArtifact(ctx, artifacts.Artifact{
+		Kind:           artifacts.KindImage,
+		Name:           fmt.Sprintf("ai_image_%s%s", generateCorrelationID(), encoded.Format.Ext()),
+		CanvasID:       config.CanvasID,
+		SourceWidgetID: sourceID,
+		Prompt:         prompt,
+	}, tempFile, log)
 
 	return nil
 }
@@ -829,10 +1873,10 @@ func handleSnapshot(update Update, client *canvusapi.Client, config *core.Config
 	)
 
 	ctx := context.Background()
-	start := time.Now()
+	start := deps.now()
 
 	// Record task start for dashboard metrics
-	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeHandwriting, config.CanvasID)
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeHandwriting, config.CanvasID, snapshotID)
 
 	deps.downloadsMutex.Lock()
 	defer deps.downloadsMutex.Unlock()
@@ -853,12 +1897,13 @@ func handleSnapshot(update Update, client *canvusapi.Client, config *core.Config
 		deps.recordTaskComplete(taskRecord, "failed to create processing note")
 		return
 	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
 
 	// Get the snapshot URL
 	snapshotURL, ok := update["snapshotUrl"].(string)
 	if !ok || snapshotURL == "" {
 		log.Error("snapshot URL missing")
-		updateProcessingNote(client, processingNoteID, "❌ Error: No snapshot URL", config, log)
+		updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgNoSnapshotURL), config, log)
 		deps.recordTaskComplete(taskRecord, "snapshot URL missing")
 		return
 	}
@@ -866,15 +1911,9 @@ func handleSnapshot(update Update, client *canvusapi.Client, config *core.Config
 	log.Info("snapshot URL retrieved",
 		zap.String("url", snapshotURL))
 
-	// Create OCR processor
-	ocrProc, err := ocrprocessor.NewProcessor(
-		config.GoogleVisionAPIKey,
-		core.GetHTTPClient(config.AllowSelfSignedCerts),
-		logger,
-		ocrprocessor.DefaultProcessorConfig(),
-	)
+	ocrProc, err := newOCRProcessor(config, logger)
 	if err != nil {
-		errMsg := fmt.Sprintf("❌ OCR Error: %v", err)
+		errMsg := i18n.T(config.Language, i18n.MsgOCRError, err)
 		log.Error("failed to create OCR processor", zap.Error(err))
 		updateProcessingNote(client, processingNoteID, errMsg, config, log)
 		deps.recordTaskComplete(taskRecord, err.Error())
@@ -882,28 +1921,29 @@ func handleSnapshot(update Update, client *canvusapi.Client, config *core.Config
 	}
 
 	// Process the snapshot with OCR
-	recognizedText, err := ocrProc.ProcessURL(ctx, snapshotURL)
+	ocrResult, err := ocrProc.ProcessURL(ctx, snapshotURL)
 	if err != nil {
-		errMsg := fmt.Sprintf("❌ OCR Error: %v", err)
+		errMsg := i18n.T(config.Language, i18n.MsgOCRError, err)
 		log.Error("OCR processing failed", zap.Error(err))
 		updateProcessingNote(client, processingNoteID, errMsg, config, log)
 		recordProcessingHistory(
 			ctx, repo, correlationID, config.CanvasID, snapshotID,
 			"handwriting_recognition", snapshotURL, "", "google-vision",
-			0, 0, int(time.Since(start).Milliseconds()),
+			0, 0, int(deps.since(start).Milliseconds()),
 			"error", err.Error(), log,
 		)
 		deps.recordTaskComplete(taskRecord, err.Error())
 		return
 	}
 
+	recognizedText := ocrResult.Text
 	if recognizedText == "" {
 		log.Warn("no text recognized in snapshot")
-		updateProcessingNote(client, processingNoteID, "⚠️ No text recognized", config, log)
+		updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgNoTextRecognized), config, log)
 		recordProcessingHistory(
 			ctx, repo, correlationID, config.CanvasID, snapshotID,
 			"handwriting_recognition", snapshotURL, "", "google-vision",
-			0, 0, int(time.Since(start).Milliseconds()),
+			0, 0, int(deps.since(start).Milliseconds()),
 			"success", "no text detected", log,
 		)
 		deps.recordTaskComplete(taskRecord, "no text recognized")
@@ -914,22 +1954,222 @@ func handleSnapshot(update Update, client *canvusapi.Client, config *core.Config
 		zap.Int("length", len(recognizedText)),
 		zap.String("preview", truncateText(recognizedText, 100)))
 
-	// Update the processing note with the recognized text
-	updateProcessingNote(client, processingNoteID, recognizedText, config, log)
+	// Recreate each text block where it was written, or fall back to one merged note
+	trail := deps.newAuditTrail(config, correlationID, update, "handwriting_recognition", "google-vision", log)
+	layoutNotes := 0
+	if config.OCRPreserveLayout && len(ocrResult.Blocks) > 1 {
+		layoutNotes = createLayoutNotes(ctx, client, update, ocrResult, trail, log)
+	}
+	if layoutNotes > 0 {
+		if err := client.DeleteNote(processingNoteID); err != nil {
+			log.Warn("failed to delete processing note", zap.Error(err))
+		}
+	} else {
+		finishProcessingNote(ctx, client, processingNoteID, recognizedText, config, trail, log)
+	}
 
 	// Record success to database
 	recordProcessingHistory(
 		ctx, repo, correlationID, config.CanvasID, snapshotID,
 		"handwriting_recognition", snapshotURL, truncateText(recognizedText, 1000), "google-vision",
-		0, len(recognizedText), int(time.Since(start).Milliseconds()),
+		0, len(recognizedText), int(deps.since(start).Milliseconds()),
 		"success", "", log,
 	)
 	// Update metrics
-	deps.recordMetrics("image", time.Since(start))
+	deps.recordMetrics("image", deps.since(start))
 	deps.recordTaskComplete(taskRecord, "") // Empty string = success
 
 	log.Info("completed snapshot processing",
-		zap.Duration("duration", time.Since(start)))
+		zap.Duration("duration", deps.since(start)))
+}
+
+// handleHandwritingBatch reads the handwriting in every snapshot on the
+// canvas for a {{handwriting}} note and collects the text, in reading
+// order, in one note beside it. Snapshots are read a few at a time, as
+// bounded by HANDWRITING_BATCH_CONCURRENCY.
+func handleHandwritingBatch(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, deps *HandlerDependencies) {
+	triggerID, _ := update["id"].(string)
+	correlationID := generateCorrelationID()
+	log := logger.With(
+		zap.String("correlation_id", correlationID),
+		zap.String("widget_id", triggerID),
+		zap.String("widget_type", "Note"),
+	)
+
+	ctx := context.Background()
+	start := deps.now()
+
+	// Record task start for dashboard metrics
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeHandwriting, config.CanvasID, triggerID)
+
+	record := func(result, status, errMsg string) {
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, triggerID,
+			"handwriting_batch", "", result, "google-vision",
+			0, len(result), int(deps.since(start).Milliseconds()),
+			status, errMsg, log,
+		)
+	}
+
+	widgets, err := client.GetWidgets(false)
+	if err != nil {
+		log.Error("failed to fetch widgets", zap.Error(err))
+		if noteErr := handleAIError(ctx, client, repo, correlationID, update, err, i18n.T(config.Language, i18n.MsgOCRError, err), config, log); noteErr != nil {
+			log.Error("failed to create error note", zap.Error(noteErr))
+		}
+		record("", "error", err.Error())
+		deps.recordTaskComplete(taskRecord, err.Error())
+		return
+	}
+	snapshots := handwritingbatch.Find(widgets)
+	if len(snapshots) == 0 {
+		syntax := handlers.NewTriggerSyntax(config.TriggerOpen, config.TriggerClose)
+		if err := createRefusalNote(client, update, i18n.T(config.Language, i18n.MsgHandwritingNone, syntax.Open+"handwriting"+syntax.Close), config, log); err != nil {
+			log.Error("failed to create handwriting refusal note", zap.Error(err))
+		}
+		deps.recordTaskComplete(taskRecord, "")
+		return
+	}
+	reader := deps.getHandwriting()
+	total := len(snapshots)
+	if limit := reader.Config().MaxSnapshots; total > limit {
+		snapshots = snapshots[:limit]
+	}
+
+	processingNoteID, err := createProcessingNote(client, update, config, log)
+	if err != nil {
+		log.Error("failed to create processing note", zap.Error(err))
+		deps.recordTaskComplete(taskRecord, "failed to create processing note")
+		return
+	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
+	updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgHandwritingReading, 0, len(snapshots)), config, log)
+
+	ocrProc, err := newOCRProcessor(config, logger)
+	if err != nil {
+		log.Error("failed to create OCR processor", zap.Error(err))
+		updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgOCRError, err), config, log)
+		record("", "error", err.Error())
+		deps.recordTaskComplete(taskRecord, err.Error())
+		return
+	}
+	recognize := func(ctx context.Context, s handwritingbatch.Snapshot) (string, error) {
+		path := filepath.Join(config.DownloadsDir, "handwriting_"+s.ID+".png")
+		defer os.Remove(path)
+		if err := client.DownloadImage(s.ID, path); err != nil {
+			return "", fmt.Errorf("download failed: %w", err)
+		}
+		result, err := ocrProc.ProcessFile(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		return result.Text, nil
+	}
+	progress := func(done, total int) {
+		updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgHandwritingReading, done, total), config, log)
+	}
+	results := reader.Read(ctx, snapshots, recognize, progress)
+
+	// One section per snapshot with text, then the ones without and the failures
+	read, _, failed := handwritingbatch.Count(results)
+	var sections, empty, failures []string
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			log.Warn("snapshot OCR failed", zap.String("snapshot_id", r.Snapshot.ID), zap.Error(r.Err))
+			failures = append(failures, i18n.T(config.Language, i18n.MsgHandwritingFailed, r.Snapshot.Title, r.Err))
+		case r.Text == "":
+			empty = append(empty, r.Snapshot.Title)
+		default:
+			sections = append(sections, "**"+r.Snapshot.Title+"**\n"+r.Text)
+		}
+	}
+	text := i18n.T(config.Language, i18n.MsgHandwritingTitle, read)
+	if failed == len(results) {
+		text = "❌ " + text
+	}
+	if len(sections) > 0 {
+		text += "\n\n" + strings.Join(sections, "\n\n")
+	}
+	if len(empty) > 0 {
+		text += "\n\n" + i18n.T(config.Language, i18n.MsgHandwritingEmpty, strings.Join(empty, ", "))
+	}
+	if len(failures) > 0 {
+		text += "\n\n" + strings.Join(failures, "\n")
+	}
+	if len(snapshots) < total {
+		text += "\n\n" + i18n.T(config.Language, i18n.MsgHandwritingPartial, len(snapshots), total)
+	}
+	trail := deps.newAuditTrail(config, correlationID, update, "handwriting_batch", "google-vision", log)
+	finishProcessingNote(ctx, client, processingNoteID, text, config, trail, log)
+
+	if failed == len(results) {
+		errMsg := fmt.Sprintf("all %d snapshots failed", failed)
+		record("", "error", errMsg)
+		deps.recordTaskComplete(taskRecord, errMsg)
+		return
+	}
+	record(truncateText(text, 1000), "success", "")
+	deps.recordMetrics("image", deps.since(start))
+	deps.recordTaskComplete(taskRecord, "") // Empty string = success
+
+	log.Info("completed handwriting batch",
+		zap.Int("snapshots", len(snapshots)),
+		zap.Int("read", read),
+		zap.Int("failed", failed),
+		zap.Duration("duration", deps.since(start)))
+}
+
+// newOCRProcessor creates the Google Vision OCR processor. Images are
+// scrubbed before they leave the machine.
+func newOCRProcessor(config *core.Config, logger *logging.Logger) (*ocrprocessor.Processor, error) {
+	ocrConfig := ocrprocessor.DefaultProcessorConfig()
+	ocrConfig.Privacy = ocrprocessor.PrivacyOptions{
+		StripMetadata: config.CloudVisionStripMetadata,
+		MaxDimension:  config.CloudVisionMaxDimension,
+	}
+	return ocrprocessor.NewProcessor(
+		config.GoogleVisionKey,
+		core.GetHTTPClient(config, config.AITimeout),
+		logger,
+		ocrConfig,
+	)
+}
+
+// createLayoutNotes creates one note per OCR text block, positioned over the
+// part of the snapshot where the block was detected.
+// Each note is recorded in trail. Returns the number of notes created.
+func createLayoutNotes(ctx context.Context, client *canvusapi.Client, snapshot Update, result *ocrprocessor.ProcessResult, trail *auditTrail, log *logging.Logger) int {
+	bounds := handlers.WidgetBounds(snapshot, nil)
+	target := ocrprocessor.CanvasRect{X: bounds.X, Y: bounds.Y, Width: bounds.Width, Height: bounds.Height}
+
+	created := 0
+	for i, block := range result.Blocks {
+		rect := ocrprocessor.MapBlockToCanvas(block, result.ImageWidth, result.ImageHeight, target)
+		if rect.Width == 0 || rect.Height == 0 {
+			continue
+		}
+		note, err := client.CreateNote(map[string]interface{}{
+			"title":    fmt.Sprintf("Handwriting %d", i+1),
+			"text":     block.Text,
+			"location": handlers.LocationToMap(handlers.Location{X: rect.X, Y: rect.Y}),
+			"size":     handlers.SizeToMap(handlers.NoteSize{Width: rect.Width, Height: rect.Height}),
+		})
+		if err != nil {
+			log.Warn("failed to create layout note",
+				zap.Int("block", i),
+				zap.Error(err))
+			continue
+		}
+		noteID, _ := note["id"].(string)
+		trail.created(ctx, "Note", noteID, []byte(block.Text))
+		created++
+	}
+
+	log.Info("created layout-preserving OCR notes",
+		zap.Int("blocks", len(result.Blocks)),
+		zap.Int("notes", created))
+	return created
 }
 
 // handleImageAnalysis analyzes an image widget using llamaruntime.InferVision.
@@ -937,7 +2177,7 @@ func handleSnapshot(update Update, client *canvusapi.Client, config *core.Config
 // It downloads the image, runs vision inference, and creates a note with the description.
 //
 // Atomic design: Organism (orchestrates vision inference, Canvus API, and note creation)
-func handleImageAnalysis(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient *llamaruntime.Client, deps *HandlerDependencies) {
+func handleImageAnalysis(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient LlamaModel, deps *HandlerDependencies) {
 	triggerID, _ := update["id"].(string)
 	correlationID := generateCorrelationID()
 	log := logger.With(
@@ -946,11 +2186,20 @@ func handleImageAnalysis(update Update, client *canvusapi.Client, config *core.C
 		zap.String("widget_type", "AI_Icon_Image_Analysis"),
 	)
 
-	ctx := context.Background()
-	start := time.Now()
+	// An icon straddling two images (or anchored onto a connector between
+	// them) switches to comparison mode.
+	if imageIDs, ok := findComparisonPair(update, client, log); ok {
+		handleImageComparison(update, imageIDs, correlationID, client, config, log, repo, llamaClient, deps)
+		return
+	}
+
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: triggerID, Operation: "image_analysis",
+	})
+	start := deps.now()
 
 	// Record task start for dashboard metrics
-	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeImageAnalysis, config.CanvasID)
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeImageAnalysis, config.CanvasID, triggerID)
 
 	deps.downloadsMutex.Lock()
 	defer deps.downloadsMutex.Unlock()
@@ -997,23 +2246,24 @@ func handleImageAnalysis(update Update, client *canvusapi.Client, config *core.C
 		deps.recordTaskComplete(taskRecord, "failed to create processing note")
 		return
 	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
 
 	// Check if llamaClient is available
 	if llamaClient == nil {
 		errMsg := "Vision analysis not available (llama runtime not initialized)"
 		log.Error(errMsg)
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgVisionUnavailable), config, log)
 		deps.recordTaskComplete(taskRecord, errMsg)
 		return
 	}
 
 	// Download the image to a temporary file
-	httpClient := core.GetHTTPClient(config.AllowSelfSignedCerts)
+	httpClient := core.GetHTTPClient(config, config.AITimeout)
 	resp, err := httpClient.Get(imageURL)
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to download image: %v", err)
 		log.Error("image download failed", zap.Error(err))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgImageDownloadFailed, err), config, log)
 		deps.recordTaskComplete(taskRecord, errMsg)
 		return
 	}
@@ -1022,163 +2272,653 @@ func handleImageAnalysis(update Update, client *canvusapi.Client, config *core.C
 	if resp.StatusCode != http.StatusOK {
 		errMsg := fmt.Sprintf("Image download failed with status: %d", resp.StatusCode)
 		log.Error("image download failed", zap.Int("status", resp.StatusCode))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgImageDownloadStatus, resp.StatusCode), config, log)
 		deps.recordTaskComplete(taskRecord, errMsg)
 		return
 	}
 
 	// Save to temporary file
-	tempFile := filepath.Join(config.DownloadsDir, fmt.Sprintf("image_analysis_%s.jpg", correlationID))
-	outFile, err := os.Create(tempFile)
-	if err != nil {
-		errMsg := fmt.Sprintf("Failed to create temp file: %v", err)
-		log.Error("temp file creation failed", zap.Error(err))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
-		deps.recordTaskComplete(taskRecord, errMsg)
-		return
-	}
-
-	_, err = io.Copy(outFile, resp.Body)
-	outFile.Close()
+	imageFile, err := deps.storeTempFile(resp.Body, ".jpg", config)
 	if err != nil {
-		os.Remove(tempFile)
 		errMsg := fmt.Sprintf("Failed to save image: %v", err)
 		log.Error("image save failed", zap.Error(err))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgImageSaveFailed, err), config, log)
 		deps.recordTaskComplete(taskRecord, errMsg)
 		return
 	}
-	defer os.Remove(tempFile) // Clean up after analysis
+	defer imageFile.Release() // Clean up after analysis
+	tempFile := imageFile.Path
 
 	log.Info("image downloaded for analysis",
 		zap.String("temp_file", tempFile))
 
 	// Run vision inference
-	prompt := "Describe this image in detail."
-	description, err := llamaClient.InferVision(ctx, tempFile, prompt, llamaruntime.VisionParams{
+	notifyWarmupIfUnloaded(client, processingNoteID, llamaClient, config, log)
+	prompt := deps.withExamples(fewshot.TaskImageDescription, i18n.Prompt(config.Language, i18n.PromptImageDescription, "Describe this image in detail."))
+	result, err := llamaClient.InferVision(ctx, llamaruntime.VisionParams{
+		ImagePath:   tempFile,
+		Prompt:      prompt,
 		MaxTokens:   500,
 		Temperature: 0.7,
 	})
 	if err != nil {
 		errMsg := fmt.Sprintf("Vision inference failed: %v", err)
 		log.Error("vision inference failed", zap.Error(err))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgVisionFailed, err), config, log)
 		recordProcessingHistory(
 			ctx, repo, correlationID, config.CanvasID, triggerID,
 			"image_analysis", prompt, "", config.VisionModel,
-			0, 0, int(time.Since(start).Milliseconds()),
+			0, 0, int(deps.since(start).Milliseconds()),
 			"error", err.Error(), log,
 		)
 		deps.recordTaskComplete(taskRecord, errMsg)
 		return
 	}
 
+	description := result.Text
 	log.Info("image analysis complete",
 		zap.Int("description_length", len(description)))
 
 	// Update the processing note with the description
-	updateProcessingNote(client, processingNoteID, description, config, log)
+	deps.recordSession(ctx, config, sessions.KindTrigger, correlationID, "image_analysis", update, "", "", log)
+	deps.recordSession(ctx, config, sessions.KindResponse, correlationID, "image_analysis", update, description, config.VisionModel, log)
+	trail := deps.newAuditTrail(config, correlationID, update, "image_analysis", config.VisionModel, log)
+	shown := deps.filterResponse(ctx, repo, correlationID, triggerID, description, config, log) + contextReducedFooter(llamaClient, config)
+	finishProcessingNote(ctx, client, processingNoteID, shown, config, trail, log)
 
 	// Record success to database
 	recordProcessingHistory(
 		ctx, repo, correlationID, config.CanvasID, triggerID,
 		"image_analysis", prompt, truncateText(description, 1000), config.VisionModel,
-		0, len(description), int(time.Since(start).Milliseconds()),
+		result.TokensPrompt, result.TokensGenerated, int(deps.since(start).Milliseconds()),
 		"success", "", log,
 	)
 
 	// Update metrics
-	deps.recordMetrics("image", time.Since(start))
+	deps.recordMetrics("image", deps.since(start))
 	deps.recordTaskComplete(taskRecord, "") // Empty string = success
 
 	log.Info("completed image analysis",
-		zap.Duration("duration", time.Since(start)),
+		zap.Duration("duration", deps.since(start)),
 		zap.Int("description_length", len(description)))
 }
 
-// getPDFChunkPrompt returns the system message for PDF chunk analysis (delegated to handlers package)
-func getPDFChunkPrompt() string {
-	return handlers.GetPDFChunkPrompt()
+// downloadImageBytes downloads an image widget into memory via a temp file in DownloadsDir.
+func downloadImageBytes(client *canvusapi.Client, imageID, name string, config *core.Config) ([]byte, error) {
+	tempFile := filepath.Join(config.DownloadsDir, name)
+	defer os.Remove(tempFile)
+
+	if err := client.DownloadImage(imageID, tempFile); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(tempFile)
 }
 
-// handlePDFPrecis processes PDF analysis requests.
-// This handler downloads a PDF, extracts and chunks the text, generates a summary using AI,
-// and creates a note with the summary on the canvas.
+// findComparisonPair checks whether the analysis icon refers to two images.
+// Returns the image IDs (left to right) and true when comparison mode applies.
+func findComparisonPair(update Update, client *canvusapi.Client, log *logging.Logger) ([]string, bool) {
+	widgets, err := client.GetWidgets(false)
+	if err != nil {
+		log.Warn("failed to list widgets for comparison check", zap.Error(err))
+		return nil, false
+	}
+
+	candidates := make([]handlers.Update, len(widgets))
+	for i, w := range widgets {
+		candidates[i] = w
+	}
+
+	imageIDs, err := handlers.FindComparisonImages(update, candidates)
+	if err != nil {
+		return nil, false
+	}
+	return imageIDs, true
+}
+
+// handleImageComparison runs a "compare these images" vision prompt over two images.
+// The images are composed side by side so single-image vision models can see both.
 //
-// Atomic design: Organism (orchestrates PDF processing, AI summarization, and note creation)
-func handlePDFPrecis(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, deps *HandlerDependencies) {
+// Atomic design: Organism (orchestrates downloads, composition, vision inference, and note creation)
+func handleImageComparison(update Update, imageIDs []string, correlationID string, client *canvusapi.Client, config *core.Config, log *logging.Logger, repo *db.Repository, llamaClient LlamaModel, deps *HandlerDependencies) {
 	triggerID, _ := update["id"].(string)
-	correlationID := generateCorrelationID()
-	log := logger.With(
-		zap.String("correlation_id", correlationID),
-		zap.String("widget_id", triggerID),
-		zap.String("widget_type", "AI_Icon_PDF_Precis"),
+	log = log.With(
+		zap.String("left_image_id", imageIDs[0]),
+		zap.String("right_image_id", imageIDs[1]),
 	)
 
-	ctx := context.Background()
-	start := time.Now()
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: triggerID, Operation: "image_comparison",
+	})
+	start := deps.now()
 
-	// Record task start for dashboard metrics
-	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypePDF, config.CanvasID)
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeImageAnalysis, config.CanvasID, triggerID)
+
+	deps.downloadsMutex.Lock()
+	defer deps.downloadsMutex.Unlock()
+
+	log.Info("comparing images")
 
-	// Create processing note
 	processingNoteID, err := createProcessingNote(client, update, config, log)
 	if err != nil {
 		log.Error("failed to create processing note", zap.Error(err))
 		deps.recordTaskComplete(taskRecord, "failed to create processing note")
 		return
 	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
 
-	// Get the parent widget (the PDF to analyze)
-	parentID := update["parentId"].(string)
-	if parentID == "" {
-		log.Error("no parent PDF to analyze")
-		updateProcessingNote(client, processingNoteID, "❌ Error: No parent PDF found", config, log)
-		deps.recordTaskComplete(taskRecord, "no parent PDF")
+	fail := func(key i18n.Key, args ...interface{}) {
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, key, args...), config, log)
+		deps.recordTaskComplete(taskRecord, i18n.T(i18n.English, key, args...))
+	}
+
+	if llamaClient == nil {
+		log.Error("llama runtime not initialized")
+		fail(i18n.MsgVisionUnavailable)
 		return
 	}
 
-	parentWidget, err := client.GetWidget(parentID, false)
+	// Download and decode both images
+	images := make([]image.Image, len(imageIDs))
+	for i, imageID := range imageIDs {
+		data, err := downloadImageBytes(client, imageID, fmt.Sprintf("image_compare_%s_%d", correlationID, i), config)
+		if err != nil {
+			log.Error("image download failed", zap.String("image_id", imageID), zap.Error(err))
+			fail(i18n.MsgImageDownloadFailed, err)
+			return
+		}
+		images[i], err = vision.DecodeImage(data)
+		if err != nil {
+			log.Error("failed to decode image", zap.String("image_id", imageID), zap.Error(err))
+			fail(i18n.MsgImageDecodeFailed, err)
+			return
+		}
+	}
+
+	composite, err := vision.ComposeSideBySide(images[0], images[1], vision.DefaultCompositeHeight, 32)
 	if err != nil {
-		errMsg := fmt.Sprintf("Failed to get parent widget: %v", err)
-		log.Error("failed to get parent widget", zap.Error(err))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
-		deps.recordTaskComplete(taskRecord, errMsg)
+		log.Error("failed to compose images", zap.Error(err))
+		fail(i18n.MsgImageCombineFailed, err)
 		return
 	}
-
-	// Verify parent is a PDF
-	widgetType, _ := parentWidget["type"].(string)
-	if widgetType != "Pdf" {
-		errMsg := fmt.Sprintf("Parent widget is not a PDF (type: %s)", widgetType)
-		log.Error("parent widget is not a PDF", zap.String("parent_type", widgetType))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
-		deps.recordTaskComplete(taskRecord, errMsg)
+	imageData, err := vision.EncodePNG(composite)
+	if err != nil {
+		log.Error("failed to encode composite", zap.Error(err))
+		fail(i18n.MsgImageCombineFailed, err)
 		return
 	}
 
-	pdfURL, ok := parentWidget["url"].(string)
-	if !ok || pdfURL == "" {
-		log.Error("parent PDF has no URL")
-		updateProcessingNote(client, processingNoteID, "❌ Error: PDF has no URL", config, log)
-		deps.recordTaskComplete(taskRecord, "parent PDF has no URL")
+	notifyWarmupIfUnloaded(client, processingNoteID, llamaClient, config, log)
+	result, err := llamaClient.InferVision(ctx, llamaruntime.VisionParams{
+		ImageData:   imageData,
+		Prompt:      deps.withExamples(fewshot.TaskImageComparison, i18n.Prompt(config.Language, i18n.PromptImageComparison, handlers.ImageComparisonPrompt)),
+		MaxTokens:   800,
+		Temperature: 0.3,
+	})
+	if err != nil {
+		log.Error("vision inference failed", zap.Error(err))
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, triggerID,
+			"image_comparison", handlers.ImageComparisonPrompt, "", config.VisionModel,
+			0, 0, int(deps.since(start).Milliseconds()),
+			"error", err.Error(), log,
+		)
+		fail(i18n.MsgVisionFailed, err)
 		return
 	}
 
-	log.Info("analyzing PDF",
+	deps.recordSession(ctx, config, sessions.KindTrigger, correlationID, "image_comparison", update, "", "", log)
+	deps.recordSession(ctx, config, sessions.KindResponse, correlationID, "image_comparison", update, result.Text, config.VisionModel, log)
+	trail := deps.newAuditTrail(config, correlationID, update, "image_comparison", config.VisionModel, log)
+	shown := deps.filterResponse(ctx, repo, correlationID, triggerID, result.Text, config, log) + contextReducedFooter(llamaClient, config)
+	finishProcessingNote(ctx, client, processingNoteID, shown, config, trail, log)
+
+	recordProcessingHistory(
+		ctx, repo, correlationID, config.CanvasID, triggerID,
+		"image_comparison", handlers.ImageComparisonPrompt, truncateText(result.Text, 1000), config.VisionModel,
+		result.TokensPrompt, result.TokensGenerated, int(deps.since(start).Milliseconds()),
+		"success", "", log,
+	)
+
+	deps.recordMetrics("image", deps.since(start))
+	deps.recordTaskComplete(taskRecord, "")
+
+	log.Info("completed image comparison",
+		zap.Duration("duration", deps.since(start)),
+		zap.Int("result_length", len(result.Text)))
+}
+
+// handleStructuredExtraction extracts chart data or a box-and-arrow diagram from an image.
+// Charts become a markdown table note; diagrams are rebuilt as notes joined by
+// connectors, laid out to the right of the source image in the same arrangement.
+// This handler is triggered by an AI_Icon_Image_Extract widget placed on an image.
+//
+// Atomic design: Organism (orchestrates vision inference, parsing, and widget creation)
+func handleStructuredExtraction(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient LlamaModel, deps *HandlerDependencies) {
+	triggerID, _ := update["id"].(string)
+	correlationID := generateCorrelationID()
+	log := logger.With(
+		zap.String("correlation_id", correlationID),
+		zap.String("widget_id", triggerID),
+		zap.String("widget_type", "AI_Icon_Image_Extract"),
+	)
+
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: triggerID, Operation: "image_extraction",
+	})
+	start := deps.now()
+
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeImageAnalysis, config.CanvasID, triggerID)
+
+	deps.downloadsMutex.Lock()
+	defer deps.downloadsMutex.Unlock()
+
+	parentID, _ := update["parent_id"].(string)
+	if parentID == "" {
+		log.Error("no parent image to extract from")
+		deps.recordTaskComplete(taskRecord, "no parent image")
+		return
+	}
+
+	parentWidget, err := client.GetWidget(parentID, false)
+	if err != nil {
+		log.Error("failed to get parent widget", zap.Error(err))
+		deps.recordTaskComplete(taskRecord, fmt.Sprintf("failed to get parent widget: %v", err))
+		return
+	}
+	if widgetType, _ := parentWidget["widget_type"].(string); widgetType != "Image" {
+		log.Error("parent widget is not an image", zap.String("parent_type", widgetType))
+		deps.recordTaskComplete(taskRecord, "parent is not an image")
+		return
+	}
+
+	processingNoteID, err := createProcessingNote(client, update, config, log)
+	if err != nil {
+		log.Error("failed to create processing note", zap.Error(err))
+		deps.recordTaskComplete(taskRecord, "failed to create processing note")
+		return
+	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
+
+	fail := func(key i18n.Key, args ...interface{}) {
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, key, args...), config, log)
+		deps.recordTaskComplete(taskRecord, i18n.T(i18n.English, key, args...))
+	}
+
+	if llamaClient == nil {
+		log.Error("llama runtime not initialized")
+		fail(i18n.MsgVisionUnavailable)
+		return
+	}
+
+	imageData, err := downloadImageBytes(client, parentID, fmt.Sprintf("image_extract_%s", correlationID), config)
+	if err != nil {
+		log.Error("image download failed", zap.Error(err))
+		fail(i18n.MsgImageDownloadFailed, err)
+		return
+	}
+
+	notifyWarmupIfUnloaded(client, processingNoteID, llamaClient, config, log)
+	result, err := llamaClient.InferVision(ctx, llamaruntime.VisionParams{
+		ImageData:   imageData,
+		Prompt:      handlers.StructuredExtractionPrompt,
+		MaxTokens:   int(config.ImageAnalysisTokens),
+		Temperature: 0.1,
+	})
+	if err == nil {
+		var extraction *handlers.StructuredExtraction
+		if extraction, err = handlers.ParseStructuredExtraction(result.Text); err == nil {
+			if extraction.Repaired {
+				log.Info("repaired malformed JSON in structured extraction")
+				deps.recordStructuredResponse("repaired")
+			} else {
+				deps.recordStructuredResponse("valid")
+			}
+			trail := deps.newAuditTrail(config, correlationID, update, "image_extraction", config.VisionModel, log)
+			err = createExtractionWidgets(ctx, client, parentWidget, processingNoteID, extraction, config, trail, log)
+		} else if errors.Is(err, handlers.ErrInvalidJSON) {
+			deps.recordStructuredResponse("failed")
+		}
+	}
+	if err != nil {
+		log.Error("structured extraction failed", zap.Error(err))
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, triggerID,
+			"image_extraction", handlers.StructuredExtractionPrompt, "", config.VisionModel,
+			0, 0, int(deps.since(start).Milliseconds()),
+			"error", err.Error(), log,
+		)
+		fail(i18n.MsgExtractionFailed, err)
+		return
+	}
+
+	recordProcessingHistory(
+		ctx, repo, correlationID, config.CanvasID, triggerID,
+		"image_extraction", handlers.StructuredExtractionPrompt, truncateText(result.Text, 1000), config.VisionModel,
+		result.TokensPrompt, result.TokensGenerated, int(deps.since(start).Milliseconds()),
+		"success", "", log,
+	)
+
+	deps.recordMetrics("image", deps.since(start))
+	deps.recordTaskComplete(taskRecord, "")
+
+	log.Info("completed structured extraction",
+		zap.Duration("duration", deps.since(start)))
+}
+
+// createExtractionWidgets turns a parsed extraction into canvas widgets.
+// Charts reuse the processing note; diagrams replace it with one note per
+// node plus a connector per edge. Every widget written is recorded in trail.
+func createExtractionWidgets(ctx context.Context, client *canvusapi.Client, image Update, processingNoteID string, extraction *handlers.StructuredExtraction, config *core.Config, trail *auditTrail, log *logging.Logger) error {
+	if extraction.Kind == handlers.ExtractionKindChart {
+		finishProcessingNote(ctx, client, processingNoteID, handlers.FormatChartMarkdown(extraction.Chart), config, trail, log)
+		return nil
+	}
+
+	// Mirror the diagram into an area of the same size to the right of the image
+	bounds := handlers.WidgetBounds(image, nil)
+	area := handlers.Rect{X: bounds.X + bounds.Width*1.1, Y: bounds.Y, Width: bounds.Width, Height: bounds.Height}
+	noteSize := handlers.NoteSize{Width: 300, Height: 150}
+
+	noteIDs := make(map[string]string, len(extraction.Diagram.Nodes))
+	for _, node := range extraction.Diagram.Nodes {
+		loc := handlers.DiagramNodeLocation(node, area, noteSize)
+		note, err := client.CreateNote(map[string]interface{}{
+			"title":    node.ID,
+			"text":     node.Label,
+			"location": handlers.LocationToMap(loc),
+			"size":     handlers.SizeToMap(noteSize),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create note for node %s: %w", node.ID, err)
+		}
+		if id, ok := note["id"].(string); ok {
+			noteIDs[node.ID] = id
+			trail.created(ctx, "Note", id, []byte(node.Label))
+		}
+	}
+
+	for _, edge := range extraction.Diagram.Edges {
+		srcID, dstID := noteIDs[edge.From], noteIDs[edge.To]
+		if srcID == "" || dstID == "" {
+			continue
+		}
+		connector, err := client.CreateConnector(map[string]interface{}{
+			"src": map[string]interface{}{"id": srcID, "auto_location": true, "tip": "none"},
+			"dst": map[string]interface{}{"id": dstID, "auto_location": true, "tip": "solid-equilateral-triangle"},
+		})
+		if err != nil {
+			log.Warn("failed to create connector",
+				zap.String("from", edge.From),
+				zap.String("to", edge.To),
+				zap.Error(err))
+			continue
+		}
+		connectorID, _ := connector["id"].(string)
+		trail.created(ctx, "Connector", connectorID, []byte(srcID+"->"+dstID))
+	}
+
+	if err := client.DeleteNote(processingNoteID); err != nil {
+		log.Warn("failed to delete processing note", zap.Error(err))
+	}
+
+	log.Info("diagram rebuilt on canvas",
+		zap.Int("nodes", len(noteIDs)),
+		zap.Int("edges", len(extraction.Diagram.Edges)))
+	return nil
+}
+
+// handleStickyWall digitizes a photo of a physical sticky-note wall.
+// Sticky notes are located by colour segmentation, each crop is transcribed by
+// the vision model, and a colour-matched note is created for each one in the
+// same arrangement to the right of the photo.
+// This handler is triggered by an AI_Icon_Sticky_Wall widget placed on an image.
+//
+// Atomic design: Organism (orchestrates detection, vision inference, and note creation)
+func handleStickyWall(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient LlamaModel, deps *HandlerDependencies) {
+	triggerID, _ := update["id"].(string)
+	correlationID := generateCorrelationID()
+	log := logger.With(
+		zap.String("correlation_id", correlationID),
+		zap.String("widget_id", triggerID),
+		zap.String("widget_type", "AI_Icon_Sticky_Wall"),
+	)
+
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: triggerID, Operation: "sticky_wall",
+	})
+	start := deps.now()
+
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeHandwriting, config.CanvasID, triggerID)
+
+	deps.downloadsMutex.Lock()
+	defer deps.downloadsMutex.Unlock()
+
+	parentID, _ := update["parent_id"].(string)
+	if parentID == "" {
+		log.Error("no parent photo to digitize")
+		deps.recordTaskComplete(taskRecord, "no parent image")
+		return
+	}
+
+	parentWidget, err := client.GetWidget(parentID, false)
+	if err != nil {
+		log.Error("failed to get parent widget", zap.Error(err))
+		deps.recordTaskComplete(taskRecord, fmt.Sprintf("failed to get parent widget: %v", err))
+		return
+	}
+	if widgetType, _ := parentWidget["widget_type"].(string); widgetType != "Image" {
+		log.Error("parent widget is not an image", zap.String("parent_type", widgetType))
+		deps.recordTaskComplete(taskRecord, "parent is not an image")
+		return
+	}
+
+	processingNoteID, err := createProcessingNote(client, update, config, log)
+	if err != nil {
+		log.Error("failed to create processing note", zap.Error(err))
+		deps.recordTaskComplete(taskRecord, "failed to create processing note")
+		return
+	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
+
+	fail := func(key i18n.Key, args ...interface{}) {
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, key, args...), config, log)
+		deps.recordTaskComplete(taskRecord, i18n.T(i18n.English, key, args...))
+	}
+
+	if llamaClient == nil {
+		log.Error("llama runtime not initialized")
+		fail(i18n.MsgVisionUnavailable)
+		return
+	}
+
+	data, err := downloadImageBytes(client, parentID, fmt.Sprintf("sticky_wall_%s", correlationID), config)
+	if err != nil {
+		log.Error("image download failed", zap.Error(err))
+		fail(i18n.MsgImageDownloadFailed, err)
+		return
+	}
+	photo, err := vision.DecodeImage(data)
+	if err != nil {
+		log.Error("failed to decode photo", zap.Error(err))
+		fail(i18n.MsgImageDecodeFailed, err)
+		return
+	}
+
+	regions, err := vision.DetectStickyNotes(photo, vision.DefaultStickyDetectOptions())
+	if err != nil || len(regions) == 0 {
+		log.Warn("no sticky notes detected", zap.Error(err))
+		updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgNoStickyNotes), config, log)
+		deps.recordTaskComplete(taskRecord, "no sticky notes detected")
+		return
+	}
+
+	log.Info("sticky notes detected", zap.Int("count", len(regions)))
+
+	// Rebuild the wall in an area of the same size to the right of the photo
+	bounds := handlers.WidgetBounds(parentWidget, nil)
+	area := handlers.Rect{X: bounds.X + bounds.Width*1.1, Y: bounds.Y, Width: bounds.Width, Height: bounds.Height}
+	photoBounds := photo.Bounds()
+
+	notifyWarmupIfUnloaded(client, processingNoteID, llamaClient, config, log)
+
+	trail := deps.newAuditTrail(config, correlationID, update, "sticky_wall", config.VisionModel, log)
+	var transcripts []string
+	created := 0
+	for i, region := range regions {
+		updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgReadingStickyNote, i+1, len(regions)), config, log)
+
+		crop, err := vision.EncodePNG(vision.CropImage(photo, region.Bounds))
+		if err != nil {
+			log.Warn("failed to encode sticky note crop", zap.Int("index", i), zap.Error(err))
+			continue
+		}
+		result, err := llamaClient.InferVision(ctx, llamaruntime.VisionParams{
+			ImageData:   crop,
+			Prompt:      handlers.StickyNoteTranscriptionPrompt,
+			MaxTokens:   200,
+			Temperature: 0.1,
+		})
+		if err != nil {
+			log.Warn("sticky note transcription failed", zap.Int("index", i), zap.Error(err))
+			continue
+		}
+		text := handlers.CleanTranscription(result.Text)
+
+		px := handlers.PixelRect{
+			MinX: region.Bounds.Min.X - photoBounds.Min.X,
+			MinY: region.Bounds.Min.Y - photoBounds.Min.Y,
+			MaxX: region.Bounds.Max.X - photoBounds.Min.X,
+			MaxY: region.Bounds.Max.Y - photoBounds.Min.Y,
+		}
+		rect := handlers.ScalePixelRect(px, photoBounds.Dx(), photoBounds.Dy(), area)
+		note, err := client.CreateNote(map[string]interface{}{
+			"text":             text,
+			"location":         handlers.LocationToMap(handlers.Location{X: rect.X, Y: rect.Y}),
+			"size":             handlers.SizeToMap(handlers.NoteSize{Width: rect.Width, Height: rect.Height}),
+			"background_color": vision.ColorHex(region.Color),
+		})
+		if err != nil {
+			log.Warn("failed to create sticky note", zap.Int("index", i), zap.Error(err))
+			continue
+		}
+		noteID, _ := note["id"].(string)
+		trail.created(ctx, "Note", noteID, []byte(text))
+		transcripts = append(transcripts, text)
+		created++
+	}
+
+	if created == 0 {
+		fail(i18n.MsgNoStickyNotesMade)
+		return
+	}
+
+	if err := client.DeleteNote(processingNoteID); err != nil {
+		log.Warn("failed to delete processing note", zap.Error(err))
+	}
+
+	allText := strings.Join(transcripts, "\n---\n")
+	recordProcessingHistory(
+		ctx, repo, correlationID, config.CanvasID, triggerID,
+		"sticky_wall", handlers.StickyNoteTranscriptionPrompt, truncateText(allText, 1000), config.VisionModel,
+		0, len(allText), int(deps.since(start).Milliseconds()),
+		"success", "", log,
+	)
+
+	deps.recordMetrics("image", deps.since(start))
+	deps.recordTaskComplete(taskRecord, "")
+
+	log.Info("completed sticky wall digitization",
+		zap.Int("detected", len(regions)),
+		zap.Int("created", created),
+		zap.Duration("duration", deps.since(start)))
+}
+
+// getPDFChunkPrompt returns the system message for PDF chunk analysis (delegated to handlers package)
+func getPDFChunkPrompt() string {
+	return handlers.PDFChunkPrompt()
+}
+
+// handlePDFPrecis processes PDF analysis requests.
+// This handler downloads a PDF, extracts and chunks the text, generates a summary using AI,
+// and creates a note with the summary on the canvas.
+//
+// Atomic design: Organism (orchestrates PDF processing, AI summarization, and note creation)
+func handlePDFPrecis(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, deps *HandlerDependencies) {
+	triggerID, _ := update["id"].(string)
+	correlationID := generateCorrelationID()
+	log := logger.With(
+		zap.String("correlation_id", correlationID),
+		zap.String("widget_id", triggerID),
+		zap.String("widget_type", "AI_Icon_PDF_Precis"),
+	)
+
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: triggerID, Operation: "pdf_analysis",
+	})
+	start := deps.now()
+
+	// Record task start for dashboard metrics
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypePDF, config.CanvasID, triggerID)
+
+	// Create processing note
+	processingNoteID, err := createProcessingNote(client, update, config, log)
+	if err != nil {
+		log.Error("failed to create processing note", zap.Error(err))
+		deps.recordTaskComplete(taskRecord, "failed to create processing note")
+		return
+	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
+
+	// Get the parent widget (the PDF to analyze)
+	parentID := update["parentId"].(string)
+	if parentID == "" {
+		log.Error("no parent PDF to analyze")
+		updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgNoParentPDF), config, log)
+		deps.recordTaskComplete(taskRecord, "no parent PDF")
+		return
+	}
+
+	parentWidget, err := client.GetWidget(parentID, false)
+	if err != nil {
+		errMsg := fmt.Sprintf("Failed to get parent widget: %v", err)
+		log.Error("failed to get parent widget", zap.Error(err))
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgParentWidgetFailed, err), config, log)
+		deps.recordTaskComplete(taskRecord, errMsg)
+		return
+	}
+
+	// Verify parent is a PDF
+	widgetType, _ := parentWidget["type"].(string)
+	if widgetType != "Pdf" {
+		errMsg := fmt.Sprintf("Parent widget is not a PDF (type: %s)", widgetType)
+		log.Error("parent widget is not a PDF", zap.String("parent_type", widgetType))
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgNotAPDF, widgetType), config, log)
+		deps.recordTaskComplete(taskRecord, errMsg)
+		return
+	}
+
+	pdfURL, ok := parentWidget["url"].(string)
+	if !ok || pdfURL == "" {
+		log.Error("parent PDF has no URL")
+		updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgPDFNoURL), config, log)
+		deps.recordTaskComplete(taskRecord, "parent PDF has no URL")
+		return
+	}
+
+	log.Info("analyzing PDF",
 		zap.String("pdf_url", pdfURL),
 		zap.String("parent_id", parentID))
 
 	// Update processing note to show download in progress
-	updateProcessingNote(client, processingNoteID, "⏳ Downloading PDF...", config, log)
+	updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgDownloadingPDF), config, log)
 
 	// Download the PDF
-	httpClient := core.GetHTTPClient(config.AllowSelfSignedCerts)
+	httpClient := core.GetHTTPClient(config, config.AITimeout)
 	resp, err := httpClient.Get(pdfURL)
 	if err != nil {
 		errMsg := fmt.Sprintf("Failed to download PDF: %v", err)
 		log.Error("PDF download failed", zap.Error(err))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgPDFDownloadFailed, err), config, log)
 		deps.recordTaskComplete(taskRecord, errMsg)
 		return
 	}
@@ -1187,68 +2927,72 @@ func handlePDFPrecis(update Update, client *canvusapi.Client, config *core.Confi
 	if resp.StatusCode != http.StatusOK {
 		errMsg := fmt.Sprintf("PDF download failed with status: %d", resp.StatusCode)
 		log.Error("PDF download failed", zap.Int("status", resp.StatusCode))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgPDFDownloadStatus, resp.StatusCode), config, log)
 		deps.recordTaskComplete(taskRecord, errMsg)
 		return
 	}
 
 	// Save to temporary file
-	tempFile := filepath.Join(config.DownloadsDir, fmt.Sprintf("pdf_analysis_%s.pdf", correlationID))
-	outFile, err := os.Create(tempFile)
-	if err != nil {
-		errMsg := fmt.Sprintf("Failed to create temp file: %v", err)
-		log.Error("temp file creation failed", zap.Error(err))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
-		deps.recordTaskComplete(taskRecord, errMsg)
-		return
-	}
-
-	_, err = io.Copy(outFile, resp.Body)
-	outFile.Close()
+	pdfFile, err := deps.storeTempFile(resp.Body, ".pdf", config)
 	if err != nil {
-		os.Remove(tempFile)
 		errMsg := fmt.Sprintf("Failed to save PDF: %v", err)
 		log.Error("PDF save failed", zap.Error(err))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgPDFSaveFailed, err), config, log)
 		deps.recordTaskComplete(taskRecord, errMsg)
 		return
 	}
-	defer os.Remove(tempFile) // Clean up after processing
+	defer pdfFile.Release() // Clean up after processing
+	tempFile := pdfFile.Path
 
 	log.Info("PDF downloaded",
 		zap.String("temp_file", tempFile))
 
+	pdfTitle, _ := parentWidget["title"].(string)
+	deps.keepArtifact(ctx, artifacts.Artifact{
+		Kind:           artifacts.KindPDF,
+		Name:           pdfArtifactName(pdfTitle),
+		CanvasID:       config.CanvasID,
+		SourceWidgetID: parentID,
+	}, tempFile, log)
+
 	// Update processing note to show extraction in progress
-	updateProcessingNote(client, processingNoteID, "⏳ Extracting text from PDF...", config, log)
+	updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgExtractingPDF), config, log)
 
 	// Create PDF processor with progress callback
-	processorConfig := pdfprocessor.ProcessorConfig{
-		ChunkSizeTokens:  config.PDFChunkSizeTokens,
-		MaxChunksTokens:  config.PDFMaxChunksTokens,
-		SummaryMaxTokens: config.PDFSummaryMaxTokens,
-		Model:            config.OpenAIPDFModel,
-		Temperature:      0.3,
+	processorConfig := pdfprocessor.DefaultProcessorConfig()
+	processorConfig.ChunkerConfig.MaxChunkTokens = int(config.PDFChunkSizeTokens)
+	processorConfig.ChunkerConfig.MaxChunks = int(config.PDFMaxChunksTokens)
+	processorConfig.SummarizerConfig.MaxTokens = int(config.PDFPrecisTokens)
+	if config.OpenAIPDFModel != "" {
+		processorConfig.SummarizerConfig.Model = config.OpenAIPDFModel
 	}
 
 	// Progress callback to update the processing note
-	progressCallback := func(stage string, current, total int) {
-		progressMsg := fmt.Sprintf("⏳ %s (%d/%d)", stage, current, total)
+	progressCallback := func(stage string, progress float64, message string) {
+		progressMsg := fmt.Sprintf("⏳ %s (%.0f%%)", message, progress*100)
 		updateProcessingNote(client, processingNoteID, progressMsg, config, log)
 	}
 
 	aiClient := core.CreateOpenAIClient(config)
 	processor := pdfprocessor.NewProcessorWithProgress(processorConfig, aiClient, progressCallback)
 
+	// Neutralize prompt injection and mask personal data in the chunks
+	// before they reach a cloud LLM
+	chunkRedactor := &pdfChunkRedactor{guard: deps.getPromptGuard(), redactor: deps.cloudRedactor(config)}
+	if chunkRedactor.guard != nil || chunkRedactor.redactor != nil {
+		processor.SetRedactor(chunkRedactor)
+	}
+
 	// Process the PDF
-	result, err := processor.Process(ctx, tempFile, "Please provide a comprehensive summary of this document.")
+	result, err := processor.Process(ctx, tempFile)
 	if err != nil {
 		errMsg := fmt.Sprintf("PDF processing failed: %v", err)
 		log.Error("PDF processing failed", zap.Error(err))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgPDFFailed, err), config, log)
 		recordProcessingHistory(
 			ctx, repo, correlationID, config.CanvasID, triggerID,
 			"pdf_analysis", pdfURL, "", config.OpenAIPDFModel,
-			0, 0, int(time.Since(start).Milliseconds()),
+			0, 0, int(deps.since(start).Milliseconds()),
 			"error", err.Error(), log,
 		)
 		deps.recordTaskComplete(taskRecord, errMsg)
@@ -1257,23 +3001,30 @@ func handlePDFPrecis(update Update, client *canvusapi.Client, config *core.Confi
 
 	log.Info("PDF summary generated",
 		zap.Int("summary_length", len(result.Summary)),
-		zap.Int("pages_processed", result.PagesProcessed))
+		zap.Int("pages_processed", result.ExtractionResult.ExtractedPages))
+
+	recordInjection(ctx, repo, correlationID, config.CanvasID, triggerID, chunkRedactor.injections, log)
+	recordRedaction(ctx, repo, correlationID, config.CanvasID, triggerID, chunkRedactor.result, log)
 
 	// Update the processing note with the summary
-	updateProcessingNote(client, processingNoteID, result.Summary, config, log)
+	deps.recordSession(ctx, config, sessions.KindTrigger, correlationID, "pdf_analysis", update, "", "", log)
+	deps.recordSession(ctx, config, sessions.KindResponse, correlationID, "pdf_analysis", update, result.Summary, config.OpenAIPDFModel, log)
+	trail := deps.newAuditTrail(config, correlationID, update, "pdf_analysis", config.OpenAIPDFModel, log)
+	shown := deps.filterResponse(ctx, repo, correlationID, triggerID, result.Summary, config, log)
+	finishProcessingNote(ctx, client, processingNoteID, shown, config, trail, log)
 
 	// Record success to database
 	recordProcessingHistory(
 		ctx, repo, correlationID, config.CanvasID, triggerID,
 		"pdf_analysis", pdfURL, truncateText(result.Summary, 1000), config.OpenAIPDFModel,
-		result.InputTokens, result.OutputTokens, int(time.Since(start).Milliseconds()),
+		result.SummaryResult.PromptTokens, result.SummaryResult.CompletionTokens, int(deps.since(start).Milliseconds()),
 		"success", "", log,
 	)
-	deps.recordMetrics("pdf", time.Since(start))
+	deps.recordMetrics("pdf", deps.since(start))
 	deps.recordTaskComplete(taskRecord, "") // Empty string = success
 
 	log.Info("completed PDF analysis",
-		zap.Duration("duration", time.Since(start)))
+		zap.Duration("duration", deps.since(start)))
 }
 
 // handleCanvusPrecis processes canvas analysis requests.
@@ -1281,7 +3032,7 @@ func handlePDFPrecis(update Update, client *canvusapi.Client, config *core.Confi
 // and creates a note with the analysis on the canvas.
 //
 // Atomic design: Organism (orchestrates canvas fetching, AI analysis, and note creation)
-func handleCanvusPrecis(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient *llamaruntime.Client, deps *HandlerDependencies) {
+func handleCanvusPrecis(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient LlamaModel, deps *HandlerDependencies) {
 	triggerID, _ := update["id"].(string)
 	correlationID := generateCorrelationID()
 	log := logger.With(
@@ -1290,11 +3041,13 @@ func handleCanvusPrecis(update Update, client *canvusapi.Client, config *core.Co
 		zap.String("widget_type", "AI_Icon_Canvus_Precis"),
 	)
 
-	ctx := context.Background()
-	start := time.Now()
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: triggerID, Operation: "canvas_analysis",
+	})
+	start := deps.now()
 
 	// Record task start for dashboard metrics
-	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeCanvas, config.CanvasID)
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeCanvasAnalysis, config.CanvasID, triggerID)
 
 	// Create processing note
 	processingNoteID, err := createProcessingNote(client, update, config, log)
@@ -1303,175 +3056,1372 @@ func handleCanvusPrecis(update Update, client *canvusapi.Client, config *core.Co
 		deps.recordTaskComplete(taskRecord, "failed to create processing note")
 		return
 	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
 
 	log.Info("analyzing canvas",
 		zap.String("canvas_id", config.CanvasID))
 
 	// Update processing note to show fetching in progress
-	updateProcessingNote(client, processingNoteID, "⏳ Fetching canvas widgets...", config, log)
+	updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgFetchingWidgets), config, log)
 
 	// Create canvas analyzer processor
 	analyzerConfig := canvasanalyzer.ProcessorConfig{
-		MaxTokens:   config.CanvasAnalysisMaxTokens,
-		Model:       config.OpenAICanvasModel,
-		Temperature: 0.5,
+		MaxTokens:    int(config.CanvasPrecisTokens),
+		Model:        config.OpenAICanvasModel,
+		Temperature:  0.5,
+		SystemPrompt: deps.withExamples(fewshot.TaskCanvasAnalysis, i18n.Prompt(config.Language, i18n.PromptCanvasAnalysis, canvasanalyzer.DefaultSystemPrompt)),
+		// Canvases too large for one request are summarized in groups first
+		BatchTokens:      config.CanvasBatchTokens,
+		BatchConcurrency: config.CanvasBatchConcurrency,
 	}
 
 	var processor *canvasanalyzer.Processor
 	if llamaClient != nil {
 		log.Info("using local LLM for canvas analysis")
-		processor = canvasanalyzer.NewProcessorWithLlama(analyzerConfig, client, llamaClient, logger)
+		processor = canvasanalyzer.NewLocalProcessor(analyzerConfig, func(ctx context.Context, systemPrompt, userContent string, maxTokens int) (string, error) {
+			return llamaClient.Generate(ctx, userContent, llamaruntime.GenerationParams{
+				MaxTokens:    maxTokens,
+				Temperature:  analyzerConfig.Temperature,
+				SystemPrompt: &systemPrompt,
+			})
+		}, log.Zap())
 	} else {
 		log.Info("using cloud API for canvas analysis")
 		aiClient := core.CreateOpenAIClient(config)
-		processor = canvasanalyzer.NewProcessor(analyzerConfig, client, aiClient, logger)
+		processor = canvasanalyzer.NewProcessor(analyzerConfig, aiClient, log.Zap())
+	}
+	fetcher := canvasanalyzer.NewFetcher(client, canvasanalyzer.DefaultFetcherConfig(), log.Zap())
+	analyzer := canvasanalyzer.NewAnalyzerWithComponents(fetcher, processor, canvasanalyzer.DefaultAnalyzerConfig(), log.Zap())
+
+	// Neutralize prompt injection in widget titles and texts
+	var injections *promptguard.Result
+	if guard := deps.getPromptGuard(); guard != nil {
+		processor.SetSanitizer(func(texts []string) []string {
+			var out []string
+			out, injections = guard.SanitizeAll(texts)
+			return out
+		})
 	}
 
-	// Process the canvas
-	result, err := processor.Process(ctx, "Please provide a comprehensive analysis of this canvas, including the main topics, structure, and key insights.")
+	// Show the group summary stages of large canvases on the processing note
+	analyzer.SetProgressCallback(func(stage, message string) {
+		updateProcessingNote(client, processingNoteID, "⏳ "+message, config, log)
+	})
+
+	// Analyze every widget but the trigger icon
+	analyzed, err := analyzer.Analyze(ctx, triggerID)
+	if err == nil && analyzed.Analysis == nil {
+		err = errors.New("the canvas has no widgets to analyze")
+	}
 	if err != nil {
 		errMsg := fmt.Sprintf("Canvas analysis failed: %v", err)
 		log.Error("canvas analysis failed", zap.Error(err))
-		updateProcessingNote(client, processingNoteID, fmt.Sprintf("❌ %s", errMsg), config, log)
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgCanvasFailed, err), config, log)
 		recordProcessingHistory(
 			ctx, repo, correlationID, config.CanvasID, triggerID,
 			"canvas_analysis", "", "", config.OpenAICanvasModel,
-			0, 0, int(time.Since(start).Milliseconds()),
+			0, 0, int(deps.since(start).Milliseconds()),
 			"error", err.Error(), log,
 		)
 		deps.recordTaskComplete(taskRecord, errMsg)
 		return
 	}
 
+	result := analyzed.Analysis
 	log.Info("canvas analysis generated",
-		zap.Int("analysis_length", len(result.Analysis)),
-		zap.Int("widgets_analyzed", result.WidgetsAnalyzed))
+		zap.Int("analysis_length", len(result.Content)),
+		zap.Int("widgets_analyzed", result.WidgetCount))
+	recordInjection(ctx, repo, correlationID, config.CanvasID, triggerID, injections, log)
 
 	// Update the processing note with the analysis
-	updateProcessingNote(client, processingNoteID, result.Analysis, config, log)
+	deps.recordSession(ctx, config, sessions.KindTrigger, correlationID, "canvas_analysis", update, "", "", log)
+	deps.recordSession(ctx, config, sessions.KindResponse, correlationID, "canvas_analysis", update, result.Content, config.OpenAICanvasModel, log)
+	trail := deps.newAuditTrail(config, correlationID, update, "canvas_analysis", config.OpenAICanvasModel, log)
+	shown := deps.filterResponse(ctx, repo, correlationID, triggerID, result.Content, config, log) + contextReducedFooter(llamaClient, config)
+	finishProcessingNote(ctx, client, processingNoteID, shown, config, trail, log)
 
 	// Record success to database
 	recordProcessingHistory(
 		ctx, repo, correlationID, config.CanvasID, triggerID,
-		"canvas_analysis", "", truncateText(result.Analysis, 1000), config.OpenAICanvasModel,
-		result.InputTokens, result.OutputTokens, int(time.Since(start).Milliseconds()),
+		"canvas_analysis", "", truncateText(result.Content, 1000), config.OpenAICanvasModel,
+		result.PromptTokens, result.CompletionTokens, int(deps.since(start).Milliseconds()),
 		"success", "", log,
 	)
-	deps.recordMetrics("note", time.Since(start))
+	deps.recordMetrics("note", deps.since(start))
 	deps.recordTaskComplete(taskRecord, "") // Empty string = success
 
 	log.Info("completed canvas analysis",
-		zap.Duration("duration", time.Since(start)))
+		zap.Duration("duration", deps.since(start)))
 }
 
-// createProcessingNote creates a temporary "AI Processing" note on the canvas.
-// This note is updated as processing progresses and eventually contains the final result.
-func createProcessingNote(client *canvusapi.Client, triggerWidget Update, config *core.Config, log *logging.Logger) (string, error) {
-	location := triggerWidget["location"].(map[string]interface{})
-	size := triggerWidget["size"].(map[string]interface{})
-
-	// Calculate position for the processing note (to the right of the trigger)
-	newLocation := handlers.CalculateNoteLocation(location, size, config.NoteSpacing)
-
-	note := canvusapi.CreateNoteRequest{
-		Location: canvusapi.WidgetLocation{
-			X: newLocation["x"].(float64),
-			Y: newLocation["y"].(float64),
-		},
-		Size: canvusapi.WidgetSize{
-			Width:  config.NoteWidth,
-			Height: config.NoteHeight,
+// handleExport exports the canvas, or one of its zones, as a Markdown or
+// HTML document. The document is kept as an artifact and the processing
+// note is replaced with its download link.
+//
+// Atomic design: Organism (orchestrates canvas export, artifact storage and note creation)
+func handleExport(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, deps *HandlerDependencies, format canvasexport.Format, zone string) {
+	triggerID, _ := update["id"].(string)
+	correlationID := generateCorrelationID()
+	log := logger.With(
+		zap.String("correlation_id", correlationID),
+		zap.String("widget_id", triggerID),
+		zap.String("widget_type", "Note"),
+	)
+
+	ctx := context.Background()
+	start := deps.now()
+
+	// Record task start for dashboard metrics
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeExport, config.CanvasID, triggerID)
+
+	processingNoteID, err := createProcessingNote(client, update, config, log)
+	if err != nil {
+		log.Error("failed to create processing note", zap.Error(err))
+		deps.recordTaskComplete(taskRecord, "failed to create processing note")
+		return
+	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
+	updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgExporting), config, log)
+
+	fail := func(err error) {
+		log.Error("canvas export failed", zap.Error(err))
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgExportFailed, err), config, log)
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, triggerID,
+			"canvas_export", zone, "", "",
+			0, 0, int(deps.since(start).Milliseconds()),
+			"error", err.Error(), log,
+		)
+		deps.recordTaskComplete(taskRecord, err.Error())
+	}
+
+	// The download link serves the document from the artifact store
+	store := deps.getArtifactStore()
+	if store == nil {
+		fail(errors.New("exports are kept as artifacts, but ARTIFACT_STORE is not set"))
+		return
+	}
+
+	exporter := canvasexport.NewExporter(client, config.DownloadsDir, log.Zap())
+	exporter.SetClock(deps.getClock())
+	doc, err := exporter.Export(ctx, canvasexport.Options{
+		Title:      config.CanvasName,
+		Zone:       zone,
+		ExcludeIDs: []string{triggerID, processingNoteID},
+	})
+	if err != nil {
+		fail(err)
+		return
+	}
+	data, err := canvasexport.Render(doc, format)
+	if err != nil {
+		fail(err)
+		return
+	}
+	kept, err := store.Put(ctx, artifacts.Artifact{
+		Kind:           artifacts.KindExport,
+		Name:           canvasexport.FileName(doc, format),
+		ContentType:    format.ContentType(),
+		CanvasID:       config.CanvasID,
+		SourceWidgetID: triggerID,
+	}, data)
+	if err != nil {
+		fail(fmt.Errorf("failed to keep export: %w", err))
+		return
+	}
+
+	link := webUIPublicURL(config) + "/api/artifacts/content?id=" + kept.ID
+	trail := deps.newAuditTrail(config, correlationID, update, "canvas_export", "", log)
+	finishProcessingNote(ctx, client, processingNoteID, i18n.T(config.Language, i18n.MsgExportReady, len(doc.Sections), link), config, trail, log)
+
+	recordProcessingHistory(
+		ctx, repo, correlationID, config.CanvasID, triggerID,
+		"canvas_export", zone, link, "",
+		0, 0, int(deps.since(start).Milliseconds()),
+		"success", "", log,
+	)
+	deps.recordMetrics("note", deps.since(start))
+	deps.recordTaskComplete(taskRecord, "") // Empty string = success
+
+	log.Info("completed canvas export",
+		zap.String("artifact_id", kept.ID),
+		zap.String("format", string(format)),
+		zap.Int("sections", len(doc.Sections)),
+		zap.Duration("duration", deps.since(start)))
+}
+
+// handleVariants shows the earlier images generated from the trigger note:
+// with pick 0 their thumbnails are laid out under the note, otherwise the
+// pick-th newest is uploaded again at full size from the artifact store.
+// The processing note says what was done.
+//
+// Atomic design: Organism (orchestrates variant history, artifact storage and note creation)
+func handleVariants(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, deps *HandlerDependencies, pick int) {
+	triggerID, _ := update["id"].(string)
+	correlationID := generateCorrelationID()
+	log := logger.With(
+		zap.String("correlation_id", correlationID),
+		zap.String("widget_id", triggerID),
+		zap.String("widget_type", "Note"),
+	)
+
+	ctx := context.Background()
+	start := deps.now()
+
+	// Record task start for dashboard metrics
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeImage, config.CanvasID, triggerID)
+
+	processingNoteID, err := createProcessingNote(client, update, config, log)
+	if err != nil {
+		log.Error("failed to create processing note", zap.Error(err))
+		deps.recordTaskComplete(taskRecord, "failed to create processing note")
+		return
+	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
+	updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgLoadingVariants), config, log)
+
+	trail := deps.newAuditTrail(config, correlationID, update, "image_variants", "", log)
+	finish := func(text, status, errMsg string) {
+		finishProcessingNote(ctx, client, processingNoteID, text, config, trail, log)
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, triggerID,
+			"image_variants", strconv.Itoa(pick), text, "",
+			0, 0, int(deps.since(start).Milliseconds()),
+			status, errMsg, log,
+		)
+		deps.recordTaskComplete(taskRecord, errMsg)
+	}
+	fail := func(err error) {
+		log.Error("showing image variants failed", zap.Error(err))
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgVariantsFailed, err), config, log)
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, triggerID,
+			"image_variants", strconv.Itoa(pick), "", "",
+			0, 0, int(deps.since(start).Milliseconds()),
+			"error", err.Error(), log,
+		)
+		deps.recordTaskComplete(taskRecord, err.Error())
+	}
+
+	list, err := deps.getImageVariants().List(ctx, config.CanvasID, triggerID)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if len(list) == 0 {
+		finish(i18n.T(config.Language, i18n.MsgNoVariants), "success", "")
+		return
+	}
+
+	parent := updateToParentWidget(update)
+	anchor := variants.Anchor{
+		X:      parent.GetLocation().X,
+		Y:      parent.GetLocation().Y,
+		Width:  parent.GetSize().Width,
+		Height: parent.GetSize().Height,
+		Scale:  parent.GetScale(),
+		Depth:  parent.GetDepth(),
+	}
+
+	if pick == 0 {
+		ids, err := variants.PostStrip(ctx, client, list, anchor, config.DownloadsDir)
+		for _, id := range ids {
+			trail.created(ctx, "Image", id, nil)
+		}
+		if err != nil {
+			fail(err)
+			return
+		}
+		syntax := handlers.NewTriggerSyntax(config.TriggerOpen, config.TriggerClose)
+		finish(i18n.T(config.Language, i18n.MsgVariantsPosted, len(ids), syntax.Open+"variants: 1"+syntax.Close), "success", "")
+		log.Info("posted image variants",
+			zap.Int("variants", len(ids)),
+			zap.Duration("duration", deps.since(start)))
+		return
+	}
+
+	if pick > len(list) {
+		finish(i18n.T(config.Language, i18n.MsgVariantUnknown, len(list), pick), "success", "")
+		return
+	}
+	v := list[pick-1]
+	store := deps.getArtifactStore()
+	if store == nil || v.ArtifactID == "" {
+		finish(i18n.T(config.Language, i18n.MsgVariantNotKept, pick), "success", "")
+		return
+	}
+	a, content, err := store.Open(ctx, v.ArtifactID)
+	if errors.Is(err, artifacts.ErrNotFound) {
+		finish(i18n.T(config.Language, i18n.MsgVariantNotKept, pick), "success", "")
+		return
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+	data, err := io.ReadAll(content)
+	content.Close()
+	if err != nil {
+		fail(err)
+		return
+	}
+	widgetID, err := variants.PostImage(client, v, pick, data, a.Name, anchor, config.DownloadsDir)
+	if err != nil {
+		fail(err)
+		return
+	}
+	trail.created(ctx, "Image", widgetID, data)
+	finish(i18n.T(config.Language, i18n.MsgVariantRestored, pick), "success", "")
+	log.Info("brought back image variant",
+		zap.Int("variant", pick),
+		zap.String("artifact_id", v.ArtifactID),
+		zap.String("image_widget_id", widgetID))
+}
+
+// handleCollage composes the images of a zone, or of the whole canvas, into
+// one image placed to the right of them. A mood board, or a collage with an
+// empty --title, is headed by a title written by the AI. The processing note
+// says how many images were used.
+//
+// Atomic design: Organism (orchestrates canvas export, collage composition and image upload)
+func handleCollage(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient LlamaModel, deps *HandlerDependencies, req collage.Request) {
+	triggerID, _ := update["id"].(string)
+	correlationID := generateCorrelationID()
+	log := logger.With(
+		zap.String("correlation_id", correlationID),
+		zap.String("widget_id", triggerID),
+		zap.String("widget_type", "Note"),
+	)
+
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: triggerID, Operation: "collage",
+	})
+	start := deps.now()
+
+	// Record task start for dashboard metrics
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeImage, config.CanvasID, triggerID)
+
+	processingNoteID, err := createProcessingNote(client, update, config, log)
+	if err != nil {
+		log.Error("failed to create processing note", zap.Error(err))
+		deps.recordTaskComplete(taskRecord, "failed to create processing note")
+		return
+	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
+	updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgBuildingCollage), config, log)
+
+	trail := deps.newAuditTrail(config, correlationID, update, "collage", "", log)
+	fail := func(err error) {
+		log.Error("building collage failed", zap.Error(err))
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgCollageFailed, err), config, log)
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, triggerID,
+			"collage", req.Zone, "", "",
+			0, 0, int(deps.since(start).Milliseconds()),
+			"error", err.Error(), log,
+		)
+		deps.recordTaskComplete(taskRecord, err.Error())
+	}
+	noImages := func() {
+		text := i18n.T(config.Language, i18n.MsgCollageNoImages)
+		finishProcessingNote(ctx, client, processingNoteID, text, config, trail, log)
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, triggerID,
+			"collage", req.Zone, text, "",
+			0, 0, int(deps.since(start).Milliseconds()),
+			"success", "", log,
+		)
+		deps.recordTaskComplete(taskRecord, "")
+	}
+
+	// The export collects the images of the zone in reading order
+	exporter := canvasexport.NewExporter(client, config.DownloadsDir, log.Zap())
+	exporter.SetClock(deps.getClock())
+	doc, err := exporter.Export(ctx, canvasexport.Options{
+		Zone:       req.Zone,
+		ExcludeIDs: []string{triggerID, processingNoteID},
+	})
+	if err != nil {
+		fail(err)
+		return
+	}
+	var images [][]byte
+	for _, s := range doc.Sections {
+		if s.Kind == canvasexport.KindImage && s.Image != nil && len(images) < config.CollageMaxImages {
+			images = append(images, s.Image.Data)
+		}
+	}
+	area, ok := collage.Area(doc)
+	if len(images) == 0 || !ok {
+		noImages()
+		return
+	}
+
+	title, model := req.Title, ""
+	if title == "" && req.AutoTitle {
+		title, model, err = generateCollageTitle(ctx, config, llamaClient, deps, collage.TitlePrompt(doc))
+		if err != nil {
+			// The collage is still worth having without its title
+			log.Warn("failed to write collage title", zap.Error(err))
+		}
+	}
+
+	result, err := collage.Compose(images, collage.Options{
+		CellSize: config.CollageCellSize,
+		Columns:  req.Columns,
+		Title:    title,
+	})
+	if errors.Is(err, collage.ErrNoImages) {
+		noImages()
+		return
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+	widgetID, err := collage.Post(client, result, title, area, updateToParentWidget(update).GetDepth(), config.DownloadsDir)
+	if err != nil {
+		fail(err)
+		return
+	}
+	trail.created(ctx, "Image", widgetID, result.Data)
+
+	text := i18n.T(config.Language, i18n.MsgCollagePosted, result.Images)
+	finishProcessingNote(ctx, client, processingNoteID, text, config, trail, log)
+	recordProcessingHistory(
+		ctx, repo, correlationID, config.CanvasID, triggerID,
+		"collage", req.Zone, title, model,
+		0, 0, int(deps.since(start).Milliseconds()),
+		"success", "", log,
+	)
+	deps.recordMetrics("image", deps.since(start))
+	deps.recordTaskComplete(taskRecord, "") // Empty string = success
+
+	log.Info("completed collage",
+		zap.String("image_widget_id", widgetID),
+		zap.String("zone", req.Zone),
+		zap.Int("images", result.Images),
+		zap.Int("skipped", result.Skipped),
+		zap.Duration("duration", deps.since(start)))
+}
+
+// generateCollageTitle asks the local model if loaded, otherwise the cloud
+// note model, for the title of a collage described by prompt. It returns
+// the title and the model that wrote it.
+func generateCollageTitle(ctx context.Context, config *core.Config, llamaClient LlamaModel, deps *HandlerDependencies, prompt string) (string, string, error) {
+	systemMessage := i18n.Prompt(config.Language, i18n.PromptCollageTitle, collage.TitleSystemPrompt)
+	prompt, _ = deps.getPromptGuard().Sanitize(prompt)
+
+	if llamaClient != nil {
+		text, err := llamaClient.Generate(ctx, prompt, llamaruntime.GenerationParams{
+			MaxTokens:    30,
+			Temperature:  0.8,
+			SystemPrompt: &systemMessage,
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("AI generation error: %w", err)
+		}
+		return collage.CleanTitle(text), "local", nil
+	}
+
+	if redactor := deps.cloudRedactor(config); redactor != nil {
+		redacted, _, err := redactor.Redact(ctx, prompt)
+		if err != nil {
+			return "", "", fmt.Errorf("PII redaction failed: %w", err)
+		}
+		prompt = redacted
+	}
+	resp, err := core.CreateOpenAIClient(config).CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: config.OpenAINoteModel,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: systemMessage},
+			{Role: "user", Content: prompt},
 		},
-		BackgroundColor: processingNoteColor,
-		TextColor:       processingNoteTextColor,
-		Text:            "⏳ " + processingNoteTitle,
+		MaxTokens:   30,
+		Temperature: 0.8,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("OpenAI API error: %w", err)
 	}
+	if len(resp.Choices) == 0 {
+		return "", "", fmt.Errorf("no response from AI")
+	}
+	return collage.CleanTitle(resp.Choices[0].Message.Content), config.OpenAINoteModel, nil
+}
+
+// handleNoteFix corrects the spelling and grammar of a note in place: the
+// trigger is removed and the corrected text replaces the note text. The
+// text before the fix is kept so {{fix: undo}} can put it back. Nothing is
+// written when the fix fails; the reason goes to a note beside it.
+//
+// Atomic design: Organism (orchestrates text correction, note update and undo history)
+func handleNoteFix(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient LlamaModel, deps *HandlerDependencies, undo bool) {
+	noteID, _ := update["id"].(string)
+	correlationID := generateCorrelationID()
+	log := logger.With(
+		zap.String("correlation_id", correlationID),
+		zap.String("widget_id", noteID),
+		zap.String("widget_type", "Note"),
+	)
+
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: noteID, Operation: "note_fix",
+	})
+	start := deps.now()
+
+	// Record task start for dashboard metrics
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeNote, config.CanvasID, noteID)
+
+	text, _ := update["text"].(string)
+	syntax := handlers.NewTriggerSyntax(config.TriggerOpen, config.TriggerClose)
+	_, rest, _ := syntax.Cut(text)
+	current := strings.TrimSpace(rest)
 
-	result, err := client.CreateNote(note)
+	operation := "note_fix"
+	if undo {
+		operation = "note_fix_undo"
+	}
+	trail := deps.newAuditTrail(config, correlationID, update, operation, "", log)
+	history := deps.getNoteFixes()
+
+	// refuse explains on a note beside this one why it was left as it is
+	refuse := func(message string) {
+		if err := createRefusalNote(client, update, message, config, log); err != nil {
+			log.Error("failed to create note fix refusal note", zap.Error(err))
+		}
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, noteID,
+			operation, current, message, "",
+			0, 0, int(deps.since(start).Milliseconds()),
+			"success", "", log,
+		)
+		deps.recordTaskComplete(taskRecord, "")
+	}
+	fail := func(err error) {
+		log.Error("fixing note failed", zap.Error(err))
+		if noteErr := handleAIError(ctx, client, repo, correlationID, update, err, i18n.T(config.Language, i18n.MsgFixFailed, err), config, log); noteErr != nil {
+			log.Error("failed to create error note", zap.Error(noteErr))
+		}
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, noteID,
+			operation, current, "", "",
+			0, 0, int(deps.since(start).Milliseconds()),
+			"error", err.Error(), log,
+		)
+		deps.recordTaskComplete(taskRecord, err.Error())
+	}
+	// write replaces the note text, trigger included
+	write := func(newText, model string) bool {
+		if _, err := client.UpdateNote(noteID, map[string]interface{}{"text": newText}); err != nil {
+			fail(fmt.Errorf("failed to update note: %w", err))
+			return false
+		}
+		trail.updated(ctx, "Note", noteID, []byte(newText))
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, noteID,
+			operation, current, newText, model,
+			0, 0, int(deps.since(start).Milliseconds()),
+			"success", "", log,
+		)
+		deps.recordMetrics("note", deps.since(start))
+		deps.recordTaskComplete(taskRecord, "") // Empty string = success
+		return true
+	}
+
+	if undo {
+		fix, err := history.Undo(ctx, config.CanvasID, noteID, current)
+		switch {
+		case errors.Is(err, notefix.ErrNothingToUndo):
+			refuse(i18n.T(config.Language, i18n.MsgFixNothingToUndo))
+		case errors.Is(err, notefix.ErrEditedSince):
+			refuse(i18n.T(config.Language, i18n.MsgFixEditedSince))
+		case err != nil:
+			fail(err)
+		default:
+			if write(fix.Original, "") {
+				log.Info("undid note fix",
+					zap.Int64("fix_id", fix.ID),
+					zap.Duration("duration", deps.since(start)))
+			}
+		}
+		return
+	}
+
+	if current == "" {
+		// Only the trigger is left; there is nothing to correct
+		write("", "")
+		return
+	}
+	fixed, model, err := generateNoteFix(ctx, config, llamaClient, deps, current)
+	if errors.Is(err, errEditPII) {
+		refuse(i18n.T(config.Language, i18n.MsgFixNeedsLocal))
+		return
+	}
+	if err == nil {
+		err = notefix.Check(current, fixed)
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+	if !write(fixed, model) {
+		return
+	}
+	if err := history.Record(ctx, config.CanvasID, noteID, current, fixed); err != nil {
+		// The fix stands; it just cannot be undone
+		log.Warn("failed to record note fix", zap.Error(err))
+	}
+	log.Info("fixed note",
+		zap.String("model", model),
+		zap.Bool("changed", fixed != current),
+		zap.Duration("duration", deps.since(start)))
+}
+
+// errEditPII is returned by generateText when text edited in place would
+// be redacted before going to the cloud model. The placeholders would end
+// up in the note, so the text is not sent.
+var errEditPII = errors.New("note contains personal data that would be redacted")
+
+// generateNoteFix asks for the correction of text. It returns the
+// corrected text and the model that wrote it.
+func generateNoteFix(ctx context.Context, config *core.Config, llamaClient LlamaModel, deps *HandlerDependencies, text string) (string, string, error) {
+	// Room for the whole text again, plus some slack for the tokenizer
+	maxTokens := handlers.EstimateTokenCount(text)*2 + 100
+	reply, model, err := generateText(ctx, config, llamaClient, deps, notefix.SystemPrompt, notefix.Prompt(text), maxTokens, 0.1, true)
+	return notefix.Clean(reply), model, err
+}
+
+// generateText asks the local model if loaded, otherwise the cloud note
+// model, to answer prompt as systemMessage says. It returns the reply and
+// the model that wrote it. Callers run canvas text through the prompt guard
+// first where changing it is acceptable. If inPlace, the reply replaces
+// the text of a note: text that redaction would change is not sent to the
+// cloud and errEditPII is returned.
+func generateText(ctx context.Context, config *core.Config, llamaClient LlamaModel, deps *HandlerDependencies, systemMessage, prompt string, maxTokens int, temperature float32, inPlace bool) (string, string, error) {
+	if llamaClient != nil {
+		reply, err := llamaClient.Generate(ctx, prompt, llamaruntime.GenerationParams{
+			MaxTokens:    maxTokens,
+			Temperature:  temperature,
+			SystemPrompt: &systemMessage,
+		})
+		if err != nil {
+			return "", "", fmt.Errorf("AI generation error: %w", err)
+		}
+		return reply, "local", nil
+	}
+
+	if redactor := deps.cloudRedactor(config); redactor != nil {
+		redacted, result, err := redactor.Redact(ctx, prompt)
+		if err != nil {
+			return "", "", fmt.Errorf("PII redaction failed: %w", err)
+		}
+		if inPlace && result != nil && result.Total() > 0 {
+			return "", "", errEditPII
+		}
+		prompt = redacted
+	}
+	resp, err := core.CreateOpenAIClient(config).CreateChatCompletion(ctx, openai.ChatCompletionRequest{
+		Model: config.OpenAINoteModel,
+		Messages: []openai.ChatCompletionMessage{
+			{Role: "system", Content: systemMessage},
+			{Role: "user", Content: prompt},
+		},
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("OpenAI API error: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", "", fmt.Errorf("no response from AI")
+	}
+	return resp.Choices[0].Message.Content, config.OpenAINoteModel, nil
+}
+
+// handleRewrite rewrites a note in a tone, or shortens or expands it, as
+// the template of req says. The result goes to a note beside it, or with
+// --replace replaces the note text like {{fix}}, which can undo it.
+//
+// Atomic design: Organism (orchestrates prompt templates, text generation and note update)
+func handleRewrite(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient LlamaModel, deps *HandlerDependencies, req rewrite.Request) {
+	noteID, _ := update["id"].(string)
+	correlationID := generateCorrelationID()
+	log := logger.With(
+		zap.String("correlation_id", correlationID),
+		zap.String("widget_id", noteID),
+		zap.String("widget_type", "Note"),
+	)
+
+	operation := req.Template.Name
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: noteID, Operation: operation,
+	})
+	start := deps.now()
+
+	// Record task start for dashboard metrics
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeNote, config.CanvasID, noteID)
+
+	text, _ := update["text"].(string)
+	syntax := handlers.NewTriggerSyntax(config.TriggerOpen, config.TriggerClose)
+	_, rest, _ := syntax.Cut(text)
+	current := strings.TrimSpace(rest)
+
+	trail := deps.newAuditTrail(config, correlationID, update, operation, "", log)
+	record := func(result, model, status, errMsg string) {
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, noteID,
+			operation, req.Tone, result, model,
+			0, 0, int(deps.since(start).Milliseconds()),
+			status, errMsg, log,
+		)
+	}
+	refuse := func(message string) {
+		if err := createRefusalNote(client, update, message, config, log); err != nil {
+			log.Error("failed to create rewrite refusal note", zap.Error(err))
+		}
+		record(message, "", "success", "")
+		deps.recordTaskComplete(taskRecord, "")
+	}
+
+	if current == "" {
+		refuse(i18n.T(config.Language, i18n.MsgRewriteNoText))
+		return
+	}
+
+	// Without --replace the result goes to a processing note beside this one
+	processingNoteID := ""
+	if !req.Replace {
+		var err error
+		processingNoteID, err = createProcessingNote(client, update, config, log)
+		if err != nil {
+			log.Error("failed to create processing note", zap.Error(err))
+			deps.recordTaskComplete(taskRecord, "failed to create processing note")
+			return
+		}
+		deps.recordProcessingNote(taskRecord, processingNoteID)
+		updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgRewriting), config, log)
+		notifyWarmupIfUnloaded(client, processingNoteID, llamaClient, config, log)
+	}
+	fail := func(err error) {
+		log.Error("rewriting note failed", zap.Error(err))
+		if processingNoteID != "" {
+			updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgRewriteFailed, err), config, log)
+		} else if noteErr := handleAIError(ctx, client, repo, correlationID, update, err, i18n.T(config.Language, i18n.MsgRewriteFailed, err), config, log); noteErr != nil {
+			log.Error("failed to create error note", zap.Error(noteErr))
+		}
+		record("", "", "error", err.Error())
+		deps.recordTaskComplete(taskRecord, err.Error())
+	}
+
+	maxTokens := req.MaxTokens(handlers.EstimateTokenCount(current))
+	reply, model, err := generateText(ctx, config, llamaClient, deps, req.SystemPrompt(), rewrite.Prompt(current), maxTokens, 0.4, req.Replace)
+	if errors.Is(err, errEditPII) {
+		refuse(i18n.T(config.Language, i18n.MsgRewriteNeedsLocal))
+		return
+	}
+	rewritten := rewrite.Clean(reply)
+	if err == nil {
+		err = req.Check(current, rewritten)
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+	shown := deps.filterResponse(ctx, repo, correlationID, noteID, rewritten, config, log)
+
+	if req.Replace {
+		if _, err := client.UpdateNote(noteID, map[string]interface{}{"text": shown}); err != nil {
+			fail(fmt.Errorf("failed to update note: %w", err))
+			return
+		}
+		trail.updated(ctx, "Note", noteID, []byte(shown))
+		// Recorded with the {{fix}} corrections, so {{fix: undo}} undoes it
+		if err := deps.getNoteFixes().Record(ctx, config.CanvasID, noteID, current, shown); err != nil {
+			log.Warn("failed to record rewrite for undo", zap.Error(err))
+		}
+	} else {
+		finishProcessingNote(ctx, client, processingNoteID, shown+contextReducedFooter(llamaClient, config), config, trail, log)
+	}
+	record(shown, model, "success", "")
+	deps.recordMetrics("note", deps.since(start))
+	deps.recordTaskComplete(taskRecord, "") // Empty string = success
+
+	log.Info("rewrote note",
+		zap.String("template", req.Template.Name),
+		zap.String("tone", req.Tone),
+		zap.Bool("replace", req.Replace),
+		zap.String("model", model),
+		zap.Duration("duration", deps.since(start)))
+}
+
+// handleGroupSummary summarizes the notes grouped with the trigger note,
+// the children of the widget it is attached to or the notes of its anchor,
+// in a note placed to the right of the group.
+//
+// Atomic design: Organism (orchestrates group lookup, prompt guard and text generation)
+func handleGroupSummary(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient LlamaModel, deps *HandlerDependencies) {
+	triggerID, _ := update["id"].(string)
+	correlationID := generateCorrelationID()
+	log := logger.With(
+		zap.String("correlation_id", correlationID),
+		zap.String("widget_id", triggerID),
+		zap.String("widget_type", "Note"),
+	)
+
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: triggerID, Operation: "group_summary",
+	})
+	start := deps.now()
+
+	// Record task start for dashboard metrics
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeCanvasAnalysis, config.CanvasID, triggerID)
+
+	record := func(group, result, model, status, errMsg string) {
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, triggerID,
+			"group_summary", group, result, model,
+			0, 0, int(deps.since(start).Milliseconds()),
+			status, errMsg, log,
+		)
+	}
+	refuse := func(message string) {
+		if err := createRefusalNote(client, update, message, config, log); err != nil {
+			log.Error("failed to create group summary refusal note", zap.Error(err))
+		}
+		record("", message, "", "success", "")
+		deps.recordTaskComplete(taskRecord, "")
+	}
+
+	widgets, err := client.GetWidgets(false)
+	if err != nil {
+		log.Error("failed to fetch widgets", zap.Error(err))
+		if noteErr := handleAIError(ctx, client, repo, correlationID, update, err, i18n.T(config.Language, i18n.MsgGroupSummaryFailed, err), config, log); noteErr != nil {
+			log.Error("failed to create error note", zap.Error(noteErr))
+		}
+		record("", "", "", "error", err.Error())
+		deps.recordTaskComplete(taskRecord, err.Error())
+		return
+	}
+	syntax := handlers.NewTriggerSyntax(config.TriggerOpen, config.TriggerClose)
+	group, err := groupsummary.Find(widgets, triggerID)
+	if errors.Is(err, groupsummary.ErrNotInGroup) {
+		refuse(i18n.T(config.Language, i18n.MsgGroupNotFound, syntax.Open+"summarize"+syntax.Close))
+		return
+	}
+	if errors.Is(err, groupsummary.ErrTriggerNotFound) {
+		log.Info("summarize trigger note deleted before it was processed")
+		deps.recordTaskComplete(taskRecord, "")
+		return
+	}
+	if len(group.Members) == 0 {
+		refuse(i18n.T(config.Language, i18n.MsgGroupEmpty))
+		return
+	}
+
+	// The summary goes to the right of the group, not of the trigger inside it
+	processingNoteID, err := createProcessingNoteAt(client, widgettemplates.Beside(group.Bounds), "", config, log)
+	if err != nil {
+		log.Error("failed to create processing note", zap.Error(err))
+		deps.recordTaskComplete(taskRecord, "failed to create processing note")
+		return
+	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
+	updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgSummarizingGroup, len(group.Members)), config, log)
+	notifyWarmupIfUnloaded(client, processingNoteID, llamaClient, config, log)
+
+	// Neutralize prompt injection in the member notes
+	texts, injections := deps.getPromptGuard().SanitizeAll(group.Texts())
+	recordInjection(ctx, repo, correlationID, config.CanvasID, triggerID, injections, log)
+	prompt, included := groupsummary.Prompt(group, texts, groupsummary.DefaultMaxPromptRunes)
+
+	systemMessage := i18n.Prompt(config.Language, i18n.PromptGroupSummary, groupsummary.SystemPrompt)
+	summary, model, err := generateText(ctx, config, llamaClient, deps, systemMessage, prompt, int(config.NoteResponseTokens), 0.5, false)
+	if err == nil && strings.TrimSpace(summary) == "" {
+		err = fmt.Errorf("no response from AI")
+	}
+	if err != nil {
+		log.Error("group summary failed", zap.Error(err))
+		updateProcessingNote(client, processingNoteID, "❌ "+i18n.T(config.Language, i18n.MsgGroupSummaryFailed, err), config, log)
+		record(group.Name, "", model, "error", err.Error())
+		deps.recordTaskComplete(taskRecord, err.Error())
+		return
+	}
+
+	shown := deps.filterResponse(ctx, repo, correlationID, triggerID, strings.TrimSpace(summary), config, log)
+	if included < len(group.Members) {
+		shown += "\n\n" + i18n.T(config.Language, i18n.MsgGroupSummaryPartial, included, len(group.Members))
+	}
+	shown += contextReducedFooter(llamaClient, config)
+	trail := deps.newAuditTrail(config, correlationID, update, "group_summary", model, log)
+	finishProcessingNote(ctx, client, processingNoteID, shown, config, trail, log)
+
+	record(group.Name, truncateText(summary, 1000), model, "success", "")
+	deps.recordMetrics("note", deps.since(start))
+	deps.recordTaskComplete(taskRecord, "") // Empty string = success
+
+	log.Info("completed group summary",
+		zap.String("group_kind", group.Kind),
+		zap.String("group_id", group.ID),
+		zap.Int("notes", len(group.Members)),
+		zap.Int("summarized", included),
+		zap.Duration("duration", deps.since(start)))
+}
+
+// handleVoiceNote transcribes an audio file uploaded to the canvas into a
+// note attached to it. Recordings of at least VOICE_NOTE_SUMMARY_MINUTES
+// also get a summary above the transcript. Each recording is transcribed
+// once; a failed transcription is tried again on the widget's next update.
+func handleVoiceNote(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient LlamaModel, deps *HandlerDependencies) {
+	audioID, _ := update["id"].(string)
+	title := strings.TrimSpace(handlers.GetStringField(update, "title", ""))
+	correlationID := generateCorrelationID()
+	log := logger.With(
+		zap.String("correlation_id", correlationID),
+		zap.String("widget_id", audioID),
+		zap.String("widget_type", "Video"),
+	)
+
+	transcriber := deps.getVoiceNotes()
+	if transcriber == nil {
+		log.Debug("voice notes not configured, skipping audio")
+		return
+	}
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: audioID, Operation: "voice_note",
+	})
+	start := deps.now()
+
+	voiceNote := voicenote.VoiceNote{CanvasID: config.CanvasID, WidgetID: audioID, Title: title, CreatedAt: start}
+	claimed, err := transcriber.Claim(ctx, voiceNote)
+	if err != nil {
+		log.Error("failed to claim recording", zap.Error(err))
+		return
+	}
+	if !claimed {
+		log.Debug("recording already transcribed, skipping")
+		return
+	}
+
+	// Record task start for dashboard metrics
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeTranscription, config.CanvasID, audioID)
+
+	record := func(result, model, status, errMsg string) {
+		recordProcessingHistory(
+			ctx, repo, correlationID, config.CanvasID, audioID,
+			"voice_note", title, result, model,
+			0, 0, int(deps.since(start).Milliseconds()),
+			status, errMsg, log,
+		)
+	}
+	fail := func(noteID string, err error) {
+		log.Error("voice note failed", zap.Error(err))
+		updateProcessingNote(client, noteID, "❌ "+i18n.T(config.Language, i18n.MsgTranscriptionFailed, err), config, log)
+		if releaseErr := transcriber.Release(ctx, config.CanvasID, audioID); releaseErr != nil {
+			log.Warn("failed to release recording", zap.Error(releaseErr))
+		}
+		record("", transcriber.Model(), "error", err.Error())
+		deps.recordTaskComplete(taskRecord, err.Error())
+	}
+
+	// The transcript is attached to the right of the recording, in its
+	// coordinates
+	size := handlers.ExtractSize(handlers.GetMapField(update, "size"))
+	origin := widgettemplates.Beside(handlers.Rect{Width: size.Width, Height: size.Height})
+	processingNoteID, err := createProcessingNoteAt(client, origin, audioID, config, log)
+	if err != nil {
+		log.Error("failed to create processing note", zap.Error(err))
+		if releaseErr := transcriber.Release(ctx, config.CanvasID, audioID); releaseErr != nil {
+			log.Warn("failed to release recording", zap.Error(releaseErr))
+		}
+		deps.recordTaskComplete(taskRecord, "failed to create processing note")
+		return
+	}
+	deps.recordProcessingNote(taskRecord, processingNoteID)
+	updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgTranscribing, title), config, log)
+
+	audioPath := filepath.Join(config.DownloadsDir, "voice_"+audioID+voicenote.Extension(title))
+	defer os.Remove(audioPath)
+	if err := client.DownloadVideo(audioID, audioPath); err != nil {
+		fail(processingNoteID, fmt.Errorf("download failed: %w", err))
+		return
+	}
+	transcript, err := transcriber.Transcribe(ctx, audioPath)
+	if err != nil {
+		fail(processingNoteID, err)
+		return
+	}
+	voiceNote.NoteID, voiceNote.Duration, voiceNote.Language = processingNoteID, transcript.Duration, transcript.Language
+	if transcript.Text == "" {
+		updateProcessingNote(client, processingNoteID, i18n.T(config.Language, i18n.MsgVoiceNoteNoSpeech, title), config, log)
+		if err := transcriber.Save(ctx, voiceNote); err != nil {
+			log.Warn("failed to save voice note", zap.Error(err))
+		}
+		record("", transcriber.Model(), "success", "")
+		deps.recordTaskComplete(taskRecord, "")
+		return
+	}
+
+	text := i18n.T(config.Language, i18n.MsgVoiceNoteTitle, title, voicenote.FormatDuration(transcript.Duration)) + "\n\n"
+	model := transcriber.Model()
+	if transcriber.Config().Summarize(transcript.Duration) {
+		notifyWarmupIfUnloaded(client, processingNoteID, llamaClient, config, log)
+		input := deps.guardPrompt(ctx, repo, correlationID, config.CanvasID, audioID, voicenote.SummaryInput(transcript.Text), log)
+		systemMessage := i18n.Prompt(config.Language, i18n.PromptVoiceNoteSummary, voicenote.SummaryPrompt)
+		summary, summaryModel, err := generateText(ctx, config, llamaClient, deps, systemMessage, input, int(config.NoteResponseTokens), 0.3, false)
+		if err == nil && strings.TrimSpace(summary) != "" {
+			// The transcript is shown as recognized; only the summary is model text
+			summary = deps.filterResponse(ctx, repo, correlationID, audioID, strings.TrimSpace(summary), config, log)
+			text += "**" + i18n.T(config.Language, i18n.MsgVoiceNoteSummary) + "**\n" + summary + "\n\n**" +
+				i18n.T(config.Language, i18n.MsgVoiceNoteTranscript) + "**\n"
+			model += ", " + summaryModel
+		} else {
+			log.Warn("voice note summary failed, posting the transcript only", zap.Error(err))
+		}
+	}
+	text += voicenote.FormatTranscript(transcript)
+
+	trail := deps.newAuditTrail(config, correlationID, update, "voice_note", model, log)
+	finishProcessingNote(ctx, client, processingNoteID, text, config, trail, log)
+	if err := transcriber.Save(ctx, voiceNote); err != nil {
+		log.Warn("failed to save voice note", zap.Error(err))
+	}
+
+	record(truncateText(transcript.Text, 1000), model, "success", "")
+	deps.recordTaskComplete(taskRecord, "") // Empty string = success
+
+	log.Info("completed voice note",
+		zap.Duration("recording", transcript.Duration),
+		zap.String("language", transcript.Language),
+		zap.Int("segments", len(transcript.Segments)),
+		zap.Duration("duration", deps.since(start)))
+}
+
+// handleLiveTranscript starts a live meeting transcript in the {{live}}
+// note, or stops the one on the canvas for {{live: stop}}. The session
+// runs in the background: the note is rewritten with the transcript as
+// people speak, and a summary note beside it is updated every
+// LIVE_TRANSCRIPT_SUMMARY_MINUTES of audio.
+func handleLiveTranscript(update Update, client *canvusapi.Client, config *core.Config, logger *logging.Logger, repo *db.Repository, llamaClient LlamaModel, deps *HandlerDependencies) {
+	noteID, _ := update["id"].(string)
+	correlationID := generateCorrelationID()
+	log := logger.With(
+		zap.String("correlation_id", correlationID),
+		zap.String("widget_id", noteID),
+		zap.String("widget_type", "Note"),
+	)
+	syntax := handlers.NewTriggerSyntax(config.TriggerOpen, config.TriggerClose)
+	stopTrigger := syntax.Open + "live: stop" + syntax.Close
+	content, _ := syntax.Enclosed(handlers.GetStringField(update, "text", ""))
+	stop, ok := livetranscript.ParseTrigger(content)
+	if !ok {
+		log.Warn("live trigger no longer present, skipping task")
+		return
+	}
+	refuse := func(message string) {
+		if err := createRefusalNote(client, update, message, config, log); err != nil {
+			log.Error("failed to create live transcript refusal note", zap.Error(err))
+		}
+	}
+
+	manager := deps.getLiveTranscripts()
+	if stop {
+		if !manager.Stop(config.CanvasID) {
+			refuse(i18n.T(config.Language, i18n.MsgLiveTranscriptNone))
+			return
+		}
+		if _, err := client.UpdateNote(noteID, map[string]interface{}{"text": i18n.T(config.Language, i18n.MsgLiveTranscriptDone)}); err != nil {
+			log.Warn("failed to update stop note", zap.Error(err))
+		}
+		log.Info("live transcript stop requested")
+		return
+	}
+	if manager == nil {
+		refuse(i18n.T(config.Language, i18n.MsgLiveTranscriptOff))
+		return
+	}
+
+	ctx := llmcapture.WithTask(context.Background(), llmcapture.Task{
+		CanvasID: config.CanvasID, CorrelationID: correlationID, WidgetID: noteID, Operation: "live_transcript",
+	})
+	start := deps.now()
+	// The task is starting the session; the session itself outlives it
+	taskRecord := deps.recordTaskStart(correlationID, metrics.TaskTypeTranscription, config.CanvasID, noteID)
+
+	summaryModel := ""
+	summarize := func(_ context.Context, transcript string) (string, error) {
+		input := deps.guardPrompt(ctx, repo, correlationID, config.CanvasID, noteID, livetranscript.SummaryInput(transcript), log)
+		systemMessage := i18n.Prompt(config.Language, i18n.PromptLiveSummary, livetranscript.SummaryPrompt)
+		summary, model, err := generateText(ctx, config, llamaClient, deps, systemMessage, input, int(config.NoteResponseTokens), 0.3, false)
+		if err == nil && strings.TrimSpace(summary) == "" {
+			err = fmt.Errorf("no response from AI")
+		}
+		if err != nil {
+			return "", err
+		}
+		summaryModel = model
+		return deps.filterResponse(ctx, repo, correlationID, noteID, strings.TrimSpace(summary), config, log), nil
+	}
+	sink := &liveTranscriptNotes{
+		client: client, config: config, trigger: update, noteID: noteID, stopTrigger: stopTrigger, log: log,
+		ended: func(status livetranscript.Status) {
+			model := manager.Model()
+			if summaryModel != "" {
+				model += ", " + summaryModel
+			}
+			result, errMsg := "success", ""
+			if status.Err != nil {
+				result, errMsg = "error", status.Err.Error()
+			}
+			recordProcessingHistory(
+				ctx, repo, correlationID, config.CanvasID, noteID,
+				"live_transcript", "", truncateText(livetranscript.Text(status.Lines), 1000), model,
+				0, 0, int(deps.since(start).Milliseconds()),
+				result, errMsg, log,
+			)
+		},
+	}
+
+	if err := manager.Start(config.CanvasID, noteID, sink, summarize); err != nil {
+		log.Warn("live transcript not started", zap.Error(err))
+		refuse(i18n.T(config.Language, i18n.MsgLiveTranscriptBusy, err, stopTrigger))
+		deps.recordTaskComplete(taskRecord, err.Error())
+		return
+	}
+	// Replacing the trigger also keeps the note from starting another session
+	if _, err := client.UpdateNote(noteID, map[string]interface{}{
+		"title": i18n.T(config.Language, i18n.MsgLiveTranscriptTitle),
+		"text":  sink.text(livetranscript.Status{}),
+	}); err != nil {
+		log.Warn("failed to update live transcript note", zap.Error(err))
+	}
+	deps.recordTaskComplete(taskRecord, "") // Empty string = success
+	log.Info("live transcript started",
+		zap.String("model", manager.Model()),
+		zap.Duration("chunk", manager.Config().Chunk))
+}
+
+// liveTranscriptNotes shows a live transcript on the canvas: the {{live}}
+// note holds the transcript and a note beside it the latest summary.
+// Implements livetranscript.Sink.
+type liveTranscriptNotes struct {
+	client      *canvusapi.Client
+	config      *core.Config
+	trigger     Update
+	noteID      string
+	summaryID   string
+	stopTrigger string
+	log         *logging.Logger
+	// ended is called once the final status is shown
+	ended func(livetranscript.Status)
+}
+
+// Transcript rewrites the transcript note.
+func (n *liveTranscriptNotes) Transcript(status livetranscript.Status) error {
+	if status.Ended && n.ended != nil {
+		defer n.ended(status)
+	}
+	text := n.text(status)
+	style := widgettemplates.ThemeFromConfig(n.config).StatusStyle(text)
+	if _, err := n.client.UpdateNote(n.noteID, map[string]interface{}{
+		"text":             text,
+		"background_color": style.BackgroundColor,
+		"text_color":       style.TextColor,
+	}); err != nil {
+		if isNotFound(err) {
+			return livetranscript.ErrNoteGone
+		}
+		return err
+	}
+	return nil
+}
+
+// text returns the transcript note text for status.
+func (n *liveTranscriptNotes) text(status livetranscript.Status) string {
+	lang := n.config.Language
+	var header string
+	switch {
+	case status.Err != nil:
+		header = "❌ " + i18n.T(lang, i18n.MsgLiveTranscriptError, status.Err) + "\n" +
+			i18n.T(lang, i18n.MsgLiveTranscriptEnded, livetranscript.FormatDuration(status.Elapsed))
+	case status.Ended:
+		header = i18n.T(lang, i18n.MsgLiveTranscriptEnded, livetranscript.FormatDuration(status.Elapsed))
+	default:
+		header = i18n.T(lang, i18n.MsgLiveTranscriptLive, livetranscript.FormatDuration(status.Elapsed)) + "\n" +
+			i18n.T(lang, i18n.MsgLiveTranscriptStop, n.stopTrigger)
+	}
+	if len(status.Lines) == 0 {
+		if status.Ended {
+			return header
+		}
+		return header + "\n\n" + i18n.T(lang, i18n.MsgLiveTranscriptWait)
+	}
+	return header + "\n\n" + livetranscript.FormatLines(status.Lines)
+}
+
+// Summary writes the summary note, creating it beside the transcript the
+// first time, or again if it was deleted.
+func (n *liveTranscriptNotes) Summary(text string, at time.Duration) error {
+	text = "**" + i18n.T(n.config.Language, i18n.MsgLiveSummaryTitle, livetranscript.FormatDuration(at)) + "**\n\n" + text
+	if n.summaryID != "" {
+		err := updateProcessingNote(n.client, n.summaryID, text, n.config, n.log)
+		if err == nil || !isNotFound(err) {
+			return err
+		}
+	}
+	id, err := createProcessingNoteAt(n.client, widgettemplates.Beside(handlers.WidgetBounds(n.trigger, nil)), "", n.config, n.log)
+	if err != nil {
+		return err
+	}
+	n.summaryID = id
+	return updateProcessingNote(n.client, id, text, n.config, n.log)
+}
+
+// webUIPublicURL returns the address canvas users reach the WebUI at,
+// defaulting to this machine.
+func webUIPublicURL(config *core.Config) string {
+	if config.WebUIPublicURL != "" {
+		return config.WebUIPublicURL
+	}
+	return fmt.Sprintf("http://localhost:%d", config.Port)
+}
+
+// createProcessingNote creates a temporary "AI Processing" note on the canvas.
+// This note is updated as processing progresses and eventually contains the final result.
+func createProcessingNote(client *canvusapi.Client, triggerWidget Update, config *core.Config, log *logging.Logger) (string, error) {
+	// The processing note goes where the response note would
+	return createProcessingNoteAt(client, widgettemplates.Beside(handlers.WidgetBounds(triggerWidget, nil)), "", config, log)
+}
+
+// createProcessingNoteAt is createProcessingNote for a note at origin, for
+// results that belong beside something other than the trigger. If
+// parentID is set, the note is attached to that widget and origin is
+// relative to it.
+func createProcessingNoteAt(client *canvusapi.Client, origin handlers.Location, parentID string, config *core.Config, log *logging.Logger) (string, error) {
+	theme := widgettemplates.ThemeFromConfig(config)
+	widgets := widgettemplates.Response.Instantiate(
+		widgettemplates.Content{Body: i18n.T(config.Language, i18n.MsgProcessing)},
+		origin,
+		theme.WithBody(widgettemplates.Style{BackgroundColor: widgettemplates.ProcessingColor, TextColor: widgettemplates.ProcessingTextColor}),
+	)
+	if parentID != "" {
+		for _, w := range widgets {
+			w.Payload["parent_id"] = parentID
+		}
+	}
+	created, err := widgettemplates.Create(client, widgets)
 	if err != nil {
 		return "", fmt.Errorf("failed to create processing note: %w", err)
 	}
+	noteID := widgettemplates.ID(created, widgettemplates.RoleBody)
 
 	log.Debug("processing note created",
-		zap.String("note_id", result.ID))
+		zap.String("note_id", noteID))
 
-	return result.ID, nil
+	return noteID, nil
 }
 
-// updateProcessingNote updates the text of an existing note widget.
-func updateProcessingNote(client *canvusapi.Client, noteID string, text string, config *core.Config, log *logging.Logger) {
-	// Determine the color based on the content
-	var bgColor, textColor string
-	if strings.HasPrefix(text, "❌") {
-		// Error state - red background
-		bgColor = "#DC143C" // Crimson
-		textColor = "#FFFFFF"
-	} else if strings.HasPrefix(text, "⚠️") {
-		// Warning state - yellow background
-		bgColor = "#FFD700" // Gold
-		textColor = "#000000"
-	} else if strings.HasPrefix(text, "⏳") {
-		// Processing state - dark red
-		bgColor = processingNoteColor
-		textColor = processingNoteTextColor
-	} else {
-		// Success state - use configured colors
-		bgColor = config.NoteColor
-		textColor = config.NoteTextColor
+// createRefusalNote creates a note to the right of the trigger explaining
+// that a request was not processed. Unlike handleAIError it uses the AI
+// response note colors and records no error, since nothing failed.
+func createRefusalNote(client *canvusapi.Client, triggerWidget Update, text string, config *core.Config, log *logging.Logger) error {
+	widgets := widgettemplates.Response.Instantiate(
+		widgettemplates.Content{Body: text},
+		widgettemplates.Beside(handlers.WidgetBounds(triggerWidget, nil)),
+		widgettemplates.ThemeFromConfig(config),
+	)
+	created, err := widgettemplates.Create(client, widgets)
+	if err != nil {
+		return fmt.Errorf("failed to create refusal note: %w", err)
 	}
 
-	req := canvusapi.UpdateWidgetRequest{
-		Text:            &text,
-		BackgroundColor: &bgColor,
-		TextColor:       &textColor,
+	log.Debug("refusal note created",
+		zap.String("note_id", widgettemplates.ID(created, widgettemplates.RoleBody)))
+	return nil
+}
+
+// notifyWarmupIfUnloaded tells the user the local model is warming up when it
+// was unloaded from VRAM while idle. The next inference call reloads it.
+func notifyWarmupIfUnloaded(client *canvusapi.Client, noteID string, llamaClient LlamaModel, config *core.Config, log *logging.Logger) {
+	if noteID == "" || llamaClient == nil || llamaClient.IsLoaded() {
+		return
 	}
+	log.Info("local model unloaded while idle, reloading")
+	updateProcessingNote(client, noteID, i18n.T(config.Language, i18n.MsgWarmingUp), config, log)
+}
 
-	if err := client.UpdateWidget(noteID, req); err != nil {
+// updateProcessingNote updates the text of an existing note widget.
+// Failures are logged and returned.
+func updateProcessingNote(client *canvusapi.Client, noteID string, text string, config *core.Config, log *logging.Logger) error {
+	// The color shows whether the note is in progress, failed or done
+	style := widgettemplates.ThemeFromConfig(config).StatusStyle(text)
+
+	if _, err := client.UpdateNote(noteID, map[string]interface{}{
+		"text":             text,
+		"background_color": style.BackgroundColor,
+		"text_color":       style.TextColor,
+	}); err != nil {
 		log.Error("failed to update processing note",
 			zap.String("note_id", noteID),
 			zap.Error(err))
-		return
+		return err
 	}
 
 	log.Debug("processing note updated",
 		zap.String("note_id", noteID),
 		zap.Int("text_length", len(text)))
+	return nil
 }
 
-// handleAIError creates an error note on the canvas to inform the user of processing failures.
-func handleAIError(ctx context.Context, client *canvusapi.Client, update Update, err error, baseText string, config *core.Config, log *logging.Logger) error {
-	location := update["location"].(map[string]interface{})
-	size := update["size"].(map[string]interface{})
-
-	// Calculate position for the error note (to the right of the trigger)
-	newLocation := handlers.CalculateNoteLocation(location, size, config.NoteSpacing)
+// finishProcessingNote writes the AI result to the processing note and
+// records the change in trail.
+func finishProcessingNote(ctx context.Context, client *canvusapi.Client, noteID string, text string, config *core.Config, trail *auditTrail, log *logging.Logger) {
+	if err := updateProcessingNote(client, noteID, text, config, log); err == nil {
+		trail.updated(ctx, "Note", noteID, []byte(text))
+	}
+}
 
-	errorText := fmt.Sprintf("❌ Error: %v", err)
-	if baseText != "" {
-		errorText = fmt.Sprintf("%s\n\n❌ Error: %v", baseText, err)
+// contextReducedFooter returns a note footer saying the local model ran
+// with a smaller context window than configured because GPU memory ran
+// short, or "" if it did not (or was not used).
+func contextReducedFooter(llamaClient LlamaModel, config *core.Config) string {
+	if llamaClient == nil {
+		return ""
 	}
+	requested, actual := llamaClient.ContextDowngrade()
+	if actual >= requested {
+		return ""
+	}
+	return "\n\n---\n" + i18n.T(config.Language, i18n.MsgContextReduced, actual, requested)
+}
 
-	note := canvusapi.CreateNoteRequest{
-		Location: canvusapi.WidgetLocation{
-			X: newLocation["x"].(float64),
-			Y: newLocation["y"].(float64),
-		},
-		Size: canvusapi.WidgetSize{
-			Width:  config.NoteWidth,
-			Height: config.NoteHeight,
-		},
-		BackgroundColor: "#DC143C", // Crimson for errors
-		TextColor:       "#FFFFFF",
-		Text:            errorText,
+// handleAIError creates an error note on the canvas to inform the user of processing failures.
+// The note shows the error's code and remediation hint, and the failure is
+// filed under the same code in the error log.
+func handleAIError(ctx context.Context, client *canvusapi.Client, repo *db.Repository, correlationID string, update Update, err error, baseText string, config *core.Config, log *logging.Logger) error {
+	info := errs.Describe(err)
+	widgetID, _ := update["id"].(string)
+	recordErrorLog(ctx, repo, correlationID, config.CanvasID, widgetID, info, err, log)
+
+	errorText := i18n.T(config.Language, i18n.MsgError, err) + "\n\n" +
+		i18n.T(config.Language, i18n.MsgErrorCode, info.Code, info.Hint)
+	if baseText != "" {
+		errorText = baseText + "\n\n" + errorText
 	}
 
-	result, err := client.CreateNote(note)
+	// The error note goes to the right of the trigger, in the error colors
+	theme := widgettemplates.ThemeFromConfig(config)
+	widgets := widgettemplates.Response.Instantiate(
+		widgettemplates.Content{Body: errorText},
+		widgettemplates.Beside(handlers.WidgetBounds(update, nil)),
+		theme.WithBody(widgettemplates.Style{BackgroundColor: widgettemplates.ErrorColor, TextColor: widgettemplates.ErrorTextColor}),
+	)
+	created, err := widgettemplates.Create(client, widgets)
 	if err != nil {
 		return fmt.Errorf("failed to create error note: %w", err)
 	}
 
 	log.Debug("error note created",
-		zap.String("note_id", result.ID))
+		zap.String("note_id", widgettemplates.ID(created, widgettemplates.RoleBody)))
 
 	return nil
 }
@@ -0,0 +1,173 @@
+// Package handlers provides image comparison atoms for the image analysis icon.
+package handlers
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// ErrNoComparisonPair is returned when the analysis icon is not anchored
+// across two images or onto a connector between two images.
+var ErrNoComparisonPair = errors.New("no image pair found for comparison")
+
+// ImageComparisonPrompt instructs the vision model how to compare the two
+// halves of a side-by-side composite image.
+const ImageComparisonPrompt = `The picture contains two images placed side by side: ` +
+	`the LEFT image and the RIGHT image, separated by a white gap. ` +
+	`Compare them for a design review. ` +
+	`First list the key similarities, then list the differences (layout, color, content, text, style). ` +
+	`Finish with a one-sentence overall verdict. ` +
+	`Use short markdown bullet points under the headings "Similarities", "Differences" and "Verdict".`
+
+// Rect is an axis-aligned rectangle in canvas coordinates.
+type Rect struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// Intersect returns the area shared by two rectangles (0 if they don't overlap).
+func (r Rect) Intersect(o Rect) float64 {
+	w := minFloat(r.X+r.Width, o.X+o.Width) - maxFloat(r.X, o.X)
+	h := minFloat(r.Y+r.Height, o.Y+o.Height) - maxFloat(r.Y, o.Y)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	return w * h
+}
+
+// WidgetBounds returns a widget's rectangle in canvas coordinates.
+// If parent is non-nil, the widget location and size are treated as relative
+// to the parent (scaled by the parent's scale), as Canvus reports them for
+// anchored children.
+//
+// This is a pure function (atom) with no external dependencies.
+func WidgetBounds(widget Update, parent Update) Rect {
+	loc := ExtractLocation(GetMapField(widget, "location"))
+	size := ExtractSize(GetMapField(widget, "size"))
+	scale := widgetScale(widget)
+	bounds := Rect{X: loc.X, Y: loc.Y, Width: size.Width * scale, Height: size.Height * scale}
+
+	if parent == nil {
+		return bounds
+	}
+
+	parentLoc := ExtractLocation(GetMapField(parent, "location"))
+	parentScale := widgetScale(parent)
+	return Rect{
+		X:      parentLoc.X + bounds.X*parentScale,
+		Y:      parentLoc.Y + bounds.Y*parentScale,
+		Width:  bounds.Width * parentScale,
+		Height: bounds.Height * parentScale,
+	}
+}
+
+// FindComparisonImages resolves the two images an analysis icon refers to.
+//
+// Two placements are recognised:
+//   - the icon is anchored onto a Connector whose source and destination are both images
+//   - the icon overlaps two Image widgets (e.g. straddling the gap between them)
+//
+// Returns the image IDs in left-to-right order, or ErrNoComparisonPair.
+//
+// This is a pure function (atom) with no external dependencies.
+func FindComparisonImages(icon Update, widgets []Update) ([]string, error) {
+	iconID := GetStringField(icon, "id", "")
+	parentID := GetStringField(icon, "parent_id", GetStringField(icon, "parentId", ""))
+
+	byID := make(map[string]Update, len(widgets))
+	for _, w := range widgets {
+		if id := GetStringField(w, "id", ""); id != "" {
+			byID[id] = w
+		}
+	}
+
+	if parent, ok := byID[parentID]; ok && isWidgetType(parent, "Connector") {
+		srcID := GetStringField(GetMapField(parent, "src"), "id", "")
+		dstID := GetStringField(GetMapField(parent, "dst"), "id", "")
+		src, dst := byID[srcID], byID[dstID]
+		if isComparableImage(src, iconID) && isComparableImage(dst, iconID) && srcID != dstID {
+			return orderLeftToRight(byID, srcID, dstID), nil
+		}
+		return nil, ErrNoComparisonPair
+	}
+
+	var parent Update
+	if p, ok := byID[parentID]; ok && !isWidgetType(p, "Canvas") {
+		parent = p
+	}
+	iconBounds := WidgetBounds(icon, parent)
+
+	type candidate struct {
+		id      string
+		overlap float64
+	}
+	var candidates []candidate
+	for id, w := range byID {
+		if !isComparableImage(w, iconID) {
+			continue
+		}
+		if overlap := iconBounds.Intersect(WidgetBounds(w, nil)); overlap > 0 {
+			candidates = append(candidates, candidate{id: id, overlap: overlap})
+		}
+	}
+	if len(candidates) < 2 {
+		return nil, ErrNoComparisonPair
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].overlap != candidates[j].overlap {
+			return candidates[i].overlap > candidates[j].overlap
+		}
+		return candidates[i].id < candidates[j].id
+	})
+	return orderLeftToRight(byID, candidates[0].id, candidates[1].id), nil
+}
+
+// isComparableImage reports whether w is an image other than an AI icon.
+func isComparableImage(w Update, iconID string) bool {
+	if w == nil || !isWidgetType(w, "Image") {
+		return false
+	}
+	if GetStringField(w, "id", "") == iconID {
+		return false
+	}
+	return !strings.HasPrefix(GetStringField(w, "title", ""), "AI_Icon_")
+}
+
+// isWidgetType checks both the "widget_type" (stream) and "type" (REST) fields.
+func isWidgetType(w Update, widgetType string) bool {
+	return GetStringField(w, "widget_type", GetStringField(w, "type", "")) == widgetType
+}
+
+// orderLeftToRight returns the two IDs sorted by their x coordinate.
+func orderLeftToRight(byID map[string]Update, a, b string) []string {
+	if WidgetBounds(byID[b], nil).X < WidgetBounds(byID[a], nil).X {
+		a, b = b, a
+	}
+	return []string{a, b}
+}
+
+// widgetScale returns the widget's scale, defaulting to 1.
+func widgetScale(w Update) float64 {
+	if s, ok := w["scale"].(float64); ok && s > 0 {
+		return s
+	}
+	return 1
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,118 @@
+package handlers_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"go_backend/handlers"
+)
+
+func widgetAt(id, widgetType string, x, y, w, h float64) handlers.Update {
+	return handlers.Update{
+		"id":          id,
+		"widget_type": widgetType,
+		"location":    map[string]interface{}{"x": x, "y": y},
+		"size":        map[string]interface{}{"width": w, "height": h},
+		"scale":       1.0,
+	}
+}
+
+func TestRectIntersect(t *testing.T) {
+	a := handlers.Rect{X: 0, Y: 0, Width: 10, Height: 10}
+
+	if got := a.Intersect(handlers.Rect{X: 5, Y: 5, Width: 10, Height: 10}); got != 25 {
+		t.Errorf("Intersect() overlapping = %v, want 25", got)
+	}
+	if got := a.Intersect(handlers.Rect{X: 20, Y: 0, Width: 5, Height: 5}); got != 0 {
+		t.Errorf("Intersect() disjoint = %v, want 0", got)
+	}
+	if got := a.Intersect(handlers.Rect{X: 10, Y: 0, Width: 5, Height: 5}); got != 0 {
+		t.Errorf("Intersect() touching edge = %v, want 0", got)
+	}
+}
+
+func TestWidgetBoundsRelativeToParent(t *testing.T) {
+	parent := widgetAt("p", "Image", 100, 200, 400, 300)
+	parent["scale"] = 2.0
+	child := widgetAt("c", "Image", 10, 20, 50, 50)
+
+	got := handlers.WidgetBounds(child, parent)
+	want := handlers.Rect{X: 120, Y: 240, Width: 100, Height: 100}
+	if got != want {
+		t.Errorf("WidgetBounds() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFindComparisonImagesOverlap(t *testing.T) {
+	left := widgetAt("left", "Image", 0, 0, 100, 100)
+	right := widgetAt("right", "Image", 120, 0, 100, 100)
+	far := widgetAt("far", "Image", 1000, 1000, 100, 100)
+	icon := widgetAt("icon", "Image", 80, 40, 60, 20)
+	icon["title"] = "AI_Icon_Image_Analysis"
+
+	got, err := handlers.FindComparisonImages(icon, []handlers.Update{right, far, left, icon})
+	if err != nil {
+		t.Fatalf("FindComparisonImages() unexpected error: %v", err)
+	}
+	if want := []string{"left", "right"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindComparisonImages() = %v, want %v", got, want)
+	}
+}
+
+func TestFindComparisonImagesConnector(t *testing.T) {
+	a := widgetAt("a", "Image", 500, 0, 100, 100)
+	b := widgetAt("b", "Image", 0, 0, 100, 100)
+	conn := handlers.Update{
+		"id":          "conn",
+		"widget_type": "Connector",
+		"src":         map[string]interface{}{"id": "a"},
+		"dst":         map[string]interface{}{"id": "b"},
+	}
+	icon := widgetAt("icon", "Image", 0, 0, 10, 10)
+	icon["parent_id"] = "conn"
+
+	got, err := handlers.FindComparisonImages(icon, []handlers.Update{a, b, conn})
+	if err != nil {
+		t.Fatalf("FindComparisonImages() unexpected error: %v", err)
+	}
+	if want := []string{"b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindComparisonImages() = %v, want %v", got, want)
+	}
+}
+
+func TestFindComparisonImagesNoPair(t *testing.T) {
+	single := widgetAt("single", "Image", 0, 0, 100, 100)
+	note := widgetAt("note", "Note", 100, 0, 100, 100)
+	otherIcon := widgetAt("other", "Image", 90, 0, 20, 20)
+	otherIcon["title"] = "AI_Icon_PDFPrecis"
+	icon := widgetAt("icon", "Image", 90, 10, 20, 20)
+
+	tests := []struct {
+		name    string
+		icon    handlers.Update
+		widgets []handlers.Update
+	}{
+		{"single image", icon, []handlers.Update{single, note}},
+		{"ignores other icons", icon, []handlers.Update{single, otherIcon}},
+		{
+			"connector to note",
+			handlers.Update{"id": "icon", "parent_id": "conn"},
+			[]handlers.Update{single, note, {
+				"id":          "conn",
+				"widget_type": "Connector",
+				"src":         map[string]interface{}{"id": "single"},
+				"dst":         map[string]interface{}{"id": "note"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handlers.FindComparisonImages(tt.icon, tt.widgets)
+			if !errors.Is(err, handlers.ErrNoComparisonPair) {
+				t.Errorf("FindComparisonImages() error = %v, want ErrNoComparisonPair", err)
+			}
+		})
+	}
+}
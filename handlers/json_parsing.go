@@ -3,21 +3,22 @@ package handlers
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strings"
+
+	"go_backend/core/errs"
 )
 
 // JSON parsing errors
 var (
 	// ErrNoJSONFound is returned when no JSON object is found in the text.
-	ErrNoJSONFound = errors.New("no JSON object found in text")
+	ErrNoJSONFound = errs.New(errs.CodeBadAIResponse, "no JSON object found in text")
 	// ErrInvalidJSON is returned when JSON parsing fails.
-	ErrInvalidJSON = errors.New("invalid JSON")
+	ErrInvalidJSON = errs.New(errs.CodeBadAIResponse, "invalid JSON")
 	// ErrMissingContentField is returned when the required "content" field is missing.
-	ErrMissingContentField = errors.New("missing 'content' field")
+	ErrMissingContentField = errs.New(errs.CodeBadAIResponse, "missing 'content' field")
 	// ErrMissingTypeField is returned when the required "type" field is missing.
-	ErrMissingTypeField = errors.New("missing 'type' field")
+	ErrMissingTypeField = errs.New(errs.CodeBadAIResponse, "missing 'type' field")
 )
 
 // AIResponse represents the expected structure of an AI response.
@@ -0,0 +1,173 @@
+// Package handlers provides JSON repair atoms for malformed AI responses.
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Note response types.
+const (
+	NoteResponseText  = "text"
+	NoteResponseImage = "image"
+)
+
+// RepairJSON repairs the common ways a model breaks a JSON object: prose or
+// code fences around it, smart quotes used as string delimiters, trailing
+// commas, raw newlines and tabs inside strings, and an object cut off before
+// its closing quotes and brackets. Text after the object is dropped. It
+// returns the repaired object, or an error if it still is not valid JSON.
+//
+// This is a pure function (atom) with no external dependencies.
+//
+// Example:
+//
+//	fixed, err := handlers.RepairJSON("```json\n{\"type\": \"text\", \"content\": \"hi\",}\n```")
+//	// fixed == `{"type": "text", "content": "hi"}`
+func RepairJSON(text string) (string, error) {
+	start := strings.Index(text, "{")
+	if start == -1 {
+		return "", ErrNoJSONFound
+	}
+	text = text[start:]
+	if !strings.Contains(text, `"`) {
+		text = strings.NewReplacer("“", `"`, "”", `"`).Replace(text)
+	}
+
+	var out strings.Builder
+	var stack []byte
+	inString, escaped := false, false
+scan:
+	for _, r := range text {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case r == '\\':
+				escaped = true
+			case r == '"':
+				inString = false
+			case r == '\n':
+				out.WriteString(`\n`)
+				continue
+			case r == '\r':
+				out.WriteString(`\r`)
+				continue
+			case r == '\t':
+				out.WriteString(`\t`)
+				continue
+			}
+			out.WriteRune(r)
+			continue
+		}
+
+		switch r {
+		case '"':
+			inString = true
+		case '{':
+			stack = append(stack, '}')
+		case '[':
+			stack = append(stack, ']')
+		case '}', ']':
+			if len(stack) == 0 || stack[len(stack)-1] != byte(r) {
+				return "", fmt.Errorf("%w: unexpected %q", ErrInvalidJSON, r)
+			}
+			trimTrailingComma(&out)
+			stack = stack[:len(stack)-1]
+			out.WriteRune(r)
+			if len(stack) == 0 {
+				break scan
+			}
+			continue
+		}
+		out.WriteRune(r)
+	}
+
+	// Close whatever a cut-off response left open
+	if inString {
+		if escaped {
+			out.WriteString(`\`)
+		}
+		out.WriteString(`"`)
+	}
+	trimTrailingComma(&out)
+	if strings.HasSuffix(out.String(), ":") {
+		out.WriteString("null")
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		out.WriteByte(stack[i])
+	}
+
+	repaired := out.String()
+	if !json.Valid([]byte(repaired)) {
+		return "", fmt.Errorf("%w: could not be repaired", ErrInvalidJSON)
+	}
+	return repaired, nil
+}
+
+// trimTrailingComma removes a comma (and the whitespace after it) from the
+// end of the text written so far.
+func trimTrailingComma(out *strings.Builder) {
+	s := strings.TrimRight(out.String(), " \t\r\n")
+	if !strings.HasSuffix(s, ",") {
+		return
+	}
+	s = strings.TrimSuffix(s, ",")
+	out.Reset()
+	out.WriteString(s)
+}
+
+// ValidateNoteResponse checks that a parsed note response matches the note
+// schema: type is "text" or "image" and content is not empty. The type is
+// normalised to lower case.
+//
+// This is a pure function (atom) with no external dependencies.
+func ValidateNoteResponse(resp *AIResponse) error {
+	resp.Type = strings.ToLower(strings.TrimSpace(resp.Type))
+	if resp.Type != NoteResponseText && resp.Type != NoteResponseImage {
+		return fmt.Errorf("%w: type must be %q or %q, got %q", ErrInvalidJSON, NoteResponseText, NoteResponseImage, resp.Type)
+	}
+	if strings.TrimSpace(resp.Content) == "" {
+		return fmt.Errorf("%w: content is empty", ErrInvalidJSON)
+	}
+	return nil
+}
+
+// ParseNoteResponse parses the JSON reply to a note and validates it with
+// ValidateNoteResponse. A reply that is not valid JSON as written is
+// repaired with RepairJSON first; repaired reports whether that was needed.
+// The returned error describes what is wrong with the reply, so it can be
+// shown to the model when asking it to try again.
+//
+// This is a pure function (atom) with no external dependencies.
+//
+// Example:
+//
+//	resp, repaired, err := handlers.ParseNoteResponse(rawText)
+//	if err != nil {
+//	    // treat rawText as a plain text answer
+//	}
+func ParseNoteResponse(text string) (resp *AIResponse, repaired bool, err error) {
+	jsonStr := strings.TrimSpace(text)
+	data, err := ParseJSONToMap(jsonStr)
+	if err != nil {
+		fixed, repairErr := RepairJSON(jsonStr)
+		if repairErr != nil {
+			return nil, false, err
+		}
+		if data, err = ParseJSONToMap(fixed); err != nil {
+			return nil, false, err
+		}
+		repaired = true
+	}
+
+	if err := ValidateAIResponseFields(data); err != nil {
+		return nil, repaired, err
+	}
+	resp = &AIResponse{Type: data["type"].(string), Content: data["content"].(string)}
+	if err := ValidateNoteResponse(resp); err != nil {
+		return nil, repaired, err
+	}
+	return resp, repaired, nil
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRepairJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "code fence and prose",
+			text: "Sure! ```json\n{\"type\": \"text\", \"content\": \"hi\"}\n``` Hope that helps {:}",
+			want: `{"type": "text", "content": "hi"}`,
+		},
+		{
+			name: "trailing commas",
+			text: `{"items": [1, 2, ], "content": "hi", }`,
+			want: `{"items": [1, 2], "content": "hi"}`,
+		},
+		{
+			name: "raw newline and tab in string",
+			text: "{\"content\": \"line one\nline\ttwo\"}",
+			want: `{"content": "line one\nline\ttwo"}`,
+		},
+		{
+			name: "smart quotes",
+			text: `{“type”: “text”, “content”: “hi”}`,
+			want: `{"type": "text", "content": "hi"}`,
+		},
+		{
+			name: "cut off in a string",
+			text: `{"type": "text", "content": "a long answ`,
+			want: `{"type": "text", "content": "a long answ"}`,
+		},
+		{
+			name: "cut off after a key",
+			text: `{"type": "text", "content":`,
+			want: `{"type": "text", "content":null}`,
+		},
+		{
+			name: "cut off in an array",
+			text: `{"series": [{"name": "a", "points": [1, 2,`,
+			want: `{"series": [{"name": "a", "points": [1, 2]}]}`,
+		},
+		{
+			name: "braces inside strings",
+			text: `{"content": "use } and ] freely",}`,
+			want: `{"content": "use } and ] freely"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RepairJSON(tt.text)
+			if err != nil {
+				t.Fatalf("RepairJSON() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RepairJSON() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepairJSONErrors(t *testing.T) {
+	if _, err := RepairJSON("no json here"); !errors.Is(err, ErrNoJSONFound) {
+		t.Errorf("plain text: error = %v, want ErrNoJSONFound", err)
+	}
+	for _, text := range []string{`{"a": [1}`, `{type: text}`} {
+		if _, err := RepairJSON(text); !errors.Is(err, ErrInvalidJSON) {
+			t.Errorf("RepairJSON(%q) error = %v, want ErrInvalidJSON", text, err)
+		}
+	}
+}
+
+func TestParseNoteResponse(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantType     string
+		wantContent  string
+		wantRepaired bool
+	}{
+		{"valid", `{"type": "text", "content": "hi"}`, "text", "hi", false},
+		{"type is normalised", ` {"type": " Image ", "content": "a fox"} `, "image", "a fox", false},
+		{"repaired", "```json\n{\"type\": \"text\", \"content\": \"hi\",}\n```", "text", "hi", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, repaired, err := ParseNoteResponse(tt.text)
+			if err != nil {
+				t.Fatalf("ParseNoteResponse() error = %v", err)
+			}
+			if resp.Type != tt.wantType || resp.Content != tt.wantContent || repaired != tt.wantRepaired {
+				t.Errorf("ParseNoteResponse() = %+v, repaired %v", resp, repaired)
+			}
+		})
+	}
+}
+
+func TestParseNoteResponseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr error
+	}{
+		{"plain text", "Just an answer.", ErrInvalidJSON},
+		{"missing type", `{"content": "hi"}`, ErrMissingTypeField},
+		{"missing content", `{"type": "text"}`, ErrMissingContentField},
+		{"unknown type", `{"type": "video", "content": "hi"}`, ErrInvalidJSON},
+		{"empty content", `{"type": "text", "content": "  "}`, ErrInvalidJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := ParseNoteResponse(tt.text); !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseNoteResponse() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
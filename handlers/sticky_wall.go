@@ -0,0 +1,61 @@
+// Package handlers provides sticky-note wall digitization atoms.
+package handlers
+
+import (
+	"strings"
+)
+
+// StickyNoteTranscriptionPrompt asks the vision model to read a single cropped sticky note.
+const StickyNoteTranscriptionPrompt = `This image is a single photographed sticky note. ` +
+	`Transcribe the handwritten or printed text on it exactly, keeping line breaks. ` +
+	`If there is no readable text, respond with an empty string. ` +
+	`Do not describe the note and do not include any additional text or explanations.`
+
+// PixelRect is a rectangle in source image pixels.
+type PixelRect struct {
+	MinX int
+	MinY int
+	MaxX int
+	MaxY int
+}
+
+// ScalePixelRect maps a pixel rectangle from an image of imageWidth x imageHeight
+// into the given canvas area, preserving relative position and size.
+// Returns a zero Rect if the image dimensions are unknown.
+//
+// This is a pure function (atom) with no external dependencies.
+func ScalePixelRect(px PixelRect, imageWidth, imageHeight int, area Rect) Rect {
+	if imageWidth <= 0 || imageHeight <= 0 {
+		return Rect{}
+	}
+	sx := area.Width / float64(imageWidth)
+	sy := area.Height / float64(imageHeight)
+	return Rect{
+		X:      area.X + float64(px.MinX)*sx,
+		Y:      area.Y + float64(px.MinY)*sy,
+		Width:  float64(px.MaxX-px.MinX) * sx,
+		Height: float64(px.MaxY-px.MinY) * sy,
+	}
+}
+
+// CleanTranscription normalises a model transcription of a sticky note:
+// strips surrounding quotes and code fences and collapses blank lines.
+//
+// This is a pure function (atom) with no external dependencies.
+func CleanTranscription(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.TrimPrefix(text, "```")
+	text = strings.TrimSuffix(text, "```")
+	text = strings.TrimSpace(text)
+	if len(text) >= 2 && text[0] == '"' && text[len(text)-1] == '"' {
+		text = text[1 : len(text)-1]
+	}
+
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,44 @@
+package handlers_test
+
+import (
+	"testing"
+
+	"go_backend/handlers"
+)
+
+func TestScalePixelRect(t *testing.T) {
+	area := handlers.Rect{X: 1000, Y: 500, Width: 400, Height: 300}
+	px := handlers.PixelRect{MinX: 200, MinY: 150, MaxX: 400, MaxY: 300}
+
+	got := handlers.ScalePixelRect(px, 800, 600, area)
+	want := handlers.Rect{X: 1100, Y: 575, Width: 100, Height: 75}
+	if got != want {
+		t.Errorf("ScalePixelRect() = %+v, want %+v", got, want)
+	}
+
+	if got := handlers.ScalePixelRect(px, 0, 600, area); got != (handlers.Rect{}) {
+		t.Errorf("ScalePixelRect() with unknown size = %+v, want zero", got)
+	}
+}
+
+func TestCleanTranscription(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"plain", "Buy milk", "Buy milk"},
+		{"quoted", `"Ship v2"`, "Ship v2"},
+		{"fenced", "```\nIdea A\n```", "Idea A"},
+		{"blank lines", "  Line 1 \n\n\n Line 2  ", "Line 1\nLine 2"},
+		{"empty", "   ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := handlers.CleanTranscription(tt.in); got != tt.want {
+				t.Errorf("CleanTranscription(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+// Package handlers provides structured image extraction atoms (charts and diagrams).
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Structured extraction kinds returned by the vision model.
+const (
+	ExtractionKindChart   = "chart"
+	ExtractionKindDiagram = "diagram"
+)
+
+// ErrUnknownExtractionKind is returned when the model response is neither a chart nor a diagram.
+var ErrUnknownExtractionKind = errors.New("unknown extraction kind")
+
+// StructuredExtractionPrompt asks the vision model to return chart data or a
+// box-and-arrow diagram as JSON so it can be rebuilt as canvas widgets.
+const StructuredExtractionPrompt = `Extract the structured content of this image and respond with JSON only. ` +
+	`If the image is a chart or graph, respond with: ` +
+	`{"kind": "chart", "chart": {"title": "...", "chart_type": "bar|line|pie|scatter|other", "x_axis": "...", "y_axis": "...", ` +
+	`"series": [{"name": "...", "points": [{"label": "...", "value": 0}]}]}}. ` +
+	`If the image is a diagram, whiteboard sketch, or flowchart, respond with: ` +
+	`{"kind": "diagram", "diagram": {"nodes": [{"id": "n1", "label": "...", "x": 0.0, "y": 0.0}], ` +
+	`"edges": [{"from": "n1", "to": "n2", "label": ""}]}}. ` +
+	`For diagram nodes, x and y are the centre of each box as fractions (0 to 1) of the image width and height. ` +
+	`Use the exact text written in the image. Do not include any additional text or explanations.`
+
+// ChartPoint is a single labelled value in a chart series.
+type ChartPoint struct {
+	Label string  `json:"label"`
+	Value float64 `json:"value"`
+}
+
+// ChartSeries is one named data series of a chart.
+type ChartSeries struct {
+	Name   string       `json:"name"`
+	Points []ChartPoint `json:"points"`
+}
+
+// ChartData is the data extracted from a chart image.
+type ChartData struct {
+	Title     string        `json:"title"`
+	ChartType string        `json:"chart_type"`
+	XAxis     string        `json:"x_axis"`
+	YAxis     string        `json:"y_axis"`
+	Series    []ChartSeries `json:"series"`
+}
+
+// DiagramNode is a box in a diagram. X and Y are normalised (0-1) image coordinates.
+type DiagramNode struct {
+	ID    string  `json:"id"`
+	Label string  `json:"label"`
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+}
+
+// DiagramEdge is an arrow between two diagram nodes.
+type DiagramEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label"`
+}
+
+// DiagramData is the box-and-arrow structure extracted from a diagram image.
+type DiagramData struct {
+	Nodes []DiagramNode `json:"nodes"`
+	Edges []DiagramEdge `json:"edges"`
+}
+
+// StructuredExtraction is the parsed response to StructuredExtractionPrompt.
+// Exactly one of Chart or Diagram is set, matching Kind.
+type StructuredExtraction struct {
+	Kind    string       `json:"kind"`
+	Chart   *ChartData   `json:"chart,omitempty"`
+	Diagram *DiagramData `json:"diagram,omitempty"`
+	// Repaired reports whether the response was malformed JSON that
+	// RepairJSON fixed
+	Repaired bool `json:"-"`
+}
+
+// ParseStructuredExtraction parses a vision model response into a StructuredExtraction.
+// Surrounding prose and code fences are ignored, and malformed JSON is
+// repaired with RepairJSON. Diagram nodes are clamped to the 0-1 range and
+// edges referencing unknown nodes are dropped.
+//
+// This is a pure function (atom) with no external dependencies.
+func ParseStructuredExtraction(text string) (*StructuredExtraction, error) {
+	jsonStr, err := ExtractJSONFromText(text)
+	if err != nil {
+		if !strings.Contains(text, "{") {
+			return nil, err
+		}
+		// A response cut off before its closing brace can still be repaired
+		jsonStr = text
+	}
+
+	var result StructuredExtraction
+	if err := json.Unmarshal([]byte(jsonStr), &result); err != nil {
+		fixed, repairErr := RepairJSON(text)
+		if repairErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+		}
+		result = StructuredExtraction{Repaired: true}
+		if err := json.Unmarshal([]byte(fixed), &result); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+		}
+	}
+
+	result.Kind = strings.ToLower(strings.TrimSpace(result.Kind))
+	switch result.Kind {
+	case ExtractionKindChart:
+		if result.Chart == nil {
+			return nil, fmt.Errorf("%w: chart response has no chart data", ErrInvalidJSON)
+		}
+		result.Diagram = nil
+	case ExtractionKindDiagram:
+		if result.Diagram == nil || len(result.Diagram.Nodes) == 0 {
+			return nil, fmt.Errorf("%w: diagram response has no nodes", ErrInvalidJSON)
+		}
+		result.Chart = nil
+		sanitizeDiagram(result.Diagram)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownExtractionKind, result.Kind)
+	}
+
+	return &result, nil
+}
+
+// FormatChartMarkdown renders chart data as a markdown table for a canvas note.
+// Each series becomes a column; rows are the union of point labels in first-seen order.
+//
+// This is a pure function (atom) with no external dependencies.
+func FormatChartMarkdown(chart *ChartData) string {
+	var sb strings.Builder
+
+	title := chart.Title
+	if title == "" {
+		title = "Chart data"
+	}
+	sb.WriteString("# " + title + "\n\n")
+	if chart.XAxis != "" || chart.YAxis != "" {
+		fmt.Fprintf(&sb, "X axis: %s\nY axis: %s\n\n", orDash(chart.XAxis), orDash(chart.YAxis))
+	}
+
+	var labels []string
+	values := make(map[string]map[int]float64)
+	for i, series := range chart.Series {
+		for _, p := range series.Points {
+			if _, seen := values[p.Label]; !seen {
+				labels = append(labels, p.Label)
+				values[p.Label] = make(map[int]float64)
+			}
+			values[p.Label][i] = p.Value
+		}
+	}
+
+	sb.WriteString("| " + orDash(chart.XAxis) + " |")
+	for i, series := range chart.Series {
+		name := series.Name
+		if name == "" {
+			name = fmt.Sprintf("Series %d", i+1)
+		}
+		sb.WriteString(" " + name + " |")
+	}
+	sb.WriteString("\n|---|")
+	for range chart.Series {
+		sb.WriteString("---|")
+	}
+	sb.WriteString("\n")
+
+	for _, label := range labels {
+		sb.WriteString("| " + label + " |")
+		for i := range chart.Series {
+			if v, ok := values[label][i]; ok {
+				sb.WriteString(" " + strconv.FormatFloat(v, 'f', -1, 64) + " |")
+			} else {
+				sb.WriteString("  |")
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// DiagramNodeLocation maps a normalised diagram node position into canvas
+// coordinates within the given area, returning the top-left corner for a
+// note of noteSize centred on the node.
+//
+// This is a pure function (atom) with no external dependencies.
+func DiagramNodeLocation(node DiagramNode, area Rect, noteSize NoteSize) Location {
+	return Location{
+		X: area.X + node.X*area.Width - noteSize.Width/2,
+		Y: area.Y + node.Y*area.Height - noteSize.Height/2,
+	}
+}
+
+// sanitizeDiagram clamps node positions, fills missing IDs, and drops dangling edges.
+func sanitizeDiagram(d *DiagramData) {
+	ids := make(map[string]bool, len(d.Nodes))
+	for i := range d.Nodes {
+		n := &d.Nodes[i]
+		if n.ID == "" {
+			n.ID = fmt.Sprintf("n%d", i+1)
+		}
+		n.X = clamp01(n.X)
+		n.Y = clamp01(n.Y)
+		ids[n.ID] = true
+	}
+
+	edges := d.Edges[:0]
+	for _, e := range d.Edges {
+		if ids[e.From] && ids[e.To] && e.From != e.To {
+			edges = append(edges, e)
+		}
+	}
+	d.Edges = edges
+}
+
+func clamp01(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 1 {
+		return 1
+	}
+	return v
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
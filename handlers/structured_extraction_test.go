@@ -0,0 +1,120 @@
+package handlers_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go_backend/handlers"
+)
+
+func TestParseStructuredExtractionChart(t *testing.T) {
+	text := "Here you go:\n```json\n" + `{"kind": "Chart", "chart": {"title": "Sales", "x_axis": "Quarter", "y_axis": "USD",
+	"series": [{"name": "2024", "points": [{"label": "Q1", "value": 10}, {"label": "Q2", "value": 12.5}]}]}}` + "\n```"
+
+	got, err := handlers.ParseStructuredExtraction(text)
+	if err != nil {
+		t.Fatalf("ParseStructuredExtraction() unexpected error: %v", err)
+	}
+	if got.Kind != handlers.ExtractionKindChart || got.Chart == nil {
+		t.Fatalf("ParseStructuredExtraction() = %+v, want chart", got)
+	}
+	if got.Chart.Title != "Sales" || len(got.Chart.Series[0].Points) != 2 {
+		t.Errorf("chart = %+v, want title Sales with 2 points", got.Chart)
+	}
+}
+
+func TestParseStructuredExtractionDiagram(t *testing.T) {
+	text := `{"kind": "diagram", "diagram": {
+		"nodes": [{"id": "a", "label": "Start", "x": -0.2, "y": 0.5}, {"label": "End", "x": 1.4, "y": 0.5}],
+		"edges": [{"from": "a", "to": "n2"}, {"from": "a", "to": "missing"}, {"from": "a", "to": "a"}]}}`
+
+	got, err := handlers.ParseStructuredExtraction(text)
+	if err != nil {
+		t.Fatalf("ParseStructuredExtraction() unexpected error: %v", err)
+	}
+	d := got.Diagram
+	if d == nil || len(d.Nodes) != 2 {
+		t.Fatalf("diagram = %+v, want 2 nodes", d)
+	}
+	if d.Nodes[0].X != 0 || d.Nodes[1].X != 1 {
+		t.Errorf("node x = %v, %v, want clamped to 0 and 1", d.Nodes[0].X, d.Nodes[1].X)
+	}
+	if d.Nodes[1].ID != "n2" {
+		t.Errorf("missing node ID = %q, want n2", d.Nodes[1].ID)
+	}
+	if len(d.Edges) != 1 || d.Edges[0].To != "n2" {
+		t.Errorf("edges = %+v, want only a->n2", d.Edges)
+	}
+}
+
+func TestParseStructuredExtractionRepaired(t *testing.T) {
+	text := "```json\n{\"kind\": \"diagram\", \"diagram\": {\"nodes\": [{\"id\": \"n1\", \"label\": \"Start\", \"x\": 0.2, \"y\": 0.5},], \"edges\": ["
+
+	got, err := handlers.ParseStructuredExtraction(text)
+	if err != nil {
+		t.Fatalf("ParseStructuredExtraction() error = %v", err)
+	}
+	if !got.Repaired || got.Kind != handlers.ExtractionKindDiagram || len(got.Diagram.Nodes) != 1 {
+		t.Errorf("ParseStructuredExtraction() = %+v, want a repaired diagram with one node", got)
+	}
+}
+
+func TestParseStructuredExtractionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr error
+	}{
+		{"no json", "I cannot read this image", handlers.ErrNoJSONFound},
+		{"invalid json", "{kind: chart}", handlers.ErrInvalidJSON},
+		{"unknown kind", `{"kind": "photo"}`, handlers.ErrUnknownExtractionKind},
+		{"chart without data", `{"kind": "chart"}`, handlers.ErrInvalidJSON},
+		{"diagram without nodes", `{"kind": "diagram", "diagram": {"nodes": []}}`, handlers.ErrInvalidJSON},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := handlers.ParseStructuredExtraction(tt.text)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ParseStructuredExtraction() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFormatChartMarkdown(t *testing.T) {
+	chart := &handlers.ChartData{
+		Title: "Revenue",
+		XAxis: "Month",
+		YAxis: "EUR",
+		Series: []handlers.ChartSeries{
+			{Name: "North", Points: []handlers.ChartPoint{{Label: "Jan", Value: 1}, {Label: "Feb", Value: 2}}},
+			{Points: []handlers.ChartPoint{{Label: "Feb", Value: 3.5}}},
+		},
+	}
+
+	got := handlers.FormatChartMarkdown(chart)
+
+	for _, want := range []string{
+		"# Revenue",
+		"| Month | North | Series 2 |",
+		"| Jan | 1 |  |",
+		"| Feb | 2 | 3.5 |",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("FormatChartMarkdown() missing %q in:\n%s", want, got)
+		}
+	}
+}
+
+func TestDiagramNodeLocation(t *testing.T) {
+	area := handlers.Rect{X: 100, Y: 200, Width: 1000, Height: 500}
+	node := handlers.DiagramNode{X: 0.5, Y: 0.2}
+
+	got := handlers.DiagramNodeLocation(node, area, handlers.NoteSize{Width: 100, Height: 60})
+	want := handlers.Location{X: 550, Y: 270}
+	if got != want {
+		t.Errorf("DiagramNodeLocation() = %+v, want %+v", got, want)
+	}
+}
@@ -3,10 +3,25 @@ package handlers
 
 import (
 	"strings"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 )
 
+// correlationIDs, if set, replaces the random correlation IDs.
+var correlationIDs atomic.Pointer[func() string]
+
+// SetCorrelationIDSource makes GenerateCorrelationID return the IDs of
+// next, e.g. a counter so that runs repeat (TEST_DETERMINISTIC). nil
+// restores random IDs.
+func SetCorrelationIDSource(next func() string) {
+	if next == nil {
+		correlationIDs.Store(nil)
+		return
+	}
+	correlationIDs.Store(&next)
+}
+
 // GenerateCorrelationID creates a unique 8-character ID for request tracing.
 // Uses UUID v4 and truncates to first 8 characters for brevity while
 // maintaining sufficient uniqueness for correlation purposes.
@@ -18,6 +33,9 @@ import (
 //	correlationID := handlers.GenerateCorrelationID()
 //	log.Printf("Processing request: %s", correlationID)
 func GenerateCorrelationID() string {
+	if next := correlationIDs.Load(); next != nil {
+		return (*next)()
+	}
 	return uuid.New().String()[:8]
 }
 
@@ -46,7 +64,7 @@ func TruncateText(text string, maxLength int) string {
 //	prompt := handlers.ExtractAIPrompt("{{Generate a haiku}}")
 //	// Returns: "Generate a haiku"
 func ExtractAIPrompt(noteText string) string {
-	return strings.ReplaceAll(strings.ReplaceAll(noteText, "{{", ""), "}}", "")
+	return DefaultTriggerSyntax.ExtractPrompt(noteText)
 }
 
 // HasAITrigger checks if text contains an AI trigger pattern ({{ }}).
@@ -60,7 +78,73 @@ func ExtractAIPrompt(noteText string) string {
 //	    processAIRequest(noteText)
 //	}
 func HasAITrigger(text string) bool {
-	return strings.Contains(text, "{{") && strings.Contains(text, "}}")
+	return DefaultTriggerSyntax.HasTrigger(text)
+}
+
+// TriggerSyntax is the pair of markers that wrap an AI prompt in a note.
+type TriggerSyntax struct {
+	Open  string
+	Close string
+}
+
+// DefaultTriggerSyntax is the {{ }} syntax.
+var DefaultTriggerSyntax = TriggerSyntax{Open: "{{", Close: "}}"}
+
+// NewTriggerSyntax returns the syntax with the given markers, or
+// DefaultTriggerSyntax if either marker is empty.
+//
+// This is a pure atom function.
+//
+// Example:
+//
+//	syntax := handlers.NewTriggerSyntax("[[", "]]")
+//	prompt := syntax.ExtractPrompt("[[Generate a haiku]]")
+func NewTriggerSyntax(open, close string) TriggerSyntax {
+	if open == "" || close == "" {
+		return DefaultTriggerSyntax
+	}
+	return TriggerSyntax{Open: open, Close: close}
+}
+
+// ExtractPrompt removes the trigger markers from note text.
+func (t TriggerSyntax) ExtractPrompt(noteText string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(noteText, t.Open, ""), t.Close, "")
+}
+
+// HasTrigger checks if text contains both trigger markers.
+func (t TriggerSyntax) HasTrigger(text string) bool {
+	return strings.Contains(text, t.Open) && strings.Contains(text, t.Close)
+}
+
+// Enclosed returns the trimmed text between the first opening marker and
+// the closing marker after it. ok is false if there is no such pair.
+func (t TriggerSyntax) Enclosed(text string) (content string, ok bool) {
+	start := strings.Index(text, t.Open)
+	if start == -1 {
+		return "", false
+	}
+	start += len(t.Open)
+	end := strings.Index(text[start:], t.Close)
+	if end == -1 {
+		return "", false
+	}
+	return strings.TrimSpace(text[start : start+end]), true
+}
+
+// Cut is like Enclosed but also returns the text around the trigger, with
+// the trigger and its markers removed.
+func (t TriggerSyntax) Cut(text string) (content, rest string, ok bool) {
+	start := strings.Index(text, t.Open)
+	if start == -1 {
+		return "", "", false
+	}
+	inner := start + len(t.Open)
+	end := strings.Index(text[inner:], t.Close)
+	if end == -1 {
+		return "", "", false
+	}
+	end += inner
+	return strings.TrimSpace(text[inner:end]), text[:start] + text[end+len(t.Close):], true
 }
 
 // IsAzureOpenAIEndpoint checks if an endpoint URL is an Azure OpenAI endpoint.
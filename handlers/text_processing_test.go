@@ -29,6 +29,23 @@ func TestGenerateCorrelationID(t *testing.T) {
 	}
 }
 
+func TestSetCorrelationIDSource(t *testing.T) {
+	n := 0
+	SetCorrelationIDSource(func() string {
+		n++
+		return strings.Repeat(string(rune('0'+n)), 8)
+	})
+	defer SetCorrelationIDSource(nil)
+	if a, b := GenerateCorrelationID(), GenerateCorrelationID(); a != "11111111" || b != "22222222" {
+		t.Errorf("GenerateCorrelationID() = %q, %q from the source", a, b)
+	}
+
+	SetCorrelationIDSource(nil)
+	if id := GenerateCorrelationID(); len(id) != 8 || id == "33333333" {
+		t.Errorf("GenerateCorrelationID() = %q after the source was cleared", id)
+	}
+}
+
 func TestTruncateText(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -184,6 +201,29 @@ func TestHasAITrigger(t *testing.T) {
 	}
 }
 
+func TestTriggerSyntax(t *testing.T) {
+	syntax := NewTriggerSyntax("[[", "]]")
+
+	if !syntax.HasTrigger("Please [[write a poem]]") || syntax.HasTrigger("{{write a poem}}") {
+		t.Error("HasTrigger() should only match the custom markers")
+	}
+	if got := syntax.ExtractPrompt("[[write a poem]]"); got != "write a poem" {
+		t.Errorf("ExtractPrompt() = %q", got)
+	}
+	if got, ok := syntax.Enclosed("note [[ image: a cat ]] and [[more]]"); !ok || got != "image: a cat" {
+		t.Errorf("Enclosed() = %q, %v", got, ok)
+	}
+	if _, ok := syntax.Enclosed("]] before [["); ok {
+		t.Error("Enclosed() should need a closing marker after the opening one")
+	}
+	if got, rest, ok := syntax.Cut("[[ use:standup ]]\nteam: Platform"); !ok || got != "use:standup" || rest != "\nteam: Platform" {
+		t.Errorf("Cut() = %q, %q, %v", got, rest, ok)
+	}
+	if NewTriggerSyntax("[[", "") != DefaultTriggerSyntax {
+		t.Error("NewTriggerSyntax() with an empty marker should return the default")
+	}
+}
+
 func TestIsAzureOpenAIEndpoint(t *testing.T) {
 	tests := []struct {
 		name     string
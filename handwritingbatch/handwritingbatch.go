@@ -0,0 +1,195 @@
+// Package handwritingbatch provides the {{handwriting}} trigger, which reads
+// the handwriting and annotations in every snapshot on a canvas in one go
+// and collects the text in one note, instead of one trigger per snapshot.
+package handwritingbatch
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"go_backend/core"
+	"go_backend/handlers"
+)
+
+// Defaults.
+const (
+	DefaultConcurrency  = 3
+	DefaultMaxSnapshots = 50
+)
+
+// Config holds the batch settings.
+type Config struct {
+	// Concurrency is the number of snapshots sent to the Vision API at a
+	// time, across all batches
+	Concurrency int
+	// MaxSnapshots bounds the snapshots read by one trigger
+	MaxSnapshots int
+}
+
+// ConfigFromEnv reads HANDWRITING_BATCH_CONCURRENCY and HANDWRITING_BATCH_MAX.
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Concurrency:  core.ParseIntEnv("HANDWRITING_BATCH_CONCURRENCY", DefaultConcurrency),
+		MaxSnapshots: core.ParseIntEnv("HANDWRITING_BATCH_MAX", DefaultMaxSnapshots),
+	}
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	if cfg.MaxSnapshots < 1 {
+		cfg.MaxSnapshots = DefaultMaxSnapshots
+	}
+	return cfg
+}
+
+// ParseTrigger reports whether content is the batch trigger "handwriting"
+// (also "ocr").
+func ParseTrigger(content string) bool {
+	switch strings.ToLower(strings.TrimSpace(content)) {
+	case "handwriting", "ocr":
+		return true
+	}
+	return false
+}
+
+// IsSnapshot reports whether an image title is that of a Canvus snapshot,
+// which captures the ink and annotations drawn over an area.
+func IsSnapshot(title string) bool {
+	return strings.HasPrefix(title, "Snapshot at")
+}
+
+// Snapshot is a snapshot image on the canvas.
+type Snapshot struct {
+	ID    string
+	Title string
+	// Bounds places the snapshot in reading order
+	Bounds handlers.Rect
+}
+
+// Find returns the snapshots among widgets in reading order, top to
+// bottom and left to right.
+//
+// This is a pure function (molecule) with no external dependencies.
+func Find(widgets []map[string]interface{}) []Snapshot {
+	var snapshots []Snapshot
+	for _, w := range widgets {
+		if handlers.GetStringField(w, "widget_type", handlers.GetStringField(w, "type", "")) != "Image" {
+			continue
+		}
+		title := strings.TrimSpace(handlers.GetStringField(w, "title", ""))
+		if !IsSnapshot(title) {
+			continue
+		}
+		snapshots = append(snapshots, Snapshot{
+			ID:     handlers.GetStringField(w, "id", ""),
+			Title:  title,
+			Bounds: handlers.WidgetBounds(w, nil),
+		})
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		a, b := snapshots[i].Bounds, snapshots[j].Bounds
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+	return snapshots
+}
+
+// Result is the text read from a snapshot.
+type Result struct {
+	Snapshot Snapshot
+	// Text is empty if no text was recognized
+	Text string
+	Err  error
+}
+
+// Recognizer returns the text in a snapshot.
+type Recognizer func(ctx context.Context, s Snapshot) (string, error)
+
+// Reader reads batches of snapshots, bounding the requests to the Vision
+// API in flight across all of them.
+//
+// Thread-Safety: Reader is safe for concurrent use.
+type Reader struct {
+	cfg   Config
+	slots chan struct{}
+}
+
+// NewReader creates a Reader. A nil Reader reads one snapshot at a time.
+func NewReader(cfg Config) *Reader {
+	if cfg.Concurrency < 1 {
+		cfg.Concurrency = 1
+	}
+	if cfg.MaxSnapshots < 1 {
+		cfg.MaxSnapshots = DefaultMaxSnapshots
+	}
+	return &Reader{cfg: cfg, slots: make(chan struct{}, cfg.Concurrency)}
+}
+
+// Config returns the batch settings.
+func (r *Reader) Config() Config {
+	if r == nil {
+		return Config{Concurrency: 1, MaxSnapshots: DefaultMaxSnapshots}
+	}
+	return r.cfg
+}
+
+// Read reads snapshots with recognize and returns the results in the order
+// of snapshots. progress, if set, is called after each snapshot with the
+// number done. Snapshots not started when ctx ends fail with its error.
+func (r *Reader) Read(ctx context.Context, snapshots []Snapshot, recognize Recognizer, progress func(done, total int)) []Result {
+	slots := make(chan struct{}, 1)
+	if r != nil {
+		slots = r.slots
+	}
+	results := make([]Result, len(snapshots))
+	var mu sync.Mutex
+	done := 0
+	var wg sync.WaitGroup
+	for i, s := range snapshots {
+		results[i].Snapshot = s
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, s Snapshot) {
+			defer wg.Done()
+			text, err := recognize(ctx, s)
+			<-slots
+			results[i].Text, results[i].Err = strings.TrimSpace(text), err
+
+			mu.Lock()
+			done++
+			if progress != nil {
+				progress(done, len(snapshots))
+			}
+			mu.Unlock()
+		}(i, s)
+	}
+	wg.Wait()
+	return results
+}
+
+// Count returns the number of results with text, without text and failed.
+func Count(results []Result) (read, empty, failed int) {
+	for _, r := range results {
+		switch {
+		case r.Err != nil:
+			failed++
+		case r.Text == "":
+			empty++
+		default:
+			read++
+		}
+	}
+	return read, empty, failed
+}
@@ -0,0 +1,102 @@
+package handwritingbatch
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseTrigger(t *testing.T) {
+	for content, want := range map[string]bool{
+		"handwriting": true, " OCR ": true, "Handwriting": true,
+		"handwriting tips": false, "summarize": false, "": false,
+	} {
+		if got := ParseTrigger(content); got != want {
+			t.Errorf("ParseTrigger(%q) = %v, want %v", content, got, want)
+		}
+	}
+}
+
+func widget(id, kind, title string, x, y float64) map[string]interface{} {
+	return map[string]interface{}{
+		"id": id, "widget_type": kind, "title": title,
+		"location": map[string]interface{}{"x": x, "y": y},
+		"size":     map[string]interface{}{"width": 100.0, "height": 100.0},
+	}
+}
+
+func TestFind(t *testing.T) {
+	snapshots := Find([]map[string]interface{}{
+		widget("b", "Image", "Snapshot at 2024-05-02 10:01", 500, 0),
+		widget("photo", "Image", "whiteboard.jpg", 0, 0),
+		widget("note", "Note", "Snapshot at noon", 0, 0),
+		widget("c", "Image", "Snapshot at 2024-05-02 10:02", 0, 400),
+		widget("a", "Image", "Snapshot at 2024-05-02 10:00", 0, 0),
+	})
+	if len(snapshots) != 3 || snapshots[0].ID != "a" || snapshots[1].ID != "b" || snapshots[2].ID != "c" {
+		t.Errorf("Find() = %+v, want a, b, c", snapshots)
+	}
+	if snapshots[2].Bounds.Y != 400 || snapshots[0].Title != "Snapshot at 2024-05-02 10:00" {
+		t.Errorf("Find() snapshot = %+v", snapshots[2])
+	}
+}
+
+func TestRead(t *testing.T) {
+	snapshots := []Snapshot{{ID: "a"}, {ID: "b"}, {ID: "c"}, {ID: "d"}, {ID: "e"}}
+	var running, peak int32
+	recognize := func(ctx context.Context, s Snapshot) (string, error) {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		switch s.ID {
+		case "b":
+			return " ", nil
+		case "d":
+			return "", errors.New("vision unavailable")
+		}
+		return " text of " + s.ID + "\n", nil
+	}
+
+	var calls []int
+	results := NewReader(Config{Concurrency: 2}).Read(context.Background(), snapshots, recognize, func(done, total int) {
+		if total != len(snapshots) {
+			t.Errorf("progress total = %d", total)
+		}
+		calls = append(calls, done)
+	})
+	if peak > 2 {
+		t.Errorf("%d snapshots read at once, want at most 2", peak)
+	}
+	if len(calls) != 5 || calls[4] != 5 {
+		t.Errorf("progress calls = %v", calls)
+	}
+	if results[0].Text != "text of a" || results[4].Snapshot.ID != "e" || results[3].Err == nil {
+		t.Errorf("Read() = %+v", results)
+	}
+	if read, empty, failed := Count(results); read != 3 || empty != 1 || failed != 1 {
+		t.Errorf("Count() = %d, %d, %d", read, empty, failed)
+	}
+
+	// A nil Reader reads one at a time; canceled batches do not start
+	atomic.StoreInt32(&peak, 0)
+	var off *Reader
+	off.Read(context.Background(), snapshots[:3], recognize, nil)
+	if peak != 1 {
+		t.Errorf("nil Reader read %d at once", peak)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, r := range off.Read(ctx, snapshots, recognize, nil) {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("Read() after cancel = %+v", r)
+		}
+	}
+}
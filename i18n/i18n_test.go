@@ -0,0 +1,93 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCatalogsComplete(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key, english := range catalogs[English] {
+			msg, ok := catalog[key]
+			if !ok {
+				t.Errorf("%s: missing %s", lang, key)
+				continue
+			}
+			if strings.Count(msg, "%") != strings.Count(english, "%") {
+				t.Errorf("%s: %s has different format verbs than English: %q", lang, key, msg)
+			}
+			for _, prefix := range []string{"❌", "⚠️", "⏳"} {
+				if strings.HasPrefix(english, prefix) != strings.HasPrefix(msg, prefix) {
+					t.Errorf("%s: %s must keep the %s prefix", lang, key, prefix)
+				}
+			}
+		}
+		if _, ok := languageNames[lang]; !ok {
+			t.Errorf("%s: missing language name", lang)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	tests := []struct {
+		lang string
+		key  Key
+		args []interface{}
+		want string
+	}{
+		{"de", MsgReadingStickyNote, []interface{}{2, 5}, "⏳ Lese Haftnotiz 2/5"},
+		{"de-AT", MsgNoTextRecognized, nil, "⚠️ Kein Text erkannt"},
+		{"de_DE:de", MsgNoTextRecognized, nil, "⚠️ Kein Text erkannt"},
+		{"FR", MsgError, []interface{}{"boom"}, "❌ Erreur : boom"},
+		{"xx", MsgError, []interface{}{"boom"}, "❌ Error: boom"},
+		{"", MsgProcessing, nil, "⏳ AI Processing"},
+		{"en", Key("missing"), nil, "missing"},
+	}
+	for _, tt := range tests {
+		if got := T(tt.lang, tt.key, tt.args...); got != tt.want {
+			t.Errorf("T(%q, %s) = %q, want %q", tt.lang, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSupported(t *testing.T) {
+	if !Supported("es") || Supported("pt") || Supported("") {
+		t.Errorf("Supported() wrong; languages = %v", Languages())
+	}
+	if got := Name("de-CH"); got != "German" {
+		t.Errorf("Name(de-CH) = %q", got)
+	}
+}
+
+func TestPrompt(t *testing.T) {
+	base := "You are an assistant."
+	if got := Prompt("en", PromptNote, base); got != base {
+		t.Errorf("English prompt = %q, want base", got)
+	}
+	if got := Prompt("fr", PromptNote, base); !strings.HasPrefix(got, base) || !strings.Contains(got, "in French") {
+		t.Errorf("French prompt = %q, want base plus instruction", got)
+	}
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "es"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "es", "canvas_analysis.txt"), []byte("Eres un asistente.\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "es", "empty.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	n, err := LoadPromptVariants(dir)
+	if err != nil || n != 1 {
+		t.Fatalf("LoadPromptVariants() = %d, %v; want 1", n, err)
+	}
+	if got := Prompt("es", PromptCanvasAnalysis, base); got != "Eres un asistente." {
+		t.Errorf("Spanish variant = %q", got)
+	}
+	if got := Prompt("es", PromptNote, base); !strings.Contains(got, "in Spanish") {
+		t.Errorf("prompt without variant = %q", got)
+	}
+}
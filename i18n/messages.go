@@ -0,0 +1,531 @@
+// Package i18n provides localized canvas-facing messages and language
+// variants of AI system prompts. This file contains the message catalogs.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// English is the default language and the fallback for missing messages.
+const English = "en"
+
+// Key identifies a canvas-facing message. Messages are fmt format strings.
+type Key string
+
+// Canvas-facing messages. Status messages keep their emoji prefix in every
+// language, since processing notes are colored by it.
+const (
+	MsgProcessing          Key = "processing"
+	MsgWarmingUp           Key = "warming_up"
+	MsgError               Key = "error"
+	MsgNoSnapshotURL       Key = "no_snapshot_url"
+	MsgOCRError            Key = "ocr_error"
+	MsgNoTextRecognized    Key = "no_text_recognized"
+	MsgVisionUnavailable   Key = "vision_unavailable"
+	MsgImageDownloadFailed Key = "image_download_failed"
+	MsgImageDownloadStatus Key = "image_download_status"
+	MsgImageSaveFailed     Key = "image_save_failed"
+	MsgImageDecodeFailed   Key = "image_decode_failed"
+	MsgImageCombineFailed  Key = "image_combine_failed"
+	MsgVisionFailed        Key = "vision_failed"
+	MsgExtractionFailed    Key = "extraction_failed"
+	MsgNoStickyNotes       Key = "no_sticky_notes"
+	MsgReadingStickyNote   Key = "reading_sticky_note"
+	MsgNoStickyNotesMade   Key = "no_sticky_notes_made"
+	MsgNoParentPDF         Key = "no_parent_pdf"
+	MsgParentWidgetFailed  Key = "parent_widget_failed"
+	MsgNotAPDF             Key = "not_a_pdf"
+	MsgPDFNoURL            Key = "pdf_no_url"
+	MsgDownloadingPDF      Key = "downloading_pdf"
+	MsgPDFDownloadFailed   Key = "pdf_download_failed"
+	MsgPDFDownloadStatus   Key = "pdf_download_status"
+	MsgPDFSaveFailed       Key = "pdf_save_failed"
+	MsgExtractingPDF       Key = "extracting_pdf"
+	MsgPDFFailed           Key = "pdf_failed"
+	MsgFetchingWidgets     Key = "fetching_widgets"
+	MsgCanvasFailed        Key = "canvas_failed"
+	MsgGeneratingImage     Key = "generating_image"
+	MsgImageFailed         Key = "image_failed"
+	MsgModelNotAllowed     Key = "model_not_allowed"
+	MsgBudgetExceeded      Key = "budget_exceeded"
+	MsgFeatureNotAllowed   Key = "feature_not_allowed"
+	MsgTaskInterrupted     Key = "task_interrupted"
+	MsgResponseBlocked     Key = "response_blocked"
+	MsgContextReduced      Key = "context_reduced"
+	MsgExporting           Key = "exporting"
+	MsgExportFailed        Key = "export_failed"
+	MsgExportReady         Key = "export_ready"
+	MsgErrorCode           Key = "error_code"
+	MsgLoadingVariants     Key = "loading_variants"
+	MsgVariantsFailed      Key = "variants_failed"
+	MsgNoVariants          Key = "no_variants"
+	MsgVariantsPosted      Key = "variants_posted"
+	MsgVariantUnknown      Key = "variant_unknown"
+	MsgVariantNotKept      Key = "variant_not_kept"
+	MsgVariantRestored     Key = "variant_restored"
+	MsgBuildingCollage     Key = "building_collage"
+	MsgCollageFailed       Key = "collage_failed"
+	MsgCollageNoImages     Key = "collage_no_images"
+	MsgCollagePosted       Key = "collage_posted"
+	MsgFixFailed           Key = "fix_failed"
+	MsgFixNeedsLocal       Key = "fix_needs_local"
+	MsgFixNothingToUndo    Key = "fix_nothing_to_undo"
+	MsgFixEditedSince      Key = "fix_edited_since"
+	MsgRewriting           Key = "rewriting"
+	MsgRewriteFailed       Key = "rewrite_failed"
+	MsgRewriteNeedsLocal   Key = "rewrite_needs_local"
+	MsgRewriteNoText       Key = "rewrite_no_text"
+	MsgSummarizingGroup    Key = "summarizing_group"
+	MsgGroupSummaryFailed  Key = "group_summary_failed"
+	MsgGroupNotFound       Key = "group_not_found"
+	MsgGroupEmpty          Key = "group_empty"
+	MsgGroupSummaryPartial Key = "group_summary_partial"
+	MsgTranscribing        Key = "transcribing"
+	MsgTranscriptionFailed Key = "transcription_failed"
+	MsgVoiceNoteTitle      Key = "voice_note_title"
+	MsgVoiceNoteSummary    Key = "voice_note_summary"
+	MsgVoiceNoteTranscript Key = "voice_note_transcript"
+	MsgVoiceNoteNoSpeech   Key = "voice_note_no_speech"
+	MsgLiveTranscriptTitle Key = "live_transcript_title"
+	MsgLiveTranscriptLive  Key = "live_transcript_live"
+	MsgLiveTranscriptWait  Key = "live_transcript_wait"
+	MsgLiveTranscriptStop  Key = "live_transcript_stop"
+	MsgLiveTranscriptEnded Key = "live_transcript_ended"
+	MsgLiveTranscriptError Key = "live_transcript_error"
+	MsgLiveSummaryTitle    Key = "live_summary_title"
+	MsgLiveTranscriptBusy  Key = "live_transcript_busy"
+	MsgLiveTranscriptOff   Key = "live_transcript_off"
+	MsgLiveTranscriptDone  Key = "live_transcript_done"
+	MsgLiveTranscriptNone  Key = "live_transcript_none"
+	MsgHandwritingNone     Key = "handwriting_none"
+	MsgHandwritingReading  Key = "handwriting_reading"
+	MsgHandwritingTitle    Key = "handwriting_title"
+	MsgHandwritingEmpty    Key = "handwriting_empty"
+	MsgHandwritingFailed   Key = "handwriting_failed"
+	MsgHandwritingPartial  Key = "handwriting_partial"
+)
+
+// languageNames are the English names of the supported languages, used in
+// prompts.
+var languageNames = map[string]string{
+	"en": "English",
+	"de": "German",
+	"fr": "French",
+	"es": "Spanish",
+}
+
+// catalogs holds the messages of each language.
+var catalogs = map[string]map[Key]string{
+	"en": {
+		MsgProcessing:          "⏳ AI Processing",
+		MsgWarmingUp:           "⏳ Warming up model... The first request after an idle period takes longer.",
+		MsgError:               "❌ Error: %v",
+		MsgNoSnapshotURL:       "❌ Error: No snapshot URL",
+		MsgOCRError:            "❌ OCR Error: %v",
+		MsgNoTextRecognized:    "⚠️ No text recognized",
+		MsgVisionUnavailable:   "Vision analysis not available (llama runtime not initialized)",
+		MsgImageDownloadFailed: "Failed to download image: %v",
+		MsgImageDownloadStatus: "Image download failed with status: %d",
+		MsgImageSaveFailed:     "Failed to save image: %v",
+		MsgImageDecodeFailed:   "Failed to decode image: %v",
+		MsgImageCombineFailed:  "Failed to combine images: %v",
+		MsgVisionFailed:        "Vision inference failed: %v",
+		MsgExtractionFailed:    "Structured extraction failed: %v",
+		MsgNoStickyNotes:       "⚠️ No sticky notes detected in this photo",
+		MsgReadingStickyNote:   "⏳ Reading sticky note %d/%d",
+		MsgNoStickyNotesMade:   "Could not recreate any sticky notes",
+		MsgNoParentPDF:         "❌ Error: No parent PDF found",
+		MsgParentWidgetFailed:  "Failed to get parent widget: %v",
+		MsgNotAPDF:             "Parent widget is not a PDF (type: %s)",
+		MsgPDFNoURL:            "❌ Error: PDF has no URL",
+		MsgDownloadingPDF:      "⏳ Downloading PDF...",
+		MsgPDFDownloadFailed:   "Failed to download PDF: %v",
+		MsgPDFDownloadStatus:   "PDF download failed with status: %d",
+		MsgPDFSaveFailed:       "Failed to save PDF: %v",
+		MsgExtractingPDF:       "⏳ Extracting text from PDF...",
+		MsgPDFFailed:           "PDF processing failed: %v",
+		MsgFetchingWidgets:     "⏳ Fetching canvas widgets...",
+		MsgCanvasFailed:        "Canvas analysis failed: %v",
+		MsgGeneratingImage:     "[SD] Generating image...\nThis may take 10-30 seconds.",
+		MsgImageFailed:         "[SD] Image generation failed: %v",
+		MsgModelNotAllowed:     "model %q is not allowed on this canvas",
+		MsgBudgetExceeded:      "daily AI task limit reached for this canvas (%d)",
+		MsgFeatureNotAllowed:   "🙏 Sorry, %s is not available on this canvas.",
+		MsgTaskInterrupted:     "❌ This request was interrupted %d times by a server restart and will not be retried. Edit the trigger to try again.",
+		MsgResponseBlocked:     "🚫 The AI response was withheld by the content filter.",
+		MsgContextReduced:      "⚠️ Generated with a reduced context window (%d of %d tokens) because GPU memory ran short.",
+		MsgExporting:           "⏳ Exporting canvas...",
+		MsgExportFailed:        "Canvas export failed: %v",
+		MsgExportReady:         "📄 Canvas export ready (%d items):\n%s",
+		MsgErrorCode:           "Code %s: %s",
+		MsgLoadingVariants:     "⏳ Loading earlier images...",
+		MsgVariantsFailed:      "Showing earlier images failed: %v",
+		MsgNoVariants:          "⚠️ No images have been generated from this note yet",
+		MsgVariantsPosted:      "🖼️ %d earlier images of this note, newest first. Write %s to bring one back at full size.",
+		MsgVariantUnknown:      "⚠️ This note has %d earlier images; there is no image %d",
+		MsgVariantNotKept:      "⚠️ Image %d was not kept at full size (set ARTIFACT_STORE to keep generated images)",
+		MsgVariantRestored:     "🖼️ Brought back image %d",
+		MsgBuildingCollage:     "⏳ Building collage...",
+		MsgCollageFailed:       "Building the collage failed: %v",
+		MsgCollageNoImages:     "⚠️ No images were found for the collage",
+		MsgCollagePosted:       "🖼️ Collage of %d images posted",
+		MsgFixFailed:           "Fixing the note failed: %v",
+		MsgFixNeedsLocal:       "⚠️ This note contains personal data that would be masked before it is sent to the cloud model, which would change the note. Fixing it needs the local model.",
+		MsgFixNothingToUndo:    "⚠️ This note has no fix to undo",
+		MsgFixEditedSince:      "⚠️ This note was edited after it was fixed; undoing the fix would lose the edit",
+		MsgRewriting:           "⏳ Rewriting...",
+		MsgRewriteFailed:       "Rewriting the note failed: %v",
+		MsgRewriteNeedsLocal:   "⚠️ This note contains personal data that would be masked before it is sent to the cloud model, which would change the note. Rewriting it in place needs the local model; without --replace the rewrite goes to a new note.",
+		MsgRewriteNoText:       "⚠️ This note has no text to rewrite",
+		MsgSummarizingGroup:    "⏳ Summarizing %d notes...",
+		MsgGroupSummaryFailed:  "Summarizing the group failed: %v",
+		MsgGroupNotFound:       "⚠️ %s summarizes the notes of the anchor or group it is in; this note is in neither",
+		MsgGroupEmpty:          "⚠️ There are no other notes with text in this group",
+		MsgGroupSummaryPartial: "(%d of the %d notes were summarized; the rest did not fit)",
+		MsgTranscribing:        "⏳ Transcribing %s...",
+		MsgTranscriptionFailed: "Transcribing the recording failed: %v",
+		MsgVoiceNoteTitle:      "🎙️ Transcript of %s (%s)",
+		MsgVoiceNoteSummary:    "Summary",
+		MsgVoiceNoteTranscript: "Transcript",
+		MsgVoiceNoteNoSpeech:   "⚠️ No speech was recognized in %s",
+		MsgLiveTranscriptTitle: "Live transcript",
+		MsgLiveTranscriptLive:  "🔴 Live transcript (%s)",
+		MsgLiveTranscriptWait:  "🎙️ Listening... what is said appears here.",
+		MsgLiveTranscriptStop:  "Write %s in a note to stop.",
+		MsgLiveTranscriptEnded: "⏹️ Transcript (%s)",
+		MsgLiveTranscriptError: "Live transcription stopped: %v",
+		MsgLiveSummaryTitle:    "📝 Meeting summary at %s",
+		MsgLiveTranscriptBusy:  "⚠️ A live transcript is already running (%v). Stop it with %s first.",
+		MsgLiveTranscriptOff:   "⚠️ Live transcription is not set up on this server (LIVE_TRANSCRIPT_SOURCE and WHISPER_URL).",
+		MsgLiveTranscriptDone:  "⏹️ Live transcript stopped.",
+		MsgLiveTranscriptNone:  "No live transcript is running on this canvas.",
+		MsgHandwritingNone:     "There are no snapshots on this canvas. Take a snapshot of the handwriting or annotations first, then write %s again.",
+		MsgHandwritingReading:  "⏳ Reading handwriting: %d of %d snapshots...",
+		MsgHandwritingTitle:    "✍️ Handwriting from %d snapshots",
+		MsgHandwritingEmpty:    "No text recognized in: %s",
+		MsgHandwritingFailed:   "Could not read %s: %v",
+		MsgHandwritingPartial:  "Only the first %d of %d snapshots were read.",
+	},
+	"de": {
+		MsgProcessing:          "⏳ KI-Verarbeitung",
+		MsgWarmingUp:           "⏳ Modell wird geladen... Die erste Anfrage nach einer Pause dauert länger.",
+		MsgError:               "❌ Fehler: %v",
+		MsgNoSnapshotURL:       "❌ Fehler: Keine Snapshot-URL",
+		MsgOCRError:            "❌ Texterkennungsfehler: %v",
+		MsgNoTextRecognized:    "⚠️ Kein Text erkannt",
+		MsgVisionUnavailable:   "Bildanalyse nicht verfügbar (lokales Modell nicht geladen)",
+		MsgImageDownloadFailed: "Bild konnte nicht heruntergeladen werden: %v",
+		MsgImageDownloadStatus: "Bild-Download fehlgeschlagen mit Status: %d",
+		MsgImageSaveFailed:     "Bild konnte nicht gespeichert werden: %v",
+		MsgImageDecodeFailed:   "Bild konnte nicht gelesen werden: %v",
+		MsgImageCombineFailed:  "Bilder konnten nicht kombiniert werden: %v",
+		MsgVisionFailed:        "Bildanalyse fehlgeschlagen: %v",
+		MsgExtractionFailed:    "Strukturierte Extraktion fehlgeschlagen: %v",
+		MsgNoStickyNotes:       "⚠️ Keine Haftnotizen auf diesem Foto erkannt",
+		MsgReadingStickyNote:   "⏳ Lese Haftnotiz %d/%d",
+		MsgNoStickyNotesMade:   "Es konnten keine Haftnotizen erstellt werden",
+		MsgNoParentPDF:         "❌ Fehler: Kein übergeordnetes PDF gefunden",
+		MsgParentWidgetFailed:  "Übergeordnetes Widget konnte nicht geladen werden: %v",
+		MsgNotAPDF:             "Übergeordnetes Widget ist kein PDF (Typ: %s)",
+		MsgPDFNoURL:            "❌ Fehler: PDF hat keine URL",
+		MsgDownloadingPDF:      "⏳ PDF wird heruntergeladen...",
+		MsgPDFDownloadFailed:   "PDF konnte nicht heruntergeladen werden: %v",
+		MsgPDFDownloadStatus:   "PDF-Download fehlgeschlagen mit Status: %d",
+		MsgPDFSaveFailed:       "PDF konnte nicht gespeichert werden: %v",
+		MsgExtractingPDF:       "⏳ Text wird aus dem PDF extrahiert...",
+		MsgPDFFailed:           "PDF-Verarbeitung fehlgeschlagen: %v",
+		MsgFetchingWidgets:     "⏳ Canvas-Widgets werden geladen...",
+		MsgCanvasFailed:        "Canvas-Analyse fehlgeschlagen: %v",
+		MsgGeneratingImage:     "[SD] Bild wird erzeugt...\nDas kann 10-30 Sekunden dauern.",
+		MsgImageFailed:         "[SD] Bilderzeugung fehlgeschlagen: %v",
+		MsgModelNotAllowed:     "Modell %q ist auf diesem Canvas nicht erlaubt",
+		MsgBudgetExceeded:      "Tageslimit für KI-Aufgaben auf diesem Canvas erreicht (%d)",
+		MsgFeatureNotAllowed:   "🙏 Entschuldigung, %s ist auf diesem Canvas nicht verfügbar.",
+		MsgTaskInterrupted:     "❌ Diese Anfrage wurde %d-mal durch einen Serverneustart unterbrochen und wird nicht wiederholt. Bearbeiten Sie den Auslöser, um es erneut zu versuchen.",
+		MsgResponseBlocked:     "🚫 Die KI-Antwort wurde vom Inhaltsfilter zurückgehalten.",
+		MsgContextReduced:      "⚠️ Mit verkleinertem Kontextfenster erzeugt (%d von %d Tokens), da der GPU-Speicher knapp war.",
+		MsgExporting:           "⏳ Canvas wird exportiert...",
+		MsgExportFailed:        "Canvas-Export fehlgeschlagen: %v",
+		MsgExportReady:         "📄 Canvas-Export bereit (%d Elemente):\n%s",
+		MsgErrorCode:           "Code %s: %s",
+		MsgLoadingVariants:     "⏳ Frühere Bilder werden geladen...",
+		MsgVariantsFailed:      "Frühere Bilder konnten nicht angezeigt werden: %v",
+		MsgNoVariants:          "⚠️ Aus dieser Notiz wurden noch keine Bilder erzeugt",
+		MsgVariantsPosted:      "🖼️ %d frühere Bilder dieser Notiz, das neueste zuerst. Schreibe %s, um eines in voller Größe zurückzuholen.",
+		MsgVariantUnknown:      "⚠️ Diese Notiz hat %d frühere Bilder; Bild %d gibt es nicht",
+		MsgVariantNotKept:      "⚠️ Bild %d wurde nicht in voller Größe aufbewahrt (ARTIFACT_STORE setzen, um erzeugte Bilder aufzubewahren)",
+		MsgVariantRestored:     "🖼️ Bild %d zurückgeholt",
+		MsgBuildingCollage:     "⏳ Collage wird erstellt...",
+		MsgCollageFailed:       "Die Collage konnte nicht erstellt werden: %v",
+		MsgCollageNoImages:     "⚠️ Für die Collage wurden keine Bilder gefunden",
+		MsgCollagePosted:       "🖼️ Collage aus %d Bildern erstellt",
+		MsgFixFailed:           "Die Notiz konnte nicht korrigiert werden: %v",
+		MsgFixNeedsLocal:       "⚠️ Diese Notiz enthält personenbezogene Daten, die vor dem Senden an das Cloud-Modell maskiert würden, was die Notiz verändern würde. Für die Korrektur wird das lokale Modell benötigt.",
+		MsgFixNothingToUndo:    "⚠️ Für diese Notiz gibt es keine Korrektur zum Rückgängigmachen",
+		MsgFixEditedSince:      "⚠️ Diese Notiz wurde nach der Korrektur bearbeitet; das Rückgängigmachen würde die Änderung verwerfen",
+		MsgRewriting:           "⏳ Wird umgeschrieben...",
+		MsgRewriteFailed:       "Die Notiz konnte nicht umgeschrieben werden: %v",
+		MsgRewriteNeedsLocal:   "⚠️ Diese Notiz enthält personenbezogene Daten, die vor dem Senden an das Cloud-Modell maskiert würden, was die Notiz verändern würde. Zum Umschreiben an Ort und Stelle wird das lokale Modell benötigt; ohne --replace wird der neue Text in eine neue Notiz geschrieben.",
+		MsgRewriteNoText:       "⚠️ Diese Notiz enthält keinen Text zum Umschreiben",
+		MsgSummarizingGroup:    "⏳ %d Notizen werden zusammengefasst...",
+		MsgGroupSummaryFailed:  "Die Gruppe konnte nicht zusammengefasst werden: %v",
+		MsgGroupNotFound:       "⚠️ %s fasst die Notizen des Ankers oder der Gruppe zusammen, in der es steht; diese Notiz ist in keinem von beiden",
+		MsgGroupEmpty:          "⚠️ In dieser Gruppe gibt es keine weiteren Notizen mit Text",
+		MsgGroupSummaryPartial: "(%d der %d Notizen wurden zusammengefasst; der Rest passte nicht hinein)",
+		MsgTranscribing:        "⏳ %s wird transkribiert...",
+		MsgTranscriptionFailed: "Das Transkribieren der Aufnahme ist fehlgeschlagen: %v",
+		MsgVoiceNoteTitle:      "🎙️ Transkript von %s (%s)",
+		MsgVoiceNoteSummary:    "Zusammenfassung",
+		MsgVoiceNoteTranscript: "Transkript",
+		MsgVoiceNoteNoSpeech:   "⚠️ In %s wurde keine Sprache erkannt",
+		MsgLiveTranscriptTitle: "Live-Transkript",
+		MsgLiveTranscriptLive:  "🔴 Live-Transkript (%s)",
+		MsgLiveTranscriptWait:  "🎙️ Höre zu... das Gesagte erscheint hier.",
+		MsgLiveTranscriptStop:  "Schreibe %s in eine Notiz, um aufzuhören.",
+		MsgLiveTranscriptEnded: "⏹️ Transkript (%s)",
+		MsgLiveTranscriptError: "Die Live-Transkription wurde beendet: %v",
+		MsgLiveSummaryTitle:    "📝 Zusammenfassung des Meetings bei %s",
+		MsgLiveTranscriptBusy:  "⚠️ Es läuft bereits ein Live-Transkript (%v). Beende es zuerst mit %s.",
+		MsgLiveTranscriptOff:   "⚠️ Die Live-Transkription ist auf diesem Server nicht eingerichtet (LIVE_TRANSCRIPT_SOURCE und WHISPER_URL).",
+		MsgLiveTranscriptDone:  "⏹️ Live-Transkript beendet.",
+		MsgLiveTranscriptNone:  "Auf diesem Canvas läuft kein Live-Transkript.",
+		MsgHandwritingNone:     "Auf diesem Canvas gibt es keine Schnappschüsse. Mache zuerst einen Schnappschuss der Handschrift oder Anmerkungen und schreibe dann erneut %s.",
+		MsgHandwritingReading:  "⏳ Handschrift wird gelesen: %d von %d Schnappschüssen...",
+		MsgHandwritingTitle:    "✍️ Handschrift aus %d Schnappschüssen",
+		MsgHandwritingEmpty:    "Kein Text erkannt in: %s",
+		MsgHandwritingFailed:   "%s konnte nicht gelesen werden: %v",
+		MsgHandwritingPartial:  "Nur die ersten %d von %d Schnappschüssen wurden gelesen.",
+	},
+	"fr": {
+		MsgProcessing:          "⏳ Traitement IA",
+		MsgWarmingUp:           "⏳ Chargement du modèle... La première requête après une pause prend plus de temps.",
+		MsgError:               "❌ Erreur : %v",
+		MsgNoSnapshotURL:       "❌ Erreur : aucune URL de capture",
+		MsgOCRError:            "❌ Erreur de reconnaissance de texte : %v",
+		MsgNoTextRecognized:    "⚠️ Aucun texte reconnu",
+		MsgVisionUnavailable:   "Analyse d'image indisponible (modèle local non chargé)",
+		MsgImageDownloadFailed: "Échec du téléchargement de l'image : %v",
+		MsgImageDownloadStatus: "Échec du téléchargement de l'image, statut : %d",
+		MsgImageSaveFailed:     "Échec de l'enregistrement de l'image : %v",
+		MsgImageDecodeFailed:   "Impossible de lire l'image : %v",
+		MsgImageCombineFailed:  "Impossible de combiner les images : %v",
+		MsgVisionFailed:        "Échec de l'analyse d'image : %v",
+		MsgExtractionFailed:    "Échec de l'extraction structurée : %v",
+		MsgNoStickyNotes:       "⚠️ Aucun post-it détecté sur cette photo",
+		MsgReadingStickyNote:   "⏳ Lecture du post-it %d/%d",
+		MsgNoStickyNotesMade:   "Aucun post-it n'a pu être recréé",
+		MsgNoParentPDF:         "❌ Erreur : aucun PDF parent trouvé",
+		MsgParentWidgetFailed:  "Impossible de charger le widget parent : %v",
+		MsgNotAPDF:             "Le widget parent n'est pas un PDF (type : %s)",
+		MsgPDFNoURL:            "❌ Erreur : le PDF n'a pas d'URL",
+		MsgDownloadingPDF:      "⏳ Téléchargement du PDF...",
+		MsgPDFDownloadFailed:   "Échec du téléchargement du PDF : %v",
+		MsgPDFDownloadStatus:   "Échec du téléchargement du PDF, statut : %d",
+		MsgPDFSaveFailed:       "Échec de l'enregistrement du PDF : %v",
+		MsgExtractingPDF:       "⏳ Extraction du texte du PDF...",
+		MsgPDFFailed:           "Échec du traitement du PDF : %v",
+		MsgFetchingWidgets:     "⏳ Chargement des widgets du canevas...",
+		MsgCanvasFailed:        "Échec de l'analyse du canevas : %v",
+		MsgGeneratingImage:     "[SD] Génération de l'image...\nCela peut prendre 10 à 30 secondes.",
+		MsgImageFailed:         "[SD] Échec de la génération d'image : %v",
+		MsgModelNotAllowed:     "le modèle %q n'est pas autorisé sur ce canevas",
+		MsgBudgetExceeded:      "limite quotidienne de tâches IA atteinte pour ce canevas (%d)",
+		MsgFeatureNotAllowed:   "🙏 Désolé, %s n'est pas disponible sur ce canevas.",
+		MsgTaskInterrupted:     "❌ Cette demande a été interrompue %d fois par un redémarrage du serveur et ne sera pas relancée. Modifiez le déclencheur pour réessayer.",
+		MsgResponseBlocked:     "🚫 La réponse de l'IA a été retenue par le filtre de contenu.",
+		MsgContextReduced:      "⚠️ Généré avec une fenêtre de contexte réduite (%d sur %d jetons) faute de mémoire GPU.",
+		MsgExporting:           "⏳ Export du canevas...",
+		MsgExportFailed:        "Échec de l'export du canevas : %v",
+		MsgExportReady:         "📄 Export du canevas prêt (%d éléments) :\n%s",
+		MsgErrorCode:           "Code %s : %s",
+		MsgLoadingVariants:     "⏳ Chargement des images précédentes...",
+		MsgVariantsFailed:      "Impossible d'afficher les images précédentes : %v",
+		MsgNoVariants:          "⚠️ Aucune image n'a encore été générée à partir de cette note",
+		MsgVariantsPosted:      "🖼️ %d images précédentes de cette note, la plus récente en premier. Écrivez %s pour en récupérer une en taille réelle.",
+		MsgVariantUnknown:      "⚠️ Cette note a %d images précédentes ; l'image %d n'existe pas",
+		MsgVariantNotKept:      "⚠️ L'image %d n'a pas été conservée en taille réelle (définissez ARTIFACT_STORE pour conserver les images générées)",
+		MsgVariantRestored:     "🖼️ Image %d récupérée",
+		MsgBuildingCollage:     "⏳ Création du collage...",
+		MsgCollageFailed:       "La création du collage a échoué : %v",
+		MsgCollageNoImages:     "⚠️ Aucune image trouvée pour le collage",
+		MsgCollagePosted:       "🖼️ Collage de %d images publié",
+		MsgFixFailed:           "La correction de la note a échoué : %v",
+		MsgFixNeedsLocal:       "⚠️ Cette note contient des données personnelles qui seraient masquées avant l'envoi au modèle cloud, ce qui modifierait la note. Sa correction nécessite le modèle local.",
+		MsgFixNothingToUndo:    "⚠️ Cette note n'a aucune correction à annuler",
+		MsgFixEditedSince:      "⚠️ Cette note a été modifiée après sa correction ; annuler la correction perdrait la modification",
+		MsgRewriting:           "⏳ Réécriture...",
+		MsgRewriteFailed:       "La réécriture de la note a échoué : %v",
+		MsgRewriteNeedsLocal:   "⚠️ Cette note contient des données personnelles qui seraient masquées avant l'envoi au modèle cloud, ce qui modifierait la note. La réécrire sur place nécessite le modèle local ; sans --replace, la réécriture va dans une nouvelle note.",
+		MsgRewriteNoText:       "⚠️ Cette note ne contient aucun texte à réécrire",
+		MsgSummarizingGroup:    "⏳ Résumé de %d notes...",
+		MsgGroupSummaryFailed:  "Le résumé du groupe a échoué : %v",
+		MsgGroupNotFound:       "⚠️ %s résume les notes de l'ancre ou du groupe où il se trouve ; cette note n'est dans aucun des deux",
+		MsgGroupEmpty:          "⚠️ Ce groupe ne contient aucune autre note avec du texte",
+		MsgGroupSummaryPartial: "(%d des %d notes ont été résumées ; les autres ne tenaient pas)",
+		MsgTranscribing:        "⏳ Transcription de %s...",
+		MsgTranscriptionFailed: "La transcription de l'enregistrement a échoué : %v",
+		MsgVoiceNoteTitle:      "🎙️ Transcription de %s (%s)",
+		MsgVoiceNoteSummary:    "Résumé",
+		MsgVoiceNoteTranscript: "Transcription",
+		MsgVoiceNoteNoSpeech:   "⚠️ Aucune parole n'a été reconnue dans %s",
+		MsgLiveTranscriptTitle: "Transcription en direct",
+		MsgLiveTranscriptLive:  "🔴 Transcription en direct (%s)",
+		MsgLiveTranscriptWait:  "🎙️ À l'écoute... ce qui est dit apparaît ici.",
+		MsgLiveTranscriptStop:  "Écrivez %s dans une note pour arrêter.",
+		MsgLiveTranscriptEnded: "⏹️ Transcription (%s)",
+		MsgLiveTranscriptError: "La transcription en direct s'est arrêtée : %v",
+		MsgLiveSummaryTitle:    "📝 Résumé de la réunion à %s",
+		MsgLiveTranscriptBusy:  "⚠️ Une transcription en direct est déjà en cours (%v). Arrêtez-la d'abord avec %s.",
+		MsgLiveTranscriptOff:   "⚠️ La transcription en direct n'est pas configurée sur ce serveur (LIVE_TRANSCRIPT_SOURCE et WHISPER_URL).",
+		MsgLiveTranscriptDone:  "⏹️ Transcription en direct arrêtée.",
+		MsgLiveTranscriptNone:  "Aucune transcription en direct n'est en cours sur ce canevas.",
+		MsgHandwritingNone:     "Il n'y a aucun instantané sur ce canevas. Prenez d'abord un instantané de l'écriture ou des annotations, puis écrivez à nouveau %s.",
+		MsgHandwritingReading:  "⏳ Lecture de l'écriture : %d sur %d instantanés...",
+		MsgHandwritingTitle:    "✍️ Écriture de %d instantanés",
+		MsgHandwritingEmpty:    "Aucun texte reconnu dans : %s",
+		MsgHandwritingFailed:   "Impossible de lire %s : %v",
+		MsgHandwritingPartial:  "Seuls les %d premiers des %d instantanés ont été lus.",
+	},
+	"es": {
+		MsgProcessing:          "⏳ Procesando con IA",
+		MsgWarmingUp:           "⏳ Cargando el modelo... La primera solicitud tras una pausa tarda más.",
+		MsgError:               "❌ Error: %v",
+		MsgNoSnapshotURL:       "❌ Error: no hay URL de la captura",
+		MsgOCRError:            "❌ Error de reconocimiento de texto: %v",
+		MsgNoTextRecognized:    "⚠️ No se reconoció ningún texto",
+		MsgVisionUnavailable:   "Análisis de imagen no disponible (modelo local no cargado)",
+		MsgImageDownloadFailed: "No se pudo descargar la imagen: %v",
+		MsgImageDownloadStatus: "La descarga de la imagen falló con estado: %d",
+		MsgImageSaveFailed:     "No se pudo guardar la imagen: %v",
+		MsgImageDecodeFailed:   "No se pudo leer la imagen: %v",
+		MsgImageCombineFailed:  "No se pudieron combinar las imágenes: %v",
+		MsgVisionFailed:        "El análisis de imagen falló: %v",
+		MsgExtractionFailed:    "La extracción estructurada falló: %v",
+		MsgNoStickyNotes:       "⚠️ No se detectaron notas adhesivas en esta foto",
+		MsgReadingStickyNote:   "⏳ Leyendo nota adhesiva %d/%d",
+		MsgNoStickyNotesMade:   "No se pudo recrear ninguna nota adhesiva",
+		MsgNoParentPDF:         "❌ Error: no se encontró el PDF principal",
+		MsgParentWidgetFailed:  "No se pudo obtener el widget principal: %v",
+		MsgNotAPDF:             "El widget principal no es un PDF (tipo: %s)",
+		MsgPDFNoURL:            "❌ Error: el PDF no tiene URL",
+		MsgDownloadingPDF:      "⏳ Descargando el PDF...",
+		MsgPDFDownloadFailed:   "No se pudo descargar el PDF: %v",
+		MsgPDFDownloadStatus:   "La descarga del PDF falló con estado: %d",
+		MsgPDFSaveFailed:       "No se pudo guardar el PDF: %v",
+		MsgExtractingPDF:       "⏳ Extrayendo el texto del PDF...",
+		MsgPDFFailed:           "El procesamiento del PDF falló: %v",
+		MsgFetchingWidgets:     "⏳ Cargando los widgets del lienzo...",
+		MsgCanvasFailed:        "El análisis del lienzo falló: %v",
+		MsgGeneratingImage:     "[SD] Generando imagen...\nEsto puede tardar entre 10 y 30 segundos.",
+		MsgImageFailed:         "[SD] La generación de la imagen falló: %v",
+		MsgModelNotAllowed:     "el modelo %q no está permitido en este lienzo",
+		MsgBudgetExceeded:      "se alcanzó el límite diario de tareas de IA en este lienzo (%d)",
+		MsgFeatureNotAllowed:   "🙏 Lo sentimos, %s no está disponible en este lienzo.",
+		MsgTaskInterrupted:     "❌ Esta solicitud se interrumpió %d veces por un reinicio del servidor y no se volverá a intentar. Edite el disparador para intentarlo de nuevo.",
+		MsgResponseBlocked:     "🚫 El filtro de contenido retuvo la respuesta de la IA.",
+		MsgContextReduced:      "⚠️ Generado con una ventana de contexto reducida (%d de %d tokens) por falta de memoria de GPU.",
+		MsgExporting:           "⏳ Exportando el lienzo...",
+		MsgExportFailed:        "La exportación del lienzo falló: %v",
+		MsgExportReady:         "📄 Exportación del lienzo lista (%d elementos):\n%s",
+		MsgErrorCode:           "Código %s: %s",
+		MsgLoadingVariants:     "⏳ Cargando imágenes anteriores...",
+		MsgVariantsFailed:      "No se pudieron mostrar las imágenes anteriores: %v",
+		MsgNoVariants:          "⚠️ Todavía no se ha generado ninguna imagen a partir de esta nota",
+		MsgVariantsPosted:      "🖼️ %d imágenes anteriores de esta nota, la más reciente primero. Escribe %s para recuperar una a tamaño completo.",
+		MsgVariantUnknown:      "⚠️ Esta nota tiene %d imágenes anteriores; no existe la imagen %d",
+		MsgVariantNotKept:      "⚠️ La imagen %d no se conservó a tamaño completo (define ARTIFACT_STORE para conservar las imágenes generadas)",
+		MsgVariantRestored:     "🖼️ Imagen %d recuperada",
+		MsgBuildingCollage:     "⏳ Creando el collage...",
+		MsgCollageFailed:       "No se pudo crear el collage: %v",
+		MsgCollageNoImages:     "⚠️ No se encontraron imágenes para el collage",
+		MsgCollagePosted:       "🖼️ Collage de %d imágenes publicado",
+		MsgFixFailed:           "No se pudo corregir la nota: %v",
+		MsgFixNeedsLocal:       "⚠️ Esta nota contiene datos personales que se enmascararían antes de enviarla al modelo en la nube, lo que cambiaría la nota. Corregirla requiere el modelo local.",
+		MsgFixNothingToUndo:    "⚠️ Esta nota no tiene ninguna corrección que deshacer",
+		MsgFixEditedSince:      "⚠️ Esta nota se editó después de corregirla; deshacer la corrección perdería la edición",
+		MsgRewriting:           "⏳ Reescribiendo...",
+		MsgRewriteFailed:       "No se pudo reescribir la nota: %v",
+		MsgRewriteNeedsLocal:   "⚠️ Esta nota contiene datos personales que se enmascararían antes de enviarla al modelo en la nube, lo que cambiaría la nota. Reescribirla en su lugar requiere el modelo local; sin --replace, la reescritura va a una nota nueva.",
+		MsgRewriteNoText:       "⚠️ Esta nota no tiene texto que reescribir",
+		MsgSummarizingGroup:    "⏳ Resumiendo %d notas...",
+		MsgGroupSummaryFailed:  "No se pudo resumir el grupo: %v",
+		MsgGroupNotFound:       "⚠️ %s resume las notas del ancla o grupo en el que está; esta nota no está en ninguno",
+		MsgGroupEmpty:          "⚠️ No hay otras notas con texto en este grupo",
+		MsgGroupSummaryPartial: "(Se resumieron %d de las %d notas; el resto no cabía)",
+		MsgTranscribing:        "⏳ Transcribiendo %s...",
+		MsgTranscriptionFailed: "La transcripción de la grabación falló: %v",
+		MsgVoiceNoteTitle:      "🎙️ Transcripción de %s (%s)",
+		MsgVoiceNoteSummary:    "Resumen",
+		MsgVoiceNoteTranscript: "Transcripción",
+		MsgVoiceNoteNoSpeech:   "⚠️ No se reconoció ninguna voz en %s",
+		MsgLiveTranscriptTitle: "Transcripción en directo",
+		MsgLiveTranscriptLive:  "🔴 Transcripción en directo (%s)",
+		MsgLiveTranscriptWait:  "🎙️ Escuchando... lo que se dice aparece aquí.",
+		MsgLiveTranscriptStop:  "Escribe %s en una nota para detenerla.",
+		MsgLiveTranscriptEnded: "⏹️ Transcripción (%s)",
+		MsgLiveTranscriptError: "La transcripción en directo se detuvo: %v",
+		MsgLiveSummaryTitle:    "📝 Resumen de la reunión a los %s",
+		MsgLiveTranscriptBusy:  "⚠️ Ya hay una transcripción en directo en curso (%v). Detenla primero con %s.",
+		MsgLiveTranscriptOff:   "⚠️ La transcripción en directo no está configurada en este servidor (LIVE_TRANSCRIPT_SOURCE y WHISPER_URL).",
+		MsgLiveTranscriptDone:  "⏹️ Transcripción en directo detenida.",
+		MsgLiveTranscriptNone:  "No hay ninguna transcripción en directo en curso en este lienzo.",
+		MsgHandwritingNone:     "No hay instantáneas en este lienzo. Toma primero una instantánea de la escritura o las anotaciones y vuelve a escribir %s.",
+		MsgHandwritingReading:  "⏳ Leyendo la escritura: %d de %d instantáneas...",
+		MsgHandwritingTitle:    "✍️ Escritura de %d instantáneas",
+		MsgHandwritingEmpty:    "No se reconoció texto en: %s",
+		MsgHandwritingFailed:   "No se pudo leer %s: %v",
+		MsgHandwritingPartial:  "Solo se leyeron las primeras %d de %d instantáneas.",
+	},
+}
+
+// T returns the message for key in lang, formatted with args. Unsupported
+// languages and missing messages fall back to English.
+func T(lang string, key Key, args ...interface{}) string {
+	format, ok := catalogs[Normalize(lang)][key]
+	if !ok {
+		format, ok = catalogs[English][key]
+	}
+	if !ok {
+		format = string(key)
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Normalize returns the base language of a tag such as "de-AT", "fr_CA"
+// or the gettext list "es_ES:es", in lower case.
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_:."); i > 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
+
+// Supported reports whether messages are available in lang.
+func Supported(lang string) bool {
+	_, ok := catalogs[Normalize(lang)]
+	return ok
+}
+
+// Languages returns the supported language codes, sorted.
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Name returns the English name of lang, or lang itself if unknown.
+func Name(lang string) string {
+	if name, ok := languageNames[Normalize(lang)]; ok {
+		return name
+	}
+	return lang
+}
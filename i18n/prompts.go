@@ -0,0 +1,86 @@
+// Package i18n provides localized canvas-facing messages and language
+// variants of AI system prompts. This file contains the prompt variant
+// registry.
+package i18n
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Prompts that can have language variants.
+const (
+	PromptNote             = "note"               // Note trigger classification and answers
+	PromptImageDescription = "image_description"  // AI_Icon_Image_Analysis on one image
+	PromptImageComparison  = "image_comparison"   // AI_Icon_Image_Analysis on two images
+	PromptCanvasAnalysis   = "canvas_analysis"    // AI_Icon_CanvusPrecis
+	PromptCollageTitle     = "collage_title"      // {{moodboard}} titles
+	PromptGroupSummary     = "group_summary"      // {{summarize}} in a group or anchor
+	PromptVoiceNoteSummary = "voice_note_summary" // Summaries of long audio recordings
+	PromptLiveSummary      = "live_summary"       // Rolling summaries of {{live}} transcripts
+)
+
+// languageInstruction is appended to prompts without a variant for the language.
+const languageInstruction = "\n\nWrite your response in %s. Keep JSON keys and fixed values such as \"type\" in English."
+
+var (
+	variantsMu sync.RWMutex
+	variants   = make(map[string]map[string]string) // language -> prompt name -> text
+)
+
+// Prompt returns the prompt name in lang. A variant registered for the
+// language is used as is; otherwise base is returned with an instruction
+// to respond in lang. English returns base unchanged.
+func Prompt(lang, name, base string) string {
+	lang = Normalize(lang)
+	variantsMu.RLock()
+	variant, ok := variants[lang][name]
+	variantsMu.RUnlock()
+	if ok {
+		return variant
+	}
+	if lang == "" || lang == English {
+		return base
+	}
+	return base + fmt.Sprintf(languageInstruction, Name(lang))
+}
+
+// SetPromptVariant registers the text of prompt name in lang.
+func SetPromptVariant(lang, name, text string) {
+	lang = Normalize(lang)
+	variantsMu.Lock()
+	defer variantsMu.Unlock()
+	if variants[lang] == nil {
+		variants[lang] = make(map[string]string)
+	}
+	variants[lang][name] = text
+}
+
+// LoadPromptVariants registers the variants in dir, stored as
+// dir/<language>/<prompt name>.txt (e.g. prompts/de/note.txt), and returns
+// how many were loaded. Empty files are skipped.
+func LoadPromptVariants(dir string) (int, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*.txt"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list prompt variants: %w", err)
+	}
+	loaded := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return loaded, fmt.Errorf("failed to read prompt variant %s: %w", file, err)
+		}
+		text := strings.TrimSpace(string(data))
+		if text == "" {
+			continue
+		}
+		lang := filepath.Base(filepath.Dir(file))
+		name := strings.TrimSuffix(filepath.Base(file), ".txt")
+		SetPromptVariant(lang, name, text)
+		loaded++
+	}
+	return loaded, nil
+}
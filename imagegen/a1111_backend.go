@@ -0,0 +1,132 @@
+// Package imagegen provides image generation utilities for the Canvus canvas.
+//
+// a1111_backend.go implements the A1111Backend molecule that generates
+// images with the txt2img API of an AUTOMATIC1111 (or Forge) server.
+package imagegen
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"go_backend/sdruntime"
+)
+
+// a1111Path is the txt2img endpoint of the AUTOMATIC1111 API (started with
+// --api).
+const a1111Path = "/sdapi/v1/txt2img"
+
+// maxBackendResponseBytes bounds a response of an external service.
+const maxBackendResponseBytes = 128 << 20
+
+// A1111Backend implements Backend with an AUTOMATIC1111 server.
+//
+// Thread Safety: A1111Backend is safe for concurrent use; the server
+// queues concurrent requests itself.
+type A1111Backend struct {
+	config BackendConfig
+	http   *http.Client
+}
+
+// NewA1111Backend creates a backend for the server at cfg.URL. The fields
+// of cfg.Template are added to every request, after the generation
+// parameters, so a template can set sampler_name or override_settings.
+func NewA1111Backend(cfg BackendConfig) *A1111Backend {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	return &A1111Backend{config: cfg, http: httpClient}
+}
+
+// a1111Response is the txt2img response.
+type a1111Response struct {
+	Images []string `json:"images"`
+}
+
+// a1111Error is an error response of the API.
+type a1111Error struct {
+	Error  string `json:"error"`
+	Detail string `json:"detail"`
+	Errors string `json:"errors"`
+}
+
+// Generate generates an image and returns its PNG data.
+func (b *A1111Backend) Generate(ctx context.Context, params sdruntime.GenerateParams) ([]byte, error) {
+	if b.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.config.Timeout)
+		defer cancel()
+	}
+
+	request := map[string]interface{}{
+		"prompt":          params.Prompt,
+		"negative_prompt": params.NegativePrompt,
+		"width":           params.Width,
+		"height":          params.Height,
+		"steps":           params.Steps,
+		"cfg_scale":       params.CFGScale,
+		"seed":            params.Seed,
+		"batch_size":      1,
+		"n_iter":          1,
+	}
+	if b.config.Checkpoint != "" {
+		request["override_settings"] = map[string]interface{}{"sd_model_checkpoint": b.config.Checkpoint}
+	}
+	values := templateValues(params, b.config.Checkpoint)
+	for key, value := range b.config.Template {
+		request[key] = fillTemplate(value, values)
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("imagegen: a1111: encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.config.URL+a1111Path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("imagegen: a1111: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("imagegen: a1111: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBackendResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("imagegen: a1111: read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr a1111Error
+		json.Unmarshal(data, &apiErr)
+		message := strings.TrimSpace(strings.Join([]string{apiErr.Error, apiErr.Detail, apiErr.Errors}, " "))
+		if message == "" {
+			message = fmt.Sprintf("%s: %s", resp.Status, bytes.TrimSpace(data))
+		}
+		return nil, backendError("a1111", message)
+	}
+
+	var result a1111Response
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("imagegen: a1111: decode response: %w", err)
+	}
+	if len(result.Images) == 0 {
+		return nil, backendError("a1111", "no image in response")
+	}
+	image, err := base64.StdEncoding.DecodeString(result.Images[0])
+	if err != nil {
+		return nil, fmt.Errorf("imagegen: a1111: decode image: %w", err)
+	}
+	return image, nil
+}
+
+// IsLoaded reports true: AUTOMATIC1111 keeps its model loaded.
+func (b *A1111Backend) IsLoaded() bool {
+	return true
+}
@@ -0,0 +1,61 @@
+package imagegen
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go_backend/sdruntime"
+)
+
+func TestA1111Backend_Generate(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != a1111Path {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		json.NewEncoder(w).Encode(a1111Response{Images: []string{base64.StdEncoding.EncodeToString([]byte("png"))}})
+	}))
+	defer server.Close()
+
+	backend := NewA1111Backend(BackendConfig{
+		Kind:       BackendA1111,
+		URL:        server.URL,
+		Checkpoint: "sdxl.safetensors",
+		Template:   map[string]interface{}{"sampler_name": "DPM++ 2M Karras", "hr_prompt": "{{prompt}}, detailed"},
+	})
+	image, err := backend.Generate(context.Background(), sdruntime.GenerateParams{Prompt: "a fox", Width: 512, Height: 512, Steps: 20, CFGScale: 7, Seed: -1})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if string(image) != "png" {
+		t.Errorf("image = %q", image)
+	}
+	if got["prompt"] != "a fox" || got["steps"] != float64(20) || got["seed"] != float64(-1) {
+		t.Errorf("request = %v", got)
+	}
+	if got["sampler_name"] != "DPM++ 2M Karras" || got["hr_prompt"] != "a fox, detailed" {
+		t.Errorf("template fields not applied: %v", got)
+	}
+	if settings, _ := got["override_settings"].(map[string]interface{}); settings["sd_model_checkpoint"] != "sdxl.safetensors" {
+		t.Errorf("override_settings = %v", got["override_settings"])
+	}
+}
+
+func TestA1111Backend_OutOfMemory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(a1111Error{Error: "OutOfMemoryError", Errors: "CUDA out of memory. Tried to allocate 2.00 GiB"})
+	}))
+	defer server.Close()
+
+	_, err := NewA1111Backend(BackendConfig{URL: server.URL}).Generate(context.Background(), sdruntime.GenerateParams{Prompt: "a fox"})
+	if !errors.Is(err, sdruntime.ErrOutOfVRAM) {
+		t.Errorf("err = %v, want ErrOutOfVRAM", err)
+	}
+}
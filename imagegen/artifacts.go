@@ -0,0 +1,98 @@
+// Package imagegen provides image generation utilities for the Canvus canvas.
+//
+// artifacts.go contains the artifactKeeper molecule which keeps generated
+// images in an artifact store and in the variant history of their trigger
+// note after they are uploaded.
+package imagegen
+
+import (
+	"context"
+	"sync"
+
+	"go_backend/artifacts"
+	"go_backend/logging"
+	"go_backend/variants"
+
+	"go.uber.org/zap"
+)
+
+// artifactKeeper keeps generated images in an optional artifact store and
+// variant history. The zero value keeps nothing.
+type artifactKeeper struct {
+	mu       sync.RWMutex
+	store    *artifacts.Store
+	variants *variants.History
+	canvasID string
+}
+
+// set sets the store and the canvas recorded with kept images.
+func (k *artifactKeeper) set(store *artifacts.Store, canvasID string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.store = store
+	k.canvasID = canvasID
+}
+
+// setVariants sets the variant history and the canvas recorded with it.
+func (k *artifactKeeper) setVariants(history *variants.History, canvasID string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.variants = history
+	k.canvasID = canvasID
+}
+
+// get returns the store, variant history and canvas.
+func (k *artifactKeeper) get() (*artifacts.Store, *variants.History, string) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.store, k.variants, k.canvasID
+}
+
+// keep stores a generated image, uploaded as widgetID, with how it was
+// generated, which is nil for cloud providers. The image is recorded as a
+// variant of sourceWidgetID, linked to the kept artifact if any. Failures
+// are logged; the generation itself is not affected.
+func (k *artifactKeeper) keep(ctx context.Context, name, prompt, sourceWidgetID, widgetID string, data []byte, generation *artifacts.Generation, log *logging.Logger) {
+	store, history, canvasID := k.get()
+
+	var artifactID string
+	if store != nil {
+		artifactID = k.put(ctx, store, canvasID, name, prompt, sourceWidgetID, data, generation, log)
+	}
+	if history != nil {
+		v := variants.Variant{
+			CanvasID:   canvasID,
+			NoteID:     sourceWidgetID,
+			WidgetID:   widgetID,
+			ArtifactID: artifactID,
+			Prompt:     prompt,
+		}
+		if generation != nil {
+			v.Seed = generation.Seed
+		}
+		if err := history.Record(ctx, v, data); err != nil {
+			log.Warn("failed to record image variant", zap.Error(err))
+		}
+	}
+}
+
+// put stores a generated image in store and returns its artifact ID, or ""
+// if it could not be stored.
+func (k *artifactKeeper) put(ctx context.Context, store *artifacts.Store, canvasID, name, prompt, sourceWidgetID string, data []byte, generation *artifacts.Generation, log *logging.Logger) string {
+	kept, err := store.Put(ctx, artifacts.Artifact{
+		Kind:           artifacts.KindImage,
+		Name:           name,
+		CanvasID:       canvasID,
+		SourceWidgetID: sourceWidgetID,
+		Prompt:         prompt,
+		Generation:     generation,
+	}, data)
+	if err != nil {
+		log.Warn("failed to keep generated image", zap.Error(err))
+		return ""
+	}
+	log.Debug("generated image kept",
+		zap.String("artifact_id", kept.ID),
+		zap.String("backend", store.Backend()))
+	return kept.ID
+}
@@ -0,0 +1,159 @@
+// Package imagegen provides image generation utilities for the Canvus canvas.
+//
+// aspect.go contains the aspect ratio of a generated image, taken from an
+// inline trigger ratio such as {{image:16:9 a fox}} or the shape of the
+// trigger note, and maps it to the sizes SD and DALL-E support.
+package imagegen
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go_backend/sdruntime"
+)
+
+// AspectRatio is the width-to-height ratio of an image, e.g. 16:9. The
+// zero value means no preference: the configured size is used.
+type AspectRatio struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// aspectRatios are the ratios a trigger can name and a note shape snaps to.
+var aspectRatios = []AspectRatio{
+	{1, 1}, {5, 4}, {4, 5}, {4, 3}, {3, 4}, {3, 2}, {2, 3},
+	{16, 9}, {9, 16}, {7, 4}, {4, 7}, {21, 9}, {9, 21},
+}
+
+// sdSizeMultiple is the multiple SD image dimensions are rounded to; SD
+// models are trained on multiples of 64.
+const sdSizeMultiple = 64
+
+// squareTolerance is how far from square a note can be and still be
+// treated as square, so notes resized slightly to fit their text keep the
+// configured size.
+const squareTolerance = 1.25
+
+// leadingAspectPattern matches a ratio at the start of a prompt: "16:9 a fox".
+var leadingAspectPattern = regexp.MustCompile(`^\s*(\d{1,2}):(\d{1,2})(?:\s+|$)`)
+
+// ParseAspectRatio parses a ratio such as "16:9". Only the ratios in
+// aspectRatios are accepted, so prompts starting with a time such as
+// "12:30" are left alone.
+func ParseAspectRatio(s string) (AspectRatio, bool) {
+	w, h, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return AspectRatio{}, false
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil {
+		return AspectRatio{}, false
+	}
+	ratio := AspectRatio{Width: width, Height: height}
+	for _, a := range aspectRatios {
+		if a == ratio {
+			return ratio, true
+		}
+	}
+	return AspectRatio{}, false
+}
+
+// AspectFromSize returns the supported ratio nearest to a note's shape, or
+// the zero AspectRatio for a roughly square note.
+func AspectFromSize(size WidgetSize) AspectRatio {
+	if size.Width <= 0 || size.Height <= 0 {
+		return AspectRatio{}
+	}
+	r := size.Width / size.Height
+	if r < squareTolerance && r > 1/squareTolerance {
+		return AspectRatio{}
+	}
+	return nearestRatio(r, aspectRatios)
+}
+
+// IsZero reports whether the ratio is unset.
+func (a AspectRatio) IsZero() bool {
+	return a.Width <= 0 || a.Height <= 0
+}
+
+// String formats the ratio as "16:9".
+func (a AspectRatio) String() string {
+	return fmt.Sprintf("%d:%d", a.Width, a.Height)
+}
+
+// nearestRatio returns the candidate whose ratio is closest to r, comparing
+// logarithms so 2:1 and 1:2 are equally far from square.
+func nearestRatio(r float64, candidates []AspectRatio) AspectRatio {
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if math.Abs(math.Log(r/c.ratio())) < math.Abs(math.Log(r/best.ratio())) {
+			best = c
+		}
+	}
+	return best
+}
+
+// ratio returns width divided by height.
+func (a AspectRatio) ratio() float64 {
+	return float64(a.Width) / float64(a.Height)
+}
+
+// SDSize returns the SD image size with this ratio and about the pixel
+// count of a base x base image, in multiples of 64 within the sizes
+// sdruntime accepts. A zero ratio returns base x base.
+func (a AspectRatio) SDSize(base int) (width, height int) {
+	if a.IsZero() {
+		return base, base
+	}
+	area := float64(base) * float64(base)
+	width = roundToMultiple(math.Sqrt(area*a.ratio()), sdSizeMultiple)
+	height = roundToMultiple(math.Sqrt(area/a.ratio()), sdSizeMultiple)
+	return width, height
+}
+
+// roundToMultiple rounds v to the nearest multiple of m within the sizes
+// sdruntime accepts.
+func roundToMultiple(v float64, m int) int {
+	n := int(math.Round(v/float64(m))) * m
+	if n < sdruntime.MinImageSize {
+		return sdruntime.MinImageSize
+	}
+	if n > sdruntime.MaxImageSize {
+		return sdruntime.MaxImageSize
+	}
+	return n
+}
+
+// OpenAISize returns the OpenAI or Azure image size nearest to this ratio
+// for model: 1024x1024, 1792x1024 or 1024x1792 for DALL-E 3, and
+// 1024x1024, 1536x1024 or 1024x1536 for gpt-image-1. It returns "" for a
+// zero ratio and for DALL-E 2, which only makes square images.
+func (a AspectRatio) OpenAISize(model string) string {
+	lower := strings.ToLower(model)
+	if a.IsZero() || strings.Contains(lower, "dall-e-2") || strings.Contains(lower, "dalle2") {
+		return ""
+	}
+	sizes := []AspectRatio{{1024, 1024}, {1792, 1024}, {1024, 1792}}
+	if strings.HasPrefix(lower, "gpt-image") {
+		sizes = []AspectRatio{{1024, 1024}, {1536, 1024}, {1024, 1536}}
+	}
+	best := nearestRatio(a.ratio(), sizes)
+	return fmt.Sprintf("%dx%d", best.Width, best.Height)
+}
+
+// parseLeadingAspect removes a leading ratio such as "16:9" from prompt.
+func parseLeadingAspect(prompt string) (string, AspectRatio) {
+	m := leadingAspectPattern.FindStringSubmatch(prompt)
+	if m == nil {
+		return prompt, AspectRatio{}
+	}
+	aspect, ok := ParseAspectRatio(m[1] + ":" + m[2])
+	if !ok {
+		return prompt, AspectRatio{}
+	}
+	return prompt[len(m[0]):], aspect
+}
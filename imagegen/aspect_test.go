@@ -0,0 +1,87 @@
+package imagegen
+
+import "testing"
+
+func TestParseAspectRatio(t *testing.T) {
+	tests := []struct {
+		in   string
+		want AspectRatio
+		ok   bool
+	}{
+		{"16:9", AspectRatio{16, 9}, true},
+		{" 9:16 ", AspectRatio{9, 16}, true},
+		{"1:1", AspectRatio{1, 1}, true},
+		{"12:30", AspectRatio{}, false},
+		{"16x9", AspectRatio{}, false},
+		{"a:b", AspectRatio{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseAspectRatio(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseAspectRatio(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAspectFromSize(t *testing.T) {
+	tests := []struct {
+		size WidgetSize
+		want AspectRatio
+	}{
+		{WidgetSize{300, 300}, AspectRatio{}},
+		{WidgetSize{330, 300}, AspectRatio{}},
+		{WidgetSize{640, 360}, AspectRatio{16, 9}},
+		{WidgetSize{200, 600}, AspectRatio{9, 21}},
+		{WidgetSize{400, 300}, AspectRatio{4, 3}},
+		{WidgetSize{0, 300}, AspectRatio{}},
+	}
+	for _, tt := range tests {
+		if got := AspectFromSize(tt.size); got != tt.want {
+			t.Errorf("AspectFromSize(%v) = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestAspectRatio_SDSize(t *testing.T) {
+	tests := []struct {
+		aspect        AspectRatio
+		base          int
+		width, height int
+	}{
+		{AspectRatio{}, 512, 512, 512},
+		{AspectRatio{1, 1}, 512, 512, 512},
+		{AspectRatio{16, 9}, 512, 704, 384},
+		{AspectRatio{9, 16}, 512, 384, 704},
+		{AspectRatio{21, 9}, 1024, 1536, 640},
+		{AspectRatio{9, 21}, 128, 128, 192},
+	}
+	for _, tt := range tests {
+		w, h := tt.aspect.SDSize(tt.base)
+		if w != tt.width || h != tt.height {
+			t.Errorf("%v.SDSize(%d) = %dx%d, want %dx%d", tt.aspect, tt.base, w, h, tt.width, tt.height)
+		}
+		if w%8 != 0 || h%8 != 0 {
+			t.Errorf("%v.SDSize(%d) = %dx%d, not divisible by 8", tt.aspect, tt.base, w, h)
+		}
+	}
+}
+
+func TestAspectRatio_OpenAISize(t *testing.T) {
+	tests := []struct {
+		aspect AspectRatio
+		model  string
+		want   string
+	}{
+		{AspectRatio{16, 9}, "dall-e-3", "1792x1024"},
+		{AspectRatio{9, 16}, "dall-e-3", "1024x1792"},
+		{AspectRatio{5, 4}, "dall-e-3", "1024x1024"},
+		{AspectRatio{3, 2}, "gpt-image-1", "1536x1024"},
+		{AspectRatio{16, 9}, "dall-e-2", ""},
+		{AspectRatio{}, "dall-e-3", ""},
+	}
+	for _, tt := range tests {
+		if got := tt.aspect.OpenAISize(tt.model); got != tt.want {
+			t.Errorf("%v.OpenAISize(%q) = %q, want %q", tt.aspect, tt.model, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ package imagegen
 
 import (
 	"strings"
+	"unicode"
 )
 
 // IsAzureEndpoint checks if the given endpoint URL is an Azure OpenAI endpoint.
@@ -81,3 +82,76 @@ func IsLocalEndpoint(endpoint string) bool {
 		strings.Contains(lower, "192.168.") ||
 		strings.Contains(lower, "10.")
 }
+
+// Hosted image services, named by the prefix of a hosted model.
+const (
+	ServiceStability = "stability"
+	ServiceReplicate = "replicate"
+)
+
+// SplitHostedModel splits a model name of the form "<service>:<model>"
+// into the hosted service and its model. The model may be empty to use
+// the service default. ok is false for models of other providers.
+//
+// Example:
+//
+//	SplitHostedModel("stability:ultra")                          // "stability", "ultra", true
+//	SplitHostedModel("replicate:black-forest-labs/flux-schnell") // "replicate", "black-forest-labs/flux-schnell", true
+//	SplitHostedModel("stability")                                // "stability", "", true
+//	SplitHostedModel("dall-e-3")                                 // "", "", false
+func SplitHostedModel(model string) (service, name string, ok bool) {
+	service, name, _ = strings.Cut(strings.TrimSpace(model), ":")
+	service = strings.ToLower(service)
+	if service != ServiceStability && service != ServiceReplicate {
+		return "", "", false
+	}
+	return service, strings.TrimSpace(name), true
+}
+
+// IsHostedModel reports whether model names a Stability AI or Replicate
+// model rather than an OpenAI, Azure or local one.
+func IsHostedModel(model string) bool {
+	_, _, ok := SplitHostedModel(model)
+	return ok
+}
+
+// ParseImageDirective parses the content of an image trigger. It accepts
+// "image: <prompt>" and "image@<model>: <prompt>", where the model runs up
+// to the first whitespace and may itself contain colons. The model is
+// empty when the trigger does not name one. ok is false when content is
+// not an image directive or the prompt is empty.
+//
+// Example:
+//
+//	ParseImageDirective("image: a fox")                 // "a fox", "", true
+//	ParseImageDirective("image@stability:ultra: a fox") // "a fox", "stability:ultra", true
+//	ParseImageDirective("Image@local: a fox")           // "a fox", "local", true
+//	ParseImageDirective("explain foxes")                // "", "", false
+func ParseImageDirective(content string) (prompt, model string, ok bool) {
+	content = strings.TrimSpace(content)
+	if len(content) < len("image") || !strings.EqualFold(content[:len("image")], "image") {
+		return "", "", false
+	}
+	rest := content[len("image"):]
+	switch {
+	case strings.HasPrefix(rest, ":"):
+		prompt = rest[1:]
+	case strings.HasPrefix(rest, "@"):
+		end := strings.IndexFunc(rest, unicode.IsSpace)
+		if end == -1 {
+			return "", "", false
+		}
+		model = strings.TrimSuffix(rest[1:end], ":")
+		if model == "" {
+			return "", "", false
+		}
+		prompt = rest[end:]
+	default:
+		return "", "", false
+	}
+	prompt = strings.TrimSpace(prompt)
+	if prompt == "" {
+		return "", "", false
+	}
+	return prompt, model, true
+}
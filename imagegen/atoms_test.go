@@ -205,3 +205,46 @@ func BenchmarkIsLocalEndpoint(b *testing.B) {
 		IsLocalEndpoint(endpoint)
 	}
 }
+
+func TestSplitHostedModel(t *testing.T) {
+	tests := []struct {
+		model, service, name string
+		ok                   bool
+	}{
+		{"stability:ultra", ServiceStability, "ultra", true},
+		{"Stability", ServiceStability, "", true},
+		{"replicate:stability-ai/sdxl:5599ed30", ServiceReplicate, "stability-ai/sdxl:5599ed30", true},
+		{"dall-e-3", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		service, name, ok := SplitHostedModel(tt.model)
+		if service != tt.service || name != tt.name || ok != tt.ok {
+			t.Errorf("SplitHostedModel(%q) = %q, %q, %v", tt.model, service, name, ok)
+		}
+	}
+}
+
+func TestParseImageDirective(t *testing.T) {
+	tests := []struct {
+		content, prompt, model string
+		ok                     bool
+	}{
+		{"image: a fox", "a fox", "", true},
+		{" IMAGE:a fox ", "a fox", "", true},
+		{"image@stability: a fox", "a fox", "stability", true},
+		{"image@replicate:black-forest-labs/flux-schnell: a fox\nin snow", "a fox\nin snow", "replicate:black-forest-labs/flux-schnell", true},
+		{"Image@local a fox", "a fox", "local", true},
+		{"image@stability:", "", "", false},
+		{"image@: a fox", "", "", false},
+		{"image:   ", "", "", false},
+		{"images of foxes", "", "", false},
+		{"explain foxes", "", "", false},
+	}
+	for _, tt := range tests {
+		prompt, model, ok := ParseImageDirective(tt.content)
+		if prompt != tt.prompt || model != tt.model || ok != tt.ok {
+			t.Errorf("ParseImageDirective(%q) = %q, %q, %v", tt.content, prompt, model, ok)
+		}
+	}
+}
@@ -32,6 +32,7 @@ type AzureProvider struct {
 	client     *openai.Client
 	config     *core.Config
 	deployment string
+	options    ImageOptions
 }
 
 // AzureProviderConfig holds configuration specific to the Azure provider.
@@ -50,6 +51,12 @@ type AzureProviderConfig struct {
 	// APIVersion is the Azure API version (optional)
 	// Default: 2024-02-15-preview
 	APIVersion string
+
+	// Size, Quality and Style are the image parameters (default: the
+	// API's size and quality, vivid style)
+	Size    string
+	Quality string
+	Style   string
 }
 
 // NewAzureProvider creates a new Azure OpenAI image generation provider.
@@ -107,6 +114,7 @@ func NewAzureProvider(cfg *core.Config) (*AzureProvider, error) {
 		client:     openai.NewClientWithConfig(clientConfig),
 		config:     cfg,
 		deployment: deployment,
+		options:    ImageOptionsFromConfig(cfg).withDefaults(),
 	}, nil
 }
 
@@ -143,10 +151,12 @@ func NewAzureProviderWithConfig(providerCfg AzureProviderConfig, coreCfg *core.C
 		clientConfig.HTTPClient = core.GetHTTPClient(coreCfg, coreCfg.AITimeout)
 	}
 
+	options := ImageOptions{Size: providerCfg.Size, Quality: providerCfg.Quality, Style: providerCfg.Style}
 	return &AzureProvider{
 		client:     openai.NewClientWithConfig(clientConfig),
 		config:     coreCfg,
 		deployment: providerCfg.Deployment,
+		options:    options.withDefaults(),
 	}, nil
 }
 
@@ -169,42 +179,40 @@ func NewAzureProviderWithConfig(providerCfg AzureProviderConfig, coreCfg *core.C
 //
 // Note: The returned URL is temporary and should be downloaded promptly.
 func (p *AzureProvider) Generate(ctx context.Context, prompt string) (string, error) {
-	if prompt == "" {
-		return "", fmt.Errorf("imagegen: prompt cannot be empty")
-	}
+	url, _, err := p.GenerateRevised(ctx, prompt)
+	return url, err
+}
 
-	// Build image request - Azure uses deployment name as model
-	req := openai.ImageRequest{
-		Prompt:         prompt,
-		Model:          p.deployment,
-		ResponseFormat: openai.CreateImageResponseFormatURL,
-		N:              1,
+// GenerateRevised is Generate that also returns the revised prompt a
+// DALL-E 3 deployment generated the image from.
+func (p *AzureProvider) GenerateRevised(ctx context.Context, prompt string) (string, string, error) {
+	if prompt == "" {
+		return "", "", fmt.Errorf("imagegen: prompt cannot be empty")
 	}
 
-	// Add style parameter only for DALL-E deployments
-	// Azure's gpt-image-1 deployment doesn't support style parameter
-	if isDalleDeployment(p.deployment) {
-		req.Style = openai.CreateImageStyleVivid
-	}
+	// Build image request - Azure uses deployment name as model. The
+	// style parameter is only sent to DALL-E deployments; Azure's
+	// gpt-image-1 deployment doesn't support it
+	req := p.options.request(prompt, p.deployment)
 
 	// Call Azure OpenAI API
 	response, err := p.client.CreateImage(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("imagegen: Azure image generation failed: %w", err)
+		return "", "", fmt.Errorf("imagegen: Azure image generation failed: %w", err)
 	}
 
 	// Validate response
 	if response.Data == nil {
-		return "", fmt.Errorf("imagegen: Azure returned nil Data field")
+		return "", "", fmt.Errorf("imagegen: Azure returned nil Data field")
 	}
 	if len(response.Data) == 0 {
-		return "", fmt.Errorf("imagegen: Azure returned empty Data array")
+		return "", "", fmt.Errorf("imagegen: Azure returned empty Data array")
 	}
 	if response.Data[0].URL == "" {
-		return "", fmt.Errorf("imagegen: Azure returned empty image URL")
+		return "", "", fmt.Errorf("imagegen: Azure returned empty image URL")
 	}
 
-	return response.Data[0].URL, nil
+	return response.Data[0].URL, response.Data[0].RevisedPrompt, nil
 }
 
 // Deployment returns the configured Azure deployment name.
@@ -227,5 +235,5 @@ func isDalleDeployment(deployment string) bool {
 		strings.Contains(lower, "dalle-3")
 }
 
-// Ensure AzureProvider implements Provider interface at compile time.
-var _ Provider = (*AzureProvider)(nil)
+// Ensure AzureProvider implements RevisingProvider interface at compile time.
+var _ RevisingProvider = (*AzureProvider)(nil)
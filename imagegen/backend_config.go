@@ -0,0 +1,178 @@
+// Package imagegen provides image generation utilities for the Canvus canvas.
+//
+// backend_config.go configures the external Stable Diffusion services a
+// Processor can generate with instead of the embedded stable-diffusion.cpp.
+package imagegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"go_backend/core"
+	"go_backend/sdruntime"
+)
+
+// BackendKind names an external Stable Diffusion service.
+type BackendKind string
+
+const (
+	// BackendLocal generates with the embedded SD runtime
+	BackendLocal BackendKind = "local"
+	// BackendComfyUI queues a workflow on a ComfyUI server
+	BackendComfyUI BackendKind = "comfyui"
+	// BackendA1111 calls the txt2img API of AUTOMATIC1111 (or Forge)
+	BackendA1111 BackendKind = "a1111"
+)
+
+// BackendConfig configures an external Stable Diffusion service.
+type BackendConfig struct {
+	// Kind of service (default: local)
+	Kind BackendKind
+
+	// URL of the service: http://host:8188 for ComfyUI, http://host:7860
+	// for AUTOMATIC1111
+	URL string
+
+	// Template is the ComfyUI workflow in API format, or extra fields of
+	// the AUTOMATIC1111 txt2img request. Strings may hold the placeholders
+	// {{prompt}}, {{negative_prompt}}, {{width}}, {{height}}, {{steps}},
+	// {{cfg_scale}}, {{seed}} and {{checkpoint}}. Nil uses the built-in
+	// ComfyUI txt2img workflow.
+	Template map[string]interface{}
+
+	// Checkpoint is the model file the built-in ComfyUI workflow loads, or
+	// the checkpoint AUTOMATIC1111 switches to (optional there)
+	Checkpoint string
+
+	// Timeout bounds one image (default: SD_TIMEOUT_SECONDS)
+	Timeout time.Duration
+
+	// HTTPClient sends the requests (default: a plain client)
+	HTTPClient *http.Client
+}
+
+// External reports whether images are generated by an external service.
+func (c BackendConfig) External() bool {
+	return c.Kind == BackendComfyUI || c.Kind == BackendA1111
+}
+
+// BackendConfigFromEnv reads SD_BACKEND, SD_BACKEND_URL,
+// SD_BACKEND_TEMPLATE (path to a JSON file), SD_BACKEND_CHECKPOINT and
+// SD_BACKEND_TIMEOUT (seconds). An invalid value is returned as an error
+// together with a config for the embedded runtime.
+func BackendConfigFromEnv() (BackendConfig, error) {
+	local := BackendConfig{Kind: BackendLocal}
+	cfg := BackendConfig{
+		Kind:       BackendKind(strings.ToLower(strings.TrimSpace(core.GetEnvOrDefault("SD_BACKEND", string(BackendLocal))))),
+		URL:        strings.TrimRight(strings.TrimSpace(os.Getenv("SD_BACKEND_URL")), "/"),
+		Checkpoint: os.Getenv("SD_BACKEND_CHECKPOINT"),
+		Timeout:    core.ParseDurationEnv("SD_BACKEND_TIMEOUT", int(sdruntime.LoadSDConfig().Timeout/time.Second)),
+	}
+	switch cfg.Kind {
+	case BackendLocal:
+		return local, nil
+	case BackendComfyUI, BackendA1111:
+	default:
+		return local, fmt.Errorf("imagegen: unknown SD_BACKEND %q (use local, comfyui or a1111)", cfg.Kind)
+	}
+
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return local, fmt.Errorf("imagegen: SD_BACKEND=%s needs SD_BACKEND_URL=http://host:port", cfg.Kind)
+	}
+	if path := os.Getenv("SD_BACKEND_TEMPLATE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return local, fmt.Errorf("imagegen: read SD_BACKEND_TEMPLATE: %w", err)
+		}
+		if err := json.Unmarshal(data, &cfg.Template); err != nil {
+			return local, fmt.Errorf("imagegen: SD_BACKEND_TEMPLATE %s is not a JSON object: %w", path, err)
+		}
+	}
+	if cfg.Kind == BackendComfyUI && cfg.Template == nil && cfg.Checkpoint == "" {
+		return local, fmt.Errorf("imagegen: the built-in ComfyUI workflow needs SD_BACKEND_CHECKPOINT (a file in ComfyUI's models/checkpoints)")
+	}
+	return cfg, nil
+}
+
+// NewBackend creates the Backend for an external service.
+func NewBackend(cfg BackendConfig) (Backend, error) {
+	switch cfg.Kind {
+	case BackendComfyUI:
+		return NewComfyUIBackend(cfg), nil
+	case BackendA1111:
+		return NewA1111Backend(cfg), nil
+	}
+	return nil, fmt.Errorf("imagegen: %q is not an external backend", cfg.Kind)
+}
+
+// templateValues returns the placeholder values for params.
+func templateValues(params sdruntime.GenerateParams, checkpoint string) map[string]interface{} {
+	return map[string]interface{}{
+		"prompt":          params.Prompt,
+		"negative_prompt": params.NegativePrompt,
+		"width":           params.Width,
+		"height":          params.Height,
+		"steps":           params.Steps,
+		"cfg_scale":       params.CFGScale,
+		"seed":            params.Seed,
+		"checkpoint":      checkpoint,
+	}
+}
+
+// fillTemplate returns a copy of v with its placeholders replaced. A string
+// that is a single placeholder takes the value's type, so "{{steps}}"
+// becomes a number; placeholders within longer strings are replaced by
+// their text.
+func fillTemplate(v interface{}, values map[string]interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = fillTemplate(item, values)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = fillTemplate(item, values)
+		}
+		return out
+	case string:
+		if name, ok := placeholder(v); ok {
+			if value, ok := values[name]; ok {
+				return value
+			}
+		}
+		for name, value := range values {
+			v = strings.ReplaceAll(v, "{{"+name+"}}", fmt.Sprint(value))
+		}
+		return v
+	}
+	return v
+}
+
+// placeholder returns the name of s if s is a single placeholder.
+func placeholder(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "{{") || !strings.HasSuffix(s, "}}") {
+		return "", false
+	}
+	name := strings.TrimSpace(s[2 : len(s)-2])
+	return name, name != "" && !strings.ContainsAny(name, "{}")
+}
+
+// backendError classifies an error message of an external service, so an
+// out-of-memory failure is retried with the VRAM retry ladder.
+func backendError(service, message string) error {
+	lower := strings.ToLower(message)
+	if strings.Contains(lower, "out of memory") || strings.Contains(lower, "outofmemory") {
+		return fmt.Errorf("imagegen: %s: %w: %s", service, sdruntime.ErrOutOfVRAM, message)
+	}
+	return fmt.Errorf("imagegen: %s: %w: %s", service, sdruntime.ErrGenerationFailed, message)
+}
@@ -0,0 +1,52 @@
+package imagegen
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBackendConfigFromEnv(t *testing.T) {
+	t.Setenv("SD_BACKEND", "")
+	cfg, err := BackendConfigFromEnv()
+	if err != nil || cfg.External() {
+		t.Errorf("default = %+v, %v; want the embedded runtime", cfg, err)
+	}
+
+	template := filepath.Join(t.TempDir(), "workflow.json")
+	os.WriteFile(template, []byte(`{"3": {"class_type": "KSampler", "inputs": {"seed": "{{seed}}"}}}`), 0644)
+	t.Setenv("SD_BACKEND", "ComfyUI")
+	t.Setenv("SD_BACKEND_URL", "http://gpu-box:8188/")
+	t.Setenv("SD_BACKEND_TEMPLATE", template)
+	cfg, err = BackendConfigFromEnv()
+	if err != nil {
+		t.Fatalf("BackendConfigFromEnv: %v", err)
+	}
+	if cfg.Kind != BackendComfyUI || cfg.URL != "http://gpu-box:8188" || cfg.Template["3"] == nil {
+		t.Errorf("config = %+v", cfg)
+	}
+	if _, err := NewBackend(cfg); err != nil {
+		t.Errorf("NewBackend: %v", err)
+	}
+
+	tests := []struct {
+		name, backend, url, template string
+	}{
+		{"unknown backend", "invokeai", "http://gpu-box:8188", ""},
+		{"missing URL", "a1111", "", ""},
+		{"built-in workflow without checkpoint", "comfyui", "http://gpu-box:8188", ""},
+		{"missing template", "comfyui", "http://gpu-box:8188", filepath.Join(t.TempDir(), "missing.json")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SD_BACKEND", tt.backend)
+			t.Setenv("SD_BACKEND_URL", tt.url)
+			t.Setenv("SD_BACKEND_TEMPLATE", tt.template)
+			t.Setenv("SD_BACKEND_CHECKPOINT", "")
+			cfg, err := BackendConfigFromEnv()
+			if err == nil || cfg.External() {
+				t.Errorf("BackendConfigFromEnv = %+v, %v; want an error and the embedded runtime", cfg, err)
+			}
+		})
+	}
+}
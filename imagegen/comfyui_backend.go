@@ -0,0 +1,249 @@
+// Package imagegen provides image generation utilities for the Canvus canvas.
+//
+// comfyui_backend.go implements the ComfyUIBackend molecule that generates
+// images by queueing a workflow on a ComfyUI server.
+package imagegen
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"go_backend/sdruntime"
+
+	"github.com/google/uuid"
+)
+
+// defaultComfyUIWorkflow is a txt2img workflow in ComfyUI's API format.
+//
+//go:embed comfyui_workflow.json
+var defaultComfyUIWorkflow []byte
+
+// comfyUIPollInterval is how often a queued workflow is checked.
+const comfyUIPollInterval = 500 * time.Millisecond
+
+// ComfyUIBackend implements Backend with a ComfyUI server. It queues the
+// configured workflow with the placeholders filled in, waits for it to
+// finish and downloads the first image it saved.
+//
+// Thread Safety: ComfyUIBackend is safe for concurrent use; the server
+// runs queued workflows one after the other.
+type ComfyUIBackend struct {
+	config       BackendConfig
+	http         *http.Client
+	workflow     map[string]interface{}
+	clientID     string
+	pollInterval time.Duration
+}
+
+// NewComfyUIBackend creates a backend for the server at cfg.URL running
+// cfg.Template, or the built-in workflow loading cfg.Checkpoint.
+func NewComfyUIBackend(cfg BackendConfig) *ComfyUIBackend {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	workflow := cfg.Template
+	if workflow == nil {
+		json.Unmarshal(defaultComfyUIWorkflow, &workflow)
+	}
+	return &ComfyUIBackend{
+		config:       cfg,
+		http:         httpClient,
+		workflow:     workflow,
+		clientID:     uuid.New().String(),
+		pollInterval: comfyUIPollInterval,
+	}
+}
+
+// comfyUIImage is an image a workflow saved.
+type comfyUIImage struct {
+	Filename  string `json:"filename"`
+	Subfolder string `json:"subfolder"`
+	Type      string `json:"type"`
+}
+
+// comfyUIHistory is the /history entry of a queued workflow.
+type comfyUIHistory struct {
+	Outputs map[string]struct {
+		Images []comfyUIImage `json:"images"`
+	} `json:"outputs"`
+	Status struct {
+		StatusStr string `json:"status_str"`
+		Completed bool   `json:"completed"`
+		// Messages are [type, data] pairs; an execution_error carries
+		// the exception
+		Messages [][]json.RawMessage `json:"messages"`
+	} `json:"status"`
+}
+
+// Generate runs the workflow and returns the PNG data of its first image.
+func (b *ComfyUIBackend) Generate(ctx context.Context, params sdruntime.GenerateParams) ([]byte, error) {
+	if b.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.config.Timeout)
+		defer cancel()
+	}
+	// ComfyUI seeds must not be negative
+	if params.Seed < 0 {
+		params.Seed = sdruntime.RandomSeed()
+	}
+
+	promptID, err := b.queue(ctx, fillTemplate(b.workflow, templateValues(params, b.config.Checkpoint)))
+	if err != nil {
+		return nil, err
+	}
+	image, err := b.wait(ctx, promptID)
+	if err != nil {
+		return nil, err
+	}
+	return b.download(ctx, image)
+}
+
+// IsLoaded reports true: ComfyUI loads models as workflows need them.
+func (b *ComfyUIBackend) IsLoaded() bool {
+	return true
+}
+
+// queue submits workflow and returns its prompt ID.
+func (b *ComfyUIBackend) queue(ctx context.Context, workflow interface{}) (string, error) {
+	body, err := json.Marshal(map[string]interface{}{"prompt": workflow, "client_id": b.clientID})
+	if err != nil {
+		return "", fmt.Errorf("imagegen: comfyui: encode workflow: %w", err)
+	}
+	var resp struct {
+		PromptID string `json:"prompt_id"`
+		Error    *struct {
+			Message string `json:"message"`
+			Details string `json:"details"`
+		} `json:"error"`
+		NodeErrors map[string]interface{} `json:"node_errors"`
+	}
+	status, err := b.do(ctx, http.MethodPost, "/prompt", bytes.NewReader(body), &resp)
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusOK || resp.PromptID == "" {
+		message := fmt.Sprintf("workflow rejected (HTTP %d)", status)
+		if resp.Error != nil {
+			message = resp.Error.Message + " " + resp.Error.Details
+		}
+		if len(resp.NodeErrors) > 0 {
+			nodes, _ := json.Marshal(resp.NodeErrors)
+			message += " " + string(nodes)
+		}
+		return "", backendError("comfyui", message)
+	}
+	return resp.PromptID, nil
+}
+
+// wait polls the history of promptID until the workflow finishes and
+// returns its first image.
+func (b *ComfyUIBackend) wait(ctx context.Context, promptID string) (comfyUIImage, error) {
+	ticker := time.NewTicker(b.pollInterval)
+	defer ticker.Stop()
+	for {
+		var history map[string]comfyUIHistory
+		if _, err := b.do(ctx, http.MethodGet, "/history/"+url.PathEscape(promptID), nil, &history); err != nil {
+			return comfyUIImage{}, err
+		}
+		if entry, ok := history[promptID]; ok {
+			if entry.Status.StatusStr == "error" {
+				return comfyUIImage{}, backendError("comfyui", executionError(entry))
+			}
+			if entry.Status.Completed {
+				// Outputs are keyed by node ID; take the first that saved an image
+				nodes := make([]string, 0, len(entry.Outputs))
+				for node := range entry.Outputs {
+					nodes = append(nodes, node)
+				}
+				sort.Strings(nodes)
+				for _, node := range nodes {
+					if images := entry.Outputs[node].Images; len(images) > 0 {
+						return images[0], nil
+					}
+				}
+				return comfyUIImage{}, backendError("comfyui", "workflow finished without saving an image")
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return comfyUIImage{}, fmt.Errorf("imagegen: comfyui: waiting for workflow %s: %w", promptID, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// executionError returns the exception a failed workflow reported.
+func executionError(entry comfyUIHistory) string {
+	for _, msg := range entry.Status.Messages {
+		var kind string
+		if len(msg) != 2 || json.Unmarshal(msg[0], &kind) != nil || kind != "execution_error" {
+			continue
+		}
+		var data struct {
+			NodeType         string `json:"node_type"`
+			ExceptionType    string `json:"exception_type"`
+			ExceptionMessage string `json:"exception_message"`
+		}
+		if json.Unmarshal(msg[1], &data) == nil {
+			return fmt.Sprintf("%s in %s: %s", data.ExceptionType, data.NodeType, data.ExceptionMessage)
+		}
+	}
+	return "workflow failed"
+}
+
+// download fetches a saved image.
+func (b *ComfyUIBackend) download(ctx context.Context, image comfyUIImage) ([]byte, error) {
+	query := url.Values{"filename": {image.Filename}, "subfolder": {image.Subfolder}, "type": {image.Type}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.config.URL+"/view?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("imagegen: comfyui: %w", err)
+	}
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("imagegen: comfyui: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, backendError("comfyui", fmt.Sprintf("download %s: %s", image.Filename, resp.Status))
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBackendResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("imagegen: comfyui: download %s: %w", image.Filename, err)
+	}
+	return data, nil
+}
+
+// do sends a request and decodes the JSON response into out, returning
+// the HTTP status.
+func (b *ComfyUIBackend) do(ctx context.Context, method, path string, body io.Reader, out interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, b.config.URL+path, body)
+	if err != nil {
+		return 0, fmt.Errorf("imagegen: comfyui: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := b.http.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("imagegen: comfyui: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBackendResponseBytes))
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("imagegen: comfyui: read response: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, fmt.Errorf("imagegen: comfyui: decode %s: %w", path, err)
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,120 @@
+package imagegen
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go_backend/sdruntime"
+)
+
+// fakeComfyUI is a ComfyUI server that finishes a workflow after polls
+// history requests, or fails it with failure.
+type fakeComfyUI struct {
+	mu       sync.Mutex
+	workflow map[string]interface{}
+	polls    int
+	failure  string
+}
+
+func (f *fakeComfyUI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.URL.Path == "/prompt":
+		var body struct {
+			Prompt map[string]interface{} `json:"prompt"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.workflow = body.Prompt
+		json.NewEncoder(w).Encode(map[string]string{"prompt_id": "p-1"})
+	case r.URL.Path == "/history/p-1":
+		if f.polls--; f.polls > 0 {
+			w.Write([]byte("{}"))
+			return
+		}
+		if f.failure != "" {
+			w.Write([]byte(`{"p-1": {"outputs": {}, "status": {"status_str": "error", "completed": false, "messages": [
+				["execution_start", {"prompt_id": "p-1"}],
+				["execution_error", {"node_type": "KSampler", "exception_type": "torch.OutOfMemoryError", "exception_message": "` + f.failure + `"}]]}}}`))
+			return
+		}
+		w.Write([]byte(`{"p-1": {"outputs": {"9": {"images": [{"filename": "canvuslocallm_00001_.png", "subfolder": "", "type": "output"}]}},
+			"status": {"status_str": "success", "completed": true, "messages": []}}}`))
+	case r.URL.Path == "/view":
+		if r.URL.Query().Get("filename") != "canvuslocallm_00001_.png" || r.URL.Query().Get("type") != "output" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("png"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestComfyUIBackend_Generate(t *testing.T) {
+	fake := &fakeComfyUI{polls: 2}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	backend := NewComfyUIBackend(BackendConfig{Kind: BackendComfyUI, URL: server.URL, Checkpoint: "v1-5.safetensors"})
+	backend.pollInterval = time.Millisecond
+	image, err := backend.Generate(context.Background(), sdruntime.GenerateParams{Prompt: "a fox", Width: 768, Height: 512, Steps: 25, CFGScale: 6.5, Seed: -1})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if string(image) != "png" {
+		t.Errorf("image = %q", image)
+	}
+
+	// The built-in workflow gets the parameters with their JSON types
+	inputs := func(node string) map[string]interface{} {
+		return fake.workflow[node].(map[string]interface{})["inputs"].(map[string]interface{})
+	}
+	if inputs("6")["text"] != "a fox" || inputs("4")["ckpt_name"] != "v1-5.safetensors" {
+		t.Errorf("prompt or checkpoint not filled in: %v", fake.workflow)
+	}
+	if inputs("5")["width"] != float64(768) || inputs("3")["steps"] != float64(25) || inputs("3")["cfg"] != 6.5 {
+		t.Errorf("numbers not filled in: %v %v", inputs("5"), inputs("3"))
+	}
+	if seed, ok := inputs("3")["seed"].(float64); !ok || seed < 0 {
+		t.Errorf("seed = %v, want a random non-negative seed", inputs("3")["seed"])
+	}
+}
+
+func TestComfyUIBackend_ExecutionError(t *testing.T) {
+	server := httptest.NewServer(&fakeComfyUI{polls: 1, failure: "Allocation on device 0 would exceed allowed memory. (out of memory)"})
+	defer server.Close()
+
+	backend := NewComfyUIBackend(BackendConfig{URL: server.URL, Checkpoint: "v1-5.safetensors"})
+	_, err := backend.Generate(context.Background(), sdruntime.GenerateParams{Prompt: "a fox"})
+	if !errors.Is(err, sdruntime.ErrOutOfVRAM) {
+		t.Errorf("err = %v, want ErrOutOfVRAM", err)
+	}
+}
+
+func TestFillTemplate(t *testing.T) {
+	values := templateValues(sdruntime.GenerateParams{Prompt: "a fox", Steps: 20}, "model.ckpt")
+	got := fillTemplate(map[string]interface{}{
+		"steps":  "{{steps}}",
+		"text":   "photo of {{prompt}}, {{steps}} steps",
+		"list":   []interface{}{"{{ checkpoint }}", 3},
+		"other":  "{{unknown}}",
+		"number": 1.5,
+	}, values).(map[string]interface{})
+
+	if got["steps"] != 20 || got["text"] != "photo of a fox, 20 steps" || got["number"] != 1.5 {
+		t.Errorf("filled = %v", got)
+	}
+	if list := got["list"].([]interface{}); list[0] != "model.ckpt" || list[1] != 3 {
+		t.Errorf("list = %v", list)
+	}
+	if got["other"] != "{{unknown}}" {
+		t.Errorf("unknown placeholder = %v, want it kept", got["other"])
+	}
+}
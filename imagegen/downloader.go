@@ -10,6 +10,7 @@ package imagegen
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"net/http"
@@ -25,7 +26,9 @@ import (
 //
 // Image generation providers (OpenAI, Azure) return temporary URLs
 // that expire after about 1 hour. This molecule downloads the image
-// data and saves it locally before uploading to Canvus.
+// data and saves it locally before uploading to Canvus. Providers that
+// return the image itself (Stability AI) pass it as a data: URL, which is
+// decoded instead of fetched.
 //
 // Thread Safety: Downloader is safe for concurrent use.
 // Each download creates its own HTTP request.
@@ -169,6 +172,9 @@ func (d *Downloader) Download(ctx context.Context, url string, filename string)
 	if filename == "" {
 		return nil, fmt.Errorf("imagegen: filename cannot be empty")
 	}
+	if isDataURL(url) {
+		return d.saveDataURL(url, filename)
+	}
 
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -236,6 +242,9 @@ func (d *Downloader) DownloadBytes(ctx context.Context, url string) ([]byte, str
 	if url == "" {
 		return nil, "", fmt.Errorf("imagegen: URL cannot be empty")
 	}
+	if isDataURL(url) {
+		return decodeDataURL(url)
+	}
 
 	// Create HTTP request
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
@@ -264,6 +273,48 @@ func (d *Downloader) DownloadBytes(ctx context.Context, url string) ([]byte, str
 	return data, resp.Header.Get("Content-Type"), nil
 }
 
+// saveDataURL saves the image in a data: URL like Download saves a
+// fetched one.
+func (d *Downloader) saveDataURL(url string, filename string) (*DownloadResult, error) {
+	data, contentType, err := decodeDataURL(url)
+	if err != nil {
+		return nil, err
+	}
+	ext := extensionFromContentType(contentType)
+	if ext == "" {
+		ext = ".png"
+	}
+	fullPath := filepath.Join(d.downloadsDir, sanitizeFilename(filename)+ext)
+	if err := os.WriteFile(fullPath, data, 0644); err != nil {
+		os.Remove(fullPath)
+		return nil, fmt.Errorf("imagegen: failed to write image data: %w", err)
+	}
+	return &DownloadResult{
+		Path:        fullPath,
+		Size:        int64(len(data)),
+		ContentType: contentType,
+	}, nil
+}
+
+// isDataURL reports whether url carries its data inline.
+func isDataURL(url string) bool {
+	return strings.HasPrefix(strings.ToLower(url), "data:")
+}
+
+// decodeDataURL returns the data and media type of a base64 data: URL,
+// e.g. "data:image/png;base64,iVBORw0...".
+func decodeDataURL(url string) ([]byte, string, error) {
+	header, payload, ok := strings.Cut(url[len("data:"):], ",")
+	if !ok || !strings.HasSuffix(strings.ToLower(header), ";base64") {
+		return nil, "", fmt.Errorf("imagegen: unsupported data URL; only base64 data is accepted")
+	}
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, "", fmt.Errorf("imagegen: failed to decode data URL: %w", err)
+	}
+	return data, header[:len(header)-len(";base64")], nil
+}
+
 // DownloadsDir returns the configured downloads directory.
 func (d *Downloader) DownloadsDir() string {
 	return d.downloadsDir
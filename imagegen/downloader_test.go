@@ -2,6 +2,7 @@ package imagegen
 
 import (
 	"context"
+	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -189,6 +190,29 @@ func TestDownload_Success(t *testing.T) {
 	}
 }
 
+// TestDownload_DataURL tests that data: URLs are decoded instead of fetched.
+func TestDownload_DataURL(t *testing.T) {
+	downloader, err := NewDownloader(&core.Config{DownloadsDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("unexpected error creating downloader: %v", err)
+	}
+
+	result, err := downloader.Download(context.Background(), "data:image/jpeg;base64,"+base64.StdEncoding.EncodeToString([]byte("jpeg")), "stability")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if filepath.Ext(result.Path) != ".jpg" || result.Size != 4 || result.ContentType != "image/jpeg" {
+		t.Errorf("result = %+v", result)
+	}
+	if data, _ := os.ReadFile(result.Path); string(data) != "jpeg" {
+		t.Errorf("file content = %q", data)
+	}
+
+	if _, err := downloader.Download(context.Background(), "data:text/plain,jpeg", "plain"); err == nil {
+		t.Error("expected error for a data URL that is not base64")
+	}
+}
+
 // TestDownload_JPEGContentType tests JPEG content type handling.
 func TestDownload_JPEGContentType(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -4,7 +4,8 @@
 // image generation pipeline using cloud providers (OpenAI/Azure) and image download.
 //
 // This organism composes:
-//   - Provider interface: OpenAIProvider or AzureProvider for image generation
+//   - Provider interface: OpenAIProvider, AzureProvider, StabilityProvider or
+//     ReplicateProvider for image generation
 //   - Downloader: for downloading generated images from temporary URLs
 //   - canvusapi.Client: for canvas widget operations
 //   - logging.Logger: for structured logging
@@ -16,12 +17,18 @@ package imagegen
 import (
 	"context"
 	"fmt"
+	"image"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 
+	"go_backend/artifacts"
 	"go_backend/canvusapi"
 	"go_backend/core"
+	"go_backend/core/errs"
 	"go_backend/logging"
+	"go_backend/variants"
 
 	"go.uber.org/zap"
 )
@@ -42,6 +49,9 @@ type Generator struct {
 
 	// mu protects file operations in downloads directory
 	mu sync.Mutex
+
+	// artifacts keeps generated images (optional)
+	artifacts artifactKeeper
 }
 
 // GeneratorConfig holds configuration for the image generation generator.
@@ -58,6 +68,9 @@ type GeneratorConfig struct {
 	// CleanupTempFiles controls whether to delete temp files after upload
 	// Default: true
 	CleanupTempFiles bool
+
+	// Output controls the format and size of uploaded images
+	Output OutputOptions
 }
 
 // DefaultGeneratorConfig returns sensible default configuration.
@@ -67,6 +80,7 @@ func DefaultGeneratorConfig() GeneratorConfig {
 		PlacementConfig:  DefaultPlacementConfig(),
 		ProcessingNote:   DefaultProcessingNoteConfig(),
 		CleanupTempFiles: true,
+		Output:           DefaultOutputOptions(),
 	}
 }
 
@@ -121,11 +135,13 @@ func NewGenerator(provider Provider, downloader *Downloader, client *canvusapi.C
 //
 // This is a convenience constructor that:
 //  1. Detects the appropriate provider based on endpoint configuration
-//  2. Creates the provider (OpenAI or Azure)
+//  2. Creates the provider (OpenAI, Azure, Stability AI or Replicate)
 //  3. Creates a Downloader
 //  4. Assembles the Generator
 //
 // Provider selection logic:
+//   - If OpenAIImageModel is "stability:<model>" -> StabilityProvider
+//   - If OpenAIImageModel is "replicate:<owner/name>" -> ReplicateProvider
 //   - If AzureOpenAIEndpoint is set and is an Azure endpoint -> AzureProvider
 //   - If ImageLLMURL is an Azure endpoint -> AzureProvider
 //   - Otherwise -> OpenAIProvider
@@ -159,7 +175,14 @@ func NewGeneratorFromConfig(cfg *core.Config, client *canvusapi.Client, logger *
 		useAzure = true
 	}
 
-	if useAzure {
+	if IsHostedModel(cfg.OpenAIImageModel) {
+		log.Info("using hosted provider for image generation",
+			zap.String("model", cfg.OpenAIImageModel))
+		provider, err = NewHostedProvider(cfg, cfg.OpenAIImageModel)
+		if err != nil {
+			return nil, fmt.Errorf("imagegen: failed to create hosted provider: %w", err)
+		}
+	} else if useAzure {
 		log.Info("using Azure OpenAI provider for image generation",
 			zap.String("endpoint", cfg.AzureOpenAIEndpoint),
 			zap.String("deployment", cfg.AzureOpenAIDeployment))
@@ -188,10 +211,23 @@ func NewGeneratorFromConfig(cfg *core.Config, client *canvusapi.Client, logger *
 	if genConfig.DownloadsDir == "" {
 		genConfig.DownloadsDir = "downloads"
 	}
+	genConfig.Output = OutputOptionsFromConfig(cfg)
 
 	return NewGenerator(provider, downloader, client, logger, genConfig)
 }
 
+// SetArtifactStore keeps every generated image in store, recorded as
+// belonging to canvasID. A nil store keeps nothing.
+func (g *Generator) SetArtifactStore(store *artifacts.Store, canvasID string) {
+	g.artifacts.set(store, canvasID)
+}
+
+// SetImageVariants records every generated image in the variant history of
+// its trigger note. A nil history records nothing.
+func (g *Generator) SetImageVariants(history *variants.History) {
+	g.artifacts.setVariants(history, g.client.CanvasID)
+}
+
 // GenerateResult contains the result of image generation and upload.
 type GenerateResult struct {
 	// ImagePath is the local path to the downloaded image (may be cleaned up)
@@ -202,6 +238,10 @@ type GenerateResult struct {
 
 	// ImageURL is the temporary URL from the provider (may expire)
 	ImageURL string
+
+	// RevisedPrompt is the prompt the provider generated the image from
+	// when it rewrote the original (DALL-E 3), or ""
+	RevisedPrompt string
 }
 
 // Generate handles the end-to-end flow of generating an image from a prompt
@@ -241,7 +281,7 @@ func (g *Generator) Generate(ctx context.Context, prompt string, parentWidget Pa
 	if prompt == "" {
 		err := fmt.Errorf("imagegen: prompt cannot be empty")
 		log.Error("invalid prompt", zap.Error(err))
-		g.createErrorNote(ctx, parentWidget, "Prompt cannot be empty", log)
+		g.createErrorNote(ctx, parentWidget, "Prompt cannot be empty", errs.CodeInvalidPrompt, log)
 		return nil, err
 	}
 
@@ -266,17 +306,20 @@ func (g *Generator) Generate(ctx context.Context, prompt string, parentWidget Pa
 		g.updateProcessingNote(processingNoteID, "Generating image...\nThis may take 10-30 seconds.", log)
 	}
 
-	imageURL, err := g.provider.Generate(ctx, prompt)
+	imageURL, revisedPrompt, err := g.generate(ctx, prompt)
 	if err != nil {
 		log.Error("image generation failed", zap.Error(err))
 		if processingNoteID != "" {
 			g.updateProcessingNote(processingNoteID, fmt.Sprintf("Generation failed: %v", err), log)
 		}
-		g.createErrorNote(ctx, parentWidget, fmt.Sprintf("Image generation failed: %v", err), log)
+		g.createErrorNote(ctx, parentWidget, fmt.Sprintf("Image generation failed: %v", err), errs.CodeOr(err, errs.CodeImageGeneration), log)
 		return nil, fmt.Errorf("imagegen: generation failed: %w", err)
 	}
 
 	log.Debug("image generated successfully", zap.String("image_url", truncateText(imageURL, 100)))
+	if revisedPrompt != "" {
+		log.Info("provider revised the prompt", zap.String("revised_prompt", truncateText(revisedPrompt, 200)))
+	}
 
 	// Step 4: Download the image
 	if processingNoteID != "" {
@@ -287,7 +330,7 @@ func (g *Generator) Generate(ctx context.Context, prompt string, parentWidget Pa
 	downloadResult, err := g.downloader.Download(ctx, imageURL, filename)
 	if err != nil {
 		log.Error("failed to download image", zap.Error(err))
-		g.createErrorNote(ctx, parentWidget, fmt.Sprintf("Failed to download image: %v", err), log)
+		g.createErrorNote(ctx, parentWidget, fmt.Sprintf("Failed to download image: %v", err), errs.CodeOr(err, errs.CodeImageGeneration), log)
 		return nil, fmt.Errorf("imagegen: download failed: %w", err)
 	}
 
@@ -305,6 +348,8 @@ func (g *Generator) Generate(ctx context.Context, prompt string, parentWidget Pa
 		}()
 	}
 
+	imagePath = g.applyOutputOptions(imagePath, log)
+
 	// Step 5: Calculate placement
 	if processingNoteID != "" {
 		g.updateProcessingNote(processingNoteID, "Uploading image to canvas...", log)
@@ -322,9 +367,8 @@ func (g *Generator) Generate(ctx context.Context, prompt string, parentWidget Pa
 		log.Warn("failed to stat image file", zap.Error(err))
 	}
 
-	// Default size for DALL-E 3 is 1024x1024
-	width := 1024.0
-	height := 1024.0
+	// Size the widget like the image; 1024x1024 is the DALL-E default
+	width, height := imageDimensions(imagePath, 1024, 1024)
 
 	widgetPayload := map[string]interface{}{
 		"title": fmt.Sprintf("AI Generated: %s", truncateText(prompt, 50)),
@@ -343,7 +387,7 @@ func (g *Generator) Generate(ctx context.Context, prompt string, parentWidget Pa
 	response, err := g.client.CreateImage(imagePath, widgetPayload)
 	if err != nil {
 		log.Error("failed to upload image to canvas", zap.Error(err))
-		g.createErrorNote(ctx, parentWidget, fmt.Sprintf("Failed to upload image: %v", err), log)
+		g.createErrorNote(ctx, parentWidget, fmt.Sprintf("Failed to upload image: %v", err), errs.CodeOf(err), log)
 		return nil, fmt.Errorf("imagegen: failed to upload image: %w", err)
 	}
 
@@ -352,13 +396,79 @@ func (g *Generator) Generate(ctx context.Context, prompt string, parentWidget Pa
 		zap.String("widget_id", widgetID),
 		zap.Int64("file_size", fileInfo.Size()))
 
+	if data, err := os.ReadFile(imagePath); err == nil {
+		g.artifacts.keep(ctx, filepath.Base(imagePath), prompt, parentWidget.GetID(), widgetID, data, nil, log)
+	}
+
 	return &GenerateResult{
-		ImagePath: imagePath,
-		WidgetID:  widgetID,
-		ImageURL:  imageURL,
+		ImagePath:     imagePath,
+		WidgetID:      widgetID,
+		ImageURL:      imageURL,
+		RevisedPrompt: revisedPrompt,
 	}, nil
 }
 
+// generate asks the provider for an image, with the revised prompt if
+// the provider reports one.
+func (g *Generator) generate(ctx context.Context, prompt string) (string, string, error) {
+	if reviser, ok := g.provider.(RevisingProvider); ok {
+		return reviser.GenerateRevised(ctx, prompt)
+	}
+	url, err := g.provider.Generate(ctx, prompt)
+	return url, "", err
+}
+
+// imageDimensions returns the pixel size of the image at path, or the
+// fallback size if it cannot be read.
+func imageDimensions(path string, fallbackWidth, fallbackHeight float64) (float64, float64) {
+	file, err := os.Open(path)
+	if err != nil {
+		return fallbackWidth, fallbackHeight
+	}
+	defer file.Close()
+	config, _, err := image.DecodeConfig(file)
+	if err != nil || config.Width == 0 || config.Height == 0 {
+		return fallbackWidth, fallbackHeight
+	}
+	return float64(config.Width), float64(config.Height)
+}
+
+// applyOutputOptions converts the downloaded image to the configured format
+// and size and returns the path of the file to upload. If conversion fails
+// the original file is uploaded.
+func (g *Generator) applyOutputOptions(imagePath string, log *logging.Logger) string {
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		log.Warn("failed to read image for conversion", zap.Error(err))
+		return imagePath
+	}
+	encoded, err := EncodeForUpload(data, g.config.Output)
+	if err != nil {
+		log.Warn("failed to apply image output options, uploading original", zap.Error(err))
+		return imagePath
+	}
+	if !encoded.Resized && filepath.Ext(imagePath) == encoded.Format.Ext() {
+		return imagePath
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	converted := strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + encoded.Format.Ext()
+	if err := os.WriteFile(converted, encoded.Data, 0644); err != nil {
+		log.Warn("failed to save converted image, uploading original", zap.Error(err))
+		return imagePath
+	}
+	if converted != imagePath {
+		os.Remove(imagePath)
+	}
+	log.Debug("image converted for upload",
+		zap.String("format", string(encoded.Format)),
+		zap.Int("width", encoded.Width),
+		zap.Int("height", encoded.Height),
+		zap.Int("size_bytes", len(encoded.Data)))
+	return converted
+}
+
 // createProcessingNote creates a processing indicator note on the canvas.
 func (g *Generator) createProcessingNote(ctx context.Context, parent ParentWidget, text string, log *logging.Logger) (string, error) {
 	loc := parent.GetLocation()
@@ -407,10 +517,12 @@ func (g *Generator) updateProcessingNote(noteID, text string, log *logging.Logge
 }
 
 // createErrorNote creates an error note on the canvas to inform the user.
-func (g *Generator) createErrorNote(ctx context.Context, parent ParentWidget, errorMessage string, log *logging.Logger) {
+// The note ends with the error's code and what to do about it.
+func (g *Generator) createErrorNote(ctx context.Context, parent ParentWidget, errorMessage string, code errs.ErrCode, log *logging.Logger) {
 	loc := parent.GetLocation()
 
-	content := fmt.Sprintf("# Image Generation Error\n\n%s\n\nPlease try again or adjust your prompt.", errorMessage)
+	info := errs.Lookup(code)
+	content := fmt.Sprintf("# Image Generation Error\n\n%s\n\nCode %s: %s", errorMessage, info.Code, info.Hint)
 
 	payload := map[string]interface{}{
 		"title": "AI Image Generation Error",
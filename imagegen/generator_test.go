@@ -1,8 +1,11 @@
 package imagegen
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"image"
+	"image/png"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -530,3 +533,99 @@ func TestNewGeneratorFromConfig_NoAPIKey(t *testing.T) {
 		t.Error("expected error for missing API key")
 	}
 }
+
+func TestNewGeneratorFromConfig_SelectsHostedProvider(t *testing.T) {
+	logger := newTestLogger(t)
+	canvusServer := newMockCanvusServer()
+	defer canvusServer.Close()
+
+	client := canvusapi.NewClient(canvusServer.URL, "test-canvas", "test-key", false)
+	cfg := &core.Config{
+		StabilityAPIKey:   "sk-stability",
+		ReplicateAPIToken: "r8-test",
+		// Hosted models ignore the OpenAI and Azure settings
+		AzureOpenAIEndpoint:   "https://myresource.openai.azure.com/",
+		AzureOpenAIDeployment: "dalle3",
+		DownloadsDir:          t.TempDir(),
+	}
+
+	cfg.OpenAIImageModel = "stability:ultra"
+	generator, err := NewGeneratorFromConfig(cfg, client, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p, ok := generator.Provider().(*StabilityProvider); !ok || p.Model() != "ultra" {
+		t.Errorf("provider = %#v, want StabilityProvider for ultra", generator.Provider())
+	}
+
+	cfg.OpenAIImageModel = "replicate:"
+	generator, err = NewGeneratorFromConfig(cfg, client, logger)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p, ok := generator.Provider().(*ReplicateProvider); !ok || p.Model() != DefaultReplicateProviderConfig().Model {
+		t.Errorf("provider = %#v, want ReplicateProvider for the default model", generator.Provider())
+	}
+
+	cfg.StabilityAPIKey = ""
+	cfg.OpenAIImageModel = "stability:core"
+	if _, err := NewGeneratorFromConfig(cfg, client, logger); err == nil {
+		t.Error("expected error for missing Stability AI key")
+	}
+}
+
+// revisingProvider is a mockProvider whose service revises the prompt.
+type revisingProvider struct {
+	mockProvider
+	revisedPrompt string
+}
+
+func (m *revisingProvider) GenerateRevised(ctx context.Context, prompt string) (string, string, error) {
+	url, err := m.Generate(ctx, prompt)
+	return url, m.revisedPrompt, err
+}
+
+func TestGenerator_Generate_RevisedPrompt(t *testing.T) {
+	imageServer := newMockImageServer()
+	defer imageServer.Close()
+	canvusServer := newMockCanvusServer()
+	defer canvusServer.Close()
+
+	client := canvusapi.NewClient(canvusServer.URL, "test-canvas", "test-key", false)
+	downloader, err := NewDownloaderWithConfig(DownloaderConfig{DownloadsDir: t.TempDir()}, nil)
+	if err != nil {
+		t.Fatalf("failed to create downloader: %v", err)
+	}
+	provider := &revisingProvider{
+		mockProvider:  mockProvider{imageURL: imageServer.URL + "/image.png"},
+		revisedPrompt: "A photorealistic red fox sitting in fresh snow at dawn",
+	}
+	config := DefaultGeneratorConfig()
+	config.DownloadsDir = t.TempDir()
+	generator, err := NewGenerator(provider, downloader, client, newTestLogger(t), config)
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	result, err := generator.Generate(context.Background(), "a fox", CanvasWidget{ID: "parent-123", Scale: 1})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if result.RevisedPrompt != provider.revisedPrompt {
+		t.Errorf("RevisedPrompt = %q, want %q", result.RevisedPrompt, provider.revisedPrompt)
+	}
+}
+
+func TestImageDimensions(t *testing.T) {
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 16, 9)))
+	path := filepath.Join(t.TempDir(), "wide.png")
+	os.WriteFile(path, buf.Bytes(), 0644)
+
+	if w, h := imageDimensions(path, 1024, 1024); w != 16 || h != 9 {
+		t.Errorf("imageDimensions = %vx%v, want 16x9", w, h)
+	}
+	if w, h := imageDimensions(filepath.Join(t.TempDir(), "missing.png"), 1024, 1024); w != 1024 || h != 1024 {
+		t.Errorf("imageDimensions of a missing file = %vx%v, want the fallback", w, h)
+	}
+}
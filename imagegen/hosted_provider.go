@@ -0,0 +1,64 @@
+// Package imagegen provides image generation utilities for the Canvus canvas.
+//
+// hosted_provider.go selects the provider of a hosted model (Stability AI
+// or Replicate) and maps their HTTP failures to error codes.
+//
+// This molecule composes:
+//   - atoms.go: SplitHostedModel for model name parsing
+//   - stability_provider.go, replicate_provider.go: the providers
+//   - core/errs: for error codes
+package imagegen
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go_backend/core"
+	"go_backend/core/errs"
+)
+
+// maxHostedResponseBytes bounds a JSON response of a hosted service; a
+// Stability AI response carries the image itself.
+const maxHostedResponseBytes = 64 << 20
+
+// NewHostedProvider creates the provider for a hosted model of the form
+// "<service>:<model>", such as "stability:ultra" or
+// "replicate:black-forest-labs/flux-schnell".
+//
+// Returns an error if model is not a hosted model or the service's API
+// key is not configured.
+func NewHostedProvider(cfg *core.Config, model string) (Provider, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("imagegen: config cannot be nil")
+	}
+	service, name, ok := SplitHostedModel(model)
+	if !ok {
+		return nil, fmt.Errorf("imagegen: %q is not a hosted image model", model)
+	}
+	switch service {
+	case ServiceStability:
+		return NewStabilityProvider(cfg, name)
+	default:
+		return NewReplicateProvider(cfg, name)
+	}
+}
+
+// hostedError returns the error for a failed request to service, coded by
+// the HTTP status so the canvas error note explains what to fix.
+func hostedError(service string, status int, message string) error {
+	message = strings.TrimSpace(message)
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	code := errs.CodeImageGeneration
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		code = errs.CodeProviderAuth
+	case status == http.StatusTooManyRequests || status == http.StatusPaymentRequired:
+		code = errs.CodeRateLimited
+	case status >= http.StatusInternalServerError:
+		code = errs.CodeProviderServer
+	}
+	return errs.Wrap(code, fmt.Errorf("imagegen: %s: %s (HTTP %d)", service, message, status))
+}
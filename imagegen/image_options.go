@@ -0,0 +1,308 @@
+// Package imagegen provides image generation utilities for the Canvus canvas.
+//
+// image_options.go contains the parameters of an image: the model, size,
+// quality and style of OpenAI and Azure images, set with IMAGE_GEN_*
+// settings, and the steps, guidance scale and seed of SD images. Triggers
+// override them with inline flags such as "--size 1792x1024", pick an
+// aspect ratio with a leading "16:9" and ask for several images with
+// "--n 4".
+package imagegen
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"go_backend/core"
+	"go_backend/core/errs"
+	"go_backend/sdruntime"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// ImageOptions are the parameters of an image request. Empty fields keep
+// the configured value; options a model does not take are ignored.
+type ImageOptions struct {
+	// Model is the image model, e.g. dall-e-3 or gpt-image-1
+	Model string `json:"model,omitempty"`
+
+	// Size is WIDTHxHEIGHT, e.g. 1024x1024 or 1792x1024
+	Size string `json:"size,omitempty"`
+
+	// Quality is standard or hd for DALL-E 3, and low, medium, high or
+	// auto for gpt-image-1
+	Quality string `json:"quality,omitempty"`
+
+	// Style is vivid or natural (DALL-E 3 only)
+	Style string `json:"style,omitempty"`
+
+	// Aspect is the ratio named at the start of the prompt, e.g. 16:9
+	Aspect AspectRatio `json:"aspect"`
+
+	// Steps is the number of SD inference steps
+	Steps int `json:"steps,omitempty"`
+
+	// CFGScale is the SD guidance scale
+	CFGScale float64 `json:"cfg_scale,omitempty"`
+
+	// Seed is the SD seed; nil picks a random seed
+	Seed *int64 `json:"seed,omitempty"`
+
+	// N is how many images to generate (0 means one)
+	N int `json:"n,omitempty"`
+
+	// invalid describes a numeric flag whose value is not a number
+	invalid string
+}
+
+// Values accepted for each option, matching core.LoadConfig.
+var (
+	imageSizes     = []string{"256x256", "512x512", "1024x1024", "1792x1024", "1024x1792", "1536x1024", "1024x1536", "auto"}
+	imageQualities = []string{"standard", "hd", "low", "medium", "high", "auto"}
+	imageStyles    = []string{"vivid", "natural"}
+)
+
+// Limits of the SD guidance scale, matching SD_GUIDANCE_SCALE.
+const (
+	minCFGScale = 1.0
+	maxCFGScale = 20.0
+)
+
+// imageFlags are the inline flags of an image trigger.
+var imageFlags = []string{"model", "size", "quality", "style", "steps", "cfg", "seed", "n"}
+
+// ImageOptionsFromConfig returns the image options set in cfg.
+func ImageOptionsFromConfig(cfg *core.Config) ImageOptions {
+	if cfg == nil {
+		return ImageOptions{}
+	}
+	return ImageOptions{
+		Model:   cfg.OpenAIImageModel,
+		Size:    cfg.ImageGenSize,
+		Quality: cfg.ImageGenQuality,
+		Style:   cfg.ImageGenStyle,
+	}
+}
+
+// ParseImageFlags removes the --model, --size, --quality, --style,
+// --steps, --cfg, --seed and --n flags and a leading aspect ratio from an
+// image prompt and returns the remaining prompt and the options they set.
+// Flag values are lower-cased except the model; use Validate or
+// ValidateSD to check them.
+//
+// Example:
+//
+//	ParseImageFlags("16:9 a red fox --steps 30 --quality hd")
+//	// "a red fox", ImageOptions{Aspect: AspectRatio{16, 9}, Steps: 30, Quality: "hd"}
+func ParseImageFlags(prompt string) (string, ImageOptions) {
+	var opts ImageOptions
+	prompt, opts.Aspect = parseLeadingAspect(prompt)
+	remaining, flags := extractFlags(prompt, imageFlags...)
+
+	opts.Model = flags["model"]
+	opts.Size = strings.ToLower(flags["size"])
+	opts.Quality = strings.ToLower(flags["quality"])
+	opts.Style = strings.ToLower(flags["style"])
+
+	number := func(name string, parse func(string) error) {
+		if value, ok := flags[name]; ok && parse(value) != nil && opts.invalid == "" {
+			opts.invalid = fmt.Sprintf("--%s %s is not a number", name, value)
+		}
+	}
+	number("steps", func(v string) (err error) { opts.Steps, err = strconv.Atoi(v); return err })
+	number("cfg", func(v string) (err error) { opts.CFGScale, err = strconv.ParseFloat(v, 64); return err })
+	number("n", func(v string) (err error) { opts.N, err = strconv.Atoi(v); return err })
+	number("seed", func(v string) error {
+		seed, err := strconv.ParseInt(v, 10, 64)
+		if err == nil {
+			opts.Seed = &seed
+		}
+		return err
+	})
+	return remaining, opts
+}
+
+// extractFlags removes the named "--flag value" and "--flag=value"
+// flags from a prompt and returns the remaining prompt and the flag
+// values, keyed by lower-cased name. Flag names match case-insensitively,
+// the last value of a repeated flag wins, and other "--" words stay in the
+// prompt.
+//
+// Example:
+//
+//	prompt, flags := extractFlags("a red fox --steps 30 --seed=7", "steps", "seed")
+//	// "a red fox", map[steps:30 seed:7]
+func extractFlags(prompt string, names ...string) (string, map[string]string) {
+	flags := make(map[string]string)
+	if len(names) == 0 {
+		return strings.TrimSpace(prompt), flags
+	}
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	pattern := regexp.MustCompile(`(?i)(?:^|\s)--(` + strings.Join(quoted, "|") + `)(?:=|\s+)(\S+)`)
+	remaining := pattern.ReplaceAllStringFunc(prompt, func(flag string) string {
+		m := pattern.FindStringSubmatch(flag)
+		flags[strings.ToLower(m[1])] = m[2]
+		return ""
+	})
+	return strings.TrimSpace(remaining), flags
+}
+
+// Validate checks the options of a cloud image against cfg: the size,
+// quality and style the OpenAI and Azure APIs take, and --n against
+// IMAGE_MAX_COUNT. The error is coded as an invalid prompt, since invalid
+// values come from trigger flags.
+func (o ImageOptions) Validate(cfg *core.Config) error {
+	if err := o.validateCommon(cfg); err != nil {
+		return err
+	}
+	if err := checkChoice("size", o.Size, imageSizes); err != nil {
+		return err
+	}
+	if err := checkChoice("quality", o.Quality, imageQualities); err != nil {
+		return err
+	}
+	return checkChoice("style", o.Style, imageStyles)
+}
+
+// ValidateSD checks the options of a Stable Diffusion image against cfg:
+// --size up to SD_MAX_IMAGE_SIZE in multiples of 8, --steps up to
+// SD_MAX_STEPS, --cfg between 1 and 20, and --n against IMAGE_MAX_COUNT.
+// A --model is refused, since the runtime generates with the model it
+// loaded; callers route prompts naming a hosted model elsewhere first.
+func (o ImageOptions) ValidateSD(cfg *core.Config) error {
+	if err := o.validateCommon(cfg); err != nil {
+		return err
+	}
+	if o.Model != "" {
+		return invalidOption(fmt.Sprintf("--model %s is not available for Stable Diffusion images, which use SD_MODEL_PATH", o.Model))
+	}
+	if o.Size != "" {
+		width, height, ok := ParseImageSize(o.Size)
+		if !ok || width%8 != 0 || height%8 != 0 ||
+			width < sdruntime.MinImageSize || height < sdruntime.MinImageSize ||
+			width > cfg.SDMaxImageSize || height > cfg.SDMaxImageSize {
+			return invalidOption(fmt.Sprintf("unsupported image size %q (use WIDTHxHEIGHT in multiples of 8, from %d to %d)", o.Size, sdruntime.MinImageSize, cfg.SDMaxImageSize))
+		}
+	}
+	if o.Steps != 0 && (o.Steps < 1 || o.Steps > cfg.SDMaxSteps) {
+		return invalidOption(fmt.Sprintf("--steps must be between 1 and %d, got %d", cfg.SDMaxSteps, o.Steps))
+	}
+	if o.CFGScale != 0 && (o.CFGScale < minCFGScale || o.CFGScale > maxCFGScale) {
+		return invalidOption(fmt.Sprintf("--cfg must be between %.0f and %.0f, got %g", minCFGScale, maxCFGScale, o.CFGScale))
+	}
+	return nil
+}
+
+// validateCommon checks the options both kinds of image take.
+func (o ImageOptions) validateCommon(cfg *core.Config) error {
+	if o.invalid != "" {
+		return invalidOption(o.invalid)
+	}
+	if o.N != 0 && (o.N < 1 || o.N > cfg.ImageMaxCount) {
+		return invalidOption(fmt.Sprintf("--n must be between 1 and %d, got %d", cfg.ImageMaxCount, o.N))
+	}
+	return nil
+}
+
+// checkChoice checks that a non-empty value is one of allowed.
+func checkChoice(name, value string, allowed []string) error {
+	if value == "" {
+		return nil
+	}
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return invalidOption(fmt.Sprintf("unsupported image %s %q (use %s)", name, value, strings.Join(allowed, ", ")))
+}
+
+// invalidOption returns the error for an invalid trigger flag.
+func invalidOption(message string) error {
+	return errs.Wrap(errs.CodeInvalidPrompt, fmt.Errorf("imagegen: %s", message))
+}
+
+// Count returns how many images to generate.
+func (o ImageOptions) Count() int {
+	if o.N < 1 {
+		return 1
+	}
+	return o.N
+}
+
+// ParseImageSize parses a WIDTHxHEIGHT size.
+func ParseImageSize(size string) (width, height int, ok bool) {
+	w, h, found := strings.Cut(size, "x")
+	if !found {
+		return 0, 0, false
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	return width, height, errW == nil && errH == nil && width > 0 && height > 0
+}
+
+// Apply returns a copy of cfg with the non-empty options set.
+func (o ImageOptions) Apply(cfg *core.Config) *core.Config {
+	applied := *cfg
+	override := func(dst *string, value string) {
+		if value != "" {
+			*dst = value
+		}
+	}
+	override(&applied.OpenAIImageModel, o.Model)
+	override(&applied.ImageGenSize, o.Size)
+	override(&applied.ImageGenQuality, o.Quality)
+	override(&applied.ImageGenStyle, o.Style)
+	return &applied
+}
+
+// FitAspect returns the options with the size set from the aspect ratio
+// when no --size was given: the ratio named in the prompt, or else the
+// shape of the trigger note. model is the model the image runs on. A
+// square note and DALL-E 2 keep the configured size.
+func (o ImageOptions) FitAspect(noteSize WidgetSize, model string) ImageOptions {
+	if o.Size != "" {
+		return o
+	}
+	aspect := o.Aspect
+	if aspect.IsZero() {
+		aspect = AspectFromSize(noteSize)
+	}
+	o.Size = aspect.OpenAISize(model)
+	return o
+}
+
+// withDefaults returns the options with the vivid DALL-E 3 style when
+// none is set.
+func (o ImageOptions) withDefaults() ImageOptions {
+	if o.Style == "" {
+		o.Style = openai.CreateImageStyleVivid
+	}
+	return o
+}
+
+// request returns the image request for prompt on model, leaving out the
+// parameters the model does not support: DALL-E 2 takes neither quality
+// nor style, and only DALL-E 3 takes a style.
+func (o ImageOptions) request(prompt, model string) openai.ImageRequest {
+	req := openai.ImageRequest{
+		Prompt:         prompt,
+		Model:          model,
+		Size:           o.Size,
+		ResponseFormat: openai.CreateImageResponseFormatURL,
+		N:              1,
+	}
+	lower := strings.ToLower(model)
+	if strings.Contains(lower, "dall-e-2") || strings.Contains(lower, "dalle2") {
+		return req
+	}
+	req.Quality = o.Quality
+	if isDalleDeployment(model) {
+		req.Style = o.Style
+	}
+	return req
+}
@@ -0,0 +1,174 @@
+package imagegen
+
+import (
+	"reflect"
+	"testing"
+
+	"go_backend/core"
+	"go_backend/core/errs"
+)
+
+func TestParseImageFlags(t *testing.T) {
+	tests := []struct {
+		prompt string
+		want   string
+		opts   ImageOptions
+	}{
+		{"a red fox", "a red fox", ImageOptions{}},
+		{"a red fox --size 1792x1024 --quality HD", "a red fox", ImageOptions{Size: "1792x1024", Quality: "hd"}},
+		{"--style=natural a red fox\nin snow --model dall-e-3", "a red fox\nin snow", ImageOptions{Model: "dall-e-3", Style: "natural"}},
+		{"a fox--size 512x512", "a fox--size 512x512", ImageOptions{}},
+		{"a fox --strength 0.5", "a fox --strength 0.5", ImageOptions{}},
+		{"16:9 a red fox --quality hd", "a red fox", ImageOptions{Quality: "hd", Aspect: AspectRatio{16, 9}}},
+		{"12:30 lunch meeting", "12:30 lunch meeting", ImageOptions{}},
+		{"a fox --steps 30 --CFG 7.5 --n=2", "a fox", ImageOptions{Steps: 30, CFGScale: 7.5, N: 2}},
+	}
+	for _, tt := range tests {
+		got, opts := ParseImageFlags(tt.prompt)
+		if got != tt.want || opts != tt.opts {
+			t.Errorf("ParseImageFlags(%q) = %q, %+v; want %q, %+v", tt.prompt, got, opts, tt.want, tt.opts)
+		}
+	}
+}
+
+func TestImageOptions_Validate(t *testing.T) {
+	cfg := &core.Config{ImageMaxCount: 4}
+	if err := (ImageOptions{Size: "1024x1792", Quality: "hd", Style: "natural", N: 4}).Validate(cfg); err != nil {
+		t.Errorf("valid options: %v", err)
+	}
+	_, notANumber := ParseImageFlags("a fox --n two")
+	for _, opts := range []ImageOptions{{Size: "800x600"}, {Quality: "ultra"}, {Style: "sepia"}, {N: 5}, notANumber} {
+		if err := opts.Validate(cfg); errs.CodeOf(err) != errs.CodeInvalidPrompt {
+			t.Errorf("Validate(%+v) = %v, want an invalid prompt error", opts, err)
+		}
+	}
+}
+
+func TestImageOptions_Apply(t *testing.T) {
+	base := &core.Config{OpenAIImageModel: "dall-e-3", ImageGenSize: "1024x1024", ImageGenStyle: "vivid"}
+	cfg := ImageOptions{Size: "1792x1024", Quality: "hd"}.Apply(base)
+	if cfg.OpenAIImageModel != "dall-e-3" || cfg.ImageGenSize != "1792x1024" || cfg.ImageGenQuality != "hd" || cfg.ImageGenStyle != "vivid" {
+		t.Errorf("applied = %+v", ImageOptionsFromConfig(cfg))
+	}
+	if base.ImageGenSize != "1024x1024" {
+		t.Error("Apply modified the base config")
+	}
+}
+
+func TestImageOptions_Request(t *testing.T) {
+	opts := ImageOptions{Size: "1792x1024", Quality: "hd", Style: "natural"}
+	tests := []struct {
+		model, quality, style string
+	}{
+		{"dall-e-3", "hd", "natural"},
+		{"dalle3-deployment", "hd", "natural"},
+		{"dall-e-2", "", ""},
+		{"gpt-image-1", "hd", ""},
+	}
+	for _, tt := range tests {
+		req := opts.request("a fox", tt.model)
+		if req.Model != tt.model || req.Size != "1792x1024" || req.Quality != tt.quality || req.Style != tt.style {
+			t.Errorf("request for %s = %+v", tt.model, req)
+		}
+	}
+}
+
+func TestImageOptions_FitAspect(t *testing.T) {
+	wide := WidgetSize{Width: 640, Height: 360}
+	square := WidgetSize{Width: 300, Height: 300}
+	tests := []struct {
+		name string
+		opts ImageOptions
+		note WidgetSize
+		want string
+	}{
+		{"size flag wins", ImageOptions{Size: "1024x1024", Aspect: AspectRatio{16, 9}}, wide, "1024x1024"},
+		{"trigger ratio", ImageOptions{Aspect: AspectRatio{9, 16}}, wide, "1024x1792"},
+		{"note shape", ImageOptions{}, wide, "1792x1024"},
+		{"square note keeps config", ImageOptions{}, square, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.opts.FitAspect(tt.note, "dall-e-3").Size; got != tt.want {
+			t.Errorf("%s: size = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseImageFlags_Seed(t *testing.T) {
+	_, opts := ParseImageFlags("a fox --seed 0")
+	if opts.Seed == nil || *opts.Seed != 0 {
+		t.Errorf("--seed 0 = %v, want 0", opts.Seed)
+	}
+	if _, opts := ParseImageFlags("a fox"); opts.Seed != nil {
+		t.Errorf("no --seed = %v, want nil", *opts.Seed)
+	}
+}
+
+func TestImageOptions_ValidateSD(t *testing.T) {
+	cfg := &core.Config{SDMaxImageSize: 1024, SDMaxSteps: 50, ImageMaxCount: 4}
+	if err := (ImageOptions{Size: "768x512", Steps: 50, CFGScale: 7.5, N: 2}).ValidateSD(cfg); err != nil {
+		t.Errorf("valid options: %v", err)
+	}
+	for _, opts := range []ImageOptions{
+		{Size: "1792x1024"},
+		{Size: "770x512"},
+		{Size: "big"},
+		{Steps: 51},
+		{CFGScale: 25},
+		{N: 9},
+		{Model: "sdxl-turbo"},
+	} {
+		if err := opts.ValidateSD(cfg); errs.CodeOf(err) != errs.CodeInvalidPrompt {
+			t.Errorf("ValidateSD(%+v) = %v, want an invalid prompt error", opts, err)
+		}
+	}
+}
+
+func TestExtractFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		flags    map[string]string
+	}{
+		{
+			name:     "no flags",
+			input:    "a red fox",
+			expected: "a red fox",
+			flags:    map[string]string{},
+		},
+		{
+			name:     "space and equals forms",
+			input:    "a red fox --steps 30 --seed=7",
+			expected: "a red fox",
+			flags:    map[string]string{"steps": "30", "seed": "7"},
+		},
+		{
+			name:     "case-insensitive, last value wins",
+			input:    "--STEPS 10 a red fox --steps 20",
+			expected: "a red fox",
+			flags:    map[string]string{"steps": "20"},
+		},
+		{
+			name:     "unknown flags stay",
+			input:    "a red fox --strength 0.5",
+			expected: "a red fox --strength 0.5",
+			flags:    map[string]string{},
+		},
+		{
+			name:     "flag must start a word",
+			input:    "a fox--steps 30",
+			expected: "a fox--steps 30",
+			flags:    map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, flags := extractFlags(tt.input, "steps", "seed")
+			if result != tt.expected || !reflect.DeepEqual(flags, tt.flags) {
+				t.Errorf("extractFlags(%q) = %q, %v; want %q, %v", tt.input, result, flags, tt.expected, tt.flags)
+			}
+		})
+	}
+}
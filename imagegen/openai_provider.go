@@ -33,20 +33,31 @@ type Provider interface {
 	Generate(ctx context.Context, prompt string) (string, error)
 }
 
+// RevisingProvider is a Provider whose service may rewrite the prompt
+// before generating, as DALL-E 3 does.
+type RevisingProvider interface {
+	Provider
+
+	// GenerateRevised is Generate that also returns the prompt the image
+	// was generated from, or "" if the service did not revise it.
+	GenerateRevised(ctx context.Context, prompt string) (url, revisedPrompt string, err error)
+}
+
 // OpenAIProvider implements Provider for OpenAI DALL-E image generation.
 //
 // This molecule handles:
 //   - OpenAI client configuration with proper HTTP transport
 //   - Model selection (DALL-E 2 vs DALL-E 3)
-//   - Style parameters for DALL-E 3
+//   - Size, quality and style parameters (see ImageOptions)
 //   - Error handling and response validation
 //
 // Thread Safety: OpenAIProvider is safe for concurrent use.
 // The underlying OpenAI client handles connection pooling.
 type OpenAIProvider struct {
-	client *openai.Client
-	config *core.Config
-	model  string
+	client  *openai.Client
+	config  *core.Config
+	model   string
+	options ImageOptions
 }
 
 // OpenAIProviderConfig holds configuration specific to the OpenAI provider.
@@ -60,6 +71,12 @@ type OpenAIProviderConfig struct {
 	// Model is the image model to use (default: dall-e-3)
 	Model string
 
+	// Size, Quality and Style are the image parameters (default: the
+	// API's size and quality, vivid style)
+	Size    string
+	Quality string
+	Style   string
+
 	// HTTPClient is the HTTP client for API calls (optional)
 	// If nil, a default client will be created
 	HTTPClient interface{}
@@ -122,9 +139,10 @@ func NewOpenAIProvider(cfg *core.Config) (*OpenAIProvider, error) {
 	}
 
 	return &OpenAIProvider{
-		client: openai.NewClientWithConfig(clientConfig),
-		config: cfg,
-		model:  model,
+		client:  openai.NewClientWithConfig(clientConfig),
+		config:  cfg,
+		model:   model,
+		options: ImageOptionsFromConfig(cfg).withDefaults(),
 	}, nil
 }
 
@@ -165,18 +183,20 @@ func NewOpenAIProviderWithConfig(providerCfg OpenAIProviderConfig, coreCfg *core
 		model = "dall-e-3"
 	}
 
+	options := ImageOptions{Size: providerCfg.Size, Quality: providerCfg.Quality, Style: providerCfg.Style}
 	return &OpenAIProvider{
-		client: openai.NewClientWithConfig(clientConfig),
-		config: coreCfg,
-		model:  model,
+		client:  openai.NewClientWithConfig(clientConfig),
+		config:  coreCfg,
+		model:   model,
+		options: options.withDefaults(),
 	}, nil
 }
 
 // Generate creates an image from the given prompt using OpenAI's DALL-E API.
 //
 // The method:
-//  1. Creates an image request with the configured model
-//  2. Adds style parameter for DALL-E 3 (vivid by default)
+//  1. Creates an image request with the configured model, size and quality
+//  2. Adds the style parameter for DALL-E 3 (vivid by default)
 //  3. Calls the OpenAI API
 //  4. Validates the response
 //  5. Returns the URL of the generated image
@@ -192,41 +212,35 @@ func NewOpenAIProviderWithConfig(providerCfg OpenAIProviderConfig, coreCfg *core
 // Note: The returned URL is temporary and should be downloaded promptly.
 // URLs typically expire after about 1 hour.
 func (p *OpenAIProvider) Generate(ctx context.Context, prompt string) (string, error) {
-	if prompt == "" {
-		return "", fmt.Errorf("imagegen: prompt cannot be empty")
-	}
-
-	// Build image request
-	req := openai.ImageRequest{
-		Prompt:         prompt,
-		Model:          p.model,
-		ResponseFormat: openai.CreateImageResponseFormatURL,
-		N:              1,
-	}
+	url, _, err := p.GenerateRevised(ctx, prompt)
+	return url, err
+}
 
-	// Add style parameter for DALL-E 3 (not supported by DALL-E 2)
-	if p.model == "dall-e-3" {
-		req.Style = openai.CreateImageStyleVivid
+// GenerateRevised is Generate that also returns the revised prompt
+// DALL-E 3 generated the image from.
+func (p *OpenAIProvider) GenerateRevised(ctx context.Context, prompt string) (string, string, error) {
+	if prompt == "" {
+		return "", "", fmt.Errorf("imagegen: prompt cannot be empty")
 	}
 
 	// Call OpenAI API
-	response, err := p.client.CreateImage(ctx, req)
+	response, err := p.client.CreateImage(ctx, p.options.request(prompt, p.model))
 	if err != nil {
-		return "", fmt.Errorf("imagegen: OpenAI image generation failed: %w", err)
+		return "", "", fmt.Errorf("imagegen: OpenAI image generation failed: %w", err)
 	}
 
 	// Validate response
 	if response.Data == nil {
-		return "", fmt.Errorf("imagegen: OpenAI returned nil Data field")
+		return "", "", fmt.Errorf("imagegen: OpenAI returned nil Data field")
 	}
 	if len(response.Data) == 0 {
-		return "", fmt.Errorf("imagegen: OpenAI returned empty Data array")
+		return "", "", fmt.Errorf("imagegen: OpenAI returned empty Data array")
 	}
 	if response.Data[0].URL == "" {
-		return "", fmt.Errorf("imagegen: OpenAI returned empty image URL")
+		return "", "", fmt.Errorf("imagegen: OpenAI returned empty image URL")
 	}
 
-	return response.Data[0].URL, nil
+	return response.Data[0].URL, response.Data[0].RevisedPrompt, nil
 }
 
 // Model returns the configured image model name.
@@ -234,5 +248,5 @@ func (p *OpenAIProvider) Model() string {
 	return p.model
 }
 
-// Ensure OpenAIProvider implements Provider interface at compile time.
-var _ Provider = (*OpenAIProvider)(nil)
+// Ensure OpenAIProvider implements RevisingProvider interface at compile time.
+var _ RevisingProvider = (*OpenAIProvider)(nil)
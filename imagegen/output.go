@@ -0,0 +1,180 @@
+// Package imagegen provides image generation utilities for the Canvus canvas.
+//
+// output.go contains the output options applied to generated images before
+// they are uploaded: the file format, JPEG quality and a maximum size, with
+// larger images scaled down on the server.
+package imagegen
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+
+	"go_backend/core"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // decode WebP input
+)
+
+// OutputFormat is the file format of uploaded images.
+type OutputFormat string
+
+// Supported output formats.
+const (
+	FormatPNG  OutputFormat = "png"
+	FormatJPEG OutputFormat = "jpeg"
+	FormatWebP OutputFormat = "webp"
+)
+
+// DefaultOutputQuality is the JPEG quality used when none is configured.
+const DefaultOutputQuality = 90
+
+// ParseOutputFormat parses a format name. "jpg" is accepted for JPEG and
+// an empty name means PNG.
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "png":
+		return FormatPNG, nil
+	case "jpeg", "jpg":
+		return FormatJPEG, nil
+	case "webp":
+		return FormatWebP, nil
+	}
+	return "", fmt.Errorf("imagegen: unsupported output format %q (use png, jpeg or webp)", name)
+}
+
+// Ext returns the file extension for the format, including the dot.
+func (f OutputFormat) Ext() string {
+	switch f {
+	case FormatJPEG:
+		return ".jpg"
+	case FormatWebP:
+		return ".webp"
+	}
+	return ".png"
+}
+
+// OutputOptions controls how generated images are encoded for upload.
+type OutputOptions struct {
+	// Format is the uploaded file format. WebP output is lossless.
+	Format OutputFormat
+
+	// Quality is the JPEG quality, 1-100
+	Quality int
+
+	// MaxWidth and MaxHeight bound the uploaded image; larger images are
+	// scaled down keeping their aspect ratio. Zero means no limit.
+	MaxWidth  int
+	MaxHeight int
+}
+
+// DefaultOutputOptions returns options that upload images unchanged as PNG.
+func DefaultOutputOptions() OutputOptions {
+	return OutputOptions{
+		Format:  FormatPNG,
+		Quality: DefaultOutputQuality,
+	}
+}
+
+// OutputOptionsFromConfig returns the output options set in cfg. The
+// format has already been validated by core.LoadConfig.
+func OutputOptionsFromConfig(cfg *core.Config) OutputOptions {
+	opts := DefaultOutputOptions()
+	if cfg == nil {
+		return opts
+	}
+	if format, err := ParseOutputFormat(cfg.ImageOutputFormat); err == nil {
+		opts.Format = format
+	}
+	if cfg.ImageOutputQuality > 0 {
+		opts.Quality = cfg.ImageOutputQuality
+	}
+	opts.MaxWidth = cfg.ImageMaxUploadWidth
+	opts.MaxHeight = cfg.ImageMaxUploadHeight
+	return opts
+}
+
+// EncodedImage is an image ready for upload.
+type EncodedImage struct {
+	Data   []byte
+	Format OutputFormat
+	Width  int
+	Height int
+	// Resized is true when the image was scaled down to fit the limits.
+	Resized bool
+}
+
+// EncodeForUpload converts image data (PNG, JPEG or WebP) to the configured
+// format and size. Input already in the target format and within the
+// limits is returned as is.
+func EncodeForUpload(data []byte, opts OutputOptions) (*EncodedImage, error) {
+	if opts.Format == "" {
+		opts.Format = FormatPNG
+	}
+	if opts.Quality < 1 || opts.Quality > 100 {
+		opts.Quality = DefaultOutputQuality
+	}
+
+	cfg, inputFormat, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("imagegen: failed to read image: %w", err)
+	}
+	width, height := fitWithin(cfg.Width, cfg.Height, opts.MaxWidth, opts.MaxHeight)
+	resized := width != cfg.Width || height != cfg.Height
+	if !resized && inputFormat == string(opts.Format) {
+		return &EncodedImage{Data: data, Format: opts.Format, Width: width, Height: height}, nil
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("imagegen: failed to decode image: %w", err)
+	}
+	if resized {
+		scaled := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Src, nil)
+		img = scaled
+	}
+
+	var buf bytes.Buffer
+	switch opts.Format {
+	case FormatJPEG:
+		err = jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: opts.Quality})
+	case FormatWebP:
+		err = encodeWebP(&buf, img)
+	default:
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("imagegen: failed to encode %s: %w", opts.Format, err)
+	}
+	return &EncodedImage{Data: buf.Bytes(), Format: opts.Format, Width: width, Height: height, Resized: resized}, nil
+}
+
+// fitWithin returns width and height scaled down to fit maxWidth x
+// maxHeight, keeping the aspect ratio. Zero limits are ignored.
+func fitWithin(width, height, maxWidth, maxHeight int) (int, int) {
+	scale := 1.0
+	if maxWidth > 0 && width > maxWidth {
+		scale = float64(maxWidth) / float64(width)
+	}
+	if maxHeight > 0 && height > maxHeight {
+		scale = min(scale, float64(maxHeight)/float64(height))
+	}
+	if scale == 1.0 {
+		return width, height
+	}
+	return max(1, int(float64(width)*scale+0.5)), max(1, int(float64(height)*scale+0.5))
+}
+
+// flatten draws img over white, since JPEG has no transparency.
+func flatten(img image.Image) image.Image {
+	b := img.Bounds()
+	out := image.NewRGBA(b)
+	draw.Draw(out, b, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(out, b, img, b.Min, draw.Over)
+	return out
+}
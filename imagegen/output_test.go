@@ -0,0 +1,145 @@
+package imagegen
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// testImage returns a gradient with noise, a hard edge and, optionally,
+// translucent pixels.
+func testImage(width, height int, alpha bool) *image.NRGBA {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{uint8(x * 255 / width), uint8(y * 255 / height), uint8(rng.Intn(16)), 0xff}
+			if x > width/2 {
+				c.B += 128
+			}
+			if alpha && y < height/4 {
+				c.A = uint8(x)
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode failed: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		width, height int
+		alpha         bool
+	}{
+		{"1x1", 1, 1, false},
+		{"odd size", 37, 21, false},
+		{"alpha", 64, 48, true},
+		{"flat", 40, 40, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			src := testImage(tc.width, tc.height, tc.alpha)
+			if tc.name == "flat" {
+				src = image.NewNRGBA(image.Rect(0, 0, tc.width, tc.height))
+			}
+
+			var buf bytes.Buffer
+			if err := encodeWebP(&buf, src); err != nil {
+				t.Fatalf("encodeWebP failed: %v", err)
+			}
+			got, err := webp.Decode(&buf)
+			if err != nil {
+				t.Fatalf("webp.Decode failed: %v", err)
+			}
+			if got.Bounds() != src.Bounds() {
+				t.Fatalf("bounds = %v, want %v", got.Bounds(), src.Bounds())
+			}
+			for y := 0; y < tc.height; y++ {
+				for x := 0; x < tc.width; x++ {
+					if g, w := color.NRGBAModel.Convert(got.At(x, y)), src.NRGBAAt(x, y); g != w {
+						t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, g, w)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeForUpload(t *testing.T) {
+	src := encodePNG(t, testImage(200, 100, false))
+
+	same, err := EncodeForUpload(src, DefaultOutputOptions())
+	if err != nil {
+		t.Fatalf("EncodeForUpload failed: %v", err)
+	}
+	if !bytes.Equal(same.Data, src) || same.Resized {
+		t.Error("PNG within limits should be returned unchanged")
+	}
+
+	out, err := EncodeForUpload(src, OutputOptions{Format: FormatJPEG, Quality: 70, MaxWidth: 100, MaxHeight: 100})
+	if err != nil {
+		t.Fatalf("EncodeForUpload failed: %v", err)
+	}
+	if out.Width != 100 || out.Height != 50 || !out.Resized || out.Format.Ext() != ".jpg" {
+		t.Errorf("encoded = %dx%d resized=%v format=%s", out.Width, out.Height, out.Resized, out.Format)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(out.Data))
+	if err != nil {
+		t.Fatalf("jpeg.Decode failed: %v", err)
+	}
+	if img.Bounds().Dx() != 100 || img.Bounds().Dy() != 50 {
+		t.Errorf("decoded bounds = %v", img.Bounds())
+	}
+
+	out, err = EncodeForUpload(src, OutputOptions{Format: FormatWebP})
+	if err != nil {
+		t.Fatalf("EncodeForUpload failed: %v", err)
+	}
+	if _, err := webp.Decode(bytes.NewReader(out.Data)); err != nil {
+		t.Errorf("webp.Decode failed: %v", err)
+	}
+
+	if _, err := EncodeForUpload([]byte("not an image"), DefaultOutputOptions()); err == nil {
+		t.Error("expected error for invalid image data")
+	}
+}
+
+func TestFitWithin(t *testing.T) {
+	for _, tc := range []struct{ w, h, maxW, maxH, wantW, wantH int }{
+		{2048, 2048, 1024, 0, 1024, 1024},
+		{2048, 1024, 1024, 1024, 1024, 512},
+		{1024, 2048, 1024, 512, 256, 512},
+		{512, 512, 1024, 1024, 512, 512},
+		{512, 512, 0, 0, 512, 512},
+	} {
+		if w, h := fitWithin(tc.w, tc.h, tc.maxW, tc.maxH); w != tc.wantW || h != tc.wantH {
+			t.Errorf("fitWithin(%d, %d, %d, %d) = %d, %d; want %d, %d", tc.w, tc.h, tc.maxW, tc.maxH, w, h, tc.wantW, tc.wantH)
+		}
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for in, want := range map[string]OutputFormat{"": FormatPNG, "PNG": FormatPNG, "jpg": FormatJPEG, "jpeg": FormatJPEG, "webp": FormatWebP} {
+		if got, err := ParseOutputFormat(in); err != nil || got != want {
+			t.Errorf("ParseOutputFormat(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseOutputFormat("gif"); err == nil {
+		t.Error("expected error for gif")
+	}
+}
@@ -44,6 +44,25 @@ func (w SimpleWidget) GetSize() WidgetSize {
 	return w.Size
 }
 
+// SeriesGap is the space between the images of a series, such as the
+// images of a --n trigger, in image pixels.
+const SeriesGap = 40.0
+
+// NextInSeries returns parentWidget moved right past an image of the given
+// pixel width, so the next image of a series is placed beside the
+// previous one. Images are placed at a third of the parent's scale.
+func NextInSeries(parentWidget ParentWidget, imageWidth float64) ParentWidget {
+	loc := parentWidget.GetLocation()
+	loc.X += (imageWidth + SeriesGap) * parentWidget.GetScale() / 3
+	return CanvasWidget{
+		ID:       parentWidget.GetID(),
+		Location: loc,
+		Size:     parentWidget.GetSize(),
+		Scale:    parentWidget.GetScale(),
+		Depth:    parentWidget.GetDepth(),
+	}
+}
+
 // PlacementConfig holds configuration for image placement calculations.
 type PlacementConfig struct {
 	OffsetX float64
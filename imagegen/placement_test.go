@@ -219,3 +219,14 @@ func TestDefaultPlacementConfig(t *testing.T) {
 		t.Errorf("DefaultPlacementConfig().OffsetY = %v, want %v", config.OffsetY, DefaultOffsetY)
 	}
 }
+
+func TestNextInSeries(t *testing.T) {
+	parent := CanvasWidget{ID: "note", Location: WidgetLocation{X: 100, Y: 200}, Size: WidgetSize{300, 300}, Scale: 3, Depth: 5}
+	next := NextInSeries(parent, 512)
+	if loc := next.GetLocation(); loc.X != 100+512+SeriesGap || loc.Y != 200 {
+		t.Errorf("next location = %+v, want one image and gap to the right", loc)
+	}
+	if next.GetID() != "note" || next.GetScale() != 3 || next.GetDepth() != 5 {
+		t.Errorf("next = %+v, want the parent's ID, scale and depth", next)
+	}
+}
@@ -4,22 +4,29 @@
 // image generation pipeline from prompt to canvas upload.
 //
 // This organism composes:
-//   - sdruntime.ContextPool: for image generation
+//   - Backend: for image generation (sdruntime.ContextPool, an sd-worker,
+//     ComfyUI or AUTOMATIC1111)
+//   - thermal.Throttle: for pacing generation while the GPU runs hot
 //   - placement.go: for canvas coordinate calculation
 //   - canvusapi.Client: for canvas widget operations
 //   - logging.Logger: for structured logging
 package imagegen
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 	"sync"
 
+	"go_backend/artifacts"
 	"go_backend/canvusapi"
+	"go_backend/core/errs"
 	"go_backend/logging"
 	"go_backend/sdruntime"
+	"go_backend/tempfiles"
+	"go_backend/thermal"
+	"go_backend/variants"
 
 	"go.uber.org/zap"
 )
@@ -62,6 +69,13 @@ type ProcessorConfig struct {
 
 	// ProcessingNote controls the appearance of processing indicator notes
 	ProcessingNote ProcessingNoteConfig
+
+	// Output controls the format and size of uploaded images
+	Output OutputOptions
+
+	// VRAMRetryLadder lists smaller sizes and step counts to retry with
+	// when generation runs out of GPU memory (nil disables retries)
+	VRAMRetryLadder []sdruntime.DowngradeStep
 }
 
 // DefaultProcessorConfig returns sensible default configuration.
@@ -74,24 +88,50 @@ func DefaultProcessorConfig() ProcessorConfig {
 		DefaultCFGScale: 7.0,
 		PlacementConfig: DefaultPlacementConfig(),
 		ProcessingNote:  DefaultProcessingNoteConfig(),
+		Output:          DefaultOutputOptions(),
+		VRAMRetryLadder: sdruntime.DefaultDowngradeLadder(sdruntime.DefaultImageSize, sdruntime.DefaultInferenceSteps),
 	}
 }
 
+// Backend generates images for a Processor. Implemented by
+// *sdruntime.ContextPool, by *sdworker.Client for a remote GPU host, and by
+// ComfyUIBackend and A1111Backend for existing Stable Diffusion servers.
+type Backend interface {
+	Generate(ctx context.Context, params sdruntime.GenerateParams) ([]byte, error)
+
+	// IsLoaded reports whether the model is ready, so the processing note
+	// can warn that the first image takes longer
+	IsLoaded() bool
+}
+
 // Processor handles the end-to-end image generation pipeline.
 // It manages generating images from prompts and uploading them to Canvus canvas.
 //
 // Thread-Safety:
 //   - Processor is safe for concurrent use
 //   - Uses mutex to protect downloads directory access
-//   - Pool handles concurrent generation internally
+//   - Backend handles concurrent generation internally
 type Processor struct {
-	pool   *sdruntime.ContextPool
-	client *canvusapi.Client
-	logger *logging.Logger
-	config ProcessorConfig
+	backend Backend
+	client  *canvusapi.Client
+	logger  *logging.Logger
+	config  ProcessorConfig
 
 	// mu protects file operations in downloads directory
 	mu sync.Mutex
+
+	// artifacts keeps generated images (optional)
+	artifacts artifactKeeper
+
+	// tempFiles holds images until they are uploaded (optional; nil
+	// writes them to DownloadsDir)
+	tempFiles   *tempfiles.TempFileManager
+	tempFilesMu sync.RWMutex
+
+	// throttle limits generation while the GPU is over its thermal
+	// limits (optional)
+	throttle   *thermal.Throttle
+	throttleMu sync.RWMutex
 }
 
 // NewProcessor creates a new image generation processor.
@@ -111,6 +151,15 @@ func NewProcessor(pool *sdruntime.ContextPool, client *canvusapi.Client, logger
 	if pool.IsClosed() {
 		return nil, fmt.Errorf("imagegen: pool is already closed")
 	}
+	return NewProcessorWithBackend(pool, client, logger, config)
+}
+
+// NewProcessorWithBackend creates a processor that generates images with
+// backend, such as an sd-worker on another host.
+func NewProcessorWithBackend(backend Backend, client *canvusapi.Client, logger *logging.Logger, config ProcessorConfig) (*Processor, error) {
+	if backend == nil {
+		return nil, fmt.Errorf("imagegen: backend cannot be nil")
+	}
 	if client == nil {
 		return nil, fmt.Errorf("imagegen: client cannot be nil")
 	}
@@ -124,13 +173,104 @@ func NewProcessor(pool *sdruntime.ContextPool, client *canvusapi.Client, logger
 	}
 
 	return &Processor{
-		pool:   pool,
-		client: client,
-		logger: logger.Named("imagegen"),
-		config: config,
+		backend: backend,
+		client:  client,
+		logger:  logger.Named("imagegen"),
+		config:  config,
 	}, nil
 }
 
+// SetArtifactStore keeps every generated image in store, recorded as
+// belonging to canvasID. A nil store keeps nothing.
+func (p *Processor) SetArtifactStore(store *artifacts.Store, canvasID string) {
+	p.artifacts.set(store, canvasID)
+}
+
+// SetImageVariants records every generated image in the variant history of
+// its trigger note. A nil history records nothing.
+func (p *Processor) SetImageVariants(history *variants.History) {
+	p.artifacts.setVariants(history, p.client.CanvasID)
+}
+
+// SetTempFiles stages generated images in the given manager before upload.
+func (p *Processor) SetTempFiles(tempFiles *tempfiles.TempFileManager) {
+	p.tempFilesMu.Lock()
+	defer p.tempFilesMu.Unlock()
+	p.tempFiles = tempFiles
+}
+
+// SetThrottle gates every generation through throttle. A nil throttle
+// generates without waiting.
+func (p *Processor) SetThrottle(throttle *thermal.Throttle) {
+	p.throttleMu.Lock()
+	defer p.throttleMu.Unlock()
+	p.throttle = throttle
+}
+
+// ForCanvas returns a processor that places images on canvasID. It shares
+// p's backend, throttle, temp files, artifact store and variant history, so one GPU host can
+// serve several canvases.
+func (p *Processor) ForCanvas(canvasID string) *Processor {
+	client := *p.client
+	client.CanvasID = canvasID
+
+	store, history, _ := p.artifacts.get()
+	p.tempFilesMu.RLock()
+	tempFiles := p.tempFiles
+	p.tempFilesMu.RUnlock()
+	p.throttleMu.RLock()
+	throttle := p.throttle
+	p.throttleMu.RUnlock()
+
+	other := &Processor{
+		backend:   p.backend,
+		client:    &client,
+		logger:    p.logger,
+		config:    p.config,
+		tempFiles: tempFiles,
+		throttle:  throttle,
+	}
+	other.artifacts.set(store, canvasID)
+	other.artifacts.setVariants(history, canvasID)
+	return other
+}
+
+// throttled reports whether generation is being slowed to let the GPU cool.
+func (p *Processor) throttled() bool {
+	p.throttleMu.RLock()
+	defer p.throttleMu.RUnlock()
+	return p.throttle != nil && p.throttle.Status().Throttled
+}
+
+// generate generates an image once the throttle, if any, allows it.
+func (p *Processor) generate(ctx context.Context, params sdruntime.GenerateParams) ([]byte, error) {
+	p.throttleMu.RLock()
+	throttle := p.throttle
+	p.throttleMu.RUnlock()
+	if throttle != nil {
+		release, err := throttle.Acquire(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("wait for GPU to cool: %w", err)
+		}
+		defer release()
+	}
+	return p.backend.Generate(ctx, params)
+}
+
+// storeImage saves image data as a temporary file with extension ext.
+func (p *Processor) storeImage(imageData []byte, ext string) (*tempfiles.File, error) {
+	p.tempFilesMu.RLock()
+	tempFiles := p.tempFiles
+	p.tempFilesMu.RUnlock()
+	if tempFiles != nil {
+		return tempFiles.StoreBytes(imageData, ext)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return tempfiles.StoreUnmanaged(p.config.DownloadsDir, bytes.NewReader(imageData), ext)
+}
+
 // ParentWidget represents the widget that triggered the image generation.
 // This interface is used to calculate placement for the generated image.
 type ParentWidget interface {
@@ -175,6 +315,14 @@ type ProcessResult struct {
 
 	// Seed is the random seed used for generation
 	Seed int64
+
+	// Downgrade describes the reduced settings used after running out of
+	// GPU memory, e.g. "384x384, 15 steps" (empty if none were needed)
+	Downgrade string
+
+	// WidgetIDs are the IDs of every image widget created, when the
+	// trigger asked for several images with --n
+	WidgetIDs []string
 }
 
 // ProcessImagePrompt handles the end-to-end flow of generating an image
@@ -201,6 +349,77 @@ type ProcessResult struct {
 // Returns the result on success, or an error. Canvas error notes are created
 // automatically on failure.
 func (p *Processor) ProcessImagePrompt(ctx context.Context, prompt string, parentWidget ParentWidget) (*ProcessResult, error) {
+	return p.ProcessImagePromptWithOptions(ctx, prompt, parentWidget, ImageOptions{})
+}
+
+// ProcessImagePromptWithOptions is ProcessImagePrompt with the options of
+// a trigger's inline flags, checked with ValidateSD:
+//   - --size, or else the aspect ratio, sets the image size. A zero ratio
+//     follows the shape of the parent widget, and a roughly square parent
+//     gets the configured size; other ratios keep about its pixel count.
+//   - --steps, --cfg and --seed override the configured parameters.
+//   - --n generates several images side by side, with consecutive seeds
+//     when --seed is given.
+//
+// The result is the first image's, with WidgetIDs listing every image.
+// Generation stops at the first image that fails.
+func (p *Processor) ProcessImagePromptWithOptions(ctx context.Context, prompt string, parentWidget ParentWidget, opts ImageOptions) (*ProcessResult, error) {
+	params := p.params(parentWidget, opts)
+	var first *ProcessResult
+	for i := 0; i < opts.Count(); i++ {
+		result, err := p.processImage(ctx, prompt, parentWidget, params)
+		if err != nil {
+			return nil, err
+		}
+		if first == nil {
+			first = result
+		}
+		first.WidgetIDs = append(first.WidgetIDs, result.WidgetID)
+
+		if params.Seed >= 0 {
+			params.Seed++
+		}
+		parentWidget = NextInSeries(parentWidget, float64(params.Width))
+	}
+	return first, nil
+}
+
+// params returns the generation parameters of an image for parentWidget,
+// without the prompt.
+func (p *Processor) params(parentWidget ParentWidget, opts ImageOptions) sdruntime.GenerateParams {
+	params := sdruntime.GenerateParams{
+		Width:    p.config.DefaultWidth,
+		Height:   p.config.DefaultHeight,
+		Steps:    p.config.DefaultSteps,
+		CFGScale: p.config.DefaultCFGScale,
+		Seed:     -1, // Random seed
+	}
+	if width, height, ok := ParseImageSize(opts.Size); ok {
+		params.Width, params.Height = width, height
+	} else {
+		aspect := opts.Aspect
+		if aspect.IsZero() {
+			aspect = AspectFromSize(parentWidget.GetSize())
+		}
+		if !aspect.IsZero() {
+			params.Width, params.Height = aspect.SDSize(p.config.DefaultWidth)
+		}
+	}
+	if opts.Steps > 0 {
+		params.Steps = opts.Steps
+	}
+	if opts.CFGScale > 0 {
+		params.CFGScale = opts.CFGScale
+	}
+	if opts.Seed != nil {
+		params.Seed = *opts.Seed
+	}
+	return params
+}
+
+// processImage generates one image with params for prompt and places it
+// next to parentWidget.
+func (p *Processor) processImage(ctx context.Context, prompt string, parentWidget ParentWidget, params sdruntime.GenerateParams) (*ProcessResult, error) {
 	correlationID := generateCorrelationID()
 	log := p.logger.With(
 		zap.String("correlation_id", correlationID),
@@ -214,7 +433,7 @@ func (p *Processor) ProcessImagePrompt(ctx context.Context, prompt string, paren
 	prompt = sdruntime.SanitizePrompt(prompt)
 	if err := sdruntime.ValidatePrompt(prompt); err != nil {
 		log.Error("invalid prompt", zap.Error(err))
-		p.createErrorNote(ctx, parentWidget, fmt.Sprintf("Invalid prompt: %v", err), log)
+		p.createErrorNote(ctx, parentWidget, fmt.Sprintf("Invalid prompt: %v", err), errs.CodeOr(err, errs.CodeInvalidPrompt), log)
 		return nil, fmt.Errorf("imagegen: %w", err)
 	}
 
@@ -236,51 +455,79 @@ func (p *Processor) ProcessImagePrompt(ctx context.Context, prompt string, paren
 
 	// Step 3: Update processing note and generate image
 	if processingNoteID != "" {
-		p.updateProcessingNote(processingNoteID, "Generating image...\nThis may take 10-30 seconds.", log)
+		if p.throttled() {
+			p.updateProcessingNote(processingNoteID, "GPU is running hot, waiting to start...\nImages are generated more slowly until it cools.", log)
+		} else if p.backend.IsLoaded() {
+			p.updateProcessingNote(processingNoteID, "Generating image...\nThis may take 10-30 seconds.", log)
+		} else {
+			// Model was unloaded while idle (or never used); first Acquire reloads it
+			p.updateProcessingNote(processingNoteID, "Warming up image model...\nThe first image after an idle period takes longer.", log)
+		}
 	}
 
-	params := sdruntime.GenerateParams{
-		Prompt:   prompt,
-		Width:    p.config.DefaultWidth,
-		Height:   p.config.DefaultHeight,
-		Steps:    p.config.DefaultSteps,
-		CFGScale: p.config.DefaultCFGScale,
-		Seed:     -1, // Random seed
+	params.Prompt = prompt
+	// Pick the random seed here rather than in the backend, so the seed
+	// reported and kept with the image reproduces it
+	if params.Seed < 0 {
+		params.Seed = sdruntime.RandomSeed()
 	}
-
-	imageData, err := p.pool.Generate(ctx, params)
+	log.Debug("generation parameters",
+		zap.Int("width", params.Width),
+		zap.Int("height", params.Height),
+		zap.Int("steps", params.Steps),
+		zap.Float64("cfg_scale", params.CFGScale),
+		zap.Int64("seed", params.Seed))
+
+	imageData, used, err := sdruntime.GenerateWithDowngrade(ctx, p.generate, params, p.config.VRAMRetryLadder,
+		func(step sdruntime.DowngradeStep, err error) {
+			log.Warn("out of GPU memory, retrying with smaller settings",
+				zap.Stringer("retry", step), zap.Error(err))
+			if processingNoteID != "" {
+				p.updateProcessingNote(processingNoteID, fmt.Sprintf("Out of GPU memory, retrying at %dx%d...", step.Width, step.Height), log)
+			}
+		})
 	if err != nil {
 		log.Error("image generation failed", zap.Error(err))
 		if processingNoteID != "" {
 			p.updateProcessingNote(processingNoteID, fmt.Sprintf("Generation failed: %v", err), log)
 		}
-		p.createErrorNote(ctx, parentWidget, fmt.Sprintf("Image generation failed: %v", err), log)
+		p.createErrorNote(ctx, parentWidget, fmt.Sprintf("Image generation failed: %v", err), errs.CodeOr(err, errs.CodeImageGeneration), log)
 		return nil, fmt.Errorf("imagegen: generation failed: %w", err)
 	}
 
 	log.Debug("image generated successfully", zap.Int("size_bytes", len(imageData)))
 
+	var downgrade string
+	if used.Width != params.Width || used.Height != params.Height || used.Steps != params.Steps {
+		downgrade = fmt.Sprintf("%dx%d, %d steps", used.Width, used.Height, used.Steps)
+		log.Info("image generated with reduced settings", zap.String("downgrade", downgrade))
+	}
+
 	// Step 4: Save to temporary file
 	if processingNoteID != "" {
 		p.updateProcessingNote(processingNoteID, "Uploading image to canvas...", log)
 	}
 
-	p.mu.Lock()
-	imagePath := filepath.Join(p.config.DownloadsDir, fmt.Sprintf("sd_image_%s.png", correlationID))
-	if err := os.WriteFile(imagePath, imageData, 0644); err != nil {
-		p.mu.Unlock()
+	encoded, err := EncodeForUpload(imageData, p.config.Output)
+	if err != nil {
+		log.Warn("failed to apply image output options, uploading original", zap.Error(err))
+		encoded = &EncodedImage{Data: imageData, Format: FormatPNG}
+	} else if encoded.Resized || encoded.Format != FormatPNG {
+		log.Debug("image converted for upload",
+			zap.String("format", string(encoded.Format)),
+			zap.Int("width", encoded.Width),
+			zap.Int("height", encoded.Height),
+			zap.Int("size_bytes", len(encoded.Data)))
+	}
+
+	imageFile, err := p.storeImage(encoded.Data, encoded.Format.Ext())
+	if err != nil {
 		log.Error("failed to save image file", zap.Error(err))
-		p.createErrorNote(ctx, parentWidget, fmt.Sprintf("Failed to save image: %v", err), log)
+		p.createErrorNote(ctx, parentWidget, fmt.Sprintf("Failed to save image: %v", err), errs.CodeOf(err), log)
 		return nil, fmt.Errorf("imagegen: failed to save image: %w", err)
 	}
-	p.mu.Unlock()
-
-	// Ensure cleanup of temp file
-	defer func() {
-		if removeErr := os.Remove(imagePath); removeErr != nil && !os.IsNotExist(removeErr) {
-			log.Warn("failed to remove temp image file", zap.Error(removeErr))
-		}
-	}()
+	defer imageFile.Release() // Clean up after upload
+	imagePath := imageFile.Path
 
 	// Step 5: Calculate placement
 	x, y := CalculatePlacementWithConfig(parentWidget, p.config.PlacementConfig)
@@ -289,15 +536,19 @@ func (p *Processor) ProcessImagePrompt(ctx context.Context, prompt string, paren
 		zap.Float64("y", y))
 
 	// Step 6: Upload to Canvus
+	title := fmt.Sprintf("AI Generated Image for %s", parentWidget.GetID())
+	if downgrade != "" {
+		title += fmt.Sprintf(" (reduced to %s: out of GPU memory)", downgrade)
+	}
 	widgetPayload := map[string]interface{}{
-		"title": fmt.Sprintf("AI Generated Image for %s", parentWidget.GetID()),
+		"title": title,
 		"location": map[string]float64{
 			"x": x,
 			"y": y,
 		},
 		"size": map[string]interface{}{
-			"width":  float64(p.config.DefaultWidth),
-			"height": float64(p.config.DefaultHeight),
+			"width":  float64(used.Width),
+			"height": float64(used.Height),
 		},
 		"depth": parentWidget.GetDepth() + 10,
 		"scale": parentWidget.GetScale() / 3,
@@ -306,7 +557,7 @@ func (p *Processor) ProcessImagePrompt(ctx context.Context, prompt string, paren
 	response, err := p.client.CreateImage(imagePath, widgetPayload)
 	if err != nil {
 		log.Error("failed to upload image to canvas", zap.Error(err))
-		p.createErrorNote(ctx, parentWidget, fmt.Sprintf("Failed to upload image: %v", err), log)
+		p.createErrorNote(ctx, parentWidget, fmt.Sprintf("Failed to upload image: %v", err), errs.CodeOf(err), log)
 		return nil, fmt.Errorf("imagegen: failed to upload image: %w", err)
 	}
 
@@ -314,10 +565,15 @@ func (p *Processor) ProcessImagePrompt(ctx context.Context, prompt string, paren
 	log.Info("image uploaded successfully",
 		zap.String("widget_id", widgetID))
 
+	seed := used.Seed
+	p.artifacts.keep(ctx, fmt.Sprintf("sd_image_%s%s", correlationID, encoded.Format.Ext()), prompt, parentWidget.GetID(), widgetID, encoded.Data,
+		&artifacts.Generation{Seed: &seed, Steps: used.Steps, CFGScale: used.CFGScale, Width: used.Width, Height: used.Height}, log)
+
 	return &ProcessResult{
 		ImagePath: imagePath, // Note: file is cleaned up after return
 		WidgetID:  widgetID,
-		Seed:      params.Seed,
+		Seed:      used.Seed,
+		Downgrade: downgrade,
 	}, nil
 }
 
@@ -369,10 +625,12 @@ func (p *Processor) updateProcessingNote(noteID, text string, log *logging.Logge
 }
 
 // createErrorNote creates an error note on the canvas to inform the user.
-func (p *Processor) createErrorNote(ctx context.Context, parent ParentWidget, errorMessage string, log *logging.Logger) {
+// The note ends with the error's code and what to do about it.
+func (p *Processor) createErrorNote(ctx context.Context, parent ParentWidget, errorMessage string, code errs.ErrCode, log *logging.Logger) {
 	loc := parent.GetLocation()
 
-	content := fmt.Sprintf("# Image Generation Error\n\n%s\n\nPlease try again or adjust your prompt.", errorMessage)
+	info := errs.Lookup(code)
+	content := fmt.Sprintf("# Image Generation Error\n\n%s\n\nCode %s: %s", errorMessage, info.Code, info.Hint)
 
 	payload := map[string]interface{}{
 		"title": "AI Image Generation Error",
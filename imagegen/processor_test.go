@@ -139,6 +139,50 @@ func TestNewProcessor_CreatesDownloadsDir(t *testing.T) {
 	}
 }
 
+// fakeBackend is a Backend that returns fixed results.
+type fakeBackend struct {
+	image  []byte
+	err    error
+	params []sdruntime.GenerateParams
+}
+
+func (b *fakeBackend) Generate(ctx context.Context, params sdruntime.GenerateParams) ([]byte, error) {
+	b.params = append(b.params, params)
+	return b.image, b.err
+}
+
+func (b *fakeBackend) IsLoaded() bool { return true }
+
+// TestNewProcessorWithBackend tests that a processor generates with the backend it is given.
+func TestNewProcessorWithBackend(t *testing.T) {
+	tmpDir := t.TempDir()
+	logger, err := logging.NewLogger(true, filepath.Join(tmpDir, "test.log"))
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Sync()
+	client := canvusapi.NewClient("http://test", "canvas-123", "api-key", false)
+	config := DefaultProcessorConfig()
+	config.DownloadsDir = tmpDir
+
+	if _, err := NewProcessorWithBackend(nil, client, logger, config); err == nil {
+		t.Error("Expected error for nil backend")
+	}
+
+	backend := &fakeBackend{image: []byte("png")}
+	processor, err := NewProcessorWithBackend(backend, client, logger, config)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	image, err := processor.generate(context.Background(), sdruntime.GenerateParams{Prompt: "a cat"})
+	if err != nil || string(image) != "png" {
+		t.Errorf("generate = %q, %v; want the backend's image", image, err)
+	}
+	if len(backend.params) != 1 || backend.params[0].Prompt != "a cat" {
+		t.Errorf("backend got %+v", backend.params)
+	}
+}
+
 // TestTruncateText tests the truncateText helper function.
 func TestTruncateText(t *testing.T) {
 	tests := []struct {
@@ -359,3 +403,32 @@ func searchSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestProcessor_ForCanvas(t *testing.T) {
+	logger, err := logging.NewLogger(true, filepath.Join(t.TempDir(), "test.log"))
+	if err != nil {
+		t.Fatalf("Failed to create logger: %v", err)
+	}
+	defer logger.Sync()
+	pool := createTestPool(t)
+	defer pool.Close()
+
+	config := DefaultProcessorConfig()
+	config.DownloadsDir = t.TempDir()
+	client := canvusapi.NewClient("https://canvus.example.com", "canvas-123", "api-key", false)
+	processor, err := NewProcessor(pool, client, logger, config)
+	if err != nil {
+		t.Fatalf("Failed to create processor: %v", err)
+	}
+
+	other := processor.ForCanvas("canvas-456")
+	if other.client.CanvasID != "canvas-456" || other.artifacts.canvasID != "canvas-456" {
+		t.Errorf("ForCanvas placed images on %q (artifacts %q), want canvas-456", other.client.CanvasID, other.artifacts.canvasID)
+	}
+	if processor.client.CanvasID != "canvas-123" {
+		t.Errorf("ForCanvas changed the original canvas to %q", processor.client.CanvasID)
+	}
+	if other.backend != Backend(pool) || other.client.HTTP != client.HTTP {
+		t.Error("ForCanvas did not share the pool and HTTP client")
+	}
+}
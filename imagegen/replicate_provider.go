@@ -0,0 +1,235 @@
+// Package imagegen provides image generation utilities for the Canvus canvas.
+//
+// replicate_provider.go implements the ReplicateProvider molecule that
+// generates images with Replicate predictions.
+//
+// This molecule composes:
+//   - hosted_provider.go: hostedError for failed requests
+//   - core.Config: for the API token and HTTP transport
+package imagegen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go_backend/core"
+	"go_backend/core/errs"
+)
+
+// replicatePollInterval is how often a running prediction is checked.
+const replicatePollInterval = time.Second
+
+// ReplicateProvider implements Provider for Replicate.
+//
+// The model is "owner/name", which runs the latest version of an official
+// model, or "owner/name:version" for a specific version of any model. The
+// prediction is created with "Prefer: wait" and polled until it finishes
+// if it takes longer; its first output is the image URL.
+//
+// Thread Safety: ReplicateProvider is safe for concurrent use.
+type ReplicateProvider struct {
+	http         *http.Client
+	apiToken     string
+	baseURL      string
+	model        string
+	pollInterval time.Duration
+}
+
+// ReplicateProviderConfig holds configuration specific to the Replicate provider.
+type ReplicateProviderConfig struct {
+	// APIToken is the Replicate API token (required)
+	APIToken string
+
+	// BaseURL is the API endpoint (default: https://api.replicate.com)
+	BaseURL string
+
+	// Model is the model to run (default: black-forest-labs/flux-schnell)
+	Model string
+
+	// HTTPClient is the HTTP client for API calls (optional)
+	HTTPClient *http.Client
+}
+
+// DefaultReplicateProviderConfig returns sensible defaults for Replicate image generation.
+func DefaultReplicateProviderConfig() ReplicateProviderConfig {
+	return ReplicateProviderConfig{
+		BaseURL: "https://api.replicate.com",
+		Model:   "black-forest-labs/flux-schnell",
+	}
+}
+
+// NewReplicateProvider creates a Replicate provider for model using
+// REPLICATE_API_TOKEN from cfg. An empty model uses FLUX.1 [schnell].
+func NewReplicateProvider(cfg *core.Config, model string) (*ReplicateProvider, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("imagegen: config cannot be nil")
+	}
+	if cfg.ReplicateAPIToken == "" {
+		return nil, fmt.Errorf("imagegen: Replicate API token is required; set REPLICATE_API_TOKEN")
+	}
+	providerCfg := DefaultReplicateProviderConfig()
+	providerCfg.APIToken = cfg.ReplicateAPIToken
+	providerCfg.HTTPClient = core.GetHTTPClient(cfg, cfg.AITimeout)
+	if model != "" {
+		providerCfg.Model = model
+	}
+	return NewReplicateProviderWithConfig(providerCfg)
+}
+
+// NewReplicateProviderWithConfig creates a Replicate provider with explicit configuration.
+// This is useful for testing or when you need fine-grained control over settings.
+//
+// Returns an error if the API token is empty or the model is not
+// "owner/name" or "owner/name:version".
+func NewReplicateProviderWithConfig(providerCfg ReplicateProviderConfig) (*ReplicateProvider, error) {
+	if providerCfg.APIToken == "" {
+		return nil, fmt.Errorf("imagegen: Replicate API token is required")
+	}
+	defaults := DefaultReplicateProviderConfig()
+	if providerCfg.BaseURL == "" {
+		providerCfg.BaseURL = defaults.BaseURL
+	}
+	if providerCfg.Model == "" {
+		providerCfg.Model = defaults.Model
+	}
+	name, _, _ := strings.Cut(providerCfg.Model, ":")
+	if owner, model, ok := strings.Cut(name, "/"); !ok || owner == "" || model == "" {
+		return nil, fmt.Errorf("imagegen: Replicate model %q must be owner/name or owner/name:version", providerCfg.Model)
+	}
+	if providerCfg.HTTPClient == nil {
+		providerCfg.HTTPClient = &http.Client{}
+	}
+	return &ReplicateProvider{
+		http:         providerCfg.HTTPClient,
+		apiToken:     providerCfg.APIToken,
+		baseURL:      strings.TrimSuffix(providerCfg.BaseURL, "/"),
+		model:        providerCfg.Model,
+		pollInterval: replicatePollInterval,
+	}, nil
+}
+
+// replicatePrediction is a prediction as the API returns it.
+type replicatePrediction struct {
+	ID     string          `json:"id"`
+	Status string          `json:"status"`
+	Output json.RawMessage `json:"output"`
+	Error  interface{}     `json:"error"`
+	URLs   struct {
+		Get string `json:"get"`
+	} `json:"urls"`
+}
+
+// Generate runs a prediction for the prompt and returns the URL of its
+// first output image.
+func (p *ReplicateProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	if prompt == "" {
+		return "", fmt.Errorf("imagegen: prompt cannot be empty")
+	}
+
+	// Official models run their latest version; a pinned version is
+	// created through /v1/predictions
+	request := map[string]interface{}{"input": map[string]interface{}{"prompt": prompt}}
+	path := "/v1/models/" + p.model + "/predictions"
+	if _, version, ok := strings.Cut(p.model, ":"); ok {
+		request["version"] = version
+		path = "/v1/predictions"
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("imagegen: replicate: encode request: %w", err)
+	}
+
+	prediction, err := p.do(ctx, http.MethodPost, p.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	ticker := time.NewTicker(p.pollInterval)
+	defer ticker.Stop()
+	for {
+		switch prediction.Status {
+		case "succeeded":
+			return firstOutput(prediction.Output)
+		case "failed", "canceled":
+			return "", errs.Wrap(errs.CodeImageGeneration, fmt.Errorf("imagegen: replicate: prediction %s %s: %v", prediction.ID, prediction.Status, prediction.Error))
+		}
+		if prediction.URLs.Get == "" {
+			return "", fmt.Errorf("imagegen: replicate: prediction %s has no status URL", prediction.ID)
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("imagegen: replicate: waiting for prediction %s: %w", prediction.ID, ctx.Err())
+		case <-ticker.C:
+		}
+		if prediction, err = p.do(ctx, http.MethodGet, prediction.URLs.Get, nil); err != nil {
+			return "", err
+		}
+	}
+}
+
+// firstOutput returns the first image URL of a prediction output, which
+// is a single URL or a list of them depending on the model.
+func firstOutput(output json.RawMessage) (string, error) {
+	var single string
+	if json.Unmarshal(output, &single) == nil && single != "" {
+		return single, nil
+	}
+	var list []string
+	if json.Unmarshal(output, &list) == nil && len(list) > 0 && list[0] != "" {
+		return list[0], nil
+	}
+	return "", fmt.Errorf("imagegen: replicate: prediction output has no image URL: %s", output)
+}
+
+// do sends a request and decodes the prediction in the response.
+func (p *ReplicateProvider) do(ctx context.Context, method, url string, body io.Reader) (replicatePrediction, error) {
+	var prediction replicatePrediction
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return prediction, fmt.Errorf("imagegen: replicate: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiToken)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+		// Hold the connection until the prediction finishes (up to 60s)
+		req.Header.Set("Prefer", "wait")
+	}
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return prediction, fmt.Errorf("imagegen: replicate: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHostedResponseBytes))
+	if err != nil {
+		return prediction, fmt.Errorf("imagegen: replicate: read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var apiErr struct {
+			Title  string `json:"title"`
+			Detail string `json:"detail"`
+		}
+		json.Unmarshal(data, &apiErr)
+		return prediction, hostedError(ServiceReplicate, resp.StatusCode, apiErr.Title+" "+apiErr.Detail)
+	}
+	if err := json.Unmarshal(data, &prediction); err != nil {
+		return prediction, fmt.Errorf("imagegen: replicate: decode prediction: %w", err)
+	}
+	return prediction, nil
+}
+
+// Model returns the configured model name.
+func (p *ReplicateProvider) Model() string {
+	return p.model
+}
+
+// Ensure ReplicateProvider implements Provider interface at compile time.
+var _ Provider = (*ReplicateProvider)(nil)
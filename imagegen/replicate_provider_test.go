@@ -0,0 +1,94 @@
+package imagegen
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go_backend/core/errs"
+)
+
+func TestReplicateProvider_Generate(t *testing.T) {
+	var server *httptest.Server
+	polls := 0
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer r8-test" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		switch r.URL.Path {
+		case "/v1/models/black-forest-labs/flux-schnell/predictions":
+			var body struct {
+				Input map[string]string `json:"input"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if body.Input["prompt"] != "a fox" || r.Header.Get("Prefer") != "wait" {
+				t.Errorf("request = %v, Prefer %q", body, r.Header.Get("Prefer"))
+			}
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": "p-1", "status": "processing", "urls": {"get": "` + server.URL + `/v1/predictions/p-1"}}`))
+		case "/v1/predictions/p-1":
+			if polls++; polls < 2 {
+				w.Write([]byte(`{"id": "p-1", "status": "processing", "urls": {"get": "` + server.URL + `/v1/predictions/p-1"}}`))
+				return
+			}
+			w.Write([]byte(`{"id": "p-1", "status": "succeeded", "output": ["https://replicate.delivery/out-0.webp"]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewReplicateProviderWithConfig(ReplicateProviderConfig{APIToken: "r8-test", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewReplicateProviderWithConfig: %v", err)
+	}
+	provider.pollInterval = time.Millisecond
+	url, err := provider.Generate(context.Background(), "a fox")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if url != "https://replicate.delivery/out-0.webp" {
+		t.Errorf("url = %q", url)
+	}
+}
+
+func TestReplicateProvider_PinnedVersionFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.URL.Path != "/v1/predictions" || body["version"] != "5599ed30" {
+			t.Errorf("path = %s, body = %v", r.URL.Path, body)
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": "p-2", "status": "failed", "error": "NSFW content detected"}`))
+	}))
+	defer server.Close()
+
+	provider, _ := NewReplicateProviderWithConfig(ReplicateProviderConfig{APIToken: "r8-test", BaseURL: server.URL, Model: "stability-ai/sdxl:5599ed30"})
+	_, err := provider.Generate(context.Background(), "a fox")
+	if errs.CodeOf(err) != errs.CodeImageGeneration {
+		t.Errorf("err = %v, want a failed prediction", err)
+	}
+}
+
+func TestNewReplicateProviderWithConfig_InvalidModel(t *testing.T) {
+	for _, model := range []string{"flux-schnell", "/flux", "owner/:v1"} {
+		if _, err := NewReplicateProviderWithConfig(ReplicateProviderConfig{APIToken: "r8-test", Model: model}); err == nil {
+			t.Errorf("model %q: expected an error", model)
+		}
+	}
+}
+
+func TestFirstOutput(t *testing.T) {
+	for _, output := range []string{`"https://example.com/a.png"`, `["https://example.com/a.png", "https://example.com/b.png"]`} {
+		if url, err := firstOutput(json.RawMessage(output)); err != nil || url != "https://example.com/a.png" {
+			t.Errorf("firstOutput(%s) = %q, %v", output, url, err)
+		}
+	}
+	if _, err := firstOutput(json.RawMessage(`null`)); err == nil {
+		t.Error("expected an error for an empty output")
+	}
+}
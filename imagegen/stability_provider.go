@@ -0,0 +1,189 @@
+// Package imagegen provides image generation utilities for the Canvus canvas.
+//
+// stability_provider.go implements the StabilityProvider molecule that
+// generates images with the Stability AI Stable Image REST API.
+//
+// This molecule composes:
+//   - hosted_provider.go: hostedError for failed requests
+//   - core.Config: for the API key and HTTP transport
+package imagegen
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"go_backend/core"
+	"go_backend/core/errs"
+)
+
+// StabilityProvider implements Provider for Stability AI.
+//
+// The model selects the endpoint: "core" and "ultra" are Stable Image
+// Core and Ultra, and "sd3..." models (such as "sd3.5-large") use the SD3
+// endpoint. The API returns the image itself, so Generate returns it as a
+// data: URL, which the Downloader decodes instead of fetching.
+//
+// Thread Safety: StabilityProvider is safe for concurrent use.
+type StabilityProvider struct {
+	http    *http.Client
+	apiKey  string
+	baseURL string
+	model   string
+}
+
+// StabilityProviderConfig holds configuration specific to the Stability AI provider.
+type StabilityProviderConfig struct {
+	// APIKey is the Stability AI API key (required)
+	APIKey string
+
+	// BaseURL is the API endpoint (default: https://api.stability.ai)
+	BaseURL string
+
+	// Model is the image model to use (default: core)
+	Model string
+
+	// HTTPClient is the HTTP client for API calls (optional)
+	HTTPClient *http.Client
+}
+
+// DefaultStabilityProviderConfig returns sensible defaults for Stability AI image generation.
+func DefaultStabilityProviderConfig() StabilityProviderConfig {
+	return StabilityProviderConfig{
+		BaseURL: "https://api.stability.ai",
+		Model:   "core",
+	}
+}
+
+// NewStabilityProvider creates a Stability AI provider for model using
+// STABILITY_API_KEY from cfg. An empty model uses Stable Image Core.
+func NewStabilityProvider(cfg *core.Config, model string) (*StabilityProvider, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("imagegen: config cannot be nil")
+	}
+	if cfg.StabilityAPIKey == "" {
+		return nil, fmt.Errorf("imagegen: Stability AI API key is required; set STABILITY_API_KEY")
+	}
+	providerCfg := DefaultStabilityProviderConfig()
+	providerCfg.APIKey = cfg.StabilityAPIKey
+	providerCfg.HTTPClient = core.GetHTTPClient(cfg, cfg.AITimeout)
+	if model != "" {
+		providerCfg.Model = model
+	}
+	return NewStabilityProviderWithConfig(providerCfg)
+}
+
+// NewStabilityProviderWithConfig creates a Stability AI provider with explicit configuration.
+// This is useful for testing or when you need fine-grained control over settings.
+func NewStabilityProviderWithConfig(providerCfg StabilityProviderConfig) (*StabilityProvider, error) {
+	if providerCfg.APIKey == "" {
+		return nil, fmt.Errorf("imagegen: Stability AI API key is required")
+	}
+	defaults := DefaultStabilityProviderConfig()
+	if providerCfg.BaseURL == "" {
+		providerCfg.BaseURL = defaults.BaseURL
+	}
+	if providerCfg.Model == "" {
+		providerCfg.Model = defaults.Model
+	}
+	if providerCfg.HTTPClient == nil {
+		providerCfg.HTTPClient = &http.Client{}
+	}
+	return &StabilityProvider{
+		http:    providerCfg.HTTPClient,
+		apiKey:  providerCfg.APIKey,
+		baseURL: strings.TrimSuffix(providerCfg.BaseURL, "/"),
+		model:   strings.ToLower(providerCfg.Model),
+	}, nil
+}
+
+// stabilityResponse is the JSON response of a generate endpoint.
+type stabilityResponse struct {
+	Image        string `json:"image"`
+	FinishReason string `json:"finish_reason"`
+}
+
+// stabilityError is an error response of the API.
+type stabilityError struct {
+	Name    string   `json:"name"`
+	Message string   `json:"message"`
+	Errors  []string `json:"errors"`
+}
+
+// Generate creates an image from the given prompt and returns it as a
+// data:image/png;base64 URL.
+func (p *StabilityProvider) Generate(ctx context.Context, prompt string) (string, error) {
+	if prompt == "" {
+		return "", fmt.Errorf("imagegen: prompt cannot be empty")
+	}
+
+	// The generate endpoints take multipart form data
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("prompt", prompt)
+	form.WriteField("output_format", "png")
+	if p.endpoint() == "sd3" {
+		form.WriteField("model", p.model)
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("imagegen: stability: encode request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/v2beta/stable-image/generate/"+p.endpoint(), &body)
+	if err != nil {
+		return "", fmt.Errorf("imagegen: stability: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("imagegen: stability: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxHostedResponseBytes))
+	if err != nil {
+		return "", fmt.Errorf("imagegen: stability: read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr stabilityError
+		json.Unmarshal(data, &apiErr)
+		message := strings.Join(append([]string{apiErr.Name, apiErr.Message}, apiErr.Errors...), " ")
+		return "", hostedError(ServiceStability, resp.StatusCode, message)
+	}
+
+	var result stabilityResponse
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf("imagegen: stability: decode response: %w", err)
+	}
+	if result.FinishReason == "CONTENT_FILTERED" {
+		return "", errs.Wrap(errs.CodeInvalidPrompt, fmt.Errorf("imagegen: stability: image was blocked by the content filter"))
+	}
+	if result.Image == "" {
+		return "", fmt.Errorf("imagegen: stability: no image in response")
+	}
+	return "data:image/png;base64," + result.Image, nil
+}
+
+// endpoint returns the generate endpoint serving the model.
+func (p *StabilityProvider) endpoint() string {
+	if strings.HasPrefix(p.model, "sd3") {
+		return "sd3"
+	}
+	return p.model
+}
+
+// Model returns the configured image model name.
+func (p *StabilityProvider) Model() string {
+	return p.model
+}
+
+// Ensure StabilityProvider implements Provider interface at compile time.
+var _ Provider = (*StabilityProvider)(nil)
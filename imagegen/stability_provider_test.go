@@ -0,0 +1,69 @@
+package imagegen
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go_backend/core/errs"
+)
+
+func TestStabilityProvider_Generate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2beta/stable-image/generate/sd3" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer sk-test" || r.Header.Get("Accept") != "application/json" {
+			t.Errorf("headers = %v", r.Header)
+		}
+		if r.FormValue("prompt") != "a fox" || r.FormValue("model") != "sd3.5-large" || r.FormValue("output_format") != "png" {
+			t.Errorf("form = %v", r.MultipartForm.Value)
+		}
+		json.NewEncoder(w).Encode(stabilityResponse{Image: base64.StdEncoding.EncodeToString([]byte("png")), FinishReason: "SUCCESS"})
+	}))
+	defer server.Close()
+
+	provider, err := NewStabilityProviderWithConfig(StabilityProviderConfig{APIKey: "sk-test", BaseURL: server.URL, Model: "sd3.5-large"})
+	if err != nil {
+		t.Fatalf("NewStabilityProviderWithConfig: %v", err)
+	}
+	url, err := provider.Generate(context.Background(), "a fox")
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	data, contentType, err := decodeDataURL(url)
+	if err != nil || string(data) != "png" || contentType != "image/png" {
+		t.Errorf("image = %q, %q, %v", data, contentType, err)
+	}
+}
+
+func TestStabilityProvider_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   interface{}
+		code   errs.ErrCode
+	}{
+		{"bad key", http.StatusUnauthorized, stabilityError{Name: "unauthorized", Errors: []string{"invalid API key"}}, errs.CodeProviderAuth},
+		{"out of credits", http.StatusPaymentRequired, stabilityError{Name: "payment_required"}, errs.CodeRateLimited},
+		{"content filter", http.StatusOK, stabilityResponse{FinishReason: "CONTENT_FILTERED"}, errs.CodeInvalidPrompt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(tt.body)
+			}))
+			defer server.Close()
+
+			provider, _ := NewStabilityProviderWithConfig(StabilityProviderConfig{APIKey: "sk-test", BaseURL: server.URL})
+			_, err := provider.Generate(context.Background(), "a fox")
+			if code := errs.CodeOf(err); code != tt.code {
+				t.Errorf("err = %v (%s), want %s", err, code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Package imagegen provides image generation utilities for the Canvus canvas.
+//
+// validation.go contains the startup validation checks contributed by this
+// package.
+package imagegen
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"go_backend/core/validation"
+)
+
+// ValidationChecks returns the checks for the image generation configuration,
+// for registration with validation.ValidationSuite.Register.
+func ValidationChecks() []validation.Check {
+	return []validation.Check{
+		{Name: "Image Endpoint", Module: "imagegen", Run: checkImageEndpoint},
+	}
+}
+
+// checkImageEndpoint probes IMAGE_LLM_URL when image generation uses a
+// remote provider. An unreachable endpoint is a warning, since only image
+// requests are affected.
+func checkImageEndpoint(ctx context.Context) validation.CheckResult {
+	endpoint := os.Getenv("IMAGE_LLM_URL")
+	if endpoint == "" {
+		if os.Getenv("SD_MODEL_PATH") != "" {
+			return validation.Skipped("Using local Stable Diffusion")
+		}
+		return validation.Skipped("IMAGE_LLM_URL not set")
+	}
+
+	checker := validation.NewConnectivityChecker().
+		WithAllowSelfSignedCerts(os.Getenv("ALLOW_SELF_SIGNED_CERTS") == "true")
+	result := checker.ProbeEndpoint(ctx, endpoint, nil)
+	if !result.Reachable {
+		hint := result.Hint
+		if hint == "" {
+			hint = "Check IMAGE_LLM_URL and that the image service is running"
+		}
+		return validation.CheckResult{
+			Status:  validation.StepWarning,
+			Message: fmt.Sprintf("%s not reachable", endpoint),
+			Error:   result.Error,
+			Hint:    hint,
+		}
+	}
+
+	provider := "OpenAI-compatible"
+	if IsAzureEndpoint(endpoint) {
+		provider = "Azure OpenAI"
+	}
+	return validation.Passed(fmt.Sprintf("%s %s (latency: %v)", provider, endpoint, result.Latency.Round(time.Millisecond)))
+}
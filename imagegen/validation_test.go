@@ -0,0 +1,57 @@
+package imagegen
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_backend/core/validation"
+)
+
+func TestCheckImageEndpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	refused := "http://" + ln.Addr().String()
+	ln.Close()
+
+	tests := []struct {
+		name       string
+		endpoint   string
+		sdModel    string
+		wantStatus validation.StepStatus
+		wantText   string
+	}{
+		{"local SD", "", "/models/sd.safetensors", validation.StepSkipped, "local Stable Diffusion"},
+		{"unset", "", "", validation.StepSkipped, "IMAGE_LLM_URL not set"},
+		{"reachable", server.URL, "", validation.StepPassed, server.URL},
+		{"refused", refused, "", validation.StepWarning, "not reachable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("IMAGE_LLM_URL", tt.endpoint)
+			t.Setenv("SD_MODEL_PATH", tt.sdModel)
+
+			result := checkImageEndpoint(context.Background())
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v (%s)", result.Status, tt.wantStatus, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.wantText) {
+				t.Errorf("Message = %q, want it to contain %q", result.Message, tt.wantText)
+			}
+			if result.Status == validation.StepWarning && !strings.Contains(result.Hint, "Nothing is listening") {
+				t.Errorf("Hint = %q, want refused-connection hint", result.Hint)
+			}
+		})
+	}
+}
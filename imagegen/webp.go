@@ -0,0 +1,579 @@
+// Package imagegen provides image generation utilities for the Canvus canvas.
+//
+// webp.go contains a lossless WebP (VP8L) encoder. The standard library and
+// golang.org/x/image only decode WebP, so this encoder implements the parts
+// of the VP8L format needed for good lossless compression: the subtract-green
+// and predictor transforms and per-channel Huffman coding. It does not use
+// LZ77 backward references or the color cache.
+//
+// The VP8L specification is at:
+// https://developers.google.com/speed/webp/docs/webp_lossless_bitstream_specification
+package imagegen
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"sort"
+)
+
+// VP8L limits and constants.
+const (
+	vp8lSignature     = 0x2f
+	vp8lMaxDimension  = 1 << 14
+	vp8lPredictorBits = 4 // 16x16 predictor tiles
+
+	vp8lTransformPredictor     = 0
+	vp8lTransformSubtractGreen = 2
+
+	vp8lMaxCodeLength           = 15
+	vp8lMaxCodeLengthCodeLength = 7
+)
+
+// vp8lAlphabetSizes are the sizes of the green (plus LZ77 length prefixes),
+// red, blue, alpha and distance alphabets.
+var vp8lAlphabetSizes = [5]int{256 + 24, 256, 256, 256, 40}
+
+// vp8lCodeLengthCodeOrder is the order in which code length code lengths
+// are written.
+var vp8lCodeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// encodeWebP writes img to w as a lossless WebP file.
+func encodeWebP(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return errors.New("imagegen: image dimensions out of range for WebP")
+	}
+
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, b.Min, draw.Src)
+	pix := nrgba.Pix
+
+	hasAlpha := false
+	for p := 3; p < len(pix); p += 4 {
+		if pix[p] != 0xff {
+			hasAlpha = true
+			break
+		}
+	}
+
+	bw := &bitWriter{}
+	bw.writeBits(vp8lSignature, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	if hasAlpha {
+		bw.writeBits(1, 1)
+	} else {
+		bw.writeBits(0, 1)
+	}
+	bw.writeBits(0, 3) // version
+
+	// Transforms are listed in the order they are applied; the decoder
+	// inverts them in reverse.
+	subtractGreen(pix)
+	bw.writeBits(1, 1)
+	bw.writeBits(vp8lTransformSubtractGreen, 2)
+
+	residuals, modes := predict(pix, width, height, vp8lPredictorBits)
+	bw.writeBits(1, 1)
+	bw.writeBits(vp8lTransformPredictor, 2)
+	bw.writeBits(vp8lPredictorBits-2, 3)
+	writeEntropyImage(bw, modes, false)
+
+	bw.writeBits(0, 1) // no more transforms
+	writeEntropyImage(bw, residuals, true)
+
+	payload := bw.bytes()
+	chunkSize := len(payload)
+	padded := chunkSize + chunkSize&1
+
+	var header [20]byte
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+8+padded))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(chunkSize))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if chunkSize&1 == 1 {
+		payload = append(payload, 0)
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// subtractGreen subtracts the green channel from red and blue.
+func subtractGreen(pix []byte) {
+	for p := 0; p < len(pix); p += 4 {
+		pix[p+0] -= pix[p+1]
+		pix[p+2] -= pix[p+1]
+	}
+}
+
+// predict returns the predictor residuals of pix and the sub-image of the
+// mode chosen for each tile. Each tile uses the mode with the smallest
+// residuals.
+func predict(pix []byte, width, height, bits int) (residuals, modes []byte) {
+	tileSize := 1 << bits
+	tilesX := (width + tileSize - 1) >> bits
+	tilesY := (height + tileSize - 1) >> bits
+	modes = make([]byte, 4*tilesX*tilesY)
+	residuals = make([]byte, len(pix))
+
+	var pred [4]byte
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			x0, y0 := tx*tileSize, ty*tileSize
+			x1, y1 := min(x0+tileSize, width), min(y0+tileSize, height)
+
+			best, bestCost := byte(1), -1
+			for mode := byte(0); mode < 14; mode++ {
+				cost := 0
+				for y := y0; y < y1; y++ {
+					for x := x0; x < x1; x++ {
+						p := 4 * (y*width + x)
+						predictPixel(pix, width, x, y, mode, &pred)
+						for c := 0; c < 4; c++ {
+							cost += residualCost(pix[p+c] - pred[c])
+						}
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = mode, cost
+				}
+			}
+
+			q := 4 * (ty*tilesX + tx)
+			modes[q+1] = best // green carries the mode
+			modes[q+3] = 0xff
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					p := 4 * (y*width + x)
+					predictPixel(pix, width, x, y, best, &pred)
+					for c := 0; c < 4; c++ {
+						residuals[p+c] = pix[p+c] - pred[c]
+					}
+				}
+			}
+		}
+	}
+	return residuals, modes
+}
+
+// residualCost estimates the cost of coding a residual by its magnitude.
+func residualCost(r byte) int {
+	return absInt(int(int8(r)))
+}
+
+// predictPixel sets pred to the prediction for (x, y) using mode. The top
+// row and left column use fixed modes, as the format requires.
+func predictPixel(pix []byte, width, x, y int, mode byte, pred *[4]byte) {
+	p := 4 * (y*width + x)
+	switch {
+	case x == 0 && y == 0:
+		*pred = [4]byte{0, 0, 0, 0xff}
+		return
+	case y == 0:
+		mode = 1
+	case x == 0:
+		mode = 2
+	}
+
+	// The top-right pixel of the last column is the first pixel of the
+	// current row, which flat indexing gives for free.
+	l, t := p-4, p-4*width
+	tl, tr := t-4, t+4
+	for c := 0; c < 4; c++ {
+		var v byte
+		switch mode {
+		case 0:
+			if c == 3 {
+				v = 0xff
+			}
+		case 1:
+			v = pix[l+c]
+		case 2:
+			v = pix[t+c]
+		case 3:
+			v = pix[tr+c]
+		case 4:
+			v = pix[tl+c]
+		case 5:
+			v = average2(average2(pix[l+c], pix[tr+c]), pix[t+c])
+		case 6:
+			v = average2(pix[l+c], pix[tl+c])
+		case 7:
+			v = average2(pix[l+c], pix[t+c])
+		case 8:
+			v = average2(pix[tl+c], pix[t+c])
+		case 9:
+			v = average2(pix[t+c], pix[tr+c])
+		case 10:
+			v = average2(average2(pix[l+c], pix[tl+c]), average2(pix[t+c], pix[tr+c]))
+		case 11:
+			v = selectPredictor(pix, l, t, tl, c)
+		case 12:
+			v = clampByte(int(pix[l+c]) + int(pix[t+c]) - int(pix[tl+c]))
+		case 13:
+			a := int(average2(pix[l+c], pix[t+c]))
+			v = clampByte(a + (a-int(pix[tl+c]))/2)
+		}
+		pred[c] = v
+	}
+}
+
+// selectPredictor returns channel c of L or T, whichever is closer to the
+// gradient estimate L + T - TL.
+func selectPredictor(pix []byte, l, t, tl, c int) byte {
+	distL, distT := 0, 0
+	for i := 0; i < 4; i++ {
+		distL += absInt(int(pix[tl+i]) - int(pix[t+i]))
+		distT += absInt(int(pix[tl+i]) - int(pix[l+i]))
+	}
+	if distL < distT {
+		return pix[l+c]
+	}
+	return pix[t+c]
+}
+
+func average2(a, b byte) byte {
+	return byte((int(a) + int(b)) / 2)
+}
+
+func clampByte(v int) byte {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return byte(v)
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// writeEntropyImage writes pix (RGBA bytes) as literal pixels with one
+// Huffman code per channel. The meta prefix flag is only present in the
+// main image.
+func writeEntropyImage(bw *bitWriter, pix []byte, topLevel bool) {
+	bw.writeBits(0, 1) // no color cache
+	if topLevel {
+		bw.writeBits(0, 1) // a single prefix code group
+	}
+
+	var histograms [5][]uint32
+	for i, size := range vp8lAlphabetSizes {
+		histograms[i] = make([]uint32, size)
+	}
+	for p := 0; p < len(pix); p += 4 {
+		histograms[0][pix[p+1]]++
+		histograms[1][pix[p+0]]++
+		histograms[2][pix[p+2]]++
+		histograms[3][pix[p+3]]++
+	}
+
+	var codes [5]huffmanCode
+	for i := range histograms {
+		codes[i] = writeHuffmanCode(bw, histograms[i])
+	}
+
+	for p := 0; p < len(pix); p += 4 {
+		codes[0].write(bw, int(pix[p+1]))
+		codes[1].write(bw, int(pix[p+0]))
+		codes[2].write(bw, int(pix[p+2]))
+		codes[3].write(bw, int(pix[p+3]))
+	}
+}
+
+// huffmanCode holds the bit-reversed codes and lengths of an alphabet.
+type huffmanCode struct {
+	codes   []uint32
+	lengths []uint8
+}
+
+func (h huffmanCode) write(bw *bitWriter, symbol int) {
+	if n := h.lengths[symbol]; n > 0 {
+		bw.writeBits(h.codes[symbol], uint(n))
+	}
+}
+
+// writeHuffmanCode writes the code for a histogram and returns it.
+func writeHuffmanCode(bw *bitWriter, histogram []uint32) huffmanCode {
+	var used []int
+	for s, n := range histogram {
+		if n > 0 {
+			used = append(used, s)
+		}
+	}
+	code := huffmanCode{codes: make([]uint32, len(histogram)), lengths: make([]uint8, len(histogram))}
+
+	// Up to two symbols below 256 use the simple code; a lone symbol
+	// takes no bits per pixel.
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		if len(used) == 0 {
+			used = []int{0}
+		}
+		bw.writeBits(1, 1)
+		bw.writeBits(uint32(len(used)-1), 1)
+		if used[0] < 2 {
+			bw.writeBits(0, 1)
+			bw.writeBits(uint32(used[0]), 1)
+		} else {
+			bw.writeBits(1, 1)
+			bw.writeBits(uint32(used[0]), 8)
+		}
+		if len(used) == 2 {
+			bw.writeBits(uint32(used[1]), 8)
+			code.lengths[used[0]], code.lengths[used[1]] = 1, 1
+			code.codes[used[1]] = 1
+		}
+		return code
+	}
+
+	lengths := huffmanLengths(histogram, vp8lMaxCodeLength)
+	writeCodeLengths(bw, lengths)
+	code.lengths = lengths
+	code.codes = canonicalCodes(lengths)
+	return code
+}
+
+// writeCodeLengths writes the code lengths of a normal code, themselves
+// Huffman coded with runs of zeros collapsed.
+func writeCodeLengths(bw *bitWriter, lengths []uint8) {
+	type token struct{ symbol, extra, extraBits int }
+	var tokens []token
+	for i := 0; i < len(lengths); {
+		if lengths[i] != 0 {
+			tokens = append(tokens, token{symbol: int(lengths[i])})
+			i++
+			continue
+		}
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == 0 && run < 138 {
+			run++
+		}
+		switch {
+		case run >= 11:
+			tokens = append(tokens, token{18, run - 11, 7})
+		case run >= 3:
+			tokens = append(tokens, token{17, run - 3, 3})
+		default:
+			run = 1
+			tokens = append(tokens, token{symbol: 0})
+		}
+		i += run
+	}
+
+	histogram := make([]uint32, 19)
+	for _, t := range tokens {
+		histogram[t.symbol]++
+	}
+	var clLengths []uint8
+	single := -1
+	if n := countNonZero(histogram); n == 1 {
+		// A code with one symbol takes no bits; its length must still
+		// be non-zero to mark it as used.
+		clLengths = make([]uint8, 19)
+		for s, c := range histogram {
+			if c > 0 {
+				clLengths[s], single = 1, s
+			}
+		}
+	} else {
+		clLengths = huffmanLengths(histogram, vp8lMaxCodeLengthCodeLength)
+	}
+
+	nCodes := 4
+	for i, s := range vp8lCodeLengthCodeOrder {
+		if clLengths[s] > 0 && i+1 > nCodes {
+			nCodes = i + 1
+		}
+	}
+	bw.writeBits(0, 1) // normal code
+	bw.writeBits(uint32(nCodes-4), 4)
+	for _, s := range vp8lCodeLengthCodeOrder[:nCodes] {
+		bw.writeBits(uint32(clLengths[s]), 3)
+	}
+	bw.writeBits(0, 1) // code lengths for every symbol follow
+
+	clCode := huffmanCode{codes: canonicalCodes(clLengths), lengths: clLengths}
+	for _, t := range tokens {
+		if single < 0 {
+			clCode.write(bw, t.symbol)
+		}
+		if t.extraBits > 0 {
+			bw.writeBits(uint32(t.extra), uint(t.extraBits))
+		}
+	}
+}
+
+func countNonZero(histogram []uint32) int {
+	n := 0
+	for _, c := range histogram {
+		if c > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// huffmanNode is a node of the tree built by huffmanLengths.
+type huffmanNode struct {
+	weight      uint64
+	symbol      int // -1 for internal nodes
+	left, right int
+}
+
+// huffmanHeap orders node indices by weight.
+type huffmanHeap struct {
+	nodes []huffmanNode
+	items []int
+}
+
+func (h *huffmanHeap) Len() int { return len(h.items) }
+func (h *huffmanHeap) Less(i, j int) bool {
+	a, b := h.nodes[h.items[i]], h.nodes[h.items[j]]
+	if a.weight != b.weight {
+		return a.weight < b.weight
+	}
+	return h.items[i] < h.items[j]
+}
+func (h *huffmanHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *huffmanHeap) Push(x any)    { h.items = append(h.items, x.(int)) }
+func (h *huffmanHeap) Pop() any {
+	x := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return x
+}
+
+// huffmanLengths returns Huffman code lengths for a histogram with at least
+// two used symbols, limited to maxLength. When the tree is too deep the
+// counts are flattened and the tree rebuilt.
+func huffmanLengths(histogram []uint32, maxLength int) []uint8 {
+	counts := make([]uint64, len(histogram))
+	for i, c := range histogram {
+		counts[i] = uint64(c)
+	}
+	for {
+		lengths, depth := buildHuffmanLengths(counts)
+		if depth <= maxLength {
+			return lengths
+		}
+		for i, c := range counts {
+			if c > 0 {
+				counts[i] = c/2 + 1
+			}
+		}
+	}
+}
+
+func buildHuffmanLengths(counts []uint64) ([]uint8, int) {
+	h := &huffmanHeap{}
+	for s, c := range counts {
+		if c > 0 {
+			h.nodes = append(h.nodes, huffmanNode{weight: c, symbol: s})
+			h.items = append(h.items, len(h.nodes)-1)
+		}
+	}
+	heap.Init(h)
+	for h.Len() > 1 {
+		a := heap.Pop(h).(int)
+		b := heap.Pop(h).(int)
+		h.nodes = append(h.nodes, huffmanNode{weight: h.nodes[a].weight + h.nodes[b].weight, symbol: -1, left: a, right: b})
+		heap.Push(h, len(h.nodes)-1)
+	}
+
+	lengths := make([]uint8, len(counts))
+	maxDepth := 0
+	var walk func(n, depth int)
+	walk = func(n, depth int) {
+		node := h.nodes[n]
+		if node.symbol >= 0 {
+			lengths[node.symbol] = uint8(depth)
+			maxDepth = max(maxDepth, depth)
+			return
+		}
+		walk(node.left, depth+1)
+		walk(node.right, depth+1)
+	}
+	walk(h.items[0], 0)
+	return lengths, maxDepth
+}
+
+// canonicalCodes assigns canonical codes to lengths, bit-reversed so they
+// can be written least significant bit first.
+func canonicalCodes(lengths []uint8) []uint32 {
+	type sym struct {
+		symbol int
+		length uint8
+	}
+	var syms []sym
+	for s, l := range lengths {
+		if l > 0 {
+			syms = append(syms, sym{s, l})
+		}
+	}
+	sort.Slice(syms, func(i, j int) bool {
+		if syms[i].length != syms[j].length {
+			return syms[i].length < syms[j].length
+		}
+		return syms[i].symbol < syms[j].symbol
+	})
+
+	codes := make([]uint32, len(lengths))
+	code, prevLength := uint32(0), uint8(0)
+	for i, s := range syms {
+		if i > 0 {
+			code++
+		}
+		code <<= s.length - prevLength
+		prevLength = s.length
+		codes[s.symbol] = reverseBits(code, s.length)
+	}
+	return codes
+}
+
+func reverseBits(code uint32, n uint8) uint32 {
+	var r uint32
+	for i := uint8(0); i < n; i++ {
+		r = r<<1 | code&1
+		code >>= 1
+	}
+	return r
+}
+
+// bitWriter packs bits least significant bit first.
+type bitWriter struct {
+	buf   bytes.Buffer
+	bits  uint64
+	nBits uint
+}
+
+func (w *bitWriter) writeBits(v uint32, n uint) {
+	w.bits |= uint64(v) << w.nBits
+	w.nBits += n
+	for w.nBits >= 8 {
+		w.buf.WriteByte(byte(w.bits))
+		w.bits >>= 8
+		w.nBits -= 8
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	if w.nBits > 0 {
+		w.buf.WriteByte(byte(w.bits))
+		w.bits, w.nBits = 0, 0
+	}
+	return w.buf.Bytes()
+}
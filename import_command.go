@@ -0,0 +1,97 @@
+// Package main provides the import subcommand for laying out documents on
+// a canvas.
+//
+// Usage:
+//
+//	canvuslocallm import [-canvas ID] [-title TITLE] [-no-summary] <file.pdf|file.docx>
+//
+// The document is summarized with the model configured by LLAMA_MODEL_PATH
+// (without one, or with -no-summary, each section shows its start) and laid
+// out right of the existing widgets on the canvas.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+
+	"go_backend/canvusapi"
+	"go_backend/core"
+	"go_backend/docimport"
+	"go_backend/llamaruntime"
+)
+
+// runImportCommand runs the import subcommand and returns the process exit code.
+func runImportCommand(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	canvasID := fs.String("canvas", "", "canvas to import onto (default: CANVAS_ID)")
+	title := fs.String("title", "", "title of the layout (default: the document title)")
+	noSummary := fs.Bool("no-summary", false, "show the start of each section instead of a model summary")
+	fs.Usage = func() {
+		fmt.Println("Usage: canvuslocallm import [-canvas ID] [-title TITLE] [-no-summary] <file.pdf|file.docx>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return core.ExitCodeError
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return core.ExitCodeError
+	}
+	path := fs.Arg(0)
+	if _, err := docimport.DetectFormat(path); err != nil {
+		fmt.Println(err)
+		return core.ExitCodeError
+	}
+
+	config, err := core.LoadConfig()
+	if err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		return core.ExitCodeError
+	}
+	if *canvasID == "" {
+		*canvasID = config.CanvasID
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var llamaClient LlamaModel
+	if modelPath := os.Getenv("LLAMA_MODEL_PATH"); modelPath != "" && !*noSummary {
+		fmt.Printf("Loading text model %s...\n", modelPath)
+		clientConfig := llamaruntime.DefaultClientConfig()
+		clientConfig.ModelPath = modelPath
+		clientConfig.AutoOffload = core.ParseBoolEnv("LLAMA_AUTO_OFFLOAD", true)
+		local, err := llamaruntime.NewClient(clientConfig)
+		if err != nil {
+			fmt.Printf("Summaries disabled: %v\n", err)
+		} else {
+			defer local.Close()
+			llamaClient = local
+		}
+	}
+
+	client := canvusapi.NewClient(config.CanvusServerURL, *canvasID, config.CanvusAPIKey, config.AllowSelfSignedCerts)
+	importer := docimport.NewImporter(client, documentSummarizer(llamaClient), config.DownloadsDir, nil)
+
+	fmt.Printf("Importing %s...\n", filepath.Base(path))
+	result, err := importer.Import(ctx, path, filepath.Base(path), docimport.Options{
+		Title:         *title,
+		SkipSummaries: *noSummary,
+	})
+	if err != nil {
+		fmt.Printf("Import failed: %v\n", err)
+		return core.ExitCodeError
+	}
+
+	summaries := "section starts"
+	if result.Summarized {
+		summaries = "model summaries"
+	}
+	fmt.Printf("Imported %q: %d sections, %d figures, %s\n", result.Title, result.Sections, result.Figures, summaries)
+	fmt.Printf("Anchor %s on canvas %s (%d widgets)\n", result.AnchorID, *canvasID, len(result.WidgetIDs))
+	return core.ExitCodeSuccess
+}
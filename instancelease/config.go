@@ -0,0 +1,75 @@
+package instancelease
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go_backend/core"
+	"go_backend/core/clock"
+
+	"go.uber.org/zap"
+)
+
+// Mode says what an instance does when another instance holds the lease.
+type Mode string
+
+const (
+	// ModeDefer stands by without processing triggers and takes over when
+	// the other instance's lease expires
+	ModeDefer Mode = "defer"
+	// ModeRefuse refuses to start while another instance holds the lease
+	ModeRefuse Mode = "refuse"
+	// ModeOff disables the lease; every instance processes triggers
+	ModeOff Mode = "off"
+)
+
+// DefaultTTL is how long a lease lasts without renewal.
+const DefaultTTL = 60 * time.Second
+
+// ParseMode parses an INSTANCE_LEASE value. An unknown mode is returned as
+// an error together with ModeDefer.
+func ParseMode(name string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(name))) {
+	case "", ModeDefer:
+		return ModeDefer, nil
+	case ModeRefuse:
+		return ModeRefuse, nil
+	case ModeOff:
+		return ModeOff, nil
+	default:
+		return ModeDefer, fmt.Errorf("instancelease: unknown mode %q (use defer, refuse or off)", name)
+	}
+}
+
+// Config configures the Lease.
+type Config struct {
+	Mode Mode
+
+	// TTL is how long the lease lasts without renewal; it is renewed every
+	// third of the TTL (default: 60s)
+	TTL time.Duration
+
+	// Instance identifies this process in the lease record
+	Instance string
+	Host     string
+	PID      int
+
+	// Logger for diagnostic output (optional)
+	Logger *zap.Logger
+
+	// Clock schedules renewals and times the lease. Defaults to the system
+	// clock.
+	Clock clock.Clock
+}
+
+// ConfigFromEnv reads INSTANCE_LEASE (defer, refuse or off; default defer)
+// and INSTANCE_LEASE_TTL (seconds, default 60). An unknown mode is returned
+// as an error together with a config that defers, so a typo never lets two
+// instances answer the same canvas.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{TTL: core.ParseDurationEnv("INSTANCE_LEASE_TTL", int(DefaultTTL/time.Second))}
+	mode, err := ParseMode(core.GetEnvOrDefault("INSTANCE_LEASE", string(ModeDefer)))
+	cfg.Mode = mode
+	return cfg, err
+}
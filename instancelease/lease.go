@@ -0,0 +1,407 @@
+package instancelease
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go_backend/canvusapi"
+	"go_backend/core/clock"
+
+	"go.uber.org/zap"
+)
+
+// State is the state of this instance's lease.
+type State string
+
+const (
+	// StateUnknown means the canvas has not been checked yet
+	StateUnknown State = "unknown"
+	// StateHolding means this instance holds the lease and processes triggers
+	StateHolding State = "holding"
+	// StateStandby means another instance holds the lease
+	StateStandby State = "standby"
+	// StateDisabled means the lease is off (INSTANCE_LEASE=off)
+	StateDisabled State = "disabled"
+)
+
+// ErrHeldElsewhere is returned by Acquire when another instance holds a
+// live lease on the canvas.
+var ErrHeldElsewhere = errors.New("instancelease: another instance holds the canvas lease")
+
+// Status is a snapshot of the lease state.
+type Status struct {
+	Mode     Mode      `json:"mode"`
+	State    State     `json:"state"`
+	Instance string    `json:"instance,omitempty"`
+	Since    time.Time `json:"since,omitempty"`
+	// Holder is the instance holding the lease while this one stands by
+	Holder    *Record `json:"holder,omitempty"`
+	LastError string  `json:"last_error,omitempty"`
+}
+
+// Store is the part of the Canvus API the lease uses. Implemented by
+// canvusapi.Client.
+type Store interface {
+	GetWidgets(subscribe bool) ([]map[string]interface{}, error)
+	CreateNote(payload map[string]interface{}) (map[string]interface{}, error)
+	UpdateNote(id string, payload map[string]interface{}) (map[string]interface{}, error)
+	DeleteNote(id string) error
+}
+
+// Lease is an organism that holds this instance's lease on a canvas.
+//
+// Usage:
+//
+//	lease := instancelease.New(client, cfg)
+//	if err := lease.Acquire(); errors.Is(err, instancelease.ErrHeldElsewhere) {
+//	    // another server answers this canvas
+//	}
+//	go lease.Run(ctx) // renews the lease, takes over expired ones
+//	defer lease.Release()
+//	if lease.Held() {
+//	    // process the trigger
+//	}
+type Lease struct {
+	store  Store
+	config Config
+	logger *zap.Logger
+	clock  clock.Clock
+
+	// checkMu serializes checks of the canvas and guards the fields below
+	checkMu sync.Mutex
+	seen    map[string]observation
+	noteID  string // Our lease note while holding
+	record  Record // Our lease record while holding
+
+	// mu guards the state read by Held, which must not wait for the
+	// Canvus server
+	mu        sync.Mutex
+	renewedAt time.Time // Local time of our last successful renewal
+	status    Status
+}
+
+// New creates a Lease in the unknown state. Missing Instance, Host and PID
+// are filled in from the process.
+func New(store Store, cfg Config) *Lease {
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	if cfg.Host == "" {
+		cfg.Host, _ = os.Hostname()
+	}
+	if cfg.PID == 0 {
+		cfg.PID = os.Getpid()
+	}
+	if cfg.Instance == "" {
+		cfg.Instance = newInstanceID(cfg.Host, cfg.PID)
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	cfg.Clock = clock.OrReal(cfg.Clock)
+
+	state := StateUnknown
+	if cfg.Mode == ModeOff {
+		state = StateDisabled
+	}
+	return &Lease{
+		store:  store,
+		config: cfg,
+		logger: logger,
+		clock:  cfg.Clock,
+		seen:   make(map[string]observation),
+		status: Status{Mode: cfg.Mode, State: state, Instance: cfg.Instance, Since: cfg.Clock.Now()},
+	}
+}
+
+// newInstanceID returns an ID unique to this process.
+func newInstanceID(host string, pid int) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, pid, hex.EncodeToString(b))
+}
+
+// Held reports whether this instance may process triggers: it holds the
+// lease and renewed it within the TTL, or the lease is off. A nil Lease is
+// always held.
+func (l *Lease) Held() bool {
+	if l == nil || l.config.Mode == ModeOff {
+		return true
+	}
+	return l.holding(l.clock.Now())
+}
+
+// Status returns a snapshot of the lease state.
+func (l *Lease) Status() Status {
+	if l == nil {
+		return Status{Mode: ModeOff, State: StateDisabled}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.status
+}
+
+// Acquire checks the canvas once: it takes or renews the lease if no other
+// instance holds a live one, and returns ErrHeldElsewhere otherwise.
+func (l *Lease) Acquire() error {
+	if l.config.Mode == ModeOff {
+		return nil
+	}
+	l.checkMu.Lock()
+	defer l.checkMu.Unlock()
+
+	err := l.check()
+	l.mu.Lock()
+	if err != nil && !errors.Is(err, ErrHeldElsewhere) {
+		l.status.LastError = err.Error()
+	} else {
+		l.status.LastError = ""
+	}
+	l.mu.Unlock()
+	return err
+}
+
+// Run renews the lease every third of the TTL until ctx is done. A
+// standby instance takes over once the holder's lease has expired.
+func (l *Lease) Run(ctx context.Context) {
+	if l.config.Mode == ModeOff {
+		return
+	}
+	ticker := l.clock.NewTicker(l.config.TTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if err := l.Acquire(); err != nil && !errors.Is(err, ErrHeldElsewhere) {
+				l.logger.Warn("Failed to renew canvas lease", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Release deletes this instance's lease note so another instance can take
+// over without waiting for the TTL.
+func (l *Lease) Release() error {
+	if l.config.Mode == ModeOff {
+		return nil
+	}
+	l.checkMu.Lock()
+	defer l.checkMu.Unlock()
+
+	if l.state() != StateHolding {
+		return nil
+	}
+	err := l.deleteNote(l.noteID)
+	l.noteID = ""
+	l.setState(StateUnknown, nil)
+	return err
+}
+
+// check lists the lease notes and takes, renews or gives up the lease.
+// The caller holds l.checkMu.
+func (l *Lease) check() error {
+	notes, err := l.list()
+	if err != nil {
+		return err
+	}
+	wasHolding := l.holding(l.clock.Now())
+	if err := l.decide(notes); err != nil || wasHolding {
+		return err
+	}
+
+	// Confirm a new lease: an instance starting at the same moment may
+	// have written one too, and only one of them may keep it
+	notes, err = l.list()
+	if err != nil {
+		return nil
+	}
+	return l.decide(notes)
+}
+
+// list returns the lease notes on the canvas and records their renewals.
+func (l *Lease) list() ([]leaseNote, error) {
+	widgets, err := l.store.GetWidgets(false)
+	if err != nil {
+		return nil, fmt.Errorf("instancelease: list widgets: %w", err)
+	}
+	notes := findLeaseNotes(widgets)
+	observe(l.seen, notes, l.clock.Now())
+	return notes, nil
+}
+
+// decide takes or renews the lease when no other instance wins it, and
+// stands by otherwise. Expired notes of other instances are deleted.
+func (l *Lease) decide(notes []leaseNote) error {
+	now := l.clock.Now()
+	var live []Record
+	var stale []string
+	for _, n := range notes {
+		switch {
+		case n.Record.Instance == l.config.Instance:
+			l.noteID = n.ID
+		case isLive(l.seen, n.Record, now):
+			live = append(live, n.Record)
+		default:
+			stale = append(stale, n.ID)
+		}
+	}
+
+	// A lease not renewed within the TTL is lost, even if no one took it
+	holding := l.holding(now)
+	if len(live) > 0 {
+		contenders := live
+		if holding {
+			contenders = append(contenders, l.record)
+		}
+		if w := winner(contenders); w.Instance != l.config.Instance {
+			if holding {
+				// Lost a race with an instance that acquired first
+				if err := l.deleteNote(l.noteID); err != nil {
+					l.logger.Warn("Failed to delete canvas lease note", zap.Error(err))
+				}
+				l.noteID = ""
+			}
+			l.setState(StateStandby, &w)
+			return ErrHeldElsewhere
+		}
+	}
+
+	if err := l.write(now); err != nil {
+		return err
+	}
+	for _, id := range stale {
+		if err := l.deleteNote(id); err != nil {
+			l.logger.Debug("Failed to delete expired canvas lease note", zap.String("note_id", id), zap.Error(err))
+		}
+	}
+	l.setState(StateHolding, nil)
+	return nil
+}
+
+// write creates or renews this instance's lease note.
+func (l *Lease) write(now time.Time) error {
+	record := l.record
+	if !l.holding(now) {
+		record = Record{
+			Instance:   l.config.Instance,
+			Host:       l.config.Host,
+			PID:        l.config.PID,
+			AcquiredAt: now.UTC(),
+			TTLSeconds: int(l.config.TTL / time.Second),
+		}
+	}
+	record.RenewedAt = now.UTC()
+
+	if l.noteID != "" {
+		_, err := l.store.UpdateNote(l.noteID, map[string]interface{}{"text": record.Text()})
+		if !isNotFound(err) {
+			if err != nil {
+				return fmt.Errorf("instancelease: renew lease: %w", err)
+			}
+			l.renewed(record, now)
+			return nil
+		}
+		// Someone deleted the note; write a new one
+	}
+
+	created, err := l.store.CreateNote(notePayload(record))
+	if err != nil {
+		return fmt.Errorf("instancelease: create lease note: %w", err)
+	}
+	l.noteID, _ = created["id"].(string)
+	l.renewed(record, now)
+	return nil
+}
+
+// renewed records a successful write of record at now.
+func (l *Lease) renewed(record Record, now time.Time) {
+	l.record = record
+	l.mu.Lock()
+	l.renewedAt = now
+	l.mu.Unlock()
+}
+
+// holding reports whether this instance holds the lease and renewed it
+// within the TTL at now.
+func (l *Lease) holding(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.status.State == StateHolding && now.Sub(l.renewedAt) < l.config.TTL
+}
+
+// state returns the current state.
+func (l *Lease) state() State {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.status.State
+}
+
+// notePayload returns the lease note: tiny, pinned and far from the
+// content, so it stays out of the way of canvas users.
+func notePayload(record Record) map[string]interface{} {
+	return map[string]interface{}{
+		"title":    NoteTitle,
+		"text":     record.Text(),
+		"location": map[string]interface{}{"x": -100000.0, "y": -100000.0},
+		"size":     map[string]interface{}{"width": 400.0, "height": 200.0},
+		"scale":    0.01,
+		"pinned":   true,
+	}
+}
+
+// deleteNote deletes a lease note; a note that is already gone is not an
+// error.
+func (l *Lease) deleteNote(id string) error {
+	if id == "" {
+		return nil
+	}
+	if err := l.store.DeleteNote(id); err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// setState moves the lease to state and logs changes. holder is the
+// instance holding the lease in StateStandby.
+func (l *Lease) setState(state State, holder *Record) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	changed := l.status.State != state
+	if changed {
+		l.status.Since = l.clock.Now()
+	}
+	l.status.State = state
+	l.status.Holder = holder
+	if !changed {
+		return
+	}
+
+	switch state {
+	case StateHolding:
+		l.logger.Info("Holding canvas lease; processing triggers", zap.String("instance", l.config.Instance))
+	case StateStandby:
+		l.logger.Warn("Another instance is processing this canvas; standing by",
+			zap.String("holder", holder.Instance),
+			zap.String("holder_host", holder.Host),
+			zap.Int("holder_pid", holder.PID),
+			zap.Duration("ttl", holder.TTL()))
+	}
+}
+
+// isNotFound reports whether err is a Canvus 404.
+func isNotFound(err error) bool {
+	var apiErr *canvusapi.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
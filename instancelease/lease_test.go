@@ -0,0 +1,290 @@
+package instancelease
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"go_backend/canvusapi"
+	"go_backend/core/clock"
+)
+
+// fakeCanvas is an in-memory Store shared by the instances under test.
+type fakeCanvas struct {
+	mu     sync.Mutex
+	notes  map[string]map[string]interface{}
+	nextID int
+	err    error
+}
+
+func newFakeCanvas() *fakeCanvas {
+	return &fakeCanvas{notes: make(map[string]map[string]interface{})}
+}
+
+func (c *fakeCanvas) GetWidgets(bool) ([]map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, c.err
+	}
+	var widgets []map[string]interface{}
+	for id, n := range c.notes {
+		widgets = append(widgets, map[string]interface{}{
+			"id": id, "widget_type": "Note", "title": n["title"], "text": n["text"],
+		})
+	}
+	return widgets, nil
+}
+
+func (c *fakeCanvas) CreateNote(payload map[string]interface{}) (map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nextID++
+	id := fmt.Sprintf("note-%d", c.nextID)
+	c.notes[id] = payload
+	return map[string]interface{}{"id": id}, nil
+}
+
+func (c *fakeCanvas) UpdateNote(id string, payload map[string]interface{}) (map[string]interface{}, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n, ok := c.notes[id]
+	if !ok {
+		return nil, &canvusapi.APIError{StatusCode: 404, Message: "not found"}
+	}
+	n["text"] = payload["text"]
+	return n, nil
+}
+
+func (c *fakeCanvas) DeleteNote(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.notes[id]; !ok {
+		return &canvusapi.APIError{StatusCode: 404, Message: "not found"}
+	}
+	delete(c.notes, id)
+	return nil
+}
+
+func (c *fakeCanvas) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.notes)
+}
+
+// newTestClock returns the clock shared by the instances under test.
+func newTestClock() *clock.Fake { return clock.NewFake(time.Unix(1000, 0)) }
+
+func newTestLease(store Store, fake *clock.Fake, instance string) *Lease {
+	return New(store, Config{Mode: ModeDefer, TTL: 60 * time.Second, Instance: instance, Host: "host", PID: 1, Clock: fake})
+}
+
+func TestSecondInstanceStandsBy(t *testing.T) {
+	canvas, fake := newFakeCanvas(), newTestClock()
+	a := newTestLease(canvas, fake, "a")
+	b := newTestLease(canvas, fake, "b")
+
+	if err := a.Acquire(); err != nil {
+		t.Fatalf("a.Acquire: %v", err)
+	}
+	if !a.Held() {
+		t.Fatal("a does not hold the lease it acquired")
+	}
+
+	if err := b.Acquire(); !errors.Is(err, ErrHeldElsewhere) {
+		t.Fatalf("b.Acquire = %v, want ErrHeldElsewhere", err)
+	}
+	if b.Held() {
+		t.Error("b holds the lease while a does")
+	}
+	if s := b.Status(); s.State != StateStandby || s.Holder == nil || s.Holder.Instance != "a" {
+		t.Errorf("b status = %+v, want standby behind a", s)
+	}
+
+	// While a renews, b keeps standing by
+	for i := 0; i < 5; i++ {
+		fake.Advance(20 * time.Second)
+		if err := a.Acquire(); err != nil {
+			t.Fatalf("a renew: %v", err)
+		}
+		if err := b.Acquire(); !errors.Is(err, ErrHeldElsewhere) {
+			t.Fatalf("b.Acquire after renewal = %v, want ErrHeldElsewhere", err)
+		}
+	}
+	if canvas.count() != 1 {
+		t.Errorf("%d lease notes, want 1", canvas.count())
+	}
+}
+
+func TestStandbyTakesOverExpiredLease(t *testing.T) {
+	canvas, fake := newFakeCanvas(), newTestClock()
+	a := newTestLease(canvas, fake, "a")
+	b := newTestLease(canvas, fake, "b")
+	a.Acquire()
+	b.Acquire()
+
+	// a stops renewing, e.g. it crashed
+	fake.Advance(30 * time.Second)
+	if err := b.Acquire(); !errors.Is(err, ErrHeldElsewhere) {
+		t.Fatalf("b took over after 30s: %v", err)
+	}
+	if a.Held() != true {
+		t.Error("a lost the lease before its TTL")
+	}
+	fake.Advance(31 * time.Second)
+	if a.Held() {
+		t.Error("a still holds a lease it has not renewed for a TTL")
+	}
+	if err := b.Acquire(); err != nil {
+		t.Fatalf("b.Acquire after expiry: %v", err)
+	}
+	if !b.Held() || canvas.count() != 1 {
+		t.Errorf("b held = %v with %d notes, want held with a's note deleted", b.Held(), canvas.count())
+	}
+
+	// a comes back and finds b holding the lease
+	if err := a.Acquire(); !errors.Is(err, ErrHeldElsewhere) {
+		t.Errorf("a.Acquire = %v, want ErrHeldElsewhere", err)
+	}
+}
+
+// createSignal is a Store that reports every note it creates.
+type createSignal struct {
+	Store
+	created chan struct{}
+}
+
+func (s createSignal) CreateNote(payload map[string]interface{}) (map[string]interface{}, error) {
+	defer func() { s.created <- struct{}{} }()
+	return s.Store.CreateNote(payload)
+}
+
+func TestRunTakesOverExpiredLease(t *testing.T) {
+	canvas, fake := newFakeCanvas(), newTestClock()
+	a := newTestLease(canvas, fake, "a")
+	a.Acquire()
+	store := createSignal{Store: canvas, created: make(chan struct{}, 1)}
+	b := newTestLease(store, fake, "b")
+	if err := b.Acquire(); !errors.Is(err, ErrHeldElsewhere) {
+		t.Fatalf("b.Acquire = %v, want ErrHeldElsewhere", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Run(ctx)
+
+	// a stops renewing; b's next renewal after the TTL takes over
+	fake.BlockUntil(1)
+	fake.Advance(61 * time.Second)
+	select {
+	case <-store.created:
+	case <-time.After(5 * time.Second):
+		t.Fatal("b did not take over the expired lease")
+	}
+	// Acquire waits for the renewal in progress to finish
+	if err := b.Acquire(); err != nil {
+		t.Fatalf("b.Acquire after takeover: %v", err)
+	}
+	if !b.Held() || canvas.count() != 1 {
+		t.Errorf("b held = %v with %d notes, want held with a's note deleted", b.Held(), canvas.count())
+	}
+}
+
+func TestReleaseHandsOver(t *testing.T) {
+	canvas, fake := newFakeCanvas(), newTestClock()
+	a := newTestLease(canvas, fake, "a")
+	b := newTestLease(canvas, fake, "b")
+	a.Acquire()
+	b.Acquire()
+
+	if err := a.Release(); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if a.Held() || canvas.count() != 0 {
+		t.Errorf("after Release: held = %v, %d notes", a.Held(), canvas.count())
+	}
+	if err := b.Acquire(); err != nil || !b.Held() {
+		t.Errorf("b.Acquire after release = %v, held = %v", err, b.Held())
+	}
+}
+
+func TestSimultaneousStartKeepsOneHolder(t *testing.T) {
+	canvas, fake := newFakeCanvas(), newTestClock()
+	a := newTestLease(canvas, fake, "a")
+	b := newTestLease(canvas, fake, "b")
+
+	// Both wrote a lease before seeing the other's
+	a.write(fake.Now())
+	a.setState(StateHolding, nil)
+	fake.Advance(time.Second)
+	b.write(fake.Now())
+	b.setState(StateHolding, nil)
+
+	errA, errB := b.Acquire(), a.Acquire()
+	if errA == nil == (errB == nil) {
+		t.Fatalf("b.Acquire = %v, a.Acquire = %v; want exactly one holder", errA, errB)
+	}
+	if !a.Held() || b.Held() {
+		t.Errorf("a held = %v, b held = %v; want the first to acquire to win", a.Held(), b.Held())
+	}
+	if canvas.count() != 1 {
+		t.Errorf("%d lease notes, want 1", canvas.count())
+	}
+}
+
+func TestRecreatesDeletedNote(t *testing.T) {
+	canvas, fake := newFakeCanvas(), newTestClock()
+	a := newTestLease(canvas, fake, "a")
+	a.Acquire()
+	for id := range canvas.notes {
+		canvas.DeleteNote(id)
+	}
+	fake.Advance(20 * time.Second)
+	if err := a.Acquire(); err != nil || !a.Held() || canvas.count() != 1 {
+		t.Errorf("Acquire = %v, held = %v, %d notes; want the note recreated", err, a.Held(), canvas.count())
+	}
+}
+
+func TestCanvasErrorsKeepLeaseUntilTTL(t *testing.T) {
+	canvas, fake := newFakeCanvas(), newTestClock()
+	a := newTestLease(canvas, fake, "a")
+	a.Acquire()
+
+	canvas.err = errors.New("server down")
+	fake.Advance(30 * time.Second)
+	if err := a.Acquire(); err == nil {
+		t.Fatal("Acquire succeeded with the canvas down")
+	}
+	if !a.Held() || a.Status().LastError == "" {
+		t.Errorf("held = %v, status = %+v; want held with the error reported", a.Held(), a.Status())
+	}
+	fake.Advance(31 * time.Second)
+	if a.Held() {
+		t.Error("lease still held after a TTL without renewal")
+	}
+}
+
+func TestNilAndOffLeaseAreHeld(t *testing.T) {
+	var nilLease *Lease
+	if !nilLease.Held() {
+		t.Error("nil lease not held")
+	}
+	off := New(newFakeCanvas(), Config{Mode: ModeOff})
+	if err := off.Acquire(); err != nil || !off.Held() || off.Status().State != StateDisabled {
+		t.Errorf("off lease: Acquire = %v, held = %v, status = %+v", err, off.Held(), off.Status())
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for in, want := range map[string]Mode{"": ModeDefer, "Defer": ModeDefer, " refuse ": ModeRefuse, "off": ModeOff} {
+		if got, err := ParseMode(in); err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %s, %v; want %s", in, got, err, want)
+		}
+	}
+	if got, err := ParseMode("never"); err == nil || got != ModeDefer {
+		t.Errorf("ParseMode(never) = %s, %v; want error with ModeDefer", got, err)
+	}
+}
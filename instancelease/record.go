@@ -0,0 +1,134 @@
+// Package instancelease keeps two servers from answering the same canvas.
+//
+// Each instance writes a lease record to a small pinned note on the canvas
+// and renews it while it runs. An instance that finds another live lease
+// stands by instead of processing triggers, so a second server pointed at
+// the canvas by mistake does not duplicate every AI response. When the
+// holder stops renewing, for example because it crashed, a standby instance
+// takes over once the lease has expired.
+//
+// Canvus has no compare-and-swap, so two instances starting at the same
+// moment can both write a lease. Every instance resolves this the same way:
+// the lease acquired first wins and the other instance steps down.
+//
+// This file contains the lease record and its pure atoms.
+package instancelease
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NoteTitle is the title of the note holding a lease record.
+const NoteTitle = "CanvusLocalLLM instance lease"
+
+// Record is the lease record stored as the text of the lease note.
+type Record struct {
+	Instance string `json:"instance"`
+	Host     string `json:"host,omitempty"`
+	PID      int    `json:"pid,omitempty"`
+	// AcquiredAt is when the instance took the lease; it decides between
+	// instances that hold a lease at the same time
+	AcquiredAt time.Time `json:"acquired_at"`
+	// RenewedAt changes on every renewal
+	RenewedAt  time.Time `json:"renewed_at"`
+	TTLSeconds int       `json:"ttl_seconds"`
+}
+
+// TTL returns how long the lease lasts without renewal.
+func (r Record) TTL() time.Duration {
+	return time.Duration(r.TTLSeconds) * time.Second
+}
+
+// Text returns the record as note text.
+func (r Record) Text() string {
+	data, _ := json.Marshal(r)
+	return string(data)
+}
+
+// ParseRecord parses the text of a lease note. It reports false for text
+// that is not a lease record, e.g. after someone edited the note.
+func ParseRecord(text string) (Record, bool) {
+	var r Record
+	if err := json.Unmarshal([]byte(strings.TrimSpace(text)), &r); err != nil {
+		return Record{}, false
+	}
+	if r.Instance == "" || r.TTLSeconds <= 0 {
+		return Record{}, false
+	}
+	return r, true
+}
+
+// leaseNote is a lease note found on the canvas.
+type leaseNote struct {
+	ID     string
+	Record Record
+}
+
+// findLeaseNotes returns the lease notes among widgets, ordered by ID.
+func findLeaseNotes(widgets []map[string]interface{}) []leaseNote {
+	var notes []leaseNote
+	for _, w := range widgets {
+		if widgetType, _ := w["widget_type"].(string); widgetType != "Note" {
+			continue
+		}
+		if title, _ := w["title"].(string); title != NoteTitle {
+			continue
+		}
+		id, _ := w["id"].(string)
+		text, _ := w["text"].(string)
+		if r, ok := ParseRecord(text); ok && id != "" {
+			notes = append(notes, leaseNote{ID: id, Record: r})
+		}
+	}
+	sort.Slice(notes, func(i, j int) bool { return notes[i].ID < notes[j].ID })
+	return notes
+}
+
+// observation is when an instance's lease was last seen to change.
+type observation struct {
+	renewedAt time.Time
+	seenAt    time.Time
+}
+
+// observe records the lease records seen at now and forgets instances
+// whose notes are gone. Liveness is judged by the local clock from when a
+// renewal was seen, so clock differences between hosts do not matter.
+func observe(seen map[string]observation, notes []leaseNote, now time.Time) {
+	present := make(map[string]bool, len(notes))
+	for _, n := range notes {
+		present[n.Record.Instance] = true
+		if o, ok := seen[n.Record.Instance]; ok && o.renewedAt.Equal(n.Record.RenewedAt) {
+			continue
+		}
+		seen[n.Record.Instance] = observation{renewedAt: n.Record.RenewedAt, seenAt: now}
+	}
+	for instance := range seen {
+		if !present[instance] {
+			delete(seen, instance)
+		}
+	}
+}
+
+// isLive reports whether the lease r was renewed within its TTL, as
+// observed by seen. A lease seen for the first time counts as live for a
+// full TTL, since its holder may be running.
+func isLive(seen map[string]observation, r Record, now time.Time) bool {
+	o, ok := seen[r.Instance]
+	return !ok || now.Sub(o.seenAt) < r.TTL()
+}
+
+// winner returns the lease that wins among records held at the same time:
+// the earliest acquired, then the lowest instance ID.
+func winner(records []Record) Record {
+	best := records[0]
+	for _, r := range records[1:] {
+		if r.AcquiredAt.Before(best.AcquiredAt) ||
+			(r.AcquiredAt.Equal(best.AcquiredAt) && r.Instance < best.Instance) {
+			best = r
+		}
+	}
+	return best
+}
@@ -0,0 +1,81 @@
+package instancelease
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecord(t *testing.T) {
+	r := Record{Instance: "a", Host: "host", PID: 7, AcquiredAt: time.Unix(100, 0).UTC(), RenewedAt: time.Unix(130, 0).UTC(), TTLSeconds: 60}
+	got, ok := ParseRecord(r.Text())
+	if !ok || got != r {
+		t.Errorf("ParseRecord(Text()) = %+v, %v; want %+v", got, ok, r)
+	}
+
+	for _, text := range []string{"", "hello", `{"instance":""}`, `{"instance":"a","ttl_seconds":0}`} {
+		if _, ok := ParseRecord(text); ok {
+			t.Errorf("ParseRecord(%q) accepted", text)
+		}
+	}
+}
+
+func TestFindLeaseNotes(t *testing.T) {
+	lease := Record{Instance: "a", TTLSeconds: 60}.Text()
+	widgets := []map[string]interface{}{
+		{"id": "2", "widget_type": "Note", "title": NoteTitle, "text": lease},
+		{"id": "1", "widget_type": "Note", "title": NoteTitle, "text": lease},
+		{"id": "3", "widget_type": "Note", "title": "Other", "text": lease},
+		{"id": "4", "widget_type": "Image", "title": NoteTitle, "text": lease},
+		{"id": "5", "widget_type": "Note", "title": NoteTitle, "text": "edited by hand"},
+	}
+	notes := findLeaseNotes(widgets)
+	if len(notes) != 2 || notes[0].ID != "1" || notes[1].ID != "2" {
+		t.Errorf("findLeaseNotes = %+v, want notes 1 and 2", notes)
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	start := time.Unix(1000, 0)
+	seen := make(map[string]observation)
+	r := Record{Instance: "a", RenewedAt: time.Unix(5, 0), TTLSeconds: 60}
+	notes := []leaseNote{{ID: "1", Record: r}}
+
+	// A lease seen for the first time is live for a full TTL
+	observe(seen, notes, start)
+	if !isLive(seen, r, start.Add(59*time.Second)) {
+		t.Error("new lease not live within its TTL")
+	}
+
+	// Without renewals it expires, whatever the holder's clock says
+	observe(seen, notes, start.Add(30*time.Second))
+	if isLive(seen, r, start.Add(61*time.Second)) {
+		t.Error("unrenewed lease still live after its TTL")
+	}
+
+	// A renewal restarts the TTL
+	r.RenewedAt = time.Unix(6, 0)
+	notes[0].Record = r
+	observe(seen, notes, start.Add(61*time.Second))
+	if !isLive(seen, r, start.Add(100*time.Second)) {
+		t.Error("renewed lease not live")
+	}
+
+	// Instances whose notes are gone are forgotten
+	observe(seen, nil, start.Add(120*time.Second))
+	if len(seen) != 0 {
+		t.Errorf("seen = %v, want empty", seen)
+	}
+}
+
+func TestWinner(t *testing.T) {
+	early := Record{Instance: "z", AcquiredAt: time.Unix(1, 0)}
+	late := Record{Instance: "a", AcquiredAt: time.Unix(2, 0)}
+	tie := Record{Instance: "b", AcquiredAt: time.Unix(2, 0)}
+
+	if got := winner([]Record{late, early}); got.Instance != "z" {
+		t.Errorf("winner = %s, want the earliest acquired", got.Instance)
+	}
+	if got := winner([]Record{tie, late}); got.Instance != "a" {
+		t.Errorf("winner = %s, want the lowest instance on a tie", got.Instance)
+	}
+}
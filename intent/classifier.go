@@ -0,0 +1,127 @@
+// Package intent decides whether a note prompt asks for text or an image
+// without a full LLM round-trip where it can. This file contains the
+// Classifier organism, which tries the heuristic, then an optional small
+// local classifier, and leaves the rest to the LLM.
+package intent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go_backend/core"
+)
+
+// Mode selects how intent is decided before the LLM is asked.
+type Mode string
+
+// Classifier modes.
+const (
+	// ModeLLM always lets the LLM decide, as a single call that also
+	// writes the answer
+	ModeLLM Mode = "llm"
+	// ModeHeuristic settles obvious prompts from their wording
+	ModeHeuristic Mode = "heuristic"
+	// ModeLocal also asks the local model for a one-word verdict on
+	// prompts the heuristic cannot settle
+	ModeLocal Mode = "local"
+)
+
+// ParseMode parses a mode name. An empty name is ModeHeuristic.
+func ParseMode(name string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(name))) {
+	case "", ModeHeuristic:
+		return ModeHeuristic, nil
+	case ModeLLM:
+		return ModeLLM, nil
+	case ModeLocal:
+		return ModeLocal, nil
+	default:
+		return ModeHeuristic, fmt.Errorf("intent: unknown mode %q (use llm, heuristic or local)", name)
+	}
+}
+
+// Decision sources.
+const (
+	SourceHeuristic = "heuristic"
+	SourceLocal     = "local"
+)
+
+// Decision is the outcome of Classify.
+type Decision struct {
+	// Intent is Unknown if the LLM must decide
+	Intent Intent
+	// Prompt is the prompt to act on, without intent flags
+	Prompt string
+	// Source is SourceHeuristic or SourceLocal; empty when Intent is Unknown
+	Source string
+	// Rule is the heuristic rule that decided, for logging
+	Rule string
+}
+
+// maxLocalInput bounds the prompt shown to the local classifier, in bytes.
+const maxLocalInput = 2000
+
+const localPrompt = `Decide whether the request below asks for a written answer or for a picture to be generated.
+Reply with one word: text, image, or unsure.
+
+Request:
+%s`
+
+// Classifier decides note intent ahead of the LLM.
+type Classifier struct {
+	mode     Mode
+	generate core.GenerateFunc
+}
+
+// NewClassifier creates a Classifier. generate runs the small local
+// classifier in ModeLocal; if it is nil, ModeLocal works like
+// ModeHeuristic.
+func NewClassifier(mode Mode, generate core.GenerateFunc) *Classifier {
+	return &Classifier{mode: mode, generate: generate}
+}
+
+// Mode returns the classifier's mode.
+func (c *Classifier) Mode() Mode {
+	return c.mode
+}
+
+// Classify decides the intent of prompt. An error from the local
+// classifier is returned with an Unknown decision, which the caller can
+// still hand to the LLM.
+func (c *Classifier) Classify(ctx context.Context, prompt string) (Decision, error) {
+	if c == nil || c.mode == ModeLLM {
+		return Decision{Prompt: prompt}, nil
+	}
+
+	intent, cleaned, rule := Heuristic(prompt)
+	if intent != Unknown {
+		return Decision{Intent: intent, Prompt: cleaned, Source: SourceHeuristic, Rule: rule}, nil
+	}
+	if c.mode != ModeLocal || c.generate == nil {
+		return Decision{Prompt: cleaned}, nil
+	}
+
+	input := cleaned
+	if len(input) > maxLocalInput {
+		// Cut on a rune boundary so the prompt stays valid UTF-8
+		cut := maxLocalInput
+		for cut > 0 && !utf8.RuneStart(input[cut]) {
+			cut--
+		}
+		input = input[:cut]
+	}
+	reply, err := c.generate(ctx, fmt.Sprintf(localPrompt, input))
+	if err != nil {
+		return Decision{Prompt: cleaned}, fmt.Errorf("intent: local classifier failed: %w", err)
+	}
+	switch word(strings.Fields(strings.ToLower(reply)), 0) {
+	case string(Text):
+		return Decision{Intent: Text, Prompt: cleaned, Source: SourceLocal}, nil
+	case string(Image):
+		return Decision{Intent: Image, Prompt: cleaned, Source: SourceLocal}, nil
+	default:
+		return Decision{Prompt: cleaned}, nil
+	}
+}
@@ -0,0 +1,14 @@
+// Package intent decides whether a note prompt asks for text or an image
+// without a full LLM round-trip where it can. This file contains the
+// configuration read from the environment.
+package intent
+
+import (
+	"go_backend/core"
+)
+
+// ModeFromEnv reads INTENT_CLASSIFIER. An unknown mode is returned as an
+// error together with ModeHeuristic.
+func ModeFromEnv() (Mode, error) {
+	return ParseMode(core.GetEnvOrDefault("INTENT_CLASSIFIER", string(ModeHeuristic)))
+}
@@ -0,0 +1,164 @@
+// Package intent decides whether a note prompt asks for text or an image
+// without a full LLM round-trip where it can. This file contains the
+// heuristic atom, which settles obvious prompts from their wording.
+package intent
+
+import (
+	"strings"
+)
+
+// Intent is what a note prompt asks for.
+type Intent string
+
+// Intents.
+const (
+	// Unknown means the prompt is ambiguous and the LLM must decide
+	Unknown Intent = ""
+	Text    Intent = "text"
+	Image   Intent = "image"
+)
+
+// Flags at the end of a prompt that force its intent, e.g.
+// "a lighthouse at dusk --img".
+var (
+	imageFlags = []string{"--img", "--image"}
+	textFlags  = []string{"--text", "--txt"}
+)
+
+// imageVerbs at the start of a prompt ask for an image.
+var imageVerbs = []string{"draw", "sketch", "paint", "illustrate", "render"}
+
+// imagePhrases anywhere in a prompt ask for an image, unless the prompt is
+// a question that does not open with a creationVerb.
+var imagePhrases = []string{
+	"picture of", "image of", "photo of", "photograph of", "drawing of",
+	"painting of", "illustration of", "sketch of", "portrait of",
+}
+
+// creationVerbs at the start of a question keep an image phrase in it an
+// image request, e.g. "Make a picture of a dog?".
+var creationVerbs = []string{"make", "create", "generate", "produce", "design", "show", "give"}
+
+// requestOpeners are the modals of a polite request, e.g. "Could you
+// paint a sunset". The words after "you" decide the intent.
+var requestOpeners = []string{"can", "could", "would", "will"}
+
+// politeWords between a request opener and its verb, e.g. "can you
+// please draw".
+var politeWords = []string{"you", "u", "please", "pls"}
+
+// textStarts at the start of a prompt ask for text.
+var textStarts = []string{
+	"what", "why", "how", "who", "when", "where", "which",
+	"write", "explain", "summarize", "summarise", "list", "translate", "define",
+}
+
+// auxiliaryStarts at the start of a prompt ask a question, for text unless
+// the prompt mentions an image.
+var auxiliaryStarts = []string{"is", "are", "can", "could", "would", "will", "do", "does", "should"}
+
+// Heuristic decides the intent of prompt from its wording. It returns
+// Unknown when the wording is not clear. The returned prompt has any
+// intent flag removed, and rule names the rule that decided, for logging.
+func Heuristic(prompt string) (intent Intent, cleaned string, rule string) {
+	cleaned = strings.TrimSpace(prompt)
+	lower := strings.ToLower(cleaned)
+
+	for _, flag := range imageFlags {
+		if strings.HasSuffix(lower, flag) {
+			return Image, strings.TrimSpace(cleaned[:len(cleaned)-len(flag)]), "flag " + flag
+		}
+	}
+	for _, flag := range textFlags {
+		if strings.HasSuffix(lower, flag) {
+			return Text, strings.TrimSpace(cleaned[:len(cleaned)-len(flag)]), "flag " + flag
+		}
+	}
+
+	words := strings.Fields(lower)
+	first := word(words, 0)
+	if verb, ok := imageVerb(words); ok {
+		return Image, cleaned, "verb " + verb
+	}
+
+	// "Could you paint a sunset" asks for an image, "could you explain" for text
+	if second := word(words, 1); contains(requestOpeners, first) && (second == "you" || second == "u") {
+		rest := words[1:]
+		for len(rest) > 0 && contains(politeWords, word(rest, 0)) {
+			rest = rest[1:]
+		}
+		if verb, ok := imageVerb(rest); ok {
+			return Image, cleaned, "request " + verb
+		}
+		if phrase := imagePhrase(lower); phrase != "" {
+			return Image, cleaned, "request " + phrase
+		}
+		return Text, cleaned, "request " + first
+	}
+
+	if contains(textStarts, first) {
+		return Text, cleaned, "starts with " + first
+	}
+	phrase := imagePhrase(lower)
+	if contains(auxiliaryStarts, first) {
+		// "Is this a picture of a cat?" may ask about an image or for one
+		if phrase != "" {
+			return Unknown, cleaned, ""
+		}
+		return Text, cleaned, "starts with " + first
+	}
+
+	if strings.HasSuffix(lower, "?") {
+		switch {
+		case phrase == "":
+			return Text, cleaned, "question"
+		case contains(creationVerbs, first):
+			return Image, cleaned, "phrase " + phrase
+		default:
+			// "the picture of dorian gray, themes?"
+			return Unknown, cleaned, ""
+		}
+	}
+	if phrase != "" {
+		return Image, cleaned, "phrase " + phrase
+	}
+	return Unknown, cleaned, ""
+}
+
+// imageVerb reports whether words open with an image verb. "draw up a
+// plan" asks for text.
+func imageVerb(words []string) (string, bool) {
+	first := word(words, 0)
+	if contains(imageVerbs, first) && word(words, 1) != "up" {
+		return first, true
+	}
+	return "", false
+}
+
+// imagePhrase returns the first image phrase in lower, or "".
+func imagePhrase(lower string) string {
+	for _, phrase := range imagePhrases {
+		if strings.Contains(lower, phrase) {
+			return phrase
+		}
+	}
+	return ""
+}
+
+// contains reports whether list holds s.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// word returns the i-th of words without trailing punctuation, or "".
+func word(words []string, i int) string {
+	if i >= len(words) {
+		return ""
+	}
+	return strings.TrimRight(words[i], ",.:;!?")
+}
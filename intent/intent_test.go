@@ -0,0 +1,113 @@
+package intent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestHeuristic(t *testing.T) {
+	tests := []struct {
+		prompt string
+		intent Intent
+		clean  string
+	}{
+		{"a lighthouse at dusk --img", Image, "a lighthouse at dusk"},
+		{"A lighthouse at dusk --IMAGE", Image, "A lighthouse at dusk"},
+		{"draw me a lighthouse at dusk --text", Text, "draw me a lighthouse at dusk"},
+		{"Draw a cat wearing a hat", Image, "Draw a cat wearing a hat"},
+		{"Sketch, quickly: a red barn", Image, "Sketch, quickly: a red barn"},
+		{"draw up a plan for the launch", Unknown, "draw up a plan for the launch"},
+		{"a picture of a fox in the snow", Image, "a picture of a fox in the snow"},
+		{"What is The Picture of Dorian Gray about", Text, "What is The Picture of Dorian Gray about"},
+		{"the picture of dorian gray, themes?", Unknown, "the picture of dorian gray, themes?"},
+		{"Can you draw a cat", Image, "Can you draw a cat"},
+		{"Could you paint a sunset over the sea", Image, "Could you paint a sunset over the sea"},
+		{"Make a picture of a dog?", Image, "Make a picture of a dog?"},
+		{"could you please make a portrait of my cat?", Image, "could you please make a portrait of my cat?"},
+		{"Can you explain photosynthesis?", Text, "Can you explain photosynthesis?"},
+		{"Is this a picture of a cat?", Unknown, "Is this a picture of a cat?"},
+		{"Is it going to rain today", Text, "Is it going to rain today"},
+		{"Write a haiku about autumn", Text, "Write a haiku about autumn"},
+		{"  a sunset over the sea  ", Unknown, "a sunset over the sea"},
+		{"", Unknown, ""},
+	}
+	for _, tt := range tests {
+		intent, clean, _ := Heuristic(tt.prompt)
+		if intent != tt.intent || clean != tt.clean {
+			t.Errorf("Heuristic(%q) = %q, %q; want %q, %q", tt.prompt, intent, clean, tt.intent, tt.clean)
+		}
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for name, want := range map[string]Mode{"": ModeHeuristic, "LLM": ModeLLM, " local ": ModeLocal, "heuristic": ModeHeuristic} {
+		if got, err := ParseMode(name); err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if got, err := ParseMode("magic"); err == nil || got != ModeHeuristic {
+		t.Errorf("ParseMode(magic) = %q, %v; want heuristic and an error", got, err)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	reply, replyErr := "", error(nil)
+	generate := func(ctx context.Context, prompt string) (string, error) {
+		calls++
+		return reply, replyErr
+	}
+
+	t.Run("llm mode leaves everything to the LLM", func(t *testing.T) {
+		d, err := NewClassifier(ModeLLM, generate).Classify(ctx, "draw a cat --img")
+		if err != nil || d.Intent != Unknown || d.Prompt != "draw a cat --img" {
+			t.Errorf("Classify() = %+v, %v", d, err)
+		}
+	})
+
+	t.Run("heuristic mode does not call the model", func(t *testing.T) {
+		c := NewClassifier(ModeHeuristic, generate)
+		if d, _ := c.Classify(ctx, "draw a cat"); d.Intent != Image || d.Source != SourceHeuristic {
+			t.Errorf("Classify(draw) = %+v", d)
+		}
+		if d, _ := c.Classify(ctx, "a sunset"); d.Intent != Unknown {
+			t.Errorf("Classify(ambiguous) = %+v", d)
+		}
+		if calls != 0 {
+			t.Errorf("model called %d times", calls)
+		}
+	})
+
+	t.Run("local mode asks the model when ambiguous", func(t *testing.T) {
+		c := NewClassifier(ModeLocal, generate)
+		for r, want := range map[string]Intent{"image": Image, " Text.": Text, "unsure": Unknown, "{": Unknown} {
+			reply = r
+			if d, err := c.Classify(ctx, "a sunset"); err != nil || d.Intent != want {
+				t.Errorf("reply %q: Classify() = %+v, %v; want %q", r, d, err, want)
+			}
+		}
+		reply, replyErr = "", errors.New("model unloaded")
+		if d, err := c.Classify(ctx, "a sunset"); err == nil || d.Intent != Unknown || d.Prompt != "a sunset" {
+			t.Errorf("Classify() with failing model = %+v, %v", d, err)
+		}
+	})
+
+	t.Run("long prompts are cut on a rune boundary", func(t *testing.T) {
+		var sent string
+		c := NewClassifier(ModeLocal, func(ctx context.Context, prompt string) (string, error) {
+			sent = prompt
+			return "image", nil
+		})
+		// The 2000-byte limit falls in the middle of a two-byte "é"
+		if _, err := c.Classify(ctx, "a"+strings.Repeat("é", 1500)); err != nil {
+			t.Fatalf("Classify() error = %v", err)
+		}
+		if !utf8.ValidString(sent) || !strings.Contains(sent, strings.Repeat("é", 999)) {
+			t.Errorf("prompt sent to the model is not valid UTF-8 or lost the input")
+		}
+	})
+}
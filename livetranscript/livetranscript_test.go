@@ -0,0 +1,273 @@
+package livetranscript
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_backend/whisperruntime"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestParseTrigger(t *testing.T) {
+	for content, want := range map[string][2]bool{
+		"live":           {false, true},
+		" LIVE ":         {false, true},
+		"live: start":    {false, true},
+		"live: stop":     {true, true},
+		"live music":     {false, false},
+		"live: pause":    {false, false},
+		"summarize live": {false, false},
+	} {
+		stop, ok := ParseTrigger(content)
+		if stop != want[0] || ok != want[1] {
+			t.Errorf("ParseTrigger(%q) = %v, %v, want %v, %v", content, stop, ok, want[0], want[1])
+		}
+	}
+}
+
+func TestCaptureArgs(t *testing.T) {
+	for _, tt := range []struct {
+		source, goos, input string
+	}{
+		{"mic", "linux", "-f pulse -i default"},
+		{"mic:alsa_input.usb", "linux", "-f pulse -i alsa_input.usb"},
+		{"mic", "darwin", "-f avfoundation -i :0"},
+		{"mic:Microphone (USB Audio)", "windows", "-f dshow -i audio=Microphone (USB Audio)"},
+		{"rtp://0.0.0.0:5004", "linux", "-i rtp://0.0.0.0:5004"},
+		{"/etc/meeting.sdp", "linux", "-protocol_whitelist file,udp,rtp -i /etc/meeting.sdp"},
+	} {
+		args, err := CaptureArgs(tt.source, tt.goos)
+		if err != nil {
+			t.Errorf("CaptureArgs(%q, %s) error = %v", tt.source, tt.goos, err)
+			continue
+		}
+		joined := strings.Join(args, " ")
+		if !strings.Contains(joined, tt.input) || !strings.HasSuffix(joined, "-ac 1 -ar 16000 -f s16le -") {
+			t.Errorf("CaptureArgs(%q, %s) = %q", tt.source, tt.goos, joined)
+		}
+	}
+	for _, source := range []string{"", "http://radio/stream", "speaker"} {
+		if _, err := CaptureArgs(source, "linux"); !errors.Is(err, ErrInvalidSource) {
+			t.Errorf("CaptureArgs(%q) error = %v, want ErrInvalidSource", source, err)
+		}
+	}
+	if _, err := CaptureArgs("mic", "windows"); !errors.Is(err, ErrInvalidSource) {
+		t.Error("CaptureArgs(mic) on Windows without a device succeeded")
+	}
+}
+
+// tone returns d of loud PCM, or silence.
+func tone(d time.Duration, loud bool) []byte {
+	pcm := make([]byte, int(d/time.Second)*bytesPerSecond)
+	for i := 0; loud && i < len(pcm)/bytesPerSample; i++ {
+		v := int16(3000)
+		if i%20 < 10 {
+			v = -v
+		}
+		binary.LittleEndian.PutUint16(pcm[i*bytesPerSample:], uint16(v))
+	}
+	return pcm
+}
+
+func TestAudio(t *testing.T) {
+	if !silent(tone(time.Second, false)) || silent(tone(time.Second, true)) {
+		t.Error("silent() did not tell silence from sound")
+	}
+	if pcmDuration(3*bytesPerSecond) != 3*time.Second {
+		t.Error("pcmDuration() is wrong")
+	}
+
+	path := filepath.Join(t.TempDir(), "chunk.wav")
+	if err := writeWAV(path, tone(time.Second, true)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data[:4]) != "RIFF" || string(data[8:12]) != "WAVE" || len(data) != 44+bytesPerSecond ||
+		binary.LittleEndian.Uint32(data[24:]) != SampleRate || binary.LittleEndian.Uint32(data[40:]) != bytesPerSecond {
+		t.Errorf("writeWAV() header = %v", data[:44])
+	}
+}
+
+func TestFormat(t *testing.T) {
+	lines := []Line{{At: 5 * time.Second, Text: "Hello."}, {At: 65 * time.Minute, Text: "Bye."}}
+	if got := FormatLines(lines); got != "[0:05] Hello.\n[1:05:00] Bye." {
+		t.Errorf("FormatLines() = %q", got)
+	}
+	if Text(lines) != "Hello. Bye." {
+		t.Errorf("Text() = %q", Text(lines))
+	}
+	input := SummaryInput(strings.Repeat("x", maxSummaryRunes) + "end")
+	if !strings.HasSuffix(input, "end\n</transcript>") || strings.Count(input, "x") != maxSummaryRunes-3 {
+		t.Error("SummaryInput() did not keep the end of the transcript")
+	}
+}
+
+// fakeWhisper numbers the chunks it transcribes.
+type fakeWhisper struct {
+	mu      sync.Mutex
+	chunks  int
+	prompts []string
+}
+
+func (f *fakeWhisper) CreateTranscription(ctx context.Context, request openai.AudioRequest) (openai.AudioResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.chunks++
+	f.prompts = append(f.prompts, request.Prompt)
+	var resp openai.AudioResponse
+	err := json.Unmarshal([]byte(fmt.Sprintf(`{"text": "Chunk %d.", "segments": [{"start": 1, "end": 4, "text": " Chunk %d."}]}`, f.chunks, f.chunks)), &resp)
+	return resp, err
+}
+
+// fakeSource plays fixed audio.
+type fakeSource struct {
+	audio []byte
+	err   error
+}
+
+func (s fakeSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return io.NopCloser(bytes.NewReader(s.audio)), nil
+}
+
+// fakeSink records what a session shows.
+type fakeSink struct {
+	mu        sync.Mutex
+	statuses  []Status
+	summaries []string
+	err       error
+}
+
+func (s *fakeSink) Transcript(status Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses = append(s.statuses, status)
+	return s.err
+}
+
+func (s *fakeSink) Summary(text string, at time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.summaries = append(s.summaries, fmt.Sprintf("%s at %s", text, FormatDuration(at)))
+	return nil
+}
+
+// waitEnded waits for the session on canvas-1 to end.
+func waitEnded(t *testing.T, m *Manager) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); m.Active("canvas-1", ""); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("session did not end")
+		}
+	}
+}
+
+func TestSession(t *testing.T) {
+	// Three chunks of speech with a silent one between the first two
+	var audio []byte
+	for _, loud := range []bool{true, false, true, true} {
+		audio = append(audio, tone(5*time.Second, loud)...)
+	}
+	whisper := &fakeWhisper{}
+	cfg := Config{Chunk: 5 * time.Second, SummaryEvery: 15 * time.Second, MaxDuration: time.Hour}
+	m := NewManager(cfg, whisperruntime.NewClient(whisperruntime.Config{URL: "http://whisper"}, whisper), fakeSource{audio: audio}, nil)
+	sink := &fakeSink{}
+	summarize := func(ctx context.Context, transcript string) (string, error) {
+		return "Summary of " + transcript, nil
+	}
+
+	if err := m.Start("canvas-1", "note-1", sink, summarize); err != nil {
+		t.Fatal(err)
+	}
+	if !m.Active("canvas-1", "note-1") || m.Active("canvas-2", "") {
+		t.Error("Active() does not report the session")
+	}
+	if err := m.Start("canvas-2", "note-2", &fakeSink{}, nil); !errors.Is(err, ErrBusy) {
+		t.Errorf("second Start() error = %v, want ErrBusy", err)
+	}
+	waitEnded(t, m)
+
+	// The silent chunk is skipped, each chunk continues the text before it
+	if whisper.chunks != 3 || whisper.prompts[0] != "" || whisper.prompts[2] != "Chunk 1. Chunk 2." {
+		t.Errorf("transcribed %d chunks with prompts %q", whisper.chunks, whisper.prompts)
+	}
+	if len(sink.statuses) != 4 {
+		t.Fatalf("got %d transcript updates, want 3 and the final one", len(sink.statuses))
+	}
+	final := sink.statuses[3]
+	if !final.Ended || final.Err != nil || final.Elapsed != 20*time.Second {
+		t.Errorf("final status = %+v", final)
+	}
+	if got := FormatLines(final.Lines); got != "[0:01] Chunk 1.\n[0:11] Chunk 2.\n[0:16] Chunk 3." {
+		t.Errorf("transcript = %q", got)
+	}
+	// A rolling summary every 15 seconds of audio, and one at the end for
+	// what came after the last
+	want := []string{"Summary of Chunk 1. Chunk 2. at 0:15", "Summary of Chunk 1. Chunk 2. Chunk 3. at 0:20"}
+	if strings.Join(sink.summaries, "|") != strings.Join(want, "|") {
+		t.Errorf("summaries = %q", sink.summaries)
+	}
+}
+
+func TestSessionEnds(t *testing.T) {
+	client := whisperruntime.NewClient(whisperruntime.Config{URL: "http://whisper"}, &fakeWhisper{})
+	cfg := Config{Chunk: 5 * time.Second, MaxDuration: 10 * time.Second}
+
+	// The time limit ends a session normally
+	m := NewManager(cfg, client, fakeSource{audio: tone(30*time.Second, true)}, nil)
+	sink := &fakeSink{}
+	if err := m.Start("canvas-1", "note-1", sink, nil); err != nil {
+		t.Fatal(err)
+	}
+	waitEnded(t, m)
+	if final := sink.statuses[len(sink.statuses)-1]; !final.Ended || final.Err != nil || final.Elapsed != 10*time.Second {
+		t.Errorf("final status at the time limit = %+v", final)
+	}
+
+	// Deleting the note stops the session
+	m = NewManager(cfg, client, fakeSource{audio: tone(10*time.Second, true)}, nil)
+	sink = &fakeSink{err: ErrNoteGone}
+	if err := m.Start("canvas-1", "note-1", sink, nil); err != nil {
+		t.Fatal(err)
+	}
+	waitEnded(t, m)
+	if len(sink.statuses) != 2 || sink.statuses[1].Elapsed != 5*time.Second {
+		t.Errorf("session went on after its note was deleted: %+v", sink.statuses)
+	}
+
+	// A source that does not open ends the session with its error
+	m = NewManager(cfg, client, fakeSource{err: errors.New("no such device")}, nil)
+	sink = &fakeSink{}
+	if err := m.Start("canvas-1", "note-1", sink, nil); err != nil {
+		t.Fatal(err)
+	}
+	waitEnded(t, m)
+	if len(sink.statuses) != 1 || sink.statuses[0].Err == nil {
+		t.Errorf("statuses = %+v, want the source error", sink.statuses)
+	}
+
+	var off *Manager
+	if off.Start("canvas-1", "note-1", sink, nil) == nil || off.Stop("canvas-1") || off.Close(context.Background()) != nil {
+		t.Error("nil Manager started a session")
+	}
+	if NewManager(cfg, nil, fakeSource{}, nil) != nil {
+		t.Error("NewManager() without a client is not nil")
+	}
+}
@@ -0,0 +1,338 @@
+package livetranscript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"go_backend/whisperruntime"
+
+	"go.uber.org/zap"
+)
+
+// chunkBacklog is the number of captured chunks waiting for transcription
+// before capture blocks.
+const chunkBacklog = 8
+
+// maxFailures is the number of chunks in a row that may fail to transcribe
+// before the session ends.
+const maxFailures = 3
+
+// finalTimeout bounds the last summary and transcript update of a session.
+const finalTimeout = 2 * time.Minute
+
+var (
+	// ErrBusy is returned by Start while a session runs; there is one
+	// audio input.
+	ErrBusy = errors.New("livetranscript: a live transcript is already running")
+	// ErrNoteGone is returned by a Sink whose transcript note was deleted,
+	// which ends the session.
+	ErrNoteGone = errors.New("livetranscript: transcript note deleted")
+
+	errMaxDuration = errors.New("livetranscript: time limit reached")
+)
+
+// Sink shows a session on the canvas. Implemented in the main package.
+type Sink interface {
+	// Transcript shows the status of the session. It is called after each
+	// chunk with speech and once when the session ends.
+	Transcript(s Status) error
+	// Summary shows the rolling summary of the meeting at time at.
+	Summary(text string, at time.Duration) error
+}
+
+// Summarizer returns the summary of a transcript so far.
+type Summarizer func(ctx context.Context, transcript string) (string, error)
+
+// Manager runs live transcript sessions, one at a time.
+//
+// Thread-Safety: Manager is safe for concurrent use.
+type Manager struct {
+	cfg    Config
+	client *whisperruntime.Client
+	source Source
+	logger *zap.Logger
+
+	mu      sync.Mutex
+	session *session
+	closing bool
+}
+
+// session is a running live transcript.
+type session struct {
+	canvasID string
+	noteID   string
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewManager creates a Manager transcribing audio from source with client.
+// It returns nil if either is nil; a nil Manager starts no sessions.
+func NewManager(cfg Config, client *whisperruntime.Client, source Source, logger *zap.Logger) *Manager {
+	if client == nil || source == nil {
+		return nil
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if cfg.Chunk <= 0 {
+		cfg.Chunk = DefaultChunk
+	}
+	if cfg.MaxDuration <= 0 {
+		cfg.MaxDuration = DefaultMaxDuration
+	}
+	return &Manager{cfg: cfg, client: client, source: source, logger: logger}
+}
+
+// Config returns the live transcription settings.
+func (m *Manager) Config() Config {
+	if m == nil {
+		return Config{}
+	}
+	return m.cfg
+}
+
+// Model returns the Whisper model name.
+func (m *Manager) Model() string {
+	if m == nil {
+		return ""
+	}
+	return m.client.Model()
+}
+
+// Start starts a session on a canvas writing to the transcript note
+// noteID through sink. summarize may be nil to leave out the summaries.
+// It returns an error wrapping ErrBusy if a session runs.
+func (m *Manager) Start(canvasID, noteID string, sink Sink, summarize Summarizer) error {
+	if m == nil {
+		return fmt.Errorf("livetranscript: live transcription is not configured")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closing {
+		return fmt.Errorf("livetranscript: shutting down")
+	}
+	if m.session != nil {
+		return fmt.Errorf("%w on canvas %s", ErrBusy, m.session.canvasID)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &session{canvasID: canvasID, noteID: noteID, cancel: cancel, done: make(chan struct{})}
+	m.session = s
+	go m.run(ctx, s, sink, summarize)
+	return nil
+}
+
+// Stop stops the session on a canvas and reports whether one ran. The
+// session writes its last summary and status after Stop returns.
+func (m *Manager) Stop(canvasID string) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.session == nil || m.session.canvasID != canvasID {
+		return false
+	}
+	m.session.cancel()
+	return true
+}
+
+// Active reports whether a session runs on a canvas, writing to noteID
+// unless noteID is "".
+func (m *Manager) Active(canvasID, noteID string) bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.session != nil && m.session.canvasID == canvasID && (noteID == "" || m.session.noteID == noteID)
+}
+
+// Close stops the running session, skipping its last summary, and waits
+// for it to update its note or for ctx to be done.
+func (m *Manager) Close(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	m.closing = true
+	s := m.session
+	m.mu.Unlock()
+	if s == nil {
+		return nil
+	}
+	s.cancel()
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run captures and transcribes until the session is stopped, the source
+// ends or the time limit is reached.
+func (m *Manager) run(ctx context.Context, s *session, sink Sink, summarize Summarizer) {
+	defer close(s.done)
+	defer m.clear(s)
+	log := m.logger.With(zap.String("canvas_id", s.canvasID), zap.String("note_id", s.noteID))
+	log.Info("live transcript started")
+
+	var status Status
+	stream, err := m.source.Open(ctx)
+	if err != nil {
+		m.finish(log, sink, summarize, &status, 0, err)
+		return
+	}
+	chunks := make(chan []byte, chunkBacklog)
+	readErr := make(chan error, 1)
+	go func() { readErr <- readChunks(stream, m.cfg, chunks) }()
+
+	var endErr error
+	var lastSummary time.Duration
+	summarized, failures := 0, 0
+	for pcm := range chunks {
+		at := status.Elapsed
+		status.Elapsed += pcmDuration(len(pcm))
+		lines, err := m.transcribe(ctx, pcm, at, Text(status.Lines))
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			failures++
+			log.Warn("live transcript chunk failed", zap.Int("failures", failures), zap.Error(err))
+			if failures >= maxFailures {
+				endErr = err
+				break
+			}
+			continue
+		}
+		failures = 0
+		if len(lines) > 0 {
+			status.Lines = append(status.Lines, lines...)
+			if err := sink.Transcript(status); errors.Is(err, ErrNoteGone) {
+				log.Info("live transcript note deleted, stopping")
+				break
+			} else if err != nil {
+				log.Warn("failed to update live transcript", zap.Error(err))
+			}
+		}
+		if summarize != nil && m.cfg.SummaryEvery > 0 && status.Elapsed-lastSummary >= m.cfg.SummaryEvery && len(status.Lines) > summarized {
+			lastSummary, summarized = status.Elapsed, len(status.Lines)
+			m.summarize(ctx, log, sink, summarize, status)
+		}
+	}
+	// Stop capturing, and let the reader finish if the loop ended early
+	s.cancel()
+	for range chunks {
+	}
+	stream.Close()
+	if err := <-readErr; endErr == nil && !errors.Is(err, errMaxDuration) && ctx.Err() == nil && err != nil && err != io.EOF {
+		endErr = err
+	}
+	m.finish(log, sink, summarize, &status, summarized, endErr)
+}
+
+// readChunks reads chunks of audio into chunks until the stream ends or
+// the time limit, then closes chunks.
+func readChunks(stream io.Reader, cfg Config, chunks chan<- []byte) error {
+	defer close(chunks)
+	size := int(cfg.Chunk/time.Second) * bytesPerSecond
+	var total time.Duration
+	for total < cfg.MaxDuration {
+		pcm := make([]byte, size)
+		n, err := io.ReadFull(stream, pcm)
+		n -= n % bytesPerSample
+		if n > 0 {
+			chunks <- pcm[:n]
+			total += pcmDuration(n)
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return io.EOF
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return errMaxDuration
+}
+
+// transcribe returns the lines spoken in pcm, which starts at time at.
+func (m *Manager) transcribe(ctx context.Context, pcm []byte, at time.Duration, previous string) ([]Line, error) {
+	if silent(pcm) {
+		return nil, nil
+	}
+	f, err := os.CreateTemp("", "live-*.wav")
+	if err != nil {
+		return nil, fmt.Errorf("livetranscript: %w", err)
+	}
+	path := f.Name()
+	f.Close()
+	defer os.Remove(path)
+	if err := writeWAV(path, pcm); err != nil {
+		return nil, fmt.Errorf("livetranscript: %w", err)
+	}
+
+	transcript, err := m.client.TranscribeAfter(ctx, path, previous)
+	if err != nil {
+		return nil, err
+	}
+	if len(transcript.Segments) == 0 {
+		if transcript.Text == "" {
+			return nil, nil
+		}
+		return []Line{{At: at, Text: transcript.Text}}, nil
+	}
+	lines := make([]Line, len(transcript.Segments))
+	for i, seg := range transcript.Segments {
+		lines[i] = Line{At: at + seg.Start, Text: seg.Text}
+	}
+	return lines, nil
+}
+
+// summarize shows the summary of status.
+func (m *Manager) summarize(ctx context.Context, log *zap.Logger, sink Sink, summarize Summarizer, status Status) {
+	summary, err := summarize(ctx, Text(status.Lines))
+	if err != nil {
+		log.Warn("live transcript summary failed", zap.Error(err))
+		return
+	}
+	if err := sink.Summary(summary, status.Elapsed); err != nil {
+		log.Warn("failed to update live summary", zap.Error(err))
+	}
+}
+
+// finish writes the last summary, unless shutting down, and the final
+// status of a session.
+func (m *Manager) finish(log *zap.Logger, sink Sink, summarize Summarizer, status *Status, summarized int, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), finalTimeout)
+	defer cancel()
+	m.mu.Lock()
+	closing := m.closing
+	m.mu.Unlock()
+	if summarize != nil && m.cfg.SummaryEvery > 0 && !closing && len(status.Lines) > summarized {
+		m.summarize(ctx, log, sink, summarize, *status)
+	}
+
+	status.Ended, status.Err = true, err
+	if sinkErr := sink.Transcript(*status); sinkErr != nil && !errors.Is(sinkErr, ErrNoteGone) {
+		log.Warn("failed to update live transcript", zap.Error(sinkErr))
+	}
+	log.Info("live transcript ended",
+		zap.Duration("elapsed", status.Elapsed),
+		zap.Int("lines", len(status.Lines)),
+		zap.Error(err))
+}
+
+// clear forgets s once it ended.
+func (m *Manager) clear(s *session) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.session == s {
+		m.session = nil
+	}
+}
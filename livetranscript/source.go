@@ -0,0 +1,213 @@
+package livetranscript
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Audio is captured as 16 kHz mono 16-bit little-endian PCM, the format
+// Whisper models work in.
+const (
+	SampleRate     = 16000
+	bytesPerSample = 2
+	bytesPerSecond = SampleRate * bytesPerSample
+)
+
+// silenceRMS is the sample level under which a chunk counts as silence
+// and is not transcribed; Whisper tends to invent text for silence.
+const silenceRMS = 200
+
+// stderrTail bounds the ffmpeg output kept for error messages.
+const stderrTail = 1024
+
+// Source opens the audio input of a session.
+type Source interface {
+	// Open starts capturing and returns the PCM audio; canceling ctx stops
+	// the capture.
+	Open(ctx context.Context) (io.ReadCloser, error)
+}
+
+// FFmpegSource captures audio with ffmpeg, which reads microphones on
+// Linux (PulseAudio or PipeWire), macOS and Windows, and RTP, UDP and SRT
+// streams.
+type FFmpegSource struct {
+	path string
+	args []string
+}
+
+// NewFFmpegSource returns the source of cfg.Source, or ErrInvalidSource.
+func NewFFmpegSource(cfg Config) (*FFmpegSource, error) {
+	args, err := CaptureArgs(cfg.Source, runtime.GOOS)
+	if err != nil {
+		return nil, err
+	}
+	path := cfg.FFmpegPath
+	if path == "" {
+		path = DefaultFFmpegPath
+	}
+	return &FFmpegSource{path: path, args: args}, nil
+}
+
+// CaptureArgs returns the ffmpeg arguments capturing source on the
+// operating system goos as PCM on stdout.
+func CaptureArgs(source, goos string) ([]string, error) {
+	args := []string{"-hide_banner", "-loglevel", "error", "-nostdin"}
+	source = strings.TrimSpace(source)
+	lower := strings.ToLower(source)
+	switch {
+	case lower == "mic" || strings.HasPrefix(lower, "mic:"):
+		device := strings.TrimSpace(strings.TrimPrefix(source[len("mic"):], ":"))
+		switch goos {
+		case "linux":
+			if device == "" {
+				device = "default"
+			}
+			args = append(args, "-f", "pulse", "-i", device)
+		case "darwin":
+			if device == "" {
+				device = "0"
+			}
+			args = append(args, "-f", "avfoundation", "-i", ":"+device)
+		case "windows":
+			if device == "" {
+				return nil, fmt.Errorf("%w: name the microphone, e.g. mic:Microphone (USB Audio)", ErrInvalidSource)
+			}
+			args = append(args, "-f", "dshow", "-i", "audio="+device)
+		default:
+			return nil, fmt.Errorf("%w: microphones are not supported on %s", ErrInvalidSource, goos)
+		}
+	case strings.HasPrefix(lower, "rtp://"), strings.HasPrefix(lower, "udp://"), strings.HasPrefix(lower, "srt://"):
+		args = append(args, "-i", source)
+	case strings.HasSuffix(lower, ".sdp"):
+		args = append(args, "-protocol_whitelist", "file,udp,rtp", "-i", source)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSource, source)
+	}
+	return append(args, "-vn", "-ac", "1", "-ar", fmt.Sprint(SampleRate), "-f", "s16le", "-"), nil
+}
+
+// Open starts ffmpeg.
+func (s *FFmpegSource) Open(ctx context.Context) (io.ReadCloser, error) {
+	cmd := exec.CommandContext(ctx, s.path, s.args...)
+	stderr := &tailBuffer{}
+	cmd.Stderr = stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("livetranscript: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("livetranscript: failed to start ffmpeg: %w", err)
+	}
+	return &ffmpegStream{stdout: stdout, cmd: cmd, stderr: stderr}, nil
+}
+
+// ffmpegStream is the output of a running ffmpeg. Reading it to the end
+// returns ffmpeg's error, if it failed, instead of io.EOF.
+type ffmpegStream struct {
+	stdout io.ReadCloser
+	cmd    *exec.Cmd
+	stderr *tailBuffer
+
+	once sync.Once
+	err  error
+}
+
+func (s *ffmpegStream) Read(p []byte) (int, error) {
+	n, err := s.stdout.Read(p)
+	if err == io.EOF {
+		if waitErr := s.wait(); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// Close stops ffmpeg.
+func (s *ffmpegStream) Close() error {
+	if s.cmd.ProcessState == nil {
+		_ = s.cmd.Process.Kill()
+	}
+	s.wait()
+	return nil
+}
+
+// wait waits for ffmpeg to exit and returns why it failed.
+func (s *ffmpegStream) wait() error {
+	s.once.Do(func() {
+		if err := s.cmd.Wait(); err != nil {
+			if msg := strings.TrimSpace(s.stderr.String()); msg != "" {
+				err = fmt.Errorf("%w: %s", err, msg)
+			}
+			s.err = fmt.Errorf("livetranscript: ffmpeg failed: %w", err)
+		}
+	})
+	return s.err
+}
+
+// tailBuffer keeps the end of what is written to it.
+type tailBuffer struct {
+	mu sync.Mutex
+	b  []byte
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.b = append(t.b, p...)
+	if len(t.b) > stderrTail {
+		t.b = t.b[len(t.b)-stderrTail:]
+	}
+	return len(p), nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.b)
+}
+
+// pcmDuration returns the length of n bytes of PCM.
+func pcmDuration(n int) time.Duration {
+	return time.Duration(n) * time.Second / bytesPerSecond
+}
+
+// silent reports whether pcm is too quiet to hold speech.
+func silent(pcm []byte) bool {
+	samples := len(pcm) / bytesPerSample
+	if samples == 0 {
+		return true
+	}
+	var sum float64
+	for i := 0; i < samples; i++ {
+		v := float64(int16(binary.LittleEndian.Uint16(pcm[i*bytesPerSample:])))
+		sum += v * v
+	}
+	return math.Sqrt(sum/float64(samples)) < silenceRMS
+}
+
+// writeWAV writes pcm to path as a WAV file.
+func writeWAV(path string, pcm []byte) error {
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(36+len(pcm)))
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16) // PCM format chunk size
+	binary.LittleEndian.PutUint16(header[20:], 1)  // PCM
+	binary.LittleEndian.PutUint16(header[22:], 1)  // Mono
+	binary.LittleEndian.PutUint32(header[24:], SampleRate)
+	binary.LittleEndian.PutUint32(header[28:], bytesPerSecond)
+	binary.LittleEndian.PutUint16(header[32:], bytesPerSample)
+	binary.LittleEndian.PutUint16(header[34:], 8*bytesPerSample)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(len(pcm)))
+	return os.WriteFile(path, append(header, pcm...), 0o600)
+}
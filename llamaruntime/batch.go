@@ -0,0 +1,376 @@
+// Package llamaruntime provides Go bindings to llama.cpp for local LLM inference.
+// This file contains the batchScheduler, which decodes several text requests
+// together in one llama.cpp context.
+//
+// Without batching every request owns a whole context and runs its own
+// llama_decode per token, so concurrent requests compete for the GPU one
+// small batch at a time. The scheduler instead gives each request a sequence
+// ID inside a shared context (n_seq_max > 1) and, on every step, decodes the
+// next token of all active sequences plus any pending prompt tokens in a
+// single llama_decode call.
+//
+// The scheduling logic is pure Go and talks to llama.cpp through the
+// seqBackend interface, implemented with CGo in bindings.go and by a
+// deterministic stub in bindings_stub.go.
+package llamaruntime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrSchedulerClosed is returned when submitting to a closed batchScheduler.
+var ErrSchedulerClosed = errors.New("batch scheduler closed")
+
+// batchToken is one entry of a multi-sequence decode batch.
+type batchToken struct {
+	Token  int32
+	Pos    int
+	Seq    int
+	Logits bool
+}
+
+// seqBackend is the llama.cpp surface needed for multi-sequence decoding.
+// Methods are only called from the scheduler goroutine, except Tokenize.
+type seqBackend interface {
+	// Tokenize converts a prompt into tokens. Safe for concurrent use.
+	Tokenize(prompt string) ([]int32, error)
+
+	// MaxSequences is the number of sequences the context holds (n_seq_max).
+	MaxSequences() int
+
+	// SeqContextSize is the number of KV cells available to each sequence.
+	SeqContextSize() int
+
+	// BatchCapacity is the maximum number of tokens per Decode call.
+	BatchCapacity() int
+
+	// StartSequence prepares sampling for a sequence slot.
+	StartSequence(seq int, params SamplingParams)
+
+	// Decode evaluates a batch of tokens across sequences.
+	Decode(tokens []batchToken) error
+
+	// Sample picks the next token of seq from the logits at batchIndex
+	// of the last Decode call. End of sequence is suppressed unless allowEOG.
+	Sample(seq int, batchIndex int, allowEOG bool) int32
+
+	// IsEOG reports whether token ends generation.
+	IsEOG(token int32) bool
+
+	// TokenPiece returns the raw bytes of a token, which may be only part
+	// of a multi-byte UTF-8 character.
+	TokenPiece(token int32) string
+
+	// EndSequence removes the sequence from the KV cache and frees its sampler.
+	EndSequence(seq int)
+
+	// Close releases the backend.
+	Close()
+}
+
+// batchRequest is a text generation request waiting for a sequence slot.
+type batchRequest struct {
+	ctx    context.Context
+	tokens []int32
+	limits generationLimits
+	params SamplingParams
+	result chan batchResult
+}
+
+// batchResult is the outcome of a batchRequest.
+type batchResult struct {
+	gen generation
+	err error
+}
+
+// batchSequence is the state of a request occupying a sequence slot.
+type batchSequence struct {
+	req *batchRequest
+
+	// pending holds prompt tokens not yet decoded
+	pending []int32
+
+	// pos is the position of the next token in the sequence
+	pos int
+
+	// next is the sampled token to feed on the next step (-1 while prefilling)
+	next int32
+
+	// logitsIndex is the batch index holding this sequence's logits after
+	// the last Decode, or -1 if it has none.
+	logitsIndex int
+
+	generated  int
+	text       []byte
+	detok      streamDetokenizer
+	stopReason string
+}
+
+// batchScheduler multiplexes text requests onto one multi-sequence context.
+// Thread-safe: Submit may be called from many goroutines.
+type batchScheduler struct {
+	backend seqBackend
+
+	requests chan *batchRequest
+	done     chan struct{}
+	wg       sync.WaitGroup
+
+	closeOnce sync.Once
+}
+
+// newBatchScheduler starts a scheduler goroutine driving backend.
+// The scheduler owns the backend and closes it on Close.
+func newBatchScheduler(backend seqBackend) *batchScheduler {
+	s := &batchScheduler{
+		backend:  backend,
+		requests: make(chan *batchRequest),
+		done:     make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+// Submit generates text for prompt within limits, batched with other
+// concurrent requests. On cancellation the text generated so far is
+// returned together with ctx.Err().
+func (s *batchScheduler) Submit(ctx context.Context, prompt string, limits generationLimits, params SamplingParams) (generation, error) {
+	tokens, err := s.backend.Tokenize(prompt)
+	if err != nil {
+		return generation{}, fmt.Errorf("tokenize prompt: %w", err)
+	}
+	if len(tokens) == 0 {
+		return generation{}, &LlamaError{Op: "Submit", Code: -1, Message: "empty prompt"}
+	}
+	if limit := s.backend.SeqContextSize(); len(tokens)+limits.MaxTokens > limit {
+		return generation{}, &LlamaError{
+			Op:      "Submit",
+			Code:    -1,
+			Message: fmt.Sprintf("prompt (%d tokens) + max_tokens (%d) exceeds context size (%d)", len(tokens), limits.MaxTokens, limit),
+		}
+	}
+
+	req := &batchRequest{
+		ctx:    ctx,
+		tokens: tokens,
+		limits: limits,
+		params: params,
+		result: make(chan batchResult, 1),
+	}
+
+	select {
+	case s.requests <- req:
+	case <-s.done:
+		return generation{}, ErrSchedulerClosed
+	case <-ctx.Done():
+		return generation{}, ctx.Err()
+	}
+
+	res := <-req.result
+	return res.gen, res.err
+}
+
+// Close stops the scheduler, failing in-flight requests, and closes the backend.
+// Safe to call multiple times.
+func (s *batchScheduler) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+		s.backend.Close()
+	})
+}
+
+// run is the scheduler loop. It admits requests into free sequence slots,
+// decodes one combined batch per step and samples every sequence that has
+// fresh logits.
+func (s *batchScheduler) run() {
+	defer s.wg.Done()
+
+	slots := make([]*batchSequence, s.backend.MaxSequences())
+	var queue []*batchRequest
+	batch := make([]batchToken, 0, s.backend.BatchCapacity())
+
+	for {
+		// Block for work when idle, otherwise just drain new arrivals
+		if len(queue) == 0 && activeSequences(slots) == 0 {
+			select {
+			case req := <-s.requests:
+				queue = append(queue, req)
+			case <-s.done:
+				return
+			}
+		}
+	drain:
+		for {
+			select {
+			case req := <-s.requests:
+				queue = append(queue, req)
+			case <-s.done:
+				s.failAll(slots, queue, ErrSchedulerClosed)
+				return
+			default:
+				break drain
+			}
+		}
+
+		// Admit queued requests into free slots
+		for i := range slots {
+			if len(queue) == 0 {
+				break
+			}
+			if slots[i] == nil {
+				req := queue[0]
+				queue = queue[1:]
+				s.backend.StartSequence(i, req.params)
+				slots[i] = &batchSequence{req: req, pending: req.tokens, next: -1, logitsIndex: -1}
+			}
+		}
+
+		// Drop cancelled requests before spending a decode on them
+		waiting := queue[:0]
+		for _, req := range queue {
+			if err := req.ctx.Err(); err != nil {
+				req.result <- batchResult{err: err}
+				continue
+			}
+			waiting = append(waiting, req)
+		}
+		queue = waiting
+		for i, seq := range slots {
+			if seq != nil && seq.req.ctx.Err() != nil {
+				s.finish(slots, i, seq.req.ctx.Err())
+			}
+		}
+
+		batch = s.buildBatch(slots, batch[:0])
+		if len(batch) == 0 {
+			continue
+		}
+
+		if err := s.backend.Decode(batch); err != nil {
+			for i, seq := range slots {
+				if seq != nil {
+					s.finish(slots, i, &LlamaError{
+						Op:      "batchDecode",
+						Code:    -1,
+						Message: "failed to decode batch",
+						Err:     fmt.Errorf("%w: %v", ErrInferenceFailed, err),
+					})
+				}
+			}
+			continue
+		}
+
+		s.sampleAll(slots)
+	}
+}
+
+// buildBatch assembles the next decode batch: first one token for every
+// generating sequence, then as many pending prompt tokens as still fit.
+func (s *batchScheduler) buildBatch(slots []*batchSequence, batch []batchToken) []batchToken {
+	capacity := s.backend.BatchCapacity()
+
+	for i, seq := range slots {
+		if seq == nil {
+			continue
+		}
+		seq.logitsIndex = -1
+		if seq.next >= 0 && len(batch) < capacity {
+			seq.logitsIndex = len(batch)
+			batch = append(batch, batchToken{Token: seq.next, Pos: seq.pos, Seq: i, Logits: true})
+			seq.pos++
+			seq.next = -1
+		}
+	}
+
+	for i, seq := range slots {
+		if seq == nil || len(seq.pending) == 0 {
+			continue
+		}
+		n := capacity - len(batch)
+		if n <= 0 {
+			break
+		}
+		if n > len(seq.pending) {
+			n = len(seq.pending)
+		}
+		for j := 0; j < n; j++ {
+			last := j == n-1 && n == len(seq.pending)
+			if last {
+				seq.logitsIndex = len(batch)
+			}
+			batch = append(batch, batchToken{Token: seq.pending[j], Pos: seq.pos, Seq: i, Logits: last})
+			seq.pos++
+		}
+		seq.pending = seq.pending[n:]
+	}
+
+	return batch
+}
+
+// sampleAll samples the next token for every sequence with fresh logits
+// and finishes sequences that reached end of generation or a limit.
+func (s *batchScheduler) sampleAll(slots []*batchSequence) {
+	for i, seq := range slots {
+		if seq == nil || seq.logitsIndex < 0 {
+			continue
+		}
+
+		limits := seq.req.limits
+		token := s.backend.Sample(i, seq.logitsIndex, limits.allowEOG(seq.generated))
+		if s.backend.IsEOG(token) {
+			seq.text = append(seq.text, seq.detok.Flush()...)
+			seq.stopReason = StopReasonEOS
+			s.finish(slots, i, nil)
+			continue
+		}
+
+		piece := seq.detok.Write(s.backend.TokenPiece(token))
+		seq.text = append(seq.text, piece...)
+		seq.generated++
+		if keep, reason := limits.check(seq.text, len(piece), seq.generated); reason != "" {
+			seq.text = seq.text[:keep]
+			seq.stopReason = reason
+			s.finish(slots, i, nil)
+			continue
+		}
+		seq.next = token
+	}
+}
+
+// finish delivers the result of the sequence in slot i and frees the slot.
+func (s *batchScheduler) finish(slots []*batchSequence, i int, err error) {
+	seq := slots[i]
+	s.backend.EndSequence(i)
+	slots[i] = nil
+	seq.req.result <- batchResult{
+		gen: generation{Text: string(seq.text), Tokens: seq.generated, StopReason: seq.stopReason},
+		err: err,
+	}
+}
+
+// failAll fails every active and queued request.
+func (s *batchScheduler) failAll(slots []*batchSequence, queue []*batchRequest, err error) {
+	for i, seq := range slots {
+		if seq != nil {
+			s.finish(slots, i, err)
+		}
+	}
+	for _, req := range queue {
+		req.result <- batchResult{err: err}
+	}
+}
+
+// activeSequences counts occupied slots.
+func activeSequences(slots []*batchSequence) int {
+	n := 0
+	for _, seq := range slots {
+		if seq != nil {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,365 @@
+package llamaruntime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSeqBackend is a deterministic seqBackend for scheduler tests.
+// Every sequence generates genTokens tokens with piece "x", then EOS.
+type fakeSeqBackend struct {
+	nSeq      int
+	ctxSize   int
+	capacity  int
+	genTokens int
+
+	// pieces, if set, are emitted in order instead of "x"
+	pieces []string
+
+	mu        sync.Mutex
+	generated map[int]int
+	decodes   [][]batchToken
+	decodeErr error
+	ended     []int
+	closed    bool
+
+	// gate, if set, blocks the first Decode until closed.
+	gate    chan struct{}
+	entered chan struct{}
+}
+
+const fakeEOS = int32(-2)
+
+func newFakeSeqBackend(nSeq, capacity, genTokens int) *fakeSeqBackend {
+	return &fakeSeqBackend{
+		nSeq:      nSeq,
+		ctxSize:   256,
+		capacity:  capacity,
+		genTokens: genTokens,
+		generated: make(map[int]int),
+	}
+}
+
+func (f *fakeSeqBackend) Tokenize(prompt string) ([]int32, error) {
+	tokens := make([]int32, len(strings.Fields(prompt)))
+	for i := range tokens {
+		tokens[i] = int32(i + 1)
+	}
+	return tokens, nil
+}
+
+func (f *fakeSeqBackend) MaxSequences() int   { return f.nSeq }
+func (f *fakeSeqBackend) SeqContextSize() int { return f.ctxSize }
+func (f *fakeSeqBackend) BatchCapacity() int  { return f.capacity }
+
+func (f *fakeSeqBackend) StartSequence(seq int, params SamplingParams) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.generated[seq] = 0
+}
+
+func (f *fakeSeqBackend) Decode(tokens []batchToken) error {
+	f.mu.Lock()
+	gate := f.gate
+	f.gate = nil
+	f.decodes = append(f.decodes, append([]batchToken(nil), tokens...))
+	err := f.decodeErr
+	f.mu.Unlock()
+
+	if gate != nil {
+		close(f.entered)
+		<-gate
+	}
+	return err
+}
+
+func (f *fakeSeqBackend) Sample(seq int, batchIndex int, allowEOG bool) int32 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.generated[seq]++
+	if f.generated[seq] > f.genTokens && allowEOG {
+		return fakeEOS
+	}
+	if f.pieces != nil {
+		return int32((f.generated[seq] - 1) % len(f.pieces))
+	}
+	return 7
+}
+
+func (f *fakeSeqBackend) IsEOG(token int32) bool { return token == fakeEOS }
+func (f *fakeSeqBackend) TokenPiece(token int32) string {
+	if f.pieces != nil {
+		return f.pieces[token]
+	}
+	return "x"
+}
+
+func (f *fakeSeqBackend) EndSequence(seq int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.ended = append(f.ended, seq)
+}
+
+func (f *fakeSeqBackend) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+}
+
+func TestBatchScheduler_SingleRequest(t *testing.T) {
+	backend := newFakeSeqBackend(2, 16, 3)
+	s := newBatchScheduler(backend)
+	defer s.Close()
+
+	gen, err := s.Submit(context.Background(), "a b c", generationLimits{MaxTokens: 10}, SamplingParams{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if gen.Text != "xxx" || gen.StopReason != StopReasonEOS {
+		t.Errorf("Submit() = %q (%s), want %q (eos)", gen.Text, gen.StopReason, "xxx")
+	}
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	// Prompt decode, then one decode per generated token
+	if len(backend.decodes) != 4 {
+		t.Errorf("decodes = %d, want 4", len(backend.decodes))
+	}
+	prompt := backend.decodes[0]
+	if len(prompt) != 3 || !prompt[2].Logits || prompt[0].Logits {
+		t.Errorf("prompt batch = %+v, want 3 tokens with logits only on the last", prompt)
+	}
+	if len(backend.ended) != 1 {
+		t.Errorf("ended sequences = %v, want one", backend.ended)
+	}
+}
+
+func TestBatchScheduler_MaxTokens(t *testing.T) {
+	backend := newFakeSeqBackend(1, 16, 100)
+	s := newBatchScheduler(backend)
+	defer s.Close()
+
+	gen, err := s.Submit(context.Background(), "a", generationLimits{MaxTokens: 5}, SamplingParams{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if gen.Text != "xxxxx" || gen.Tokens != 5 || gen.StopReason != StopReasonMaxTokens {
+		t.Errorf("Submit() = %q (%d tokens, %s), want 5 tokens (max_tokens)", gen.Text, gen.Tokens, gen.StopReason)
+	}
+}
+
+func TestBatchScheduler_MinTokensAndStopSequence(t *testing.T) {
+	backend := newFakeSeqBackend(1, 16, 1)
+	s := newBatchScheduler(backend)
+	defer s.Close()
+
+	// End of sequence is suppressed until MinTokens
+	gen, err := s.Submit(context.Background(), "a", generationLimits{MaxTokens: 10, MinTokens: 4}, SamplingParams{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if gen.Text != "xxxx" || gen.StopReason != StopReasonEOS {
+		t.Errorf("Submit() = %q (%s), want %q (eos)", gen.Text, gen.StopReason, "xxxx")
+	}
+
+	backend.genTokens = 100
+	gen, err = s.Submit(context.Background(), "a", generationLimits{MaxTokens: 50, StopSequences: []string{"xxx"}}, SamplingParams{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if gen.Text != "" || gen.Tokens != 3 || gen.StopReason != StopReasonStopSequence {
+		t.Errorf("Submit() = %q (%d tokens, %s), want empty text after 3 tokens (stop_sequence)",
+			gen.Text, gen.Tokens, gen.StopReason)
+	}
+}
+
+func TestBatchScheduler_SplitMultiByteCharacters(t *testing.T) {
+	// "日本" and "🎉" split across byte-level tokens
+	pieces := []string{"\xe6\x97", "\xa5\xe6", "\x9c\xac", " ", "\xf0\x9f", "\x8e\x89"}
+	backend := newFakeSeqBackend(1, 16, len(pieces))
+	backend.pieces = pieces
+	s := newBatchScheduler(backend)
+	defer s.Close()
+
+	gen, err := s.Submit(context.Background(), "a", generationLimits{MaxTokens: 10}, SamplingParams{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if gen.Text != "日本 🎉" {
+		t.Errorf("text = %q, want %q", gen.Text, "日本 🎉")
+	}
+
+	// The byte cap must not cut a character in half
+	gen, err = s.Submit(context.Background(), "a", generationLimits{MaxTokens: 10, MaxOutputBytes: 5}, SamplingParams{})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	if gen.Text != "日" || gen.StopReason != StopReasonMaxBytes {
+		t.Errorf("capped text = %q (%s), want %q (max_bytes)", gen.Text, gen.StopReason, "日")
+	}
+}
+
+func TestBatchScheduler_ChunksLongPrompts(t *testing.T) {
+	backend := newFakeSeqBackend(1, 4, 1)
+	s := newBatchScheduler(backend)
+	defer s.Close()
+
+	if _, err := s.Submit(context.Background(), "a b c d e f g h i j", generationLimits{MaxTokens: 4}, SamplingParams{}); err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	var sizes []int
+	for _, d := range backend.decodes[:3] {
+		sizes = append(sizes, len(d))
+	}
+	if sizes[0] != 4 || sizes[1] != 4 || sizes[2] != 2 {
+		t.Errorf("prompt chunk sizes = %v, want [4 4 2]", sizes)
+	}
+	for i, d := range backend.decodes[:2] {
+		for _, tok := range d {
+			if tok.Logits {
+				t.Errorf("chunk %d requested logits before the prompt was complete", i)
+			}
+		}
+	}
+	last := backend.decodes[2]
+	if !last[len(last)-1].Logits {
+		t.Error("last prompt token should request logits")
+	}
+	if last[len(last)-1].Pos != 9 {
+		t.Errorf("last prompt position = %d, want 9", last[len(last)-1].Pos)
+	}
+}
+
+func TestBatchScheduler_BatchesConcurrentRequests(t *testing.T) {
+	backend := newFakeSeqBackend(4, 32, 5)
+	gate := make(chan struct{})
+	backend.gate = gate
+	backend.entered = make(chan struct{})
+	s := newBatchScheduler(backend)
+	defer s.Close()
+
+	const n = 4
+	var wg sync.WaitGroup
+	results := make([]generation, n)
+	errs := make([]error, n)
+	submit := func(i int) {
+		defer wg.Done()
+		results[i], errs[i] = s.Submit(context.Background(), "a b", generationLimits{MaxTokens: 10}, SamplingParams{})
+	}
+
+	// Hold the first decode so the other requests queue up behind it
+	wg.Add(1)
+	go submit(0)
+	<-backend.entered
+	for i := 1; i < n; i++ {
+		wg.Add(1)
+		go submit(i)
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(gate)
+	wg.Wait()
+
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatalf("request %d error = %v", i, errs[i])
+		}
+		if results[i].Text != "xxxxx" {
+			t.Errorf("request %d text = %q, want %q", i, results[i].Text, "xxxxx")
+		}
+	}
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	maxSeqs := 0
+	for _, d := range backend.decodes {
+		seqs := make(map[int]bool)
+		for _, tok := range d {
+			seqs[tok.Seq] = true
+		}
+		if len(seqs) > maxSeqs {
+			maxSeqs = len(seqs)
+		}
+	}
+	if maxSeqs < n {
+		t.Errorf("largest batch covered %d sequences, want %d", maxSeqs, n)
+	}
+}
+
+func TestBatchScheduler_MoreRequestsThanSlots(t *testing.T) {
+	backend := newFakeSeqBackend(2, 32, 3)
+	s := newBatchScheduler(backend)
+	defer s.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gen, err := s.Submit(context.Background(), "a b c", generationLimits{MaxTokens: 10}, SamplingParams{})
+			if err != nil || gen.Text != "xxx" {
+				t.Errorf("Submit() = %q, %v; want %q", gen.Text, err, "xxx")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBatchScheduler_PromptTooLong(t *testing.T) {
+	backend := newFakeSeqBackend(1, 16, 1)
+	backend.ctxSize = 4
+	s := newBatchScheduler(backend)
+	defer s.Close()
+
+	if _, err := s.Submit(context.Background(), "a b c", generationLimits{MaxTokens: 2}, SamplingParams{}); err == nil {
+		t.Error("expected error when prompt + max_tokens exceeds the sequence context")
+	}
+}
+
+func TestBatchScheduler_DecodeError(t *testing.T) {
+	backend := newFakeSeqBackend(1, 16, 3)
+	backend.decodeErr = errors.New("out of memory")
+	s := newBatchScheduler(backend)
+	defer s.Close()
+
+	_, err := s.Submit(context.Background(), "a b", generationLimits{MaxTokens: 10}, SamplingParams{})
+	if !errors.Is(err, ErrInferenceFailed) {
+		t.Errorf("error = %v, want ErrInferenceFailed", err)
+	}
+}
+
+func TestBatchScheduler_Cancellation(t *testing.T) {
+	backend := newFakeSeqBackend(1, 16, 1000)
+	s := newBatchScheduler(backend)
+	defer s.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := s.Submit(ctx, "a b", generationLimits{MaxTokens: 200}, SamplingParams{})
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error = %v, want nil or DeadlineExceeded", err)
+	}
+}
+
+func TestBatchScheduler_Close(t *testing.T) {
+	backend := newFakeSeqBackend(1, 16, 1)
+	s := newBatchScheduler(backend)
+	s.Close()
+	s.Close() // idempotent
+
+	if _, err := s.Submit(context.Background(), "a", generationLimits{MaxTokens: 1}, SamplingParams{}); !errors.Is(err, ErrSchedulerClosed) {
+		t.Errorf("Submit() after Close error = %v, want ErrSchedulerClosed", err)
+	}
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if !backend.closed {
+		t.Error("backend should be closed")
+	}
+}
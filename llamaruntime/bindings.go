@@ -118,6 +118,8 @@ extern int32_t llama_n_embd(const llama_model * model);
 extern const char * llama_token_get_text(const llama_model * model, llama_token token);
 extern llama_token llama_token_bos(const llama_model * model);
 extern llama_token llama_token_eos(const llama_model * model);
+extern llama_token llama_token_eot(const llama_model * model);
+extern bool llama_token_is_eog(const llama_model * model, llama_token token);
 extern llama_token llama_token_nl(const llama_model * model);
 extern int32_t llama_tokenize(const llama_model * model, const char * text, int32_t text_len, llama_token * tokens, int32_t n_tokens_max, bool add_special, bool parse_special);
 extern int32_t llama_token_to_piece(const llama_model * model, llama_token token, char * buf, int32_t length, int32_t lstrip, bool special);
@@ -127,8 +129,10 @@ extern int32_t llama_decode(llama_context * ctx, struct llama_batch batch);
 extern float * llama_get_logits(llama_context * ctx);
 extern float * llama_get_logits_ith(llama_context * ctx, int32_t i);
 extern void llama_kv_cache_clear(llama_context * ctx);
+extern bool llama_kv_cache_seq_rm(llama_context * ctx, llama_seq_id seq_id, llama_pos p0, llama_pos p1);
 extern void llama_synchronize(llama_context * ctx);
 extern void llama_perf_context_reset(llama_context * ctx);
+extern const char * llama_print_system_info(void);
 
 // Sampler functions
 extern struct llama_sampler_chain_params llama_sampler_chain_default_params(void);
@@ -161,6 +165,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"math"
 	"os/exec"
 	"runtime"
 	"strconv"
@@ -178,6 +183,15 @@ var (
 	llamaBackendInit bool
 )
 
+// Linked reports whether this build links llama.cpp.
+const Linked = true
+
+// SystemInfo returns the CPU and GPU features llama.cpp was compiled with,
+// e.g. "CUDA : ARCHS = 860 | CPU : AVX2 = 1 | ...".
+func SystemInfo() string {
+	return C.GoString(C.llama_print_system_info())
+}
+
 // llamaInit initializes the llama.cpp backend.
 // This function is safe to call multiple times; initialization happens only once.
 // It should be called before any other llama operations.
@@ -309,6 +323,29 @@ func (m *llamaModel) EOSToken() int {
 	return int(C.llama_token_eos(m.ptr))
 }
 
+// EOTToken returns the end-of-turn token ID, or -1 if the model has none.
+func (m *llamaModel) EOTToken() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ptr == nil {
+		return -1
+	}
+	return int(C.llama_token_eot(m.ptr))
+}
+
+// IsEOG reports whether token ends generation. Besides EOS this covers
+// chat-template terminators such as <|eot_id|> and <|im_end|>.
+func (m *llamaModel) IsEOG(token int32) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.ptr == nil {
+		return false
+	}
+	return bool(C.llama_token_is_eog(m.ptr, C.llama_token(token)))
+}
+
 // Close releases the model resources.
 // This is safe to call multiple times.
 func (m *llamaModel) Close() {
@@ -337,7 +374,14 @@ type llamaContext struct {
 // contextSize is the maximum context window (prompt + response tokens).
 // batchSize is the number of tokens processed in parallel.
 // numThreads is the number of CPU threads for inference.
-func createContext(model *llamaModel, contextSize, batchSize, numThreads int) (*llamaContext, error) {
+// opts selects flash attention and the KV cache data types.
+func createContext(model *llamaModel, contextSize, batchSize, numThreads int, opts ContextOptions) (*llamaContext, error) {
+	return createSeqContext(model, contextSize, batchSize, numThreads, 1, opts)
+}
+
+// createSeqContext creates an inference context holding up to nSeqMax
+// independent sequences that share contextSize KV cells.
+func createSeqContext(model *llamaModel, contextSize, batchSize, numThreads, nSeqMax int, opts ContextOptions) (*llamaContext, error) {
 	if model == nil || model.ptr == nil {
 		return nil, &LlamaError{
 			Op:      "createContext",
@@ -353,9 +397,12 @@ func createContext(model *llamaModel, contextSize, batchSize, numThreads int) (*
 	params.n_ctx = C.uint32_t(contextSize)
 	params.n_batch = C.uint32_t(batchSize)
 	params.n_ubatch = C.uint32_t(batchSize)
+	params.n_seq_max = C.uint32_t(nSeqMax)
 	params.n_threads = C.int32_t(numThreads)
 	params.n_threads_batch = C.int32_t(numThreads)
-	params.flash_attn = C.bool(true) // Enable flash attention if available
+	params.flash_attn = C.bool(!opts.DisableFlashAttention) // Used if the backend supports it
+	params.type_k = C.int32_t(opts.TypeK.ggmlType())
+	params.type_v = C.int32_t(opts.TypeV.ggmlType())
 
 	// Create context
 	model.mu.Lock()
@@ -464,31 +511,36 @@ func (c *llamaContext) configureSampler(params SamplingParams) {
 		C.llama_sampler_free(c.sampler)
 	}
 
-	// Create new sampler chain
+	c.sampler = newSamplerChain(c.model, params)
+}
+
+// newSamplerChain creates a sampler chain for the given parameters.
+// The caller must free it with llama_sampler_free.
+func newSamplerChain(model *llamaModel, params SamplingParams) *C.llama_sampler {
 	samplerParams := C.llama_sampler_chain_default_params()
-	c.sampler = C.llama_sampler_chain_init(samplerParams)
+	sampler := C.llama_sampler_chain_init(samplerParams)
 
 	// Add samplers in order (order matters!)
 	// Temperature sampling
 	if params.Temperature > 0 {
-		C.llama_sampler_chain_add(c.sampler, C.llama_sampler_init_temp(C.float(params.Temperature)))
+		C.llama_sampler_chain_add(sampler, C.llama_sampler_init_temp(C.float(params.Temperature)))
 	}
 
 	// Top-K sampling
 	if params.TopK > 0 {
-		C.llama_sampler_chain_add(c.sampler, C.llama_sampler_init_top_k(C.int32_t(params.TopK)))
+		C.llama_sampler_chain_add(sampler, C.llama_sampler_init_top_k(C.int32_t(params.TopK)))
 	}
 
 	// Top-P (nucleus) sampling
 	if params.TopP > 0 && params.TopP < 1.0 {
-		C.llama_sampler_chain_add(c.sampler, C.llama_sampler_init_top_p(C.float(params.TopP), 1))
+		C.llama_sampler_chain_add(sampler, C.llama_sampler_init_top_p(C.float(params.TopP), 1))
 	}
 
 	// Repetition penalty
 	if params.RepeatPenalty != 1.0 {
-		vocabSize := c.model.VocabSize()
-		eosToken := c.model.EOSToken()
-		C.llama_sampler_chain_add(c.sampler, C.llama_sampler_init_penalties(
+		vocabSize := model.VocabSize()
+		eosToken := model.EOSToken()
+		C.llama_sampler_chain_add(sampler, C.llama_sampler_init_penalties(
 			C.int32_t(vocabSize),
 			C.llama_token(eosToken),
 			C.llama_token(-1), // no linefeed penalty
@@ -502,7 +554,9 @@ func (c *llamaContext) configureSampler(params SamplingParams) {
 	}
 
 	// Distribution sampler (with seed for reproducibility)
-	C.llama_sampler_chain_add(c.sampler, C.llama_sampler_init_dist(C.uint32_t(params.Seed)))
+	C.llama_sampler_chain_add(sampler, C.llama_sampler_init_dist(C.uint32_t(params.Seed)))
+
+	return sampler
 }
 
 // tokenize converts text to tokens using the model's tokenizer.
@@ -565,7 +619,9 @@ func tokenize(model *llamaModel, text string, addBOS bool) ([]C.llama_token, err
 	return tokens[:nTokens], nil
 }
 
-// detokenize converts a token to its text representation.
+// detokenize converts a token to its raw bytes. Control tokens render as
+// empty while user-defined added tokens keep their text. A piece may hold
+// only part of a multi-byte character; pass it through streamDetokenizer.
 func detokenize(model *llamaModel, token C.llama_token) string {
 	if model == nil || model.ptr == nil {
 		return ""
@@ -645,10 +701,11 @@ func batchSetLogits(batch *C.struct_llama_batch, i int, logits C.int8_t) {
 
 // inferText performs text inference on the given prompt.
 // It returns the generated text and any error encountered.
-// The context is used for cancellation and timeout.
-func inferText(ctx context.Context, llamaCtx *llamaContext, prompt string, maxTokens int, params SamplingParams) (string, error) {
+// The context is used for cancellation and timeout; limits are enforced
+// after every sampled token.
+func inferText(ctx context.Context, llamaCtx *llamaContext, prompt string, limits generationLimits, params SamplingParams) (generation, error) {
 	if llamaCtx == nil || llamaCtx.ptr == nil {
-		return "", &LlamaError{
+		return generation{}, &LlamaError{
 			Op:      "inferText",
 			Code:    -1,
 			Message: "invalid context (nil)",
@@ -664,16 +721,16 @@ func inferText(ctx context.Context, llamaCtx *llamaContext, prompt string, maxTo
 	// Tokenize the prompt
 	tokens, err := tokenize(llamaCtx.model, prompt, true)
 	if err != nil {
-		return "", fmt.Errorf("tokenize prompt: %w", err)
+		return generation{}, fmt.Errorf("tokenize prompt: %w", err)
 	}
 
 	// Check if prompt fits in context
 	contextSize := llamaCtx.ContextSize()
-	if len(tokens)+maxTokens > contextSize {
-		return "", &LlamaError{
+	if len(tokens)+limits.MaxTokens > contextSize {
+		return generation{}, &LlamaError{
 			Op:      "inferText",
 			Code:    -1,
-			Message: fmt.Sprintf("prompt (%d tokens) + max_tokens (%d) exceeds context size (%d)", len(tokens), maxTokens, contextSize),
+			Message: fmt.Sprintf("prompt (%d tokens) + max_tokens (%d) exceeds context size (%d)", len(tokens), limits.MaxTokens, contextSize),
 		}
 	}
 
@@ -695,7 +752,7 @@ func inferText(ctx context.Context, llamaCtx *llamaContext, prompt string, maxTo
 	// Decode prompt
 	if ret := C.llama_decode(llamaCtx.ptr, llamaCtx.batch); ret != 0 {
 		llamaCtx.mu.Unlock()
-		return "", &LlamaError{
+		return generation{}, &LlamaError{
 			Op:      "inferText",
 			Code:    int(ret),
 			Message: "failed to decode prompt",
@@ -707,32 +764,46 @@ func inferText(ctx context.Context, llamaCtx *llamaContext, prompt string, maxTo
 
 	// Generate tokens
 	var result []byte
+	var detok streamDetokenizer
 	nPrompt := len(tokens)
-	eosToken := C.llama_token(llamaCtx.model.EOSToken())
+	gen := generation{StopReason: StopReasonEOS}
 
-	for i := 0; i < maxTokens; i++ {
+	for i := 0; ; i++ {
 		// Check for cancellation
 		select {
 		case <-ctx.Done():
-			return string(result), ctx.Err()
+			gen.Text = string(result)
+			return gen, ctx.Err()
 		default:
 		}
 
 		llamaCtx.mu.Lock()
 
-		// Sample next token
+		// Sample next token, keeping EOS out of reach until MinTokens
+		if !limits.allowEOG(i) {
+			suppressEOG(llamaCtx, -1)
+		}
 		newToken := C.llama_sampler_sample(llamaCtx.sampler, llamaCtx.ptr, -1)
 
 		llamaCtx.mu.Unlock()
 
-		// Check for end of sequence
-		if newToken == eosToken {
+		// Check for end of generation
+		if llamaCtx.model.IsEOG(int32(newToken)) {
+			result = append(result, detok.Flush()...)
 			break
 		}
 
-		// Decode token to text
-		piece := detokenize(llamaCtx.model, newToken)
+		// Decode token to text, holding back incomplete characters
+		piece := detok.Write(detokenize(llamaCtx.model, newToken))
 		result = append(result, piece...)
+		gen.Tokens++
+
+		// Enforce stop sequences, byte cap and max tokens
+		if keep, reason := limits.check(result, len(piece), gen.Tokens); reason != "" {
+			result = result[:keep]
+			gen.StopReason = reason
+			break
+		}
 
 		// Prepare next batch
 		llamaCtx.mu.Lock()
@@ -747,7 +818,8 @@ func inferText(ctx context.Context, llamaCtx *llamaContext, prompt string, maxTo
 		// Decode
 		if ret := C.llama_decode(llamaCtx.ptr, llamaCtx.batch); ret != 0 {
 			llamaCtx.mu.Unlock()
-			return string(result), &LlamaError{
+			gen.Text = string(result)
+			return gen, &LlamaError{
 				Op:      "inferText",
 				Code:    int(ret),
 				Message: fmt.Sprintf("failed to decode at token %d", i),
@@ -758,7 +830,132 @@ func inferText(ctx context.Context, llamaCtx *llamaContext, prompt string, maxTo
 		llamaCtx.mu.Unlock()
 	}
 
-	return string(result), nil
+	gen.Text = string(result)
+	return gen, nil
+}
+
+// suppressEOG sets the EOS and EOT logits at batch index idx to -inf so the
+// sampler cannot end generation. Caller must hold the context lock.
+func suppressEOG(llamaCtx *llamaContext, idx int32) {
+	logits := C.llama_get_logits_ith(llamaCtx.ptr, C.int32_t(idx))
+	if logits == nil {
+		return
+	}
+	nVocab := llamaCtx.model.VocabSize()
+	values := unsafe.Slice((*C.float)(unsafe.Pointer(logits)), nVocab)
+	for _, token := range []int{llamaCtx.model.EOSToken(), llamaCtx.model.EOTToken()} {
+		if token >= 0 && token < nVocab {
+			values[token] = C.float(math.Inf(-1))
+		}
+	}
+}
+
+// cgoSeqBackend implements seqBackend on a multi-sequence llama.cpp context.
+type cgoSeqBackend struct {
+	ctx        *llamaContext
+	nSeq       int
+	seqCtxSize int
+	batchSize  int
+	samplers   []*C.llama_sampler
+}
+
+// newSeqBackend creates a context with nSeq sequences of seqCtxSize tokens each.
+func newSeqBackend(model *llamaModel, nSeq, seqCtxSize, batchSize, numThreads int, opts ContextOptions) (seqBackend, error) {
+	ctx, err := createSeqContext(model, seqCtxSize*nSeq, batchSize, numThreads, nSeq, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &cgoSeqBackend{
+		ctx:        ctx,
+		nSeq:       nSeq,
+		seqCtxSize: seqCtxSize,
+		batchSize:  batchSize,
+		samplers:   make([]*C.llama_sampler, nSeq),
+	}, nil
+}
+
+func (b *cgoSeqBackend) Tokenize(prompt string) ([]int32, error) {
+	tokens, err := tokenize(b.ctx.model, prompt, true)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]int32, len(tokens))
+	for i, t := range tokens {
+		result[i] = int32(t)
+	}
+	return result, nil
+}
+
+func (b *cgoSeqBackend) MaxSequences() int   { return b.nSeq }
+func (b *cgoSeqBackend) SeqContextSize() int { return b.seqCtxSize }
+func (b *cgoSeqBackend) BatchCapacity() int  { return b.batchSize }
+
+func (b *cgoSeqBackend) StartSequence(seq int, params SamplingParams) {
+	if b.samplers[seq] != nil {
+		C.llama_sampler_free(b.samplers[seq])
+	}
+	b.samplers[seq] = newSamplerChain(b.ctx.model, params)
+}
+
+func (b *cgoSeqBackend) Decode(tokens []batchToken) error {
+	b.ctx.mu.Lock()
+	defer b.ctx.mu.Unlock()
+
+	for i, t := range tokens {
+		batchSetToken(&b.ctx.batch, i, C.llama_token(t.Token))
+		batchSetPos(&b.ctx.batch, i, C.llama_pos(t.Pos))
+		batchSetNSeqID(&b.ctx.batch, i, 1)
+		batchSetSeqID(&b.ctx.batch, i, 0, C.llama_seq_id(t.Seq))
+		logits := C.int8_t(0)
+		if t.Logits {
+			logits = 1
+		}
+		batchSetLogits(&b.ctx.batch, i, logits)
+	}
+	b.ctx.batch.n_tokens = C.int32_t(len(tokens))
+
+	if ret := C.llama_decode(b.ctx.ptr, b.ctx.batch); ret != 0 {
+		return fmt.Errorf("llama_decode returned %d", int(ret))
+	}
+	return nil
+}
+
+func (b *cgoSeqBackend) Sample(seq int, batchIndex int, allowEOG bool) int32 {
+	b.ctx.mu.Lock()
+	defer b.ctx.mu.Unlock()
+	if !allowEOG {
+		suppressEOG(b.ctx, int32(batchIndex))
+	}
+	return int32(C.llama_sampler_sample(b.samplers[seq], b.ctx.ptr, C.int32_t(batchIndex)))
+}
+
+func (b *cgoSeqBackend) IsEOG(token int32) bool {
+	return b.ctx.model.IsEOG(token)
+}
+
+func (b *cgoSeqBackend) TokenPiece(token int32) string {
+	return detokenize(b.ctx.model, C.llama_token(token))
+}
+
+func (b *cgoSeqBackend) EndSequence(seq int) {
+	b.ctx.mu.Lock()
+	C.llama_kv_cache_seq_rm(b.ctx.ptr, C.llama_seq_id(seq), -1, -1)
+	b.ctx.mu.Unlock()
+
+	if b.samplers[seq] != nil {
+		C.llama_sampler_free(b.samplers[seq])
+		b.samplers[seq] = nil
+	}
+}
+
+func (b *cgoSeqBackend) Close() {
+	for i, sampler := range b.samplers {
+		if sampler != nil {
+			C.llama_sampler_free(sampler)
+			b.samplers[i] = nil
+		}
+	}
+	b.ctx.Close()
 }
 
 // inferVision performs multimodal (text + image) inference.
@@ -783,11 +980,14 @@ func inferVision(ctx context.Context, llamaCtx *llamaContext, prompt string, ima
 
 // GPUMemoryInfo holds GPU memory statistics.
 type GPUMemoryInfo struct {
-	Used       int64     // Used VRAM in bytes
-	Total      int64     // Total VRAM in bytes
-	Free       int64     // Free VRAM in bytes
-	UsedPct    float64   // Usage percentage
-	LastUpdate time.Time // When this info was collected
+	Used        int64     // Used VRAM in bytes
+	Total       int64     // Total VRAM in bytes
+	Free        int64     // Free VRAM in bytes
+	UsedPct     float64   // Usage percentage
+	Utilization float64   // GPU utilization percentage (NVML only)
+	Temperature float64   // GPU temperature in Celsius (NVML only)
+	PowerDraw   float64   // Power draw in watts (NVML only)
+	LastUpdate  time.Time // When this info was collected
 }
 
 // nvmlState tracks NVML initialization state.
@@ -837,13 +1037,26 @@ func getGPUMemoryViaNVML() (*GPUMemoryInfo, error) {
 		usedPct = float64(memInfo.Used) / float64(memInfo.Total) * 100.0
 	}
 
-	return &GPUMemoryInfo{
+	info := &GPUMemoryInfo{
 		Used:       int64(memInfo.Used),
 		Total:      int64(memInfo.Total),
 		Free:       int64(memInfo.Free),
 		UsedPct:    usedPct,
 		LastUpdate: time.Now(),
-	}, nil
+	}
+
+	// Optional sensors; unsupported queries leave the field at zero
+	if util, ret := device.GetUtilizationRates(); ret == nvml.SUCCESS {
+		info.Utilization = float64(util.Gpu)
+	}
+	if temp, ret := device.GetTemperature(nvml.TEMPERATURE_GPU); ret == nvml.SUCCESS {
+		info.Temperature = float64(temp)
+	}
+	if power, ret := device.GetPowerUsage(); ret == nvml.SUCCESS {
+		info.PowerDraw = float64(power) / 1000.0 // milliwatts to watts
+	}
+
+	return info, nil
 }
 
 // getGPUMemoryViaNvidiaSMI queries GPU memory by parsing nvidia-smi output.
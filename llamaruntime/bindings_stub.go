@@ -16,6 +16,7 @@ package llamaruntime
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -26,6 +27,14 @@ var (
 	llamaBackendInit bool
 )
 
+// Linked reports whether this build links llama.cpp.
+const Linked = false
+
+// SystemInfo returns "" in stub mode.
+func SystemInfo() string {
+	return ""
+}
+
 // llamaInit initializes the llama.cpp backend (stub).
 func llamaInit() {
 	llamaBackendOnce.Do(func() {
@@ -89,6 +98,16 @@ func (m *llamaModel) EOSToken() int {
 	return 2
 }
 
+// EOTToken returns the end-of-turn token ID (stub: none).
+func (m *llamaModel) EOTToken() int {
+	return -1
+}
+
+// IsEOG reports whether token ends generation (stub).
+func (m *llamaModel) IsEOG(token int32) bool {
+	return token == int32(m.EOSToken())
+}
+
 // Close releases the model resources (stub).
 func (m *llamaModel) Close() {
 	// Nothing to do in stub mode
@@ -102,7 +121,7 @@ type llamaContext struct {
 }
 
 // createContext creates an inference context (stub).
-func createContext(model *llamaModel, contextSize, batchSize, numThreads int) (*llamaContext, error) {
+func createContext(model *llamaModel, contextSize, batchSize, numThreads int, opts ContextOptions) (*llamaContext, error) {
 	if model == nil {
 		return nil, &LlamaError{
 			Op:      "createContext",
@@ -183,9 +202,10 @@ func detokenize(model *llamaModel, token int32) string {
 }
 
 // inferText performs text inference (stub: returns mock response).
-func inferText(ctx context.Context, llamaCtx *llamaContext, prompt string, maxTokens int, params SamplingParams) (string, error) {
+// The response is emitted one word per token so generation limits apply.
+func inferText(ctx context.Context, llamaCtx *llamaContext, prompt string, limits generationLimits, params SamplingParams) (generation, error) {
 	if llamaCtx == nil {
-		return "", &LlamaError{
+		return generation{}, &LlamaError{
 			Op:      "inferText",
 			Code:    -1,
 			Message: "invalid context (nil)",
@@ -195,12 +215,26 @@ func inferText(ctx context.Context, llamaCtx *llamaContext, prompt string, maxTo
 	// Check context cancellation
 	select {
 	case <-ctx.Done():
-		return "", ctx.Err()
+		return generation{}, ctx.Err()
 	default:
 	}
 
 	// Return a stub response for testing
-	return fmt.Sprintf("[Stub Response to: %s] This is a mock response from the stub llama.cpp bindings. In production, this would be generated by the actual model.", truncateForStub(prompt, 50)), nil
+	response := fmt.Sprintf("[Stub Response to: %s] This is a mock response from the stub llama.cpp bindings. In production, this would be generated by the actual model.", truncateForStub(prompt, 50))
+
+	var result []byte
+	gen := generation{StopReason: StopReasonEOS}
+	for _, piece := range strings.SplitAfter(response, " ") {
+		result = append(result, piece...)
+		gen.Tokens++
+		if keep, reason := limits.check(result, len(piece), gen.Tokens); reason != "" {
+			result = result[:keep]
+			gen.StopReason = reason
+			break
+		}
+	}
+	gen.Text = string(result)
+	return gen, nil
 }
 
 // truncateForStub truncates a string for stub responses.
@@ -211,6 +245,60 @@ func truncateForStub(s string, maxLen int) string {
 	return s[:maxLen] + "..."
 }
 
+// stubSeqTokens is the number of tokens the stub sequence backend
+// generates before signalling end of generation.
+const stubSeqTokens = 8
+
+// stubSeqBackend implements seqBackend without llama.cpp.
+// Each sequence yields stubSeqTokens "stub " pieces, then EOS.
+type stubSeqBackend struct {
+	model      *llamaModel
+	nSeq       int
+	seqCtxSize int
+	batchSize  int
+	generated  []int
+}
+
+// newSeqBackend creates a stub multi-sequence backend.
+func newSeqBackend(model *llamaModel, nSeq, seqCtxSize, batchSize, numThreads int, opts ContextOptions) (seqBackend, error) {
+	if model == nil {
+		return nil, &LlamaError{
+			Op:      "newSeqBackend",
+			Code:    -1,
+			Message: "invalid model (nil)",
+		}
+	}
+	return &stubSeqBackend{
+		model:      model,
+		nSeq:       nSeq,
+		seqCtxSize: seqCtxSize,
+		batchSize:  batchSize,
+		generated:  make([]int, nSeq),
+	}, nil
+}
+
+func (b *stubSeqBackend) Tokenize(prompt string) ([]int32, error) {
+	return tokenize(b.model, prompt, true)
+}
+
+func (b *stubSeqBackend) MaxSequences() int                            { return b.nSeq }
+func (b *stubSeqBackend) SeqContextSize() int                          { return b.seqCtxSize }
+func (b *stubSeqBackend) BatchCapacity() int                           { return b.batchSize }
+func (b *stubSeqBackend) StartSequence(seq int, params SamplingParams) { b.generated[seq] = 0 }
+func (b *stubSeqBackend) Decode(tokens []batchToken) error             { return nil }
+func (b *stubSeqBackend) IsEOG(token int32) bool                       { return b.model.IsEOG(token) }
+func (b *stubSeqBackend) TokenPiece(token int32) string                { return "stub " }
+func (b *stubSeqBackend) EndSequence(seq int)                          {}
+func (b *stubSeqBackend) Close()                                       {}
+
+func (b *stubSeqBackend) Sample(seq int, batchIndex int, allowEOG bool) int32 {
+	b.generated[seq]++
+	if b.generated[seq] > stubSeqTokens && allowEOG {
+		return int32(b.model.EOSToken())
+	}
+	return 3
+}
+
 // inferVision performs multimodal inference (stub).
 func inferVision(ctx context.Context, llamaCtx *llamaContext, prompt string, imageData []byte, maxTokens int, params SamplingParams) (string, error) {
 	return "", &LlamaError{
@@ -222,11 +310,14 @@ func inferVision(ctx context.Context, llamaCtx *llamaContext, prompt string, ima
 
 // GPUMemoryInfo holds GPU memory statistics.
 type GPUMemoryInfo struct {
-	Used       int64
-	Total      int64
-	Free       int64
-	UsedPct    float64
-	LastUpdate time.Time
+	Used        int64
+	Total       int64
+	Free        int64
+	UsedPct     float64
+	Utilization float64
+	Temperature float64
+	PowerDraw   float64
+	LastUpdate  time.Time
 }
 
 // getGPUMemory returns GPU memory usage (stub).
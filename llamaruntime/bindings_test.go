@@ -106,7 +106,7 @@ func TestCreateContext(t *testing.T) {
 	llamaInit()
 
 	// Test with nil model
-	_, err := createContext(nil, 4096, 512, 4)
+	_, err := createContext(nil, 4096, 512, 4, ContextOptions{})
 	if err == nil {
 		t.Error("createContext with nil model should fail")
 	}
@@ -122,7 +122,7 @@ func TestCreateContext(t *testing.T) {
 	defer model.Close()
 
 	// Create context
-	ctx, err := createContext(model, 4096, 512, 4)
+	ctx, err := createContext(model, 4096, 512, 4, ContextOptions{})
 	if err != nil {
 		t.Fatalf("createContext failed: %v", err)
 	}
@@ -161,7 +161,7 @@ func TestInferTextNilContext(t *testing.T) {
 	ctx := context.Background()
 	params := DefaultSamplingParams()
 
-	_, err := inferText(ctx, nil, "Hello", 10, params)
+	_, err := inferText(ctx, nil, "Hello", generationLimits{MaxTokens: 10}, params)
 	if err == nil {
 		t.Error("inferText with nil context should fail")
 	}
@@ -186,7 +186,7 @@ func TestInferText(t *testing.T) {
 	}
 	defer model.Close()
 
-	llamaCtx, err := createContext(model, 4096, 512, 4)
+	llamaCtx, err := createContext(model, 4096, 512, 4, ContextOptions{})
 	if err != nil {
 		t.Fatalf("createContext failed: %v", err)
 	}
@@ -195,16 +195,16 @@ func TestInferText(t *testing.T) {
 	ctx := context.Background()
 	params := DefaultSamplingParams()
 
-	response, err := inferText(ctx, llamaCtx, "Hello, how are you?", 20, params)
+	gen, err := inferText(ctx, llamaCtx, "Hello, how are you?", generationLimits{MaxTokens: 20}, params)
 	if err != nil {
 		t.Fatalf("inferText failed: %v", err)
 	}
 
-	if response == "" {
+	if gen.Text == "" {
 		t.Error("inferText returned empty response")
 	}
 
-	t.Logf("Response: %s", response)
+	t.Logf("Response: %s", gen.Text)
 }
 
 func TestInferTextWithTimeout(t *testing.T) {
@@ -219,7 +219,7 @@ func TestInferTextWithTimeout(t *testing.T) {
 	}
 	defer model.Close()
 
-	llamaCtx, err := createContext(model, 4096, 512, 4)
+	llamaCtx, err := createContext(model, 4096, 512, 4, ContextOptions{})
 	if err != nil {
 		t.Fatalf("createContext failed: %v", err)
 	}
@@ -233,7 +233,7 @@ func TestInferTextWithTimeout(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 
 	params := DefaultSamplingParams()
-	_, err = inferText(ctx, llamaCtx, "Hello", 100, params)
+	_, err = inferText(ctx, llamaCtx, "Hello", generationLimits{MaxTokens: 100}, params)
 
 	// In stub mode, this might complete before timeout check
 	// In real mode, it should timeout
@@ -256,7 +256,7 @@ func TestInferVisionNotImplemented(t *testing.T) {
 	}
 	defer model.Close()
 
-	llamaCtx, err := createContext(model, 4096, 512, 4)
+	llamaCtx, err := createContext(model, 4096, 512, 4, ContextOptions{})
 	if err != nil {
 		t.Fatalf("createContext failed: %v", err)
 	}
@@ -327,7 +327,7 @@ func TestFreeContext(t *testing.T) {
 	}
 	defer model.Close()
 
-	llamaCtx, err := createContext(model, 4096, 512, 4)
+	llamaCtx, err := createContext(model, 4096, 512, 4, ContextOptions{})
 	if err != nil {
 		t.Fatalf("createContext failed: %v", err)
 	}
@@ -397,7 +397,7 @@ func BenchmarkCreateContext(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		ctx, err := createContext(model, 4096, 512, 4)
+		ctx, err := createContext(model, 4096, 512, 4, ContextOptions{})
 		if err == nil {
 			ctx.Close()
 		}
@@ -413,7 +413,7 @@ func BenchmarkInferText(b *testing.B) {
 	}
 	defer model.Close()
 
-	llamaCtx, err := createContext(model, 4096, 512, 4)
+	llamaCtx, err := createContext(model, 4096, 512, 4, ContextOptions{})
 	if err != nil {
 		b.Skip("Could not create context for benchmark")
 	}
@@ -424,6 +424,6 @@ func BenchmarkInferText(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_, _ = inferText(ctx, llamaCtx, "Hello", 10, params)
+		_, _ = inferText(ctx, llamaCtx, "Hello", generationLimits{MaxTokens: 10}, params)
 	}
 }
@@ -0,0 +1,253 @@
+// Package llamaruntime provides Go bindings to llama.cpp for local LLM inference.
+// This file contains chat template support.
+//
+// Instruction-tuned models expect prompts wrapped in the exact turn markers
+// they were trained with (ChatML for Qwen, header tokens for Llama-3, ...).
+// GGUF files carry the model's Jinja template under tokenizer.chat_template.
+// Rather than evaluating Jinja, the template source is matched against the
+// known formats, as llama.cpp's llama_chat_apply_template does, and the
+// matching formatter from the registry below renders the conversation.
+package llamaruntime
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Chat message roles.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// ChatMessage is one turn of a conversation.
+type ChatMessage struct {
+	Role    string
+	Content string
+}
+
+// Chat template names, matching llama.cpp's --chat-template values.
+const (
+	ChatTemplateChatML  = "chatml"
+	ChatTemplateLlama3  = "llama3"
+	ChatTemplateLlama2  = "llama2"
+	ChatTemplateMistral = "mistral"
+	ChatTemplateGemma   = "gemma"
+	ChatTemplatePhi3    = "phi3"
+	ChatTemplateZephyr  = "zephyr"
+	ChatTemplateVicuna  = "vicuna"
+
+	// ChatTemplateRaw joins message contents with blank lines. It is used
+	// when a model has no recognizable template.
+	ChatTemplateRaw = "raw"
+)
+
+// chatFormatter renders messages followed by the opening of an assistant turn.
+type chatFormatter func(messages []ChatMessage) string
+
+// chatTemplates is the registry of supported chat formats.
+var chatTemplates = map[string]chatFormatter{
+	ChatTemplateChatML:  formatChatML,
+	ChatTemplateLlama3:  formatLlama3,
+	ChatTemplateLlama2:  formatLlama2,
+	ChatTemplateMistral: formatMistral,
+	ChatTemplateGemma:   formatGemma,
+	ChatTemplatePhi3:    formatPhi3,
+	ChatTemplateZephyr:  formatZephyr,
+	ChatTemplateVicuna:  formatVicuna,
+	ChatTemplateRaw:     formatRaw,
+}
+
+// ChatTemplateNames returns the names of all supported chat templates.
+func ChatTemplateNames() []string {
+	names := make([]string, 0, len(chatTemplates))
+	for name := range chatTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseChatTemplate validates a chat template name (case-insensitive).
+func ParseChatTemplate(name string) (string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if _, ok := chatTemplates[name]; !ok {
+		return "", fmt.Errorf("unsupported chat template %q (supported: %s)",
+			name, strings.Join(ChatTemplateNames(), ", "))
+	}
+	return name, nil
+}
+
+// DetectChatTemplate identifies the chat format of a Jinja template taken
+// from GGUF metadata. Returns "" if the template is empty or unrecognized.
+func DetectChatTemplate(source string) string {
+	contains := func(s string) bool { return strings.Contains(source, s) }
+
+	switch {
+	case source == "":
+		return ""
+	case contains("<|im_start|>"):
+		return ChatTemplateChatML
+	case contains("<|start_header_id|>") && contains("<|end_header_id|>"):
+		return ChatTemplateLlama3
+	case contains("[INST]") && contains("<<SYS>>"):
+		return ChatTemplateLlama2
+	case contains("[INST]"):
+		return ChatTemplateMistral
+	case contains("<start_of_turn>"):
+		return ChatTemplateGemma
+	case contains("<|assistant|>") && contains("<|end|>"):
+		return ChatTemplatePhi3
+	case contains("<|user|>"):
+		return ChatTemplateZephyr
+	case contains("USER: ") && contains("ASSISTANT"):
+		return ChatTemplateVicuna
+	}
+	return ""
+}
+
+// ApplyChatTemplate renders messages with the named template and opens an
+// assistant turn for the model to complete. An empty name uses
+// ChatTemplateRaw.
+func ApplyChatTemplate(name string, messages []ChatMessage) (string, error) {
+	if name == "" {
+		name = ChatTemplateRaw
+	}
+	format, ok := chatTemplates[name]
+	if !ok {
+		return "", fmt.Errorf("unsupported chat template %q", name)
+	}
+	return format(messages), nil
+}
+
+// splitSystem separates a leading system message from the conversation.
+func splitSystem(messages []ChatMessage) (string, []ChatMessage) {
+	if len(messages) > 0 && messages[0].Role == RoleSystem {
+		return messages[0].Content, messages[1:]
+	}
+	return "", messages
+}
+
+func formatChatML(messages []ChatMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&b, "<|im_start|>%s\n%s<|im_end|>\n", m.Role, m.Content)
+	}
+	b.WriteString("<|im_start|>assistant\n")
+	return b.String()
+}
+
+func formatLlama3(messages []ChatMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&b, "<|start_header_id|>%s<|end_header_id|>\n\n%s<|eot_id|>", m.Role, strings.TrimSpace(m.Content))
+	}
+	b.WriteString("<|start_header_id|>assistant<|end_header_id|>\n\n")
+	return b.String()
+}
+
+func formatLlama2(messages []ChatMessage) string {
+	system, turns := splitSystem(messages)
+	var b strings.Builder
+	for i, m := range turns {
+		switch m.Role {
+		case RoleAssistant:
+			fmt.Fprintf(&b, " %s </s>", strings.TrimSpace(m.Content))
+		default:
+			if i > 0 {
+				b.WriteString("<s>")
+			}
+			b.WriteString("[INST] ")
+			if system != "" {
+				fmt.Fprintf(&b, "<<SYS>>\n%s\n<</SYS>>\n\n", system)
+				system = ""
+			}
+			fmt.Fprintf(&b, "%s [/INST]", strings.TrimSpace(m.Content))
+		}
+	}
+	return b.String()
+}
+
+func formatMistral(messages []ChatMessage) string {
+	system, turns := splitSystem(messages)
+	var b strings.Builder
+	for _, m := range turns {
+		switch m.Role {
+		case RoleAssistant:
+			fmt.Fprintf(&b, " %s</s>", strings.TrimSpace(m.Content))
+		default:
+			b.WriteString("[INST] ")
+			if system != "" {
+				fmt.Fprintf(&b, "%s\n\n", system)
+				system = ""
+			}
+			fmt.Fprintf(&b, "%s [/INST]", strings.TrimSpace(m.Content))
+		}
+	}
+	return b.String()
+}
+
+// formatGemma folds the system prompt into the first user turn, since
+// Gemma has no system role.
+func formatGemma(messages []ChatMessage) string {
+	system, turns := splitSystem(messages)
+	var b strings.Builder
+	for _, m := range turns {
+		role := "user"
+		if m.Role == RoleAssistant {
+			role = "model"
+		}
+		content := strings.TrimSpace(m.Content)
+		if system != "" && m.Role == RoleUser {
+			content = system + "\n\n" + content
+			system = ""
+		}
+		fmt.Fprintf(&b, "<start_of_turn>%s\n%s<end_of_turn>\n", role, content)
+	}
+	b.WriteString("<start_of_turn>model\n")
+	return b.String()
+}
+
+func formatPhi3(messages []ChatMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&b, "<|%s|>\n%s<|end|>\n", m.Role, m.Content)
+	}
+	b.WriteString("<|assistant|>\n")
+	return b.String()
+}
+
+func formatZephyr(messages []ChatMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		fmt.Fprintf(&b, "<|%s|>\n%s</s>\n", m.Role, m.Content)
+	}
+	b.WriteString("<|assistant|>\n")
+	return b.String()
+}
+
+func formatVicuna(messages []ChatMessage) string {
+	var b strings.Builder
+	for _, m := range messages {
+		switch m.Role {
+		case RoleSystem:
+			fmt.Fprintf(&b, "%s\n\n", m.Content)
+		case RoleAssistant:
+			fmt.Fprintf(&b, "ASSISTANT: %s</s>\n", m.Content)
+		default:
+			fmt.Fprintf(&b, "USER: %s\n", m.Content)
+		}
+	}
+	b.WriteString("ASSISTANT:")
+	return b.String()
+}
+
+func formatRaw(messages []ChatMessage) string {
+	parts := make([]string, 0, len(messages))
+	for _, m := range messages {
+		parts = append(parts, m.Content)
+	}
+	return strings.Join(parts, "\n\n")
+}
@@ -0,0 +1,141 @@
+package llamaruntime
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectChatTemplate(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"empty", "", ""},
+		{"qwen chatml", "{% for message in messages %}{{'<|im_start|>' + message['role'] + '\\n' + message['content'] + '<|im_end|>' + '\\n'}}{% endfor %}", ChatTemplateChatML},
+		{"llama3", "{% set content = '<|start_header_id|>' + message['role'] + '<|end_header_id|>\\n\\n' + message['content'] | trim + '<|eot_id|>' %}", ChatTemplateLlama3},
+		{"llama2", "{{ bos_token + '[INST] ' + '<<SYS>>\\n' + system_message + '\\n<</SYS>>\\n\\n' }}", ChatTemplateLlama2},
+		{"mistral", "{{ bos_token }}{% for message in messages %}{{ '[INST] ' + message['content'] + ' [/INST]' }}{% endfor %}", ChatTemplateMistral},
+		{"gemma", "{{ '<start_of_turn>' + role + '\\n' + message['content'] | trim + '<end_of_turn>\\n' }}", ChatTemplateGemma},
+		{"phi3", "{{'<|' + message['role'] + '|>' + '\\n' + message['content'] + '<|end|>\\n'}}{% if add_generation_prompt %}{{ '<|assistant|>\\n' }}{% endif %}", ChatTemplatePhi3},
+		{"zephyr", "{{ '<|user|>\\n' + message['content'] + eos_token }}", ChatTemplateZephyr},
+		{"vicuna", "{{ 'USER: ' + message['content'] + '\\n' }}{{ 'ASSISTANT:' }}", ChatTemplateVicuna},
+		{"unknown", "{{ message['content'] }}", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectChatTemplate(tt.source); got != tt.want {
+				t.Errorf("DetectChatTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyChatTemplate(t *testing.T) {
+	messages := []ChatMessage{
+		{Role: RoleSystem, Content: "Be brief."},
+		{Role: RoleUser, Content: "Hi"},
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{ChatTemplateChatML, "<|im_start|>system\nBe brief.<|im_end|>\n<|im_start|>user\nHi<|im_end|>\n<|im_start|>assistant\n"},
+		{ChatTemplateLlama3, "<|start_header_id|>system<|end_header_id|>\n\nBe brief.<|eot_id|><|start_header_id|>user<|end_header_id|>\n\nHi<|eot_id|><|start_header_id|>assistant<|end_header_id|>\n\n"},
+		{ChatTemplateLlama2, "[INST] <<SYS>>\nBe brief.\n<</SYS>>\n\nHi [/INST]"},
+		{ChatTemplateMistral, "[INST] Be brief.\n\nHi [/INST]"},
+		{ChatTemplateGemma, "<start_of_turn>user\nBe brief.\n\nHi<end_of_turn>\n<start_of_turn>model\n"},
+		{ChatTemplatePhi3, "<|system|>\nBe brief.<|end|>\n<|user|>\nHi<|end|>\n<|assistant|>\n"},
+		{ChatTemplateZephyr, "<|system|>\nBe brief.</s>\n<|user|>\nHi</s>\n<|assistant|>\n"},
+		{ChatTemplateVicuna, "Be brief.\n\nUSER: Hi\nASSISTANT:"},
+		{ChatTemplateRaw, "Be brief.\n\nHi"},
+		{"", "Be brief.\n\nHi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			got, err := ApplyChatTemplate(tt.template, messages)
+			if err != nil {
+				t.Fatalf("ApplyChatTemplate() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ApplyChatTemplate() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyChatTemplate_MultiTurn(t *testing.T) {
+	messages := []ChatMessage{
+		{Role: RoleUser, Content: "Hi"},
+		{Role: RoleAssistant, Content: "Hello!"},
+		{Role: RoleUser, Content: "Bye"},
+	}
+
+	got, err := ApplyChatTemplate(ChatTemplateLlama2, messages)
+	if err != nil {
+		t.Fatalf("ApplyChatTemplate() error = %v", err)
+	}
+	want := "[INST] Hi [/INST] Hello! </s><s>[INST] Bye [/INST]"
+	if got != want {
+		t.Errorf("ApplyChatTemplate() = %q, want %q", got, want)
+	}
+}
+
+func TestApplyChatTemplate_Unknown(t *testing.T) {
+	if _, err := ApplyChatTemplate("jinja", nil); err == nil {
+		t.Error("expected error for unknown template")
+	}
+}
+
+func TestParseChatTemplate(t *testing.T) {
+	if got, err := ParseChatTemplate(" ChatML "); err != nil || got != ChatTemplateChatML {
+		t.Errorf("ParseChatTemplate() = %q, %v; want chatml", got, err)
+	}
+	if _, err := ParseChatTemplate("alpaca"); err == nil {
+		t.Error("expected error for unsupported template")
+	}
+}
+
+func TestResolveChatTemplate(t *testing.T) {
+	b := &ggufBuilder{}
+	b.addString("general.architecture", "qwen2")
+	b.addString("tokenizer.chat_template", "{{'<|im_start|>' + message['role'] }}")
+	dir := t.TempDir()
+	qwenPath := filepath.Join(dir, "qwen.gguf")
+	if err := os.WriteFile(qwenPath, b.bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	plainPath := filepath.Join(dir, "plain.gguf")
+	if err := os.WriteFile(plainPath, []byte("not a gguf file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		override  string
+		modelPath string
+		want      string
+		wantErr   bool
+	}{
+		{"detected from metadata", "", qwenPath, ChatTemplateChatML, false},
+		{"override wins", "llama3", qwenPath, ChatTemplateLlama3, false},
+		{"no metadata falls back to raw", "", plainPath, ChatTemplateRaw, false},
+		{"invalid override", "bogus", qwenPath, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveChatTemplate(tt.override, tt.modelPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveChatTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveChatTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
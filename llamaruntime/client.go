@@ -43,8 +43,21 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"go_backend/core/clock"
+	"go_backend/llmcapture"
 )
 
+// samplerSeed seeds the sampler of every text and vision generation.
+var samplerSeed atomic.Uint32
+
+// SetSamplerSeed sets the seed of the sampler of every generation, so runs
+// with the same prompts repeat their output (TEST_DETERMINISTIC). The
+// default is 0.
+func SetSamplerSeed(seed uint32) {
+	samplerSeed.Store(seed)
+}
+
 // =============================================================================
 // Client Configuration
 // =============================================================================
@@ -64,6 +77,10 @@ type ClientConfig struct {
 	// Defaults to DefaultContextSize.
 	ContextSize int
 
+	// MinContextSize is the smallest context window to retry with when
+	// contexts of ContextSize do not fit. See ContextPoolConfig.MinContextSize.
+	MinContextSize int
+
 	// BatchSize is the batch size for inference.
 	// Defaults to DefaultBatchSize.
 	BatchSize int
@@ -91,6 +108,26 @@ type ClientConfig struct {
 	// VerboseLogging enables verbose llama.cpp logging.
 	// Defaults to false.
 	VerboseLogging bool
+
+	// AutoOffload picks the number of GPU layers from free VRAM instead of
+	// NumGPULayers. See ContextPoolConfig.AutoOffload.
+	AutoOffload bool
+
+	// VRAMHeadroom is the VRAM AutoOffload leaves free for other workloads.
+	// Defaults to DefaultVRAMHeadroom.
+	VRAMHeadroom int64
+
+	// ContextOptions tunes flash attention and the KV cache data types.
+	ContextOptions ContextOptions
+
+	// ParallelSequences batches concurrent Infer calls into one context
+	// when greater than 1. See ContextPoolConfig.ParallelSequences.
+	ParallelSequences int
+
+	// ChatTemplate names the chat format used by Chat and Generate
+	// (see ChatTemplateNames). Empty means detect it from the model's
+	// tokenizer.chat_template metadata, falling back to ChatTemplateRaw.
+	ChatTemplate string
 }
 
 // DefaultClientConfig returns a ClientConfig with sensible defaults.
@@ -120,11 +157,18 @@ func DefaultClientConfig() ClientConfig {
 // - Multiple goroutines can call Infer/InferVision concurrently
 // - Concurrency is bounded by NumContexts in the configuration
 type Client struct {
-	pool      *ContextPool
-	config    ClientConfig
-	modelInfo *ModelInfo
-	mu        sync.RWMutex
-	closed    bool
+	pool       *ContextPool // nil while unloaded
+	poolConfig ContextPoolConfig
+	config     ClientConfig
+	modelInfo  *ModelInfo
+	mu         sync.RWMutex
+	closed     bool
+
+	// Idle unloading
+	activeUsers int64 // atomic; in-flight inferences holding the pool
+	lastUsed    int64 // atomic; unix nanoseconds of last pool use
+	// clock stamps lastUsed; nil is the real clock
+	clock atomic.Pointer[clock.Clock]
 
 	// Statistics
 	startTime         time.Time
@@ -205,17 +249,35 @@ func NewClient(config ClientConfig) (*Client, error) {
 		config.AcquireTimeout = 30 * time.Second
 	}
 
+	// Resolve the chat template before loading the model
+	chatTemplate, err := resolveChatTemplate(config.ChatTemplate, absPath)
+	if err != nil {
+		return nil, &LlamaError{
+			Op:      "NewClient",
+			Code:    -1,
+			Message: "invalid chat template",
+			Err:     err,
+		}
+	}
+	config.ChatTemplate = chatTemplate
+
 	// Create context pool configuration
 	poolConfig := ContextPoolConfig{
 		ModelPath:      absPath,
 		NumContexts:    config.NumContexts,
 		ContextSize:    config.ContextSize,
+		MinContextSize: config.MinContextSize,
 		BatchSize:      config.BatchSize,
 		NumGPULayers:   config.NumGPULayers,
 		NumThreads:     config.NumThreads,
 		UseMMap:        config.UseMMap,
 		UseMlock:       config.UseMlock,
 		AcquireTimeout: config.AcquireTimeout,
+		AutoOffload:    config.AutoOffload,
+		VRAMHeadroom:   config.VRAMHeadroom,
+		ContextOptions: config.ContextOptions,
+
+		ParallelSequences: config.ParallelSequences,
 	}
 
 	// Create context pool
@@ -235,18 +297,135 @@ func NewClient(config ClientConfig) (*Client, error) {
 		Name:          filepath.Base(absPath),
 		Size:          fileInfo.Size(),
 		Format:        "GGUF",
-		ContextLength: config.ContextSize,
+		ContextLength: pool.Config().ContextSize,
+		ChatTemplate:  chatTemplate,
 		LoadedAt:      time.Now(),
 	}
 
 	return &Client{
-		pool:      pool,
-		config:    config,
-		modelInfo: modelInfo,
-		startTime: time.Now(),
+		pool:       pool,
+		poolConfig: poolConfig,
+		config:     config,
+		modelInfo:  modelInfo,
+		startTime:  time.Now(),
+		lastUsed:   time.Now().UnixNano(),
 	}, nil
 }
 
+// acquirePool returns the context pool, reloading the model first if it was
+// unloaded while idle. Callers must call the returned release function when
+// they no longer use the pool, which prevents Unload from freeing it.
+func (c *Client) acquirePool(op string) (*ContextPool, func(), error) {
+	for {
+		c.mu.RLock()
+		if c.closed {
+			c.mu.RUnlock()
+			return nil, nil, &LlamaError{
+				Op:      op,
+				Code:    -1,
+				Message: "client is closed",
+			}
+		}
+		if pool := c.pool; pool != nil {
+			atomic.AddInt64(&c.activeUsers, 1)
+			c.mu.RUnlock()
+			release := func() {
+				atomic.StoreInt64(&c.lastUsed, c.now().UnixNano())
+				atomic.AddInt64(&c.activeUsers, -1)
+			}
+			return pool, release, nil
+		}
+		c.mu.RUnlock()
+
+		if err := c.reload(); err != nil {
+			return nil, nil, &LlamaError{
+				Op:      op,
+				Code:    -1,
+				Message: "failed to reload model",
+				Err:     err,
+			}
+		}
+	}
+}
+
+// reload recreates the context pool after an idle unload.
+func (c *Client) reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || c.pool != nil {
+		return nil
+	}
+
+	start := time.Now()
+	pool, err := NewContextPool(c.poolConfig)
+	if err != nil {
+		return err
+	}
+	c.pool = pool
+	c.modelInfo.ContextLength = pool.Config().ContextSize
+	c.modelInfo.LoadedAt = time.Now()
+	c.modelInfo.LoadDuration = time.Since(start)
+	return nil
+}
+
+// Unload frees the model and all contexts to release VRAM.
+// The model is reloaded transparently on the next inference request.
+// Returns false if the model is not loaded or inference is in progress.
+func (c *Client) Unload() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed || c.pool == nil || atomic.LoadInt64(&c.activeUsers) > 0 {
+		return false
+	}
+
+	c.pool.Close()
+	c.pool = nil
+	return true
+}
+
+// IsLoaded returns true if the model is resident in memory.
+func (c *Client) IsLoaded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return !c.closed && c.pool != nil
+}
+
+// ContextDowngrade returns the context window size that was configured and
+// the one in use, which is smaller if the configured window did not fit in
+// memory. Both are the configured size while the model is unloaded.
+func (c *Client) ContextDowngrade() (requested, actual int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.pool == nil {
+		return c.config.ContextSize, c.config.ContextSize
+	}
+	return c.pool.ContextDowngrade()
+}
+
+// LastUsed returns when the model was last used for inference.
+func (c *Client) LastUsed() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&c.lastUsed))
+}
+
+// SetClock sets the clock that stamps LastUsed (default: clock.Real). The
+// model counts as used at the time it is set, so it is not idle on a new
+// clock before it was ever used.
+func (c *Client) SetClock(clk clock.Clock) {
+	clk = clock.OrReal(clk)
+	c.clock.Store(&clk)
+	atomic.StoreInt64(&c.lastUsed, clk.Now().UnixNano())
+}
+
+// now returns the time on the client's clock.
+func (c *Client) now() time.Time {
+	if clk := c.clock.Load(); clk != nil {
+		return (*clk).Now()
+	}
+	return time.Now()
+}
+
 // Infer performs text inference with the given parameters.
 // It acquires a context from the pool, runs inference, and releases the context.
 //
@@ -258,16 +437,37 @@ func NewClient(config ClientConfig) (*Client, error) {
 //
 // Thread-safe: multiple goroutines can call Infer concurrently.
 func (c *Client) Infer(ctx context.Context, params InferenceParams) (*InferenceResult, error) {
-	c.mu.RLock()
-	if c.closed {
-		c.mu.RUnlock()
-		return nil, &LlamaError{
-			Op:      "Infer",
-			Code:    -1,
-			Message: "client is closed",
-		}
+	if !llmcapture.Active() {
+		return c.infer(ctx, params)
 	}
-	c.mu.RUnlock()
+
+	start := time.Now()
+	result, err := c.infer(ctx, params)
+	capture := llmcapture.Capture{
+		Backend:    llmcapture.BackendLocal,
+		Request:    params.Prompt,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if info := c.ModelInfo(); info != nil {
+		capture.Model = info.Name
+	}
+	if err != nil {
+		capture.Error = err.Error()
+	} else {
+		capture.Response = result.Text
+	}
+	// A failed capture must not fail the inference
+	_ = llmcapture.Record(ctx, capture)
+	return result, err
+}
+
+// infer runs Infer without capturing.
+func (c *Client) infer(ctx context.Context, params InferenceParams) (*InferenceResult, error) {
+	pool, releasePool, err := c.acquirePool("Infer")
+	if err != nil {
+		return nil, err
+	}
+	defer releasePool()
 
 	// Apply defaults
 	if params.MaxTokens <= 0 {
@@ -289,42 +489,54 @@ func (c *Client) Infer(ctx context.Context, params InferenceParams) (*InferenceR
 		params.Timeout = DefaultTimeout
 	}
 
-	// Acquire context from pool
-	llamaCtx, err := c.pool.Acquire(ctx)
-	if err != nil {
-		atomic.AddInt64(&c.errorCount, 1)
-		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+	limits := newGenerationLimits(params)
+	samplingParams := SamplingParams{
+		Temperature:   params.Temperature,
+		TopK:          params.TopK,
+		TopP:          params.TopP,
+		RepeatPenalty: params.RepeatPenalty,
+		Seed:          samplerSeed.Load(),
+	}
+
+	var gen generation
+	var startTime time.Time
+	if pool.Batched() {
+		// Batched decoding shares one context across concurrent requests
+		inferCtx, cancel := context.WithTimeout(ctx, params.Timeout)
+		defer cancel()
+
+		startTime = time.Now()
+		gen, err = pool.inferBatched(inferCtx, params.Prompt, limits, samplingParams)
+	} else {
+		// Acquire context from pool
+		llamaCtx, acquireErr := pool.Acquire(ctx)
+		if acquireErr != nil {
+			atomic.AddInt64(&c.errorCount, 1)
+			if errors.Is(acquireErr, context.DeadlineExceeded) || errors.Is(acquireErr, context.Canceled) {
+				return nil, &LlamaError{
+					Op:      "Infer",
+					Code:    -1,
+					Message: "timeout waiting for inference context",
+					Err:     ErrTimeout,
+				}
+			}
 			return nil, &LlamaError{
 				Op:      "Infer",
 				Code:    -1,
-				Message: "timeout waiting for inference context",
-				Err:     ErrTimeout,
+				Message: "failed to acquire context",
+				Err:     acquireErr,
 			}
 		}
-		return nil, &LlamaError{
-			Op:      "Infer",
-			Code:    -1,
-			Message: "failed to acquire context",
-			Err:     err,
-		}
-	}
-	defer c.pool.Release(llamaCtx)
+		defer pool.Release(llamaCtx)
 
-	// Create inference context with timeout
-	inferCtx, cancel := context.WithTimeout(ctx, params.Timeout)
-	defer cancel()
-
-	// Run inference
-	startTime := time.Now()
+		// Create inference context with timeout
+		inferCtx, cancel := context.WithTimeout(ctx, params.Timeout)
+		defer cancel()
 
-	samplingParams := SamplingParams{
-		Temperature:   params.Temperature,
-		TopK:          params.TopK,
-		TopP:          params.TopP,
-		RepeatPenalty: params.RepeatPenalty,
+		// Run inference
+		startTime = time.Now()
+		gen, err = inferText(inferCtx, llamaCtx, params.Prompt, limits, samplingParams)
 	}
-
-	text, err := inferText(inferCtx, llamaCtx, params.Prompt, params.MaxTokens, samplingParams)
 	if err != nil {
 		atomic.AddInt64(&c.errorCount, 1)
 		return nil, &LlamaError{
@@ -336,14 +548,20 @@ func (c *Client) Infer(ctx context.Context, params InferenceParams) (*InferenceR
 	}
 
 	duration := time.Since(startTime)
+	text := gen.Text
 
-	// Estimate token counts (approximation - actual counts from llama.cpp would be better)
-	tokensPrompt := len(params.Prompt) / 4 // ~4 chars per token
-	tokensGenerated := len(text) / 4       // ~4 chars per token
+	// Estimate the prompt token count (approximation - ~4 chars per token)
+	tokensPrompt := len(params.Prompt) / 4
+	tokensGenerated := gen.Tokens
 	if tokensGenerated < 1 {
 		tokensGenerated = 1
 	}
 
+	stopReason := gen.StopReason
+	if stopReason == "" {
+		stopReason = determineStopReason(text, params)
+	}
+
 	tokensPerSecond := float64(tokensGenerated) / duration.Seconds()
 	if duration.Seconds() < 0.001 {
 		tokensPerSecond = 0
@@ -365,10 +583,59 @@ func (c *Client) Infer(ctx context.Context, params InferenceParams) (*InferenceR
 		TokensPrompt:    tokensPrompt,
 		Duration:        duration,
 		TokensPerSecond: tokensPerSecond,
-		StopReason:      determineStopReason(text, params),
+		StopReason:      stopReason,
 	}, nil
 }
 
+// Generate runs text inference on prompt and returns the generated text.
+// It is a convenience wrapper around Chat for callers that only need the
+// text; params.SystemPrompt, if set, becomes the system message.
+//
+// Thread-safe: multiple goroutines can call Generate concurrently.
+func (c *Client) Generate(ctx context.Context, prompt string, params GenerationParams) (string, error) {
+	var messages []ChatMessage
+	if params.SystemPrompt != nil && *params.SystemPrompt != "" {
+		messages = append(messages, ChatMessage{Role: RoleSystem, Content: *params.SystemPrompt})
+	}
+	messages = append(messages, ChatMessage{Role: RoleUser, Content: prompt})
+
+	return c.Chat(ctx, messages, params)
+}
+
+// Chat formats messages with the model's chat template, runs inference and
+// returns the assistant's reply. params.SystemPrompt is ignored; pass a
+// RoleSystem message instead.
+//
+// Thread-safe: multiple goroutines can call Chat concurrently.
+func (c *Client) Chat(ctx context.Context, messages []ChatMessage, params GenerationParams) (string, error) {
+	prompt, err := ApplyChatTemplate(c.config.ChatTemplate, messages)
+	if err != nil {
+		return "", &LlamaError{
+			Op:      "Chat",
+			Code:    -1,
+			Message: "failed to apply chat template",
+			Err:     err,
+		}
+	}
+
+	result, err := c.Infer(ctx, InferenceParams{
+		Prompt:         prompt,
+		MaxTokens:      params.MaxTokens,
+		Temperature:    params.Temperature,
+		TopP:           params.TopP,
+		TopK:           params.TopK,
+		RepeatPenalty:  params.RepeatPenalty,
+		StopSequences:  params.StopSequences,
+		MinTokens:      params.MinTokens,
+		MaxOutputBytes: params.MaxOutputBytes,
+		Timeout:        params.Timeout,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
 // InferVision performs vision (multimodal) inference with the given parameters.
 // This is designed for use with models like Bunny that support image input.
 //
@@ -377,16 +644,11 @@ func (c *Client) Infer(ctx context.Context, params InferenceParams) (*InferenceR
 //
 // Thread-safe: multiple goroutines can call InferVision concurrently.
 func (c *Client) InferVision(ctx context.Context, params VisionParams) (*InferenceResult, error) {
-	c.mu.RLock()
-	if c.closed {
-		c.mu.RUnlock()
-		return nil, &LlamaError{
-			Op:      "InferVision",
-			Code:    -1,
-			Message: "client is closed",
-		}
+	pool, releasePool, err := c.acquirePool("InferVision")
+	if err != nil {
+		return nil, err
 	}
-	c.mu.RUnlock()
+	defer releasePool()
 
 	// Validate image input
 	imageData := params.ImageData
@@ -425,7 +687,7 @@ func (c *Client) InferVision(ctx context.Context, params VisionParams) (*Inferen
 	}
 
 	// Acquire context from pool
-	llamaCtx, err := c.pool.Acquire(ctx)
+	llamaCtx, err := pool.Acquire(ctx)
 	if err != nil {
 		atomic.AddInt64(&c.errorCount, 1)
 		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
@@ -443,7 +705,7 @@ func (c *Client) InferVision(ctx context.Context, params VisionParams) (*Inferen
 			Err:     err,
 		}
 	}
-	defer c.pool.Release(llamaCtx)
+	defer pool.Release(llamaCtx)
 
 	// Create inference context with timeout
 	inferCtx, cancel := context.WithTimeout(ctx, params.Timeout)
@@ -457,6 +719,7 @@ func (c *Client) InferVision(ctx context.Context, params VisionParams) (*Inferen
 		TopK:          DefaultTopK,
 		TopP:          DefaultTopP,
 		RepeatPenalty: DefaultRepeatPenalty,
+		Seed:          samplerSeed.Load(),
 	}
 
 	text, err := inferVision(inferCtx, llamaCtx, params.Prompt, imageData, params.MaxTokens, samplingParams)
@@ -527,6 +790,7 @@ func (c *Client) GetGPUMemoryUsage() (*GPUMemoryInfo, error) {
 func (c *Client) HealthCheck() (*HealthStatus, error) {
 	c.mu.RLock()
 	closed := c.closed
+	pool := c.pool
 	c.mu.RUnlock()
 
 	status := &HealthStatus{
@@ -540,8 +804,11 @@ func (c *Client) HealthCheck() (*HealthStatus, error) {
 		return status, nil
 	}
 
-	// Get pool stats
-	poolStats := c.pool.Stats()
+	// Get pool stats (zero while the model is unloaded)
+	var poolStats ContextPoolStats
+	if pool != nil {
+		poolStats = pool.Stats()
+	}
 
 	// Get GPU memory
 	gpuMem, err := getGPUMemory()
@@ -585,7 +852,7 @@ func (c *Client) HealthCheck() (*HealthStatus, error) {
 		ErrorCount:             errorCount,
 	}
 
-	status.ModelLoaded = c.modelInfo != nil
+	status.ModelLoaded = c.modelInfo != nil && pool != nil
 	status.ModelInfo = c.modelInfo
 	status.LastInference = lastInfer
 
@@ -611,6 +878,11 @@ func (c *Client) ModelInfo() *ModelInfo {
 	return c.modelInfo
 }
 
+// ChatTemplate returns the name of the chat format used by Chat and Generate.
+func (c *Client) ChatTemplate() string {
+	return c.config.ChatTemplate
+}
+
 // Stats returns inference statistics.
 func (c *Client) Stats() InferenceStats {
 	totalInferences := atomic.LoadInt64(&c.totalInferences)
@@ -656,6 +928,20 @@ func (c *Client) Close() error {
 // Helper Functions
 // =============================================================================
 
+// resolveChatTemplate validates an explicit template name, or detects the
+// template from the model's GGUF metadata when name is empty.
+func resolveChatTemplate(name, modelPath string) (string, error) {
+	if name != "" {
+		return ParseChatTemplate(name)
+	}
+	if meta, err := ReadGGUFMetadata(modelPath); err == nil {
+		if detected := DetectChatTemplate(meta.ChatTemplate()); detected != "" {
+			return detected, nil
+		}
+	}
+	return ChatTemplateRaw, nil
+}
+
 // determineStopReason determines why generation stopped.
 func determineStopReason(text string, params InferenceParams) string {
 	// Check for stop sequences
@@ -705,6 +991,19 @@ func QuickInfer(modelPath, prompt string) (string, error) {
 	return result.Text, nil
 }
 
+// Warmup runs a short completion so the first real request does not pay for
+// CUDA kernel compilation and cache warm-up.
+func (c *Client) Warmup(ctx context.Context) error {
+	_, err := c.Infer(ctx, InferenceParams{
+		Prompt:    "Hello",
+		MaxTokens: 8,
+	})
+	if err != nil {
+		return fmt.Errorf("warmup: %w", err)
+	}
+	return nil
+}
+
 // InferWithTimeout is a convenience wrapper that adds a timeout to inference.
 func (c *Client) InferWithTimeout(timeout time.Duration, params InferenceParams) (*InferenceResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
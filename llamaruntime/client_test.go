@@ -7,8 +7,10 @@ package llamaruntime
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -620,6 +622,193 @@ func TestClient_Close_Idempotent(t *testing.T) {
 	}
 }
 
+// =============================================================================
+// Idle Unload Tests
+// =============================================================================
+
+func TestClient_UnloadAndReload(t *testing.T) {
+	config := DefaultClientConfig()
+	config.ModelPath = testModelPath(t)
+	config.NumContexts = 1
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if !client.IsLoaded() {
+		t.Fatal("expected model to be loaded after NewClient")
+	}
+
+	before := client.LastUsed()
+	if !client.Unload() {
+		t.Fatal("Unload() = false, want true")
+	}
+	if client.IsLoaded() {
+		t.Error("expected model to be unloaded")
+	}
+	if client.Unload() {
+		t.Error("second Unload() should return false")
+	}
+
+	status, err := client.HealthCheck()
+	if err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+	if status.ModelLoaded {
+		t.Error("HealthCheck should report model not loaded")
+	}
+
+	// Inference reloads the model transparently
+	params := DefaultInferenceParams()
+	params.Prompt = "Hello"
+	if _, err := client.Infer(context.Background(), params); err != nil {
+		t.Fatalf("Infer after Unload failed: %v", err)
+	}
+	if !client.IsLoaded() {
+		t.Error("expected model to be reloaded by Infer")
+	}
+	if client.LastUsed().Before(before) {
+		t.Error("LastUsed() should advance after Infer")
+	}
+}
+
+func TestClient_Unload_ClosedClient(t *testing.T) {
+	config := DefaultClientConfig()
+	config.ModelPath = testModelPath(t)
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	client.Close()
+
+	if client.Unload() {
+		t.Error("Unload() on closed client should return false")
+	}
+	if client.IsLoaded() {
+		t.Error("closed client should not report loaded")
+	}
+}
+
+// =============================================================================
+// Generate Tests
+// =============================================================================
+
+func TestClient_Generate(t *testing.T) {
+	config := DefaultClientConfig()
+	config.ModelPath = testModelPath(t)
+	config.NumContexts = 1
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	system := "You are terse."
+	text, err := client.Generate(context.Background(), "Hello", GenerationParams{SystemPrompt: &system})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+	if !strings.Contains(text, system) {
+		t.Errorf("expected system prompt to precede the prompt, got %q", text)
+	}
+}
+
+func TestClient_Infer_StopControls(t *testing.T) {
+	config := DefaultClientConfig()
+	config.ModelPath = testModelPath(t)
+	config.NumContexts = 1
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	params := DefaultInferenceParams()
+	params.Prompt = "Hello"
+	params.StopSequences = []string{"mock"}
+	result, err := client.Infer(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Infer failed: %v", err)
+	}
+	if result.StopReason != StopReasonStopSequence || strings.Contains(result.Text, "mock") {
+		t.Errorf("expected text cut before stop sequence, got %q (%s)", result.Text, result.StopReason)
+	}
+
+	params = DefaultInferenceParams()
+	params.Prompt = "Hello"
+	params.MaxOutputBytes = 10
+	result, err = client.Infer(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Infer failed: %v", err)
+	}
+	if result.StopReason != StopReasonMaxBytes || len(result.Text) != 10 {
+		t.Errorf("expected 10-byte output, got %q (%s)", result.Text, result.StopReason)
+	}
+
+	params = DefaultInferenceParams()
+	params.Prompt = "Hello"
+	params.MaxTokens = 3
+	result, err = client.Infer(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Infer failed: %v", err)
+	}
+	if result.StopReason != StopReasonMaxTokens || result.TokensGenerated != 3 {
+		t.Errorf("expected 3 tokens, got %d (%s)", result.TokensGenerated, result.StopReason)
+	}
+}
+
+// =============================================================================
+// Batching Tests
+// =============================================================================
+
+func TestClient_ParallelSequences(t *testing.T) {
+	config := DefaultClientConfig()
+	config.ModelPath = testModelPath(t)
+	config.NumContexts = 1
+	config.ParallelSequences = 4
+
+	client, err := NewClient(config)
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer client.Close()
+
+	if !client.pool.Batched() {
+		t.Fatal("expected pool to use batched decoding")
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			params := DefaultInferenceParams()
+			params.Prompt = "Hello"
+			params.MaxTokens = 16
+			result, err := client.Infer(context.Background(), params)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if !strings.Contains(result.Text, "stub") {
+				errs <- fmt.Errorf("unexpected text %q", result.Text)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("batched Infer failed: %v", err)
+	}
+}
+
 // =============================================================================
 // Concurrent Tests
 // =============================================================================
@@ -21,6 +21,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -64,6 +66,34 @@ type ContextPoolConfig struct {
 	// AcquireTimeout is the maximum time to wait for a context.
 	// Defaults to 30 seconds.
 	AcquireTimeout time.Duration
+
+	// MinContextSize is the smallest context window to retry with when
+	// contexts of ContextSize fail to be created (e.g., out of VRAM). The
+	// size is halved on each retry. Defaults to DefaultMinContextSize; a
+	// value of ContextSize or more disables the retry.
+	MinContextSize int
+
+	// AutoOffload picks NumGPULayers from free VRAM and the model's layer
+	// sizes instead of using the fixed value, retrying with fewer layers
+	// if context creation fails. Defaults to false.
+	AutoOffload bool
+
+	// VRAMHeadroom is the VRAM left free by AutoOffload for other GPU
+	// workloads (e.g., Stable Diffusion). Defaults to DefaultVRAMHeadroom.
+	VRAMHeadroom int64
+
+	// Logger receives offload planning messages. If nil, uses standard log.
+	Logger *log.Logger
+
+	// ContextOptions tunes flash attention and the KV cache data types.
+	ContextOptions ContextOptions
+
+	// ParallelSequences enables batched text decoding when greater than 1:
+	// text requests share one extra context holding this many sequences,
+	// each with ContextSize tokens, and are decoded together in a single
+	// GPU pass per token. The NumContexts contexts remain available through
+	// Acquire (e.g., for vision). Defaults to 0 (disabled).
+	ParallelSequences int
 }
 
 // DefaultContextPoolConfig returns a ContextPoolConfig with sensible defaults.
@@ -71,12 +101,14 @@ func DefaultContextPoolConfig() ContextPoolConfig {
 	return ContextPoolConfig{
 		NumContexts:    5,
 		ContextSize:    DefaultContextSize,
+		MinContextSize: DefaultMinContextSize,
 		BatchSize:      DefaultBatchSize,
 		NumGPULayers:   DefaultNumGPULayers,
 		NumThreads:     DefaultNumThreads,
 		UseMMap:        true,
 		UseMlock:       false,
 		AcquireTimeout: 30 * time.Second,
+		VRAMHeadroom:   DefaultVRAMHeadroom,
 	}
 }
 
@@ -120,6 +152,16 @@ type ContextPool struct {
 	acquireTimeouts int64
 	acquireErrors   int64
 	createdAt       time.Time
+
+	// offloadPlan records the GPU layer split chosen by AutoOffload
+	offloadPlan OffloadPlan
+
+	// requestedContextSize is the ContextSize asked for; config.ContextSize
+	// is smaller if contexts had to be recreated with a smaller window
+	requestedContextSize int
+
+	// scheduler batches text requests across sequences (nil if disabled)
+	scheduler *batchScheduler
 }
 
 // NewContextPool creates a new context pool with the given configuration.
@@ -132,7 +174,7 @@ type ContextPool struct {
 // Returns an error if:
 // - ModelPath is empty or file doesn't exist
 // - Model loading fails
-// - Any context creation fails (e.g., insufficient GPU memory)
+// - Any context creation fails at MinContextSize (e.g., insufficient GPU memory)
 func NewContextPool(config ContextPoolConfig) (*ContextPool, error) {
 	// Validate and apply defaults
 	if config.ModelPath == "" {
@@ -149,6 +191,9 @@ func NewContextPool(config ContextPoolConfig) (*ContextPool, error) {
 	if config.ContextSize <= 0 {
 		config.ContextSize = DefaultContextSize
 	}
+	if config.MinContextSize <= 0 {
+		config.MinContextSize = DefaultMinContextSize
+	}
 	if config.BatchSize <= 0 {
 		config.BatchSize = DefaultBatchSize
 	}
@@ -161,10 +206,71 @@ func NewContextPool(config ContextPoolConfig) (*ContextPool, error) {
 	if config.AcquireTimeout <= 0 {
 		config.AcquireTimeout = 30 * time.Second
 	}
+	if config.VRAMHeadroom <= 0 {
+		config.VRAMHeadroom = DefaultVRAMHeadroom
+	}
+	if err := config.ContextOptions.Validate(); err != nil {
+		return nil, &LlamaError{
+			Op:      "NewContextPool",
+			Code:    -1,
+			Message: "invalid context options",
+			Err:     err,
+		}
+	}
 
 	// Initialize llama backend
 	llamaInit()
 
+	build := buildContextPool
+	if config.AutoOffload {
+		build = newContextPoolAutoOffload
+	}
+	return newContextPoolShrinking(config, build)
+}
+
+// newContextPoolShrinking builds the pool with build, halving the context
+// window whenever a context fails to be created until MinContextSize is
+// reached. The pool remembers the size originally requested.
+func newContextPoolShrinking(config ContextPoolConfig, build func(ContextPoolConfig) (*ContextPool, error)) (*ContextPool, error) {
+	requested := config.ContextSize
+	for {
+		pool, err := build(config)
+		if err == nil {
+			pool.requestedContextSize = requested
+			if config.ContextSize < requested {
+				poolLogger(config).Printf("Context window reduced from %d to %d tokens to fit in memory",
+					requested, config.ContextSize)
+			}
+			return pool, nil
+		}
+
+		if !errors.Is(err, ErrContextCreateFailed) {
+			return nil, err
+		}
+		next := nextContextRetry(config.ContextSize, config.MinContextSize)
+		if next < 0 {
+			return nil, err
+		}
+		poolLogger(config).Printf("Context window of %d tokens did not fit (%v), retrying with %d",
+			config.ContextSize, err, next)
+		config.ContextSize = next
+		if config.BatchSize > next {
+			config.BatchSize = next
+		}
+	}
+}
+
+// poolLogger returns the logger for pool setup messages.
+func poolLogger(config ContextPoolConfig) *log.Logger {
+	if config.Logger != nil {
+		return config.Logger
+	}
+	return log.New(os.Stdout, "[llamaruntime] ", log.LstdFlags)
+}
+
+// buildContextPool loads the model with config.NumGPULayers and pre-creates
+// all contexts. Config defaults must already be applied.
+func buildContextPool(config ContextPoolConfig) (*ContextPool, error) {
 	// Load model
 	model, err := loadModel(config.ModelPath, config.NumGPULayers, config.UseMMap, config.UseMlock)
 	if err != nil {
@@ -181,7 +287,7 @@ func NewContextPool(config ContextPoolConfig) (*ContextPool, error) {
 
 	// Pre-create all contexts
 	for i := 0; i < config.NumContexts; i++ {
-		ctx, err := createContext(model, config.ContextSize, config.BatchSize, config.NumThreads)
+		ctx, err := createContext(model, config.ContextSize, config.BatchSize, config.NumThreads, config.ContextOptions)
 		if err != nil {
 			// Clean up already created contexts and model
 			pool.Close()
@@ -195,9 +301,94 @@ func NewContextPool(config ContextPoolConfig) (*ContextPool, error) {
 		pool.contexts <- ctx
 	}
 
+	if err := pool.startBatching(model); err != nil {
+		pool.Close()
+		return nil, err
+	}
+
 	return pool, nil
 }
 
+// startBatching creates the batch scheduler when ParallelSequences > 1.
+func (p *ContextPool) startBatching(model *llamaModel) error {
+	if p.config.ParallelSequences <= 1 {
+		return nil
+	}
+	backend, err := newSeqBackend(model, p.config.ParallelSequences, p.config.ContextSize,
+		p.config.BatchSize, p.config.NumThreads, p.config.ContextOptions)
+	if err != nil {
+		return &LlamaError{
+			Op:      "NewContextPool",
+			Code:    -1,
+			Message: fmt.Sprintf("failed to create batched context for %d sequences", p.config.ParallelSequences),
+			Err:     err,
+		}
+	}
+	p.scheduler = newBatchScheduler(backend)
+	return nil
+}
+
+// newContextPoolAutoOffload plans the GPU layer split from free VRAM and
+// builds the pool, stepping the layer count down whenever the model or a
+// context fails to fit.
+func newContextPoolAutoOffload(config ContextPoolConfig) (*ContextPool, error) {
+	logger := poolLogger(config)
+
+	plan := planOffloadForConfig(config)
+	logger.Printf("GPU offload plan: %s", plan)
+
+	layers := plan.Layers
+	for {
+		attempt := config
+		attempt.NumGPULayers = layers
+
+		pool, err := buildContextPool(attempt)
+		if err == nil {
+			pool.offloadPlan = plan
+			pool.offloadPlan.Layers = layers
+			if layers < 0 {
+				logger.Printf("GPU offload: all layers on GPU")
+			} else {
+				logger.Printf("GPU offload: %d/%d layers on GPU, rest on CPU", layers, plan.TotalLayers)
+			}
+			return pool, nil
+		}
+
+		if !errors.Is(err, ErrContextCreateFailed) && !errors.Is(err, ErrModelLoadFailed) {
+			return nil, err
+		}
+
+		next := nextOffloadRetry(layers, plan.TotalLayers)
+		if next < 0 {
+			return nil, err
+		}
+		logger.Printf("GPU offload: %d layers did not fit (%v), retrying with %d", layers, err, next)
+		layers = next
+	}
+}
+
+// planOffloadForConfig gathers model and VRAM information and returns the
+// offload plan. Missing information yields a full-offload plan.
+func planOffloadForConfig(config ContextPoolConfig) OffloadPlan {
+	totalLayers := 0
+	if meta, err := ReadGGUFMetadata(config.ModelPath); err == nil {
+		totalLayers = meta.BlockCount()
+	}
+
+	var freeVRAM int64
+	if info, err := getGPUMemory(); err == nil {
+		freeVRAM = info.Free
+	}
+
+	return PlanGPUOffload(
+		GetModelSize(config.ModelPath),
+		totalLayers,
+		freeVRAM,
+		config.VRAMHeadroom,
+		(config.NumContexts+config.ParallelSequences)*config.ContextSize,
+	)
+}
+
 // NewContextPoolWithModel creates a context pool using an existing model.
 // This allows sharing a model across multiple pools or for custom model management.
 //
@@ -239,7 +430,7 @@ func NewContextPoolWithModel(model *llamaModel, config ContextPoolConfig) (*Cont
 
 	// Pre-create all contexts
 	for i := 0; i < config.NumContexts; i++ {
-		ctx, err := createContext(model, config.ContextSize, config.BatchSize, config.NumThreads)
+		ctx, err := createContext(model, config.ContextSize, config.BatchSize, config.NumThreads, config.ContextOptions)
 		if err != nil {
 			pool.Close()
 			return nil, &LlamaError{
@@ -385,6 +576,12 @@ func (p *ContextPool) Close() error {
 	}
 	p.closed = true
 
+	// Stop batched decoding before freeing the model it uses
+	if p.scheduler != nil {
+		p.scheduler.Close()
+		p.scheduler = nil
+	}
+
 	// Close the channel to prevent new contexts from being added
 	close(p.contexts)
 
@@ -453,6 +650,48 @@ func (p *ContextPool) Model() *llamaModel {
 	return p.model
 }
 
+// OffloadPlan returns the GPU layer split chosen when AutoOffload is enabled.
+// Layers is the value actually used after any retries.
+func (p *ContextPool) OffloadPlan() OffloadPlan {
+	return p.offloadPlan
+}
+
+// ContextDowngrade returns the context window size that was requested and
+// the one in use. They differ when the requested window did not fit in
+// memory and the pool was created with a smaller one.
+func (p *ContextPool) ContextDowngrade() (requested, actual int) {
+	requested = p.requestedContextSize
+	if requested == 0 {
+		requested = p.config.ContextSize
+	}
+	return requested, p.config.ContextSize
+}
+
+// Batched reports whether text inference is batched across sequences.
+func (p *ContextPool) Batched() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.scheduler != nil
+}
+
+// inferBatched runs text inference through the batch scheduler.
+// Only valid when Batched() is true.
+func (p *ContextPool) inferBatched(ctx context.Context, prompt string, limits generationLimits, params SamplingParams) (generation, error) {
+	p.mu.RLock()
+	scheduler := p.scheduler
+	closed := p.closed
+	p.mu.RUnlock()
+
+	if closed || scheduler == nil {
+		return generation{}, &LlamaError{
+			Op:      "inferBatched",
+			Code:    -1,
+			Message: "pool is closed",
+		}
+	}
+	return scheduler.Submit(ctx, prompt, limits, params)
+}
+
 // Config returns the pool configuration.
 func (p *ContextPool) Config() ContextPoolConfig {
 	return p.config
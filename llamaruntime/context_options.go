@@ -0,0 +1,97 @@
+// Package llamaruntime provides Go bindings to llama.cpp for local LLM inference.
+// This file contains tuning options for llama.cpp context creation.
+//
+// Memory-constrained GPUs benefit from a quantized KV cache (q8_0 roughly
+// halves KV memory compared to f16, q4_0 quarters it) and from flash
+// attention, which llama.cpp requires for a quantized V cache.
+package llamaruntime
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KVCacheType is the data type of the KV cache, named as in llama.cpp
+// (--cache-type-k / --cache-type-v). The empty value means f16.
+type KVCacheType string
+
+// Supported KV cache types.
+const (
+	KVCacheF32  KVCacheType = "f32"
+	KVCacheF16  KVCacheType = "f16"
+	KVCacheQ8_0 KVCacheType = "q8_0"
+	KVCacheQ5_1 KVCacheType = "q5_1"
+	KVCacheQ5_0 KVCacheType = "q5_0"
+	KVCacheQ4_1 KVCacheType = "q4_1"
+	KVCacheQ4_0 KVCacheType = "q4_0"
+)
+
+// kvCacheGGMLTypes maps KV cache types to their ggml_type enum values.
+var kvCacheGGMLTypes = map[KVCacheType]int32{
+	KVCacheF32:  0,
+	KVCacheF16:  1,
+	KVCacheQ4_0: 2,
+	KVCacheQ4_1: 3,
+	KVCacheQ5_0: 6,
+	KVCacheQ5_1: 7,
+	KVCacheQ8_0: 8,
+}
+
+// ParseKVCacheType parses a KV cache type name (case-insensitive).
+// An empty string yields KVCacheF16.
+func ParseKVCacheType(s string) (KVCacheType, error) {
+	if s == "" {
+		return KVCacheF16, nil
+	}
+	t := KVCacheType(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := kvCacheGGMLTypes[t]; !ok {
+		return "", fmt.Errorf("unsupported KV cache type %q (supported: f32, f16, q8_0, q5_1, q5_0, q4_1, q4_0)", s)
+	}
+	return t, nil
+}
+
+// IsQuantized reports whether the type is a quantized (non-float) type.
+func (t KVCacheType) IsQuantized() bool {
+	return t != "" && t != KVCacheF16 && t != KVCacheF32
+}
+
+// ggmlType returns the ggml_type enum value, defaulting to f16.
+func (t KVCacheType) ggmlType() int32 {
+	if v, ok := kvCacheGGMLTypes[t]; ok {
+		return v
+	}
+	return kvCacheGGMLTypes[KVCacheF16]
+}
+
+// ContextOptions tunes llama.cpp context creation.
+// The zero value enables flash attention with an f16 KV cache.
+type ContextOptions struct {
+	// DisableFlashAttention turns off flash attention.
+	// Flash attention is enabled by default when the backend supports it.
+	DisableFlashAttention bool
+
+	// TypeK is the data type of the K cache. Defaults to f16.
+	TypeK KVCacheType
+
+	// TypeV is the data type of the V cache. Defaults to f16.
+	// A quantized V cache requires flash attention.
+	TypeV KVCacheType
+}
+
+// Validate checks that the options are supported by llama.cpp.
+func (o ContextOptions) Validate() error {
+	if o.TypeK != "" {
+		if _, err := ParseKVCacheType(string(o.TypeK)); err != nil {
+			return fmt.Errorf("type_k: %w", err)
+		}
+	}
+	if o.TypeV != "" {
+		if _, err := ParseKVCacheType(string(o.TypeV)); err != nil {
+			return fmt.Errorf("type_v: %w", err)
+		}
+	}
+	if o.TypeV.IsQuantized() && o.DisableFlashAttention {
+		return fmt.Errorf("type_v %s requires flash attention", o.TypeV)
+	}
+	return nil
+}
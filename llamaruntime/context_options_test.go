@@ -0,0 +1,65 @@
+package llamaruntime
+
+import "testing"
+
+func TestParseKVCacheType(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    KVCacheType
+		wantErr bool
+	}{
+		{"", KVCacheF16, false},
+		{"f16", KVCacheF16, false},
+		{"Q8_0", KVCacheQ8_0, false},
+		{" q4_0 ", KVCacheQ4_0, false},
+		{"q3_k", "", true},
+		{"int8", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseKVCacheType(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKVCacheType(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseKVCacheType(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKVCacheType_GGMLType(t *testing.T) {
+	if got := KVCacheType("").ggmlType(); got != 1 {
+		t.Errorf("empty type ggmlType() = %d, want 1 (f16)", got)
+	}
+	if got := KVCacheQ8_0.ggmlType(); got != 8 {
+		t.Errorf("q8_0 ggmlType() = %d, want 8", got)
+	}
+	if got := KVCacheQ4_0.ggmlType(); got != 2 {
+		t.Errorf("q4_0 ggmlType() = %d, want 2", got)
+	}
+}
+
+func TestContextOptions_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    ContextOptions
+		wantErr bool
+	}{
+		{"zero value", ContextOptions{}, false},
+		{"quantized KV with flash attention", ContextOptions{TypeK: KVCacheQ8_0, TypeV: KVCacheQ8_0}, false},
+		{"quantized K without flash attention", ContextOptions{DisableFlashAttention: true, TypeK: KVCacheQ4_0}, false},
+		{"quantized V without flash attention", ContextOptions{DisableFlashAttention: true, TypeV: KVCacheQ8_0}, true},
+		{"unknown type", ContextOptions{TypeK: "q2_k"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.opts.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -4,6 +4,9 @@ package llamaruntime
 import (
 	"context"
 	"errors"
+	"io"
+	"log"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -606,3 +609,53 @@ func BenchmarkContextPoolConcurrent(b *testing.B) {
 		}
 	})
 }
+
+func TestNewContextPoolShrinking(t *testing.T) {
+	config := DefaultContextPoolConfig()
+	config.ContextSize = 2048
+	config.BatchSize = 1024
+	config.Logger = log.New(io.Discard, "", 0)
+
+	var tried []int
+	build := func(c ContextPoolConfig) (*ContextPool, error) {
+		tried = append(tried, c.ContextSize)
+		if c.ContextSize > 512 {
+			return nil, &LlamaError{Op: "createContext", Code: -1, Err: ErrContextCreateFailed}
+		}
+		if c.BatchSize > c.ContextSize {
+			t.Errorf("BatchSize %d exceeds ContextSize %d", c.BatchSize, c.ContextSize)
+		}
+		return &ContextPool{config: c}, nil
+	}
+
+	pool, err := newContextPoolShrinking(config, build)
+	if err != nil {
+		t.Fatalf("newContextPoolShrinking() error: %v", err)
+	}
+	if want := []int{2048, 1024, 512}; !reflect.DeepEqual(tried, want) {
+		t.Errorf("tried context sizes %v, want %v", tried, want)
+	}
+	if requested, actual := pool.ContextDowngrade(); requested != 2048 || actual != 512 {
+		t.Errorf("ContextDowngrade() = %d, %d; want 2048, 512", requested, actual)
+	}
+
+	// Below MinContextSize the last error is returned
+	config.MinContextSize = 1024
+	tried = nil
+	if _, err := newContextPoolShrinking(config, build); !errors.Is(err, ErrContextCreateFailed) {
+		t.Errorf("newContextPoolShrinking() = %v, want ErrContextCreateFailed", err)
+	}
+	if want := []int{2048, 1024}; !reflect.DeepEqual(tried, want) {
+		t.Errorf("tried context sizes %v, want %v", tried, want)
+	}
+
+	// Other errors are not retried
+	tried = nil
+	_, err = newContextPoolShrinking(config, func(c ContextPoolConfig) (*ContextPool, error) {
+		tried = append(tried, c.ContextSize)
+		return nil, ErrModelNotFound
+	})
+	if !errors.Is(err, ErrModelNotFound) || len(tried) != 1 {
+		t.Errorf("newContextPoolShrinking() = %v after %d attempts, want ErrModelNotFound after 1", err, len(tried))
+	}
+}
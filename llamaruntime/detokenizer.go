@@ -0,0 +1,69 @@
+// Package llamaruntime provides Go bindings to llama.cpp for local LLM inference.
+// This file contains the streaming detokenizer used by the decode loops.
+//
+// Tokens do not align with characters: byte-level BPE vocabularies split
+// multi-byte UTF-8 characters (CJK, emoji, accented letters) across several
+// tokens. Converting each token's bytes to text on its own produces partial
+// runes, which show up as mojibake once the text is cut (stop sequences,
+// byte caps, cancellation) or forwarded incrementally. streamDetokenizer
+// holds back an incomplete trailing character until the tokens completing
+// it arrive, so every emitted chunk is valid UTF-8.
+package llamaruntime
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// streamDetokenizer assembles token pieces into valid UTF-8 text.
+// The zero value is ready to use. Not safe for concurrent use.
+type streamDetokenizer struct {
+	// pending holds the bytes of an incomplete trailing character
+	pending []byte
+}
+
+// Write appends the raw bytes of one token and returns the text completed
+// by it. Bytes that can never form valid UTF-8 are replaced with U+FFFD.
+func (d *streamDetokenizer) Write(piece string) string {
+	d.pending = append(d.pending, piece...)
+	n := completePrefix(d.pending)
+	if n == 0 {
+		return ""
+	}
+
+	out := strings.ToValidUTF8(string(d.pending[:n]), string(utf8.RuneError))
+	d.pending = append(d.pending[:0], d.pending[n:]...)
+	return out
+}
+
+// Flush returns any held-back bytes at the end of generation. An incomplete
+// character is rendered as U+FFFD.
+func (d *streamDetokenizer) Flush() string {
+	if len(d.pending) == 0 {
+		return ""
+	}
+	out := strings.ToValidUTF8(string(d.pending), string(utf8.RuneError))
+	d.pending = d.pending[:0]
+	return out
+}
+
+// Pending reports whether an incomplete character is being held back.
+func (d *streamDetokenizer) Pending() bool {
+	return len(d.pending) > 0
+}
+
+// completePrefix returns the length of b without a trailing incomplete
+// UTF-8 sequence. Invalid bytes count as complete so they are not held
+// back forever.
+func completePrefix(b []byte) int {
+	for i := len(b) - 1; i >= 0 && i > len(b)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(b[i]) {
+			continue
+		}
+		if utf8.FullRune(b[i:]) {
+			return len(b)
+		}
+		return i
+	}
+	return len(b)
+}
@@ -0,0 +1,104 @@
+package llamaruntime
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// splitBytes splits s into pieces of at most n bytes, ignoring rune boundaries.
+func splitBytes(s string, n int) []string {
+	var pieces []string
+	for len(s) > n {
+		pieces = append(pieces, s[:n])
+		s = s[n:]
+	}
+	return append(pieces, s)
+}
+
+func TestStreamDetokenizer_MultiByte(t *testing.T) {
+	texts := []struct {
+		name string
+		text string
+	}{
+		{"ascii", "Hello, world!"},
+		{"accented", "Café crème brûlée"},
+		{"cjk", "日本語のテキストと中文文本"},
+		{"korean", "안녕하세요"},
+		{"emoji", "Done 🎉🚀 ok 👍🏽"},
+		{"zwj emoji", "family 👨‍👩‍👧‍👦 here"},
+		{"mixed", "Résumé: 東京 → 🗼 ✓"},
+	}
+
+	for _, tt := range texts {
+		for n := 1; n <= 5; n++ {
+			var d streamDetokenizer
+			var out strings.Builder
+			for _, piece := range splitBytes(tt.text, n) {
+				chunk := d.Write(piece)
+				if !utf8.ValidString(chunk) {
+					t.Fatalf("%s/%d: chunk %q is not valid UTF-8", tt.name, n, chunk)
+				}
+				out.WriteString(chunk)
+			}
+			out.WriteString(d.Flush())
+
+			if out.String() != tt.text {
+				t.Errorf("%s/%d: got %q, want %q", tt.name, n, out.String(), tt.text)
+			}
+		}
+	}
+}
+
+func TestStreamDetokenizer_HoldsIncompleteCharacter(t *testing.T) {
+	var d streamDetokenizer
+
+	// First two bytes of "日" (e6 97 a5)
+	if got := d.Write("a\xe6\x97"); got != "a" {
+		t.Errorf("Write() = %q, want %q", got, "a")
+	}
+	if !d.Pending() {
+		t.Error("expected incomplete character to be pending")
+	}
+	if got := d.Write("\xa5b"); got != "日b" {
+		t.Errorf("Write() = %q, want %q", got, "日b")
+	}
+	if d.Pending() {
+		t.Error("expected nothing pending")
+	}
+}
+
+func TestStreamDetokenizer_InvalidBytes(t *testing.T) {
+	var d streamDetokenizer
+
+	// A stray continuation byte can never become valid
+	if got := d.Write("a\x80b"); got != "a�b" {
+		t.Errorf("Write() = %q, want %q", got, "a�b")
+	}
+
+	// An incomplete character at the end of generation is replaced
+	if got := d.Write("\xf0\x9f"); got != "" {
+		t.Errorf("Write() = %q, want empty", got)
+	}
+	if got := d.Flush(); got != "�" {
+		t.Errorf("Flush() = %q, want %q", got, "�")
+	}
+	if got := d.Flush(); got != "" {
+		t.Errorf("second Flush() = %q, want empty", got)
+	}
+}
+
+func TestStreamDetokenizer_EmptyPieces(t *testing.T) {
+	// Control tokens render as empty pieces
+	var d streamDetokenizer
+	if got := d.Write(""); got != "" {
+		t.Errorf("Write(\"\") = %q, want empty", got)
+	}
+	d.Write("\xe4")
+	if got := d.Write(""); got != "" {
+		t.Errorf("Write(\"\") with pending bytes = %q, want empty", got)
+	}
+	if got := d.Write("\xb8\xad"); got != "中" {
+		t.Errorf("Write() = %q, want %q", got, "中")
+	}
+}
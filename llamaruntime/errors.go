@@ -2,8 +2,9 @@
 package llamaruntime
 
 import (
-	"errors"
 	"fmt"
+
+	"go_backend/core/errs"
 )
 
 // LlamaError represents an error from llama.cpp operations.
@@ -35,33 +36,33 @@ func (e *LlamaError) Unwrap() error {
 // These are used for error checking with errors.Is().
 var (
 	// ErrModelNotFound indicates the model file was not found at the specified path.
-	ErrModelNotFound = errors.New("model file not found")
+	ErrModelNotFound = errs.New(errs.CodeModelMissing, "model file not found")
 
 	// ErrModelLoadFailed indicates the model file exists but failed to load.
 	// This may be due to corruption, incompatible format, or insufficient resources.
-	ErrModelLoadFailed = errors.New("failed to load model")
+	ErrModelLoadFailed = errs.New(errs.CodeModelMissing, "failed to load model")
 
 	// ErrContextCreateFailed indicates failure to create an inference context.
 	// This may be due to insufficient GPU memory or invalid parameters.
-	ErrContextCreateFailed = errors.New("failed to create inference context")
+	ErrContextCreateFailed = errs.New(errs.CodeInference, "failed to create inference context")
 
 	// ErrInferenceFailed indicates the inference operation failed.
 	// This may be due to invalid input, timeout, or internal llama.cpp errors.
-	ErrInferenceFailed = errors.New("inference failed")
+	ErrInferenceFailed = errs.New(errs.CodeInference, "inference failed")
 
 	// ErrGPUNotAvailable indicates CUDA GPU is not available or not detected.
 	// This is a critical error as CPU-only mode is not supported in Phase 2.
-	ErrGPUNotAvailable = errors.New("CUDA GPU not available")
+	ErrGPUNotAvailable = errs.New(errs.CodeGPUUnavailable, "CUDA GPU not available")
 
 	// ErrInsufficientVRAM indicates insufficient GPU VRAM to load the model.
 	// The user may need to use a smaller quantization or upgrade hardware.
-	ErrInsufficientVRAM = errors.New("insufficient GPU VRAM")
+	ErrInsufficientVRAM = errs.New(errs.CodeOutOfVRAM, "insufficient GPU VRAM")
 
 	// ErrInvalidImage indicates the provided image data is invalid or unsupported.
 	// This may be due to unsupported format, corrupted data, or encoding issues.
-	ErrInvalidImage = errors.New("invalid or unsupported image format")
+	ErrInvalidImage = errs.New(errs.CodeInvalidImage, "invalid or unsupported image format")
 
 	// ErrTimeout indicates the inference operation timed out.
 	// This may occur with very long prompts or insufficient GPU resources.
-	ErrTimeout = errors.New("inference timeout")
+	ErrTimeout = errs.New(errs.CodeTimeout, "inference timeout")
 )
@@ -0,0 +1,240 @@
+// Package llamaruntime provides Go bindings to llama.cpp for local LLM inference.
+// This file contains a pure Go reader for GGUF file metadata.
+//
+// The reader parses only the header key/value section of a GGUF file so that
+// model properties (architecture, layer count, chat template, ...) can be
+// inspected before the model is handed to llama.cpp. Tensor data is never read.
+// Array values are skipped and only their length is recorded, since the large
+// arrays (tokenizer vocab, merges) are not needed by the runtime.
+package llamaruntime
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// ggufMagic is the little-endian "GGUF" magic number at the start of every file.
+const ggufMagic = 0x46554747
+
+// maxGGUFStringLen caps string lengths to guard against corrupted headers.
+const maxGGUFStringLen = 16 * 1024 * 1024
+
+// GGUF metadata value types (see ggml/docs/gguf.md).
+const (
+	ggufTypeUint8   = 0
+	ggufTypeInt8    = 1
+	ggufTypeUint16  = 2
+	ggufTypeInt16   = 3
+	ggufTypeUint32  = 4
+	ggufTypeInt32   = 5
+	ggufTypeFloat32 = 6
+	ggufTypeBool    = 7
+	ggufTypeString  = 8
+	ggufTypeArray   = 9
+	ggufTypeUint64  = 10
+	ggufTypeInt64   = 11
+	ggufTypeFloat64 = 12
+)
+
+// ErrInvalidGGUF indicates the file is not a valid GGUF file.
+var ErrInvalidGGUF = errors.New("invalid GGUF file")
+
+// GGUFMetadata contains the header metadata of a GGUF file.
+type GGUFMetadata struct {
+	// Version is the GGUF format version.
+	Version uint32
+
+	// TensorCount is the number of tensors in the file.
+	TensorCount uint64
+
+	// Values holds scalar and string metadata keyed by GGUF key
+	// (e.g., "general.architecture"). Integers are stored as int64 or
+	// uint64, floats as float64.
+	Values map[string]interface{}
+
+	// ArrayLengths holds the element count of array-valued keys.
+	ArrayLengths map[string]uint64
+}
+
+// String returns a string metadata value, or "" if missing or not a string.
+func (m *GGUFMetadata) String(key string) string {
+	if v, ok := m.Values[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// Int returns an integer metadata value, or 0 if missing or not an integer.
+func (m *GGUFMetadata) Int(key string) int64 {
+	switch v := m.Values[key].(type) {
+	case int64:
+		return v
+	case uint64:
+		return int64(v)
+	}
+	return 0
+}
+
+// Architecture returns the model architecture (e.g., "llama", "qwen2").
+func (m *GGUFMetadata) Architecture() string {
+	return m.String("general.architecture")
+}
+
+// BlockCount returns the number of transformer layers in the model.
+// Returns 0 if the metadata does not specify it.
+func (m *GGUFMetadata) BlockCount() int {
+	arch := m.Architecture()
+	if arch == "" {
+		return 0
+	}
+	return int(m.Int(arch + ".block_count"))
+}
+
+// ChatTemplate returns the model's Jinja chat template, or "" if absent.
+func (m *GGUFMetadata) ChatTemplate() string {
+	return m.String("tokenizer.chat_template")
+}
+
+// ReadGGUFMetadata reads the header metadata of the GGUF file at path.
+func ReadGGUFMetadata(path string) (*GGUFMetadata, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseGGUFMetadata(bufio.NewReader(f))
+}
+
+// parseGGUFMetadata parses GGUF header metadata from r.
+func parseGGUFMetadata(r io.Reader) (*GGUFMetadata, error) {
+	p := &ggufParser{r: r}
+
+	magic := p.uint32()
+	if p.err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGGUF, p.err)
+	}
+	if magic != ggufMagic {
+		return nil, fmt.Errorf("%w: bad magic 0x%08x", ErrInvalidGGUF, magic)
+	}
+
+	meta := &GGUFMetadata{
+		Values:       make(map[string]interface{}),
+		ArrayLengths: make(map[string]uint64),
+	}
+	meta.Version = p.uint32()
+	if meta.Version < 2 {
+		// Version 1 used 32-bit counts; it predates every model we ship.
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidGGUF, meta.Version)
+	}
+	meta.TensorCount = p.uint64()
+	kvCount := p.uint64()
+
+	for i := uint64(0); i < kvCount && p.err == nil; i++ {
+		key := p.string()
+		valueType := p.uint32()
+		if valueType == ggufTypeArray {
+			elemType := p.uint32()
+			n := p.uint64()
+			meta.ArrayLengths[key] = n
+			for j := uint64(0); j < n && p.err == nil; j++ {
+				p.value(elemType)
+			}
+			continue
+		}
+		v := p.value(valueType)
+		if p.err == nil {
+			meta.Values[key] = v
+		}
+	}
+
+	if p.err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidGGUF, p.err)
+	}
+	return meta, nil
+}
+
+// ggufParser reads little-endian GGUF primitives, latching the first error.
+type ggufParser struct {
+	r   io.Reader
+	err error
+	buf [8]byte
+}
+
+func (p *ggufParser) read(n int) []byte {
+	if p.err != nil {
+		return p.buf[:n]
+	}
+	_, p.err = io.ReadFull(p.r, p.buf[:n])
+	return p.buf[:n]
+}
+
+func (p *ggufParser) uint32() uint32 {
+	return binary.LittleEndian.Uint32(p.read(4))
+}
+
+func (p *ggufParser) uint64() uint64 {
+	return binary.LittleEndian.Uint64(p.read(8))
+}
+
+func (p *ggufParser) string() string {
+	n := p.uint64()
+	if p.err != nil {
+		return ""
+	}
+	if n > maxGGUFStringLen {
+		p.err = fmt.Errorf("string length %d exceeds limit", n)
+		return ""
+	}
+	b := make([]byte, n)
+	_, p.err = io.ReadFull(p.r, b)
+	return string(b)
+}
+
+// value reads a single value of the given type.
+func (p *ggufParser) value(t uint32) interface{} {
+	switch t {
+	case ggufTypeUint8:
+		return uint64(p.read(1)[0])
+	case ggufTypeInt8:
+		return int64(int8(p.read(1)[0]))
+	case ggufTypeUint16:
+		return uint64(binary.LittleEndian.Uint16(p.read(2)))
+	case ggufTypeInt16:
+		return int64(int16(binary.LittleEndian.Uint16(p.read(2))))
+	case ggufTypeUint32:
+		return uint64(p.uint32())
+	case ggufTypeInt32:
+		return int64(int32(p.uint32()))
+	case ggufTypeFloat32:
+		return float64(math.Float32frombits(p.uint32()))
+	case ggufTypeBool:
+		return p.read(1)[0] != 0
+	case ggufTypeString:
+		return p.string()
+	case ggufTypeUint64:
+		return p.uint64()
+	case ggufTypeInt64:
+		return int64(p.uint64())
+	case ggufTypeFloat64:
+		return math.Float64frombits(p.uint64())
+	case ggufTypeArray:
+		// Nested arrays: skip contents
+		elemType := p.uint32()
+		n := p.uint64()
+		for j := uint64(0); j < n && p.err == nil; j++ {
+			p.value(elemType)
+		}
+		return nil
+	default:
+		if p.err == nil {
+			p.err = fmt.Errorf("unknown value type %d", t)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,123 @@
+package llamaruntime
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ggufBuilder writes a minimal GGUF header for tests.
+type ggufBuilder struct {
+	buf     bytes.Buffer
+	kvCount uint64
+	body    bytes.Buffer
+}
+
+func (b *ggufBuilder) writeString(w *bytes.Buffer, s string) {
+	binary.Write(w, binary.LittleEndian, uint64(len(s)))
+	w.WriteString(s)
+}
+
+func (b *ggufBuilder) addString(key, value string) {
+	b.writeString(&b.body, key)
+	binary.Write(&b.body, binary.LittleEndian, uint32(ggufTypeString))
+	b.writeString(&b.body, value)
+	b.kvCount++
+}
+
+func (b *ggufBuilder) addUint32(key string, value uint32) {
+	b.writeString(&b.body, key)
+	binary.Write(&b.body, binary.LittleEndian, uint32(ggufTypeUint32))
+	binary.Write(&b.body, binary.LittleEndian, value)
+	b.kvCount++
+}
+
+func (b *ggufBuilder) addStringArray(key string, values []string) {
+	b.writeString(&b.body, key)
+	binary.Write(&b.body, binary.LittleEndian, uint32(ggufTypeArray))
+	binary.Write(&b.body, binary.LittleEndian, uint32(ggufTypeString))
+	binary.Write(&b.body, binary.LittleEndian, uint64(len(values)))
+	for _, v := range values {
+		b.writeString(&b.body, v)
+	}
+	b.kvCount++
+}
+
+func (b *ggufBuilder) bytes() []byte {
+	var out bytes.Buffer
+	binary.Write(&out, binary.LittleEndian, uint32(ggufMagic))
+	binary.Write(&out, binary.LittleEndian, uint32(3))
+	binary.Write(&out, binary.LittleEndian, uint64(0)) // tensor count
+	binary.Write(&out, binary.LittleEndian, b.kvCount)
+	out.Write(b.body.Bytes())
+	return out.Bytes()
+}
+
+func TestParseGGUFMetadata(t *testing.T) {
+	b := &ggufBuilder{}
+	b.addString("general.architecture", "llama")
+	b.addUint32("llama.block_count", 32)
+	b.addStringArray("tokenizer.ggml.tokens", []string{"<s>", "</s>", "a"})
+	b.addString("tokenizer.chat_template", "{{ messages }}")
+
+	meta, err := parseGGUFMetadata(bytes.NewReader(b.bytes()))
+	if err != nil {
+		t.Fatalf("parseGGUFMetadata() error = %v", err)
+	}
+
+	if meta.Version != 3 {
+		t.Errorf("Version = %d, want 3", meta.Version)
+	}
+	if meta.Architecture() != "llama" {
+		t.Errorf("Architecture() = %q, want %q", meta.Architecture(), "llama")
+	}
+	if meta.BlockCount() != 32 {
+		t.Errorf("BlockCount() = %d, want 32", meta.BlockCount())
+	}
+	if meta.ArrayLengths["tokenizer.ggml.tokens"] != 3 {
+		t.Errorf("tokens array length = %d, want 3", meta.ArrayLengths["tokenizer.ggml.tokens"])
+	}
+	if meta.String("tokenizer.chat_template") != "{{ messages }}" {
+		t.Errorf("chat template = %q", meta.String("tokenizer.chat_template"))
+	}
+}
+
+func TestParseGGUFMetadata_BadMagic(t *testing.T) {
+	_, err := parseGGUFMetadata(bytes.NewReader([]byte("NOPE\x03\x00\x00\x00")))
+	if !errors.Is(err, ErrInvalidGGUF) {
+		t.Errorf("expected ErrInvalidGGUF, got %v", err)
+	}
+}
+
+func TestParseGGUFMetadata_Truncated(t *testing.T) {
+	b := &ggufBuilder{}
+	b.addString("general.architecture", "llama")
+	data := b.bytes()
+
+	_, err := parseGGUFMetadata(bytes.NewReader(data[:len(data)-3]))
+	if !errors.Is(err, ErrInvalidGGUF) {
+		t.Errorf("expected ErrInvalidGGUF, got %v", err)
+	}
+}
+
+func TestReadGGUFMetadata_File(t *testing.T) {
+	b := &ggufBuilder{}
+	b.addString("general.architecture", "qwen2")
+	b.addUint32("qwen2.block_count", 28)
+
+	path := filepath.Join(t.TempDir(), "model.gguf")
+	if err := os.WriteFile(path, b.bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, err := ReadGGUFMetadata(path)
+	if err != nil {
+		t.Fatalf("ReadGGUFMetadata() error = %v", err)
+	}
+	if meta.BlockCount() != 28 {
+		t.Errorf("BlockCount() = %d, want 28", meta.BlockCount())
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"go_backend/core/clock"
 )
 
 // =============================================================================
@@ -143,15 +145,24 @@ type GPUMonitorConfig struct {
 	// AlertThreshold is the VRAM usage percentage that triggers alerts.
 	// Set to 0 to disable alerts. Defaults to 90 (90%).
 	AlertThreshold float64
+
+	// TemperatureThreshold is the GPU temperature in Celsius that triggers
+	// alerts. Only applies when the temperature is reported (NVML).
+	// Set to a negative value to disable. Defaults to 85.
+	TemperatureThreshold float64
+
+	// Clock schedules the checks. Defaults to the system clock.
+	Clock clock.Clock
 }
 
 // DefaultGPUMonitorConfig returns a GPUMonitorConfig with sensible defaults.
 func DefaultGPUMonitorConfig() GPUMonitorConfig {
 	return GPUMonitorConfig{
-		Interval:       5 * time.Second,
-		LogEnabled:     false,
-		LogPrefix:      "[GPU]",
-		AlertThreshold: 90.0,
+		Interval:             5 * time.Second,
+		LogEnabled:           false,
+		LogPrefix:            "[GPU]",
+		AlertThreshold:       90.0,
+		TemperatureThreshold: 85.0,
 	}
 }
 
@@ -184,6 +195,10 @@ func NewGPUMonitor(config GPUMonitorConfig) *GPUMonitor {
 	if config.AlertThreshold == 0 {
 		config.AlertThreshold = 90.0
 	}
+	if config.TemperatureThreshold == 0 {
+		config.TemperatureThreshold = 85.0
+	}
+	config.Clock = clock.OrReal(config.Clock)
 
 	return &GPUMonitor{
 		config: config,
@@ -201,7 +216,7 @@ func (m *GPUMonitor) Start(ctx context.Context) error {
 	}
 
 	m.ctx, m.cancel = context.WithCancel(ctx)
-	m.startTime = time.Now()
+	m.startTime = m.config.Clock.Now()
 
 	m.wg.Add(1)
 	go m.monitorLoop()
@@ -243,7 +258,7 @@ func (m *GPUMonitor) Stats() GPUMonitorStats {
 
 	var uptime time.Duration
 	if !m.startTime.IsZero() {
-		uptime = time.Since(m.startTime)
+		uptime = m.config.Clock.Since(m.startTime)
 	}
 
 	return GPUMonitorStats{
@@ -270,7 +285,7 @@ type GPUMonitorStats struct {
 func (m *GPUMonitor) monitorLoop() {
 	defer m.wg.Done()
 
-	ticker := time.NewTicker(m.config.Interval)
+	ticker := m.config.Clock.NewTicker(m.config.Interval)
 	defer ticker.Stop()
 
 	// Do initial check immediately
@@ -280,7 +295,7 @@ func (m *GPUMonitor) monitorLoop() {
 		select {
 		case <-m.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			m.checkGPUMemory()
 		}
 	}
@@ -320,6 +335,13 @@ func (m *GPUMonitor) checkGPUMemory() {
 				info.UsedPct, m.config.AlertThreshold)
 		}
 	}
+	if m.config.TemperatureThreshold > 0 && info.Temperature >= m.config.TemperatureThreshold {
+		atomic.AddInt64(&m.alertCount, 1)
+		if m.config.LogEnabled {
+			m.log("WARNING: High GPU temperature (%.0f°C >= %.0f°C threshold)",
+				info.Temperature, m.config.TemperatureThreshold)
+		}
+	}
 
 	// Invoke callback if provided
 	if m.config.Callback != nil {
@@ -370,6 +392,9 @@ type HealthCheckerConfig struct {
 	// MaxErrorRate is the maximum error rate (0.0-1.0) to consider healthy.
 	// Defaults to 0.1 (10%).
 	MaxErrorRate float64
+
+	// Clock schedules the checks. Defaults to the system clock.
+	Clock clock.Clock
 }
 
 // DefaultHealthCheckerConfig returns a HealthCheckerConfig with sensible defaults.
@@ -415,6 +440,7 @@ func NewHealthChecker(client *Client, config HealthCheckerConfig) *HealthChecker
 	if config.MaxErrorRate == 0 {
 		config.MaxErrorRate = 0.1
 	}
+	config.Clock = clock.OrReal(config.Clock)
 
 	return &HealthChecker{
 		config: config,
@@ -431,7 +457,7 @@ func (h *HealthChecker) Start(ctx context.Context) error {
 	}
 
 	h.ctx, h.cancel = context.WithCancel(ctx)
-	h.startTime = time.Now()
+	h.startTime = h.config.Clock.Now()
 
 	h.wg.Add(1)
 	go h.checkLoop()
@@ -484,7 +510,7 @@ func (h *HealthChecker) Stats() HealthCheckerStats {
 
 	var uptime time.Duration
 	if !h.startTime.IsZero() {
-		uptime = time.Since(h.startTime)
+		uptime = h.config.Clock.Since(h.startTime)
 	}
 
 	return HealthCheckerStats{
@@ -511,7 +537,7 @@ type HealthCheckerStats struct {
 func (h *HealthChecker) checkLoop() {
 	defer h.wg.Done()
 
-	ticker := time.NewTicker(h.config.Interval)
+	ticker := h.config.Clock.NewTicker(h.config.Interval)
 	defer ticker.Stop()
 
 	// Do initial check immediately
@@ -521,7 +547,7 @@ func (h *HealthChecker) checkLoop() {
 		select {
 		case <-h.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			h.performCheck()
 		}
 	}
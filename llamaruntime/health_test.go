@@ -12,6 +12,8 @@ import (
 	"sync"
 	"testing"
 	"time"
+
+	"go_backend/core/clock"
 )
 
 // =============================================================================
@@ -93,12 +95,30 @@ func TestDefaultGPUMonitorConfig(t *testing.T) {
 	if config.AlertThreshold != 90.0 {
 		t.Errorf("AlertThreshold = %f, want 90.0", config.AlertThreshold)
 	}
+	if config.TemperatureThreshold != 85.0 {
+		t.Errorf("TemperatureThreshold = %f, want 85.0", config.TemperatureThreshold)
+	}
 }
 
 // =============================================================================
 // GPU Monitor Tests
 // =============================================================================
 
+// testClock returns a fake clock for the monitor and checker loops.
+func testClock() *clock.Fake {
+	return clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// waitCheck waits for a check callback.
+func waitCheck(t *testing.T, checks <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-checks:
+	case <-time.After(5 * time.Second):
+		t.Fatal("check did not run")
+	}
+}
+
 func TestNewGPUMonitor(t *testing.T) {
 	config := DefaultGPUMonitorConfig()
 	monitor := NewGPUMonitor(config)
@@ -112,8 +132,10 @@ func TestNewGPUMonitor(t *testing.T) {
 }
 
 func TestGPUMonitor_StartStop(t *testing.T) {
+	checks := make(chan struct{}, 10)
 	config := DefaultGPUMonitorConfig()
-	config.Interval = 100 * time.Millisecond // Fast for testing
+	config.Clock = testClock()
+	config.Callback = func(info *GPUMemoryInfo) { checks <- struct{}{} }
 	monitor := NewGPUMonitor(config)
 
 	ctx := context.Background()
@@ -127,8 +149,8 @@ func TestGPUMonitor_StartStop(t *testing.T) {
 		t.Error("monitor should be running after Start")
 	}
 
-	// Wait for at least one check
-	time.Sleep(150 * time.Millisecond)
+	// Wait for the check at start
+	waitCheck(t, checks)
 
 	// Stop monitor
 	monitor.Stop()
@@ -169,86 +191,91 @@ func TestGPUMonitor_StopIdempotent(t *testing.T) {
 }
 
 func TestGPUMonitor_LastInfo(t *testing.T) {
+	fake := testClock()
+	checks := make(chan struct{}, 10)
 	config := DefaultGPUMonitorConfig()
-	config.Interval = 50 * time.Millisecond
+	config.Clock = fake
+	config.Callback = func(info *GPUMemoryInfo) { checks <- struct{}{} }
 	monitor := NewGPUMonitor(config)
 
 	ctx := context.Background()
 	monitor.Start(ctx)
+	defer monitor.Stop()
 
-	// Wait for check
-	time.Sleep(100 * time.Millisecond)
+	// The first check runs at start
+	waitCheck(t, checks)
 
 	info := monitor.LastInfo()
-	// In stub mode, we should get mock data
 	if info == nil {
-		t.Log("LastInfo is nil (may be expected if GPU check failed)")
-	} else {
-		if info.Total <= 0 {
-			t.Error("expected positive total memory")
-		}
+		t.Fatal("LastInfo is nil after a check")
+	}
+	if info.Total <= 0 {
+		t.Error("expected positive total memory")
 	}
-
-	monitor.Stop()
 }
 
 func TestGPUMonitor_Stats(t *testing.T) {
+	fake := testClock()
+	checks := make(chan struct{}, 10)
 	config := DefaultGPUMonitorConfig()
-	config.Interval = 50 * time.Millisecond
+	config.Clock = fake
+	config.Callback = func(info *GPUMemoryInfo) { checks <- struct{}{} }
 	monitor := NewGPUMonitor(config)
 
 	ctx := context.Background()
 	monitor.Start(ctx)
+	defer monitor.Stop()
 
-	// Wait for a few checks
-	time.Sleep(200 * time.Millisecond)
+	// The check at start and two interval checks
+	waitCheck(t, checks)
+	for i := 0; i < 2; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(config.Interval)
+		waitCheck(t, checks)
+	}
 
 	stats := monitor.Stats()
 
 	if !stats.Running {
 		t.Error("stats.Running should be true")
 	}
-	if stats.CheckCount <= 0 {
-		t.Error("expected positive check count")
+	if stats.CheckCount != 3 {
+		t.Errorf("CheckCount = %d, want 3", stats.CheckCount)
 	}
-	if stats.Uptime <= 0 {
-		t.Error("expected positive uptime")
+	if stats.Uptime != 2*config.Interval {
+		t.Errorf("Uptime = %v, want %v", stats.Uptime, 2*config.Interval)
 	}
-
-	monitor.Stop()
 }
 
 func TestGPUMonitor_Callback(t *testing.T) {
-	var callbackCalled int
-	var mu sync.Mutex
-
+	fake := testClock()
+	checks := make(chan struct{}, 10)
 	config := DefaultGPUMonitorConfig()
-	config.Interval = 50 * time.Millisecond
-	config.Callback = func(info *GPUMemoryInfo) {
-		mu.Lock()
-		callbackCalled++
-		mu.Unlock()
-	}
+	config.Clock = fake
+	config.Callback = func(info *GPUMemoryInfo) { checks <- struct{}{} }
 
 	monitor := NewGPUMonitor(config)
 	ctx := context.Background()
 	monitor.Start(ctx)
+	defer monitor.Stop()
 
-	// Wait for callbacks
-	time.Sleep(200 * time.Millisecond)
-
-	monitor.Stop()
+	waitCheck(t, checks)
 
-	mu.Lock()
-	if callbackCalled <= 0 {
-		t.Error("callback should have been called at least once")
+	// Nothing more until the interval passes
+	fake.BlockUntil(1)
+	fake.Advance(config.Interval - time.Second)
+	select {
+	case <-checks:
+		t.Fatal("callback called before the interval passed")
+	default:
 	}
-	mu.Unlock()
+	fake.Advance(time.Second)
+	waitCheck(t, checks)
 }
 
 func TestGPUMonitor_ContextCancellation(t *testing.T) {
 	config := DefaultGPUMonitorConfig()
-	config.Interval = 100 * time.Millisecond
+	config.Clock = testClock()
 	monitor := NewGPUMonitor(config)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -258,14 +285,19 @@ func TestGPUMonitor_ContextCancellation(t *testing.T) {
 		t.Error("monitor should be running")
 	}
 
-	// Cancel context
+	// Cancel context; the loop exits without a Stop
 	cancel()
-
-	// Wait for shutdown
-	time.Sleep(50 * time.Millisecond)
-
-	// Check if stopped (may take a moment)
-	time.Sleep(150 * time.Millisecond)
+	done := make(chan struct{})
+	go func() {
+		monitor.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("monitor loop did not exit after the context was cancelled")
+	}
+	monitor.Stop()
 }
 
 // =============================================================================
@@ -331,8 +363,10 @@ func TestHealthChecker_StartStop(t *testing.T) {
 	client := testClient(t)
 	defer client.Close()
 
+	checks := make(chan struct{}, 10)
 	config := DefaultHealthCheckerConfig()
-	config.Interval = 100 * time.Millisecond
+	config.Clock = testClock()
+	config.Callback = func(status *HealthStatus) { checks <- struct{}{} }
 	checker := NewHealthChecker(client, config)
 
 	ctx := context.Background()
@@ -346,8 +380,8 @@ func TestHealthChecker_StartStop(t *testing.T) {
 		t.Error("checker should be running after Start")
 	}
 
-	// Wait for at least one check
-	time.Sleep(150 * time.Millisecond)
+	// Wait for the check at start
+	waitCheck(t, checks)
 
 	// Stop checker
 	checker.Stop()
@@ -382,15 +416,17 @@ func TestHealthChecker_IsHealthy(t *testing.T) {
 	client := testClient(t)
 	defer client.Close()
 
+	checks := make(chan struct{}, 10)
 	config := DefaultHealthCheckerConfig()
-	config.Interval = 50 * time.Millisecond
+	config.Clock = testClock()
+	config.Callback = func(status *HealthStatus) { checks <- struct{}{} }
 	checker := NewHealthChecker(client, config)
 
 	ctx := context.Background()
 	checker.Start(ctx)
 
-	// Wait for check
-	time.Sleep(100 * time.Millisecond)
+	// Wait for the check at start
+	waitCheck(t, checks)
 
 	// In stub mode with fresh client, should be healthy
 	if !checker.IsHealthy() {
@@ -404,82 +440,88 @@ func TestHealthChecker_LastStatus(t *testing.T) {
 	client := testClient(t)
 	defer client.Close()
 
+	checks := make(chan struct{}, 10)
 	config := DefaultHealthCheckerConfig()
-	config.Interval = 50 * time.Millisecond
+	config.Clock = testClock()
+	config.Callback = func(status *HealthStatus) { checks <- struct{}{} }
 	checker := NewHealthChecker(client, config)
 
 	ctx := context.Background()
 	checker.Start(ctx)
+	defer checker.Stop()
 
-	// Wait for check
-	time.Sleep(100 * time.Millisecond)
+	// The first check runs at start
+	waitCheck(t, checks)
 
 	status := checker.LastStatus()
 	if status == nil {
 		t.Fatal("expected non-nil status after check")
 	}
-
-	checker.Stop()
 }
 
 func TestHealthChecker_Stats(t *testing.T) {
 	client := testClient(t)
 	defer client.Close()
 
+	fake := testClock()
+	checks := make(chan struct{}, 10)
 	config := DefaultHealthCheckerConfig()
-	config.Interval = 50 * time.Millisecond
+	config.Clock = fake
+	config.Callback = func(status *HealthStatus) { checks <- struct{}{} }
 	checker := NewHealthChecker(client, config)
 
 	ctx := context.Background()
 	checker.Start(ctx)
+	defer checker.Stop()
 
-	// Wait for checks
-	time.Sleep(200 * time.Millisecond)
+	// The check at start and two interval checks
+	waitCheck(t, checks)
+	for i := 0; i < 2; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(config.Interval)
+		waitCheck(t, checks)
+	}
 
 	stats := checker.Stats()
 
 	if !stats.Running {
 		t.Error("stats.Running should be true")
 	}
-	if stats.CheckCount <= 0 {
-		t.Error("expected positive check count")
+	if stats.CheckCount != 3 {
+		t.Errorf("CheckCount = %d, want 3", stats.CheckCount)
 	}
-	if stats.Uptime <= 0 {
-		t.Error("expected positive uptime")
+	if stats.Uptime != 2*config.Interval {
+		t.Errorf("Uptime = %v, want %v", stats.Uptime, 2*config.Interval)
 	}
-
-	checker.Stop()
 }
 
 func TestHealthChecker_Callback(t *testing.T) {
 	client := testClient(t)
 	defer client.Close()
 
-	var callbackCalled int
-	var mu sync.Mutex
-
+	fake := testClock()
+	checks := make(chan struct{}, 10)
 	config := DefaultHealthCheckerConfig()
-	config.Interval = 50 * time.Millisecond
-	config.Callback = func(status *HealthStatus) {
-		mu.Lock()
-		callbackCalled++
-		mu.Unlock()
-	}
+	config.Clock = fake
+	config.Callback = func(status *HealthStatus) { checks <- struct{}{} }
 
 	checker := NewHealthChecker(client, config)
 	ctx := context.Background()
 	checker.Start(ctx)
+	defer checker.Stop()
 
-	// Wait for callbacks
-	time.Sleep(200 * time.Millisecond)
-
-	checker.Stop()
+	waitCheck(t, checks)
 
-	mu.Lock()
-	if callbackCalled <= 0 {
-		t.Error("callback should have been called at least once")
+	// Nothing more until the interval passes
+	fake.BlockUntil(1)
+	fake.Advance(config.Interval - time.Second)
+	select {
+	case <-checks:
+		t.Fatal("callback called before the interval passed")
+	default:
 	}
-	mu.Unlock()
+	fake.Advance(time.Second)
+	waitCheck(t, checks)
 }
 
 func TestHealthChecker_Transitions(t *testing.T) {
@@ -489,8 +531,11 @@ func TestHealthChecker_Transitions(t *testing.T) {
 	var healthyCount, unhealthyCount int
 	var mu sync.Mutex
 
+	fake := testClock()
+	checks := make(chan struct{}, 10)
 	config := DefaultHealthCheckerConfig()
-	config.Interval = 50 * time.Millisecond
+	config.Clock = fake
+	config.Callback = func(status *HealthStatus) { checks <- struct{}{} }
 	config.OnHealthy = func() {
 		mu.Lock()
 		healthyCount++
@@ -506,11 +551,18 @@ func TestHealthChecker_Transitions(t *testing.T) {
 	ctx := context.Background()
 	checker.Start(ctx)
 
-	// Wait for checks
-	time.Sleep(200 * time.Millisecond)
+	// The check at start and three interval checks
+	waitCheck(t, checks)
+	for i := 0; i < 3; i++ {
+		fake.BlockUntil(1)
+		fake.Advance(config.Interval)
+		waitCheck(t, checks)
+	}
 
 	checker.Stop()
 
+	mu.Lock()
+	defer mu.Unlock()
 	// Just verify no panic - actual counts depend on mock data
 	t.Logf("Healthy transitions: %d, Unhealthy transitions: %d", healthyCount, unhealthyCount)
 }
@@ -527,34 +579,21 @@ func TestMonitorGPUMemory(t *testing.T) {
 		t.Fatal("expected non-nil stop function")
 	}
 
-	// Let it run briefly
-	time.Sleep(50 * time.Millisecond)
-
-	// Stop it
+	// Stop waits for the monitor loop to exit
 	stop()
 }
 
 func TestMonitorGPUMemoryWithCallback(t *testing.T) {
-	var called bool
-	var mu sync.Mutex
+	checks := make(chan struct{}, 10)
 
 	ctx := context.Background()
-	stop := MonitorGPUMemoryWithCallback(ctx, 50*time.Millisecond, func(info *GPUMemoryInfo) {
-		mu.Lock()
-		called = true
-		mu.Unlock()
+	stop := MonitorGPUMemoryWithCallback(ctx, time.Hour, func(info *GPUMemoryInfo) {
+		checks <- struct{}{}
 	})
+	defer stop()
 
-	// Wait for callback
-	time.Sleep(100 * time.Millisecond)
-
-	stop()
-
-	mu.Lock()
-	if !called {
-		t.Error("callback should have been called")
-	}
-	mu.Unlock()
+	// The first check runs at start, long before the interval
+	waitCheck(t, checks)
 }
 
 func TestPeriodicHealthCheck(t *testing.T) {
@@ -571,18 +610,24 @@ func TestPeriodicHealthCheck(t *testing.T) {
 		t.Fatal("expected non-nil checker")
 	}
 
-	// Wait for checks
-	time.Sleep(100 * time.Millisecond)
+	if !checker.IsRunning() {
+		t.Error("checker should be running until stopped")
+	}
 
 	stop()
+	if checker.IsRunning() {
+		t.Error("checker still running after stop")
+	}
 }
 
 func TestPeriodicHealthCheckWithCallbacks(t *testing.T) {
 	client := testClient(t)
 	defer client.Close()
 
+	checks := make(chan struct{}, 10)
 	config := DefaultHealthCheckerConfig()
-	config.Interval = 50 * time.Millisecond
+	config.Clock = testClock()
+	config.Callback = func(status *HealthStatus) { checks <- struct{}{} }
 
 	ctx := context.Background()
 	stop, checker := PeriodicHealthCheckWithCallbacks(ctx, client, config)
@@ -594,8 +639,8 @@ func TestPeriodicHealthCheckWithCallbacks(t *testing.T) {
 		t.Fatal("expected non-nil checker")
 	}
 
-	// Wait for checks
-	time.Sleep(100 * time.Millisecond)
+	// Wait for the check at start
+	waitCheck(t, checks)
 
 	stop()
 }
@@ -0,0 +1,117 @@
+// Package llamaruntime provides Go bindings to llama.cpp for local LLM inference.
+// This file contains the stop controls enforced inside the decode loops.
+//
+// Limits are checked after every generated token rather than on the final
+// text, so a model that starts repeating itself stops as soon as it emits a
+// stop sequence or reaches the byte cap instead of filling the context window
+// and running into the inference timeout.
+package llamaruntime
+
+import (
+	"bytes"
+	"unicode/utf8"
+)
+
+// generation is the output of a decode loop.
+type generation struct {
+	// Text is the generated text, with any matched stop sequence removed.
+	Text string
+
+	// Tokens is the number of tokens sampled.
+	Tokens int
+
+	// StopReason is one of the StopReason* constants.
+	StopReason string
+}
+
+// generationLimits are the stop controls for one decode loop.
+type generationLimits struct {
+	// MaxTokens is the maximum number of tokens to generate.
+	MaxTokens int
+
+	// MinTokens suppresses end-of-generation and stop sequences until this
+	// many tokens have been generated.
+	MinTokens int
+
+	// MaxOutputBytes is a hard cap on the output size in bytes (0 = no cap).
+	MaxOutputBytes int
+
+	// StopSequences end generation when they appear in the output.
+	StopSequences []string
+}
+
+// newGenerationLimits extracts the stop controls from inference parameters.
+func newGenerationLimits(params InferenceParams) generationLimits {
+	limits := generationLimits{
+		MaxTokens:      params.MaxTokens,
+		MinTokens:      params.MinTokens,
+		MaxOutputBytes: params.MaxOutputBytes,
+	}
+	if limits.MinTokens > limits.MaxTokens {
+		limits.MinTokens = limits.MaxTokens
+	}
+	for _, seq := range params.StopSequences {
+		if seq != "" {
+			limits.StopSequences = append(limits.StopSequences, seq)
+		}
+	}
+	return limits
+}
+
+// allowEOG reports whether end-of-generation may be sampled once
+// generated tokens have been produced.
+func (l generationLimits) allowEOG(generated int) bool {
+	return generated >= l.MinTokens
+}
+
+// check is called after each token's text (pieceLen bytes) has been appended
+// to out. It returns the length of out to keep and, when generation must
+// stop, a non-empty stop reason.
+func (l generationLimits) check(out []byte, pieceLen, generated int) (int, string) {
+	if generated >= l.MinTokens {
+		if idx := l.findStopSequence(out, pieceLen); idx >= 0 {
+			return idx, StopReasonStopSequence
+		}
+	}
+
+	if l.MaxOutputBytes > 0 && len(out) >= l.MaxOutputBytes {
+		return runeBoundary(out, l.MaxOutputBytes), StopReasonMaxBytes
+	}
+
+	if generated >= l.MaxTokens {
+		return len(out), StopReasonMaxTokens
+	}
+
+	return len(out), ""
+}
+
+// findStopSequence returns the index of the earliest stop sequence that ends
+// within the last pieceLen bytes of out, or -1. Only the tail is searched:
+// earlier matches were either reported already or fell before MinTokens.
+func (l generationLimits) findStopSequence(out []byte, pieceLen int) int {
+	found := -1
+	for _, seq := range l.StopSequences {
+		start := len(out) - pieceLen - len(seq) + 1
+		if start < 0 {
+			start = 0
+		}
+		if idx := bytes.Index(out[start:], []byte(seq)); idx >= 0 {
+			if idx += start; found < 0 || idx < found {
+				found = idx
+			}
+		}
+	}
+	return found
+}
+
+// runeBoundary returns the largest n <= limit that does not split a
+// multi-byte UTF-8 sequence in b.
+func runeBoundary(b []byte, limit int) int {
+	if limit >= len(b) {
+		return len(b)
+	}
+	for limit > 0 && !utf8.RuneStart(b[limit]) {
+		limit--
+	}
+	return limit
+}
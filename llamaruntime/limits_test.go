@@ -0,0 +1,148 @@
+package llamaruntime
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+// runLimits feeds pieces through limits.check like a decode loop and
+// returns the kept text, token count and stop reason.
+func runLimits(limits generationLimits, pieces []string) (string, int, string) {
+	var out []byte
+	for i, piece := range pieces {
+		out = append(out, piece...)
+		if keep, reason := limits.check(out, len(piece), i+1); reason != "" {
+			return string(out[:keep]), i + 1, reason
+		}
+	}
+	return string(out), len(pieces), ""
+}
+
+func TestGenerationLimits_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		limits     generationLimits
+		pieces     []string
+		wantText   string
+		wantTokens int
+		wantReason string
+	}{
+		{
+			name:       "no limit reached",
+			limits:     generationLimits{MaxTokens: 10},
+			pieces:     []string{"a", "b"},
+			wantText:   "ab",
+			wantTokens: 2,
+		},
+		{
+			name:       "max tokens",
+			limits:     generationLimits{MaxTokens: 2},
+			pieces:     []string{"a", "b", "c"},
+			wantText:   "ab",
+			wantTokens: 2,
+			wantReason: StopReasonMaxTokens,
+		},
+		{
+			name:       "stop sequence trimmed",
+			limits:     generationLimits{MaxTokens: 10, StopSequences: []string{"User:"}},
+			pieces:     []string{"Hi", " there", "\nUser:", " more"},
+			wantText:   "Hi there\n",
+			wantTokens: 3,
+			wantReason: StopReasonStopSequence,
+		},
+		{
+			name:       "stop sequence split across tokens",
+			limits:     generationLimits{MaxTokens: 10, StopSequences: []string{"\n\n"}},
+			pieces:     []string{"one", "\n", "\n", "two"},
+			wantText:   "one",
+			wantTokens: 3,
+			wantReason: StopReasonStopSequence,
+		},
+		{
+			name:       "earliest stop sequence wins",
+			limits:     generationLimits{MaxTokens: 10, StopSequences: []string{"C", "AB"}},
+			pieces:     []string{"xABC"},
+			wantText:   "x",
+			wantTokens: 1,
+			wantReason: StopReasonStopSequence,
+		},
+		{
+			name:       "stop sequence ignored before min tokens",
+			limits:     generationLimits{MaxTokens: 10, MinTokens: 3, StopSequences: []string{"."}},
+			pieces:     []string{"a", ".", "b", ".", "c"},
+			wantText:   "a.b",
+			wantTokens: 4,
+			wantReason: StopReasonStopSequence,
+		},
+		{
+			name:       "byte cap",
+			limits:     generationLimits{MaxTokens: 100, MaxOutputBytes: 5},
+			pieces:     []string{"abc", "def", "ghi"},
+			wantText:   "abcde",
+			wantTokens: 2,
+			wantReason: StopReasonMaxBytes,
+		},
+		{
+			name:       "byte cap applies before min tokens",
+			limits:     generationLimits{MaxTokens: 100, MinTokens: 50, MaxOutputBytes: 4},
+			pieces:     []string{"repeat", "repeat"},
+			wantText:   "repe",
+			wantTokens: 1,
+			wantReason: StopReasonMaxBytes,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, tokens, reason := runLimits(tt.limits, tt.pieces)
+			if text != tt.wantText || tokens != tt.wantTokens || reason != tt.wantReason {
+				t.Errorf("got (%q, %d, %q), want (%q, %d, %q)",
+					text, tokens, reason, tt.wantText, tt.wantTokens, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestGenerationLimits_ByteCapKeepsUTF8(t *testing.T) {
+	limits := generationLimits{MaxTokens: 10, MaxOutputBytes: 4}
+	text, _, reason := runLimits(limits, []string{"a", "日本"})
+
+	if reason != StopReasonMaxBytes {
+		t.Fatalf("reason = %q, want %q", reason, StopReasonMaxBytes)
+	}
+	if text != "a日" {
+		t.Errorf("text = %q, want %q", text, "a日")
+	}
+	if !utf8.ValidString(text) {
+		t.Errorf("text %q is not valid UTF-8", text)
+	}
+}
+
+func TestGenerationLimits_AllowEOG(t *testing.T) {
+	limits := generationLimits{MaxTokens: 10, MinTokens: 2}
+	if limits.allowEOG(1) {
+		t.Error("allowEOG(1) should be false below MinTokens")
+	}
+	if !limits.allowEOG(2) {
+		t.Error("allowEOG(2) should be true at MinTokens")
+	}
+}
+
+func TestNewGenerationLimits(t *testing.T) {
+	limits := newGenerationLimits(InferenceParams{
+		MaxTokens:      5,
+		MinTokens:      9,
+		MaxOutputBytes: 100,
+		StopSequences:  []string{"", "END"},
+	})
+
+	if limits.MinTokens != 5 {
+		t.Errorf("MinTokens = %d, want capped at MaxTokens (5)", limits.MinTokens)
+	}
+	if len(limits.StopSequences) != 1 || limits.StopSequences[0] != "END" {
+		t.Errorf("StopSequences = %q, want empty sequences dropped", limits.StopSequences)
+	}
+	if limits.MaxOutputBytes != 100 {
+		t.Errorf("MaxOutputBytes = %d, want 100", limits.MaxOutputBytes)
+	}
+}
@@ -41,6 +41,32 @@ type ModelLoaderConfig struct {
 	// StartupTestTimeout is the timeout for the startup test.
 	StartupTestTimeout time.Duration
 
+	// AutoOffload sizes GPU layer offload to the free VRAM.
+	AutoOffload bool
+
+	// VRAMHeadroom is the VRAM AutoOffload leaves free (e.g., for SD).
+	// Zero uses DefaultVRAMHeadroom.
+	VRAMHeadroom int64
+
+	// BatchSize is the llama.cpp n_batch. Zero uses DefaultBatchSize.
+	BatchSize int
+
+	// MinContextSize is the smallest context window to retry with when
+	// the default one does not fit. Zero uses DefaultMinContextSize.
+	MinContextSize int
+
+	// ContextOptions tunes flash attention and the KV cache data types.
+	ContextOptions ContextOptions
+
+	// ParallelSequences batches concurrent text requests into one context
+	// when greater than 1. The regular context pool then shrinks to a
+	// single context for non-batched operations such as vision.
+	ParallelSequences int
+
+	// ChatTemplate overrides the chat format detected from the model.
+	// Empty means detect it from GGUF metadata.
+	ChatTemplate string
+
 	// Logger is an optional logger for model loading events.
 	// If nil, uses standard log.
 	Logger *log.Logger
@@ -144,6 +170,18 @@ func (m *ModelLoader) Load(ctx context.Context) (*Client, error) {
 	// Step 4: Create the Client
 	clientConfig := DefaultClientConfig()
 	clientConfig.ModelPath = resolvedPath
+	clientConfig.AutoOffload = m.config.AutoOffload
+	clientConfig.VRAMHeadroom = m.config.VRAMHeadroom
+	clientConfig.ContextOptions = m.config.ContextOptions
+	clientConfig.MinContextSize = m.config.MinContextSize
+	clientConfig.ChatTemplate = m.config.ChatTemplate
+	if m.config.ParallelSequences > 1 {
+		clientConfig.ParallelSequences = m.config.ParallelSequences
+		clientConfig.NumContexts = 1
+	}
+	if m.config.BatchSize > 0 {
+		clientConfig.BatchSize = m.config.BatchSize
+	}
 
 	client, err := NewClient(clientConfig)
 	if err != nil {
@@ -152,6 +190,7 @@ func (m *ModelLoader) Load(ctx context.Context) (*Client, error) {
 
 	// Step 5: Update metadata with model info from client
 	m.updateMetadataFromClient(client)
+	m.logger.Printf("Chat template: %s", client.ChatTemplate())
 
 	// Step 6: Run startup test if enabled
 	if m.config.RunStartupTest {
@@ -0,0 +1,137 @@
+// Package llamaruntime provides Go bindings to llama.cpp for local LLM inference.
+// This file contains automatic GPU layer offload planning.
+//
+// Instead of a fixed n_gpu_layers, the planner compares free VRAM against the
+// model's per-layer size (estimated from the GGUF file size and block count)
+// and picks the largest number of layers that fits while leaving headroom for
+// other GPU workloads such as Stable Diffusion.
+package llamaruntime
+
+import (
+	"fmt"
+)
+
+// DefaultVRAMHeadroom is the VRAM kept free when planning layer offload.
+const DefaultVRAMHeadroom = 1024 * 1024 * 1024 // 1 GB
+
+// contextVRAMPerToken approximates the KV cache and compute buffer cost of one
+// context token. Deliberately conservative; a failed context creation triggers
+// a retry with fewer layers anyway.
+const contextVRAMPerToken = 512 * 1024 // 512 KB
+
+// OffloadPlan describes how many model layers to place on the GPU.
+type OffloadPlan struct {
+	// Layers is the number of layers to offload (-1 means all layers).
+	Layers int
+
+	// TotalLayers is the model's transformer block count (0 if unknown).
+	TotalLayers int
+
+	// LayerBytes is the estimated VRAM cost of one layer.
+	LayerBytes int64
+
+	// FreeVRAM is the free VRAM observed when planning.
+	FreeVRAM int64
+
+	// Budget is the VRAM available to model weights after headroom and
+	// context buffers are subtracted.
+	Budget int64
+
+	// Reason explains how the plan was chosen, for logging.
+	Reason string
+}
+
+// FullOffload reports whether the plan offloads every layer.
+func (p OffloadPlan) FullOffload() bool {
+	return p.Layers < 0 || (p.TotalLayers > 0 && p.Layers >= p.TotalLayers)
+}
+
+// String returns a human-readable summary of the plan.
+func (p OffloadPlan) String() string {
+	if p.FullOffload() {
+		return fmt.Sprintf("all %d layers on GPU (%s)", p.TotalLayers, p.Reason)
+	}
+	return fmt.Sprintf("%d/%d layers on GPU (%s)", p.Layers, p.TotalLayers, p.Reason)
+}
+
+// PlanGPUOffload picks the number of layers to offload.
+//
+// modelSize is the model file size, totalLayers the block count from GGUF
+// metadata, freeVRAM the currently free VRAM, headroom the VRAM to leave
+// unused and contextTokens the total context tokens across all contexts.
+//
+// When VRAM or layer information is unavailable the plan falls back to
+// offloading all layers, matching the previous fixed behavior.
+func PlanGPUOffload(modelSize int64, totalLayers int, freeVRAM, headroom int64, contextTokens int) OffloadPlan {
+	plan := OffloadPlan{
+		Layers:      -1,
+		TotalLayers: totalLayers,
+		FreeVRAM:    freeVRAM,
+	}
+
+	if totalLayers <= 0 || modelSize <= 0 {
+		plan.Reason = "layer count unknown"
+		return plan
+	}
+	if freeVRAM <= 0 {
+		plan.Reason = "free VRAM unknown"
+		return plan
+	}
+
+	// Embedding and output tensors are roughly one extra layer's worth
+	plan.LayerBytes = modelSize / int64(totalLayers+1)
+	plan.Budget = freeVRAM - headroom - int64(contextTokens)*contextVRAMPerToken
+
+	if plan.Budget >= modelSize {
+		plan.Reason = "model fits in VRAM"
+		return plan
+	}
+	if plan.Budget <= 0 {
+		plan.Layers = 0
+		plan.Reason = "no VRAM left after headroom"
+		return plan
+	}
+
+	layers := int(plan.Budget / plan.LayerBytes)
+	if layers > totalLayers {
+		layers = totalLayers
+	}
+	plan.Layers = layers
+	plan.Reason = "limited by free VRAM"
+	return plan
+}
+
+// nextContextRetry returns the context size to try after contexts of the
+// given size failed to fit, halving it down to minSize. Returns -1 when no
+// further reduction is possible.
+func nextContextRetry(size, minSize int) int {
+	if size <= minSize {
+		return -1
+	}
+	next := size / 2
+	if next < minSize {
+		next = minSize
+	}
+	return next
+}
+
+// nextOffloadRetry returns the layer count to try after a failed attempt
+// with the given number of layers, stepping down by an eighth of the model.
+// Returns -1 when no further reduction is possible.
+func nextOffloadRetry(layers, totalLayers int) int {
+	if layers < 0 {
+		layers = totalLayers
+	}
+	if layers <= 0 {
+		return -1
+	}
+	step := totalLayers / 8
+	if step < 1 {
+		step = 1
+	}
+	next := layers - step
+	if next < 0 {
+		next = 0
+	}
+	return next
+}
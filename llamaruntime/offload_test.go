@@ -0,0 +1,77 @@
+package llamaruntime
+
+import "testing"
+
+const gib = 1024 * 1024 * 1024
+
+func TestPlanGPUOffload_Fits(t *testing.T) {
+	plan := PlanGPUOffload(4*gib, 32, 12*gib, DefaultVRAMHeadroom, 2048)
+	if plan.Layers != -1 {
+		t.Errorf("Layers = %d, want -1 (all)", plan.Layers)
+	}
+	if !plan.FullOffload() {
+		t.Error("expected FullOffload() to be true")
+	}
+}
+
+func TestPlanGPUOffload_Partial(t *testing.T) {
+	// 33 "layers" of ~256MB each; 4GB budget after 1GB headroom and 0 context
+	plan := PlanGPUOffload(33*256*1024*1024, 32, 5*gib, gib, 0)
+	if plan.Layers != 16 {
+		t.Errorf("Layers = %d, want 16", plan.Layers)
+	}
+	if plan.FullOffload() {
+		t.Error("expected partial offload")
+	}
+}
+
+func TestPlanGPUOffload_NoBudget(t *testing.T) {
+	plan := PlanGPUOffload(8*gib, 32, gib, 2*gib, 0)
+	if plan.Layers != 0 {
+		t.Errorf("Layers = %d, want 0", plan.Layers)
+	}
+}
+
+func TestPlanGPUOffload_UnknownInputs(t *testing.T) {
+	if plan := PlanGPUOffload(8*gib, 0, 12*gib, 0, 0); plan.Layers != -1 {
+		t.Errorf("unknown layer count: Layers = %d, want -1", plan.Layers)
+	}
+	if plan := PlanGPUOffload(8*gib, 32, 0, 0, 0); plan.Layers != -1 {
+		t.Errorf("unknown VRAM: Layers = %d, want -1", plan.Layers)
+	}
+}
+
+func TestNextOffloadRetry(t *testing.T) {
+	tests := []struct {
+		layers, total, want int
+	}{
+		{-1, 32, 28},
+		{28, 32, 24},
+		{3, 32, 0},
+		{0, 32, -1},
+		{5, 4, 4},
+		{-1, 0, -1},
+	}
+	for _, tt := range tests {
+		if got := nextOffloadRetry(tt.layers, tt.total); got != tt.want {
+			t.Errorf("nextOffloadRetry(%d, %d) = %d, want %d", tt.layers, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestNextContextRetry(t *testing.T) {
+	tests := []struct {
+		size, min, want int
+	}{
+		{2048, 512, 1024},
+		{1024, 512, 512},
+		{768, 512, 512},
+		{512, 512, -1},
+		{2048, 4096, -1},
+	}
+	for _, tt := range tests {
+		if got := nextContextRetry(tt.size, tt.min); got != tt.want {
+			t.Errorf("nextContextRetry(%d, %d) = %d, want %d", tt.size, tt.min, got, tt.want)
+		}
+	}
+}
@@ -15,6 +15,10 @@ const (
 	// Bunny v1.1 supports up to 2048 tokens, but we use a conservative default.
 	DefaultContextSize = 2048
 
+	// DefaultMinContextSize is the smallest context window tried when a
+	// context of the configured size does not fit in memory.
+	DefaultMinContextSize = 512
+
 	// DefaultBatchSize is the default batch size for inference.
 	// Smaller batches use less memory but may be slower.
 	DefaultBatchSize = 512
@@ -153,9 +157,19 @@ type InferenceParams struct {
 	RepeatPenalty float32
 
 	// StopSequences are sequences that stop generation when encountered.
+	// The matched sequence is not included in the result.
 	// Common examples: ["</s>", "\n\n", "User:"]
 	StopSequences []string
 
+	// MinTokens is the minimum number of tokens to generate before
+	// end-of-sequence or a stop sequence may end generation.
+	// Capped at MaxTokens.
+	MinTokens int
+
+	// MaxOutputBytes is a hard cap on the generated text in bytes.
+	// The text is cut at a UTF-8 boundary. 0 means no cap.
+	MaxOutputBytes int
+
 	// Timeout is the maximum time allowed for inference.
 	// Defaults to DefaultTimeout.
 	Timeout time.Duration
@@ -173,6 +187,42 @@ func DefaultInferenceParams() InferenceParams {
 	}
 }
 
+// GenerationParams contains parameters for Client.Generate, a prompt-based
+// convenience wrapper around Infer. Zero values use the Infer defaults.
+type GenerationParams struct {
+	// MaxTokens is the maximum number of tokens to generate.
+	MaxTokens int
+
+	// Temperature controls randomness in sampling.
+	Temperature float32
+
+	// TopP is the nucleus sampling parameter.
+	TopP float32
+
+	// TopK is the top-k sampling parameter.
+	TopK int
+
+	// RepeatPenalty penalizes repeated tokens.
+	RepeatPenalty float32
+
+	// SystemPrompt, if set, is placed before the user prompt.
+	SystemPrompt *string
+
+	// StopSequences are sequences that stop generation when encountered.
+	// The matched sequence is not included in the result.
+	StopSequences []string
+
+	// MinTokens is the minimum number of tokens to generate before
+	// end-of-sequence or a stop sequence may end generation.
+	MinTokens int
+
+	// MaxOutputBytes is a hard cap on the generated text in bytes (0 = no cap).
+	MaxOutputBytes int
+
+	// Timeout is the maximum time allowed for inference.
+	Timeout time.Duration
+}
+
 // =============================================================================
 // Vision Types (for Bunny multimodal model)
 // =============================================================================
@@ -237,10 +287,18 @@ type InferenceResult struct {
 	TokensPerSecond float64
 
 	// StopReason indicates why generation stopped.
-	// Possible values: "max_tokens", "stop_sequence", "eos"
+	// One of the StopReason* constants.
 	StopReason string
 }
 
+// Stop reasons reported in InferenceResult.StopReason.
+const (
+	StopReasonEOS          = "eos"
+	StopReasonMaxTokens    = "max_tokens"
+	StopReasonStopSequence = "stop_sequence"
+	StopReasonMaxBytes     = "max_bytes"
+)
+
 // InferenceStats contains detailed performance statistics.
 type InferenceStats struct {
 	// TotalInferences is the total number of inference calls.
@@ -368,6 +426,9 @@ type ModelInfo struct {
 	// IsMultimodal indicates if the model supports vision input.
 	IsMultimodal bool
 
+	// ChatTemplate is the chat format used to build prompts (e.g., "chatml").
+	ChatTemplate string
+
 	// LoadedAt is when the model was loaded.
 	LoadedAt time.Time
 
@@ -0,0 +1,80 @@
+// Package llamaruntime provides Go bindings to llama.cpp for local LLM inference.
+// This file contains the startup validation checks contributed by this package.
+//
+// The checks only inspect files and settings, so they behave the same with and
+// without cgo and never load the model.
+package llamaruntime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go_backend/core"
+	"go_backend/core/validation"
+)
+
+// ValidationChecks returns the checks for the local LLM configuration, for
+// registration with validation.ValidationSuite.Register.
+func ValidationChecks() []validation.Check {
+	return []validation.Check{
+		{Name: "LLM Model File", Module: "llamaruntime", Run: checkModelFile},
+		{Name: "LLM Chat Template", Module: "llamaruntime", Run: checkChatTemplate},
+	}
+}
+
+// checkModelFile verifies LLAMA_MODEL_PATH points at a readable GGUF file,
+// resolved against LLAMA_MODELS_DIR the same way the model loader does.
+func checkModelFile(ctx context.Context) validation.CheckResult {
+	modelPath := os.Getenv("LLAMA_MODEL_PATH")
+	if modelPath == "" {
+		return validation.Skipped("LLAMA_MODEL_PATH not set, local LLM disabled")
+	}
+	modelsDir := os.Getenv("LLAMA_MODELS_DIR")
+	if modelsDir == "" {
+		modelsDir = "./models"
+	}
+	resolved := ResolveModelPath(modelPath, modelsDir)
+
+	if !ModelExists(resolved) {
+		if os.Getenv("LLAMA_AUTO_DOWNLOAD") == "true" && os.Getenv("LLAMA_MODEL_URL") != "" {
+			return validation.Warning(fmt.Sprintf("%s not found, will be downloaded", resolved),
+				"The first start downloads the model from LLAMA_MODEL_URL; check free disk space")
+		}
+		return validation.Failed("Model file not found",
+			fmt.Errorf("model file not found: %s", resolved),
+			"Download a model with the dashboard Model Catalog, or set LLAMA_MODEL_URL and LLAMA_AUTO_DOWNLOAD=true")
+	}
+
+	if err := ValidateModelPath(resolved); err != nil {
+		return validation.Failed("Invalid model file", err,
+			"The file is not a GGUF model or the download is incomplete; delete it and download it again")
+	}
+
+	meta, err := ReadGGUFMetadata(resolved)
+	if err != nil {
+		return validation.Failed("Unreadable GGUF metadata", err,
+			"The file may be truncated; delete it and download it again")
+	}
+
+	details := core.FormatBytes(GetModelSize(resolved))
+	if arch := meta.Architecture(); arch != "" {
+		details = arch + ", " + details
+	}
+	return validation.Passed(fmt.Sprintf("%s (%s)", ExtractModelName(resolved), details))
+}
+
+// checkChatTemplate verifies a LLAMA_CHAT_TEMPLATE override names a
+// supported template.
+func checkChatTemplate(ctx context.Context) validation.CheckResult {
+	name := os.Getenv("LLAMA_CHAT_TEMPLATE")
+	if name == "" {
+		return validation.Skipped("Detected from model metadata")
+	}
+	if _, err := ParseChatTemplate(name); err != nil {
+		return validation.Failed("Unsupported LLAMA_CHAT_TEMPLATE", err,
+			"Use one of: "+strings.Join(ChatTemplateNames(), ", ")+", or unset it to detect the template from the model")
+	}
+	return validation.Passed(name)
+}
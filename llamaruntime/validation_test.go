@@ -0,0 +1,91 @@
+package llamaruntime
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go_backend/core/validation"
+)
+
+func TestValidationChecks(t *testing.T) {
+	checks := ValidationChecks()
+	if len(checks) == 0 {
+		t.Fatal("ValidationChecks() returned no checks")
+	}
+	for _, c := range checks {
+		if c.Name == "" || c.Module != "llamaruntime" || c.Run == nil {
+			t.Errorf("incomplete check %+v", c)
+		}
+	}
+}
+
+func TestCheckModelFile(t *testing.T) {
+	dir := t.TempDir()
+
+	b := &ggufBuilder{}
+	b.addString("general.architecture", "qwen2")
+	if err := os.WriteFile(filepath.Join(dir, "good.gguf"), b.bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bad.gguf"), []byte("not a model"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		modelPath    string
+		autoDownload string
+		wantStatus   validation.StepStatus
+		wantMessage  string
+	}{
+		{"unset", "", "", validation.StepSkipped, "LLAMA_MODEL_PATH not set"},
+		{"valid relative to models dir", "good.gguf", "", validation.StepPassed, "qwen2"},
+		{"missing", "missing.gguf", "", validation.StepFailed, "not found"},
+		{"missing with auto download", "missing.gguf", "true", validation.StepWarning, "will be downloaded"},
+		{"not gguf", "bad.gguf", "", validation.StepFailed, "Invalid model file"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LLAMA_MODEL_PATH", tt.modelPath)
+			t.Setenv("LLAMA_MODELS_DIR", dir)
+			t.Setenv("LLAMA_AUTO_DOWNLOAD", tt.autoDownload)
+			t.Setenv("LLAMA_MODEL_URL", "https://example.com/model.gguf")
+
+			result := checkModelFile(context.Background())
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v (%s)", result.Status, tt.wantStatus, result.Message)
+			}
+			if !strings.Contains(result.Message, tt.wantMessage) {
+				t.Errorf("Message = %q, want it to contain %q", result.Message, tt.wantMessage)
+			}
+			if result.Status == validation.StepFailed && result.Hint == "" {
+				t.Error("failed check has no hint")
+			}
+		})
+	}
+}
+
+func TestCheckChatTemplate(t *testing.T) {
+	t.Setenv("LLAMA_CHAT_TEMPLATE", "")
+	if got := checkChatTemplate(context.Background()).Status; got != validation.StepSkipped {
+		t.Errorf("unset: Status = %v, want skipped", got)
+	}
+
+	t.Setenv("LLAMA_CHAT_TEMPLATE", ChatTemplateChatML)
+	if got := checkChatTemplate(context.Background()).Status; got != validation.StepPassed {
+		t.Errorf("chatml: Status = %v, want passed", got)
+	}
+
+	t.Setenv("LLAMA_CHAT_TEMPLATE", "nonsense")
+	result := checkChatTemplate(context.Background())
+	if result.Status != validation.StepFailed {
+		t.Errorf("nonsense: Status = %v, want failed", result.Status)
+	}
+	if !strings.Contains(result.Hint, ChatTemplateLlama3) {
+		t.Errorf("Hint = %q, want it to list supported templates", result.Hint)
+	}
+}
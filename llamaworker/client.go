@@ -0,0 +1,199 @@
+package llamaworker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go_backend/llamaruntime"
+)
+
+// healthTTL is how long a health report is reused by IsLoaded and
+// ContextDowngrade.
+const healthTTL = 5 * time.Second
+
+// Client is an organism that runs inference on the GPU worker. It stands
+// in for the server's local *llamaruntime.Client.
+//
+// Usage:
+//
+//	client := llamaworker.NewClient(cfg)
+//	text, err := client.Generate(ctx, prompt, llamaruntime.GenerationParams{MaxTokens: 256})
+type Client struct {
+	config Config
+	http   *http.Client
+
+	mu        sync.Mutex
+	health    Health
+	checkedAt time.Time
+}
+
+// NewClient creates a client for the worker at config.URL.
+func NewClient(config Config) *Client {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	return &Client{config: config, http: httpClient}
+}
+
+// URL returns the worker's URL.
+func (c *Client) URL() string {
+	return c.config.URL
+}
+
+// Generate runs text inference on prompt on the worker. Errors the worker
+// reports keep their llamaruntime meaning, so errors.Is(err,
+// llamaruntime.ErrTimeout) holds for a completion that timed out there.
+func (c *Client) Generate(ctx context.Context, prompt string, params llamaruntime.GenerationParams) (string, error) {
+	var resp GenerateResponse
+	if err := c.post(ctx, PathGenerate, requestFromParams(prompt, params), &resp); err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// InferVision describes an image on the worker. An ImagePath is read here
+// and sent with the request.
+func (c *Client) InferVision(ctx context.Context, params llamaruntime.VisionParams) (*llamaruntime.InferenceResult, error) {
+	image := params.ImageData
+	if len(image) == 0 && params.ImagePath != "" {
+		data, err := os.ReadFile(params.ImagePath)
+		if err != nil {
+			return nil, fmt.Errorf("llamaworker: %w: %v", llamaruntime.ErrInvalidImage, err)
+		}
+		image = data
+	}
+	req := VisionRequest{
+		Image:       image,
+		Prompt:      params.Prompt,
+		MaxTokens:   params.MaxTokens,
+		Temperature: params.Temperature,
+		TimeoutMS:   params.Timeout.Milliseconds(),
+	}
+	var resp InferenceResponse
+	if err := c.post(ctx, PathVision, req, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Result(), nil
+}
+
+// Warmup runs a short completion on the worker.
+func (c *Client) Warmup(ctx context.Context) error {
+	return c.post(ctx, PathWarmup, struct{}{}, nil)
+}
+
+// Health asks the worker for the state of its model.
+func (c *Client) Health(ctx context.Context) (Health, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, PathHealth, nil)
+	if err != nil {
+		return Health{}, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Health{}, fmt.Errorf("llamaworker: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Health{}, readError(resp)
+	}
+	var health Health
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return Health{}, fmt.Errorf("llamaworker: decode health: %w", err)
+	}
+
+	c.mu.Lock()
+	c.health, c.checkedAt = health, time.Now()
+	c.mu.Unlock()
+	return health, nil
+}
+
+// IsLoaded reports whether the worker has its model in GPU memory, as of
+// a health report at most a few seconds old. An unreachable worker is
+// reported as not loaded.
+func (c *Client) IsLoaded() bool {
+	health, err := c.recentHealth()
+	return err == nil && health.Loaded
+}
+
+// ContextDowngrade returns the configured context window and the one the
+// worker's model runs with. An unreachable worker reports no downgrade.
+func (c *Client) ContextDowngrade() (requested, actual int) {
+	health, err := c.recentHealth()
+	if err != nil {
+		return 0, 0
+	}
+	return health.ContextRequested, health.ContextActual
+}
+
+// recentHealth returns the last health report if it is fresh, or asks
+// the worker again.
+func (c *Client) recentHealth() (Health, error) {
+	c.mu.Lock()
+	health, fresh := c.health, time.Since(c.checkedAt) < healthTTL
+	c.mu.Unlock()
+	if fresh {
+		return health, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return c.Health(ctx)
+}
+
+// post sends body as JSON to path and decodes the answer into out, unless
+// out is nil.
+func (c *Client) post(ctx context.Context, path string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("llamaworker: %w", err)
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("llamaworker: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return readError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("llamaworker: decode response: %w", err)
+	}
+	return nil
+}
+
+// newRequest creates a request for path on the worker.
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.config.URL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("llamaworker: %w", err)
+	}
+	if c.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+	return req, nil
+}
+
+// readError decodes the ErrorResponse of a failed request.
+func readError(resp *http.Response) error {
+	var body ErrorResponse
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if json.Unmarshal(data, &body) != nil || body.Error == "" {
+		body.Error = fmt.Sprintf("%s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	return errorFromResponse(body)
+}
@@ -0,0 +1,193 @@
+// Package llamaworker runs llama.cpp text and vision inference in the GPU
+// worker process (GPU_ISOLATION=process), so a crash inside llama.cpp ends
+// the worker instead of the canvas monitor.
+//
+// The gpu-worker subcommand serves a Server in front of its llamaruntime
+// client next to the sd-worker protocol; the canvas monitor uses a Client
+// in place of the local client, so prompts and replies cross a loopback
+// port while templates, redaction and canvas updates stay with the monitor.
+//
+// This file contains the HTTP protocol atoms shared by both sides.
+package llamaworker
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go_backend/core/errs"
+	"go_backend/llamaruntime"
+)
+
+// Protocol paths. Generate, Vision and Warmup take their request as JSON
+// and answer with their response, or an ErrorResponse with a non-2xx
+// status. They share the worker's port with the sd-worker paths.
+const (
+	PathPrefix   = "/v1/llama/"
+	PathGenerate = PathPrefix + "generate"
+	PathVision   = PathPrefix + "vision"
+	PathWarmup   = PathPrefix + "warmup"
+	PathHealth   = PathPrefix + "health"
+)
+
+// Config configures the Server and the Client.
+type Config struct {
+	// URL of the worker: http://127.0.0.1:8092
+	URL string
+
+	// Token, if set, must be sent as "Authorization: Bearer <token>"
+	Token string
+
+	// HTTPClient sends the Client's requests (default: a plain client;
+	// the request's context and its Timeout bound each request)
+	HTTPClient *http.Client
+}
+
+// GenerateRequest asks the worker for one text completion.
+type GenerateRequest struct {
+	Prompt         string   `json:"prompt"`
+	SystemPrompt   *string  `json:"system_prompt,omitempty"`
+	MaxTokens      int      `json:"max_tokens,omitempty"`
+	Temperature    float32  `json:"temperature,omitempty"`
+	TopP           float32  `json:"top_p,omitempty"`
+	TopK           int      `json:"top_k,omitempty"`
+	RepeatPenalty  float32  `json:"repeat_penalty,omitempty"`
+	StopSequences  []string `json:"stop_sequences,omitempty"`
+	MinTokens      int      `json:"min_tokens,omitempty"`
+	MaxOutputBytes int      `json:"max_output_bytes,omitempty"`
+	TimeoutMS      int64    `json:"timeout_ms,omitempty"`
+}
+
+// requestFromParams converts generation parameters to a request.
+func requestFromParams(prompt string, p llamaruntime.GenerationParams) GenerateRequest {
+	return GenerateRequest{
+		Prompt:         prompt,
+		SystemPrompt:   p.SystemPrompt,
+		MaxTokens:      p.MaxTokens,
+		Temperature:    p.Temperature,
+		TopP:           p.TopP,
+		TopK:           p.TopK,
+		RepeatPenalty:  p.RepeatPenalty,
+		StopSequences:  p.StopSequences,
+		MinTokens:      p.MinTokens,
+		MaxOutputBytes: p.MaxOutputBytes,
+		TimeoutMS:      p.Timeout.Milliseconds(),
+	}
+}
+
+// Params converts the request to generation parameters.
+func (r GenerateRequest) Params() llamaruntime.GenerationParams {
+	return llamaruntime.GenerationParams{
+		MaxTokens:      r.MaxTokens,
+		Temperature:    r.Temperature,
+		TopP:           r.TopP,
+		TopK:           r.TopK,
+		RepeatPenalty:  r.RepeatPenalty,
+		SystemPrompt:   r.SystemPrompt,
+		StopSequences:  r.StopSequences,
+		MinTokens:      r.MinTokens,
+		MaxOutputBytes: r.MaxOutputBytes,
+		Timeout:        time.Duration(r.TimeoutMS) * time.Millisecond,
+	}
+}
+
+// GenerateResponse is the completion.
+type GenerateResponse struct {
+	Text string `json:"text"`
+}
+
+// VisionRequest asks the worker to describe one image. The image travels
+// in the request, so the worker needs no access to the server's files.
+type VisionRequest struct {
+	Image       []byte  `json:"image"`
+	Prompt      string  `json:"prompt"`
+	MaxTokens   int     `json:"max_tokens,omitempty"`
+	Temperature float32 `json:"temperature,omitempty"`
+	TimeoutMS   int64   `json:"timeout_ms,omitempty"`
+}
+
+// Params converts the request to vision parameters.
+func (r VisionRequest) Params() llamaruntime.VisionParams {
+	return llamaruntime.VisionParams{
+		ImageData:   r.Image,
+		Prompt:      r.Prompt,
+		MaxTokens:   r.MaxTokens,
+		Temperature: r.Temperature,
+		Timeout:     time.Duration(r.TimeoutMS) * time.Millisecond,
+	}
+}
+
+// InferenceResponse is the result of a vision request.
+type InferenceResponse struct {
+	Text            string  `json:"text"`
+	TokensGenerated int     `json:"tokens_generated"`
+	TokensPrompt    int     `json:"tokens_prompt"`
+	DurationMS      int64   `json:"duration_ms"`
+	TokensPerSecond float64 `json:"tokens_per_second"`
+	StopReason      string  `json:"stop_reason,omitempty"`
+}
+
+// responseFromResult converts an inference result to a response.
+func responseFromResult(r *llamaruntime.InferenceResult) InferenceResponse {
+	return InferenceResponse{
+		Text:            r.Text,
+		TokensGenerated: r.TokensGenerated,
+		TokensPrompt:    r.TokensPrompt,
+		DurationMS:      r.Duration.Milliseconds(),
+		TokensPerSecond: r.TokensPerSecond,
+		StopReason:      r.StopReason,
+	}
+}
+
+// Result converts the response to an inference result.
+func (r InferenceResponse) Result() *llamaruntime.InferenceResult {
+	return &llamaruntime.InferenceResult{
+		Text:            r.Text,
+		TokensGenerated: r.TokensGenerated,
+		TokensPrompt:    r.TokensPrompt,
+		Duration:        time.Duration(r.DurationMS) * time.Millisecond,
+		TokensPerSecond: r.TokensPerSecond,
+		StopReason:      r.StopReason,
+	}
+}
+
+// ErrorResponse reports a failed request.
+type ErrorResponse struct {
+	Code  errs.ErrCode `json:"code,omitempty"`
+	Error string       `json:"error"`
+}
+
+// Health reports the worker's state.
+type Health struct {
+	// Loaded reports whether the model is in GPU memory; the first request
+	// after an idle unload takes longer
+	Loaded bool `json:"loaded"`
+
+	// ContextRequested and ContextActual are the configured context window
+	// and the one the model runs with, smaller when GPU memory ran short
+	ContextRequested int `json:"context_requested"`
+	ContextActual    int `json:"context_actual"`
+}
+
+// codeErrors maps error codes to the llamaruntime errors they stand for,
+// so callers can test a worker's errors as they would a local client's.
+var codeErrors = map[errs.ErrCode]error{
+	errs.CodeInference:      llamaruntime.ErrInferenceFailed,
+	errs.CodeTimeout:        llamaruntime.ErrTimeout,
+	errs.CodeModelMissing:   llamaruntime.ErrModelLoadFailed,
+	errs.CodeOutOfVRAM:      llamaruntime.ErrInsufficientVRAM,
+	errs.CodeGPUUnavailable: llamaruntime.ErrGPUNotAvailable,
+	errs.CodeInvalidImage:   llamaruntime.ErrInvalidImage,
+}
+
+// errorFromResponse rebuilds the error a worker reported.
+func errorFromResponse(resp ErrorResponse) error {
+	if sentinel, ok := codeErrors[resp.Code]; ok {
+		return fmt.Errorf("llamaworker: %w (%s)", sentinel, resp.Error)
+	}
+	if resp.Code != "" {
+		return fmt.Errorf("llamaworker: %w", errs.New(resp.Code, resp.Error))
+	}
+	return errors.New("llamaworker: " + resp.Error)
+}
@@ -0,0 +1,173 @@
+package llamaworker
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go_backend/core/errs"
+	"go_backend/llamaruntime"
+
+	"go.uber.org/zap"
+)
+
+// maxRequestBytes bounds a request body; vision requests carry the image.
+const maxRequestBytes = 64 << 20
+
+// Model runs inference. Implemented by *llamaruntime.Client.
+type Model interface {
+	Generate(ctx context.Context, prompt string, params llamaruntime.GenerationParams) (string, error)
+	InferVision(ctx context.Context, params llamaruntime.VisionParams) (*llamaruntime.InferenceResult, error)
+	Warmup(ctx context.Context) error
+	IsLoaded() bool
+	ContextDowngrade() (requested, actual int)
+}
+
+// Server is an organism that serves a Model over HTTP. It is a handler for
+// the PathPrefix paths; the gpu-worker mounts it next to the sd-worker
+// server on one listener.
+//
+// Usage:
+//
+//	mux.Handle(llamaworker.PathPrefix, llamaworker.NewServer(cfg, client, logger))
+type Server struct {
+	config Config
+	model  Model
+	logger *zap.Logger
+}
+
+// NewServer creates a server for model.
+func NewServer(config Config, model Model, logger *zap.Logger) *Server {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Server{config: config, model: model, logger: logger}
+}
+
+// ServeHTTP routes protocol requests.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, ErrorResponse{Error: "invalid or missing token"})
+		return
+	}
+	switch r.URL.Path {
+	case PathHealth:
+		requested, actual := s.model.ContextDowngrade()
+		writeJSON(w, Health{Loaded: s.model.IsLoaded(), ContextRequested: requested, ContextActual: actual})
+		return
+	case PathGenerate, PathVision, PathWarmup:
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "use POST"})
+		return
+	}
+
+	switch r.URL.Path {
+	case PathGenerate:
+		s.handleGenerate(w, r)
+	case PathVision:
+		s.handleVision(w, r)
+	case PathWarmup:
+		if err := s.model.Warmup(r.Context()); err != nil {
+			s.fail(w, r, "Warmup failed", err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleGenerate runs one text completion.
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	var req GenerateRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	start := time.Now()
+	text, err := s.model.Generate(r.Context(), req.Prompt, req.Params())
+	if err != nil {
+		s.fail(w, r, "Text generation failed", err)
+		return
+	}
+	s.logger.Debug("Text generated",
+		zap.Int("prompt_length", len(req.Prompt)),
+		zap.Int("text_length", len(text)),
+		zap.Duration("duration", time.Since(start)))
+	writeJSON(w, GenerateResponse{Text: text})
+}
+
+// handleVision describes one image.
+func (s *Server) handleVision(w http.ResponseWriter, r *http.Request) {
+	var req VisionRequest
+	if !decode(w, r, &req) {
+		return
+	}
+	result, err := s.model.InferVision(r.Context(), req.Params())
+	if err != nil {
+		s.fail(w, r, "Vision inference failed", err)
+		return
+	}
+	s.logger.Debug("Image described",
+		zap.Int("image_bytes", len(req.Image)),
+		zap.Int("tokens", result.TokensGenerated),
+		zap.Duration("duration", result.Duration))
+	writeJSON(w, responseFromResult(result))
+}
+
+// fail logs err and answers with its code.
+func (s *Server) fail(w http.ResponseWriter, r *http.Request, msg string, err error) {
+	s.logger.Warn(msg, zap.String("path", r.URL.Path), zap.Error(err))
+	code := errs.CodeOr(err, errs.CodeInference)
+	writeError(w, statusOf(code), ErrorResponse{Code: code, Error: err.Error()})
+}
+
+// authorized checks the bearer token if one is configured.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.config.Token == "" {
+		return true
+	}
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.config.Token)) == 1
+}
+
+// decode reads the JSON request body into v, answering with an error if
+// it cannot.
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBytes)).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Code: errs.CodeInvalidPrompt, Error: "invalid request: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// statusOf returns the HTTP status reporting an error with code.
+func statusOf(code errs.ErrCode) int {
+	switch code {
+	case errs.CodeInvalidPrompt, errs.CodeInvalidImage:
+		return http.StatusBadRequest
+	case errs.CodeTimeout:
+		return http.StatusGatewayTimeout
+	case errs.CodeOutOfVRAM, errs.CodeGPUUnavailable, errs.CodeModelMissing:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// writeJSON answers with v as JSON.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with resp as JSON.
+func writeError(w http.ResponseWriter, status int, resp ErrorResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,167 @@
+package llamaworker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go_backend/core/errs"
+	"go_backend/llamaruntime"
+)
+
+// fakeModel is a Model that returns fixed results.
+type fakeModel struct {
+	text   string
+	err    error
+	prompt string
+	params llamaruntime.GenerationParams
+	vision llamaruntime.VisionParams
+	warmed bool
+}
+
+func (m *fakeModel) Generate(ctx context.Context, prompt string, params llamaruntime.GenerationParams) (string, error) {
+	m.prompt, m.params = prompt, params
+	return m.text, m.err
+}
+
+func (m *fakeModel) InferVision(ctx context.Context, params llamaruntime.VisionParams) (*llamaruntime.InferenceResult, error) {
+	m.vision = params
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &llamaruntime.InferenceResult{Text: m.text, TokensGenerated: 12, Duration: 1500 * time.Millisecond, StopReason: llamaruntime.StopReasonEOS}, nil
+}
+
+func (m *fakeModel) Warmup(ctx context.Context) error {
+	m.warmed = true
+	return m.err
+}
+
+func (m *fakeModel) IsLoaded() bool                            { return true }
+func (m *fakeModel) ContextDowngrade() (requested, actual int) { return 8192, 4096 }
+
+// newTestWorker serves model and returns a client for it.
+func newTestWorker(t *testing.T, model Model, serverToken, clientToken string) *Client {
+	t.Helper()
+	server := httptest.NewServer(NewServer(Config{Token: serverToken}, model, nil))
+	t.Cleanup(server.Close)
+	return NewClient(Config{URL: server.URL, Token: clientToken})
+}
+
+func TestClientGeneratesOnWorker(t *testing.T) {
+	model := &fakeModel{text: "A lighthouse at dusk."}
+	client := newTestWorker(t, model, "secret", "secret")
+
+	system := "Answer briefly."
+	params := llamaruntime.GenerationParams{
+		MaxTokens: 256, Temperature: 0.3, SystemPrompt: &system,
+		StopSequences: []string{"\n\n"}, Timeout: 30 * time.Second,
+	}
+	text, err := client.Generate(context.Background(), "Describe a lighthouse", params)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if text != "A lighthouse at dusk." || model.prompt != "Describe a lighthouse" {
+		t.Errorf("text = %q, worker prompt = %q", text, model.prompt)
+	}
+	if !reflect.DeepEqual(model.params, params) {
+		t.Errorf("worker got %+v, want %+v", model.params, params)
+	}
+
+	if err := client.Warmup(context.Background()); err != nil || !model.warmed {
+		t.Errorf("Warmup = %v, warmed = %v", err, model.warmed)
+	}
+	if !client.IsLoaded() {
+		t.Error("IsLoaded = false for a loaded worker")
+	}
+	if requested, actual := client.ContextDowngrade(); requested != 8192 || actual != 4096 {
+		t.Errorf("ContextDowngrade = %d, %d", requested, actual)
+	}
+}
+
+func TestClientSendsImageFile(t *testing.T) {
+	model := &fakeModel{text: "a cat"}
+	client := newTestWorker(t, model, "", "")
+	path := filepath.Join(t.TempDir(), "cat.png")
+	if err := os.WriteFile(path, []byte("\x89PNG"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := client.InferVision(context.Background(), llamaruntime.VisionParams{ImagePath: path, Prompt: "What is this?", MaxTokens: 64})
+	if err != nil {
+		t.Fatalf("InferVision: %v", err)
+	}
+	if string(model.vision.ImageData) != "\x89PNG" || model.vision.ImagePath != "" || model.vision.Prompt != "What is this?" {
+		t.Errorf("worker got %+v", model.vision)
+	}
+	if result.Text != "a cat" || result.TokensGenerated != 12 || result.Duration != 1500*time.Millisecond {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestClientKeepsWorkerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		is   error
+		code errs.ErrCode
+	}{
+		{"timeout", fmt.Errorf("infer: %w", llamaruntime.ErrTimeout), llamaruntime.ErrTimeout, errs.CodeTimeout},
+		{"out of VRAM", llamaruntime.ErrInsufficientVRAM, llamaruntime.ErrInsufficientVRAM, errs.CodeOutOfVRAM},
+		{"invalid image", llamaruntime.ErrInvalidImage, llamaruntime.ErrInvalidImage, errs.CodeInvalidImage},
+		{"uncoded", errors.New("abort"), llamaruntime.ErrInferenceFailed, errs.CodeInference},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestWorker(t, &fakeModel{err: tt.err}, "", "")
+			_, err := client.Generate(context.Background(), "hello", llamaruntime.GenerationParams{})
+			if err == nil {
+				t.Fatal("Generate succeeded")
+			}
+			if !errors.Is(err, tt.is) {
+				t.Errorf("err = %v, want %v", err, tt.is)
+			}
+			if code := errs.CodeOf(err); code != tt.code {
+				t.Errorf("code = %s, want %s", code, tt.code)
+			}
+		})
+	}
+}
+
+func TestServerChecksToken(t *testing.T) {
+	client := newTestWorker(t, &fakeModel{text: "hi"}, "secret", "wrong")
+	_, err := client.Generate(context.Background(), "hello", llamaruntime.GenerationParams{})
+	if err == nil || !strings.Contains(err.Error(), "token") {
+		t.Errorf("Generate = %v, want a token error", err)
+	}
+	if client.IsLoaded() {
+		t.Error("IsLoaded = true without access to the worker")
+	}
+}
+
+func TestServerRoutes(t *testing.T) {
+	server := NewServer(Config{}, &fakeModel{}, nil)
+	tests := []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, PathGenerate, http.StatusMethodNotAllowed},
+		{http.MethodPost, PathPrefix + "chat", http.StatusNotFound},
+		{http.MethodPost, PathVision, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader("{")))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.status)
+		}
+	}
+}
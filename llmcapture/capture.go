@@ -0,0 +1,77 @@
+// Package llmcapture provides the capture mode that keeps full LLM prompts
+// and raw model responses, local and cloud, for a bounded time window, to
+// troubleshoot bad answers such as malformed intent JSON. This file
+// contains the Capture type and the task context that links captures to
+// the widget that caused them.
+package llmcapture
+
+import (
+	"context"
+	"time"
+)
+
+// Backend says where a captured request was answered.
+type Backend string
+
+// Backends.
+const (
+	BackendLocal Backend = "local"
+	BackendCloud Backend = "cloud"
+)
+
+// Capture is one LLM request and its raw response.
+type Capture struct {
+	ID            int64     `json:"id"`
+	Time          time.Time `json:"time"`
+	CanvasID      string    `json:"canvas_id"`
+	CorrelationID string    `json:"correlation_id"`
+	WidgetID      string    `json:"widget_id"`
+	// Operation is the task type, as in processing_history (e.g. "pdf_analysis")
+	Operation string  `json:"operation"`
+	Backend   Backend `json:"backend"`
+	Model     string  `json:"model"`
+	// Endpoint is the API path of cloud requests
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Request is the prompt as sent: the request JSON of cloud requests,
+	// the prompt after the chat template of local ones
+	Request string `json:"request"`
+	// Response is the raw answer: the response JSON of cloud requests, the
+	// generated text of local ones
+	Response   string `json:"response"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	// Redacted is true if personal data was masked before storing
+	Redacted bool `json:"redacted"`
+}
+
+// Query selects captures. Empty fields match everything.
+type Query struct {
+	CanvasID      string
+	CorrelationID string
+	Backend       Backend
+	// Limit is the most captures returned, newest first (0 = 100)
+	Limit int
+}
+
+// Task identifies the AI task an LLM request belongs to.
+type Task struct {
+	CanvasID      string
+	CorrelationID string
+	WidgetID      string
+	Operation     string
+}
+
+type taskKey struct{}
+
+// WithTask returns a context that attributes LLM requests made with it to
+// task.
+func WithTask(ctx context.Context, task Task) context.Context {
+	return context.WithValue(ctx, taskKey{}, task)
+}
+
+// TaskFrom returns the task set by WithTask, or an empty Task.
+func TaskFrom(ctx context.Context) Task {
+	task, _ := ctx.Value(taskKey{}).(Task)
+	return task
+}
@@ -0,0 +1,150 @@
+package llmcapture
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// memStorage keeps captures in memory
+type memStorage struct {
+	captures []Capture
+}
+
+func (m *memStorage) InsertLLMCapture(ctx context.Context, c Capture) (int64, error) {
+	c.ID = int64(len(m.captures) + 1)
+	m.captures = append(m.captures, c)
+	return c.ID, nil
+}
+
+func (m *memStorage) ListLLMCaptures(ctx context.Context, q Query) ([]Capture, error) {
+	var out []Capture
+	for i := len(m.captures) - 1; i >= 0 && len(out) < q.Limit; i-- {
+		if q.CorrelationID == "" || m.captures[i].CorrelationID == q.CorrelationID {
+			out = append(out, m.captures[i])
+		}
+	}
+	return out, nil
+}
+
+func (m *memStorage) DeleteLLMCaptures(ctx context.Context, before time.Time) (int64, error) {
+	var kept []Capture
+	for _, c := range m.captures {
+		if !c.Time.Before(before) {
+			kept = append(kept, c)
+		}
+	}
+	deleted := int64(len(m.captures) - len(kept))
+	m.captures = kept
+	return deleted, nil
+}
+
+func TestRecorder(t *testing.T) {
+	storage := &memStorage{}
+	mask := func(ctx context.Context, text string) (string, error) {
+		return strings.ReplaceAll(text, "ann@example.com", "[EMAIL_1]"), nil
+	}
+	r := NewRecorder(storage, mask)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	r.clock = fake
+	ctx := WithTask(context.Background(), Task{CanvasID: "c1", CorrelationID: "abc", Operation: "text_generation"})
+
+	// Nothing is stored while capture is off
+	r.Record(ctx, Capture{Request: "off"})
+	if len(storage.captures) != 0 {
+		t.Fatal("captured while off")
+	}
+
+	status, err := r.Start(ctx, 10*time.Hour, true)
+	if err != nil || !status.Active || !status.Until.Equal(now.Add(MaxWindow)) || !status.Redact {
+		t.Fatalf("Start() = %+v, %v", status, err)
+	}
+	if err := r.Record(ctx, Capture{Backend: BackendLocal, Request: "mail ann@example.com", Response: strings.Repeat("x", MaxTextBytes+1)}); err != nil {
+		t.Fatal(err)
+	}
+	got := storage.captures[0]
+	if got.CanvasID != "c1" || got.CorrelationID != "abc" || got.Operation != "text_generation" {
+		t.Errorf("task not applied: %+v", got)
+	}
+	if got.Request != "mail [EMAIL_1]" || !got.Redacted {
+		t.Errorf("request = %q, want redacted", got.Request)
+	}
+	if !strings.HasSuffix(got.Response, "...(truncated)") {
+		t.Error("response not truncated")
+	}
+
+	// The window closes by itself, and old captures are pruned on start
+	fake.Advance(MaxWindow)
+	if r.Active() {
+		t.Error("window did not close")
+	}
+	fake.Advance(Retention)
+	if _, err := r.Start(ctx, 0, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(storage.captures) != 0 {
+		t.Errorf("old captures kept: %d", len(storage.captures))
+	}
+	if status, _ := r.Stop(ctx); status.Active {
+		t.Error("Stop() left capture on")
+	}
+
+	if _, err := NewRecorder(storage, nil).Start(ctx, 0, true); err != ErrNoRedactor {
+		t.Errorf("Start(redact) without redactor = %v, want ErrNoRedactor", err)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), "hello") {
+			t.Errorf("server got %q", body)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"choices":[{"message":{"content":"{\"type\":\"text\""}}]}`)
+	}))
+	defer server.Close()
+
+	storage := &memStorage{}
+	r := NewRecorder(storage, nil)
+	SetDefault(r)
+	defer SetDefault(nil)
+	client := &http.Client{Transport: WrapTransport(nil)}
+	post := func(path string) string {
+		ctx := WithTask(context.Background(), Task{CorrelationID: "abc"})
+		req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+path, strings.NewReader(`{"model":"gpt-4o","messages":[{"content":"hello"}]}`))
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	post("/v1/chat/completions")
+	if len(storage.captures) != 0 {
+		t.Fatal("captured while off")
+	}
+
+	r.Start(context.Background(), time.Minute, false)
+	body := post("/v1/chat/completions")
+	post("/v1/images/generations")
+	if len(storage.captures) != 1 {
+		t.Fatalf("captures = %d, want only the completion", len(storage.captures))
+	}
+	got := storage.captures[0]
+	if got.Backend != BackendCloud || got.Model != "gpt-4o" || got.CorrelationID != "abc" || got.Endpoint != "/v1/chat/completions" {
+		t.Errorf("unexpected capture: %+v", got)
+	}
+	if got.Response != body || !strings.Contains(got.Request, "hello") {
+		t.Errorf("capture = %q / %q, client got %q", got.Request, got.Response, body)
+	}
+}
@@ -0,0 +1,236 @@
+// Package llmcapture provides the capture mode for LLM troubleshooting.
+// This file contains the Recorder organism, which stores captures while a
+// capture window is open.
+package llmcapture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// Capture window and storage limits.
+const (
+	// DefaultWindow is how long capture runs when no duration is given
+	DefaultWindow = 30 * time.Minute
+	// MaxWindow is the longest capture runs at once, so a forgotten
+	// capture does not keep storing prompts
+	MaxWindow = 4 * time.Hour
+	// MaxTextBytes is the most of a request or response that is stored
+	MaxTextBytes = 64 << 10
+	// Retention is how long captures are kept; older ones are deleted
+	// when a capture window starts or stops
+	Retention = 7 * 24 * time.Hour
+)
+
+// ErrNoRedactor is returned when redaction is requested but no redactor
+// is set.
+var ErrNoRedactor = errors.New("llmcapture: redaction is unavailable")
+
+// Storage persists captures. Implemented by db.Repository.
+type Storage interface {
+	// InsertLLMCapture stores c and returns its ID.
+	InsertLLMCapture(ctx context.Context, c Capture) (int64, error)
+	// ListLLMCaptures returns the captures matching q, newest first.
+	ListLLMCaptures(ctx context.Context, q Query) ([]Capture, error)
+	// DeleteLLMCaptures deletes captures taken before t and returns how
+	// many were deleted.
+	DeleteLLMCaptures(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RedactFunc masks personal data in text.
+type RedactFunc func(ctx context.Context, text string) (string, error)
+
+// Status describes the capture window.
+type Status struct {
+	Active bool       `json:"active"`
+	Until  *time.Time `json:"until,omitempty"`
+	// Redact is true if personal data is masked before captures are stored
+	Redact bool `json:"redact"`
+	// RedactAvailable is true if redaction can be turned on
+	RedactAvailable bool `json:"redact_available"`
+}
+
+// Recorder stores captures while a capture window is open. Capture is off
+// until Start is called.
+//
+// Thread-Safety: Recorder is safe for concurrent use.
+type Recorder struct {
+	storage  Storage
+	redactor RedactFunc
+	clock    clock.Clock
+
+	mu     sync.RWMutex
+	until  time.Time
+	redact bool
+}
+
+// NewRecorder creates a Recorder backed by storage. redactor masks
+// personal data when a window is started with redaction; it may be nil.
+func NewRecorder(storage Storage, redactor RedactFunc) *Recorder {
+	return &Recorder{storage: storage, redactor: redactor, clock: clock.Real()}
+}
+
+// Start opens a capture window of d (DefaultWindow if d <= 0, at most
+// MaxWindow). With redact, personal data is masked before storing.
+// Captures older than Retention are deleted.
+func (r *Recorder) Start(ctx context.Context, d time.Duration, redact bool) (Status, error) {
+	if redact && r.redactor == nil {
+		return r.Status(), ErrNoRedactor
+	}
+	if d <= 0 {
+		d = DefaultWindow
+	}
+	if d > MaxWindow {
+		d = MaxWindow
+	}
+
+	r.mu.Lock()
+	r.until = r.clock.Now().Add(d)
+	r.redact = redact
+	r.mu.Unlock()
+
+	return r.Status(), r.prune(ctx)
+}
+
+// Stop closes the capture window. Captures older than Retention are
+// deleted.
+func (r *Recorder) Stop(ctx context.Context) (Status, error) {
+	r.mu.Lock()
+	r.until = time.Time{}
+	r.mu.Unlock()
+	return r.Status(), r.prune(ctx)
+}
+
+// Active reports whether requests are captured now.
+func (r *Recorder) Active() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.clock.Now().Before(r.until)
+}
+
+// Status returns the state of the capture window.
+func (r *Recorder) Status() Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	status := Status{RedactAvailable: r.redactor != nil}
+	if r.clock.Now().Before(r.until) {
+		until := r.until
+		status.Active = true
+		status.Until = &until
+		status.Redact = r.redact
+	}
+	return status
+}
+
+// Record stores c if a capture window is open; otherwise it does nothing.
+// The task of ctx fills in the fields c leaves empty. Request and response
+// are cut to MaxTextBytes.
+func (r *Recorder) Record(ctx context.Context, c Capture) error {
+	r.mu.RLock()
+	active := r.clock.Now().Before(r.until)
+	redact := r.redact
+	r.mu.RUnlock()
+	if !active {
+		return nil
+	}
+
+	task := TaskFrom(ctx)
+	if c.CanvasID == "" {
+		c.CanvasID = task.CanvasID
+	}
+	if c.CorrelationID == "" {
+		c.CorrelationID = task.CorrelationID
+	}
+	if c.WidgetID == "" {
+		c.WidgetID = task.WidgetID
+	}
+	if c.Operation == "" {
+		c.Operation = task.Operation
+	}
+	c.Time = r.clock.Now().UTC()
+
+	// A cancelled task still gets its capture stored
+	ctx = context.WithoutCancel(ctx)
+	if redact {
+		var err error
+		if c.Request, err = r.redactor(ctx, c.Request); err != nil {
+			return fmt.Errorf("llmcapture: redact request: %w", err)
+		}
+		if c.Response, err = r.redactor(ctx, c.Response); err != nil {
+			return fmt.Errorf("llmcapture: redact response: %w", err)
+		}
+		c.Redacted = true
+	}
+	c.Request = truncate(c.Request)
+	c.Response = truncate(c.Response)
+
+	if _, err := r.storage.InsertLLMCapture(ctx, c); err != nil {
+		return fmt.Errorf("llmcapture: %w", err)
+	}
+	return nil
+}
+
+// List returns the stored captures matching q, newest first.
+func (r *Recorder) List(ctx context.Context, q Query) ([]Capture, error) {
+	if q.Limit <= 0 {
+		q.Limit = 100
+	}
+	return r.storage.ListLLMCaptures(ctx, q)
+}
+
+// Clear deletes every stored capture.
+func (r *Recorder) Clear(ctx context.Context) (int64, error) {
+	return r.storage.DeleteLLMCaptures(ctx, r.clock.Now().Add(time.Second))
+}
+
+// prune deletes captures older than Retention.
+func (r *Recorder) prune(ctx context.Context) error {
+	if _, err := r.storage.DeleteLLMCaptures(ctx, r.clock.Now().Add(-Retention)); err != nil {
+		return fmt.Errorf("llmcapture: prune: %w", err)
+	}
+	return nil
+}
+
+// truncate cuts s to MaxTextBytes.
+func truncate(s string) string {
+	if len(s) <= MaxTextBytes {
+		return s
+	}
+	return s[:MaxTextBytes] + "...(truncated)"
+}
+
+// defaultRecorder receives the captures of Record and the transports of
+// WrapTransport.
+var defaultRecorder atomic.Pointer[Recorder]
+
+// SetDefault makes r the recorder LLM clients capture to. A nil r turns
+// capturing off.
+func SetDefault(r *Recorder) {
+	defaultRecorder.Store(r)
+}
+
+// Default returns the recorder set by SetDefault, or nil.
+func Default() *Recorder {
+	return defaultRecorder.Load()
+}
+
+// Active reports whether the default recorder captures requests now.
+func Active() bool {
+	r := Default()
+	return r != nil && r.Active()
+}
+
+// Record stores c with the default recorder, if one is set and capturing.
+func Record(ctx context.Context, c Capture) error {
+	r := Default()
+	if r == nil {
+		return nil
+	}
+	return r.Record(ctx, c)
+}
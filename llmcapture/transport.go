@@ -0,0 +1,106 @@
+// Package llmcapture provides the capture mode for LLM troubleshooting.
+// This file contains the HTTP transport that captures requests to
+// OpenAI-compatible completion endpoints.
+package llmcapture
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// streamedResponse is stored instead of streamed responses, which are
+// passed through untouched.
+const streamedResponse = "(streamed response not captured)"
+
+// capturedPaths are the API paths whose requests are captured.
+var capturedPaths = []string{"/chat/completions", "/completions"}
+
+// transport captures completion requests to the default recorder.
+type transport struct {
+	base http.RoundTripper
+}
+
+// WrapTransport returns a RoundTripper that sends requests through base
+// (http.DefaultTransport if nil) and, while the default recorder captures,
+// stores completion requests and their responses.
+func WrapTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorder := Default()
+	if recorder == nil || !recorder.Active() || req.Body == nil || !isCompletionPath(req.URL.Path) {
+		return t.base.RoundTrip(req)
+	}
+
+	reqBody, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(reqBody)), nil
+	}
+
+	capture := Capture{
+		Backend:  BackendCloud,
+		Model:    modelOf(reqBody),
+		Endpoint: req.URL.Path,
+		Request:  string(reqBody),
+	}
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	capture.DurationMS = time.Since(start).Milliseconds()
+
+	switch {
+	case err != nil:
+		capture.Error = err.Error()
+	case strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream"):
+		capture.Response = streamedResponse
+	default:
+		respBody, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(respBody))
+		capture.Response = string(respBody)
+		if readErr != nil {
+			capture.Error = readErr.Error()
+		} else if resp.StatusCode >= 400 {
+			capture.Error = resp.Status
+		}
+	}
+
+	// A failed capture must not fail the request
+	_ = recorder.Record(req.Context(), capture)
+	return resp, err
+}
+
+// isCompletionPath reports whether path is a completion endpoint.
+func isCompletionPath(path string) bool {
+	for _, suffix := range capturedPaths {
+		if strings.HasSuffix(path, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// modelOf returns the model named in a request body, or "".
+func modelOf(body []byte) string {
+	var req struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return ""
+	}
+	return req.Model
+}
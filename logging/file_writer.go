@@ -1,8 +1,14 @@
 package logging
 
 import (
+	"os"
+	"sync"
+	"time"
+
 	"go.uber.org/zap/zapcore"
 	"gopkg.in/natefinch/lumberjack.v2"
+
+	"go_backend/core/clock"
 )
 
 // Default file writer configuration values
@@ -43,6 +49,11 @@ type FileWriterConfig struct {
 	// LocalTime determines if the timestamps in backup file names use local time.
 	// Default: false (uses UTC)
 	LocalTime bool
+
+	// RotateInterval additionally rotates the log file when a write falls
+	// into a new interval, e.g. every UTC day for 24h, whatever its size.
+	// Default: 0 (rotate by size only)
+	RotateInterval time.Duration
 }
 
 // DefaultFileWriterConfig returns a FileWriterConfig with default values.
@@ -99,10 +110,49 @@ func NewFileWriterWithConfig(path string, config FileWriterConfig) zapcore.Write
 		Compress:   cfg.Compress,
 		LocalTime:  cfg.LocalTime,
 	}
+	if cfg.RotateInterval > 0 {
+		return zapcore.AddSync(newIntervalWriter(logger, cfg.RotateInterval, clock.Real()))
+	}
 
 	return zapcore.AddSync(logger)
 }
 
+// intervalWriter rotates a lumberjack.Logger when a write falls into a
+// different interval than the previous one. Intervals are aligned to the
+// zero time, so a 24h interval rotates at UTC midnight.
+type intervalWriter struct {
+	mu       sync.Mutex
+	logger   *lumberjack.Logger
+	interval time.Duration
+	clock    clock.Clock
+	last     time.Time
+}
+
+// newIntervalWriter creates an intervalWriter. A log file left by an
+// earlier run counts as written at its modification time, so restarts do
+// not postpone rotation.
+func newIntervalWriter(logger *lumberjack.Logger, interval time.Duration, c clock.Clock) *intervalWriter {
+	last := c.Now()
+	if info, err := os.Stat(logger.Filename); err == nil && info.Size() > 0 {
+		last = info.ModTime()
+	}
+	return &intervalWriter{logger: logger, interval: interval, clock: c, last: last}
+}
+
+// Write rotates the file if needed, then writes p to it.
+func (w *intervalWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.clock.Now()
+	if !now.Truncate(w.interval).Equal(w.last.Truncate(w.interval)) {
+		// A failed rotation keeps writing to the current file
+		_ = w.logger.Rotate()
+	}
+	w.last = now
+	return w.logger.Write(p)
+}
+
 // applyFileWriterDefaults fills in zero values with defaults.
 // This is a pure function with no side effects.
 func applyFileWriterDefaults(config FileWriterConfig) FileWriterConfig {
@@ -4,6 +4,11 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"go_backend/core/clock"
 )
 
 func TestDefaultFileWriterConfig(t *testing.T) {
@@ -196,3 +201,30 @@ func TestApplyFileWriterDefaults(t *testing.T) {
 		})
 	}
 }
+
+func TestIntervalWriter(t *testing.T) {
+	logPath := filepath.Join(t.TempDir(), "app.log")
+	fake := clock.NewFake(time.Date(2026, 1, 1, 23, 0, 0, 0, time.UTC))
+	writer := newIntervalWriter(&lumberjack.Logger{Filename: logPath}, 24*time.Hour, fake)
+
+	writer.Write([]byte("day one\n"))
+	fake.Advance(30 * time.Minute)
+	writer.Write([]byte("day one, later\n"))
+	if files, _ := LogFiles(logPath); len(files) != 1 {
+		t.Fatalf("rotated within the interval: %+v", files)
+	}
+
+	fake.Advance(time.Hour)
+	writer.Write([]byte("day two\n"))
+	files, err := LogFiles(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || !files[0].Current {
+		t.Fatalf("files = %+v, want app.log and one backup", files)
+	}
+	content, _ := os.ReadFile(logPath)
+	if string(content) != "day two\n" {
+		t.Errorf("app.log = %q, want only the new day", content)
+	}
+}
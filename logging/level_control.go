@@ -0,0 +1,183 @@
+package logging
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+
+	"go_backend/core/clock"
+)
+
+// MaxBodyLogging is the longest request/response body logging can be
+// turned on for at once, so a forgotten toggle does not fill the disk.
+const MaxBodyLogging = time.Hour
+
+// LevelControl changes what a Logger writes while the application runs:
+// the level of all loggers, levels for named loggers such as "canvusapi",
+// and whether request and response bodies are logged for a while.
+// It is safe for concurrent use.
+type LevelControl struct {
+	mu       sync.RWMutex
+	level    zapcore.Level
+	packages map[string]zapcore.Level
+	// minLevel is the lowest of level and packages, for Enabled
+	minLevel  zapcore.Level
+	bodyUntil time.Time
+	clock     clock.Clock
+}
+
+// LevelState is a snapshot of a LevelControl.
+type LevelState struct {
+	Level    string            `json:"level"`
+	Packages map[string]string `json:"packages"`
+	// BodyLogging is true while request/response bodies are logged
+	BodyLogging      bool       `json:"body_logging"`
+	BodyLoggingUntil *time.Time `json:"body_logging_until,omitempty"`
+}
+
+// NewLevelControl creates a LevelControl that writes entries at level and
+// above.
+func NewLevelControl(level zapcore.Level) *LevelControl {
+	return &LevelControl{
+		level:    level,
+		packages: map[string]zapcore.Level{},
+		minLevel: level,
+		clock:    clock.Real(),
+	}
+}
+
+// SetLevel sets the level of loggers without a package level.
+func (c *LevelControl) SetLevel(level zapcore.Level) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.level = level
+	c.updateMinLevel()
+}
+
+// SetPackageLevel sets the level of the logger named name and the loggers
+// below it (e.g. "imagegen" also covers "imagegen.worker").
+func (c *LevelControl) SetPackageLevel(name string, level zapcore.Level) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.packages[name] = level
+	c.updateMinLevel()
+}
+
+// ClearPackageLevel makes the logger named name follow the global level
+// again.
+func (c *LevelControl) ClearPackageLevel(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.packages, name)
+	c.updateMinLevel()
+}
+
+// SetBodyLogging logs request and response bodies for d, capped at
+// MaxBodyLogging. A zero or negative d turns body logging off.
+func (c *LevelControl) SetBodyLogging(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if d <= 0 {
+		c.bodyUntil = time.Time{}
+		return
+	}
+	if d > MaxBodyLogging {
+		d = MaxBodyLogging
+	}
+	c.bodyUntil = c.clock.Now().Add(d)
+}
+
+// BodyLogging reports whether request and response bodies should be
+// logged now.
+func (c *LevelControl) BodyLogging() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.clock.Now().Before(c.bodyUntil)
+}
+
+// State returns the current settings.
+func (c *LevelControl) State() LevelState {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	state := LevelState{Level: c.level.String(), Packages: map[string]string{}}
+	for name, level := range c.packages {
+		state.Packages[name] = level.String()
+	}
+	if c.clock.Now().Before(c.bodyUntil) {
+		until := c.bodyUntil
+		state.BodyLogging = true
+		state.BodyLoggingUntil = &until
+	}
+	return state
+}
+
+// Enabled reports whether an entry at level from the logger named name is
+// written. The most specific package level applies.
+func (c *LevelControl) Enabled(name string, level zapcore.Level) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return level >= c.levelFor(name)
+}
+
+// levelFor returns the level of the logger named name. Callers hold mu.
+func (c *LevelControl) levelFor(name string) zapcore.Level {
+	best := ""
+	level := c.level
+	for pkg, pkgLevel := range c.packages {
+		if (name == pkg || strings.HasPrefix(name, pkg+".")) && len(pkg) > len(best) {
+			best, level = pkg, pkgLevel
+		}
+	}
+	return level
+}
+
+// updateMinLevel recomputes minLevel. Callers hold mu.
+func (c *LevelControl) updateMinLevel() {
+	c.minLevel = c.level
+	for _, level := range c.packages {
+		if level < c.minLevel {
+			c.minLevel = level
+		}
+	}
+}
+
+// anyEnabled reports whether some logger writes entries at level.
+func (c *LevelControl) anyEnabled(level zapcore.Level) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return level >= c.minLevel
+}
+
+// controlledCore filters entries of a core, built to accept every level,
+// through a LevelControl.
+type controlledCore struct {
+	zapcore.Core
+	control *LevelControl
+}
+
+// newControlledCore wraps core, which must accept debug entries.
+func newControlledCore(core zapcore.Core, control *LevelControl) zapcore.Core {
+	return &controlledCore{Core: core, control: control}
+}
+
+// Enabled reports whether any logger writes entries at level; Check
+// decides per logger name.
+func (c *controlledCore) Enabled(level zapcore.Level) bool {
+	return c.control.anyEnabled(level)
+}
+
+// With returns a child core that is filtered the same way.
+func (c *controlledCore) With(fields []zapcore.Field) zapcore.Core {
+	return &controlledCore{Core: c.Core.With(fields), control: c.control}
+}
+
+// Check adds the wrapped core if the entry's logger writes its level.
+func (c *controlledCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.control.Enabled(entry.LoggerName, entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
@@ -0,0 +1,76 @@
+package logging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"go_backend/core/clock"
+)
+
+func TestLevelControl(t *testing.T) {
+	var buf bytes.Buffer
+	control := NewLevelControl(zapcore.InfoLevel)
+	core := newControlledCore(NewMultiCoreWithWriters(zapcore.DebugLevel, zapcore.AddSync(&bytes.Buffer{}), zapcore.AddSync(&buf), false), control)
+	root := zap.New(core)
+	api := root.Named("canvusapi")
+	child := api.Named("upload").With(zap.String("k", "v"))
+
+	root.Debug("root debug")
+	api.Debug("api debug hidden")
+	control.SetPackageLevel("canvusapi", zapcore.DebugLevel)
+	root.Debug("root debug hidden")
+	api.Debug("api debug")
+	child.Debug("child debug")
+	root.Named("canvusapix").Debug("prefix is not a package")
+	control.SetPackageLevel("canvusapi.upload", zapcore.ErrorLevel)
+	child.Warn("child warn hidden")
+	control.ClearPackageLevel("canvusapi")
+	control.ClearPackageLevel("canvusapi.upload")
+	control.SetLevel(zapcore.WarnLevel)
+	root.Info("root info hidden")
+	root.Warn("root warn")
+
+	out := buf.String()
+	for _, msg := range []string{"api debug", "child debug", "root warn"} {
+		if !strings.Contains(out, `"`+msg+`"`) {
+			t.Errorf("missing %q in %s", msg, out)
+		}
+	}
+	for _, msg := range []string{"root debug", "hidden", "prefix is not a package"} {
+		if strings.Contains(out, msg) {
+			t.Errorf("unexpected %q in %s", msg, out)
+		}
+	}
+	if state := control.State(); state.Level != "warn" || len(state.Packages) != 0 {
+		t.Errorf("state = %+v", state)
+	}
+}
+
+func TestLevelControl_BodyLogging(t *testing.T) {
+	control := NewLevelControl(zapcore.InfoLevel)
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(now)
+	control.clock = fake
+
+	if control.BodyLogging() {
+		t.Fatal("body logging on by default")
+	}
+	control.SetBodyLogging(24 * time.Hour)
+	if state := control.State(); !state.BodyLogging || !state.BodyLoggingUntil.Equal(now.Add(MaxBodyLogging)) {
+		t.Errorf("state = %+v, want on until the cap", state)
+	}
+	fake.Advance(MaxBodyLogging)
+	if control.BodyLogging() {
+		t.Error("body logging did not expire")
+	}
+	control.SetBodyLogging(time.Minute)
+	control.SetBodyLogging(0)
+	if control.BodyLogging() {
+		t.Error("body logging not turned off")
+	}
+}
@@ -0,0 +1,130 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat is the timestamp lumberjack puts in rotated file names,
+// e.g. app-2026-01-02T15-04-05.000.log.gz.
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// FileWriterConfigFromEnv returns the default rotation configuration with
+// any of these environment variables applied:
+//
+//   - LOG_MAX_SIZE_MB: rotate when app.log reaches this size
+//   - LOG_MAX_BACKUPS: rotated files to keep
+//   - LOG_MAX_AGE_DAYS: days to keep rotated files
+//   - LOG_COMPRESS: gzip rotated files (true/false)
+//   - LOG_ROTATE_INTERVAL: also rotate on this schedule, e.g. 24h (0 = off)
+//
+// Invalid values are ignored, since the logger that would report them does
+// not exist yet.
+func FileWriterConfigFromEnv() FileWriterConfig {
+	config := DefaultFileWriterConfig()
+	if n, ok := positiveIntEnv("LOG_MAX_SIZE_MB"); ok {
+		config.MaxSizeMB = n
+	}
+	if n, ok := positiveIntEnv("LOG_MAX_BACKUPS"); ok {
+		config.MaxBackups = n
+	}
+	if n, ok := positiveIntEnv("LOG_MAX_AGE_DAYS"); ok {
+		config.MaxAgeDays = n
+	}
+	if b, err := strconv.ParseBool(os.Getenv("LOG_COMPRESS")); err == nil {
+		config.Compress = b
+	}
+	if d, err := time.ParseDuration(os.Getenv("LOG_ROTATE_INTERVAL")); err == nil && d >= 0 {
+		config.RotateInterval = d
+	}
+	return config
+}
+
+// positiveIntEnv parses a positive integer environment variable.
+func positiveIntEnv(key string) (int, bool) {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// LogFile describes the log file or one of its rotated backups.
+type LogFile struct {
+	Name     string    `json:"name"`
+	Path     string    `json:"-"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	// Current is true for the file being written to
+	Current bool `json:"current"`
+	// Compressed is true for gzipped backups
+	Compressed bool `json:"compressed"`
+	// rotated is when a backup was rotated out, from its name
+	rotated time.Time
+}
+
+// LogFiles returns the log file at path followed by its rotated backups,
+// newest first. Missing files are left out.
+func LogFiles(path string) ([]LogFile, error) {
+	dir := filepath.Dir(path)
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(filepath.Base(path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var current []LogFile
+	var backups []LogFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		file := LogFile{Name: name, Path: filepath.Join(dir, name)}
+
+		switch {
+		case name == filepath.Base(path):
+			file.Current = true
+		case strings.HasPrefix(name, prefix):
+			stamp := strings.TrimPrefix(name, prefix)
+			if strings.HasSuffix(stamp, ext+".gz") {
+				file.Compressed = true
+				stamp = strings.TrimSuffix(stamp, ext+".gz")
+			} else if strings.HasSuffix(stamp, ext) {
+				stamp = strings.TrimSuffix(stamp, ext)
+			} else {
+				continue
+			}
+			rotated, err := time.Parse(backupTimeFormat, stamp)
+			if err != nil {
+				continue
+			}
+			file.rotated = rotated
+		default:
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed by rotation in the meantime
+		}
+		file.Size = info.Size()
+		file.Modified = info.ModTime()
+		if file.Current {
+			current = append(current, file)
+		} else {
+			backups = append(backups, file)
+		}
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].rotated.After(backups[j].rotated)
+	})
+	return append(current, backups...), nil
+}
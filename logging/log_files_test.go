@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileWriterConfigFromEnv(t *testing.T) {
+	t.Setenv("LOG_MAX_SIZE_MB", "10")
+	t.Setenv("LOG_MAX_BACKUPS", "-1")
+	t.Setenv("LOG_COMPRESS", "false")
+	t.Setenv("LOG_ROTATE_INTERVAL", "24h")
+
+	config := FileWriterConfigFromEnv()
+	if config.MaxSizeMB != 10 || config.Compress || config.RotateInterval != 24*time.Hour {
+		t.Errorf("env not applied: %+v", config)
+	}
+	if config.MaxBackups != DefaultMaxBackups || config.MaxAgeDays != DefaultMaxAgeDays {
+		t.Errorf("invalid or unset values should keep defaults: %+v", config)
+	}
+}
+
+func TestLogFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"app.log",
+		"app-2026-01-01T00-00-00.000.log.gz",
+		"app-2026-01-03T00-00-00.000.log",
+		"app-2026-01-02T00-00-00.000.log.gz",
+		"app-notes.log",
+		"other-2026-01-04T00-00-00.000.log",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := LogFiles(filepath.Join(dir, "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"app.log",
+		"app-2026-01-03T00-00-00.000.log",
+		"app-2026-01-02T00-00-00.000.log.gz",
+		"app-2026-01-01T00-00-00.000.log.gz",
+	}
+	if len(files) != len(want) {
+		t.Fatalf("files = %+v, want %v", files, want)
+	}
+	for i, name := range want {
+		if files[i].Name != name {
+			t.Errorf("files[%d] = %s, want %s", i, files[i].Name, name)
+		}
+	}
+	if !files[0].Current || files[1].Compressed || !files[2].Compressed {
+		t.Errorf("flags wrong: %+v", files)
+	}
+}
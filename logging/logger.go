@@ -37,6 +37,9 @@ type Logger struct {
 
 	// logFilePath is the path to the log file
 	logFilePath string
+
+	// levels changes the log level at runtime
+	levels *LevelControl
 }
 
 // NewLogger creates a new Logger instance configured for the given environment.
@@ -74,10 +77,13 @@ func NewLogger(isDevelopment bool, logFilePath string) (*Logger, error) {
 
 	// Create multi-core that outputs to both console and file
 	// Uses FileWriter molecule internally for rotation
-	core, err := NewMultiCore(level, logFilePath, isDevelopment)
+	// The cores accept every level; levels filters at runtime
+	core, err := NewMultiCore(zapcore.DebugLevel, logFilePath, isDevelopment)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create log core: %w", err)
 	}
+	levels := NewLevelControl(level)
+	core = newControlledCore(core, levels)
 
 	// Build the zap logger with caller info
 	zapLogger := zap.New(core,
@@ -90,6 +96,7 @@ func NewLogger(isDevelopment bool, logFilePath string) (*Logger, error) {
 		sugar:         zapLogger.Sugar(),
 		isDevelopment: isDevelopment,
 		logFilePath:   logFilePath,
+		levels:        levels,
 	}, nil
 }
 
@@ -123,7 +130,9 @@ func NewLoggerWithConfig(isDevelopment bool, logFilePath string, fileConfig File
 	consoleWriter := zapcore.AddSync(&consoleWriterSync{})
 
 	// Create multi-core with custom writers
-	core := NewMultiCoreWithWriters(level, consoleWriter, fileWriter, isDevelopment)
+	// The cores accept every level; levels filters at runtime
+	levels := NewLevelControl(level)
+	core := newControlledCore(NewMultiCoreWithWriters(zapcore.DebugLevel, consoleWriter, fileWriter, isDevelopment), levels)
 
 	// Build the zap logger with caller info
 	zapLogger := zap.New(core,
@@ -136,6 +145,7 @@ func NewLoggerWithConfig(isDevelopment bool, logFilePath string, fileConfig File
 		sugar:         zapLogger.Sugar(),
 		isDevelopment: isDevelopment,
 		logFilePath:   logFilePath,
+		levels:        levels,
 	}, nil
 }
 
@@ -368,6 +378,7 @@ func (l *Logger) With(fields ...zap.Field) *Logger {
 		sugar:         l.sugar.With(l.redactFieldsToInterface(fields)...),
 		isDevelopment: l.isDevelopment,
 		logFilePath:   l.logFilePath,
+		levels:        l.levels,
 	}
 }
 
@@ -383,6 +394,7 @@ func (l *Logger) WithOptions(opts ...zap.Option) *Logger {
 		sugar:         newZap.Sugar(),
 		isDevelopment: l.isDevelopment,
 		logFilePath:   l.logFilePath,
+		levels:        l.levels,
 	}
 }
 
@@ -400,6 +412,7 @@ func (l *Logger) Named(name string) *Logger {
 		sugar:         newZap.Sugar(),
 		isDevelopment: l.isDevelopment,
 		logFilePath:   l.logFilePath,
+		levels:        l.levels,
 	}
 }
 
@@ -420,6 +433,12 @@ func (l *Logger) IsDevelopment() bool {
 	return l.isDevelopment
 }
 
+// Levels returns the control that changes the log level at runtime. It is
+// shared by the loggers derived from this one.
+func (l *Logger) Levels() *LevelControl {
+	return l.levels
+}
+
 // LogFilePath returns the path to the log file.
 func (l *Logger) LogFilePath() string {
 	return l.logFilePath
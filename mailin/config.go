@@ -0,0 +1,117 @@
+package mailin
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"go_backend/core"
+
+	"go.uber.org/zap"
+)
+
+// Defaults for the email-in gateway.
+const (
+	// DefaultIMAPPort is the IMAP over TLS port used when MAILIN_IMAP_PORT is unset.
+	DefaultIMAPPort = 993
+	// DefaultMailbox is the folder polled for new mail.
+	DefaultMailbox = "INBOX"
+	// DefaultPollInterval is how often the mailbox is checked.
+	DefaultPollInterval = 60 * time.Second
+	// DefaultMaxAttachmentMB bounds the size of an uploaded attachment.
+	DefaultMaxAttachmentMB = 20
+)
+
+// IMAPConfig holds the incoming mail server settings.
+type IMAPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// Mailbox is the folder polled for new mail (default: INBOX)
+	Mailbox string
+	// TLS connects with implicit TLS; turn off only for local test servers
+	TLS bool
+}
+
+// Config configures the Gateway.
+type Config struct {
+	// IMAP server polled for new mail; an empty Host disables the gateway
+	IMAP IMAPConfig
+
+	// PollInterval between mailbox checks (default: 60s)
+	PollInterval time.Duration
+
+	// AllowedSenders lists the addresses ("alice@example.com") and domains
+	// ("@example.com") mail is accepted from; empty accepts every sender
+	AllowedSenders []string
+
+	// MaxAttachmentBytes bounds the size of an uploaded attachment
+	MaxAttachmentBytes int64
+
+	// Reply sends the sender a confirmation, or the reason the email was
+	// not posted, through SMTP
+	Reply bool
+	SMTP  core.SMTPConfig
+
+	// Logger for diagnostic output (optional)
+	Logger *zap.Logger
+}
+
+// Enabled reports whether an IMAP server is configured.
+func (c Config) Enabled() bool {
+	return c.IMAP.Host != ""
+}
+
+// ConfigFromEnv loads the gateway configuration from environment variables:
+//   - MAILIN_IMAP_HOST, MAILIN_IMAP_PORT (default: 993), MAILIN_IMAP_USERNAME,
+//     MAILIN_IMAP_PASSWORD: the mailbox to poll
+//   - MAILIN_IMAP_MAILBOX: folder to poll (default: INBOX)
+//   - MAILIN_IMAP_TLS: connect with TLS (default: true)
+//   - MAILIN_POLL_INTERVAL: seconds between checks (default: 60)
+//   - MAILIN_ALLOWED_SENDERS: comma-separated addresses and @domains
+//   - MAILIN_MAX_ATTACHMENT_MB: largest attachment uploaded (default: 20)
+//   - MAILIN_REPLY: reply to senders (default: true, needs SMTP_HOST)
+//   - SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, SMTP_FROM
+func ConfigFromEnv() Config {
+	cfg := Config{
+		IMAP: IMAPConfig{
+			Host:     strings.TrimSpace(os.Getenv("MAILIN_IMAP_HOST")),
+			Port:     core.ParseIntEnv("MAILIN_IMAP_PORT", DefaultIMAPPort),
+			Username: os.Getenv("MAILIN_IMAP_USERNAME"),
+			Password: os.Getenv("MAILIN_IMAP_PASSWORD"),
+			Mailbox:  strings.TrimSpace(os.Getenv("MAILIN_IMAP_MAILBOX")),
+			TLS:      core.ParseBoolEnv("MAILIN_IMAP_TLS", true),
+		},
+		PollInterval:       core.ParseDurationEnv("MAILIN_POLL_INTERVAL", int(DefaultPollInterval/time.Second)),
+		MaxAttachmentBytes: int64(core.ParseIntEnv("MAILIN_MAX_ATTACHMENT_MB", DefaultMaxAttachmentMB)) << 20,
+		Reply:              core.ParseBoolEnv("MAILIN_REPLY", true),
+		SMTP:               core.SMTPConfigFromEnv(),
+	}
+	if cfg.IMAP.Mailbox == "" {
+		cfg.IMAP.Mailbox = DefaultMailbox
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = DefaultPollInterval
+	}
+	for _, part := range strings.Split(os.Getenv("MAILIN_ALLOWED_SENDERS"), ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			cfg.AllowedSenders = append(cfg.AllowedSenders, part)
+		}
+	}
+	return cfg
+}
+
+// Allowed reports whether mail from address is accepted.
+func (c Config) Allowed(address string) bool {
+	if len(c.AllowedSenders) == 0 {
+		return true
+	}
+	address = strings.ToLower(strings.TrimSpace(address))
+	for _, sender := range c.AllowedSenders {
+		if sender == address || (strings.HasPrefix(sender, "@") && strings.HasSuffix(address, sender)) {
+			return true
+		}
+	}
+	return false
+}
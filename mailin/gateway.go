@@ -0,0 +1,474 @@
+// Package mailin provides the email-in gateway, which bridges canvases with
+// ordinary office mail.
+//
+// Architecture:
+//   - IMAPMailbox (molecule): reads unseen mail from an IMAP folder
+//   - ParseMessage, ParseSubject (atoms): sender, body, attachments and the
+//     "canvas/zone" destination of an email
+//   - Gateway (organism): polls the mailbox and posts each email to its
+//     canvas as a summary note beside its uploaded attachments
+package mailin
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go_backend/core"
+	"go_backend/handlers"
+
+	"go.uber.org/zap"
+)
+
+// Errors returned for emails that cannot be posted.
+var (
+	ErrNoCanvas  = errors.New("mailin: no canvas to post to")
+	ErrNoZone    = errors.New("mailin: zone not found")
+	ErrForbidden = errors.New("mailin: sender not allowed")
+)
+
+// Layout of a posted email.
+const (
+	noteWidth     = 400.0
+	noteHeight    = 300.0
+	pdfHeight     = 520.0
+	videoHeight   = 270.0
+	itemGap       = 40.0
+	placementGap  = 200.0
+	summaryColor  = "#CFE2F3"
+	maxNoteRunes  = 600
+	maxEmailRunes = 6000
+)
+
+const summaryPrompt = `Summarize this email from %s in at most three short bullet points for a note on a shared canvas.
+Reply with the bullet points only.
+
+Subject: %s
+
+%s`
+
+// Client writes to a canvas. Implemented by canvusapi.Client.
+type Client interface {
+	GetCanvasInfo() (map[string]interface{}, error)
+	GetWidgets(subscribe bool) ([]map[string]interface{}, error)
+	CreateNote(payload map[string]interface{}) (map[string]interface{}, error)
+	CreateImage(filePath string, metadata map[string]interface{}) (map[string]interface{}, error)
+	CreatePDF(filePath string, metadata map[string]interface{}) (map[string]interface{}, error)
+	CreateVideo(filePath string, metadata map[string]interface{}) (map[string]interface{}, error)
+}
+
+// Mailbox provides incoming mail. Implemented by IMAPMailbox.
+type Mailbox interface {
+	Fetch(ctx context.Context) ([]Mail, error)
+	MarkSeen(ctx context.Context, uids []uint32) error
+}
+
+// Delivery describes an email posted to a canvas.
+type Delivery struct {
+	CanvasID string
+	Zone     string
+	NoteID   string
+	// Uploaded and Skipped list attachments by name
+	Uploaded []string
+	Skipped  []string
+}
+
+// Gateway is an organism that posts incoming email to canvases.
+//
+// Usage:
+//
+//	g := mailin.New(cfg, mailin.NewIMAPMailbox(cfg.IMAP), clientFor, canvasIDs, generate, tempDir)
+//	go g.Run(ctx)
+type Gateway struct {
+	config    Config
+	mailbox   Mailbox
+	clientFor func(canvasID string) Client
+	canvasIDs []string
+	generate  core.GenerateFunc
+	tempDir   string
+	logger    *zap.Logger
+
+	// sendMail delivers replies; replaced in tests
+	sendMail func(ctx context.Context, to []string, subject, body string) error
+}
+
+// New creates a Gateway. canvasIDs are the canvases mail can be posted to,
+// by ID or name; the first is used when the subject names none. Emails are
+// summarized with generate; when it is nil the note shows the start of the
+// email. Attachments are written under tempDir (default: the system
+// temporary directory) for upload.
+func New(config Config, mailbox Mailbox, clientFor func(canvasID string) Client, canvasIDs []string, generate core.GenerateFunc, tempDir string) *Gateway {
+	logger := config.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	if config.MaxAttachmentBytes <= 0 {
+		config.MaxAttachmentBytes = DefaultMaxAttachmentMB << 20
+	}
+	g := &Gateway{
+		config:    config,
+		mailbox:   mailbox,
+		clientFor: clientFor,
+		canvasIDs: canvasIDs,
+		generate:  generate,
+		tempDir:   tempDir,
+		logger:    logger,
+	}
+	g.sendMail = func(ctx context.Context, to []string, subject, body string) error {
+		return core.SendMail(ctx, g.config.SMTP, to, subject, body)
+	}
+	return g
+}
+
+// Run polls the mailbox until ctx is cancelled.
+func (g *Gateway) Run(ctx context.Context) {
+	g.logger.Info("Email gateway started",
+		zap.String("mailbox", g.config.IMAP.Mailbox),
+		zap.Duration("interval", g.config.PollInterval))
+
+	ticker := time.NewTicker(g.config.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := g.Poll(ctx); err != nil && ctx.Err() == nil {
+			g.logger.Warn("Email poll failed", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Poll posts the unseen mail and returns the number of emails posted. Every
+// fetched email is marked seen, including those that could not be posted,
+// so a bad email is not retried forever.
+func (g *Gateway) Poll(ctx context.Context) (int, error) {
+	mails, err := g.mailbox.Fetch(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	var seen []uint32
+	for _, m := range mails {
+		if ctx.Err() != nil {
+			break
+		}
+		seen = append(seen, m.UID)
+		msg, err := ParseMessage(m.Data)
+		if err != nil {
+			g.logger.Warn("Unreadable email skipped", zap.Uint32("uid", m.UID), zap.Error(err))
+			continue
+		}
+		delivery, err := g.Deliver(ctx, msg)
+		switch {
+		case errors.Is(err, ErrForbidden):
+			// No reply: it would only confirm the address to strangers
+			g.logger.Warn("Email from unknown sender ignored", zap.String("from", msg.From))
+		case err != nil:
+			g.logger.Warn("Email not posted", zap.String("from", msg.From), zap.String("subject", msg.Subject), zap.Error(err))
+			g.reply(ctx, msg, fmt.Sprintf("Your email could not be posted: %v\n\nUse a subject of the form \"canvas/zone\", for example \"%s/Sprint Board\".", strings.TrimPrefix(err.Error(), "mailin: "), g.exampleCanvas()))
+		default:
+			posted++
+			g.reply(ctx, msg, confirmation(delivery))
+		}
+	}
+
+	if err := g.mailbox.MarkSeen(ctx, seen); err != nil {
+		return posted, fmt.Errorf("mark emails seen: %w", err)
+	}
+	return posted, nil
+}
+
+// Deliver posts one email: a summary note and its attachments in a row,
+// inside the zone named by the subject or right of the canvas content.
+func (g *Gateway) Deliver(ctx context.Context, msg *Message) (*Delivery, error) {
+	if !g.config.Allowed(msg.From) {
+		return nil, ErrForbidden
+	}
+	canvasID, zone, err := g.resolve(ParseSubject(msg.Subject))
+	if err != nil {
+		return nil, err
+	}
+	client := g.clientFor(canvasID)
+	widgets, err := client.GetWidgets(false)
+	if err != nil {
+		return nil, fmt.Errorf("mailin: failed to fetch widgets: %w", err)
+	}
+	origin, err := placement(widgets, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &Delivery{CanvasID: canvasID, Zone: zone}
+	x := origin.X + noteWidth + itemGap
+	if len(msg.Attachments) > 0 {
+		dir, err := g.mkdirTemp()
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(dir)
+		for i, a := range msg.Attachments {
+			width, err := g.upload(client, dir, i, a, handlers.Location{X: x, Y: origin.Y})
+			if err != nil {
+				g.logger.Warn("Attachment not uploaded", zap.String("attachment", a.Name), zap.Error(err))
+				delivery.Skipped = append(delivery.Skipped, a.Name)
+				continue
+			}
+			delivery.Uploaded = append(delivery.Uploaded, a.Name)
+			x += width + itemGap
+		}
+	}
+
+	note, err := client.CreateNote(map[string]interface{}{
+		"title":            "Email from " + msg.Sender(),
+		"text":             g.noteText(ctx, msg, delivery),
+		"location":         handlers.LocationToMap(origin),
+		"size":             handlers.SizeToMap(handlers.NoteSize{Width: noteWidth, Height: noteHeight}),
+		"background_color": summaryColor,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("mailin: failed to create note: %w", err)
+	}
+	delivery.NoteID, _ = note["id"].(string)
+
+	g.logger.Info("Email posted",
+		zap.String("from", msg.From),
+		zap.String("canvas_id", canvasID),
+		zap.String("zone", zone),
+		zap.Int("attachments", len(delivery.Uploaded)))
+	return delivery, nil
+}
+
+// resolve finds the canvas and zone of a route. A subject without "/" names
+// a canvas, or failing that a zone on the default canvas.
+func (g *Gateway) resolve(route Route) (string, string, error) {
+	if len(g.canvasIDs) == 0 {
+		return "", "", ErrNoCanvas
+	}
+	if route.Canvas == "" {
+		return g.canvasIDs[0], route.Zone, nil
+	}
+	for _, id := range g.canvasIDs {
+		if id == route.Canvas || strings.EqualFold(g.canvasName(id), route.Canvas) {
+			return id, route.Zone, nil
+		}
+	}
+	if !route.Explicit {
+		return g.canvasIDs[0], route.Canvas, nil
+	}
+	return "", "", fmt.Errorf("%w: no canvas named %q", ErrNoCanvas, route.Canvas)
+}
+
+// canvasName returns the name of a canvas, or "" when it cannot be read.
+func (g *Gateway) canvasName(canvasID string) string {
+	info, err := g.clientFor(canvasID).GetCanvasInfo()
+	if err != nil {
+		g.logger.Debug("Canvas name unavailable", zap.String("canvas_id", canvasID), zap.Error(err))
+		return ""
+	}
+	return handlers.GetStringField(info, "name", "")
+}
+
+// exampleCanvas names the default canvas in replies.
+func (g *Gateway) exampleCanvas() string {
+	if len(g.canvasIDs) == 0 {
+		return "Canvas"
+	}
+	if name := g.canvasName(g.canvasIDs[0]); name != "" {
+		return name
+	}
+	return g.canvasIDs[0]
+}
+
+// placement returns where an email is posted: below the content of the
+// named zone, or right of everything on the canvas, level with the topmost
+// widget.
+func placement(widgets []map[string]interface{}, zone string) (handlers.Location, error) {
+	if zone == "" {
+		var loc handlers.Location
+		found := false
+		for _, w := range widgets {
+			if handlers.GetStringField(w, "widget_type", "") == "SharedCanvas" {
+				continue
+			}
+			b := handlers.WidgetBounds(w, nil)
+			if !found || b.X+b.Width+placementGap > loc.X {
+				loc.X = b.X + b.Width + placementGap
+			}
+			if !found || b.Y < loc.Y {
+				loc.Y = b.Y
+			}
+			found = true
+		}
+		return loc, nil
+	}
+
+	var area handlers.Rect
+	found := false
+	for _, w := range widgets {
+		if handlers.GetStringField(w, "widget_type", "") == "Anchor" &&
+			strings.EqualFold(handlers.GetStringField(w, "anchor_name", ""), zone) {
+			area, found = handlers.WidgetBounds(w, nil), true
+			break
+		}
+	}
+	if !found {
+		return handlers.Location{}, fmt.Errorf("%w: no anchor named %q", ErrNoZone, zone)
+	}
+
+	loc := handlers.Location{X: area.X + itemGap, Y: area.Y + itemGap}
+	for _, w := range widgets {
+		switch handlers.GetStringField(w, "widget_type", "") {
+		case "SharedCanvas", "Anchor":
+			continue
+		}
+		b := handlers.WidgetBounds(w, nil)
+		cx, cy := b.X+b.Width/2, b.Y+b.Height/2
+		if cx >= area.X && cx <= area.X+area.Width && cy >= area.Y && cy <= area.Y+area.Height && b.Y+b.Height+itemGap > loc.Y {
+			loc.Y = b.Y + b.Height + itemGap
+		}
+	}
+	return loc, nil
+}
+
+// noteText summarizes the email, falling back to its start, and lists the
+// attachments.
+func (g *Gateway) noteText(ctx context.Context, msg *Message, d *Delivery) string {
+	text := strings.TrimSpace(msg.Text)
+	summary := ""
+	if g.generate != nil && text != "" {
+		reply, err := g.generate(ctx, fmt.Sprintf(summaryPrompt, msg.Sender(), msg.Subject, truncateRunes(text, maxEmailRunes)))
+		if err != nil {
+			g.logger.Warn("Email summary failed, showing its start", zap.Error(err))
+		}
+		summary = strings.TrimSpace(reply)
+	}
+	if summary == "" {
+		summary = truncateRunes(text, maxNoteRunes)
+	}
+	if summary == "" {
+		summary = "(no message)"
+	}
+
+	var b strings.Builder
+	b.WriteString(summary)
+	b.WriteString("\n\nFrom: " + msg.From)
+	if len(d.Uploaded) > 0 {
+		b.WriteString("\nAttachments: " + strings.Join(d.Uploaded, ", "))
+	}
+	if len(d.Skipped) > 0 {
+		b.WriteString("\nNot uploaded: " + strings.Join(d.Skipped, ", "))
+	}
+	return b.String()
+}
+
+// upload creates a widget for an attachment at loc and returns its width.
+// Images, PDFs and videos are uploaded; other files are an error.
+func (g *Gateway) upload(client Client, dir string, index int, a Attachment, loc handlers.Location) (float64, error) {
+	if int64(len(a.Data)) > g.config.MaxAttachmentBytes {
+		return 0, fmt.Errorf("%d bytes is over the %d MB limit", len(a.Data), g.config.MaxAttachmentBytes>>20)
+	}
+	contentType := a.ContentType
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = mime.TypeByExtension(strings.ToLower(filepath.Ext(a.Name)))
+	}
+
+	size := handlers.NoteSize{Width: noteWidth}
+	var create func(string, map[string]interface{}) (map[string]interface{}, error)
+	switch {
+	case contentType == "image/png" || contentType == "image/jpeg" || contentType == "image/gif":
+		cfg, _, err := image.DecodeConfig(bytes.NewReader(a.Data))
+		if err != nil || cfg.Width == 0 {
+			return 0, fmt.Errorf("unreadable image: %v", err)
+		}
+		size.Height = noteWidth * float64(cfg.Height) / float64(cfg.Width)
+		create = client.CreateImage
+	case contentType == "application/pdf":
+		size.Height = pdfHeight
+		create = client.CreatePDF
+	case strings.HasPrefix(contentType, "video/"):
+		size = handlers.NoteSize{Width: videoHeight * 16 / 9, Height: videoHeight}
+		create = client.CreateVideo
+	default:
+		return 0, fmt.Errorf("unsupported attachment type %q", contentType)
+	}
+
+	// Prefix the index so attachments with the same name do not collide
+	path := filepath.Join(dir, fmt.Sprintf("%02d-%s", index, filepath.Base(a.Name)))
+	if err := os.WriteFile(path, a.Data, 0644); err != nil {
+		return 0, err
+	}
+	if _, err := create(path, map[string]interface{}{
+		"title":    a.Name,
+		"location": handlers.LocationToMap(loc),
+		"size":     handlers.SizeToMap(size),
+	}); err != nil {
+		return 0, err
+	}
+	return size.Width, nil
+}
+
+// mkdirTemp creates the directory attachments are uploaded from.
+func (g *Gateway) mkdirTemp() (string, error) {
+	if g.tempDir != "" {
+		if err := os.MkdirAll(g.tempDir, 0755); err != nil {
+			return "", fmt.Errorf("mailin: failed to create temp directory: %w", err)
+		}
+	}
+	dir, err := os.MkdirTemp(g.tempDir, "mailin-")
+	if err != nil {
+		return "", fmt.Errorf("mailin: failed to create temp directory: %w", err)
+	}
+	return dir, nil
+}
+
+// reply emails the sender when replies are enabled.
+func (g *Gateway) reply(ctx context.Context, msg *Message, body string) {
+	if !g.config.Reply || !g.config.SMTP.Enabled() || msg.From == "" {
+		return
+	}
+	subject := "Re: " + msg.Subject
+	if err := g.sendMail(ctx, []string{msg.From}, subject, body); err != nil {
+		g.logger.Warn("Email reply failed", zap.String("to", msg.From), zap.Error(err))
+	}
+}
+
+// confirmation describes a delivery to its sender.
+func confirmation(d *Delivery) string {
+	where := "canvas " + d.CanvasID
+	if d.Zone != "" {
+		where = fmt.Sprintf("zone %q on %s", d.Zone, where)
+	}
+	body := fmt.Sprintf("Your email was posted to %s.", where)
+	if len(d.Uploaded) > 0 {
+		body += "\n\nUploaded: " + strings.Join(d.Uploaded, ", ")
+	}
+	if len(d.Skipped) > 0 {
+		body += "\nNot uploaded (only images, PDFs and videos are supported): " + strings.Join(d.Skipped, ", ")
+	}
+	return body
+}
+
+// truncateRunes shortens s to at most n runes, ending with "…" when cut.
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return strings.TrimSpace(string(r[:n-1])) + "…"
+}
@@ -0,0 +1,227 @@
+package mailin
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IMAP client limits.
+const (
+	// maxFetch bounds the messages fetched in one poll; the rest wait for
+	// the next one
+	maxFetch = 20
+	// maxMessageBytes bounds the size of a fetched message
+	maxMessageBytes = 64 << 20
+	// imapTimeout bounds a whole IMAP session
+	imapTimeout = 5 * time.Minute
+)
+
+// IMAPMailbox is a molecule that reads unseen mail from an IMAP folder. It
+// speaks the few IMAP4rev1 commands the gateway needs and opens a new
+// session for every call.
+type IMAPMailbox struct {
+	config IMAPConfig
+}
+
+// NewIMAPMailbox creates an IMAPMailbox for the server in config.
+func NewIMAPMailbox(config IMAPConfig) *IMAPMailbox {
+	if config.Mailbox == "" {
+		config.Mailbox = DefaultMailbox
+	}
+	return &IMAPMailbox{config: config}
+}
+
+// Fetch returns up to maxFetch unseen messages, oldest first, without
+// marking them seen.
+func (m *IMAPMailbox) Fetch(ctx context.Context) ([]Mail, error) {
+	var mails []Mail
+	err := m.session(ctx, func(c *imapConn) error {
+		resp, err := c.command("UID SEARCH UNSEEN")
+		if err != nil {
+			return err
+		}
+		var uids []uint32
+		for _, r := range resp {
+			fields := strings.Fields(r.line)
+			if len(fields) < 2 || !strings.EqualFold(fields[1], "SEARCH") {
+				continue
+			}
+			for _, f := range fields[2:] {
+				if uid, err := strconv.ParseUint(f, 10, 32); err == nil {
+					uids = append(uids, uint32(uid))
+				}
+			}
+		}
+		if len(uids) > maxFetch {
+			uids = uids[:maxFetch]
+		}
+
+		for _, uid := range uids {
+			resp, err := c.command(fmt.Sprintf("UID FETCH %d (BODY.PEEK[])", uid))
+			if err != nil {
+				return err
+			}
+			for _, r := range resp {
+				if len(r.literals) > 0 && strings.Contains(strings.ToUpper(r.line), "FETCH") {
+					mails = append(mails, Mail{UID: uid, Data: r.literals[0]})
+					break
+				}
+			}
+		}
+		return nil
+	})
+	return mails, err
+}
+
+// MarkSeen flags the messages as seen so they are not fetched again.
+func (m *IMAPMailbox) MarkSeen(ctx context.Context, uids []uint32) error {
+	if len(uids) == 0 {
+		return nil
+	}
+	set := make([]string, len(uids))
+	for i, uid := range uids {
+		set[i] = strconv.FormatUint(uint64(uid), 10)
+	}
+	return m.session(ctx, func(c *imapConn) error {
+		_, err := c.command(fmt.Sprintf(`UID STORE %s +FLAGS.SILENT (\Seen)`, strings.Join(set, ",")))
+		return err
+	})
+}
+
+// session logs in, selects the mailbox, runs fn and logs out.
+func (m *IMAPMailbox) session(ctx context.Context, fn func(c *imapConn) error) error {
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	var conn net.Conn
+	var err error
+	if m.config.TLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: m.config.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("imap: connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(imapTimeout))
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c := &imapConn{conn: conn, r: bufio.NewReader(conn)}
+	greeting, err := c.readResponse()
+	if err != nil {
+		return fmt.Errorf("imap: read greeting: %w", err)
+	}
+	if !strings.HasPrefix(strings.ToUpper(greeting.line), "* OK") && !strings.HasPrefix(strings.ToUpper(greeting.line), "* PREAUTH") {
+		return fmt.Errorf("imap: server refused connection: %s", greeting.line)
+	}
+	if _, err := c.command("LOGIN " + quote(m.config.Username) + " " + quote(m.config.Password)); err != nil {
+		return err
+	}
+	if _, err := c.command("SELECT " + quote(m.config.Mailbox)); err != nil {
+		return err
+	}
+	if err := fn(c); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	c.command("LOGOUT")
+	return nil
+}
+
+// imapResponse is one response line with the literals it carried.
+type imapResponse struct {
+	line     string
+	literals [][]byte
+}
+
+// imapConn is an IMAP connection that issues tagged commands.
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+}
+
+// command sends a command and returns its untagged responses. A NO or BAD
+// completion is an error.
+func (c *imapConn) command(cmd string) ([]imapResponse, error) {
+	c.tag++
+	tag := fmt.Sprintf("A%03d", c.tag)
+	if _, err := io.WriteString(c.conn, tag+" "+cmd+"\r\n"); err != nil {
+		return nil, fmt.Errorf("imap: send command: %w", err)
+	}
+
+	verb := strings.Fields(cmd)[0]
+	var untagged []imapResponse
+	for {
+		r, err := c.readResponse()
+		if err != nil {
+			return nil, fmt.Errorf("imap: %s: %w", verb, err)
+		}
+		if !strings.HasPrefix(r.line, tag+" ") {
+			untagged = append(untagged, r)
+			continue
+		}
+		status := strings.TrimPrefix(r.line, tag+" ")
+		if !strings.HasPrefix(strings.ToUpper(status), "OK") {
+			return nil, fmt.Errorf("imap: %s failed: %s", verb, status)
+		}
+		return untagged, nil
+	}
+}
+
+// readResponse reads one response, following any {n} literals it contains.
+func (c *imapConn) readResponse() (imapResponse, error) {
+	var r imapResponse
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return r, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		r.line += line
+
+		n, ok := literalSize(line)
+		if !ok {
+			return r, nil
+		}
+		if n > maxMessageBytes {
+			return r, fmt.Errorf("message of %d bytes is too large", n)
+		}
+		literal := make([]byte, n)
+		if _, err := io.ReadFull(c.r, literal); err != nil {
+			return r, err
+		}
+		r.literals = append(r.literals, literal)
+	}
+}
+
+// literalSize returns n when line ends with a {n} literal announcement.
+func literalSize(line string) (int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	open := strings.LastIndexByte(line, '{')
+	if open < 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(line[open+1 : len(line)-1])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// quote returns s as an IMAP quoted string.
+func quote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,331 @@
+package mailin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"go_backend/core"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// testEmail builds a multipart email with a plain and HTML body, a PNG and a zip.
+func testEmail(t *testing.T, from, subject string) []byte {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("Subject: " + subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: multipart/mixed; boundary=outer\r\n\r\n")
+	b.WriteString("--outer\r\nContent-Type: multipart/alternative; boundary=inner\r\n\r\n")
+	b.WriteString("--inner\r\nContent-Type: text/plain; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n")
+	b.WriteString("Caf=C3=A9 moved to Friday.\r\nBring the mock-ups.\r\n-- \r\nAlice\r\n")
+	b.WriteString("--inner\r\nContent-Type: text/html\r\n\r\n<p>Caf&eacute; moved</p>\r\n--inner--\r\n")
+	b.WriteString("--outer\r\nContent-Type: image/png; name=\"sketch.png\"\r\nContent-Disposition: attachment; filename=\"sketch.png\"\r\nContent-Transfer-Encoding: base64\r\n\r\n")
+	b.WriteString(base64.StdEncoding.EncodeToString(testPNG(t, 200, 100)) + "\r\n")
+	b.WriteString("--outer\r\nContent-Type: application/zip\r\nContent-Disposition: attachment; filename=\"sources.zip\"\r\n\r\nPK\r\n")
+	b.WriteString("--outer--\r\n")
+	return []byte(b.String())
+}
+
+func TestParseMessage(t *testing.T) {
+	msg, err := ParseMessage(testEmail(t, `"Alice Smith" <alice@example.com>`, "=?utf-8?q?Re:_Team/Sprint_Board?="))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.From != "alice@example.com" || msg.Sender() != "Alice Smith" || msg.Subject != "Re: Team/Sprint Board" {
+		t.Errorf("header = %+v", msg)
+	}
+	if msg.Text != "Café moved to Friday.\nBring the mock-ups." {
+		t.Errorf("text = %q", msg.Text)
+	}
+	if len(msg.Attachments) != 2 || msg.Attachments[0].Name != "sketch.png" || msg.Attachments[0].ContentType != "image/png" {
+		t.Fatalf("attachments = %+v", msg.Attachments)
+	}
+	if _, err := png.DecodeConfig(bytes.NewReader(msg.Attachments[0].Data)); err != nil {
+		t.Errorf("attachment not decoded: %v", err)
+	}
+
+	html, err := ParseMessage([]byte("From: bob@example.com\r\nContent-Type: text/html; charset=iso-8859-1\r\n\r\n<style>p{}</style><p>Na\xefve&nbsp;plan</p><p>Line two</p>"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if html.Text != "Naïve plan\nLine two" {
+		t.Errorf("html text = %q", html.Text)
+	}
+}
+
+func TestParseSubject(t *testing.T) {
+	for subject, want := range map[string]Route{
+		"Team Canvas/Sprint Board":  {Canvas: "Team Canvas", Zone: "Sprint Board", Explicit: true},
+		"Fwd: RE: Team / Backlog":   {Canvas: "Team", Zone: "Backlog", Explicit: true},
+		"Sprint Board":              {Canvas: "Sprint Board"},
+		"/Backlog":                  {Zone: "Backlog", Explicit: true},
+		"  ":                        {},
+		"Re: Retro notes/Week 1/2 ": {Canvas: "Retro notes", Zone: "Week 1/2", Explicit: true},
+	} {
+		if got := ParseSubject(subject); got != want {
+			t.Errorf("ParseSubject(%q) = %+v, want %+v", subject, got, want)
+		}
+	}
+}
+
+func TestConfigAllowed(t *testing.T) {
+	cfg := Config{AllowedSenders: []string{"bob@partner.com", "@example.com"}}
+	for address, want := range map[string]bool{
+		"Alice@Example.com":  true,
+		"bob@partner.com":    true,
+		"eve@partner.com":    false,
+		"eve@notexample.com": false,
+	} {
+		if got := cfg.Allowed(address); got != want {
+			t.Errorf("Allowed(%q) = %v, want %v", address, got, want)
+		}
+	}
+	if !(Config{}).Allowed("anyone@anywhere") {
+		t.Error("empty allow list rejected a sender")
+	}
+}
+
+// fakeMailbox serves fixed mail and records what is marked seen
+type fakeMailbox struct {
+	mails []Mail
+	seen  []uint32
+}
+
+func (m *fakeMailbox) Fetch(ctx context.Context) ([]Mail, error) { return m.mails, nil }
+
+func (m *fakeMailbox) MarkSeen(ctx context.Context, uids []uint32) error {
+	m.seen = append(m.seen, uids...)
+	return nil
+}
+
+// fakeCanvas holds a zone with one note and records created widgets
+type fakeCanvas struct {
+	name    string
+	created []map[string]interface{}
+}
+
+func (c *fakeCanvas) GetCanvasInfo() (map[string]interface{}, error) {
+	return map[string]interface{}{"name": c.name}, nil
+}
+
+func (c *fakeCanvas) GetWidgets(subscribe bool) ([]map[string]interface{}, error) {
+	return []map[string]interface{}{
+		{"id": "canvas", "widget_type": "SharedCanvas", "location": map[string]interface{}{"x": 0.0, "y": 0.0}, "size": map[string]interface{}{"width": 100000.0, "height": 100000.0}},
+		{"id": "a1", "widget_type": "Anchor", "anchor_name": "Sprint Board", "location": map[string]interface{}{"x": 1000.0, "y": 1000.0}, "size": map[string]interface{}{"width": 2000.0, "height": 1500.0}},
+		{"id": "n1", "widget_type": "Note", "location": map[string]interface{}{"x": 1100.0, "y": 1100.0}, "size": map[string]interface{}{"width": 300.0, "height": 300.0}},
+		{"id": "n2", "widget_type": "Note", "location": map[string]interface{}{"x": 5000.0, "y": 200.0}, "size": map[string]interface{}{"width": 300.0, "height": 300.0}},
+	}, nil
+}
+
+func (c *fakeCanvas) record(kind string, payload map[string]interface{}) (map[string]interface{}, error) {
+	payload["kind"] = kind
+	c.created = append(c.created, payload)
+	return map[string]interface{}{"id": fmt.Sprintf("%s-%d", kind, len(c.created))}, nil
+}
+
+func (c *fakeCanvas) CreateNote(payload map[string]interface{}) (map[string]interface{}, error) {
+	return c.record("note", payload)
+}
+
+func (c *fakeCanvas) CreateImage(filePath string, metadata map[string]interface{}) (map[string]interface{}, error) {
+	return c.record("image", metadata)
+}
+
+func (c *fakeCanvas) CreatePDF(filePath string, metadata map[string]interface{}) (map[string]interface{}, error) {
+	return c.record("pdf", metadata)
+}
+
+func (c *fakeCanvas) CreateVideo(filePath string, metadata map[string]interface{}) (map[string]interface{}, error) {
+	return c.record("video", metadata)
+}
+
+func TestGatewayPoll(t *testing.T) {
+	mailbox := &fakeMailbox{mails: []Mail{
+		{UID: 1, Data: testEmail(t, "Alice <alice@example.com>", "Team Canvas/Sprint Board")},
+		{UID: 2, Data: testEmail(t, "bob@example.com", "Team Canvas/Nowhere")},
+		{UID: 3, Data: testEmail(t, "eve@elsewhere.com", "Team Canvas")},
+		{UID: 4, Data: []byte("not an email")},
+	}}
+	canvases := map[string]*fakeCanvas{"c1": {name: "Other"}, "c2": {name: "Team Canvas"}}
+	cfg := Config{
+		AllowedSenders: []string{"@example.com"},
+		Reply:          true,
+		SMTP:           core.SMTPConfig{Host: "smtp.example.com"},
+	}
+	var prompts []string
+	generate := func(ctx context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return "- Café moved to Friday", nil
+	}
+	g := New(cfg, mailbox, func(id string) Client { return canvases[id] }, []string{"c1", "c2"}, generate, t.TempDir())
+	replies := map[string]string{}
+	g.sendMail = func(ctx context.Context, to []string, subject, body string) error {
+		replies[to[0]] = body
+		return nil
+	}
+
+	posted, err := g.Poll(context.Background())
+	if err != nil || posted != 1 {
+		t.Fatalf("Poll() = %d, %v", posted, err)
+	}
+	if fmt.Sprint(mailbox.seen) != "[1 2 3 4]" {
+		t.Errorf("seen = %v", mailbox.seen)
+	}
+	if len(canvases["c1"].created) != 0 || len(prompts) != 1 {
+		t.Errorf("c1 widgets = %v, %d prompts", canvases["c1"].created, len(prompts))
+	}
+
+	created := canvases["c2"].created
+	if len(created) != 2 || created[0]["kind"] != "image" || created[1]["kind"] != "note" {
+		t.Fatalf("created = %+v", created)
+	}
+	// The note goes below the zone's note; the image right of it at 2:1
+	note, img := created[1], created[0]
+	if loc := note["location"].(map[string]float64); loc["x"] != 1000+itemGap || loc["y"] != 1100+300+itemGap {
+		t.Errorf("note location = %+v", loc)
+	}
+	if loc := img["location"].(map[string]float64); loc["x"] != 1000+itemGap+noteWidth+itemGap {
+		t.Errorf("image location = %+v", loc)
+	}
+	if size := img["size"].(map[string]interface{}); size["height"] != noteWidth/2 {
+		t.Errorf("image size = %+v", size)
+	}
+	text := note["text"].(string)
+	if note["title"] != "Email from Alice" || !strings.HasPrefix(text, "- Café moved to Friday") ||
+		!strings.Contains(text, "Attachments: sketch.png") || !strings.Contains(text, "Not uploaded: sources.zip") {
+		t.Errorf("note = %+v", note)
+	}
+
+	if !strings.Contains(replies["alice@example.com"], `zone "Sprint Board" on canvas c2`) {
+		t.Errorf("confirmation = %q", replies["alice@example.com"])
+	}
+	if !strings.Contains(replies["bob@example.com"], `no anchor named "Nowhere"`) {
+		t.Errorf("error reply = %q", replies["bob@example.com"])
+	}
+	if _, ok := replies["eve@elsewhere.com"]; ok {
+		t.Error("replied to a sender who is not allowed")
+	}
+}
+
+func TestGatewayResolve(t *testing.T) {
+	canvases := map[string]*fakeCanvas{"c1": {name: "Main"}, "c2": {name: "Team Canvas"}}
+	g := New(Config{}, nil, func(id string) Client { return canvases[id] }, []string{"c1", "c2"}, nil, "")
+	for _, tt := range []struct {
+		subject, canvas, zone string
+	}{
+		{"", "c1", ""},
+		{"team canvas", "c2", ""},
+		{"c2/Backlog", "c2", "Backlog"},
+		{"Backlog", "c1", "Backlog"},
+	} {
+		canvas, zone, err := g.resolve(ParseSubject(tt.subject))
+		if err != nil || canvas != tt.canvas || zone != tt.zone {
+			t.Errorf("resolve(%q) = %q, %q, %v", tt.subject, canvas, zone, err)
+		}
+	}
+	if _, _, err := g.resolve(ParseSubject("Unknown/Backlog")); err == nil {
+		t.Error("resolve(Unknown/Backlog) succeeded")
+	}
+}
+
+// serveIMAP answers the commands of one IMAP session with a single unseen message.
+func serveIMAP(t *testing.T, ln net.Listener, message string, log *[]string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, cmd, _ := strings.Cut(strings.TrimSpace(line), " ")
+		*log = append(*log, cmd)
+		switch {
+		case strings.HasPrefix(cmd, "UID SEARCH"):
+			fmt.Fprint(conn, "* SEARCH 7\r\n")
+		case strings.HasPrefix(cmd, "UID FETCH 7"):
+			fmt.Fprintf(conn, "* 1 FETCH (UID 7 BODY[] {%d}\r\n%s)\r\n", len(message), message)
+		case strings.HasPrefix(cmd, "LOGIN") && !strings.Contains(cmd, `"s\"cret"`):
+			fmt.Fprintf(conn, "%s NO bad password\r\n", tag)
+			continue
+		}
+		fmt.Fprintf(conn, "%s OK done\r\n", tag)
+		if cmd == "LOGOUT" {
+			return
+		}
+	}
+}
+
+func TestIMAPMailbox(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer ln.Close()
+	addr := ln.Addr().(*net.TCPAddr)
+	message := "From: alice@example.com\r\nSubject: Hi\r\n\r\nHello {there}\r\n"
+
+	var log []string
+	done := make(chan struct{})
+	go func() {
+		serveIMAP(t, ln, message, &log)
+		serveIMAP(t, ln, message, &log)
+		close(done)
+	}()
+
+	m := NewIMAPMailbox(IMAPConfig{Host: "127.0.0.1", Port: addr.Port, Username: "bot", Password: `s"cret`})
+	mails, err := m.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mails) != 1 || mails[0].UID != 7 || string(mails[0].Data) != message {
+		t.Fatalf("mails = %+v", mails)
+	}
+	if err := m.MarkSeen(context.Background(), []uint32{7, 9}); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	if got := strings.Join(log, "|"); !strings.Contains(got, `SELECT "INBOX"`) || !strings.Contains(got, `UID STORE 7,9 +FLAGS.SILENT (\Seen)`) {
+		t.Errorf("commands = %s", got)
+	}
+
+	bad := NewIMAPMailbox(IMAPConfig{Host: "127.0.0.1", Port: addr.Port, Password: "wrong"})
+	go serveIMAP(t, ln, message, &[]string{})
+	if _, err := bad.Fetch(context.Background()); err == nil || !strings.Contains(err.Error(), "bad password") {
+		t.Errorf("Fetch with a bad password = %v", err)
+	}
+}
+
+func TestLiteralSize(t *testing.T) {
+	for line, want := range map[string]int{"* 1 FETCH (BODY[] {42}": 42, "* OK {x}": -1, "* OK": -1} {
+		n, ok := literalSize(line)
+		if !ok {
+			n = -1
+		}
+		if n != want {
+			t.Errorf("literalSize(%q) = %s", line, strconv.Itoa(n))
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package mailin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxPartDepth bounds the nesting of multipart bodies.
+const maxPartDepth = 5
+
+// Mail is a raw message read from the mailbox.
+type Mail struct {
+	UID  uint32
+	Data []byte
+}
+
+// Attachment is a file attached to an email.
+type Attachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+// Message is a parsed email.
+type Message struct {
+	// From is the sender address; FromName the display name, if any
+	From     string
+	FromName string
+	Subject  string
+	Date     time.Time
+	// Text is the plain text body, or the text of the HTML body
+	Text        string
+	Attachments []Attachment
+}
+
+// Sender returns the display name of the sender, or the address.
+func (m *Message) Sender() string {
+	if m.FromName != "" {
+		return m.FromName
+	}
+	return m.From
+}
+
+// wordDecoder decodes RFC 2047 encoded headers.
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// ParseMessage parses a raw RFC 5322 message: its sender, subject, text
+// body and attachments. A text/plain body is preferred to an HTML one.
+func ParseMessage(raw []byte) (*Message, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("mailin: invalid message: %w", err)
+	}
+
+	msg := &Message{}
+	if from, err := mail.ParseAddress(m.Header.Get("From")); err == nil {
+		msg.From, msg.FromName = from.Address, from.Name
+	} else {
+		msg.From = strings.TrimSpace(m.Header.Get("From"))
+	}
+	msg.Subject = decodeHeader(m.Header.Get("Subject"))
+	msg.Date, _ = m.Header.Date()
+
+	var b bodyParts
+	if err := b.walk(textproto.MIMEHeader(m.Header), m.Body, 0); err != nil {
+		return nil, fmt.Errorf("mailin: invalid message body: %w", err)
+	}
+	msg.Text = b.plain.String()
+	if strings.TrimSpace(msg.Text) == "" {
+		msg.Text = htmlToText(b.html.String())
+	}
+	msg.Text = stripSignature(strings.TrimSpace(strings.ReplaceAll(msg.Text, "\r\n", "\n")))
+	msg.Attachments = b.attachments
+	return msg, nil
+}
+
+// bodyParts collects the parts of a message body.
+type bodyParts struct {
+	plain, html bytes.Buffer
+	attachments []Attachment
+}
+
+// walk adds a part, descending into multipart bodies.
+func (b *bodyParts) walk(header textproto.MIMEHeader, body io.Reader, depth int) error {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxPartDepth || params["boundary"] == "" {
+			return nil
+		}
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := b.walk(part.Header, part, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(header.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return err
+	}
+
+	disposition, dispParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	name := decodeHeader(dispParams["filename"])
+	if name == "" {
+		name = decodeHeader(params["name"])
+	}
+	isText := mediaType == "text/plain" || mediaType == "text/html"
+	if disposition == "attachment" || !isText {
+		if name == "" {
+			name = fmt.Sprintf("attachment-%d%s", len(b.attachments)+1, extensionFor(mediaType))
+		}
+		b.attachments = append(b.attachments, Attachment{Name: filepath.Base(name), ContentType: mediaType, Data: data})
+		return nil
+	}
+
+	text, err := io.ReadAll(charsetReaderOrRaw(params["charset"], data))
+	if err != nil {
+		return err
+	}
+	if mediaType == "text/html" {
+		b.html.Write(text)
+	} else {
+		if b.plain.Len() > 0 {
+			b.plain.WriteString("\n\n")
+		}
+		b.plain.Write(text)
+	}
+	return nil
+}
+
+// decodeTransfer undoes a Content-Transfer-Encoding.
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// charsetReader converts text in the common Latin-1 charsets to UTF-8.
+// Other charsets are an error.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "latin1", "windows-1252", "cp1252", "iso-8859-15":
+		data, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, len(data))
+		for i, c := range data {
+			runes[i] = rune(c)
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q", charset)
+}
+
+// charsetReaderOrRaw converts data to UTF-8, keeping the valid UTF-8 of
+// data in an unsupported charset.
+func charsetReaderOrRaw(charset string, data []byte) io.Reader {
+	if r, err := charsetReader(charset, bytes.NewReader(data)); err == nil {
+		return r
+	}
+	return strings.NewReader(strings.ToValidUTF8(string(data), string(utf8.RuneError)))
+}
+
+// decodeHeader decodes RFC 2047 encoded words, keeping the raw value when
+// they cannot be decoded.
+func decodeHeader(value string) string {
+	decoded, err := wordDecoder.DecodeHeader(value)
+	if err != nil {
+		return strings.TrimSpace(value)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+// extensionFor returns the file extension of a media type, if known.
+func extensionFor(mediaType string) string {
+	switch mediaType {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "application/pdf":
+		return ".pdf"
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+var (
+	htmlDropped    = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	htmlLineBreaks = regexp.MustCompile(`(?i)<(br|/p|/div|/li|/tr|/h[1-6])\b[^>]*>`)
+	htmlTags       = regexp.MustCompile(`<[^>]*>`)
+	blankLines     = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText returns the visible text of an HTML body.
+func htmlToText(s string) string {
+	s = htmlDropped.ReplaceAllString(s, "")
+	s = htmlLineBreaks.ReplaceAllString(s, "\n")
+	s = html.UnescapeString(htmlTags.ReplaceAllString(s, ""))
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.TrimSpace(blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+// signatureDelimiter matches the "-- " line before a signature, whose
+// trailing space quoted-printable decoding drops.
+var signatureDelimiter = regexp.MustCompile(`\n-- ?\n`)
+
+// stripSignature drops a signature after its delimiter line.
+func stripSignature(text string) string {
+	if loc := signatureDelimiter.FindStringIndex(text); loc != nil {
+		return strings.TrimSpace(text[:loc[0]])
+	}
+	return text
+}
+
+// Route is the destination named by an email subject.
+type Route struct {
+	// Canvas is the canvas name or ID; empty for the default canvas
+	Canvas string
+	// Zone is the anchor name; empty for the canvas itself
+	Zone string
+	// Explicit is set when the subject separated canvas and zone with "/"
+	Explicit bool
+}
+
+// replyPrefix matches the reply and forward markers mail clients add.
+var replyPrefix = regexp.MustCompile(`(?i)^\s*((re|fw|fwd|aw|wg|sv)\s*:\s*)+`)
+
+// ParseSubject reads the destination from a subject of the form
+// "canvas/zone". Reply and forward prefixes are ignored.
+func ParseSubject(subject string) Route {
+	subject = strings.TrimSpace(replyPrefix.ReplaceAllString(subject, ""))
+	canvas, zone, found := strings.Cut(subject, "/")
+	return Route{Canvas: strings.TrimSpace(canvas), Zone: strings.TrimSpace(zone), Explicit: found}
+}
@@ -6,25 +6,74 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
+	"go_backend/artifacts"
+	"go_backend/audit"
+	"go_backend/buildinfo"
+	"go_backend/canvasexport"
+	"go_backend/canvaspreview"
+	"go_backend/canvassettings"
+	"go_backend/chatops"
+	"go_backend/cluster"
 	"go_backend/canvusapi"
 	"go_backend/core"
+	"go_backend/core/clock"
 	"go_backend/core/modelmanager"
 	"go_backend/core/validation"
 	"go_backend/db"
+	"go_backend/deterministic"
+	"go_backend/digest"
+	"go_backend/docimport"
+	"go_backend/events"
+	"go_backend/featureflags"
+	"go_backend/fewshot"
+	"go_backend/grpcapi"
+	"go_backend/handlers"
+	"go_backend/handwritingbatch"
+	"go_backend/i18n"
 	"go_backend/imagegen"
+	"go_backend/instancelease"
+	"go_backend/intent"
+	"go_backend/livetranscript"
 	"go_backend/llamaruntime"
+	"go_backend/llamaworker"
+	"go_backend/llmcapture"
 	"go_backend/logging"
+	"go_backend/mailin"
 	"go_backend/metrics"
+	"go_backend/netproxy"
+	"go_backend/notefix"
+	"go_backend/notetags"
+	"go_backend/ocrprocessor"
+	"go_backend/outputfilter"
+	"go_backend/promptexpander"
+	"go_backend/promptguard"
+	"go_backend/promptlib"
+	"go_backend/redact"
+	"go_backend/remotetrigger"
 	"go_backend/sdruntime"
+	"go_backend/sdworker"
+	"go_backend/sessions"
 	"go_backend/shutdown"
+	"go_backend/tempfiles"
+	"go_backend/thermal"
+	"go_backend/updater"
+	"go_backend/variants"
+	"go_backend/voicenote"
+	"go_backend/watchdog"
+	"go_backend/webhooks"
 	"go_backend/webui"
 	"go_backend/webui/auth"
+	"go_backend/whisperruntime"
+	"go_backend/workerproc"
 
 	"github.com/joho/godotenv"
 	"go.uber.org/zap"
@@ -55,16 +104,58 @@ func main() {
 		fmt.Printf("Warning: .env file not found: %v\n", err)
 	}
 
+	// Route clients without their own transport through the configured proxy
+	netproxy.InstallDefault()
+
+	// Subcommands that run instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		os.Exit(runBenchCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "models" {
+		os.Exit(runModelsCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		os.Exit(runImportCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "stress" {
+		os.Exit(runStressCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "update" {
+		os.Exit(runUpdateCommand(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "gpu-worker" {
+		os.Exit(runGPUWorkerCommand(os.Args[2:]))
+	}
+
 	// Determine if running in development mode
 	isDevelopment := os.Getenv("DEV_MODE") == "true"
 
-	// Initialize structured logger early
-	logger, err := logging.NewLogger(isDevelopment, "app.log")
+	// Initialize structured logger early; app.log rotation is set by LOG_* variables
+	logger, err := logging.NewLoggerWithConfig(isDevelopment, "app.log", logging.FileWriterConfigFromEnv())
 	if err != nil {
 		fmt.Printf("Failed to initialize logger: %v\n", err)
 		os.Exit(core.ExitCodeError)
 	}
 
+	if proxy := netproxy.Summary(); proxy != "" {
+		logger.Info("Outbound proxy configured", zap.String("proxy", proxy))
+	}
+
+	// Log how this binary was built, so reports from different machines can
+	// be compared; /api/buildinfo serves the full report
+	buildReport := readBuildInfo()
+	logger.Info("Build info",
+		zap.String("version", buildReport.Version),
+		zap.String("git_commit", buildReport.GitCommit),
+		zap.String("platform", buildReport.Platform),
+		zap.Bool("cgo", buildReport.CGO),
+		zap.Strings("tags", buildReport.Tags),
+		zap.Strings("subsystems", buildReport.Compiled()),
+	)
+
 	// Run startup validation before heavy operations
 	exitCode := runStartupValidation(logger, isDevelopment)
 	if exitCode != core.ExitCodeSuccess {
@@ -96,6 +187,9 @@ func main() {
 		zap.Bool("dev_mode", isDevelopment),
 		zap.Int("webui_port", config.Port),
 	)
+	for _, warning := range config.Warnings {
+		logger.Warn("Configuration warning", zap.String("warning", warning))
+	}
 
 	// Create downloads directory
 	if err := os.MkdirAll(config.DownloadsDir, 0755); err != nil {
@@ -130,6 +224,15 @@ func main() {
 	repository := db.NewRepository(database, asyncWriter)
 	logger.Info("Database and repository initialized")
 
+	// Canvus API requests are logged under "canvusapi", with bodies while
+	// body logging is switched on from the dashboard
+	canvusapi.SetLogger(logger.Zap().Named("canvusapi"), logger.Levels().BodyLogging)
+
+	// Prompts and raw model responses are stored while capture is switched
+	// on from the dashboard
+	llmCapture := newLLMCapture(repository)
+	llmcapture.SetDefault(llmCapture)
+
 	// Initialize Canvus client
 	client := canvusapi.NewClient(
 		config.CanvusServerURL,
@@ -142,7 +245,7 @@ func main() {
 	shutdownManager := shutdown.NewManager(logger.Zap(), shutdown.WithTimeout(60*time.Second))
 
 	// Register logger sync as highest priority (runs first during shutdown)
-	shutdownManager.Register("logger-sync", 5, func(ctx context.Context) error {
+	shutdownManager.Register("logger-sync", shutdown.PriorityCritical, func(ctx context.Context) error {
 		logger.Info("Syncing logger...")
 		if syncErr := logger.Sync(); syncErr != nil {
 			logger.Warn("Failed to sync logger during shutdown", zap.Error(syncErr))
@@ -152,16 +255,16 @@ func main() {
 		return nil
 	})
 
-	// Register async writer shutdown (priority 10 - before database)
-	shutdownManager.Register("async-writer", 10, func(ctx context.Context) error {
+	// Register async writer shutdown (before database)
+	shutdownManager.Register("async-writer", shutdown.PriorityConnections, func(ctx context.Context) error {
 		logger.Info("Stopping async writer...")
 		asyncWriter.Stop()
 		logger.Info("Async writer stopped")
 		return nil
-	})
+	}, shutdown.StopBefore("database"))
 
-	// Register database close (priority 15 - after async writer)
-	shutdownManager.Register("database", 15, func(ctx context.Context) error {
+	// Register database close (after everything that writes to it)
+	shutdownManager.Register("database", shutdown.PriorityConnections, func(ctx context.Context) error {
 		logger.Info("Closing database...")
 		if closeErr := database.Close(); closeErr != nil {
 			logger.Error("Failed to close database", zap.Error(closeErr))
@@ -171,63 +274,114 @@ func main() {
 		return nil
 	})
 
+	// Fix seeds, correlation IDs and timestamps for golden-file tests
+	// (TEST_DETERMINISTIC). recordClock stamps everything the server records.
+	recordClock := applyDeterministicMode(logger)
+
 	// Initialize SD runtime and imagegen processor (optional)
 	var sdPool *sdruntime.ContextPool
 	var imageProcessor *imagegen.Processor
 
-	sdPool, imageProcessor, err = initializeSDRuntime(logger, client, config)
-	if err != nil {
-		// Log the error but continue - SD is optional
-		logger.Warn("SD runtime initialization failed, image generation disabled",
-			zap.Error(err))
-	} else if sdPool != nil {
-		// Register SD pool shutdown (priority 30 - resource cleanup)
-		shutdownManager.Register("sd-pool", 30, func(ctx context.Context) error {
-			logger.Info("Shutting down SD context pool...")
-			if closeErr := sdPool.Close(); closeErr != nil {
-				logger.Error("Failed to close SD pool", zap.Error(closeErr))
-				return closeErr
-			}
-			logger.Info("SD context pool closed")
-			return nil
+	// With GPU_ISOLATION=process, SD and llama.cpp run in a supervised
+	// child process
+	gpuWorker, imageProcessor, llamaWorker, gpuWorkerErr := initializeGPUWorker(shutdownManager.Context(), logger, client, config)
+	if gpuWorkerErr != nil {
+		// Log the error but continue - local models are optional
+		logger.Warn("GPU worker failed to start, local image and text generation disabled",
+			zap.Error(gpuWorkerErr))
+	} else if gpuWorker != nil {
+		// Register GPU worker shutdown (resource cleanup)
+		shutdownManager.Register("gpu-worker", shutdown.PriorityResources, func(ctx context.Context) error {
+			logger.Info("Stopping GPU worker process...")
+			return gpuWorker.Stop(ctx)
 		})
 	}
+	if gpuWorkerErr == nil && imageProcessor == nil {
+		sdPool, imageProcessor, err = initializeSDRuntime(logger, client, config)
+		if err != nil {
+			// Log the error but continue - SD is optional
+			logger.Warn("SD runtime initialization failed, image generation disabled",
+				zap.Error(err))
+		} else if sdPool != nil {
+			// Register SD pool shutdown (resource cleanup)
+			shutdownManager.Register("sd-pool", shutdown.PriorityResources, func(ctx context.Context) error {
+				logger.Info("Shutting down SD context pool...")
+				if closeErr := sdPool.Close(); closeErr != nil {
+					logger.Error("Failed to close SD pool", zap.Error(closeErr))
+					return closeErr
+				}
+				logger.Info("SD context pool closed")
+				return nil
+			})
+		}
+	}
 
-	// Initialize llamaruntime (LLM inference) - optional
+	// Initialize llamaruntime (LLM inference) - optional. llamaModel is the
+	// local client or, with GPU isolation, the GPU worker's.
+	var llamaModel LlamaModel
 	var llamaClient *llamaruntime.Client
 	var llamaHealthChecker *llamaruntime.HealthChecker
 	var llamaGPUMonitor *llamaruntime.GPUMonitor
 
-	llamaClient, llamaHealthChecker, llamaGPUMonitor, err = initializeLlamaRuntime(logger, shutdownManager.Context())
-	if err != nil {
-		// Log the error but continue - llamaruntime is optional
-		logger.Warn("llamaruntime initialization failed, local LLM inference disabled",
-			zap.Error(err))
-	} else if llamaClient != nil {
-		// Register llamaruntime shutdown (priority 35 - after SD pool)
-		shutdownManager.Register("llamaruntime", 35, func(ctx context.Context) error {
-			logger.Info("Shutting down llamaruntime...")
-
-			// Stop health checker first
-			if llamaHealthChecker != nil {
-				llamaHealthChecker.Stop()
-				logger.Info("llamaruntime health checker stopped")
-			}
+	if llamaWorker != nil {
+		llamaModel = llamaWorker
+	} else if gpuWorkerErr == nil {
+		llamaClient, llamaHealthChecker, llamaGPUMonitor, err = initializeLlamaRuntime(logger, shutdownManager.Context())
+		if err != nil {
+			// Log the error but continue - llamaruntime is optional
+			logger.Warn("llamaruntime initialization failed, local LLM inference disabled",
+				zap.Error(err))
+		} else if llamaClient != nil {
+			llamaModel = llamaClient
+			// Register llamaruntime shutdown (after SD pool)
+			shutdownManager.Register("llamaruntime", shutdown.PriorityResources, func(ctx context.Context) error {
+				logger.Info("Shutting down llamaruntime...")
+
+				// Stop health checker first
+				if llamaHealthChecker != nil {
+					llamaHealthChecker.Stop()
+					logger.Info("llamaruntime health checker stopped")
+				}
+
+				// Stop GPU monitor
+				if llamaGPUMonitor != nil {
+					llamaGPUMonitor.Stop()
+					logger.Info("llamaruntime GPU monitor stopped")
+				}
+
+				// Close the client
+				if closeErr := llamaClient.Close(); closeErr != nil {
+					logger.Error("Failed to close llamaruntime client", zap.Error(closeErr))
+					return closeErr
+				}
+				logger.Info("llamaruntime client closed")
+				return nil
+			})
+		}
+	}
 
-			// Stop GPU monitor
-			if llamaGPUMonitor != nil {
-				llamaGPUMonitor.Stop()
-				logger.Info("llamaruntime GPU monitor stopped")
-			}
+	// Unload idle models so overnight-idle servers release VRAM
+	idleUnloader := core.NewIdleUnloader(time.Minute, func(name string) {
+		logger.Info("Unloaded idle model from VRAM", zap.String("model", name))
+	})
+	if sdPool != nil {
+		idleUnloader.Register("stable-diffusion", sdPool, config.SDIdleTimeout)
+	}
+	if llamaClient != nil {
+		idleUnloader.Register("llama", llamaClient, config.LlamaIdleTimeout)
+	}
+	if idleUnloader.Len() > 0 {
+		idleUnloader.Start(shutdownManager.Context())
+		logger.Info("Idle model unloading enabled",
+			zap.Duration("llama_idle_timeout", config.LlamaIdleTimeout),
+			zap.Duration("sd_idle_timeout", config.SDIdleTimeout),
+		)
 
-			// Close the client
-			if closeErr := llamaClient.Close(); closeErr != nil {
-				logger.Error("Failed to close llamaruntime client", zap.Error(closeErr))
-				return closeErr
-			}
-			logger.Info("llamaruntime client closed")
+		// Register idle unloader shutdown (before model cleanup)
+		shutdownManager.Register("idle-unloader", shutdown.PriorityResources, func(ctx context.Context) error {
+			idleUnloader.Stop()
 			return nil
-		})
+		}, shutdown.StopBefore("sd-pool", "llamaruntime"))
 	}
 
 	// Initialize MetricsStore for dashboard metrics
@@ -235,14 +389,83 @@ func main() {
 		TaskHistoryCapacity: 100,
 		Version:             "1.0.0",
 	}
-	metricsStore := metrics.NewMetricsStore(metricsConfig, time.Now())
+	metricsStore := metrics.NewMetricsStore(metricsConfig, recordClock.Now())
+	metricsStore.SetClock(recordClock)
 	logger.Info("MetricsStore initialized")
 
+	// Restore task history and counters saved by the previous run, then save
+	// them periodically so dashboard totals survive restarts
+	metricsPersister := newMetricsPersister(shutdownManager.Context(), logger, metricsStore, repository)
+
+	// Register final metrics save (before the database closes)
+	shutdownManager.Register("metrics-persist", shutdown.PriorityConnections, func(ctx context.Context) error {
+		if err := metricsPersister.Save(ctx); err != nil {
+			logger.Warn("Failed to save metrics on shutdown", zap.Error(err))
+			return err
+		}
+		logger.Info("Metrics saved")
+		return nil
+	})
+
+	// Outbound webhooks for task failures, budget, GPU and stream alerts
+	webhookDispatcher := newWebhookDispatcher(logger, recordClock)
+
+	// Register webhook flush (after the servers, lets pending deliveries
+	// finish without holding up the rest of shutdown)
+	shutdownManager.Register("webhooks", shutdown.PriorityServices, func(ctx context.Context) error {
+		return webhookDispatcher.Wait(ctx)
+	}, shutdown.StopAfter("webui-server", "grpc-server"), shutdown.StopTimeout(15*time.Second))
+
+	// Register connection pool cleanup (after outbound requests finish)
+	shutdownManager.Register("http-pool", shutdown.PriorityServices, func(ctx context.Context) error {
+		core.CloseIdleConnections()
+		return nil
+	}, shutdown.StopAfter("webhooks", "cluster"))
+
+	// Daily activity digest email; nil unless DIGEST_EMAIL and SMTP_HOST are set
+	dailyDigest := newDailyDigest(logger, repository, metricsStore)
+	if dailyDigest != nil {
+		go dailyDigest.Run(shutdownManager.Context())
+	}
+
+	// Slow local image generation while the GPU is over its thermal limits
+	// (THERMAL_THROTTLE); nil when off
+	thermalThrottle := newThermalThrottle(logger, sdPool, imageProcessor)
+
+	// Keep GPU samples in the database for long-range dashboard charts
+	gpuHistory := newGPUHistory(shutdownManager.Context(), logger, repository)
+
+	// Register final GPU history save (before the database closes)
+	shutdownManager.Register("gpu-history", shutdown.PriorityConnections, func(ctx context.Context) error {
+		if err := gpuHistory.Flush(ctx); err != nil {
+			logger.Warn("Failed to save GPU history on shutdown", zap.Error(err))
+			return err
+		}
+		return nil
+	})
+
 	// Initialize GPUCollector for GPU metrics
 	gpuConfig := metrics.DefaultGPUCollectorConfig()
 	gpuCollector := metrics.NewGPUCollector(gpuConfig, func(gpuMetrics metrics.GPUMetrics) {
 		// Update metrics store with GPU data
 		metricsStore.UpdateGPUMetrics(gpuMetrics)
+		// Average into the persisted GPU history
+		gpuHistory.Record(gpuMetrics)
+		// Alert webhooks on GPU temperature and memory thresholds
+		webhookDispatcher.CheckGPU(gpuMetrics)
+		// Sample GPU utilization for the daily digest
+		dailyDigest.RecordGPU(gpuMetrics)
+		// Throttle image generation on GPU temperature and power
+		if thermalThrottle != nil {
+			if status, changed := thermalThrottle.Observe(gpuMetrics); changed && status.Throttled {
+				logger.Warn("GPU over thermal limit, throttling image generation",
+					zap.String("reason", status.Reason),
+					zap.Int("concurrency", status.Limit))
+			} else if changed {
+				logger.Info("GPU cooled, image generation back to full speed",
+					zap.Float64("temperature", status.Temperature))
+			}
+		}
 	})
 	logger.Info("GPUCollector initialized",
 		zap.Duration("interval", gpuConfig.CollectionInterval),
@@ -252,8 +475,8 @@ func main() {
 	// Start GPU collector goroutine (uses internal context)
 	gpuCollector.Start()
 
-	// Register GPU collector shutdown (priority 25 - after web server)
-	shutdownManager.Register("gpu-collector", 25, func(ctx context.Context) error {
+	// Register GPU collector shutdown (after the servers that report it)
+	shutdownManager.Register("gpu-collector", shutdown.PriorityServices, func(ctx context.Context) error {
 		logger.Info("Stopping GPU collector...")
 		gpuCollector.Stop()
 		logger.Info("GPU collector stopped")
@@ -263,8 +486,19 @@ func main() {
 	// Start monitoring with context from shutdown manager
 	monitor := NewMonitor(client, config, logger, repository)
 
-	// Wire metrics store into monitor for task recording
+	// Consumers of the monitor's widget, trigger and task events; the
+	// dashboard and gRPC broadcasters subscribe once the servers are up
+	bus := monitor.Events()
+	events.RecordTasks(bus, "metrics", metricsStore)
+	webhookDispatcher.Subscribe(bus)
+	recordCanvasEvents(bus, repository, logger)
+	if gpuWorker != nil {
+		gpuWorker.OnCrash(func(crash workerproc.Crash) {
+			events.Publish(bus, events.WorkerCrashed{Crash: crash})
+		})
+	}
 	monitor.SetMetricsStore(metricsStore)
+	monitor.SetClock(recordClock)
 
 	// Wire in the imagegen processor if available
 	if imageProcessor != nil {
@@ -273,12 +507,199 @@ func main() {
 	}
 
 	// Wire in the llamaruntime client if available
-	if llamaClient != nil {
-		monitor.SetLlamaClient(llamaClient)
+	if llamaModel != nil {
+		monitor.SetLlamaClient(llamaModel)
 		logger.Info("Local LLM inference enabled via llamaruntime")
 	}
 
-	go monitor.Start(shutdownManager.Context())
+	// Watch the Canvus stream: alert on sustained failure, back off reconnects
+	// and optionally pause AI processing until the server returns
+	canvusWatchdog := newCanvusWatchdog(logger, webhookDispatcher)
+	monitor.SetWatchdog(canvusWatchdog)
+
+	// Share work with other instances through a Redis queue (CLUSTER_URL)
+	clusterNode := newClusterNode(shutdownManager.Context(), logger, config)
+	if clusterNode != nil {
+		monitor.SetCluster(clusterNode)
+		// Register cluster leave (after the servers stop taking work)
+		shutdownManager.Register("cluster", shutdown.PriorityServices, func(ctx context.Context) error {
+			return clusterNode.Leave()
+		}, shutdown.StopAfter("webui-server", "grpc-server"))
+		if clusterNode.HasRole(cluster.RoleImage) {
+			if imageProcessor != nil {
+				go serveImageJobs(shutdownManager.Context(), clusterNode, imageProcessor, config, logger)
+			} else {
+				logger.Warn("Cluster image role needs the SD runtime; not serving image jobs")
+			}
+		}
+	}
+
+	// Keep a second instance pointed at this canvas from duplicating every
+	// response (INSTANCE_LEASE)
+	var instanceLease *instancelease.Lease
+	if clusterNode.HasRole(cluster.RoleMonitor) {
+		instanceLease = newInstanceLease(shutdownManager.Context(), logger, client)
+	}
+	if instanceLease != nil {
+		monitor.SetInstanceLease(instanceLease)
+		// Register lease release (after the servers stop taking work)
+		shutdownManager.Register("instance-lease", shutdown.PriorityServices, func(ctx context.Context) error {
+			return instanceLease.Release()
+		}, shutdown.StopAfter("webui-server", "grpc-server"))
+	}
+
+	// Keep generated images and downloaded PDFs (ARTIFACT_STORE)
+	artifactStore := newArtifactStore(shutdownManager.Context(), logger)
+	if artifactStore != nil {
+		artifactStore.SetClock(recordClock)
+		monitor.SetArtifactStore(artifactStore)
+		if imageProcessor != nil {
+			imageProcessor.SetArtifactStore(artifactStore, config.CanvasID)
+		}
+	}
+
+	// Remember the images generated from each trigger note for {{variants}}
+	imageVariants := newImageVariants(logger, repository)
+	if imageVariants != nil {
+		imageVariants.SetClock(recordClock)
+	}
+	monitor.SetImageVariants(imageVariants)
+	if imageProcessor != nil {
+		imageProcessor.SetImageVariants(imageVariants)
+	}
+
+	// Keep the note text before each {{fix}} so it can be undone
+	noteFixes := newNoteFixes(logger, repository)
+	if noteFixes != nil {
+		noteFixes.SetClock(recordClock)
+	}
+	monitor.SetNoteFixes(noteFixes)
+
+	// Read every snapshot of a canvas for {{handwriting}}, a few at a time
+	// (HANDWRITING_BATCH_CONCURRENCY)
+	monitor.SetHandwriting(handwritingbatch.NewReader(handwritingbatch.ConfigFromEnv()))
+
+	// Transcribe audio files uploaded to the canvas, and meetings into
+	// {{live}} notes (WHISPER_URL, LIVE_TRANSCRIPT_SOURCE)
+	whisperClient := newWhisperClient(config)
+	monitor.SetVoiceNotes(newVoiceNotes(logger, whisperClient, repository))
+	liveTranscripts := newLiveTranscripts(logger, whisperClient)
+	if liveTranscripts != nil {
+		monitor.SetLiveTranscripts(liveTranscripts)
+		shutdownManager.Register("live-transcripts", shutdown.PriorityServices, func(ctx context.Context) error {
+			return liveTranscripts.Close(ctx)
+		})
+	}
+
+	// Tag notes in the background so canvases can be filtered by topic (AUTO_TAGS)
+	noteTagger := newNoteTagger(logger, repository, monitor)
+	if noteTagger != nil {
+		noteTagger.SetClock(recordClock)
+		monitor.SetNoteTagger(noteTagger)
+		shutdownManager.Register("note-tagger", shutdown.PriorityServices, func(ctx context.Context) error {
+			noteTagger.Close()
+			return nil
+		})
+	}
+
+	// Hold downloaded and generated files under a disk quota (TEMP_QUOTA_MB)
+	tempFiles := newTempFileManager(logger, config.DownloadsDir)
+	if tempFiles != nil {
+		monitor.SetTempFiles(tempFiles)
+		if imageProcessor != nil {
+			imageProcessor.SetTempFiles(tempFiles)
+		}
+	}
+
+	// Download signed releases from the update channel and install them when
+	// the server stops (UPDATE_MANIFEST_URL, UPDATE_CHANNEL)
+	selfUpdater := newUpdater(shutdownManager.Context(), logger)
+	if selfUpdater != nil {
+		shutdownManager.Register("install-update", shutdown.PriorityFinal, func(ctx context.Context) error {
+			version, err := selfUpdater.Apply()
+			if version != "" {
+				logger.Info("Update installed; the next start runs the new version", zap.String("version", version))
+			}
+			return err
+		})
+	}
+
+	// Canvas messages and AI responses in the configured language (LANGUAGE, PROMPTS_DIR)
+	loadPromptVariants(logger, config.Language)
+
+	// Mask personal data in text sent to cloud LLMs (PII_REDACTION)
+	if redactor := newRedactor(logger, llamaModel); redactor != nil {
+		monitor.SetRedactor(redactor)
+	}
+	if filter := newOutputFilter(logger, llamaModel); filter != nil {
+		monitor.SetOutputFilter(filter)
+	}
+	// Neutralize prompt injection in canvas and PDF text (PROMPT_GUARD)
+	if guard := newPromptGuard(logger); guard != nil {
+		monitor.SetPromptGuard(guard)
+	}
+
+	// Settle obvious note intents without an LLM round-trip (INTENT_CLASSIFIER)
+	monitor.SetIntentClassifier(newIntentClassifier(logger, llamaModel))
+	// Expand image requests from notes into detailed prompts (PROMPT_EXPANSION)
+	monitor.SetPromptExpander(newPromptExpander(logger))
+
+	// Record every AI widget change in the hash-chained audit trail (AUDIT_LOG)
+	auditLog := newAuditLog(shutdownManager.Context(), logger, repository)
+	if auditLog != nil {
+		auditLog.SetClock(recordClock)
+		monitor.SetAuditLog(auditLog)
+	}
+
+	// Record each canvas session's triggers and AI responses for replay
+	// (SESSION_RECORDING)
+	sessionRecorder := newSessionRecorder(logger, repository)
+	if sessionRecorder != nil {
+		sessionRecorder.SetClock(recordClock)
+		monitor.SetSessionRecorder(sessionRecorder)
+	}
+
+	// Per-canvas overrides of features, models, triggers, colors and budgets
+	canvasSettings := newCanvasSettings(shutdownManager.Context(), logger, repository)
+	if canvasSettings != nil {
+		canvasSettings.SetClock(recordClock)
+		monitor.SetCanvasSettings(canvasSettings)
+	}
+	// Operator allow and deny lists of features per canvas (CANVAS_POLICY_FILE)
+	if policy := loadCanvasPolicy(logger); policy != nil {
+		monitor.SetCanvasPolicy(policy)
+	}
+
+	// Templates that {{use:name}} triggers expand
+	promptLibrary := newPromptLibrary(shutdownManager.Context(), logger, repository)
+	if promptLibrary != nil {
+		promptLibrary.SetClock(recordClock)
+		monitor.SetPromptLibrary(promptLibrary)
+	}
+
+	// Few-shot examples attached to the system prompts of AI tasks
+	fewShotExamples := newFewShotExamples(shutdownManager.Context(), logger, repository)
+	if fewShotExamples != nil {
+		fewShotExamples.SetClock(recordClock)
+		monitor.SetFewShotExamples(fewShotExamples)
+	}
+
+	// Requeue or fail the tasks a crashed run left unfinished before the
+	// monitor reads the canvas, and journal this run's tasks
+	// (RECOVERY_MAX_ATTEMPTS)
+	recoverySummary := runStartupRecovery(shutdownManager, logger, config, client, repository, monitor, tempFiles)
+
+	// Run the AI tasks monitor nodes queue, once the monitor has every
+	// dependency its handlers use
+	if clusterNode != nil && clusterNode.HasRole(cluster.RoleTasks) {
+		go serveTasks(shutdownManager.Context(), clusterNode, monitor, config.MaxConcurrent)
+	}
+
+	if clusterNode.HasRole(cluster.RoleMonitor) {
+		go monitor.Start(shutdownManager.Context())
+	} else {
+		logger.Info("No monitor role in CLUSTER_ROLES; serving cluster jobs without following the canvas")
+	}
 
 	// Initialize WebUIServer with the real components
 	serverConfig := webui.ServerConfig{
@@ -289,7 +710,7 @@ func main() {
 		IdleTimeout:     DefaultIdleTimeout,
 		ShutdownTimeout: DefaultShutdownTimeout,
 		StaticConfig:    webui.DefaultStaticAssetConfig(),
-		LogSkipPaths:    []string{"/health", "/api/status"},
+		LogSkipPaths:    []string{"/health", "/health/ready", "/api/status"},
 		VersionInfo: webui.VersionInfo{
 			Version: "1.0.0",
 		},
@@ -317,14 +738,121 @@ func main() {
 		zap.Bool("auth_enabled", authProvider != nil),
 	)
 
-	// Wire WebSocket broadcaster into monitor for real-time task updates
+	// Optional gRPC API for programmatic clients (GRPC_PORT)
+	grpcServer := newGRPCServer(logger, metricsStore, recordClock, client, config.CanvasID, func() (string, string) {
+		syntax := monitor.triggerSyntax()
+		return syntax.Open, syntax.Close
+	})
+
+	// Subscribe the WebSocket broadcaster to task updates and alerts
 	if broadcaster := webServer.GetBroadcaster(); broadcaster != nil {
-		monitor.SetTaskBroadcaster(broadcaster)
+		events.BroadcastTasks(bus, "dashboard", broadcaster)
+		events.Subscribe(bus, "dashboard", func(e events.LatencyChanged) { broadcaster.BroadcastLatencyAlert(e.Alert) })
+		events.Subscribe(bus, "dashboard", func(e events.SLOChanged) { broadcaster.BroadcastSLOAlert(e.Status) })
+		canvusWatchdog.SetOnChange(broadcaster.BroadcastCanvusHealth)
+		monitor.StreamState().SetOnChange(broadcaster.BroadcastMonitorState)
 		logger.Info("Task broadcaster wired for real-time dashboard updates")
+
+		// Canvas map: follows tasks and the widgets AI tasks write to
+		previewer := newCanvasPreviewer(logger, config, broadcaster)
+		events.BroadcastTasks(bus, "canvas-preview", previewer)
+		monitor.SetWidgetObserver(previewer)
+		webServer.SetCanvasPreview(webui.NewCanvasPreviewAPI(previewer, logger.Zap()))
+		go previewer.Run(shutdownManager.Context())
+	}
+	if grpcServer != nil {
+		events.BroadcastTasks(bus, "grpc", grpcServer)
+	}
+	webServer.SetWatchdog(canvusWatchdog)
+	webServer.SetInstanceLease(instanceLease)
+	webServer.SetCluster(clusterNode)
+	webServer.SetStreamState(monitor.StreamState())
+	webServer.SetTaskHistory(repository)
+	webServer.SetWidgetHistory(webui.NewWidgetHistoryAPI(repository, logger.Zap()))
+	webServer.SetTags(webui.NewTagsAPI(repository, logger.Zap()))
+	webServer.SetGPUHistory(gpuHistory)
+	webServer.SetWebhooks(webui.NewWebhooksAPI(webhookDispatcher, logger.Zap()))
+
+	// Alert the dashboard when a task type's p95 latency exceeds its threshold
+	if latencyWatcher := newLatencyWatcher(logger, metricsStore, bus); latencyWatcher != nil {
+		go latencyWatcher.Run(shutdownManager.Context())
+	}
+
+	// Evaluate SLO burn rates; violations reach the dashboard and webhooks
+	sloMonitor := newSLOMonitor(logger, metricsStore, bus)
+	if sloMonitor != nil {
+		go sloMonitor.Run(shutdownManager.Context())
+	}
+	webServer.SetSLO(webui.NewSLOAPI(sloMonitor, logger.Zap()))
+	webServer.SetBuildInfo(webui.NewBuildInfoAPI(buildReport, logger.Zap()))
+	if tempFiles != nil {
+		webServer.SetTempFiles(tempFiles)
+	}
+	webServer.SetRecovery(recoverySummary)
+	// Remote triggers run on the monitored canvas only
+	triggerInjector := remotetrigger.New(func(canvasID string) remotetrigger.Client {
+		return canvusapi.NewClient(config.CanvusServerURL, canvasID, config.CanvusAPIKey, config.AllowSelfSignedCerts)
+	}, []string{config.CanvasID}, func(string) handlers.TriggerSyntax {
+		return monitor.triggerSyntax()
+	}, monitor.SubmitWidget, logger.Zap())
+	artifactsAPI := webui.NewArtifactsAPI(artifactStore, client, config.DownloadsDir, logger.Zap())
+	artifactsAPI.SetRegenerator(triggerInjector)
+	webServer.SetArtifacts(artifactsAPI)
+	webServer.SetAudit(webui.NewAuditAPI(auditLog, logger.Zap()))
+	webServer.SetLogs(webui.NewLogsAPI(logger.LogFilePath(), logger.Zap()))
+	webServer.SetLogging(webui.NewLoggingAPI(logger.Levels(), logger.Zap()))
+	webServer.SetLLMCapture(webui.NewLLMCaptureAPI(llmCapture, logger.Zap()))
+	webServer.SetSessions(webui.NewSessionsAPI(sessionRecorder, monitor.ReplayNotePrompt,
+		func(canvasID string) sessions.Canvas {
+			return canvusapi.NewClient(config.CanvusServerURL, canvasID, config.CanvusAPIKey, config.AllowSelfSignedCerts)
+		}, logger.Zap()))
+	webServer.SetCanvasSettings(webui.NewCanvasSettingsAPI(canvasSettings, config.GetCanvasIDs(), logger.Zap()))
+	webServer.SetPromptLibrary(webui.NewPromptLibraryAPI(promptLibrary, logger.Zap()))
+	webServer.SetFewShot(webui.NewFewShotAPI(fewShotExamples, logger.Zap()))
+	webServer.SetExport(webui.NewExportAPI(func(canvasID string) *canvasexport.Exporter {
+		client := canvusapi.NewClient(config.CanvusServerURL, canvasID, config.CanvusAPIKey, config.AllowSelfSignedCerts)
+		exporter := canvasexport.NewExporter(client, config.DownloadsDir, logger.Zap())
+		exporter.SetClock(recordClock)
+		return exporter
+	}, config.GetCanvasIDs(), logger.Zap()))
+	webServer.SetImport(webui.NewImportAPI(func(canvasID string) *docimport.Importer {
+		client := canvusapi.NewClient(config.CanvusServerURL, canvasID, config.CanvusAPIKey, config.AllowSelfSignedCerts)
+		return docimport.NewImporter(client, documentSummarizer(llamaModel), config.DownloadsDir, logger.Zap())
+	}, config.GetCanvasIDs(), config.DownloadsDir, logger.Zap()))
+	webServer.SetTriggers(webui.NewTriggersAPI(triggerInjector, logger.Zap()))
+
+	// Email-in gateway; nil unless MAILIN_IMAP_HOST is set
+	mailGateway := newMailGateway(logger, config, llamaModel)
+	if mailGateway != nil {
+		go mailGateway.Run(shutdownManager.Context())
+	}
+	if chatAPI := newChatOpsAPI(logger, config, monitor); chatAPI != nil {
+		webServer.SetChatOps(chatAPI)
+	}
+	featureFlags := newFeatureFlags(shutdownManager.Context(), logger, repository)
+	monitor.SetFeatureFlags(featureFlags)
+	webServer.SetFeatureFlags(webui.NewFeatureFlagsAPI(featureFlags, config.GetCanvasIDs(), logger.Zap()))
+
+	// Warm up local models in the background; /health/ready reports
+	// not-ready until every warmup has finished
+	readiness := webui.NewReadinessTracker()
+	webServer.SetReadiness(readiness)
+	if config.WarmupOnStartup {
+		warmupModels(shutdownManager.Context(), logger, readiness, llamaModel, sdPool)
+	}
+
+	// Model catalog: browse and download recommended models from the dashboard
+	if catalogAPI, err := newModelCatalogAPI(shutdownManager.Context(), logger, gpuCollector); err != nil {
+		logger.Warn("Model catalog disabled", zap.Error(err))
+	} else {
+		webServer.SetModelCatalog(catalogAPI)
+		if grpcServer != nil {
+			grpcServer.SetModelCatalog(catalogAPI)
+		}
 	}
 
-	// Register WebUI server shutdown (priority 20 - service cleanup)
-	shutdownManager.Register("webui-server", 20, func(ctx context.Context) error {
+	// Register WebUI server shutdown (service cleanup)
+	shutdownManager.Register("webui-server", shutdown.PriorityServices, func(ctx context.Context) error {
 		logger.Info("Shutting down WebUI server...")
 		if err := webServer.Shutdown(ctx); err != nil {
 			logger.Error("WebUI server shutdown error", zap.Error(err))
@@ -334,8 +862,38 @@ func main() {
 		return nil
 	})
 
-	// Register temp file cleanup (priority 45 - final cleanup)
-	shutdownManager.Register("cleanup-downloads", 45, shutdown.CleanupDownloads(logger.Zap(), config.DownloadsDir))
+	if grpcServer != nil {
+		// Register gRPC server shutdown (after the WebUI server)
+		shutdownManager.Register("grpc-server", shutdown.PriorityServices, func(ctx context.Context) error {
+			if err := grpcServer.Shutdown(ctx); err != nil {
+				logger.Error("gRPC server shutdown error", zap.Error(err))
+				return err
+			}
+			return nil
+		}, shutdown.StopAfter("webui-server"))
+		go func() {
+			if err := grpcServer.Start(); err != nil {
+				logger.Error("gRPC server stopped", zap.Error(err))
+			}
+		}()
+	}
+
+	// Register temp file cleanup (final cleanup)
+	shutdownManager.Register("cleanup-downloads", shutdown.PriorityFinal, shutdown.CleanupDownloads(logger.Zap(), config.DownloadsDir))
+
+	// Check the shutdown order now rather than at shutdown; --shutdown-plan
+	// prints it and exits (dry run)
+	if planErr := logShutdownPlan(logger, shutdownManager); planErr != nil {
+		logger.Error("Shutdown dependencies form a cycle; shutdown will fall back to priority order", zap.Error(planErr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "--shutdown-plan" {
+		planErr := shutdownManager.WritePlan(os.Stdout)
+		shutdownManager.Shutdown()
+		if planErr != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 
 	// Start shutdown manager (signal handling)
 	shutdownManager.Start()
@@ -464,7 +1022,9 @@ func createAuthProvider(config *core.Config, logger *logging.Logger) (webui.Auth
 // initializeSDRuntime initializes the Stable Diffusion runtime and image processor.
 // Returns (nil, nil, nil) if SD is not configured (no model path).
 // Returns (nil, nil, error) if SD is configured but initialization fails.
-// Returns (pool, processor, nil) on success.
+// Returns (pool, processor, nil) on success, and (nil, processor, nil) when
+// images are generated on an sd-worker (SD_WORKER_URL) or an existing
+// ComfyUI or AUTOMATIC1111 server (SD_BACKEND).
 //
 // This is a molecule that composes:
 //   - sdruntime.LoadSDConfig (atom)
@@ -475,6 +1035,26 @@ func initializeSDRuntime(logger *logging.Logger, client *canvusapi.Client, confi
 	// Load SD configuration
 	sdConfig := sdruntime.LoadSDConfig()
 
+	// Generate on a GPU host instead of loading the model here
+	workerConfig, err := sdworker.ConfigFromEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+	if workerConfig.Remote() {
+		processor, err := initializeSDWorker(logger, client, config, sdConfig, workerConfig)
+		return nil, processor, err
+	}
+
+	// Or reuse a ComfyUI or AUTOMATIC1111 server
+	backendConfig, err := imagegen.BackendConfigFromEnv()
+	if err != nil {
+		return nil, nil, err
+	}
+	if backendConfig.External() {
+		processor, err := initializeSDBackend(logger, client, config, sdConfig, backendConfig)
+		return nil, processor, err
+	}
+
 	// Check if SD is configured
 	if sdConfig.ModelPath == "" {
 		logger.Info("SD model path not configured, image generation disabled")
@@ -523,28 +1103,200 @@ func initializeSDRuntime(logger *logging.Logger, client *canvusapi.Client, confi
 		zap.Int("max_size", pool.MaxSize()))
 
 	// Create imagegen processor
-	processorConfig := imagegen.ProcessorConfig{
-		DownloadsDir:    config.DownloadsDir,
-		DefaultWidth:    sdConfig.ImageSize,
-		DefaultHeight:   sdConfig.ImageSize,
-		DefaultSteps:    sdConfig.InferenceSteps,
-		DefaultCFGScale: sdConfig.GuidanceScale,
-		PlacementConfig: imagegen.DefaultPlacementConfig(),
-		ProcessingNote:  imagegen.DefaultProcessingNoteConfig(),
-	}
-
-	processor, err := imagegen.NewProcessor(pool, client, logger, processorConfig)
+	processor, err := imagegen.NewProcessor(pool, client, logger, newProcessorConfig(sdConfig, config))
 	if err != nil {
 		// Clean up the pool if processor creation fails
 		pool.Close()
 		return nil, nil, fmt.Errorf("failed to create image processor: %w", err)
 	}
 
-	logger.Info("Image generation processor initialized")
+	logger.Info("Image generation processor initialized",
+		zap.Stringers("vram_retry_ladder", sdConfig.VRAMRetryLadder))
 
 	return pool, processor, nil
 }
 
+// initializeSDWorker creates an image processor that generates on the
+// sd-worker at workerConfig.URL. The worker only renders pixels; prompts
+// are checked and images placed on the canvas here.
+func initializeSDWorker(logger *logging.Logger, client *canvusapi.Client, config *core.Config, sdConfig *sdruntime.SDConfig, workerConfig sdworker.Config) (*imagegen.Processor, error) {
+	worker := sdworker.NewClient(workerConfig)
+
+	// An unreachable worker is not fatal: it may start after this instance
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if health, err := worker.Health(ctx); err != nil {
+		logger.Warn("SD worker not reachable yet; image prompts fail until it is",
+			zap.String("url", worker.URL()), zap.Error(err))
+	} else {
+		logger.Info("SD worker connected",
+			zap.String("url", worker.URL()),
+			zap.String("model", health.Model),
+			zap.Int("max_concurrent", health.MaxConcurrent),
+			zap.Bool("loaded", health.Loaded))
+	}
+
+	processor, err := imagegen.NewProcessorWithBackend(worker, client, logger, newProcessorConfig(sdConfig, config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image processor: %w", err)
+	}
+	return processor, nil
+}
+
+// initializeSDBackend creates an image processor that generates with the
+// ComfyUI or AUTOMATIC1111 server of backendConfig.
+func initializeSDBackend(logger *logging.Logger, client *canvusapi.Client, config *core.Config, sdConfig *sdruntime.SDConfig, backendConfig imagegen.BackendConfig) (*imagegen.Processor, error) {
+	backend, err := imagegen.NewBackend(backendConfig)
+	if err != nil {
+		return nil, err
+	}
+	processor, err := imagegen.NewProcessorWithBackend(backend, client, logger, newProcessorConfig(sdConfig, config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create image processor: %w", err)
+	}
+	logger.Info("Image generation uses an external Stable Diffusion server",
+		zap.String("backend", string(backendConfig.Kind)),
+		zap.String("url", backendConfig.URL),
+		zap.Bool("template", backendConfig.Template != nil),
+		zap.String("checkpoint", backendConfig.Checkpoint))
+	return processor, nil
+}
+
+// applyDeterministicMode fixes the image and text seeds and the correlation
+// IDs when TEST_DETERMINISTIC is set, and returns the clock that stamps
+// what the server records: task records and metrics, audit entries,
+// sessions, artifacts, exports and saved settings. It returns the real
+// clock when the mode is off. Leases, cluster heartbeats, retention jobs
+// and request signatures keep the real clock, as they coordinate with
+// other processes and services.
+func applyDeterministicMode(logger *logging.Logger) clock.Clock {
+	cfg, err := deterministic.ConfigFromEnv()
+	if err != nil {
+		logger.Warn("Invalid deterministic mode setting", zap.Error(err))
+	}
+	if !cfg.Enabled {
+		return clock.Real()
+	}
+	sdruntime.SetFixedSeed(cfg.Seed)
+	llamaruntime.SetSamplerSeed(cfg.SamplerSeed())
+	handlers.SetCorrelationIDSource(deterministic.IDs())
+	logger.Warn("Deterministic test mode is on: seeds, correlation IDs and timestamps are fixed; do not use it in production",
+		zap.Int64("seed", cfg.Seed),
+		zap.Time("epoch", cfg.Epoch))
+	return cfg.Clock()
+}
+
+// initializeGPUWorker starts the local models in a gpu-worker child process
+// when GPU_ISOLATION=process, so a crash inside stable-diffusion.cpp or
+// llama.cpp restarts the worker instead of ending the canvas monitor. It
+// returns an image processor when Stable Diffusion runs there and a llama
+// client when llama.cpp does, and nil for all three when isolation is off
+// or no local model is configured. Images generated on an SD_WORKER_URL or
+// SD_BACKEND server are left to initializeSDRuntime.
+func initializeGPUWorker(ctx context.Context, logger *logging.Logger, client *canvusapi.Client, config *core.Config) (*workerproc.Supervisor, *imagegen.Processor, *llamaworker.Client, error) {
+	isolation, err := workerproc.ConfigFromEnv()
+	if err != nil {
+		logger.Warn("Invalid GPU isolation setting, running runtimes in process", zap.Error(err))
+	}
+	if !isolation.Enabled() {
+		return nil, nil, nil, nil
+	}
+
+	sdConfig := sdruntime.LoadSDConfig()
+	localSD := sdConfig.ModelPath != ""
+	if workerConfig, _ := sdworker.ConfigFromEnv(); workerConfig.Remote() {
+		localSD = false
+	}
+	if backendConfig, _ := imagegen.BackendConfigFromEnv(); backendConfig.External() {
+		localSD = false
+	}
+	localLlama := os.Getenv("LLAMA_MODEL_PATH") != ""
+	if !localSD && !localLlama {
+		return nil, nil, nil, nil
+	}
+	if localSD {
+		if _, err := os.Stat(sdConfig.ModelPath); err != nil {
+			return nil, nil, nil, fmt.Errorf("SD model file: %w", err)
+		}
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("locate executable for the GPU worker: %w", err)
+	}
+
+	// The worker loads the models whose paths it is given
+	isolation.Token = workerproc.NewToken()
+	env := append(os.Environ(),
+		"GPU_WORKER_ADDR="+isolation.Addr,
+		"GPU_WORKER_TOKEN="+isolation.Token)
+	if !localSD {
+		env = append(env, "SD_MODEL_PATH=")
+	}
+	supervisor := workerproc.New("gpu-worker", isolation, func() *exec.Cmd {
+		cmd := exec.Command(executable, "gpu-worker")
+		cmd.Env = env
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		return cmd
+	}, logger.Zap())
+
+	url := "http://" + isolation.Addr
+	var sdWorker *sdworker.Client
+	if localSD {
+		sdWorker = sdworker.NewClient(sdworker.Config{URL: url, Token: isolation.Token, Timeout: sdConfig.Timeout})
+	}
+	var llamaWorker *llamaworker.Client
+	if localLlama {
+		llamaWorker = llamaworker.NewClient(llamaworker.Config{URL: url, Token: isolation.Token})
+	}
+
+	logger.Info("Starting GPU worker process",
+		zap.String("addr", isolation.Addr),
+		zap.Bool("stable_diffusion", localSD),
+		zap.Bool("llama", localLlama),
+		zap.Duration("start_timeout", isolation.StartTimeout))
+	err = supervisor.Start(ctx, func(ctx context.Context) error {
+		if sdWorker != nil {
+			if _, err := sdWorker.Health(ctx); err != nil {
+				return err
+			}
+		}
+		if llamaWorker != nil {
+			if _, err := llamaWorker.Health(ctx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if sdWorker == nil {
+		return supervisor, nil, llamaWorker, nil
+	}
+
+	processor, err := imagegen.NewProcessorWithBackend(sdWorker, client, logger, newProcessorConfig(sdConfig, config))
+	if err != nil {
+		supervisor.Stop(ctx)
+		return nil, nil, nil, fmt.Errorf("failed to create image processor: %w", err)
+	}
+	return supervisor, processor, llamaWorker, nil
+}
+
+// newProcessorConfig returns the imagegen processor settings for sdConfig.
+func newProcessorConfig(sdConfig *sdruntime.SDConfig, config *core.Config) imagegen.ProcessorConfig {
+	return imagegen.ProcessorConfig{
+		DownloadsDir:    config.DownloadsDir,
+		DefaultWidth:    sdConfig.ImageSize,
+		DefaultHeight:   sdConfig.ImageSize,
+		DefaultSteps:    sdConfig.InferenceSteps,
+		DefaultCFGScale: sdConfig.GuidanceScale,
+		PlacementConfig: imagegen.DefaultPlacementConfig(),
+		ProcessingNote:  imagegen.DefaultProcessingNoteConfig(),
+		Output:          imagegen.OutputOptionsFromConfig(config),
+		VRAMRetryLadder: sdConfig.VRAMRetryLadder,
+	}
+}
+
 // initializeLlamaRuntime initializes the llamaruntime LLM client.
 // Returns (nil, nil, nil, nil) if llamaruntime is not configured (no model path).
 // Returns (nil, nil, nil, error) if llamaruntime is configured but initialization fails.
@@ -577,6 +1329,47 @@ func initializeLlamaRuntime(logger *logging.Logger, ctx context.Context) (*llama
 	loaderConfig.ModelURL = os.Getenv("LLAMA_MODEL_URL")
 	loaderConfig.RunStartupTest = true
 
+	// Size GPU layer offload to free VRAM, leaving room for SD when it is enabled
+	loaderConfig.AutoOffload = core.ParseBoolEnv("LLAMA_AUTO_OFFLOAD", true)
+	defaultHeadroomMB := int64(1024)
+	if os.Getenv("SD_MODEL_PATH") != "" {
+		defaultHeadroomMB = 4096
+	}
+	loaderConfig.VRAMHeadroom = core.ParseInt64Env("LLAMA_VRAM_HEADROOM_MB", defaultHeadroomMB) * 1024 * 1024
+
+	// Attention and KV cache tuning for memory-constrained GPUs
+	contextOptions, batchSize, err := llamaContextTuningFromEnv()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid llama context settings: %w", err)
+	}
+	loaderConfig.ContextOptions = contextOptions
+	loaderConfig.BatchSize = batchSize
+
+	// Smallest context window to fall back to when the default does not fit
+	loaderConfig.MinContextSize = core.ParseIntEnv("LLAMA_MIN_CONTEXT_SIZE", llamaruntime.DefaultMinContextSize)
+	logger.Info("llamaruntime context settings",
+		zap.Bool("flash_attn", !contextOptions.DisableFlashAttention),
+		zap.String("type_k", string(contextOptions.TypeK)),
+		zap.String("type_v", string(contextOptions.TypeV)),
+		zap.Int("n_batch", batchSize),
+	)
+
+	// Chat template override; detected from the model's GGUF metadata when unset
+	loaderConfig.ChatTemplate = os.Getenv("LLAMA_CHAT_TEMPLATE")
+
+	// Decode concurrent text requests together in one multi-sequence context
+	loaderConfig.ParallelSequences = core.ParseIntEnv("LLAMA_PARALLEL_SEQUENCES", 0)
+	if mode, _ := deterministic.ConfigFromEnv(); mode.Enabled && loaderConfig.ParallelSequences > 1 {
+		// Requests decoded together sample differently depending on what
+		// runs alongside them
+		logger.Info("Batched decoding disabled by TEST_DETERMINISTIC")
+		loaderConfig.ParallelSequences = 0
+	}
+	if loaderConfig.ParallelSequences > 1 {
+		logger.Info("llamaruntime batched decoding enabled",
+			zap.Int("parallel_sequences", loaderConfig.ParallelSequences))
+	}
+
 	// Create model loader
 	loader := llamaruntime.NewModelLoader(loaderConfig)
 
@@ -647,27 +1440,116 @@ func initializeLlamaRuntime(logger *logging.Logger, ctx context.Context) (*llama
 	return client, healthChecker, gpuMonitor, nil
 }
 
-// runStartupValidation performs comprehensive startup validation.
-// This includes configuration validation and optionally model availability checks.
-//
-// Returns the appropriate exit code:
-//   - ExitCodeSuccess (0) if all validations pass
-//   - ExitCodeError (1) if any validation fails
-func runStartupValidation(logger *logging.Logger, isDevelopment bool) int {
-	logger.Info("Starting startup validation...")
+// warmupModels runs a tiny generation on each available local model in a
+// background goroutine, so first requests do not pay for CUDA kernel
+// compilation and cache warm-up. Models are warmed one at a time since they
+// share the GPU. Each model is registered with the readiness tracker before
+// this function returns.
+func warmupModels(ctx context.Context, logger *logging.Logger, readiness *webui.ReadinessTracker, llamaClient LlamaModel, sdPool *sdruntime.ContextPool) {
+	type warmup struct {
+		name string
+		run  func(context.Context) error
+	}
 
-	// Determine if self-signed certs are allowed
-	allowSelfSigned := os.Getenv("ALLOW_SELF_SIGNED_CERTS") == "true"
+	var warmups []warmup
+	if llamaClient != nil {
+		warmups = append(warmups, warmup{"llama", llamaClient.Warmup})
+	}
+	if sdPool != nil {
+		warmups = append(warmups, warmup{"stable-diffusion", sdPool.Warmup})
+	}
+	for _, w := range warmups {
+		readiness.Add(w.name)
+	}
 
-	// Run configuration validation suite
-	suite := validation.NewValidationSuite().
-		WithAllowSelfSignedCerts(allowSelfSigned).
-		WithShowProgress(true)
+	go func() {
+		for _, w := range warmups {
+			logger.Info("Warming up model", zap.String("model", w.name))
+			start := time.Now()
+			if err := w.run(ctx); err != nil {
+				logger.Warn("Model warmup failed",
+					zap.String("model", w.name),
+					zap.Error(err),
+				)
+				readiness.MarkFailed(w.name, err)
+				continue
+			}
+			logger.Info("Model warmup complete",
+				zap.String("model", w.name),
+				zap.Duration("duration", time.Since(start)),
+			)
+			readiness.MarkReady(w.name)
+		}
+	}()
+}
 
-	result := suite.Validate()
+// llamaContextTuningFromEnv reads LLAMA_FLASH_ATTN, LLAMA_TYPE_K, LLAMA_TYPE_V
+// and LLAMA_N_BATCH. Unlike the lenient env helpers, invalid values are
+// reported as errors, since silently falling back could exhaust VRAM.
+func llamaContextTuningFromEnv() (llamaruntime.ContextOptions, int, error) {
+	var opts llamaruntime.ContextOptions
 
-	if !result.Success {
-		logger.Error("Configuration validation failed",
+	if value := strings.TrimSpace(os.Getenv("LLAMA_FLASH_ATTN")); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return opts, 0, fmt.Errorf("LLAMA_FLASH_ATTN must be true or false, got %q", value)
+		}
+		opts.DisableFlashAttention = !enabled
+	}
+
+	typeK, err := llamaruntime.ParseKVCacheType(os.Getenv("LLAMA_TYPE_K"))
+	if err != nil {
+		return opts, 0, fmt.Errorf("LLAMA_TYPE_K: %w", err)
+	}
+	typeV, err := llamaruntime.ParseKVCacheType(os.Getenv("LLAMA_TYPE_V"))
+	if err != nil {
+		return opts, 0, fmt.Errorf("LLAMA_TYPE_V: %w", err)
+	}
+	opts.TypeK = typeK
+	opts.TypeV = typeV
+
+	if err := opts.Validate(); err != nil {
+		return opts, 0, fmt.Errorf("%w (set LLAMA_FLASH_ATTN=true or LLAMA_TYPE_V=f16)", err)
+	}
+
+	batchSize := llamaruntime.DefaultBatchSize
+	if value := strings.TrimSpace(os.Getenv("LLAMA_N_BATCH")); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > llamaruntime.DefaultContextSize {
+			return opts, 0, fmt.Errorf("LLAMA_N_BATCH must be between 1 and %d, got %q",
+				llamaruntime.DefaultContextSize, value)
+		}
+		batchSize = n
+	}
+
+	return opts, batchSize, nil
+}
+
+// runStartupValidation performs comprehensive startup validation.
+// This includes configuration validation and optionally model availability checks.
+//
+// Returns the appropriate exit code:
+//   - ExitCodeSuccess (0) if all validations pass
+//   - ExitCodeError (1) if any validation fails
+func runStartupValidation(logger *logging.Logger, isDevelopment bool) int {
+	logger.Info("Starting startup validation...")
+
+	// Determine if self-signed certs are allowed
+	allowSelfSigned := os.Getenv("ALLOW_SELF_SIGNED_CERTS") == "true"
+
+	// Run configuration validation suite, including the module checks
+	suite := validation.NewValidationSuite().
+		WithAllowSelfSignedCerts(allowSelfSigned).
+		WithShowProgress(true).
+		Register(llamaruntime.ValidationChecks()...).
+		Register(sdruntime.ValidationChecks()...).
+		Register(imagegen.ValidationChecks()...).
+		Register(ocrprocessor.ValidationChecks()...)
+
+	result := suite.Validate()
+
+	if !result.Success {
+		logger.Error("Configuration validation failed",
 			zap.Int("passed", result.PassedSteps),
 			zap.Int("failed", result.FailedSteps),
 			zap.Duration("duration", result.Duration),
@@ -679,6 +1561,7 @@ func runStartupValidation(logger *logging.Logger, isDevelopment bool) int {
 				logger.Error("Validation step failed",
 					zap.String("step", step.Name),
 					zap.String("message", step.Message),
+					zap.String("hint", step.Hint),
 					zap.Error(step.Error),
 				)
 			}
@@ -704,6 +1587,835 @@ func runStartupValidation(logger *logging.Logger, isDevelopment bool) int {
 	return core.ExitCodeSuccess
 }
 
+// newCanvusWatchdog creates the Canvus health watchdog from the WATCHDOG_*
+// settings and logs which alert channels are enabled. Outages are also sent
+// to the webhook dispatcher when it has targets.
+func newCanvusWatchdog(logger *logging.Logger, dispatcher *webhooks.Dispatcher) *watchdog.Watchdog {
+	cfg := watchdog.ConfigFromEnv()
+	cfg.Logger = logger.Zap()
+	if dispatcher.Enabled() {
+		cfg.Notifiers = append(cfg.Notifiers, dispatcher)
+	}
+
+	logger.Info("Canvus watchdog configured",
+		zap.Int("failure_threshold", cfg.FailureThreshold),
+		zap.Bool("pause_processing", cfg.PauseProcessing),
+		zap.Int("notifiers", len(cfg.Notifiers)),
+	)
+	return watchdog.New(cfg)
+}
+
+// newClusterNode joins the cluster at CLUSTER_URL and keeps sending
+// heartbeats. It returns nil when clustering is off or the queue server
+// cannot be reached; the instance then does all of its work itself.
+func newClusterNode(ctx context.Context, logger *logging.Logger, config *core.Config) *cluster.Node {
+	cfg, err := cluster.ConfigFromEnv()
+	if err != nil {
+		logger.Warn("Invalid cluster settings, running on its own", zap.Error(err))
+		return nil
+	}
+	if !cfg.Enabled() {
+		return nil
+	}
+	cfg.CanvasID = config.CanvasID
+	cfg.Logger = logger.Zap()
+
+	backend, err := cluster.NewRedisBackend(ctx, cfg.URL, 5*time.Second)
+	if err != nil {
+		logger.Warn("Cannot reach the cluster queue, running on its own", zap.Error(err))
+		return nil
+	}
+	node := cluster.New(backend, cfg)
+	if err := node.Join(ctx); err != nil {
+		logger.Warn("Cannot join the cluster, running on its own", zap.Error(err))
+		backend.Close()
+		return nil
+	}
+	go node.Run(ctx)
+	return node
+}
+
+// newInstanceLease takes the canvas lease from the INSTANCE_LEASE settings
+// and keeps renewing it. It returns nil when the lease is off. With
+// INSTANCE_LEASE=refuse the process exits if another instance already holds
+// the lease; otherwise it stands by until that lease expires.
+func newInstanceLease(ctx context.Context, logger *logging.Logger, client *canvusapi.Client) *instancelease.Lease {
+	cfg, err := instancelease.ConfigFromEnv()
+	if err != nil {
+		logger.Warn("Invalid INSTANCE_LEASE, deferring to other instances", zap.Error(err))
+	}
+	if cfg.Mode == instancelease.ModeOff {
+		logger.Info("Instance lease disabled; other instances on this canvas will duplicate responses")
+		return nil
+	}
+	cfg.Logger = logger.Zap()
+
+	lease := instancelease.New(client, cfg)
+	err = lease.Acquire()
+	switch {
+	case errors.Is(err, instancelease.ErrHeldElsewhere) && cfg.Mode == instancelease.ModeRefuse:
+		holder := lease.Status().Holder
+		logger.Fatal("Another instance is already processing this canvas",
+			zap.String("holder", holder.Instance),
+			zap.String("holder_host", holder.Host),
+			zap.Int("holder_pid", holder.PID),
+			zap.String("hint", "stop the other instance, or set INSTANCE_LEASE=defer to stand by"))
+	case err != nil && !errors.Is(err, instancelease.ErrHeldElsewhere):
+		logger.Warn("Could not check the canvas lease, retrying", zap.Error(err))
+	}
+
+	go lease.Run(ctx)
+	return lease
+}
+
+// newMetricsPersister restores the metrics store from the database and
+// starts saving it every METRICS_PERSIST_INTERVAL seconds (default: 60).
+func newMetricsPersister(ctx context.Context, logger *logging.Logger, store *metrics.MetricsStore, repository *db.Repository) *metrics.Persister {
+	interval := core.ParseDurationEnv("METRICS_PERSIST_INTERVAL", int(metrics.DefaultPersistInterval/time.Second))
+	persister := metrics.NewPersister(store, repository, interval)
+
+	if found, err := persister.Restore(ctx); err != nil {
+		logger.Warn("Failed to restore persisted metrics", zap.Error(err))
+	} else if found {
+		taskMetrics := store.GetTaskMetrics()
+		logger.Info("Restored persisted metrics",
+			zap.Int64("total_processed", taskMetrics.TotalProcessed),
+			zap.Int64("total_errors", taskMetrics.TotalErrors),
+		)
+	}
+
+	go persister.Run(ctx, func(err error) {
+		logger.Warn("Failed to persist metrics", zap.Error(err))
+	})
+	return persister
+}
+
+// newGPUHistory starts saving GPU samples to the database every minute,
+// with 5-minute and hourly rollups for longer ranges.
+func newGPUHistory(ctx context.Context, logger *logging.Logger, repository *db.Repository) *metrics.GPUHistory {
+	history := metrics.NewGPUHistory(repository)
+	go history.Run(ctx, func(err error) {
+		logger.Warn("Failed to persist GPU history", zap.Error(err))
+	})
+	return history
+}
+
+// newCanvasPreviewer creates the dashboard's canvas map for the monitored
+// canvases. Layouts are reused for up to CANVAS_PREVIEW_REFRESH seconds.
+func newCanvasPreviewer(logger *logging.Logger, config *core.Config, broadcaster *webui.WebSocketBroadcaster) *canvaspreview.Previewer {
+	return canvaspreview.New(canvaspreview.Config{
+		RefreshInterval: core.ParseDurationEnv("CANVAS_PREVIEW_REFRESH", 30),
+		Logger:          logger.Zap(),
+	}, func(canvasID string) canvaspreview.Client {
+		return canvusapi.NewClient(config.CanvusServerURL, canvasID, config.CanvusAPIKey, config.AllowSelfSignedCerts)
+	}, config.GetCanvasIDs(), broadcaster)
+}
+
+// newLatencyWatcher creates the latency watcher from LATENCY_P95_THRESHOLDS.
+// Breaches and recoveries are logged and published on bus as
+// events.LatencyChanged. It returns nil when no thresholds are configured.
+func newLatencyWatcher(logger *logging.Logger, store *metrics.MetricsStore, bus *events.Bus) *metrics.LatencyWatcher {
+	thresholds, err := metrics.ParseLatencyThresholds(os.Getenv("LATENCY_P95_THRESHOLDS"))
+	if err != nil {
+		logger.Warn("Latency alerts disabled", zap.Error(err))
+		return nil
+	}
+	watcher := metrics.NewLatencyWatcher(store, metrics.LatencyWatcherConfig{Thresholds: thresholds})
+	if !watcher.Enabled() {
+		return nil
+	}
+
+	watcher.SetOnAlert(func(alert metrics.LatencyAlert) {
+		fields := []zap.Field{
+			zap.String("task_type", alert.TaskType),
+			zap.String("window", alert.Window),
+			zap.Duration("p95", alert.P95),
+			zap.Duration("threshold", alert.Threshold),
+			zap.Int("samples", alert.Samples),
+		}
+		if alert.Breached {
+			logger.Warn("Task latency above threshold", fields...)
+		} else {
+			logger.Info("Task latency back below threshold", fields...)
+		}
+		events.Publish(bus, events.LatencyChanged{Alert: alert})
+	})
+	logger.Info("Latency alerts enabled", zap.Int("thresholds", len(thresholds)))
+	return watcher
+}
+
+// recordCanvasEvents records every trigger outcome published on bus as a
+// canvas event, so the widget inspector shows why a widget did or did not
+// get a response.
+func recordCanvasEvents(bus *events.Bus, repo *db.Repository, logger *logging.Logger) {
+	if repo == nil {
+		return
+	}
+	events.Subscribe(bus, "canvas-events", func(e events.TriggerHandled) {
+		event := db.CanvasEvent{
+			CanvasID:       e.CanvasID,
+			WidgetID:       e.WidgetID,
+			EventType:      e.Outcome,
+			WidgetType:     e.WidgetType,
+			ContentPreview: truncateText(e.Detail, 500),
+		}
+		if _, err := repo.InsertCanvasEvent(context.Background(), event); err != nil {
+			logger.Warn("failed to record canvas event",
+				zap.String("widget_id", event.WidgetID),
+				zap.Error(err))
+		}
+	})
+}
+
+// newSLOMonitor creates the SLO monitor from SLO_OBJECTIVES and
+// SLO_BURN_RATE_THRESHOLD. Violations and recoveries are logged and
+// published on bus as events.SLOChanged, for the dashboard and webhooks.
+// It returns nil when no SLOs are configured.
+func newSLOMonitor(logger *logging.Logger, store *metrics.MetricsStore, bus *events.Bus) *metrics.SLOMonitor {
+	slos, err := metrics.ParseSLOs(os.Getenv("SLO_OBJECTIVES"))
+	if err != nil {
+		logger.Warn("SLO monitoring disabled", zap.Error(err))
+		return nil
+	}
+	if len(slos) == 0 {
+		return nil
+	}
+	monitor := metrics.NewSLOMonitor(store, metrics.SLOConfig{
+		SLOs:              slos,
+		BurnRateThreshold: core.ParseFloat64Env("SLO_BURN_RATE_THRESHOLD", 2),
+	})
+
+	monitor.SetOnAlert(func(status metrics.SLOStatus) {
+		fields := []zap.Field{
+			zap.String("slo", status.SLO),
+			zap.String("task_type", status.TaskType),
+			zap.Float64("short_burn_rate", status.ShortBurnRate),
+			zap.Float64("long_burn_rate", status.LongBurnRate),
+			zap.Int("samples", status.Samples),
+		}
+		if status.Violated {
+			logger.Warn("SLO violated", fields...)
+		} else {
+			logger.Info("SLO recovered", fields...)
+		}
+		events.Publish(bus, events.SLOChanged{Status: status})
+	})
+	logger.Info("SLO monitoring enabled", zap.Int("objectives", len(slos)))
+	return monitor
+}
+
+// newArtifactStore creates the artifact store from the ARTIFACT_* settings
+// and starts pruning it if a retention policy is set. It returns nil when
+// ARTIFACT_STORE is not set or the store cannot be created.
+func newArtifactStore(ctx context.Context, logger *logging.Logger) *artifacts.Store {
+	cfg := artifacts.ConfigFromEnv()
+	store, err := artifacts.NewStoreFromConfig(cfg)
+	if err != nil {
+		logger.Warn("Artifact storage disabled", zap.Error(err))
+		return nil
+	}
+	if store == nil {
+		return nil
+	}
+
+	if cfg.Retention.Enabled() {
+		go artifacts.NewPruner(store, cfg.Retention, logger.Zap()).Run(ctx)
+	}
+	logger.Info("Artifact storage enabled",
+		zap.String("backend", store.Backend()),
+		zap.Duration("max_age", cfg.Retention.MaxAge),
+		zap.Int("max_count", cfg.Retention.MaxCount),
+	)
+	return store
+}
+
+// newImageVariants creates the history of the images generated from each
+// trigger note. It returns nil when IMAGE_VARIANTS_KEEP is 0.
+func newImageVariants(logger *logging.Logger, repository *db.Repository) *variants.History {
+	cfg := variants.ConfigFromEnv()
+	history := variants.NewHistory(repository, cfg.Keep)
+	if history == nil {
+		return nil
+	}
+	logger.Info("Image variant history enabled", zap.Int("keep_per_note", cfg.Keep))
+	return history
+}
+
+// newNoteFixes creates the history of {{fix}} corrections. It returns nil
+// when NOTE_FIX_UNDO_KEEP is 0.
+func newNoteFixes(logger *logging.Logger, repository *db.Repository) *notefix.History {
+	cfg := notefix.ConfigFromEnv()
+	history := notefix.NewHistory(repository, cfg.Keep)
+	if history == nil {
+		return nil
+	}
+	logger.Info("Note fix undo enabled", zap.Int("keep_per_note", cfg.Keep))
+	return history
+}
+
+// newWhisperClient creates the Whisper client from the WHISPER_*
+// settings. It returns nil when WHISPER_URL is not set.
+func newWhisperClient(config *core.Config) *whisperruntime.Client {
+	cfg := whisperruntime.ConfigFromEnv(config.OpenAIAPIKey)
+	if cfg.URL == "" {
+		return nil
+	}
+	return whisperruntime.NewClient(cfg, core.OpenAIClient(config, cfg.URL, cfg.APIKey, cfg.Timeout))
+}
+
+// newVoiceNotes creates the transcriber of uploaded audio files from the
+// VOICE_NOTE_* settings. It returns nil without a Whisper client.
+func newVoiceNotes(logger *logging.Logger, client *whisperruntime.Client, repository *db.Repository) *voicenote.Transcriber {
+	if client == nil {
+		return nil
+	}
+	notes := voicenote.ConfigFromEnv()
+	logger.Info("Voice note transcription enabled",
+		zap.String("model", client.Model()),
+		zap.Duration("summary_after", notes.SummaryAfter))
+	return voicenote.NewTranscriber(notes, client, repository)
+}
+
+// newLiveTranscripts creates the manager of {{live}} meeting transcripts
+// from the LIVE_TRANSCRIPT_* settings. It returns nil when
+// LIVE_TRANSCRIPT_SOURCE is not set or invalid, or without a Whisper
+// client.
+func newLiveTranscripts(logger *logging.Logger, client *whisperruntime.Client) *livetranscript.Manager {
+	cfg := livetranscript.ConfigFromEnv()
+	if cfg.Source == "" {
+		return nil
+	}
+	if client == nil {
+		logger.Warn("LIVE_TRANSCRIPT_SOURCE is set without WHISPER_URL, live transcription disabled")
+		return nil
+	}
+	source, err := livetranscript.NewFFmpegSource(cfg)
+	if err != nil {
+		logger.Warn("Invalid LIVE_TRANSCRIPT_SOURCE, live transcription disabled", zap.Error(err))
+		return nil
+	}
+	logger.Info("Live transcription enabled",
+		zap.String("source", cfg.Source),
+		zap.Duration("chunk", cfg.Chunk),
+		zap.Duration("summary_every", cfg.SummaryEvery))
+	return livetranscript.NewManager(cfg, client, source, logger.Zap())
+}
+
+// newNoteTagger creates the background note tagger from the AUTO_TAG*
+// settings. It returns nil when AUTO_TAGS is off or invalid.
+func newNoteTagger(logger *logging.Logger, repository *db.Repository, monitor *Monitor) *notetags.Tagger {
+	cfg, err := notetags.ConfigFromEnv()
+	if err != nil {
+		logger.Warn("Invalid AUTO_TAGS, note auto-tagging disabled", zap.Error(err))
+	}
+	tagger := notetags.NewTagger(cfg, repository, monitor.GenerateTags, logger.Zap())
+	if tagger == nil {
+		return nil
+	}
+	logger.Info("Note auto-tagging enabled",
+		zap.String("mode", string(cfg.Mode)),
+		zap.Int("max_tags", cfg.MaxTags),
+		zap.Duration("delay", cfg.Delay))
+	return tagger
+}
+
+// newTempFileManager creates the temp file manager from the TEMP_* settings,
+// removing files left by a previous run. It returns nil if the directory
+// cannot be used; handlers then write unmanaged files to downloadsDir.
+func newTempFileManager(logger *logging.Logger, downloadsDir string) *tempfiles.TempFileManager {
+	cfg := tempfiles.ConfigFromEnv(downloadsDir)
+	manager, err := tempfiles.NewTempFileManager(cfg, logger.Zap())
+	if err != nil {
+		logger.Warn("Temp file manager disabled", zap.Error(err))
+		return nil
+	}
+	logger.Info("Temp file manager enabled",
+		zap.String("dir", cfg.Dir),
+		zap.Int64("quota_bytes", cfg.QuotaBytes),
+	)
+	return manager
+}
+
+// readBuildInfo returns the build report with the optional subsystems
+// that depend on build tags and linked libraries.
+func readBuildInfo() buildinfo.Report {
+	return buildinfo.Read(
+		buildinfo.Subsystem{Name: "llama.cpp", Compiled: llamaruntime.Linked, Detail: llamaruntime.SystemInfo()},
+		buildinfo.Subsystem{Name: "stable-diffusion.cpp", Compiled: sdruntime.Bindings == "sd", Detail: sdruntime.GetBackendInfo()},
+		buildinfo.Subsystem{Name: "nvml", Compiled: metrics.NVMLLinked},
+		buildinfo.Subsystem{Name: "windows-service", Compiled: runtime.GOOS == "windows"},
+	)
+}
+
+// newUpdater starts the self-updater from the UPDATE_* settings. It returns
+// nil when updates are not configured or the settings are invalid. The
+// binary replaced by the last update is removed first.
+func newUpdater(ctx context.Context, logger *logging.Logger) *updater.Updater {
+	cfg, err := updater.ConfigFromEnv()
+	if err != nil {
+		logger.Warn("Self-update disabled", zap.Error(err))
+		return nil
+	}
+	if !cfg.Enabled() {
+		return nil
+	}
+	exe, err := executablePath()
+	if err != nil {
+		logger.Warn("Self-update disabled", zap.Error(err))
+		return nil
+	}
+	if err := updater.RemoveOld(exe); err != nil {
+		logger.Warn("Failed to remove the binary replaced by the last update", zap.Error(err))
+	}
+
+	u := updater.New(cfg, core.GetVersion(), exe, logger.Zap())
+	go u.Run(ctx)
+	logger.Info("Self-update enabled",
+		zap.String("channel", cfg.Channel),
+		zap.String("version", core.GetVersion()),
+		zap.Duration("check_interval", cfg.CheckInterval),
+		zap.Bool("auto_download", cfg.AutoDownload),
+	)
+	return u
+}
+
+// loadPromptVariants loads the system prompt variants in PROMPTS_DIR.
+// Prompts without a variant ask the model to answer in the configured
+// language instead.
+func loadPromptVariants(logger *logging.Logger, language string) {
+	logger.Info("Canvas language", zap.String("language", i18n.Name(language)))
+	dir := os.Getenv("PROMPTS_DIR")
+	if dir == "" {
+		return
+	}
+	n, err := i18n.LoadPromptVariants(dir)
+	if err != nil {
+		logger.Warn("Failed to load prompt variants", zap.String("dir", dir), zap.Error(err))
+		return
+	}
+	logger.Info("Prompt variants loaded", zap.String("dir", dir), zap.Int("variants", n))
+}
+
+// newRedactor creates the PII redactor from the PII_REDACTION* settings. It
+// returns nil when redaction is off. Names are found by the local model, so
+// they are only redacted when llamaClient is available.
+func newRedactor(logger *logging.Logger, llamaClient LlamaModel) *redact.Redactor {
+	cfg, err := redact.ConfigFromEnv()
+	if err != nil {
+		logger.Warn("Invalid PII_REDACTION_KINDS, redacting all kinds", zap.Error(err))
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var names redact.NameDetector
+	if llamaClient != nil {
+		names = redact.NewLLMNameDetector(func(ctx context.Context, prompt string) (string, error) {
+			return llamaClient.Generate(ctx, prompt, llamaruntime.GenerationParams{
+				MaxTokens:   256,
+				Temperature: 0,
+			})
+		})
+	}
+	redactor := redact.NewRedactor(cfg, names)
+	logger.Info("PII redaction enabled",
+		zap.Bool("emails", redactor.Redacts(redact.KindEmail)),
+		zap.Bool("phones", redactor.Redacts(redact.KindPhone)),
+		zap.Bool("names", redactor.Redacts(redact.KindName)),
+	)
+	return redactor
+}
+
+// newPromptGuard creates the prompt-injection guard from the PROMPT_GUARD*
+// settings. It returns nil when the guard is off.
+func newPromptGuard(logger *logging.Logger) *promptguard.Guard {
+	cfg, err := promptguard.ConfigFromEnv()
+	if err != nil {
+		logger.Warn("Invalid PROMPT_GUARD_MODE, neutralizing", zap.Error(err))
+	}
+	if !cfg.Enabled {
+		logger.Info("Prompt injection guard disabled")
+		return nil
+	}
+	guard := promptguard.NewGuard(cfg)
+	logger.Info("Prompt injection guard enabled", zap.String("mode", string(guard.Mode())))
+	return guard
+}
+
+// documentSummarizer summarizes the sections of imported documents on the
+// local model. It returns nil without one, and imports then show the start
+// of each section.
+func documentSummarizer(llamaClient LlamaModel) core.GenerateFunc {
+	if llamaClient == nil {
+		return nil
+	}
+	return func(ctx context.Context, prompt string) (string, error) {
+		return llamaClient.Generate(ctx, prompt, llamaruntime.GenerationParams{
+			MaxTokens:   300,
+			Temperature: 0.3,
+		})
+	}
+}
+
+// newMailGateway creates the email-in gateway from the MAILIN_* and SMTP_*
+// settings. It returns nil when no IMAP server is configured. Emails are
+// summarized by the local model when llamaClient is available.
+func newMailGateway(logger *logging.Logger, config *core.Config, llamaClient LlamaModel) *mailin.Gateway {
+	cfg := mailin.ConfigFromEnv()
+	if !cfg.Enabled() {
+		return nil
+	}
+	if len(cfg.AllowedSenders) == 0 {
+		logger.Warn("Email gateway accepts mail from any sender; set MAILIN_ALLOWED_SENDERS to restrict it")
+	}
+	if cfg.Reply && !cfg.SMTP.Enabled() {
+		logger.Info("Email gateway replies disabled: SMTP_HOST is not set")
+	}
+	cfg.Logger = logger.Zap()
+
+	logger.Info("Email gateway enabled",
+		zap.String("imap_host", cfg.IMAP.Host),
+		zap.String("mailbox", cfg.IMAP.Mailbox),
+		zap.Int("allowed_senders", len(cfg.AllowedSenders)),
+	)
+	return mailin.New(cfg, mailin.NewIMAPMailbox(cfg.IMAP), func(canvasID string) mailin.Client {
+		return canvusapi.NewClient(config.CanvusServerURL, canvasID, config.CanvusAPIKey, config.AllowSelfSignedCerts)
+	}, config.GetCanvasIDs(), documentSummarizer(llamaClient), config.DownloadsDir)
+}
+
+// newChatOpsAPI creates the Slack and Teams endpoints from the
+// SLACK_SIGNING_SECRET, TEAMS_WEBHOOK_SECRET and CHATOPS_CANVAS_ID settings.
+// It returns nil when neither platform is configured. Questions are answered
+// like note prompts by the monitor.
+func newChatOpsAPI(logger *logging.Logger, config *core.Config, monitor *Monitor) *webui.ChatOpsAPI {
+	cfg := chatops.ConfigFromEnv()
+	if !cfg.Enabled() {
+		return nil
+	}
+	if cfg.CanvasID == "" {
+		if ids := config.GetCanvasIDs(); len(ids) > 0 {
+			cfg.CanvasID = ids[0]
+		}
+	}
+	logger.Info("Chat integrations enabled",
+		zap.Bool("slack", cfg.SlackSigningSecret != ""),
+		zap.Bool("teams", cfg.TeamsSecret != ""),
+		zap.String("canvas_id", cfg.CanvasID),
+	)
+	client := canvusapi.NewClient(config.CanvusServerURL, cfg.CanvasID, config.CanvusAPIKey, config.AllowSelfSignedCerts)
+	return webui.NewChatOpsAPI(chatops.NewAsker(client, monitor.ReplayNotePrompt, logger.Zap()), cfg, logger.Zap())
+}
+
+// newOutputFilter creates the brand-safety filter from the OUTPUT_FILTER*
+// settings. It returns nil when the filter is off or has nothing to check.
+// The classifier runs on the local model, so it is only used when
+// llamaClient is available.
+func newOutputFilter(logger *logging.Logger, llamaClient LlamaModel) *outputfilter.Filter {
+	cfg, err := outputfilter.ConfigFromEnv()
+	if err != nil {
+		logger.Warn("Invalid output filter settings", zap.Error(err))
+	}
+	if !cfg.Enabled {
+		return nil
+	}
+
+	var classifier outputfilter.Classifier
+	if cfg.Classifier {
+		if llamaClient == nil {
+			logger.Warn("OUTPUT_FILTER_CLASSIFIER needs the local model, using the word list only")
+		} else {
+			classifier = outputfilter.NewLLMClassifier(func(ctx context.Context, prompt string) (string, error) {
+				return llamaClient.Generate(ctx, prompt, llamaruntime.GenerationParams{
+					MaxTokens:   64,
+					Temperature: 0,
+				})
+			})
+		}
+	}
+	filter := outputfilter.NewFilter(cfg, classifier)
+	if filter.Terms() == 0 && !filter.Classifies() {
+		logger.Warn("Output filter enabled but has no word list or classifier, disabling it")
+		return nil
+	}
+	logger.Info("Output filter enabled",
+		zap.String("mode", string(filter.Mode())),
+		zap.Int("terms", filter.Terms()),
+		zap.Bool("classifier", filter.Classifies()),
+	)
+	return filter
+}
+
+// newIntentClassifier creates the note intent classifier from
+// INTENT_CLASSIFIER. The local mode needs the local model; without it
+// only the heuristic is used.
+func newIntentClassifier(logger *logging.Logger, llamaClient LlamaModel) *intent.Classifier {
+	mode, err := intent.ModeFromEnv()
+	if err != nil {
+		logger.Warn("Invalid INTENT_CLASSIFIER, using the heuristic", zap.Error(err))
+	}
+
+	var generate core.GenerateFunc
+	if mode == intent.ModeLocal {
+		if llamaClient == nil {
+			logger.Warn("INTENT_CLASSIFIER=local needs the local model, using the heuristic only")
+		} else {
+			generate = func(ctx context.Context, prompt string) (string, error) {
+				return llamaClient.Generate(ctx, prompt, llamaruntime.GenerationParams{
+					MaxTokens:   4,
+					Temperature: 0,
+				})
+			}
+		}
+	}
+	logger.Info("Note intent classifier", zap.String("mode", string(mode)))
+	return intent.NewClassifier(mode, generate)
+}
+
+// newPromptExpander creates the image prompt expander from the
+// PROMPT_EXPANSION* settings.
+func newPromptExpander(logger *logging.Logger) *promptexpander.Expander {
+	cfg, err := promptexpander.ConfigFromEnv()
+	if err != nil {
+		logger.Warn("Invalid PROMPT_EXPANSION_CREATIVITY, using medium", zap.Error(err))
+	}
+	expander := promptexpander.NewExpander(cfg)
+	if expander.Enabled() {
+		logger.Info("Image prompt expansion enabled",
+			zap.String("creativity", string(expander.Config().Creativity)),
+			zap.Int("max_length", expander.Config().MaxLength))
+	} else {
+		logger.Info("Image prompt expansion disabled, image requests are used as written")
+	}
+	return expander
+}
+
+// newThermalThrottle creates the throttle that slows local image generation
+// while the GPU is over its thermal or power limits, when THERMAL_THROTTLE
+// is set, and installs it in the image processor. It returns nil when
+// throttling is off or local image generation is not available.
+func newThermalThrottle(logger *logging.Logger, sdPool *sdruntime.ContextPool, imageProcessor *imagegen.Processor) *thermal.Throttle {
+	cfg := thermal.ConfigFromEnv()
+	if !cfg.Enabled {
+		return nil
+	}
+	if sdPool == nil || imageProcessor == nil {
+		logger.Warn("THERMAL_THROTTLE needs local image generation (SD_MODEL_PATH), ignoring it")
+		return nil
+	}
+
+	throttle := thermal.NewThrottle(cfg, sdPool.MaxSize())
+	imageProcessor.SetThrottle(throttle)
+	logger.Info("Thermal throttling enabled",
+		zap.Float64("temp_limit", cfg.TempLimit),
+		zap.Float64("hysteresis", cfg.Hysteresis),
+		zap.Float64("power_limit_percent", cfg.PowerLimit),
+		zap.Int("throttled_concurrency", cfg.Concurrency),
+		zap.Duration("delay", cfg.Delay),
+	)
+	return throttle
+}
+
+// newAuditLog creates the audit trail of AI widget changes when AUDIT_LOG
+// is set, and verifies the existing chain so tampering is reported at
+// startup. It returns nil when auditing is off.
+func newAuditLog(ctx context.Context, logger *logging.Logger, repository *db.Repository) *audit.Log {
+	if !core.ParseBoolEnv("AUDIT_LOG", false) {
+		return nil
+	}
+	auditLog := audit.NewLog(repository)
+	result, err := auditLog.Verify(ctx)
+	switch {
+	case err != nil:
+		logger.Warn("Failed to verify audit log", zap.Error(err))
+	case !result.Valid:
+		logger.Error("Audit log hash chain is broken",
+			zap.Int64("first_bad_seq", result.FirstBadSeq),
+			zap.String("problem", result.Problem),
+		)
+	default:
+		logger.Info("Audit log enabled", zap.Int64("entries", result.Entries))
+	}
+	return auditLog
+}
+
+// newSessionRecorder creates the recorder of canvas sessions when
+// SESSION_RECORDING is set. A canvas idle for SESSION_IDLE_GAP_MINUTES
+// starts a new session. It returns nil when recording is off.
+func newSessionRecorder(logger *logging.Logger, repository *db.Repository) *sessions.Recorder {
+	if !core.ParseBoolEnv("SESSION_RECORDING", false) {
+		return nil
+	}
+	idleGap := time.Duration(core.ParseIntEnv("SESSION_IDLE_GAP_MINUTES", int(sessions.DefaultIdleGap/time.Minute))) * time.Minute
+	logger.Info("Session recording enabled", zap.Duration("idle_gap", idleGap))
+	return sessions.NewRecorder(repository, idleGap)
+}
+
+// newLLMCapture creates the recorder of LLM prompts and responses. Capture
+// is off until started from the dashboard. Redaction masks emails and
+// phone numbers only, since name detection would itself call the model.
+func newLLMCapture(repository *db.Repository) *llmcapture.Recorder {
+	redactor := redact.NewRedactor(redact.Config{
+		Enabled: true,
+		Kinds:   []redact.Kind{redact.KindEmail, redact.KindPhone},
+	}, nil)
+	return llmcapture.NewRecorder(repository, func(ctx context.Context, text string) (string, error) {
+		out, _, err := redactor.Redact(ctx, text)
+		return out, err
+	})
+}
+
+// newCanvasSettings loads the per-canvas settings saved in the database.
+// It returns nil if they cannot be loaded, leaving every canvas on the
+// server configuration.
+func newCanvasSettings(ctx context.Context, logger *logging.Logger, repository *db.Repository) *canvassettings.Store {
+	store, err := canvassettings.NewStore(ctx, repository, repository)
+	if err != nil {
+		logger.Warn("Failed to load canvas settings, using server configuration", zap.Error(err))
+		return nil
+	}
+	if n := len(store.List()); n > 0 {
+		logger.Info("Canvas settings loaded", zap.Int("canvases", n))
+	}
+	return store
+}
+
+// loadCanvasPolicy reads the allow and deny lists of features per canvas
+// from CANVAS_POLICY_FILE. It returns nil when the setting is empty. An
+// unreadable or invalid file stops startup, so a broken policy never lets
+// every feature through.
+func loadCanvasPolicy(logger *logging.Logger) *canvassettings.Policy {
+	path := os.Getenv("CANVAS_POLICY_FILE")
+	policy, err := canvassettings.LoadPolicy(path)
+	if err != nil {
+		logger.Fatal("Failed to load canvas policy", zap.String("file", path), zap.Error(err))
+	}
+	if policy != nil {
+		logger.Info("Canvas policy loaded",
+			zap.String("file", path),
+			zap.Int("canvases", len(policy.Canvases)))
+	}
+	return policy
+}
+
+// newPromptLibrary loads the prompt templates saved in the database. It
+// returns nil if they cannot be loaded, so {{use:name}} triggers fail with
+// an error note.
+func newPromptLibrary(ctx context.Context, logger *logging.Logger, repository *db.Repository) *promptlib.Library {
+	library, err := promptlib.NewLibrary(ctx, repository)
+	if err != nil {
+		logger.Warn("Failed to load prompt library", zap.Error(err))
+		return nil
+	}
+	if n := len(library.List()); n > 0 {
+		logger.Info("Prompt library loaded", zap.Int("templates", n))
+	}
+	return library
+}
+
+// newFewShotExamples loads the few-shot examples saved in the database.
+// It returns nil if they cannot be loaded, so prompts are sent without
+// examples.
+func newFewShotExamples(ctx context.Context, logger *logging.Logger, repository *db.Repository) *fewshot.Set {
+	examples, err := fewshot.NewSet(ctx, repository)
+	if err != nil {
+		logger.Warn("Failed to load few-shot examples", zap.Error(err))
+		return nil
+	}
+	if n := len(examples.List("")); n > 0 {
+		logger.Info("Few-shot examples loaded", zap.Int("examples", n))
+	}
+	return examples
+}
+
+// newFeatureFlags creates the feature flag registry from FEATURE_FLAGS and
+// the overrides saved in the database. It returns nil if the overrides
+// cannot be loaded, leaving every flag at its default.
+func newFeatureFlags(ctx context.Context, logger *logging.Logger, repository *db.Repository) *featureflags.Registry {
+	env, unknown := featureflags.ParseEnv(os.Getenv("FEATURE_FLAGS"))
+	if len(unknown) > 0 {
+		logger.Warn("Ignoring unknown feature flags in FEATURE_FLAGS", zap.Strings("flags", unknown))
+	}
+	registry, err := featureflags.NewRegistry(ctx, repository, env)
+	if err != nil {
+		logger.Warn("Failed to load feature flag overrides, using defaults", zap.Error(err))
+		return nil
+	}
+	return registry
+}
+
+// newGRPCServer creates the gRPC API server from the GRPC_* settings,
+// stamping task start times with clk. It returns nil when GRPC_PORT is not
+// set. markers returns the trigger markers used for submitted prompt notes.
+func newGRPCServer(logger *logging.Logger, store *metrics.MetricsStore, clk clock.Clock, client *canvusapi.Client, canvasID string, markers func() (string, string)) *grpcapi.Server {
+	port := core.ParseIntEnv("GRPC_PORT", 0)
+	if port <= 0 {
+		return nil
+	}
+	server := grpcapi.NewServer(grpcapi.Config{
+		Host:  core.GetEnvOrDefault("GRPC_HOST", ""),
+		Port:  port,
+		Token: os.Getenv("GRPC_TOKEN"),
+	}, store, logger.Zap())
+	server.SetClock(clk)
+	submitter := grpcapi.NewNoteSubmitter(client, canvasID)
+	submitter.SetTriggerMarkers(markers)
+	server.SetTaskSubmitter(submitter)
+	return server
+}
+
+// logShutdownPlan logs the order the shutdown handlers will run in and
+// returns the error if their dependencies form a cycle.
+func logShutdownPlan(logger *logging.Logger, manager *shutdown.Manager) error {
+	steps, err := manager.Plan()
+	order := make([]string, len(steps))
+	for i, step := range steps {
+		order[i] = step.Name
+	}
+	logger.Debug("Shutdown plan", zap.Strings("order", order))
+	return err
+}
+
+// newWebhookDispatcher creates the webhook dispatcher from the WEBHOOK_*
+// settings, stamping events with clk. Without WEBHOOK_URLS it is disabled
+// and sends nothing.
+func newWebhookDispatcher(logger *logging.Logger, clk clock.Clock) *webhooks.Dispatcher {
+	cfg := webhooks.ConfigFromEnv()
+	cfg.Logger = logger.Zap()
+	cfg.Clock = clk
+
+	if len(cfg.Targets) > 0 {
+		logger.Info("Webhook notifications enabled",
+			zap.Int("targets", len(cfg.Targets)),
+			zap.Int("daily_task_budget", cfg.DailyTaskBudget),
+		)
+	}
+	return webhooks.New(cfg)
+}
+
+// newDailyDigest creates the daily digest from the DIGEST_* and SMTP_*
+// settings. It returns nil when the digest is not configured.
+func newDailyDigest(logger *logging.Logger, repository *db.Repository, store metrics.MetricsCollector) *digest.Digest {
+	cfg, err := digest.ConfigFromEnv()
+	if err != nil {
+		logger.Warn("Daily digest disabled", zap.Error(err))
+		return nil
+	}
+	if len(cfg.Recipients) == 0 {
+		return nil
+	}
+	if !cfg.SMTP.Enabled() {
+		logger.Warn("Daily digest disabled: DIGEST_EMAIL is set but SMTP_HOST is not")
+		return nil
+	}
+	cfg.Logger = logger.Zap()
+
+	logger.Info("Daily digest enabled",
+		zap.Int("recipients", len(cfg.Recipients)),
+		zap.String("send_at", fmt.Sprintf("%02d:%02d", cfg.Hour, cfg.Minute)),
+	)
+	return digest.New(cfg, repository, store)
+}
+
 // shouldCheckModels determines if model availability checks should be performed.
 // Model checks are enabled when LOCAL_MODEL_DIR is configured and not empty.
 func shouldCheckModels() bool {
@@ -801,6 +2513,38 @@ func formatDuration(d time.Duration) string {
 	return fmt.Sprintf("%ds", seconds)
 }
 
+// newModelCatalogAPI creates the dashboard model catalog.
+// The catalog is read from MODEL_CATALOG_PATH (default: the embedded
+// catalog) and models are downloaded to LOCAL_MODEL_DIR (default: ./models).
+// Completed downloads update the model path settings in .env.
+func newModelCatalogAPI(ctx context.Context, logger *logging.Logger, gpuCollector *metrics.GPUCollector) (*webui.ModelCatalogAPI, error) {
+	catalog, err := modelmanager.LoadCatalog(os.Getenv("MODEL_CATALOG_PATH"))
+	if err != nil {
+		return nil, err
+	}
+
+	modelDir := core.GetEnvOrDefault("LOCAL_MODEL_DIR", "./models")
+	httpClient := core.GetHTTPClient(&core.Config{
+		AllowSelfSignedCerts: os.Getenv("ALLOW_SELF_SIGNED_CERTS") == "true",
+	}, 0) // No timeout for large downloads
+
+	logger.Info("Model catalog loaded",
+		zap.Int("models", len(catalog.Models)),
+		zap.String("model_dir", modelDir),
+	)
+	return webui.NewModelCatalogAPI(
+		ctx,
+		catalog,
+		modelmanager.NewModelManager(modelDir, httpClient,
+			modelmanager.WithHFToken(os.Getenv("HF_TOKEN")),
+			modelmanager.WithHFEndpoint(os.Getenv("HF_ENDPOINT")),
+		),
+		gpuCollector,
+		".env",
+		logger.Zap(),
+	), nil
+}
+
 // ensureModelsAvailable checks that required AI models are available.
 // If models are missing, attempts to download them with progress display.
 func ensureModelsAvailable(logger *logging.Logger) error {
@@ -838,6 +2582,8 @@ func ensureModelsAvailable(logger *logging.Logger) error {
 		modelDir,
 		httpClient,
 		modelmanager.WithOnProgress(progressCallback),
+		modelmanager.WithHFToken(os.Getenv("HF_TOKEN")),
+		modelmanager.WithHFEndpoint(os.Getenv("HF_ENDPOINT")),
 	)
 
 	// Create context for model operations (can be cancelled via signal)
@@ -860,12 +2606,29 @@ func ensureModelsAvailable(logger *logging.Logger) error {
 		logger.Info("Ensuring model available", zap.String("model", modelName))
 		fmt.Printf("Downloading model: %s\n", modelName)
 
-		if err := modelManager.EnsureModelAvailable(ctx, modelName); err != nil {
-			fmt.Println() // Ensure we're on a new line after any progress bar
-			return fmt.Errorf("model %q not available: %w", modelName, err)
+		var modelPath string
+		if modelmanager.IsHFURI(modelName) {
+			// Hub files are downloaded without registering them first
+			file, err := modelmanager.ParseHFURI(modelName)
+			if err != nil {
+				return err
+			}
+			modelPath, err = modelManager.EnsureModel(ctx, modelmanager.ModelConfig{
+				Name:     modelName,
+				URL:      modelName,
+				Filename: filepath.Base(file.Path),
+			}, nil)
+			if err != nil {
+				fmt.Println() // Ensure we're on a new line after any progress bar
+				return fmt.Errorf("model %q not available: %w", modelName, err)
+			}
+		} else {
+			if err := modelManager.EnsureModelAvailable(ctx, modelName); err != nil {
+				fmt.Println() // Ensure we're on a new line after any progress bar
+				return fmt.Errorf("model %q not available: %w", modelName, err)
+			}
+			modelPath, _ = modelManager.GetModelPath(modelName)
 		}
-
-		modelPath, _ := modelManager.GetModelPath(modelName)
 		logger.Info("Model ready", zap.String("model", modelName), zap.String("path", modelPath))
 		fmt.Printf("Model ready: %s\n", modelPath)
 	}
@@ -877,7 +2640,8 @@ func ensureModelsAvailable(logger *logging.Logger) error {
 }
 
 // getRequiredModels returns the list of model names that should be checked.
-// This reads from REQUIRED_MODELS environment variable (comma-separated)
+// This reads from REQUIRED_MODELS environment variable (comma-separated
+// registered model names or hf://org/repo[@revision]/file URIs)
 // or defaults to checking the text model if LOCAL_MODEL_DIR is set.
 func getRequiredModels() []string {
 	// Check for explicit model list
@@ -8,6 +8,7 @@ import (
 
 	"go_backend/canvusapi"
 	"go_backend/core"
+	"go_backend/llamaruntime"
 	"go_backend/logging"
 )
 
@@ -157,6 +158,55 @@ func TestInitializeSDRuntimeConfigParsing(t *testing.T) {
 }
 
 // TestSplitAndTrim tests the splitAndTrim helper function.
+func TestLlamaContextTuningFromEnv(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		opts, batch, err := llamaContextTuningFromEnv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if opts.DisableFlashAttention {
+			t.Error("flash attention should be enabled by default")
+		}
+		if batch != llamaruntime.DefaultBatchSize {
+			t.Errorf("batch = %d, want %d", batch, llamaruntime.DefaultBatchSize)
+		}
+	})
+
+	t.Run("quantized cache", func(t *testing.T) {
+		t.Setenv("LLAMA_TYPE_K", "q8_0")
+		t.Setenv("LLAMA_TYPE_V", "q8_0")
+		t.Setenv("LLAMA_N_BATCH", "256")
+		opts, batch, err := llamaContextTuningFromEnv()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if opts.TypeK != llamaruntime.KVCacheQ8_0 || opts.TypeV != llamaruntime.KVCacheQ8_0 {
+			t.Errorf("types = %s/%s, want q8_0/q8_0", opts.TypeK, opts.TypeV)
+		}
+		if batch != 256 {
+			t.Errorf("batch = %d, want 256", batch)
+		}
+	})
+
+	invalid := map[string]map[string]string{
+		"bad flash attn":            {"LLAMA_FLASH_ATTN": "maybe"},
+		"bad cache type":            {"LLAMA_TYPE_K": "q2"},
+		"quantized V without flash": {"LLAMA_FLASH_ATTN": "false", "LLAMA_TYPE_V": "q4_0"},
+		"batch out of range":        {"LLAMA_N_BATCH": "0"},
+		"batch not a number":        {"LLAMA_N_BATCH": "big"},
+	}
+	for name, env := range invalid {
+		t.Run(name, func(t *testing.T) {
+			for k, v := range env {
+				t.Setenv(k, v)
+			}
+			if _, _, err := llamaContextTuningFromEnv(); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
 func TestSplitAndTrim(t *testing.T) {
 	tests := []struct {
 		input    string
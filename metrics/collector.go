@@ -50,6 +50,23 @@ type MetricsCollector interface {
 	GetSystemStatus() SystemStatus
 }
 
+// FilterRecorder is implemented by collectors that count responses changed
+// by the output filter. It is separate from MetricsCollector so existing
+// collectors need not implement it.
+type FilterRecorder interface {
+	// RecordFilteredResponse counts a response; action is "masked" or "blocked".
+	RecordFilteredResponse(action string)
+}
+
+// RepairRecorder is implemented by collectors that count how structured
+// (JSON) LLM responses were read. It is separate from MetricsCollector so
+// existing collectors need not implement it.
+type RepairRecorder interface {
+	// RecordStructuredResponse counts a response; outcome is "valid",
+	// "repaired", "retried" or "failed".
+	RecordStructuredResponse(outcome string)
+}
+
 // TaskBroadcaster defines the interface for broadcasting task updates to connected clients.
 // This allows the Monitor to send real-time task status updates without depending on webui package.
 // The webui.WebSocketBroadcaster implements this interface via BroadcastTaskUpdateFromMetrics.
@@ -59,6 +76,17 @@ type TaskBroadcaster interface {
 	BroadcastTaskUpdateFromMetrics(data TaskBroadcastData)
 }
 
+// TaskBroadcasters fans task updates out to several broadcasters
+// (e.g., the WebSocket dashboard and gRPC task watchers).
+type TaskBroadcasters []TaskBroadcaster
+
+// BroadcastTaskUpdateFromMetrics sends data to every broadcaster.
+func (b TaskBroadcasters) BroadcastTaskUpdateFromMetrics(data TaskBroadcastData) {
+	for _, broadcaster := range b {
+		broadcaster.BroadcastTaskUpdateFromMetrics(data)
+	}
+}
+
 // TaskBroadcastData contains the information needed for a task broadcast.
 // This is a minimal struct that can be converted to webui.TaskUpdateData.
 type TaskBroadcastData struct {
@@ -70,6 +98,8 @@ type TaskBroadcastData struct {
 	Status string
 	// CanvasID identifies which canvas this task belongs to
 	CanvasID string
+	// WidgetID is the widget that triggered the task, if known
+	WidgetID string
 	// Duration is how long the task took (only set on completion)
 	Duration time.Duration
 	// Error contains error details if Status is "error"
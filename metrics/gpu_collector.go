@@ -13,6 +13,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"go_backend/core/clock"
 )
 
 // GPUReader is the interface for reading GPU metrics.
@@ -34,6 +36,12 @@ type GPUCollectorConfig struct {
 	// NvidiaSMIPath is the path to the nvidia-smi executable
 	// If empty, uses "nvidia-smi" and relies on PATH
 	NvidiaSMIPath string
+
+	// DisableNVML forces the nvidia-smi fallback even when NVML is available
+	DisableNVML bool
+
+	// Clock schedules the collections (optional, default: the system clock)
+	Clock clock.Clock
 }
 
 // DefaultGPUCollectorConfig returns a default configuration.
@@ -46,7 +54,8 @@ func DefaultGPUCollectorConfig() GPUCollectorConfig {
 }
 
 // GPUCollector is an organism that periodically collects GPU metrics.
-// It uses nvidia-smi to query GPU state and stores historical samples.
+// It queries GPU state through NVML, falling back to nvidia-smi when NVML
+// cannot be initialized, and stores historical samples.
 //
 // This organism composes:
 // - GPUMetrics atoms for data representation
@@ -58,6 +67,10 @@ type GPUCollector struct {
 	config GPUCollectorConfig
 	reader GPUReader
 
+	// NVML reader, initialized lazily on first collection
+	nvmlReader *NVMLReader
+	nvmlTried  bool
+
 	// History storage (circular buffer)
 	history  []GPUMetrics
 	histHead int
@@ -90,6 +103,7 @@ func NewGPUCollector(config GPUCollectorConfig, onMetrics func(GPUMetrics)) *GPU
 	if config.NvidiaSMIPath == "" {
 		config.NvidiaSMIPath = "nvidia-smi"
 	}
+	config.Clock = clock.OrReal(config.Clock)
 
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -123,6 +137,13 @@ func (c *GPUCollector) Start() {
 func (c *GPUCollector) Stop() {
 	c.cancel()
 	c.wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nvmlReader != nil {
+		c.nvmlReader.Close()
+		c.nvmlReader = nil
+	}
 }
 
 // IsAvailable returns true if the GPU is available for metrics collection.
@@ -186,14 +207,14 @@ func (c *GPUCollector) collectLoop() {
 	// Collect immediately on start
 	c.collectOnce()
 
-	ticker := time.NewTicker(c.config.CollectionInterval)
+	ticker := c.config.Clock.NewTicker(c.config.CollectionInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-c.ctx.Done():
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			c.collectOnce()
 		}
 	}
@@ -206,6 +227,8 @@ func (c *GPUCollector) collectOnce() {
 
 	if c.reader != nil {
 		metrics, err = c.reader.ReadGPUMetrics()
+	} else if nvml := c.getNVMLReader(); nvml != nil {
+		metrics, err = nvml.ReadGPUMetrics()
 	} else {
 		metrics, err = c.readNvidiaSMI()
 	}
@@ -236,15 +259,35 @@ func (c *GPUCollector) collectOnce() {
 	}
 }
 
+// getNVMLReader returns the NVML reader, initializing it on first use.
+// Returns nil if NVML is disabled or could not be initialized, in which
+// case the caller falls back to nvidia-smi.
+func (c *GPUCollector) getNVMLReader() *NVMLReader {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.config.DisableNVML {
+		return nil
+	}
+	if !c.nvmlTried {
+		c.nvmlTried = true
+		if reader, err := NewNVMLReader(); err == nil {
+			c.nvmlReader = reader
+		}
+	}
+	return c.nvmlReader
+}
+
 // readNvidiaSMI queries nvidia-smi for GPU metrics.
 func (c *GPUCollector) readNvidiaSMI() (GPUMetrics, error) {
-	// Query: utilization, temperature, memory used, memory total
-	// Format: CSV with headers
+	// Query: utilization, temperature, memory used, memory total,
+	// power draw, power limit
+	// Format: CSV without headers
 	ctx, cancel := context.WithTimeout(c.ctx, 5*time.Second)
 	defer cancel()
 
 	cmd := exec.CommandContext(ctx, c.config.NvidiaSMIPath,
-		"--query-gpu=utilization.gpu,temperature.gpu,memory.used,memory.total",
+		"--query-gpu=utilization.gpu,temperature.gpu,memory.used,memory.total,power.draw,power.limit",
 		"--format=csv,noheader,nounits")
 
 	var stdout, stderr bytes.Buffer
@@ -260,6 +303,8 @@ func (c *GPUCollector) readNvidiaSMI() (GPUMetrics, error) {
 }
 
 // parseNvidiaSMIOutput parses the CSV output from nvidia-smi.
+// The power fields are optional; GPUs that do not report power print
+// "[N/A]", which leaves them at zero.
 func parseNvidiaSMIOutput(output string) (GPUMetrics, error) {
 	output = strings.TrimSpace(output)
 	if output == "" {
@@ -306,13 +351,48 @@ func parseNvidiaSMIOutput(output string) (GPUMetrics, error) {
 	memUsed := int64(memUsedMiB * mibToBytes)
 	memFree := memTotal - memUsed
 
-	return GPUMetrics{
+	metrics := GPUMetrics{
 		Utilization: util,
 		Temperature: temp,
 		MemoryTotal: memTotal,
 		MemoryUsed:  memUsed,
 		MemoryFree:  memFree,
-	}, nil
+	}
+
+	// Parse power draw and limit (W)
+	if len(record) >= 6 {
+		metrics.PowerDraw, _ = strconv.ParseFloat(strings.TrimSpace(record[4]), 64)
+		metrics.PowerLimit, _ = strconv.ParseFloat(strings.TrimSpace(record[5]), 64)
+	}
+
+	return metrics, nil
+}
+
+// AggregateGPUDevices combines per-device metrics into a single GPUMetrics.
+// Memory and power are summed, utilization is averaged and temperature is
+// the hottest device, so alerts trigger on the worst-case GPU.
+func AggregateGPUDevices(devices []GPUDeviceMetrics) GPUMetrics {
+	var m GPUMetrics
+	if len(devices) == 0 {
+		return m
+	}
+
+	var utilSum float64
+	for _, d := range devices {
+		utilSum += d.Utilization
+		if d.Temperature > m.Temperature {
+			m.Temperature = d.Temperature
+		}
+		m.MemoryTotal += d.MemoryTotal
+		m.MemoryUsed += d.MemoryUsed
+		m.MemoryFree += d.MemoryFree
+		m.PowerDraw += d.PowerDraw
+		m.PowerLimit += d.PowerLimit
+	}
+	m.Utilization = utilSum / float64(len(devices))
+	m.Name = devices[0].Name
+	m.Devices = devices
+	return m
 }
 
 // MockGPUReader is a mock implementation of GPUReader for testing.
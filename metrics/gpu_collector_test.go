@@ -6,6 +6,8 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"go_backend/core/clock"
 )
 
 func TestDefaultGPUCollectorConfig(t *testing.T) {
@@ -68,6 +70,23 @@ func TestNewGPUCollector(t *testing.T) {
 	})
 }
 
+// collectorClock returns a fake clock for the collection loop. The loop
+// starts its ticker after the first collection, so BlockUntil(1) waits for
+// that collection to finish.
+func collectorClock() *clock.Fake {
+	return clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+}
+
+// waitCollected waits for a collection callback.
+func waitCollected(t *testing.T, collected <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-collected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("collection did not happen")
+	}
+}
+
 func TestGPUCollector_WithMockReader(t *testing.T) {
 	expectedMetrics := GPUMetrics{
 		Utilization: 75.0,
@@ -80,29 +99,26 @@ func TestGPUCollector_WithMockReader(t *testing.T) {
 	mockReader := NewMockGPUReader(expectedMetrics)
 
 	var receivedMetrics GPUMetrics
-	var callbackCalled atomic.Int32
+	collected := make(chan struct{}, 10)
 	callback := func(m GPUMetrics) {
 		receivedMetrics = m
-		callbackCalled.Add(1)
+		collected <- struct{}{}
 	}
 
 	config := GPUCollectorConfig{
-		CollectionInterval: 50 * time.Millisecond,
+		CollectionInterval: 5 * time.Second,
 		HistorySize:        10,
+		Clock:              collectorClock(),
 	}
 
 	collector := NewGPUCollectorWithReader(config, mockReader, callback)
 	collector.Start()
 
-	// Wait for at least one collection
-	time.Sleep(100 * time.Millisecond)
+	// The first collection runs at start
+	waitCollected(t, collected)
 
 	collector.Stop()
 
-	if callbackCalled.Load() == 0 {
-		t.Fatal("expected callback to be called at least once")
-	}
-
 	if !collector.IsAvailable() {
 		t.Error("expected GPU to be available")
 	}
@@ -123,25 +139,38 @@ func TestGPUCollector_WithMockReader(t *testing.T) {
 }
 
 func TestGPUCollector_History(t *testing.T) {
-	t.Run("stores metrics in circular buffer", func(t *testing.T) {
-		mockReader := NewMockGPUReader(GPUMetrics{Utilization: 50.0})
-
+	// collect runs the initial collection and n ticks on a fake clock.
+	collect := func(t *testing.T, historySize, n int) *GPUCollector {
+		t.Helper()
+		fake := collectorClock()
+		collected := make(chan struct{}, n+1)
 		config := GPUCollectorConfig{
-			CollectionInterval: 10 * time.Millisecond,
-			HistorySize:        5,
+			CollectionInterval: 5 * time.Second,
+			HistorySize:        historySize,
+			Clock:              fake,
 		}
-
-		collector := NewGPUCollectorWithReader(config, mockReader, nil)
+		collector := NewGPUCollectorWithReader(config, NewMockGPUReader(GPUMetrics{Utilization: 50.0}), func(GPUMetrics) {
+			collected <- struct{}{}
+		})
 		collector.Start()
+		defer collector.Stop()
 
-		// Wait for multiple collections
-		time.Sleep(100 * time.Millisecond)
+		for i := 0; i <= n; i++ {
+			if i > 0 {
+				fake.BlockUntil(1)
+				fake.Advance(config.CollectionInterval)
+			}
+			waitCollected(t, collected)
+		}
+		return collector
+	}
 
-		collector.Stop()
+	t.Run("stores metrics in circular buffer", func(t *testing.T) {
+		collector := collect(t, 5, 3)
 
 		histSize := collector.GetHistorySize()
-		if histSize == 0 {
-			t.Fatal("expected non-empty history")
+		if histSize != 4 {
+			t.Fatalf("expected 4 history entries, got %d", histSize)
 		}
 
 		history := collector.GetHistory(histSize)
@@ -151,39 +180,28 @@ func TestGPUCollector_History(t *testing.T) {
 	})
 
 	t.Run("respects history limit", func(t *testing.T) {
-		mockReader := NewMockGPUReader(GPUMetrics{Utilization: 50.0})
-
-		config := GPUCollectorConfig{
-			CollectionInterval: 10 * time.Millisecond,
-			HistorySize:        3,
-		}
-
-		collector := NewGPUCollectorWithReader(config, mockReader, nil)
-		collector.Start()
-
-		// Wait for more collections than history size
-		time.Sleep(150 * time.Millisecond)
-
-		collector.Stop()
+		collector := collect(t, 3, 5)
 
 		// History should be capped at 3
 		histSize := collector.GetHistorySize()
-		if histSize > 3 {
-			t.Errorf("expected history size <= 3, got %d", histSize)
+		if histSize != 3 {
+			t.Errorf("expected history size 3, got %d", histSize)
 		}
 	})
 
 	t.Run("GetHistory handles various limits", func(t *testing.T) {
 		mockReader := NewMockGPUReader(GPUMetrics{Utilization: 50.0})
 
+		fake := collectorClock()
 		config := GPUCollectorConfig{
-			CollectionInterval: 10 * time.Millisecond,
+			CollectionInterval: 5 * time.Second,
 			HistorySize:        10,
+			Clock:              fake,
 		}
 
 		collector := NewGPUCollectorWithReader(config, mockReader, nil)
 		collector.Start()
-		time.Sleep(100 * time.Millisecond)
+		fake.BlockUntil(1)
 		collector.Stop()
 
 		// Zero limit
@@ -217,16 +235,16 @@ func TestGPUCollector_ErrorHandling(t *testing.T) {
 			callbackCalled.Add(1)
 		}
 
+		fake := collectorClock()
 		config := GPUCollectorConfig{
-			CollectionInterval: 10 * time.Millisecond,
+			CollectionInterval: 5 * time.Second,
 			HistorySize:        5,
+			Clock:              fake,
 		}
 
 		collector := NewGPUCollectorWithReader(config, mockReader, callback)
 		collector.Start()
-
-		time.Sleep(50 * time.Millisecond)
-
+		fake.BlockUntil(1)
 		collector.Stop()
 
 		// Should not be available due to error
@@ -250,38 +268,32 @@ func TestGPUCollector_ErrorHandling(t *testing.T) {
 		mockReader := NewMockGPUReader(GPUMetrics{})
 		mockReader.SetError(errors.New("GPU not available"))
 
-		var callbackCalled atomic.Int32
+		collected := make(chan struct{}, 10)
 		callback := func(m GPUMetrics) {
-			callbackCalled.Add(1)
+			collected <- struct{}{}
 		}
 
+		fake := collectorClock()
 		config := GPUCollectorConfig{
-			CollectionInterval: 10 * time.Millisecond,
+			CollectionInterval: 5 * time.Second,
 			HistorySize:        5,
+			Clock:              fake,
 		}
 
 		collector := NewGPUCollectorWithReader(config, mockReader, callback)
 		collector.Start()
 
-		// Wait for error state with polling
-		deadline := time.Now().Add(200 * time.Millisecond)
-		for time.Now().Before(deadline) && collector.IsAvailable() {
-			time.Sleep(5 * time.Millisecond)
-		}
-
+		// The first collection fails
+		fake.BlockUntil(1)
 		if collector.IsAvailable() {
 			t.Error("expected GPU to not be available initially")
 		}
 
-		// Clear error and set metrics
+		// Clear error and set metrics; the next collection succeeds
 		mockReader.SetError(nil)
 		mockReader.SetMetrics(GPUMetrics{Utilization: 80.0})
-
-		// Wait for recovery with polling
-		deadline = time.Now().Add(200 * time.Millisecond)
-		for time.Now().Before(deadline) && !collector.IsAvailable() {
-			time.Sleep(5 * time.Millisecond)
-		}
+		fake.Advance(config.CollectionInterval)
+		waitCollected(t, collected)
 
 		collector.Stop()
 
@@ -295,9 +307,8 @@ func TestGPUCollector_ErrorHandling(t *testing.T) {
 			t.Errorf("expected no error after recovery, got %v", collector.GetLastError())
 		}
 
-		// Callback should have been called at least once
-		if callbackCalled.Load() == 0 {
-			t.Error("expected callback to be called after recovery")
+		if current := collector.GetCurrentMetrics(); current.Utilization != 80.0 {
+			t.Errorf("utilization after recovery = %v, want 80", current.Utilization)
 		}
 	})
 }
@@ -366,6 +377,32 @@ func TestParseNvidiaSMIOutput(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:   "valid output with power",
+			output: "90, 84, 4096, 8192, 142.37, 150.00",
+			want: GPUMetrics{
+				Utilization: 90.0,
+				Temperature: 84.0,
+				MemoryTotal: 8192 * 1024 * 1024,
+				MemoryUsed:  4096 * 1024 * 1024,
+				MemoryFree:  4096 * 1024 * 1024,
+				PowerDraw:   142.37,
+				PowerLimit:  150.0,
+			},
+			wantErr: false,
+		},
+		{
+			name:   "power not supported",
+			output: "75, 65, 4096, 8192, [N/A], [N/A]",
+			want: GPUMetrics{
+				Utilization: 75.0,
+				Temperature: 65.0,
+				MemoryTotal: 8192 * 1024 * 1024,
+				MemoryUsed:  4096 * 1024 * 1024,
+				MemoryFree:  4096 * 1024 * 1024,
+			},
+			wantErr: false,
+		},
 		{
 			name:    "empty output",
 			output:  "",
@@ -426,11 +463,50 @@ func TestParseNvidiaSMIOutput(t *testing.T) {
 				if got.MemoryFree != tt.want.MemoryFree {
 					t.Errorf("MemoryFree = %v, want %v", got.MemoryFree, tt.want.MemoryFree)
 				}
+				if got.PowerDraw != tt.want.PowerDraw || got.PowerLimit != tt.want.PowerLimit {
+					t.Errorf("Power = %v/%v, want %v/%v", got.PowerDraw, got.PowerLimit, tt.want.PowerDraw, tt.want.PowerLimit)
+				}
 			}
 		})
 	}
 }
 
+func TestAggregateGPUDevices(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		got := AggregateGPUDevices(nil)
+		if got.MemoryTotal != 0 || got.Devices != nil {
+			t.Errorf("expected zero metrics, got %+v", got)
+		}
+	})
+
+	t.Run("two devices", func(t *testing.T) {
+		devices := []GPUDeviceMetrics{
+			{Index: 0, Name: "RTX A", Utilization: 80, Temperature: 70, MemoryTotal: 8000, MemoryUsed: 6000, MemoryFree: 2000, PowerDraw: 200, PowerLimit: 300},
+			{Index: 1, Name: "RTX B", Utilization: 20, Temperature: 85, MemoryTotal: 8000, MemoryUsed: 1000, MemoryFree: 7000, PowerDraw: 50, PowerLimit: 300},
+		}
+		got := AggregateGPUDevices(devices)
+
+		if got.Utilization != 50 {
+			t.Errorf("Utilization = %v, want 50", got.Utilization)
+		}
+		if got.Temperature != 85 {
+			t.Errorf("Temperature = %v, want 85 (hottest device)", got.Temperature)
+		}
+		if got.MemoryTotal != 16000 || got.MemoryUsed != 7000 || got.MemoryFree != 9000 {
+			t.Errorf("memory = %d/%d/%d, want 16000/7000/9000", got.MemoryTotal, got.MemoryUsed, got.MemoryFree)
+		}
+		if got.PowerDraw != 250 || got.PowerLimit != 600 {
+			t.Errorf("power = %v/%v, want 250/600", got.PowerDraw, got.PowerLimit)
+		}
+		if got.Name != "RTX A" {
+			t.Errorf("Name = %q, want %q", got.Name, "RTX A")
+		}
+		if len(got.Devices) != 2 {
+			t.Errorf("expected 2 devices, got %d", len(got.Devices))
+		}
+	})
+}
+
 func TestMockGPUReader(t *testing.T) {
 	t.Run("returns configured metrics", func(t *testing.T) {
 		expected := GPUMetrics{
@@ -505,15 +581,16 @@ func TestMockGPUReader(t *testing.T) {
 func TestGPUCollector_StopIdempotent(t *testing.T) {
 	mockReader := NewMockGPUReader(GPUMetrics{Utilization: 50.0})
 
+	fake := collectorClock()
 	config := GPUCollectorConfig{
-		CollectionInterval: 50 * time.Millisecond,
+		CollectionInterval: 5 * time.Second,
 		HistorySize:        5,
+		Clock:              fake,
 	}
 
 	collector := NewGPUCollectorWithReader(config, mockReader, nil)
 	collector.Start()
-
-	time.Sleep(100 * time.Millisecond)
+	fake.BlockUntil(1)
 
 	// Multiple stops should be safe
 	collector.Stop()
@@ -529,22 +606,23 @@ func TestGPUCollector_CallbackNotCalledAfterStop(t *testing.T) {
 		callbackCalled.Add(1)
 	}
 
+	fake := collectorClock()
 	config := GPUCollectorConfig{
-		CollectionInterval: 10 * time.Millisecond,
+		CollectionInterval: 5 * time.Second,
 		HistorySize:        5,
+		Clock:              fake,
 	}
 
 	collector := NewGPUCollectorWithReader(config, mockReader, callback)
 	collector.Start()
-
-	time.Sleep(50 * time.Millisecond)
+	fake.BlockUntil(1)
 
 	collector.Stop()
 
 	countAtStop := callbackCalled.Load()
 
-	// Wait a bit more to ensure no more callbacks
-	time.Sleep(50 * time.Millisecond)
+	// Intervals passing after Stop collect nothing
+	fake.Advance(3 * config.CollectionInterval)
 
 	if callbackCalled.Load() != countAtStop {
 		t.Errorf("callback called after stop: was %d at stop, now %d",
@@ -0,0 +1,248 @@
+// Package metrics provides the GPUHistory organism for long-term GPU metrics.
+// This file contains the GPUSample atom and the GPUHistory organism that
+// persists GPU samples as 1-minute averages and downsamples them into
+// 5-minute and hourly rollups.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// GPUResolution is a step of the persisted GPU history and how long
+// samples at that step are kept.
+type GPUResolution struct {
+	Step      time.Duration
+	Retention time.Duration
+}
+
+// GPUResolutions are the persisted GPU history steps, finest first. Each
+// step is rolled up from the one before it.
+var GPUResolutions = []GPUResolution{
+	{Step: time.Minute, Retention: 24 * time.Hour},
+	{Step: 5 * time.Minute, Retention: 7 * 24 * time.Hour},
+	{Step: time.Hour, Retention: 90 * 24 * time.Hour},
+}
+
+// MaxGPUHistoryPoints bounds the samples returned for one range; longer
+// ranges use a coarser step.
+const MaxGPUHistoryPoints = 2880
+
+// GPUSample is the aggregate of the GPU metrics collected in one step.
+type GPUSample struct {
+	// Time is the start of the step
+	Time time.Time     `json:"time"`
+	Step time.Duration `json:"-"`
+	// Samples is the number of collections averaged
+	Samples int `json:"samples"`
+
+	Utilization    float64 `json:"utilization"`
+	UtilizationMax float64 `json:"utilization_max"`
+	Temperature    float64 `json:"temperature"`
+	TemperatureMax float64 `json:"temperature_max"`
+	MemoryUsed     int64   `json:"memory_used"`
+	MemoryTotal    int64   `json:"memory_total"`
+	PowerDraw      float64 `json:"power_draw,omitempty"`
+}
+
+// add accumulates m into the sample. Averages are kept as sums until
+// finish is called.
+func (s *GPUSample) add(m GPUMetrics) {
+	s.Samples++
+	s.Utilization += m.Utilization
+	s.Temperature += m.Temperature
+	s.MemoryUsed += m.MemoryUsed
+	s.PowerDraw += m.PowerDraw
+	if m.Utilization > s.UtilizationMax {
+		s.UtilizationMax = m.Utilization
+	}
+	if m.Temperature > s.TemperatureMax {
+		s.TemperatureMax = m.Temperature
+	}
+	if m.MemoryTotal > s.MemoryTotal {
+		s.MemoryTotal = m.MemoryTotal
+	}
+}
+
+// finish turns the accumulated sums into averages.
+func (s GPUSample) finish() GPUSample {
+	if s.Samples > 0 {
+		n := float64(s.Samples)
+		s.Utilization /= n
+		s.Temperature /= n
+		s.MemoryUsed /= int64(s.Samples)
+		s.PowerDraw /= n
+	}
+	return s
+}
+
+// GPUHistoryStorage persists GPU samples.
+// Implemented by db.Repository.
+type GPUHistoryStorage interface {
+	// SaveGPUSamples stores samples, replacing any with the same step and time.
+	SaveGPUSamples(ctx context.Context, samples []GPUSample) error
+
+	// RollupGPUSamples recomputes the samples at step to from those at
+	// step from, for every to-step starting at or after since.
+	RollupGPUSamples(ctx context.Context, from, to time.Duration, since time.Time) error
+
+	// DeleteGPUSamples deletes the samples at step that start before before.
+	DeleteGPUSamples(ctx context.Context, step time.Duration, before time.Time) error
+
+	// QueryGPUSamples returns the samples at step in [since, until), oldest first.
+	QueryGPUSamples(ctx context.Context, step time.Duration, since, until time.Time) ([]GPUSample, error)
+}
+
+// GPUHistory is an organism that keeps a long-term GPU metrics history.
+// Collected metrics are averaged per minute in memory and saved every
+// minute; the 5-minute and hourly rollups are then refreshed and samples
+// past their retention deleted.
+//
+// Usage:
+//
+//	h := metrics.NewGPUHistory(repository)
+//	// from the GPUCollector callback:
+//	h.Record(gpuMetrics)
+//	go h.Run(ctx, onError)
+//	// on shutdown:
+//	h.Flush(ctx)
+type GPUHistory struct {
+	storage GPUHistoryStorage
+
+	mu      sync.Mutex
+	current GPUSample
+	pending []GPUSample
+
+	clock clock.Clock
+}
+
+// NewGPUHistory creates a GPUHistory saving to storage.
+func NewGPUHistory(storage GPUHistoryStorage) *GPUHistory {
+	return &GPUHistory{storage: storage, clock: clock.Real()}
+}
+
+// SetClock replaces the clock that assigns samples to minutes and drives
+// Run. Call before recording.
+func (h *GPUHistory) SetClock(c clock.Clock) {
+	h.clock = clock.OrReal(c)
+}
+
+// Record adds collected metrics to the current minute. It does not block
+// on storage and is safe to call from the GPUCollector callback.
+func (h *GPUHistory) Record(m GPUMetrics) {
+	step := GPUResolutions[0].Step
+	start := h.clock.Now().UTC().Truncate(step)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.current.Time.Equal(start) {
+		if h.current.Samples > 0 {
+			h.pending = append(h.pending, h.current.finish())
+		}
+		h.current = GPUSample{Time: start, Step: step}
+	}
+	h.current.add(m)
+}
+
+// Flush saves the completed minutes and the current partial minute, then
+// refreshes the rollups and deletes expired samples.
+func (h *GPUHistory) Flush(ctx context.Context) error {
+	h.mu.Lock()
+	finished := h.pending
+	h.pending = nil
+	samples := finished
+	if h.current.Samples > 0 {
+		// The partial minute is saved again, complete, once it ends
+		samples = append(samples[:len(samples):len(samples)], h.current.finish())
+	}
+	h.mu.Unlock()
+
+	if len(samples) > 0 {
+		if err := h.storage.SaveGPUSamples(ctx, samples); err != nil {
+			h.mu.Lock()
+			h.pending = append(finished, h.pending...)
+			h.mu.Unlock()
+			return fmt.Errorf("failed to save GPU samples: %w", err)
+		}
+	}
+
+	now := h.clock.Now().UTC()
+	for i := 1; i < len(GPUResolutions); i++ {
+		from, to := GPUResolutions[i-1], GPUResolutions[i]
+		// Redo the last two steps: the newest is still filling up, and the
+		// one before it may have been rolled up before its last minute
+		since := now.Truncate(to.Step).Add(-to.Step)
+		if err := h.storage.RollupGPUSamples(ctx, from.Step, to.Step, since); err != nil {
+			return fmt.Errorf("failed to roll up GPU samples: %w", err)
+		}
+	}
+	for _, res := range GPUResolutions {
+		if err := h.storage.DeleteGPUSamples(ctx, res.Step, now.Add(-res.Retention)); err != nil {
+			return fmt.Errorf("failed to delete expired GPU samples: %w", err)
+		}
+	}
+	return nil
+}
+
+// Run flushes every minute until ctx is cancelled. Flush errors are passed
+// to onError (which may be nil) and do not stop the loop.
+func (h *GPUHistory) Run(ctx context.Context, onError func(error)) {
+	ticker := h.clock.NewTicker(GPUResolutions[0].Step)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if err := h.Flush(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// ResolutionFor returns the finest step that keeps span and needs at most
+// MaxGPUHistoryPoints samples to cover it, or false if span exceeds the
+// longest retention.
+func ResolutionFor(span time.Duration) (time.Duration, bool) {
+	for _, res := range GPUResolutions {
+		if span <= res.Retention && span/res.Step <= MaxGPUHistoryPoints {
+			return res.Step, true
+		}
+	}
+	return 0, false
+}
+
+// Query returns the samples at step for the span ending now, oldest first.
+// The current minute is included when step is the finest step.
+func (h *GPUHistory) Query(ctx context.Context, span, step time.Duration) ([]GPUSample, error) {
+	now := h.clock.Now().UTC()
+	samples, err := h.storage.QueryGPUSamples(ctx, step, now.Add(-span).Truncate(step), now)
+	if err != nil {
+		return nil, err
+	}
+	if step != GPUResolutions[0].Step {
+		return samples, nil
+	}
+
+	h.mu.Lock()
+	unsaved := append([]GPUSample(nil), h.pending...)
+	if h.current.Samples > 0 {
+		unsaved = append(unsaved, h.current.finish())
+	}
+	h.mu.Unlock()
+
+	// Unsaved minutes replace any stored copy of the same minute
+	for _, s := range unsaved {
+		for len(samples) > 0 && !samples[len(samples)-1].Time.Before(s.Time) {
+			samples = samples[:len(samples)-1]
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// memoryGPUStorage is an in-memory GPUHistoryStorage for tests. It keeps
+// saved minutes and records rollup and delete calls.
+type memoryGPUStorage struct {
+	saved   map[time.Time]GPUSample
+	saveErr error
+	rollups []time.Duration
+	deletes map[time.Duration]time.Time
+}
+
+func newMemoryGPUStorage() *memoryGPUStorage {
+	return &memoryGPUStorage{saved: map[time.Time]GPUSample{}, deletes: map[time.Duration]time.Time{}}
+}
+
+func (m *memoryGPUStorage) SaveGPUSamples(ctx context.Context, samples []GPUSample) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	for _, s := range samples {
+		m.saved[s.Time] = s
+	}
+	return nil
+}
+
+func (m *memoryGPUStorage) RollupGPUSamples(ctx context.Context, from, to time.Duration, since time.Time) error {
+	m.rollups = append(m.rollups, to)
+	return nil
+}
+
+func (m *memoryGPUStorage) DeleteGPUSamples(ctx context.Context, step time.Duration, before time.Time) error {
+	m.deletes[step] = before
+	return nil
+}
+
+func (m *memoryGPUStorage) QueryGPUSamples(ctx context.Context, step time.Duration, since, until time.Time) ([]GPUSample, error) {
+	var samples []GPUSample
+	for t := since; t.Before(until); t = t.Add(step) {
+		if s, ok := m.saved[t]; ok {
+			samples = append(samples, s)
+		}
+	}
+	return samples, nil
+}
+
+func TestGPUHistory(t *testing.T) {
+	storage := newMemoryGPUStorage()
+	h := NewGPUHistory(storage)
+	base := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(base)
+	h.SetClock(fake)
+
+	// Two samples in the first minute, one in the second
+	h.Record(GPUMetrics{Utilization: 20, Temperature: 60, MemoryUsed: 100, MemoryTotal: 1000})
+	fake.Set(base.Add(30 * time.Second))
+	h.Record(GPUMetrics{Utilization: 40, Temperature: 70, MemoryUsed: 300, MemoryTotal: 1000})
+	fake.Set(base.Add(70 * time.Second))
+	h.Record(GPUMetrics{Utilization: 90, Temperature: 80, MemoryUsed: 500, MemoryTotal: 1000})
+
+	// Unsaved minutes are already visible
+	samples, err := h.Query(context.Background(), time.Hour, time.Minute)
+	if err != nil || len(samples) != 2 {
+		t.Fatalf("Query() = %+v, %v", samples, err)
+	}
+	first := samples[0]
+	if !first.Time.Equal(base) || first.Samples != 2 || first.Utilization != 30 || first.UtilizationMax != 40 ||
+		first.Temperature != 65 || first.TemperatureMax != 70 || first.MemoryUsed != 200 || first.MemoryTotal != 1000 {
+		t.Errorf("first minute = %+v", first)
+	}
+
+	storage.saveErr = errors.New("disk full")
+	if err := h.Flush(context.Background()); err == nil {
+		t.Fatal("Flush() succeeded with failing storage")
+	}
+	storage.saveErr = nil
+	if err := h.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+	if len(storage.saved) != 2 || storage.saved[base].Utilization != 30 || storage.saved[base.Add(time.Minute)].Samples != 1 {
+		t.Errorf("saved = %+v", storage.saved)
+	}
+	if len(storage.rollups) != 2 || storage.rollups[0] != 5*time.Minute || storage.rollups[1] != time.Hour {
+		t.Errorf("rollups = %v", storage.rollups)
+	}
+	if !storage.deletes[time.Minute].Equal(fake.Now().Add(-24 * time.Hour)) {
+		t.Errorf("deletes = %v", storage.deletes)
+	}
+
+	// The partial minute keeps filling after it was saved
+	h.Record(GPUMetrics{Utilization: 10, MemoryTotal: 1000})
+	samples, _ = h.Query(context.Background(), time.Hour, time.Minute)
+	if len(samples) != 2 || samples[1].Samples != 2 || samples[1].Utilization != 50 {
+		t.Errorf("samples after flush = %+v", samples)
+	}
+}
+
+func TestResolutionFor(t *testing.T) {
+	for span, want := range map[time.Duration]time.Duration{
+		time.Hour:           time.Minute,
+		24 * time.Hour:      time.Minute,
+		7 * 24 * time.Hour:  5 * time.Minute,
+		30 * 24 * time.Hour: time.Hour,
+	} {
+		if got, ok := ResolutionFor(span); !ok || got != want {
+			t.Errorf("ResolutionFor(%v) = %v, %v; want %v", span, got, ok, want)
+		}
+	}
+	if _, ok := ResolutionFor(365 * 24 * time.Hour); ok {
+		t.Error("ResolutionFor(1 year) succeeded")
+	}
+}
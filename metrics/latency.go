@@ -0,0 +1,127 @@
+// Package metrics provides latency percentile tracking for the MetricsStore.
+// This file contains the per-type buffer of recent task outcomes used to
+// compute p50/p95/p99 durations and SLO burn rates over sliding windows.
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultLatencyWindows are the sliding windows percentiles are computed over.
+var DefaultLatencyWindows = []time.Duration{5 * time.Minute, time.Hour, 24 * time.Hour}
+
+// DefaultLatencySampleCapacity is the number of task outcomes retained per task type.
+const DefaultLatencySampleCapacity = 1000
+
+// latencySample is the outcome of one completed task.
+type latencySample struct {
+	at       time.Time
+	duration time.Duration
+	success  bool
+}
+
+// latencySamples holds the most recent outcomes of one task type, oldest first.
+type latencySamples struct {
+	samples []latencySample
+}
+
+// add appends a sample and drops samples beyond capacity or older than maxAge.
+func (l *latencySamples) add(sample latencySample, capacity int, maxAge time.Duration) {
+	l.samples = append(l.samples, sample)
+	if over := len(l.samples) - capacity; over > 0 {
+		l.samples = append(l.samples[:0], l.samples[over:]...)
+	}
+	l.prune(sample.at.Add(-maxAge))
+}
+
+// prune drops samples recorded before cutoff.
+func (l *latencySamples) prune(cutoff time.Time) {
+	i := sort.Search(len(l.samples), func(i int) bool {
+		return !l.samples[i].at.Before(cutoff)
+	})
+	if i > 0 {
+		l.samples = append(l.samples[:0], l.samples[i:]...)
+	}
+}
+
+// stats computes percentiles of successful durations for each window ending at now.
+func (l *latencySamples) stats(now time.Time, windows []time.Duration) []LatencyStats {
+	result := make([]LatencyStats, 0, len(windows))
+	for _, window := range windows {
+		cutoff := now.Add(-window)
+		var durations []time.Duration
+		for _, sample := range l.samples {
+			if sample.success && !sample.at.Before(cutoff) {
+				durations = append(durations, sample.duration)
+			}
+		}
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+		result = append(result, LatencyStats{
+			Window:  WindowLabel(window),
+			Samples: len(durations),
+			P50:     percentile(durations, 50),
+			P95:     percentile(durations, 95),
+			P99:     percentile(durations, 99),
+		})
+	}
+	return result
+}
+
+// OutcomeCounts summarizes the task outcomes of one type within a window.
+type OutcomeCounts struct {
+	// Total is the number of completed tasks
+	Total int
+	// Errors is the number of failed tasks
+	Errors int
+	// Slow is the number of successful tasks at or above the duration limit
+	Slow int
+}
+
+// outcomes counts the samples recorded at or after cutoff.
+func (l *latencySamples) outcomes(cutoff time.Time, slowAt time.Duration) OutcomeCounts {
+	var counts OutcomeCounts
+	for _, sample := range l.samples {
+		if sample.at.Before(cutoff) {
+			continue
+		}
+		counts.Total++
+		switch {
+		case !sample.success:
+			counts.Errors++
+		case slowAt > 0 && sample.duration >= slowAt:
+			counts.Slow++
+		}
+	}
+	return counts
+}
+
+// percentile returns the nearest-rank percentile p of sorted durations.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
+
+// WindowLabel formats a window length compactly ("5m", "1h", "24h").
+func WindowLabel(window time.Duration) string {
+	switch {
+	case window >= time.Hour && window%time.Hour == 0:
+		return fmt.Sprintf("%dh", window/time.Hour)
+	case window >= time.Minute && window%time.Minute == 0:
+		return fmt.Sprintf("%dm", window/time.Minute)
+	default:
+		return window.String()
+	}
+}
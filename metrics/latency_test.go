@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	tests := []struct {
+		p    float64
+		want time.Duration
+	}{
+		{50, 50 * time.Millisecond},
+		{95, 95 * time.Millisecond},
+		{99, 99 * time.Millisecond},
+		{100, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		if got := percentile(sorted, tt.p); got != tt.want {
+			t.Errorf("percentile(%v) = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+	if got := percentile(nil, 50); got != 0 {
+		t.Errorf("percentile(nil) = %v, want 0", got)
+	}
+	if got := percentile([]time.Duration{time.Second}, 99); got != time.Second {
+		t.Errorf("percentile(single) = %v, want 1s", got)
+	}
+}
+
+func TestWindowLabel(t *testing.T) {
+	tests := map[time.Duration]string{
+		5 * time.Minute:  "5m",
+		time.Hour:        "1h",
+		24 * time.Hour:   "24h",
+		90 * time.Minute: "90m",
+		30 * time.Second: "30s",
+	}
+	for window, want := range tests {
+		if got := WindowLabel(window); got != want {
+			t.Errorf("WindowLabel(%v) = %q, want %q", window, got, want)
+		}
+	}
+}
+
+func TestMetricsStore_LatencyPercentiles(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMetricsStore(StoreConfig{
+		LatencyWindows: []time.Duration{time.Hour, 5 * time.Minute},
+	}, now)
+	store.SetClock(clock.NewFake(now))
+
+	// Ten fast tasks an hour-ish ago, outside the 5m window
+	for i := 0; i < 10; i++ {
+		store.RecordTask(TaskRecord{Type: "image", Status: TaskStatusSuccess, EndTime: now.Add(-30 * time.Minute), Duration: time.Second})
+	}
+	// Ten slow tasks in the last 5 minutes
+	for i := 1; i <= 10; i++ {
+		store.RecordTask(TaskRecord{Type: "image", Status: TaskStatusSuccess, EndTime: now.Add(-time.Minute), Duration: time.Duration(i) * 10 * time.Second})
+	}
+	// Errors and expired samples are ignored
+	store.RecordTask(TaskRecord{Type: "image", Status: TaskStatusError, EndTime: now, Duration: time.Hour})
+	store.RecordTask(TaskRecord{Type: "image", Status: TaskStatusSuccess, EndTime: now.Add(-2 * time.Hour), Duration: time.Hour})
+
+	latency := store.GetTaskMetrics().ByType["image"].Latency
+	if len(latency) != 2 {
+		t.Fatalf("len(Latency) = %d, want 2", len(latency))
+	}
+
+	short := latency[0]
+	if short.Window != "5m" || short.Samples != 10 {
+		t.Errorf("short window = %+v, want 5m with 10 samples", short)
+	}
+	if short.P50 != 50*time.Second || short.P95 != 100*time.Second || short.P99 != 100*time.Second {
+		t.Errorf("short percentiles = %v/%v/%v", short.P50, short.P95, short.P99)
+	}
+
+	long := latency[1]
+	if long.Window != "1h" || long.Samples != 20 || long.P50 != time.Second {
+		t.Errorf("long window = %+v, want 1h with 20 samples and p50 1s", long)
+	}
+}
+
+func TestMetricsStore_LatencySampleCapacity(t *testing.T) {
+	store := NewMetricsStore(StoreConfig{LatencySampleCapacity: 3}, time.Now())
+	for i := 1; i <= 5; i++ {
+		store.RecordTask(TaskRecord{Type: "note", Status: TaskStatusSuccess, Duration: time.Duration(i) * time.Second})
+	}
+	stats := store.GetTaskMetrics().ByType["note"].Latency[0]
+	if stats.Samples != 3 || stats.P50 != 4*time.Second {
+		t.Errorf("stats = %+v, want the 3 most recent samples", stats)
+	}
+}
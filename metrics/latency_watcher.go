@@ -0,0 +1,195 @@
+// Package metrics provides the LatencyWatcher organism for latency alerts.
+// This file contains the LatencyWatcher which compares per-type p95 durations
+// against thresholds and reports breaches and recoveries.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AnyTaskType is the threshold key applied to task types without their own threshold.
+const AnyTaskType = "*"
+
+// LatencyAlert reports that a task type's p95 duration crossed its threshold.
+type LatencyAlert struct {
+	// TaskType is the task type whose latency changed state
+	TaskType string `json:"task_type"`
+
+	// Window is the sliding window the p95 was computed over
+	Window string `json:"window"`
+
+	// P95 is the current p95 duration
+	P95 time.Duration `json:"p95"`
+
+	// Threshold is the configured p95 threshold
+	Threshold time.Duration `json:"threshold"`
+
+	// Samples is the number of tasks in the window
+	Samples int `json:"samples"`
+
+	// Breached is true when P95 exceeds Threshold, false on recovery
+	Breached bool `json:"breached"`
+
+	// Time is when the change was detected
+	Time time.Time `json:"time"`
+}
+
+// LatencyWatcherConfig configures the LatencyWatcher.
+type LatencyWatcherConfig struct {
+	// Thresholds maps task type (or AnyTaskType) to its p95 threshold
+	Thresholds map[string]time.Duration
+
+	// MinSamples is the minimum window size before alerting (default: 5)
+	MinSamples int
+
+	// CheckInterval is how often Run evaluates thresholds (default: 30s)
+	CheckInterval time.Duration
+}
+
+// LatencyWatcher is an organism that watches MetricsStore latency
+// percentiles and reports when a task type's p95 over the shortest window
+// exceeds its threshold, and again when it recovers.
+//
+// Usage:
+//
+//	w := metrics.NewLatencyWatcher(store, cfg)
+//	w.SetOnAlert(func(a metrics.LatencyAlert) { ... })
+//	go w.Run(ctx)
+type LatencyWatcher struct {
+	store  *MetricsStore
+	config LatencyWatcherConfig
+
+	mu       sync.Mutex
+	breached map[string]bool
+	onAlert  func(LatencyAlert)
+}
+
+// NewLatencyWatcher creates a LatencyWatcher for store.
+func NewLatencyWatcher(store *MetricsStore, config LatencyWatcherConfig) *LatencyWatcher {
+	if config.MinSamples < 1 {
+		config.MinSamples = 5
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = 30 * time.Second
+	}
+	return &LatencyWatcher{
+		store:    store,
+		config:   config,
+		breached: make(map[string]bool),
+	}
+}
+
+// Enabled reports whether any thresholds are configured.
+func (w *LatencyWatcher) Enabled() bool {
+	return len(w.config.Thresholds) > 0
+}
+
+// SetOnAlert sets the function called for each breach and recovery.
+func (w *LatencyWatcher) SetOnAlert(fn func(LatencyAlert)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onAlert = fn
+}
+
+// threshold returns the p95 threshold for taskType.
+func (w *LatencyWatcher) threshold(taskType string) (time.Duration, bool) {
+	if t, ok := w.config.Thresholds[taskType]; ok {
+		return t, true
+	}
+	t, ok := w.config.Thresholds[AnyTaskType]
+	return t, ok
+}
+
+// Check evaluates all task types once and returns the state changes,
+// which are also passed to the alert function.
+func (w *LatencyWatcher) Check() []LatencyAlert {
+	taskMetrics := w.store.GetTaskMetrics()
+	now := w.store.getClock().Now()
+
+	types := make([]string, 0, len(taskMetrics.ByType))
+	for taskType := range taskMetrics.ByType {
+		types = append(types, taskType)
+	}
+	sort.Strings(types)
+
+	w.mu.Lock()
+	var alerts []LatencyAlert
+	for _, taskType := range types {
+		threshold, ok := w.threshold(taskType)
+		latency := taskMetrics.ByType[taskType].Latency
+		if !ok || threshold <= 0 || len(latency) == 0 {
+			continue
+		}
+		stats := latency[0]
+		if stats.Samples < w.config.MinSamples {
+			continue
+		}
+
+		breached := stats.P95 > threshold
+		if breached == w.breached[taskType] {
+			continue
+		}
+		w.breached[taskType] = breached
+		alerts = append(alerts, LatencyAlert{
+			TaskType:  taskType,
+			Window:    stats.Window,
+			P95:       stats.P95,
+			Threshold: threshold,
+			Samples:   stats.Samples,
+			Breached:  breached,
+			Time:      now,
+		})
+	}
+	onAlert := w.onAlert
+	w.mu.Unlock()
+
+	if onAlert != nil {
+		for _, alert := range alerts {
+			onAlert(alert)
+		}
+	}
+	return alerts
+}
+
+// Run checks thresholds every CheckInterval until ctx is cancelled.
+func (w *LatencyWatcher) Run(ctx context.Context) {
+	ticker := w.store.getClock().NewTicker(w.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			w.Check()
+		}
+	}
+}
+
+// ParseLatencyThresholds parses "type=duration" pairs separated by commas,
+// e.g. "image=30s,pdf=1m,*=20s". "*" applies to all other task types.
+func ParseLatencyThresholds(s string) (map[string]time.Duration, error) {
+	thresholds := make(map[string]time.Duration)
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		taskType, value, ok := strings.Cut(part, "=")
+		taskType = strings.TrimSpace(taskType)
+		if !ok || taskType == "" {
+			return nil, fmt.Errorf("invalid latency threshold %q, want type=duration", part)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid latency threshold duration %q for %s", value, taskType)
+		}
+		thresholds[taskType] = d
+	}
+	return thresholds, nil
+}
@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+func TestLatencyWatcher_BreachAndRecovery(t *testing.T) {
+	store := NewMetricsStore(StoreConfig{LatencySampleCapacity: 5}, time.Now())
+	w := NewLatencyWatcher(store, LatencyWatcherConfig{
+		Thresholds: map[string]time.Duration{"image": 10 * time.Second, AnyTaskType: time.Minute},
+		MinSamples: 3,
+	})
+	var received []LatencyAlert
+	w.SetOnAlert(func(a LatencyAlert) { received = append(received, a) })
+
+	record := func(taskType string, d time.Duration, n int) {
+		for i := 0; i < n; i++ {
+			store.RecordTask(TaskRecord{Type: taskType, Status: TaskStatusSuccess, Duration: d})
+		}
+	}
+
+	// Too few samples to alert
+	record("image", 20*time.Second, 2)
+	if alerts := w.Check(); len(alerts) != 0 {
+		t.Fatalf("Check() with 2 samples = %+v, want none", alerts)
+	}
+
+	record("image", 20*time.Second, 1)
+	record("note", 5*time.Second, 3)
+	alerts := w.Check()
+	if len(alerts) != 1 || alerts[0].TaskType != "image" || !alerts[0].Breached || alerts[0].Threshold != 10*time.Second {
+		t.Fatalf("Check() = %+v, want image breach", alerts)
+	}
+
+	// No repeat while still breached
+	if alerts := w.Check(); len(alerts) != 0 {
+		t.Errorf("repeated Check() = %+v, want none", alerts)
+	}
+
+	// Fast tasks push the slow ones out of the sample buffer
+	record("image", time.Second, 5)
+	alerts = w.Check()
+	if len(alerts) != 1 || alerts[0].Breached {
+		t.Fatalf("Check() after recovery = %+v, want image recovery", alerts)
+	}
+	if len(received) != 2 {
+		t.Errorf("onAlert called %d times, want 2", len(received))
+	}
+}
+
+func TestLatencyWatcher_RunChecksEachInterval(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	store := NewMetricsStore(DefaultStoreConfig(), fake.Now())
+	store.SetClock(fake)
+	w := NewLatencyWatcher(store, LatencyWatcherConfig{
+		Thresholds: map[string]time.Duration{"image": 10 * time.Second},
+		MinSamples: 1,
+	})
+	alerts := make(chan LatencyAlert, 1)
+	w.SetOnAlert(func(a LatencyAlert) { alerts <- a })
+	store.RecordTask(TaskRecord{Type: "image", Status: TaskStatusSuccess, Duration: time.Minute})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	fake.BlockUntil(1)
+	fake.Advance(30 * time.Second)
+	select {
+	case a := <-alerts:
+		if !a.Breached || !a.Time.Equal(fake.Now()) {
+			t.Errorf("alert = %+v, want breach at %v", a, fake.Now())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert after one check interval")
+	}
+}
+
+func TestParseLatencyThresholds(t *testing.T) {
+	got, err := ParseLatencyThresholds(" image=30s, pdf=1m ,*=20s,")
+	if err != nil {
+		t.Fatalf("ParseLatencyThresholds() error = %v", err)
+	}
+	if len(got) != 3 || got["image"] != 30*time.Second || got["pdf"] != time.Minute || got[AnyTaskType] != 20*time.Second {
+		t.Errorf("ParseLatencyThresholds() = %v", got)
+	}
+
+	for _, bad := range []string{"image", "image=fast", "=30s", "image=-1s"} {
+		if _, err := ParseLatencyThresholds(bad); err == nil {
+			t.Errorf("ParseLatencyThresholds(%q) should fail", bad)
+		}
+	}
+
+	if got, err := ParseLatencyThresholds(""); err != nil || len(got) != 0 {
+		t.Errorf("ParseLatencyThresholds(\"\") = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,133 @@
+// Package metrics provides the NVMLReader for GPU metrics collection.
+// This file reads GPU metrics directly from NVML (libnvidia-ml) instead of
+// spawning nvidia-smi, giving per-GPU and per-process detail.
+//
+// Build Tags:
+// - cgo: go-nvml loads libnvidia-ml through CGo
+// - !nocgo: Excluded when nocgo tag is set (see nvml_reader_stub.go)
+//
+//go:build cgo && !nocgo
+
+package metrics
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+// NVMLLinked reports whether this build reads GPU metrics through NVML.
+const NVMLLinked = true
+
+// NVMLReader is a GPUReader backed by the NVIDIA Management Library.
+type NVMLReader struct {
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewNVMLReader initializes NVML and returns a reader.
+// Returns an error if the NVML library or a driver is not available.
+// Callers must call Close when the reader is no longer needed.
+func NewNVMLReader() (*NVMLReader, error) {
+	if ret := nvml.Init(); ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("NVML init failed: %v", nvml.ErrorString(ret))
+	}
+	return &NVMLReader{}, nil
+}
+
+// Close shuts down NVML. Safe to call multiple times.
+func (r *NVMLReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if ret := nvml.Shutdown(); ret != nvml.SUCCESS {
+		return fmt.Errorf("NVML shutdown failed: %v", nvml.ErrorString(ret))
+	}
+	return nil
+}
+
+// ReadGPUMetrics reads metrics for every GPU visible to NVML.
+// Per-device values are returned in Devices and aggregated into the
+// top-level fields. Optional queries (power, processes) that the device
+// does not support are left at zero rather than failing the whole read.
+func (r *NVMLReader) ReadGPUMetrics() (GPUMetrics, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return GPUMetrics{}, fmt.Errorf("NVML reader is closed")
+	}
+
+	count, ret := nvml.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		return GPUMetrics{}, fmt.Errorf("failed to get device count: %v", nvml.ErrorString(ret))
+	}
+	if count == 0 {
+		return GPUMetrics{}, fmt.Errorf("no NVIDIA GPUs found")
+	}
+
+	devices := make([]GPUDeviceMetrics, 0, count)
+	var processes []GPUProcessMemory
+
+	for i := 0; i < count; i++ {
+		device, ret := nvml.DeviceGetHandleByIndex(i)
+		if ret != nvml.SUCCESS {
+			return GPUMetrics{}, fmt.Errorf("failed to get handle for GPU %d: %v", i, nvml.ErrorString(ret))
+		}
+
+		mem, ret := device.GetMemoryInfo()
+		if ret != nvml.SUCCESS {
+			return GPUMetrics{}, fmt.Errorf("failed to get memory info for GPU %d: %v", i, nvml.ErrorString(ret))
+		}
+
+		d := GPUDeviceMetrics{
+			Index:       i,
+			MemoryTotal: int64(mem.Total),
+			MemoryUsed:  int64(mem.Used),
+			MemoryFree:  int64(mem.Free),
+		}
+
+		if name, ret := device.GetName(); ret == nvml.SUCCESS {
+			d.Name = name
+		}
+		if uuid, ret := device.GetUUID(); ret == nvml.SUCCESS {
+			d.UUID = uuid
+		}
+		if util, ret := device.GetUtilizationRates(); ret == nvml.SUCCESS {
+			d.Utilization = float64(util.Gpu)
+		}
+		if temp, ret := device.GetTemperature(nvml.TEMPERATURE_GPU); ret == nvml.SUCCESS {
+			d.Temperature = float64(temp)
+		}
+		// Power values are reported in milliwatts
+		if power, ret := device.GetPowerUsage(); ret == nvml.SUCCESS {
+			d.PowerDraw = float64(power) / 1000.0
+		}
+		if limit, ret := device.GetEnforcedPowerLimit(); ret == nvml.SUCCESS {
+			d.PowerLimit = float64(limit) / 1000.0
+		}
+
+		if procs, ret := device.GetComputeRunningProcesses(); ret == nvml.SUCCESS {
+			for _, p := range procs {
+				processes = append(processes, GPUProcessMemory{
+					PID:         p.Pid,
+					DeviceIndex: i,
+					MemoryUsed:  int64(p.UsedGpuMemory),
+				})
+			}
+		}
+
+		devices = append(devices, d)
+	}
+
+	metrics := AggregateGPUDevices(devices)
+	metrics.Processes = processes
+	if version, ret := nvml.SystemGetDriverVersion(); ret == nvml.SUCCESS {
+		metrics.DriverVersion = version
+	}
+
+	return metrics, nil
+}
@@ -0,0 +1,32 @@
+// Package metrics provides a stub NVMLReader for builds without CGo.
+//
+// Build Tags:
+// - nocgo: Enable this file when CGo is disabled or NVML is unavailable
+// - !cgo: Also enable when CGo is not available
+//
+//go:build nocgo || !cgo
+
+package metrics
+
+import "fmt"
+
+// NVMLLinked reports whether this build reads GPU metrics through NVML.
+const NVMLLinked = false
+
+// NVMLReader is a GPUReader backed by the NVIDIA Management Library (stub).
+type NVMLReader struct{}
+
+// NewNVMLReader always fails in builds without CGo.
+func NewNVMLReader() (*NVMLReader, error) {
+	return nil, fmt.Errorf("NVML not available: built without cgo")
+}
+
+// Close is a no-op in stub mode.
+func (r *NVMLReader) Close() error {
+	return nil
+}
+
+// ReadGPUMetrics always fails in stub mode.
+func (r *NVMLReader) ReadGPUMetrics() (GPUMetrics, error) {
+	return GPUMetrics{}, fmt.Errorf("NVML not available: built without cgo")
+}
@@ -0,0 +1,339 @@
+// Package metrics provides the SLOMonitor organism for service level objectives.
+// This file contains SLO definitions and the SLOMonitor which evaluates
+// error-budget burn rates from MetricsStore task outcomes.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SLOKind identifies what an SLO measures.
+type SLOKind string
+
+const (
+	// SLOKindLatency requires a fraction of tasks to finish under a duration
+	SLOKindLatency SLOKind = "latency"
+
+	// SLOKindErrorRate requires the error rate to stay under a percentage
+	SLOKindErrorRate SLOKind = "error_rate"
+)
+
+// SLO is a service level objective for one task type.
+type SLO struct {
+	// TaskType is the task type the objective applies to, or AnyTaskType
+	// to apply it to every type separately
+	TaskType string
+
+	// Kind is what the objective measures
+	Kind SLOKind
+
+	// Target is the fraction of tasks that must be good (e.g., 0.95)
+	Target float64
+
+	// Latency is the duration a latency SLO's tasks must finish under
+	Latency time.Duration
+}
+
+// String formats the SLO in the syntax accepted by ParseSLOs.
+func (s SLO) String() string {
+	if s.Kind == SLOKindLatency {
+		return fmt.Sprintf("%s:p%s<%s", s.TaskType, formatPercent(s.Target*100), s.Latency)
+	}
+	return fmt.Sprintf("%s:errors<%s%%", s.TaskType, formatPercent((1-s.Target)*100))
+}
+
+// budget returns the allowed fraction of bad tasks.
+func (s SLO) budget() float64 {
+	return 1 - s.Target
+}
+
+// SLOStatus is the evaluation of one SLO for one task type.
+type SLOStatus struct {
+	// SLO is the objective in ParseSLOs syntax (e.g., "note:p95<20s")
+	SLO string `json:"slo"`
+
+	// TaskType is the evaluated task type
+	TaskType string `json:"task_type"`
+
+	// Kind is what the objective measures
+	Kind SLOKind `json:"kind"`
+
+	// ShortBurnRate and LongBurnRate are how fast the error budget is being
+	// spent over each window; 1 spends it exactly at the allowed rate
+	ShortBurnRate float64 `json:"short_burn_rate"`
+	LongBurnRate  float64 `json:"long_burn_rate"`
+
+	// Samples is the number of tasks in the long window
+	Samples int `json:"samples"`
+
+	// Violated is true while both burn rates exceed the threshold
+	Violated bool `json:"violated"`
+
+	// Time is when the status was evaluated
+	Time time.Time `json:"time"`
+}
+
+// SLOConfig configures the SLOMonitor.
+type SLOConfig struct {
+	// SLOs are the objectives to evaluate
+	SLOs []SLO
+
+	// ShortWindow and LongWindow are the burn-rate windows (default: 5m and 1h).
+	// An SLO is violated only when both burn rates exceed BurnRateThreshold,
+	// so short spikes and long-recovered incidents do not alert.
+	ShortWindow time.Duration
+	LongWindow  time.Duration
+
+	// BurnRateThreshold is the burn rate that triggers an alert (default: 2)
+	BurnRateThreshold float64
+
+	// MinSamples is the minimum tasks in the long window before alerting (default: 10)
+	MinSamples int
+
+	// CheckInterval is how often Run evaluates the SLOs (default: 30s)
+	CheckInterval time.Duration
+}
+
+// SLOMonitor is an organism that evaluates SLO burn rates from the task
+// outcomes held by a MetricsStore and reports violations and recoveries.
+//
+// Usage:
+//
+//	m := metrics.NewSLOMonitor(store, cfg)
+//	m.SetOnAlert(func(s metrics.SLOStatus) { ... })
+//	go m.Run(ctx)
+type SLOMonitor struct {
+	store  *MetricsStore
+	config SLOConfig
+
+	mu       sync.Mutex
+	statuses map[string]SLOStatus
+	onAlert  func(SLOStatus)
+}
+
+// NewSLOMonitor creates an SLOMonitor for store.
+func NewSLOMonitor(store *MetricsStore, config SLOConfig) *SLOMonitor {
+	if config.ShortWindow <= 0 {
+		config.ShortWindow = 5 * time.Minute
+	}
+	if config.LongWindow <= 0 {
+		config.LongWindow = time.Hour
+	}
+	if config.BurnRateThreshold <= 0 {
+		config.BurnRateThreshold = 2
+	}
+	if config.MinSamples < 1 {
+		config.MinSamples = 10
+	}
+	if config.CheckInterval <= 0 {
+		config.CheckInterval = 30 * time.Second
+	}
+	return &SLOMonitor{
+		store:    store,
+		config:   config,
+		statuses: make(map[string]SLOStatus),
+	}
+}
+
+// Enabled reports whether any SLOs are configured.
+func (m *SLOMonitor) Enabled() bool {
+	return m != nil && len(m.config.SLOs) > 0
+}
+
+// SetOnAlert sets the function called when an SLO becomes violated or recovers.
+func (m *SLOMonitor) SetOnAlert(fn func(SLOStatus)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onAlert = fn
+}
+
+// Statuses returns the latest evaluation of every SLO, violated first.
+func (m *SLOMonitor) Statuses() []SLOStatus {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]SLOStatus, 0, len(m.statuses))
+	for _, status := range m.statuses {
+		statuses = append(statuses, status)
+	}
+	sortSLOStatuses(statuses)
+	return statuses
+}
+
+// Check evaluates every SLO once and returns the statuses that changed
+// between violated and not violated, which are also passed to the alert
+// function.
+func (m *SLOMonitor) Check() []SLOStatus {
+	now := m.store.getClock().Now()
+	var taskTypes []string
+	for taskType := range m.store.GetTaskMetrics().ByType {
+		taskTypes = append(taskTypes, taskType)
+	}
+	sort.Strings(taskTypes)
+
+	var evaluated []SLOStatus
+	for _, slo := range m.config.SLOs {
+		for _, taskType := range taskTypes {
+			if slo.TaskType != AnyTaskType && slo.TaskType != taskType {
+				continue
+			}
+			evaluated = append(evaluated, m.evaluate(slo, taskType, now))
+		}
+	}
+
+	m.mu.Lock()
+	var changed []SLOStatus
+	for _, status := range evaluated {
+		key := status.SLO + "|" + status.TaskType
+		if previous, ok := m.statuses[key]; ok && previous.Violated != status.Violated {
+			changed = append(changed, status)
+		} else if !ok && status.Violated {
+			changed = append(changed, status)
+		}
+		m.statuses[key] = status
+	}
+	onAlert := m.onAlert
+	m.mu.Unlock()
+
+	if onAlert != nil {
+		for _, status := range changed {
+			onAlert(status)
+		}
+	}
+	return changed
+}
+
+// evaluate computes the burn rates of slo for taskType.
+func (m *SLOMonitor) evaluate(slo SLO, taskType string, now time.Time) SLOStatus {
+	var slowAt time.Duration
+	if slo.Kind == SLOKindLatency {
+		slowAt = slo.Latency
+	}
+	short := m.store.TaskOutcomes(taskType, m.config.ShortWindow, slowAt)
+	long := m.store.TaskOutcomes(taskType, m.config.LongWindow, slowAt)
+
+	status := SLOStatus{
+		SLO:           slo.String(),
+		TaskType:      taskType,
+		Kind:          slo.Kind,
+		ShortBurnRate: burnRate(slo, short),
+		LongBurnRate:  burnRate(slo, long),
+		Samples:       long.Total,
+		Time:          now,
+	}
+	status.Violated = long.Total >= m.config.MinSamples &&
+		status.ShortBurnRate > m.config.BurnRateThreshold &&
+		status.LongBurnRate > m.config.BurnRateThreshold
+	return status
+}
+
+// burnRate returns the bad fraction of counts divided by the SLO's budget.
+func burnRate(slo SLO, counts OutcomeCounts) float64 {
+	if counts.Total == 0 || slo.budget() <= 0 {
+		return 0
+	}
+	// Failed tasks count against latency objectives too
+	bad := counts.Errors
+	if slo.Kind == SLOKindLatency {
+		bad += counts.Slow
+	}
+	return float64(bad) / float64(counts.Total) / slo.budget()
+}
+
+// Run evaluates the SLOs every CheckInterval until ctx is cancelled.
+func (m *SLOMonitor) Run(ctx context.Context) {
+	ticker := m.store.getClock().NewTicker(m.config.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			m.Check()
+		}
+	}
+}
+
+// sortSLOStatuses orders statuses violated first, then by SLO and type.
+func sortSLOStatuses(statuses []SLOStatus) {
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Violated != statuses[j].Violated {
+			return statuses[i].Violated
+		}
+		if statuses[i].SLO != statuses[j].SLO {
+			return statuses[i].SLO < statuses[j].SLO
+		}
+		return statuses[i].TaskType < statuses[j].TaskType
+	})
+}
+
+// ParseSLOs parses comma-separated objectives of the forms
+//
+//	<type>:p<percent><<duration>   e.g. note:p95<20s   (95% of tasks under 20s)
+//	<type>:errors<<percent>%       e.g. *:errors<2%    (error rate under 2%)
+//
+// where <type> is a task type or "*" for every type.
+func ParseSLOs(s string) ([]SLO, error) {
+	var slos []SLO
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		slo, err := parseSLO(part)
+		if err != nil {
+			return nil, err
+		}
+		slos = append(slos, slo)
+	}
+	return slos, nil
+}
+
+// parseSLO parses a single objective.
+func parseSLO(s string) (SLO, error) {
+	taskType, objective, ok := strings.Cut(s, ":")
+	taskType = strings.TrimSpace(taskType)
+	metric, limit, hasLimit := strings.Cut(strings.TrimSpace(objective), "<")
+	if !ok || taskType == "" || !hasLimit {
+		return SLO{}, fmt.Errorf("invalid SLO %q, want type:p95<20s or type:errors<2%%", s)
+	}
+	metric = strings.TrimSpace(metric)
+	limit = strings.TrimSpace(limit)
+
+	if metric == "errors" {
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(limit, "%"), 64)
+		if err != nil || pct <= 0 || pct >= 100 {
+			return SLO{}, fmt.Errorf("invalid SLO %q: error rate must be a percentage between 0 and 100", s)
+		}
+		return SLO{TaskType: taskType, Kind: SLOKindErrorRate, Target: 1 - pct/100}, nil
+	}
+
+	if !strings.HasPrefix(metric, "p") {
+		return SLO{}, fmt.Errorf("invalid SLO %q: unknown objective %q", s, metric)
+	}
+	pct, err := strconv.ParseFloat(metric[1:], 64)
+	if err != nil || pct <= 0 || pct >= 100 {
+		return SLO{}, fmt.Errorf("invalid SLO %q: percentile must be between 0 and 100", s)
+	}
+	latency, err := time.ParseDuration(limit)
+	if err != nil || latency <= 0 {
+		return SLO{}, fmt.Errorf("invalid SLO %q: invalid duration %q", s, limit)
+	}
+	return SLO{TaskType: taskType, Kind: SLOKindLatency, Target: pct / 100, Latency: latency}, nil
+}
+
+// formatPercent formats a percentage without trailing zeros.
+func formatPercent(pct float64) string {
+	return strconv.FormatFloat(math.Round(pct*1000)/1000, 'f', -1, 64)
+}
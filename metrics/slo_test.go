@@ -0,0 +1,116 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+func TestParseSLOs(t *testing.T) {
+	slos, err := ParseSLOs("note:p95<20s, *:errors<2%")
+	if err != nil {
+		t.Fatalf("ParseSLOs() error = %v", err)
+	}
+	if len(slos) != 2 {
+		t.Fatalf("len(slos) = %d, want 2", len(slos))
+	}
+
+	latency := slos[0]
+	if latency.TaskType != "note" || latency.Kind != SLOKindLatency || latency.Target != 0.95 || latency.Latency != 20*time.Second {
+		t.Errorf("latency SLO = %+v", latency)
+	}
+	errRate := slos[1]
+	if errRate.TaskType != AnyTaskType || errRate.Kind != SLOKindErrorRate || errRate.Target != 0.98 {
+		t.Errorf("error rate SLO = %+v", errRate)
+	}
+
+	// String round-trips
+	if latency.String() != "note:p95<20s" || errRate.String() != "*:errors<2%" {
+		t.Errorf("String() = %q, %q", latency.String(), errRate.String())
+	}
+
+	for _, bad := range []string{"note", "note:p95", "note:p95<soon", "note:p100<1s", "note:errors<0%", "note:latency<1s", ":errors<2%"} {
+		if _, err := ParseSLOs(bad); err == nil {
+			t.Errorf("ParseSLOs(%q) should fail", bad)
+		}
+	}
+}
+
+func TestBurnRate(t *testing.T) {
+	errSLO := SLO{Kind: SLOKindErrorRate, Target: 0.98}
+	// 4% errors against a 2% budget burns at 2x
+	if got := burnRate(errSLO, OutcomeCounts{Total: 100, Errors: 4, Slow: 50}); got < 1.99 || got > 2.01 {
+		t.Errorf("error burn rate = %v, want 2", got)
+	}
+
+	latencySLO := SLO{Kind: SLOKindLatency, Target: 0.9, Latency: time.Second}
+	// 10 slow + 10 failed of 100 is 20% bad against a 10% budget
+	if got := burnRate(latencySLO, OutcomeCounts{Total: 100, Errors: 10, Slow: 10}); got < 1.99 || got > 2.01 {
+		t.Errorf("latency burn rate = %v, want 2", got)
+	}
+
+	if got := burnRate(errSLO, OutcomeCounts{}); got != 0 {
+		t.Errorf("burn rate with no tasks = %v, want 0", got)
+	}
+}
+
+func TestSLOMonitor_ViolationAndRecovery(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMetricsStore(DefaultStoreConfig(), now)
+	fake := clock.NewFake(now)
+	store.SetClock(fake)
+
+	slos, _ := ParseSLOs("*:errors<10%,note:p90<10s")
+	m := NewSLOMonitor(store, SLOConfig{SLOs: slos, MinSamples: 5})
+	var received []SLOStatus
+	m.SetOnAlert(func(s SLOStatus) { received = append(received, s) })
+
+	record := func(taskType, status string, d time.Duration, at time.Time, n int) {
+		for i := 0; i < n; i++ {
+			store.RecordTask(TaskRecord{Type: taskType, Status: status, Duration: d, EndTime: at})
+		}
+	}
+
+	// Healthy traffic: nothing changes
+	record("note", TaskStatusSuccess, time.Second, now.Add(-30*time.Minute), 10)
+	if changed := m.Check(); len(changed) != 0 {
+		t.Fatalf("Check() on healthy traffic = %+v", changed)
+	}
+	if statuses := m.Statuses(); len(statuses) != 2 || statuses[0].Violated {
+		t.Fatalf("Statuses() = %+v, want 2 healthy statuses", statuses)
+	}
+
+	// Recent failures burn both windows well above 2x
+	record("note", TaskStatusError, time.Second, now.Add(-time.Minute), 5)
+	changed := m.Check()
+	if len(changed) != 2 || !changed[0].Violated || !changed[1].Violated {
+		t.Fatalf("Check() after failures = %+v, want both SLOs violated", changed)
+	}
+	if statuses := m.Statuses(); !statuses[0].Violated {
+		t.Errorf("Statuses() should list violations first: %+v", statuses)
+	}
+
+	// A later window of good tasks recovers both
+	now = now.Add(time.Hour)
+	fake.Set(now)
+	record("note", TaskStatusSuccess, time.Second, now.Add(-time.Minute), 20)
+	changed = m.Check()
+	if len(changed) != 2 || changed[0].Violated || changed[1].Violated {
+		t.Fatalf("Check() after recovery = %+v, want both recovered", changed)
+	}
+	if len(received) != 4 {
+		t.Errorf("onAlert called %d times, want 4", len(received))
+	}
+}
+
+func TestSLOMonitor_MinSamples(t *testing.T) {
+	store := NewMetricsStore(DefaultStoreConfig(), time.Now())
+	store.RecordTask(TaskRecord{Type: "pdf", Status: TaskStatusError})
+
+	slos, _ := ParseSLOs("pdf:errors<1%")
+	m := NewSLOMonitor(store, SLOConfig{SLOs: slos})
+	if changed := m.Check(); len(changed) != 0 {
+		t.Errorf("Check() with 1 sample = %+v, want no alert", changed)
+	}
+}
@@ -0,0 +1,162 @@
+// Package metrics provides MetricsStore snapshots for persistence.
+// This file contains the StoreSnapshot atom and the Persister organism that
+// saves the task history and aggregate counters so they survive restarts.
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultPersistInterval is how often the Persister saves the store.
+const DefaultPersistInterval = time.Minute
+
+// TaskTypeTotals holds the persisted per-type aggregation counters.
+type TaskTypeTotals struct {
+	Count         int64
+	SuccessCount  int64
+	TotalDuration time.Duration
+}
+
+// StoreSnapshot is the persistable state of a MetricsStore: the retained
+// task history and the aggregate counters. GPU metrics and canvas statuses
+// are live values and are not included.
+type StoreSnapshot struct {
+	// Tasks is the retained history, oldest first
+	Tasks []TaskRecord
+
+	TotalTasks   int64
+	TotalSuccess int64
+	TotalErrors  int64
+
+	// ByType holds the per-type counters keyed by task type
+	ByType map[string]TaskTypeTotals
+}
+
+// Snapshot returns a copy of the task history and aggregate counters.
+func (s *MetricsStore) Snapshot() StoreSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snap := StoreSnapshot{
+		Tasks:        make([]TaskRecord, s.taskSize),
+		TotalTasks:   s.totalTasks,
+		TotalSuccess: s.totalSuccess,
+		TotalErrors:  s.totalErrors,
+		ByType:       make(map[string]TaskTypeTotals, len(s.taskByType)),
+	}
+	for i := 0; i < s.taskSize; i++ {
+		snap.Tasks[i] = s.taskHistory[(s.taskHead-s.taskSize+i+s.taskCap)%s.taskCap]
+	}
+	for taskType, stats := range s.taskByType {
+		snap.ByType[taskType] = TaskTypeTotals{
+			Count:         stats.count,
+			SuccessCount:  stats.successCount,
+			TotalDuration: stats.totalDuration,
+		}
+	}
+	return snap
+}
+
+// Restore replaces the task history and aggregate counters with snap.
+// If snap holds more tasks than the store's capacity, only the most recent
+// are kept. GPU metrics and canvas statuses are left unchanged.
+func (s *MetricsStore) Restore(snap StoreSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tasks := snap.Tasks
+	if len(tasks) > s.taskCap {
+		tasks = tasks[len(tasks)-s.taskCap:]
+	}
+	s.taskHistory = make([]TaskRecord, s.taskCap)
+	copy(s.taskHistory, tasks)
+	s.taskSize = len(tasks)
+	s.taskHead = s.taskSize % s.taskCap
+
+	s.totalTasks = snap.TotalTasks
+	s.totalSuccess = snap.TotalSuccess
+	s.totalErrors = snap.TotalErrors
+
+	s.taskByType = make(map[string]*taskTypeStats, len(snap.ByType))
+	for taskType, totals := range snap.ByType {
+		s.taskByType[taskType] = &taskTypeStats{
+			count:         totals.Count,
+			successCount:  totals.SuccessCount,
+			totalDuration: totals.TotalDuration,
+		}
+	}
+}
+
+// SnapshotStorage persists StoreSnapshots.
+// Implemented by db.Repository.
+type SnapshotStorage interface {
+	// SaveMetricsSnapshot replaces the persisted snapshot.
+	SaveMetricsSnapshot(ctx context.Context, snap StoreSnapshot) error
+
+	// LoadMetricsSnapshot returns the persisted snapshot; found is false
+	// if nothing has been saved yet.
+	LoadMetricsSnapshot(ctx context.Context) (snap StoreSnapshot, found bool, err error)
+}
+
+// Persister is an organism that periodically saves a MetricsStore to
+// SnapshotStorage and restores it at startup.
+//
+// Usage:
+//
+//	p := metrics.NewPersister(store, repository, metrics.DefaultPersistInterval)
+//	if err := p.Restore(ctx); err != nil { ... }
+//	go p.Run(ctx, onError)
+//	// on shutdown:
+//	p.Save(ctx)
+type Persister struct {
+	store    *MetricsStore
+	storage  SnapshotStorage
+	interval time.Duration
+}
+
+// NewPersister creates a Persister. A non-positive interval uses
+// DefaultPersistInterval.
+func NewPersister(store *MetricsStore, storage SnapshotStorage, interval time.Duration) *Persister {
+	if interval <= 0 {
+		interval = DefaultPersistInterval
+	}
+	return &Persister{
+		store:    store,
+		storage:  storage,
+		interval: interval,
+	}
+}
+
+// Restore loads the persisted snapshot into the store, if one exists.
+func (p *Persister) Restore(ctx context.Context) (bool, error) {
+	snap, found, err := p.storage.LoadMetricsSnapshot(ctx)
+	if err != nil || !found {
+		return false, err
+	}
+	p.store.Restore(snap)
+	return true, nil
+}
+
+// Save persists the current state of the store.
+func (p *Persister) Save(ctx context.Context) error {
+	return p.storage.SaveMetricsSnapshot(ctx, p.store.Snapshot())
+}
+
+// Run saves the store every interval until ctx is cancelled. Save errors
+// are passed to onError (which may be nil) and do not stop the loop.
+func (p *Persister) Run(ctx context.Context, onError func(error)) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := p.Save(ctx); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
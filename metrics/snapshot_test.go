@@ -0,0 +1,129 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// memorySnapshotStorage is an in-memory SnapshotStorage for tests.
+type memorySnapshotStorage struct {
+	snap    *StoreSnapshot
+	saveErr error
+	saves   int
+}
+
+func (m *memorySnapshotStorage) SaveMetricsSnapshot(ctx context.Context, snap StoreSnapshot) error {
+	m.saves++
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.snap = &snap
+	return nil
+}
+
+func (m *memorySnapshotStorage) LoadMetricsSnapshot(ctx context.Context) (StoreSnapshot, bool, error) {
+	if m.snap == nil {
+		return StoreSnapshot{}, false, nil
+	}
+	return *m.snap, true, nil
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	store := NewMetricsStore(StoreConfig{TaskHistoryCapacity: 3}, time.Now())
+	for i, status := range []string{TaskStatusSuccess, TaskStatusError, TaskStatusSuccess, TaskStatusSuccess} {
+		store.RecordTask(TaskRecord{ID: string(rune('a' + i)), Type: "note", Status: status, Duration: time.Second})
+	}
+
+	snap := store.Snapshot()
+	if len(snap.Tasks) != 3 || snap.Tasks[0].ID != "b" || snap.Tasks[2].ID != "d" {
+		t.Fatalf("snapshot tasks = %+v, want b..d oldest first", snap.Tasks)
+	}
+	if snap.TotalTasks != 4 || snap.TotalSuccess != 3 || snap.TotalErrors != 1 {
+		t.Errorf("snapshot totals = %d/%d/%d, want 4/3/1", snap.TotalTasks, snap.TotalSuccess, snap.TotalErrors)
+	}
+
+	restored := NewMetricsStore(StoreConfig{TaskHistoryCapacity: 2}, time.Now())
+	restored.Restore(snap)
+
+	metrics := restored.GetTaskMetrics()
+	if metrics.TotalProcessed != 4 || metrics.TotalSuccess != 3 {
+		t.Errorf("restored totals = %+v", metrics)
+	}
+	if note := metrics.ByType["note"]; note == nil || note.Count != 4 || note.SuccessRate != 75 || note.AvgDuration != time.Second {
+		t.Errorf("restored note stats = %+v", note)
+	}
+	recent := restored.GetRecentTasks(10)
+	if len(recent) != 2 || recent[0].ID != "c" || recent[1].ID != "d" {
+		t.Errorf("restored history = %+v, want the 2 most recent", recent)
+	}
+
+	// New tasks continue from the restored state
+	restored.RecordTask(TaskRecord{ID: "e", Type: "note", Status: TaskStatusSuccess})
+	recent = restored.GetRecentTasks(10)
+	if len(recent) != 2 || recent[0].ID != "d" || recent[1].ID != "e" {
+		t.Errorf("history after restore = %+v, want d, e", recent)
+	}
+	if restored.GetTaskMetrics().TotalProcessed != 5 {
+		t.Error("TotalProcessed should continue from restored count")
+	}
+}
+
+func TestPersister(t *testing.T) {
+	ctx := context.Background()
+	storage := &memorySnapshotStorage{}
+
+	store := NewMetricsStore(DefaultStoreConfig(), time.Now())
+	p := NewPersister(store, storage, 0)
+	if found, err := p.Restore(ctx); found || err != nil {
+		t.Fatalf("Restore() with empty storage = %v, %v", found, err)
+	}
+
+	store.RecordTask(TaskRecord{ID: "1", Type: "pdf", Status: TaskStatusSuccess})
+	if err := p.Save(ctx); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	next := NewMetricsStore(DefaultStoreConfig(), time.Now())
+	if found, err := NewPersister(next, storage, 0).Restore(ctx); !found || err != nil {
+		t.Fatalf("Restore() = %v, %v", found, err)
+	}
+	if next.GetTaskMetrics().TotalProcessed != 1 || len(next.GetRecentTasks(10)) != 1 {
+		t.Error("restored store should contain the saved task")
+	}
+}
+
+func TestPersisterRun(t *testing.T) {
+	storage := &memorySnapshotStorage{saveErr: errors.New("disk full")}
+	p := NewPersister(NewMetricsStore(DefaultStoreConfig(), time.Now()), storage, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 10)
+	done := make(chan struct{})
+	go func() {
+		p.Run(ctx, func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		})
+		close(done)
+	}()
+
+	select {
+	case err := <-errs:
+		if err.Error() != "disk full" {
+			t.Errorf("onError got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not save within 1s")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not stop after cancel")
+	}
+}
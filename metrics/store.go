@@ -3,8 +3,11 @@
 package metrics
 
 import (
+	"sort"
 	"sync"
 	"time"
+
+	"go_backend/core/clock"
 )
 
 // MetricsStore is an in-memory storage organism for all dashboard metrics.
@@ -35,6 +38,14 @@ type MetricsStore struct {
 	totalSuccess int64
 	totalErrors  int64
 	taskByType   map[string]*taskTypeStats // Per-type statistics
+	filtered     FilterCounts              // Responses changed by the output filter
+	jsonRepair   RepairCounts              // How structured responses were read
+
+	// Recent task outcomes per type (latency percentiles and SLOs)
+	latency         map[string]*latencySamples
+	latencyWindows  []time.Duration
+	latencyCapacity int
+	clock           clock.Clock
 
 	// GPU metrics (latest snapshot)
 	gpuMetrics GPUMetrics
@@ -60,6 +71,12 @@ type StoreConfig struct {
 	TaskHistoryCapacity int
 	// Version is the application version string
 	Version string
+	// LatencyWindows are the sliding windows for duration percentiles
+	// (default: DefaultLatencyWindows)
+	LatencyWindows []time.Duration
+	// LatencySampleCapacity is the max task outcomes retained per task type
+	// (default: DefaultLatencySampleCapacity)
+	LatencySampleCapacity int
 }
 
 // DefaultStoreConfig returns a default configuration.
@@ -78,18 +95,48 @@ func NewMetricsStore(config StoreConfig, startTime time.Time) *MetricsStore {
 		cap = 100
 	}
 
+	windows := append([]time.Duration(nil), config.LatencyWindows...)
+	if len(windows) == 0 {
+		windows = append(windows, DefaultLatencyWindows...)
+	}
+	sort.Slice(windows, func(i, j int) bool { return windows[i] < windows[j] })
+
+	latencyCap := config.LatencySampleCapacity
+	if latencyCap < 1 {
+		latencyCap = DefaultLatencySampleCapacity
+	}
+
 	return &MetricsStore{
-		taskHistory:    make([]TaskRecord, cap),
-		taskCap:        cap,
-		taskHead:       0,
-		taskSize:       0,
-		taskByType:     make(map[string]*taskTypeStats),
-		canvasStatuses: make(map[string]CanvasStatus),
-		startTime:      startTime,
-		version:        config.Version,
+		taskHistory:     make([]TaskRecord, cap),
+		taskCap:         cap,
+		taskHead:        0,
+		taskSize:        0,
+		taskByType:      make(map[string]*taskTypeStats),
+		latency:         make(map[string]*latencySamples),
+		latencyWindows:  windows,
+		latencyCapacity: latencyCap,
+		clock:           clock.Real(),
+		canvasStatuses:  make(map[string]CanvasStatus),
+		startTime:       startTime,
+		version:         config.Version,
 	}
 }
 
+// SetClock replaces the clock that ages task outcomes and drives the SLO
+// monitor and latency watcher built on the store.
+func (s *MetricsStore) SetClock(c clock.Clock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clock = clock.OrReal(c)
+}
+
+// getClock returns the store's clock.
+func (s *MetricsStore) getClock() clock.Clock {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clock
+}
+
 // RecordTask logs a completed task execution.
 // This implements part of the MetricsCollector interface.
 func (s *MetricsStore) RecordTask(task TaskRecord) {
@@ -123,6 +170,70 @@ func (s *MetricsStore) RecordTask(task TaskRecord) {
 		stats.successCount++
 	}
 	stats.totalDuration += task.Duration
+
+	// Track completed outcomes for latency percentiles and SLOs
+	if task.Status == TaskStatusSuccess || task.Status == TaskStatusError {
+		at := task.EndTime
+		if at.IsZero() {
+			at = s.clock.Now()
+		}
+		samples, ok := s.latency[task.Type]
+		if !ok {
+			samples = &latencySamples{}
+			s.latency[task.Type] = samples
+		}
+		sample := latencySample{at: at, duration: task.Duration, success: task.Status == TaskStatusSuccess}
+		samples.add(sample, s.latencyCapacity, s.latencyWindows[len(s.latencyWindows)-1])
+	}
+}
+
+// RecordFilteredResponse counts a response the output filter changed.
+// action is "masked" or "blocked"; other values are ignored.
+// This implements the FilterRecorder interface.
+func (s *MetricsStore) RecordFilteredResponse(action string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch action {
+	case "masked":
+		s.filtered.Masked++
+	case "blocked":
+		s.filtered.Blocked++
+	}
+}
+
+// RecordStructuredResponse counts how a structured LLM response was read.
+// outcome is "valid", "repaired", "retried" or "failed"; other values are
+// ignored. This implements the RepairRecorder interface.
+func (s *MetricsStore) RecordStructuredResponse(outcome string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch outcome {
+	case "valid":
+		s.jsonRepair.Valid++
+	case "repaired":
+		s.jsonRepair.Repaired++
+	case "retried":
+		s.jsonRepair.Retried++
+	case "failed":
+		s.jsonRepair.Failed++
+	}
+}
+
+// TaskOutcomes counts the completed tasks of taskType within the last
+// window. Successful tasks taking slowAt or longer are counted as Slow
+// (slowAt 0 disables). Only outcomes still held for the latency windows
+// are counted.
+func (s *MetricsStore) TaskOutcomes(taskType string, window, slowAt time.Duration) OutcomeCounts {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	samples, ok := s.latency[taskType]
+	if !ok {
+		return OutcomeCounts{}
+	}
+	return samples.outcomes(s.clock.Now().Add(-window), slowAt)
 }
 
 // GetTaskMetrics returns aggregated task processing statistics.
@@ -136,7 +247,10 @@ func (s *MetricsStore) GetTaskMetrics() TaskMetrics {
 		TotalSuccess:   s.totalSuccess,
 		TotalErrors:    s.totalErrors,
 		ByType:         make(map[string]*TaskTypeMetrics),
+		Filtered:       s.filtered,
+		JSONRepair:     s.jsonRepair,
 	}
+	now := s.clock.Now()
 
 	for taskType, stats := range s.taskByType {
 		var successRate float64
@@ -149,11 +263,15 @@ func (s *MetricsStore) GetTaskMetrics() TaskMetrics {
 			avgDuration = stats.totalDuration / time.Duration(stats.count)
 		}
 
-		metrics.ByType[taskType] = &TaskTypeMetrics{
+		typeMetrics := &TaskTypeMetrics{
 			Count:       stats.count,
 			SuccessRate: successRate,
 			AvgDuration: avgDuration,
 		}
+		if samples, ok := s.latency[taskType]; ok {
+			typeMetrics.Latency = samples.stats(now, s.latencyWindows)
+		}
+		metrics.ByType[taskType] = typeMetrics
 	}
 
 	return metrics
@@ -255,10 +373,16 @@ func (s *MetricsStore) GetSystemStatus() SystemStatus {
 	return SystemStatus{
 		Health:    health,
 		Version:   s.version,
-		Uptime:    time.Since(s.startTime),
-		LastCheck: time.Now(),
+		Uptime:    s.clock.Since(s.startTime),
+		LastCheck: s.clock.Now(),
 	}
 }
 
 // Verify MetricsStore implements MetricsCollector interface
 var _ MetricsCollector = (*MetricsStore)(nil)
+
+// Verify MetricsStore implements FilterRecorder interface
+var _ FilterRecorder = (*MetricsStore)(nil)
+
+// Verify MetricsStore implements RepairRecorder interface
+var _ RepairRecorder = (*MetricsStore)(nil)
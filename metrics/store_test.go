@@ -203,6 +203,32 @@ func TestGetRecentTasks(t *testing.T) {
 	})
 }
 
+func TestMetricsStore_RecordFilteredResponse(t *testing.T) {
+	store := NewMetricsStore(DefaultStoreConfig(), time.Now())
+	store.RecordFilteredResponse("masked")
+	store.RecordFilteredResponse("blocked")
+	store.RecordFilteredResponse("blocked")
+	store.RecordFilteredResponse("none")
+
+	got := store.GetTaskMetrics().Filtered
+	if got.Masked != 1 || got.Blocked != 2 {
+		t.Errorf("expected 1 masked and 2 blocked, got %+v", got)
+	}
+}
+
+func TestMetricsStore_RecordStructuredResponse(t *testing.T) {
+	store := NewMetricsStore(DefaultStoreConfig(), time.Now())
+	for _, outcome := range []string{"valid", "valid", "repaired", "retried", "failed", "other"} {
+		store.RecordStructuredResponse(outcome)
+	}
+
+	got := store.GetTaskMetrics().JSONRepair
+	want := RepairCounts{Valid: 2, Repaired: 1, Retried: 1, Failed: 1}
+	if got != want {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
 func TestMetricsStore_GPUMetrics(t *testing.T) {
 	t.Run("returns zero value when not set", func(t *testing.T) {
 		store := NewMetricsStore(DefaultStoreConfig(), time.Now())
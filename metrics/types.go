@@ -16,6 +16,9 @@ type TaskRecord struct {
 	// CanvasID identifies which canvas this task belongs to
 	CanvasID string `json:"canvas_id"`
 
+	// WidgetID is the widget that triggered the task, if known
+	WidgetID string `json:"widget_id,omitempty"`
+
 	// Status indicates the current state: "success", "error", "processing"
 	Status string `json:"status"`
 
@@ -50,6 +53,70 @@ type GPUMetrics struct {
 
 	// MemoryFree is the amount of available GPU memory (bytes)
 	MemoryFree int64 `json:"memory_free"`
+
+	// PowerDraw is the current power draw in watts (0 if unknown)
+	PowerDraw float64 `json:"power_draw,omitempty"`
+
+	// PowerLimit is the enforced power limit in watts (0 if unknown)
+	PowerLimit float64 `json:"power_limit,omitempty"`
+
+	// Name is the product name of the primary GPU
+	Name string `json:"name,omitempty"`
+
+	// DriverVersion is the installed NVIDIA driver version
+	DriverVersion string `json:"driver_version,omitempty"`
+
+	// Devices holds per-GPU metrics when more than one source is available.
+	// The top-level fields are the aggregate across all devices.
+	Devices []GPUDeviceMetrics `json:"devices,omitempty"`
+
+	// Processes lists GPU memory usage per process across all devices
+	Processes []GPUProcessMemory `json:"processes,omitempty"`
+}
+
+// GPUDeviceMetrics represents the metrics of a single GPU device.
+type GPUDeviceMetrics struct {
+	// Index is the NVML device index
+	Index int `json:"index"`
+
+	// Name is the product name of the device
+	Name string `json:"name"`
+
+	// UUID is the unique device identifier
+	UUID string `json:"uuid,omitempty"`
+
+	// Utilization is the GPU utilization percentage (0-100)
+	Utilization float64 `json:"utilization"`
+
+	// Temperature is the GPU temperature in Celsius
+	Temperature float64 `json:"temperature"`
+
+	// MemoryTotal is the total device memory in bytes
+	MemoryTotal int64 `json:"memory_total"`
+
+	// MemoryUsed is the device memory in use (bytes)
+	MemoryUsed int64 `json:"memory_used"`
+
+	// MemoryFree is the free device memory (bytes)
+	MemoryFree int64 `json:"memory_free"`
+
+	// PowerDraw is the current power draw in watts
+	PowerDraw float64 `json:"power_draw"`
+
+	// PowerLimit is the enforced power limit in watts
+	PowerLimit float64 `json:"power_limit"`
+}
+
+// GPUProcessMemory represents the GPU memory used by a single process.
+type GPUProcessMemory struct {
+	// PID is the operating system process ID
+	PID uint32 `json:"pid"`
+
+	// DeviceIndex is the GPU the memory is allocated on
+	DeviceIndex int `json:"device_index"`
+
+	// MemoryUsed is the GPU memory used by the process (bytes)
+	MemoryUsed int64 `json:"memory_used"`
 }
 
 // CanvasStatus represents the connection and health status of a monitored canvas.
@@ -113,6 +180,40 @@ type TaskMetrics struct {
 
 	// ByType contains per-type statistics
 	ByType map[string]*TaskTypeMetrics `json:"by_type"`
+
+	// Filtered counts the responses changed by the output filter
+	Filtered FilterCounts `json:"filtered"`
+
+	// JSONRepair counts how structured LLM responses were read
+	JSONRepair RepairCounts `json:"json_repair"`
+}
+
+// FilterCounts represents the number of LLM responses the output filter
+// masked or blocked. This is a pure data structure with no behavior.
+type FilterCounts struct {
+	// Masked is the count of responses shown with terms masked
+	Masked int64 `json:"masked"`
+
+	// Blocked is the count of responses withheld from the canvas
+	Blocked int64 `json:"blocked"`
+}
+
+// RepairCounts represents how structured (JSON) LLM responses were read.
+// This is a pure data structure with no behavior.
+type RepairCounts struct {
+	// Valid is the count of responses that parsed as written
+	Valid int64 `json:"valid"`
+
+	// Repaired is the count of malformed responses fixed without asking
+	// the model again
+	Repaired int64 `json:"repaired"`
+
+	// Retried is the count of responses the model had to be asked to fix
+	Retried int64 `json:"retried"`
+
+	// Failed is the count of responses that could not be read and were
+	// treated as plain text
+	Failed int64 `json:"failed"`
 }
 
 // TaskTypeMetrics represents statistics for a specific task type.
@@ -126,6 +227,25 @@ type TaskTypeMetrics struct {
 
 	// AvgDuration is the average execution time for this task type
 	AvgDuration time.Duration `json:"avg_duration"`
+
+	// Latency holds duration percentiles over each sliding window,
+	// shortest window first
+	Latency []LatencyStats `json:"latency,omitempty"`
+}
+
+// LatencyStats represents duration percentiles of successful tasks over a
+// sliding window. This is a pure data structure with no behavior.
+type LatencyStats struct {
+	// Window is the window length (e.g., "5m", "1h")
+	Window string `json:"window"`
+
+	// Samples is the number of tasks in the window
+	Samples int `json:"samples"`
+
+	// P50, P95 and P99 are the duration percentiles (0 with no samples)
+	P50 time.Duration `json:"p50"`
+	P95 time.Duration `json:"p95"`
+	P99 time.Duration `json:"p99"`
 }
 
 // Status constants for TaskRecord
@@ -150,4 +270,6 @@ const (
 	TaskTypeImageAnalysis  = "image_analysis"
 	TaskTypeCanvasAnalysis = "canvas_analysis"
 	TaskTypeHandwriting    = "handwriting"
+	TaskTypeExport         = "export"
+	TaskTypeTranscription  = "transcription"
 )
@@ -0,0 +1,122 @@
+// Package main provides the models subcommand for managing downloaded models.
+//
+// Usage:
+//
+//	canvuslocallm models list
+//	canvuslocallm models clean [-dry-run]
+//	canvuslocallm models delete <file>
+//
+// Models are read from LOCAL_MODEL_DIR (default: ./models). Files referenced
+// by LLAMA_MODEL_PATH or SD_MODEL_PATH are in use and never deleted.
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"go_backend/core"
+	"go_backend/core/modelmanager"
+)
+
+// runModelsCommand runs the models subcommand and returns the process exit code.
+func runModelsCommand(args []string) int {
+	fs := flag.NewFlagSet("models", flag.ContinueOnError)
+	dir := fs.String("dir", core.GetEnvOrDefault("LOCAL_MODEL_DIR", "./models"), "model directory")
+	dryRun := fs.Bool("dry-run", false, "list the files clean would delete without deleting them")
+	fs.Usage = func() {
+		fmt.Println("Usage: canvuslocallm models [-dir DIR] list | clean [-dry-run] | delete <file>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return core.ExitCodeError
+	}
+
+	action := "list"
+	if fs.NArg() > 0 {
+		action = fs.Arg(0)
+		// Allow flags after the action, e.g. "models clean -dry-run"
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return core.ExitCodeError
+		}
+	}
+
+	mm := modelmanager.NewModelManager(*dir, nil)
+	inUse := modelmanager.InUseModelPaths()
+
+	switch action {
+	case "list":
+		models, err := mm.ListLocalModels(inUse)
+		if err != nil {
+			fmt.Printf("Failed to list models: %v\n", err)
+			return core.ExitCodeError
+		}
+		printLocalModels(*dir, models)
+
+	case "clean":
+		removed, err := mm.CleanupUnusedModels(inUse, *dryRun)
+		var freed int64
+		for _, m := range removed {
+			freed += m.SizeBytes
+			verb := "Deleted"
+			if *dryRun {
+				verb = "Would delete"
+			}
+			fmt.Printf("%s %s (%s)\n", verb, m.Filename, core.FormatBytes(m.SizeBytes))
+		}
+		if err != nil {
+			fmt.Printf("Cleanup failed: %v\n", err)
+			return core.ExitCodeError
+		}
+		if len(removed) == 0 {
+			fmt.Println("No unused models to delete")
+		} else if *dryRun {
+			fmt.Printf("%s would be freed (run without -dry-run to delete)\n", core.FormatBytes(freed))
+		} else {
+			fmt.Printf("%s freed\n", core.FormatBytes(freed))
+		}
+
+	case "delete":
+		if fs.NArg() != 1 {
+			fs.Usage()
+			return core.ExitCodeError
+		}
+		freed, err := mm.DeleteLocalModel(fs.Arg(0), inUse)
+		if err != nil {
+			fmt.Printf("Delete failed: %v\n", err)
+			return core.ExitCodeError
+		}
+		fmt.Printf("Deleted %s (%s freed)\n", fs.Arg(0), core.FormatBytes(freed))
+
+	default:
+		fs.Usage()
+		return core.ExitCodeError
+	}
+	return core.ExitCodeSuccess
+}
+
+// printLocalModels prints the model files with their status and the free disk space.
+func printLocalModels(dir string, models []modelmanager.LocalModel) {
+	if len(models) == 0 {
+		fmt.Printf("No models in %s\n", dir)
+		return
+	}
+
+	var total int64
+	for _, m := range models {
+		status := "unused"
+		switch {
+		case m.InUse:
+			status = "in use"
+		case m.Partial:
+			status = "partial download"
+		}
+		total += m.SizeBytes
+		fmt.Printf("  %-50s %10s  %s\n", m.Filename, core.FormatBytes(m.SizeBytes), status)
+	}
+
+	fmt.Printf("\n%d files, %s in %s", len(models), core.FormatBytes(total), dir)
+	if info, err := core.GetDiskSpace(dir); err == nil {
+		fmt.Printf(" (%s free)", info.FreeFormatted)
+	}
+	fmt.Println()
+}
@@ -3,20 +3,56 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"go_backend/artifacts"
+	"go_backend/audit"
+	"go_backend/canvasexport"
+	"go_backend/canvassettings"
 	"go_backend/canvusapi"
+	"go_backend/cluster"
+	"go_backend/collage"
 	"go_backend/core"
+	"go_backend/core/clock"
+	"go_backend/core/errs"
 	"go_backend/db"
+	"go_backend/events"
+	"go_backend/featureflags"
+	"go_backend/fewshot"
+	"go_backend/groupsummary"
+	"go_backend/handlers"
+	"go_backend/handwritingbatch"
+	"go_backend/i18n"
 	"go_backend/imagegen"
-	"go_backend/llamaruntime"
+	"go_backend/instancelease"
+	"go_backend/intent"
+	"go_backend/livetranscript"
 	"go_backend/logging"
 	"go_backend/metrics"
+	"go_backend/netdial"
+	"go_backend/notefix"
+	"go_backend/notetags"
+	"go_backend/outputfilter"
+	"go_backend/promptexpander"
+	"go_backend/promptguard"
+	"go_backend/promptlib"
+	"go_backend/recovery"
+	"go_backend/redact"
+	"go_backend/remotetrigger"
+	"go_backend/rewrite"
+	"go_backend/sessions"
+	"go_backend/streamstate"
+	"go_backend/tempfiles"
+	"go_backend/variants"
+	"go_backend/voicenote"
+	"go_backend/watchdog"
 
 	"go.uber.org/zap"
 )
@@ -32,14 +68,31 @@ type Monitor struct {
 	widgetsMux      sync.RWMutex
 	imagegenProc    *imagegen.Processor
 	imagegenProcMux sync.RWMutex
-	llamaClient     *llamaruntime.Client
+	llamaClient     LlamaModel
 	llamaClientMux  sync.RWMutex
-	metricsStore    metrics.MetricsCollector
-	metricsStoreMux sync.RWMutex
-	taskBroadcaster metrics.TaskBroadcaster
-	broadcasterMux  sync.RWMutex
+	events          *events.Bus          // Widget, trigger and task events for subscribers
 	handlerDeps     *HandlerDependencies // Dependency injection for handlers
 	handlerDepsMux  sync.RWMutex
+	watchdog        *watchdog.Watchdog
+	watchdogMux     sync.RWMutex
+	canvasSettings  *canvassettings.Store
+	canvasPolicy    *canvassettings.Policy
+	featureFlags    *featureflags.Registry
+	settingsMux     sync.RWMutex
+	lease           *instancelease.Lease
+	leaseMux        sync.RWMutex
+	cluster         *cluster.Node
+	clusterMux      sync.RWMutex
+	noteTagger      *notetags.Tagger
+	noteTaggerMux   sync.RWMutex
+
+	// Stream state, used only by the Start goroutine: whether a stream
+	// connected before, and when the last one was lost
+	streamed     bool
+	streamLostAt time.Time
+
+	// streamState is the state machine reported by /api/status
+	streamState *streamstate.Machine
 }
 
 // WidgetState tracks widget information
@@ -64,6 +117,7 @@ var sharedCanvas SharedCanvas
 
 // NewMonitor creates a new Monitor instance
 func NewMonitor(client *canvusapi.Client, cfg *core.Config, logger *logging.Logger, repo *db.Repository) *Monitor {
+	bus := events.New(logger.Zap())
 	return &Monitor{
 		client:      client,
 		config:      cfg,
@@ -72,7 +126,49 @@ func NewMonitor(client *canvusapi.Client, cfg *core.Config, logger *logging.Logg
 		done:        make(chan struct{}),
 		widgets:     make(map[string]map[string]interface{}),
 		widgetsMux:  sync.RWMutex{},
-		handlerDeps: NewHandlerDependencies(nil, nil), // Initialize with nil, will be set via SetMetricsStore/SetTaskBroadcaster
+		events:      bus,
+		handlerDeps: NewHandlerDependencies(bus),
+		streamState: streamstate.New(),
+	}
+}
+
+// Events returns the bus the monitor and its handlers publish widget
+// changes, trigger outcomes and task lifecycle events on. Consumers (the
+// metrics store, broadcasters, webhooks, the database) subscribe to it
+// rather than being wired into the monitor.
+func (m *Monitor) Events() *events.Bus {
+	return m.events
+}
+
+// StreamState returns the state machine of the widget stream, for the
+// dashboard and the broadcaster.
+func (m *Monitor) StreamState() *streamstate.Machine {
+	return m.streamState
+}
+
+// setStreamState moves the widget stream to state to and logs the
+// transition. Transitions the machine refuses are logged at debug level.
+func (m *Monitor) setStreamState(to streamstate.State, reason string) {
+	t, err := m.streamState.Transition(to, reason)
+	if err != nil {
+		m.logger.Debug("Ignoring widget stream state change", zap.Error(err))
+		return
+	}
+	if t.From == t.To {
+		return
+	}
+	fields := []zap.Field{
+		zap.String("from", string(t.From)),
+		zap.String("to", string(t.To)),
+	}
+	if reason != "" {
+		fields = append(fields, zap.String("reason", reason))
+	}
+	switch to {
+	case streamstate.StateDegraded, streamstate.StateReconnecting:
+		m.logger.Warn("Widget stream state changed", fields...)
+	default:
+		m.logger.Info("Widget stream state changed", fields...)
 	}
 }
 
@@ -86,52 +182,270 @@ func (m *Monitor) SetImagegenProcessor(proc *imagegen.Processor) {
 	m.logger.Info("imagegen processor set for direct image prompt handling")
 }
 
-// SetMetricsStore sets the metrics recorder for task tracking.
-// This allows the Monitor to record task completion metrics for the dashboard.
-func (m *Monitor) SetMetricsStore(store metrics.MetricsCollector) {
-	m.metricsStoreMux.Lock()
-	defer m.metricsStoreMux.Unlock()
-	m.metricsStore = store
+// SetArtifactStore sets the store that keeps generated images and downloaded
+// PDFs processed by the handlers. A nil store keeps nothing.
+func (m *Monitor) SetArtifactStore(store *artifacts.Store) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetArtifactStore(store)
+	}
+}
+
+// SetImageVariants sets the history of the images generated from trigger
+// notes by the handlers. A nil history records nothing.
+func (m *Monitor) SetImageVariants(history *variants.History) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetImageVariants(history)
+	}
+}
+
+// SetNoteFixes sets the history of {{fix}} corrections made by the
+// handlers. A nil history keeps nothing, so fixes cannot be undone.
+func (m *Monitor) SetNoteFixes(history *notefix.History) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetNoteFixes(history)
+	}
+}
+
+// SetVoiceNotes sets the transcriber of audio files uploaded to the
+// canvas. A nil transcriber leaves audio alone.
+func (m *Monitor) SetVoiceNotes(t *voicenote.Transcriber) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetVoiceNotes(t)
+	}
+}
+
+// SetHandwriting sets the reader of {{handwriting}} batches.
+func (m *Monitor) SetHandwriting(r *handwritingbatch.Reader) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetHandwriting(r)
+	}
+}
+
+// SetClock sets the clock of task records and handler durations. nil uses
+// the real clock.
+func (m *Monitor) SetClock(c clock.Clock) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetClock(c)
+	}
+}
+
+// SetLiveTranscripts sets the manager of {{live}} meeting transcripts. A
+// nil manager answers {{live}} with a note saying it is not set up.
+func (m *Monitor) SetLiveTranscripts(lt *livetranscript.Manager) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetLiveTranscripts(lt)
+	}
+}
+
+// SetNoteTagger sets the tagger that tags notes without a trigger in the
+// background. A nil tagger tags nothing.
+func (m *Monitor) SetNoteTagger(t *notetags.Tagger) {
+	m.noteTaggerMux.Lock()
+	defer m.noteTaggerMux.Unlock()
+	m.noteTagger = t
+}
+
+// getNoteTagger returns the note tagger, or nil if none is set.
+func (m *Monitor) getNoteTagger() *notetags.Tagger {
+	m.noteTaggerMux.RLock()
+	defer m.noteTaggerMux.RUnlock()
+	return m.noteTagger
+}
+
+// SetTempFiles sets the manager that holds files downloaded by the handlers.
+func (m *Monitor) SetTempFiles(tempFiles *tempfiles.TempFileManager) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetTempFiles(tempFiles)
+	}
+}
+
+// SetRedactor sets the redactor that masks personal data in text the
+// handlers send to a cloud LLM.
+func (m *Monitor) SetRedactor(r *redact.Redactor) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetRedactor(r)
+	}
+}
+
+// SetOutputFilter sets the filter that masks or blocks unsafe LLM text
+// before the handlers write it to the canvas.
+func (m *Monitor) SetOutputFilter(f *outputfilter.Filter) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetOutputFilter(f)
+	}
+}
 
-	// Also update handlerDeps
+// SetPromptGuard sets the guard that neutralizes prompt-injection phrases
+// in note, PDF and canvas text before the handlers send it to an LLM.
+func (m *Monitor) SetPromptGuard(g *promptguard.Guard) {
 	m.handlerDepsMux.Lock()
 	defer m.handlerDepsMux.Unlock()
 	if m.handlerDeps != nil {
-		m.handlerDeps.SetMetrics(store, m.taskBroadcaster)
+		m.handlerDeps.SetPromptGuard(g)
 	}
+}
 
-	m.logger.Info("metrics store set for task tracking")
+// SetTaskJournal sets the journal that records running tasks, so the
+// startup recovery pass can requeue or fail them after a crash.
+func (m *Monitor) SetTaskJournal(j *recovery.Journal) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetTaskJournal(j)
+	}
 }
 
-// getMetricsStore returns the metrics recorder if available.
-func (m *Monitor) getMetricsStore() metrics.MetricsCollector {
-	m.metricsStoreMux.RLock()
-	defer m.metricsStoreMux.RUnlock()
-	return m.metricsStore
+// SkipWidget marks a widget as seen in its current state, so reading the
+// canvas at startup does not run its trigger again. The recovery pass uses
+// it for tasks it failed; the widget runs again once it is edited.
+func (m *Monitor) SkipWidget(widget map[string]interface{}) {
+	m.updateWidgetState(Update(widget))
+}
+
+// SetAuditLog sets the audit trail that records the widgets AI tasks
+// create and modify.
+func (m *Monitor) SetAuditLog(l *audit.Log) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetAuditLog(l)
+	}
+}
+
+// SetWidgetObserver sets the observer told about the widgets AI tasks
+// create or write to.
+func (m *Monitor) SetWidgetObserver(o WidgetObserver) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetWidgetObserver(o)
+	}
 }
 
-// SetTaskBroadcaster sets the broadcaster for real-time task updates to WebSocket clients.
-// This allows the Monitor to broadcast task start/complete events for the dashboard.
-func (m *Monitor) SetTaskBroadcaster(broadcaster metrics.TaskBroadcaster) {
-	m.broadcasterMux.Lock()
-	defer m.broadcasterMux.Unlock()
-	m.taskBroadcaster = broadcaster
+// SetSessionRecorder sets the recorder that keeps each canvas session's
+// triggers and AI responses for replay.
+func (m *Monitor) SetSessionRecorder(r *sessions.Recorder) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetSessionRecorder(r)
+	}
+}
 
-	// Also update handlerDeps
+// SetPromptLibrary sets the templates that {{use:name}} triggers expand.
+func (m *Monitor) SetPromptLibrary(l *promptlib.Library) {
 	m.handlerDepsMux.Lock()
 	defer m.handlerDepsMux.Unlock()
 	if m.handlerDeps != nil {
-		m.handlerDeps.SetMetrics(m.metricsStore, broadcaster)
+		m.handlerDeps.SetPromptLibrary(l)
 	}
+}
 
-	m.logger.Info("task broadcaster set for real-time updates")
+// SetIntentClassifier sets the classifier that decides obvious note
+// intents before the LLM is asked.
+func (m *Monitor) SetIntentClassifier(c *intent.Classifier) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetIntentClassifier(c)
+	}
 }
 
-// getTaskBroadcaster returns the task broadcaster if available.
-func (m *Monitor) getTaskBroadcaster() metrics.TaskBroadcaster {
-	m.broadcasterMux.RLock()
-	defer m.broadcasterMux.RUnlock()
-	return m.taskBroadcaster
+// SetPromptExpander sets the stage that expands image requests from notes
+// into detailed image prompts.
+func (m *Monitor) SetPromptExpander(e *promptexpander.Expander) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetPromptExpander(e)
+	}
+}
+
+// SetFewShotExamples sets the few-shot examples attached to the system
+// prompts of AI tasks.
+func (m *Monitor) SetFewShotExamples(s *fewshot.Set) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetFewShotExamples(s)
+	}
+}
+
+// ReplayNotePrompt answers a recorded note prompt with the monitor's
+// models. It is the sessions.RespondFunc used by session replay.
+func (m *Monitor) ReplayNotePrompt(ctx context.Context, prompt, model string) (string, error) {
+	m.handlerDepsMux.RLock()
+	deps := m.handlerDeps
+	m.handlerDepsMux.RUnlock()
+	return replayNotePrompt(ctx, m.config, m.getLlamaClient(), deps, prompt, model)
+}
+
+// SubmitWidget runs the AI task of a widget of the monitored canvas as if
+// it had just changed, even if it was processed before. A note without a
+// trigger has its whole text used as the prompt. It is the
+// remotetrigger.SubmitFunc used by the dashboard.
+func (m *Monitor) SubmitWidget(canvasID string, widget map[string]interface{}) error {
+	if m.config == nil || canvasID != m.config.CanvasID {
+		return remotetrigger.ErrUnknownCanvas
+	}
+	if m.getWatchdog().ProcessingPaused() {
+		return remotetrigger.ErrProcessingPaused
+	}
+
+	update := make(Update, len(widget))
+	for k, v := range widget {
+		update[k] = v
+	}
+	switch handlers.GetStringField(update, "widget_type", "") {
+	case "Note":
+		text := handlers.GetStringField(update, "text", "")
+		if strings.TrimSpace(text) == "" {
+			return remotetrigger.ErrNoPrompt
+		}
+		update["text"] = remotetrigger.Wrap(m.triggerSyntax(), text)
+	case "Image":
+		title := handlers.GetStringField(update, "title", "")
+		if !strings.HasPrefix(title, "Snapshot at") && !strings.HasPrefix(title, "AI_Icon_") {
+			return remotetrigger.ErrNoPrompt
+		}
+	default:
+		return remotetrigger.ErrNoPrompt
+	}
+
+	m.roundLocationValues(&update)
+	m.updateWidgetState(update)
+	m.publishWidget(update)
+	return m.routeUpdate(update)
+}
+
+// SetMetricsStore sets the metrics store counting filtered and repaired
+// responses. Task records reach the store through the event bus.
+func (m *Monitor) SetMetricsStore(store metrics.MetricsCollector) {
+	m.handlerDepsMux.Lock()
+	defer m.handlerDepsMux.Unlock()
+	if m.handlerDeps != nil {
+		m.handlerDeps.SetMetrics(store)
+	}
 }
 
 // getHandlerDeps returns a copy of the handler dependencies for safe concurrent use.
@@ -151,7 +465,7 @@ func (m *Monitor) getImagegenProcessor() *imagegen.Processor {
 // SetLlamaClient sets the llamaruntime client for image analysis.
 // This should be called after the llama runtime is initialized.
 // If not set, image analysis via AI_Icon_Image_Analysis will not be available.
-func (m *Monitor) SetLlamaClient(client *llamaruntime.Client) {
+func (m *Monitor) SetLlamaClient(client LlamaModel) {
 	m.llamaClientMux.Lock()
 	defer m.llamaClientMux.Unlock()
 	m.llamaClient = client
@@ -159,67 +473,211 @@ func (m *Monitor) SetLlamaClient(client *llamaruntime.Client) {
 }
 
 // getLlamaClient returns the llamaruntime client if available.
-func (m *Monitor) getLlamaClient() *llamaruntime.Client {
+func (m *Monitor) getLlamaClient() LlamaModel {
 	m.llamaClientMux.RLock()
 	defer m.llamaClientMux.RUnlock()
 	return m.llamaClient
 }
 
-// RecordTaskStart records that a task has started processing and broadcasts the update.
-// It records to MetricsStore (if available) and broadcasts via TaskBroadcaster (if available).
-// Returns the TaskRecord that should be updated on completion.
-func (m *Monitor) RecordTaskStart(taskID, taskType, canvasID string) metrics.TaskRecord {
-	record := metrics.TaskRecord{
-		ID:        taskID,
-		Type:      taskType,
-		CanvasID:  canvasID,
-		Status:    metrics.TaskStatusProcessing,
-		StartTime: time.Now(),
-	}
-
-	// Broadcast the "processing" status
-	if broadcaster := m.getTaskBroadcaster(); broadcaster != nil {
-		broadcaster.BroadcastTaskUpdateFromMetrics(metrics.TaskBroadcastData{
-			TaskID:   record.ID,
-			TaskType: record.Type,
-			Status:   record.Status,
-			CanvasID: record.CanvasID,
-		})
+// SetWatchdog sets the Canvus health watchdog. The monitor reports every
+// stream connection attempt to it, backs off reconnects while the server is
+// down and skips AI processing while the watchdog pauses it.
+func (m *Monitor) SetWatchdog(wd *watchdog.Watchdog) {
+	m.watchdogMux.Lock()
+	defer m.watchdogMux.Unlock()
+	m.watchdog = wd
+}
+
+// getWatchdog returns the watchdog if set.
+func (m *Monitor) getWatchdog() *watchdog.Watchdog {
+	m.watchdogMux.RLock()
+	defer m.watchdogMux.RUnlock()
+	return m.watchdog
+}
+
+// SetInstanceLease sets the lease that keeps two instances from answering
+// the same canvas. Triggers are skipped while another instance holds it.
+func (m *Monitor) SetInstanceLease(l *instancelease.Lease) {
+	m.leaseMux.Lock()
+	defer m.leaseMux.Unlock()
+	m.lease = l
+}
+
+// getInstanceLease returns the instance lease if set.
+func (m *Monitor) getInstanceLease() *instancelease.Lease {
+	m.leaseMux.RLock()
+	defer m.leaseMux.RUnlock()
+	return m.lease
+}
+
+// SetCluster sets the cluster node. Without the image role, images are
+// generated on the cluster's image nodes.
+func (m *Monitor) SetCluster(node *cluster.Node) {
+	m.clusterMux.Lock()
+	defer m.clusterMux.Unlock()
+	m.cluster = node
+}
+
+// getCluster returns the cluster node if set.
+func (m *Monitor) getCluster() *cluster.Node {
+	m.clusterMux.RLock()
+	defer m.clusterMux.RUnlock()
+	return m.cluster
+}
+
+// SetCanvasSettings sets the per-canvas overrides (features, models,
+// trigger syntax, note colors and daily budget) applied to every update.
+func (m *Monitor) SetCanvasSettings(store *canvassettings.Store) {
+	m.settingsMux.Lock()
+	defer m.settingsMux.Unlock()
+	m.canvasSettings = store
+}
+
+// SetCanvasPolicy sets the allow and deny lists of features per canvas
+// read from CANVAS_POLICY_FILE. A nil policy permits every feature.
+func (m *Monitor) SetCanvasPolicy(policy *canvassettings.Policy) {
+	m.settingsMux.Lock()
+	defer m.settingsMux.Unlock()
+	m.canvasPolicy = policy
+}
+
+// getCanvasPolicy returns the canvas policy, or nil if none is set.
+func (m *Monitor) getCanvasPolicy() *canvassettings.Policy {
+	m.settingsMux.RLock()
+	defer m.settingsMux.RUnlock()
+	return m.canvasPolicy
+}
+
+// SetFeatureFlags sets the registry of the experimental features' flags.
+// Without one, experimental features stay at their defaults (off).
+func (m *Monitor) SetFeatureFlags(registry *featureflags.Registry) {
+	m.settingsMux.Lock()
+	defer m.settingsMux.Unlock()
+	m.featureFlags = registry
+}
+
+// getFeatureFlags returns the feature flag registry, or nil if none is set.
+func (m *Monitor) getFeatureFlags() *featureflags.Registry {
+	m.settingsMux.RLock()
+	defer m.settingsMux.RUnlock()
+	return m.featureFlags
+}
+
+// getCanvasSettings returns the canvas settings store if set.
+func (m *Monitor) getCanvasSettings() *canvassettings.Store {
+	m.settingsMux.RLock()
+	defer m.settingsMux.RUnlock()
+	return m.canvasSettings
+}
+
+// effectiveConfig returns the configuration with the overrides of the
+// monitored canvas applied.
+func (m *Monitor) effectiveConfig() *core.Config {
+	if m.config == nil {
+		return &core.Config{}
 	}
+	return m.getCanvasSettings().Config(m.config.CanvasID, m.config)
+}
 
-	return record
+// triggerSyntax returns the AI trigger markers used on the monitored canvas.
+func (m *Monitor) triggerSyntax() handlers.TriggerSyntax {
+	cfg := m.effectiveConfig()
+	return handlers.NewTriggerSyntax(cfg.TriggerOpen, cfg.TriggerClose)
 }
 
-// RecordTaskComplete records that a task has completed and broadcasts the update.
-// The record parameter should be the one returned from RecordTaskStart.
-// If errMsg is non-empty, the task is marked as failed; otherwise it's marked as successful.
-func (m *Monitor) RecordTaskComplete(record metrics.TaskRecord, errMsg string) {
-	record.EndTime = time.Now()
-	record.Duration = record.EndTime.Sub(record.StartTime)
+// runIfAllowed runs a task unless the canvas policy or settings refuse it.
+// A feature the policy does not permit is answered with a polite refusal
+// note; features disabled in the settings, and experimental features
+// whose flag is off for the canvas, are skipped silently; a disallowed
+// model or a used-up daily budget is reported on the canvas with an error
+// note.
+func (m *Monitor) runIfAllowed(update Update, cfg *core.Config, feature, model string, run func()) {
+	store := m.getCanvasSettings()
+	settings := store.Get(cfg.CanvasID)
+	log := m.logger.With(zap.Any("widget_id", update["id"]), zap.String("feature", feature))
+
+	if !m.getCanvasPolicy().Permits(cfg.CanvasID, feature) {
+		log.Warn("feature not permitted by canvas policy, refusing update",
+			zap.String("canvas_id", cfg.CanvasID))
+		m.publishTrigger(update, cfg, feature, events.TriggerRefused, "feature not permitted by policy: "+feature)
+		text := i18n.T(cfg.Language, i18n.MsgFeatureNotAllowed, strings.ReplaceAll(feature, "_", " "))
+		if err := createRefusalNote(m.client, update, text, cfg, log); err != nil {
+			log.Error("failed to report refused update", zap.Error(err))
+		}
+		return
+	}
+
+	if !settings.FeatureEnabled(feature) {
+		log.Info("feature disabled for this canvas, skipping update")
+		m.publishTrigger(update, cfg, feature, events.TriggerSkipped, "feature disabled: "+feature)
+		return
+	}
 
-	if errMsg != "" {
-		record.Status = metrics.TaskStatusError
-		record.ErrorMsg = errMsg
-	} else {
-		record.Status = metrics.TaskStatusSuccess
+	if featureflags.Gates(feature) && !m.getFeatureFlags().Enabled(feature, cfg.CanvasID) {
+		log.Info("feature flag off for this canvas, skipping update")
+		m.publishTrigger(update, cfg, feature, events.TriggerSkipped, "feature flag off: "+feature)
+		return
 	}
 
-	// Record to metrics store
-	if store := m.getMetricsStore(); store != nil {
-		store.RecordTask(record)
+	ctx := context.Background()
+	var refusal error
+	if !settings.ModelAllowed(model) {
+		if model == "" {
+			model = canvassettings.LocalModel
+		}
+		refusal = errs.New(errs.CodeModelNotAllowed, i18n.T(cfg.Language, i18n.MsgModelNotAllowed, model))
+	} else if err := store.CheckBudget(ctx, cfg.CanvasID); errors.Is(err, canvassettings.ErrBudgetExceeded) {
+		refusal = errs.New(errs.CodeBudgetExceeded, i18n.T(cfg.Language, i18n.MsgBudgetExceeded, settings.DailyTaskLimit))
+	} else if err != nil {
+		log.Warn("failed to check daily task budget, processing anyway", zap.Error(err))
 	}
 
-	// Broadcast the completion status
-	if broadcaster := m.getTaskBroadcaster(); broadcaster != nil {
-		broadcaster.BroadcastTaskUpdateFromMetrics(metrics.TaskBroadcastData{
-			TaskID:   record.ID,
-			TaskType: record.Type,
-			Status:   record.Status,
-			CanvasID: record.CanvasID,
-			Duration: record.Duration,
-			Error:    record.ErrorMsg,
-		})
+	if refusal != nil {
+		log.Warn("update refused by canvas settings", zap.Error(refusal))
+		m.publishTrigger(update, cfg, feature, events.TriggerRefused, refusal.Error())
+		if err := handleAIError(ctx, m.client, m.repository, generateCorrelationID(), update, refusal, "", cfg, log); err != nil {
+			log.Error("failed to report refused update", zap.Error(err))
+		}
+		return
 	}
+	m.publishTrigger(update, cfg, feature, events.TriggerTriggered, feature+": "+widgetContent(update))
+	run()
+}
+
+// publishTrigger publishes what happened to a widget's update when it
+// reached feature, so the widget's history shows why it did or did not get
+// a response.
+func (m *Monitor) publishTrigger(update Update, cfg *core.Config, feature, outcome, detail string) {
+	events.Publish(m.events, events.TriggerHandled{
+		CanvasID:   cfg.CanvasID,
+		WidgetID:   handlers.GetStringField(update, "id", ""),
+		WidgetType: handlers.GetStringField(update, "widget_type", ""),
+		Feature:    feature,
+		Outcome:    outcome,
+		Detail:     detail,
+		Time:       m.getHandlerDeps().now(),
+	})
+}
+
+// publishWidget publishes a relevant widget update before it is routed.
+func (m *Monitor) publishWidget(update Update) {
+	canvasID := ""
+	if m.config != nil {
+		canvasID = m.config.CanvasID
+	}
+	events.Publish(m.events, events.WidgetChanged{
+		CanvasID: canvasID,
+		Widget:   update,
+		Time:     m.getHandlerDeps().now(),
+	})
+}
+
+// widgetContent returns a note's text or another widget's title.
+func widgetContent(update Update) string {
+	if text := handlers.GetStringField(update, "text", ""); text != "" {
+		return text
+	}
+	return handlers.GetStringField(update, "title", "")
 }
 
 // Done returns a channel that's closed when monitoring is complete
@@ -230,6 +688,7 @@ func (m *Monitor) Done() <-chan struct{} {
 // Start begins monitoring the canvas
 func (m *Monitor) Start(ctx context.Context) {
 	defer close(m.done)
+	defer m.setStreamState(streamstate.StateStopped, "monitor stopped")
 
 	for {
 		select {
@@ -237,13 +696,33 @@ func (m *Monitor) Start(ctx context.Context) {
 			m.logger.Warn("Stopping monitor due to context cancellation")
 			return
 		default:
+			wd := m.getWatchdog()
+			m.setStreamState(streamstate.StateConnecting, "")
 			if err := m.connectAndStream(ctx); err != nil {
-				m.logger.Error("Stream error, reconnecting in 5 seconds",
-					zap.Error(err))
+				if ctx.Err() != nil {
+					return
+				}
+				delay := 5 * time.Second
+				if wd != nil {
+					wd.RecordFailure(err)
+					delay = wd.RetryDelay()
+				}
+				if m.streamed && m.streamLostAt.IsZero() {
+					m.streamLostAt = time.Now()
+				}
+				fields := []zap.Field{zap.Duration("retry_in", delay), zap.Error(err)}
+				var dialErr *netdial.Error
+				if errors.As(err, &dialErr) {
+					fields = append(fields,
+						zap.Strings("addresses_tried", dialErr.Addresses()),
+						zap.String("ip_family", string(dialErr.Family)))
+				}
+				m.logger.Error("Stream error, reconnecting", fields...)
+				m.setStreamState(streamstate.StateReconnecting, err.Error())
 				select {
 				case <-ctx.Done():
 					return
-				case <-time.After(5 * time.Second):
+				case <-time.After(delay):
 					continue
 				}
 			}
@@ -251,25 +730,129 @@ func (m *Monitor) Start(ctx context.Context) {
 	}
 }
 
-// connectAndStream establishes and maintains the API stream connection
+// connectAndStream establishes and maintains the API stream connection. It
+// returns when the stream ends; a stream that goes silent (e.g. half-open
+// after a VPN drop) ends with canvusapi.ErrStreamStalled.
 func (m *Monitor) connectAndStream(ctx context.Context) error {
-	// Use the existing GetWidgets method with subscribe=true
-	widgets, err := m.client.GetWidgets(true)
+	reconnect := m.streamed
+	opts := canvusapi.StreamOptions{
+		IdleTimeout:  m.config.StreamIdleTimeout,
+		PingInterval: m.config.StreamPingInterval,
+		OnConnected: func() {
+			m.streamed = true
+			m.setStreamState(streamstate.StateStreaming, "")
+			if wd := m.getWatchdog(); wd != nil {
+				wd.RecordSuccess()
+			}
+		},
+		OnDegraded: func(reason string) {
+			m.setStreamState(streamstate.StateDegraded, reason)
+		},
+		OnRecovered: func() {
+			m.setStreamState(streamstate.StateStreaming, "data resumed")
+		},
+	}
+
+	// The first line holds the full widget list, later lines updates;
+	// handleUpdate logs lines it cannot parse. After a reconnect the list is
+	// compared with the snapshot to replay what changed during the gap.
+	first := true
+	err := m.client.StreamWidgets(ctx, opts, func(line string) {
+		if first && reconnect {
+			first = false
+			m.replayMissedChanges(line)
+			return
+		}
+		first = false
+		m.handleUpdate(line)
+	})
+	if errors.Is(err, canvusapi.ErrStreamStalled) {
+		m.logger.Warn("Widget stream went silent, reconnecting",
+			zap.Duration("idle_timeout", opts.IdleTimeout),
+			zap.Duration("ping_interval", opts.PingInterval),
+			zap.Error(err))
+	}
 	if err != nil {
-		return fmt.Errorf("failed to connect to widget stream: %w", err)
+		return fmt.Errorf("widget stream: %w", err)
 	}
+	return nil
+}
 
-	// Process initial widget state
-	for _, widget := range widgets {
-		if widgetJSON, err := json.Marshal(widget); err == nil {
-			if err := m.handleUpdate(string(widgetJSON)); err != nil {
-				m.logger.Error("Error handling initial widget",
-					zap.Error(err))
-			}
+// replayMissedChanges compares the full widget list sent after a reconnect
+// with the snapshot of known widgets and processes the widgets created or
+// changed while the stream was down, so their triggers are not lost.
+// Widgets deleted meanwhile are dropped from the snapshot.
+func (m *Monitor) replayMissedChanges(line string) {
+	var widgets []Update
+	if err := m.parseUpdates(line, &widgets); err != nil {
+		m.logger.Error("Failed to parse widget list after reconnect", zap.Error(err))
+		return
+	}
+
+	m.widgetsMux.Lock()
+	created, updated, deleted := diffWidgetSnapshot(m.widgets, widgets)
+	for _, id := range deleted {
+		delete(m.widgets, id)
+	}
+	m.widgetsMux.Unlock()
+
+	gap := time.Duration(0)
+	if !m.streamLostAt.IsZero() {
+		gap = time.Since(m.streamLostAt)
+		m.streamLostAt = time.Time{}
+	}
+	m.logger.Info("Replaying changes missed while the widget stream was down",
+		zap.Duration("gap", gap),
+		zap.Int("widgets", len(widgets)),
+		zap.Int("created", len(created)),
+		zap.Int("updated", len(updated)),
+		zap.Int("deleted", len(deleted)))
+
+	// processUpdate records each widget in the snapshot before routing it
+	for _, update := range append(created, updated...) {
+		if err := m.processUpdate(update); err != nil {
+			m.logger.Error("Error replaying update",
+				zap.Any("widget_id", update["id"]),
+				zap.Error(err))
 		}
 	}
+}
 
-	return nil
+// diffWidgetSnapshot compares the current widgets of the canvas with the
+// snapshot of known widgets. It returns the widgets not in the snapshot,
+// the widgets whose text, title or parent changed, and the IDs of snapshot
+// widgets that no longer exist. Only widgets processUpdate would handle
+// (normal Notes and Images) count as created or updated.
+func diffWidgetSnapshot(snapshot map[string]map[string]interface{}, widgets []Update) (created, updated []Update, deleted []string) {
+	current := make(map[string]bool, len(widgets))
+	for _, update := range widgets {
+		id, ok := update["id"].(string)
+		if !ok {
+			continue
+		}
+		current[id] = true
+
+		state, _ := update["state"].(string)
+		widgetType, _ := update["widget_type"].(string)
+		if state != "normal" || (widgetType != "Note" && widgetType != "Image") {
+			continue
+		}
+		known, exists := snapshot[id]
+		switch {
+		case !exists:
+			created = append(created, update)
+		case widgetStateChanged(known, update):
+			updated = append(updated, update)
+		}
+	}
+
+	for id := range snapshot {
+		if !current[id] {
+			deleted = append(deleted, id)
+		}
+	}
+	sort.Strings(deleted)
+	return created, updated, deleted
 }
 
 // handleUpdate processes a single update from the stream
@@ -325,8 +908,8 @@ func (m *Monitor) processUpdate(update Update) error {
 		return m.handleSharedCanvasUpdate(update)
 	}
 
-	// Process only Note and Image widgets
-	if widgetType != "Note" && widgetType != "Image" {
+	// Process only Note and Image widgets, and Video widgets for audio files
+	if widgetType != "Note" && widgetType != "Image" && widgetType != "Video" {
 		return nil
 	}
 
@@ -337,6 +920,7 @@ func (m *Monitor) processUpdate(update Update) error {
 	if !m.isRelevantUpdate(update) {
 		return nil
 	}
+	m.publishWidget(update)
 
 	// Route to appropriate handler
 	return m.routeUpdate(update)
@@ -370,6 +954,17 @@ func (m *Monitor) isRelevantUpdate(update Update) bool {
 		return true
 	}
 
+	if widgetStateChanged(state, update) {
+		m.updateWidgetState(update)
+		return true
+	}
+
+	return false
+}
+
+// widgetStateChanged reports whether update changed the text, title or
+// parent of the widget with the stored state.
+func widgetStateChanged(state map[string]interface{}, update Update) bool {
 	text, _ := update["text"].(string)
 	title, _ := update["title"].(string)
 	parentID, _ := update["parent_id"].(string)
@@ -379,14 +974,9 @@ func (m *Monitor) isRelevantUpdate(update Update) bool {
 	stateTitle, _ := state["title"].(string)
 	stateParentID, _ := state["parent_id"].(string)
 
-	if stateText != text ||
+	return stateText != text ||
 		stateTitle != title ||
-		stateParentID != parentID {
-		m.updateWidgetState(update)
-		return true
-	}
-
-	return false
+		stateParentID != parentID
 }
 
 // updateWidgetState updates the stored state of a widget
@@ -433,30 +1023,141 @@ func (m *Monitor) saveSharedCanvasData(data Update) error {
 
 // routeUpdate directs updates to appropriate handlers
 func (m *Monitor) routeUpdate(update Update) error {
-	// Get handler dependencies for this update
-	deps := m.getHandlerDeps()
+	// Results cannot be written back while the Canvus server is down
+	if m.getWatchdog().ProcessingPaused() {
+		m.logger.Warn("Skipping update while AI processing is paused (Canvus server down)",
+			zap.Any("widget_id", update["id"]))
+		return nil
+	}
+	// Another instance answers this canvas; processing here would duplicate
+	// every response
+	if !m.getInstanceLease().Held() {
+		m.logger.Info("Skipping update while another instance holds the canvas lease",
+			zap.Any("widget_id", update["id"]))
+		return nil
+	}
+
+	// Get the canvas's configuration for this update
+	cfg := m.effectiveConfig()
 
 	switch update["widget_type"].(string) {
 	case "Note":
-		// Check for direct image prompt {{image:...}}
-		if prompt, ok := m.parseImagePrompt(update); ok {
-			go m.handleImagePrompt(update, prompt)
+		text, _ := update["text"].(string)
+		syntax := handlers.NewTriggerSyntax(cfg.TriggerOpen, cfg.TriggerClose)
+		if !syntax.HasTrigger(text) {
+			m.tagNote(update, cfg, text)
+			return nil
+		}
+		// {{export}} posts a link to the canvas as a document,
+		// {{variants}} the earlier images generated from the note,
+		// {{collage}} one image of the images in a zone, {{fix}} corrects
+		// the note itself, {{rewrite}}, {{shorten}} and {{expand}} rewrite
+		// it, {{summarize}} summarizes the notes of its group, {{live}}
+		// turns it into a live meeting transcript and {{handwriting}} reads
+		// every snapshot on the canvas
+		if content, ok := syntax.Enclosed(text); ok {
+			if _, _, ok := canvasexport.ParseTrigger(content); ok {
+				m.dispatch(update, cfg, canvassettings.FeatureExport, "")
+				return nil
+			}
+			if _, ok := variants.ParseTrigger(content); ok {
+				m.dispatch(update, cfg, canvassettings.FeatureImageVariants, "")
+				return nil
+			}
+			if _, ok := collage.ParseTrigger(content); ok {
+				m.dispatch(update, cfg, canvassettings.FeatureCollage, "")
+				return nil
+			}
+			if _, ok := notefix.ParseTrigger(content); ok {
+				m.dispatch(update, cfg, canvassettings.FeatureNoteFix, "")
+				return nil
+			}
+			if _, ok := rewrite.ParseTrigger(content); ok {
+				m.dispatch(update, cfg, canvassettings.FeatureRewrite, "")
+				return nil
+			}
+			if groupsummary.ParseTrigger(content) {
+				m.dispatch(update, cfg, canvassettings.FeatureGroupSummary, "")
+				return nil
+			}
+			if _, ok := livetranscript.ParseTrigger(content); ok {
+				m.dispatch(update, cfg, canvassettings.FeatureLiveTranscript, "")
+				return nil
+			}
+			if handwritingbatch.ParseTrigger(content) {
+				m.dispatch(update, cfg, canvassettings.FeatureHandwritingAll, "")
+				return nil
+			}
+		}
+		// Check for direct image prompt {{image:...}} or {{image@model:...}}
+		if _, options, ok := parseImageTrigger(syntax, update); ok {
+			m.dispatch(update, cfg, canvassettings.FeatureImageGeneration, m.imageModel(cfg, options.Model))
 			return nil
 		}
 		// Fall back to existing text/image classification flow
-		go handleNote(update, m.client, m.config, m.logger, m.repository, m.getLlamaClient(), deps)
+		m.dispatch(update, cfg, canvassettings.FeatureNotes, cfg.OpenAINoteModel)
 	case "Image":
 		if title, ok := update["title"].(string); ok {
 			if strings.HasPrefix(title, "Snapshot at") {
-				go handleSnapshot(update, m.client, m.config, m.logger, m.repository, deps)
+				m.dispatch(update, cfg, canvassettings.FeatureHandwriting, "")
 			} else if strings.HasPrefix(title, "AI_Icon_") {
-				return m.handleAIIcon(update, deps)
+				return m.handleAIIcon(update, cfg)
 			}
 		}
+	case "Video":
+		// Canvus shows audio files as videos; recordings are transcribed once
+		transcriber := m.getHandlerDeps().getVoiceNotes()
+		title := handlers.GetStringField(update, "title", "")
+		if transcriber != nil && voicenote.IsAudio(title) &&
+			!transcriber.Transcribed(context.Background(), cfg.CanvasID, handlers.GetStringField(update, "id", "")) {
+			m.dispatch(update, cfg, canvassettings.FeatureVoiceNotes, "")
+		}
 	}
 	return nil
 }
 
+// tagNote passes a note without a trigger to the note tagger, unless
+// auto-tagging is not permitted or disabled on the canvas. Processing notes
+// and running live transcripts are skipped; the tagger waits for notes to
+// stop changing.
+func (m *Monitor) tagNote(update Update, cfg *core.Config, text string) {
+	tagger := m.getNoteTagger()
+	if tagger == nil || strings.HasPrefix(strings.TrimSpace(text), "⏳") {
+		return
+	}
+	if m.getHandlerDeps().getLiveTranscripts().Active(cfg.CanvasID, handlers.GetStringField(update, "id", "")) {
+		return
+	}
+	if !m.getCanvasPolicy().Permits(cfg.CanvasID, canvassettings.FeatureAutoTags) ||
+		!m.getCanvasSettings().Get(cfg.CanvasID).FeatureEnabled(canvassettings.FeatureAutoTags) {
+		return
+	}
+	client, _ := m.taskTarget(cfg.CanvasID)
+	tagger.Observe(notetags.Note{
+		CanvasID: cfg.CanvasID,
+		ID:       handlers.GetStringField(update, "id", ""),
+		Text:     text,
+		Canvas:   client,
+	})
+}
+
+// GenerateTags asks the local model, or the cloud note model of the
+// canvas, for the tags of a note. Implements notetags.Generator.
+func (m *Monitor) GenerateTags(ctx context.Context, canvasID, systemPrompt, prompt string) (string, string, error) {
+	_, cfg := m.taskTarget(canvasID)
+	llamaClient := m.getLlamaClient()
+	model := canvassettings.LocalModel
+	if llamaClient == nil {
+		model = cfg.OpenAINoteModel
+	}
+	if !m.getCanvasSettings().Get(canvasID).ModelAllowed(model) {
+		return "", "", fmt.Errorf("model %s is not allowed on canvas %s", model, canvasID)
+	}
+	deps := m.getHandlerDeps()
+	prompt, _ = deps.getPromptGuard().Sanitize(prompt)
+	return generateText(ctx, cfg, llamaClient, deps, systemPrompt, prompt, 100, 0.2, false)
+}
+
 // parseImagePrompt checks if the note text contains a direct image prompt.
 // Returns the extracted prompt and true if found, empty string and false otherwise.
 //
@@ -465,60 +1166,91 @@ func (m *Monitor) routeUpdate(update Update) error {
 //   - {{image:prompt text here}}
 //   - {{ image: prompt text here }}
 //   - {{IMAGE: prompt text here}} (case-insensitive prefix)
+//
+// Canvases with their own trigger syntax use their markers instead of {{ }}.
 func (m *Monitor) parseImagePrompt(update Update) (string, bool) {
+	return parseImagePromptWith(m.triggerSyntax(), update)
+}
+
+// parseImagePromptWith extracts a direct image prompt written with syntax.
+func parseImagePromptWith(syntax handlers.TriggerSyntax, update Update) (string, bool) {
+	prompt, _, ok := parseImageTrigger(syntax, update)
+	return prompt, ok
+}
+
+// parseImageTrigger extracts a direct image prompt written with syntax and
+// the options it sets. options.Model is the model the trigger names, e.g.
+// "stability:ultra" for {{image@stability:ultra: a fox}} or "dall-e-3"
+// for {{image: a fox --model dall-e-3}}, and options.Aspect the ratio it
+// starts with, e.g. 16:9 for {{image:16:9 a fox}}. Option flags and the
+// ratio are removed from the prompt.
+func parseImageTrigger(syntax handlers.TriggerSyntax, update Update) (prompt string, options imagegen.ImageOptions, ok bool) {
 	text, ok := update["text"].(string)
 	if !ok || text == "" {
-		return "", false
+		return "", options, false
 	}
 
-	// Find the start of the trigger
-	startIdx := strings.Index(text, "{{")
-	if startIdx == -1 {
-		return "", false
+	content, ok := syntax.Enclosed(text)
+	if !ok {
+		return "", options, false
 	}
-
-	// Find the end of the trigger
-	endIdx := strings.Index(text[startIdx:], "}}")
-	if endIdx == -1 {
-		return "", false
+	prompt, model, ok := imagegen.ParseImageDirective(content)
+	if !ok {
+		return "", options, false
 	}
-	endIdx += startIdx // Adjust to absolute position
-
-	// Extract content between {{ and }}
-	content := text[startIdx+2 : endIdx]
-	content = strings.TrimSpace(content)
-
-	// Check if it starts with "image:" (case-insensitive)
-	lower := strings.ToLower(content)
-	if !strings.HasPrefix(lower, "image:") {
-		return "", false
+	// A --model flag also picks the model, like image@; the other flags
+	// are applied by handleNote
+	prompt, options = imagegen.ParseImageFlags(prompt)
+	if model != "" {
+		options.Model = model
 	}
+	return prompt, options, prompt != ""
+}
 
-	// Extract the prompt after "image:"
-	prompt := strings.TrimSpace(content[6:]) // len("image:") == 6
-	if prompt == "" {
-		return "", false
+// imageModel returns the model an image prompt runs on: the model its
+// trigger names, a hosted model configured for the canvas, or "" for an
+// SD runtime, here or on an image node. "local" in the trigger picks the
+// SD runtime.
+func (m *Monitor) imageModel(cfg *core.Config, triggerModel string) string {
+	switch {
+	case triggerModel == canvassettings.LocalModel:
+		return ""
+	case triggerModel != "":
+		return triggerModel
+	case imagegen.IsHostedModel(cfg.OpenAIImageModel):
+		return cfg.OpenAIImageModel
+	case m.imageGenerator(cfg.CanvasID) != nil:
+		return ""
 	}
-
-	return prompt, true
+	return cfg.OpenAIImageModel
 }
 
 // handleImagePrompt processes a direct image generation prompt via imagegen.
-// If no imagegen processor is available, it falls back to the standard handleNote flow.
-func (m *Monitor) handleImagePrompt(update Update, prompt string) {
+// Prompts for a cloud model, and prompts with no imagegen processor
+// available, go through the standard handleNote flow with that model.
+// options are the trigger's inline flags; options.Model is the model the
+// prompt runs on, or "" for an SD runtime.
+func (m *Monitor) handleImagePrompt(client *canvusapi.Client, update Update, prompt string, options imagegen.ImageOptions, cfg *core.Config) {
 	noteID, _ := update["id"].(string)
 	log := m.logger.With(
 		zap.String("widget_id", noteID),
 		zap.String("prompt_preview", truncatePrompt(prompt, 50)),
 	)
 
-	log.Info("detected direct image prompt")
+	log.Info("detected direct image prompt", zap.String("model", options.Model))
+
+	if options.Model != "" {
+		cloudCfg := *cfg
+		cloudCfg.OpenAIImageModel = options.Model
+		handleNote(update, client, &cloudCfg, m.logger, m.repository, m.getLlamaClient(), m.getHandlerDeps())
+		return
+	}
 
-	// Check if imagegen processor is available
-	proc := m.getImagegenProcessor()
-	if proc == nil {
+	// Check if image generation is available, here or on an image node
+	generate := m.imageGenerator(client.CanvasID)
+	if generate == nil {
 		log.Debug("imagegen processor not available, falling back to handleNote")
-		handleNote(update, m.client, m.config, m.logger, m.repository, m.getLlamaClient(), m.getHandlerDeps())
+		handleNote(update, client, cfg, m.logger, m.repository, m.getLlamaClient(), m.getHandlerDeps())
 		return
 	}
 
@@ -527,43 +1259,53 @@ func (m *Monitor) handleImagePrompt(update Update, prompt string) {
 	if err != nil {
 		log.Error("failed to create parent widget for image generation", zap.Error(err))
 		// Fall back to handleNote which has error handling
-		handleNote(update, m.client, m.config, m.logger, m.repository, m.getLlamaClient(), m.getHandlerDeps())
+		handleNote(update, client, cfg, m.logger, m.repository, m.getLlamaClient(), m.getHandlerDeps())
 		return
 	}
 
 	// Update the note to show processing
 	originalText, _ := update["text"].(string)
-	baseText := strings.ReplaceAll(strings.ReplaceAll(originalText, "{{", ""), "}}", "")
+	syntax := handlers.NewTriggerSyntax(cfg.TriggerOpen, cfg.TriggerClose)
+	baseText := strings.ReplaceAll(strings.ReplaceAll(originalText, syntax.Open, ""), syntax.Close, "")
 	baseText = strings.TrimSpace(baseText)
 	// Remove "image:" prefix for display
 	if strings.HasPrefix(strings.ToLower(baseText), "image:") {
 		baseText = strings.TrimSpace(baseText[6:])
 	}
 
-	_, err = m.client.UpdateNote(noteID, map[string]interface{}{
-		"text": baseText + "\n\n[SD] Generating image...\nThis may take 10-30 seconds.",
+	// --size, --steps, --cfg and --n are limited by the SD settings
+	if err := options.ValidateSD(cfg); err != nil {
+		log.Warn("invalid image options", zap.Error(err))
+		_, _ = client.UpdateNote(noteID, map[string]interface{}{
+			"text": baseText + "\n\n" + i18n.T(cfg.Language, i18n.MsgImageFailed, err),
+		})
+		return
+	}
+
+	_, err = client.UpdateNote(noteID, map[string]interface{}{
+		"text": baseText + "\n\n" + i18n.T(cfg.Language, i18n.MsgGeneratingImage),
 	})
 	if err != nil {
 		log.Warn("failed to update note with processing status", zap.Error(err))
 	}
 
 	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), m.config.AITimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.AITimeout)
 	defer cancel()
 
 	// Process the image prompt
-	result, err := proc.ProcessImagePrompt(ctx, prompt, parentWidget)
+	result, err := generate(ctx, prompt, parentWidget, options)
 	if err != nil {
 		log.Error("image generation failed", zap.Error(err))
 		// Update note with error
-		_, _ = m.client.UpdateNote(noteID, map[string]interface{}{
-			"text": baseText + "\n\n[SD] Image generation failed: " + err.Error(),
+		_, _ = client.UpdateNote(noteID, map[string]interface{}{
+			"text": baseText + "\n\n" + i18n.T(cfg.Language, i18n.MsgImageFailed, err),
 		})
 		return
 	}
 
 	// Clear processing status from note
-	_, err = m.client.UpdateNote(noteID, map[string]interface{}{
+	_, err = client.UpdateNote(noteID, map[string]interface{}{
 		"text": baseText,
 	})
 	if err != nil {
@@ -572,6 +1314,8 @@ func (m *Monitor) handleImagePrompt(update Update, prompt string) {
 
 	log.Info("image generation completed successfully",
 		zap.String("widget_id", result.WidgetID))
+	m.getHandlerDeps().newAuditTrail(cfg, generateCorrelationID(), update, "image_generation", cfg.SDModelPath, log).
+		created(ctx, "Image", result.WidgetID, auditFileContent(result.ImagePath))
 }
 
 // createParentWidget creates an imagegen.ParentWidget from an Update.
@@ -636,7 +1380,7 @@ func truncatePrompt(text string, maxLen int) string {
 }
 
 // handleAIIcon processes AI_Icon_ image updates
-func (m *Monitor) handleAIIcon(update Update, deps *HandlerDependencies) error {
+func (m *Monitor) handleAIIcon(update Update, cfg *core.Config) error {
 	title, _ := update["title"].(string)
 
 	// Extract the action from the title
@@ -645,20 +1389,37 @@ func (m *Monitor) handleAIIcon(update Update, deps *HandlerDependencies) error {
 	// Route to appropriate precis handler based on action
 	switch action {
 	case "PDFPrecis":
-		go handlePDFPrecis(update, m.client, m.config, m.logger, m.repository, deps)
+		m.dispatch(update, cfg, canvassettings.FeaturePDFPrecis, cfg.OpenAIPDFModel)
 	case "CanvusPrecis":
-		go handleCanvusPrecis(update, m.client, m.config, m.logger, m.repository, m.getLlamaClient(), deps)
+		m.dispatch(update, cfg, canvassettings.FeatureCanvasPrecis, cfg.OpenAICanvasModel)
 	case "Image_Analysis":
-		llamaClient := m.getLlamaClient()
-		if llamaClient == nil {
-			m.logger.Warn("llamaruntime client not available for image analysis",
-				zap.String("action", action))
+		if !m.canRunVision("image analysis") {
 			return nil
 		}
-		go handleImageAnalysis(update, m.client, m.config, m.logger, m.repository, llamaClient, deps)
+		m.dispatch(update, cfg, canvassettings.FeatureImageAnalysis, "")
+	case "Image_Extract":
+		if !m.canRunVision("structured extraction") {
+			return nil
+		}
+		m.dispatch(update, cfg, canvassettings.FeatureImageExtraction, "")
+	case "Sticky_Wall":
+		if !m.canRunVision("sticky wall digitization") {
+			return nil
+		}
+		m.dispatch(update, cfg, canvassettings.FeatureStickyWall, "")
 	default:
 		m.logger.Debug("unknown AI_Icon action", zap.String("action", action))
 	}
 
 	return nil
 }
+
+// canRunVision reports whether a vision task can run: here with the
+// llamaruntime client, or on a tasks node of the cluster.
+func (m *Monitor) canRunVision(task string) bool {
+	if m.getLlamaClient() != nil || m.queuesTasks() {
+		return true
+	}
+	m.logger.Warn("llamaruntime client not available for "+task, zap.String("task", task))
+	return false
+}
@@ -1,12 +1,17 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"testing"
 
+	"go_backend/canvassettings"
 	"go_backend/canvusapi"
+	"go_backend/cluster"
 	"go_backend/core"
 	"go_backend/db"
+	"go_backend/featureflags"
 	"go_backend/imagegen"
 	"go_backend/logging"
 )
@@ -82,6 +87,12 @@ func TestParseImagePrompt(t *testing.T) {
 			expectPrompt:  "a forest",
 			expectMatched: true,
 		},
+		{
+			name:          "image prompt naming a model",
+			text:          "{{image@replicate:black-forest-labs/flux-schnell: a red fox}}",
+			expectPrompt:  "a red fox",
+			expectMatched: true,
+		},
 		{
 			name:          "regular text trigger not image",
 			text:          "{{explain quantum physics}}",
@@ -451,3 +462,125 @@ func TestSetLlamaClient(t *testing.T) {
 	// so we just test the getter returns nil when not set.
 	// Full integration testing would require the llama.cpp library to be available.
 }
+
+// TestDiffWidgetSnapshot tests how the widget list after a reconnect is
+// compared with the known widgets.
+func TestDiffWidgetSnapshot(t *testing.T) {
+	snapshot := map[string]map[string]interface{}{
+		"same":    {"text": "hello", "title": "", "parent_id": "root"},
+		"edited":  {"text": "old", "title": "", "parent_id": "root"},
+		"moved":   {"text": "x", "title": "", "parent_id": "root"},
+		"deleted": {"text": "gone", "title": "", "parent_id": "root"},
+	}
+	widgets := []Update{
+		{"id": "same", "widget_type": "Note", "state": "normal", "text": "hello", "parent_id": "root"},
+		{"id": "edited", "widget_type": "Note", "state": "normal", "text": "{{ new }}", "parent_id": "root"},
+		{"id": "moved", "widget_type": "Note", "state": "normal", "text": "x", "parent_id": "other"},
+		{"id": "new", "widget_type": "Note", "state": "normal", "text": "{{ hi }}", "parent_id": "root"},
+		{"id": "new-image", "widget_type": "Image", "state": "normal", "parent_id": "root"},
+		{"id": "new-pdf", "widget_type": "Pdf", "state": "normal", "parent_id": "root"},
+		{"id": "new-deleted", "widget_type": "Note", "state": "deleted", "parent_id": "root"},
+	}
+
+	created, updated, deleted := diffWidgetSnapshot(snapshot, widgets)
+
+	ids := func(updates []Update) []string {
+		var out []string
+		for _, u := range updates {
+			out = append(out, u["id"].(string))
+		}
+		return out
+	}
+	if got := ids(created); len(got) != 2 || got[0] != "new" || got[1] != "new-image" {
+		t.Errorf("created = %v, want [new new-image]", got)
+	}
+	if got := ids(updated); len(got) != 2 || got[0] != "edited" || got[1] != "moved" {
+		t.Errorf("updated = %v, want [edited moved]", got)
+	}
+	if len(deleted) != 1 || deleted[0] != "deleted" {
+		t.Errorf("deleted = %v, want [deleted]", deleted)
+	}
+}
+
+// flagStorage keeps feature flag overrides in memory.
+type flagStorage []featureflags.Override
+
+func (s flagStorage) ListFlagOverrides(ctx context.Context) ([]featureflags.Override, error) {
+	return s, nil
+}
+func (s flagStorage) SaveFlagOverride(ctx context.Context, o featureflags.Override) error { return nil }
+func (s flagStorage) DeleteFlagOverride(ctx context.Context, flag, canvasID string) error {
+	return nil
+}
+
+// TestRunIfAllowedChecksFeatureFlags tests that experimental features run
+// only on canvases their flag is on for.
+func TestRunIfAllowedChecksFeatureFlags(t *testing.T) {
+	m := NewMonitor(&canvusapi.Client{}, &core.Config{}, createTestLogger(t), nil)
+	registry, err := featureflags.NewRegistry(context.Background(), flagStorage{
+		{Flag: featureflags.FlagMindMap, CanvasID: "canvas-a", Enabled: true},
+	}, map[string]bool{featureflags.FlagClustering: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		registry *featureflags.Registry
+		feature  string
+		canvasID string
+		want     bool
+	}{
+		{"flag on for the canvas", registry, featureflags.FlagMindMap, "canvas-a", true},
+		{"flag on for another canvas", registry, featureflags.FlagMindMap, "canvas-b", false},
+		{"flag on in FEATURE_FLAGS", registry, featureflags.FlagClustering, "canvas-b", true},
+		{"flag at its default", registry, featureflags.FlagImg2Img, "canvas-a", false},
+		{"no registry", nil, featureflags.FlagMindMap, "canvas-a", false},
+		{"feature without a flag", nil, canvassettings.FeatureNotes, "canvas-a", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m.SetFeatureFlags(tt.registry)
+			ran := false
+			m.runIfAllowed(Update{"id": "note-1"}, &core.Config{CanvasID: tt.canvasID}, tt.feature, "", func() { ran = true })
+			if ran != tt.want {
+				t.Errorf("ran = %v, want %v", ran, tt.want)
+			}
+		})
+	}
+}
+
+// errorQueue is a taskQueue whose Enqueue fails with err.
+type errorQueue struct {
+	err error
+}
+
+func (q errorQueue) Enqueue(task aiTask) error {
+	return q.err
+}
+
+// TestQueueTaskRunsOnlyUnsentTasks tests that a task the cluster's queue
+// may have received is not also run here.
+func TestQueueTaskRunsOnlyUnsentTasks(t *testing.T) {
+	m := &Monitor{logger: createTestLogger(t)}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"queued", nil, false},
+		{"queue server unreachable", fmt.Errorf("%w: dial tcp: connection refused", cluster.ErrNotSent), true},
+		{"timed out after sending", fmt.Errorf("cluster: LPUSH: %w", context.DeadlineExceeded), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ran := false
+			local := localTaskQueue{run: func(aiTask) { ran = true }}
+			m.queueTask(errorQueue{tt.err}, local, aiTask{Feature: canvassettings.FeatureNotes, Update: Update{"id": "note-1"}})
+			if ran != tt.want {
+				t.Errorf("ran here = %v, want %v", ran, tt.want)
+			}
+		})
+	}
+}
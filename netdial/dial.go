@@ -0,0 +1,363 @@
+// Package netdial connects to servers on dual-stack networks. It resolves
+// the host once, orders the addresses by the configured address family,
+// and races them Happy Eyeballs style (RFC 8305), so one unusable address
+// (e.g. an IPv6 link-local address the server does not listen on) costs a
+// short delay instead of a failed connection. When every address fails,
+// the error lists each address tried. It uses only the standard library so
+// every package that connects to the Canvus server can use it.
+package netdial
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Family selects which address families are tried, and in which order.
+type Family string
+
+const (
+	// FamilyAuto tries addresses in resolver order, alternating families
+	FamilyAuto Family = "auto"
+	// FamilyPreferIPv4 tries IPv4 addresses first
+	FamilyPreferIPv4 Family = "prefer-ipv4"
+	// FamilyPreferIPv6 tries IPv6 addresses first
+	FamilyPreferIPv6 Family = "prefer-ipv6"
+	// FamilyIPv4 tries only IPv4 addresses
+	FamilyIPv4 Family = "ipv4"
+	// FamilyIPv6 tries only IPv6 addresses
+	FamilyIPv6 Family = "ipv6"
+)
+
+// DefaultFallbackDelay is how long an attempt runs before the next address
+// is tried alongside it, as recommended by RFC 8305.
+const DefaultFallbackDelay = 250 * time.Millisecond
+
+// ParseFamily parses an address family name; empty means FamilyAuto.
+func ParseFamily(s string) (Family, error) {
+	switch f := Family(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return FamilyAuto, nil
+	case FamilyAuto, FamilyPreferIPv4, FamilyPreferIPv6, FamilyIPv4, FamilyIPv6:
+		return f, nil
+	}
+	return "", fmt.Errorf("netdial: unknown address family %q (use auto, prefer-ipv4, prefer-ipv6, ipv4 or ipv6)", s)
+}
+
+// Dialer connects to the addresses of a host in Family order.
+type Dialer struct {
+	// Family selects the address families to try
+	Family Family
+	// FallbackDelay is how long an attempt runs before the next address is
+	// tried alongside it; zero uses DefaultFallbackDelay
+	FallbackDelay time.Duration
+	// Timeout bounds each connection attempt; zero means no limit
+	Timeout time.Duration
+	// KeepAlive is the keep-alive period of connections
+	KeepAlive time.Duration
+
+	// lookup resolves host names; nil uses net.DefaultResolver
+	lookup func(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Attempt is one address tried by the dialer.
+type Attempt struct {
+	// Addr is the address with port, e.g. "[fe80::1]:443"
+	Addr string
+	// Err is why the connection failed, or nil if it was not tried
+	Err error
+}
+
+// Error reports a host none of whose addresses could be connected to.
+type Error struct {
+	// Address is the host and port that was dialed
+	Address string
+	// Family is the address family setting used
+	Family Family
+	// Attempts lists the addresses in the order they were tried
+	Attempts []Attempt
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "dial %s: no connection to any of %d addresses:", e.Address, len(e.Attempts))
+	for i, a := range e.Attempts {
+		if i > 0 {
+			sb.WriteString(";")
+		}
+		reason := "not tried"
+		if a.Err != nil {
+			reason = attemptReason(a.Err)
+		}
+		fmt.Fprintf(&sb, " %s (%s)", a.Addr, reason)
+	}
+	return sb.String()
+}
+
+// Unwrap returns the errors of the attempts, so errors.Is and errors.As
+// see e.g. syscall.ECONNREFUSED or context.DeadlineExceeded.
+func (e *Error) Unwrap() []error {
+	var errs []error
+	for _, a := range e.Attempts {
+		if a.Err != nil {
+			errs = append(errs, a.Err)
+		}
+	}
+	return errs
+}
+
+// Timeout reports whether every attempt timed out.
+func (e *Error) Timeout() bool {
+	for _, a := range e.Attempts {
+		var netErr net.Error
+		if a.Err == nil || !errors.As(a.Err, &netErr) || !netErr.Timeout() {
+			return false
+		}
+	}
+	return len(e.Attempts) > 0
+}
+
+// Temporary is part of net.Error; dial failures are not temporary.
+func (e *Error) Temporary() bool { return false }
+
+// Addresses returns the addresses tried, for logging.
+func (e *Error) Addresses() []string {
+	addrs := make([]string, len(e.Attempts))
+	for i, a := range e.Attempts {
+		addrs[i] = a.Addr
+	}
+	return addrs
+}
+
+// attemptReason returns the cause of a failed attempt without the address
+// net.OpError repeats.
+func attemptReason(err error) string {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Err != nil {
+		return opErr.Err.Error()
+	}
+	return err.Error()
+}
+
+// DialContext connects to address on tcp networks, racing the resolved
+// addresses; other networks are dialed directly.
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	base := &net.Dialer{Timeout: d.Timeout, KeepAlive: d.KeepAlive}
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return base.DialContext(ctx, network, address)
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := d.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	family := d.Family
+	switch network {
+	case "tcp4":
+		family = FamilyIPv4
+	case "tcp6":
+		family = FamilyIPv6
+	}
+	ordered := OrderAddrs(ips, family)
+	if len(ordered) == 0 {
+		return nil, fmt.Errorf("dial %s: no %s address among %s (see CANVUS_IP_FAMILY)",
+			address, family, formatIPs(ips))
+	}
+
+	addrs := make([]string, len(ordered))
+	for i, ip := range ordered {
+		addrs[i] = net.JoinHostPort(ip.String(), port)
+	}
+	if len(addrs) == 1 {
+		conn, err := base.DialContext(ctx, "tcp", addrs[0])
+		if err != nil && host != ordered[0].String() {
+			return nil, &Error{Address: address, Family: family, Attempts: []Attempt{{Addr: addrs[0], Err: err}}}
+		}
+		return conn, err
+	}
+	return d.race(ctx, base, address, family, addrs)
+}
+
+// race dials addrs, starting the next attempt when the previous one fails
+// or has run for FallbackDelay. The first connection wins.
+func (d *Dialer) race(ctx context.Context, base *net.Dialer, address string, family Family, addrs []string) (net.Conn, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	delay := d.FallbackDelay
+	if delay <= 0 {
+		delay = DefaultFallbackDelay
+	}
+
+	type result struct {
+		index int
+		conn  net.Conn
+		err   error
+	}
+	results := make(chan result, len(addrs))
+	attempts := make([]Attempt, len(addrs))
+	for i, addr := range addrs {
+		attempts[i].Addr = addr
+	}
+
+	next, pending := 0, 0
+	var fallback <-chan time.Time
+	start := func() {
+		i := next
+		next++
+		pending++
+		go func() {
+			conn, err := base.DialContext(ctx, "tcp", addrs[i])
+			results <- result{index: i, conn: conn, err: err}
+		}()
+		fallback = nil
+		if next < len(addrs) {
+			fallback = time.After(delay)
+		}
+	}
+
+	start()
+	for pending > 0 {
+		select {
+		case r := <-results:
+			pending--
+			if r.err == nil {
+				cancel()
+				// Close the connections of attempts that still finish
+				go func(pending int) {
+					for ; pending > 0; pending-- {
+						if late := <-results; late.conn != nil {
+							late.conn.Close()
+						}
+					}
+				}(pending)
+				return r.conn, nil
+			}
+			attempts[r.index].Err = r.err
+			if next < len(addrs) && ctx.Err() == nil {
+				start()
+			}
+		case <-fallback:
+			start()
+		}
+	}
+
+	// Addresses not started before the context ended stay listed as not tried
+	return nil, &Error{Address: address, Family: family, Attempts: attempts}
+}
+
+// resolve returns the addresses of host; an IP literal is returned as is.
+func (d *Dialer) resolve(ctx context.Context, host string) ([]net.IPAddr, error) {
+	ipHost, zone, _ := strings.Cut(host, "%")
+	if ip := net.ParseIP(ipHost); ip != nil {
+		return []net.IPAddr{{IP: ip, Zone: zone}}, nil
+	}
+	lookup := d.lookup
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupIPAddr
+	}
+	return lookup(ctx, host)
+}
+
+// OrderAddrs filters ips by family and orders them for dialing: the
+// preferred family first, then alternating families (RFC 8305). IPv6
+// link-local addresses without a zone cannot be dialed and are moved to
+// the end.
+func OrderAddrs(ips []net.IPAddr, family Family) []net.IPAddr {
+	var v4, v6, unusable []net.IPAddr
+	for _, ip := range ips {
+		switch {
+		case ip.IP.To4() != nil:
+			if family != FamilyIPv6 {
+				v4 = append(v4, ip)
+			}
+		case family == FamilyIPv4:
+		case ip.IP.IsLinkLocalUnicast() && ip.Zone == "":
+			unusable = append(unusable, ip)
+		default:
+			v6 = append(v6, ip)
+		}
+	}
+
+	first, second := v6, v4
+	switch family {
+	case FamilyPreferIPv4:
+		first, second = v4, v6
+	case FamilyAuto, "":
+		// Keep the family the resolver listed first
+		for _, ip := range ips {
+			if ip.IP.To4() != nil {
+				first, second = v4, v6
+				break
+			}
+			if !ip.IP.IsLinkLocalUnicast() || ip.Zone != "" {
+				break
+			}
+		}
+	}
+
+	ordered := make([]net.IPAddr, 0, len(ips))
+	for i := 0; i < len(first) || i < len(second); i++ {
+		if i < len(first) {
+			ordered = append(ordered, first[i])
+		}
+		if i < len(second) {
+			ordered = append(ordered, second[i])
+		}
+	}
+	return append(ordered, unusable...)
+}
+
+// formatIPs lists ips for an error message.
+func formatIPs(ips []net.IPAddr) string {
+	if len(ips) == 0 {
+		return "no addresses"
+	}
+	parts := make([]string, len(ips))
+	for i, ip := range ips {
+		parts[i] = ip.String()
+	}
+	return strings.Join(parts, ", ")
+}
+
+var (
+	envOnce   sync.Once
+	envDialer *Dialer
+)
+
+// FromEnvironment returns the dialer configured by CANVUS_IP_FAMILY and
+// CANVUS_HAPPY_EYEBALLS_DELAY (milliseconds). It reads the environment on
+// first use. Invalid values fall back to the defaults here; core.LoadConfig
+// rejects them at startup.
+func FromEnvironment() *Dialer {
+	envOnce.Do(func() {
+		family, err := ParseFamily(os.Getenv("CANVUS_IP_FAMILY"))
+		if err != nil {
+			family = FamilyAuto
+		}
+		delay := DefaultFallbackDelay
+		if ms, err := strconv.Atoi(os.Getenv("CANVUS_HAPPY_EYEBALLS_DELAY")); err == nil && ms > 0 {
+			delay = time.Duration(ms) * time.Millisecond
+		}
+		envDialer = &Dialer{
+			Family:        family,
+			FallbackDelay: delay,
+			Timeout:       30 * time.Second,
+			KeepAlive:     30 * time.Second,
+		}
+	})
+	return envDialer
+}
@@ -0,0 +1,150 @@
+package netdial
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func ips(addrs ...string) []net.IPAddr {
+	out := make([]net.IPAddr, len(addrs))
+	for i, a := range addrs {
+		ip, zone, _ := strings.Cut(a, "%")
+		out[i] = net.IPAddr{IP: net.ParseIP(ip), Zone: zone}
+	}
+	return out
+}
+
+func TestParseFamily(t *testing.T) {
+	for in, want := range map[string]Family{"": FamilyAuto, "IPv4": FamilyIPv4, " prefer-ipv6 ": FamilyPreferIPv6} {
+		if got, err := ParseFamily(in); err != nil || got != want {
+			t.Errorf("ParseFamily(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseFamily("ipv5"); err == nil {
+		t.Error("ParseFamily(ipv5) should fail")
+	}
+}
+
+func TestOrderAddrs(t *testing.T) {
+	resolved := ips("fe80::1", "2001:db8::1", "2001:db8::2", "10.0.0.1", "10.0.0.2")
+	tests := []struct {
+		family Family
+		want   string
+	}{
+		{FamilyAuto, "2001:db8::1 10.0.0.1 2001:db8::2 10.0.0.2 fe80::1"},
+		{FamilyPreferIPv4, "10.0.0.1 2001:db8::1 10.0.0.2 2001:db8::2 fe80::1"},
+		{FamilyPreferIPv6, "2001:db8::1 10.0.0.1 2001:db8::2 10.0.0.2 fe80::1"},
+		{FamilyIPv4, "10.0.0.1 10.0.0.2"},
+		{FamilyIPv6, "2001:db8::1 2001:db8::2 fe80::1"},
+	}
+	for _, tt := range tests {
+		if got := formatOrder(OrderAddrs(resolved, tt.family)); got != tt.want {
+			t.Errorf("OrderAddrs(%s) = %q, want %q", tt.family, got, tt.want)
+		}
+	}
+
+	// A zoned link-local address is usable and keeps its place
+	if got := formatOrder(OrderAddrs(ips("fe80::1%eth0", "10.0.0.1"), FamilyAuto)); got != "fe80::1%eth0 10.0.0.1" {
+		t.Errorf("OrderAddrs(zoned) = %q", got)
+	}
+	// Auto keeps IPv4 first when the resolver lists it first
+	if got := formatOrder(OrderAddrs(ips("10.0.0.1", "2001:db8::1"), FamilyAuto)); got != "10.0.0.1 2001:db8::1" {
+		t.Errorf("OrderAddrs(ipv4 first) = %q", got)
+	}
+}
+
+func formatOrder(addrs []net.IPAddr) string {
+	parts := make([]string, len(addrs))
+	for i, a := range addrs {
+		parts[i] = a.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// closedPort returns a loopback port nothing listens on.
+func closedPort(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	l.Close()
+	return port
+}
+
+func TestDialFallsBackToWorkingAddress(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+
+	// The unzoned link-local address cannot connect; 127.0.0.1 answers
+	d := &Dialer{
+		FallbackDelay: 50 * time.Millisecond,
+		Timeout:       2 * time.Second,
+		lookup: func(ctx context.Context, host string) ([]net.IPAddr, error) {
+			return ips("fe80::1", "127.0.0.1"), nil
+		},
+	}
+	conn, err := d.DialContext(context.Background(), "tcp", net.JoinHostPort("canvus.test", port))
+	if err != nil {
+		t.Fatalf("DialContext() error = %v", err)
+	}
+	conn.Close()
+}
+
+func TestDialListsAllAddresses(t *testing.T) {
+	port := closedPort(t)
+	d := &Dialer{
+		FallbackDelay: 50 * time.Millisecond,
+		Timeout:       2 * time.Second,
+		lookup: func(ctx context.Context, host string) ([]net.IPAddr, error) {
+			return ips("127.0.0.1", "127.0.0.2"), nil
+		},
+	}
+	_, err := d.DialContext(context.Background(), "tcp", net.JoinHostPort("canvus.test", port))
+
+	var dialErr *Error
+	if !errors.As(err, &dialErr) {
+		t.Fatalf("DialContext() error = %v, want *Error", err)
+	}
+	if got := strings.Join(dialErr.Addresses(), " "); got != "127.0.0.1:"+port+" 127.0.0.2:"+port {
+		t.Errorf("Addresses() = %q", got)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Errorf("error should wrap ECONNREFUSED: %v", err)
+	}
+	if !strings.Contains(err.Error(), "127.0.0.2:"+port+" (connect: connection refused)") {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestDialNoAddressOfFamily(t *testing.T) {
+	d := &Dialer{
+		Family: FamilyIPv6,
+		lookup: func(ctx context.Context, host string) ([]net.IPAddr, error) {
+			return ips("10.0.0.1"), nil
+		},
+	}
+	_, err := d.DialContext(context.Background(), "tcp", "canvus.test:443")
+	if err == nil || !strings.Contains(err.Error(), "no ipv6 address among 10.0.0.1") {
+		t.Errorf("DialContext() error = %v", err)
+	}
+}
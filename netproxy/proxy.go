@@ -0,0 +1,171 @@
+// Package netproxy chooses the proxy for outbound requests, for installs
+// behind mandatory corporate proxies. Requests use HTTP_PROXY, HTTPS_PROXY
+// and NO_PROXY as usual, and PROXY_OVERRIDES routes single destinations
+// through another proxy or directly. It uses only the standard library so
+// every package that makes requests can use it.
+package netproxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Direct is the override value that connects without a proxy.
+const Direct = "direct"
+
+// Override routes the requests to one destination.
+type Override struct {
+	// Host is the destination host name. A leading "*." (or ".") matches
+	// the domain and all its subdomains; "*" matches every host
+	Host string
+	// Proxy is the proxy URL, or nil to connect directly
+	Proxy *url.URL
+}
+
+// Matches reports whether the override applies to host (without port).
+func (o Override) Matches(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	pattern := strings.ToLower(o.Host)
+	switch {
+	case pattern == "*":
+		return true
+	case strings.HasPrefix(pattern, "*."):
+		pattern = pattern[1:]
+		fallthrough
+	case strings.HasPrefix(pattern, "."):
+		return host == pattern[1:] || strings.HasSuffix(host, pattern)
+	default:
+		return host == pattern
+	}
+}
+
+// ParseOverrides parses a comma-separated list of host=proxy entries, e.g.
+// "canvus.corp.local=direct,*.openai.azure.com=http://egress:3128". The
+// proxy is a http, https or socks5 URL, or "direct".
+func ParseOverrides(list string) ([]Override, error) {
+	var overrides []Override
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		host, proxy, ok := strings.Cut(entry, "=")
+		host, proxy = strings.TrimSpace(host), strings.TrimSpace(proxy)
+		if !ok || host == "" || proxy == "" {
+			return nil, fmt.Errorf("netproxy: invalid override %q (use host=proxy-url or host=direct)", entry)
+		}
+
+		override := Override{Host: host}
+		if !strings.EqualFold(proxy, Direct) {
+			u, err := url.Parse(proxy)
+			if err != nil || u.Host == "" {
+				return nil, fmt.Errorf("netproxy: invalid proxy URL for %s: %q", host, proxy)
+			}
+			switch u.Scheme {
+			case "http", "https", "socks5":
+			default:
+				return nil, fmt.Errorf("netproxy: unsupported proxy scheme %q for %s (use http, https or socks5)", u.Scheme, host)
+			}
+			override.Proxy = u
+		}
+		overrides = append(overrides, override)
+	}
+	return overrides, nil
+}
+
+// Func returns a proxy function for http.Transport. The first override
+// matching the request host decides; other requests use the environment
+// like http.ProxyFromEnvironment.
+func Func(overrides []Override) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		host := req.URL.Hostname()
+		for _, o := range overrides {
+			if o.Matches(host) {
+				return o.Proxy, nil
+			}
+		}
+		return http.ProxyFromEnvironment(req)
+	}
+}
+
+var (
+	envOnce  sync.Once
+	envProxy func(*http.Request) (*url.URL, error)
+)
+
+// FromEnvironment is a proxy function for http.Transport that applies
+// PROXY_OVERRIDES, then HTTP_PROXY, HTTPS_PROXY and NO_PROXY. Like
+// http.ProxyFromEnvironment, it reads the environment on first use.
+// Invalid overrides are ignored here; core.LoadConfig rejects them at
+// startup.
+func FromEnvironment(req *http.Request) (*url.URL, error) {
+	envOnce.Do(func() {
+		overrides, _ := ParseOverrides(os.Getenv("PROXY_OVERRIDES"))
+		envProxy = Func(overrides)
+	})
+	return envProxy(req)
+}
+
+// InstallDefault makes http.DefaultTransport, and so every client without
+// its own transport, use FromEnvironment. Call it once at startup, after
+// the environment is loaded.
+func InstallDefault() {
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		t.Proxy = FromEnvironment
+	}
+}
+
+// NewTransport returns a clone of http.DefaultTransport that uses
+// FromEnvironment, for clients that need their own TLS settings.
+func NewTransport() *http.Transport {
+	var t *http.Transport
+	if base, ok := http.DefaultTransport.(*http.Transport); ok {
+		t = base.Clone()
+	} else {
+		t = &http.Transport{}
+	}
+	t.Proxy = FromEnvironment
+	return t
+}
+
+// Summary describes the proxy settings of the environment for the startup
+// log, with proxy credentials hidden. It is empty if no proxy is set.
+func Summary() string {
+	var parts []string
+	for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY", "NO_PROXY"} {
+		value := os.Getenv(name)
+		if value == "" {
+			value = os.Getenv(strings.ToLower(name))
+		}
+		if value == "" {
+			continue
+		}
+		if name != "NO_PROXY" {
+			value = redact(value)
+		}
+		parts = append(parts, name+"="+value)
+	}
+	if overrides, err := ParseOverrides(os.Getenv("PROXY_OVERRIDES")); err == nil {
+		for _, o := range overrides {
+			target := Direct
+			if o.Proxy != nil {
+				target = redact(o.Proxy.String())
+			}
+			parts = append(parts, o.Host+"->"+target)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// redact hides the credentials of a proxy URL.
+func redact(proxy string) string {
+	u, err := url.Parse(proxy)
+	if err != nil || u.User == nil {
+		return proxy
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,118 @@
+// Package notefix provides the {{fix}} trigger and its undo. This file
+// contains the History of fixes and its Storage.
+package notefix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// ErrNothingToUndo is returned by Undo when the note has no fix to undo.
+var ErrNothingToUndo = errors.New("notefix: nothing to undo")
+
+// ErrEditedSince is returned by Undo when the note was changed after its
+// last fix; putting the old text back would lose the change.
+var ErrEditedSince = errors.New("notefix: the note was edited after it was fixed")
+
+// Fix is one correction of a note.
+type Fix struct {
+	ID       int64  `json:"id"`
+	CanvasID string `json:"canvas_id"`
+	NoteID   string `json:"note_id"`
+	// Original is the note text before the fix, without the trigger
+	Original string `json:"original"`
+	// Fixed is the text written to the note
+	Fixed     string    `json:"fixed"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Storage persists fixes. Implemented by db.Repository.
+type Storage interface {
+	// AddNoteFix stores f and removes the oldest fixes of its note beyond
+	// keep. It returns the ID of f.
+	AddNoteFix(ctx context.Context, f Fix, keep int) (int64, error)
+	// LatestNoteFix returns the newest fix of a note, or nil if it has none.
+	LatestNoteFix(ctx context.Context, canvasID, noteID string) (*Fix, error)
+	// DeleteNoteFix removes a fix.
+	DeleteNoteFix(ctx context.Context, id int64) error
+}
+
+// History keeps the latest fixes of each note so they can be undone, most
+// recent first.
+//
+// Thread-Safety: History is safe for concurrent use if its Storage is.
+type History struct {
+	storage Storage
+	keep    int
+	clock   clock.Clock
+}
+
+// NewHistory creates a History that keeps the keep latest fixes of each
+// note. It returns nil if keep is not positive, which records nothing.
+func NewHistory(storage Storage, keep int) *History {
+	if storage == nil || keep <= 0 {
+		return nil
+	}
+	return &History{storage: storage, keep: keep, clock: clock.Real()}
+}
+
+// SetClock sets the clock that stamps recorded corrections (default:
+// clock.Real). Call it before the history is used.
+func (h *History) SetClock(c clock.Clock) {
+	h.clock = clock.OrReal(c)
+}
+
+// Keep returns the number of fixes kept per note.
+func (h *History) Keep() int {
+	if h == nil {
+		return 0
+	}
+	return h.keep
+}
+
+// Record stores a fix of a note. A nil History records nothing.
+func (h *History) Record(ctx context.Context, canvasID, noteID, original, fixed string) error {
+	if h == nil {
+		return nil
+	}
+	_, err := h.storage.AddNoteFix(ctx, Fix{
+		CanvasID:  canvasID,
+		NoteID:    noteID,
+		Original:  original,
+		Fixed:     fixed,
+		CreatedAt: h.clock.Now(),
+	}, h.keep)
+	if err != nil {
+		return fmt.Errorf("notefix: failed to record fix of note %s: %w", noteID, err)
+	}
+	return nil
+}
+
+// Undo removes the latest fix of a note and returns it, so its Original
+// can be written back. current is the note text without the trigger; if
+// it is not the text the fix wrote, ErrEditedSince is returned and the fix
+// is kept. Undoing again steps back through earlier fixes.
+func (h *History) Undo(ctx context.Context, canvasID, noteID, current string) (*Fix, error) {
+	if h == nil {
+		return nil, errors.New("notefix: fixes cannot be undone (NOTE_FIX_UNDO_KEEP is 0)")
+	}
+	fix, err := h.storage.LatestNoteFix(ctx, canvasID, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("notefix: failed to read fixes of note %s: %w", noteID, err)
+	}
+	if fix == nil {
+		return nil, ErrNothingToUndo
+	}
+	if strings.TrimSpace(current) != strings.TrimSpace(fix.Fixed) {
+		return nil, ErrEditedSince
+	}
+	if err := h.storage.DeleteNoteFix(ctx, fix.ID); err != nil {
+		return nil, fmt.Errorf("notefix: failed to remove fix of note %s: %w", noteID, err)
+	}
+	return fix, nil
+}
@@ -0,0 +1,125 @@
+package notefix
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// memStorage keeps fixes in memory
+type memStorage struct {
+	fixes  []Fix
+	nextID int64
+}
+
+func (m *memStorage) AddNoteFix(ctx context.Context, f Fix, keep int) (int64, error) {
+	m.nextID++
+	f.ID = m.nextID
+	m.fixes = append(m.fixes, f)
+	return f.ID, nil
+}
+
+func (m *memStorage) LatestNoteFix(ctx context.Context, canvasID, noteID string) (*Fix, error) {
+	for i := len(m.fixes) - 1; i >= 0; i-- {
+		if f := m.fixes[i]; f.CanvasID == canvasID && f.NoteID == noteID {
+			return &f, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memStorage) DeleteNoteFix(ctx context.Context, id int64) error {
+	for i, f := range m.fixes {
+		if f.ID == id {
+			m.fixes = append(m.fixes[:i], m.fixes[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func TestParseTrigger(t *testing.T) {
+	tests := []struct {
+		in       string
+		undo, ok bool
+	}{
+		{"fix", false, true},
+		{" FIX ", false, true},
+		{"fix:", false, true},
+		{"fix: undo", true, true},
+		{"fix:UNDO", true, true},
+		{"fix: the intro", false, false},
+		{"fix the intro", false, false},
+		{"fixes", false, false},
+	}
+	for _, tt := range tests {
+		undo, ok := ParseTrigger(tt.in)
+		if undo != tt.undo || ok != tt.ok {
+			t.Errorf("ParseTrigger(%q) = %v, %v, want %v, %v", tt.in, undo, ok, tt.undo, tt.ok)
+		}
+	}
+}
+
+func TestCleanAndCheck(t *testing.T) {
+	for in, want := range map[string]string{
+		"  The fox jumps.\n":               "The fox jumps.",
+		"<text>\nThe fox jumps.\n</text>":  "The fox jumps.",
+		"```text\nLine one\nLine two\n```": "Line one\nLine two",
+	} {
+		if got := Clean(in); got != want {
+			t.Errorf("Clean(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	original := "Teh quick brwon fox jumpd over the lazy dog"
+	if err := Check(original, "The quick brown fox jumped over the lazy dog."); err != nil {
+		t.Errorf("Check(correction) = %v", err)
+	}
+	if err := Check(original, strings.Repeat("A poem about a fox. ", 10)); !errors.Is(err, ErrImplausible) {
+		t.Errorf("Check(answer) = %v, want ErrImplausible", err)
+	}
+	if err := Check(original, " "); !errors.Is(err, ErrImplausible) {
+		t.Errorf("Check(empty) = %v, want ErrImplausible", err)
+	}
+	if err := Check("teh", "the"); err != nil {
+		t.Errorf("Check(short) = %v", err)
+	}
+}
+
+func TestHistoryUndo(t *testing.T) {
+	if NewHistory(&memStorage{}, 0) != nil {
+		t.Error("NewHistory(keep 0) should be nil")
+	}
+	var disabled *History
+	if err := disabled.Record(context.Background(), "c", "n", "a", "b"); err != nil {
+		t.Errorf("nil Record() = %v", err)
+	}
+	if _, err := disabled.Undo(context.Background(), "c", "n", "b"); err == nil {
+		t.Error("nil Undo() should fail")
+	}
+
+	ctx := context.Background()
+	h := NewHistory(&memStorage{}, 5)
+	if err := h.Record(ctx, "c", "n", "teh fox", "the fox"); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Record(ctx, "c", "n", "the fox", "The fox."); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := h.Undo(ctx, "c", "n", "The fox. Edited"); !errors.Is(err, ErrEditedSince) {
+		t.Errorf("Undo(edited) = %v, want ErrEditedSince", err)
+	}
+	fix, err := h.Undo(ctx, "c", "n", " The fox.\n")
+	if err != nil || fix.Original != "the fox" {
+		t.Fatalf("Undo() = %+v, %v", fix, err)
+	}
+	fix, err = h.Undo(ctx, "c", "n", "the fox")
+	if err != nil || fix.Original != "teh fox" {
+		t.Fatalf("second Undo() = %+v, %v", fix, err)
+	}
+	if _, err := h.Undo(ctx, "c", "n", "teh fox"); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("Undo(no fixes) = %v, want ErrNothingToUndo", err)
+	}
+}
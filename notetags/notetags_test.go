@@ -0,0 +1,179 @@
+package notetags
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memStorage keeps tagged notes in memory
+type memStorage struct {
+	mu    sync.Mutex
+	notes map[string]TaggedNote
+}
+
+func (m *memStorage) SaveNoteTags(ctx context.Context, n TaggedNote) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.notes == nil {
+		m.notes = make(map[string]TaggedNote)
+	}
+	m.notes[n.CanvasID+"/"+n.NoteID] = n
+	return nil
+}
+
+func (m *memStorage) NoteTagHash(ctx context.Context, canvasID, noteID string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.notes[canvasID+"/"+noteID].ContentHash, nil
+}
+
+func (m *memStorage) ListNoteTags(ctx context.Context, canvasID string) ([]TagCount, error) {
+	return nil, nil
+}
+
+func (m *memStorage) FindTaggedNotes(ctx context.Context, canvasID, tag string, limit int) ([]TaggedNote, error) {
+	return nil, nil
+}
+
+// memCanvas records note updates
+type memCanvas struct {
+	updates []map[string]interface{}
+}
+
+func (c *memCanvas) UpdateNote(noteID string, payload map[string]interface{}) (map[string]interface{}, error) {
+	c.updates = append(c.updates, payload)
+	return payload, nil
+}
+
+func TestParseMode(t *testing.T) {
+	for in, want := range map[string]Mode{"": ModeOff, "off": ModeOff, " Store ": ModeStore, "append": ModeAppend, "COLOR": ModeColor} {
+		if got, err := ParseMode(in); err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if got, err := ParseMode("colour"); err == nil || got != ModeOff {
+		t.Errorf("ParseMode(colour) = %q, %v, want ModeOff and an error", got, err)
+	}
+}
+
+func TestTags(t *testing.T) {
+	if got := Hashtags("Plan #Budget and #hiring-plan, not a#b or #1 #budget"); !reflect.DeepEqual(got, []string{"budget", "hiring-plan"}) {
+		t.Errorf("Hashtags() = %v", got)
+	}
+	for reply, want := range map[string][]string{
+		`["Budget", "hiring plan"]`:           {"budget", "hiring-plan"},
+		"```json\n[\"roadmap\"]\n```":         {"roadmap"},
+		"Tags: budget, Q3 goals":              {"tags-budget", "q3-goals"},
+		"- budget\n- hiring\n":                {"budget", "hiring"},
+		`["` + strings.Repeat("a", 40) + `"]`: {strings.Repeat("a", maxTagLength)},
+	} {
+		if got := ParseTags(reply); !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseTags(%q) = %v, want %v", reply, got, want)
+		}
+	}
+	if got := Merge([]string{"budget"}, []string{"Budget", "roadmap", "hiring"}, 2); !reflect.DeepEqual(got, []string{"budget", "roadmap"}) {
+		t.Errorf("Merge() = %v", got)
+	}
+
+	text := WithTagLine("Plan the budget", []string{"budget", "roadmap"})
+	if !strings.HasSuffix(text, "\n\n🏷️ #budget #roadmap") || StripTagLine(text) != "Plan the budget" {
+		t.Errorf("WithTagLine() = %q, stripped %q", text, StripTagLine(text))
+	}
+	if Hash(StripTagLine(text)) != Hash("Plan the budget\n") {
+		t.Error("Hash() changed by the tag line or trailing space")
+	}
+	if Color("budget") != Color("budget") {
+		t.Error("Color() is not stable")
+	}
+}
+
+func TestTaggerTag(t *testing.T) {
+	storage := &memStorage{}
+	calls := 0
+	generate := func(ctx context.Context, canvasID, systemPrompt, prompt string) (string, string, error) {
+		calls++
+		if !strings.Contains(systemPrompt, "at most 2 ") || !strings.Contains(prompt, "<text>") {
+			t.Errorf("unexpected prompts %q, %q", systemPrompt, prompt)
+		}
+		return `["budget", "roadmap", "hiring"]`, "local", nil
+	}
+	tagger := NewTagger(Config{Mode: ModeAppend, MaxTags: 2, MinLength: 10}, storage, generate, nil)
+	canvas := &memCanvas{}
+	note := Note{CanvasID: "c1", ID: "n1", Text: "We need a #plan for next year's budget", Canvas: canvas}
+
+	if err := tagger.Tag(context.Background(), note); err != nil {
+		t.Fatal(err)
+	}
+	saved := storage.notes["c1/n1"]
+	if !reflect.DeepEqual(saved.Tags, []string{"plan", "budget"}) || saved.Model != "local" {
+		t.Errorf("saved %+v", saved)
+	}
+	if len(canvas.updates) != 1 || !strings.HasSuffix(canvas.updates[0]["text"].(string), "🏷️ #plan #budget") {
+		t.Fatalf("updates = %v", canvas.updates)
+	}
+
+	// The note with its tag line appended is not tagged again
+	note.Text = canvas.updates[0]["text"].(string)
+	if err := tagger.Tag(context.Background(), note); err != nil || calls != 1 {
+		t.Errorf("retag: err %v, %d calls", err, calls)
+	}
+	// Neither are short notes
+	if err := tagger.Tag(context.Background(), Note{CanvasID: "c1", ID: "n2", Text: "Hi"}); err != nil || calls != 1 {
+		t.Errorf("short note: err %v, %d calls", err, calls)
+	}
+
+	failing := NewTagger(Config{Mode: ModeColor}, storage, func(context.Context, string, string, string) (string, string, error) {
+		return "", "", errors.New("no model")
+	}, nil)
+	if err := failing.Tag(context.Background(), Note{CanvasID: "c1", ID: "n3", Text: "A long enough note about the roadmap"}); err == nil {
+		t.Error("Tag() with a failing model succeeded")
+	}
+
+	if NewTagger(Config{Mode: ModeOff}, storage, generate, nil) != nil {
+		t.Error("NewTagger(ModeOff) is not nil")
+	}
+	var off *Tagger
+	off.Observe(note)
+	off.Close()
+}
+
+func TestTaggerObserveDebounces(t *testing.T) {
+	storage := &memStorage{}
+	var mu sync.Mutex
+	var tagged []string
+	generate := func(ctx context.Context, canvasID, systemPrompt, prompt string) (string, string, error) {
+		mu.Lock()
+		tagged = append(tagged, prompt)
+		mu.Unlock()
+		return `["color"]`, "local", nil
+	}
+	tagger := NewTagger(Config{Mode: ModeColor, MaxTags: 1, Delay: 20 * time.Millisecond}, storage, generate, nil)
+	canvas := &memCanvas{}
+	tagger.Observe(Note{CanvasID: "c1", ID: "n1", Text: "first draft", Canvas: canvas})
+	tagger.Observe(Note{CanvasID: "c1", ID: "n1", Text: "final text", Canvas: canvas})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(tagged)
+		mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	tagger.Close()
+
+	if len(tagged) != 1 || !strings.Contains(tagged[0], "final text") {
+		t.Errorf("tagged %q, want only the final text", tagged)
+	}
+	if len(canvas.updates) != 1 || canvas.updates[0]["background_color"] != Color("color") {
+		t.Errorf("updates = %v", canvas.updates)
+	}
+	tagger.Observe(Note{CanvasID: "c1", ID: "n2", Text: "after close"})
+}
@@ -0,0 +1,253 @@
+// Package notetags provides automatic tagging of notes. This file contains
+// the Tagger organism, which waits for notes to settle, tags them one at a
+// time and writes the tags back.
+package notetags
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+
+	"go_backend/core/clock"
+)
+
+// excerptLength bounds the note text stored with its tags.
+const excerptLength = 200
+
+// TaggedNote is a note and its tags.
+type TaggedNote struct {
+	CanvasID string   `json:"canvas_id"`
+	NoteID   string   `json:"note_id"`
+	Tags     []string `json:"tags"`
+	// ContentHash fingerprints the note text that was tagged
+	ContentHash string `json:"-"`
+	// Excerpt is the start of the note text
+	Excerpt  string    `json:"excerpt"`
+	Model    string    `json:"model,omitempty"`
+	TaggedAt time.Time `json:"tagged_at"`
+}
+
+// TagCount is a tag and the number of notes that have it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Notes int    `json:"notes"`
+}
+
+// Storage persists note tags. Implemented by db.Repository.
+type Storage interface {
+	// SaveNoteTags replaces the tags of a note.
+	SaveNoteTags(ctx context.Context, n TaggedNote) error
+	// NoteTagHash returns the ContentHash of a tagged note, or "" if the
+	// note has not been tagged.
+	NoteTagHash(ctx context.Context, canvasID, noteID string) (string, error)
+	// ListNoteTags returns the tags of a canvas (all canvases if canvasID
+	// is empty), most used first.
+	ListNoteTags(ctx context.Context, canvasID string) ([]TagCount, error)
+	// FindTaggedNotes returns the notes with tag, most recently tagged first.
+	FindTaggedNotes(ctx context.Context, canvasID, tag string, limit int) ([]TaggedNote, error)
+}
+
+// Canvas updates notes. Implemented by canvusapi.Client.
+type Canvas interface {
+	UpdateNote(noteID string, payload map[string]interface{}) (map[string]interface{}, error)
+}
+
+// Generator asks a model for a reply to prompt, for the canvas canvasID.
+// It returns the reply and the model that wrote it.
+type Generator func(ctx context.Context, canvasID, systemPrompt, prompt string) (reply, model string, err error)
+
+// Note is a note seen on a canvas.
+type Note struct {
+	CanvasID string
+	ID       string
+	Text     string
+	// Canvas is where the tags are written in ModeAppend and ModeColor
+	Canvas Canvas
+}
+
+// Tagger tags notes in the background. Each observed note is tagged once
+// it has stayed unchanged for the configured delay; notes are tagged one
+// at a time so a canvas full of new notes does not flood the model.
+//
+// Thread-Safety: Tagger is safe for concurrent use.
+type Tagger struct {
+	cfg      Config
+	storage  Storage
+	generate Generator
+	logger   *zap.Logger
+	clock    clock.Clock
+
+	mu      sync.Mutex
+	pending map[string]*time.Timer // canvas ID + note ID -> timer
+	closed  bool
+
+	run sync.Mutex // held while a note is tagged
+	wg  sync.WaitGroup
+}
+
+// NewTagger creates a Tagger. It returns nil if cfg.Mode is ModeOff, and
+// a nil Tagger ignores every note.
+func NewTagger(cfg Config, storage Storage, generate Generator, logger *zap.Logger) *Tagger {
+	if cfg.Mode == ModeOff || cfg.Mode == "" || storage == nil || generate == nil {
+		return nil
+	}
+	if cfg.MaxTags < 1 {
+		cfg.MaxTags = DefaultMaxTags
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Tagger{
+		cfg:      cfg,
+		storage:  storage,
+		generate: generate,
+		logger:   logger,
+		clock:    clock.Real(),
+		pending:  make(map[string]*time.Timer),
+	}
+}
+
+// SetClock sets the clock that stamps saved tags (default: clock.Real).
+// Call it before the tagger is used.
+func (t *Tagger) SetClock(c clock.Clock) {
+	t.clock = clock.OrReal(c)
+}
+
+// Mode returns the tagging mode; ModeOff for a nil Tagger.
+func (t *Tagger) Mode() Mode {
+	if t == nil {
+		return ModeOff
+	}
+	return t.cfg.Mode
+}
+
+// Observe schedules n to be tagged after the delay, restarting the wait if
+// the note was already waiting.
+func (t *Tagger) Observe(n Note) {
+	if t == nil || n.ID == "" {
+		return
+	}
+	key := n.CanvasID + "/" + n.ID
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	if timer, ok := t.pending[key]; ok && timer.Stop() {
+		t.wg.Done() // The stopped timer will not run
+	}
+	t.wg.Add(1)
+	t.pending[key] = time.AfterFunc(t.cfg.Delay, func() {
+		defer t.wg.Done()
+		t.mu.Lock()
+		delete(t.pending, key)
+		closed := t.closed
+		t.mu.Unlock()
+		if closed {
+			return
+		}
+		if err := t.Tag(context.Background(), n); err != nil {
+			t.logger.Warn("Failed to tag note",
+				zap.String("canvas_id", n.CanvasID),
+				zap.String("note_id", n.ID),
+				zap.Error(err))
+		}
+	})
+}
+
+// Tag tags n now, unless it is too short or its text was tagged before.
+// The tags are stored and, depending on the mode, appended to the note or
+// shown as its color.
+func (t *Tagger) Tag(ctx context.Context, n Note) error {
+	if t == nil {
+		return nil
+	}
+	t.run.Lock()
+	defer t.run.Unlock()
+
+	content := StripTagLine(n.Text)
+	if utf8.RuneCountInString(strings.TrimSpace(content)) < t.cfg.MinLength {
+		return nil
+	}
+	hash := Hash(content)
+	tagged, err := t.storage.NoteTagHash(ctx, n.CanvasID, n.ID)
+	if err != nil {
+		return fmt.Errorf("notetags: failed to read tags of note %s: %w", n.ID, err)
+	}
+	if tagged == hash {
+		return nil // Unchanged since it was tagged, e.g. after the tag line was appended
+	}
+
+	reply, model, err := t.generate(ctx, n.CanvasID, fmt.Sprintf(SystemPrompt, t.cfg.MaxTags), "<text>\n"+content+"\n</text>")
+	if err != nil {
+		return fmt.Errorf("notetags: failed to generate tags: %w", err)
+	}
+	tags := Merge(Hashtags(content), ParseTags(reply), t.cfg.MaxTags)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	note := TaggedNote{
+		CanvasID:    n.CanvasID,
+		NoteID:      n.ID,
+		Tags:        tags,
+		ContentHash: hash,
+		Excerpt:     excerpt(content),
+		Model:       model,
+		TaggedAt:    t.clock.Now(),
+	}
+	if err := t.storage.SaveNoteTags(ctx, note); err != nil {
+		return fmt.Errorf("notetags: failed to save tags of note %s: %w", n.ID, err)
+	}
+
+	if n.Canvas != nil {
+		switch t.cfg.Mode {
+		case ModeAppend:
+			_, err = n.Canvas.UpdateNote(n.ID, map[string]interface{}{"text": WithTagLine(content, tags)})
+		case ModeColor:
+			_, err = n.Canvas.UpdateNote(n.ID, map[string]interface{}{"background_color": Color(tags[0])})
+		}
+		if err != nil {
+			return fmt.Errorf("notetags: failed to update note %s: %w", n.ID, err)
+		}
+	}
+	t.logger.Debug("Tagged note",
+		zap.String("canvas_id", n.CanvasID),
+		zap.String("note_id", n.ID),
+		zap.Strings("tags", tags),
+		zap.String("model", model))
+	return nil
+}
+
+// Close stops tagging: notes still waiting are dropped and Close waits for
+// a note being tagged.
+func (t *Tagger) Close() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	t.closed = true
+	for key, timer := range t.pending {
+		if timer.Stop() {
+			t.wg.Done()
+		}
+		delete(t.pending, key)
+	}
+	t.mu.Unlock()
+	t.wg.Wait()
+}
+
+// excerpt returns the start of text, on one line.
+func excerpt(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > excerptLength {
+		return string(runes[:excerptLength-1]) + "…"
+	}
+	return text
+}
@@ -19,6 +19,7 @@ import (
 	"net/http"
 	"time"
 
+	"go_backend/core/errs"
 	"go_backend/logging"
 
 	"go.uber.org/zap"
@@ -65,7 +66,7 @@ func DefaultVisionClientConfig() VisionClientConfig {
 // Common errors for OCR operations.
 var (
 	// ErrNoTextFound indicates the image contains no recognizable text.
-	ErrNoTextFound = errors.New("ocrprocessor: no text found in image")
+	ErrNoTextFound = errs.New(errs.CodeNoContent, "ocrprocessor: no text found in image")
 
 	// ErrEmptyResponse indicates the API returned an empty response.
 	ErrEmptyResponse = errors.New("ocrprocessor: empty response from Vision API")
@@ -152,6 +153,13 @@ type OCRResult struct {
 
 	// ProcessingTime is how long the API call took
 	ProcessingTime time.Duration
+
+	// Blocks are the detected text blocks with their image positions
+	Blocks []TextBlock
+
+	// ImageWidth and ImageHeight are the page dimensions reported by Vision
+	ImageWidth  int
+	ImageHeight int
 }
 
 // ExtractText performs OCR on the provided image data.
@@ -231,14 +239,26 @@ func (c *VisionClient) ExtractText(ctx context.Context, imageData []byte) (*OCRR
 		return nil, err
 	}
 
+	// Decode the block layout separately; a malformed layout only loses positions
+	var layout visionLayoutResponse
+	var blocks []TextBlock
+	var width, height int
+	if err := json.Unmarshal(bodyBytes, &layout); err == nil && len(layout.Responses) > 0 {
+		blocks, width, height = extractBlocks(layout.Responses[0].FullTextAnnotation.Pages)
+	}
+
 	processingTime := time.Since(startTime)
 	log.Info("OCR extraction completed",
 		zap.Int("text_length", len(text)),
+		zap.Int("blocks", len(blocks)),
 		zap.Duration("processing_time", processingTime))
 
 	return &OCRResult{
 		Text:           text,
 		ProcessingTime: processingTime,
+		Blocks:         blocks,
+		ImageWidth:     width,
+		ImageHeight:    height,
 	}, nil
 }
 
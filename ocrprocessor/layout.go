@@ -0,0 +1,191 @@
+// Package ocrprocessor provides OCR (Optical Character Recognition) functionality
+// for CanvusLocalLLM using Google Cloud Vision API.
+//
+// layout.go contains atoms that turn the page/block/paragraph/word/symbol
+// hierarchy of a DOCUMENT_TEXT_DETECTION response into positioned text blocks
+// and map them back onto canvas coordinates.
+package ocrprocessor
+
+import (
+	"strings"
+)
+
+// BoundingBox is an axis-aligned box in image pixel coordinates.
+type BoundingBox struct {
+	MinX int
+	MinY int
+	MaxX int
+	MaxY int
+}
+
+// Width returns the box width in pixels.
+func (b BoundingBox) Width() int { return b.MaxX - b.MinX }
+
+// Height returns the box height in pixels.
+func (b BoundingBox) Height() int { return b.MaxY - b.MinY }
+
+// TextBlock is a block of text detected by the Vision API with its position.
+type TextBlock struct {
+	// Text is the block text with line breaks preserved
+	Text string
+
+	// Bounds is the block bounding box in image pixels
+	Bounds BoundingBox
+
+	// Confidence is the block confidence score (0-1), 0 if not reported
+	Confidence float64
+}
+
+// CanvasRect is a rectangle in canvas coordinates.
+type CanvasRect struct {
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+// visionVertex is a bounding polygon vertex. Vision omits zero coordinates.
+type visionVertex struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// visionBoundingPoly is a bounding polygon from the Vision API.
+type visionBoundingPoly struct {
+	Vertices []visionVertex `json:"vertices"`
+}
+
+// visionLayoutResponse decodes only the page hierarchy of a Vision API response.
+type visionLayoutResponse struct {
+	Responses []struct {
+		FullTextAnnotation struct {
+			Pages []visionPage `json:"pages"`
+		} `json:"fullTextAnnotation"`
+	} `json:"responses"`
+}
+
+// visionPage is a page in the full text annotation.
+type visionPage struct {
+	Width  int           `json:"width"`
+	Height int           `json:"height"`
+	Blocks []visionBlock `json:"blocks"`
+}
+
+// visionBlock is a block of paragraphs.
+type visionBlock struct {
+	BoundingBox visionBoundingPoly `json:"boundingBox"`
+	Paragraphs  []visionParagraph  `json:"paragraphs"`
+	Confidence  float64            `json:"confidence"`
+	BlockType   string             `json:"blockType"`
+}
+
+// visionParagraph is a paragraph of words.
+type visionParagraph struct {
+	Words []visionWord `json:"words"`
+}
+
+// visionWord is a word made of symbols.
+type visionWord struct {
+	Symbols []visionSymbol `json:"symbols"`
+}
+
+// visionSymbol is a single character with optional break information.
+type visionSymbol struct {
+	Text     string `json:"text"`
+	Property struct {
+		DetectedBreak struct {
+			Type string `json:"type"`
+		} `json:"detectedBreak"`
+	} `json:"property"`
+}
+
+// boundsFromPoly converts a bounding polygon to an axis-aligned box.
+func boundsFromPoly(poly visionBoundingPoly) BoundingBox {
+	if len(poly.Vertices) == 0 {
+		return BoundingBox{}
+	}
+	b := BoundingBox{
+		MinX: poly.Vertices[0].X, MinY: poly.Vertices[0].Y,
+		MaxX: poly.Vertices[0].X, MaxY: poly.Vertices[0].Y,
+	}
+	for _, v := range poly.Vertices[1:] {
+		b.MinX = min(b.MinX, v.X)
+		b.MinY = min(b.MinY, v.Y)
+		b.MaxX = max(b.MaxX, v.X)
+		b.MaxY = max(b.MaxY, v.Y)
+	}
+	return b
+}
+
+// blockText reassembles a block's text from its symbols, honouring the
+// detected breaks Vision attaches to the last symbol of each word.
+func blockText(block visionBlock) string {
+	var paragraphs []string
+	for _, para := range block.Paragraphs {
+		var sb strings.Builder
+		for _, word := range para.Words {
+			for _, sym := range word.Symbols {
+				sb.WriteString(sym.Text)
+				switch sym.Property.DetectedBreak.Type {
+				case "SPACE", "SURE_SPACE":
+					sb.WriteString(" ")
+				case "EOL_SURE_SPACE", "LINE_BREAK":
+					sb.WriteString("\n")
+				case "HYPHEN":
+					sb.WriteString("-\n")
+				}
+			}
+		}
+		if text := strings.TrimSpace(sb.String()); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	return strings.Join(paragraphs, "\n")
+}
+
+// extractBlocks flattens all text blocks from the response pages.
+// Non-text blocks and blocks without text are skipped.
+// Returns the blocks and the dimensions of the first page.
+func extractBlocks(pages []visionPage) ([]TextBlock, int, int) {
+	if len(pages) == 0 {
+		return nil, 0, 0
+	}
+
+	var blocks []TextBlock
+	for _, page := range pages {
+		for _, b := range page.Blocks {
+			if b.BlockType != "" && b.BlockType != "TEXT" {
+				continue
+			}
+			text := blockText(b)
+			if text == "" {
+				continue
+			}
+			blocks = append(blocks, TextBlock{
+				Text:       text,
+				Bounds:     boundsFromPoly(b.BoundingBox),
+				Confidence: b.Confidence,
+			})
+		}
+	}
+	return blocks, pages[0].Width, pages[0].Height
+}
+
+// MapBlockToCanvas maps a block's pixel bounds into canvas coordinates,
+// given the image dimensions in pixels and the image's rectangle on the canvas.
+// Returns a zero rectangle if the image dimensions are unknown.
+//
+// This is a pure function (atom) with no external dependencies.
+func MapBlockToCanvas(block TextBlock, imageWidth, imageHeight int, target CanvasRect) CanvasRect {
+	if imageWidth <= 0 || imageHeight <= 0 {
+		return CanvasRect{}
+	}
+	sx := target.Width / float64(imageWidth)
+	sy := target.Height / float64(imageHeight)
+	return CanvasRect{
+		X:      target.X + float64(block.Bounds.MinX)*sx,
+		Y:      target.Y + float64(block.Bounds.MinY)*sy,
+		Width:  float64(block.Bounds.Width()) * sx,
+		Height: float64(block.Bounds.Height()) * sy,
+	}
+}
@@ -0,0 +1,341 @@
+// Package ocrprocessor provides OCR (Optical Character Recognition) functionality
+// for CanvusLocalLLM using Google Cloud Vision API.
+//
+// privacy.go contains the privacy scrubbing applied to user images before
+// they leave the machine: EXIF, XMP, IPTC and text metadata (GPS position,
+// camera model, timestamps) is removed, and large images can be scaled down.
+package ocrprocessor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+
+	_ "image/gif" // decode GIF input
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // decode WebP input
+)
+
+// scrubJPEGQuality is used when a JPEG has to be re-encoded because it is
+// rotated or scaled down.
+const scrubJPEGQuality = 92
+
+// ErrScrubUnsupported indicates metadata could not be removed because the
+// image format is not recognised.
+var ErrScrubUnsupported = errors.New("ocrprocessor: cannot scrub metadata from this image format")
+
+// PrivacyOptions controls what is removed from images before they are sent
+// to a cloud vision API. The zero value sends images unchanged.
+type PrivacyOptions struct {
+	// StripMetadata removes EXIF, XMP, IPTC and text metadata. JPEG and PNG
+	// are stripped without re-encoding; other formats are re-encoded as PNG.
+	StripMetadata bool
+
+	// MaxDimension scales images down so neither side exceeds it, keeping
+	// the aspect ratio. Zero means no limit.
+	MaxDimension int
+}
+
+// Enabled reports whether the options change images at all.
+func (o PrivacyOptions) Enabled() bool {
+	return o.StripMetadata || o.MaxDimension > 0
+}
+
+// ScrubResult describes what ScrubImage did.
+type ScrubResult struct {
+	Data []byte
+	// Stripped is true when metadata was removed.
+	Stripped bool
+	// Resized is true when the image was scaled down.
+	Resized bool
+}
+
+// ScrubImage applies opts to image data. JPEG images with an EXIF
+// orientation are rotated upright before the orientation tag is dropped so
+// the text stays readable. ErrScrubUnsupported is returned for formats that
+// cannot be decoded, such as TIFF or PDF.
+func ScrubImage(data []byte, opts PrivacyOptions) (*ScrubResult, error) {
+	if !opts.Enabled() {
+		return &ScrubResult{Data: data}, nil
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScrubUnsupported, err)
+	}
+
+	orientation := 1
+	if format == "jpeg" {
+		orientation = jpegOrientation(data)
+	}
+	width, height := cfg.Width, cfg.Height
+	if orientation >= 5 {
+		width, height = height, width
+	}
+	newWidth, newHeight := fitDimension(width, height, opts.MaxDimension)
+	resized := newWidth != width || newHeight != height
+
+	// Lossless paths: only metadata segments are dropped.
+	if !resized && (orientation == 1 || !opts.StripMetadata) {
+		switch {
+		case !opts.StripMetadata:
+			return &ScrubResult{Data: data}, nil
+		case format == "jpeg":
+			out, err := stripJPEG(data)
+			if err != nil {
+				return nil, err
+			}
+			return &ScrubResult{Data: out, Stripped: true}, nil
+		case format == "png":
+			out, err := stripPNG(data)
+			if err != nil {
+				return nil, err
+			}
+			return &ScrubResult{Data: out, Stripped: true}, nil
+		}
+	}
+
+	// Everything else is decoded and re-encoded, which carries no metadata.
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScrubUnsupported, err)
+	}
+	img = applyOrientation(img, orientation)
+	if resized {
+		scaled := image.NewNRGBA(image.Rect(0, 0, newWidth, newHeight))
+		draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Src, nil)
+		img = scaled
+	}
+
+	var buf bytes.Buffer
+	if format == "jpeg" {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: scrubJPEGQuality})
+	} else {
+		err = png.Encode(&buf, img)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ocrprocessor: failed to re-encode image: %w", err)
+	}
+	return &ScrubResult{Data: buf.Bytes(), Stripped: true, Resized: resized}, nil
+}
+
+// fitDimension scales width and height down so neither exceeds limit.
+func fitDimension(width, height, limit int) (int, int) {
+	if limit <= 0 || (width <= limit && height <= limit) {
+		return width, height
+	}
+	scale := float64(limit) / float64(max(width, height))
+	return max(1, int(float64(width)*scale+0.5)), max(1, int(float64(height)*scale+0.5))
+}
+
+// stripJPEG copies a JPEG without its APPn and COM segments. JFIF (APP0),
+// ICC colour profiles (APP2) and the Adobe colour transform (APP14) are kept
+// because they affect how the pixels decode.
+func stripJPEG(data []byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("%w: not a JPEG", ErrScrubUnsupported)
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+
+	i := 2
+	for i < len(data) {
+		if data[i] != 0xFF {
+			return nil, fmt.Errorf("%w: malformed JPEG marker", ErrScrubUnsupported)
+		}
+		// Skip fill bytes before the marker code.
+		for i < len(data) && data[i] == 0xFF {
+			i++
+		}
+		if i >= len(data) {
+			break
+		}
+		marker := data[i]
+		i++
+
+		// Markers without a length.
+		if marker == 0xD8 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out = append(out, 0xFF, marker)
+			continue
+		}
+		if marker == 0xD9 {
+			out = append(out, 0xFF, marker)
+			return out, nil
+		}
+
+		if i+2 > len(data) {
+			return nil, fmt.Errorf("%w: truncated JPEG", ErrScrubUnsupported)
+		}
+		length := int(binary.BigEndian.Uint16(data[i:]))
+		if length < 2 || i+length > len(data) {
+			return nil, fmt.Errorf("%w: truncated JPEG", ErrScrubUnsupported)
+		}
+		segment := data[i+2 : i+length]
+
+		// Start of scan: the entropy-coded data follows, copy the rest.
+		if marker == 0xDA {
+			out = append(out, 0xFF, marker)
+			return append(out, data[i:]...), nil
+		}
+		if keepJPEGSegment(marker, segment) {
+			out = append(out, 0xFF, marker)
+			out = append(out, data[i:i+length]...)
+		}
+		i += length
+	}
+	return out, nil
+}
+
+// keepJPEGSegment reports whether a segment before the scan data is kept.
+func keepJPEGSegment(marker byte, segment []byte) bool {
+	switch {
+	case marker == 0xE0: // JFIF
+		return true
+	case marker == 0xE2:
+		return bytes.HasPrefix(segment, []byte("ICC_PROFILE\x00"))
+	case marker == 0xEE:
+		return bytes.HasPrefix(segment, []byte("Adobe"))
+	case marker >= 0xE1 && marker <= 0xEF, marker == 0xFE: // APPn, COM
+		return false
+	}
+	return true
+}
+
+// pngKeepChunks are the ancillary chunks that affect rendering. All other
+// ancillary chunks (tEXt, zTXt, iTXt, eXIf, tIME and private chunks) are
+// dropped; critical chunks are always kept.
+var pngKeepChunks = map[string]bool{
+	"tRNS": true, "gAMA": true, "cHRM": true, "sRGB": true, "iCCP": true,
+	"sBIT": true, "bKGD": true, "pHYs": true, "hIST": true, "sPLT": true,
+	"acTL": true, "fcTL": true, "fdAT": true,
+}
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// stripPNG copies a PNG without its metadata chunks. Chunks are copied
+// whole, so their CRCs stay valid.
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, fmt.Errorf("%w: not a PNG", ErrScrubUnsupported)
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+
+	i := len(pngSignature)
+	for i+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		end := i + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, fmt.Errorf("%w: truncated PNG", ErrScrubUnsupported)
+		}
+		chunkType := string(data[i+4 : i+8])
+		critical := chunkType[0] >= 'A' && chunkType[0] <= 'Z'
+		if critical || pngKeepChunks[chunkType] {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+		if chunkType == "IEND" {
+			break
+		}
+	}
+	return out, nil
+}
+
+// jpegOrientation returns the EXIF orientation (1-8) of a JPEG, or 1 if it
+// has none.
+func jpegOrientation(data []byte) int {
+	i := 2
+	for i+4 <= len(data) && data[i] == 0xFF {
+		marker := data[i+1]
+		if marker == 0xDA || marker == 0xD9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			break
+		}
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		i += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation reads the orientation tag from IFD0 of a TIFF header.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation returns img transformed so an image with the given EXIF
+// orientation displays upright without the tag.
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	outW, outH := w, h
+	if orientation >= 5 {
+		outW, outH = h, w
+	}
+	out := image.NewNRGBA(image.Rect(0, 0, outW, outH))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored horizontally
+				dx, dy = w-1-x, y
+			case 3: // rotated 180
+				dx, dy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				dx, dy = x, h-1-y
+			case 5: // transposed
+				dx, dy = y, x
+			case 6: // rotated 90 clockwise
+				dx, dy = h-1-y, x
+			case 7: // transversed
+				dx, dy = h-1-y, w-1-x
+			case 8: // rotated 90 counter-clockwise
+				dx, dy = y, w-1-x
+			}
+			out.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return out
+}
@@ -0,0 +1,254 @@
+package ocrprocessor
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"testing"
+)
+
+// testImage returns a w x h image, red on the left half and blue on the right
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.Set(x, y, color.NRGBA{255, 0, 0, 255})
+			} else {
+				img.Set(x, y, color.NRGBA{0, 0, 255, 255})
+			}
+		}
+	}
+	return img
+}
+
+// exifSegment builds an APP1 segment with a GPS marker string and the given orientation
+func exifSegment(orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	entries := make([]byte, 2+12+4)
+	binary.BigEndian.PutUint16(entries[0:], 1)
+	binary.BigEndian.PutUint16(entries[2:], 0x0112)
+	binary.BigEndian.PutUint16(entries[4:], 3) // SHORT
+	binary.BigEndian.PutUint32(entries[6:], 1)
+	binary.BigEndian.PutUint16(entries[10:], orientation)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	payload = append(payload, entries...)
+	payload = append(payload, []byte("GPS 52.5200N 13.4050E Pixel 8")...)
+
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// jpegWithEXIF encodes img as JPEG and inserts an EXIF segment after SOI
+func jpegWithEXIF(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("jpeg.Encode: %v", err)
+	}
+	data := buf.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, exifSegment(orientation)...)
+	return append(out, data[2:]...)
+}
+
+// pngWithText encodes img as PNG and inserts a tEXt chunk after IHDR
+func pngWithText(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("png.Encode: %v", err)
+	}
+	data := buf.Bytes()
+	ihdrEnd := 8 + 12 + 13
+
+	body := []byte("tEXtComment\x00GPS 52.5200N 13.4050E")
+	chunk := make([]byte, 4, 4+len(body)+4)
+	binary.BigEndian.PutUint32(chunk, uint32(len(body)-4))
+	chunk = append(chunk, body...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(body))
+
+	out := append([]byte{}, data[:ihdrEnd]...)
+	out = append(out, chunk...)
+	return append(out, data[ihdrEnd:]...)
+}
+
+func TestScrubImage_Disabled(t *testing.T) {
+	data := []byte("not an image")
+	result, err := ScrubImage(data, PrivacyOptions{})
+	if err != nil {
+		t.Fatalf("ScrubImage() error: %v", err)
+	}
+	if !bytes.Equal(result.Data, data) || result.Stripped || result.Resized {
+		t.Errorf("ScrubImage() = %+v, want data unchanged", result)
+	}
+}
+
+func TestScrubImage_JPEG(t *testing.T) {
+	data := jpegWithEXIF(t, testImage(8, 4), 1)
+
+	result, err := ScrubImage(data, PrivacyOptions{StripMetadata: true})
+	if err != nil {
+		t.Fatalf("ScrubImage() error: %v", err)
+	}
+	if !result.Stripped || result.Resized {
+		t.Errorf("ScrubImage() flags = %+v", result)
+	}
+	if bytes.Contains(result.Data, []byte("Exif")) || bytes.Contains(result.Data, []byte("GPS")) {
+		t.Error("EXIF metadata still present")
+	}
+	if len(result.Data) != len(data)-len(exifSegment(1)) {
+		t.Errorf("scrubbed size = %d, want only the EXIF segment removed from %d", len(result.Data), len(data))
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(result.Data)); err != nil {
+		t.Errorf("scrubbed JPEG does not decode: %v", err)
+	}
+}
+
+func TestScrubImage_JPEGOrientation(t *testing.T) {
+	// Orientation 6: stored 32x16, displayed rotated 90 degrees clockwise as 16x32
+	data := jpegWithEXIF(t, testImage(32, 16), 6)
+
+	result, err := ScrubImage(data, PrivacyOptions{StripMetadata: true})
+	if err != nil {
+		t.Fatalf("ScrubImage() error: %v", err)
+	}
+	if bytes.Contains(result.Data, []byte("Exif")) {
+		t.Error("EXIF metadata still present")
+	}
+	img, err := jpeg.Decode(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatalf("scrubbed JPEG does not decode: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 32 {
+		t.Errorf("bounds = %v, want 16x32", b)
+	}
+	// The red left half becomes the top half
+	if r, _, bl, _ := img.At(8, 4).RGBA(); r < bl {
+		t.Error("top half is not red after rotation")
+	}
+	if r, _, bl, _ := img.At(8, 28).RGBA(); r > bl {
+		t.Error("bottom half is not blue after rotation")
+	}
+}
+
+func TestScrubImage_PNG(t *testing.T) {
+	data := pngWithText(t, testImage(8, 4))
+
+	result, err := ScrubImage(data, PrivacyOptions{StripMetadata: true})
+	if err != nil {
+		t.Fatalf("ScrubImage() error: %v", err)
+	}
+	if bytes.Contains(result.Data, []byte("tEXt")) || bytes.Contains(result.Data, []byte("GPS")) {
+		t.Error("PNG text metadata still present")
+	}
+	if _, err := png.Decode(bytes.NewReader(result.Data)); err != nil {
+		t.Errorf("scrubbed PNG does not decode: %v", err)
+	}
+}
+
+func TestScrubImage_Downscale(t *testing.T) {
+	data := jpegWithEXIF(t, testImage(400, 100), 1)
+
+	result, err := ScrubImage(data, PrivacyOptions{StripMetadata: true, MaxDimension: 100})
+	if err != nil {
+		t.Fatalf("ScrubImage() error: %v", err)
+	}
+	if !result.Resized || !result.Stripped {
+		t.Errorf("ScrubImage() flags = %+v", result)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatalf("DecodeConfig: %v", err)
+	}
+	if format != "jpeg" || cfg.Width != 100 || cfg.Height != 25 {
+		t.Errorf("result = %s %dx%d, want jpeg 100x25", format, cfg.Width, cfg.Height)
+	}
+	if bytes.Contains(result.Data, []byte("GPS")) {
+		t.Error("EXIF metadata still present")
+	}
+}
+
+func TestScrubImage_DownscaleOnly(t *testing.T) {
+	data := pngWithText(t, testImage(50, 50))
+
+	// Within the limit and stripping disabled: sent unchanged
+	result, err := ScrubImage(data, PrivacyOptions{MaxDimension: 100})
+	if err != nil {
+		t.Fatalf("ScrubImage() error: %v", err)
+	}
+	if !bytes.Equal(result.Data, data) {
+		t.Error("image within the limit was changed")
+	}
+}
+
+func TestScrubImage_Unsupported(t *testing.T) {
+	_, err := ScrubImage([]byte("%PDF-1.7"), PrivacyOptions{StripMetadata: true})
+	if !errors.Is(err, ErrScrubUnsupported) {
+		t.Errorf("ScrubImage() error = %v, want ErrScrubUnsupported", err)
+	}
+}
+
+func TestFitDimension(t *testing.T) {
+	tests := []struct {
+		w, h, limit  int
+		wantW, wantH int
+	}{
+		{100, 50, 0, 100, 50},
+		{100, 50, 200, 100, 50},
+		{400, 100, 100, 100, 25},
+		{100, 400, 200, 50, 200},
+		{1000, 1, 10, 10, 1},
+	}
+	for _, tt := range tests {
+		w, h := fitDimension(tt.w, tt.h, tt.limit)
+		if w != tt.wantW || h != tt.wantH {
+			t.Errorf("fitDimension(%d, %d, %d) = %dx%d, want %dx%d", tt.w, tt.h, tt.limit, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+// TestProcessor_ProcessImage_Privacy tests that metadata is removed before the Vision API call
+func TestProcessor_ProcessImage_Privacy(t *testing.T) {
+	var sent []byte
+	server := mockVisionServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var req visionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Requests) > 0 {
+			sent, _ = base64.StdEncoding.DecodeString(req.Requests[0].Image.Content)
+		}
+		successHandler("text")(w, r)
+	})
+	defer server.Close()
+
+	config := DefaultProcessorConfig()
+	config.VisionClientConfig.Endpoint = server.URL
+	config.Privacy = PrivacyOptions{StripMetadata: true}
+
+	processor, err := NewProcessor(testAPIKey, server.Client(), testLogger(t), config)
+	if err != nil {
+		t.Fatalf("NewProcessor() error: %v", err)
+	}
+
+	data := jpegWithEXIF(t, testImage(8, 4), 1)
+	result, err := processor.ProcessImage(context.Background(), data)
+	if err != nil {
+		t.Fatalf("ProcessImage() error: %v", err)
+	}
+	if result.ImageSize >= int64(len(data)) {
+		t.Errorf("ImageSize = %d, want less than %d", result.ImageSize, len(data))
+	}
+	if len(sent) == 0 || bytes.Contains(sent, []byte("Exif")) {
+		t.Error("EXIF metadata was sent to the Vision API")
+	}
+}
@@ -36,6 +36,10 @@ type ProcessorConfig struct {
 
 	// DownloadTimeout for downloading images from URLs
 	DownloadTimeout time.Duration
+
+	// Privacy controls metadata stripping and downscaling before images
+	// are sent to the Vision API
+	Privacy PrivacyOptions
 }
 
 // DefaultProcessorConfig returns sensible default configuration.
@@ -82,8 +86,15 @@ type ProcessResult struct {
 	// VisionAPITime is the time spent in Vision API call
 	VisionAPITime time.Duration
 
-	// ImageSize is the size of the processed image in bytes
+	// ImageSize is the size of the image sent to the Vision API in bytes
 	ImageSize int64
+
+	// Blocks are the detected text blocks with their image positions
+	Blocks []TextBlock
+
+	// ImageWidth and ImageHeight are the image dimensions in pixels
+	ImageWidth  int
+	ImageHeight int
 }
 
 // ProgressCallback is called to report processing progress.
@@ -195,6 +206,20 @@ func (p *Processor) ProcessImage(ctx context.Context, imageData []byte) (*Proces
 		return nil, fmt.Errorf("%w: %d bytes (max: %d)", ErrImageTooLarge, len(imageData), p.config.MaxImageSize)
 	}
 
+	if p.config.Privacy.Enabled() {
+		p.reportProgress("processing", 0.1, "Removing image metadata...")
+		scrubbed, err := ScrubImage(imageData, p.config.Privacy)
+		if err != nil {
+			log.Error("image privacy scrubbing failed", zap.Error(err))
+			return nil, err
+		}
+		log.Debug("image scrubbed before upload",
+			zap.Bool("metadata_stripped", scrubbed.Stripped),
+			zap.Bool("resized", scrubbed.Resized),
+			zap.Int("scrubbed_size_bytes", len(scrubbed.Data)))
+		imageData = scrubbed.Data
+	}
+
 	p.reportProgress("processing", 0.2, "Sending to Vision API...")
 
 	// Perform OCR using the Vision client
@@ -217,6 +242,9 @@ func (p *Processor) ProcessImage(ctx context.Context, imageData []byte) (*Proces
 		ProcessingTime: processingTime,
 		VisionAPITime:  ocrResult.ProcessingTime,
 		ImageSize:      int64(len(imageData)),
+		Blocks:         ocrResult.Blocks,
+		ImageWidth:     ocrResult.ImageWidth,
+		ImageHeight:    ocrResult.ImageHeight,
 	}, nil
 }
 
@@ -0,0 +1,33 @@
+// Package ocrprocessor provides OCR (Optical Character Recognition) functionality
+// for CanvusLocalLLM using Google Cloud Vision API.
+//
+// validation.go contains the startup validation checks contributed by this package.
+package ocrprocessor
+
+import (
+	"context"
+	"os"
+
+	"go_backend/core/validation"
+)
+
+// ValidationChecks returns the checks for the OCR configuration, for
+// registration with validation.ValidationSuite.Register.
+func ValidationChecks() []validation.Check {
+	return []validation.Check{
+		{Name: "Google Vision API Key", Module: "ocrprocessor", Run: checkAPIKey},
+	}
+}
+
+// checkAPIKey verifies the format of GOOGLE_VISION_API_KEY when it is set.
+func checkAPIKey(ctx context.Context) validation.CheckResult {
+	apiKey := os.Getenv("GOOGLE_VISION_API_KEY")
+	if apiKey == "" {
+		return validation.Skipped("GOOGLE_VISION_API_KEY not set, OCR disabled")
+	}
+	if err := ValidateGoogleAPIKey(apiKey); err != nil {
+		return validation.Failed("Invalid API key", err,
+			"Copy the key from Google Cloud Console > APIs & Services > Credentials; keys start with AIza")
+	}
+	return validation.Passed("Key format valid")
+}
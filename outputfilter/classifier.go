@@ -0,0 +1,123 @@
+// Package outputfilter provides the brand-safety stage that checks LLM text
+// before it is written to the canvas. This file contains the LLMClassifier
+// molecule, which asks a local model whether a response is safe to show.
+package outputfilter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go_backend/core"
+)
+
+// Verdict is a classifier's judgement of a response.
+type Verdict struct {
+	Unsafe bool
+	// Category names why the response is unsafe, e.g. "profanity".
+	Category string
+}
+
+// Classifier judges whether text is safe to show on a public display.
+type Classifier interface {
+	Classify(ctx context.Context, text string) (Verdict, error)
+}
+
+// maxClassifierInput bounds the text sent to the model in one call, in bytes.
+const maxClassifierInput = 6000
+
+const classifierPrompt = `You review text before it is shown on a public display wall.
+Decide whether the text below contains profanity, sexual content, hate or harassment,
+violence, self-harm, or other content unsuitable for a general audience.
+Reply with JSON only: {"safe": true} or {"safe": false, "category": "<one word>"}.
+
+Text:
+%s`
+
+// LLMClassifier is a Classifier backed by a local model. Long responses
+// are judged in pieces of at most maxClassifierInput bytes; the response is
+// unsafe if any piece is.
+type LLMClassifier struct {
+	generate core.GenerateFunc
+}
+
+// NewLLMClassifier creates a classifier that prompts generate.
+func NewLLMClassifier(generate core.GenerateFunc) *LLMClassifier {
+	return &LLMClassifier{generate: generate}
+}
+
+// Classify asks the model to judge text.
+func (c *LLMClassifier) Classify(ctx context.Context, text string) (Verdict, error) {
+	for _, piece := range splitText(text, maxClassifierInput) {
+		reply, err := c.generate(ctx, fmt.Sprintf(classifierPrompt, piece))
+		if err != nil {
+			return Verdict{}, fmt.Errorf("outputfilter: classification failed: %w", err)
+		}
+		verdict, err := parseVerdict(reply)
+		if err != nil {
+			return Verdict{}, err
+		}
+		if verdict.Unsafe {
+			return verdict, nil
+		}
+	}
+	return Verdict{}, nil
+}
+
+// parseVerdict reads the JSON verdict from a model reply, ignoring any text
+// around it.
+func parseVerdict(reply string) (Verdict, error) {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start == -1 || end < start {
+		return Verdict{}, fmt.Errorf("outputfilter: classifier reply is not a JSON object: %q", truncate(reply, 100))
+	}
+	var parsed struct {
+		Safe     *bool  `json:"safe"`
+		Category string `json:"category"`
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &parsed); err != nil {
+		return Verdict{}, fmt.Errorf("outputfilter: failed to parse classifier reply: %w", err)
+	}
+	if parsed.Safe == nil {
+		return Verdict{}, fmt.Errorf("outputfilter: classifier reply has no verdict: %q", truncate(reply, 100))
+	}
+	if *parsed.Safe {
+		return Verdict{}, nil
+	}
+	category := strings.ToLower(strings.TrimSpace(parsed.Category))
+	if category == "" {
+		category = "unsafe"
+	}
+	return Verdict{Unsafe: true, Category: category}, nil
+}
+
+// splitText splits text into pieces of at most size bytes, breaking at
+// whitespace where possible.
+func splitText(text string, size int) []string {
+	var pieces []string
+	for len(text) > size {
+		cut := strings.LastIndexAny(text[:size], " \n\t")
+		if cut <= 0 {
+			cut = size
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		pieces = append(pieces, text[:cut])
+		text = text[cut:]
+	}
+	if strings.TrimSpace(text) != "" {
+		pieces = append(pieces, text)
+	}
+	return pieces
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
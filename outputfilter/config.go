@@ -0,0 +1,43 @@
+// Package outputfilter provides the brand-safety stage that checks LLM text
+// before it is written to the canvas. This file contains the configuration
+// read from the environment.
+package outputfilter
+
+import (
+	"errors"
+	"strings"
+
+	"go_backend/core"
+)
+
+// ConfigFromEnv reads OUTPUT_FILTER, OUTPUT_FILTER_MODE, OUTPUT_FILTER_WORDS,
+// OUTPUT_FILTER_WORDLISTS and OUTPUT_FILTER_CLASSIFIER. Problems are
+// returned together with the best config that could be read; an unknown
+// mode blocks, so a typo never weakens the filter.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Enabled:    core.ParseBoolEnv("OUTPUT_FILTER", false),
+		Words:      ParseWords(core.GetEnvOrDefault("OUTPUT_FILTER_WORDS", "")),
+		Classifier: core.ParseBoolEnv("OUTPUT_FILTER_CLASSIFIER", false),
+	}
+
+	var errs []error
+	mode, err := ParseMode(core.GetEnvOrDefault("OUTPUT_FILTER_MODE", string(ModeMask)))
+	if err != nil {
+		errs = append(errs, err)
+	}
+	cfg.Mode = mode
+
+	for _, path := range strings.Split(core.GetEnvOrDefault("OUTPUT_FILTER_WORDLISTS", ""), ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		words, err := LoadWordList(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		cfg.Words = append(cfg.Words, words...)
+	}
+	return cfg, errors.Join(errs...)
+}
@@ -0,0 +1,158 @@
+// Package outputfilter provides the brand-safety stage that checks LLM text
+// before it is written to the canvas. This file contains the Filter
+// organism, which combines the word list atom with an optional Classifier.
+package outputfilter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Mode is what the filter does with a response that matches the word list.
+type Mode string
+
+// Filter modes.
+const (
+	// ModeMask replaces matched terms with asterisks.
+	ModeMask Mode = "mask"
+	// ModeBlock withholds the whole response.
+	ModeBlock Mode = "block"
+)
+
+// ParseMode parses a mode name. An empty name is ModeMask.
+func ParseMode(name string) (Mode, error) {
+	switch Mode(strings.ToLower(strings.TrimSpace(name))) {
+	case "", ModeMask:
+		return ModeMask, nil
+	case ModeBlock:
+		return ModeBlock, nil
+	default:
+		return ModeBlock, fmt.Errorf("outputfilter: unknown mode %q (use mask or block)", name)
+	}
+}
+
+// Action is what the filter did to a response.
+type Action string
+
+// Filter actions.
+const (
+	ActionNone    Action = "none"
+	ActionMasked  Action = "masked"
+	ActionBlocked Action = "blocked"
+)
+
+// Config configures a Filter.
+type Config struct {
+	// Enabled turns the filter on.
+	Enabled bool
+	// Mode applies to word list matches (default: ModeMask). Responses the
+	// classifier flags are always blocked, since there is nothing to mask.
+	Mode Mode
+	// Words are the blocked terms and phrases, matched case-insensitively
+	// as whole words.
+	Words []string
+	// Classifier asks the local model to judge each response.
+	Classifier bool
+}
+
+// Result reports what the filter did. It never holds the matched terms,
+// so it is safe to log and store.
+type Result struct {
+	Action Action
+	// Matches is the number of word list occurrences found.
+	Matches int
+	// Flagged is true when the classifier judged the response unsafe.
+	Flagged bool
+	// Category is the classifier's reason, e.g. "profanity".
+	Category string
+}
+
+// Filtered reports whether the response was masked or blocked.
+func (r *Result) Filtered() bool {
+	return r != nil && r.Action != ActionNone
+}
+
+// Summary describes the result as "masked matches=2" or
+// "blocked classifier=hate", or "none".
+func (r *Result) Summary() string {
+	if !r.Filtered() {
+		return "none"
+	}
+	parts := []string{string(r.Action)}
+	if r.Matches > 0 {
+		parts = append(parts, fmt.Sprintf("matches=%d", r.Matches))
+	}
+	if r.Flagged {
+		parts = append(parts, "classifier="+r.Category)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Filter masks or blocks LLM responses that contain listed terms or that
+// the classifier judges unsafe for a public display.
+//
+// Thread-Safety: Filter is safe for concurrent use.
+type Filter struct {
+	mode       Mode
+	words      *wordList
+	classifier Classifier
+}
+
+// NewFilter creates a Filter. classifier may be nil, in which case only the
+// word list is applied.
+func NewFilter(config Config, classifier Classifier) *Filter {
+	mode := config.Mode
+	if mode == "" {
+		mode = ModeMask
+	}
+	return &Filter{mode: mode, words: newWordList(config.Words), classifier: classifier}
+}
+
+// Mode returns the mode applied to word list matches.
+func (f *Filter) Mode() Mode {
+	return f.mode
+}
+
+// Terms returns the number of terms on the word list.
+func (f *Filter) Terms() int {
+	return f.words.size()
+}
+
+// Classifies reports whether the Filter asks a classifier.
+func (f *Filter) Classifies() bool {
+	return f.classifier != nil
+}
+
+// Check filters text and returns the text to show. A blocked response
+// returns "". If the classifier fails the response is blocked and the
+// error returned, so unchecked text never reaches the canvas.
+func (f *Filter) Check(ctx context.Context, text string) (string, *Result, error) {
+	result := &Result{Action: ActionNone}
+	out, matches := f.words.mask(text)
+	result.Matches = matches
+
+	if matches > 0 && f.mode == ModeBlock {
+		result.Action = ActionBlocked
+		return "", result, nil
+	}
+
+	if f.classifier != nil {
+		verdict, err := f.classifier.Classify(ctx, out)
+		if err != nil {
+			result.Action = ActionBlocked
+			return "", result, err
+		}
+		if verdict.Unsafe {
+			result.Action = ActionBlocked
+			result.Flagged = true
+			result.Category = verdict.Category
+			return "", result, nil
+		}
+	}
+
+	if matches > 0 {
+		result.Action = ActionMasked
+	}
+	return out, result, nil
+}
@@ -0,0 +1,146 @@
+package outputfilter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// fakeClassifier returns a fixed verdict
+type fakeClassifier struct {
+	verdict Verdict
+	err     error
+	calls   int
+}
+
+func (f *fakeClassifier) Classify(ctx context.Context, text string) (Verdict, error) {
+	f.calls++
+	return f.verdict, f.err
+}
+
+func TestCheck_Mask(t *testing.T) {
+	f := NewFilter(Config{Enabled: true, Words: []string{"darn", "Acme Corp"}}, nil)
+	text := "Darn, the ACME  corp widget is darned good. darn!"
+
+	out, result, err := f.Check(context.Background(), text)
+	if err != nil {
+		t.Fatalf("Check() error: %v", err)
+	}
+	if want := "****, the ********** widget is darned good. ****!"; out != want {
+		t.Errorf("Check() = %q, want %q", out, want)
+	}
+	if result.Action != ActionMasked || result.Matches != 3 {
+		t.Errorf("result = %+v", result)
+	}
+	if got := result.Summary(); got != "masked matches=3" {
+		t.Errorf("Summary() = %q", got)
+	}
+}
+
+func TestCheck_Block(t *testing.T) {
+	f := NewFilter(Config{Enabled: true, Mode: ModeBlock, Words: []string{"darn"}}, nil)
+
+	out, result, _ := f.Check(context.Background(), "well, darn")
+	if out != "" || result.Action != ActionBlocked {
+		t.Errorf("Check() = %q, %+v; want blocked", out, result)
+	}
+	out, result, _ = f.Check(context.Background(), "all clean")
+	if out != "all clean" || result.Filtered() {
+		t.Errorf("Check() = %q, %+v; want unchanged", out, result)
+	}
+}
+
+func TestCheck_Classifier(t *testing.T) {
+	classifier := &fakeClassifier{verdict: Verdict{Unsafe: true, Category: "hate"}}
+	f := NewFilter(Config{Enabled: true}, classifier)
+
+	out, result, err := f.Check(context.Background(), "something awful")
+	if err != nil || out != "" || !result.Flagged || result.Action != ActionBlocked {
+		t.Errorf("Check() = %q, %+v, %v; want blocked", out, result, err)
+	}
+	if got := result.Summary(); got != "blocked classifier=hate" {
+		t.Errorf("Summary() = %q", got)
+	}
+
+	classifier.verdict, classifier.err = Verdict{}, errors.New("model offline")
+	out, result, err = f.Check(context.Background(), "anything")
+	if err == nil || out != "" || result.Action != ActionBlocked {
+		t.Errorf("Check() on classifier failure = %q, %+v, %v; want blocked with error", out, result, err)
+	}
+}
+
+func TestCheck_BlockSkipsClassifier(t *testing.T) {
+	classifier := &fakeClassifier{}
+	f := NewFilter(Config{Enabled: true, Mode: ModeBlock, Words: []string{"darn"}}, classifier)
+	if _, _, _ = f.Check(context.Background(), "darn"); classifier.calls != 0 {
+		t.Error("a blocked response should not be classified")
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	if mode, err := ParseMode(""); mode != ModeMask || err != nil {
+		t.Errorf("ParseMode(\"\") = %v, %v", mode, err)
+	}
+	if mode, err := ParseMode(" Block "); mode != ModeBlock || err != nil {
+		t.Errorf("ParseMode(Block) = %v, %v", mode, err)
+	}
+	if mode, err := ParseMode("bleep"); mode != ModeBlock || err == nil {
+		t.Errorf("ParseMode(bleep) = %v, %v; want ModeBlock with error", mode, err)
+	}
+}
+
+func TestLoadWordList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := os.WriteFile(path, []byte("# brands\nAcme Corp\n\n  darn  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	words, err := LoadWordList(path)
+	if err != nil {
+		t.Fatalf("LoadWordList() error: %v", err)
+	}
+	if want := []string{"Acme Corp", "darn"}; !reflect.DeepEqual(words, want) {
+		t.Errorf("LoadWordList() = %v, want %v", words, want)
+	}
+	if _, err := LoadWordList(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadWordList() should fail for a missing file")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("OUTPUT_FILTER", "true")
+	t.Setenv("OUTPUT_FILTER_MODE", "bleep")
+	t.Setenv("OUTPUT_FILTER_WORDS", "darn, heck")
+	t.Setenv("OUTPUT_FILTER_WORDLISTS", filepath.Join(t.TempDir(), "missing.txt"))
+
+	cfg, err := ConfigFromEnv()
+	if err == nil {
+		t.Error("ConfigFromEnv() should report the bad mode and missing list")
+	}
+	if !cfg.Enabled || cfg.Mode != ModeBlock || len(cfg.Words) != 2 {
+		t.Errorf("ConfigFromEnv() = %+v", cfg)
+	}
+}
+
+func TestLLMClassifier(t *testing.T) {
+	tests := []struct {
+		reply   string
+		want    Verdict
+		wantErr bool
+	}{
+		{`{"safe": true}`, Verdict{}, false},
+		{"Sure: {\"safe\": false, \"category\": \"Profanity\"}", Verdict{Unsafe: true, Category: "profanity"}, false},
+		{`{"safe": false}`, Verdict{Unsafe: true, Category: "unsafe"}, false},
+		{`{"category": "x"}`, Verdict{}, true},
+		{"I cannot help with that", Verdict{}, true},
+	}
+	for _, tt := range tests {
+		c := NewLLMClassifier(func(ctx context.Context, prompt string) (string, error) { return tt.reply, nil })
+		got, err := c.Classify(context.Background(), "text")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("Classify() with reply %q = %+v, %v", tt.reply, got, err)
+		}
+	}
+}
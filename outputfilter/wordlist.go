@@ -0,0 +1,119 @@
+// Package outputfilter provides the brand-safety stage that checks LLM text
+// before it is written to the canvas. This file contains the word list atom
+// that finds and masks listed terms.
+package outputfilter
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ParseWords parses a comma-separated list of terms, e.g. "darn,heck".
+func ParseWords(list string) []string {
+	var words []string
+	for _, part := range strings.Split(list, ",") {
+		if word := strings.TrimSpace(part); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// LoadWordList reads a word list file with one term or phrase per line.
+// Blank lines and lines starting with # are skipped.
+func LoadWordList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("outputfilter: failed to open word list: %w", err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("outputfilter: failed to read word list %s: %w", path, err)
+	}
+	return words, nil
+}
+
+// wordList matches listed terms case-insensitively as whole words. Spaces
+// in a phrase match any run of whitespace.
+type wordList struct {
+	pattern *regexp.Regexp
+	terms   int
+}
+
+// newWordList compiles words into one pattern, longest first so phrases
+// win over the words they contain.
+func newWordList(words []string) *wordList {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, word := range words {
+		word = strings.ToLower(strings.Join(strings.Fields(word), " "))
+		if word != "" && !seen[word] {
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	if len(terms) == 0 {
+		return &wordList{}
+	}
+	sort.SliceStable(terms, func(i, j int) bool { return len(terms[i]) > len(terms[j]) })
+
+	alternatives := make([]string, len(terms))
+	for i, term := range terms {
+		alternatives[i] = strings.ReplaceAll(regexp.QuoteMeta(term), " ", `\s+`)
+	}
+	return &wordList{
+		pattern: regexp.MustCompile(`(?i)(?:` + strings.Join(alternatives, "|") + `)`),
+		terms:   len(terms),
+	}
+}
+
+func (w *wordList) size() int {
+	return w.terms
+}
+
+// mask replaces each whole-word match with one asterisk per character and
+// returns the number replaced.
+func (w *wordList) mask(text string) (string, int) {
+	if w.pattern == nil {
+		return text, 0
+	}
+	var b strings.Builder
+	n, last := 0, 0
+	for _, loc := range w.pattern.FindAllStringIndex(text, -1) {
+		start, end := loc[0], loc[1]
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordRune(before) || isWordRune(after) {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(strings.Repeat("*", utf8.RuneCountInString(text[start:end])))
+		last = end
+		n++
+	}
+	if n == 0 {
+		return text, 0
+	}
+	b.WriteString(text[last:])
+	return b.String(), n
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
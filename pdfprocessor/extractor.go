@@ -10,11 +10,13 @@ import (
 	"fmt"
 	"strings"
 
+	"go_backend/core/errs"
+
 	"github.com/ledongthuc/pdf"
 )
 
 // ErrNoPDFContent is returned when a PDF contains no extractable text.
-var ErrNoPDFContent = errors.New("no text content found in PDF")
+var ErrNoPDFContent = errs.New(errs.CodeNoContent, "no text content found in PDF")
 
 // ErrEmptyPath is returned when an empty file path is provided.
 var ErrEmptyPath = errors.New("empty PDF path provided")
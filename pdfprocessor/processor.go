@@ -65,9 +65,16 @@ type ProcessResult struct {
 type ProcessingStages struct {
 	ExtractionTime  time.Duration
 	ChunkingTime    time.Duration
+	RedactionTime   time.Duration
 	SummarizingTime time.Duration
 }
 
+// ChunkRedactor masks personal data in chunks before they are sent to the
+// AI. It returns one chunk for every chunk it is given.
+type ChunkRedactor interface {
+	RedactChunks(ctx context.Context, chunks []string) ([]string, error)
+}
+
 // ProgressCallback is called to report processing progress.
 // stage is the current stage name, progress is 0.0-1.0, message is a human-readable status.
 type ProgressCallback func(stage string, progress float64, message string)
@@ -79,6 +86,7 @@ type Processor struct {
 	chunker    *Chunker
 	summarizer *Summarizer
 	progress   ProgressCallback
+	redactor   ChunkRedactor
 }
 
 // NewProcessor creates a new Processor with the given configuration and OpenAI client.
@@ -116,6 +124,12 @@ func (p *Processor) SetProgressCallback(progress ProgressCallback) {
 	p.progress = progress
 }
 
+// SetRedactor sets the redactor run on chunks before summarization.
+// A nil redactor sends chunks unchanged.
+func (p *Processor) SetRedactor(redactor ChunkRedactor) {
+	p.redactor = redactor
+}
+
 // Process extracts text from a PDF file, chunks it, and generates an AI summary.
 // This is the main entry point for PDF processing.
 //
@@ -159,7 +173,12 @@ func (p *Processor) Process(ctx context.Context, pdfPath string) (*ProcessResult
 	p.reportProgress("chunking", 1.0, fmt.Sprintf("Created %d chunks",
 		chunkerResult.TotalChunks))
 
-	// Stage 3: Generate AI summary
+	// Stage 3: Redact personal data
+	if err := p.redactChunks(ctx, chunkerResult, result); err != nil {
+		return nil, err
+	}
+
+	// Stage 4: Generate AI summary
 	p.reportProgress("summarizing", 0.0, fmt.Sprintf("Sending %d chunks to AI...",
 		chunkerResult.TotalChunks))
 	summaryStart := time.Now()
@@ -204,7 +223,12 @@ func (p *Processor) ProcessText(ctx context.Context, text string) (*ProcessResul
 	p.reportProgress("chunking", 1.0, fmt.Sprintf("Created %d chunks",
 		chunkerResult.TotalChunks))
 
-	// Stage 2: Generate AI summary
+	// Stage 2: Redact personal data
+	if err := p.redactChunks(ctx, chunkerResult, result); err != nil {
+		return nil, err
+	}
+
+	// Stage 3: Generate AI summary
 	p.reportProgress("summarizing", 0.0, fmt.Sprintf("Sending %d chunks to AI...",
 		chunkerResult.TotalChunks))
 	summaryStart := time.Now()
@@ -223,6 +247,31 @@ func (p *Processor) ProcessText(ctx context.Context, text string) (*ProcessResul
 	return result, nil
 }
 
+// redactChunks runs the redactor, if set, on the chunk texts in place.
+func (p *Processor) redactChunks(ctx context.Context, chunkerResult *ChunkerResult, result *ProcessResult) error {
+	if p.redactor == nil || len(chunkerResult.Chunks) == 0 {
+		return nil
+	}
+
+	p.reportProgress("redacting", 0.0, "Redacting personal data...")
+	redactStart := time.Now()
+
+	redacted, err := p.redactor.RedactChunks(ctx, ChunksToStrings(chunkerResult))
+	if err != nil {
+		return fmt.Errorf("redaction failed: %w", err)
+	}
+	if len(redacted) != len(chunkerResult.Chunks) {
+		return fmt.Errorf("redaction failed: got %d chunks, want %d", len(redacted), len(chunkerResult.Chunks))
+	}
+	for i := range chunkerResult.Chunks {
+		chunkerResult.Chunks[i].Text = redacted[i]
+	}
+	result.Stages.RedactionTime = time.Since(redactStart)
+
+	p.reportProgress("redacting", 1.0, "Personal data redacted")
+	return nil
+}
+
 // reportProgress calls the progress callback if set.
 func (p *Processor) reportProgress(stage string, progress float64, message string) {
 	if p.progress != nil {
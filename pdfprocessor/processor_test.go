@@ -287,6 +287,48 @@ func TestProcessor_ProcessText(t *testing.T) {
 	}
 }
 
+// upperRedactor replaces "secret" in every chunk
+type upperRedactor struct{}
+
+func (upperRedactor) RedactChunks(ctx context.Context, chunks []string) ([]string, error) {
+	out := make([]string, len(chunks))
+	for i, c := range chunks {
+		out[i] = strings.ReplaceAll(c, "secret", "[MASKED]")
+	}
+	return out, nil
+}
+
+func TestProcessor_ProcessText_Redactor(t *testing.T) {
+	var sent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openai.ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, m := range req.Messages {
+			sent += m.Content
+		}
+		json.NewEncoder(w).Encode(openai.ChatCompletionResponse{
+			Choices: []openai.ChatCompletionChoice{{Message: openai.ChatCompletionMessage{Content: `{"type": "text", "content": "ok"}`}}},
+		})
+	}))
+	defer server.Close()
+
+	clientConfig := openai.DefaultConfig("test-key")
+	clientConfig.BaseURL = server.URL + "/v1"
+	processor := NewProcessor(DefaultProcessorConfig(), openai.NewClientWithConfig(clientConfig))
+	processor.SetRedactor(upperRedactor{})
+
+	result, err := processor.ProcessText(context.Background(), "The secret plan is in this document.")
+	if err != nil {
+		t.Fatalf("ProcessText failed: %v", err)
+	}
+	if strings.Contains(sent, "secret") || !strings.Contains(sent, "[MASKED]") {
+		t.Errorf("AI request was not redacted: %q", sent)
+	}
+	if !strings.Contains(result.ChunkerResult.Chunks[0].Text, "[MASKED]") {
+		t.Error("ChunkerResult should hold the redacted chunks")
+	}
+}
+
 func TestProcessor_ExtractOnly(t *testing.T) {
 	pdfPath := getTestPDFPath()
 	if _, err := os.Stat(pdfPath); os.IsNotExist(err) {
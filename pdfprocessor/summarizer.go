@@ -13,6 +13,8 @@ import (
 	"fmt"
 	"strings"
 
+	"go_backend/core/errs"
+
 	"github.com/sashabaranov/go-openai"
 )
 
@@ -20,10 +22,10 @@ import (
 var ErrNoChunks = errors.New("no chunks provided for summarization")
 
 // ErrEmptyResponse is returned when the AI returns an empty response.
-var ErrEmptyResponse = errors.New("AI returned empty response")
+var ErrEmptyResponse = errs.New(errs.CodeBadAIResponse, "AI returned empty response")
 
 // ErrInvalidJSON is returned when the AI response doesn't contain valid JSON.
-var ErrInvalidJSON = errors.New("AI response does not contain valid JSON")
+var ErrInvalidJSON = errs.New(errs.CodeBadAIResponse, "AI response does not contain valid JSON")
 
 // SummarizerConfig holds configuration for AI summarization.
 type SummarizerConfig struct {
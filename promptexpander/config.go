@@ -0,0 +1,20 @@
+// Package promptexpander provides the stage that turns a short image
+// request into a detailed prompt for the image generator. This file
+// contains the configuration read from the environment.
+package promptexpander
+
+import (
+	"go_backend/core"
+)
+
+// ConfigFromEnv reads PROMPT_EXPANSION, PROMPT_EXPANSION_CREATIVITY and
+// PROMPT_EXPANSION_MAX_LENGTH. An unknown creativity is returned as an
+// error together with a config using CreativityMedium.
+func ConfigFromEnv() (Config, error) {
+	creativity, err := ParseCreativity(core.GetEnvOrDefault("PROMPT_EXPANSION_CREATIVITY", string(CreativityMedium)))
+	return Config{
+		Enabled:    core.ParseBoolEnv("PROMPT_EXPANSION", true),
+		Creativity: creativity,
+		MaxLength:  core.ParseIntEnv("PROMPT_EXPANSION_MAX_LENGTH", DefaultMaxLength),
+	}, err
+}
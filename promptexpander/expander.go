@@ -0,0 +1,160 @@
+// Package promptexpander provides the stage that turns a short image
+// request into a detailed prompt for the image generator. This file
+// contains the Expander organism.
+package promptexpander
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Creativity is how freely the expander embellishes a request.
+type Creativity string
+
+// Creativity levels.
+const (
+	// CreativityLow keeps the request's wording and adds a few details
+	CreativityLow Creativity = "low"
+	// CreativityMedium keeps everything asked for and adds style, mood
+	// and composition
+	CreativityMedium Creativity = "medium"
+	// CreativityHigh expands the request into an imaginative scene
+	CreativityHigh Creativity = "high"
+)
+
+// ParseCreativity parses a creativity level. An empty name is
+// CreativityMedium.
+func ParseCreativity(name string) (Creativity, error) {
+	switch Creativity(strings.ToLower(strings.TrimSpace(name))) {
+	case "", CreativityMedium:
+		return CreativityMedium, nil
+	case CreativityLow:
+		return CreativityLow, nil
+	case CreativityHigh:
+		return CreativityHigh, nil
+	default:
+		return CreativityMedium, fmt.Errorf("promptexpander: unknown creativity %q (use low, medium or high)", name)
+	}
+}
+
+// Length limits of expanded prompts, in characters.
+const (
+	DefaultMaxLength = 1000
+	MaxMaxLength     = 4000
+)
+
+// Config configures an Expander.
+type Config struct {
+	// Enabled turns expansion on; when off, requests are used as written
+	Enabled    bool
+	Creativity Creativity
+	// MaxLength is the longest expanded prompt, in characters
+	// (0 = DefaultMaxLength); longer replies are cut at a word boundary
+	MaxLength int
+}
+
+// GenerateFunc runs prompt through a model with systemPrompt and returns
+// its reply.
+type GenerateFunc func(ctx context.Context, systemPrompt, prompt string, temperature float32) (string, error)
+
+// style holds what each creativity level asks of the model.
+type style struct {
+	instruction string
+	temperature float32
+}
+
+var styles = map[Creativity]style{
+	CreativityLow: {
+		instruction: `Keep the user's subject and wording exactly. ` +
+			`Only add a few details about style, lighting and composition where the request leaves them open.`,
+		temperature: 0.3,
+	},
+	CreativityMedium: {
+		instruction: `Keep every element the user asked for, in their words. ` +
+			`Add details about style, mood, lighting and composition that fit the request.`,
+		temperature: 0.7,
+	},
+	CreativityHigh: {
+		instruction: `Do NOT simply repeat or rephrase the user's input. Instead, expand it into a unique, creative, and visually rich scene, ` +
+			`including style, mood, composition, and any relevant artistic details.`,
+		temperature: 1.0,
+	},
+}
+
+const systemPromptFormat = `You write prompts for an AI image generator from a user's image request. %s ` +
+	`Reply with the image prompt only, in at most %d characters, without quotes or explanations.`
+
+// Expander turns image requests into detailed image prompts. The model is
+// passed to each Expand call, so expansion runs on the same backend as the
+// task it belongs to.
+type Expander struct {
+	config Config
+}
+
+// NewExpander creates an Expander.
+func NewExpander(config Config) *Expander {
+	if config.MaxLength <= 0 {
+		config.MaxLength = DefaultMaxLength
+	}
+	if config.MaxLength > MaxMaxLength {
+		config.MaxLength = MaxMaxLength
+	}
+	if _, ok := styles[config.Creativity]; !ok {
+		config.Creativity = CreativityMedium
+	}
+	return &Expander{config: config}
+}
+
+// Enabled reports whether requests are expanded. A nil Expander is
+// disabled.
+func (e *Expander) Enabled() bool {
+	return e != nil && e.config.Enabled
+}
+
+// Config returns the expander's settings.
+func (e *Expander) Config() Config {
+	return e.config
+}
+
+// Expand returns the image prompt for request, written by generate. When
+// expansion is off, or the model returns nothing, request is returned
+// unchanged.
+func (e *Expander) Expand(ctx context.Context, request string, generate GenerateFunc) (string, error) {
+	request = strings.TrimSpace(request)
+	if !e.Enabled() || request == "" {
+		return request, nil
+	}
+
+	s := styles[e.config.Creativity]
+	system := fmt.Sprintf(systemPromptFormat, s.instruction, e.config.MaxLength)
+	reply, err := generate(ctx, system, request, s.temperature)
+	if err != nil {
+		return request, fmt.Errorf("promptexpander: expansion failed: %w", err)
+	}
+	prompt := strings.Trim(strings.TrimSpace(reply), `"'`)
+	if prompt == "" {
+		return request, nil
+	}
+	return cut(prompt, e.config.MaxLength), nil
+}
+
+// cut shortens s to at most max characters, at a word boundary if there
+// is one.
+func cut(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	runes = runes[:max]
+	for i := len(runes) - 1; i > max/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			runes = runes[:i]
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes), func(r rune) bool {
+		return unicode.IsSpace(r) || r == ','
+	})
+}
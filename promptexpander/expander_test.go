@@ -0,0 +1,73 @@
+package promptexpander
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	ctx := context.Background()
+	var gotSystem string
+	var gotTemp float32
+	reply, replyErr := "", error(nil)
+	generate := func(ctx context.Context, system, prompt string, temperature float32) (string, error) {
+		gotSystem, gotTemp = system, temperature
+		return reply, replyErr
+	}
+
+	t.Run("disabled keeps the request", func(t *testing.T) {
+		e := NewExpander(Config{Enabled: false})
+		if got, err := e.Expand(ctx, " a red fox ", generate); err != nil || got != "a red fox" {
+			t.Errorf("Expand() = %q, %v", got, err)
+		}
+		var nilExpander *Expander
+		if got, _ := nilExpander.Expand(ctx, "a red fox", generate); got != "a red fox" {
+			t.Errorf("nil Expand() = %q", got)
+		}
+	})
+
+	t.Run("creativity sets instruction and temperature", func(t *testing.T) {
+		reply = `"A red fox in fresh snow, soft morning light"`
+		e := NewExpander(Config{Enabled: true, Creativity: CreativityLow, MaxLength: 300})
+		got, err := e.Expand(ctx, "a red fox in snow", generate)
+		if err != nil || got != "A red fox in fresh snow, soft morning light" {
+			t.Errorf("Expand() = %q, %v", got, err)
+		}
+		if gotTemp != 0.3 || !strings.Contains(gotSystem, "subject and wording exactly") || !strings.Contains(gotSystem, "300 characters") {
+			t.Errorf("system = %q, temperature = %v", gotSystem, gotTemp)
+		}
+	})
+
+	t.Run("long replies are cut at a word", func(t *testing.T) {
+		reply = strings.Repeat("misty pine forest, ", 20)
+		got, _ := NewExpander(Config{Enabled: true, MaxLength: 50}).Expand(ctx, "a forest", generate)
+		if len([]rune(got)) > 50 || strings.HasSuffix(got, ",") || strings.HasSuffix(got, " ") {
+			t.Errorf("Expand() = %q (%d chars)", got, len(got))
+		}
+	})
+
+	t.Run("empty reply or error keeps the request", func(t *testing.T) {
+		e := NewExpander(Config{Enabled: true})
+		reply = "  "
+		if got, err := e.Expand(ctx, "a forest", generate); err != nil || got != "a forest" {
+			t.Errorf("Expand() = %q, %v", got, err)
+		}
+		replyErr = errors.New("rate limited")
+		if got, err := e.Expand(ctx, "a forest", generate); err == nil || got != "a forest" {
+			t.Errorf("Expand() = %q, %v; want request and error", got, err)
+		}
+	})
+}
+
+func TestParseCreativity(t *testing.T) {
+	for name, want := range map[string]Creativity{"": CreativityMedium, "LOW": CreativityLow, "high": CreativityHigh} {
+		if got, err := ParseCreativity(name); err != nil || got != want {
+			t.Errorf("ParseCreativity(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseCreativity("wild"); err == nil {
+		t.Error("ParseCreativity(wild) succeeded")
+	}
+}
@@ -0,0 +1,19 @@
+// Package promptguard provides the sanitation stage that neutralizes
+// prompt-injection attempts in canvas and document text before it is
+// placed in an LLM prompt. This file contains the configuration read from
+// the environment.
+package promptguard
+
+import (
+	"go_backend/core"
+)
+
+// ConfigFromEnv reads PROMPT_GUARD and PROMPT_GUARD_MODE. An unknown mode
+// is returned as an error with a config that neutralizes, so a typo never
+// weakens the guard.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Enabled: core.ParseBoolEnv("PROMPT_GUARD", true)}
+	mode, err := ParseMode(core.GetEnvOrDefault("PROMPT_GUARD_MODE", string(ModeNeutralize)))
+	cfg.Mode = mode
+	return cfg, err
+}
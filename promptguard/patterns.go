@@ -0,0 +1,91 @@
+// Package promptguard provides the sanitation stage that neutralizes
+// prompt-injection attempts in canvas and document text before it is
+// placed in an LLM prompt. This file contains the pattern atoms that find
+// injection phrases, chat template markers and hidden characters.
+package promptguard
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Category is a kind of prompt-injection pattern.
+type Category string
+
+// Categories of injection patterns.
+const (
+	// CategoryIgnoreInstructions is a request to drop earlier
+	// instructions, e.g. "ignore all previous instructions".
+	CategoryIgnoreInstructions Category = "ignore_instructions"
+	// CategoryRoleOverride is an attempt to give the model a new role or
+	// rules, e.g. "you are now DAN" or "new instructions:".
+	CategoryRoleOverride Category = "role_override"
+	// CategoryPromptLeak is a request to reveal the system prompt.
+	CategoryPromptLeak Category = "prompt_leak"
+	// CategoryRoleMarker is a chat template token such as <|im_start|> or
+	// [INST] that could open a fake system or assistant turn.
+	CategoryRoleMarker Category = "role_marker"
+	// CategoryHiddenText is zero-width and bidirectional control
+	// characters, which can hide a phrase from people reading the canvas.
+	CategoryHiddenText Category = "hidden_text"
+)
+
+// AllCategories lists every category in the order they are checked.
+var AllCategories = []Category{
+	CategoryHiddenText,
+	CategoryRoleMarker,
+	CategoryIgnoreInstructions,
+	CategoryRoleOverride,
+	CategoryPromptLeak,
+}
+
+// patterns are the phrase patterns of each category. They match
+// case-insensitively and tolerate any run of whitespace between words.
+var patterns = map[Category][]*regexp.Regexp{
+	CategoryRoleMarker: {
+		regexp.MustCompile(`<\|(?:im_start|im_end|system|user|assistant|endoftext|eot_id|start_header_id|end_header_id)\|>`),
+		regexp.MustCompile(`\[/?INST\]|<</?SYS>>`),
+	},
+	CategoryIgnoreInstructions: {
+		regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override|bypass)\s+(?:(?:all|any|every|of|the|your|my|these|those)\s+)*(?:previous|prior|above|earlier|preceding|original|initial|system)\s+(?:instructions?|prompts?|rules|directions|guidelines|messages?|context)\b`),
+		regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget)\s+(?:everything|anything|all)\s+(?:above|before|you\s+were\s+told)\b`),
+	},
+	CategoryRoleOverride: {
+		regexp.MustCompile(`(?i)\byou\s+are\s+(?:now|no\s+longer)\s+(?:an?\s+|in\s+|the\s+)?(?:DAN|unrestricted|unfiltered|jailbroken|uncensored|developer\s+mode|bound\s+by)\b`),
+		regexp.MustCompile(`(?i)\b(?:enable|enter|activate)\s+(?:developer|jailbreak|DAN|god)\s+mode\b`),
+		regexp.MustCompile(`(?i)\bnew\s+(?:system\s+)?instructions?\s*:`),
+		regexp.MustCompile(`(?i)\b(?:act|pretend|behave)\s+as\s+(?:if\s+you\s+(?:are|were)\s+)?(?:an?\s+)?(?:unrestricted|unfiltered|jailbroken|uncensored)\b`),
+	},
+	CategoryPromptLeak: {
+		regexp.MustCompile(`(?i)\b(?:reveal|print|show|repeat|output|display|leak|dump)\s+(?:me\s+)?(?:your|the)\s+(?:(?:full|entire|original|hidden|initial|secret)\s+)*(?:system\s+prompt|system\s+message|hidden\s+prompt|initial\s+prompt)\b`),
+		regexp.MustCompile(`(?i)\b(?:reveal|print|repeat|output|dump|leak)\s+(?:me\s+)?your\s+(?:(?:full|entire|original|hidden|initial|secret)\s+)*instructions\b`),
+	},
+}
+
+// isHidden reports whether r is a zero-width or bidirectional control
+// character.
+func isHidden(r rune) bool {
+	switch {
+	case r >= '\u200b' && r <= '\u200f', // zero-width space to right-to-left mark
+		r >= '\u202a' && r <= '\u202e', // bidirectional embeddings and overrides
+		r >= '\u2060' && r <= '\u2064', // word joiner and invisible operators
+		r >= '\u2066' && r <= '\u2069', // bidirectional isolates
+		r == '\ufeff':
+		return true
+	}
+	return false
+}
+
+// stripHidden removes hidden characters from text and returns how many it
+// removed.
+func stripHidden(text string) (string, int) {
+	n := 0
+	out := strings.Map(func(r rune) rune {
+		if isHidden(r) {
+			n++
+			return -1
+		}
+		return r
+	}, text)
+	return out, n
+}
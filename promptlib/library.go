@@ -0,0 +1,118 @@
+// Package promptlib provides the prompt library used by {{use:name}}
+// triggers. This file contains the Library organism, which caches the
+// templates and writes changes through to the database.
+package promptlib
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"go_backend/core/clock"
+)
+
+// Storage persists prompt templates. Implemented by db.Repository.
+type Storage interface {
+	ListPromptTemplates(ctx context.Context) ([]Template, error)
+	SavePromptTemplate(ctx context.Context, t Template) error
+	DeletePromptTemplate(ctx context.Context, name string) error
+}
+
+// Library holds every template in memory and writes changes through to
+// Storage.
+//
+// Thread-Safety: Library is safe for concurrent use.
+type Library struct {
+	storage Storage
+	clock   clock.Clock
+
+	mu        sync.RWMutex
+	templates map[string]Template
+}
+
+// NewLibrary creates a Library and loads the saved templates.
+func NewLibrary(ctx context.Context, storage Storage) (*Library, error) {
+	l := &Library{
+		storage:   storage,
+		clock:     clock.Real(),
+		templates: make(map[string]Template),
+	}
+	saved, err := storage.ListPromptTemplates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load prompt library: %w", err)
+	}
+	for _, t := range saved {
+		l.templates[t.Name] = t
+	}
+	return l, nil
+}
+
+// SetClock sets the clock that stamps saved templates (default:
+// clock.Real). Call it before the library is used.
+func (l *Library) SetClock(c clock.Clock) {
+	l.clock = clock.OrReal(c)
+}
+
+// Get returns the template called name.
+func (l *Library) Get(name string) (Template, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	t, ok := l.templates[name]
+	return t, ok
+}
+
+// List returns every template, by name.
+func (l *Library) List() []Template {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	out := make([]Template, 0, len(l.templates))
+	for _, t := range l.templates {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Put validates and saves t, replacing any template with the same name.
+// The saved template is returned.
+func (l *Library) Put(ctx context.Context, t Template) (Template, error) {
+	t = t.normalize()
+	if err := t.Validate(); err != nil {
+		return t, err
+	}
+	t.UpdatedAt = l.clock.Now().UTC()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.storage.SavePromptTemplate(ctx, t); err != nil {
+		return t, fmt.Errorf("failed to save prompt template: %w", err)
+	}
+	l.templates[t.Name] = t
+	return t, nil
+}
+
+// Delete removes the template called name. Deleting a template that does
+// not exist returns ErrUnknownTemplate.
+func (l *Library) Delete(ctx context.Context, name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.templates[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrUnknownTemplate, name)
+	}
+	if err := l.storage.DeletePromptTemplate(ctx, name); err != nil {
+		return fmt.Errorf("failed to delete prompt template: %w", err)
+	}
+	delete(l.templates, name)
+	return nil
+}
+
+// Expand fills the template called name from note text; see
+// Template.Expand.
+func (l *Library) Expand(name, noteText string) (string, error) {
+	t, ok := l.Get(name)
+	if !ok {
+		return "", fmt.Errorf("%w: no prompt template is called %q", ErrUnknownTemplate, name)
+	}
+	return t.Expand(noteText)
+}
@@ -0,0 +1,126 @@
+package promptlib
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// memStorage keeps templates in a map
+type memStorage struct {
+	templates map[string]Template
+}
+
+func (m *memStorage) ListPromptTemplates(ctx context.Context) ([]Template, error) {
+	var out []Template
+	for _, t := range m.templates {
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func (m *memStorage) SavePromptTemplate(ctx context.Context, t Template) error {
+	m.templates[t.Name] = t
+	return nil
+}
+
+func (m *memStorage) DeletePromptTemplate(ctx context.Context, name string) error {
+	delete(m.templates, name)
+	return nil
+}
+
+const weeklyReview = "Write a weekly review for team {team}, week {week}.\nNotes:\n{text}\nTeam {Team} wants it short."
+
+func TestTemplateVariables(t *testing.T) {
+	tmpl := Template{Name: "weekly-review", Body: weeklyReview}
+	if got, want := tmpl.Variables(), []string{"team", "week"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Variables() = %v, want %v", got, want)
+	}
+}
+
+func TestTemplateExpand(t *testing.T) {
+	tmpl := Template{Name: "weekly-review", Body: weeklyReview}
+
+	got, err := tmpl.Expand("Team: Platform\nShipped the importer.\nweek: 42\nNote: retro on Friday")
+	if err != nil {
+		t.Fatalf("Expand() error: %v", err)
+	}
+	want := "Write a weekly review for team Platform, week 42.\nNotes:\nShipped the importer.\nNote: retro on Friday\nTeam Platform wants it short."
+	if got != want {
+		t.Errorf("Expand() = %q, want %q", got, want)
+	}
+
+	_, err = tmpl.Expand("team: Platform\nweek:")
+	if !errors.Is(err, ErrMissingVariables) || !strings.Contains(err.Error(), "week") {
+		t.Errorf("Expand() without week = %v, want ErrMissingVariables naming it", err)
+	}
+}
+
+func TestTemplateValidate(t *testing.T) {
+	for _, bad := range []Template{
+		{Name: "", Body: "x"},
+		{Name: "Weekly Review", Body: "x"},
+		{Name: "ok", Body: ""},
+		{Name: "ok", Body: strings.Repeat("x", maxBodyBytes+1)},
+	} {
+		if err := bad.Validate(); !errors.Is(err, ErrInvalidTemplate) {
+			t.Errorf("Validate(%q) = %v, want ErrInvalidTemplate", bad.Name, err)
+		}
+	}
+}
+
+func TestParseUse(t *testing.T) {
+	tests := []struct {
+		in   string
+		name string
+		ok   bool
+	}{
+		{"use:weekly-review", "weekly-review", true},
+		{" USE: Weekly-Review ", "weekly-review", true},
+		{"image: a cat", "", false},
+		{"us", "", false},
+	}
+	for _, tt := range tests {
+		if name, ok := ParseUse(tt.in); name != tt.name || ok != tt.ok {
+			t.Errorf("ParseUse(%q) = %q, %v", tt.in, name, ok)
+		}
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	storage := &memStorage{templates: map[string]Template{
+		"standup": {Name: "standup", Body: "Summarize: {text}"},
+	}}
+	ctx := context.Background()
+	lib, err := NewLibrary(ctx, storage)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := lib.Put(ctx, Template{Name: " Weekly-Review ", Body: weeklyReview})
+	if err != nil || saved.Name != "weekly-review" || saved.UpdatedAt.IsZero() {
+		t.Fatalf("Put() = %+v, %v", saved, err)
+	}
+	if _, ok := storage.templates["weekly-review"]; !ok {
+		t.Error("Put() did not write through to storage")
+	}
+	if list := lib.List(); len(list) != 2 || list[0].Name != "standup" {
+		t.Errorf("List() = %+v", list)
+	}
+
+	if got, err := lib.Expand("standup", "all green"); err != nil || got != "Summarize: all green" {
+		t.Errorf("Expand() = %q, %v", got, err)
+	}
+	if _, err := lib.Expand("missing", ""); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("Expand(missing) = %v, want ErrUnknownTemplate", err)
+	}
+
+	if err := lib.Delete(ctx, "standup"); err != nil {
+		t.Fatalf("Delete() error: %v", err)
+	}
+	if err := lib.Delete(ctx, "standup"); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("second Delete() = %v, want ErrUnknownTemplate", err)
+	}
+}
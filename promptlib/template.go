@@ -0,0 +1,131 @@
+// Package promptlib provides the prompt library: named prompt templates,
+// kept in the database and edited from the dashboard, that a note uses with
+// a {{use:name}} trigger. This file contains the Template type and its
+// expansion.
+package promptlib
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Validation errors.
+var (
+	ErrInvalidTemplate = errors.New("invalid prompt template")
+	ErrUnknownTemplate = errors.New("unknown prompt template")
+	// ErrMissingVariables is returned when a note does not set every
+	// variable its template needs.
+	ErrMissingVariables = errors.New("missing template variables")
+)
+
+// VarText is the placeholder replaced by the note text that is not a
+// variable line.
+const VarText = "text"
+
+// maxBodyBytes bounds the size of a template body.
+const maxBodyBytes = 16 << 10
+
+var (
+	namePattern        = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+	placeholderPattern = regexp.MustCompile(`\{([A-Za-z][A-Za-z0-9_-]*)\}`)
+	variableLine       = regexp.MustCompile(`^\s*([A-Za-z][A-Za-z0-9_-]*)\s*:\s*(.*?)\s*$`)
+)
+
+// Template is a named prompt. Its body names variables as {name}; {text}
+// is replaced by the rest of the note.
+type Template struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Body        string    `json:"body"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// normalize lowercases the name and trims the fields.
+func (t Template) normalize() Template {
+	t.Name = strings.ToLower(strings.TrimSpace(t.Name))
+	t.Description = strings.TrimSpace(t.Description)
+	t.Body = strings.TrimSpace(t.Body)
+	return t
+}
+
+// Validate reports the first problem with the template.
+func (t Template) Validate() error {
+	if !namePattern.MatchString(t.Name) {
+		return fmt.Errorf("%w: name %q must be 1-64 lowercase letters, digits, '-' or '_'", ErrInvalidTemplate, t.Name)
+	}
+	if t.Body == "" {
+		return fmt.Errorf("%w: body is empty", ErrInvalidTemplate)
+	}
+	if len(t.Body) > maxBodyBytes {
+		return fmt.Errorf("%w: body is longer than %d bytes", ErrInvalidTemplate, maxBodyBytes)
+	}
+	return nil
+}
+
+// Variables returns the variables the body names, other than {text}, in
+// order of first use.
+func (t Template) Variables() []string {
+	var vars []string
+	seen := map[string]bool{VarText: true}
+	for _, m := range placeholderPattern.FindAllStringSubmatch(t.Body, -1) {
+		name := strings.ToLower(m[1])
+		if !seen[name] {
+			seen[name] = true
+			vars = append(vars, name)
+		}
+	}
+	return vars
+}
+
+// Expand fills the template from note text. Lines of the form
+// "name: value" set the variables the template names (names are not case
+// sensitive); every other line becomes {text}. Returns ErrMissingVariables
+// naming the variables the note does not set.
+func (t Template) Expand(noteText string) (string, error) {
+	wanted := make(map[string]bool)
+	for _, v := range t.Variables() {
+		wanted[v] = true
+	}
+
+	values := make(map[string]string)
+	var rest []string
+	for _, line := range strings.Split(noteText, "\n") {
+		if m := variableLine.FindStringSubmatch(line); m != nil {
+			name := strings.ToLower(m[1])
+			if _, set := values[name]; wanted[name] && !set {
+				values[name] = m[2]
+				continue
+			}
+		}
+		rest = append(rest, line)
+	}
+	values[VarText] = strings.TrimSpace(strings.Join(rest, "\n"))
+
+	var missing []string
+	for _, v := range t.Variables() {
+		if values[v] == "" {
+			missing = append(missing, v)
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %q needs %s (add a line like \"%s: ...\" to the note)",
+			ErrMissingVariables, t.Name, strings.Join(missing, ", "), missing[0])
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(t.Body, func(p string) string {
+		return values[strings.ToLower(p[1:len(p)-1])]
+	}), nil
+}
+
+// ParseUse returns the template name of a "use:name" trigger. ok is false
+// if trigger does not use a template.
+func ParseUse(trigger string) (name string, ok bool) {
+	trigger = strings.TrimSpace(trigger)
+	if len(trigger) < len("use:") || !strings.EqualFold(trigger[:len("use:")], "use:") {
+		return "", false
+	}
+	return strings.ToLower(strings.TrimSpace(trigger[len("use:"):])), true
+}
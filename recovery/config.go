@@ -0,0 +1,22 @@
+// Package recovery provides the startup recovery pass that finds the work
+// a previous run left unfinished because it did not shut down cleanly.
+// This file contains the configuration read from the environment.
+package recovery
+
+import (
+	"go_backend/core"
+)
+
+// DefaultMaxAttempts is the number of runs a task gets when
+// RECOVERY_MAX_ATTEMPTS is not set.
+const DefaultMaxAttempts = 2
+
+// ConfigFromEnv reads RECOVERY_MAX_ATTEMPTS. Values below 1 are raised
+// to 1.
+func ConfigFromEnv() Config {
+	attempts := core.ParseIntEnv("RECOVERY_MAX_ATTEMPTS", DefaultMaxAttempts)
+	if attempts < 1 {
+		attempts = 1
+	}
+	return Config{MaxAttempts: attempts}
+}
@@ -0,0 +1,107 @@
+// Package recovery provides the startup recovery pass that finds the work
+// a previous run left unfinished because it did not shut down cleanly.
+// This file contains the task journal, which records AI tasks in the
+// database while they run.
+package recovery
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// journalTimeout bounds each journal write, so a busy database cannot hold
+// up a task.
+const journalTimeout = 5 * time.Second
+
+// Entry is an AI task recorded in the journal.
+type Entry struct {
+	TaskID   string `json:"task_id"`
+	TaskType string `json:"task_type"`
+	CanvasID string `json:"canvas_id"`
+	// WidgetID is the trigger widget the task answers
+	WidgetID string `json:"widget_id"`
+	// ProcessingNoteID is the "AI Processing" note the task put on the
+	// canvas, if any
+	ProcessingNoteID string    `json:"processing_note_id,omitempty"`
+	StartedAt        time.Time `json:"started_at"`
+	// Attempts counts the runs of the trigger's task that were
+	// interrupted before this one
+	Attempts int `json:"attempts"`
+}
+
+// Storage persists the journal. Implemented by db.Repository.
+type Storage interface {
+	// StartJournalTask records a task as running
+	StartJournalTask(ctx context.Context, entry Entry) error
+	// SetJournalProcessingNote records the processing note of a running task
+	SetJournalProcessingNote(ctx context.Context, taskID, noteID string) error
+	// FinishJournalTask removes a finished task and the interrupted runs
+	// counted for its trigger
+	FinishJournalTask(ctx context.Context, taskID, widgetID string) error
+	// ListJournalTasks returns the tasks recorded as running, with their
+	// earlier interrupted runs in Attempts
+	ListJournalTasks(ctx context.Context) ([]Entry, error)
+	// ResolveJournalTask removes an interrupted task. If requeued, its
+	// trigger keeps entry.Attempts interrupted runs; otherwise the count
+	// is cleared.
+	ResolveJournalTask(ctx context.Context, entry Entry, requeued bool) error
+}
+
+// Journal records the AI tasks of this run, so the next run can tell which
+// were interrupted. Write failures are logged rather than returned, since
+// a task runs the same without its journal entry. A nil Journal records
+// nothing.
+type Journal struct {
+	storage Storage
+	logger  *zap.Logger
+}
+
+// NewJournal creates a journal that writes to storage.
+func NewJournal(storage Storage, logger *zap.Logger) *Journal {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Journal{storage: storage, logger: logger.Named("journal")}
+}
+
+// Start records that a task has started.
+func (j *Journal) Start(entry Entry) {
+	if j == nil {
+		return
+	}
+	if entry.StartedAt.IsZero() {
+		entry.StartedAt = time.Now()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), journalTimeout)
+	defer cancel()
+	if err := j.storage.StartJournalTask(ctx, entry); err != nil {
+		j.logger.Warn("Failed to record running task", zap.String("task_id", entry.TaskID), zap.Error(err))
+	}
+}
+
+// SetProcessingNote records the processing note a task created, so it can
+// be cleaned up if the task is interrupted.
+func (j *Journal) SetProcessingNote(taskID, noteID string) {
+	if j == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), journalTimeout)
+	defer cancel()
+	if err := j.storage.SetJournalProcessingNote(ctx, taskID, noteID); err != nil {
+		j.logger.Warn("Failed to record processing note", zap.String("task_id", taskID), zap.Error(err))
+	}
+}
+
+// Finish records that a task has finished, whether it succeeded or not.
+func (j *Journal) Finish(taskID, widgetID string) {
+	if j == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), journalTimeout)
+	defer cancel()
+	if err := j.storage.FinishJournalTask(ctx, taskID, widgetID); err != nil {
+		j.logger.Warn("Failed to record finished task", zap.String("task_id", taskID), zap.Error(err))
+	}
+}
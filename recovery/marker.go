@@ -0,0 +1,51 @@
+// Package recovery provides the startup recovery pass that finds the work
+// a previous run left unfinished because it did not shut down cleanly.
+// This file contains the run marker, a file that exists while the server
+// runs.
+package recovery
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// MarkerName is the name of the run marker file.
+const MarkerName = ".running"
+
+// Marker is the run marker of this run. It is created at startup and
+// removed at the end of a clean shutdown, so a marker found at startup
+// means the previous run crashed or was killed.
+type Marker struct {
+	path string
+}
+
+// CreateMarker creates the run marker in dir. It reports whether a marker
+// was already there, left by a run that did not shut down cleanly.
+func CreateMarker(dir string) (*Marker, bool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, false, fmt.Errorf("recovery: failed to create marker directory: %w", err)
+	}
+	path := filepath.Join(dir, MarkerName)
+	_, err := os.Stat(path)
+	found := err == nil
+
+	content := fmt.Sprintf("pid %d started %s\n", os.Getpid(), time.Now().Format(time.RFC3339))
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return nil, found, fmt.Errorf("recovery: failed to write run marker: %w", err)
+	}
+	return &Marker{path: path}, found, nil
+}
+
+// Remove deletes the marker at the end of a clean shutdown. A nil Marker or
+// a marker already gone is not an error.
+func (m *Marker) Remove() error {
+	if m == nil {
+		return nil
+	}
+	if err := os.Remove(m.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("recovery: failed to remove run marker: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+// Package recovery provides the startup recovery pass that finds the work
+// a previous run left unfinished because it did not shut down cleanly.
+// This file contains the Recoverer organism, which requeues or fails the
+// tasks the journal shows were interrupted.
+package recovery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Actions taken for an interrupted task.
+const (
+	// ActionRequeued leaves the trigger on the canvas for the monitor to
+	// run again when it reads the canvas at startup
+	ActionRequeued = "requeued"
+	// ActionFailed gives up on the task and tells the user on the canvas
+	ActionFailed = "failed"
+)
+
+// Config configures a Recoverer.
+type Config struct {
+	// MaxAttempts is the number of runs a task gets. A task interrupted
+	// on its last run is failed instead of requeued, so a request that
+	// crashes the server cannot do so at every start.
+	MaxAttempts int
+}
+
+// Canvas is the canvas the recovery pass cleans up.
+type Canvas interface {
+	// Widget returns a widget, or nil if it no longer exists
+	Widget(id string) (map[string]interface{}, error)
+	// RemoveNote deletes an orphaned processing note
+	RemoveNote(id string) error
+	// ReportFailure tells the user that the task of entry was given up,
+	// on its processing note or next to its trigger
+	ReportFailure(entry Entry, trigger map[string]interface{}) error
+}
+
+// Evidence is what the previous run left behind besides its journal.
+type Evidence struct {
+	// MarkerFound is set if the run marker of the previous run was found
+	MarkerFound bool
+	// TempFiles is the number of leftover temp files removed at startup
+	TempFiles int
+}
+
+// Outcome is what the recovery pass did with one interrupted task.
+type Outcome struct {
+	Entry
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+	// Trigger is the trigger widget of a failed task that still exists
+	Trigger map[string]interface{} `json:"-"`
+}
+
+// Summary reports a recovery pass.
+type Summary struct {
+	At time.Time `json:"at"`
+	// Unclean is set if the previous run did not shut down cleanly
+	Unclean      bool      `json:"unclean"`
+	TempFiles    int       `json:"temp_files"`
+	Requeued     int       `json:"requeued"`
+	Failed       int       `json:"failed"`
+	NotesRemoved int       `json:"notes_removed"`
+	Tasks        []Outcome `json:"tasks,omitempty"`
+}
+
+// Recoverer runs the recovery pass at startup, before the monitor reads
+// the canvas.
+type Recoverer struct {
+	config   Config
+	storage  Storage
+	canvas   Canvas
+	canvasID string
+	logger   *zap.Logger
+}
+
+// NewRecoverer creates a Recoverer for the monitored canvas. Tasks of other
+// canvases are failed, since nothing will run them again.
+func NewRecoverer(config Config, storage Storage, canvas Canvas, canvasID string, logger *zap.Logger) *Recoverer {
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = DefaultMaxAttempts
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Recoverer{
+		config:   config,
+		storage:  storage,
+		canvas:   canvas,
+		canvasID: canvasID,
+		logger:   logger.Named("recovery"),
+	}
+}
+
+// Run requeues or fails every task the journal shows was still running
+// when the previous run stopped, and cleans up their processing notes.
+// A task whose journal entry cannot be resolved is still reported, and is
+// found again at the next start.
+func (r *Recoverer) Run(ctx context.Context, evidence Evidence) (*Summary, error) {
+	entries, err := r.storage.ListJournalTasks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("recovery: failed to read task journal: %w", err)
+	}
+
+	summary := &Summary{
+		At:        time.Now(),
+		Unclean:   evidence.MarkerFound || len(entries) > 0,
+		TempFiles: evidence.TempFiles,
+	}
+	for _, entry := range entries {
+		out, removed := r.recover(entry)
+		if removed {
+			summary.NotesRemoved++
+		}
+		if err := r.storage.ResolveJournalTask(ctx, out.Entry, out.Action == ActionRequeued); err != nil {
+			r.logger.Warn("Failed to update task journal", zap.String("task_id", entry.TaskID), zap.Error(err))
+		}
+		switch out.Action {
+		case ActionRequeued:
+			summary.Requeued++
+		case ActionFailed:
+			summary.Failed++
+		}
+		r.logger.Info("Interrupted task recovered",
+			zap.String("task_id", entry.TaskID),
+			zap.String("task_type", entry.TaskType),
+			zap.String("widget_id", entry.WidgetID),
+			zap.Int("attempts", out.Attempts),
+			zap.String("action", out.Action),
+			zap.String("reason", out.Reason))
+		summary.Tasks = append(summary.Tasks, out)
+	}
+	return summary, nil
+}
+
+// recover decides what to do with one interrupted task and cleans up its
+// processing note. It reports whether the note was removed.
+func (r *Recoverer) recover(entry Entry) (Outcome, bool) {
+	entry.Attempts++
+	out := Outcome{Entry: entry}
+
+	if entry.CanvasID != r.canvasID {
+		out.Action, out.Reason = ActionFailed, "canvas is no longer monitored"
+		return out, false
+	}
+
+	trigger, err := r.canvas.Widget(entry.WidgetID)
+	switch {
+	case err != nil:
+		// Without the canvas there is nothing to clean up; the monitor runs
+		// the trigger again if it still exists
+		out.Action, out.Reason = ActionRequeued, fmt.Sprintf("canvas unreachable: %v", err)
+		return out, false
+	case trigger == nil:
+		out.Action, out.Reason = ActionFailed, "trigger widget was deleted"
+	case entry.Attempts >= r.config.MaxAttempts:
+		out.Action = ActionFailed
+		out.Reason = fmt.Sprintf("interrupted %d times", entry.Attempts)
+		out.Trigger = trigger
+		if err := r.canvas.ReportFailure(entry, trigger); err != nil {
+			r.logger.Warn("Failed to report interrupted task on canvas", zap.String("task_id", entry.TaskID), zap.Error(err))
+		}
+		return out, false
+	default:
+		out.Action, out.Reason = ActionRequeued, "interrupted by unclean shutdown"
+	}
+
+	// A requeued task creates a new processing note when it runs again,
+	// and the note of a deleted trigger answers nothing
+	if entry.ProcessingNoteID == "" {
+		return out, false
+	}
+	if err := r.canvas.RemoveNote(entry.ProcessingNoteID); err != nil {
+		r.logger.Warn("Failed to remove orphaned processing note",
+			zap.String("note_id", entry.ProcessingNoteID), zap.Error(err))
+		return out, false
+	}
+	return out, true
+}
@@ -0,0 +1,195 @@
+package recovery
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// memStorage is an in-memory Storage.
+type memStorage struct {
+	running  []Entry
+	attempts map[string]int
+}
+
+func (s *memStorage) StartJournalTask(ctx context.Context, entry Entry) error {
+	s.running = append(s.running, entry)
+	return nil
+}
+
+func (s *memStorage) SetJournalProcessingNote(ctx context.Context, taskID, noteID string) error {
+	for i := range s.running {
+		if s.running[i].TaskID == taskID {
+			s.running[i].ProcessingNoteID = noteID
+		}
+	}
+	return nil
+}
+
+func (s *memStorage) FinishJournalTask(ctx context.Context, taskID, widgetID string) error {
+	s.remove(taskID)
+	delete(s.attempts, widgetID)
+	return nil
+}
+
+func (s *memStorage) ListJournalTasks(ctx context.Context) ([]Entry, error) {
+	list := make([]Entry, len(s.running))
+	for i, e := range s.running {
+		e.Attempts = s.attempts[e.WidgetID]
+		list[i] = e
+	}
+	return list, nil
+}
+
+func (s *memStorage) ResolveJournalTask(ctx context.Context, entry Entry, requeued bool) error {
+	s.remove(entry.TaskID)
+	if requeued {
+		s.attempts[entry.WidgetID] = entry.Attempts
+	} else {
+		delete(s.attempts, entry.WidgetID)
+	}
+	return nil
+}
+
+func (s *memStorage) remove(taskID string) {
+	for i, e := range s.running {
+		if e.TaskID == taskID {
+			s.running = append(s.running[:i], s.running[i+1:]...)
+			return
+		}
+	}
+}
+
+// fakeCanvas records the notes the recovery pass changes.
+type fakeCanvas struct {
+	widgets  map[string]bool
+	err      error
+	removed  []string
+	reported []string
+}
+
+func (c *fakeCanvas) Widget(id string) (map[string]interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if !c.widgets[id] {
+		return nil, nil
+	}
+	return map[string]interface{}{"id": id}, nil
+}
+
+func (c *fakeCanvas) RemoveNote(id string) error {
+	c.removed = append(c.removed, id)
+	return nil
+}
+
+func (c *fakeCanvas) ReportFailure(entry Entry, trigger map[string]interface{}) error {
+	c.reported = append(c.reported, entry.TaskID)
+	return nil
+}
+
+func TestRecoverer_Run(t *testing.T) {
+	storage := &memStorage{attempts: map[string]int{"poison": 1}}
+	journal := NewJournal(storage, nil)
+	for _, e := range []Entry{
+		{TaskID: "t1", CanvasID: "c", WidgetID: "note"},
+		{TaskID: "t2", CanvasID: "c", WidgetID: "pdf-icon"},
+		{TaskID: "t3", CanvasID: "c", WidgetID: "gone"},
+		{TaskID: "t4", CanvasID: "c", WidgetID: "poison"},
+		{TaskID: "t5", CanvasID: "old", WidgetID: "other"},
+	} {
+		journal.Start(e)
+	}
+	journal.SetProcessingNote("t2", "p2")
+	journal.SetProcessingNote("t3", "p3")
+	journal.SetProcessingNote("t4", "p4")
+
+	canvas := &fakeCanvas{widgets: map[string]bool{"note": true, "pdf-icon": true, "poison": true}}
+	summary, err := NewRecoverer(Config{MaxAttempts: 2}, storage, canvas, "c", nil).Run(context.Background(), Evidence{TempFiles: 3})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	if !summary.Unclean || summary.TempFiles != 3 || summary.Requeued != 2 || summary.Failed != 3 || summary.NotesRemoved != 2 {
+		t.Errorf("summary = %+v", summary)
+	}
+	actions := map[string]string{}
+	for _, out := range summary.Tasks {
+		actions[out.TaskID] = out.Action
+	}
+	want := map[string]string{"t1": ActionRequeued, "t2": ActionRequeued, "t3": ActionFailed, "t4": ActionFailed, "t5": ActionFailed}
+	for id, action := range want {
+		if actions[id] != action {
+			t.Errorf("%s: action = %q, want %q", id, actions[id], action)
+		}
+	}
+	if summary.Tasks[3].Trigger == nil || summary.Tasks[3].Attempts != 2 {
+		t.Errorf("poison outcome = %+v, want its trigger and 2 attempts", summary.Tasks[3])
+	}
+	if len(canvas.removed) != 2 || canvas.removed[0] != "p2" || canvas.removed[1] != "p3" {
+		t.Errorf("removed notes = %v, want [p2 p3]", canvas.removed)
+	}
+	if len(canvas.reported) != 1 || canvas.reported[0] != "t4" {
+		t.Errorf("reported failures = %v, want [t4]", canvas.reported)
+	}
+
+	// The journal is empty afterwards, and requeued triggers keep their count
+	if len(storage.running) != 0 || storage.attempts["note"] != 1 || storage.attempts["poison"] != 0 {
+		t.Errorf("storage after Run = %+v", storage)
+	}
+}
+
+func TestRecoverer_CanvasUnreachable(t *testing.T) {
+	storage := &memStorage{attempts: map[string]int{}}
+	storage.StartJournalTask(context.Background(), Entry{TaskID: "t1", CanvasID: "c", WidgetID: "w", ProcessingNoteID: "p"})
+	canvas := &fakeCanvas{err: errors.New("connection refused")}
+
+	summary, err := NewRecoverer(Config{MaxAttempts: 2}, storage, canvas, "c", nil).Run(context.Background(), Evidence{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Requeued != 1 || len(canvas.removed) != 0 {
+		t.Errorf("summary = %+v, removed = %v; want requeued without cleanup", summary, canvas.removed)
+	}
+}
+
+func TestRecoverer_CleanStart(t *testing.T) {
+	storage := &memStorage{attempts: map[string]int{}}
+	summary, err := NewRecoverer(Config{}, storage, &fakeCanvas{}, "c", nil).Run(context.Background(), Evidence{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Unclean || len(summary.Tasks) != 0 {
+		t.Errorf("summary = %+v, want a clean start", summary)
+	}
+}
+
+func TestMarker(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "downloads")
+	marker, found, err := CreateMarker(dir)
+	if err != nil || found {
+		t.Fatalf("CreateMarker() = %v, %v; want no marker found", found, err)
+	}
+
+	// A second start without a clean shutdown finds it
+	if _, found, err := CreateMarker(dir); err != nil || !found {
+		t.Fatalf("CreateMarker() again = %v, %v; want marker found", found, err)
+	}
+
+	if err := marker.Remove(); err != nil {
+		t.Fatalf("Remove() error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, MarkerName)); !os.IsNotExist(err) {
+		t.Errorf("marker still exists after Remove: %v", err)
+	}
+	if err := marker.Remove(); err != nil {
+		t.Errorf("second Remove() error: %v", err)
+	}
+
+	var none *Marker
+	if err := none.Remove(); err != nil {
+		t.Errorf("nil Remove() error: %v", err)
+	}
+}
@@ -0,0 +1,21 @@
+// Package redact provides the PII redaction stage that masks personal data
+// in text before it is sent to a cloud LLM. This file contains the
+// configuration read from the environment.
+package redact
+
+import (
+	"go_backend/core"
+)
+
+// ConfigFromEnv reads PII_REDACTION and PII_REDACTION_KINDS. If the kinds
+// cannot be parsed the error is returned with a config that redacts every
+// kind, so a typo never turns redaction off.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{Enabled: core.ParseBoolEnv("PII_REDACTION", false)}
+	kinds, err := ParseKinds(core.GetEnvOrDefault("PII_REDACTION_KINDS", "email,phone,name"))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Kinds = kinds
+	return cfg, nil
+}
@@ -0,0 +1,111 @@
+// Package redact provides the PII redaction stage that masks personal data
+// in text before it is sent to a cloud LLM. This file contains the
+// LLMNameDetector molecule, which finds person names with a local model.
+package redact
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"go_backend/core"
+)
+
+// NameDetector finds person names in text.
+type NameDetector interface {
+	DetectNames(ctx context.Context, text string) ([]string, error)
+}
+
+// maxNERInput bounds the text sent to the model in one call, in bytes.
+const maxNERInput = 6000
+
+const nerPrompt = `List every person's name that appears in the text below.
+Reply with a JSON array of strings, exactly as the names are written, and nothing else.
+Reply with [] if there are no names.
+
+Text:
+%s`
+
+// LLMNameDetector is a NameDetector backed by a local model. Text is sent
+// in pieces of at most maxNERInput bytes, so it never leaves the machine.
+type LLMNameDetector struct {
+	generate core.GenerateFunc
+}
+
+// NewLLMNameDetector creates a detector that prompts generate for names.
+func NewLLMNameDetector(generate core.GenerateFunc) *LLMNameDetector {
+	return &LLMNameDetector{generate: generate}
+}
+
+// DetectNames returns the names the model found in text. Names the model
+// returns that do not appear in the text are dropped.
+func (d *LLMNameDetector) DetectNames(ctx context.Context, text string) ([]string, error) {
+	var names []string
+	for _, piece := range splitText(text, maxNERInput) {
+		reply, err := d.generate(ctx, fmt.Sprintf(nerPrompt, piece))
+		if err != nil {
+			return nil, fmt.Errorf("redact: name detection failed: %w", err)
+		}
+		found, err := parseNames(reply)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range found {
+			if strings.Contains(piece, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// parseNames reads the JSON array of names from a model reply, ignoring
+// any text around it.
+func parseNames(reply string) ([]string, error) {
+	start := strings.Index(reply, "[")
+	end := strings.LastIndex(reply, "]")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("redact: name detection reply is not a JSON array: %q", truncate(reply, 100))
+	}
+	var names []string
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &names); err != nil {
+		return nil, fmt.Errorf("redact: failed to parse name detection reply: %w", err)
+	}
+	var out []string
+	for _, name := range names {
+		if name = strings.TrimSpace(name); len(name) >= 2 {
+			out = append(out, name)
+		}
+	}
+	return out, nil
+}
+
+// splitText splits text into pieces of at most size bytes, breaking at
+// whitespace where possible so names are not cut in half.
+func splitText(text string, size int) []string {
+	var pieces []string
+	for len(text) > size {
+		cut := strings.LastIndexAny(text[:size], " \n\t")
+		if cut <= 0 {
+			cut = size
+			for cut > 0 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+		}
+		pieces = append(pieces, text[:cut])
+		text = text[cut:]
+	}
+	if strings.TrimSpace(text) != "" {
+		pieces = append(pieces, text)
+	}
+	return pieces
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,89 @@
+// Package redact provides the PII redaction stage that masks personal data
+// in text before it is sent to a cloud LLM. This file contains the pattern
+// atoms that find email addresses and phone numbers.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Kind is a category of personal data.
+type Kind string
+
+// Kinds of personal data that can be redacted.
+const (
+	KindEmail Kind = "email"
+	KindPhone Kind = "phone"
+	KindName  Kind = "name"
+)
+
+// AllKinds lists every kind in the order they are redacted.
+var AllKinds = []Kind{KindEmail, KindPhone, KindName}
+
+// ParseKinds parses a comma-separated list of kinds, e.g. "email,phone".
+func ParseKinds(list string) ([]Kind, error) {
+	var kinds []Kind
+	for _, part := range strings.Split(list, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			continue
+		}
+		switch Kind(name) {
+		case KindEmail, KindPhone, KindName:
+			kinds = append(kinds, Kind(name))
+		default:
+			return nil, fmt.Errorf("redact: unknown kind %q (use email, phone or name)", part)
+		}
+	}
+	return kinds, nil
+}
+
+// placeholder returns the mask for the n-th distinct value of a kind,
+// e.g. "[EMAIL_1]".
+func placeholder(kind Kind, n int) string {
+	return fmt.Sprintf("[%s_%d]", strings.ToUpper(string(kind)), n)
+}
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+
+// phonePattern matches international (+44 20 7946 0958), bracketed
+// ((555) 123-4567) and separated (555-123-4567) numbers. Matches are
+// checked by isPhoneNumber.
+var phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{1,4}\)[\s.\-]?)?\d{2,4}(?:[\s.\-]\d{2,8}){1,4}`)
+
+// datePattern matches dates that phonePattern would otherwise accept.
+var datePattern = regexp.MustCompile(`^(?:\d{4}[\-./]\d{1,2}[\-./]\d{1,2}|\d{1,2}[\-./]\d{1,2}[\-./]\d{4})$`)
+
+// isPhoneNumber reports whether a phonePattern match is a plausible phone
+// number: 7 to 15 digits and not a date.
+func isPhoneNumber(match string) bool {
+	if datePattern.MatchString(match) {
+		return false
+	}
+	digits := 0
+	for _, c := range match {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
+
+// findEmails returns the email addresses in text.
+func findEmails(text string) []string {
+	return emailPattern.FindAllString(text, -1)
+}
+
+// findPhones returns the phone numbers in text.
+func findPhones(text string) []string {
+	var phones []string
+	for _, m := range phonePattern.FindAllString(text, -1) {
+		m = strings.TrimSpace(m)
+		if isPhoneNumber(m) {
+			phones = append(phones, m)
+		}
+	}
+	return phones
+}
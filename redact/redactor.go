@@ -0,0 +1,197 @@
+// Package redact provides the PII redaction stage that masks personal data
+// in text before it is sent to a cloud LLM. This file contains the Redactor
+// organism, which combines the pattern atoms with an optional NameDetector.
+package redact
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Config configures a Redactor.
+type Config struct {
+	// Enabled turns redaction on.
+	Enabled bool
+	// Kinds to redact (default: AllKinds). Names are only redacted when
+	// the Redactor has a NameDetector.
+	Kinds []Kind
+}
+
+// Result reports what a redaction masked. It never holds the original
+// values, so it is safe to log and store.
+type Result struct {
+	// Counts is the number of distinct values masked per kind.
+	Counts map[Kind]int
+	// Occurrences is the number of replacements made.
+	Occurrences int
+}
+
+// Total returns the number of distinct values masked.
+func (r *Result) Total() int {
+	total := 0
+	for _, n := range r.Counts {
+		total += n
+	}
+	return total
+}
+
+// Summary describes the result as "email=2 phone=1", or "none".
+func (r *Result) Summary() string {
+	var parts []string
+	for _, kind := range AllKinds {
+		if n := r.Counts[kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", kind, n))
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, " ")
+}
+
+// Redactor masks personal data with numbered placeholders such as
+// [EMAIL_1], so the model can still tell different people apart.
+//
+// Thread-Safety: Redactor is safe for concurrent use.
+type Redactor struct {
+	kinds map[Kind]bool
+	names NameDetector
+}
+
+// NewRedactor creates a Redactor. names may be nil, in which case only
+// emails and phone numbers are redacted.
+func NewRedactor(config Config, names NameDetector) *Redactor {
+	kinds := config.Kinds
+	if len(kinds) == 0 {
+		kinds = AllKinds
+	}
+	r := &Redactor{kinds: make(map[Kind]bool), names: names}
+	for _, kind := range kinds {
+		r.kinds[kind] = true
+	}
+	return r
+}
+
+// Redacts reports whether the Redactor masks kind.
+func (r *Redactor) Redacts(kind Kind) bool {
+	if kind == KindName && r.names == nil {
+		return false
+	}
+	return r.kinds[kind]
+}
+
+// Redact masks personal data in text.
+func (r *Redactor) Redact(ctx context.Context, text string) (string, *Result, error) {
+	out, result, err := r.RedactAll(ctx, []string{text})
+	if err != nil {
+		return "", nil, err
+	}
+	return out[0], result, nil
+}
+
+// RedactAll masks personal data in several texts, such as the chunks of a
+// document. The same value gets the same placeholder in every text. If name
+// detection fails an error is returned, so nothing unredacted is sent.
+func (r *Redactor) RedactAll(ctx context.Context, texts []string) ([]string, *Result, error) {
+	out := append([]string(nil), texts...)
+	result := &Result{Counts: make(map[Kind]int)}
+
+	if r.Redacts(KindEmail) {
+		r.replaceMatches(out, KindEmail, findEmails, result)
+	}
+	if r.Redacts(KindPhone) {
+		r.replaceMatches(out, KindPhone, findPhones, result)
+	}
+	if r.Redacts(KindName) {
+		names, err := r.names.DetectNames(ctx, strings.Join(out, "\n"))
+		if err != nil {
+			return nil, nil, err
+		}
+		r.replaceValues(out, KindName, longestFirst(names), true, result)
+	}
+	return out, result, nil
+}
+
+// replaceMatches masks every value find returns in texts.
+func (r *Redactor) replaceMatches(texts []string, kind Kind, find func(string) []string, result *Result) {
+	var values []string
+	for _, text := range texts {
+		values = append(values, find(text)...)
+	}
+	r.replaceValues(texts, kind, longestFirst(values), false, result)
+}
+
+// replaceValues replaces each value with its placeholder. Values must be
+// ordered longest first so "Ann Lee" is masked before "Ann". With
+// wholeWord, values inside longer words are left alone.
+func (r *Redactor) replaceValues(texts []string, kind Kind, values []string, wholeWord bool, result *Result) {
+	for _, value := range values {
+		masked := false
+		ph := placeholder(kind, result.Counts[kind]+1)
+		for i, text := range texts {
+			var n int
+			if wholeWord {
+				texts[i], n = replaceWord(text, value, ph)
+			} else {
+				n = strings.Count(text, value)
+				texts[i] = strings.ReplaceAll(text, value, ph)
+			}
+			if n > 0 {
+				masked = true
+				result.Occurrences += n
+			}
+		}
+		if masked {
+			result.Counts[kind]++
+		}
+	}
+}
+
+// longestFirst returns the distinct values sorted by length, longest first.
+func longestFirst(values []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, v := range values {
+		if v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return len(out[i]) > len(out[j]) })
+	return out
+}
+
+// replaceWord replaces occurrences of word in text that are not part of a
+// longer word or number, and returns the number replaced.
+func replaceWord(text, word, repl string) (string, int) {
+	var b strings.Builder
+	n, last, from := 0, 0, 0
+	for {
+		i := strings.Index(text[from:], word)
+		if i == -1 {
+			break
+		}
+		start := from + i
+		end := start + len(word)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordRune(before) || isWordRune(after) {
+			from = start + 1
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(repl)
+		last, from = end, end
+		n++
+	}
+	b.WriteString(text[last:])
+	return b.String(), n
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
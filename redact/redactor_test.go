@@ -0,0 +1,163 @@
+package redact
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeDetector returns fixed names
+type fakeDetector struct {
+	names []string
+	err   error
+	calls int
+}
+
+func (f *fakeDetector) DetectNames(ctx context.Context, text string) ([]string, error) {
+	f.calls++
+	return f.names, f.err
+}
+
+func TestRedact_EmailsAndPhones(t *testing.T) {
+	r := NewRedactor(Config{Enabled: true}, nil)
+	text := "Mail ann@example.com or bob.lee@mail.example.co.uk, call +44 20 7946 0958 or (555) 123-4567. ann@example.com again."
+
+	out, result, err := r.Redact(context.Background(), text)
+	if err != nil {
+		t.Fatalf("Redact() error: %v", err)
+	}
+	for _, leaked := range []string{"ann@example.com", "bob.lee@", "7946", "123-4567"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("output still contains %q: %s", leaked, out)
+		}
+	}
+	if strings.Count(out, "[EMAIL_1]")+strings.Count(out, "[EMAIL_2]") != 3 {
+		t.Errorf("emails not masked consistently: %s", out)
+	}
+	if result.Counts[KindEmail] != 2 || result.Counts[KindPhone] != 2 || result.Occurrences != 5 {
+		t.Errorf("result = %+v", result)
+	}
+	if got := result.Summary(); got != "email=2 phone=2" {
+		t.Errorf("Summary() = %q", got)
+	}
+}
+
+func TestRedact_NotPhones(t *testing.T) {
+	r := NewRedactor(Config{Enabled: true, Kinds: []Kind{KindPhone}}, nil)
+	text := "Due 2024-01-15 or 15.01.2024, version 1.2.3, total 42 items, order 12-34."
+
+	out, result, err := r.Redact(context.Background(), text)
+	if err != nil {
+		t.Fatalf("Redact() error: %v", err)
+	}
+	if out != text || result.Total() != 0 {
+		t.Errorf("Redact() = %q %+v, want unchanged", out, result)
+	}
+	if result.Summary() != "none" {
+		t.Errorf("Summary() = %q, want none", result.Summary())
+	}
+}
+
+func TestRedact_Names(t *testing.T) {
+	detector := &fakeDetector{names: []string{"Ann", "Ann Lee", "Zoë"}}
+	r := NewRedactor(Config{Enabled: true}, detector)
+
+	out, result, err := r.Redact(context.Background(), "Ann Lee met Zoë and Ann at Annex.")
+	if err != nil {
+		t.Fatalf("Redact() error: %v", err)
+	}
+	want := "[NAME_1] met [NAME_2] and [NAME_3] at Annex."
+	if out != want {
+		t.Errorf("Redact() = %q, want %q", out, want)
+	}
+	if result.Counts[KindName] != 3 {
+		t.Errorf("name count = %d, want 3", result.Counts[KindName])
+	}
+}
+
+func TestRedact_NameDetectionFails(t *testing.T) {
+	r := NewRedactor(Config{Enabled: true}, &fakeDetector{err: errors.New("model unloaded")})
+	if _, _, err := r.Redact(context.Background(), "Ann"); err == nil {
+		t.Error("Redact() should fail when name detection fails")
+	}
+}
+
+func TestRedactAll_ConsistentAcrossTexts(t *testing.T) {
+	detector := &fakeDetector{names: []string{"Ann"}}
+	r := NewRedactor(Config{Enabled: true}, detector)
+
+	out, result, err := r.RedactAll(context.Background(), []string{
+		"Ann wrote to a@b.io.",
+		"Reply to a@b.io, Ann.",
+	})
+	if err != nil {
+		t.Fatalf("RedactAll() error: %v", err)
+	}
+	want := []string{"[NAME_1] wrote to [EMAIL_1].", "Reply to [EMAIL_1], [NAME_1]."}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("RedactAll() = %q, want %q", out, want)
+	}
+	if detector.calls != 1 || result.Occurrences != 4 {
+		t.Errorf("calls = %d, result = %+v", detector.calls, result)
+	}
+}
+
+func TestRedacts(t *testing.T) {
+	r := NewRedactor(Config{Enabled: true, Kinds: []Kind{KindEmail, KindName}}, nil)
+	if !r.Redacts(KindEmail) || r.Redacts(KindPhone) || r.Redacts(KindName) {
+		t.Error("Redacts() should report email only without a name detector")
+	}
+}
+
+func TestParseKinds(t *testing.T) {
+	kinds, err := ParseKinds(" Email, phone ,")
+	if err != nil || !reflect.DeepEqual(kinds, []Kind{KindEmail, KindPhone}) {
+		t.Errorf("ParseKinds() = %v, %v", kinds, err)
+	}
+	if _, err := ParseKinds("email,address"); err == nil {
+		t.Error("ParseKinds() should reject unknown kinds")
+	}
+}
+
+func TestLLMNameDetector(t *testing.T) {
+	var prompts []string
+	detector := NewLLMNameDetector(func(ctx context.Context, prompt string) (string, error) {
+		prompts = append(prompts, prompt)
+		return "Sure! Here they are:\n[\"Ann Lee\", \"Nobody\", \"x\"]", nil
+	})
+
+	names, err := detector.DetectNames(context.Background(), "Ann Lee signed the contract.")
+	if err != nil {
+		t.Fatalf("DetectNames() error: %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"Ann Lee"}) {
+		t.Errorf("DetectNames() = %q, want only names present in the text", names)
+	}
+	if len(prompts) != 1 || !strings.Contains(prompts[0], "Ann Lee signed") {
+		t.Errorf("prompts = %q", prompts)
+	}
+}
+
+func TestLLMNameDetector_BadReply(t *testing.T) {
+	detector := NewLLMNameDetector(func(ctx context.Context, prompt string) (string, error) {
+		return "I cannot help with that.", nil
+	})
+	if _, err := detector.DetectNames(context.Background(), "Ann"); err == nil {
+		t.Error("DetectNames() should fail on a reply without a JSON array")
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	text := strings.Repeat("word ", 10)
+	pieces := splitText(text, 12)
+	if got := len(strings.Fields(strings.Join(pieces, ""))); got != 10 {
+		t.Errorf("pieces hold %d words, want 10: %q", got, pieces)
+	}
+	for _, p := range pieces {
+		if len(p) > 12 {
+			t.Errorf("piece %q longer than 12 bytes", p)
+		}
+	}
+}
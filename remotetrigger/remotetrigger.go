@@ -0,0 +1,234 @@
+// Package remotetrigger lets operators drive a canvas from the dashboard:
+// it posts prompt notes at a chosen location, or runs the prompt of a
+// widget already on the canvas, without touching the wall.
+//
+// Architecture:
+//   - Wrap (atom): encloses a prompt in the canvas's trigger markers
+//   - Injector (organism): creates trigger notes and submits existing
+//     widgets to the canvas monitor
+package remotetrigger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go_backend/handlers"
+
+	"go.uber.org/zap"
+)
+
+// Errors returned by the Injector.
+var (
+	// ErrUnknownCanvas is returned for canvases that are not monitored
+	ErrUnknownCanvas = errors.New("remotetrigger: canvas is not monitored")
+	// ErrEmptyPrompt is returned when a note would have no prompt
+	ErrEmptyPrompt = errors.New("remotetrigger: prompt is empty")
+	// ErrNoPrompt is returned for widgets that have no AI task to run
+	ErrNoPrompt = errors.New("remotetrigger: widget has no prompt to submit")
+	// ErrProcessingPaused is returned while AI processing is paused
+	ErrProcessingPaused = errors.New("remotetrigger: AI processing is paused")
+)
+
+// Layout of injected notes.
+const (
+	noteWidth    = 400.0
+	noteHeight   = 300.0
+	placementGap = 200.0
+)
+
+// Wrap returns prompt enclosed in the trigger markers of syntax. A prompt
+// that already contains a trigger, such as "Summarize {{this}}", is
+// returned unchanged.
+func Wrap(syntax handlers.TriggerSyntax, prompt string) string {
+	prompt = strings.TrimSpace(prompt)
+	if syntax.HasTrigger(prompt) {
+		return prompt
+	}
+	return syntax.Open + prompt + syntax.Close
+}
+
+// Client reads and writes a canvas. Implemented by *canvusapi.Client.
+type Client interface {
+	GetWidgets(subscribe bool) ([]map[string]interface{}, error)
+	GetWidget(widgetID string, subscribe bool) (map[string]interface{}, error)
+	CreateNote(payload map[string]interface{}) (map[string]interface{}, error)
+}
+
+// SubmitFunc runs the AI task of a widget as if it had just changed on the
+// canvas. It returns ErrNoPrompt for widgets without one.
+type SubmitFunc func(canvasID string, widget map[string]interface{}) error
+
+// Request asks for a trigger note.
+type Request struct {
+	CanvasID string `json:"canvas_id,omitempty"`
+	// Prompt is enclosed in the canvas's trigger markers unless it already
+	// has a trigger
+	Prompt string `json:"prompt"`
+	Title  string `json:"title,omitempty"`
+	// X and Y place the note's top left corner (default: right of the
+	// canvas content)
+	X *float64 `json:"x,omitempty"`
+	Y *float64 `json:"y,omitempty"`
+}
+
+// Result describes the note created or the widget submitted.
+type Result struct {
+	CanvasID string  `json:"canvas_id"`
+	WidgetID string  `json:"widget_id"`
+	Text     string  `json:"text,omitempty"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+}
+
+// Injector is an organism that starts AI tasks on monitored canvases from
+// outside the wall.
+//
+// Usage:
+//
+//	injector := remotetrigger.New(clientFor, canvasIDs, syntaxFor, monitor.SubmitWidget, logger)
+//	result, err := injector.Inject(ctx, remotetrigger.Request{Prompt: "Draw a lighthouse"})
+//	result, err = injector.Submit(ctx, "", widgetID)
+type Injector struct {
+	clientFor func(canvasID string) Client
+	canvasIDs []string
+	syntaxFor func(canvasID string) handlers.TriggerSyntax
+	submit    SubmitFunc
+	logger    *zap.Logger
+}
+
+// New creates an Injector for canvasIDs. Notes are written with the client
+// clientFor returns and the trigger markers syntaxFor returns; existing
+// widgets are handed to submit.
+func New(clientFor func(canvasID string) Client, canvasIDs []string, syntaxFor func(canvasID string) handlers.TriggerSyntax, submit SubmitFunc, logger *zap.Logger) *Injector {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Injector{
+		clientFor: clientFor,
+		canvasIDs: canvasIDs,
+		syntaxFor: syntaxFor,
+		submit:    submit,
+		logger:    logger,
+	}
+}
+
+// CanvasIDs returns the canvases triggers can be sent to.
+func (i *Injector) CanvasIDs() []string {
+	return append([]string(nil), i.canvasIDs...)
+}
+
+// Inject posts a note holding the request's prompt. The canvas monitor
+// picks it up like a note written on the wall.
+func (i *Injector) Inject(ctx context.Context, req Request) (*Result, error) {
+	canvasID, err := i.canvas(req.CanvasID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Prompt) == "" {
+		return nil, ErrEmptyPrompt
+	}
+	text := Wrap(i.syntaxFor(canvasID), req.Prompt)
+	client := i.clientFor(canvasID)
+
+	var loc handlers.Location
+	if req.X != nil && req.Y != nil {
+		loc = handlers.Location{X: *req.X, Y: *req.Y}
+	} else if loc, err = freeLocation(client); err != nil {
+		return nil, err
+	}
+
+	payload := map[string]interface{}{
+		"text":     text,
+		"location": handlers.LocationToMap(loc),
+		"size":     handlers.SizeToMap(handlers.NoteSize{Width: noteWidth, Height: noteHeight}),
+	}
+	if req.Title != "" {
+		payload["title"] = req.Title
+	}
+	note, err := client.CreateNote(payload)
+	if err != nil {
+		return nil, fmt.Errorf("remotetrigger: failed to create note: %w", err)
+	}
+
+	result := &Result{CanvasID: canvasID, Text: text, X: loc.X, Y: loc.Y}
+	result.WidgetID, _ = note["id"].(string)
+	i.logger.Info("Remote trigger note created",
+		zap.String("canvas_id", canvasID),
+		zap.String("widget_id", result.WidgetID))
+	return result, nil
+}
+
+// Submit runs the prompt of a widget already on the canvas: a note's
+// trigger, or its whole text when it has none, a snapshot or an AI icon.
+// It works on notes that were processed before.
+func (i *Injector) Submit(ctx context.Context, canvasID, widgetID string) (*Result, error) {
+	canvasID, err := i.canvas(canvasID)
+	if err != nil {
+		return nil, err
+	}
+	if widgetID == "" {
+		return nil, fmt.Errorf("%w: no widget given", ErrNoPrompt)
+	}
+	widget, err := i.clientFor(canvasID).GetWidget(widgetID, false)
+	if err != nil {
+		return nil, fmt.Errorf("remotetrigger: failed to read widget: %w", err)
+	}
+	if err := i.submit(canvasID, widget); err != nil {
+		return nil, err
+	}
+
+	loc := handlers.ExtractLocation(handlers.GetMapField(widget, "location"))
+	i.logger.Info("Remote trigger submitted",
+		zap.String("canvas_id", canvasID),
+		zap.String("widget_id", widgetID))
+	return &Result{
+		CanvasID: canvasID,
+		WidgetID: widgetID,
+		Text:     handlers.GetStringField(widget, "text", ""),
+		X:        loc.X,
+		Y:        loc.Y,
+	}, nil
+}
+
+// canvas resolves an empty canvas ID to the first monitored canvas.
+func (i *Injector) canvas(canvasID string) (string, error) {
+	if canvasID == "" {
+		if len(i.canvasIDs) == 0 {
+			return "", ErrUnknownCanvas
+		}
+		return i.canvasIDs[0], nil
+	}
+	for _, id := range i.canvasIDs {
+		if id == canvasID {
+			return canvasID, nil
+		}
+	}
+	return "", ErrUnknownCanvas
+}
+
+// freeLocation returns a location right of every widget on the canvas,
+// level with the topmost one.
+func freeLocation(client Client) (handlers.Location, error) {
+	widgets, err := client.GetWidgets(false)
+	if err != nil {
+		return handlers.Location{}, fmt.Errorf("remotetrigger: failed to fetch widgets: %w", err)
+	}
+	var loc handlers.Location
+	found := false
+	for _, w := range widgets {
+		if handlers.GetStringField(w, "widget_type", "") == "SharedCanvas" {
+			continue
+		}
+		b := handlers.WidgetBounds(w, nil)
+		if !found || b.X+b.Width+placementGap > loc.X {
+			loc.X = b.X + b.Width + placementGap
+		}
+		if !found || b.Y < loc.Y {
+			loc.Y = b.Y
+		}
+		found = true
+	}
+	return loc, nil
+}
@@ -0,0 +1,117 @@
+package remotetrigger
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go_backend/handlers"
+)
+
+type fakeClient struct {
+	widgets []map[string]interface{}
+	created []map[string]interface{}
+}
+
+func (c *fakeClient) GetWidgets(bool) ([]map[string]interface{}, error) {
+	return c.widgets, nil
+}
+
+func (c *fakeClient) GetWidget(id string, _ bool) (map[string]interface{}, error) {
+	for _, w := range c.widgets {
+		if w["id"] == id {
+			return w, nil
+		}
+	}
+	return nil, errors.New("widget not found")
+}
+
+func (c *fakeClient) CreateNote(payload map[string]interface{}) (map[string]interface{}, error) {
+	c.created = append(c.created, payload)
+	return map[string]interface{}{"id": "new-note"}, nil
+}
+
+func newTestInjector(client *fakeClient, submit SubmitFunc) *Injector {
+	return New(func(string) Client { return client }, []string{"c1"},
+		func(string) handlers.TriggerSyntax { return handlers.NewTriggerSyntax("[[", "]]") }, submit, nil)
+}
+
+func TestWrap(t *testing.T) {
+	syntax := handlers.DefaultTriggerSyntax
+	for prompt, want := range map[string]string{
+		"Draw a lighthouse":       "{{Draw a lighthouse}}",
+		"  padded\n":              "{{padded}}",
+		"Summarize {{this}}":      "Summarize {{this}}",
+		"{{image: a lighthouse}}": "{{image: a lighthouse}}",
+	} {
+		if got := Wrap(syntax, prompt); got != want {
+			t.Errorf("Wrap(%q) = %q, want %q", prompt, got, want)
+		}
+	}
+}
+
+func TestInject(t *testing.T) {
+	client := &fakeClient{widgets: []map[string]interface{}{
+		{"id": "canvas", "widget_type": "SharedCanvas", "location": map[string]interface{}{"x": 0.0, "y": 0.0}, "size": map[string]interface{}{"width": 9000.0, "height": 9000.0}},
+		{"id": "n1", "widget_type": "Note", "location": map[string]interface{}{"x": 100.0, "y": 50.0}, "size": map[string]interface{}{"width": 300.0, "height": 200.0}},
+	}}
+	injector := newTestInjector(client, nil)
+
+	result, err := injector.Inject(context.Background(), Request{Prompt: "Draw a lighthouse", Title: "Demo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.CanvasID != "c1" || result.WidgetID != "new-note" || result.Text != "[[Draw a lighthouse]]" {
+		t.Errorf("result = %+v", result)
+	}
+	if result.X != 600 || result.Y != 50 {
+		t.Errorf("location = (%v, %v), want right of the content at (600, 50)", result.X, result.Y)
+	}
+	if client.created[0]["title"] != "Demo" {
+		t.Errorf("payload = %v", client.created[0])
+	}
+
+	x, y := -20.0, 40.0
+	result, err = injector.Inject(context.Background(), Request{CanvasID: "c1", Prompt: "Hi", X: &x, Y: &y})
+	if err != nil || result.X != -20 || result.Y != 40 {
+		t.Errorf("chosen location: %+v %v", result, err)
+	}
+
+	if _, err := injector.Inject(context.Background(), Request{Prompt: " "}); !errors.Is(err, ErrEmptyPrompt) {
+		t.Errorf("empty prompt: err = %v", err)
+	}
+	if _, err := injector.Inject(context.Background(), Request{CanvasID: "other", Prompt: "Hi"}); !errors.Is(err, ErrUnknownCanvas) {
+		t.Errorf("unknown canvas: err = %v", err)
+	}
+}
+
+func TestSubmit(t *testing.T) {
+	client := &fakeClient{widgets: []map[string]interface{}{
+		{"id": "n1", "widget_type": "Note", "text": "{{Hi}}", "location": map[string]interface{}{"x": 10.0, "y": 20.0}},
+	}}
+	var submitted []string
+	injector := newTestInjector(client, func(canvasID string, widget map[string]interface{}) error {
+		submitted = append(submitted, canvasID+"/"+widget["id"].(string))
+		return nil
+	})
+
+	result, err := injector.Submit(context.Background(), "", "n1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submitted) != 1 || submitted[0] != "c1/n1" || result.X != 10 || result.Text != "{{Hi}}" {
+		t.Errorf("submitted = %v, result = %+v", submitted, result)
+	}
+
+	if _, err := injector.Submit(context.Background(), "", "missing"); err == nil {
+		t.Error("missing widget submitted")
+	}
+	if _, err := injector.Submit(context.Background(), "", ""); !errors.Is(err, ErrNoPrompt) {
+		t.Errorf("no widget: err = %v", err)
+	}
+
+	refuse := newTestInjector(client, func(string, map[string]interface{}) error { return ErrNoPrompt })
+	if _, err := refuse.Submit(context.Background(), "", "n1"); !errors.Is(err, ErrNoPrompt) {
+		t.Errorf("refused: err = %v", err)
+	}
+}
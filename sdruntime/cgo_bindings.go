@@ -17,6 +17,10 @@
 // Example build without library (stub mode):
 //
 //	go build -tags stub
+//
+// Example build that generates deterministic placeholder images without a GPU:
+//
+//	go build -tags sdfake
 package sdruntime
 
 // SDContext represents an opaque handle to a stable-diffusion context.
@@ -0,0 +1,196 @@
+//go:build sdfake && !stub
+
+// Fake implementation of CGo bindings that synthesizes deterministic
+// placeholder images instead of running a model. It lets CI and demo machines
+// without a GPU exercise the whole image generation pipeline.
+// Build with: go build -tags sdfake
+//
+// The image for a prompt and seed is always the same: the background color
+// is derived from both, and the prompt text is drawn onto it.
+
+package sdruntime
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// fakeBackendInfo is reported by GetBackendInfo in sdfake builds.
+const fakeBackendInfo = "sdfake (deterministic placeholder images)"
+
+// fakeTextWidth is the width in pixels the prompt is laid out at before the
+// image is scaled up to the requested size.
+const fakeTextWidth = 256
+
+// fakeContextCounter generates unique IDs for fake contexts
+var fakeContextCounter uint64
+
+// loadModelImpl is the fake implementation of LoadModel.
+// Like the stub, it checks the model path exists but loads nothing, so any
+// placeholder file can stand in for a model.
+func loadModelImpl(modelPath string) (*SDContext, error) {
+	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, modelPath)
+	} else if err != nil {
+		return nil, fmt.Errorf("%w: unable to access %s: %v", ErrModelLoadFailed, modelPath, err)
+	}
+
+	return &SDContext{
+		id:        atomic.AddUint64(&fakeContextCounter, 1),
+		modelPath: modelPath,
+		valid:     true,
+	}, nil
+}
+
+// generateImageImpl is the fake implementation of GenerateImage.
+// It renders the prompt onto a background colored by the prompt and seed. A
+// seed of -1 is replaced by one derived from the prompt so the result stays
+// reproducible.
+func generateImageImpl(ctx *SDContext, params GenerateParams) (*GenerateResult, error) {
+	if ctx == nil || !ctx.valid {
+		return nil, fmt.Errorf("%w: context is nil or invalid", ErrGenerationFailed)
+	}
+
+	seed := params.Seed
+	if seed < 0 {
+		seed = fakeSeed(params.Prompt)
+	}
+
+	data, err := renderPlaceholder(params.Prompt, seed, params.Width, params.Height)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrGenerationFailed, err)
+	}
+
+	return &GenerateResult{
+		ImageData: data,
+		Width:     params.Width,
+		Height:    params.Height,
+		Seed:      seed,
+	}, nil
+}
+
+// freeContextImpl is the fake implementation of FreeContext.
+// It marks the context as invalid.
+func freeContextImpl(ctx *SDContext) {
+	if ctx == nil {
+		return
+	}
+	ctx.valid = false
+}
+
+// Bindings names the image generation bindings compiled in.
+const Bindings = "sdfake"
+
+// getBackendInfoImpl returns backend info for sdfake mode.
+func getBackendInfoImpl() string {
+	return fakeBackendInfo
+}
+
+// fakeSeed derives a non-negative seed from the prompt.
+func fakeSeed(prompt string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(prompt))
+	return int64(h.Sum64() >> 1)
+}
+
+// renderPlaceholder draws the prompt onto a colored background and encodes
+// the result as PNG. The text is laid out on a small canvas and scaled up so
+// it stays readable at any image size.
+func renderPlaceholder(prompt string, seed int64, width, height int) ([]byte, error) {
+	scale := width / fakeTextWidth
+	if scale < 1 {
+		scale = 1
+	}
+	small := image.NewRGBA(image.Rect(0, 0, width/scale, height/scale))
+
+	bg, fg := placeholderColors(prompt, seed)
+	draw.Draw(small, small.Bounds(), image.NewUniform(bg), image.Point{}, draw.Src)
+
+	face := basicfont.Face7x13
+	margin := 8
+	lineHeight := face.Metrics().Height.Ceil()
+	drawer := &font.Drawer{Dst: small, Src: image.NewUniform(fg), Face: face}
+	maxLines := (small.Bounds().Dy() - 2*margin) / lineHeight
+	for i, line := range wrapText(drawer, prompt, small.Bounds().Dx()-2*margin) {
+		if i >= maxLines {
+			break
+		}
+		drawer.Dot = fixed.P(margin, margin+face.Metrics().Ascent.Ceil()+i*lineHeight)
+		drawer.DrawString(line)
+	}
+
+	img := small
+	if scale > 1 {
+		img = image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.NearestNeighbor.Scale(img, img.Bounds(), small, small.Bounds(), draw.Src, nil)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// placeholderColors picks a background color from the prompt and seed, and
+// black or white text, whichever contrasts more.
+func placeholderColors(prompt string, seed int64) (bg, fg color.RGBA) {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%d:%s", seed, prompt)
+	sum := h.Sum32()
+	bg = color.RGBA{R: uint8(sum >> 16), G: uint8(sum >> 8), B: uint8(sum), A: 255}
+
+	luma := 299*int(bg.R) + 587*int(bg.G) + 114*int(bg.B)
+	if luma > 128*1000 {
+		return bg, color.RGBA{A: 255}
+	}
+	return bg, color.RGBA{R: 255, G: 255, B: 255, A: 255}
+}
+
+// wrapText splits text into lines no wider than maxWidth pixels. Words
+// longer than a line are broken.
+func wrapText(drawer *font.Drawer, text string, maxWidth int) []string {
+	fits := func(s string) bool { return drawer.MeasureString(s).Ceil() <= maxWidth }
+
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(text) {
+		for runes := []rune(word); !fits(word) && len(runes) > 1; runes = []rune(word) {
+			cut := len(runes) - 1
+			for cut > 1 && !fits(string(runes[:cut])) {
+				cut--
+			}
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, string(runes[:cut]))
+			word = string(runes[cut:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case fits(line + " " + word):
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,106 @@
+//go:build sdfake && !stub
+
+package sdruntime
+
+import (
+	"bytes"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+)
+
+func loadFakeModel(t *testing.T) *SDContext {
+	t.Helper()
+	modelPath := filepath.Join(t.TempDir(), "fake_model.safetensors")
+	if err := os.WriteFile(modelPath, []byte("fake"), 0644); err != nil {
+		t.Fatalf("failed to create fake model file: %v", err)
+	}
+	ctx, err := LoadModel(modelPath)
+	if err != nil {
+		t.Fatalf("LoadModel() error = %v", err)
+	}
+	t.Cleanup(func() { FreeContext(ctx) })
+	return ctx
+}
+
+func TestFakeGenerateImage(t *testing.T) {
+	ctx := loadFakeModel(t)
+	params := GenerateParams{
+		Prompt:   "a lighthouse on a rocky coast at sunset, oil painting",
+		Width:    512,
+		Height:   384,
+		Steps:    20,
+		CFGScale: 7.5,
+		Seed:     42,
+	}
+
+	result, err := GenerateImage(ctx, params)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+	if err := ValidateImageData(result.ImageData); err != nil {
+		t.Fatalf("ValidateImageData() error = %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(result.ImageData))
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 512 || b.Dy() != 384 {
+		t.Errorf("image size = %dx%d, want 512x384", b.Dx(), b.Dy())
+	}
+	if result.Seed != 42 {
+		t.Errorf("Seed = %d, want 42", result.Seed)
+	}
+
+	// The same prompt and seed give the same image
+	again, err := GenerateImage(ctx, params)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+	if !bytes.Equal(result.ImageData, again.ImageData) {
+		t.Error("same prompt and seed produced different images")
+	}
+
+	// A different prompt or seed gives a different image
+	other := params
+	other.Prompt = "a red bicycle"
+	if r, _ := GenerateImage(ctx, other); r == nil || bytes.Equal(r.ImageData, result.ImageData) {
+		t.Error("different prompt produced the same image")
+	}
+	other = params
+	other.Seed = 7
+	if r, _ := GenerateImage(ctx, other); r == nil || bytes.Equal(r.ImageData, result.ImageData) {
+		t.Error("different seed produced the same image")
+	}
+}
+
+func TestFakeGenerateImageDerivesSeed(t *testing.T) {
+	ctx := loadFakeModel(t)
+	params := GenerateParams{Prompt: "test", Width: 128, Height: 128, Steps: 1, CFGScale: 1, Seed: -1}
+
+	first, err := GenerateImage(ctx, params)
+	if err != nil {
+		t.Fatalf("GenerateImage() error = %v", err)
+	}
+	second, _ := GenerateImage(ctx, params)
+	if first.Seed < 0 || first.Seed != second.Seed || !bytes.Equal(first.ImageData, second.ImageData) {
+		t.Errorf("seed -1 should derive the same seed from the prompt, got %d and %d", first.Seed, second.Seed)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	drawer := &font.Drawer{Face: basicfont.Face7x13}
+	lines := wrapText(drawer, "supercalifragilisticexpialidocious word", 70)
+	for _, line := range lines {
+		if w := drawer.MeasureString(line).Ceil(); w > 70 {
+			t.Errorf("line %q is %dpx wide, want at most 70", line, w)
+		}
+	}
+	if len(lines) < 3 {
+		t.Errorf("wrapText() = %q, want the long word broken over several lines", lines)
+	}
+}
@@ -1,5 +1,5 @@
-//go:build sd && cgo && !stub
-// +build sd,cgo,!stub
+//go:build sd && cgo && !stub && !sdfake
+// +build sd,cgo,!stub,!sdfake
 
 // Real CGo implementation of stable-diffusion.cpp bindings.
 // Build with: CGO_ENABLED=1 go build -tags sd
@@ -209,6 +209,10 @@ func freeContextImpl(ctx *SDContext) {
 	ctx.valid = false
 }
 
+// Bindings names the image generation bindings compiled in: "sd" when
+// stable-diffusion.cpp is linked.
+const Bindings = "sd"
+
 // getBackendInfoImpl returns backend info from the C library.
 func getBackendInfoImpl() string {
 	cInfo := C.sd_get_backend_info()
@@ -1,8 +1,8 @@
-//go:build !sd || stub
+//go:build (!sd && !sdfake) || stub
 
 // Stub implementation of CGo bindings for when stable-diffusion.cpp is not available.
 // Build with: go build -tags stub
-// Or simply build without the "sd" tag: go build
+// Or simply build without the "sd" or "sdfake" tag: go build
 
 package sdruntime
 
@@ -56,6 +56,9 @@ func freeContextImpl(ctx *SDContext) {
 	ctx.valid = false
 }
 
+// Bindings names the image generation bindings compiled in.
+const Bindings = "stub"
+
 // getBackendInfoImpl returns backend info for stub mode.
 func getBackendInfoImpl() string {
 	return "stub (no stable-diffusion.cpp library linked)"
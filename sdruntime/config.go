@@ -15,8 +15,9 @@ type SDConfig struct {
 	NegativePrompt string  // Default negative prompt
 
 	// Runtime configuration
-	Timeout       time.Duration // Generation timeout
-	MaxConcurrent int           // Maximum concurrent generations
+	Timeout         time.Duration   // Generation timeout
+	MaxConcurrent   int             // Maximum concurrent generations
+	VRAMRetryLadder []DowngradeStep // Smaller settings to retry with after ErrOutOfVRAM
 
 	// Model configuration
 	ModelPath string // Path to SD model file
@@ -34,7 +35,7 @@ const (
 // LoadSDConfig loads SD configuration from environment variables.
 // This is a pure parsing function that reads from env vars.
 func LoadSDConfig() *SDConfig {
-	return &SDConfig{
+	cfg := &SDConfig{
 		ImageSize:      parseImageSize(os.Getenv("SD_IMAGE_SIZE")),
 		InferenceSteps: parseInferenceSteps(os.Getenv("SD_INFERENCE_STEPS")),
 		GuidanceScale:  parseGuidanceScale(os.Getenv("SD_GUIDANCE_SCALE")),
@@ -43,6 +44,23 @@ func LoadSDConfig() *SDConfig {
 		MaxConcurrent:  parseMaxConcurrent(os.Getenv("SD_MAX_CONCURRENT")),
 		ModelPath:      os.Getenv("SD_MODEL_PATH"),
 	}
+	cfg.VRAMRetryLadder = parseRetryLadder(os.Getenv("SD_VRAM_RETRY_LADDER"), cfg.ImageSize, cfg.InferenceSteps)
+	return cfg
+}
+
+// parseRetryLadder parses the VRAM retry ladder from string.
+// Returns the default ladder for size and steps if invalid or empty.
+func parseRetryLadder(s string, size, steps int) []DowngradeStep {
+	if s == "" {
+		return DefaultDowngradeLadder(size, steps)
+	}
+
+	ladder, err := ParseDowngradeLadder(s)
+	if err != nil {
+		return DefaultDowngradeLadder(size, steps)
+	}
+
+	return ladder
 }
 
 // parseImageSize parses and validates image size from string.
@@ -8,6 +8,9 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
+
+	"go_backend/core/clock"
 )
 
 // PooledContext wraps an SDContext with pool management metadata.
@@ -42,6 +45,8 @@ type ContextPool struct {
 	closed    bool
 	created   int // tracks number of contexts created
 	nextID    int // next pool ID to assign
+	lastUsed  time.Time
+	clock     clock.Clock // stamps lastUsed
 }
 
 // NewContextPool creates a new context pool with the specified maximum size.
@@ -64,6 +69,8 @@ func NewContextPool(maxSize int, modelPath string) (*ContextPool, error) {
 		closed:    false,
 		created:   0,
 		nextID:    1,
+		lastUsed:  time.Now(),
+		clock:     clock.Real(),
 	}, nil
 }
 
@@ -202,6 +209,7 @@ func (p *ContextPool) Release(pc *PooledContext) {
 	defer p.mu.Unlock()
 
 	pc.inUse = false
+	p.lastUsed = p.clock.Now()
 
 	if p.closed {
 		// Pool is closed, free the context instead of returning it
@@ -246,6 +254,75 @@ func (p *ContextPool) Close() error {
 	return nil
 }
 
+// Warmup renders a tiny throwaway image so the first real request does not
+// pay for model loading and CUDA kernel compilation. The warmed context stays
+// in the pool for reuse.
+func (p *ContextPool) Warmup(ctx context.Context) error {
+	_, err := p.Generate(ctx, GenerateParams{
+		Prompt:   "warmup",
+		Width:    MinImageSize * 2,
+		Height:   MinImageSize * 2,
+		Steps:    MinSteps,
+		CFGScale: MinCFGScale,
+		Seed:     0,
+	})
+	if err != nil {
+		return fmt.Errorf("warmup: %w", err)
+	}
+	return nil
+}
+
+// Unload frees all idle contexts to release their VRAM.
+// Contexts currently in use are untouched; freed contexts are lazily
+// recreated by the next Acquire. Returns true if any context was freed.
+func (p *ContextPool) Unload() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return false
+	}
+
+	freed := 0
+	for {
+		select {
+		case pc := <-p.contexts:
+			FreeContext(pc.SDContext)
+			p.created--
+			freed++
+		default:
+			return freed > 0
+		}
+	}
+}
+
+// IsLoaded returns true if at least one context (and thus the model) is
+// resident in memory.
+func (p *ContextPool) IsLoaded() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.created > 0
+}
+
+// LastUsed returns when a context was last released back to the pool.
+// For a pool that has never been used, this is the time SetClock was last
+// called, or the pool creation time.
+func (p *ContextPool) LastUsed() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastUsed
+}
+
+// SetClock sets the clock that stamps LastUsed (default: clock.Real). The
+// pool counts as used at the time it is set, so a pool is not idle on a
+// new clock before it was ever used.
+func (p *ContextPool) SetClock(c clock.Clock) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clock = clock.OrReal(c)
+	p.lastUsed = p.clock.Now()
+}
+
 // Size returns the number of contexts currently available in the pool.
 // This does not include contexts that are currently acquired.
 func (p *ContextPool) Size() int {
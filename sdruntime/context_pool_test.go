@@ -3,6 +3,8 @@ package sdruntime
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -454,3 +456,63 @@ func TestContextPoolGenerateTimeout(t *testing.T) {
 	// Release the context
 	pool.Release(pc)
 }
+
+// TestContextPoolUnload tests that idle contexts are freed and lazily recreated.
+func TestContextPoolUnload(t *testing.T) {
+	modelPath := filepath.Join(t.TempDir(), "model.safetensors")
+	if err := os.WriteFile(modelPath, []byte("stub"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pool, err := NewContextPool(2, modelPath)
+	if err != nil {
+		t.Fatalf("NewContextPool() failed: %v", err)
+	}
+	defer pool.Close()
+
+	if pool.IsLoaded() {
+		t.Error("new pool should not be loaded before first Acquire")
+	}
+	if pool.Unload() {
+		t.Error("Unload() on empty pool should return false")
+	}
+
+	before := pool.LastUsed()
+	pc, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire() failed: %v", err)
+	}
+	if !pool.IsLoaded() {
+		t.Error("pool should be loaded after Acquire")
+	}
+
+	// In-use contexts are not freed
+	if pool.Unload() {
+		t.Error("Unload() should not free in-use contexts")
+	}
+
+	pool.Release(pc)
+	if pool.LastUsed().Before(before) {
+		t.Error("LastUsed() should advance on Release")
+	}
+
+	if !pool.Unload() {
+		t.Error("Unload() should free the idle context")
+	}
+	if pool.IsLoaded() || pool.Created() != 0 || pool.Size() != 0 {
+		t.Errorf("after Unload: loaded=%v created=%d size=%d", pool.IsLoaded(), pool.Created(), pool.Size())
+	}
+	if pc.SDContext.IsValid() {
+		t.Error("unloaded context should be invalid")
+	}
+
+	// Next Acquire reloads lazily
+	pc, err = pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire() after Unload failed: %v", err)
+	}
+	pool.Release(pc)
+	if pool.Created() != 1 {
+		t.Errorf("Created() = %d, want 1 after reload", pool.Created())
+	}
+}
@@ -0,0 +1,159 @@
+package sdruntime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DowngradeStep is one rung of the VRAM retry ladder: the image size and
+// step count to retry with after a generation fails with ErrOutOfVRAM.
+type DowngradeStep struct {
+	Width  int // Image width in pixels
+	Height int // Image height in pixels
+	Steps  int // Inference steps (0 keeps the requested count)
+}
+
+// String formats the step as "384x384" or "384x384:15".
+func (s DowngradeStep) String() string {
+	if s.Steps > 0 {
+		return fmt.Sprintf("%dx%d:%d", s.Width, s.Height, s.Steps)
+	}
+	return fmt.Sprintf("%dx%d", s.Width, s.Height)
+}
+
+// Apply returns params with the step's size and step count.
+func (s DowngradeStep) Apply(params GenerateParams) GenerateParams {
+	params.Width = s.Width
+	params.Height = s.Height
+	if s.Steps > 0 {
+		params.Steps = s.Steps
+	}
+	return params
+}
+
+// reduces reports whether the step uses a smaller image or fewer steps
+// than params.
+func (s DowngradeStep) reduces(params GenerateParams) bool {
+	applied := s.Apply(params)
+	return applied.Width*applied.Height < params.Width*params.Height || applied.Steps < params.Steps
+}
+
+// DefaultDowngradeLadder returns the ladder used when none is configured:
+// three quarters and then half of the image size, with the step count
+// reduced in the same proportion. Sizes are rounded down to a multiple of
+// 8 and never below MinImageSize.
+func DefaultDowngradeLadder(size, steps int) []DowngradeStep {
+	var ladder []DowngradeStep
+	last := size
+	for _, f := range []struct{ num, den int }{{3, 4}, {1, 2}} {
+		s := size * f.num / f.den / ImageSizeMultple * ImageSizeMultple
+		if s < MinImageSize {
+			s = MinImageSize
+		}
+		if s >= last {
+			continue
+		}
+		st := steps * f.num / f.den
+		if st < MinSteps {
+			st = MinSteps
+		}
+		ladder = append(ladder, DowngradeStep{Width: s, Height: s, Steps: st})
+		last = s
+	}
+	return ladder
+}
+
+// ParseDowngradeLadder parses a comma-separated ladder such as
+// "384x384:15,256x256:10"; the step counts are optional. "off" returns an
+// empty ladder, which disables retries.
+func ParseDowngradeLadder(s string) ([]DowngradeStep, error) {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "off") {
+		return []DowngradeStep{}, nil
+	}
+
+	var ladder []DowngradeStep
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		step, err := parseDowngradeStep(part)
+		if err != nil {
+			return nil, err
+		}
+		ladder = append(ladder, step)
+	}
+	return ladder, nil
+}
+
+// parseDowngradeStep parses one "WxH" or "WxH:steps" rung.
+func parseDowngradeStep(s string) (DowngradeStep, error) {
+	size, stepsStr, hasSteps := strings.Cut(s, ":")
+	w, h, ok := strings.Cut(strings.ToLower(size), "x")
+	if !ok {
+		return DowngradeStep{}, fmt.Errorf("%w: retry step %q must look like 384x384 or 384x384:15", ErrInvalidParams, s)
+	}
+
+	var step DowngradeStep
+	var err error
+	if step.Width, err = strconv.Atoi(strings.TrimSpace(w)); err != nil {
+		return DowngradeStep{}, fmt.Errorf("%w: retry step %q has an invalid width", ErrInvalidParams, s)
+	}
+	if step.Height, err = strconv.Atoi(strings.TrimSpace(h)); err != nil {
+		return DowngradeStep{}, fmt.Errorf("%w: retry step %q has an invalid height", ErrInvalidParams, s)
+	}
+	if hasSteps {
+		if step.Steps, err = strconv.Atoi(strings.TrimSpace(stepsStr)); err != nil || step.Steps < MinSteps || step.Steps > MaxSteps {
+			return DowngradeStep{}, fmt.Errorf("%w: retry step %q steps must be between %d and %d", ErrInvalidParams, s, MinSteps, MaxSteps)
+		}
+	}
+	for _, n := range []int{step.Width, step.Height} {
+		if n < MinImageSize || n > MaxImageSize || n%ImageSizeMultple != 0 {
+			return DowngradeStep{}, fmt.Errorf("%w: retry step %q sizes must be between %d and %d and divisible by %d",
+				ErrInvalidParams, s, MinImageSize, MaxImageSize, ImageSizeMultple)
+		}
+	}
+	return step, nil
+}
+
+// GenerateFunc generates an image from params.
+// ContextPool.Generate and Generator.Generate satisfy it.
+type GenerateFunc func(ctx context.Context, params GenerateParams) ([]byte, error)
+
+// GenerateWithDowngrade calls generate with params and, while it fails with
+// ErrOutOfVRAM, retries with each rung of ladder in turn. Rungs that would
+// not reduce the size or step count of the previous attempt are skipped.
+// onRetry, if not nil, is called before each retry.
+//
+// Returns the image and the parameters that produced it, which differ from
+// params when a retry succeeded. On failure the last error is returned.
+func GenerateWithDowngrade(ctx context.Context, generate GenerateFunc, params GenerateParams, ladder []DowngradeStep, onRetry func(step DowngradeStep, err error)) ([]byte, GenerateParams, error) {
+	data, err := generate(ctx, params)
+	if err == nil || !errors.Is(err, ErrOutOfVRAM) {
+		return data, params, err
+	}
+
+	last := params
+	for _, step := range ladder {
+		if !step.reduces(last) {
+			continue
+		}
+		if onRetry != nil {
+			onRetry(step, err)
+		}
+		attempt := step.Apply(params)
+		data, err = generate(ctx, attempt)
+		if err == nil {
+			return data, attempt, nil
+		}
+		if !errors.Is(err, ErrOutOfVRAM) {
+			return nil, attempt, err
+		}
+		last = attempt
+	}
+	return nil, last, err
+}
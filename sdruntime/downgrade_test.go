@@ -0,0 +1,101 @@
+package sdruntime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestDefaultDowngradeLadder(t *testing.T) {
+	tests := []struct {
+		size, steps int
+		want        []DowngradeStep
+	}{
+		{512, 20, []DowngradeStep{{384, 384, 15}, {256, 256, 10}}},
+		{1024, 30, []DowngradeStep{{768, 768, 22}, {512, 512, 15}}},
+		{200, 1, []DowngradeStep{{144, 144, 1}, {128, 128, 1}}},
+		{128, 20, nil},
+	}
+	for _, tt := range tests {
+		if got := DefaultDowngradeLadder(tt.size, tt.steps); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DefaultDowngradeLadder(%d, %d) = %v, want %v", tt.size, tt.steps, got, tt.want)
+		}
+	}
+}
+
+func TestParseDowngradeLadder(t *testing.T) {
+	got, err := ParseDowngradeLadder(" 384x384:15, 256X256 ,")
+	if err != nil {
+		t.Fatalf("ParseDowngradeLadder() error: %v", err)
+	}
+	if want := []DowngradeStep{{384, 384, 15}, {256, 256, 0}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseDowngradeLadder() = %v, want %v", got, want)
+	}
+
+	if got, err := ParseDowngradeLadder("off"); err != nil || got == nil || len(got) != 0 {
+		t.Errorf("ParseDowngradeLadder(off) = %v, %v; want an empty ladder", got, err)
+	}
+
+	for _, bad := range []string{"384", "384x", "384x384:0", "100x100", "385x384", "384x384:fast"} {
+		if _, err := ParseDowngradeLadder(bad); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("ParseDowngradeLadder(%q) = %v, want ErrInvalidParams", bad, err)
+		}
+	}
+}
+
+func TestParseRetryLadder(t *testing.T) {
+	if got := parseRetryLadder("", 512, 20); len(got) != 2 {
+		t.Errorf("parseRetryLadder(\"\") = %v, want the default ladder", got)
+	}
+	if got := parseRetryLadder("bogus", 512, 20); len(got) != 2 {
+		t.Errorf("parseRetryLadder(bogus) = %v, want the default ladder", got)
+	}
+	if got := parseRetryLadder("off", 512, 20); len(got) != 0 {
+		t.Errorf("parseRetryLadder(off) = %v, want no retries", got)
+	}
+}
+
+func TestGenerateWithDowngrade(t *testing.T) {
+	params := GenerateParams{Prompt: "a cat", Width: 512, Height: 512, Steps: 20}
+	ladder := []DowngradeStep{{512, 512, 0}, {384, 384, 15}, {448, 448, 0}, {256, 256, 10}}
+
+	var tried []string
+	generate := func(fits int) GenerateFunc {
+		return func(ctx context.Context, p GenerateParams) ([]byte, error) {
+			tried = append(tried, fmt.Sprintf("%dx%d:%d", p.Width, p.Height, p.Steps))
+			if p.Width > fits {
+				return nil, fmt.Errorf("generate image: %w", ErrOutOfVRAM)
+			}
+			return []byte("png"), nil
+		}
+	}
+
+	var retries []DowngradeStep
+	data, used, err := GenerateWithDowngrade(context.Background(), generate(256), params, ladder,
+		func(step DowngradeStep, err error) { retries = append(retries, step) })
+	if err != nil || string(data) != "png" {
+		t.Fatalf("GenerateWithDowngrade() = %q, %v", data, err)
+	}
+	if want := []string{"512x512:20", "384x384:15", "256x256:10"}; !reflect.DeepEqual(tried, want) {
+		t.Errorf("tried %v, want %v (rungs that do not reduce are skipped)", tried, want)
+	}
+	if used.Width != 256 || used.Steps != 10 || used.Prompt != "a cat" || len(retries) != 2 {
+		t.Errorf("used %+v after %d retries", used, len(retries))
+	}
+
+	tried = nil
+	if _, _, err := GenerateWithDowngrade(context.Background(), generate(128), params, ladder, nil); !errors.Is(err, ErrOutOfVRAM) {
+		t.Errorf("GenerateWithDowngrade() = %v, want ErrOutOfVRAM once the ladder is exhausted", err)
+	}
+
+	tried = nil
+	failing := func(ctx context.Context, p GenerateParams) ([]byte, error) {
+		tried = append(tried, "x")
+		return nil, ErrGenerationFailed
+	}
+	if _, _, err := GenerateWithDowngrade(context.Background(), failing, params, ladder, nil); !errors.Is(err, ErrGenerationFailed) || len(tried) != 1 {
+		t.Errorf("GenerateWithDowngrade() = %v after %d attempts, want other errors returned without retry", err, len(tried))
+	}
+}
@@ -1,27 +1,31 @@
 // Package sdruntime provides Stable Diffusion image generation capabilities.
 package sdruntime
 
-import "errors"
+import (
+	"errors"
+
+	"go_backend/core/errs"
+)
 
 // Sentinel errors for SD runtime operations.
 // These are domain-specific errors that provide clear failure modes.
 var (
 	// Model-related errors
-	ErrModelNotFound   = errors.New("sdruntime: model file not found")
-	ErrModelLoadFailed = errors.New("sdruntime: failed to load model")
-	ErrModelCorrupted  = errors.New("sdruntime: model file is corrupted or invalid")
+	ErrModelNotFound   = errs.New(errs.CodeModelMissing, "sdruntime: model file not found")
+	ErrModelLoadFailed = errs.New(errs.CodeModelMissing, "sdruntime: failed to load model")
+	ErrModelCorrupted  = errs.New(errs.CodeModelMissing, "sdruntime: model file is corrupted or invalid")
 
 	// Generation errors
-	ErrGenerationFailed  = errors.New("sdruntime: image generation failed")
-	ErrGenerationTimeout = errors.New("sdruntime: image generation timed out")
+	ErrGenerationFailed  = errs.New(errs.CodeImageGeneration, "sdruntime: image generation failed")
+	ErrGenerationTimeout = errs.New(errs.CodeTimeout, "sdruntime: image generation timed out")
 
 	// Input validation errors
-	ErrInvalidPrompt = errors.New("sdruntime: invalid prompt")
-	ErrInvalidParams = errors.New("sdruntime: invalid generation parameters")
+	ErrInvalidPrompt = errs.New(errs.CodeInvalidPrompt, "sdruntime: invalid prompt")
+	ErrInvalidParams = errs.New(errs.CodeImageGeneration, "sdruntime: invalid generation parameters")
 
 	// Hardware/resource errors
-	ErrCUDANotAvailable = errors.New("sdruntime: CUDA not available")
-	ErrOutOfVRAM        = errors.New("sdruntime: out of VRAM")
+	ErrCUDANotAvailable = errs.New(errs.CodeGPUUnavailable, "sdruntime: CUDA not available")
+	ErrOutOfVRAM        = errs.New(errs.CodeOutOfVRAM, "sdruntime: out of VRAM")
 
 	// Context pool errors
 	ErrContextPoolClosed = errors.New("sdruntime: context pool is closed")
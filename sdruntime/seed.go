@@ -3,12 +3,30 @@ package sdruntime
 import (
 	"crypto/rand"
 	"encoding/binary"
+	"sync/atomic"
 )
 
+// fixedSeed replaces random seeds when it is not negative.
+var fixedSeed atomic.Int64
+
+func init() {
+	fixedSeed.Store(-1)
+}
+
+// SetFixedSeed makes RandomSeed return seed, so images requested with seed
+// -1 repeat between runs (TEST_DETERMINISTIC). A negative seed restores
+// random seeds.
+func SetFixedSeed(seed int64) {
+	fixedSeed.Store(seed)
+}
+
 // RandomSeed generates a cryptographically secure random seed for image generation.
 // Returns a non-negative int64 value suitable for reproducible image generation.
 // This function uses crypto/rand for security.
 func RandomSeed() int64 {
+	if seed := fixedSeed.Load(); seed >= 0 {
+		return seed
+	}
 	var buf [8]byte
 	_, err := rand.Read(buf[:])
 	if err != nil {
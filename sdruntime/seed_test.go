@@ -36,3 +36,16 @@ func TestRandomSeed_ValidRange(t *testing.T) {
 		t.Errorf("seed must be >= 0, got: %d", seed)
 	}
 }
+
+func TestSetFixedSeed(t *testing.T) {
+	SetFixedSeed(42)
+	defer SetFixedSeed(-1)
+	if a, b := RandomSeed(), RandomSeed(); a != 42 || b != 42 {
+		t.Errorf("RandomSeed() = %d, %d with a fixed seed, want 42", a, b)
+	}
+
+	SetFixedSeed(-1)
+	if RandomSeed() == RandomSeed() {
+		t.Error("RandomSeed() repeats after the fixed seed was cleared")
+	}
+}
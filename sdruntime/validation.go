@@ -0,0 +1,55 @@
+package sdruntime
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go_backend/core"
+	"go_backend/core/validation"
+)
+
+// sdModelExtensions are the model formats stable-diffusion.cpp can load.
+var sdModelExtensions = []string{".safetensors", ".ckpt", ".gguf"}
+
+// ValidationChecks returns the checks for the Stable Diffusion configuration,
+// for registration with validation.ValidationSuite.Register.
+func ValidationChecks() []validation.Check {
+	return []validation.Check{
+		{Name: "SD Model File", Module: "sdruntime", Run: checkModelFile},
+	}
+}
+
+// checkModelFile verifies SD_MODEL_PATH points at a model file. Checksums are
+// verified when the model is loaded, not here, to keep startup fast.
+func checkModelFile(ctx context.Context) validation.CheckResult {
+	modelPath := LoadSDConfig().ModelPath
+	if modelPath == "" {
+		return validation.Skipped("SD_MODEL_PATH not set, local image generation disabled")
+	}
+
+	info, err := os.Stat(modelPath)
+	if os.IsNotExist(err) {
+		return validation.Failed("Model file not found", fmt.Errorf("%w: %s", ErrModelNotFound, modelPath),
+			"Download a Stable Diffusion model with the dashboard Model Catalog, or unset SD_MODEL_PATH")
+	}
+	if err != nil {
+		return validation.Failed("Cannot access model file", err, "Check the file permissions")
+	}
+	if info.IsDir() {
+		return validation.Failed("Model path is a directory",
+			fmt.Errorf("model path is a directory, not a file: %s", modelPath),
+			"Set SD_MODEL_PATH to the model file itself")
+	}
+
+	ext := strings.ToLower(filepath.Ext(modelPath))
+	for _, known := range sdModelExtensions {
+		if ext == known {
+			return validation.Passed(fmt.Sprintf("%s (%s)", filepath.Base(modelPath), core.FormatBytes(info.Size())))
+		}
+	}
+	return validation.Warning(fmt.Sprintf("Unrecognised model format %q", ext),
+		"stable-diffusion.cpp loads "+strings.Join(sdModelExtensions, ", ")+" files")
+}
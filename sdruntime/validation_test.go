@@ -0,0 +1,47 @@
+package sdruntime
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go_backend/core/validation"
+)
+
+func TestCheckModelFile(t *testing.T) {
+	dir := t.TempDir()
+	model := filepath.Join(dir, "sd-turbo.safetensors")
+	other := filepath.Join(dir, "model.txt")
+	for _, p := range []string{model, other} {
+		if err := os.WriteFile(p, []byte("weights"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		modelPath  string
+		wantStatus validation.StepStatus
+	}{
+		{"unset", "", validation.StepSkipped},
+		{"valid", model, validation.StepPassed},
+		{"missing", filepath.Join(dir, "missing.safetensors"), validation.StepFailed},
+		{"directory", dir, validation.StepFailed},
+		{"unknown format", other, validation.StepWarning},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("SD_MODEL_PATH", tt.modelPath)
+
+			result := checkModelFile(context.Background())
+			if result.Status != tt.wantStatus {
+				t.Errorf("Status = %v, want %v (%s)", result.Status, tt.wantStatus, result.Message)
+			}
+			if result.Status == validation.StepFailed && result.Hint == "" {
+				t.Error("failed check has no hint")
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package sdworker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"go_backend/sdruntime"
+)
+
+// healthTTL is how long a health report is reused by IsLoaded.
+const healthTTL = 5 * time.Second
+
+// maxImageBytes bounds a generated image.
+const maxImageBytes = 64 << 20
+
+// Client is an organism that generates images on an sd-worker. It stands
+// in for the local SD context pool of an imagegen.Processor.
+//
+// Usage:
+//
+//	client := sdworker.NewClient(cfg)
+//	processor, _ := imagegen.NewProcessorWithBackend(client, canvus, logger, processorConfig)
+type Client struct {
+	config Config
+	http   *http.Client
+
+	mu        sync.Mutex
+	health    Health
+	checkedAt time.Time
+}
+
+// NewClient creates a client for the worker at config.URL.
+func NewClient(config Config) *Client {
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	return &Client{config: config, http: httpClient}
+}
+
+// URL returns the worker's URL.
+func (c *Client) URL() string {
+	return c.config.URL
+}
+
+// Generate generates an image on the worker and returns its PNG data.
+// Errors the worker reports keep their sdruntime meaning, so
+// errors.Is(err, sdruntime.ErrOutOfVRAM) holds for a worker out of memory.
+func (c *Client) Generate(ctx context.Context, params sdruntime.GenerateParams) ([]byte, error) {
+	if c.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.Timeout)
+		defer cancel()
+	}
+	body, _ := json.Marshal(requestFromParams(params))
+	req, err := c.newRequest(ctx, http.MethodPost, PathGenerate, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sdworker: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError(resp)
+	}
+	image, err := io.ReadAll(io.LimitReader(resp.Body, maxImageBytes))
+	if err != nil {
+		return nil, fmt.Errorf("sdworker: read image: %w", err)
+	}
+	return image, nil
+}
+
+// Health asks the worker for its state.
+func (c *Client) Health(ctx context.Context) (Health, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, PathHealth, nil)
+	if err != nil {
+		return Health{}, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return Health{}, fmt.Errorf("sdworker: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Health{}, readError(resp)
+	}
+	var health Health
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return Health{}, fmt.Errorf("sdworker: decode health: %w", err)
+	}
+
+	c.mu.Lock()
+	c.health, c.checkedAt = health, time.Now()
+	c.mu.Unlock()
+	return health, nil
+}
+
+// IsLoaded reports whether the worker has its model in GPU memory, as of
+// a health report at most a few seconds old. An unreachable worker is
+// reported as not loaded.
+func (c *Client) IsLoaded() bool {
+	c.mu.Lock()
+	health, fresh := c.health, time.Since(c.checkedAt) < healthTTL
+	c.mu.Unlock()
+	if fresh {
+		return health.Loaded
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	health, err := c.Health(ctx)
+	return err == nil && health.Loaded
+}
+
+// newRequest creates a request for path on the worker.
+func (c *Client) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.config.URL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("sdworker: %w", err)
+	}
+	if c.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.Token)
+	}
+	return req, nil
+}
+
+// readError decodes the ErrorResponse of a failed request.
+func readError(resp *http.Response) error {
+	var body ErrorResponse
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxRequestBytes))
+	if json.Unmarshal(data, &body) != nil || body.Error == "" {
+		body.Error = fmt.Sprintf("%s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+	return errorFromResponse(body)
+}
@@ -0,0 +1,62 @@
+package sdworker
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go_backend/core"
+	"go_backend/sdruntime"
+)
+
+// DefaultAddr is the address the sd-worker listens on by default.
+const DefaultAddr = ":8091"
+
+// Config configures the Server and the Client.
+type Config struct {
+	// URL of the worker, for instances that send it images to generate:
+	// http://gpu-host:8091. Empty generates locally.
+	URL string
+
+	// Addr the worker listens on (default: :8091)
+	Addr string
+
+	// Token, if set, must be sent as "Authorization: Bearer <token>"
+	Token string
+
+	// Timeout bounds one image, including the wait for a free context
+	// (default: SD_TIMEOUT_SECONDS)
+	Timeout time.Duration
+
+	// HTTPClient sends the Client's requests (default: a plain client;
+	// Timeout bounds each request)
+	HTTPClient *http.Client
+}
+
+// Remote reports whether images are generated on a worker.
+func (c Config) Remote() bool {
+	return c.URL != ""
+}
+
+// ConfigFromEnv reads SD_WORKER_URL, SD_WORKER_ADDR, SD_WORKER_TOKEN and
+// SD_WORKER_TIMEOUT (seconds). An invalid URL is returned as an error
+// together with a config that generates locally.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		URL:     strings.TrimRight(strings.TrimSpace(core.GetEnvOrDefault("SD_WORKER_URL", "")), "/"),
+		Addr:    core.GetEnvOrDefault("SD_WORKER_ADDR", DefaultAddr),
+		Token:   core.GetEnvOrDefault("SD_WORKER_TOKEN", ""),
+		Timeout: core.ParseDurationEnv("SD_WORKER_TIMEOUT", int(sdruntime.LoadSDConfig().Timeout/time.Second)),
+	}
+	if cfg.URL == "" {
+		return cfg, nil
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		cfg.URL = ""
+		return cfg, fmt.Errorf("sdworker: invalid SD_WORKER_URL %q (use http://host:port)", core.GetEnvOrDefault("SD_WORKER_URL", ""))
+	}
+	return cfg, nil
+}
@@ -0,0 +1,25 @@
+package sdworker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("SD_WORKER_URL", "http://gpu-host:8091/")
+	t.Setenv("SD_WORKER_TOKEN", "secret")
+	t.Setenv("SD_WORKER_TIMEOUT", "90")
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv: %v", err)
+	}
+	if !cfg.Remote() || cfg.URL != "http://gpu-host:8091" || cfg.Token != "secret" || cfg.Timeout != 90*time.Second || cfg.Addr != DefaultAddr {
+		t.Errorf("config = %+v", cfg)
+	}
+
+	t.Setenv("SD_WORKER_URL", "gpu-host:8091")
+	cfg, err = ConfigFromEnv()
+	if err == nil || cfg.Remote() {
+		t.Errorf("ConfigFromEnv = %+v, %v; want an error and local generation", cfg, err)
+	}
+}
@@ -0,0 +1,104 @@
+// Package sdworker runs Stable Diffusion image generation in a separate
+// process, usually on a GPU host, for instances that cannot load the model
+// themselves.
+//
+// The sd-worker binary (cmd/sd-worker) serves a Server in front of its SD
+// context pool; the canvas monitor uses a Client in place of a local pool,
+// so only the pixels cross the network and canvas placement stays with the
+// monitor.
+//
+// This file contains the HTTP protocol atoms shared by both sides.
+package sdworker
+
+import (
+	"errors"
+	"fmt"
+
+	"go_backend/core/errs"
+	"go_backend/sdruntime"
+)
+
+// Protocol paths. Generate takes a GenerateRequest as JSON and answers with
+// the PNG image, or an ErrorResponse with a non-2xx status.
+const (
+	PathGenerate = "/v1/generate"
+	PathHealth   = "/v1/health"
+)
+
+// GenerateRequest asks the worker for one image.
+type GenerateRequest struct {
+	Prompt         string  `json:"prompt"`
+	NegativePrompt string  `json:"negative_prompt,omitempty"`
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	Steps          int     `json:"steps"`
+	CFGScale       float64 `json:"cfg_scale"`
+	Seed           int64   `json:"seed"`
+}
+
+// requestFromParams converts generation parameters to a request.
+func requestFromParams(p sdruntime.GenerateParams) GenerateRequest {
+	return GenerateRequest{
+		Prompt:         p.Prompt,
+		NegativePrompt: p.NegativePrompt,
+		Width:          p.Width,
+		Height:         p.Height,
+		Steps:          p.Steps,
+		CFGScale:       p.CFGScale,
+		Seed:           p.Seed,
+	}
+}
+
+// Params converts the request to generation parameters.
+func (r GenerateRequest) Params() sdruntime.GenerateParams {
+	return sdruntime.GenerateParams{
+		Prompt:         r.Prompt,
+		NegativePrompt: r.NegativePrompt,
+		Width:          r.Width,
+		Height:         r.Height,
+		Steps:          r.Steps,
+		CFGScale:       r.CFGScale,
+		Seed:           r.Seed,
+	}
+}
+
+// ErrorResponse reports a failed request.
+type ErrorResponse struct {
+	Code  errs.ErrCode `json:"code,omitempty"`
+	Error string       `json:"error"`
+}
+
+// Health reports the worker's state.
+type Health struct {
+	// Loaded reports whether the model is in GPU memory; the first image
+	// after an idle unload takes longer
+	Loaded bool `json:"loaded"`
+
+	// MaxConcurrent is how many images the worker generates at a time
+	MaxConcurrent int `json:"max_concurrent"`
+
+	// Model is the file name of the loaded model
+	Model string `json:"model,omitempty"`
+}
+
+// codeErrors maps error codes to the sdruntime errors they stand for, so
+// callers can test a worker's errors as they would a local pool's (the
+// VRAM retry ladder needs errors.Is(err, sdruntime.ErrOutOfVRAM)).
+var codeErrors = map[errs.ErrCode]error{
+	errs.CodeOutOfVRAM:      sdruntime.ErrOutOfVRAM,
+	errs.CodeModelMissing:   sdruntime.ErrModelLoadFailed,
+	errs.CodeTimeout:        sdruntime.ErrGenerationTimeout,
+	errs.CodeInvalidPrompt:  sdruntime.ErrInvalidPrompt,
+	errs.CodeGPUUnavailable: sdruntime.ErrCUDANotAvailable,
+}
+
+// errorFromResponse rebuilds the error a worker reported.
+func errorFromResponse(resp ErrorResponse) error {
+	if sentinel, ok := codeErrors[resp.Code]; ok {
+		return fmt.Errorf("sdworker: %w (%s)", sentinel, resp.Error)
+	}
+	if resp.Code != "" {
+		return fmt.Errorf("sdworker: %w", errs.New(resp.Code, resp.Error))
+	}
+	return errors.New("sdworker: " + resp.Error)
+}
@@ -0,0 +1,166 @@
+package sdworker
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go_backend/core/errs"
+	"go_backend/sdruntime"
+
+	"go.uber.org/zap"
+)
+
+// maxRequestBytes bounds a GenerateRequest body.
+const maxRequestBytes = 64 << 10
+
+// Pool generates images. Implemented by *sdruntime.ContextPool.
+type Pool interface {
+	Generate(ctx context.Context, params sdruntime.GenerateParams) ([]byte, error)
+	IsLoaded() bool
+	MaxSize() int
+	ModelPath() string
+}
+
+// Server is an organism that serves a Pool to remote instances over HTTP.
+//
+// Usage:
+//
+//	pool, _ := sdruntime.NewContextPool(sdConfig.MaxConcurrent, sdConfig.ModelPath)
+//	s := sdworker.NewServer(cfg, pool, logger)
+//	go s.Start()
+//	defer s.Shutdown(ctx)
+type Server struct {
+	config     Config
+	pool       Pool
+	logger     *zap.Logger
+	httpServer *http.Server
+}
+
+// NewServer creates a server listening on config.Addr.
+func NewServer(config Config, pool Pool, logger *zap.Logger) *Server {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	s := &Server{config: config, pool: pool, logger: logger}
+	s.httpServer = &http.Server{
+		Addr:              config.Addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Start listens until Shutdown is called.
+func (s *Server) Start() error {
+	s.logger.Info("SD worker listening",
+		zap.String("addr", s.config.Addr),
+		zap.Int("max_concurrent", s.pool.MaxSize()),
+		zap.Bool("token", s.config.Token != ""))
+	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("sdworker: %w", err)
+	}
+	return nil
+}
+
+// Shutdown stops taking requests and waits until ctx is done for the
+// images being generated.
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.httpServer.Shutdown(ctx)
+}
+
+// ServeHTTP routes protocol requests.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, ErrorResponse{Error: "invalid or missing token"})
+		return
+	}
+	switch r.URL.Path {
+	case PathGenerate:
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "use POST"})
+			return
+		}
+		s.handleGenerate(w, r)
+	case PathHealth:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(Health{
+			Loaded:        s.pool.IsLoaded(),
+			MaxConcurrent: s.pool.MaxSize(),
+			Model:         filepath.Base(s.pool.ModelPath()),
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleGenerate generates one image and answers with its PNG data.
+func (s *Server) handleGenerate(w http.ResponseWriter, r *http.Request) {
+	var req GenerateRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxRequestBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, ErrorResponse{Code: errs.CodeImageGeneration, Error: "invalid request: " + err.Error()})
+		return
+	}
+
+	ctx := r.Context()
+	if s.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.config.Timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	image, err := s.pool.Generate(ctx, req.Params())
+	log := s.logger.With(
+		zap.String("remote", r.RemoteAddr),
+		zap.Int("width", req.Width),
+		zap.Int("height", req.Height),
+		zap.Int("steps", req.Steps),
+		zap.Duration("duration", time.Since(start)))
+	if err != nil {
+		log.Warn("Image generation failed", zap.Error(err))
+		code := errs.CodeOr(err, errs.CodeImageGeneration)
+		writeError(w, statusOf(code), ErrorResponse{Code: code, Error: err.Error()})
+		return
+	}
+	log.Info("Image generated", zap.Int("bytes", len(image)))
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(image)
+}
+
+// authorized checks the bearer token if one is configured.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.config.Token == "" {
+		return true
+	}
+	got := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(got), []byte(s.config.Token)) == 1
+}
+
+// statusOf returns the HTTP status reporting an error with code.
+func statusOf(code errs.ErrCode) int {
+	switch code {
+	case errs.CodeInvalidPrompt:
+		return http.StatusBadRequest
+	case errs.CodeTimeout:
+		return http.StatusGatewayTimeout
+	case errs.CodeOutOfVRAM, errs.CodeGPUUnavailable, errs.CodeModelMissing:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// writeError answers with resp as JSON.
+func writeError(w http.ResponseWriter, status int, resp ErrorResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,116 @@
+package sdworker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go_backend/core/errs"
+	"go_backend/sdruntime"
+)
+
+// fakePool is a Pool that returns fixed results.
+type fakePool struct {
+	image  []byte
+	err    error
+	params sdruntime.GenerateParams
+}
+
+func (p *fakePool) Generate(ctx context.Context, params sdruntime.GenerateParams) ([]byte, error) {
+	p.params = params
+	return p.image, p.err
+}
+
+func (p *fakePool) IsLoaded() bool    { return true }
+func (p *fakePool) MaxSize() int      { return 2 }
+func (p *fakePool) ModelPath() string { return "/models/sd-v1-5.safetensors" }
+
+// newTestWorker serves pool and returns a client for it.
+func newTestWorker(t *testing.T, pool Pool, serverToken, clientToken string) *Client {
+	t.Helper()
+	server := httptest.NewServer(NewServer(Config{Token: serverToken, Timeout: time.Minute}, pool, nil))
+	t.Cleanup(server.Close)
+	return NewClient(Config{URL: server.URL, Token: clientToken, Timeout: 5 * time.Second})
+}
+
+func TestClientGeneratesOnWorker(t *testing.T) {
+	pool := &fakePool{image: []byte("\x89PNG")}
+	client := newTestWorker(t, pool, "secret", "secret")
+
+	params := sdruntime.GenerateParams{Prompt: "a lighthouse", NegativePrompt: "blurry", Width: 512, Height: 768, Steps: 20, CFGScale: 7.5, Seed: 42}
+	image, err := client.Generate(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if string(image) != "\x89PNG" {
+		t.Errorf("image = %q", image)
+	}
+	if pool.params != params {
+		t.Errorf("worker got %+v, want %+v", pool.params, params)
+	}
+
+	health, err := client.Health(context.Background())
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	if !health.Loaded || health.MaxConcurrent != 2 || health.Model != "sd-v1-5.safetensors" {
+		t.Errorf("health = %+v", health)
+	}
+	if !client.IsLoaded() {
+		t.Error("IsLoaded = false for a loaded worker")
+	}
+}
+
+func TestClientKeepsWorkerErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		is   error
+		code errs.ErrCode
+	}{
+		{"out of VRAM", fmt.Errorf("generate image: %w", sdruntime.ErrOutOfVRAM), sdruntime.ErrOutOfVRAM, errs.CodeOutOfVRAM},
+		{"invalid prompt", sdruntime.ErrInvalidPrompt, sdruntime.ErrInvalidPrompt, errs.CodeInvalidPrompt},
+		{"generation failed", sdruntime.ErrGenerationFailed, nil, errs.CodeImageGeneration},
+		{"uncoded", errors.New("segfault"), nil, errs.CodeImageGeneration},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestWorker(t, &fakePool{err: tt.err}, "", "")
+			_, err := client.Generate(context.Background(), sdruntime.GenerateParams{Prompt: "a cat"})
+			if err == nil {
+				t.Fatal("Generate succeeded")
+			}
+			if tt.is != nil && !errors.Is(err, tt.is) {
+				t.Errorf("err = %v, want %v", err, tt.is)
+			}
+			if code := errs.CodeOf(err); code != tt.code {
+				t.Errorf("code = %s, want %s", code, tt.code)
+			}
+		})
+	}
+}
+
+func TestServerChecksToken(t *testing.T) {
+	client := newTestWorker(t, &fakePool{image: []byte("png")}, "secret", "wrong")
+	_, err := client.Generate(context.Background(), sdruntime.GenerateParams{Prompt: "a cat"})
+	if err == nil || !strings.Contains(err.Error(), "token") {
+		t.Errorf("Generate = %v, want a token error", err)
+	}
+	if client.IsLoaded() {
+		t.Error("IsLoaded = true without access to the worker")
+	}
+}
+
+func TestServerRejectsGet(t *testing.T) {
+	server := NewServer(Config{}, &fakePool{}, nil)
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, PathGenerate, nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want 405", rec.Code)
+	}
+}
@@ -7,7 +7,10 @@
 package main
 
 import (
+	"crypto/ed25519"
 	"fmt"
+
+	"go_backend/updater"
 )
 
 // RunAsService is a no-op on non-Windows platforms.
@@ -45,6 +48,12 @@ func HandleServiceCommand(args []string) bool {
 	}
 }
 
+// installUpdate installs the update staged next to exe. A running server
+// keeps its old binary until it is restarted, e.g. by systemd or launchd.
+func installUpdate(exe string, key ed25519.PublicKey) (string, error) {
+	return updater.Apply(exe, key)
+}
+
 // PrintServiceUsage prints the help/usage information.
 // On non-Windows platforms, indicates that service management is Windows-only.
 func PrintServiceUsage() {
@@ -53,6 +62,8 @@ func PrintServiceUsage() {
 	fmt.Println("Usage: canvuslocallm [command]")
 	fmt.Println()
 	fmt.Println("Commands:")
+	fmt.Println("  bench      Benchmark local models and write a sizing report")
+	fmt.Println("  update     Check for or install a new release (update check | apply)")
 	fmt.Println("  help       Show this help message")
 	fmt.Println()
 	fmt.Println("Note: Service management commands (install, uninstall, start, stop,")
@@ -8,11 +8,14 @@ package main
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/kardianos/service"
+
+	"go_backend/updater"
 )
 
 // Program implements service.Interface for Windows Service integration.
@@ -227,6 +230,27 @@ func ServiceStatus() (service.Status, error) {
 	return status, nil
 }
 
+// installUpdate installs the update staged next to exe. If the service is
+// running it is stopped first, so the swap is not left to its shutdown,
+// and started again on the new binary.
+func installUpdate(exe string, key ed25519.PublicKey) (string, error) {
+	status, err := ServiceStatus()
+	running := err == nil && status == service.StatusRunning
+	if running {
+		if err := StopService(); err != nil {
+			return "", err
+		}
+	}
+
+	version, err := updater.Apply(exe, key)
+	if running {
+		if startErr := StartService(); startErr != nil && err == nil {
+			err = startErr
+		}
+	}
+	return version, err
+}
+
 // PrintServiceUsage prints the help/usage information for service commands.
 func PrintServiceUsage() {
 	fmt.Println("CanvusLocalLLM Service Management")
@@ -240,6 +264,8 @@ func PrintServiceUsage() {
 	fmt.Println("  stop       Stop the Windows service")
 	fmt.Println("  restart    Restart the Windows service (stop then start)")
 	fmt.Println("  status     Show the current service status")
+	fmt.Println("  bench      Benchmark local models and write a sizing report")
+	fmt.Println("  update     Check for or install a new release (update check | apply)")
 	fmt.Println("  help       Show this help message")
 	fmt.Println()
 	fmt.Println("Run without arguments to start the application in foreground mode.")
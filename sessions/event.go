@@ -0,0 +1,59 @@
+// Package sessions provides the session recorder that keeps the triggers and
+// AI responses of each canvas session so they can be replayed later, e.g. to
+// regression-test prompt changes against real workshop data. This file
+// contains the Event and Session types.
+package sessions
+
+import "time"
+
+// Kind says whether an event is a trigger or an AI response.
+type Kind string
+
+// Event kinds.
+const (
+	KindTrigger  Kind = "trigger"
+	KindResponse Kind = "response"
+)
+
+// OperationNote is the operation of {{ }} note prompts, as in
+// processing_history. Only note triggers can be replayed; the other
+// operations need the images or PDFs they were run on.
+const OperationNote = "text_generation"
+
+// Event is one recorded trigger or response.
+type Event struct {
+	ID        int64     `json:"id"`
+	SessionID string    `json:"session_id"`
+	CanvasID  string    `json:"canvas_id"`
+	Time      time.Time `json:"time"`
+	Kind      Kind      `json:"kind"`
+
+	// CorrelationID links a response to the trigger that caused it
+	CorrelationID string `json:"correlation_id"`
+
+	// Operation is the task type, as in processing_history (e.g. "pdf_analysis")
+	Operation  string `json:"operation"`
+	WidgetID   string `json:"widget_id"`
+	WidgetType string `json:"widget_type"`
+
+	// Text is the prompt of a trigger or the text of a response. Image
+	// responses are recorded as "image: <prompt>".
+	Text  string `json:"text"`
+	Model string `json:"model,omitempty"`
+
+	// Location and size of the trigger widget
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// Session summarizes the events recorded on a canvas between idle gaps.
+type Session struct {
+	ID        string    `json:"id"`
+	CanvasID  string    `json:"canvas_id"`
+	Started   time.Time `json:"started"`
+	Ended     time.Time `json:"ended"`
+	Triggers  int       `json:"triggers"`
+	Responses int       `json:"responses"`
+}
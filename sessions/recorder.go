@@ -0,0 +1,136 @@
+// Package sessions provides the session recorder that keeps the triggers and
+// AI responses of each canvas session. This file contains the Recorder
+// organism, which groups events into sessions and stores them.
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// DefaultIdleGap is how long a canvas must be idle before the next event
+// starts a new session.
+const DefaultIdleGap = 30 * time.Minute
+
+// Storage persists session events. Implemented by db.Repository.
+type Storage interface {
+	// InsertSessionEvent stores e and returns its ID.
+	InsertSessionEvent(ctx context.Context, e Event) (int64, error)
+	// LastSessionEvent returns the newest event of canvasID, or nil if
+	// there is none.
+	LastSessionEvent(ctx context.Context, canvasID string) (*Event, error)
+	// ListSessions returns up to limit sessions, newest first. An empty
+	// canvasID lists every canvas.
+	ListSessions(ctx context.Context, canvasID string, limit int) ([]Session, error)
+	// ListSessionEvents returns the events of sessionID, oldest first.
+	ListSessionEvents(ctx context.Context, sessionID string) ([]Event, error)
+}
+
+// Recorder records events and assigns them to sessions. Events on a canvas
+// belong to the same session until the canvas has been idle for the idle
+// gap.
+//
+// Thread-Safety: Recorder is safe for concurrent use.
+type Recorder struct {
+	storage Storage
+	idleGap time.Duration
+	clock   clock.Clock
+
+	mu sync.Mutex
+	// current holds the open session of each canvas seen since startup
+	current map[string]openSession
+}
+
+// openSession is the session an event on a canvas would join.
+type openSession struct {
+	id   string
+	last time.Time
+}
+
+// NewRecorder creates a Recorder backed by storage. An idleGap <= 0 uses
+// DefaultIdleGap.
+func NewRecorder(storage Storage, idleGap time.Duration) *Recorder {
+	if idleGap <= 0 {
+		idleGap = DefaultIdleGap
+	}
+	return &Recorder{
+		storage: storage,
+		idleGap: idleGap,
+		clock:   clock.Real(),
+		current: make(map[string]openSession),
+	}
+}
+
+// SetClock sets the clock that stamps recorded events and so starts new
+// sessions (default: clock.Real).
+func (r *Recorder) SetClock(c clock.Clock) {
+	r.mu.Lock()
+	r.clock = clock.OrReal(c)
+	r.mu.Unlock()
+}
+
+// Record fills in the session, time and ID of e and stores it. The stored
+// event is returned.
+func (r *Recorder) Record(ctx context.Context, e Event) (Event, error) {
+	if r.storage == nil {
+		return e, errors.New("sessions: no storage")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e.Time = r.clock.Now().UTC()
+	open, ok := r.current[e.CanvasID]
+	if !ok {
+		// After a restart, continue the session the canvas was in
+		last, err := r.storage.LastSessionEvent(ctx, e.CanvasID)
+		if err != nil {
+			return e, fmt.Errorf("sessions: failed to read last event: %w", err)
+		}
+		if last != nil {
+			open = openSession{id: last.SessionID, last: last.Time}
+		}
+	}
+	if open.id == "" || e.Time.Sub(open.last) >= r.idleGap {
+		open.id = newSessionID(e.CanvasID, e.Time)
+	}
+	e.SessionID = open.id
+
+	id, err := r.storage.InsertSessionEvent(ctx, e)
+	if err != nil {
+		return e, fmt.Errorf("sessions: failed to store event: %w", err)
+	}
+	e.ID = id
+	open.last = e.Time
+	r.current[e.CanvasID] = open
+	return e, nil
+}
+
+// Sessions returns up to limit sessions, newest first. An empty canvasID
+// lists every canvas.
+func (r *Recorder) Sessions(ctx context.Context, canvasID string, limit int) ([]Session, error) {
+	return r.storage.ListSessions(ctx, canvasID, limit)
+}
+
+// Events returns the events of sessionID, oldest first.
+func (r *Recorder) Events(ctx context.Context, sessionID string) ([]Event, error) {
+	return r.storage.ListSessionEvents(ctx, sessionID)
+}
+
+// newSessionID names a session after its canvas and start time, e.g.
+// "3f2a9c1e-20261016-142501".
+func newSessionID(canvasID string, start time.Time) string {
+	prefix := canvasID
+	if len(prefix) > 8 {
+		prefix = prefix[:8]
+	}
+	if prefix == "" {
+		prefix = "canvas"
+	}
+	return prefix + "-" + start.UTC().Format("20060102-150405")
+}
@@ -0,0 +1,155 @@
+// Package sessions provides the session recorder that keeps the triggers and
+// AI responses of each canvas session. This file contains Replay, which
+// runs a recorded session's prompts again and compares the responses.
+package sessions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// replayNoteGap is the space left between a replayed trigger note and its
+// response, in canvas units.
+const replayNoteGap = 50.0
+
+// RespondFunc answers a note prompt the way the live note handler does.
+// An empty model uses the model the live handler would use.
+type RespondFunc func(ctx context.Context, prompt, model string) (string, error)
+
+// Canvas is the canvas a replay writes to. canvusapi.Client satisfies it.
+type Canvas interface {
+	CreateNote(payload map[string]interface{}) (map[string]interface{}, error)
+}
+
+// ReplayOptions configures a replay.
+type ReplayOptions struct {
+	// Model answers the prompts instead of the live model (optional)
+	Model string
+	// Canvas receives a note for each prompt and its new response; nil
+	// only compares the responses
+	Canvas Canvas
+}
+
+// ReplayStep is the outcome of replaying one trigger.
+type ReplayStep struct {
+	TriggerID int64  `json:"trigger_id"`
+	Operation string `json:"operation"`
+	Prompt    string `json:"prompt"`
+	Recorded  string `json:"recorded"`
+	Replayed  string `json:"replayed,omitempty"`
+	// Changed is true if the replayed response differs from the recorded one
+	Changed bool `json:"changed"`
+	// Skipped says why the trigger was not replayed
+	Skipped string `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ReplayReport is the outcome of a replay.
+type ReplayReport struct {
+	SessionID string       `json:"session_id"`
+	Model     string       `json:"model,omitempty"`
+	Replayed  int          `json:"replayed"`
+	Changed   int          `json:"changed"`
+	Skipped   int          `json:"skipped"`
+	Failed    int          `json:"failed"`
+	Steps     []ReplayStep `json:"steps"`
+}
+
+// Replay answers the prompts of the note triggers in events again, in
+// order, and compares each answer with the recorded response. With a
+// canvas, each prompt and its new answer are written to it as a pair of
+// notes at the trigger's recorded position. Triggers of other operations
+// are skipped. A failed prompt is reported in its step and does not stop
+// the replay; only ctx ending does.
+func Replay(ctx context.Context, events []Event, respond RespondFunc, opts ReplayOptions) (ReplayReport, error) {
+	report := ReplayReport{Model: opts.Model, Steps: []ReplayStep{}}
+	if len(events) > 0 {
+		report.SessionID = events[0].SessionID
+	}
+
+	recorded := make(map[string]string)
+	for _, e := range events {
+		if e.Kind == KindResponse && e.CorrelationID != "" {
+			if _, ok := recorded[e.CorrelationID]; !ok {
+				recorded[e.CorrelationID] = e.Text
+			}
+		}
+	}
+
+	for _, e := range events {
+		if e.Kind != KindTrigger {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		step := ReplayStep{
+			TriggerID: e.ID,
+			Operation: e.Operation,
+			Prompt:    e.Text,
+			Recorded:  recorded[e.CorrelationID],
+		}
+		if e.Operation != OperationNote {
+			step.Skipped = fmt.Sprintf("%s needs the original files", e.Operation)
+			report.Skipped++
+			report.Steps = append(report.Steps, step)
+			continue
+		}
+
+		answer, err := respond(ctx, e.Text, opts.Model)
+		if err != nil {
+			step.Error = err.Error()
+			report.Failed++
+			report.Steps = append(report.Steps, step)
+			continue
+		}
+		step.Replayed = answer
+		step.Changed = strings.TrimSpace(answer) != strings.TrimSpace(step.Recorded)
+		if opts.Canvas != nil {
+			if err := writeReplayNotes(opts.Canvas, e, answer); err != nil {
+				step.Error = err.Error()
+				report.Failed++
+				report.Steps = append(report.Steps, step)
+				continue
+			}
+		}
+
+		report.Replayed++
+		if step.Changed {
+			report.Changed++
+		}
+		report.Steps = append(report.Steps, step)
+	}
+	return report, nil
+}
+
+// writeReplayNotes writes the prompt of trigger and its replayed answer as
+// two notes side by side. The prompt is written without trigger markers
+// so a monitored canvas does not process it again.
+func writeReplayNotes(canvas Canvas, trigger Event, answer string) error {
+	width, height := trigger.Width, trigger.Height
+	if width <= 0 || height <= 0 {
+		width, height = 300, 300
+	}
+	notes := []struct {
+		title, text string
+		x           float64
+	}{
+		{"Prompt", trigger.Text, trigger.X},
+		{"Replayed response", answer, trigger.X + width + replayNoteGap},
+	}
+	for _, n := range notes {
+		_, err := canvas.CreateNote(map[string]interface{}{
+			"title":    n.title,
+			"text":     n.text,
+			"location": map[string]float64{"x": n.x, "y": trigger.Y},
+			"size":     map[string]interface{}{"width": width, "height": height},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to write %s note: %w", strings.ToLower(n.title), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// memStorage keeps events in memory
+type memStorage struct {
+	events []Event
+}
+
+func (m *memStorage) InsertSessionEvent(ctx context.Context, e Event) (int64, error) {
+	e.ID = int64(len(m.events) + 1)
+	m.events = append(m.events, e)
+	return e.ID, nil
+}
+
+func (m *memStorage) LastSessionEvent(ctx context.Context, canvasID string) (*Event, error) {
+	for i := len(m.events) - 1; i >= 0; i-- {
+		if m.events[i].CanvasID == canvasID {
+			e := m.events[i]
+			return &e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memStorage) ListSessions(ctx context.Context, canvasID string, limit int) ([]Session, error) {
+	byID := map[string]*Session{}
+	for _, e := range m.events {
+		if canvasID != "" && e.CanvasID != canvasID {
+			continue
+		}
+		s, ok := byID[e.SessionID]
+		if !ok {
+			s = &Session{ID: e.SessionID, CanvasID: e.CanvasID, Started: e.Time}
+			byID[e.SessionID] = s
+		}
+		s.Ended = e.Time
+		if e.Kind == KindTrigger {
+			s.Triggers++
+		} else {
+			s.Responses++
+		}
+	}
+	var out []Session
+	for _, s := range byID {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Started.After(out[j].Started) })
+	return out, nil
+}
+
+func (m *memStorage) ListSessionEvents(ctx context.Context, sessionID string) ([]Event, error) {
+	var out []Event
+	for _, e := range m.events {
+		if e.SessionID == sessionID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func TestRecorder_SessionsSplitOnIdleGap(t *testing.T) {
+	storage := &memStorage{}
+	r := NewRecorder(storage, 10*time.Minute)
+	fake := clock.NewFake(time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC))
+	r.SetClock(fake)
+	ctx := context.Background()
+
+	first, _ := r.Record(ctx, Event{CanvasID: "canvas-a-uuid", Kind: KindTrigger})
+	fake.Advance(5 * time.Minute)
+	second, _ := r.Record(ctx, Event{CanvasID: "canvas-a-uuid", Kind: KindResponse})
+	other, _ := r.Record(ctx, Event{CanvasID: "canvas-b-uuid", Kind: KindTrigger})
+	fake.Advance(20 * time.Minute)
+	third, _ := r.Record(ctx, Event{CanvasID: "canvas-a-uuid", Kind: KindTrigger})
+
+	if first.SessionID != "canvas-a-20261016-140000" || second.SessionID != first.SessionID {
+		t.Errorf("sessions = %q, %q; want the same session", first.SessionID, second.SessionID)
+	}
+	if other.SessionID == first.SessionID {
+		t.Error("canvases should not share a session")
+	}
+	if third.SessionID == first.SessionID {
+		t.Error("an event after the idle gap should start a new session")
+	}
+
+	// A new recorder continues the canvas's session after a restart
+	restarted := NewRecorder(storage, 10*time.Minute)
+	restarted.SetClock(clock.NewFake(fake.Now().Add(time.Minute)))
+	if e, _ := restarted.Record(ctx, Event{CanvasID: "canvas-a-uuid", Kind: KindResponse}); e.SessionID != third.SessionID {
+		t.Errorf("after restart session = %q, want %q", e.SessionID, third.SessionID)
+	}
+
+	sessions, _ := r.Sessions(ctx, "canvas-a-uuid", 10)
+	if len(sessions) != 2 {
+		t.Errorf("Sessions() = %d sessions, want 2", len(sessions))
+	}
+}
+
+// fakeCanvas records the notes created on it
+type fakeCanvas struct {
+	notes []map[string]interface{}
+	err   error
+}
+
+func (c *fakeCanvas) CreateNote(payload map[string]interface{}) (map[string]interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	c.notes = append(c.notes, payload)
+	return map[string]interface{}{"id": "note"}, nil
+}
+
+func TestReplay(t *testing.T) {
+	events := []Event{
+		{ID: 1, SessionID: "s", Kind: KindTrigger, CorrelationID: "a", Operation: OperationNote, Text: "capital of France?", X: 100, Y: 200, Width: 300, Height: 300},
+		{ID: 2, SessionID: "s", Kind: KindTrigger, CorrelationID: "b", Operation: "pdf_analysis"},
+		{ID: 3, SessionID: "s", Kind: KindResponse, CorrelationID: "a", Operation: OperationNote, Text: "Paris"},
+		{ID: 4, SessionID: "s", Kind: KindTrigger, CorrelationID: "c", Operation: OperationNote, Text: "2+2?"},
+		{ID: 5, SessionID: "s", Kind: KindResponse, CorrelationID: "c", Operation: OperationNote, Text: "4"},
+		{ID: 6, SessionID: "s", Kind: KindTrigger, CorrelationID: "d", Operation: OperationNote, Text: "fail"},
+	}
+	var models []string
+	respond := func(ctx context.Context, prompt, model string) (string, error) {
+		models = append(models, model)
+		switch prompt {
+		case "capital of France?":
+			return "Paris ", nil
+		case "fail":
+			return "", errors.New("model offline")
+		}
+		return "four", nil
+	}
+	canvas := &fakeCanvas{}
+
+	report, err := Replay(context.Background(), events, respond, ReplayOptions{Model: "gpt-test", Canvas: canvas})
+	if err != nil {
+		t.Fatalf("Replay() error: %v", err)
+	}
+	if report.SessionID != "s" || report.Replayed != 2 || report.Changed != 1 || report.Skipped != 1 || report.Failed != 1 {
+		t.Errorf("report = %+v", report)
+	}
+	if len(report.Steps) != 4 || report.Steps[0].Changed || !report.Steps[2].Changed || report.Steps[2].Recorded != "4" {
+		t.Errorf("steps = %+v", report.Steps)
+	}
+	if models[0] != "gpt-test" {
+		t.Errorf("model = %q, want the override", models[0])
+	}
+
+	if len(canvas.notes) != 4 {
+		t.Fatalf("wrote %d notes, want a prompt and response per replayed trigger", len(canvas.notes))
+	}
+	loc := canvas.notes[1]["location"].(map[string]float64)
+	if canvas.notes[0]["text"] != "capital of France?" || loc["x"] != 450 || loc["y"] != 200 {
+		t.Errorf("notes = %+v", canvas.notes[:2])
+	}
+
+	canvas.err = errors.New("canvas gone")
+	report, _ = Replay(context.Background(), events[:1], respond, ReplayOptions{Canvas: canvas})
+	if report.Failed != 1 || !strings.Contains(report.Steps[0].Error, "canvas gone") {
+		t.Errorf("report on canvas failure = %+v", report)
+	}
+}
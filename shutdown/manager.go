@@ -2,7 +2,9 @@ package shutdown
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"sync"
@@ -28,13 +30,14 @@ import (
 //	logger, _ := zap.NewProduction()
 //	manager := NewManager(logger)
 //
-//	// Register cleanup handlers (lower priority runs first)
-//	manager.Register("database", 30, func(ctx context.Context) error {
+//	// Register cleanup handlers (lower priority runs first, unless
+//	// dependencies say otherwise)
+//	manager.Register("database", PriorityResources, func(ctx context.Context) error {
 //	    return db.Close()
 //	})
-//	manager.Register("logger", 5, func(ctx context.Context) error {
-//	    return logger.Sync()
-//	})
+//	manager.Register("writer", PriorityConnections, func(ctx context.Context) error {
+//	    return writer.Flush(ctx)
+//	}, StopBefore("database"), StopTimeout(10*time.Second))
 //
 //	// Start signal handling
 //	manager.Start()
@@ -121,22 +124,44 @@ func (m *Manager) Context() context.Context {
 }
 
 // Register adds a cleanup function to be called during shutdown.
-// Lower priority values are executed first.
+// StopBefore and StopAfter options order it against other components;
+// among components free to stop, lower priority values are executed first.
 //
 // Typical priority ranges:
-//   - 0-9: Critical cleanup (flush logs, metrics)
-//   - 10-19: Connection cleanup (close client connections)
-//   - 20-29: Service cleanup (stop background workers)
-//   - 30-39: Resource cleanup (close databases, files)
-//   - 40+: Final cleanup (release locks, remove temp files)
-func (m *Manager) Register(name string, priority int, fn core.ShutdownFunc) {
-	m.registry.Register(name, priority, fn)
+//   - 0-9 (PriorityCritical): Critical cleanup (flush logs, metrics)
+//   - 10-19 (PriorityConnections): Connection cleanup (close client connections)
+//   - 20-29 (PriorityServices): Service cleanup (stop background workers)
+//   - 30-39 (PriorityResources): Resource cleanup (close databases, files)
+//   - 40+ (PriorityFinal): Final cleanup (release locks, remove temp files)
+func (m *Manager) Register(name string, priority int, fn core.ShutdownFunc, opts ...ComponentOption) {
+	m.registry.Register(name, priority, fn, opts...)
 	m.logger.Debug("Registered shutdown handler",
 		zap.String("name", name),
 		zap.Int("priority", priority),
 	)
 }
 
+// Plan returns the order cleanup functions will run in during shutdown,
+// without running them. It returns an ErrDependencyCycle error if the
+// registered dependencies form a cycle.
+func (m *Manager) Plan() ([]PlanStep, error) {
+	return m.registry.Plan()
+}
+
+// WritePlan writes the shutdown plan to w as a numbered list, for a dry
+// run of the shutdown sequence. A dependency cycle is written after the
+// list and returned.
+func (m *Manager) WritePlan(w io.Writer) error {
+	steps, planErr := m.Plan()
+	if err := writePlan(w, steps); err != nil {
+		return err
+	}
+	if planErr != nil {
+		fmt.Fprintf(w, "\n%v; shutdown falls back to priority order\n", planErr)
+	}
+	return planErr
+}
+
 // Start begins signal handling for SIGINT and SIGTERM.
 // When a signal is received, the context is cancelled to initiate graceful shutdown.
 // A second signal triggers immediate forced shutdown via os.Exit(1).
@@ -173,7 +198,7 @@ func (m *Manager) Start() {
 // Shutdown executes the graceful shutdown sequence:
 //  1. Close operation tracker to reject new operations
 //  2. Wait for in-flight operations (with timeout)
-//  3. Execute registered cleanup functions in priority order
+//  3. Execute registered cleanup functions in plan order (see Plan)
 //
 // Shutdown returns an error if any cleanup function fails or if
 // waiting for in-flight operations times out.
@@ -233,6 +258,10 @@ func (m *Manager) Shutdown() error {
 
 	// Log each error
 	for _, err := range errs {
+		if errors.Is(err, ErrDependencyCycle) {
+			m.logger.Error("Shutdown dependencies form a cycle, using priority order", zap.Error(err))
+			continue
+		}
 		m.logger.Error("Cleanup function failed", zap.Error(err))
 	}
 
@@ -310,7 +339,7 @@ func (m *Manager) IsShuttingDown() bool {
 }
 
 // RegisteredHandlers returns the names of all registered cleanup handlers
-// in priority order (first to execute is first in slice).
+// in plan order (first to execute is first in slice).
 func (m *Manager) RegisteredHandlers() []string {
 	return m.registry.Names()
 }
@@ -0,0 +1,224 @@
+package shutdown
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Shutdown stages. A component's priority orders it among components it
+// has no dependency on; lower priorities stop first.
+const (
+	// PriorityCritical is for flushing logs and metrics
+	PriorityCritical = 0
+	// PriorityConnections is for writers and client connections
+	PriorityConnections = 10
+	// PriorityServices is for servers and background workers
+	PriorityServices = 20
+	// PriorityResources is for models, pools and other heavy resources
+	PriorityResources = 30
+	// PriorityFinal is for releasing locks and removing temp files
+	PriorityFinal = 40
+)
+
+// ErrDependencyCycle is returned by Plan and Shutdown when components depend on each
+// other in a cycle.
+var ErrDependencyCycle = errors.New("shutdown dependency cycle")
+
+// ErrComponentTimeout is returned for a component that did not stop within
+// its StopTimeout.
+var ErrComponentTimeout = errors.New("component did not stop in time")
+
+// ComponentOption configures a registered shutdown function.
+type ComponentOption func(*shutdownEntry)
+
+// StopBefore makes the component stop before the named components. Names
+// that are not registered are ignored, so optional components can be
+// named.
+//
+// Example:
+//
+//	manager.Register("async-writer", shutdown.PriorityConnections, stopWriter,
+//	    shutdown.StopBefore("database"))
+func StopBefore(names ...string) ComponentOption {
+	return func(e *shutdownEntry) {
+		e.before = append(e.before, names...)
+	}
+}
+
+// StopAfter makes the component stop after the named components. Names
+// that are not registered are ignored.
+func StopAfter(names ...string) ComponentOption {
+	return func(e *shutdownEntry) {
+		e.after = append(e.after, names...)
+	}
+}
+
+// StopTimeout limits how long the component may take to stop. When it
+// runs out, shutdown reports ErrComponentTimeout and moves on to the next
+// component. Without it a component may use the rest of the manager's
+// timeout.
+func StopTimeout(d time.Duration) ComponentOption {
+	return func(e *shutdownEntry) {
+		e.timeout = d
+	}
+}
+
+// PlanStep is one component in the shutdown plan.
+type PlanStep struct {
+	Name     string
+	Priority int
+	// After lists the registered components that must stop first
+	After []string
+	// Timeout is the component's StopTimeout (0 if none)
+	Timeout time.Duration
+}
+
+// plan orders entries so every component stops after the components it
+// depends on, choosing the lowest priority, then the earliest registered,
+// among those that are free to stop. If dependencies form a cycle it
+// returns the entries in priority order with an ErrDependencyCycle error
+// naming the cycle.
+func plan(entries []shutdownEntry) ([]shutdownEntry, [][]string, error) {
+	byName := make(map[string][]int)
+	for i, e := range entries {
+		byName[e.name] = append(byName[e.name], i)
+	}
+
+	// edges[i] lists the entries that must wait for entry i, and
+	// preds[i] the entries that entry i waits for
+	edges := make([][]int, len(entries))
+	preds := make([][]int, len(entries))
+	waits := make([]int, len(entries))
+	link := func(first, then int) {
+		edges[first] = append(edges[first], then)
+		preds[then] = append(preds[then], first)
+		waits[then]++
+	}
+	for i, e := range entries {
+		for _, name := range e.before {
+			for _, j := range byName[name] {
+				link(i, j)
+			}
+		}
+		for _, name := range e.after {
+			for _, j := range byName[name] {
+				link(j, i)
+			}
+		}
+	}
+
+	less := func(a, b int) bool {
+		if entries[a].priority != entries[b].priority {
+			return entries[a].priority < entries[b].priority
+		}
+		return a < b
+	}
+	var ready []int
+	for i := range entries {
+		if waits[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+	order := make([]shutdownEntry, 0, len(entries))
+	deps := make([][]string, 0, len(entries))
+	for len(ready) > 0 {
+		sort.Slice(ready, func(a, b int) bool { return less(ready[a], ready[b]) })
+		next := ready[0]
+		ready = ready[1:]
+		order = append(order, entries[next])
+		var after []string
+		for _, p := range preds[next] {
+			after = append(after, entries[p].name)
+		}
+		deps = append(deps, dedupe(after))
+		for _, j := range edges[next] {
+			if waits[j]--; waits[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+	if len(order) == len(entries) {
+		return order, deps, nil
+	}
+
+	cycle := findCycle(entries, preds, waits)
+	sorted := append([]shutdownEntry(nil), entries...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].priority < sorted[j].priority })
+	return sorted, nil, fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> "))
+}
+
+// findCycle returns the names along one dependency cycle among the entries
+// still waiting after ordering, in stopping order, e.g. [a b a].
+func findCycle(entries []shutdownEntry, preds [][]int, waits []int) []string {
+	start := -1
+	for i, n := range waits {
+		if n > 0 {
+			start = i
+			break
+		}
+	}
+	// An entry still waits only for entries that still wait, so following
+	// them from any waiting entry must come back around
+	seen := make(map[int]int)
+	var path []int
+	for i := start; ; {
+		if at, ok := seen[i]; ok {
+			path = append(path[at:], i)
+			break
+		}
+		seen[i] = len(path)
+		path = append(path, i)
+		for _, p := range preds[i] {
+			if waits[p] > 0 {
+				i = p
+				break
+			}
+		}
+	}
+	// The path runs against stopping order
+	names := make([]string, len(path))
+	for i, p := range path {
+		names[len(path)-1-i] = entries[p].name
+	}
+	return names
+}
+
+// dedupe returns names without repeats, in order.
+func dedupe(names []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, n := range names {
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// writePlan writes steps as a numbered list, one component per line.
+func writePlan(w io.Writer, steps []PlanStep) error {
+	width := 0
+	for _, s := range steps {
+		if len(s.Name) > width {
+			width = len(s.Name)
+		}
+	}
+	for i, s := range steps {
+		line := fmt.Sprintf("%2d. %-*s  priority %d", i+1, width, s.Name, s.Priority)
+		if len(s.After) > 0 {
+			line += "  after " + strings.Join(s.After, ", ")
+		}
+		if s.Timeout > 0 {
+			line += "  timeout " + s.Timeout.String()
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
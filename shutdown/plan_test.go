@@ -0,0 +1,119 @@
+package shutdown
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func noop(ctx context.Context) error { return nil }
+
+func TestShutdownRegistry_DependenciesOverridePriority(t *testing.T) {
+	registry := NewShutdownRegistry()
+
+	registry.Register("database", PriorityConnections, noop)
+	registry.Register("writer", PriorityResources, noop, StopBefore("database"))
+	registry.Register("server", PriorityServices, noop)
+	registry.Register("client", PriorityCritical, noop, StopAfter("server", "missing"))
+
+	names := registry.Names()
+	expected := []string{"server", "client", "writer", "database"}
+	if strings.Join(names, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+
+	steps, err := registry.Plan()
+	if err != nil {
+		t.Fatalf("Plan() error: %v", err)
+	}
+	if got := steps[3].After; len(got) != 1 || got[0] != "writer" {
+		t.Errorf("database After = %v, want [writer]", got)
+	}
+	if got := steps[1].After; len(got) != 1 || got[0] != "server" {
+		t.Errorf("client After = %v, want [server]", got)
+	}
+}
+
+func TestShutdownRegistry_DependencyCycle(t *testing.T) {
+	registry := NewShutdownRegistry()
+
+	var order []string
+	record := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	registry.Register("c", 30, record("c"))
+	registry.Register("a", 10, record("a"), StopBefore("b"))
+	registry.Register("b", 20, record("b"), StopBefore("a"))
+
+	if _, err := registry.Plan(); !errors.Is(err, ErrDependencyCycle) || !strings.Contains(err.Error(), "a -> b -> a") {
+		t.Errorf("Plan() error = %v, want cycle a -> b -> a", err)
+	}
+
+	errs := registry.Shutdown(context.Background())
+	if len(errs) != 1 || !errors.Is(errs[0], ErrDependencyCycle) {
+		t.Errorf("Shutdown() errors = %v, want the cycle", errs)
+	}
+	if strings.Join(order, " ") != "a b c" {
+		t.Errorf("expected fallback to priority order, got %v", order)
+	}
+}
+
+func TestShutdownRegistry_StopTimeout(t *testing.T) {
+	registry := NewShutdownRegistry()
+
+	ran := false
+	registry.Register("stuck", 10, func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, StopTimeout(20*time.Millisecond))
+	registry.Register("next", 20, func(ctx context.Context) error {
+		ran = true
+		return nil
+	})
+
+	start := time.Now()
+	errs := registry.Shutdown(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("shutdown waited %v for a stuck component", elapsed)
+	}
+	if len(errs) != 1 || !errors.Is(errs[0], ErrComponentTimeout) || !strings.HasPrefix(errs[0].Error(), "stuck:") {
+		t.Errorf("expected a timeout error for stuck, got %v", errs)
+	}
+	if !ran {
+		t.Error("component after the timed out one did not run")
+	}
+}
+
+func TestManager_WritePlan(t *testing.T) {
+	manager := NewManager(zap.NewNop())
+	manager.Register("database", PriorityResources, noop)
+	manager.Register("async-writer", PriorityConnections, noop,
+		StopBefore("database"), StopTimeout(5*time.Second))
+
+	var buf bytes.Buffer
+	if err := manager.WritePlan(&buf); err != nil {
+		t.Fatalf("WritePlan() error: %v", err)
+	}
+	expected := " 1. async-writer  priority 10  timeout 5s\n" +
+		" 2. database      priority 30  after async-writer\n"
+	if buf.String() != expected {
+		t.Errorf("WritePlan() wrote\n%s\nwant\n%s", buf.String(), expected)
+	}
+
+	manager.Register("cache", PriorityServices, noop, StopAfter("database"), StopBefore("async-writer"))
+	buf.Reset()
+	if err := manager.WritePlan(&buf); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("WritePlan() error = %v, want a cycle", err)
+	}
+	if !strings.Contains(buf.String(), "falls back to priority order") {
+		t.Errorf("WritePlan() did not report the cycle:\n%s", buf.String())
+	}
+}
@@ -2,8 +2,9 @@ package shutdown
 
 import (
 	"context"
-	"sort"
+	"fmt"
 	"sync"
+	"time"
 
 	"go_backend/core"
 )
@@ -13,12 +14,17 @@ type shutdownEntry struct {
 	name     string
 	fn       core.ShutdownFunc
 	priority int // lower = earlier execution
+
+	before  []string      // components that stop after this one
+	after   []string      // components that stop before this one
+	timeout time.Duration // 0 = no limit of its own
 }
 
 // ShutdownRegistry maintains an ordered collection of shutdown functions.
 //
-// This is a molecule that composes core.ShutdownFunc with priority ordering
-// and thread-safe registration to coordinate cleanup during graceful shutdown.
+// This is a molecule that composes core.ShutdownFunc with priority and
+// dependency ordering (plan.go) and thread-safe registration to coordinate
+// cleanup during graceful shutdown.
 //
 // Usage:
 //
@@ -30,7 +36,7 @@ type shutdownEntry struct {
 //	})
 //	registry.Register("database", 20, func(ctx context.Context) error {
 //	    return db.Close()
-//	})
+//	}, StopAfter("async-writer"))
 //
 //	// During shutdown:
 //	errs := registry.Shutdown(ctx)
@@ -51,7 +57,8 @@ func NewShutdownRegistry() *ShutdownRegistry {
 }
 
 // Register adds a shutdown function with a name and priority.
-// Lower priority values execute earlier during shutdown.
+// Lower priority values execute earlier during shutdown, unless options
+// such as StopBefore and StopAfter order the component otherwise.
 // Registration after Shutdown has been called is a no-op.
 //
 // Typical priority ranges:
@@ -60,7 +67,7 @@ func NewShutdownRegistry() *ShutdownRegistry {
 //   - 20-29: Service cleanup (stop background workers)
 //   - 30-39: Resource cleanup (close databases, files)
 //   - 40+: Final cleanup (release locks, remove temp files)
-func (r *ShutdownRegistry) Register(name string, priority int, fn core.ShutdownFunc) {
+func (r *ShutdownRegistry) Register(name string, priority int, fn core.ShutdownFunc, opts ...ComponentOption) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
@@ -68,18 +75,25 @@ func (r *ShutdownRegistry) Register(name string, priority int, fn core.ShutdownF
 		return
 	}
 
-	r.entries = append(r.entries, shutdownEntry{
+	entry := shutdownEntry{
 		name:     name,
 		fn:       fn,
 		priority: priority,
-	})
+	}
+	for _, opt := range opts {
+		opt(&entry)
+	}
+	r.entries = append(r.entries, entry)
 }
 
-// Shutdown executes all registered shutdown functions in priority order.
-// Returns a slice of errors from functions that failed (nil entries omitted).
-// Each function receives the provided context for cancellation/timeout.
+// Shutdown executes all registered shutdown functions in plan order (see
+// Plan). Returns a slice of errors from functions that failed (nil entries
+// omitted). Each function receives the provided context for
+// cancellation/timeout, limited further by its StopTimeout.
 //
 // All functions are called even if some fail. Errors are collected and returned.
+// If the dependencies form a cycle, the functions run in priority order and
+// the ErrDependencyCycle error is returned first.
 // After Shutdown completes, the registry is marked closed.
 func (r *ShutdownRegistry) Shutdown(ctx context.Context) []error {
 	r.mu.Lock()
@@ -89,18 +103,15 @@ func (r *ShutdownRegistry) Shutdown(ctx context.Context) []error {
 	}
 	r.closed = true
 
-	// Copy and sort entries by priority
-	sorted := make([]shutdownEntry, len(r.entries))
-	copy(sorted, r.entries)
+	ordered, _, planErr := plan(r.entries)
 	r.mu.Unlock()
 
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].priority < sorted[j].priority
-	})
-
 	var errs []error
-	for _, entry := range sorted {
-		if err := entry.fn(ctx); err != nil {
+	if planErr != nil {
+		errs = append(errs, planErr)
+	}
+	for _, entry := range ordered {
+		if err := runEntry(ctx, entry); err != nil {
 			errs = append(errs, err)
 		}
 	}
@@ -108,24 +119,53 @@ func (r *ShutdownRegistry) Shutdown(ctx context.Context) []error {
 	return errs
 }
 
-// Names returns the names of all registered shutdown functions in priority order.
+// runEntry runs a shutdown function, giving up after its StopTimeout.
+// A function that ignores its context keeps running in the background.
+func runEntry(ctx context.Context, entry shutdownEntry) error {
+	if entry.timeout <= 0 {
+		return entry.fn(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, entry.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- entry.fn(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w after %s", entry.name, ErrComponentTimeout, entry.timeout)
+	}
+}
+
+// Names returns the names of all registered shutdown functions in the
+// order they run.
 func (r *ShutdownRegistry) Names() []string {
+	steps, _ := r.Plan()
+	names := make([]string, len(steps))
+	for i, step := range steps {
+		names[i] = step.Name
+	}
+	return names
+}
+
+// Plan returns the order the shutdown functions will run in, with the
+// dependencies and timeout of each, without running them. If the
+// dependencies form a cycle, the priority order Shutdown falls back to is
+// returned with an ErrDependencyCycle error naming the cycle.
+func (r *ShutdownRegistry) Plan() ([]PlanStep, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Copy and sort entries by priority
-	sorted := make([]shutdownEntry, len(r.entries))
-	copy(sorted, r.entries)
-
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].priority < sorted[j].priority
-	})
-
-	names := make([]string, len(sorted))
-	for i, entry := range sorted {
-		names[i] = entry.name
+	ordered, deps, err := plan(r.entries)
+	steps := make([]PlanStep, len(ordered))
+	for i, entry := range ordered {
+		steps[i] = PlanStep{Name: entry.name, Priority: entry.priority, Timeout: entry.timeout}
+		if deps != nil {
+			steps[i].After = deps[i]
+		}
 	}
-	return names
+	return steps, err
 }
 
 // Count returns the number of registered shutdown functions.
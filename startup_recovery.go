@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go_backend/canvusapi"
+	"go_backend/core"
+	"go_backend/db"
+	"go_backend/i18n"
+	"go_backend/logging"
+	"go_backend/recovery"
+	"go_backend/shutdown"
+	"go_backend/tempfiles"
+
+	"go.uber.org/zap"
+)
+
+// recoveryTimeout bounds the startup recovery pass, which makes a Canvus
+// request per interrupted task.
+const recoveryTimeout = time.Minute
+
+// runStartupRecovery creates this run's marker, requeues or fails the tasks
+// the previous run left in the task journal, and starts journaling this
+// run's tasks. It returns the summary for the dashboard, or nil if the
+// journal could not be read. It must run before the monitor reads the
+// canvas, which runs the requeued triggers again.
+func runStartupRecovery(manager *shutdown.Manager, logger *logging.Logger, config *core.Config, client *canvusapi.Client,
+	repository *db.Repository, monitor *Monitor, tempFiles *tempfiles.TempFileManager) *recovery.Summary {
+	marker, found, err := recovery.CreateMarker(config.DownloadsDir)
+	if err != nil {
+		logger.Warn("Failed to create run marker", zap.Error(err))
+	}
+	// Removed last, so a marker left behind means shutdown did not finish
+	manager.Register("run-marker", shutdown.PriorityFinal, func(ctx context.Context) error {
+		return marker.Remove()
+	}, shutdown.StopAfter("cleanup-downloads"))
+
+	evidence := recovery.Evidence{MarkerFound: found}
+	if tempFiles != nil {
+		evidence.TempFiles = tempFiles.Swept()
+	}
+	canvas := &recoveryCanvas{client: client, config: config, log: logger}
+	recoverer := recovery.NewRecoverer(recovery.ConfigFromEnv(), repository, canvas, config.CanvasID, logger.Zap())
+
+	ctx, cancel := context.WithTimeout(manager.Context(), recoveryTimeout)
+	defer cancel()
+	summary, err := recoverer.Run(ctx, evidence)
+	monitor.SetTaskJournal(recovery.NewJournal(repository, logger.Zap()))
+	if err != nil {
+		logger.Warn("Startup recovery failed", zap.Error(err))
+		return nil
+	}
+
+	for _, out := range summary.Tasks {
+		if out.Action != recovery.ActionFailed {
+			continue
+		}
+		// Keep reading the canvas from running a failed trigger again
+		if out.Trigger != nil {
+			monitor.SkipWidget(out.Trigger)
+		}
+		recordProcessingHistory(ctx, repository, out.TaskID, out.CanvasID, out.WidgetID, "task_recovery",
+			"", "", "", 0, 0, 0, "error", out.TaskType+" task interrupted: "+out.Reason, logger)
+	}
+
+	if !summary.Unclean {
+		logger.Info("Previous run shut down cleanly")
+		return summary
+	}
+	logger.Warn("Previous run did not shut down cleanly; recovered its unfinished work",
+		zap.Bool("run_marker_found", found),
+		zap.Int("interrupted_tasks", len(summary.Tasks)),
+		zap.Int("requeued", summary.Requeued),
+		zap.Int("failed", summary.Failed),
+		zap.Int("processing_notes_removed", summary.NotesRemoved),
+		zap.Int("temp_files_removed", summary.TempFiles))
+	return summary
+}
+
+// recoveryCanvas is the monitored canvas as the recovery pass sees it.
+type recoveryCanvas struct {
+	client *canvusapi.Client
+	config *core.Config
+	log    *logging.Logger
+}
+
+// Widget returns a widget, or nil if it was deleted.
+func (c *recoveryCanvas) Widget(id string) (map[string]interface{}, error) {
+	widget, err := c.client.GetWidget(id, false)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	return widget, err
+}
+
+// RemoveNote deletes an orphaned processing note. A note already deleted
+// by hand is not an error.
+func (c *recoveryCanvas) RemoveNote(id string) error {
+	if err := c.client.DeleteNote(id); err != nil && !isNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// ReportFailure writes the failure to the task's processing note, or to a
+// new note next to the trigger if the task had none or it was deleted.
+func (c *recoveryCanvas) ReportFailure(entry recovery.Entry, trigger map[string]interface{}) error {
+	text := i18n.T(c.config.Language, i18n.MsgTaskInterrupted, entry.Attempts)
+	if entry.ProcessingNoteID != "" {
+		if err := updateProcessingNote(c.client, entry.ProcessingNoteID, text, c.config, c.log); err == nil {
+			return nil
+		}
+	}
+	return createRefusalNote(c.client, Update(trigger), text, c.config, c.log)
+}
+
+// isNotFound reports whether err is a Canvus 404.
+func isNotFound(err error) bool {
+	var apiErr *canvusapi.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,150 @@
+// Package streamstate is the state machine of the canvas monitor's widget
+// stream. The monitor reports every transition; the dashboard reads the
+// current state from /api/status and is told about changes through the
+// broadcaster. It uses only the standard library so the monitor and the web
+// UI can share it.
+package streamstate
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// State is the state of the widget stream.
+type State string
+
+const (
+	// StateConnecting means a stream connection is being opened
+	StateConnecting State = "connecting"
+	// StateStreaming means the stream is open and delivering updates
+	StateStreaming State = "streaming"
+	// StateDegraded means the stream is open but has gone quiet, e.g. after
+	// a network drop; it reconnects if no data arrives
+	StateDegraded State = "degraded"
+	// StateReconnecting means the stream was lost and the monitor waits to
+	// connect again
+	StateReconnecting State = "reconnecting"
+	// StateStopped means the monitor is not running
+	StateStopped State = "stopped"
+)
+
+// transitions lists the states each state may change to.
+var transitions = map[State][]State{
+	StateStopped:      {StateConnecting},
+	StateConnecting:   {StateStreaming, StateReconnecting, StateStopped},
+	StateStreaming:    {StateDegraded, StateReconnecting, StateStopped},
+	StateDegraded:     {StateStreaming, StateReconnecting, StateStopped},
+	StateReconnecting: {StateConnecting, StateStopped},
+}
+
+// maxRecent is how many transitions Status keeps.
+const maxRecent = 20
+
+// Transition is one state change.
+type Transition struct {
+	From   State     `json:"from"`
+	To     State     `json:"to"`
+	At     time.Time `json:"at"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// Status is the current state with its recent history.
+type Status struct {
+	State State `json:"state"`
+	// Since is when the current state was entered
+	Since time.Time `json:"since"`
+	// Reason explains the current state, e.g. the error that ended a stream
+	Reason string `json:"reason,omitempty"`
+	// Reconnects counts the connection attempts since the stream last
+	// streamed
+	Reconnects int `json:"reconnects"`
+	// LastStreaming is when the stream last started streaming
+	LastStreaming *time.Time `json:"last_streaming,omitempty"`
+	// Recent lists the latest transitions, oldest first
+	Recent []Transition `json:"recent,omitempty"`
+}
+
+// Machine tracks the state of the widget stream. It is safe for concurrent
+// use; the zero value is not usable, use New.
+type Machine struct {
+	mu       sync.RWMutex
+	status   Status
+	onChange func(Status)
+}
+
+// New returns a Machine in StateStopped.
+func New() *Machine {
+	return &Machine{status: Status{State: StateStopped, Since: time.Now()}}
+}
+
+// SetOnChange sets a callback run after every transition, e.g. to update
+// the dashboard.
+func (m *Machine) SetOnChange(fn func(Status)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = fn
+}
+
+// Status returns the current state. A nil Machine reports stopped.
+func (m *Machine) Status() Status {
+	if m == nil {
+		return Status{State: StateStopped}
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status := m.status
+	status.Recent = append([]Transition(nil), m.status.Recent...)
+	return status
+}
+
+// Transition changes the state to to, and returns the transition made. A
+// change the machine does not allow is refused with an error; changing to
+// the current state only updates the reason.
+func (m *Machine) Transition(to State, reason string) (Transition, error) {
+	m.mu.Lock()
+	from := m.status.State
+	if from == to {
+		m.status.Reason = reason
+		m.mu.Unlock()
+		return Transition{From: from, To: to, Reason: reason}, nil
+	}
+	if !allowed(from, to) {
+		m.mu.Unlock()
+		return Transition{}, fmt.Errorf("streamstate: invalid transition from %s to %s", from, to)
+	}
+
+	now := time.Now()
+	t := Transition{From: from, To: to, At: now, Reason: reason}
+	m.status.State = to
+	m.status.Since = now
+	m.status.Reason = reason
+	switch to {
+	case StateConnecting:
+		m.status.Reconnects++
+	case StateStreaming:
+		m.status.Reconnects = 0
+		m.status.LastStreaming = &now
+	}
+	m.status.Recent = append(m.status.Recent, t)
+	if len(m.status.Recent) > maxRecent {
+		m.status.Recent = m.status.Recent[len(m.status.Recent)-maxRecent:]
+	}
+	fn := m.onChange
+	m.mu.Unlock()
+
+	if fn != nil {
+		fn(m.Status())
+	}
+	return t, nil
+}
+
+// allowed reports whether the machine may change from one state to another.
+func allowed(from, to State) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package streamstate
+
+import (
+	"testing"
+)
+
+func TestMachineTransitions(t *testing.T) {
+	m := New()
+	var changes []State
+	m.SetOnChange(func(s Status) { changes = append(changes, s.State) })
+
+	steps := []struct {
+		to     State
+		reason string
+	}{
+		{StateConnecting, ""},
+		{StateStreaming, ""},
+		{StateDegraded, "no data for 30s"},
+		{StateStreaming, "data resumed"},
+		{StateReconnecting, "widget stream stalled"},
+		{StateConnecting, ""},
+		{StateReconnecting, "connection refused"},
+		{StateConnecting, ""},
+	}
+	for _, step := range steps {
+		if _, err := m.Transition(step.to, step.reason); err != nil {
+			t.Fatalf("Transition(%s) error = %v", step.to, err)
+		}
+	}
+
+	status := m.Status()
+	if status.State != StateConnecting || status.Reconnects != 2 {
+		t.Errorf("Status() = %s with %d reconnects, want connecting with 2", status.State, status.Reconnects)
+	}
+	if status.LastStreaming == nil {
+		t.Error("LastStreaming should be set")
+	}
+	if len(changes) != len(steps) || len(status.Recent) != len(steps) {
+		t.Errorf("got %d changes and %d recent transitions, want %d", len(changes), len(status.Recent), len(steps))
+	}
+	if r := status.Recent[2]; r.From != StateStreaming || r.To != StateDegraded || r.Reason != "no data for 30s" {
+		t.Errorf("Recent[2] = %+v", r)
+	}
+}
+
+func TestMachineRefusesInvalidTransition(t *testing.T) {
+	m := New()
+	if _, err := m.Transition(StateStreaming, ""); err == nil {
+		t.Error("stopped -> streaming should be refused")
+	}
+	if got := m.Status().State; got != StateStopped {
+		t.Errorf("state = %s after a refused transition, want stopped", got)
+	}
+
+	// Staying in a state only updates the reason
+	m.Transition(StateConnecting, "")
+	m.Transition(StateConnecting, "retrying")
+	if s := m.Status(); s.Reason != "retrying" || len(s.Recent) != 1 {
+		t.Errorf("Status() = %+v", s)
+	}
+}
+
+func TestMachineKeepsRecentTransitions(t *testing.T) {
+	m := New()
+	m.Transition(StateConnecting, "")
+	for i := 0; i < maxRecent; i++ {
+		m.Transition(StateReconnecting, "")
+		m.Transition(StateConnecting, "")
+	}
+	if got := len(m.Status().Recent); got != maxRecent {
+		t.Errorf("kept %d transitions, want %d", got, maxRecent)
+	}
+}
+
+func TestNilMachine(t *testing.T) {
+	var m *Machine
+	if got := m.Status().State; got != StateStopped {
+		t.Errorf("nil Machine state = %s, want stopped", got)
+	}
+}
@@ -0,0 +1,85 @@
+// Package stress provides a load harness for capacity planning.
+// This file contains the mock Canvus server results are written to.
+package stress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"go_backend/canvusapi"
+	"go_backend/core/clock"
+)
+
+// mockCanvasID is the canvas the mock server serves.
+const mockCanvasID = "stress"
+
+// MockCanvas is an in-process Canvus server that accepts note and image
+// creation, so a stress run exercises the client and upload path without
+// touching a real canvas.
+type MockCanvas struct {
+	server  *httptest.Server
+	latency time.Duration
+	clock   clock.Clock
+	nextID  atomic.Int64
+	writes  atomic.Int64
+}
+
+// NewMockCanvas starts a mock canvas that answers each request after
+// latency, to model the round trip to a real server.
+func NewMockCanvas(latency time.Duration) *MockCanvas {
+	m := &MockCanvas{latency: latency, clock: clock.Real()}
+	m.server = httptest.NewServer(http.HandlerFunc(m.serve))
+	return m
+}
+
+// SetClock replaces the clock that times the simulated latency, so a run
+// on a clock.Fake does not wait for it in real time. Call before use.
+func (m *MockCanvas) SetClock(c clock.Clock) {
+	m.clock = clock.OrReal(c)
+}
+
+// Client returns a Canvus API client for the mock canvas.
+func (m *MockCanvas) Client() *canvusapi.Client {
+	return canvusapi.NewClient(m.server.URL, mockCanvasID, "stress", false)
+}
+
+// Writes returns the number of widgets created so far.
+func (m *MockCanvas) Writes() int {
+	return int(m.writes.Load())
+}
+
+// Close shuts the server down.
+func (m *MockCanvas) Close() {
+	m.server.Close()
+}
+
+func (m *MockCanvas) serve(w http.ResponseWriter, r *http.Request) {
+	io.Copy(io.Discard, r.Body)
+	if m.latency > 0 {
+		select {
+		case <-m.clock.After(m.latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	widgetType := map[string]string{"notes": "Note", "images": "Image"}[strings.TrimPrefix(r.URL.Path, "/api/v1/canvases/"+mockCanvasID+"/")]
+	if r.Method != http.MethodPost || widgetType == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	m.writes.Add(1)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":          fmt.Sprintf("stress-%d", m.nextID.Add(1)),
+		"widget_type": widgetType,
+		"state":       "normal",
+	})
+}
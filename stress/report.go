@@ -0,0 +1,155 @@
+// Package stress provides a load harness for capacity planning. It replays
+// note triggers, PDF precis and image jobs at configured rates through the
+// local models, writes the results to a mock canvas, and reports throughput,
+// latency percentiles, queue depths and GPU usage.
+//
+// This file contains the report types and the pure statistics atoms.
+package stress
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// Job kinds reported by the harness.
+const (
+	KindNote  = "note"
+	KindPDF   = "pdf"
+	KindImage = "image"
+)
+
+// Report is the result of a stress run.
+type Report struct {
+	Timestamp time.Time `json:"timestamp"`
+	Hostname  string    `json:"hostname,omitempty"`
+	// Duration is the whole run, including draining the queues after the
+	// load stopped.
+	Duration string `json:"duration"`
+
+	Settings Settings      `json:"settings"`
+	Jobs     []JobResult   `json:"jobs"`
+	Queues   []QueueResult `json:"queues"`
+	GPU      *GPUResult    `json:"gpu,omitempty"`
+
+	// CanvasWrites counts the widgets created on the mock canvas.
+	CanvasWrites int `json:"canvas_writes"`
+
+	// Notes highlights queues that could not keep up.
+	Notes []string `json:"notes,omitempty"`
+
+	// Errors lists distinct job errors, at most maxReportedErrors.
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Settings records the load the run was configured with.
+type Settings struct {
+	LoadDuration string  `json:"load_duration"`
+	NoteRate     float64 `json:"note_rate_per_min"`
+	PDFRate      float64 `json:"pdf_rate_per_min"`
+	ImageRate    float64 `json:"image_rate_per_min"`
+	PDFChunks    int     `json:"pdf_chunks"`
+	TextWorkers  int     `json:"text_workers"`
+	ImageWorkers int     `json:"image_workers"`
+}
+
+// JobResult holds the measurements for one job kind.
+type JobResult struct {
+	Kind      string `json:"kind"`
+	Submitted int    `json:"submitted"`
+	Completed int    `json:"completed"`
+	Failed    int    `json:"failed"`
+	// Dropped counts jobs rejected because their queue was full.
+	Dropped int `json:"dropped"`
+
+	// OfferedPerMinute is the configured arrival rate; PerMinute is the
+	// rate jobs actually completed at.
+	OfferedPerMinute float64 `json:"offered_per_min"`
+	PerMinute        float64 `json:"completed_per_min"`
+
+	// Latency runs from arrival to completion; QueueWait from arrival to
+	// a worker picking the job up.
+	Latency   Percentiles `json:"latency_secs"`
+	QueueWait Percentiles `json:"queue_wait_secs"`
+}
+
+// Percentiles summarizes a set of durations, in seconds.
+type Percentiles struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+// QueueResult holds the depth measurements of one worker queue.
+type QueueResult struct {
+	Name     string  `json:"name"`
+	Workers  int     `json:"workers"`
+	MaxDepth int     `json:"max_depth"`
+	AvgDepth float64 `json:"avg_depth"`
+	// EndDepth is the depth when the load stopped; a queue that is still
+	// long then was not keeping up.
+	EndDepth int `json:"end_depth"`
+}
+
+// GPUResult holds GPU usage sampled during the run.
+type GPUResult struct {
+	Name           string  `json:"name"`
+	TotalVRAMBytes int64   `json:"total_vram_bytes"`
+	PeakVRAMBytes  int64   `json:"peak_vram_bytes"`
+	AvgUtilization float64 `json:"avg_utilization"`
+	MaxUtilization float64 `json:"max_utilization"`
+	Samples        int     `json:"samples"`
+}
+
+// WriteJSON writes the report as indented JSON to path.
+func (r *Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal report: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	return nil
+}
+
+// ComputePercentiles returns the nearest-rank percentiles of durations.
+// An empty slice gives zero values. durations is not modified.
+func ComputePercentiles(durations []time.Duration) Percentiles {
+	if len(durations) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := func(p float64) float64 {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if i < 0 {
+			i = 0
+		}
+		return sorted[i].Seconds()
+	}
+	return Percentiles{
+		P50: rank(0.50),
+		P90: rank(0.90),
+		P99: rank(0.99),
+		Max: sorted[len(sorted)-1].Seconds(),
+	}
+}
+
+// queueNotes describes queues that fell behind their load.
+func queueNotes(queues []QueueResult) []string {
+	var notes []string
+	for _, q := range queues {
+		if q.EndDepth > q.Workers {
+			notes = append(notes, fmt.Sprintf(
+				"%s queue did not keep up: %d jobs waiting when the load stopped (peak %d, %d workers)",
+				q.Name, q.EndDepth, q.MaxDepth, q.Workers))
+		}
+	}
+	return notes
+}
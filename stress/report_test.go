@@ -0,0 +1,40 @@
+package stress
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComputePercentiles(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Second)
+	}
+
+	got := ComputePercentiles(durations)
+	want := Percentiles{P50: 50, P90: 90, P99: 99, Max: 100}
+	if got != want {
+		t.Errorf("ComputePercentiles() = %+v, want %+v", got, want)
+	}
+	if durations[0] != 100*time.Second {
+		t.Error("ComputePercentiles() modified its input")
+	}
+
+	if got := ComputePercentiles([]time.Duration{2 * time.Second}); got != (Percentiles{P50: 2, P90: 2, P99: 2, Max: 2}) {
+		t.Errorf("single sample = %+v", got)
+	}
+	if got := ComputePercentiles(nil); got != (Percentiles{}) {
+		t.Errorf("no samples = %+v", got)
+	}
+}
+
+func TestQueueNotes(t *testing.T) {
+	notes := queueNotes([]QueueResult{
+		{Name: "text", Workers: 2, MaxDepth: 2, EndDepth: 1},
+		{Name: "image", Workers: 1, MaxDepth: 9, EndDepth: 8},
+	})
+	if len(notes) != 1 || !strings.HasPrefix(notes[0], "image queue did not keep up: 8 jobs waiting") {
+		t.Errorf("queueNotes() = %q", notes)
+	}
+}
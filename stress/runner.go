@@ -0,0 +1,589 @@
+// Package stress provides a load harness for capacity planning.
+// This file contains the Runner organism that generates the load.
+package stress
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go_backend/core/clock"
+	"go_backend/llamaruntime"
+	"go_backend/metrics"
+	"go_backend/sdruntime"
+)
+
+// notePrompt is the prompt of a simulated note trigger.
+const notePrompt = "Suggest three agenda items for a one hour design review of a new mobile app onboarding flow."
+
+// pdfChunkText is the text of one simulated PDF chunk, close to the chunk
+// size the PDF precis pipeline sends.
+const pdfChunkText = "Collaborative whiteboards let distributed teams sketch, annotate and " +
+	"organize ideas in real time. Sessions mix sticky notes, images, PDFs and video, " +
+	"and participants move between brainstorming, clustering and prioritizing. " +
+	"Facilitators report that shared visual context shortens meetings, while " +
+	"asynchronous contributors rely on summaries of what changed since they last looked. " +
+	"Large canvases raise questions about navigation, ownership of content and how " +
+	"decisions are recorded once a workshop ends."
+
+// imagePrompt is the prompt of a simulated image job.
+const imagePrompt = "a watercolor painting of a lighthouse on a rocky coast at sunset"
+
+// maxReportedErrors caps the distinct errors kept in the report.
+const maxReportedErrors = 10
+
+// queueCapacity is how many jobs a queue holds before new ones are dropped.
+const queueCapacity = 10000
+
+// TextGenerator runs text inference (implemented by *llamaruntime.Client).
+type TextGenerator interface {
+	Infer(ctx context.Context, params llamaruntime.InferenceParams) (*llamaruntime.InferenceResult, error)
+}
+
+// ImageGenerator renders images (implemented by *sdruntime.ContextPool).
+type ImageGenerator interface {
+	Generate(ctx context.Context, params sdruntime.GenerateParams) ([]byte, error)
+}
+
+// Canvas receives job results (implemented by *canvusapi.Client).
+type Canvas interface {
+	CreateNote(payload map[string]interface{}) (map[string]interface{}, error)
+	CreateImage(filePath string, metadata map[string]interface{}) (map[string]interface{}, error)
+}
+
+// GPUReader reads GPU metrics (implemented by *metrics.NVMLReader).
+type GPUReader interface {
+	ReadGPUMetrics() (metrics.GPUMetrics, error)
+}
+
+// Config configures a stress run. Job kinds without a generator or with a
+// zero rate are not generated.
+type Config struct {
+	// Duration is how long jobs arrive (default: 1 minute). The run then
+	// waits for the queues to drain.
+	Duration time.Duration
+
+	// NoteRate, PDFRate and ImageRate are job arrivals per minute.
+	NoteRate  float64
+	PDFRate   float64
+	ImageRate float64
+
+	// PDFChunks is the number of chunk summaries per PDF precis (default: 4).
+	PDFChunks int
+
+	// MaxTokens is the token budget per text generation (default: 128).
+	MaxTokens int
+
+	// TextWorkers is how many text jobs run at once (default: 1); note and
+	// PDF jobs share these workers.
+	TextWorkers int
+
+	// ImageWorkers is how many image jobs run at once (default: 1).
+	ImageWorkers int
+
+	// ImageSize is the image width and height in pixels (default: 512).
+	ImageSize int
+
+	// ImageSteps is the number of inference steps per image (default: 20).
+	ImageSteps int
+
+	// Text generates notes and PDF summaries.
+	Text TextGenerator
+
+	// Image generates images.
+	Image ImageGenerator
+
+	// Canvas receives the results; required.
+	Canvas Canvas
+
+	// GPU reads GPU usage; nil disables GPU measurements.
+	GPU GPUReader
+
+	// SampleInterval is how often queue depths and the GPU are sampled
+	// (default: 500ms).
+	SampleInterval time.Duration
+
+	// Logf receives progress messages; may be nil.
+	Logf func(format string, args ...interface{})
+
+	// Clock times the run, the arrivals, the samples and the job
+	// latencies (default: the system clock). With a clock.Fake, and
+	// generators that wait on it, a long run can be simulated in moments.
+	Clock clock.Clock
+}
+
+// withDefaults returns a copy of the config with defaults applied.
+func (c Config) withDefaults() Config {
+	if c.Duration <= 0 {
+		c.Duration = time.Minute
+	}
+	if c.PDFChunks <= 0 {
+		c.PDFChunks = 4
+	}
+	if c.MaxTokens <= 0 {
+		c.MaxTokens = 128
+	}
+	if c.TextWorkers <= 0 {
+		c.TextWorkers = 1
+	}
+	if c.ImageWorkers <= 0 {
+		c.ImageWorkers = 1
+	}
+	if c.ImageSize <= 0 {
+		c.ImageSize = sdruntime.DefaultImageSize
+	}
+	if c.ImageSteps <= 0 {
+		c.ImageSteps = sdruntime.DefaultInferenceSteps
+	}
+	if c.SampleInterval <= 0 {
+		c.SampleInterval = 500 * time.Millisecond
+	}
+	if c.Logf == nil {
+		c.Logf = func(string, ...interface{}) {}
+	}
+	c.Clock = clock.OrReal(c.Clock)
+	if c.Text == nil {
+		c.NoteRate, c.PDFRate = 0, 0
+	}
+	if c.Image == nil {
+		c.ImageRate = 0
+	}
+	return c
+}
+
+// job is one simulated request.
+type job struct {
+	kind    string
+	arrived time.Time
+}
+
+// jobStats collects the measurements of one job kind.
+type jobStats struct {
+	mu        sync.Mutex
+	submitted int
+	completed int
+	failed    int
+	dropped   int
+	latency   []time.Duration
+	wait      []time.Duration
+}
+
+// queue is a worker queue with its depth samples.
+type queue struct {
+	name    string
+	workers int
+	jobs    chan job
+	depth   atomic.Int64
+
+	maxDepth int
+	sumDepth int64
+	samples  int
+	endDepth int
+}
+
+// runner holds the state of one run.
+type runner struct {
+	config  Config
+	workDir string
+	stats   map[string]*jobStats
+	text    *queue
+	image   *queue
+
+	canvasWrites atomic.Int64
+
+	errMu  sync.Mutex
+	errors []string
+}
+
+// Run generates the configured load until config.Duration has passed, waits
+// for the queued jobs to finish and returns the report. Failing jobs are
+// counted rather than aborting the run; an error is returned only if
+// nothing is configured or ctx ends.
+func Run(ctx context.Context, config Config) (*Report, error) {
+	config = config.withDefaults()
+	if config.Canvas == nil {
+		return nil, errors.New("stress: no canvas configured")
+	}
+	if config.NoteRate <= 0 && config.PDFRate <= 0 && config.ImageRate <= 0 {
+		return nil, errors.New("stress: no load configured")
+	}
+
+	workDir, err := os.MkdirTemp("", "canvus-stress-*")
+	if err != nil {
+		return nil, fmt.Errorf("stress: create work directory: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	r := &runner{
+		config:  config,
+		workDir: workDir,
+		stats: map[string]*jobStats{
+			KindNote:  {},
+			KindPDF:   {},
+			KindImage: {},
+		},
+		text:  &queue{name: "text", workers: config.TextWorkers, jobs: make(chan job, queueCapacity)},
+		image: &queue{name: "image", workers: config.ImageWorkers, jobs: make(chan job, queueCapacity)},
+	}
+
+	start := config.Clock.Now()
+	report := &Report{
+		Timestamp: start,
+		Settings: Settings{
+			LoadDuration: config.Duration.String(),
+			NoteRate:     config.NoteRate,
+			PDFRate:      config.PDFRate,
+			ImageRate:    config.ImageRate,
+			PDFChunks:    config.PDFChunks,
+			TextWorkers:  config.TextWorkers,
+			ImageWorkers: config.ImageWorkers,
+		},
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		report.Hostname = hostname
+	}
+
+	var workers sync.WaitGroup
+	for _, q := range []*queue{r.text, r.image} {
+		for i := 0; i < q.workers; i++ {
+			workers.Add(1)
+			go func(q *queue) {
+				defer workers.Done()
+				r.work(ctx, q)
+			}(q)
+		}
+	}
+
+	sampler := newSampler(config.GPU)
+	samplerDone := make(chan struct{})
+	samplerStopped := make(chan struct{})
+	go func() {
+		defer close(samplerStopped)
+		ticker := config.Clock.NewTicker(config.SampleInterval)
+		defer ticker.Stop()
+		for {
+			sampler.read()
+			r.text.sample()
+			r.image.sample()
+			select {
+			case <-samplerDone:
+				return
+			case <-ticker.C():
+			}
+		}
+	}()
+
+	config.Logf("load: %.1f notes, %.1f PDFs, %.1f images per minute for %v",
+		config.NoteRate, config.PDFRate, config.ImageRate, config.Duration)
+	loadCtx, stopLoad := context.WithCancel(ctx)
+	loadTimer := config.Clock.NewTimer(config.Duration)
+	go func() {
+		select {
+		case <-loadTimer.C():
+			stopLoad()
+		case <-loadCtx.Done():
+		}
+	}()
+	var arrivals sync.WaitGroup
+	for kind, rate := range map[string]float64{KindNote: config.NoteRate, KindPDF: config.PDFRate, KindImage: config.ImageRate} {
+		if rate <= 0 {
+			continue
+		}
+		arrivals.Add(1)
+		go func(kind string, rate float64) {
+			defer arrivals.Done()
+			r.arrive(loadCtx, kind, rate)
+		}(kind, rate)
+	}
+	arrivals.Wait()
+	stopLoad()
+	loadTimer.Stop()
+
+	r.text.endDepth = int(r.text.depth.Load())
+	r.image.endDepth = int(r.image.depth.Load())
+	config.Logf("load stopped; draining %d text and %d image jobs", r.text.endDepth, r.image.endDepth)
+	close(r.text.jobs)
+	close(r.image.jobs)
+	workers.Wait()
+	close(samplerDone)
+	<-samplerStopped
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	elapsed := config.Clock.Since(start)
+	for _, kind := range []string{KindNote, KindPDF, KindImage} {
+		report.Jobs = append(report.Jobs, r.result(kind, elapsed))
+	}
+	report.Queues = []QueueResult{r.text.result(), r.image.result()}
+	report.GPU = sampler.result()
+	report.Notes = queueNotes(report.Queues)
+	report.Errors = r.errors
+	report.CanvasWrites = int(r.canvasWrites.Load())
+	report.Duration = elapsed.Round(time.Millisecond).String()
+	return report, nil
+}
+
+// arrive submits jobs of kind at rate per minute until ctx ends.
+func (r *runner) arrive(ctx context.Context, kind string, rate float64) {
+	ticker := r.config.Clock.NewTicker(time.Duration(float64(time.Minute) / rate))
+	defer ticker.Stop()
+	for {
+		r.submit(kind)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+// submit queues a job, or drops it when its queue is full.
+func (r *runner) submit(kind string) {
+	q := r.text
+	if kind == KindImage {
+		q = r.image
+	}
+	stats := r.stats[kind]
+
+	// Count the job before a worker can take it, so depth never goes negative
+	q.depth.Add(1)
+	select {
+	case q.jobs <- job{kind: kind, arrived: r.config.Clock.Now()}:
+		stats.mu.Lock()
+		stats.submitted++
+		stats.mu.Unlock()
+	default:
+		q.depth.Add(-1)
+		stats.mu.Lock()
+		stats.dropped++
+		stats.mu.Unlock()
+	}
+}
+
+// work runs jobs from q until it is closed.
+func (r *runner) work(ctx context.Context, q *queue) {
+	for j := range q.jobs {
+		q.depth.Add(-1)
+		started := r.config.Clock.Now()
+		var err error
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = r.run(ctx, j)
+		}
+
+		stats := r.stats[j.kind]
+		stats.mu.Lock()
+		if err != nil {
+			stats.failed++
+		} else {
+			stats.completed++
+			stats.latency = append(stats.latency, r.config.Clock.Since(j.arrived))
+			stats.wait = append(stats.wait, started.Sub(j.arrived))
+		}
+		stats.mu.Unlock()
+		if err != nil {
+			r.recordError(fmt.Errorf("%s: %w", j.kind, err))
+		}
+	}
+}
+
+// run executes one job the way the canvas monitor would: generate, then
+// write the result to the canvas.
+func (r *runner) run(ctx context.Context, j job) error {
+	switch j.kind {
+	case KindNote:
+		result, err := r.infer(ctx, notePrompt)
+		if err != nil {
+			return err
+		}
+		return r.writeNote(result)
+
+	case KindPDF:
+		summaries := ""
+		for i := 0; i < r.config.PDFChunks; i++ {
+			summary, err := r.infer(ctx, "Summarize this section of a document:\n\n"+pdfChunkText)
+			if err != nil {
+				return err
+			}
+			summaries += summary + "\n"
+		}
+		precis, err := r.infer(ctx, "Combine these section summaries into a precis:\n\n"+summaries)
+		if err != nil {
+			return err
+		}
+		return r.writeNote(precis)
+
+	case KindImage:
+		data, err := r.config.Image.Generate(ctx, sdruntime.GenerateParams{
+			Prompt:   imagePrompt,
+			Width:    r.config.ImageSize,
+			Height:   r.config.ImageSize,
+			Steps:    r.config.ImageSteps,
+			CFGScale: sdruntime.DefaultGuidanceScale,
+			Seed:     -1,
+		})
+		if err != nil {
+			return err
+		}
+		file, err := os.CreateTemp(r.workDir, "image-*.png")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(file.Name())
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := r.config.Canvas.CreateImage(file.Name(), map[string]interface{}{
+			"title": "stress: " + filepath.Base(file.Name()),
+		}); err != nil {
+			return err
+		}
+		r.canvasWrites.Add(1)
+		return nil
+	}
+	return fmt.Errorf("unknown job kind %q", j.kind)
+}
+
+// infer runs one text generation and returns its text.
+func (r *runner) infer(ctx context.Context, prompt string) (string, error) {
+	result, err := r.config.Text.Infer(ctx, llamaruntime.InferenceParams{
+		Prompt:      prompt,
+		MaxTokens:   r.config.MaxTokens,
+		Temperature: 0.7,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Text, nil
+}
+
+// writeNote creates a note holding text on the canvas.
+func (r *runner) writeNote(text string) error {
+	if _, err := r.config.Canvas.CreateNote(map[string]interface{}{
+		"title": "stress",
+		"text":  text,
+	}); err != nil {
+		return err
+	}
+	r.canvasWrites.Add(1)
+	return nil
+}
+
+// recordError keeps the first distinct errors for the report.
+func (r *runner) recordError(err error) {
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+	msg := err.Error()
+	for _, e := range r.errors {
+		if e == msg {
+			return
+		}
+	}
+	if len(r.errors) < maxReportedErrors {
+		r.errors = append(r.errors, msg)
+	}
+}
+
+// result summarizes the measurements of one job kind.
+func (r *runner) result(kind string, elapsed time.Duration) JobResult {
+	stats := r.stats[kind]
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	offered := map[string]float64{KindNote: r.config.NoteRate, KindPDF: r.config.PDFRate, KindImage: r.config.ImageRate}[kind]
+	result := JobResult{
+		Kind:             kind,
+		Submitted:        stats.submitted,
+		Completed:        stats.completed,
+		Failed:           stats.failed,
+		Dropped:          stats.dropped,
+		OfferedPerMinute: offered,
+		Latency:          ComputePercentiles(stats.latency),
+		QueueWait:        ComputePercentiles(stats.wait),
+	}
+	if elapsed > 0 {
+		result.PerMinute = float64(stats.completed) / elapsed.Minutes()
+	}
+	return result
+}
+
+// sample records the current depth of q.
+func (q *queue) sample() {
+	depth := int(q.depth.Load())
+	if depth > q.maxDepth {
+		q.maxDepth = depth
+	}
+	q.sumDepth += int64(depth)
+	q.samples++
+}
+
+// result summarizes the depth samples of q.
+func (q *queue) result() QueueResult {
+	result := QueueResult{Name: q.name, Workers: q.workers, MaxDepth: q.maxDepth, EndDepth: q.endDepth}
+	if q.samples > 0 {
+		result.AvgDepth = float64(q.sumDepth) / float64(q.samples)
+	}
+	return result
+}
+
+// sampler collects GPU usage samples.
+type sampler struct {
+	gpu     GPUReader
+	name    string
+	total   int64
+	peak    int64
+	sumUtil float64
+	maxUtil float64
+	samples int
+}
+
+func newSampler(gpu GPUReader) *sampler {
+	return &sampler{gpu: gpu}
+}
+
+// read takes one GPU sample; errors skip the sample.
+func (s *sampler) read() {
+	if s.gpu == nil {
+		return
+	}
+	m, err := s.gpu.ReadGPUMetrics()
+	if err != nil {
+		return
+	}
+	s.name, s.total = m.Name, m.MemoryTotal
+	if m.MemoryUsed > s.peak {
+		s.peak = m.MemoryUsed
+	}
+	if m.Utilization > s.maxUtil {
+		s.maxUtil = m.Utilization
+	}
+	s.sumUtil += m.Utilization
+	s.samples++
+}
+
+// result summarizes the GPU samples, or returns nil without any.
+func (s *sampler) result() *GPUResult {
+	if s.samples == 0 {
+		return nil
+	}
+	return &GPUResult{
+		Name:           s.name,
+		TotalVRAMBytes: s.total,
+		PeakVRAMBytes:  s.peak,
+		AvgUtilization: s.sumUtil / float64(s.samples),
+		MaxUtilization: s.maxUtil,
+		Samples:        s.samples,
+	}
+}
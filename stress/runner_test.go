@@ -0,0 +1,218 @@
+package stress
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+	"go_backend/llamaruntime"
+	"go_backend/metrics"
+	"go_backend/sdruntime"
+)
+
+// fakeText answers each prompt after delay on clock (default: the system
+// clock).
+type fakeText struct {
+	delay time.Duration
+	clock clock.Clock
+	mu    sync.Mutex
+	calls int
+}
+
+func (f *fakeText) Infer(ctx context.Context, params llamaruntime.InferenceParams) (*llamaruntime.InferenceResult, error) {
+	f.mu.Lock()
+	f.calls++
+	f.mu.Unlock()
+	clock.OrReal(f.clock).Sleep(f.delay)
+	return &llamaruntime.InferenceResult{Text: "ok", TokensGenerated: params.MaxTokens}, nil
+}
+
+// fakeImage renders after delay on clock (default: the system clock), or
+// fails with err.
+type fakeImage struct {
+	delay time.Duration
+	clock clock.Clock
+	err   error
+}
+
+func (f *fakeImage) Generate(ctx context.Context, params sdruntime.GenerateParams) ([]byte, error) {
+	clock.OrReal(f.clock).Sleep(f.delay)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return []byte("png"), nil
+}
+
+type fakeGPU struct{}
+
+func (fakeGPU) ReadGPUMetrics() (metrics.GPUMetrics, error) {
+	return metrics.GPUMetrics{Name: "Test GPU", Utilization: 80, MemoryTotal: 16 << 30, MemoryUsed: 6 << 30}, nil
+}
+
+func TestRun_NoLoad(t *testing.T) {
+	canvas := NewMockCanvas(0)
+	defer canvas.Close()
+
+	if _, err := Run(context.Background(), Config{Canvas: canvas.Client()}); err == nil {
+		t.Error("Run() without load should fail")
+	}
+	// Rates for a kind without a generator are ignored
+	if _, err := Run(context.Background(), Config{Canvas: canvas.Client(), ImageRate: 60}); err == nil {
+		t.Error("Run() without an image generator should fail")
+	}
+	if _, err := Run(context.Background(), Config{NoteRate: 60, Text: &fakeText{}}); err == nil {
+		t.Error("Run() without a canvas should fail")
+	}
+}
+
+func TestRun_ReportsJobs(t *testing.T) {
+	canvas := NewMockCanvas(time.Millisecond)
+	defer canvas.Close()
+	text := &fakeText{delay: 5 * time.Millisecond}
+
+	report, err := Run(context.Background(), Config{
+		Duration:       300 * time.Millisecond,
+		NoteRate:       600, // one every 100ms
+		PDFRate:        300,
+		ImageRate:      600,
+		PDFChunks:      2,
+		TextWorkers:    2,
+		Text:           text,
+		Image:          &fakeImage{delay: 5 * time.Millisecond},
+		Canvas:         canvas.Client(),
+		GPU:            fakeGPU{},
+		SampleInterval: 20 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	completed := 0
+	for _, job := range report.Jobs {
+		if job.Submitted == 0 || job.Completed != job.Submitted || job.Failed != 0 {
+			t.Errorf("%s jobs: %+v", job.Kind, job)
+		}
+		if job.Latency.P50 <= 0 || job.Latency.Max < job.Latency.P99 {
+			t.Errorf("%s latency = %+v", job.Kind, job.Latency)
+		}
+		completed += job.Completed
+	}
+	if report.CanvasWrites != completed || canvas.Writes() != completed {
+		t.Errorf("canvas writes = %d (mock saw %d), want %d", report.CanvasWrites, canvas.Writes(), completed)
+	}
+	// A PDF precis summarizes each chunk and then the summaries
+	pdf := report.Jobs[1]
+	if want := report.Jobs[0].Completed + pdf.Completed*3; text.calls != want {
+		t.Errorf("text generations = %d, want %d", text.calls, want)
+	}
+	if report.GPU == nil || report.GPU.MaxUtilization != 80 || report.GPU.PeakVRAMBytes != 6<<30 {
+		t.Errorf("GPU = %+v", report.GPU)
+	}
+	if len(report.Queues) != 2 || report.Queues[0].Workers != 2 {
+		t.Errorf("Queues = %+v", report.Queues)
+	}
+}
+
+func TestRun_SlowQueueIsReported(t *testing.T) {
+	canvas := NewMockCanvas(0)
+	defer canvas.Close()
+
+	report, err := Run(context.Background(), Config{
+		Duration:       200 * time.Millisecond,
+		ImageRate:      6000, // one every 10ms against 50ms renders
+		Image:          &fakeImage{delay: 50 * time.Millisecond},
+		Canvas:         canvas.Client(),
+		SampleInterval: 10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	image := report.Queues[1]
+	if image.MaxDepth < 2 || image.EndDepth < 2 {
+		t.Errorf("image queue = %+v, want it backed up", image)
+	}
+	if len(report.Notes) != 1 || !strings.Contains(report.Notes[0], "image queue did not keep up") {
+		t.Errorf("Notes = %q", report.Notes)
+	}
+	if job := report.Jobs[2]; job.QueueWait.Max <= 0 {
+		t.Errorf("image queue wait = %+v", job.QueueWait)
+	}
+}
+
+func TestRun_FailedJobs(t *testing.T) {
+	canvas := NewMockCanvas(0)
+	defer canvas.Close()
+
+	report, err := Run(context.Background(), Config{
+		Duration:  100 * time.Millisecond,
+		ImageRate: 1200,
+		Image:     &fakeImage{err: errors.New("out of VRAM")},
+		Canvas:    canvas.Client(),
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if job := report.Jobs[2]; job.Failed == 0 || job.Completed != 0 {
+		t.Errorf("image jobs = %+v", job)
+	}
+	if len(report.Errors) != 1 || report.Errors[0] != "image: out of VRAM" {
+		t.Errorf("Errors = %q", report.Errors)
+	}
+	if canvas.Writes() != 0 {
+		t.Errorf("failed jobs wrote %d widgets", canvas.Writes())
+	}
+}
+
+func TestRun_SimulatedClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC))
+	canvas := NewMockCanvas(200 * time.Millisecond)
+	canvas.SetClock(fake)
+	defer canvas.Close()
+
+	// Advance the simulated time until the run returns
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				fake.Advance(5 * time.Second)
+				time.Sleep(100 * time.Microsecond)
+			}
+		}
+	}()
+
+	// An hour of load, with 30-second images
+	report, err := Run(context.Background(), Config{
+		Duration:       time.Hour,
+		ImageRate:      1,
+		Image:          &fakeImage{delay: 30 * time.Second, clock: fake},
+		Canvas:         canvas.Client(),
+		SampleInterval: time.Minute,
+		Clock:          fake,
+	})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if !report.Timestamp.Equal(time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Timestamp = %v, want the fake clock's start", report.Timestamp)
+	}
+	if elapsed, _ := time.ParseDuration(report.Duration); elapsed < time.Hour {
+		t.Errorf("Duration = %s, want at least the simulated hour", report.Duration)
+	}
+	job := report.Jobs[2]
+	if job.Submitted == 0 || job.Completed != job.Submitted {
+		t.Errorf("image jobs = %+v", job)
+	}
+	if job.Latency.P50 < 30 {
+		t.Errorf("image latency = %+v, want at least the simulated render time", job.Latency)
+	}
+}
@@ -0,0 +1,156 @@
+// Package main provides the stress subcommand for capacity planning.
+//
+// Usage:
+//
+//	canvuslocallm stress [-duration 5m] [-note-rate 30] [-pdf-rate 2] [-image-rate 6] [-output stress-report.json]
+//
+// The command loads the models configured by LLAMA_MODEL_PATH and
+// SD_MODEL_PATH, sends simulated note triggers, PDF precis and image jobs at
+// the given rates per minute, writes the results to an in-process mock
+// canvas, prints a summary and writes the full JSON report.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"go_backend/core"
+	"go_backend/llamaruntime"
+	"go_backend/metrics"
+	"go_backend/sdruntime"
+	"go_backend/stress"
+)
+
+// runStressCommand runs the stress subcommand and returns the process exit code.
+func runStressCommand(args []string) int {
+	fs := flag.NewFlagSet("stress", flag.ContinueOnError)
+	output := fs.String("output", "stress-report.json", "path of the JSON report")
+	duration := fs.Duration("duration", time.Minute, "how long jobs keep arriving")
+	noteRate := fs.Float64("note-rate", 30, "note triggers per minute")
+	pdfRate := fs.Float64("pdf-rate", 2, "PDF precis jobs per minute")
+	imageRate := fs.Float64("image-rate", 6, "image jobs per minute")
+	pdfChunks := fs.Int("pdf-chunks", 4, "chunks summarized per PDF precis")
+	maxTokens := fs.Int("max-tokens", 128, "tokens per text generation")
+	textWorkers := fs.Int("text-workers", llamaruntime.DefaultClientConfig().NumContexts, "text jobs run at once")
+	imageWorkers := fs.Int("image-workers", core.ParseIntEnv("SD_MAX_CONCURRENT", 2), "image jobs run at once")
+	imageSize := fs.Int("image-size", core.ParseIntEnv("SD_IMAGE_SIZE", sdruntime.DefaultImageSize), "image width and height in pixels")
+	imageSteps := fs.Int("steps", core.ParseIntEnv("SD_INFERENCE_STEPS", sdruntime.DefaultInferenceSteps), "inference steps per image")
+	canvasLatency := fs.Duration("canvas-latency", 0, "simulated round trip of each mock canvas request")
+	if err := fs.Parse(args); err != nil {
+		return core.ExitCodeError
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	canvas := stress.NewMockCanvas(*canvasLatency)
+	defer canvas.Close()
+
+	config := stress.Config{
+		Duration:     *duration,
+		NoteRate:     *noteRate,
+		PDFRate:      *pdfRate,
+		ImageRate:    *imageRate,
+		PDFChunks:    *pdfChunks,
+		MaxTokens:    *maxTokens,
+		TextWorkers:  *textWorkers,
+		ImageWorkers: *imageWorkers,
+		ImageSize:    *imageSize,
+		ImageSteps:   *imageSteps,
+		Canvas:       canvas.Client(),
+		Logf: func(format string, args ...interface{}) {
+			fmt.Printf("  "+format+"\n", args...)
+		},
+	}
+
+	if reader, err := metrics.NewNVMLReader(); err != nil {
+		fmt.Printf("GPU measurements disabled: %v\n", err)
+	} else {
+		defer reader.Close()
+		config.GPU = reader
+	}
+
+	if modelPath := os.Getenv("LLAMA_MODEL_PATH"); modelPath != "" && (*noteRate > 0 || *pdfRate > 0) {
+		fmt.Printf("Loading text model %s...\n", modelPath)
+		clientConfig := llamaruntime.DefaultClientConfig()
+		clientConfig.ModelPath = modelPath
+		clientConfig.NumContexts = *textWorkers
+		clientConfig.AutoOffload = core.ParseBoolEnv("LLAMA_AUTO_OFFLOAD", true)
+		client, err := llamaruntime.NewClient(clientConfig)
+		if err != nil {
+			fmt.Printf("Note and PDF jobs skipped: %v\n", err)
+		} else {
+			defer client.Close()
+			config.Text = client
+		}
+	}
+
+	if modelPath := os.Getenv("SD_MODEL_PATH"); modelPath != "" && *imageRate > 0 {
+		pool, err := sdruntime.NewContextPool(*imageWorkers, modelPath)
+		if err != nil {
+			fmt.Printf("Image jobs skipped: %v\n", err)
+		} else {
+			defer pool.Close()
+			config.Image = pool
+		}
+	}
+
+	if config.Text == nil && config.Image == nil {
+		fmt.Println("Nothing to run: set LLAMA_MODEL_PATH and/or SD_MODEL_PATH and a non-zero rate")
+		return core.ExitCodeError
+	}
+
+	fmt.Println("Running stress test...")
+	report, err := stress.Run(ctx, config)
+	if err != nil {
+		fmt.Printf("Stress test failed: %v\n", err)
+		return core.ExitCodeError
+	}
+
+	printStressSummary(report)
+
+	if err := report.WriteJSON(*output); err != nil {
+		fmt.Printf("Failed to write report: %v\n", err)
+		return core.ExitCodeError
+	}
+	fmt.Printf("\nReport written to %s\n", *output)
+	return core.ExitCodeSuccess
+}
+
+// printStressSummary prints the headline numbers of a stress report.
+func printStressSummary(report *stress.Report) {
+	const mb = 1024 * 1024
+
+	fmt.Println()
+	fmt.Printf("%-6s %9s %9s %7s %10s %10s %8s %8s %8s %9s\n",
+		"Job", "Offered", "Done", "Failed", "Offer/min", "Done/min", "p50", "p90", "p99", "Wait p90")
+	for _, j := range report.Jobs {
+		if j.Submitted == 0 && j.Dropped == 0 {
+			continue
+		}
+		fmt.Printf("%-6s %9d %9d %7d %10.1f %10.1f %7.1fs %7.1fs %7.1fs %8.1fs\n",
+			j.Kind, j.Submitted+j.Dropped, j.Completed, j.Failed, j.OfferedPerMinute, j.PerMinute,
+			j.Latency.P50, j.Latency.P90, j.Latency.P99, j.QueueWait.P90)
+	}
+
+	fmt.Println()
+	for _, q := range report.Queues {
+		fmt.Printf("Queue %-6s %d workers, depth avg %.1f, peak %d, %d waiting when load stopped\n",
+			q.Name, q.Workers, q.AvgDepth, q.MaxDepth, q.EndDepth)
+	}
+	if gpu := report.GPU; gpu != nil {
+		fmt.Printf("GPU:         %s, utilization avg %.0f%% (peak %.0f%%), VRAM peak %d of %d MB\n",
+			gpu.Name, gpu.AvgUtilization, gpu.MaxUtilization, gpu.PeakVRAMBytes/mb, gpu.TotalVRAMBytes/mb)
+	}
+	fmt.Printf("Canvas:      %d widgets written\n", report.CanvasWrites)
+	for _, note := range report.Notes {
+		fmt.Printf("Note:        %s\n", note)
+	}
+	for _, e := range report.Errors {
+		fmt.Printf("Error:       %s\n", e)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go_backend/canvasexport"
+	"go_backend/canvassettings"
+	"go_backend/canvusapi"
+	"go_backend/cluster"
+	"go_backend/collage"
+	"go_backend/core"
+	"go_backend/groupsummary"
+	"go_backend/handlers"
+	"go_backend/notefix"
+	"go_backend/rewrite"
+	"go_backend/variants"
+
+	"go.uber.org/zap"
+)
+
+// aiTask is an AI task started by a trigger. It holds only the update and
+// the canvas it came from, so any instance can run it with its own
+// configuration and credentials.
+type aiTask struct {
+	// Feature is the canvassettings feature the trigger starts
+	Feature  string `json:"feature"`
+	CanvasID string `json:"canvas_id"`
+	Update   Update `json:"update"`
+}
+
+// taskQueue runs the AI tasks that triggers start.
+type taskQueue interface {
+	Enqueue(task aiTask) error
+}
+
+// localTaskQueue runs tasks in this instance, in the calling goroutine.
+type localTaskQueue struct {
+	run func(aiTask)
+}
+
+func (q localTaskQueue) Enqueue(task aiTask) error {
+	q.run(task)
+	return nil
+}
+
+// clusterTaskQueue puts tasks on the cluster's task queue, where they wait
+// for a node with the tasks role even if this instance stops.
+type clusterTaskQueue struct {
+	node *cluster.Node
+}
+
+func (q clusterTaskQueue) Enqueue(task aiTask) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := q.node.Enqueue(ctx, cluster.RoleTasks, task)
+	return err
+}
+
+// taskQueue returns the cluster's task queue when a node of the cluster,
+// this one included, runs tasks, and runs them here otherwise.
+func (m *Monitor) taskQueue() taskQueue {
+	node := m.getCluster()
+	if node != nil && (node.HasRole(cluster.RoleTasks) || node.HasWorkers(cluster.RoleTasks)) {
+		return clusterTaskQueue{node: node}
+	}
+	return localTaskQueue{run: m.runTask}
+}
+
+// queuesTasks reports whether tasks go to the cluster's task queue.
+func (m *Monitor) queuesTasks() bool {
+	_, ok := m.taskQueue().(clusterTaskQueue)
+	return ok
+}
+
+// dispatch queues the task feature of update, unless the canvas settings
+// refuse it. A task that certainly did not reach the queue runs here.
+func (m *Monitor) dispatch(update Update, cfg *core.Config, feature, model string) {
+	task := aiTask{Feature: feature, CanvasID: cfg.CanvasID, Update: update}
+	go m.runIfAllowed(update, cfg, feature, model, func() {
+		m.queueTask(m.taskQueue(), localTaskQueue{run: m.runTask}, task)
+	})
+}
+
+// queueTask puts task on queue, or on local if it certainly did not reach
+// queue. A task that may have reached queue, e.g. when the server took
+// LPUSH but timed out before answering, is not run again here: it would
+// run twice.
+func (m *Monitor) queueTask(queue, local taskQueue, task aiTask) {
+	err := queue.Enqueue(task)
+	if err == nil {
+		return
+	}
+	log := m.logger.With(
+		zap.Any("widget_id", task.Update["id"]),
+		zap.String("feature", task.Feature),
+		zap.Error(err))
+	if !errors.Is(err, cluster.ErrNotSent) {
+		log.Error("AI task may not have been queued; leaving it to the cluster so it does not run twice")
+		return
+	}
+	log.Warn("Failed to queue AI task, running it here")
+	local.Enqueue(task)
+}
+
+// taskTarget returns the client and configuration for the canvas of a task,
+// with that canvas's settings applied.
+func (m *Monitor) taskTarget(canvasID string) (*canvusapi.Client, *core.Config) {
+	base := core.Config{}
+	if m.config != nil {
+		base = *m.config
+	}
+	if canvasID == "" || canvasID == base.CanvasID {
+		return m.client, m.effectiveConfig()
+	}
+	client := *m.client
+	client.CanvasID = canvasID
+	base.CanvasID = canvasID
+	return &client, m.getCanvasSettings().Config(canvasID, &base)
+}
+
+// runTask runs an AI task on its canvas.
+func (m *Monitor) runTask(task aiTask) {
+	client, cfg := m.taskTarget(task.CanvasID)
+	deps := m.getHandlerDeps()
+	update := task.Update
+	syntax := handlers.NewTriggerSyntax(cfg.TriggerOpen, cfg.TriggerClose)
+	log := m.logger.With(zap.Any("widget_id", update["id"]), zap.String("feature", task.Feature))
+
+	switch task.Feature {
+	case canvassettings.FeatureExport:
+		text, _ := update["text"].(string)
+		content, _ := syntax.Enclosed(text)
+		format, zone, ok := canvasexport.ParseTrigger(content)
+		if !ok {
+			log.Warn("export trigger no longer present, skipping task")
+			return
+		}
+		handleExport(update, client, cfg, m.logger, m.repository, deps, format, zone)
+	case canvassettings.FeatureImageVariants:
+		text, _ := update["text"].(string)
+		content, _ := syntax.Enclosed(text)
+		pick, ok := variants.ParseTrigger(content)
+		if !ok {
+			log.Warn("variants trigger no longer present, skipping task")
+			return
+		}
+		handleVariants(update, client, cfg, m.logger, m.repository, deps, pick)
+	case canvassettings.FeatureCollage:
+		text, _ := update["text"].(string)
+		content, _ := syntax.Enclosed(text)
+		req, ok := collage.ParseTrigger(content)
+		if !ok {
+			log.Warn("collage trigger no longer present, skipping task")
+			return
+		}
+		handleCollage(update, client, cfg, m.logger, m.repository, m.getLlamaClient(), deps, req)
+	case canvassettings.FeatureNoteFix:
+		text, _ := update["text"].(string)
+		content, _ := syntax.Enclosed(text)
+		undo, ok := notefix.ParseTrigger(content)
+		if !ok {
+			log.Warn("fix trigger no longer present, skipping task")
+			return
+		}
+		handleNoteFix(update, client, cfg, m.logger, m.repository, m.getLlamaClient(), deps, undo)
+	case canvassettings.FeatureRewrite:
+		text, _ := update["text"].(string)
+		content, _ := syntax.Enclosed(text)
+		req, ok := rewrite.ParseTrigger(content)
+		if !ok {
+			log.Warn("rewrite trigger no longer present, skipping task")
+			return
+		}
+		handleRewrite(update, client, cfg, m.logger, m.repository, m.getLlamaClient(), deps, req)
+	case canvassettings.FeatureGroupSummary:
+		text, _ := update["text"].(string)
+		if content, _ := syntax.Enclosed(text); !groupsummary.ParseTrigger(content) {
+			log.Warn("summarize trigger no longer present, skipping task")
+			return
+		}
+		handleGroupSummary(update, client, cfg, m.logger, m.repository, m.getLlamaClient(), deps)
+	case canvassettings.FeatureVoiceNotes:
+		handleVoiceNote(update, client, cfg, m.logger, m.repository, m.getLlamaClient(), deps)
+	case canvassettings.FeatureLiveTranscript:
+		handleLiveTranscript(update, client, cfg, m.logger, m.repository, m.getLlamaClient(), deps)
+	case canvassettings.FeatureImageGeneration:
+		prompt, options, ok := parseImageTrigger(syntax, update)
+		if !ok {
+			log.Warn("image prompt no longer present, skipping task")
+			return
+		}
+		options.Model = m.imageModel(cfg, options.Model)
+		m.handleImagePrompt(client, update, prompt, options, cfg)
+	case canvassettings.FeatureNotes:
+		handleNote(update, client, cfg, m.logger, m.repository, m.getLlamaClient(), deps)
+	case canvassettings.FeatureHandwriting:
+		handleSnapshot(update, client, cfg, m.logger, m.repository, deps)
+	case canvassettings.FeatureHandwritingAll:
+		handleHandwritingBatch(update, client, cfg, m.logger, m.repository, deps)
+	case canvassettings.FeaturePDFPrecis:
+		handlePDFPrecis(update, client, cfg, m.logger, m.repository, deps)
+	case canvassettings.FeatureCanvasPrecis:
+		handleCanvusPrecis(update, client, cfg, m.logger, m.repository, m.getLlamaClient(), deps)
+	case canvassettings.FeatureImageAnalysis, canvassettings.FeatureImageExtraction, canvassettings.FeatureStickyWall:
+		llamaClient := m.getLlamaClient()
+		if llamaClient == nil {
+			log.Warn("llamaruntime client not available, skipping task")
+			return
+		}
+		switch task.Feature {
+		case canvassettings.FeatureImageAnalysis:
+			handleImageAnalysis(update, client, cfg, m.logger, m.repository, llamaClient, deps)
+		case canvassettings.FeatureImageExtraction:
+			handleStructuredExtraction(update, client, cfg, m.logger, m.repository, llamaClient, deps)
+		default:
+			handleStickyWall(update, client, cfg, m.logger, m.repository, llamaClient, deps)
+		}
+	default:
+		log.Warn("unknown AI task feature, skipping task")
+	}
+}
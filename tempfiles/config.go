@@ -0,0 +1,26 @@
+// Package tempfiles provides the TempFileManager organism for short-lived
+// files. This file contains the configuration read from the environment.
+package tempfiles
+
+import (
+	"path/filepath"
+
+	"go_backend/core"
+)
+
+// DefaultQuotaMB is the quota used when TEMP_QUOTA_MB is not set.
+const DefaultQuotaMB = 2048
+
+// ConfigFromEnv reads the manager configuration. Temp files live in a "tmp"
+// directory under downloadsDir unless TEMP_DIR is set; TEMP_QUOTA_MB sets
+// the quota (0 for unlimited).
+func ConfigFromEnv(downloadsDir string) Config {
+	quotaMB := core.ParseInt64Env("TEMP_QUOTA_MB", DefaultQuotaMB)
+	if quotaMB < 0 {
+		quotaMB = 0
+	}
+	return Config{
+		Dir:        core.GetEnvOrDefault("TEMP_DIR", filepath.Join(downloadsDir, "tmp")),
+		QuotaBytes: quotaMB * 1024 * 1024,
+	}
+}
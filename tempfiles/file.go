@@ -0,0 +1,62 @@
+// Package tempfiles provides the TempFileManager organism for short-lived
+// files. This file contains the File handle returned by the manager and a
+// fallback for callers that have no manager.
+package tempfiles
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// File is a handle to a stored temporary file. Release it when done; the
+// file may be shared with other handles and is removed with the last one.
+type File struct {
+	// Path is the file's location on disk.
+	Path string
+	// Hash is the hex SHA-256 of the content ("" for unmanaged files).
+	Hash string
+	// Size is the content length in bytes.
+	Size int64
+
+	manager *TempFileManager
+	key     string
+	once    sync.Once
+}
+
+// Release drops the handle. It is safe to call more than once.
+func (f *File) Release() {
+	if f == nil {
+		return
+	}
+	f.once.Do(func() {
+		if f.manager != nil {
+			f.manager.release(f.key)
+			return
+		}
+		os.Remove(f.Path)
+	})
+}
+
+// StoreUnmanaged writes r to a "temp_*" file in dir, which the shutdown
+// cleanup removes if Release is never called. It is used when no
+// TempFileManager is configured; there is no quota and no deduplication.
+func StoreUnmanaged(dir string, r io.Reader, ext string) (*File, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("tempfiles: failed to create directory: %w", err)
+	}
+	f, err := os.CreateTemp(dir, "temp_*"+sanitizeExt(ext))
+	if err != nil {
+		return nil, fmt.Errorf("tempfiles: failed to create file: %w", err)
+	}
+	n, err := io.Copy(f, r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("tempfiles: failed to write file: %w", err)
+	}
+	return &File{Path: f.Name(), Size: n}, nil
+}
@@ -0,0 +1,280 @@
+// Package tempfiles provides the TempFileManager organism for short-lived
+// files that handlers download or generate before uploading or analysing
+// them. This file contains the manager, which stores files by content hash,
+// enforces a disk quota and removes leftovers from a previous run.
+package tempfiles
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// ErrQuotaExceeded is returned when storing a file would take the managed
+// directory over its quota.
+var ErrQuotaExceeded = errors.New("tempfiles: disk quota exceeded")
+
+// stagingPrefix marks files that are still being written.
+const stagingPrefix = ".staging-"
+
+// Config configures a TempFileManager.
+type Config struct {
+	// Dir is the directory owned by the manager. Everything in it is
+	// removed at startup.
+	Dir string
+	// QuotaBytes caps the bytes held at once, including files still being
+	// written. Zero means unlimited.
+	QuotaBytes int64
+}
+
+// Usage reports the current state of a TempFileManager.
+type Usage struct {
+	Dir        string `json:"dir"`
+	Files      int    `json:"files"`
+	Bytes      int64  `json:"bytes"`
+	QuotaBytes int64  `json:"quota_bytes"`
+	// DedupHits counts stores whose content was already held.
+	DedupHits int64 `json:"dedup_hits"`
+	// Rejected counts stores refused because of the quota.
+	Rejected int64 `json:"rejected"`
+}
+
+// entry is one content-addressed file and the number of handles to it.
+type entry struct {
+	path string
+	size int64
+	refs int
+}
+
+// TempFileManager stores temporary files under their SHA-256 hash so the
+// same content is only written once. Each Store returns a File handle; the
+// file is removed when its last handle is released.
+//
+// Thread-Safety: TempFileManager is safe for concurrent use.
+type TempFileManager struct {
+	config Config
+	logger *zap.Logger
+
+	mu        sync.Mutex
+	entries   map[string]*entry
+	used      int64 // committed and staged bytes
+	dedupHits int64
+	rejected  int64
+	swept     int // leftover files removed at startup
+}
+
+// NewTempFileManager creates the managed directory and sweeps files left
+// behind by a previous run that did not shut down cleanly.
+func NewTempFileManager(config Config, logger *zap.Logger) (*TempFileManager, error) {
+	if config.Dir == "" {
+		return nil, errors.New("tempfiles: directory is required")
+	}
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("tempfiles: failed to create directory: %w", err)
+	}
+
+	m := &TempFileManager{
+		config:  config,
+		logger:  logger.Named("tempfiles"),
+		entries: make(map[string]*entry),
+	}
+	removed, err := m.sweep()
+	if err != nil {
+		return nil, err
+	}
+	if removed > 0 {
+		m.logger.Info("Removed leftover temp files", zap.Int("count", removed), zap.String("dir", config.Dir))
+	}
+	m.swept = removed
+	return m, nil
+}
+
+// sweep removes every file in the managed directory. It runs before any
+// file is stored, so nothing in the directory can be in use.
+func (m *TempFileManager) sweep() (int, error) {
+	dirEntries, err := os.ReadDir(m.config.Dir)
+	if err != nil {
+		return 0, fmt.Errorf("tempfiles: failed to read directory: %w", err)
+	}
+	removed := 0
+	for _, de := range dirEntries {
+		path := filepath.Join(m.config.Dir, de.Name())
+		if err := os.RemoveAll(path); err != nil {
+			m.logger.Warn("Failed to remove leftover temp file", zap.String("file", path), zap.Error(err))
+			continue
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Swept returns the number of files left by a previous run that were
+// removed at startup.
+func (m *TempFileManager) Swept() int {
+	return m.swept
+}
+
+// Dir returns the managed directory.
+func (m *TempFileManager) Dir() string {
+	return m.config.Dir
+}
+
+// Store writes r to a file named after its content hash with extension ext
+// (e.g. ".png"). If the same content is already held, the existing file is
+// shared. ErrQuotaExceeded is returned, and nothing is kept, when the
+// content does not fit in the quota.
+func (m *TempFileManager) Store(r io.Reader, ext string) (*File, error) {
+	staging, err := os.CreateTemp(m.config.Dir, stagingPrefix+"*")
+	if err != nil {
+		return nil, fmt.Errorf("tempfiles: failed to create file: %w", err)
+	}
+	stagingPath := staging.Name()
+
+	hash := sha256.New()
+	w := &quotaWriter{m: m, w: io.MultiWriter(staging, hash)}
+	_, err = io.Copy(w, r)
+	if closeErr := staging.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(stagingPath)
+		m.unreserve(w.n)
+		if errors.Is(err, ErrQuotaExceeded) {
+			m.logger.Warn("Temp file rejected by quota",
+				zap.Int64("quota_bytes", m.config.QuotaBytes),
+				zap.Int64("written_bytes", w.n))
+			return nil, err
+		}
+		return nil, fmt.Errorf("tempfiles: failed to write file: %w", err)
+	}
+
+	return m.commit(stagingPath, hex.EncodeToString(hash.Sum(nil)), sanitizeExt(ext), w.n)
+}
+
+// StoreBytes is Store for content already in memory.
+func (m *TempFileManager) StoreBytes(data []byte, ext string) (*File, error) {
+	return m.Store(bytes.NewReader(data), ext)
+}
+
+// commit moves a fully written staging file to its content-addressed name,
+// or drops it if that content is already held.
+func (m *TempFileManager) commit(stagingPath, hash, ext string, size int64) (*File, error) {
+	key := hash + ext
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[key]; ok {
+		e.refs++
+		m.dedupHits++
+		m.used -= size
+		os.Remove(stagingPath)
+		return &File{Path: e.path, Hash: hash, Size: e.size, manager: m, key: key}, nil
+	}
+
+	path := filepath.Join(m.config.Dir, key)
+	if err := os.Rename(stagingPath, path); err != nil {
+		m.used -= size
+		os.Remove(stagingPath)
+		return nil, fmt.Errorf("tempfiles: failed to commit file: %w", err)
+	}
+	m.entries[key] = &entry{path: path, size: size, refs: 1}
+	return &File{Path: path, Hash: hash, Size: size, manager: m, key: key}, nil
+}
+
+// reserve claims n bytes of quota.
+func (m *TempFileManager) reserve(n int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.config.QuotaBytes > 0 && m.used+n > m.config.QuotaBytes {
+		m.rejected++
+		return ErrQuotaExceeded
+	}
+	m.used += n
+	return nil
+}
+
+// unreserve returns n bytes of quota.
+func (m *TempFileManager) unreserve(n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.used -= n
+}
+
+// release drops one handle to key and removes the file with the last one.
+// The file is removed under the lock so a concurrent Store of the same
+// content cannot commit a file that is then deleted.
+func (m *TempFileManager) release(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		return
+	}
+	e.refs--
+	if e.refs > 0 {
+		return
+	}
+	delete(m.entries, key)
+	m.used -= e.size
+	if err := os.Remove(e.path); err != nil && !os.IsNotExist(err) {
+		m.logger.Warn("Failed to remove temp file", zap.String("file", e.path), zap.Error(err))
+	}
+}
+
+// Usage returns the current file count, bytes held and counters.
+func (m *TempFileManager) Usage() Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Usage{
+		Dir:        m.config.Dir,
+		Files:      len(m.entries),
+		Bytes:      m.used,
+		QuotaBytes: m.config.QuotaBytes,
+		DedupHits:  m.dedupHits,
+		Rejected:   m.rejected,
+	}
+}
+
+// quotaWriter reserves quota for every chunk before writing it.
+type quotaWriter struct {
+	m *TempFileManager
+	w io.Writer
+	n int64 // bytes reserved
+}
+
+func (q *quotaWriter) Write(p []byte) (int, error) {
+	if err := q.m.reserve(int64(len(p))); err != nil {
+		return 0, err
+	}
+	q.n += int64(len(p))
+	return q.w.Write(p)
+}
+
+// sanitizeExt returns ext with a leading dot, or "" if it contains
+// anything but letters and digits.
+func sanitizeExt(ext string) string {
+	ext = strings.TrimPrefix(ext, ".")
+	if ext == "" {
+		return ""
+	}
+	for _, c := range ext {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+	return "." + strings.ToLower(ext)
+}
@@ -0,0 +1,162 @@
+package tempfiles
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func newTestManager(t *testing.T, quota int64) *TempFileManager {
+	t.Helper()
+	m, err := NewTempFileManager(Config{Dir: t.TempDir(), QuotaBytes: quota}, nil)
+	if err != nil {
+		t.Fatalf("NewTempFileManager failed: %v", err)
+	}
+	return m
+}
+
+func TestStoreAndRelease(t *testing.T) {
+	m := newTestManager(t, 0)
+
+	f, err := m.Store(strings.NewReader("image"), ".PNG")
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if filepath.Base(f.Path) != f.Hash+".png" || f.Size != 5 {
+		t.Errorf("file = %+v", f)
+	}
+	if data, _ := os.ReadFile(f.Path); string(data) != "image" {
+		t.Errorf("content = %q", data)
+	}
+	if u := m.Usage(); u.Files != 1 || u.Bytes != 5 {
+		t.Errorf("usage = %+v", u)
+	}
+
+	f.Release()
+	f.Release()
+	if _, err := os.Stat(f.Path); !os.IsNotExist(err) {
+		t.Errorf("file not removed after Release: %v", err)
+	}
+	if u := m.Usage(); u.Files != 0 || u.Bytes != 0 {
+		t.Errorf("usage after release = %+v", u)
+	}
+}
+
+func TestStoreDedupes(t *testing.T) {
+	m := newTestManager(t, 0)
+
+	a, _ := m.StoreBytes([]byte("same"), "pdf")
+	b, err := m.StoreBytes([]byte("same"), "pdf")
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if a.Path != b.Path {
+		t.Errorf("paths differ: %s, %s", a.Path, b.Path)
+	}
+	if u := m.Usage(); u.Files != 1 || u.Bytes != 4 || u.DedupHits != 1 {
+		t.Errorf("usage = %+v", u)
+	}
+
+	a.Release()
+	if _, err := os.Stat(b.Path); err != nil {
+		t.Errorf("shared file removed while still held: %v", err)
+	}
+	b.Release()
+	if _, err := os.Stat(b.Path); !os.IsNotExist(err) {
+		t.Errorf("file not removed after last Release: %v", err)
+	}
+}
+
+func TestStoreQuota(t *testing.T) {
+	m := newTestManager(t, 10)
+
+	held, err := m.StoreBytes([]byte("123456"), "")
+	if err != nil {
+		t.Fatalf("Store failed: %v", err)
+	}
+	if _, err := m.StoreBytes([]byte("abcdef"), ""); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("Store over quota = %v, want ErrQuotaExceeded", err)
+	}
+	entries, _ := os.ReadDir(m.Dir())
+	if len(entries) != 1 {
+		t.Errorf("rejected file left behind: %v", entries)
+	}
+	if u := m.Usage(); u.Bytes != 6 || u.Rejected != 1 {
+		t.Errorf("usage = %+v", u)
+	}
+
+	held.Release()
+	if _, err := m.StoreBytes([]byte("abcdef"), ""); err != nil {
+		t.Errorf("Store after release = %v", err)
+	}
+}
+
+func TestStartupSweep(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "0123.png"), []byte("old"), 0644)
+	os.WriteFile(filepath.Join(dir, stagingPrefix+"42"), []byte("partial"), 0644)
+
+	m, err := NewTempFileManager(Config{Dir: dir}, nil)
+	if err != nil {
+		t.Fatalf("NewTempFileManager failed: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("leftovers not swept: %v", entries)
+	}
+	if m.Swept() != 2 {
+		t.Errorf("Swept() = %d, want 2", m.Swept())
+	}
+}
+
+func TestConcurrentStore(t *testing.T) {
+	m := newTestManager(t, 0)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			f, err := m.StoreBytes([]byte{byte(i % 3)}, "bin")
+			if err != nil {
+				t.Errorf("Store failed: %v", err)
+				return
+			}
+			if _, err := os.Stat(f.Path); err != nil {
+				t.Errorf("stored file missing: %v", err)
+			}
+			f.Release()
+		}(i)
+	}
+	wg.Wait()
+
+	if u := m.Usage(); u.Files != 0 || u.Bytes != 0 {
+		t.Errorf("usage after all released = %+v", u)
+	}
+}
+
+func TestStoreUnmanaged(t *testing.T) {
+	dir := t.TempDir()
+	f, err := StoreUnmanaged(dir, strings.NewReader("x"), ".jpg")
+	if err != nil {
+		t.Fatalf("StoreUnmanaged failed: %v", err)
+	}
+	if !strings.HasPrefix(filepath.Base(f.Path), "temp_") || filepath.Ext(f.Path) != ".jpg" {
+		t.Errorf("path = %s", f.Path)
+	}
+	f.Release()
+	if _, err := os.Stat(f.Path); !os.IsNotExist(err) {
+		t.Errorf("file not removed after Release: %v", err)
+	}
+}
+
+func TestSanitizeExt(t *testing.T) {
+	for in, want := range map[string]string{"png": ".png", ".PDF": ".pdf", "": "", "../x": "", "tar.gz": ""} {
+		if got := sanitizeExt(in); got != want {
+			t.Errorf("sanitizeExt(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
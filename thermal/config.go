@@ -0,0 +1,57 @@
+// Package thermal provides the adaptive throttle that keeps small-form-factor
+// machines driving video walls from overheating. This file contains the
+// throttle configuration read from the environment.
+package thermal
+
+import (
+	"time"
+
+	"go_backend/core"
+)
+
+// Config configures the Throttle.
+type Config struct {
+	// Enabled turns the throttle on.
+	Enabled bool
+	// TempLimit is the GPU temperature in Celsius at which throttling
+	// starts (0 ignores temperature).
+	TempLimit float64
+	// Hysteresis is how many degrees below TempLimit the GPU must cool
+	// before throttling stops.
+	Hysteresis float64
+	// PowerLimit is the power draw, as a percentage of the GPU's enforced
+	// power limit, at which throttling starts (0 ignores power).
+	PowerLimit float64
+	// Concurrency is the number of jobs allowed at once while throttled.
+	Concurrency int
+	// Delay is how long each job waits before starting while throttled.
+	Delay time.Duration
+}
+
+// DefaultConfig returns the limits used when none are configured: 83°C,
+// below the slowdown temperature of most NVIDIA GPUs, and 95% of the power
+// limit, with one job at a time started every 5 seconds.
+func DefaultConfig() Config {
+	return Config{
+		TempLimit:   83,
+		Hysteresis:  5,
+		PowerLimit:  95,
+		Concurrency: 1,
+		Delay:       5 * time.Second,
+	}
+}
+
+// ConfigFromEnv reads THERMAL_THROTTLE, THERMAL_TEMP_LIMIT,
+// THERMAL_HYSTERESIS, THERMAL_POWER_LIMIT_PERCENT,
+// THERMAL_THROTTLE_CONCURRENCY and THERMAL_THROTTLE_DELAY (seconds).
+func ConfigFromEnv() Config {
+	d := DefaultConfig()
+	return Config{
+		Enabled:     core.ParseBoolEnv("THERMAL_THROTTLE", false),
+		TempLimit:   core.ParseFloat64Env("THERMAL_TEMP_LIMIT", d.TempLimit),
+		Hysteresis:  core.ParseFloat64Env("THERMAL_HYSTERESIS", d.Hysteresis),
+		PowerLimit:  core.ParseFloat64Env("THERMAL_POWER_LIMIT_PERCENT", d.PowerLimit),
+		Concurrency: core.ParseIntEnv("THERMAL_THROTTLE_CONCURRENCY", d.Concurrency),
+		Delay:       core.ParseDurationEnv("THERMAL_THROTTLE_DELAY", int(d.Delay/time.Second)),
+	}
+}
@@ -0,0 +1,176 @@
+// Package thermal provides the adaptive throttle that keeps small-form-factor
+// machines driving video walls from overheating. This file contains the
+// Throttle organism, which limits concurrent image generation and delays
+// new jobs while the GPU is over its thermal or power limits.
+package thermal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go_backend/metrics"
+)
+
+// Status describes the throttle's current state.
+type Status struct {
+	// Throttled is true while the GPU is over a limit.
+	Throttled bool `json:"throttled"`
+	// Reason says which limit was exceeded, e.g. "temperature 86°C >= 83°C".
+	Reason string `json:"reason,omitempty"`
+	// Temperature is the last observed GPU temperature in Celsius.
+	Temperature float64 `json:"temperature"`
+	// PowerPercent is the last observed power draw as a percentage of the
+	// enforced power limit (0 if unknown).
+	PowerPercent float64 `json:"power_percent"`
+	// Limit is the number of jobs allowed to run at once.
+	Limit int `json:"limit"`
+	// Active is the number of jobs running now.
+	Active int `json:"active"`
+}
+
+// Throttle gates GPU jobs. Jobs call Acquire before they start and release
+// when they finish. While the GPU is within its limits up to the normal
+// concurrency may run; while it is over a limit only config.Concurrency
+// may run and each job first waits config.Delay, giving the GPU time to
+// cool between jobs.
+//
+// Throttling starts when the temperature reaches config.TempLimit or the
+// power draw reaches config.PowerLimit percent of the enforced limit, and
+// stops once the temperature has fallen config.Hysteresis degrees below
+// the limit and the power draw is back under its limit, so the throttle
+// does not flap around the threshold.
+type Throttle struct {
+	mu          sync.Mutex
+	config      Config
+	concurrency int
+	status      Status
+	// changed is closed and replaced whenever a slot may have freed up
+	changed chan struct{}
+}
+
+// NewThrottle creates a throttle for up to concurrency jobs at once.
+func NewThrottle(config Config, concurrency int) *Throttle {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	if config.Concurrency < 1 || config.Concurrency > concurrency {
+		config.Concurrency = 1
+	}
+	return &Throttle{
+		config:      config,
+		concurrency: concurrency,
+		status:      Status{Limit: concurrency},
+		changed:     make(chan struct{}),
+	}
+}
+
+// Observe updates the throttle from a GPU sample. It returns the new
+// status and whether the sample started or stopped throttling.
+func (t *Throttle) Observe(m metrics.GPUMetrics) (Status, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.Temperature = m.Temperature
+	t.status.PowerPercent = 0
+	if m.PowerLimit > 0 {
+		t.status.PowerPercent = m.PowerDraw / m.PowerLimit * 100
+	}
+
+	reason := t.overLimit(m)
+	was := t.status.Throttled
+	switch {
+	case reason != "":
+		t.status.Throttled = true
+		t.status.Reason = reason
+	case was && t.cooled(m):
+		t.status.Throttled = false
+		t.status.Reason = ""
+	}
+
+	t.status.Limit = t.concurrency
+	if t.status.Throttled {
+		t.status.Limit = t.config.Concurrency
+	}
+	if was && !t.status.Throttled {
+		t.notifyLocked()
+	}
+	return t.status, was != t.status.Throttled
+}
+
+// overLimit returns why m is over a limit, or "" if it is not.
+func (t *Throttle) overLimit(m metrics.GPUMetrics) string {
+	if t.config.TempLimit > 0 && m.Temperature >= t.config.TempLimit {
+		return fmt.Sprintf("temperature %.0f°C >= %.0f°C", m.Temperature, t.config.TempLimit)
+	}
+	if t.config.PowerLimit > 0 && t.status.PowerPercent >= t.config.PowerLimit {
+		return fmt.Sprintf("power %.0f W >= %.0f%% of %.0f W", m.PowerDraw, t.config.PowerLimit, m.PowerLimit)
+	}
+	return ""
+}
+
+// cooled reports whether m is far enough below the limits to stop
+// throttling.
+func (t *Throttle) cooled(m metrics.GPUMetrics) bool {
+	if t.config.TempLimit > 0 && m.Temperature > t.config.TempLimit-t.config.Hysteresis {
+		return false
+	}
+	return t.config.PowerLimit <= 0 || t.status.PowerPercent < t.config.PowerLimit
+}
+
+// Status returns the throttle's current state.
+func (t *Throttle) Status() Status {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
+// Acquire blocks until a job may start and returns the function to call
+// when the job ends. While throttled it also waits config.Delay first. Returns ctx's error if ctx ends first.
+func (t *Throttle) Acquire(ctx context.Context) (release func(), err error) {
+	t.mu.Lock()
+	throttled := t.status.Throttled
+	t.mu.Unlock()
+	if throttled && t.config.Delay > 0 {
+		timer := time.NewTimer(t.config.Delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	for {
+		t.mu.Lock()
+		if t.status.Active < t.status.Limit {
+			t.status.Active++
+			t.mu.Unlock()
+			var once sync.Once
+			return func() { once.Do(t.release) }, nil
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// release ends a job started by Acquire.
+func (t *Throttle) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.Active--
+	t.notifyLocked()
+}
+
+// notifyLocked wakes jobs waiting in Acquire. t.mu must be held.
+func (t *Throttle) notifyLocked() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
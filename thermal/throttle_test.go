@@ -0,0 +1,134 @@
+package thermal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_backend/metrics"
+)
+
+func testConfig() Config {
+	return Config{Enabled: true, TempLimit: 80, Hysteresis: 5, PowerLimit: 90, Concurrency: 1}
+}
+
+func TestObserve(t *testing.T) {
+	th := NewThrottle(testConfig(), 3)
+
+	steps := []struct {
+		name          string
+		gpu           metrics.GPUMetrics
+		wantThrottled bool
+		wantChanged   bool
+	}{
+		{"cool", metrics.GPUMetrics{Temperature: 70}, false, false},
+		{"hot", metrics.GPUMetrics{Temperature: 81}, true, true},
+		{"within hysteresis", metrics.GPUMetrics{Temperature: 77}, true, false},
+		{"cooled", metrics.GPUMetrics{Temperature: 75}, false, true},
+		{"power", metrics.GPUMetrics{Temperature: 60, PowerDraw: 140, PowerLimit: 150}, true, true},
+		{"power recovered", metrics.GPUMetrics{Temperature: 60, PowerDraw: 100, PowerLimit: 150}, false, true},
+		{"power unknown", metrics.GPUMetrics{Temperature: 60, PowerDraw: 140}, false, false},
+	}
+	for _, s := range steps {
+		status, changed := th.Observe(s.gpu)
+		if status.Throttled != s.wantThrottled || changed != s.wantChanged {
+			t.Fatalf("%s: Observe() = %+v, changed %v", s.name, status, changed)
+		}
+		wantLimit := 3
+		if s.wantThrottled {
+			wantLimit = 1
+		}
+		if status.Limit != wantLimit {
+			t.Errorf("%s: Limit = %d, want %d", s.name, status.Limit, wantLimit)
+		}
+		if s.wantThrottled && status.Reason == "" {
+			t.Errorf("%s: throttled without a reason", s.name)
+		}
+	}
+}
+
+func TestAcquire_LimitsConcurrency(t *testing.T) {
+	th := NewThrottle(testConfig(), 2)
+	th.Observe(metrics.GPUMetrics{Temperature: 85})
+
+	release, err := th.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire() error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := th.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Acquire() while throttled = %v, want a timeout", err)
+	}
+
+	acquired := make(chan struct{})
+	go func() {
+		if r, err := th.Acquire(context.Background()); err == nil {
+			r()
+		}
+		close(acquired)
+	}()
+	release()
+	release() // a second call is a no-op
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("waiting job did not start after release")
+	}
+	if active := th.Status().Active; active != 0 {
+		t.Errorf("Active = %d after all jobs ended", active)
+	}
+}
+
+func TestAcquire_CoolingWakesWaiters(t *testing.T) {
+	th := NewThrottle(testConfig(), 2)
+	th.Observe(metrics.GPUMetrics{Temperature: 85})
+	release, _ := th.Acquire(context.Background())
+	defer release()
+
+	acquired := make(chan struct{})
+	go func() {
+		if r, err := th.Acquire(context.Background()); err == nil {
+			r()
+		}
+		close(acquired)
+	}()
+	th.Observe(metrics.GPUMetrics{Temperature: 60})
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("waiting job did not start once the GPU cooled")
+	}
+}
+
+func TestAcquire_Delay(t *testing.T) {
+	config := testConfig()
+	config.Delay = time.Hour
+	th := NewThrottle(config, 1)
+
+	release, err := th.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire() while cool should not wait: %v", err)
+	}
+	release()
+
+	th.Observe(metrics.GPUMetrics{Temperature: 85})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := th.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Acquire() while throttled = %v, want it to wait for the delay", err)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("THERMAL_THROTTLE", "true")
+	t.Setenv("THERMAL_TEMP_LIMIT", "78.5")
+	t.Setenv("THERMAL_THROTTLE_DELAY", "10")
+
+	cfg := ConfigFromEnv()
+	if !cfg.Enabled || cfg.TempLimit != 78.5 || cfg.Delay != 10*time.Second || cfg.PowerLimit != 95 || cfg.Concurrency != 1 {
+		t.Errorf("ConfigFromEnv() = %+v", cfg)
+	}
+}
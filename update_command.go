@@ -0,0 +1,116 @@
+// Package main provides the update subcommand for the self-updater.
+//
+// Usage:
+//
+//	canvuslocallm update check [-channel beta]
+//	canvuslocallm update apply [-channel beta]
+//
+// check prints the running version and the latest release on the channel.
+// apply downloads and verifies the latest release and installs it; on
+// Windows a running service is stopped and started again on the new
+// binary, elsewhere the new version runs after the next restart. Updates
+// are read from UPDATE_MANIFEST_URL and verified with UPDATE_PUBLIC_KEY.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go_backend/core"
+	"go_backend/updater"
+)
+
+// runUpdateCommand runs the update subcommand and returns the process exit code.
+func runUpdateCommand(args []string) int {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	channel := fs.String("channel", "", "release channel: stable or beta (default: UPDATE_CHANNEL)")
+	fs.Usage = func() {
+		fmt.Println("Usage: canvuslocallm update [-channel stable|beta] check | apply")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return core.ExitCodeError
+	}
+
+	action := "check"
+	if fs.NArg() > 0 {
+		action = fs.Arg(0)
+		// Allow flags after the action, e.g. "update apply -channel beta"
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return core.ExitCodeError
+		}
+	}
+	if action != "check" && action != "apply" {
+		fs.Usage()
+		return core.ExitCodeError
+	}
+
+	if *channel != "" {
+		os.Setenv("UPDATE_CHANNEL", *channel)
+	}
+	cfg, err := updater.ConfigFromEnv()
+	if err != nil {
+		fmt.Printf("Invalid update settings: %v\n", err)
+		return core.ExitCodeError
+	}
+	if !cfg.Enabled() {
+		fmt.Println("Updates are not configured; set UPDATE_MANIFEST_URL and UPDATE_PUBLIC_KEY")
+		return core.ExitCodeError
+	}
+	exe, err := executablePath()
+	if err != nil {
+		fmt.Printf("Failed to find the executable: %v\n", err)
+		return core.ExitCodeError
+	}
+
+	u := updater.New(cfg, core.GetVersion(), exe, nil)
+	update, err := u.Check(context.Background())
+	if err != nil {
+		fmt.Printf("Update check failed: %v\n", err)
+		return core.ExitCodeError
+	}
+	status := u.Status()
+	fmt.Printf("Running:  %s\n", status.Current)
+	fmt.Printf("Latest:   %s (%s channel)\n", status.Latest, status.Channel)
+	if status.Staged != "" {
+		fmt.Printf("Staged:   %s, installed at the next restart\n", status.Staged)
+	}
+	if update != nil && update.Notes != "" {
+		fmt.Printf("Notes:    %s\n", update.Notes)
+	}
+	if action == "check" {
+		return core.ExitCodeSuccess
+	}
+
+	if update == nil && status.Staged == "" {
+		fmt.Println("Already up to date")
+		return core.ExitCodeSuccess
+	}
+	if update != nil && status.Staged != update.Version {
+		fmt.Printf("Downloading %s...\n", update.Version)
+		if err := u.Download(context.Background(), update); err != nil {
+			fmt.Printf("Download failed: %v\n", err)
+			return core.ExitCodeError
+		}
+	}
+	version, err := installUpdate(exe, cfg.PublicKey)
+	if err != nil {
+		fmt.Printf("Failed to install update: %v\n", err)
+		return core.ExitCodeError
+	}
+	fmt.Printf("Installed %s; it runs from the next start of the server\n", version)
+	return core.ExitCodeSuccess
+}
+
+// executablePath returns the path of the running binary with symlinks
+// resolved, so an update replaces the file rather than the link.
+func executablePath() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
@@ -0,0 +1,102 @@
+// Package updater provides the self-updater, which checks a release
+// manifest for a newer build on the configured channel, downloads and
+// verifies its signature, and installs it when the server next stops.
+// This file contains the configuration read from the environment.
+package updater
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go_backend/core"
+)
+
+// Release channels accepted by UPDATE_CHANNEL.
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
+// DefaultCheckInterval is the time between manifest checks when
+// UPDATE_CHECK_HOURS is not set.
+const DefaultCheckInterval = 6 * time.Hour
+
+// Config configures the Updater.
+type Config struct {
+	// ManifestURL is the release manifest; empty disables updates
+	ManifestURL string
+
+	// Channel is ChannelStable or ChannelBeta (default: stable)
+	Channel string
+
+	// PublicKey verifies the signature of downloaded binaries
+	PublicKey ed25519.PublicKey
+
+	// CheckInterval is the time between checks; 0 checks only at startup
+	CheckInterval time.Duration
+
+	// AutoDownload downloads a newer release as soon as it is found, to be
+	// installed when the server next stops (default: true)
+	AutoDownload bool
+}
+
+// Enabled reports whether updates are configured.
+func (c Config) Enabled() bool {
+	return c.ManifestURL != ""
+}
+
+// ConfigFromEnv loads the updater configuration from environment variables:
+//   - UPDATE_MANIFEST_URL: release manifest (default: empty, updates off)
+//   - UPDATE_CHANNEL: stable or beta (default: stable)
+//   - UPDATE_PUBLIC_KEY: Ed25519 public key of the release signer, base64
+//     or hex; required when UPDATE_MANIFEST_URL is set
+//   - UPDATE_CHECK_HOURS: hours between checks (default: 6, 0 = startup only)
+//   - UPDATE_AUTO_DOWNLOAD: download new releases when found (default: true)
+//
+// An error is returned, with updates disabled, if the channel or key is
+// invalid, so a misconfigured unit never installs unverified binaries.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Channel:       strings.ToLower(strings.TrimSpace(core.GetEnvOrDefault("UPDATE_CHANNEL", ChannelStable))),
+		CheckInterval: time.Duration(core.ParseIntEnv("UPDATE_CHECK_HOURS", int(DefaultCheckInterval/time.Hour))) * time.Hour,
+		AutoDownload:  core.ParseBoolEnv("UPDATE_AUTO_DOWNLOAD", true),
+	}
+	manifestURL := strings.TrimSpace(os.Getenv("UPDATE_MANIFEST_URL"))
+	if manifestURL == "" {
+		return cfg, nil
+	}
+	if cfg.Channel != ChannelStable && cfg.Channel != ChannelBeta {
+		return cfg, fmt.Errorf("UPDATE_CHANNEL must be %q or %q, got %q", ChannelStable, ChannelBeta, cfg.Channel)
+	}
+	key, err := ParsePublicKey(os.Getenv("UPDATE_PUBLIC_KEY"))
+	if err != nil {
+		return cfg, fmt.Errorf("UPDATE_PUBLIC_KEY: %w", err)
+	}
+	if cfg.CheckInterval < 0 {
+		cfg.CheckInterval = 0
+	}
+	cfg.ManifestURL = manifestURL
+	cfg.PublicKey = key
+	return cfg, nil
+}
+
+// ParsePublicKey parses an Ed25519 public key in base64 or hex.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, fmt.Errorf("public key is required to verify updates")
+	}
+	key, err := hex.DecodeString(s)
+	if err != nil {
+		key, err = base64.StdEncoding.DecodeString(s)
+	}
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("not a %d-byte Ed25519 key in base64 or hex", ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(key), nil
+}
@@ -0,0 +1,172 @@
+package updater
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Manifest is the release manifest served at UPDATE_MANIFEST_URL:
+//
+//	{
+//	  "channels": {
+//	    "stable": {
+//	      "version": "v1.4.0",
+//	      "notes": "Faster PDF analysis",
+//	      "assets": {
+//	        "windows-amd64": {
+//	          "url": "https://example.com/canvuslocallm-v1.4.0.exe",
+//	          "sha256": "<hex SHA-256 of the binary>",
+//	          "signature": "<base64 Ed25519 signature of SignedMessage>"
+//	        }
+//	      }
+//	    },
+//	    "beta": { ... }
+//	  }
+//	}
+type Manifest struct {
+	Channels map[string]Release `json:"channels"`
+}
+
+// Release is the latest release on a channel.
+type Release struct {
+	Version string `json:"version"`
+	Notes   string `json:"notes,omitempty"`
+	// Assets maps a platform, as returned by Platform, to its binary
+	Assets map[string]Asset `json:"assets"`
+}
+
+// Asset is the binary of a release for one platform.
+type Asset struct {
+	URL string `json:"url"`
+	// SHA256 is the hex SHA-256 of the binary
+	SHA256 string `json:"sha256"`
+	// Signature is the base64 Ed25519 signature of the SignedMessage of
+	// the release version, the platform and the SHA-256 digest
+	Signature string `json:"signature"`
+}
+
+// SignedMessage returns the message an asset's Signature signs. It binds
+// the binary's digest to the release version and platform, so a signed
+// binary cannot be offered again under a newer version or for another
+// platform:
+//
+//	canvuslocallm update
+//	version: v1.4.0
+//	platform: windows-amd64
+//	sha256: <hex SHA-256 of the binary>
+func SignedMessage(version, platform string, digest []byte) []byte {
+	return fmt.Appendf(nil, "canvuslocallm update\nversion: %s\nplatform: %s\nsha256: %x\n", version, platform, digest)
+}
+
+// Platform returns the asset key of this build, e.g. "windows-amd64".
+func Platform() string {
+	return runtime.GOOS + "-" + runtime.GOARCH
+}
+
+// Latest returns the release to run on channel. The beta channel also
+// receives stable releases newer than its latest beta.
+func (m *Manifest) Latest(channel string) (Release, error) {
+	release, ok := m.Channels[channel]
+	if channel == ChannelBeta {
+		if stable, found := m.Channels[ChannelStable]; found && (!ok || CompareVersions(stable.Version, release.Version) > 0) {
+			release, ok = stable, true
+		}
+	}
+	if !ok || release.Version == "" {
+		return Release{}, fmt.Errorf("manifest has no %s release", channel)
+	}
+	return release, nil
+}
+
+// CompareVersions compares two versions such as "v1.2.3" or "1.3.0-beta.2"
+// and returns -1, 0 or +1. A pre-release sorts before its release. Versions
+// that cannot be parsed, such as "dev", sort before every release.
+func CompareVersions(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := range va.core {
+		if c := compareInts(va.core[i], vb.core[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case va.pre == "" && vb.pre == "":
+		return 0
+	case va.pre == "":
+		return 1
+	case vb.pre == "":
+		return -1
+	}
+	return comparePrerelease(va.pre, vb.pre)
+}
+
+// version is a parsed version.
+type version struct {
+	core [3]int
+	pre  string
+}
+
+// parseVersion parses "v1.2.3-pre+build"; missing minor and patch
+// numbers are 0.
+func parseVersion(s string) (version, bool) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	var v version
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		s, v.pre = s[:i], s[i+1:]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return version{}, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return version{}, false
+		}
+		v.core[i] = n
+	}
+	return v, true
+}
+
+// comparePrerelease compares dot-separated pre-release identifiers,
+// numerically where both are numbers.
+func comparePrerelease(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		var c int
+		if errA == nil && errB == nil {
+			c = compareInts(na, nb)
+		} else {
+			c = strings.Compare(pa[i], pb[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(pa), len(pb))
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
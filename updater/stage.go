@@ -0,0 +1,161 @@
+package updater
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Suffixes of the files kept next to the executable.
+const (
+	// StagedSuffix names the downloaded binary waiting to be installed
+	StagedSuffix = ".update"
+	// OldSuffix names the replaced binary, removed at the next start
+	OldSuffix = ".old"
+)
+
+// ErrSignature is returned when a binary does not match its manifest
+// checksum or signature.
+var ErrSignature = errors.New("update signature verification failed")
+
+// staged describes the binary at exe+StagedSuffix. It is written next to
+// it with a ".json" suffix once the download has been verified.
+type staged struct {
+	Version string `json:"version"`
+	Asset   Asset  `json:"asset"`
+}
+
+// StagedVersion returns the version of the update waiting to be installed
+// next to exe, or "" if there is none.
+func StagedVersion(exe string) string {
+	info, err := readStaged(exe)
+	if err != nil {
+		return ""
+	}
+	return info.Version
+}
+
+// Apply installs the update staged next to exe: the staged binary is
+// verified again, exe is renamed to exe+OldSuffix and the staged binary
+// takes its place. It returns the installed version, or "" if nothing was
+// staged. The running process keeps running the old binary; the next start
+// runs the new one. Renaming a running executable is allowed on Windows,
+// so this also works before the process exits.
+func Apply(exe string, key ed25519.PublicKey) (string, error) {
+	info, err := readStaged(exe)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	stagedPath := exe + StagedSuffix
+	if err := verifyFile(stagedPath, info.Version, info.Asset, key); err != nil {
+		discardStaged(exe)
+		return "", err
+	}
+
+	oldPath := exe + OldSuffix
+	if err := os.Remove(oldPath); err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to remove previous binary: %w", err)
+	}
+	if err := os.Rename(exe, oldPath); err != nil {
+		return "", fmt.Errorf("failed to move current binary aside: %w", err)
+	}
+	if err := os.Rename(stagedPath, exe); err != nil {
+		// Put the current binary back so the next start still works
+		if restoreErr := os.Rename(oldPath, exe); restoreErr != nil {
+			return "", fmt.Errorf("failed to install update: %v (and to restore %s: %v)", err, exe, restoreErr)
+		}
+		return "", fmt.Errorf("failed to install update: %w", err)
+	}
+	os.Remove(stagedPath + ".json")
+	return info.Version, nil
+}
+
+// RemoveOld removes the binary replaced by the last update, once it is no
+// longer running. It is a no-op if there is none.
+func RemoveOld(exe string) error {
+	if err := os.Remove(exe + OldSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// readStaged reads the description of the staged update. The result wraps
+// os.ErrNotExist if there is none.
+func readStaged(exe string) (staged, error) {
+	var info staged
+	data, err := os.ReadFile(exe + StagedSuffix + ".json")
+	if err != nil {
+		return info, err
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		return info, fmt.Errorf("invalid staged update description: %w", err)
+	}
+	return info, nil
+}
+
+// writeStaged records that the binary at exe+StagedSuffix is a verified
+// copy of asset.
+func writeStaged(exe string, info staged) error {
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(exe+StagedSuffix+".json", data, 0644)
+}
+
+// discardStaged removes a staged update and its description.
+func discardStaged(exe string) {
+	os.Remove(exe + StagedSuffix + ".json")
+	os.Remove(exe + StagedSuffix)
+}
+
+// verifyFile checks the file at path against the checksum and signature of
+// asset, the binary of release version.
+func verifyFile(path, version string, asset Asset, key ed25519.PublicKey) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return verifyDigest(h.Sum(nil), version, asset, key)
+}
+
+// verifyDigest checks a SHA-256 digest against the checksum and signature
+// of asset, the binary of release version.
+func verifyDigest(digest []byte, version string, asset Asset, key ed25519.PublicKey) error {
+	want, err := hex.DecodeString(strings.TrimSpace(asset.SHA256))
+	if err != nil || !bytes.Equal(digest, want) {
+		return fmt.Errorf("%w: checksum mismatch", ErrSignature)
+	}
+	return verifyAsset(version, asset, key)
+}
+
+// verifyAsset checks that asset is signed as this platform's binary of
+// release version.
+func verifyAsset(version string, asset Asset, key ed25519.PublicKey) error {
+	digest, err := hex.DecodeString(strings.TrimSpace(asset.SHA256))
+	if err != nil || len(digest) != sha256.Size {
+		return fmt.Errorf("%w: invalid checksum %q", ErrSignature, asset.SHA256)
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(asset.Signature))
+	if err != nil || !ed25519.Verify(key, SignedMessage(version, Platform(), digest), sig) {
+		return fmt.Errorf("%w: invalid signature", ErrSignature)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package updater
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Request limits. Binaries with bundled runtimes are large, so downloads
+// get far longer than the manifest.
+const (
+	manifestTimeout = 30 * time.Second
+	downloadTimeout = 30 * time.Minute
+	// maxManifestBytes bounds the manifest read into memory
+	maxManifestBytes = 1 << 20
+)
+
+// Update is a release newer than the running build.
+type Update struct {
+	Version string `json:"version"`
+	Notes   string `json:"notes,omitempty"`
+	Asset   Asset  `json:"-"`
+}
+
+// Status is the updater state reported by the CLI and logs.
+type Status struct {
+	Current string `json:"current"`
+	Channel string `json:"channel"`
+	// Latest is the newest version found on the channel
+	Latest string `json:"latest,omitempty"`
+	// Staged is the version waiting to be installed at the next restart
+	Staged    string    `json:"staged,omitempty"`
+	LastCheck time.Time `json:"last_check,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Updater checks for, downloads and installs new releases of the
+// executable at exe. A nil Updater does nothing.
+type Updater struct {
+	config  Config
+	current string
+	exe     string
+	client  *http.Client
+	logger  *zap.Logger
+
+	mu     sync.Mutex
+	status Status
+}
+
+// New creates an updater for the executable at exe, which runs version
+// current.
+func New(config Config, current, exe string, logger *zap.Logger) *Updater {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Updater{
+		config:  config,
+		current: current,
+		exe:     exe,
+		client:  &http.Client{},
+		logger:  logger.Named("updater"),
+		status:  Status{Current: current, Channel: config.Channel},
+	}
+}
+
+// Run checks for updates now and then every CheckInterval until ctx is
+// done, downloading new releases if AutoDownload is set.
+func (u *Updater) Run(ctx context.Context) {
+	if u == nil {
+		return
+	}
+	u.checkAndDownload(ctx)
+	if u.config.CheckInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(u.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			u.checkAndDownload(ctx)
+		}
+	}
+}
+
+// checkAndDownload runs one check, logging the outcome.
+func (u *Updater) checkAndDownload(ctx context.Context) {
+	update, err := u.Check(ctx)
+	if err != nil {
+		u.logger.Warn("Update check failed", zap.Error(err))
+		return
+	}
+	if update == nil {
+		u.logger.Debug("No update available", zap.String("version", u.current), zap.String("channel", u.config.Channel))
+		return
+	}
+	if StagedVersion(u.exe) == update.Version {
+		return
+	}
+	if !u.config.AutoDownload {
+		u.logger.Info("Update available", zap.String("version", update.Version), zap.String("current", u.current))
+		return
+	}
+	if err := u.Download(ctx, update); err != nil {
+		u.logger.Warn("Update download failed", zap.String("version", update.Version), zap.Error(err))
+		return
+	}
+	u.logger.Info("Update downloaded; it will be installed when the server restarts",
+		zap.String("version", update.Version), zap.String("current", u.current))
+}
+
+// Check fetches the manifest and returns the release on the configured
+// channel if it is newer than the running build, or nil if there is none.
+func (u *Updater) Check(ctx context.Context) (*Update, error) {
+	update, latest, err := u.check(ctx)
+	u.mu.Lock()
+	u.status.LastCheck = time.Now()
+	u.status.LastError = ""
+	if err != nil {
+		u.status.LastError = err.Error()
+	} else {
+		u.status.Latest = latest
+	}
+	u.mu.Unlock()
+	return update, err
+}
+
+func (u *Updater) check(ctx context.Context) (*Update, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, manifestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.config.ManifestURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid manifest URL: %w", err)
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to fetch manifest: %s", resp.Status)
+	}
+
+	var manifest Manifest
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestBytes)).Decode(&manifest); err != nil {
+		return nil, "", fmt.Errorf("invalid manifest: %w", err)
+	}
+	release, err := manifest.Latest(u.config.Channel)
+	if err != nil {
+		return nil, "", err
+	}
+	asset, ok := release.Assets[Platform()]
+	if !ok {
+		return nil, "", fmt.Errorf("release %s has no binary for %s", release.Version, Platform())
+	}
+	// The signature covers the version, so an unsigned version is never
+	// compared, reported or downloaded
+	if err := verifyAsset(release.Version, asset, u.config.PublicKey); err != nil {
+		return nil, "", fmt.Errorf("release %s: %w", release.Version, err)
+	}
+	if CompareVersions(release.Version, u.current) <= 0 {
+		return nil, release.Version, nil
+	}
+	if _, ok := parseVersion(u.current); !ok {
+		// Development builds are never replaced by a release
+		return nil, release.Version, nil
+	}
+	return &Update{Version: release.Version, Notes: release.Notes, Asset: asset}, release.Version, nil
+}
+
+// Download fetches the binary of update next to the executable and
+// verifies it. A binary that fails verification is deleted. The update is
+// installed by Apply.
+func (u *Updater) Download(ctx context.Context, update *Update) error {
+	ctx, cancel := context.WithTimeout(ctx, downloadTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, update.Asset.URL, nil)
+	if err != nil {
+		return fmt.Errorf("invalid download URL: %w", err)
+	}
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download update: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download update: %s", resp.Status)
+	}
+
+	// Download to a partial file first so an interrupted download is never
+	// taken for a staged update
+	discardStaged(u.exe)
+	partPath := u.exe + StagedSuffix + ".part"
+	f, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", partPath, err)
+	}
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(f, h), resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = verifyDigest(h.Sum(nil), update.Version, update.Asset, u.config.PublicKey)
+	}
+	if err == nil {
+		err = os.Rename(partPath, u.exe+StagedSuffix)
+	}
+	if err == nil {
+		err = writeStaged(u.exe, staged{Version: update.Version, Asset: update.Asset})
+	}
+	if err != nil {
+		os.Remove(partPath)
+		discardStaged(u.exe)
+		return err
+	}
+	return nil
+}
+
+// Apply installs a downloaded update; see the package-level Apply.
+func (u *Updater) Apply() (string, error) {
+	if u == nil {
+		return "", nil
+	}
+	return Apply(u.exe, u.config.PublicKey)
+}
+
+// Status returns the current updater state.
+func (u *Updater) Status() Status {
+	if u == nil {
+		return Status{}
+	}
+	u.mu.Lock()
+	status := u.status
+	u.mu.Unlock()
+	status.Staged = StagedVersion(u.exe)
+	return status
+}
@@ -0,0 +1,250 @@
+package updater
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "1.2.3", 0},
+		{"v1.10.0", "v1.9.9", 1},
+		{"v1.2", "v1.2.1", -1},
+		{"v1.3.0-beta.2", "v1.3.0-beta.10", -1},
+		{"v1.3.0-beta.1", "v1.3.0", -1},
+		{"v1.3.0-rc.1", "v1.3.0-beta.4", 1},
+		{"v1.0.0+build5", "v1.0.0", 0},
+		{"dev", "v0.0.1", -1},
+		{"dev", "unknown", 0},
+	}
+	for _, tt := range tests {
+		if got := CompareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestManifestLatest(t *testing.T) {
+	m := &Manifest{Channels: map[string]Release{
+		ChannelStable: {Version: "v1.4.0"},
+		ChannelBeta:   {Version: "v1.5.0-beta.1"},
+	}}
+	if r, _ := m.Latest(ChannelStable); r.Version != "v1.4.0" {
+		t.Errorf("stable = %s, want v1.4.0", r.Version)
+	}
+	if r, _ := m.Latest(ChannelBeta); r.Version != "v1.5.0-beta.1" {
+		t.Errorf("beta = %s, want v1.5.0-beta.1", r.Version)
+	}
+
+	// Beta follows stable once stable has moved past it
+	m.Channels[ChannelStable] = Release{Version: "v1.5.0"}
+	if r, _ := m.Latest(ChannelBeta); r.Version != "v1.5.0" {
+		t.Errorf("beta after stable release = %s, want v1.5.0", r.Version)
+	}
+	delete(m.Channels, ChannelStable)
+	if _, err := m.Latest(ChannelStable); err == nil {
+		t.Error("Latest() with no stable release returned no error")
+	}
+}
+
+// signedAsset returns this platform's asset for binary in release
+// version, signed with key.
+func signedAsset(key ed25519.PrivateKey, version string, binary []byte) Asset {
+	digest := sha256.Sum256(binary)
+	return Asset{
+		SHA256:    hex.EncodeToString(digest[:]),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, SignedMessage(version, Platform(), digest[:]))),
+	}
+}
+
+// releaseServer serves a manifest with one release of binary on channel,
+// signed with key.
+func releaseServer(t *testing.T, key ed25519.PrivateKey, channel, version string, binary []byte) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	asset := signedAsset(key, version, binary)
+	asset.URL = srv.URL + "/binary"
+	manifest := Manifest{Channels: map[string]Release{channel: {
+		Version: version,
+		Assets:  map[string]Asset{Platform(): asset},
+	}}}
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(manifest)
+	})
+	mux.HandleFunc("/binary", func(w http.ResponseWriter, r *http.Request) {
+		w.Write(binary)
+	})
+	return srv
+}
+
+func TestUpdater_DownloadAndApply(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	srv := releaseServer(t, priv, ChannelBeta, "v2.0.0-beta.1", []byte("new binary"))
+	exe := filepath.Join(t.TempDir(), "canvuslocallm")
+	if err := os.WriteFile(exe, []byte("old binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Config{ManifestURL: srv.URL + "/manifest.json", Channel: ChannelStable, PublicKey: pub}
+	if update, err := New(cfg, "v1.0.0", exe, nil).Check(context.Background()); err == nil || update != nil {
+		t.Errorf("stable Check() = %v, %v; want an error for the missing stable release", update, err)
+	}
+
+	cfg.Channel = ChannelBeta
+	u := New(cfg, "v1.0.0", exe, nil)
+	update, err := u.Check(context.Background())
+	if err != nil || update == nil || update.Version != "v2.0.0-beta.1" {
+		t.Fatalf("beta Check() = %+v, %v", update, err)
+	}
+	if err := u.Download(context.Background(), update); err != nil {
+		t.Fatalf("Download() error: %v", err)
+	}
+	if status := u.Status(); status.Staged != "v2.0.0-beta.1" || status.Latest != "v2.0.0-beta.1" {
+		t.Errorf("Status() = %+v", status)
+	}
+
+	version, err := u.Apply()
+	if err != nil || version != "v2.0.0-beta.1" {
+		t.Fatalf("Apply() = %q, %v", version, err)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "new binary" {
+		t.Errorf("executable after Apply = %q", data)
+	}
+	if data, _ := os.ReadFile(exe + OldSuffix); string(data) != "old binary" {
+		t.Errorf("old binary = %q", data)
+	}
+	if version, err := u.Apply(); version != "" || err != nil {
+		t.Errorf("second Apply() = %q, %v; want nothing staged", version, err)
+	}
+
+	if err := RemoveOld(exe); err != nil {
+		t.Errorf("RemoveOld() error: %v", err)
+	}
+	if _, err := os.Stat(exe + OldSuffix); !os.IsNotExist(err) {
+		t.Errorf("old binary still exists: %v", err)
+	}
+
+	// The running version is now current
+	if update, err := New(cfg, "v2.0.0-beta.1", exe, nil).Check(context.Background()); err != nil || update != nil {
+		t.Errorf("Check() when current = %v, %v", update, err)
+	}
+	if update, err := New(cfg, "dev", exe, nil).Check(context.Background()); err != nil || update != nil {
+		t.Errorf("Check() on a dev build = %v, %v; want no update", update, err)
+	}
+}
+
+func TestUpdater_RejectsBadSignature(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+	srv := releaseServer(t, otherKey, ChannelStable, "v1.1.0", []byte("evil binary"))
+	exe := filepath.Join(t.TempDir(), "canvuslocallm")
+	os.WriteFile(exe, []byte("old binary"), 0755)
+
+	u := New(Config{ManifestURL: srv.URL + "/manifest.json", Channel: ChannelStable, PublicKey: pub}, "v1.0.0", exe, nil)
+	update, err := u.Check(context.Background())
+	if !errors.Is(err, ErrSignature) || update != nil {
+		t.Fatalf("Check() = %v, %v; want ErrSignature", update, err)
+	}
+	if latest := u.Status().Latest; latest != "" {
+		t.Errorf("Status().Latest = %q from an unsigned release", latest)
+	}
+
+	// A binary that does not match its signed checksum is deleted
+	_, priv, _ := ed25519.GenerateKey(nil)
+	u = New(Config{PublicKey: priv.Public().(ed25519.PublicKey)}, "v1.0.0", exe, nil)
+	asset := signedAsset(priv, "v1.1.0", []byte("good binary"))
+	asset.URL = srv.URL + "/binary"
+	if err := u.Download(context.Background(), &Update{Version: "v1.1.0", Asset: asset}); !errors.Is(err, ErrSignature) {
+		t.Fatalf("Download() error = %v, want ErrSignature", err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(exe))
+	if len(entries) != 1 {
+		t.Errorf("files after a rejected download = %d, want only the executable", len(entries))
+	}
+
+	// A staged binary changed after download is not installed
+	os.WriteFile(exe+StagedSuffix, []byte("swapped binary"), 0755)
+	writeStaged(exe, staged{Version: "v1.1.0", Asset: signedAsset(otherKey, "v1.1.0", []byte("good binary"))})
+	if _, err := Apply(exe, pub); !errors.Is(err, ErrSignature) {
+		t.Errorf("Apply() error = %v, want ErrSignature", err)
+	}
+	if data, _ := os.ReadFile(exe); string(data) != "old binary" {
+		t.Errorf("executable after rejected Apply = %q", data)
+	}
+}
+
+func TestUpdater_RejectsSignatureForAnotherRelease(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	binary := []byte("v1.1.0 binary")
+	tests := []struct {
+		name  string
+		asset Asset
+	}{
+		// An old signed binary offered as a newer release
+		{"version", signedAsset(priv, "v1.1.0", binary)},
+		// A binary signed for another platform
+		{"platform", func() Asset {
+			digest := sha256.Sum256(binary)
+			sig := ed25519.Sign(priv, SignedMessage("v9.0.0", "plan9-mips", digest[:]))
+			return Asset{SHA256: hex.EncodeToString(digest[:]), Signature: base64.StdEncoding.EncodeToString(sig)}
+		}()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest := Manifest{Channels: map[string]Release{ChannelStable: {
+				Version: "v9.0.0",
+				Assets:  map[string]Asset{Platform(): tt.asset},
+			}}}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewEncoder(w).Encode(manifest)
+			}))
+			defer srv.Close()
+
+			u := New(Config{ManifestURL: srv.URL, Channel: ChannelStable, PublicKey: pub}, "v1.0.0", t.TempDir(), nil)
+			if update, err := u.Check(context.Background()); !errors.Is(err, ErrSignature) || update != nil {
+				t.Errorf("Check() = %+v, %v; want ErrSignature", update, err)
+			}
+		})
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("UPDATE_MANIFEST_URL", "")
+	if cfg, err := ConfigFromEnv(); err != nil || cfg.Enabled() {
+		t.Errorf("ConfigFromEnv() without a URL = %+v, %v; want disabled", cfg, err)
+	}
+
+	t.Setenv("UPDATE_MANIFEST_URL", "https://example.com/manifest.json")
+	if cfg, err := ConfigFromEnv(); err == nil || cfg.Enabled() {
+		t.Errorf("ConfigFromEnv() without a key = %+v, %v; want an error", cfg, err)
+	}
+
+	pub, _, _ := ed25519.GenerateKey(nil)
+	t.Setenv("UPDATE_PUBLIC_KEY", base64.StdEncoding.EncodeToString(pub))
+	t.Setenv("UPDATE_CHANNEL", "Beta")
+	cfg, err := ConfigFromEnv()
+	if err != nil || !cfg.Enabled() || cfg.Channel != ChannelBeta || !cfg.PublicKey.Equal(pub) || cfg.CheckInterval != DefaultCheckInterval {
+		t.Errorf("ConfigFromEnv() = %+v, %v", cfg, err)
+	}
+
+	t.Setenv("UPDATE_CHANNEL", "nightly")
+	if _, err := ConfigFromEnv(); err == nil {
+		t.Error("ConfigFromEnv() accepted channel nightly")
+	}
+}
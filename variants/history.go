@@ -0,0 +1,84 @@
+// Package variants provides the generation history of image trigger
+// notes. This file contains the History organism, which records generated
+// images with their thumbnails and lists them per note.
+package variants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go_backend/artifacts"
+	"go_backend/core/clock"
+)
+
+// Storage persists variants. Implemented by db.Repository.
+type Storage interface {
+	// AddImageVariant stores v and removes the oldest variants of its note
+	// beyond keep. It returns the ID of v.
+	AddImageVariant(ctx context.Context, v Variant, keep int) (int64, error)
+	// ListImageVariants returns the variants of a note, newest first.
+	ListImageVariants(ctx context.Context, canvasID, noteID string) ([]Variant, error)
+}
+
+// History records the images generated from trigger notes.
+//
+// Thread-Safety: History is safe for concurrent use if its Storage is.
+type History struct {
+	storage Storage
+	keep    int
+	clock   clock.Clock
+}
+
+// NewHistory creates a History that keeps the keep latest variants of each
+// note. It returns nil if keep is not positive, which records nothing.
+func NewHistory(storage Storage, keep int) *History {
+	if storage == nil || keep <= 0 {
+		return nil
+	}
+	return &History{storage: storage, keep: keep, clock: clock.Real()}
+}
+
+// SetClock sets the clock that stamps recorded variants (default:
+// clock.Real). Call it before the history is used.
+func (h *History) SetClock(c clock.Clock) {
+	h.clock = clock.OrReal(c)
+}
+
+// Keep returns the number of variants kept per note.
+func (h *History) Keep() int {
+	if h == nil {
+		return 0
+	}
+	return h.keep
+}
+
+// Record stores v with a thumbnail of image. Images without a note are
+// not recorded.
+func (h *History) Record(ctx context.Context, v Variant, image []byte) error {
+	if h == nil || v.NoteID == "" {
+		return nil
+	}
+	thumb, width, height, err := artifacts.Thumbnail(image)
+	if err != nil {
+		return err
+	}
+	v.Thumbnail, v.Width, v.Height = thumb, width, height
+	v.CreatedAt = h.clock.Now().UTC()
+	if _, err := h.storage.AddImageVariant(ctx, v, h.keep); err != nil {
+		return fmt.Errorf("variants: failed to record image of note %s: %w", v.NoteID, err)
+	}
+	return nil
+}
+
+// List returns the variants of a note, newest first.
+func (h *History) List(ctx context.Context, canvasID, noteID string) ([]Variant, error) {
+	if h == nil {
+		return nil, errors.New("variants: image variants are not kept (IMAGE_VARIANTS_KEEP is 0)")
+	}
+	list, err := h.storage.ListImageVariants(ctx, canvasID, noteID)
+	if err != nil {
+		return nil, fmt.Errorf("variants: failed to list images of note %s: %w", noteID, err)
+	}
+	return list, nil
+}
@@ -0,0 +1,142 @@
+// Package variants provides the generation history of image trigger
+// notes. This file contains the layout of the variant strip on the canvas.
+package variants
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go_backend/artifacts"
+)
+
+// stripGap is the gap between the note and the strip and between
+// thumbnails, in canvas units at scale 1.
+const stripGap = 20
+
+// Canvas creates the strip's image widgets. Implemented by canvusapi.Client.
+type Canvas interface {
+	CreateImage(filePath string, metadata map[string]interface{}) (map[string]interface{}, error)
+}
+
+// Anchor is the trigger note the strip is laid out under.
+type Anchor struct {
+	X, Y          float64
+	Width, Height float64
+	Scale         float64
+	Depth         float64
+}
+
+// Title returns the widget title of the n-th newest variant.
+func (v Variant) Title(n int) string {
+	if v.Seed != nil {
+		return fmt.Sprintf("Variant %d · seed %d", n, *v.Seed)
+	}
+	return fmt.Sprintf("Variant %d", n)
+}
+
+// PostStrip uploads the thumbnails of list, newest first, in a row under
+// the note, staging them in dir. Each is titled with its number, which
+// "variants: N" takes. It returns the IDs of the widgets created.
+func PostStrip(ctx context.Context, canvas Canvas, list []Variant, anchor Anchor, dir string) ([]string, error) {
+	staging, err := stagingDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	scale := anchor.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	x := anchor.X
+	y := anchor.Y + (anchor.Height+stripGap)*scale
+	var ids []string
+	for i, v := range list {
+		if err := ctx.Err(); err != nil {
+			return ids, err
+		}
+		if len(v.Thumbnail) == 0 {
+			continue
+		}
+		path := filepath.Join(staging, fmt.Sprintf("variant_%d.jpg", i+1))
+		if err := os.WriteFile(path, v.Thumbnail, 0644); err != nil {
+			return ids, err
+		}
+		width, height := thumbnailSize(v)
+		response, err := canvas.CreateImage(path, map[string]interface{}{
+			"title":    v.Title(i + 1),
+			"location": map[string]float64{"x": x, "y": y},
+			"size":     map[string]interface{}{"width": width, "height": height},
+			"depth":    anchor.Depth + 10,
+			"scale":    scale,
+		})
+		if err != nil {
+			return ids, fmt.Errorf("variants: failed to upload variant %d: %w", i+1, err)
+		}
+		id, _ := response["id"].(string)
+		ids = append(ids, id)
+		x += (width + stripGap) * scale
+	}
+	return ids, nil
+}
+
+// PostImage uploads the full-size image of the n-th newest variant to the
+// right of the note, staging it in dir, and returns the widget ID.
+func PostImage(canvas Canvas, v Variant, n int, image []byte, name string, anchor Anchor, dir string) (string, error) {
+	staging, err := stagingDir(dir)
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(staging)
+
+	path := filepath.Join(staging, filepath.Base(name))
+	if err := os.WriteFile(path, image, 0644); err != nil {
+		return "", err
+	}
+	scale := anchor.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+	metadata := map[string]interface{}{
+		"title":    v.Title(n),
+		"location": map[string]float64{"x": anchor.X + (anchor.Width+stripGap)*scale, "y": anchor.Y},
+		"depth":    anchor.Depth + 10,
+		// Generated images are uploaded at a third of the note's scale
+		"scale": scale / 3,
+	}
+	if v.Width > 0 && v.Height > 0 {
+		metadata["size"] = map[string]interface{}{"width": float64(v.Width), "height": float64(v.Height)}
+	}
+	response, err := canvas.CreateImage(path, metadata)
+	if err != nil {
+		return "", fmt.Errorf("variants: failed to upload variant %d: %w", n, err)
+	}
+	id, _ := response["id"].(string)
+	return id, nil
+}
+
+// thumbnailSize returns the size of the thumbnail of v in pixels.
+func thumbnailSize(v Variant) (width, height float64) {
+	const size = artifacts.ThumbnailSize
+	w, h := float64(v.Width), float64(v.Height)
+	if w <= 0 || h <= 0 {
+		return size, size
+	}
+	if w <= size && h <= size {
+		return w, h
+	}
+	if w >= h {
+		return size, h * size / w
+	}
+	return w * size / h, size
+}
+
+// stagingDir creates a temporary directory in dir for uploads.
+func stagingDir(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(dir, "variants-")
+}
@@ -0,0 +1,79 @@
+// Package variants provides the generation history of image trigger
+// notes: the latest images generated from each note are kept in the
+// database with a thumbnail, so a {{variants}} trigger can lay out the
+// earlier attempts next to the note and bring one of them back. This file
+// contains the Variant type, the settings and the trigger parser.
+package variants
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go_backend/core"
+)
+
+// DefaultKeep is the number of variants kept per note.
+const DefaultKeep = 8
+
+// Variant is one image generated from a trigger note.
+type Variant struct {
+	ID       int64  `json:"id"`
+	CanvasID string `json:"canvas_id"`
+	// NoteID is the trigger note the image was generated from
+	NoteID string `json:"note_id"`
+	// WidgetID is the image widget created on the canvas
+	WidgetID string `json:"widget_id,omitempty"`
+	// ArtifactID is the full-size image in the artifact store, if kept
+	ArtifactID string `json:"artifact_id,omitempty"`
+	Prompt     string `json:"prompt"`
+	// Seed is set for local Stable Diffusion images
+	Seed   *int64 `json:"seed,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	// Thumbnail is a JPEG at most artifacts.ThumbnailSize pixels on a side
+	Thumbnail []byte    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Config holds the variant history settings.
+type Config struct {
+	// Keep is the number of variants kept per note; 0 keeps none
+	Keep int
+}
+
+// ConfigFromEnv reads IMAGE_VARIANTS_KEEP.
+func ConfigFromEnv() Config {
+	keep := core.ParseIntEnv("IMAGE_VARIANTS_KEEP", DefaultKeep)
+	if keep < 0 {
+		keep = 0
+	}
+	return Config{Keep: keep}
+}
+
+// ParseTrigger parses the content of a variants trigger: "variants" lays
+// out the earlier images of the note and "variants: 3" brings back the
+// third newest. pick is 0 for the layout. ok is false if content is not a
+// variants trigger.
+func ParseTrigger(content string) (pick int, ok bool) {
+	content = strings.TrimSpace(content)
+	if len(content) < len("variants") || !strings.EqualFold(content[:len("variants")], "variants") {
+		return 0, false
+	}
+	rest := content[len("variants"):]
+	if rest == "" {
+		return 0, true
+	}
+	if rest[0] != ':' {
+		return 0, false // e.g. "variants of a logo" is an ordinary prompt
+	}
+	rest = strings.TrimSpace(rest[1:])
+	if rest == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(rest)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,128 @@
+package variants
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"testing"
+)
+
+// memStorage keeps variants in memory
+type memStorage struct {
+	list []Variant
+}
+
+func (m *memStorage) AddImageVariant(ctx context.Context, v Variant, keep int) (int64, error) {
+	v.ID = int64(len(m.list) + 1)
+	m.list = append([]Variant{v}, m.list...)
+	if len(m.list) > keep {
+		m.list = m.list[:keep]
+	}
+	return v.ID, nil
+}
+
+func (m *memStorage) ListImageVariants(ctx context.Context, canvasID, noteID string) ([]Variant, error) {
+	var out []Variant
+	for _, v := range m.list {
+		if v.CanvasID == canvasID && v.NoteID == noteID {
+			out = append(out, v)
+		}
+	}
+	return out, nil
+}
+
+// fakeCanvas records uploaded widgets
+type fakeCanvas struct {
+	metadata []map[string]interface{}
+}
+
+func (f *fakeCanvas) CreateImage(filePath string, metadata map[string]interface{}) (map[string]interface{}, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return nil, err
+	}
+	f.metadata = append(f.metadata, metadata)
+	return map[string]interface{}{"id": metadata["title"]}, nil
+}
+
+func TestParseTrigger(t *testing.T) {
+	tests := []struct {
+		content string
+		pick    int
+		ok      bool
+	}{
+		{"variants", 0, true},
+		{" Variants ", 0, true},
+		{"variants:", 0, true},
+		{"variants: 3", 3, true},
+		{"variants:12", 12, true},
+		{"variants: 0", 0, false},
+		{"variants: two", 0, false},
+		{"variants of a logo", 0, false},
+		{"image: variants", 0, false},
+	}
+	for _, tt := range tests {
+		pick, ok := ParseTrigger(tt.content)
+		if pick != tt.pick || ok != tt.ok {
+			t.Errorf("ParseTrigger(%q) = %d, %v, want %d, %v", tt.content, pick, ok, tt.pick, tt.ok)
+		}
+	}
+}
+
+func TestHistoryRecordAndStrip(t *testing.T) {
+	if NewHistory(&memStorage{}, 0) != nil {
+		t.Fatal("NewHistory with keep 0 should record nothing")
+	}
+	var disabled *History
+	if err := disabled.Record(context.Background(), Variant{NoteID: "n"}, nil); err != nil {
+		t.Errorf("nil History Record() = %v", err)
+	}
+
+	ctx := context.Background()
+	storage := &memStorage{}
+	h := NewHistory(storage, 2)
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 1024, 512)))
+	for i := int64(1); i <= 3; i++ {
+		seed := i
+		if err := h.Record(ctx, Variant{CanvasID: "c", NoteID: "n", Prompt: "a fox", Seed: &seed}, buf.Bytes()); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	if err := h.Record(ctx, Variant{CanvasID: "c", NoteID: "n"}, []byte("not an image")); err == nil {
+		t.Error("Record() of an undecodable image should fail")
+	}
+
+	list, err := h.List(ctx, "c", "n")
+	if err != nil || len(list) != 2 {
+		t.Fatalf("List() = %+v, %v", list, err)
+	}
+	if *list[0].Seed != 3 || list[0].Width != 1024 || len(list[0].Thumbnail) == 0 || list[0].CreatedAt.IsZero() {
+		t.Errorf("newest variant = %+v", list[0])
+	}
+
+	canvas := &fakeCanvas{}
+	anchor := Anchor{X: 100, Y: 200, Width: 300, Height: 100, Scale: 1}
+	ids, err := PostStrip(ctx, canvas, list, anchor, t.TempDir())
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("PostStrip() = %v, %v", ids, err)
+	}
+	if ids[0] != "Variant 1 · seed 3" || ids[1] != "Variant 2 · seed 2" {
+		t.Errorf("titles = %v", ids)
+	}
+	first := canvas.metadata[0]["location"].(map[string]float64)
+	second := canvas.metadata[1]["location"].(map[string]float64)
+	if first["x"] != 100 || first["y"] != 320 || second["x"] != 100+256+20 {
+		t.Errorf("strip locations = %v, %v", first, second)
+	}
+	if size := canvas.metadata[0]["size"].(map[string]interface{}); size["width"] != 256.0 || size["height"] != 128.0 {
+		t.Errorf("thumbnail size = %v", size)
+	}
+
+	id, err := PostImage(canvas, list[1], 2, buf.Bytes(), "fox.png", anchor, t.TempDir())
+	if err != nil || id != "Variant 2 · seed 2" {
+		t.Errorf("PostImage() = %q, %v", id, err)
+	}
+}
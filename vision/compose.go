@@ -0,0 +1,61 @@
+package vision
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"golang.org/x/image/draw"
+)
+
+// DefaultCompositeHeight is the row height used when composing images for
+// multi-image prompts. Vision models downscale input anyway, so there is
+// little benefit in going higher.
+const DefaultCompositeHeight = 672
+
+// ComposeSideBySide places two images next to each other on a single canvas.
+// Both images are scaled to the same height (preserving aspect ratio) and
+// separated by a white gap so the model can tell them apart.
+// This lets single-image vision models answer comparison questions.
+// This is a pure function with no side effects.
+func ComposeSideBySide(left, right image.Image, height, gap int) (*image.RGBA, error) {
+	if left == nil || right == nil {
+		return nil, ErrInvalidImage
+	}
+	if height <= 0 || gap < 0 {
+		return nil, fmt.Errorf("%w: height=%d gap=%d", ErrInvalidDimensions, height, gap)
+	}
+
+	leftW := scaledWidth(left.Bounds(), height)
+	rightW := scaledWidth(right.Bounds(), height)
+	if leftW == 0 || rightW == 0 {
+		return nil, fmt.Errorf("%w: source image has zero size", ErrInvalidDimensions)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, leftW+gap+rightW, height))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{color.White}, image.Point{}, draw.Src)
+
+	draw.CatmullRom.Scale(dst, image.Rect(0, 0, leftW, height), left, left.Bounds(), draw.Over, nil)
+	draw.CatmullRom.Scale(dst, image.Rect(leftW+gap, 0, leftW+gap+rightW, height), right, right.Bounds(), draw.Over, nil)
+
+	return dst, nil
+}
+
+// EncodePNG encodes an image as PNG bytes suitable for VisionParams.ImageData.
+func EncodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("vision: failed to encode PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// scaledWidth returns the width an image would have when scaled to height.
+func scaledWidth(bounds image.Rectangle, height int) int {
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return 0
+	}
+	return max(1, bounds.Dx()*height/bounds.Dy())
+}
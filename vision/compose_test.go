@@ -0,0 +1,97 @@
+package vision
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestComposeSideBySide(t *testing.T) {
+	tests := []struct {
+		name   string
+		leftW  int
+		leftH  int
+		rightW int
+		rightH int
+		height int
+		gap    int
+		wantW  int
+	}{
+		{
+			name:  "equal squares",
+			leftW: 100, leftH: 100,
+			rightW: 100, rightH: 100,
+			height: 200, gap: 10,
+			wantW: 410,
+		},
+		{
+			name:  "landscape and portrait",
+			leftW: 200, leftH: 100,
+			rightW: 50, rightH: 100,
+			height: 100, gap: 0,
+			wantW: 250,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left := createTestImage(tt.leftW, tt.leftH)
+			right := createTestImage(tt.rightW, tt.rightH)
+
+			got, err := ComposeSideBySide(left, right, tt.height, tt.gap)
+			if err != nil {
+				t.Fatalf("ComposeSideBySide() unexpected error: %v", err)
+			}
+
+			bounds := got.Bounds()
+			if bounds.Dx() != tt.wantW || bounds.Dy() != tt.height {
+				t.Errorf("ComposeSideBySide() size = %dx%d, want %dx%d",
+					bounds.Dx(), bounds.Dy(), tt.wantW, tt.height)
+			}
+		})
+	}
+}
+
+func TestComposeSideBySideGapIsWhite(t *testing.T) {
+	left := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	right := image.NewRGBA(image.Rect(0, 0, 10, 10))
+
+	got, err := ComposeSideBySide(left, right, 10, 4)
+	if err != nil {
+		t.Fatalf("ComposeSideBySide() unexpected error: %v", err)
+	}
+
+	if c := got.RGBAAt(11, 5); c != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("gap pixel = %v, want white", c)
+	}
+}
+
+func TestComposeSideBySideErrors(t *testing.T) {
+	img := createTestImage(10, 10)
+
+	if _, err := ComposeSideBySide(nil, img, 100, 0); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("nil left: error = %v, want ErrInvalidImage", err)
+	}
+	if _, err := ComposeSideBySide(img, img, 0, 0); !errors.Is(err, ErrInvalidDimensions) {
+		t.Errorf("zero height: error = %v, want ErrInvalidDimensions", err)
+	}
+	if _, err := ComposeSideBySide(img, image.NewRGBA(image.Rect(0, 0, 0, 0)), 100, 0); !errors.Is(err, ErrInvalidDimensions) {
+		t.Errorf("empty right: error = %v, want ErrInvalidDimensions", err)
+	}
+}
+
+func TestEncodePNGRoundTrip(t *testing.T) {
+	data, err := EncodePNG(createTestImage(16, 8))
+	if err != nil {
+		t.Fatalf("EncodePNG() unexpected error: %v", err)
+	}
+
+	img, err := DecodeImage(data)
+	if err != nil {
+		t.Fatalf("DecodeImage() unexpected error: %v", err)
+	}
+	if img.Bounds().Dx() != 16 || img.Bounds().Dy() != 8 {
+		t.Errorf("round trip size = %v, want 16x8", img.Bounds())
+	}
+}
@@ -2,7 +2,6 @@ package vision
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"image"
 	"image/color"
@@ -10,15 +9,17 @@ import (
 	_ "image/jpeg"
 	_ "image/png"
 
+	"go_backend/core/errs"
+
 	"golang.org/x/image/draw"
 )
 
 // Image preprocessing errors
 var (
-	ErrInvalidImage      = errors.New("vision: invalid image data")
-	ErrUnsupportedFormat = errors.New("vision: unsupported image format")
-	ErrInvalidDimensions = errors.New("vision: invalid dimensions")
-	ErrEmptyImage        = errors.New("vision: empty image data")
+	ErrInvalidImage      = errs.New(errs.CodeInvalidImage, "vision: invalid image data")
+	ErrUnsupportedFormat = errs.New(errs.CodeInvalidImage, "vision: unsupported image format")
+	ErrInvalidDimensions = errs.New(errs.CodeInvalidImage, "vision: invalid dimensions")
+	ErrEmptyImage        = errs.New(errs.CodeInvalidImage, "vision: empty image data")
 )
 
 // ResolutionSize defines common model input resolutions
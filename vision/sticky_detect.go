@@ -0,0 +1,212 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+
+	"golang.org/x/image/draw"
+)
+
+// StickyDetectOptions tunes sticky-note detection on a photographed wall.
+type StickyDetectOptions struct {
+	// WorkingSize is the longest side the photo is downscaled to before
+	// segmentation. Smaller is faster; sticky notes stay well above this resolution.
+	WorkingSize int
+
+	// MinSaturation and MinValue (0-1) select the brightly coloured pixels
+	// typical of sticky notes against walls, whiteboards and windows.
+	MinSaturation float64
+	MinValue      float64
+
+	// MinAreaRatio discards regions smaller than this fraction of the photo.
+	MinAreaRatio float64
+
+	// MaxAreaRatio discards regions larger than this fraction (e.g. coloured walls).
+	MaxAreaRatio float64
+}
+
+// DefaultStickyDetectOptions returns options that work for typical phone photos.
+func DefaultStickyDetectOptions() StickyDetectOptions {
+	return StickyDetectOptions{
+		WorkingSize:   320,
+		MinSaturation: 0.25,
+		MinValue:      0.45,
+		MinAreaRatio:  0.002,
+		MaxAreaRatio:  0.25,
+	}
+}
+
+// StickyRegion is a detected sticky note.
+type StickyRegion struct {
+	// Bounds is the note's bounding box in source image pixels
+	Bounds image.Rectangle
+
+	// Color is the average colour of the note's pixels
+	Color color.RGBA
+}
+
+// DetectStickyNotes finds sticky notes in a photo by segmenting saturated,
+// bright pixels into connected regions. Regions are returned in reading order
+// (top-to-bottom rows, left-to-right within a row).
+// This is a pure function with no side effects.
+func DetectStickyNotes(img image.Image, opts StickyDetectOptions) ([]StickyRegion, error) {
+	if img == nil {
+		return nil, ErrInvalidImage
+	}
+	src := img.Bounds()
+	if src.Dx() == 0 || src.Dy() == 0 || opts.WorkingSize <= 0 {
+		return nil, fmt.Errorf("%w: %dx%d working=%d", ErrInvalidDimensions, src.Dx(), src.Dy(), opts.WorkingSize)
+	}
+
+	// Downscale so segmentation cost is independent of photo resolution
+	scale := float64(opts.WorkingSize) / float64(max(src.Dx(), src.Dy()))
+	if scale > 1 {
+		scale = 1
+	}
+	w := max(1, int(float64(src.Dx())*scale))
+	h := max(1, int(float64(src.Dy())*scale))
+	small := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(small, small.Bounds(), img, src, draw.Src, nil)
+
+	mask := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := small.RGBAAt(x, y)
+			s, v := saturationValue(c)
+			mask[y*w+x] = s >= opts.MinSaturation && v >= opts.MinValue
+		}
+	}
+
+	total := float64(w * h)
+	labels := make([]int, w*h)
+	var regions []StickyRegion
+	queue := make([]int, 0, 256)
+	next := 0
+
+	for start := range mask {
+		if !mask[start] || labels[start] != 0 {
+			continue
+		}
+		next++
+		labels[start] = next
+		queue = append(queue[:0], start)
+
+		minX, minY, maxX, maxY := w, h, 0, 0
+		var sumR, sumG, sumB, count int
+		for len(queue) > 0 {
+			p := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			x, y := p%w, p/w
+
+			c := small.RGBAAt(x, y)
+			sumR += int(c.R)
+			sumG += int(c.G)
+			sumB += int(c.B)
+			count++
+			minX, minY = min(minX, x), min(minY, y)
+			maxX, maxY = max(maxX, x), max(maxY, y)
+
+			for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				nx, ny := n[0], n[1]
+				if nx < 0 || ny < 0 || nx >= w || ny >= h {
+					continue
+				}
+				q := ny*w + nx
+				if mask[q] && labels[q] == 0 && similarColor(small.RGBAAt(nx, ny), c) {
+					labels[q] = next
+					queue = append(queue, q)
+				}
+			}
+		}
+
+		ratio := float64(count) / total
+		if ratio < opts.MinAreaRatio || ratio > opts.MaxAreaRatio {
+			continue
+		}
+
+		regions = append(regions, StickyRegion{
+			Bounds: image.Rect(
+				src.Min.X+int(float64(minX)/scale),
+				src.Min.Y+int(float64(minY)/scale),
+				src.Min.X+min(src.Dx(), int(float64(maxX+1)/scale)),
+				src.Min.Y+min(src.Dy(), int(float64(maxY+1)/scale)),
+			),
+			Color: color.RGBA{
+				R: uint8(sumR / count),
+				G: uint8(sumG / count),
+				B: uint8(sumB / count),
+				A: 255,
+			},
+		})
+	}
+
+	sortReadingOrder(regions)
+	return regions, nil
+}
+
+// CropImage returns the part of img inside rect as a new image.
+// This is a pure function with no side effects.
+func CropImage(img image.Image, rect image.Rectangle) image.Image {
+	rect = rect.Intersect(img.Bounds())
+	dst := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, rect.Min, draw.Src)
+	return dst
+}
+
+// ColorHex formats a colour as the #RRGGBBAA string Canvus uses for widget colours.
+func ColorHex(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X%02X", c.R, c.G, c.B, c.A)
+}
+
+// saturationValue returns HSV saturation and value (0-1) for a colour.
+func saturationValue(c color.RGBA) (float64, float64) {
+	hi := max(int(c.R), max(int(c.G), int(c.B)))
+	lo := min(int(c.R), min(int(c.G), int(c.B)))
+	if hi == 0 {
+		return 0, 0
+	}
+	return float64(hi-lo) / float64(hi), float64(hi) / 255
+}
+
+// similarColor keeps flood fill from bleeding between touching notes of different colours.
+func similarColor(a, b color.RGBA) bool {
+	const tolerance = 48
+	return absDiff(a.R, b.R) <= tolerance && absDiff(a.G, b.G) <= tolerance && absDiff(a.B, b.B) <= tolerance
+}
+
+func absDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+// sortReadingOrder sorts regions into rows, top to bottom, then each row
+// left to right. A row starts at its topmost region and takes every region
+// whose top is within half that region's height of it.
+func sortReadingOrder(regions []StickyRegion) {
+	sort.SliceStable(regions, func(i, j int) bool {
+		return regions[i].Bounds.Min.Y < regions[j].Bounds.Min.Y
+	})
+	for start := 0; start < len(regions); {
+		top := regions[start].Bounds
+		end := start + 1
+		for end < len(regions) && regions[end].Bounds.Min.Y-top.Min.Y <= top.Dy()/2 {
+			end++
+		}
+		row := regions[start:end]
+		sort.SliceStable(row, func(i, j int) bool {
+			return row[i].Bounds.Min.X < row[j].Bounds.Min.X
+		})
+		start = end
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
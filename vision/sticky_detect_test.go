@@ -0,0 +1,143 @@
+package vision
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+// createWallImage draws coloured squares onto a grey wall.
+func createWallImage(w, h int, notes map[image.Rectangle]color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{200, 200, 200, 255}}, image.Point{}, draw.Src)
+	for rect, c := range notes {
+		draw.Draw(img, rect, &image.Uniform{c}, image.Point{}, draw.Src)
+	}
+	return img
+}
+
+func TestDetectStickyNotes(t *testing.T) {
+	yellow := color.RGBA{250, 230, 60, 255}
+	pink := color.RGBA{250, 120, 180, 255}
+	blue := color.RGBA{90, 170, 250, 255}
+
+	img := createWallImage(800, 600, map[image.Rectangle]color.RGBA{
+		image.Rect(420, 40, 520, 140):  pink,
+		image.Rect(40, 50, 140, 150):   yellow,
+		image.Rect(40, 300, 140, 400):  blue,
+		image.Rect(700, 500, 702, 502): pink, // speck, below minimum area
+	})
+
+	regions, err := DetectStickyNotes(img, DefaultStickyDetectOptions())
+	if err != nil {
+		t.Fatalf("DetectStickyNotes() unexpected error: %v", err)
+	}
+	if len(regions) != 3 {
+		t.Fatalf("DetectStickyNotes() found %d regions, want 3", len(regions))
+	}
+
+	// Reading order: yellow and pink share the first row, blue is below
+	wantColors := []color.RGBA{yellow, pink, blue}
+	for i, want := range wantColors {
+		got := regions[i].Color
+		if absDiff(got.R, want.R) > 8 || absDiff(got.G, want.G) > 8 || absDiff(got.B, want.B) > 8 {
+			t.Errorf("regions[%d].Color = %v, want about %v", i, got, want)
+		}
+	}
+
+	// Bounds are mapped back to source pixels (within downscale rounding)
+	b := regions[0].Bounds
+	if abs(b.Min.X-40) > 5 || abs(b.Min.Y-50) > 5 || abs(b.Dx()-100) > 6 || abs(b.Dy()-100) > 6 {
+		t.Errorf("regions[0].Bounds = %v, want about (40,50)-(140,150)", b)
+	}
+}
+
+func TestDetectStickyNotesSplitsTouchingColours(t *testing.T) {
+	img := createWallImage(400, 400, map[image.Rectangle]color.RGBA{
+		image.Rect(50, 50, 150, 150):  {250, 230, 60, 255},
+		image.Rect(150, 50, 250, 150): {90, 170, 250, 255},
+	})
+
+	regions, err := DetectStickyNotes(img, DefaultStickyDetectOptions())
+	if err != nil {
+		t.Fatalf("DetectStickyNotes() unexpected error: %v", err)
+	}
+	if len(regions) != 2 {
+		t.Errorf("DetectStickyNotes() found %d regions, want 2", len(regions))
+	}
+}
+
+func TestDetectStickyNotesIgnoresColouredWall(t *testing.T) {
+	img := createWallImage(200, 200, map[image.Rectangle]color.RGBA{
+		image.Rect(0, 0, 200, 200): {250, 230, 60, 255},
+	})
+
+	regions, err := DetectStickyNotes(img, DefaultStickyDetectOptions())
+	if err != nil {
+		t.Fatalf("DetectStickyNotes() unexpected error: %v", err)
+	}
+	if len(regions) != 0 {
+		t.Errorf("DetectStickyNotes() found %d regions on a plain wall, want 0", len(regions))
+	}
+}
+
+func TestDetectStickyNotesErrors(t *testing.T) {
+	if _, err := DetectStickyNotes(nil, DefaultStickyDetectOptions()); !errors.Is(err, ErrInvalidImage) {
+		t.Errorf("nil image: error = %v, want ErrInvalidImage", err)
+	}
+	opts := DefaultStickyDetectOptions()
+	opts.WorkingSize = 0
+	if _, err := DetectStickyNotes(createTestImage(10, 10), opts); !errors.Is(err, ErrInvalidDimensions) {
+		t.Errorf("zero working size: error = %v, want ErrInvalidDimensions", err)
+	}
+}
+
+func TestSortReadingOrder(t *testing.T) {
+	note := func(x, y, size int) StickyRegion {
+		return StickyRegion{Bounds: image.Rect(x, y, x+size, y+size)}
+	}
+	// A slightly drifting first row, a staircase that must not chain into
+	// one row, and a small note that is within a large neighbour's row
+	want := []StickyRegion{
+		note(300, 0, 100), note(0, 20, 100), note(150, 40, 100),
+		note(200, 90, 100), note(50, 120, 100),
+		note(400, 240, 20), note(0, 250, 100), note(100, 250, 100),
+	}
+	wantOrder := []image.Point{{0, 20}, {150, 40}, {300, 0}, {50, 120}, {200, 90}, {0, 250}, {100, 250}, {400, 240}}
+
+	// Every rotation of the input gives the same order
+	for shift := range want {
+		regions := append(append([]StickyRegion(nil), want[shift:]...), want[:shift]...)
+		sortReadingOrder(regions)
+		for i, p := range wantOrder {
+			if regions[i].Bounds.Min != p {
+				t.Errorf("shift %d: regions[%d] at %v, want %v", shift, i, regions[i].Bounds.Min, p)
+			}
+		}
+	}
+}
+
+func TestCropImage(t *testing.T) {
+	img := createTestImage(100, 50)
+
+	got := CropImage(img, image.Rect(10, 10, 40, 30))
+	if got.Bounds().Dx() != 30 || got.Bounds().Dy() != 20 {
+		t.Errorf("CropImage() size = %v, want 30x20", got.Bounds())
+	}
+	if got.At(0, 0) != img.At(10, 10) {
+		t.Errorf("CropImage() origin pixel = %v, want %v", got.At(0, 0), img.At(10, 10))
+	}
+
+	clipped := CropImage(img, image.Rect(90, 40, 200, 200))
+	if clipped.Bounds().Dx() != 10 || clipped.Bounds().Dy() != 10 {
+		t.Errorf("CropImage() clipped size = %v, want 10x10", clipped.Bounds())
+	}
+}
+
+func TestColorHex(t *testing.T) {
+	if got := ColorHex(color.RGBA{255, 8, 171, 255}); got != "#FF08ABFF" {
+		t.Errorf("ColorHex() = %q, want #FF08ABFF", got)
+	}
+}
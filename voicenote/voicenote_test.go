@@ -0,0 +1,72 @@
+package voicenote
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go_backend/whisperruntime"
+)
+
+func TestIsAudio(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Standup.M4A": true, "memo.mp3 ": true, "call.wav": true,
+		"demo.mp4": false, "notes.pdf": false, "mp3": false, "": false,
+	} {
+		if got := IsAudio(name); got != want {
+			t.Errorf("IsAudio(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if Extension("Standup.M4A") != ".m4a" || Extension("Recording") != ".mp3" {
+		t.Error("Extension() did not keep the audio extension")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                                     "0:00",
+		65*time.Second + 400*time.Millisecond: "1:05",
+		time.Hour + 2*time.Minute + 3*time.Second: "1:02:03",
+	} {
+		if got := FormatDuration(d); got != want {
+			t.Errorf("FormatDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+
+	transcript := &whisperruntime.Transcript{
+		Text:     "Hello there. Let's start.",
+		Segments: []whisperruntime.Segment{{Start: 0, Text: "Hello there."}, {Start: 75 * time.Second, Text: "Let's start."}},
+	}
+	if got := FormatTranscript(transcript); got != "[0:00] Hello there.\n[1:15] Let's start." {
+		t.Errorf("FormatTranscript() = %q", got)
+	}
+	transcript.Segments = nil
+	if got := FormatTranscript(transcript); got != transcript.Text {
+		t.Errorf("FormatTranscript(no segments) = %q", got)
+	}
+
+	input := SummaryInput(strings.Repeat("x", maxSummaryRunes+10))
+	if !strings.HasPrefix(input, "<transcript>\n") || strings.Count(input, "x") != maxSummaryRunes {
+		t.Errorf("SummaryInput() did not cut the transcript")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	t.Setenv("VOICE_NOTE_SUMMARY_MINUTES", "5")
+	cfg := ConfigFromEnv()
+	if cfg.Summarize(4*time.Minute) || !cfg.Summarize(5*time.Minute) {
+		t.Errorf("Summarize() with %v", cfg.SummaryAfter)
+	}
+	if (Config{}).Summarize(time.Hour) {
+		t.Error("Summarize() without a threshold")
+	}
+
+	var off *Transcriber
+	if ok, err := off.Claim(context.Background(), VoiceNote{}); ok || err != nil || off.Model() != "" {
+		t.Error("nil Transcriber claimed a recording")
+	}
+	if NewTranscriber(cfg, nil, nil) != nil {
+		t.Error("NewTranscriber() without a client is not nil")
+	}
+}
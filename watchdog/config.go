@@ -0,0 +1,94 @@
+package watchdog
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"go_backend/core"
+	"go_backend/core/clock"
+
+	"go.uber.org/zap"
+)
+
+// Default watchdog settings.
+const (
+	DefaultFailureThreshold = 3
+	DefaultBaseRetryDelay   = 5 * time.Second
+	DefaultMaxRetryDelay    = 60 * time.Second
+)
+
+// Config configures the Watchdog.
+type Config struct {
+	// Server is the Canvus server URL, used in alerts and hints
+	Server string
+
+	// FailureThreshold is the number of consecutive stream failures after
+	// which the server is considered down (default: 3)
+	FailureThreshold int
+
+	// BaseRetryDelay is the reconnect delay before the server is down (default: 5s)
+	BaseRetryDelay time.Duration
+
+	// MaxRetryDelay caps the reconnect backoff while the server is down (default: 60s)
+	MaxRetryDelay time.Duration
+
+	// PauseProcessing stops AI processing while the server is down
+	PauseProcessing bool
+
+	// Notifiers receive an alert when the server goes down and when it recovers
+	Notifiers []Notifier
+
+	// Logger for diagnostic output (optional)
+	Logger *zap.Logger
+
+	// Clock timestamps failures, recoveries and alerts. Defaults to the
+	// system clock.
+	Clock clock.Clock
+}
+
+// DefaultConfig returns a Config with the default thresholds and no notifiers.
+func DefaultConfig() Config {
+	return Config{
+		FailureThreshold: DefaultFailureThreshold,
+		BaseRetryDelay:   DefaultBaseRetryDelay,
+		MaxRetryDelay:    DefaultMaxRetryDelay,
+	}
+}
+
+// ConfigFromEnv loads the watchdog configuration from environment variables:
+//   - CANVUS_SERVER: server named in alerts
+//   - WATCHDOG_FAILURE_THRESHOLD: consecutive failures before alerting (default: 3)
+//   - WATCHDOG_PAUSE_PROCESSING: pause AI processing while down (default: false)
+//   - WATCHDOG_WEBHOOK_URL: JSON webhook for alerts (Slack and Teams compatible)
+//   - WATCHDOG_ALERT_EMAIL: comma-separated alert recipients, sent through
+//     SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD and SMTP_FROM
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	cfg.Server = os.Getenv("CANVUS_SERVER")
+	cfg.FailureThreshold = core.ParseIntEnv("WATCHDOG_FAILURE_THRESHOLD", DefaultFailureThreshold)
+	cfg.PauseProcessing = core.ParseBoolEnv("WATCHDOG_PAUSE_PROCESSING", false)
+
+	if url := os.Getenv("WATCHDOG_WEBHOOK_URL"); url != "" {
+		cfg.Notifiers = append(cfg.Notifiers, NewWebhookNotifier(url))
+	}
+
+	if to := splitList(os.Getenv("WATCHDOG_ALERT_EMAIL")); len(to) > 0 {
+		if smtpConfig := core.SMTPConfigFromEnv(); smtpConfig.Enabled() {
+			cfg.Notifiers = append(cfg.Notifiers, &EmailNotifier{SMTP: smtpConfig, To: to})
+		}
+	}
+
+	return cfg
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,123 @@
+package watchdog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go_backend/core"
+)
+
+// Alert events.
+const (
+	// EventDown is sent when the Canvus server is considered down
+	EventDown = "canvus_down"
+	// EventRecovered is sent when the stream reconnects after being down
+	EventRecovered = "canvus_recovered"
+)
+
+// Alert is a notification about a Canvus server outage.
+type Alert struct {
+	Event   string    `json:"event"`
+	Server  string    `json:"server"`
+	Message string    `json:"message"`
+	Status  Status    `json:"status"`
+	Time    time.Time `json:"time"`
+}
+
+// Notifier delivers alerts to operators.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// WebhookNotifier posts alerts as JSON. The payload carries a "text" field,
+// so Slack and Microsoft Teams incoming webhooks display the message as is.
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewWebhookNotifier creates a WebhookNotifier for url.
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// webhookPayload is the JSON body posted by WebhookNotifier.
+type webhookPayload struct {
+	Text string `json:"text"`
+	Alert
+}
+
+// Notify posts the alert to the webhook URL.
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(webhookPayload{Text: alert.Message, Alert: alert})
+	if err != nil {
+		return fmt.Errorf("encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// EmailNotifier sends alerts by email through an SMTP server.
+type EmailNotifier struct {
+	SMTP core.SMTPConfig
+	To   []string
+}
+
+// Notify emails the alert to the recipients.
+func (n *EmailNotifier) Notify(ctx context.Context, alert Alert) error {
+	if err := core.SendMail(ctx, n.SMTP, n.To, alertSubject(alert), alertBody(alert)); err != nil {
+		return fmt.Errorf("send alert email: %w", err)
+	}
+	return nil
+}
+
+// buildEmail formats alert as a plain text email message.
+func buildEmail(from string, to []string, alert Alert) []byte {
+	return core.BuildMailMessage(from, to, alertSubject(alert), alertBody(alert))
+}
+
+// alertSubject returns the email subject for alert.
+func alertSubject(alert Alert) string {
+	if alert.Event == EventRecovered {
+		return "[CanvusLocalLLM] Canvus server recovered"
+	}
+	return "[CanvusLocalLLM] Canvus server down"
+}
+
+// alertBody returns the plain text email body for alert.
+func alertBody(alert Alert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", alert.Message)
+	fmt.Fprintf(&b, "Server: %s\n", alert.Server)
+	fmt.Fprintf(&b, "Time: %s\n", alert.Time.Format(time.RFC1123))
+	if alert.Status.LastError != "" {
+		fmt.Fprintf(&b, "Last error: %s\n", alert.Status.LastError)
+	}
+	if alert.Status.Hint != "" {
+		fmt.Fprintf(&b, "Suggested fix: %s\n", alert.Status.Hint)
+	}
+	return b.String()
+}
@@ -0,0 +1,113 @@
+package watchdog
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go_backend/core"
+)
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	var got map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("Method = %s, want POST", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	alert := Alert{
+		Event:   EventDown,
+		Server:  "https://canvus.example.com",
+		Message: "Canvus server is unreachable",
+		Status:  Status{State: StateDown, ConsecutiveFailures: 3},
+		Time:    time.Now(),
+	}
+	if err := NewWebhookNotifier(server.URL).Notify(context.Background(), alert); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	if got["text"] != alert.Message {
+		t.Errorf("text = %v, want the alert message for Slack/Teams", got["text"])
+	}
+	if got["event"] != EventDown {
+		t.Errorf("event = %v, want %q", got["event"], EventDown)
+	}
+	status, _ := got["status"].(map[string]interface{})
+	if status["state"] != string(StateDown) {
+		t.Errorf("status.state = %v, want %q", status["state"], StateDown)
+	}
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := NewWebhookNotifier(server.URL).Notify(context.Background(), Alert{Event: EventDown})
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("Notify() error = %v, want status 400 error", err)
+	}
+}
+
+func TestBuildEmail(t *testing.T) {
+	msg := string(buildEmail("llm@example.com", []string{"ops@example.com", "dev@example.com"}, Alert{
+		Event:   EventRecovered,
+		Server:  "https://canvus.example.com",
+		Message: "Canvus server is reachable again",
+		Time:    time.Now(),
+	}))
+
+	for _, want := range []string{
+		"From: llm@example.com\r\n",
+		"To: ops@example.com, dev@example.com\r\n",
+		"Subject: [CanvusLocalLLM] Canvus server recovered\r\n",
+		"Canvus server is reachable again",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("email missing %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("CANVUS_SERVER", "https://canvus.example.com")
+	t.Setenv("WATCHDOG_FAILURE_THRESHOLD", "5")
+	t.Setenv("WATCHDOG_PAUSE_PROCESSING", "true")
+	t.Setenv("WATCHDOG_WEBHOOK_URL", "https://hooks.example.com/abc")
+	t.Setenv("WATCHDOG_ALERT_EMAIL", "ops@example.com, ,dev@example.com")
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_PORT", "")
+
+	cfg := ConfigFromEnv()
+	if cfg.Server != "https://canvus.example.com" || cfg.FailureThreshold != 5 || !cfg.PauseProcessing {
+		t.Errorf("ConfigFromEnv() = %+v", cfg)
+	}
+	if len(cfg.Notifiers) != 2 {
+		t.Fatalf("len(Notifiers) = %d, want 2", len(cfg.Notifiers))
+	}
+	email, ok := cfg.Notifiers[1].(*EmailNotifier)
+	if !ok {
+		t.Fatalf("Notifiers[1] = %T, want *EmailNotifier", cfg.Notifiers[1])
+	}
+	if email.SMTP.Port != core.DefaultSMTPPort || len(email.To) != 2 {
+		t.Errorf("EmailNotifier = %+v, want default port and 2 recipients", email)
+	}
+
+	// Email needs an SMTP host
+	t.Setenv("SMTP_HOST", "")
+	if n := len(ConfigFromEnv().Notifiers); n != 1 {
+		t.Errorf("len(Notifiers) without SMTP_HOST = %d, want 1", n)
+	}
+}
@@ -0,0 +1,286 @@
+// Package watchdog tracks the health of the Canvus server connection.
+//
+// The Watchdog organism is fed the outcome of every widget stream connection
+// attempt. It classifies failures (DNS, refused, TLS, ...), marks the server
+// down after sustained failure, backs off reconnects, alerts operators through
+// the configured notifiers and can pause AI processing until the stream
+// reconnects.
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"go_backend/canvusapi"
+	"go_backend/core"
+	"go_backend/core/clock"
+	"go_backend/core/validation"
+
+	"go.uber.org/zap"
+)
+
+// State is the health state of the Canvus server connection.
+type State string
+
+const (
+	// StateHealthy means the last stream connection succeeded
+	StateHealthy State = "healthy"
+	// StateDegraded means recent attempts failed but the threshold is not reached
+	StateDegraded State = "degraded"
+	// StateDown means the stream has failed FailureThreshold times in a row
+	StateDown State = "down"
+)
+
+// Failure kinds reported in addition to the core.ConnectionErrorKind values,
+// for servers that answer with an error status.
+const (
+	// KindServerError means the server answered with a 5xx status
+	KindServerError core.ConnectionErrorKind = "server_error"
+	// KindAuth means the server rejected the API key
+	KindAuth core.ConnectionErrorKind = "auth"
+)
+
+// Status is a snapshot of the watchdog state.
+type Status struct {
+	State               State                    `json:"state"`
+	Server              string                   `json:"server"`
+	Kind                core.ConnectionErrorKind `json:"kind,omitempty"`
+	ConsecutiveFailures int                      `json:"consecutive_failures"`
+	FirstFailure        time.Time                `json:"first_failure,omitempty"`
+	LastSuccess         time.Time                `json:"last_success,omitempty"`
+	LastError           string                   `json:"last_error,omitempty"`
+	Hint                string                   `json:"hint,omitempty"`
+	ProcessingPaused    bool                     `json:"processing_paused"`
+}
+
+// Watchdog is an organism that detects sustained Canvus stream failure.
+//
+// Usage:
+//
+//	wd := watchdog.New(watchdog.ConfigFromEnv())
+//	for {
+//	    if err := connect(); err != nil {
+//	        wd.RecordFailure(err)
+//	        time.Sleep(wd.RetryDelay())
+//	        continue
+//	    }
+//	    wd.RecordSuccess()
+//	}
+type Watchdog struct {
+	mu       sync.RWMutex
+	config   Config
+	logger   *zap.Logger
+	status   Status
+	onChange func(Status)
+}
+
+// New creates a Watchdog in the healthy state.
+func New(config Config) *Watchdog {
+	defaults := DefaultConfig()
+	if config.FailureThreshold < 1 {
+		config.FailureThreshold = defaults.FailureThreshold
+	}
+	if config.BaseRetryDelay <= 0 {
+		config.BaseRetryDelay = defaults.BaseRetryDelay
+	}
+	if config.MaxRetryDelay < config.BaseRetryDelay {
+		config.MaxRetryDelay = defaults.MaxRetryDelay
+		if config.MaxRetryDelay < config.BaseRetryDelay {
+			config.MaxRetryDelay = config.BaseRetryDelay
+		}
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	config.Clock = clock.OrReal(config.Clock)
+
+	return &Watchdog{
+		config: config,
+		logger: logger,
+		status: Status{State: StateHealthy, Server: config.Server},
+	}
+}
+
+// SetOnChange sets a callback run after every state change, e.g. to update
+// the dashboard.
+func (w *Watchdog) SetOnChange(fn func(Status)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onChange = fn
+}
+
+// Status returns the current state. A nil Watchdog reports healthy.
+func (w *Watchdog) Status() Status {
+	if w == nil {
+		return Status{State: StateHealthy}
+	}
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.status
+}
+
+// ProcessingPaused reports whether AI processing should wait for the server
+// to come back. It is always false unless PauseProcessing is enabled.
+func (w *Watchdog) ProcessingPaused() bool {
+	return w.Status().ProcessingPaused
+}
+
+// RetryDelay returns how long to wait before the next reconnect attempt.
+// Below the failure threshold it is BaseRetryDelay; once the server is down
+// it doubles with every failure up to MaxRetryDelay.
+func (w *Watchdog) RetryDelay() time.Duration {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	excess := w.status.ConsecutiveFailures - w.config.FailureThreshold
+	if excess < 0 {
+		return w.config.BaseRetryDelay
+	}
+	delay := w.config.BaseRetryDelay
+	for i := 0; i <= excess && delay < w.config.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > w.config.MaxRetryDelay {
+		delay = w.config.MaxRetryDelay
+	}
+	return delay
+}
+
+// RecordFailure records a failed stream connection attempt.
+func (w *Watchdog) RecordFailure(err error) {
+	now := w.config.Clock.Now()
+	kind, hint := w.diagnose(err)
+
+	w.mu.Lock()
+	prev := w.status.State
+	if w.status.ConsecutiveFailures == 0 {
+		w.status.FirstFailure = now
+	}
+	w.status.ConsecutiveFailures++
+	w.status.Kind = kind
+	w.status.Hint = hint
+	if err != nil {
+		w.status.LastError = err.Error()
+	}
+	if w.status.ConsecutiveFailures >= w.config.FailureThreshold {
+		w.status.State = StateDown
+		w.status.ProcessingPaused = w.config.PauseProcessing
+	} else {
+		w.status.State = StateDegraded
+	}
+	status := w.status
+	w.mu.Unlock()
+
+	if status.State == prev {
+		return
+	}
+	if status.State == StateDown {
+		w.logger.Error("Canvus server down",
+			zap.String("server", status.Server),
+			zap.String("kind", string(status.Kind)),
+			zap.Int("consecutive_failures", status.ConsecutiveFailures),
+			zap.String("hint", status.Hint),
+			zap.Bool("processing_paused", status.ProcessingPaused),
+		)
+		w.notify(Alert{
+			Event:   EventDown,
+			Server:  status.Server,
+			Message: downMessage(status),
+			Status:  status,
+			Time:    now,
+		})
+	}
+	w.changed(status)
+}
+
+// RecordSuccess records a successful stream connection.
+func (w *Watchdog) RecordSuccess() {
+	now := w.config.Clock.Now()
+
+	w.mu.Lock()
+	prev := w.status
+	w.status = Status{State: StateHealthy, Server: w.config.Server, LastSuccess: now}
+	status := w.status
+	w.mu.Unlock()
+
+	if prev.State == StateHealthy {
+		return
+	}
+	if prev.State == StateDown {
+		downtime := now.Sub(prev.FirstFailure).Round(time.Second)
+		w.logger.Info("Canvus server recovered",
+			zap.String("server", status.Server),
+			zap.Duration("downtime", downtime),
+		)
+		w.notify(Alert{
+			Event:   EventRecovered,
+			Server:  status.Server,
+			Message: fmt.Sprintf("Canvus server %s is reachable again after %v", status.Server, downtime),
+			Status:  status,
+			Time:    now,
+		})
+	}
+	w.changed(status)
+}
+
+// diagnose classifies err and returns an actionable hint.
+func (w *Watchdog) diagnose(err error) (core.ConnectionErrorKind, string) {
+	var apiErr *canvusapi.APIError
+	if errors.As(err, &apiErr) {
+		switch {
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			return KindAuth, "The Canvus server rejected CANVUS_API_KEY. Check that the key is valid and has access to CANVAS_ID"
+		case apiErr.StatusCode >= 500:
+			return KindServerError, fmt.Sprintf("The Canvus server answered with status %d. "+
+				"Check that the Canvus service is running and review its logs", apiErr.StatusCode)
+		}
+	}
+
+	kind := core.ClassifyConnectionError(err)
+	hint := validation.DiagnoseConnectionError(err, w.config.Server)
+	if hint == "" {
+		hint = "Check that CANVUS_SERVER is correct and the Canvus service is running"
+	}
+	return kind, hint
+}
+
+// notify delivers alert to every notifier in the background.
+func (w *Watchdog) notify(alert Alert) {
+	for _, n := range w.config.Notifiers {
+		go func(n Notifier) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := n.Notify(ctx, alert); err != nil {
+				w.logger.Warn("Failed to deliver watchdog alert",
+					zap.String("event", alert.Event),
+					zap.Error(err),
+				)
+			}
+		}(n)
+	}
+}
+
+// changed calls the OnChange callback.
+func (w *Watchdog) changed(status Status) {
+	w.mu.RLock()
+	fn := w.onChange
+	w.mu.RUnlock()
+	if fn != nil {
+		fn(status)
+	}
+}
+
+// downMessage describes an outage for alerts.
+func downMessage(status Status) string {
+	msg := fmt.Sprintf("Canvus server %s is unreachable (%s) after %d failed attempts",
+		status.Server, status.Kind, status.ConsecutiveFailures)
+	if status.ProcessingPaused {
+		msg += "; AI processing is paused until it returns"
+	}
+	return msg
+}
@@ -0,0 +1,211 @@
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"go_backend/canvusapi"
+	"go_backend/core"
+	"go_backend/core/clock"
+)
+
+// recordingNotifier collects alerts for assertions.
+type recordingNotifier struct {
+	mu     sync.Mutex
+	alerts []Alert
+	ch     chan Alert
+}
+
+func newRecordingNotifier() *recordingNotifier {
+	return &recordingNotifier{ch: make(chan Alert, 10)}
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, alert Alert) error {
+	n.mu.Lock()
+	n.alerts = append(n.alerts, alert)
+	n.mu.Unlock()
+	n.ch <- alert
+	return nil
+}
+
+func (n *recordingNotifier) wait(t *testing.T) Alert {
+	t.Helper()
+	select {
+	case alert := <-n.ch:
+		return alert
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for alert")
+		return Alert{}
+	}
+}
+
+func refusedError() error {
+	return fmt.Errorf("failed to connect to widget stream: request failed: %w", syscall.ECONNREFUSED)
+}
+
+func TestWatchdog_GoesDownAfterThreshold(t *testing.T) {
+	notifier := newRecordingNotifier()
+	var changes []State
+	wd := New(Config{
+		Server:           "http://127.0.0.1:8090",
+		FailureThreshold: 3,
+		PauseProcessing:  true,
+		Notifiers:        []Notifier{notifier},
+	})
+	wd.SetOnChange(func(s Status) { changes = append(changes, s.State) })
+
+	wd.RecordFailure(refusedError())
+	if got := wd.Status().State; got != StateDegraded {
+		t.Fatalf("State after 1 failure = %q, want %q", got, StateDegraded)
+	}
+	if wd.ProcessingPaused() {
+		t.Error("processing paused before the server is down")
+	}
+
+	wd.RecordFailure(refusedError())
+	wd.RecordFailure(refusedError())
+
+	status := wd.Status()
+	if status.State != StateDown {
+		t.Fatalf("State after 3 failures = %q, want %q", status.State, StateDown)
+	}
+	if status.Kind != core.ConnErrorRefused {
+		t.Errorf("Kind = %q, want %q", status.Kind, core.ConnErrorRefused)
+	}
+	if !strings.Contains(status.Hint, "Nothing is listening on 127.0.0.1:8090") {
+		t.Errorf("Hint = %q, want refused-connection hint", status.Hint)
+	}
+	if !wd.ProcessingPaused() {
+		t.Error("processing not paused while down")
+	}
+
+	alert := notifier.wait(t)
+	if alert.Event != EventDown || !strings.Contains(alert.Message, "paused") {
+		t.Errorf("alert = %+v, want canvus_down mentioning the pause", alert)
+	}
+
+	// Further failures do not alert again
+	wd.RecordFailure(refusedError())
+	select {
+	case extra := <-notifier.ch:
+		t.Errorf("unexpected second alert %+v", extra)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if len(changes) != 2 || changes[0] != StateDegraded || changes[1] != StateDown {
+		t.Errorf("OnChange states = %v, want [degraded down]", changes)
+	}
+}
+
+func TestWatchdog_RecoveryAlert(t *testing.T) {
+	notifier := newRecordingNotifier()
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	wd := New(Config{Server: "https://canvus.example.com", FailureThreshold: 1, Notifiers: []Notifier{notifier}, Clock: fake})
+
+	wd.RecordFailure(refusedError())
+	if down := notifier.wait(t); !down.Time.Equal(start) || !wd.Status().FirstFailure.Equal(start) {
+		t.Errorf("down alert at %v, first failure %v, want %v", down.Time, wd.Status().FirstFailure, start)
+	}
+
+	fake.Advance(90 * time.Second)
+	wd.RecordSuccess()
+	alert := notifier.wait(t)
+	if alert.Event != EventRecovered {
+		t.Errorf("Event = %q, want %q", alert.Event, EventRecovered)
+	}
+	if !strings.Contains(alert.Message, "after 1m30s") {
+		t.Errorf("Message = %q, want the 1m30s downtime", alert.Message)
+	}
+
+	status := wd.Status()
+	if status.State != StateHealthy || status.ConsecutiveFailures != 0 || !status.LastSuccess.Equal(start.Add(90*time.Second)) {
+		t.Errorf("status after recovery = %+v, want healthy and reset", status)
+	}
+}
+
+func TestWatchdog_DegradedRecoveryDoesNotAlert(t *testing.T) {
+	notifier := newRecordingNotifier()
+	wd := New(Config{FailureThreshold: 3, Notifiers: []Notifier{notifier}})
+
+	wd.RecordFailure(refusedError())
+	wd.RecordSuccess()
+
+	select {
+	case alert := <-notifier.ch:
+		t.Errorf("unexpected alert %+v", alert)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWatchdog_PauseDisabled(t *testing.T) {
+	wd := New(Config{FailureThreshold: 1})
+	wd.RecordFailure(refusedError())
+	if wd.ProcessingPaused() {
+		t.Error("processing paused without PauseProcessing")
+	}
+}
+
+func TestWatchdog_Diagnose(t *testing.T) {
+	wd := New(Config{Server: "https://canvus.example.com"})
+
+	tests := []struct {
+		name     string
+		err      error
+		wantKind core.ConnectionErrorKind
+		wantHint string
+	}{
+		{"auth", &canvusapi.APIError{StatusCode: 401, Message: "unauthorized"}, KindAuth, "CANVUS_API_KEY"},
+		{"server error", fmt.Errorf("stream: %w", &canvusapi.APIError{StatusCode: 502}), KindServerError, "status 502"},
+		{"refused remote", refusedError(), core.ConnErrorRefused, "listens on 0.0.0.0"},
+		{"tls", errors.New("x509: certificate signed by unknown authority"), core.ConnErrorTLS, "ALLOW_SELF_SIGNED_CERTS"},
+		{"other", errors.New("boom"), core.ConnErrorOther, "CANVUS_SERVER"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kind, hint := wd.diagnose(tt.err)
+			if kind != tt.wantKind {
+				t.Errorf("kind = %q, want %q", kind, tt.wantKind)
+			}
+			if !strings.Contains(hint, tt.wantHint) {
+				t.Errorf("hint = %q, want it to contain %q", hint, tt.wantHint)
+			}
+		})
+	}
+}
+
+func TestWatchdog_RetryDelay(t *testing.T) {
+	wd := New(Config{FailureThreshold: 2, BaseRetryDelay: time.Second, MaxRetryDelay: 5 * time.Second})
+
+	want := []time.Duration{time.Second, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, w := range want {
+		if got := wd.RetryDelay(); got != w {
+			t.Errorf("RetryDelay() after %d failures = %v, want %v", i, got, w)
+		}
+		wd.RecordFailure(refusedError())
+	}
+}
+
+func TestWatchdog_NilIsHealthy(t *testing.T) {
+	var wd *Watchdog
+	if wd.Status().State != StateHealthy || wd.ProcessingPaused() {
+		t.Error("nil Watchdog should report healthy and not paused")
+	}
+}
+
+func TestNew_Defaults(t *testing.T) {
+	wd := New(Config{})
+	if wd.config.FailureThreshold != DefaultFailureThreshold {
+		t.Errorf("FailureThreshold = %d, want %d", wd.config.FailureThreshold, DefaultFailureThreshold)
+	}
+	if wd.config.BaseRetryDelay != DefaultBaseRetryDelay || wd.config.MaxRetryDelay != DefaultMaxRetryDelay {
+		t.Errorf("retry delays = %v/%v, want defaults", wd.config.BaseRetryDelay, wd.config.MaxRetryDelay)
+	}
+}
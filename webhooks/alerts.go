@@ -0,0 +1,266 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go_backend/events"
+	"go_backend/metrics"
+	"go_backend/watchdog"
+	"go_backend/workerproc"
+)
+
+// Rearm margins: how far a GPU reading must fall below its threshold
+// before the alert can fire again, so readings hovering around the
+// threshold do not flood the targets.
+const (
+	gpuTempRearmMargin   = 5.0
+	gpuMemoryRearmMargin = 5.0
+)
+
+// alertState tracks which threshold alerts have fired. Guarded by
+// Dispatcher.mu.
+type alertState struct {
+	gpuTempAlerted   bool
+	gpuMemoryAlerted bool
+
+	budgetDay      string
+	budgetTasks    int
+	budgetNotified float64
+}
+
+// TaskCompleted reports a finished AI task. Failed tasks send task_failed;
+// every task counts towards the daily task budget.
+func (d *Dispatcher) TaskCompleted(record metrics.TaskRecord) {
+	if !d.Enabled() {
+		return
+	}
+	if record.Status == metrics.TaskStatusError {
+		fields := map[string]string{"task_type": record.Type, "task_id": record.ID}
+		if record.CanvasID != "" {
+			fields["canvas_id"] = record.CanvasID
+		}
+		d.Send(Event{
+			Type:     EventTaskFailed,
+			Severity: SeverityWarning,
+			Title:    fmt.Sprintf("AI task failed: %s", record.Type),
+			Message:  record.ErrorMsg,
+			Fields:   fields,
+			Time:     record.EndTime,
+		})
+	}
+	d.countBudget(record.EndTime)
+}
+
+// countBudget counts a task towards the daily budget and sends
+// budget_threshold when usage first reaches the warning level and 100%.
+func (d *Dispatcher) countBudget(now time.Time) {
+	budget := d.config.DailyTaskBudget
+	if budget <= 0 {
+		return
+	}
+	if now.IsZero() {
+		now = time.Now()
+	}
+	day := now.Format("2006-01-02")
+
+	d.mu.Lock()
+	if d.alerts.budgetDay != day {
+		d.alerts.budgetDay = day
+		d.alerts.budgetTasks = 0
+		d.alerts.budgetNotified = 0
+	}
+	d.alerts.budgetTasks++
+	used := d.alerts.budgetTasks
+	percent := float64(used) * 100 / float64(budget)
+
+	var level float64
+	switch {
+	case percent >= 100 && d.alerts.budgetNotified < 100:
+		level = 100
+	case percent >= DefaultBudgetWarningPercent && d.alerts.budgetNotified < DefaultBudgetWarningPercent:
+		level = DefaultBudgetWarningPercent
+	}
+	if level > 0 {
+		d.alerts.budgetNotified = level
+	}
+	d.mu.Unlock()
+
+	if level == 0 {
+		return
+	}
+	severity := SeverityWarning
+	if level >= 100 {
+		severity = SeverityCritical
+	}
+	d.Send(Event{
+		Type:     EventBudgetThreshold,
+		Severity: severity,
+		Title:    fmt.Sprintf("Daily task budget at %.0f%%", level),
+		Message:  fmt.Sprintf("%d of %d AI tasks used today", used, budget),
+		Fields: map[string]string{
+			"used":   fmt.Sprint(used),
+			"budget": fmt.Sprint(budget),
+			"date":   day,
+		},
+		Time: now,
+	})
+}
+
+// CheckGPU compares GPU metrics with the configured thresholds and sends
+// gpu_alert when temperature or memory use crosses one. Each alert fires
+// once until the reading drops back below the threshold.
+func (d *Dispatcher) CheckGPU(m metrics.GPUMetrics) {
+	if !d.Enabled() {
+		return
+	}
+	var memPercent float64
+	if m.MemoryTotal > 0 {
+		memPercent = float64(m.MemoryUsed) * 100 / float64(m.MemoryTotal)
+	}
+
+	d.mu.Lock()
+	tempAlert := crossed(&d.alerts.gpuTempAlerted, m.Temperature, d.config.GPUTempThreshold, gpuTempRearmMargin)
+	memAlert := crossed(&d.alerts.gpuMemoryAlerted, memPercent, d.config.GPUMemoryThreshold, gpuMemoryRearmMargin)
+	d.mu.Unlock()
+
+	fields := map[string]string{
+		"gpu":         m.Name,
+		"temperature": fmt.Sprintf("%.0f°C", m.Temperature),
+		"memory":      fmt.Sprintf("%.0f%%", memPercent),
+		"utilization": fmt.Sprintf("%.0f%%", m.Utilization),
+	}
+	if tempAlert {
+		d.Send(Event{
+			Type:     EventGPUAlert,
+			Severity: SeverityCritical,
+			Title:    "GPU temperature high",
+			Message: fmt.Sprintf("GPU temperature is %.0f°C (threshold %.0f°C)",
+				m.Temperature, d.config.GPUTempThreshold),
+			Fields: fields,
+		})
+	}
+	if memAlert {
+		d.Send(Event{
+			Type:     EventGPUAlert,
+			Severity: SeverityWarning,
+			Title:    "GPU memory nearly full",
+			Message: fmt.Sprintf("GPU memory is %.0f%% used (threshold %.0f%%)",
+				memPercent, d.config.GPUMemoryThreshold),
+			Fields: fields,
+		})
+	}
+}
+
+// crossed reports whether value newly reached threshold, updating alerted.
+// A threshold of 0 disables the check.
+func crossed(alerted *bool, value, threshold, rearmMargin float64) bool {
+	if threshold <= 0 {
+		return false
+	}
+	if *alerted {
+		if value < threshold-rearmMargin {
+			*alerted = false
+		}
+		return false
+	}
+	if value >= threshold {
+		*alerted = true
+		return true
+	}
+	return false
+}
+
+// Notify implements watchdog.Notifier, turning Canvus outage alerts into
+// stream_disconnected and stream_reconnected events.
+func (d *Dispatcher) Notify(ctx context.Context, alert watchdog.Alert) error {
+	event := Event{
+		Type:     EventStreamDisconnected,
+		Severity: SeverityCritical,
+		Title:    "Canvus stream disconnected",
+		Message:  alert.Message,
+		Fields:   map[string]string{"server": alert.Server},
+		Time:     alert.Time,
+	}
+	if alert.Event == watchdog.EventRecovered {
+		event.Type = EventStreamReconnected
+		event.Severity = SeverityInfo
+		event.Title = "Canvus stream reconnected"
+	} else if alert.Status.Hint != "" {
+		event.Fields["hint"] = alert.Status.Hint
+	}
+	d.Send(event)
+	return nil
+}
+
+// SLOChanged reports an SLO state change from metrics.SLOMonitor as
+// slo_violated or slo_recovered.
+func (d *Dispatcher) SLOChanged(status metrics.SLOStatus) {
+	if !d.Enabled() {
+		return
+	}
+	event := Event{
+		Type:     EventSLOViolated,
+		Severity: SeverityCritical,
+		Title:    fmt.Sprintf("SLO violated: %s", status.SLO),
+		Message: fmt.Sprintf("%s tasks are burning the error budget at %.1fx the allowed rate",
+			status.TaskType, status.LongBurnRate),
+		Fields: map[string]string{
+			"slo":             status.SLO,
+			"task_type":       status.TaskType,
+			"short_burn_rate": fmt.Sprintf("%.2f", status.ShortBurnRate),
+			"long_burn_rate":  fmt.Sprintf("%.2f", status.LongBurnRate),
+			"samples":         fmt.Sprint(status.Samples),
+		},
+		Time: status.Time,
+	}
+	if !status.Violated {
+		event.Type = EventSLORecovered
+		event.Severity = SeverityInfo
+		event.Title = fmt.Sprintf("SLO recovered: %s", status.SLO)
+		event.Message = fmt.Sprintf("%s tasks are back within the error budget", status.TaskType)
+	}
+	d.Send(event)
+}
+
+// WorkerCrashed reports a crashed GPU worker process as worker_crashed.
+// The worker is restarted; the event carries its last stderr lines.
+func (d *Dispatcher) WorkerCrashed(crash workerproc.Crash) {
+	if !d.Enabled() {
+		return
+	}
+	fields := map[string]string{
+		"worker":    crash.Name,
+		"exit_code": fmt.Sprint(crash.ExitCode),
+		"uptime":    crash.Uptime.Round(time.Second).String(),
+		"restarts":  fmt.Sprint(crash.Restarts),
+	}
+	if crash.Output != "" {
+		fields["output"] = crash.Output
+	}
+	d.Send(Event{
+		Type:     EventWorkerCrashed,
+		Severity: SeverityCritical,
+		Title:    "GPU worker crashed",
+		Message:  crash.Summary() + "; restarting it",
+		Fields:   fields,
+		Time:     crash.Time,
+	})
+}
+
+// Subscribe sends task failures, budget thresholds, SLO changes and worker
+// crashes published on bus. It returns a function that removes the
+// subscriptions.
+func (d *Dispatcher) Subscribe(bus *events.Bus) (unsubscribe func()) {
+	subs := []func(){
+		events.Subscribe(bus, "webhooks", func(e events.TaskCompleted) { d.TaskCompleted(e.Record) }),
+		events.Subscribe(bus, "webhooks", func(e events.SLOChanged) { d.SLOChanged(e.Status) }),
+		events.Subscribe(bus, "webhooks", func(e events.WorkerCrashed) { d.WorkerCrashed(e.Crash) }),
+	}
+	return func() {
+		for _, unsubscribe := range subs {
+			unsubscribe()
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"go_backend/events"
+	"go_backend/metrics"
+	"go_backend/watchdog"
+	"go_backend/workerproc"
+)
+
+// sentEvents decodes the JSON events received by srv.
+func sentEvents(t *testing.T, srv *recordingServer) []Event {
+	t.Helper()
+	var events []Event
+	for _, body := range srv.Bodies() {
+		var e Event
+		if err := json.Unmarshal([]byte(body), &e); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestTaskCompletedSendsFailures(t *testing.T) {
+	srv := newRecordingServer(t)
+	d := New(Config{Targets: []Target{{URL: srv.URL}}})
+
+	d.TaskCompleted(metrics.TaskRecord{ID: "ok", Type: "note", Status: metrics.TaskStatusSuccess})
+	d.TaskCompleted(metrics.TaskRecord{
+		ID: "t2", Type: "pdf", CanvasID: "c1",
+		Status: metrics.TaskStatusError, ErrorMsg: "model timeout",
+	})
+	waitDeliveries(t, d)
+
+	events := sentEvents(t, srv)
+	if len(events) != 1 {
+		t.Fatalf("events = %d, want 1", len(events))
+	}
+	e := events[0]
+	if e.Type != EventTaskFailed || e.Message != "model timeout" || e.Fields["canvas_id"] != "c1" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+}
+
+func TestBudgetThresholds(t *testing.T) {
+	srv := newRecordingServer(t)
+	d := New(Config{Targets: []Target{{URL: srv.URL}}, DailyTaskBudget: 5})
+
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		d.TaskCompleted(metrics.TaskRecord{Status: metrics.TaskStatusSuccess, EndTime: day})
+	}
+	waitDeliveries(t, d)
+
+	events := sentEvents(t, srv)
+	if len(events) != 2 {
+		t.Fatalf("events = %d, want 2 (80%% and 100%%)", len(events))
+	}
+	titles := map[string]bool{events[0].Title: true, events[1].Title: true}
+	if !titles["Daily task budget at 80%"] || !titles["Daily task budget at 100%"] {
+		t.Errorf("unexpected titles: %v", titles)
+	}
+
+	// A new day resets the count
+	d.TaskCompleted(metrics.TaskRecord{Status: metrics.TaskStatusSuccess, EndTime: day.AddDate(0, 0, 1)})
+	waitDeliveries(t, d)
+	if got := len(sentEvents(t, srv)); got != 2 {
+		t.Errorf("events after reset = %d, want 2", got)
+	}
+}
+
+func TestCheckGPUAlertsOnceUntilRearmed(t *testing.T) {
+	srv := newRecordingServer(t)
+	d := New(Config{Targets: []Target{{URL: srv.URL}}, GPUTempThreshold: 80})
+
+	for _, temp := range []float64{70, 82, 85, 78, 74, 81} {
+		d.CheckGPU(metrics.GPUMetrics{Temperature: temp})
+	}
+	waitDeliveries(t, d)
+
+	// Fires at 82, stays quiet until below 75, fires again at 81
+	events := sentEvents(t, srv)
+	if len(events) != 2 {
+		t.Fatalf("events = %d, want 2", len(events))
+	}
+	if events[0].Type != EventGPUAlert || events[0].Title != "GPU temperature high" {
+		t.Errorf("unexpected event: %+v", events[0])
+	}
+}
+
+func TestCheckGPUMemory(t *testing.T) {
+	srv := newRecordingServer(t)
+	d := New(Config{Targets: []Target{{URL: srv.URL}}, GPUMemoryThreshold: 90})
+
+	d.CheckGPU(metrics.GPUMetrics{MemoryTotal: 100, MemoryUsed: 95})
+	waitDeliveries(t, d)
+
+	events := sentEvents(t, srv)
+	if len(events) != 1 || events[0].Title != "GPU memory nearly full" {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+func TestNotifyWatchdogAlerts(t *testing.T) {
+	srv := newRecordingServer(t)
+	d := New(Config{Targets: []Target{{URL: srv.URL}}})
+
+	wd := watchdog.New(watchdog.Config{
+		Server:           "https://canvus.example.com",
+		FailureThreshold: 1,
+		Notifiers:        []watchdog.Notifier{d},
+	})
+	wd.RecordFailure(errors.New("connection refused"))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.calls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	waitDeliveries(t, d)
+
+	events := sentEvents(t, srv)
+	if len(events) != 1 || events[0].Type != EventStreamDisconnected {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+	if events[0].Fields["server"] != "https://canvus.example.com" {
+		t.Errorf("fields = %v", events[0].Fields)
+	}
+
+	if err := d.Notify(context.Background(), watchdog.Alert{Event: watchdog.EventRecovered}); err != nil {
+		t.Fatal(err)
+	}
+	waitDeliveries(t, d)
+	if events := sentEvents(t, srv); events[1].Type != EventStreamReconnected {
+		t.Errorf("want stream_reconnected, got %s", events[1].Type)
+	}
+}
+
+func TestSLOChanged(t *testing.T) {
+	srv := newRecordingServer(t)
+	d := New(Config{Targets: []Target{{URL: srv.URL}}})
+
+	d.SLOChanged(metrics.SLOStatus{SLO: "note:p95<20s", TaskType: "note", LongBurnRate: 4, ShortBurnRate: 6, Violated: true})
+	waitDeliveries(t, d)
+	d.SLOChanged(metrics.SLOStatus{SLO: "note:p95<20s", TaskType: "note"})
+	waitDeliveries(t, d)
+
+	events := sentEvents(t, srv)
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].Type != EventSLOViolated || events[0].Severity != SeverityCritical || events[0].Fields["long_burn_rate"] != "4.00" {
+		t.Errorf("violation event = %+v", events[0])
+	}
+	if events[1].Type != EventSLORecovered || events[1].Severity != SeverityInfo {
+		t.Errorf("recovery event = %+v", events[1])
+	}
+}
+
+func TestWorkerCrashed(t *testing.T) {
+	srv := newRecordingServer(t)
+	d := New(Config{Targets: []Target{{URL: srv.URL}}})
+
+	d.WorkerCrashed(workerproc.Crash{
+		Name: "gpu-worker", PID: 4242, ExitCode: 2, Reason: "exit status 2",
+		Output: "SIGSEGV: segmentation violation", Uptime: 90 * time.Second, Restarts: 1,
+	})
+	waitDeliveries(t, d)
+
+	events := sentEvents(t, srv)
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+	e := events[0]
+	if e.Type != EventWorkerCrashed || e.Severity != SeverityCritical || e.Fields["uptime"] != "1m30s" || e.Fields["output"] == "" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	srv := newRecordingServer(t)
+	d := New(Config{Targets: []Target{{URL: srv.URL}}})
+	bus := events.New(nil)
+	unsubscribe := d.Subscribe(bus)
+
+	events.Publish(bus, events.TaskCompleted{Record: metrics.TaskRecord{
+		ID: "t1", Type: "note", Status: metrics.TaskStatusError, ErrorMsg: "model timeout",
+	}})
+	events.Publish(bus, events.SLOChanged{Status: metrics.SLOStatus{SLO: "notes", TaskType: "note", Violated: true}})
+	events.Publish(bus, events.WorkerCrashed{Crash: workerproc.Crash{Name: "gpu-worker", ExitCode: 2}})
+	waitDeliveries(t, d)
+
+	// Deliveries are concurrent, so compare the types regardless of order
+	sent := map[EventType]bool{}
+	for _, e := range sentEvents(t, srv) {
+		sent[e.Type] = true
+	}
+	if len(sent) != 3 || !sent[EventTaskFailed] || !sent[EventSLOViolated] || !sent[EventWorkerCrashed] {
+		t.Fatalf("sent %v, want task_failed, slo_violated and worker_crashed", sent)
+	}
+
+	unsubscribe()
+	events.Publish(bus, events.WorkerCrashed{Crash: workerproc.Crash{Name: "gpu-worker"}})
+	waitDeliveries(t, d)
+	if n := len(srv.Bodies()); n != 3 {
+		t.Errorf("sent %d events after unsubscribe, want 3", n)
+	}
+}
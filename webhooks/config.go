@@ -0,0 +1,127 @@
+package webhooks
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"go_backend/core"
+	"go_backend/core/clock"
+
+	"go.uber.org/zap"
+)
+
+// Default dispatcher settings.
+const (
+	DefaultMaxAttempts          = 3
+	DefaultRetryDelay           = 2 * time.Second
+	DefaultHistorySize          = 50
+	DefaultGPUTempThreshold     = 85.0
+	DefaultGPUMemoryThreshold   = 95.0
+	DefaultBudgetWarningPercent = 80.0
+)
+
+// Target is a webhook endpoint.
+type Target struct {
+	// URL receives a POST for every matching event
+	URL string
+	// Format of the payload (default: detected from URL)
+	Format Format
+	// Events to send (default: AllEvents)
+	Events []EventType
+}
+
+// wants reports whether the target subscribes to t. Test events go to
+// every target.
+func (t Target) wants(eventType EventType) bool {
+	if eventType == EventTest || len(t.Events) == 0 {
+		return true
+	}
+	for _, e := range t.Events {
+		if e == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Config configures the Dispatcher.
+type Config struct {
+	// Targets receive the events
+	Targets []Target
+
+	// MaxAttempts per delivery, including the first (default: 3)
+	MaxAttempts int
+
+	// RetryDelay before the first retry; doubles on every retry (default: 2s)
+	RetryDelay time.Duration
+
+	// HistorySize is the number of deliveries kept for the dashboard (default: 50)
+	HistorySize int
+
+	// GPUTempThreshold in °C for gpu_alert events (0 disables)
+	GPUTempThreshold float64
+
+	// GPUMemoryThreshold in percent of VRAM for gpu_alert events (0 disables)
+	GPUMemoryThreshold float64
+
+	// DailyTaskBudget is the number of AI tasks expected per day; 0 disables
+	// budget_threshold events
+	DailyTaskBudget int
+
+	// Logger for diagnostic output (optional)
+	Logger *zap.Logger
+
+	// Clock stamps events and deliveries and times the retry backoff.
+	// Defaults to the system clock.
+	Clock clock.Clock
+}
+
+// DefaultConfig returns a Config with the default settings and no targets.
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts:        DefaultMaxAttempts,
+		RetryDelay:         DefaultRetryDelay,
+		HistorySize:        DefaultHistorySize,
+		GPUTempThreshold:   DefaultGPUTempThreshold,
+		GPUMemoryThreshold: DefaultGPUMemoryThreshold,
+	}
+}
+
+// ConfigFromEnv loads the webhook configuration from environment variables:
+//   - WEBHOOK_URLS: comma-separated webhook URLs
+//   - WEBHOOK_FORMAT: json, slack or teams (default: detected per URL)
+//   - WEBHOOK_EVENTS: comma-separated event types to send (default: all)
+//   - WEBHOOK_MAX_ATTEMPTS: delivery attempts per event (default: 3)
+//   - WEBHOOK_GPU_TEMP_THRESHOLD: GPU temperature alert in °C (default: 85)
+//   - WEBHOOK_GPU_MEMORY_THRESHOLD: GPU memory alert in percent (default: 95)
+//   - DAILY_TASK_BUDGET: AI tasks per day before budget alerts (default: 0, off)
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	cfg.MaxAttempts = core.ParseIntEnv("WEBHOOK_MAX_ATTEMPTS", DefaultMaxAttempts)
+	cfg.GPUTempThreshold = core.ParseFloat64Env("WEBHOOK_GPU_TEMP_THRESHOLD", DefaultGPUTempThreshold)
+	cfg.GPUMemoryThreshold = core.ParseFloat64Env("WEBHOOK_GPU_MEMORY_THRESHOLD", DefaultGPUMemoryThreshold)
+	cfg.DailyTaskBudget = core.ParseIntEnv("DAILY_TASK_BUDGET", 0)
+
+	format := Format(strings.ToLower(strings.TrimSpace(os.Getenv("WEBHOOK_FORMAT"))))
+	var events []EventType
+	for _, e := range splitList(os.Getenv("WEBHOOK_EVENTS")) {
+		events = append(events, EventType(strings.ToLower(e)))
+	}
+
+	for _, url := range splitList(os.Getenv("WEBHOOK_URLS")) {
+		cfg.Targets = append(cfg.Targets, Target{URL: url, Format: format, Events: events})
+	}
+	return cfg
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
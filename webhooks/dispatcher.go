@@ -0,0 +1,299 @@
+// Package webhooks sends task and system events to outbound webhooks.
+//
+// The Dispatcher organism posts events (failed tasks, budget thresholds,
+// GPU alerts, Canvus stream outages) to Slack, Microsoft Teams or generic
+// JSON endpoints, retries failed deliveries with backoff and keeps a short
+// delivery history for the dashboard.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go_backend/core/clock"
+
+	"go.uber.org/zap"
+)
+
+// Delivery is the outcome of sending one event to one target.
+type Delivery struct {
+	ID         int64     `json:"id"`
+	Event      EventType `json:"event"`
+	Title      string    `json:"title"`
+	Target     string    `json:"target"`
+	Format     Format    `json:"format"`
+	Success    bool      `json:"success"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Time       time.Time `json:"time"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// TargetInfo describes a target without exposing the secret part of its URL.
+type TargetInfo struct {
+	URL    string      `json:"url"`
+	Format Format      `json:"format"`
+	Events []EventType `json:"events"`
+}
+
+// Dispatcher is an organism that delivers events to webhook targets.
+//
+// Usage:
+//
+//	d := webhooks.New(webhooks.ConfigFromEnv())
+//	d.Send(webhooks.Event{Type: webhooks.EventTaskFailed, Title: "Task failed", Message: err.Error()})
+type Dispatcher struct {
+	config Config
+	client *http.Client
+	logger *zap.Logger
+	nextID atomic.Int64
+
+	mu      sync.Mutex
+	history []Delivery
+	alerts  alertState
+	wg      sync.WaitGroup
+}
+
+// New creates a Dispatcher.
+func New(config Config) *Dispatcher {
+	defaults := DefaultConfig()
+	if config.MaxAttempts < 1 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = defaults.RetryDelay
+	}
+	if config.HistorySize < 1 {
+		config.HistorySize = defaults.HistorySize
+	}
+	config.Clock = clock.OrReal(config.Clock)
+	for i, t := range config.Targets {
+		if t.Format == "" {
+			config.Targets[i].Format = DetectFormat(t.URL)
+		}
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	return &Dispatcher{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+		logger: logger,
+	}
+}
+
+// Enabled reports whether any target is configured.
+func (d *Dispatcher) Enabled() bool {
+	return d != nil && len(d.config.Targets) > 0
+}
+
+// Targets returns the configured targets with their URLs redacted.
+func (d *Dispatcher) Targets() []TargetInfo {
+	if d == nil {
+		return []TargetInfo{}
+	}
+	infos := make([]TargetInfo, 0, len(d.config.Targets))
+	for _, t := range d.config.Targets {
+		events := t.Events
+		if len(events) == 0 {
+			events = AllEvents
+		}
+		infos = append(infos, TargetInfo{URL: redactURL(t.URL), Format: t.Format, Events: events})
+	}
+	return infos
+}
+
+// Deliveries returns the recent deliveries, newest first.
+func (d *Dispatcher) Deliveries() []Delivery {
+	if d == nil {
+		return []Delivery{}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	out := make([]Delivery, len(d.history))
+	for i, delivery := range d.history {
+		out[len(d.history)-1-i] = delivery
+	}
+	return out
+}
+
+// Send delivers event to every subscribed target in the background.
+// It is safe to call on a nil Dispatcher.
+func (d *Dispatcher) Send(event Event) {
+	if !d.Enabled() {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = d.config.Clock.Now()
+	}
+	for _, t := range d.config.Targets {
+		if !t.wants(event.Type) {
+			continue
+		}
+		d.wg.Add(1)
+		go func(t Target) {
+			defer d.wg.Done()
+			d.deliver(context.Background(), t, event)
+		}(t)
+	}
+}
+
+// Test sends a test event to every target and waits for the results.
+func (d *Dispatcher) Test(ctx context.Context) []Delivery {
+	if !d.Enabled() {
+		return []Delivery{}
+	}
+	event := Event{
+		Type:     EventTest,
+		Severity: SeverityInfo,
+		Title:    "CanvusLocalLLM test notification",
+		Message:  "Webhook notifications are configured correctly.",
+		Time:     d.config.Clock.Now(),
+	}
+
+	results := make([]Delivery, len(d.config.Targets))
+	var wg sync.WaitGroup
+	for i, t := range d.config.Targets {
+		wg.Add(1)
+		go func(i int, t Target) {
+			defer wg.Done()
+			results[i] = d.deliver(ctx, t, event)
+		}(i, t)
+	}
+	wg.Wait()
+	return results
+}
+
+// Wait blocks until background deliveries finish or ctx is done.
+func (d *Dispatcher) Wait(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliver posts event to target, retrying with backoff, and records the
+// outcome.
+func (d *Dispatcher) deliver(ctx context.Context, target Target, event Event) Delivery {
+	delivery := Delivery{
+		ID:     d.nextID.Add(1),
+		Event:  event.Type,
+		Title:  event.Title,
+		Target: redactURL(target.URL),
+		Format: target.Format,
+		Time:   d.config.Clock.Now(),
+	}
+
+	body, err := payload(target.Format, event)
+	if err != nil {
+		delivery.Error = fmt.Sprintf("encode payload: %v", err)
+		return d.record(delivery)
+	}
+
+	delay := d.config.RetryDelay
+retry:
+	for attempt := 1; attempt <= d.config.MaxAttempts; attempt++ {
+		delivery.Attempts = attempt
+		status, err := d.post(ctx, target.URL, body)
+		delivery.StatusCode = status
+		if err == nil {
+			delivery.Success = true
+			delivery.Error = ""
+			break
+		}
+		delivery.Error = err.Error()
+		if !retryable(status) || attempt == d.config.MaxAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			delivery.Error = ctx.Err().Error()
+			break retry
+		case <-d.config.Clock.After(delay):
+			delay *= 2
+		}
+	}
+	delivery.DurationMS = d.config.Clock.Since(delivery.Time).Milliseconds()
+
+	if !delivery.Success {
+		d.logger.Warn("Webhook delivery failed",
+			zap.String("event", string(event.Type)),
+			zap.String("target", delivery.Target),
+			zap.Int("attempts", delivery.Attempts),
+			zap.String("error", delivery.Error),
+		)
+	}
+	return d.record(delivery)
+}
+
+// post sends one request and returns the response status.
+func (d *Dispatcher) post(ctx context.Context, target string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// record appends delivery to the history, dropping the oldest entries.
+func (d *Dispatcher) record(delivery Delivery) Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.history = append(d.history, delivery)
+	if excess := len(d.history) - d.config.HistorySize; excess > 0 {
+		d.history = append([]Delivery(nil), d.history[excess:]...)
+	}
+	return delivery
+}
+
+// retryable reports whether a failed request may succeed later: network
+// errors (status 0), rate limiting and server errors.
+func retryable(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+// redactURL keeps the scheme and host of a webhook URL; the path of Slack
+// and Teams webhooks is a secret.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "(invalid URL)"
+	}
+	if u.Path == "" || u.Path == "/" {
+		return u.Scheme + "://" + u.Host
+	}
+	return u.Scheme + "://" + u.Host + "/…"
+}
@@ -0,0 +1,268 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go_backend/core/clock"
+)
+
+// recordingServer is a webhook endpoint that records request bodies and
+// answers with the queued status codes (200 once they run out).
+type recordingServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	bodies   []string
+	statuses []int
+	calls    atomic.Int32
+}
+
+func newRecordingServer(t *testing.T, statuses ...int) *recordingServer {
+	rs := &recordingServer{statuses: statuses}
+	rs.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rs.calls.Add(1)
+
+		rs.mu.Lock()
+		rs.bodies = append(rs.bodies, string(body))
+		status := http.StatusOK
+		if len(rs.statuses) > 0 {
+			status, rs.statuses = rs.statuses[0], rs.statuses[1:]
+		}
+		rs.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(rs.Close)
+	return rs
+}
+
+func (rs *recordingServer) Bodies() []string {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return append([]string(nil), rs.bodies...)
+}
+
+func waitDeliveries(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Wait(ctx); err != nil {
+		t.Fatalf("deliveries did not finish: %v", err)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		url  string
+		want Format
+	}{
+		{"https://hooks.slack.com/services/T/B/X", FormatSlack},
+		{"https://contoso.webhook.office.com/webhookb2/abc", FormatTeams},
+		{"https://outlook.office.com/webhook/abc", FormatTeams},
+		{"https://example.com/hook", FormatJSON},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat(tt.url); got != tt.want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestPayloadFormats(t *testing.T) {
+	event := Event{
+		Type:     EventTaskFailed,
+		Severity: SeverityWarning,
+		Title:    "AI task failed: note",
+		Message:  "timeout",
+		Fields:   map[string]string{"task_id": "t1"},
+	}
+
+	t.Run("slack", func(t *testing.T) {
+		body, err := payload(FormatSlack, event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var msg map[string]string
+		json.Unmarshal(body, &msg)
+		if !strings.Contains(msg["text"], "*AI task failed: note*") || !strings.Contains(msg["text"], "task_id: t1") {
+			t.Errorf("unexpected slack text: %q", msg["text"])
+		}
+	})
+
+	t.Run("teams", func(t *testing.T) {
+		body, err := payload(FormatTeams, event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var card map[string]interface{}
+		json.Unmarshal(body, &card)
+		if card["@type"] != "MessageCard" || card["title"] != event.Title || card["themeColor"] != "ECB22E" {
+			t.Errorf("unexpected teams card: %v", card)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		body, err := payload(FormatJSON, event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got Event
+		json.Unmarshal(body, &got)
+		if got.Type != EventTaskFailed || got.Fields["task_id"] != "t1" {
+			t.Errorf("unexpected json payload: %+v", got)
+		}
+	})
+}
+
+func TestSendRetriesServerErrors(t *testing.T) {
+	srv := newRecordingServer(t, http.StatusBadGateway, http.StatusServiceUnavailable)
+	fake := clock.NewFake(time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC))
+	d := New(Config{
+		Targets:     []Target{{URL: srv.URL}},
+		MaxAttempts: 3,
+		RetryDelay:  2 * time.Second,
+		Clock:       fake,
+	})
+
+	d.Send(Event{Type: EventTaskFailed, Title: "failed"})
+	// The backoff doubles: 2s before the second attempt, 4s before the third
+	for _, delay := range []time.Duration{2 * time.Second, 4 * time.Second} {
+		fake.BlockUntil(1)
+		fake.Advance(delay)
+	}
+	waitDeliveries(t, d)
+
+	if got := srv.calls.Load(); got != 3 {
+		t.Errorf("calls = %d, want 3", got)
+	}
+	deliveries := d.Deliveries()
+	if len(deliveries) != 1 {
+		t.Fatalf("deliveries = %d, want 1", len(deliveries))
+	}
+	if !deliveries[0].Success || deliveries[0].Attempts != 3 || deliveries[0].StatusCode != http.StatusOK {
+		t.Errorf("unexpected delivery: %+v", deliveries[0])
+	}
+	if !deliveries[0].Time.Equal(fake.Now().Add(-6 * time.Second)) {
+		t.Errorf("delivery time = %v, want the time Send was called", deliveries[0].Time)
+	}
+	if deliveries[0].DurationMS != 6000 {
+		t.Errorf("duration = %dms, want 6000ms of backoff", deliveries[0].DurationMS)
+	}
+}
+
+func TestSendDoesNotRetryClientErrors(t *testing.T) {
+	srv := newRecordingServer(t, http.StatusNotFound, http.StatusNotFound)
+	d := New(Config{
+		Targets:     []Target{{URL: srv.URL}},
+		MaxAttempts: 3,
+		RetryDelay:  time.Millisecond,
+	})
+
+	d.Send(Event{Type: EventTaskFailed, Title: "failed"})
+	waitDeliveries(t, d)
+
+	if got := srv.calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+	delivery := d.Deliveries()[0]
+	if delivery.Success || delivery.StatusCode != http.StatusNotFound || delivery.Error == "" {
+		t.Errorf("unexpected delivery: %+v", delivery)
+	}
+}
+
+func TestSendFiltersEvents(t *testing.T) {
+	srv := newRecordingServer(t)
+	d := New(Config{Targets: []Target{{URL: srv.URL, Events: []EventType{EventGPUAlert}}}})
+
+	d.Send(Event{Type: EventTaskFailed})
+	d.Send(Event{Type: EventGPUAlert})
+	waitDeliveries(t, d)
+
+	if got := srv.calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestTestDeliversToAllTargets(t *testing.T) {
+	srv := newRecordingServer(t)
+	d := New(Config{Targets: []Target{
+		{URL: srv.URL, Events: []EventType{EventGPUAlert}},
+		{URL: srv.URL + "/second"},
+	}})
+
+	results := d.Test(context.Background())
+	if len(results) != 2 {
+		t.Fatalf("results = %d, want 2", len(results))
+	}
+	for _, r := range results {
+		if !r.Success || r.Event != EventTest {
+			t.Errorf("unexpected result: %+v", r)
+		}
+	}
+}
+
+func TestHistoryIsBounded(t *testing.T) {
+	srv := newRecordingServer(t)
+	d := New(Config{Targets: []Target{{URL: srv.URL}}, HistorySize: 2})
+
+	for i := 0; i < 3; i++ {
+		d.Test(context.Background())
+	}
+	deliveries := d.Deliveries()
+	if len(deliveries) != 2 {
+		t.Fatalf("deliveries = %d, want 2", len(deliveries))
+	}
+	if deliveries[0].ID != 3 || deliveries[1].ID != 2 {
+		t.Errorf("want newest first, got IDs %d, %d", deliveries[0].ID, deliveries[1].ID)
+	}
+}
+
+func TestNilDispatcher(t *testing.T) {
+	var d *Dispatcher
+	d.Send(Event{Type: EventTaskFailed})
+	if d.Enabled() || len(d.Deliveries()) != 0 || len(d.Targets()) != 0 {
+		t.Error("nil dispatcher should be disabled and empty")
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	if got := redactURL("https://hooks.slack.com/services/T/B/secret"); got != "https://hooks.slack.com/…" {
+		t.Errorf("redactURL = %q", got)
+	}
+	if got := redactURL("http://example.com"); got != "http://example.com" {
+		t.Errorf("redactURL = %q", got)
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("WEBHOOK_URLS", "https://hooks.slack.com/services/x, https://example.com/hook")
+	t.Setenv("WEBHOOK_EVENTS", "task_failed,GPU_ALERT")
+	t.Setenv("DAILY_TASK_BUDGET", "500")
+
+	cfg := ConfigFromEnv()
+	if len(cfg.Targets) != 2 {
+		t.Fatalf("targets = %d, want 2", len(cfg.Targets))
+	}
+	if cfg.DailyTaskBudget != 500 || cfg.MaxAttempts != DefaultMaxAttempts {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+
+	d := New(cfg)
+	targets := d.Targets()
+	if targets[0].Format != FormatSlack || targets[1].Format != FormatJSON {
+		t.Errorf("formats = %q, %q", targets[0].Format, targets[1].Format)
+	}
+	if len(targets[1].Events) != 2 || targets[1].Events[1] != EventGPUAlert {
+		t.Errorf("events = %v", targets[1].Events)
+	}
+}
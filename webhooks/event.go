@@ -0,0 +1,136 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EventType identifies what happened.
+type EventType string
+
+const (
+	// EventTaskFailed is sent when an AI task fails
+	EventTaskFailed EventType = "task_failed"
+	// EventBudgetThreshold is sent when the daily task budget crosses a threshold
+	EventBudgetThreshold EventType = "budget_threshold"
+	// EventGPUAlert is sent when GPU temperature or memory crosses its threshold
+	EventGPUAlert EventType = "gpu_alert"
+	// EventStreamDisconnected is sent when the Canvus stream is considered down
+	EventStreamDisconnected EventType = "stream_disconnected"
+	// EventStreamReconnected is sent when the Canvus stream is back
+	EventStreamReconnected EventType = "stream_reconnected"
+	// EventSLOViolated is sent when an SLO burns its error budget too fast
+	EventSLOViolated EventType = "slo_violated"
+	// EventSLORecovered is sent when a violated SLO is back within budget
+	EventSLORecovered EventType = "slo_recovered"
+	// EventWorkerCrashed is sent when a GPU worker process crashes and is restarted
+	EventWorkerCrashed EventType = "worker_crashed"
+	// EventTest is sent from the dashboard to check a target
+	EventTest EventType = "test"
+)
+
+// AllEvents lists the event types a target receives by default.
+var AllEvents = []EventType{
+	EventTaskFailed,
+	EventBudgetThreshold,
+	EventGPUAlert,
+	EventStreamDisconnected,
+	EventStreamReconnected,
+	EventSLOViolated,
+	EventSLORecovered,
+	EventWorkerCrashed,
+}
+
+// Severity levels, used for message colours.
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Event is a notification sent to webhook targets.
+type Event struct {
+	Type     EventType         `json:"event"`
+	Severity string            `json:"severity"`
+	Title    string            `json:"title"`
+	Message  string            `json:"message"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Time     time.Time         `json:"time"`
+}
+
+// Format is the payload format of a webhook target.
+type Format string
+
+const (
+	// FormatJSON posts the Event as is
+	FormatJSON Format = "json"
+	// FormatSlack posts a Slack incoming webhook message
+	FormatSlack Format = "slack"
+	// FormatTeams posts a Microsoft Teams MessageCard
+	FormatTeams Format = "teams"
+)
+
+// DetectFormat guesses the payload format from the webhook URL.
+func DetectFormat(url string) Format {
+	lower := strings.ToLower(url)
+	switch {
+	case strings.Contains(lower, "hooks.slack.com"):
+		return FormatSlack
+	case strings.Contains(lower, "webhook.office.com"), strings.Contains(lower, "outlook.office.com"),
+		strings.Contains(lower, "logic.azure.com"):
+		return FormatTeams
+	}
+	return FormatJSON
+}
+
+// themeColors maps severities to Teams card colours.
+var themeColors = map[string]string{
+	SeverityInfo:     "2EB67D",
+	SeverityWarning:  "ECB22E",
+	SeverityCritical: "E01E5A",
+}
+
+// payload encodes event in the given format.
+func payload(format Format, event Event) ([]byte, error) {
+	switch format {
+	case FormatSlack:
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%s*\n%s", event.Title, event.Message)
+		for _, key := range sortedKeys(event.Fields) {
+			fmt.Fprintf(&b, "\n• %s: %s", key, event.Fields[key])
+		}
+		return json.Marshal(map[string]string{"text": b.String()})
+
+	case FormatTeams:
+		facts := make([]map[string]string, 0, len(event.Fields))
+		for _, key := range sortedKeys(event.Fields) {
+			facts = append(facts, map[string]string{"name": key, "value": event.Fields[key]})
+		}
+		card := map[string]interface{}{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    event.Title,
+			"themeColor": themeColors[event.Severity],
+			"title":      event.Title,
+			"text":       event.Message,
+		}
+		if len(facts) > 0 {
+			card["sections"] = []map[string]interface{}{{"facts": facts}}
+		}
+		return json.Marshal(card)
+	}
+	return json.Marshal(event)
+}
+
+// sortedKeys returns the keys of m in order, for stable messages.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,444 @@
+// Package webui provides the ArtifactsAPI organism for kept artifacts.
+// This file contains the REST handlers that list, download, delete and
+// re-upload generated images and downloaded PDFs, and that generate an
+// image again from its prompt for the dashboard gallery.
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go_backend/artifacts"
+	"go_backend/remotetrigger"
+
+	"go.uber.org/zap"
+)
+
+// ArtifactsResponse represents the JSON response for GET /api/artifacts.
+type ArtifactsResponse struct {
+	Enabled   bool                 `json:"enabled"`
+	Backend   string               `json:"backend,omitempty"`
+	Artifacts []artifacts.Artifact `json:"artifacts"`
+	Count     int                  `json:"count"`
+}
+
+// ArtifactUploadRequest is the JSON body of POST /api/artifacts/upload.
+type ArtifactUploadRequest struct {
+	ID string  `json:"id"`
+	X  float64 `json:"x"`
+	Y  float64 `json:"y"`
+}
+
+// ArtifactUploadResponse represents the JSON response for POST /api/artifacts/upload.
+type ArtifactUploadResponse struct {
+	ArtifactID string `json:"artifact_id"`
+	WidgetID   string `json:"widget_id"`
+}
+
+// ArtifactRegenerateRequest is the JSON body of POST /api/artifacts/regenerate.
+type ArtifactRegenerateRequest struct {
+	ID string `json:"id"`
+	// SameSeed repeats the seed, size, steps and guidance scale of the
+	// image, so a local image comes out the same; otherwise only the
+	// prompt is reused
+	SameSeed bool `json:"same_seed,omitempty"`
+	// X and Y place the trigger note (default: right of the canvas content)
+	X *float64 `json:"x,omitempty"`
+	Y *float64 `json:"y,omitempty"`
+}
+
+// PromptInjector starts AI tasks on canvases from a prompt.
+// Implemented by remotetrigger.Injector.
+type PromptInjector interface {
+	Inject(ctx context.Context, req remotetrigger.Request) (*remotetrigger.Result, error)
+}
+
+// CanvasUploader uploads files as canvas widgets. Implemented by
+// canvusapi.Client.
+type CanvasUploader interface {
+	CreateImage(filePath string, metadata map[string]interface{}) (map[string]interface{}, error)
+	CreatePDF(filePath string, metadata map[string]interface{}) (map[string]interface{}, error)
+}
+
+// errExportNotUploadable is returned when re-uploading a canvas export,
+// which Canvus cannot show as a widget.
+var errExportNotUploadable = errors.New("canvas exports cannot be uploaded to a canvas")
+
+// errNoPrompt is returned when re-generating an artifact without a prompt.
+var errNoPrompt = errors.New("artifact has no prompt to generate again")
+
+// ArtifactsAPI is an organism that exposes the artifact store.
+//
+// Endpoints:
+// - GET    /api/artifacts            - Stored artifacts, newest first (?kind=image|pdf|export&q=text&limit=N)
+// - DELETE /api/artifacts?id=X       - Delete an artifact
+// - GET    /api/artifacts/content?id=X - Download an artifact
+// - GET    /api/artifacts/thumbnail?id=X - JPEG thumbnail of an image artifact
+// - POST   /api/artifacts/upload     - Upload an image or PDF artifact to the canvas again
+// - POST   /api/artifacts/regenerate - Generate an image again from its prompt
+type ArtifactsAPI struct {
+	store       *artifacts.Store
+	uploader    CanvasUploader
+	regenerator PromptInjector
+	tempDir     string
+	logger      *zap.Logger
+}
+
+// NewArtifactsAPI creates an ArtifactsAPI. store is nil when artifacts are
+// not kept; uploader may be nil to disable re-uploads. Re-uploaded files
+// are staged in tempDir.
+func NewArtifactsAPI(store *artifacts.Store, uploader CanvasUploader, tempDir string, logger *zap.Logger) *ArtifactsAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &ArtifactsAPI{store: store, uploader: uploader, tempDir: tempDir, logger: logger}
+}
+
+// SetRegenerator enables re-generating images through injector, which
+// creates an image trigger note on the canvas the image came from. Call it
+// before the server starts.
+func (api *ArtifactsAPI) SetRegenerator(injector PromptInjector) {
+	api.regenerator = injector
+}
+
+// HandleArtifacts handles GET and DELETE /api/artifacts requests.
+func (api *ArtifactsAPI) HandleArtifacts(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		api.handleList(w, r)
+	case http.MethodDelete:
+		api.HandleDelete(w, r)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+// handleList handles GET /api/artifacts requests.
+func (api *ArtifactsAPI) handleList(w http.ResponseWriter, r *http.Request) {
+	if api.store == nil {
+		writeJSON(w, http.StatusOK, ArtifactsResponse{Artifacts: []artifacts.Artifact{}})
+		return
+	}
+
+	list, err := api.store.List(r.Context())
+	if err != nil {
+		api.logger.Error("Failed to list artifacts", zap.Error(err))
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	kind := artifacts.Kind(r.URL.Query().Get("kind"))
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	filtered := []artifacts.Artifact{}
+	for _, a := range list {
+		if kind != "" && a.Kind != kind {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(a.Prompt), query) && !strings.Contains(strings.ToLower(a.Name), query) {
+			continue
+		}
+		if limit > 0 && len(filtered) >= limit {
+			break
+		}
+		filtered = append(filtered, a)
+	}
+
+	writeJSON(w, http.StatusOK, ArtifactsResponse{
+		Enabled:   true,
+		Backend:   api.store.Backend(),
+		Artifacts: filtered,
+		Count:     len(filtered),
+	})
+}
+
+// HandleDelete handles DELETE /api/artifacts?id=X requests.
+func (api *ArtifactsAPI) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !api.requireStore(w) {
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if err := api.store.Delete(r.Context(), id); err != nil {
+		api.writeStoreError(w, id, err)
+		return
+	}
+	api.logger.Info("Artifact deleted", zap.String("artifact_id", id))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleContent handles GET /api/artifacts/content?id=X requests.
+func (api *ArtifactsAPI) HandleContent(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !api.requireStore(w) {
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	a, content, err := api.store.Open(r.Context(), id)
+	if err != nil {
+		api.writeStoreError(w, id, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.Name))
+	if _, err := io.Copy(w, content); err != nil {
+		api.logger.Debug("Artifact download interrupted", zap.String("artifact_id", id), zap.Error(err))
+	}
+}
+
+// HandleThumbnail handles GET /api/artifacts/thumbnail?id=X requests.
+func (api *ArtifactsAPI) HandleThumbnail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !api.requireStore(w) {
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	thumb, err := api.store.OpenThumbnail(r.Context(), id)
+	if err != nil {
+		api.writeStoreError(w, id, err)
+		return
+	}
+	defer thumb.Close()
+
+	w.Header().Set("Content-Type", "image/jpeg")
+	// Artifacts never change, so thumbnails can be cached for good
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	if _, err := io.Copy(w, thumb); err != nil {
+		api.logger.Debug("Thumbnail download interrupted", zap.String("artifact_id", id), zap.Error(err))
+	}
+}
+
+// HandleUpload handles POST /api/artifacts/upload requests.
+// The artifact is uploaded as a new image or PDF widget at (x, y).
+func (api *ArtifactsAPI) HandleUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !api.requireStore(w) {
+		return
+	}
+	if api.uploader == nil {
+		writeError(w, http.StatusConflict, "canvas uploads are not available")
+		return
+	}
+
+	var req ArtifactUploadRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	widgetID, err := api.upload(r, req)
+	if err != nil {
+		api.writeStoreError(w, req.ID, err)
+		return
+	}
+	api.logger.Info("Artifact uploaded to canvas",
+		zap.String("artifact_id", req.ID),
+		zap.String("widget_id", widgetID))
+	writeJSON(w, http.StatusOK, ArtifactUploadResponse{ArtifactID: req.ID, WidgetID: widgetID})
+}
+
+// upload stages the artifact in a temporary directory under its own name
+// and creates the widget.
+func (api *ArtifactsAPI) upload(r *http.Request, req ArtifactUploadRequest) (string, error) {
+	a, content, err := api.store.Open(r.Context(), req.ID)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+	if a.Kind == artifacts.KindExport {
+		return "", errExportNotUploadable
+	}
+
+	if err := os.MkdirAll(api.tempDir, 0755); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(api.tempDir, "artifact-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, a.Name)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	_, err = io.Copy(f, content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	metadata := map[string]interface{}{
+		"title":    a.Name,
+		"location": map[string]float64{"x": req.X, "y": req.Y},
+	}
+	var response map[string]interface{}
+	if a.Kind == artifacts.KindPDF {
+		response, err = api.uploader.CreatePDF(path, metadata)
+	} else {
+		response, err = api.uploader.CreateImage(path, metadata)
+	}
+	if err != nil {
+		return "", fmt.Errorf("canvas upload failed: %w", err)
+	}
+	widgetID, _ := response["id"].(string)
+	return widgetID, nil
+}
+
+// HandleRegenerate handles POST /api/artifacts/regenerate requests.
+// A trigger note with the image's prompt is created on the canvas it came
+// from, and the image is generated the same way as one asked for on the
+// wall.
+func (api *ArtifactsAPI) HandleRegenerate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !api.requireStore(w) {
+		return
+	}
+	if api.regenerator == nil {
+		writeError(w, http.StatusConflict, "re-generating images is not available")
+		return
+	}
+
+	var req ArtifactRegenerateRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if (req.X == nil) != (req.Y == nil) {
+		writeError(w, http.StatusBadRequest, "set both x and y, or neither")
+		return
+	}
+
+	a, err := api.store.Get(r.Context(), req.ID)
+	if err != nil {
+		api.writeStoreError(w, req.ID, err)
+		return
+	}
+	if a.Kind != artifacts.KindImage || strings.TrimSpace(a.Prompt) == "" {
+		api.writeStoreError(w, req.ID, errNoPrompt)
+		return
+	}
+
+	result, err := api.regenerator.Inject(r.Context(), remotetrigger.Request{
+		CanvasID: a.CanvasID,
+		Prompt:   regeneratePrompt(a, req.SameSeed),
+		X:        req.X,
+		Y:        req.Y,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, remotetrigger.ErrUnknownCanvas):
+			writeError(w, http.StatusConflict, "the canvas of this image is not monitored")
+		case errors.Is(err, remotetrigger.ErrProcessingPaused):
+			writeError(w, http.StatusServiceUnavailable, err.Error())
+		default:
+			api.logger.Warn("Artifact re-generation failed", zap.String("artifact_id", req.ID), zap.Error(err))
+			writeError(w, http.StatusBadGateway, err.Error())
+		}
+		return
+	}
+	api.logger.Info("Artifact re-generation requested",
+		zap.String("artifact_id", req.ID),
+		zap.String("widget_id", result.WidgetID),
+		zap.Bool("same_seed", req.SameSeed))
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// regeneratePrompt returns the image trigger for a, with the flags that
+// repeat its generation when sameSeed is set and the seed is known.
+func regeneratePrompt(a artifacts.Artifact, sameSeed bool) string {
+	prompt := "image: " + a.Prompt
+	g := a.Generation
+	if !sameSeed || g == nil || g.Seed == nil {
+		return prompt
+	}
+	if g.Width > 0 && g.Height > 0 {
+		prompt += fmt.Sprintf(" --size %dx%d", g.Width, g.Height)
+	}
+	if g.Steps > 0 {
+		prompt += fmt.Sprintf(" --steps %d", g.Steps)
+	}
+	if g.CFGScale > 0 {
+		prompt += " --cfg " + strconv.FormatFloat(g.CFGScale, 'g', -1, 64)
+	}
+	return prompt + fmt.Sprintf(" --seed %d", *g.Seed)
+}
+
+// RegisterRoutes registers the artifact routes on mux. If protect is
+// non-nil it wraps the handlers that change data (e.g., with authentication).
+func (api *ArtifactsAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	deleteArtifact := api.HandleDelete
+	upload := api.HandleUpload
+	regenerate := api.HandleRegenerate
+	if protect != nil {
+		deleteArtifact = protect(deleteArtifact)
+		upload = protect(upload)
+		regenerate = protect(regenerate)
+	}
+	mux.HandleFunc("/api/artifacts", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleteArtifact(w, r)
+			return
+		}
+		api.HandleArtifacts(w, r)
+	})
+	mux.HandleFunc("/api/artifacts/content", api.HandleContent)
+	mux.HandleFunc("/api/artifacts/thumbnail", api.HandleThumbnail)
+	mux.HandleFunc("/api/artifacts/upload", upload)
+	mux.HandleFunc("/api/artifacts/regenerate", regenerate)
+}
+
+// requireStore writes an error if artifacts are not kept.
+func (api *ArtifactsAPI) requireStore(w http.ResponseWriter) bool {
+	if api.store == nil {
+		writeError(w, http.StatusNotFound, "artifact storage is disabled (set ARTIFACT_STORE)")
+		return false
+	}
+	return true
+}
+
+// writeStoreError maps a store error to a response.
+func (api *ArtifactsAPI) writeStoreError(w http.ResponseWriter, id string, err error) {
+	if errors.Is(err, artifacts.ErrNotFound) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("artifact %q not found", id))
+		return
+	}
+	if errors.Is(err, errExportNotUploadable) || errors.Is(err, errNoPrompt) {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	api.logger.Error("Artifact request failed", zap.String("artifact_id", id), zap.Error(err))
+	writeError(w, http.StatusBadGateway, err.Error())
+}
@@ -0,0 +1,248 @@
+package webui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go_backend/artifacts"
+	"go_backend/remotetrigger"
+)
+
+// fakeCanvasUploader records uploads and checks the staged file
+type fakeCanvasUploader struct {
+	kind    string
+	content string
+	title   interface{}
+}
+
+func (f *fakeCanvasUploader) upload(kind, filePath string, metadata map[string]interface{}) (map[string]interface{}, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	f.kind, f.content, f.title = kind, string(data), metadata["title"]
+	return map[string]interface{}{"id": "widget-" + filepath.Base(filePath)}, nil
+}
+
+func (f *fakeCanvasUploader) CreateImage(filePath string, metadata map[string]interface{}) (map[string]interface{}, error) {
+	return f.upload("image", filePath, metadata)
+}
+
+func (f *fakeCanvasUploader) CreatePDF(filePath string, metadata map[string]interface{}) (map[string]interface{}, error) {
+	return f.upload("pdf", filePath, metadata)
+}
+
+func newTestArtifactsAPI(t *testing.T) (*ArtifactsAPI, *artifacts.Store, *fakeCanvasUploader) {
+	t.Helper()
+	bucket, err := artifacts.NewDirBucket(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirBucket failed: %v", err)
+	}
+	store := artifacts.NewStore(bucket)
+	uploader := &fakeCanvasUploader{}
+	return NewArtifactsAPI(store, uploader, t.TempDir(), nil), store, uploader
+}
+
+func TestArtifactsAPIList(t *testing.T) {
+	api, store, _ := newTestArtifactsAPI(t)
+	ctx := context.Background()
+	store.Put(ctx, artifacts.Artifact{Kind: artifacts.KindImage, Name: "a.png"}, []byte("a"))
+	store.Put(ctx, artifacts.Artifact{Kind: artifacts.KindPDF, Name: "b.pdf"}, []byte("b"))
+
+	rec := httptest.NewRecorder()
+	api.HandleArtifacts(rec, httptest.NewRequest(http.MethodGet, "/api/artifacts?kind=pdf", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var resp ArtifactsResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if !resp.Enabled || resp.Backend != "local" || resp.Count != 1 || resp.Artifacts[0].Name != "b.pdf" {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestArtifactsAPIDisabled(t *testing.T) {
+	api := NewArtifactsAPI(nil, nil, t.TempDir(), nil)
+
+	rec := httptest.NewRecorder()
+	api.HandleArtifacts(rec, httptest.NewRequest(http.MethodGet, "/api/artifacts", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"enabled":false`) {
+		t.Errorf("list = %d %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleContent(rec, httptest.NewRequest(http.MethodGet, "/api/artifacts/content?id=x", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("content status = %d, want 404", rec.Code)
+	}
+}
+
+func TestArtifactsAPIContentAndDelete(t *testing.T) {
+	api, store, _ := newTestArtifactsAPI(t)
+	a, _ := store.Put(context.Background(), artifacts.Artifact{Kind: artifacts.KindImage, Name: "cat.png"}, []byte("\x89PNG\r\n\x1a\ncat"))
+
+	rec := httptest.NewRecorder()
+	api.HandleContent(rec, httptest.NewRequest(http.MethodGet, "/api/artifacts/content?id="+a.ID, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("content = %d %v", rec.Code, rec.Header())
+	}
+	if !strings.Contains(rec.Header().Get("Content-Disposition"), `"cat.png"`) {
+		t.Errorf("Content-Disposition = %q", rec.Header().Get("Content-Disposition"))
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleDelete(rec, httptest.NewRequest(http.MethodDelete, "/api/artifacts?id="+a.ID, nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleContent(rec, httptest.NewRequest(http.MethodGet, "/api/artifacts/content?id="+a.ID, nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("content after delete status = %d, want 404", rec.Code)
+	}
+}
+
+func TestArtifactsAPIUpload(t *testing.T) {
+	api, store, uploader := newTestArtifactsAPI(t)
+	a, _ := store.Put(context.Background(), artifacts.Artifact{Kind: artifacts.KindPDF, Name: "report.pdf"}, []byte("%PDF-1.7"))
+
+	body := strings.NewReader(`{"id":"` + a.ID + `","x":100,"y":200}`)
+	rec := httptest.NewRecorder()
+	api.HandleUpload(rec, httptest.NewRequest(http.MethodPost, "/api/artifacts/upload", body))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+	}
+
+	var resp ArtifactUploadResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.WidgetID != "widget-report.pdf" {
+		t.Errorf("response = %+v", resp)
+	}
+	if uploader.kind != "pdf" || uploader.content != "%PDF-1.7" || uploader.title != "report.pdf" {
+		t.Errorf("upload = %+v", uploader)
+	}
+
+	entries, _ := os.ReadDir(api.tempDir)
+	if len(entries) != 0 {
+		t.Errorf("staged files not removed: %v", entries)
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleUpload(rec, httptest.NewRequest(http.MethodPost, "/api/artifacts/upload", strings.NewReader(`{"id":"missing"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown artifact status = %d, want 404", rec.Code)
+	}
+	export, _ := store.Put(context.Background(), artifacts.Artifact{Kind: artifacts.KindExport, Name: "retro.md"}, []byte("# Retro"))
+	rec = httptest.NewRecorder()
+	api.HandleUpload(rec, httptest.NewRequest(http.MethodPost, "/api/artifacts/upload", strings.NewReader(`{"id":"`+export.ID+`"}`)))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("export upload status = %d, want 409", rec.Code)
+	}
+}
+
+// fakePromptInjector records the injected trigger
+type fakePromptInjector struct {
+	req remotetrigger.Request
+	err error
+}
+
+func (f *fakePromptInjector) Inject(ctx context.Context, req remotetrigger.Request) (*remotetrigger.Result, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.req = req
+	return &remotetrigger.Result{CanvasID: req.CanvasID, WidgetID: "note-1"}, nil
+}
+
+func TestArtifactsAPISearchAndThumbnail(t *testing.T) {
+	api, store, _ := newTestArtifactsAPI(t)
+	ctx := context.Background()
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 64)))
+	cat, _ := store.Put(ctx, artifacts.Artifact{Kind: artifacts.KindImage, Name: "a.png", Prompt: "A ginger Cat"}, buf.Bytes())
+	store.Put(ctx, artifacts.Artifact{Kind: artifacts.KindImage, Name: "b.png", Prompt: "a dog"}, buf.Bytes())
+
+	rec := httptest.NewRecorder()
+	api.HandleArtifacts(rec, httptest.NewRequest(http.MethodGet, "/api/artifacts?q=cat", nil))
+	var resp ArtifactsResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp.Count != 1 || resp.Artifacts[0].ID != cat.ID || !resp.Artifacts[0].Thumbnail {
+		t.Fatalf("search = %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleThumbnail(rec, httptest.NewRequest(http.MethodGet, "/api/artifacts/thumbnail?id="+cat.ID, nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/jpeg" || rec.Body.Len() == 0 {
+		t.Errorf("thumbnail = %d %s", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleThumbnail(rec, httptest.NewRequest(http.MethodGet, "/api/artifacts/thumbnail?id=20260101T000000-0123abcd", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("missing thumbnail status = %d, want 404", rec.Code)
+	}
+}
+
+func TestArtifactsAPIRegenerate(t *testing.T) {
+	api, store, _ := newTestArtifactsAPI(t)
+	ctx := context.Background()
+	seed := int64(1234)
+	a, _ := store.Put(ctx, artifacts.Artifact{
+		Kind:       artifacts.KindImage,
+		Name:       "a.png",
+		CanvasID:   "canvas-1",
+		Prompt:     "a lighthouse at dusk",
+		Generation: &artifacts.Generation{Seed: &seed, Steps: 30, CFGScale: 7.5, Width: 768, Height: 512},
+	}, []byte("a"))
+	pdf, _ := store.Put(ctx, artifacts.Artifact{Kind: artifacts.KindPDF, Name: "b.pdf"}, []byte("b"))
+
+	regenerate := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		api.HandleRegenerate(rec, httptest.NewRequest(http.MethodPost, "/api/artifacts/regenerate", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := regenerate(`{"id":"` + a.ID + `"}`); rec.Code != http.StatusConflict {
+		t.Errorf("without regenerator status = %d, want 409", rec.Code)
+	}
+
+	injector := &fakePromptInjector{}
+	api.SetRegenerator(injector)
+
+	if rec := regenerate(`{"id":"` + a.ID + `"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d %s", rec.Code, rec.Body)
+	}
+	if injector.req.CanvasID != "canvas-1" || injector.req.Prompt != "image: a lighthouse at dusk" {
+		t.Errorf("request = %+v", injector.req)
+	}
+
+	if rec := regenerate(`{"id":"` + a.ID + `","same_seed":true,"x":10,"y":20}`); rec.Code != http.StatusCreated {
+		t.Fatalf("status = %d %s", rec.Code, rec.Body)
+	}
+	want := "image: a lighthouse at dusk --size 768x512 --steps 30 --cfg 7.5 --seed 1234"
+	if injector.req.Prompt != want || injector.req.X == nil || *injector.req.X != 10 {
+		t.Errorf("request = %+v, want prompt %q at x=10", injector.req, want)
+	}
+
+	if rec := regenerate(`{"id":"` + pdf.ID + `"}`); rec.Code != http.StatusConflict {
+		t.Errorf("PDF status = %d, want 409", rec.Code)
+	}
+	if rec := regenerate(`{"id":"` + a.ID + `","x":10}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("x without y status = %d, want 400", rec.Code)
+	}
+
+	injector.err = remotetrigger.ErrUnknownCanvas
+	if rec := regenerate(`{"id":"` + a.ID + `"}`); rec.Code != http.StatusConflict {
+		t.Errorf("unmonitored canvas status = %d, want 409", rec.Code)
+	}
+}
@@ -0,0 +1,209 @@
+// Package webui provides the AuditAPI organism for the AI audit trail.
+// This file contains the REST handlers that export the audit log and
+// verify its hash chain.
+package webui
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_backend/audit"
+
+	"go.uber.org/zap"
+)
+
+// auditExportPageSize is the number of entries read at a time while exporting.
+const auditExportPageSize = 500
+
+// auditCSVHeader is the header row of the CSV export.
+var auditCSVHeader = []string{
+	"seq", "time", "canvas_id", "correlation_id", "action", "widget_type", "widget_id",
+	"trigger_widget_id", "trigger_type", "operation", "model", "content_sha256",
+	"prev_hash", "hash",
+}
+
+// AuditAPI is an organism that exposes the audit log.
+//
+// Endpoints (both require authentication when auth is enabled):
+// - GET /api/audit/export - Entries, oldest first (?format=jsonl|csv&after=SEQ&limit=N)
+// - GET /api/audit/verify - Walk the hash chain and report the first broken entry
+type AuditAPI struct {
+	log    *audit.Log
+	logger *zap.Logger
+}
+
+// NewAuditAPI creates an AuditAPI. log is nil when auditing is disabled.
+func NewAuditAPI(log *audit.Log, logger *zap.Logger) *AuditAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &AuditAPI{log: log, logger: logger}
+}
+
+// HandleExport handles GET /api/audit/export requests. The export is
+// streamed, so large logs are not held in memory.
+func (api *AuditAPI) HandleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !api.requireLog(w) {
+		return
+	}
+
+	query := r.URL.Query()
+	format := query.Get("format")
+	if format == "" {
+		format = "jsonl"
+	}
+	if format != "jsonl" && format != "csv" {
+		writeError(w, http.StatusBadRequest, "format must be jsonl or csv")
+		return
+	}
+	after, err := parseAuditParam(query.Get("after"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "after must be a non-negative integer")
+		return
+	}
+	limit, err := parseAuditParam(query.Get("limit"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "limit must be a non-negative integer")
+		return
+	}
+
+	// Read the first page before writing headers so storage errors can
+	// still be reported as such
+	page, err := api.log.Entries(r.Context(), after, auditPageSize(limit, 0))
+	if err != nil {
+		api.logger.Error("Failed to read audit log", zap.Error(err))
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("audit-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var write func(audit.Entry) error
+	var flush func() error
+	if format == "csv" {
+		cw := csv.NewWriter(w)
+		if err := cw.Write(auditCSVHeader); err != nil {
+			return
+		}
+		write = func(e audit.Entry) error { return cw.Write(auditCSVRecord(e)) }
+		flush = func() error { cw.Flush(); return cw.Error() }
+	} else {
+		enc := json.NewEncoder(w)
+		write = func(e audit.Entry) error { return enc.Encode(e) }
+		flush = func() error { return nil }
+	}
+
+	var written int64
+	for len(page) > 0 {
+		for _, e := range page {
+			if err := write(e); err != nil {
+				api.logger.Debug("Audit export interrupted", zap.Error(err))
+				return
+			}
+			after = e.Seq
+			written++
+		}
+		if len(page) < auditExportPageSize || (limit > 0 && written >= limit) {
+			break
+		}
+		page, err = api.log.Entries(r.Context(), after, auditPageSize(limit, written))
+		if err != nil {
+			// Headers are already sent, so the export just ends early
+			api.logger.Error("Failed to read audit log during export", zap.Error(err))
+			break
+		}
+	}
+	if err := flush(); err != nil {
+		api.logger.Debug("Audit export interrupted", zap.Error(err))
+	}
+}
+
+// HandleVerify handles GET /api/audit/verify requests.
+func (api *AuditAPI) HandleVerify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !api.requireLog(w) {
+		return
+	}
+
+	result, err := api.log.Verify(r.Context())
+	if err != nil {
+		api.logger.Error("Failed to verify audit log", zap.Error(err))
+		writeError(w, http.StatusBadGateway, err.Error())
+		return
+	}
+	if !result.Valid {
+		api.logger.Warn("Audit log hash chain is broken",
+			zap.Int64("first_bad_seq", result.FirstBadSeq),
+			zap.String("problem", result.Problem))
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// RegisterRoutes registers the audit routes on mux. If protect is non-nil
+// it wraps every handler, since the log describes all canvas activity.
+func (api *AuditAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	export := api.HandleExport
+	verify := api.HandleVerify
+	if protect != nil {
+		export = protect(export)
+		verify = protect(verify)
+	}
+	mux.HandleFunc("/api/audit/export", export)
+	mux.HandleFunc("/api/audit/verify", verify)
+}
+
+// auditPageSize returns the next page size, honouring limit (0 = no limit).
+func auditPageSize(limit, written int64) int {
+	if limit > 0 && limit-written < auditExportPageSize {
+		return int(limit - written)
+	}
+	return auditExportPageSize
+}
+
+// parseAuditParam parses an optional non-negative integer parameter.
+func parseAuditParam(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return n, nil
+}
+
+// auditCSVRecord returns e in auditCSVHeader order.
+func auditCSVRecord(e audit.Entry) []string {
+	return []string{
+		strconv.FormatInt(e.Seq, 10), e.Time.UTC().Format(time.RFC3339Nano),
+		e.CanvasID, e.CorrelationID, string(e.Action), e.WidgetType, e.WidgetID,
+		e.TriggerWidgetID, e.TriggerType, e.Operation, e.Model, e.ContentSHA256,
+		e.PrevHash, e.Hash,
+	}
+}
+
+// requireLog writes an error if auditing is disabled.
+func (api *AuditAPI) requireLog(w http.ResponseWriter) bool {
+	if api.log == nil {
+		writeError(w, http.StatusNotFound, "audit log is disabled (set AUDIT_LOG=true)")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package webui
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_backend/audit"
+)
+
+// memoryAuditStorage keeps audit entries in a slice
+type memoryAuditStorage struct {
+	entries []audit.Entry
+}
+
+func (m *memoryAuditStorage) LastAuditEntry(ctx context.Context) (*audit.Entry, error) {
+	if len(m.entries) == 0 {
+		return nil, nil
+	}
+	e := m.entries[len(m.entries)-1]
+	return &e, nil
+}
+
+func (m *memoryAuditStorage) InsertAuditEntry(ctx context.Context, e audit.Entry) error {
+	m.entries = append(m.entries, e)
+	return nil
+}
+
+func (m *memoryAuditStorage) ListAuditEntries(ctx context.Context, afterSeq int64, limit int) ([]audit.Entry, error) {
+	var out []audit.Entry
+	for _, e := range m.entries {
+		if e.Seq > afterSeq && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func newTestAuditAPI(t *testing.T, n int) (*AuditAPI, *memoryAuditStorage) {
+	t.Helper()
+	storage := &memoryAuditStorage{}
+	log := audit.NewLog(storage)
+	for i := 0; i < n; i++ {
+		if _, err := log.Record(context.Background(), audit.Entry{
+			Action:     audit.ActionCreate,
+			WidgetType: "Note",
+			Model:      "gpt-4o, mini",
+		}); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	return NewAuditAPI(log, nil), storage
+}
+
+func TestAuditAPIExportJSONL(t *testing.T) {
+	api, _ := newTestAuditAPI(t, 5)
+
+	rec := httptest.NewRecorder()
+	api.HandleExport(rec, httptest.NewRequest(http.MethodGet, "/api/audit/export?after=1&limit=3", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("exported %d lines, want 3", len(lines))
+	}
+	var first audit.Entry
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("invalid JSON line: %v", err)
+	}
+	if first.Seq != 2 || first.Hash != audit.ComputeHash(first) {
+		t.Errorf("first entry = %+v", first)
+	}
+}
+
+func TestAuditAPIExportCSV(t *testing.T) {
+	api, _ := newTestAuditAPI(t, 2)
+
+	rec := httptest.NewRecorder()
+	api.HandleExport(rec, httptest.NewRequest(http.MethodGet, "/api/audit/export?format=csv", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv" {
+		t.Fatalf("status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != "seq" || records[2][10] != "gpt-4o, mini" {
+		t.Errorf("records = %q", records)
+	}
+}
+
+func TestAuditAPIExportBadParams(t *testing.T) {
+	api, _ := newTestAuditAPI(t, 1)
+	for _, query := range []string{"format=xml", "after=-1", "limit=x"} {
+		rec := httptest.NewRecorder()
+		api.HandleExport(rec, httptest.NewRequest(http.MethodGet, "/api/audit/export?"+query, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", query, rec.Code)
+		}
+	}
+}
+
+func TestAuditAPIVerify(t *testing.T) {
+	api, storage := newTestAuditAPI(t, 3)
+
+	var result audit.VerifyResult
+	rec := httptest.NewRecorder()
+	api.HandleVerify(rec, httptest.NewRequest(http.MethodGet, "/api/audit/verify", nil))
+	json.NewDecoder(rec.Body).Decode(&result)
+	if rec.Code != http.StatusOK || !result.Valid || result.Entries != 3 {
+		t.Fatalf("status = %d, result = %+v", rec.Code, result)
+	}
+
+	storage.entries[1].Model = "edited"
+	rec = httptest.NewRecorder()
+	api.HandleVerify(rec, httptest.NewRequest(http.MethodGet, "/api/audit/verify", nil))
+	json.NewDecoder(rec.Body).Decode(&result)
+	if result.Valid || result.FirstBadSeq != 2 {
+		t.Errorf("result after tampering = %+v", result)
+	}
+}
+
+func TestAuditAPIDisabled(t *testing.T) {
+	api := NewAuditAPI(nil, nil)
+	rec := httptest.NewRecorder()
+	api.HandleVerify(rec, httptest.NewRequest(http.MethodGet, "/api/audit/verify", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
+
+func TestAuditAPIRoutesProtected(t *testing.T) {
+	api, _ := newTestAuditAPI(t, 1)
+	mux := http.NewServeMux()
+	api.RegisterRoutes(mux, func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+
+	for _, path := range []string{"/api/audit/export", "/api/audit/verify"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want 401", path, rec.Code)
+		}
+	}
+}
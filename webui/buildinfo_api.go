@@ -0,0 +1,45 @@
+// Package webui provides the BuildInfoAPI organism for build diagnostics.
+// This file contains the REST handler reporting how the binary was built.
+package webui
+
+import (
+	"net/http"
+
+	"go_backend/buildinfo"
+
+	"go.uber.org/zap"
+)
+
+// BuildInfoAPI is an organism that reports how the running binary was
+// built and which optional subsystems it contains.
+//
+// Endpoints:
+// - GET /api/buildinfo - Version, commit, build tags, native library
+// versions and compiled-in subsystems
+type BuildInfoAPI struct {
+	report buildinfo.Report
+	logger *zap.Logger
+}
+
+// NewBuildInfoAPI creates a BuildInfoAPI serving report, which does not
+// change while the process runs.
+func NewBuildInfoAPI(report buildinfo.Report, logger *zap.Logger) *BuildInfoAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &BuildInfoAPI{report: report, logger: logger}
+}
+
+// HandleBuildInfo handles GET /api/buildinfo requests.
+func (api *BuildInfoAPI) HandleBuildInfo(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, api.report)
+}
+
+// RegisterRoutes registers the build info route on mux.
+func (api *BuildInfoAPI) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/buildinfo", api.HandleBuildInfo)
+}
@@ -0,0 +1,44 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go_backend/buildinfo"
+)
+
+func TestBuildInfoAPI_HandleBuildInfo(t *testing.T) {
+	report := buildinfo.Read(
+		buildinfo.Subsystem{Name: "llama.cpp", Compiled: false},
+		buildinfo.Subsystem{Name: "nvml", Compiled: true, Detail: "go-nvml"},
+	)
+	api := NewBuildInfoAPI(report, nil)
+
+	rec := httptest.NewRecorder()
+	api.HandleBuildInfo(rec, httptest.NewRequest(http.MethodGet, "/api/buildinfo", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+
+	var resp buildinfo.Report
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.GoVersion == "" || resp.Platform == "" || resp.Tags == nil || resp.Native.LlamaCpp != "unknown" {
+		t.Errorf("unexpected report: %+v", resp)
+	}
+	if len(resp.Subsystems) != 2 || resp.Subsystems[1].Detail != "go-nvml" {
+		t.Errorf("subsystems = %+v", resp.Subsystems)
+	}
+	if compiled := resp.Compiled(); len(compiled) != 1 || compiled[0] != "nvml" {
+		t.Errorf("Compiled() = %v, want [nvml]", compiled)
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleBuildInfo(rec, httptest.NewRequest(http.MethodPost, "/api/buildinfo", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", rec.Code)
+	}
+}
@@ -0,0 +1,92 @@
+// Package webui provides the CanvasPreviewAPI organism for the canvas map.
+// This file contains the REST handler that returns a canvas layout with the
+// AI activity on it; later changes arrive over the WebSocket.
+package webui
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"go_backend/canvaspreview"
+
+	"go.uber.org/zap"
+)
+
+// previewTimeout bounds reading a canvas for a preview.
+const previewTimeout = 15 * time.Second
+
+// CanvasPreviewer provides canvas previews.
+// Implemented by canvaspreview.Previewer.
+type CanvasPreviewer interface {
+	CanvasIDs() []string
+	Preview(ctx context.Context, canvasID string) (*canvaspreview.Preview, error)
+}
+
+// CanvasPreviewResponse represents the JSON response for GET /api/canvas/preview.
+type CanvasPreviewResponse struct {
+	// Canvases are the canvases that can be previewed
+	Canvases []string `json:"canvases"`
+	*canvaspreview.Preview
+}
+
+// CanvasPreviewAPI is an organism that serves the dashboard's canvas map.
+//
+// Endpoints:
+// - GET /api/canvas/preview?canvas_id= - Layout and AI activity of a canvas
+// (default: the first monitored canvas)
+type CanvasPreviewAPI struct {
+	previewer CanvasPreviewer
+	logger    *zap.Logger
+}
+
+// NewCanvasPreviewAPI creates a CanvasPreviewAPI backed by previewer.
+func NewCanvasPreviewAPI(previewer CanvasPreviewer, logger *zap.Logger) *CanvasPreviewAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &CanvasPreviewAPI{previewer: previewer, logger: logger}
+}
+
+// HandlePreview handles GET /api/canvas/preview requests.
+func (api *CanvasPreviewAPI) HandlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	canvases := api.previewer.CanvasIDs()
+	canvasID := r.URL.Query().Get("canvas_id")
+	if canvasID == "" {
+		if len(canvases) == 0 {
+			writeError(w, http.StatusNotFound, "no canvases are monitored")
+			return
+		}
+		canvasID = canvases[0]
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), previewTimeout)
+	defer cancel()
+	preview, err := api.previewer.Preview(ctx, canvasID)
+	if errors.Is(err, canvaspreview.ErrUnknownCanvas) {
+		writeError(w, http.StatusNotFound, "canvas is not monitored: "+canvasID)
+		return
+	}
+	if err != nil {
+		api.logger.Warn("Failed to preview canvas", zap.String("canvas_id", canvasID), zap.Error(err))
+		writeError(w, http.StatusBadGateway, "failed to read canvas")
+		return
+	}
+	writeJSON(w, http.StatusOK, CanvasPreviewResponse{Canvases: canvases, Preview: preview})
+}
+
+// RegisterRoutes registers the canvas preview route on mux. protect, if
+// non-nil, guards it, since previews show widget titles.
+func (api *CanvasPreviewAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	handler := api.HandlePreview
+	if protect != nil {
+		handler = protect(handler)
+	}
+	mux.HandleFunc("/api/canvas/preview", handler)
+}
@@ -0,0 +1,79 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go_backend/canvaspreview"
+)
+
+type fakePreviewer struct {
+	err error
+}
+
+func (f *fakePreviewer) CanvasIDs() []string { return []string{"c1", "c2"} }
+
+func (f *fakePreviewer) Preview(ctx context.Context, canvasID string) (*canvaspreview.Preview, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if canvasID != "c1" && canvasID != "c2" {
+		return nil, canvaspreview.ErrUnknownCanvas
+	}
+	preview := &canvaspreview.Preview{Layout: canvaspreview.NewLayout(nil)}
+	preview.CanvasID = canvasID
+	return preview, nil
+}
+
+func TestCanvasPreviewAPI_HandlePreview(t *testing.T) {
+	get := func(previewer CanvasPreviewer, query string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		NewCanvasPreviewAPI(previewer, nil).HandlePreview(rec, httptest.NewRequest(http.MethodGet, "/api/canvas/preview"+query, nil))
+		var body map[string]interface{}
+		json.NewDecoder(rec.Body).Decode(&body)
+		return rec, body
+	}
+
+	t.Run("defaults to the first canvas", func(t *testing.T) {
+		rec, body := get(&fakePreviewer{}, "")
+		if rec.Code != http.StatusOK || body["canvas_id"] != "c1" {
+			t.Errorf("unexpected response: %d %v", rec.Code, body)
+		}
+		if canvases, _ := body["canvases"].([]interface{}); len(canvases) != 2 {
+			t.Errorf("canvases = %v", body["canvases"])
+		}
+		if _, ok := body["widgets"]; !ok {
+			t.Error("layout missing from response")
+		}
+	})
+
+	t.Run("selects a canvas", func(t *testing.T) {
+		if _, body := get(&fakePreviewer{}, "?canvas_id=c2"); body["canvas_id"] != "c2" {
+			t.Errorf("canvas_id = %v, want c2", body["canvas_id"])
+		}
+	})
+
+	t.Run("unknown canvas", func(t *testing.T) {
+		if rec, _ := get(&fakePreviewer{}, "?canvas_id=nope"); rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", rec.Code)
+		}
+	})
+
+	t.Run("canvas read failure", func(t *testing.T) {
+		if rec, _ := get(&fakePreviewer{err: errors.New("offline")}, ""); rec.Code != http.StatusBadGateway {
+			t.Errorf("status = %d, want 502", rec.Code)
+		}
+	})
+
+	t.Run("rejects POST", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewCanvasPreviewAPI(&fakePreviewer{}, nil).HandlePreview(rec, httptest.NewRequest(http.MethodPost, "/api/canvas/preview", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want 405", rec.Code)
+		}
+	})
+}
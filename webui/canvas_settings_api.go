@@ -0,0 +1,157 @@
+// Package webui provides the CanvasSettingsAPI organism for per-canvas
+// configuration overrides. This file contains the REST handlers that list,
+// save and reset the settings of each canvas.
+package webui
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go_backend/canvassettings"
+	"go_backend/i18n"
+
+	"go.uber.org/zap"
+)
+
+// maxCanvasSettingsBody limits the size of a settings update.
+const maxCanvasSettingsBody = 64 << 10
+
+// CanvasSettingsAPI is an organism that edits per-canvas settings.
+//
+// Endpoints:
+// - GET    /api/canvas-settings              - Settings of every known canvas, features and languages
+// - GET    /api/canvas-settings?canvas_id=X  - Settings of one canvas
+// - PUT    /api/canvas-settings              - Save the settings in the JSON body (requires auth)
+// - DELETE /api/canvas-settings?canvas_id=X  - Reset a canvas to the server configuration (requires auth)
+type CanvasSettingsAPI struct {
+	store     *canvassettings.Store
+	canvasIDs []string
+	logger    *zap.Logger
+}
+
+// CanvasSettingsEntry is one canvas in the settings list.
+type CanvasSettingsEntry struct {
+	CanvasID  string                  `json:"canvas_id"`
+	Monitored bool                    `json:"monitored"` // Watched by this server
+	Custom    bool                    `json:"custom"`    // Has saved overrides
+	Settings  canvassettings.Settings `json:"settings"`
+}
+
+// CanvasSettingsList is the response of GET /api/canvas-settings.
+type CanvasSettingsList struct {
+	Canvases  []CanvasSettingsEntry `json:"canvases"`
+	Features  []string              `json:"features"`
+	Languages []string              `json:"languages"`
+}
+
+// NewCanvasSettingsAPI creates a CanvasSettingsAPI. canvasIDs are the
+// monitored canvases, listed even when they have no overrides.
+func NewCanvasSettingsAPI(store *canvassettings.Store, canvasIDs []string, logger *zap.Logger) *CanvasSettingsAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &CanvasSettingsAPI{store: store, canvasIDs: canvasIDs, logger: logger}
+}
+
+// HandleGet handles GET /api/canvas-settings requests.
+func (api *CanvasSettingsAPI) HandleGet(w http.ResponseWriter, r *http.Request) {
+	if canvasID := r.URL.Query().Get("canvas_id"); canvasID != "" {
+		writeJSON(w, http.StatusOK, api.store.Get(canvasID))
+		return
+	}
+
+	list := CanvasSettingsList{Features: canvassettings.AllFeatures, Languages: i18n.Languages()}
+	seen := make(map[string]bool)
+	for _, id := range api.canvasIDs {
+		seen[id] = true
+		settings := api.store.Get(id)
+		list.Canvases = append(list.Canvases, CanvasSettingsEntry{
+			CanvasID:  id,
+			Monitored: true,
+			Custom:    !settings.UpdatedAt.IsZero(),
+			Settings:  settings,
+		})
+	}
+	// Canvases no longer monitored keep their overrides until reset
+	for _, settings := range api.store.List() {
+		if !seen[settings.CanvasID] {
+			list.Canvases = append(list.Canvases, CanvasSettingsEntry{
+				CanvasID: settings.CanvasID,
+				Custom:   true,
+				Settings: settings,
+			})
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// HandlePut handles PUT /api/canvas-settings requests. The body replaces
+// all overrides of the canvas it names.
+func (api *CanvasSettingsAPI) HandlePut(w http.ResponseWriter, r *http.Request) {
+	var settings canvassettings.Settings
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCanvasSettingsBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&settings); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid settings: "+err.Error())
+		return
+	}
+
+	saved, err := api.store.Put(r.Context(), settings)
+	if err != nil {
+		if errors.Is(err, canvassettings.ErrInvalidSettings) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		api.logger.Error("Failed to save canvas settings", zap.String("canvas_id", settings.CanvasID), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to save canvas settings")
+		return
+	}
+
+	api.logger.Info("Canvas settings updated", zap.String("canvas_id", saved.CanvasID))
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// HandleDelete handles DELETE /api/canvas-settings?canvas_id=X requests.
+func (api *CanvasSettingsAPI) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	canvasID := r.URL.Query().Get("canvas_id")
+	if canvasID == "" {
+		writeError(w, http.StatusBadRequest, "canvas_id is required")
+		return
+	}
+	if err := api.store.Delete(r.Context(), canvasID); err != nil {
+		api.logger.Error("Failed to reset canvas settings", zap.String("canvas_id", canvasID), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to reset canvas settings")
+		return
+	}
+
+	api.logger.Info("Canvas settings reset", zap.String("canvas_id", canvasID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegisterRoutes registers the canvas settings route on mux. If protect is
+// non-nil it wraps the handlers that change settings.
+func (api *CanvasSettingsAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	put := api.HandlePut
+	del := api.HandleDelete
+	if protect != nil {
+		put = protect(put)
+		del = protect(del)
+	}
+	mux.HandleFunc("/api/canvas-settings", func(w http.ResponseWriter, r *http.Request) {
+		if api.store == nil {
+			writeError(w, http.StatusNotFound, "canvas settings are unavailable (database disabled)")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			api.HandleGet(w, r)
+		case http.MethodPut:
+			put(w, r)
+		case http.MethodDelete:
+			del(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	})
+}
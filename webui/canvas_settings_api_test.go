@@ -0,0 +1,144 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_backend/canvassettings"
+)
+
+// memorySettingsStorage keeps canvas settings in a map
+type memorySettingsStorage struct {
+	saved map[string]canvassettings.Settings
+}
+
+func (m *memorySettingsStorage) ListCanvasSettings(ctx context.Context) ([]canvassettings.Settings, error) {
+	var out []canvassettings.Settings
+	for _, s := range m.saved {
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+func (m *memorySettingsStorage) SaveCanvasSettings(ctx context.Context, s canvassettings.Settings) error {
+	m.saved[s.CanvasID] = s
+	return nil
+}
+
+func (m *memorySettingsStorage) DeleteCanvasSettings(ctx context.Context, canvasID string) error {
+	delete(m.saved, canvasID)
+	return nil
+}
+
+func newTestCanvasSettingsAPI(t *testing.T) (*http.ServeMux, *memorySettingsStorage) {
+	t.Helper()
+	storage := &memorySettingsStorage{saved: map[string]canvassettings.Settings{
+		"old-canvas": {CanvasID: "old-canvas", NoteColor: "#000000"},
+	}}
+	store, err := canvassettings.NewStore(context.Background(), storage, nil)
+	if err != nil {
+		t.Fatalf("NewStore failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	NewCanvasSettingsAPI(store, []string{"demo", "prod"}, nil).RegisterRoutes(mux, nil)
+	return mux, storage
+}
+
+func TestCanvasSettingsAPIList(t *testing.T) {
+	mux, _ := newTestCanvasSettingsAPI(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/canvas-settings", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+
+	var list CanvasSettingsList
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Features) != len(canvassettings.AllFeatures) || len(list.Languages) == 0 {
+		t.Errorf("features = %v, languages = %v", list.Features, list.Languages)
+	}
+	if len(list.Canvases) != 3 {
+		t.Fatalf("canvases = %+v, want demo, prod and old-canvas", list.Canvases)
+	}
+	if c := list.Canvases[0]; c.CanvasID != "demo" || !c.Monitored || c.Custom {
+		t.Errorf("demo = %+v", c)
+	}
+	if c := list.Canvases[2]; c.CanvasID != "old-canvas" || c.Monitored || !c.Custom {
+		t.Errorf("old-canvas = %+v", c)
+	}
+}
+
+func TestCanvasSettingsAPIPutAndDelete(t *testing.T) {
+	mux, storage := newTestCanvasSettingsAPI(t)
+
+	body := `{"canvas_id":"demo","disabled_features":["pdf_precis"],"trigger_open":"[[","trigger_close":"]]","daily_task_limit":20}`
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/canvas-settings", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if saved := storage.saved["demo"]; saved.TriggerOpen != "[[" || saved.DailyTaskLimit != 20 {
+		t.Errorf("saved = %+v", saved)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/canvas-settings?canvas_id=demo", nil))
+	var got canvassettings.Settings
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil || got.FeatureEnabled(canvassettings.FeaturePDFPrecis) {
+		t.Errorf("GET demo = %+v, %v", got, err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/canvas-settings?canvas_id=demo", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("DELETE status = %d", rec.Code)
+	}
+	if _, ok := storage.saved["demo"]; ok {
+		t.Error("DELETE should remove the saved settings")
+	}
+}
+
+func TestCanvasSettingsAPIErrors(t *testing.T) {
+	mux, _ := newTestCanvasSettingsAPI(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"invalid settings", http.MethodPut, "/api/canvas-settings", `{"canvas_id":"demo","note_color":"red"}`, http.StatusBadRequest},
+		{"unknown field", http.MethodPut, "/api/canvas-settings", `{"canvas_id":"demo","colour":"#000000"}`, http.StatusBadRequest},
+		{"bad json", http.MethodPut, "/api/canvas-settings", `{`, http.StatusBadRequest},
+		{"delete without canvas", http.MethodDelete, "/api/canvas-settings", "", http.StatusBadRequest},
+		{"wrong method", http.MethodPost, "/api/canvas-settings", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (body %s)", rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCanvasSettingsAPIUnavailable(t *testing.T) {
+	mux := http.NewServeMux()
+	NewCanvasSettingsAPI(nil, nil, nil).RegisterRoutes(mux, nil)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/canvas-settings", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 without a store", rec.Code)
+	}
+}
@@ -0,0 +1,194 @@
+// Package webui provides the ChatOpsAPI organism for chat integrations.
+// This file contains the Slack slash command and Teams outgoing webhook
+// endpoints that answer "/canvus ask" on the canvas.
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go_backend/chatops"
+	"go_backend/core/clock"
+
+	"go.uber.org/zap"
+)
+
+// Chat integration limits.
+const (
+	// maxChatBody bounds the size of a chat request
+	maxChatBody = 64 << 10
+	// chatAnswerTimeout bounds answering a question
+	chatAnswerTimeout = 2 * time.Minute
+	// teamsReplyWait is how long a Teams request waits for the answer;
+	// Teams gives up on outgoing webhooks after five seconds
+	teamsReplyWait = 4 * time.Second
+)
+
+// ChatOpsAPI is an organism that answers chat commands on a canvas.
+//
+// Endpoints (verified by the platform's request signature, not by login):
+// - POST /api/integrations/slack - Slack slash command ("/canvus ask <question>")
+// - POST /api/integrations/teams - Teams outgoing webhook ("@Canvus ask <question>")
+type ChatOpsAPI struct {
+	asker  *chatops.Asker
+	config chatops.Config
+	logger *zap.Logger
+
+	// clock and postSlack are replaced in tests
+	clock     clock.Clock
+	postSlack func(ctx context.Context, responseURL string, msg chatops.SlackMessage) error
+}
+
+// NewChatOpsAPI creates a ChatOpsAPI answering questions with asker. A
+// platform is only served when its secret is set in config.
+func NewChatOpsAPI(asker *chatops.Asker, config chatops.Config, logger *zap.Logger) *ChatOpsAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	return &ChatOpsAPI{
+		asker:  asker,
+		config: config,
+		logger: logger,
+		clock:  clock.Real(),
+		postSlack: func(ctx context.Context, responseURL string, msg chatops.SlackMessage) error {
+			return chatops.PostSlack(ctx, httpClient, responseURL, msg)
+		},
+	}
+}
+
+// HandleSlack handles POST /api/integrations/slack requests. Slack expects
+// a reply within three seconds, so the question is acknowledged at once and
+// the answer is posted to the command's response_url.
+func (api *ChatOpsAPI) HandleSlack(w http.ResponseWriter, r *http.Request) {
+	body, ok := api.readSigned(w, r, api.config.SlackSigningSecret, "Slack")
+	if !ok {
+		return
+	}
+	if err := chatops.VerifySlack(api.config.SlackSigningSecret, r.Header, body, api.clock.Now()); err != nil {
+		api.logger.Warn("Rejected Slack request", zap.Error(err))
+		writeError(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid form body")
+		return
+	}
+
+	cmd := chatops.ParseCommand(form.Get("text"))
+	if cmd.Name != chatops.CommandAsk || cmd.Args == "" {
+		writeJSON(w, http.StatusOK, chatops.SlackMessage{ResponseType: chatops.SlackEphemeral, Text: chatops.HelpText})
+		return
+	}
+
+	user, responseURL := form.Get("user_name"), form.Get("response_url")
+	question := chatops.Question{Text: cmd.Args, User: user, Source: "Slack #" + form.Get("channel_name")}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), chatAnswerTimeout)
+		defer cancel()
+		msg := chatops.SlackMessage{ResponseType: chatops.SlackInChannel, Text: api.answerText(ctx, question)}
+		if err := api.postSlack(ctx, responseURL, msg); err != nil {
+			api.logger.Warn("Failed to post answer to Slack", zap.Error(err))
+		}
+	}()
+
+	writeJSON(w, http.StatusOK, chatops.SlackMessage{
+		ResponseType: chatops.SlackInChannel,
+		Text:         fmt.Sprintf("%s asked: %s\nPosting to the canvas...", user, cmd.Args),
+	})
+}
+
+// HandleTeams handles POST /api/integrations/teams requests. The answer is
+// returned when it is ready within teamsReplyWait; otherwise the reply
+// points at the canvas, where the answer still appears.
+func (api *ChatOpsAPI) HandleTeams(w http.ResponseWriter, r *http.Request) {
+	body, ok := api.readSigned(w, r, api.config.TeamsSecret, "Teams")
+	if !ok {
+		return
+	}
+	if err := chatops.VerifyTeams(api.config.TeamsSecret, r.Header.Get("Authorization"), body); err != nil {
+		api.logger.Warn("Rejected Teams request", zap.Error(err))
+		writeError(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+	var activity chatops.TeamsActivity
+	if err := json.Unmarshal(body, &activity); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	cmd := chatops.ParseCommand(activity.CommandText())
+	if cmd.Name != chatops.CommandAsk || cmd.Args == "" {
+		writeJSON(w, http.StatusOK, chatops.NewTeamsMessage(chatops.HelpText))
+		return
+	}
+
+	question := chatops.Question{Text: cmd.Args, User: activity.From.Name, Source: "Teams"}
+	if activity.Conversation.Name != "" {
+		question.Source += " " + activity.Conversation.Name
+	}
+	answer := make(chan string, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), chatAnswerTimeout)
+		defer cancel()
+		answer <- api.answerText(ctx, question)
+	}()
+
+	select {
+	case text := <-answer:
+		writeJSON(w, http.StatusOK, chatops.NewTeamsMessage(text))
+	case <-time.After(teamsReplyWait):
+		writeJSON(w, http.StatusOK, chatops.NewTeamsMessage("Your question is on the canvas; the answer will appear beside it shortly."))
+	}
+}
+
+// answerText asks the question and describes the outcome for the chat.
+func (api *ChatOpsAPI) answerText(ctx context.Context, q chatops.Question) string {
+	answer, err := api.asker.Ask(ctx, q)
+	if err != nil {
+		api.logger.Error("Chat question failed", zap.String("source", q.Source), zap.Error(err))
+		if answer != nil && answer.QuestionID != "" {
+			return "Your question is on the canvas, but it could not be answered: " + err.Error()
+		}
+		return "Your question could not be posted to the canvas: " + err.Error()
+	}
+	return fmt.Sprintf("> %s\n%s", q.Text, answer.Text)
+}
+
+// readSigned reads the body of a POST request for a configured platform.
+func (api *ChatOpsAPI) readSigned(w http.ResponseWriter, r *http.Request, secret, platform string) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return nil, false
+	}
+	if secret == "" || api.asker == nil {
+		writeError(w, http.StatusNotFound, platform+" integration is not configured")
+		return nil, false
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxChatBody))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "request body too large")
+		} else {
+			writeError(w, http.StatusBadRequest, "failed to read request body")
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+// RegisterRoutes registers the chat routes on mux. They are not behind the
+// login: chat platforms cannot sign in, and requests are verified by
+// signature instead.
+func (api *ChatOpsAPI) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/integrations/slack", api.HandleSlack)
+	mux.HandleFunc("/api/integrations/teams", api.HandleTeams)
+}
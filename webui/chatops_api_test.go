@@ -0,0 +1,135 @@
+package webui
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go_backend/chatops"
+	"go_backend/core/clock"
+)
+
+// chatCanvas accepts every widget
+type chatCanvas struct{}
+
+func (chatCanvas) GetWidgets(subscribe bool) ([]map[string]interface{}, error) { return nil, nil }
+
+func (chatCanvas) CreateNote(payload map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"id": "note"}, nil
+}
+
+func (chatCanvas) CreateConnector(payload map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"id": "connector"}, nil
+}
+
+func newTestChatOpsAPI(respond chatops.RespondFunc, config chatops.Config) (*ChatOpsAPI, *http.ServeMux) {
+	api := NewChatOpsAPI(chatops.NewAsker(chatCanvas{}, respond, nil), config, nil)
+	mux := http.NewServeMux()
+	api.RegisterRoutes(mux)
+	return api, mux
+}
+
+func TestChatOpsAPISlack(t *testing.T) {
+	respond := func(ctx context.Context, prompt, model string) (string, error) { return "Ship the beta.", nil }
+	api, mux := newTestChatOpsAPI(respond, chatops.Config{SlackSigningSecret: "secret"})
+	now := time.Unix(1700000000, 0)
+	api.clock = clock.NewFake(now)
+	posted := make(chan chatops.SlackMessage, 1)
+	api.postSlack = func(ctx context.Context, responseURL string, msg chatops.SlackMessage) error {
+		posted <- msg
+		return nil
+	}
+
+	slackRequest := func(text, secret string) *http.Request {
+		body := url.Values{"text": {text}, "user_name": {"sam"}, "channel_name": {"design"}, "response_url": {"https://hooks.slack.com/x"}}.Encode()
+		ts := strconv.FormatInt(now.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte("v0:" + ts + ":" + body))
+		req := httptest.NewRequest(http.MethodPost, "/api/integrations/slack", strings.NewReader(body))
+		req.Header.Set("X-Slack-Request-Timestamp", ts)
+		req.Header.Set("X-Slack-Signature", "v0="+hex.EncodeToString(mac.Sum(nil)))
+		return req
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, slackRequest("ask What next?", "secret"))
+	var ack chatops.SlackMessage
+	json.NewDecoder(rec.Body).Decode(&ack)
+	if rec.Code != http.StatusOK || ack.ResponseType != chatops.SlackInChannel || !strings.Contains(ack.Text, "sam asked: What next?") {
+		t.Fatalf("status = %d, ack = %+v", rec.Code, ack)
+	}
+	select {
+	case msg := <-posted:
+		if msg.Text != "> What next?\nShip the beta." {
+			t.Errorf("answer = %q", msg.Text)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("answer not posted to Slack")
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, slackRequest("help", "secret"))
+	json.NewDecoder(rec.Body).Decode(&ack)
+	if ack.ResponseType != chatops.SlackEphemeral || ack.Text != chatops.HelpText {
+		t.Errorf("help = %+v", ack)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, slackRequest("ask hi", "forged"))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("forged request: status = %d", rec.Code)
+	}
+}
+
+func TestChatOpsAPITeams(t *testing.T) {
+	release := make(chan struct{})
+	respond := func(ctx context.Context, prompt, model string) (string, error) {
+		if prompt == "slow" {
+			<-release
+		}
+		return "Ship the beta.", nil
+	}
+	key := []byte("0123456789abcdef")
+	_, mux := newTestChatOpsAPI(respond, chatops.Config{TeamsSecret: base64.StdEncoding.EncodeToString(key)})
+	defer close(release)
+
+	teamsRequest := func(text string) *http.Request {
+		body := `{"type":"message","text":"<at>Canvus</at> ` + text + `","from":{"name":"Sam"}}`
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(body))
+		req := httptest.NewRequest(http.MethodPost, "/api/integrations/teams", strings.NewReader(body))
+		req.Header.Set("Authorization", "HMAC "+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		return req
+	}
+
+	for text, want := range map[string]string{
+		"ask What next?": "> What next?\nShip the beta.",
+		"ask slow":       "Your question is on the canvas; the answer will appear beside it shortly.",
+		"":               chatops.HelpText,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, teamsRequest(text))
+		var msg chatops.TeamsMessage
+		json.NewDecoder(rec.Body).Decode(&msg)
+		if rec.Code != http.StatusOK || msg.Type != "message" || msg.Text != want {
+			t.Errorf("%q: status = %d, reply = %+v", text, rec.Code, msg)
+		}
+	}
+
+	// Slack is not configured
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/integrations/slack", strings.NewReader("")))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unconfigured Slack: status = %d", rec.Code)
+	}
+}
@@ -4,12 +4,25 @@
 package webui
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"go_backend/cluster"
+	"go_backend/db"
+	"go_backend/instancelease"
 	"go_backend/metrics"
+	"go_backend/recovery"
+	"go_backend/streamstate"
+	"go_backend/tempfiles"
+	"go_backend/watchdog"
 )
 
 // DashboardAPI is an organism that provides REST API handlers for the dashboard.
@@ -19,15 +32,31 @@ import (
 // Endpoints:
 // - GET /api/status    - System health status
 // - GET /api/canvases  - All canvas statuses
-// - GET /api/tasks     - Recent task records (with limit param)
+// - GET /api/tasks     - Task records, filtered, sorted and paged by cursor
 // - GET /api/metrics   - Task processing metrics
 // - GET /api/gpu       - GPU metrics (with optional history param)
+// - GET /api/gpu/history - Persisted GPU samples over a range, downsampled
 type DashboardAPI struct {
 	store        metrics.MetricsCollector
 	gpuCollector *metrics.GPUCollector
 	defaultLimit int
 	maxLimit     int
 	versionInfo  VersionInfo
+	readiness    *ReadinessTracker
+	watchdog     *watchdog.Watchdog
+	lease        *instancelease.Lease
+	cluster      *cluster.Node
+	streamState  *streamstate.Machine
+	tempFiles    *tempfiles.TempFileManager
+	recovery     *recovery.Summary
+	history      TaskHistory
+	gpuHistory   *metrics.GPUHistory
+}
+
+// TaskHistory provides the persisted task history. Implemented by
+// db.Repository.
+type TaskHistory interface {
+	QueryProcessingHistory(ctx context.Context, q db.ProcessingQuery) ([]db.ProcessingRecord, error)
 }
 
 // VersionInfo contains version metadata for the status endpoint.
@@ -80,6 +109,53 @@ func NewDashboardAPI(store metrics.MetricsCollector, gpuCollector *metrics.GPUCo
 	}
 }
 
+// SetReadiness sets the tracker used to report warmup state in /api/status.
+func (api *DashboardAPI) SetReadiness(tracker *ReadinessTracker) {
+	api.readiness = tracker
+}
+
+// SetWatchdog sets the Canvus health watchdog reported in /api/status.
+func (api *DashboardAPI) SetWatchdog(wd *watchdog.Watchdog) {
+	api.watchdog = wd
+}
+
+// SetInstanceLease sets the canvas lease reported in /api/status.
+func (api *DashboardAPI) SetInstanceLease(lease *instancelease.Lease) {
+	api.lease = lease
+}
+
+// SetCluster sets the cluster node reported in /api/status.
+func (api *DashboardAPI) SetCluster(node *cluster.Node) {
+	api.cluster = node
+}
+
+// SetStreamState sets the widget stream state machine reported in
+// /api/status.
+func (api *DashboardAPI) SetStreamState(m *streamstate.Machine) {
+	api.streamState = m
+}
+
+// SetTempFiles sets the temp file manager whose usage /api/status reports.
+func (api *DashboardAPI) SetTempFiles(m *tempfiles.TempFileManager) {
+	api.tempFiles = m
+}
+
+// SetRecovery sets the startup recovery summary /api/status reports.
+func (api *DashboardAPI) SetRecovery(summary *recovery.Summary) {
+	api.recovery = summary
+}
+
+// SetTaskHistory sets the persisted history /api/tasks reads. Without it
+// /api/tasks lists the tasks held in memory.
+func (api *DashboardAPI) SetTaskHistory(history TaskHistory) {
+	api.history = history
+}
+
+// SetGPUHistory sets the persisted GPU history /api/gpu/history reads.
+func (api *DashboardAPI) SetGPUHistory(history *metrics.GPUHistory) {
+	api.gpuHistory = history
+}
+
 // StatusResponse represents the JSON response for /api/status.
 type StatusResponse struct {
 	Health     string    `json:"health"`
@@ -90,12 +166,34 @@ type StatusResponse struct {
 	UptimeSecs float64   `json:"uptime_secs"`
 	LastCheck  time.Time `json:"last_check"`
 	GPUAvail   bool      `json:"gpu_available"`
+
+	// Ready is false while startup warmup is still running.
+	Ready  bool                 `json:"ready"`
+	Warmup []ComponentReadiness `json:"warmup,omitempty"`
+
+	// Canvus is the Canvus server connection health, if a watchdog is set.
+	Canvus *watchdog.Status `json:"canvus,omitempty"`
+
+	// Lease is this instance's canvas lease, if one is set.
+	Lease *instancelease.Status `json:"lease,omitempty"`
+
+	// Cluster is this instance's view of the cluster, if it joined one.
+	Cluster *cluster.Status `json:"cluster,omitempty"`
+
+	// Monitor is the widget stream state, if a state machine is set.
+	Monitor *streamstate.Status `json:"monitor,omitempty"`
+
+	// TempFiles is the temp file disk usage, if a manager is set.
+	TempFiles *tempfiles.Usage `json:"temp_files,omitempty"`
+
+	// Recovery is what the startup recovery pass found and did, if it ran.
+	Recovery *recovery.Summary `json:"recovery,omitempty"`
 }
 
 // HandleStatus handles GET /api/status requests.
 func (api *DashboardAPI) HandleStatus(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		api.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
@@ -115,9 +213,32 @@ func (api *DashboardAPI) HandleStatus(w http.ResponseWriter, r *http.Request) {
 		UptimeSecs: status.Uptime.Seconds(),
 		LastCheck:  status.LastCheck,
 		GPUAvail:   gpuAvail,
+		Ready:      api.readiness.IsReady(),
+		Warmup:     api.readiness.Components(),
+	}
+	if api.watchdog != nil {
+		canvus := api.watchdog.Status()
+		response.Canvus = &canvus
+	}
+	if api.lease != nil {
+		lease := api.lease.Status()
+		response.Lease = &lease
+	}
+	if api.cluster != nil {
+		status := api.cluster.Status()
+		response.Cluster = &status
+	}
+	if api.streamState != nil {
+		monitor := api.streamState.Status()
+		response.Monitor = &monitor
+	}
+	if api.tempFiles != nil {
+		usage := api.tempFiles.Usage()
+		response.TempFiles = &usage
 	}
+	response.Recovery = api.recovery
 
-	api.writeJSON(w, http.StatusOK, response)
+	writeJSON(w, http.StatusOK, response)
 }
 
 // CanvasesResponse represents the JSON response for /api/canvases.
@@ -129,7 +250,7 @@ type CanvasesResponse struct {
 // HandleCanvases handles GET /api/canvases requests.
 func (api *DashboardAPI) HandleCanvases(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		api.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
@@ -140,45 +261,268 @@ func (api *DashboardAPI) HandleCanvases(w http.ResponseWriter, r *http.Request)
 		Count:    len(canvases),
 	}
 
-	api.writeJSON(w, http.StatusOK, response)
+	writeJSON(w, http.StatusOK, response)
 }
 
+// Task sources reported by /api/tasks.
+const (
+	TaskSourceHistory = "history"
+	TaskSourceMemory  = "memory"
+)
+
+// memoryTaskScan bounds the in-memory tasks /api/tasks filters.
+const memoryTaskScan = 10000
+
 // TasksResponse represents the JSON response for /api/tasks.
 type TasksResponse struct {
 	Tasks []metrics.TaskRecord `json:"tasks"`
 	Count int                  `json:"count"`
 	Limit int                  `json:"limit"`
+	// NextCursor fetches the next page; empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Source is "history" for the persisted task history or "memory"
+	Source string `json:"source"`
+}
+
+// taskQuery is a parsed /api/tasks request.
+type taskQuery struct {
+	db.ProcessingQuery
+	offset int
+}
+
+// taskCursor is the decoded form of a next_cursor. It records the sort it
+// was issued for, so it cannot be replayed against another order.
+type taskCursor struct {
+	Source string               `json:"src"`
+	Sort   string               `json:"sort"`
+	Asc    bool                 `json:"asc,omitempty"`
+	After  *db.ProcessingCursor `json:"after,omitempty"`
+	Offset int                  `json:"offset,omitempty"`
 }
 
-// HandleTasks handles GET /api/tasks requests.
+// HandleTasks handles GET /api/tasks requests. Tasks come from the
+// persisted task history when it is set, otherwise from memory.
 // Query parameters:
 // - limit: number of tasks to return (default: 20, max: 100)
+// - source: "history" (default when set) or "memory" for the live tasks
+// - status: only tasks with this status ("success", "error", ...)
+// - type: only tasks of this type ("text_generation" in the history, "note" in memory)
+// - canvas_id: only tasks of this canvas
+// - since, until: RFC 3339 time range [since, until); a duration such as 24h means that long ago
+// - sort: "created_at" (default) or "duration"
+// - order: "desc" (default) or "asc"
+// - cursor: next_cursor of the previous page
 func (api *DashboardAPI) HandleTasks(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		api.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	source := TaskSourceMemory
+	if api.history != nil {
+		source = TaskSourceHistory
+	}
+	switch requested := r.URL.Query().Get("source"); requested {
+	case "":
+	case TaskSourceMemory:
+		source = TaskSourceMemory
+	case TaskSourceHistory:
+		if api.history == nil {
+			writeError(w, http.StatusNotFound, "task history is not available")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, `source must be "history" or "memory"`)
+		return
+	}
+	q, err := api.parseTaskQuery(r.URL.Query(), source)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	limit := api.defaultLimit
-	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+	var tasks []metrics.TaskRecord
+	var next *taskCursor
+	if source == TaskSourceHistory {
+		tasks, next, err = api.historyTasks(r.Context(), q)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to query task history")
+			return
+		}
+	} else {
+		tasks, next = api.memoryTasks(q)
+	}
+
+	response := TasksResponse{
+		Tasks:  tasks,
+		Count:  len(tasks),
+		Limit:  q.Limit,
+		Source: source,
+	}
+	if next != nil {
+		next.Source, next.Sort, next.Asc = source, q.SortBy, q.Ascending
+		response.NextCursor = encodeTaskCursor(*next)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// parseTaskQuery reads the /api/tasks query parameters.
+func (api *DashboardAPI) parseTaskQuery(values url.Values, source string) (taskQuery, error) {
+	q := taskQuery{ProcessingQuery: db.ProcessingQuery{
+		CanvasID:      values.Get("canvas_id"),
+		OperationType: values.Get("type"),
+		Status:        values.Get("status"),
+		SortBy:        db.SortByCreatedAt,
+		Limit:         api.defaultLimit,
+	}}
+	if limitStr := values.Get("limit"); limitStr != "" {
 		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
-			limit = parsed
+			q.Limit = parsed
 		}
 	}
+	if q.Limit > api.maxLimit {
+		q.Limit = api.maxLimit
+	}
 
-	if limit > api.maxLimit {
-		limit = api.maxLimit
+	switch sortBy := values.Get("sort"); sortBy {
+	case "", db.SortByCreatedAt:
+	case db.SortByDuration:
+		q.SortBy = db.SortByDuration
+	default:
+		return q, fmt.Errorf("sort must be %q or %q", db.SortByCreatedAt, db.SortByDuration)
+	}
+	switch order := values.Get("order"); order {
+	case "", "desc":
+	case "asc":
+		q.Ascending = true
+	default:
+		return q, fmt.Errorf(`order must be "asc" or "desc"`)
 	}
 
-	tasks := api.store.GetRecentTasks(limit)
+	var err error
+	if q.Since, err = parseTaskTime(values.Get("since")); err != nil {
+		return q, fmt.Errorf("since: %w", err)
+	}
+	if q.Until, err = parseTaskTime(values.Get("until")); err != nil {
+		return q, fmt.Errorf("until: %w", err)
+	}
 
-	response := TasksResponse{
-		Tasks: tasks,
-		Count: len(tasks),
-		Limit: limit,
+	if raw := values.Get("cursor"); raw != "" {
+		c, err := decodeTaskCursor(raw)
+		if err != nil || c.Source != source || c.Sort != q.SortBy || c.Asc != q.Ascending {
+			return q, fmt.Errorf("invalid cursor for this sort order")
+		}
+		q.After, q.offset = c.After, c.Offset
 	}
+	return q, nil
+}
 
-	api.writeJSON(w, http.StatusOK, response)
+// parseTaskTime parses an RFC 3339 time, or a duration meaning that long ago.
+func parseTaskTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(strings.TrimPrefix(s, "-")); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not an RFC 3339 time or a duration", s)
+}
+
+// historyTasks reads a page of the persisted task history. One extra
+// record is read to tell whether another page follows.
+func (api *DashboardAPI) historyTasks(ctx context.Context, q taskQuery) ([]metrics.TaskRecord, *taskCursor, error) {
+	pq := q.ProcessingQuery
+	pq.Limit++
+	records, err := api.history.QueryProcessingHistory(ctx, pq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next *taskCursor
+	if len(records) > q.Limit {
+		records = records[:q.Limit]
+		after := db.CursorFor(records[len(records)-1])
+		next = &taskCursor{After: &after}
+	}
+	tasks := make([]metrics.TaskRecord, len(records))
+	for i, rec := range records {
+		duration := time.Duration(rec.DurationMS) * time.Millisecond
+		tasks[i] = metrics.TaskRecord{
+			ID:        strconv.FormatInt(rec.ID, 10),
+			Type:      rec.OperationType,
+			CanvasID:  rec.CanvasID,
+			Status:    rec.Status,
+			StartTime: rec.CreatedAt.Add(-duration),
+			EndTime:   rec.CreatedAt,
+			Duration:  duration,
+			ErrorMsg:  rec.ErrorMessage,
+		}
+	}
+	return tasks, next, nil
+}
+
+// memoryTasks filters, sorts and pages the tasks held in memory. Pages are
+// by offset, since the in-memory tasks have no stable key.
+func (api *DashboardAPI) memoryTasks(q taskQuery) ([]metrics.TaskRecord, *taskCursor) {
+	var tasks []metrics.TaskRecord
+	for _, t := range api.store.GetRecentTasks(memoryTaskScan) {
+		switch {
+		case q.CanvasID != "" && t.CanvasID != q.CanvasID,
+			q.OperationType != "" && t.Type != q.OperationType,
+			q.Status != "" && t.Status != q.Status,
+			!q.Since.IsZero() && t.StartTime.Before(q.Since),
+			!q.Until.IsZero() && !t.StartTime.Before(q.Until):
+			continue
+		}
+		tasks = append(tasks, t)
+	}
+
+	// The store returns tasks oldest first; order them newest first, then
+	// by duration when asked, keeping newer tasks first among equals
+	for i, j := 0, len(tasks)-1; i < j; i, j = i+1, j-1 {
+		tasks[i], tasks[j] = tasks[j], tasks[i]
+	}
+	switch {
+	case q.SortBy == db.SortByDuration:
+		sort.SliceStable(tasks, func(i, j int) bool {
+			if q.Ascending {
+				return tasks[i].Duration < tasks[j].Duration
+			}
+			return tasks[i].Duration > tasks[j].Duration
+		})
+	case q.Ascending:
+		for i, j := 0, len(tasks)-1; i < j; i, j = i+1, j-1 {
+			tasks[i], tasks[j] = tasks[j], tasks[i]
+		}
+	}
+
+	if q.offset >= len(tasks) {
+		return []metrics.TaskRecord{}, nil
+	}
+	tasks = tasks[q.offset:]
+	if len(tasks) > q.Limit {
+		return tasks[:q.Limit], &taskCursor{Offset: q.offset + q.Limit}
+	}
+	return tasks, nil
+}
+
+func encodeTaskCursor(c taskCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTaskCursor(s string) (taskCursor, error) {
+	var c taskCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
 }
 
 // MetricsResponse represents the JSON response for /api/metrics.
@@ -188,12 +532,14 @@ type MetricsResponse struct {
 	TotalErrors    int64                               `json:"total_errors"`
 	SuccessRate    float64                             `json:"success_rate"`
 	ByType         map[string]*metrics.TaskTypeMetrics `json:"by_type"`
+	Filtered       metrics.FilterCounts                `json:"filtered"`
+	JSONRepair     metrics.RepairCounts                `json:"json_repair"`
 }
 
 // HandleMetrics handles GET /api/metrics requests.
 func (api *DashboardAPI) HandleMetrics(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		api.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
@@ -210,9 +556,11 @@ func (api *DashboardAPI) HandleMetrics(w http.ResponseWriter, r *http.Request) {
 		TotalErrors:    taskMetrics.TotalErrors,
 		SuccessRate:    successRate,
 		ByType:         taskMetrics.ByType,
+		Filtered:       taskMetrics.Filtered,
+		JSONRepair:     taskMetrics.JSONRepair,
 	}
 
-	api.writeJSON(w, http.StatusOK, response)
+	writeJSON(w, http.StatusOK, response)
 }
 
 // GPUResponse represents the JSON response for /api/gpu.
@@ -229,7 +577,7 @@ type GPUResponse struct {
 // - history: number of historical samples to include (default: 0)
 func (api *DashboardAPI) HandleGPU(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		api.writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	}
 
@@ -238,7 +586,7 @@ func (api *DashboardAPI) HandleGPU(w http.ResponseWriter, r *http.Request) {
 			Available: false,
 			Error:     "GPU monitoring not configured",
 		}
-		api.writeJSON(w, http.StatusOK, response)
+		writeJSON(w, http.StatusOK, response)
 		return
 	}
 
@@ -266,42 +614,99 @@ func (api *DashboardAPI) HandleGPU(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	api.writeJSON(w, http.StatusOK, response)
+	writeJSON(w, http.StatusOK, response)
 }
 
-// RegisterRoutes registers all API routes on the given ServeMux.
-func (api *DashboardAPI) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("/api/status", api.HandleStatus)
-	mux.HandleFunc("/api/canvases", api.HandleCanvases)
-	mux.HandleFunc("/api/tasks", api.HandleTasks)
-	mux.HandleFunc("/api/metrics", api.HandleMetrics)
-	mux.HandleFunc("/api/gpu", api.HandleGPU)
-}
+// defaultGPUHistoryRange is the /api/gpu/history range when none is given.
+const defaultGPUHistoryRange = time.Hour
 
-// ErrorResponse represents an error response.
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+// GPUHistoryResponse represents the JSON response for /api/gpu/history.
+type GPUHistoryResponse struct {
+	Range string `json:"range"`
+	// Step is the length of each sample: "1m", "5m" or "1h"
+	Step    string              `json:"step"`
+	Samples []metrics.GPUSample `json:"samples"`
+	Count   int                 `json:"count"`
 }
 
-// writeJSON writes a JSON response with the given status code.
-func (api *DashboardAPI) writeJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
+// HandleGPUHistory handles GET /api/gpu/history requests.
+// Query parameters:
+// - range: how far back to go, such as 6h, 24h or 7d (default: 1h, max: 90d)
+//
+// The step is the finest that covers the range: 1 minute up to a day,
+// 5 minutes up to a week, 1 hour beyond.
+func (api *DashboardAPI) HandleGPUHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if api.gpuHistory == nil {
+		writeError(w, http.StatusNotFound, "GPU history is not available")
+		return
+	}
 
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		// Best effort - headers already written
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	span := defaultGPUHistoryRange
+	if rangeStr := r.URL.Query().Get("range"); rangeStr != "" {
+		parsed, err := parseHistoryRange(rangeStr)
+		if err != nil || parsed <= 0 {
+			writeError(w, http.StatusBadRequest, "range must be a duration such as 24h or 7d")
+			return
+		}
+		span = parsed
 	}
+	step, ok := metrics.ResolutionFor(span)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "range exceeds the GPU history retention")
+		return
+	}
+
+	samples, err := api.gpuHistory.Query(r.Context(), span, step)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query GPU history")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, GPUHistoryResponse{
+		Range:   formatHistoryStep(span),
+		Step:    formatHistoryStep(step),
+		Samples: samples,
+		Count:   len(samples),
+	})
+}
+
+// parseHistoryRange parses a duration, also accepting whole days ("7d").
+func parseHistoryRange(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
 }
 
-// writeError writes an error response.
-func (api *DashboardAPI) writeError(w http.ResponseWriter, status int, message string) {
-	response := ErrorResponse{
-		Error:   http.StatusText(status),
-		Message: message,
+// formatHistoryStep formats d compactly: "5m", "24h", "7d".
+func formatHistoryStep(d time.Duration) string {
+	switch {
+	case d >= 24*time.Hour && d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d >= time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
 	}
-	api.writeJSON(w, status, response)
+	return d.String()
+}
+
+// RegisterRoutes registers all API routes on the given ServeMux.
+func (api *DashboardAPI) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/status", api.HandleStatus)
+	mux.HandleFunc("/api/canvases", api.HandleCanvases)
+	mux.HandleFunc("/api/tasks", api.HandleTasks)
+	mux.HandleFunc("/api/metrics", api.HandleMetrics)
+	mux.HandleFunc("/api/gpu", api.HandleGPU)
+	mux.HandleFunc("/api/gpu/history", api.HandleGPUHistory)
 }
 
 // formatDuration formats a duration into a human-readable string.
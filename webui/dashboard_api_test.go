@@ -1,14 +1,21 @@
 package webui
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"go_backend/db"
 	"go_backend/metrics"
+	"go_backend/recovery"
+	"go_backend/streamstate"
+	"go_backend/tempfiles"
+	"go_backend/watchdog"
 )
 
 // mockMetricsCollector is a test implementation of MetricsCollector.
@@ -238,6 +245,113 @@ func TestHandleStatus(t *testing.T) {
 			t.Error("expected GPU to be available")
 		}
 	})
+
+	t.Run("includes Canvus health when watchdog set", func(t *testing.T) {
+		mock := newMockMetricsCollector()
+		api := NewDashboardAPI(mock, nil, DefaultDashboardAPIConfig())
+
+		wd := watchdog.New(watchdog.Config{Server: "http://127.0.0.1:8090", FailureThreshold: 1, PauseProcessing: true})
+		wd.RecordFailure(errors.New("dial tcp 127.0.0.1:8090: connect: connection refused"))
+		api.SetWatchdog(wd)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+		w := httptest.NewRecorder()
+
+		api.HandleStatus(w, req)
+
+		var response StatusResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+
+		if response.Canvus == nil {
+			t.Fatal("expected canvus health in response")
+		}
+		if response.Canvus.State != watchdog.StateDown || !response.Canvus.ProcessingPaused {
+			t.Errorf("expected down and paused, got %+v", response.Canvus)
+		}
+		if response.Canvus.Hint == "" {
+			t.Error("expected a hint for the outage")
+		}
+	})
+
+	t.Run("includes widget stream state when set", func(t *testing.T) {
+		api := NewDashboardAPI(newMockMetricsCollector(), nil, DefaultDashboardAPIConfig())
+		state := streamstate.New()
+		state.Transition(streamstate.StateConnecting, "")
+		state.Transition(streamstate.StateReconnecting, "connection refused")
+		api.SetStreamState(state)
+
+		w := httptest.NewRecorder()
+		api.HandleStatus(w, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+
+		var response StatusResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if response.Monitor == nil || response.Monitor.State != streamstate.StateReconnecting ||
+			response.Monitor.Reason != "connection refused" || len(response.Monitor.Recent) != 2 {
+			t.Errorf("monitor = %+v", response.Monitor)
+		}
+	})
+
+	t.Run("includes temp file usage when manager set", func(t *testing.T) {
+		api := NewDashboardAPI(newMockMetricsCollector(), nil, DefaultDashboardAPIConfig())
+		m, err := tempfiles.NewTempFileManager(tempfiles.Config{Dir: t.TempDir(), QuotaBytes: 1024}, nil)
+		if err != nil {
+			t.Fatalf("NewTempFileManager failed: %v", err)
+		}
+		f, _ := m.StoreBytes([]byte("image"), ".png")
+		defer f.Release()
+		api.SetTempFiles(m)
+
+		w := httptest.NewRecorder()
+		api.HandleStatus(w, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+
+		var response StatusResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if response.TempFiles == nil || response.TempFiles.Files != 1 || response.TempFiles.Bytes != 5 || response.TempFiles.QuotaBytes != 1024 {
+			t.Errorf("temp files = %+v", response.TempFiles)
+		}
+	})
+
+	t.Run("includes startup recovery summary when set", func(t *testing.T) {
+		api := NewDashboardAPI(newMockMetricsCollector(), nil, DefaultDashboardAPIConfig())
+		api.SetRecovery(&recovery.Summary{Unclean: true, Requeued: 2, Failed: 1, Tasks: []recovery.Outcome{
+			{Entry: recovery.Entry{TaskID: "t1", Attempts: 2}, Action: recovery.ActionFailed, Reason: "interrupted 2 times"},
+		}})
+
+		w := httptest.NewRecorder()
+		api.HandleStatus(w, httptest.NewRequest(http.MethodGet, "/api/status", nil))
+
+		var response StatusResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if r := response.Recovery; r == nil || !r.Unclean || r.Requeued != 2 || r.Failed != 1 ||
+			len(r.Tasks) != 1 || r.Tasks[0].Action != recovery.ActionFailed || r.Tasks[0].Attempts != 2 {
+			t.Errorf("recovery = %+v", response.Recovery)
+		}
+	})
+
+	t.Run("omits Canvus health without watchdog", func(t *testing.T) {
+		api := NewDashboardAPI(newMockMetricsCollector(), nil, DefaultDashboardAPIConfig())
+
+		req := httptest.NewRequest(http.MethodGet, "/api/status", nil)
+		w := httptest.NewRecorder()
+
+		api.HandleStatus(w, req)
+
+		var response StatusResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if response.Canvus != nil {
+			t.Errorf("expected no canvus health, got %+v", response.Canvus)
+		}
+	})
 }
 
 func TestHandleCanvases(t *testing.T) {
@@ -379,6 +493,151 @@ func TestHandleTasks(t *testing.T) {
 	})
 }
 
+// fakeTaskHistory records the last query and returns fixed records.
+type fakeTaskHistory struct {
+	query   db.ProcessingQuery
+	records []db.ProcessingRecord
+}
+
+func (h *fakeTaskHistory) QueryProcessingHistory(ctx context.Context, q db.ProcessingQuery) ([]db.ProcessingRecord, error) {
+	h.query = q
+	if len(h.records) > q.Limit {
+		return h.records[:q.Limit], nil
+	}
+	return h.records, nil
+}
+
+// getTasks requests path from api and decodes the response.
+func getTasks(t *testing.T, api *DashboardAPI, path string) (int, TasksResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	api.HandleTasks(w, httptest.NewRequest(http.MethodGet, path, nil))
+	var response TasksResponse
+	if w.Code == http.StatusOK {
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+	}
+	return w.Code, response
+}
+
+func taskIDs(tasks []metrics.TaskRecord) []string {
+	ids := make([]string, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.ID
+	}
+	return ids
+}
+
+func TestHandleTasksFilters(t *testing.T) {
+	mock := newMockMetricsCollector()
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	mock.taskRecords = []metrics.TaskRecord{
+		{ID: "t1", Type: metrics.TaskTypeNote, CanvasID: "a", Status: metrics.TaskStatusSuccess, StartTime: base, Duration: 300 * time.Millisecond},
+		{ID: "t2", Type: metrics.TaskTypePDF, CanvasID: "b", Status: metrics.TaskStatusError, StartTime: base.Add(time.Minute), Duration: 900 * time.Millisecond},
+		{ID: "t3", Type: metrics.TaskTypeNote, CanvasID: "a", Status: metrics.TaskStatusSuccess, StartTime: base.Add(2 * time.Minute), Duration: 100 * time.Millisecond},
+		{ID: "t4", Type: metrics.TaskTypeNote, CanvasID: "b", Status: metrics.TaskStatusSuccess, StartTime: base.Add(3 * time.Minute), Duration: 500 * time.Millisecond},
+	}
+	api := NewDashboardAPI(mock, nil, DefaultDashboardAPIConfig())
+
+	t.Run("filters and sorts tasks in memory", func(t *testing.T) {
+		for path, want := range map[string]string{
+			"/api/tasks":                            "[t4 t3 t2 t1]",
+			"/api/tasks?canvas_id=a":                "[t3 t1]",
+			"/api/tasks?type=note&status=success":   "[t4 t3 t1]",
+			"/api/tasks?status=error":               "[t2]",
+			"/api/tasks?since=2026-01-01T12:01:00Z": "[t4 t3 t2]",
+			"/api/tasks?until=2026-01-01T12:02:00Z": "[t2 t1]",
+			"/api/tasks?order=asc":                  "[t1 t2 t3 t4]",
+			"/api/tasks?sort=duration":              "[t2 t4 t1 t3]",
+			"/api/tasks?sort=duration&order=asc":    "[t3 t1 t4 t2]",
+			"/api/tasks?sort=duration&canvas_id=b":  "[t2 t4]",
+		} {
+			code, response := getTasks(t, api, path)
+			if code != http.StatusOK || fmt.Sprint(taskIDs(response.Tasks)) != want {
+				t.Errorf("%s: status = %d, tasks = %v, want %s", path, code, taskIDs(response.Tasks), want)
+			}
+			if response.Source != TaskSourceMemory {
+				t.Errorf("%s: source = %q", path, response.Source)
+			}
+		}
+	})
+
+	t.Run("pages with the cursor", func(t *testing.T) {
+		var pages []string
+		path := "/api/tasks?sort=duration&limit=3"
+		for {
+			code, response := getTasks(t, api, path)
+			if code != http.StatusOK {
+				t.Fatalf("%s: status = %d", path, code)
+			}
+			pages = append(pages, fmt.Sprint(taskIDs(response.Tasks)))
+			if response.NextCursor == "" {
+				break
+			}
+			path = "/api/tasks?sort=duration&limit=3&cursor=" + response.NextCursor
+		}
+		if fmt.Sprint(pages) != "[[t2 t4 t1] [t3]]" {
+			t.Errorf("pages = %v", pages)
+		}
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		_, first := getTasks(t, api, "/api/tasks?limit=1")
+		for path, want := range map[string]int{
+			"/api/tasks?sort=prompt":                              http.StatusBadRequest,
+			"/api/tasks?order=up":                                 http.StatusBadRequest,
+			"/api/tasks?since=yesterday":                          http.StatusBadRequest,
+			"/api/tasks?source=disk":                              http.StatusBadRequest,
+			"/api/tasks?cursor=bm90LWpzb24":                       http.StatusBadRequest,
+			"/api/tasks?sort=duration&cursor=" + first.NextCursor: http.StatusBadRequest,
+			"/api/tasks?source=history":                           http.StatusNotFound,
+		} {
+			if code, _ := getTasks(t, api, path); code != want {
+				t.Errorf("%s: status = %d, want %d", path, code, want)
+			}
+		}
+	})
+}
+
+func TestHandleTasksHistory(t *testing.T) {
+	created := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	history := &fakeTaskHistory{records: []db.ProcessingRecord{
+		{ID: 7, CanvasID: "a", OperationType: "text_generation", Status: "success", DurationMS: 1500, CreatedAt: created},
+		{ID: 5, CanvasID: "a", OperationType: "text_generation", Status: "error", DurationMS: 200, ErrorMessage: "timeout", CreatedAt: created.Add(-time.Minute)},
+		{ID: 2, CanvasID: "a", OperationType: "pdf_analysis", Status: "success", DurationMS: 900, CreatedAt: created.Add(-time.Hour)},
+	}}
+	api := NewDashboardAPI(newMockMetricsCollector(), nil, DefaultDashboardAPIConfig())
+	api.SetTaskHistory(history)
+
+	code, response := getTasks(t, api, "/api/tasks?limit=2&canvas_id=a&type=text_generation&since=2026-01-01T00:00:00Z")
+	if code != http.StatusOK || response.Source != TaskSourceHistory || fmt.Sprint(taskIDs(response.Tasks)) != "[7 5]" {
+		t.Fatalf("status = %d, response = %+v", code, response)
+	}
+	task := response.Tasks[0]
+	if task.Type != "text_generation" || task.Duration != 1500*time.Millisecond ||
+		!task.EndTime.Equal(created) || !task.StartTime.Equal(created.Add(-1500*time.Millisecond)) {
+		t.Errorf("task = %+v", task)
+	}
+	q := history.query
+	if q.CanvasID != "a" || q.OperationType != "text_generation" || !q.Since.Equal(created.Add(-12*time.Hour)) || q.Limit != 3 || q.After != nil {
+		t.Errorf("query = %+v", q)
+	}
+	if response.NextCursor == "" {
+		t.Fatal("expected a next cursor")
+	}
+
+	getTasks(t, api, "/api/tasks?limit=2&cursor="+response.NextCursor)
+	if after := history.query.After; after == nil || after.ID != 5 || !after.CreatedAt.Equal(created.Add(-time.Minute)) {
+		t.Errorf("cursor = %+v", after)
+	}
+
+	// The live tasks remain available
+	if _, response := getTasks(t, api, "/api/tasks?source=memory"); response.Source != TaskSourceMemory || response.Count != 2 {
+		t.Errorf("memory response = %+v", response)
+	}
+}
+
 func TestHandleMetrics(t *testing.T) {
 	t.Run("returns task metrics", func(t *testing.T) {
 		mock := newMockMetricsCollector()
@@ -587,6 +846,75 @@ func TestHandleGPU(t *testing.T) {
 	})
 }
 
+// fakeGPUStorage returns a fixed set of samples for any step.
+type fakeGPUStorage struct {
+	step    time.Duration
+	samples []metrics.GPUSample
+}
+
+func (f *fakeGPUStorage) SaveGPUSamples(ctx context.Context, samples []metrics.GPUSample) error {
+	return nil
+}
+
+func (f *fakeGPUStorage) RollupGPUSamples(ctx context.Context, from, to time.Duration, since time.Time) error {
+	return nil
+}
+
+func (f *fakeGPUStorage) DeleteGPUSamples(ctx context.Context, step time.Duration, before time.Time) error {
+	return nil
+}
+
+func (f *fakeGPUStorage) QueryGPUSamples(ctx context.Context, step time.Duration, since, until time.Time) ([]metrics.GPUSample, error) {
+	f.step = step
+	return f.samples, nil
+}
+
+func TestHandleGPUHistory(t *testing.T) {
+	api := NewDashboardAPI(newMockMetricsCollector(), nil, DefaultDashboardAPIConfig())
+
+	w := httptest.NewRecorder()
+	api.HandleGPUHistory(w, httptest.NewRequest(http.MethodGet, "/api/gpu/history", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("without history: status = %d, want 404", w.Code)
+	}
+
+	storage := &fakeGPUStorage{samples: []metrics.GPUSample{
+		{Time: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), Samples: 60, Utilization: 42, MemoryUsed: 10, MemoryTotal: 100},
+	}}
+	api.SetGPUHistory(metrics.NewGPUHistory(storage))
+
+	for query, want := range map[string]struct {
+		rangeStr, step string
+		stepDur        time.Duration
+	}{
+		"":            {"1h", "1m", time.Minute},
+		"?range=24h":  {"1d", "1m", time.Minute},
+		"?range=7d":   {"7d", "5m", 5 * time.Minute},
+		"?range=720h": {"30d", "1h", time.Hour},
+	} {
+		w := httptest.NewRecorder()
+		api.HandleGPUHistory(w, httptest.NewRequest(http.MethodGet, "/api/gpu/history"+query, nil))
+		var response GPUHistoryResponse
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("%q: failed to decode response: %v", query, err)
+		}
+		if w.Code != http.StatusOK || response.Range != want.rangeStr || response.Step != want.step || storage.step != want.stepDur {
+			t.Errorf("%q: status = %d, range = %s, step = %s (%v)", query, w.Code, response.Range, response.Step, storage.step)
+		}
+		if response.Count != 1 || response.Samples[0].Utilization != 42 {
+			t.Errorf("%q: samples = %+v", query, response.Samples)
+		}
+	}
+
+	for _, query := range []string{"?range=soon", "?range=-1h", "?range=365d"} {
+		w := httptest.NewRecorder()
+		api.HandleGPUHistory(w, httptest.NewRequest(http.MethodGet, "/api/gpu/history"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%q: status = %d, want 400", query, w.Code)
+		}
+	}
+}
+
 func TestRegisterRoutes(t *testing.T) {
 	mock := newMockMetricsCollector()
 	api := NewDashboardAPI(mock, nil, DefaultDashboardAPIConfig())
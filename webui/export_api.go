@@ -0,0 +1,107 @@
+// Package webui provides the ExportAPI organism for canvas exports. This
+// file contains the REST handler that downloads a canvas, or one of its
+// zones, as a Markdown or HTML document or a PowerPoint deck.
+package webui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go_backend/canvasexport"
+
+	"go.uber.org/zap"
+)
+
+// ExportAPI is an organism that exports canvases as documents.
+//
+// Endpoints (requires authentication when auth is enabled):
+// - GET /api/export/canvas - Download a canvas as a document (?canvas_id=X&format=markdown|html|pptx&zone=NAME)
+type ExportAPI struct {
+	exporterFor func(canvasID string) *canvasexport.Exporter
+	canvasIDs   []string
+	logger      *zap.Logger
+}
+
+// NewExportAPI creates an ExportAPI. canvasIDs are the monitored canvases,
+// the only ones that can be exported; the first is exported when no
+// canvas_id is given. exporterFor returns an exporter reading a canvas.
+func NewExportAPI(exporterFor func(canvasID string) *canvasexport.Exporter, canvasIDs []string, logger *zap.Logger) *ExportAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &ExportAPI{exporterFor: exporterFor, canvasIDs: canvasIDs, logger: logger}
+}
+
+// HandleCanvas handles GET /api/export/canvas requests.
+func (api *ExportAPI) HandleCanvas(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	query := r.URL.Query()
+
+	format, err := canvasexport.ParseFormat(query.Get("format"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	canvasID := query.Get("canvas_id")
+	if canvasID == "" && len(api.canvasIDs) > 0 {
+		canvasID = api.canvasIDs[0]
+	}
+	if !api.monitored(canvasID) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("canvas %q is not monitored", canvasID))
+		return
+	}
+
+	doc, err := api.exporterFor(canvasID).Export(r.Context(), canvasexport.Options{Zone: query.Get("zone")})
+	if err != nil {
+		if errors.Is(err, canvasexport.ErrZoneNotFound) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		api.logger.Error("Canvas export failed", zap.String("canvas_id", canvasID), zap.Error(err))
+		writeError(w, http.StatusBadGateway, "canvas export failed: "+err.Error())
+		return
+	}
+	data, err := canvasexport.Render(doc, format)
+	if err != nil {
+		api.logger.Error("Canvas export failed", zap.String("canvas_id", canvasID), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to render export")
+		return
+	}
+
+	api.logger.Info("Canvas exported",
+		zap.String("canvas_id", canvasID),
+		zap.String("zone", doc.Zone),
+		zap.String("format", string(format)),
+		zap.Int("sections", len(doc.Sections)))
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", canvasexport.FileName(doc, format)))
+	if _, err := w.Write(data); err != nil {
+		api.logger.Debug("Export download interrupted", zap.Error(err))
+	}
+}
+
+// RegisterRoutes registers the export route on mux. If protect is non-nil
+// it wraps the handler, since exports hold canvas content.
+func (api *ExportAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	handler := api.HandleCanvas
+	if protect != nil {
+		handler = protect(handler)
+	}
+	mux.HandleFunc("/api/export/canvas", handler)
+}
+
+// monitored reports whether canvasID is one of the monitored canvases.
+func (api *ExportAPI) monitored(canvasID string) bool {
+	for _, id := range api.canvasIDs {
+		if id == canvasID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package webui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_backend/canvasexport"
+)
+
+// exportCanvas serves a canvas with one note in a zone
+type exportCanvas struct{}
+
+func (exportCanvas) GetCanvasInfo() (map[string]interface{}, error) {
+	return map[string]interface{}{"name": "Retro"}, nil
+}
+
+func (exportCanvas) GetWidgets(subscribe bool) ([]map[string]interface{}, error) {
+	return []map[string]interface{}{
+		{"id": "zone", "widget_type": "Anchor", "anchor_name": "Wins",
+			"location": map[string]interface{}{"x": 0.0, "y": 0.0},
+			"size":     map[string]interface{}{"width": 500.0, "height": 500.0}},
+		{"id": "n1", "widget_type": "Note", "text": "Shipped the importer",
+			"location": map[string]interface{}{"x": 10.0, "y": 10.0},
+			"size":     map[string]interface{}{"width": 100.0, "height": 100.0}},
+	}, nil
+}
+
+func (exportCanvas) DownloadImage(imageID, localPath string) error {
+	return nil
+}
+
+func newTestExportMux(t *testing.T) (*http.ServeMux, *string) {
+	t.Helper()
+	exported := new(string)
+	exporterFor := func(canvasID string) *canvasexport.Exporter {
+		*exported = canvasID
+		return canvasexport.NewExporter(exportCanvas{}, t.TempDir(), nil)
+	}
+	mux := http.NewServeMux()
+	NewExportAPI(exporterFor, []string{"canvas-1", "canvas-2"}, nil).RegisterRoutes(mux, nil)
+	return mux, exported
+}
+
+func TestExportAPICanvas(t *testing.T) {
+	mux, exported := newTestExportMux(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/export/canvas", nil))
+	if rec.Code != http.StatusOK || *exported != "canvas-1" {
+		t.Fatalf("status = %d, exported %q: %s", rec.Code, *exported, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/markdown") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.Contains(rec.Header().Get("Content-Disposition"), "retro-") || !strings.Contains(rec.Body.String(), "## Shipped the importer") {
+		t.Errorf("unexpected export: %s %s", rec.Header().Get("Content-Disposition"), rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/export/canvas?canvas_id=canvas-2&format=html&zone=wins", nil))
+	if rec.Code != http.StatusOK || *exported != "canvas-2" || !strings.Contains(rec.Body.String(), "<title>Retro – Wins</title>") {
+		t.Errorf("html export status = %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestExportAPIErrors(t *testing.T) {
+	mux, _ := newTestExportMux(t)
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"?format=pdf", http.StatusBadRequest},
+		{"?canvas_id=other", http.StatusNotFound},
+		{"?zone=missing", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/export/canvas"+tt.query, nil))
+		if rec.Code != tt.want {
+			t.Errorf("%s status = %d, want %d", tt.query, rec.Code, tt.want)
+		}
+	}
+}
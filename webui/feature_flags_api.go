@@ -0,0 +1,128 @@
+// Package webui provides the FeatureFlagsAPI organism for experimental
+// features. This file contains the REST handlers that show each flag's
+// state and turn flags on or off globally or per canvas.
+package webui
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go_backend/featureflags"
+
+	"go.uber.org/zap"
+)
+
+// FeatureFlagsAPI is an organism that edits feature flag overrides.
+//
+// Endpoints:
+// - GET    /api/feature-flags?canvas_id=X          - Flag states for a canvas (omit canvas_id for all canvases)
+// - PUT    /api/feature-flags                      - Save the override in the JSON body (requires auth)
+// - DELETE /api/feature-flags?flag=F&canvas_id=X   - Remove an override (requires auth)
+type FeatureFlagsAPI struct {
+	registry  *featureflags.Registry
+	canvasIDs []string
+	logger    *zap.Logger
+}
+
+// FeatureFlagsResponse is the response of GET /api/feature-flags.
+type FeatureFlagsResponse struct {
+	CanvasID string               `json:"canvas_id"`
+	Canvases []string             `json:"canvases"`
+	Flags    []featureflags.State `json:"flags"`
+}
+
+// NewFeatureFlagsAPI creates a FeatureFlagsAPI. canvasIDs are the
+// monitored canvases offered for per-canvas overrides.
+func NewFeatureFlagsAPI(registry *featureflags.Registry, canvasIDs []string, logger *zap.Logger) *FeatureFlagsAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &FeatureFlagsAPI{registry: registry, canvasIDs: canvasIDs, logger: logger}
+}
+
+// HandleGet handles GET /api/feature-flags requests.
+func (api *FeatureFlagsAPI) HandleGet(w http.ResponseWriter, r *http.Request) {
+	canvasID := r.URL.Query().Get("canvas_id")
+	writeJSON(w, http.StatusOK, FeatureFlagsResponse{
+		CanvasID: canvasID,
+		Canvases: api.canvasIDs,
+		Flags:    api.registry.States(canvasID),
+	})
+}
+
+// HandlePut handles PUT /api/feature-flags requests.
+func (api *FeatureFlagsAPI) HandlePut(w http.ResponseWriter, r *http.Request) {
+	var override featureflags.Override
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&override); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid override: "+err.Error())
+		return
+	}
+
+	saved, err := api.registry.Set(r.Context(), override)
+	if err != nil {
+		if errors.Is(err, featureflags.ErrUnknownFlag) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		api.logger.Error("Failed to save feature flag override", zap.String("flag", override.Flag), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to save feature flag override")
+		return
+	}
+
+	api.logger.Info("Feature flag override saved",
+		zap.String("flag", saved.Flag),
+		zap.String("canvas_id", saved.CanvasID),
+		zap.Bool("enabled", saved.Enabled),
+	)
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// HandleDelete handles DELETE /api/feature-flags?flag=F&canvas_id=X requests.
+func (api *FeatureFlagsAPI) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	flag := r.URL.Query().Get("flag")
+	canvasID := r.URL.Query().Get("canvas_id")
+	if flag == "" {
+		writeError(w, http.StatusBadRequest, "flag is required")
+		return
+	}
+	if err := api.registry.Clear(r.Context(), flag, canvasID); err != nil {
+		api.logger.Error("Failed to delete feature flag override", zap.String("flag", flag), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to delete feature flag override")
+		return
+	}
+
+	api.logger.Info("Feature flag override removed", zap.String("flag", flag), zap.String("canvas_id", canvasID))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegisterRoutes registers the feature flag route on mux. If protect is
+// non-nil it wraps the handlers that change overrides.
+func (api *FeatureFlagsAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	put := api.HandlePut
+	del := api.HandleDelete
+	if protect != nil {
+		put = protect(put)
+		del = protect(del)
+	}
+	mux.HandleFunc("/api/feature-flags", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			api.HandleGet(w, r)
+		case http.MethodPut, http.MethodDelete:
+			if api.registry == nil {
+				writeError(w, http.StatusNotFound, "feature flag overrides are unavailable (database disabled)")
+				return
+			}
+			if r.Method == http.MethodPut {
+				put(w, r)
+			} else {
+				del(w, r)
+			}
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	})
+}
@@ -0,0 +1,119 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_backend/featureflags"
+)
+
+// memoryFlagStorage keeps feature flag overrides in a slice
+type memoryFlagStorage struct {
+	overrides []featureflags.Override
+}
+
+func (m *memoryFlagStorage) ListFlagOverrides(ctx context.Context) ([]featureflags.Override, error) {
+	return m.overrides, nil
+}
+
+func (m *memoryFlagStorage) SaveFlagOverride(ctx context.Context, o featureflags.Override) error {
+	m.overrides = append(m.overrides, o)
+	return nil
+}
+
+func (m *memoryFlagStorage) DeleteFlagOverride(ctx context.Context, flag, canvasID string) error {
+	m.overrides = nil
+	return nil
+}
+
+func getFeatureFlags(t *testing.T, mux *http.ServeMux, target string) FeatureFlagsResponse {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET %s status = %d", target, rec.Code)
+	}
+	var resp FeatureFlagsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestFeatureFlagsAPI(t *testing.T) {
+	storage := &memoryFlagStorage{}
+	registry, err := featureflags.NewRegistry(context.Background(), storage, map[string]bool{featureflags.FlagImg2Img: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	NewFeatureFlagsAPI(registry, []string{"demo"}, nil).RegisterRoutes(mux, nil)
+
+	resp := getFeatureFlags(t, mux, "/api/feature-flags")
+	if len(resp.Flags) != len(featureflags.Flags) || len(resp.Canvases) != 1 {
+		t.Fatalf("GET = %+v", resp)
+	}
+	if f := resp.Flags[0]; f.Name != featureflags.FlagImg2Img || !f.Enabled || f.Source != featureflags.SourceEnv {
+		t.Errorf("img2img = %+v, want enabled by env", f)
+	}
+
+	rec := httptest.NewRecorder()
+	body := `{"flag":"mind_map","canvas_id":"demo","enabled":true}`
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/feature-flags", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d, body = %s", rec.Code, rec.Body.String())
+	}
+	if !registry.Enabled(featureflags.FlagMindMap, "demo") || len(storage.overrides) != 1 {
+		t.Error("PUT should enable mind_map on demo")
+	}
+	if f := getFeatureFlags(t, mux, "/api/feature-flags?canvas_id=demo").Flags[1]; f.Source != featureflags.SourceCanvas {
+		t.Errorf("mind_map on demo = %+v", f)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/feature-flags?flag=mind_map&canvas_id=demo", nil))
+	if rec.Code != http.StatusNoContent || registry.Enabled(featureflags.FlagMindMap, "demo") {
+		t.Errorf("DELETE status = %d, mind_map still enabled = %v", rec.Code, registry.Enabled(featureflags.FlagMindMap, "demo"))
+	}
+}
+
+func TestFeatureFlagsAPIErrors(t *testing.T) {
+	registry, _ := featureflags.NewRegistry(context.Background(), &memoryFlagStorage{}, nil)
+	mux := http.NewServeMux()
+	NewFeatureFlagsAPI(registry, nil, nil).RegisterRoutes(mux, nil)
+
+	tests := []struct {
+		name, method, target, body string
+		want                       int
+	}{
+		{"unknown flag", http.MethodPut, "/api/feature-flags", `{"flag":"teleport","enabled":true}`, http.StatusBadRequest},
+		{"bad json", http.MethodPut, "/api/feature-flags", `{`, http.StatusBadRequest},
+		{"delete without flag", http.MethodDelete, "/api/feature-flags", "", http.StatusBadRequest},
+		{"wrong method", http.MethodPost, "/api/feature-flags", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	// Without a registry flags report their defaults and cannot be changed
+	mux = http.NewServeMux()
+	NewFeatureFlagsAPI(nil, nil, nil).RegisterRoutes(mux, nil)
+	if resp := getFeatureFlags(t, mux, "/api/feature-flags"); resp.Flags[0].Source != featureflags.SourceDefault {
+		t.Errorf("flags without registry = %+v", resp.Flags)
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/feature-flags", strings.NewReader(`{"flag":"img2img"}`)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("PUT without registry status = %d, want 404", rec.Code)
+	}
+}
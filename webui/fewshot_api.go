@@ -0,0 +1,137 @@
+// Package webui provides the FewShotAPI organism for few-shot examples.
+// This file contains the REST handlers that list, save and delete the
+// examples attached to the system prompts of AI tasks.
+package webui
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go_backend/fewshot"
+
+	"go.uber.org/zap"
+)
+
+// maxFewShotBody limits the size of an example update.
+const maxFewShotBody = 16 << 10
+
+// FewShotResponse is the response of GET /api/fewshot.
+type FewShotResponse struct {
+	// Tasks are the task types that take examples
+	Tasks []string `json:"tasks"`
+	// MaxPerTask is the most examples attached to one prompt
+	MaxPerTask int               `json:"max_per_task"`
+	Examples   []fewshot.Example `json:"examples"`
+}
+
+// FewShotAPI is an organism that edits the few-shot examples.
+//
+// Endpoints:
+// - GET    /api/fewshot         - Every example (filter: task)
+// - PUT    /api/fewshot         - Add (id 0) or replace the example in the JSON body (requires auth)
+// - DELETE /api/fewshot?id=N    - Delete an example (requires auth)
+type FewShotAPI struct {
+	examples *fewshot.Set
+	logger   *zap.Logger
+}
+
+// NewFewShotAPI creates a FewShotAPI. examples is nil when the database is
+// disabled.
+func NewFewShotAPI(examples *fewshot.Set, logger *zap.Logger) *FewShotAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &FewShotAPI{examples: examples, logger: logger}
+}
+
+// HandleGet handles GET /api/fewshot requests.
+func (api *FewShotAPI) HandleGet(w http.ResponseWriter, r *http.Request) {
+	task := r.URL.Query().Get("task")
+	if task != "" && !fewshot.IsTask(task) {
+		writeError(w, http.StatusBadRequest, "unknown task")
+		return
+	}
+	writeJSON(w, http.StatusOK, FewShotResponse{
+		Tasks:      fewshot.Tasks,
+		MaxPerTask: fewshot.MaxPerTask,
+		Examples:   api.examples.List(task),
+	})
+}
+
+// HandlePut handles PUT /api/fewshot requests.
+func (api *FewShotAPI) HandlePut(w http.ResponseWriter, r *http.Request) {
+	var e fewshot.Example
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxFewShotBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&e); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid few-shot example: "+err.Error())
+		return
+	}
+
+	saved, err := api.examples.Put(r.Context(), e)
+	if err != nil {
+		switch {
+		case errors.Is(err, fewshot.ErrInvalidExample):
+			writeError(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, fewshot.ErrUnknownExample):
+			writeError(w, http.StatusNotFound, "few-shot example not found")
+		default:
+			api.logger.Error("Failed to save few-shot example", zap.Int64("id", e.ID), zap.Error(err))
+			writeError(w, http.StatusInternalServerError, "failed to save few-shot example")
+		}
+		return
+	}
+
+	api.logger.Info("Few-shot example saved", zap.Int64("id", saved.ID), zap.String("task", saved.Task))
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// HandleDelete handles DELETE /api/fewshot?id=N requests.
+func (api *FewShotAPI) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+	if err := api.examples.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, fewshot.ErrUnknownExample) {
+			writeError(w, http.StatusNotFound, "few-shot example not found")
+			return
+		}
+		api.logger.Error("Failed to delete few-shot example", zap.Int64("id", id), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to delete few-shot example")
+		return
+	}
+
+	api.logger.Info("Few-shot example deleted", zap.Int64("id", id))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegisterRoutes registers the few-shot route on mux. If protect is
+// non-nil it wraps the handlers that change examples.
+func (api *FewShotAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	put := api.HandlePut
+	del := api.HandleDelete
+	if protect != nil {
+		put = protect(put)
+		del = protect(del)
+	}
+	mux.HandleFunc("/api/fewshot", func(w http.ResponseWriter, r *http.Request) {
+		if api.examples == nil {
+			writeError(w, http.StatusNotFound, "few-shot examples are unavailable (database disabled)")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			api.HandleGet(w, r)
+		case http.MethodPut:
+			put(w, r)
+		case http.MethodDelete:
+			del(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_backend/fewshot"
+)
+
+// memoryFewShotStorage keeps few-shot examples in a map
+type memoryFewShotStorage struct {
+	saved  map[int64]fewshot.Example
+	nextID int64
+}
+
+func (m *memoryFewShotStorage) ListFewShotExamples(ctx context.Context) ([]fewshot.Example, error) {
+	var out []fewshot.Example
+	for _, e := range m.saved {
+		out = append(out, e)
+	}
+	return out, nil
+}
+
+func (m *memoryFewShotStorage) SaveFewShotExample(ctx context.Context, e fewshot.Example) (int64, error) {
+	if e.ID == 0 {
+		m.nextID++
+		e.ID = m.nextID
+	}
+	m.saved[e.ID] = e
+	return e.ID, nil
+}
+
+func (m *memoryFewShotStorage) DeleteFewShotExample(ctx context.Context, id int64) error {
+	delete(m.saved, id)
+	return nil
+}
+
+func TestFewShotAPI(t *testing.T) {
+	storage := &memoryFewShotStorage{saved: map[int64]fewshot.Example{}}
+	examples, err := fewshot.NewSet(context.Background(), storage)
+	if err != nil {
+		t.Fatalf("NewSet failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	NewFewShotAPI(examples, nil).RegisterRoutes(mux, nil)
+	do := func(method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return rec
+	}
+
+	rec := do(http.MethodPut, "/api/fewshot", `{"task":"note","input":"draw a cat","output":"{\"type\":\"image\",\"content\":\"a cat\"}"}`)
+	var saved fewshot.Example
+	if err := json.NewDecoder(rec.Body).Decode(&saved); err != nil || rec.Code != http.StatusOK || saved.ID == 0 {
+		t.Fatalf("PUT = %d %+v, %v", rec.Code, saved, err)
+	}
+	do(http.MethodPut, "/api/fewshot", `{"task":"canvas_analysis","input":"notes","output":"# Overview"}`)
+
+	rec = do(http.MethodGet, "/api/fewshot?task=note", "")
+	var list FewShotResponse
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil || len(list.Examples) != 1 || len(list.Tasks) != len(fewshot.Tasks) {
+		t.Fatalf("GET = %+v, %v", list, err)
+	}
+
+	for _, tc := range []struct {
+		method, target, body string
+		status               int
+	}{
+		{http.MethodPut, "/api/fewshot", `{"task":"note","input":"draw","output":"not json"}`, http.StatusBadRequest},
+		{http.MethodPut, "/api/fewshot", `{"id":99,"task":"note","input":"x","output":"{}"}`, http.StatusNotFound},
+		{http.MethodGet, "/api/fewshot?task=pdf", "", http.StatusBadRequest},
+		{http.MethodDelete, "/api/fewshot", "", http.StatusBadRequest},
+		{http.MethodDelete, "/api/fewshot?id=99", "", http.StatusNotFound},
+	} {
+		if rec := do(tc.method, tc.target, tc.body); rec.Code != tc.status {
+			t.Errorf("%s %s %s: status = %d, want %d", tc.method, tc.target, tc.body, rec.Code, tc.status)
+		}
+	}
+
+	if rec := do(http.MethodDelete, "/api/fewshot?id=1", ""); rec.Code != http.StatusNoContent || len(storage.saved) != 1 {
+		t.Errorf("DELETE = %d, %d left", rec.Code, len(storage.saved))
+	}
+}
+
+func TestFewShotAPIUnavailable(t *testing.T) {
+	mux := http.NewServeMux()
+	NewFewShotAPI(nil, nil).RegisterRoutes(mux, nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/fewshot", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", rec.Code)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"go_backend/core/clock"
 	"go_backend/metrics"
 )
 
@@ -48,6 +49,7 @@ type CanvasHealthMonitor struct {
 	checkInterval  time.Duration
 	onStatusChange func(canvasID string, connected bool) // Optional callback
 	logger         Logger
+	clock          clock.Clock
 }
 
 // HealthMonitorConfig configures the CanvasHealthMonitor behavior.
@@ -58,6 +60,8 @@ type HealthMonitorConfig struct {
 	OnStatusChange func(canvasID string, connected bool)
 	// Logger for diagnostic output
 	Logger Logger
+	// Clock schedules the checks and timestamps the statuses (default: system clock)
+	Clock clock.Clock
 }
 
 // DefaultHealthMonitorConfig returns a default configuration.
@@ -87,6 +91,7 @@ func NewCanvasHealthMonitor(store metrics.MetricsCollector, config HealthMonitor
 		checkInterval:  interval,
 		onStatusChange: config.OnStatusChange,
 		logger:         logger,
+		clock:          clock.OrReal(config.Clock),
 	}
 }
 
@@ -104,7 +109,7 @@ func (m *CanvasHealthMonitor) RegisterCanvas(canvas CanvasChecker) {
 		ID:         canvasID,
 		ServerURL:  canvas.GetServerURL(),
 		Connected:  false,
-		LastUpdate: time.Now(),
+		LastUpdate: m.clock.Now(),
 	})
 }
 
@@ -134,7 +139,7 @@ func (m *CanvasHealthMonitor) Start(ctx context.Context) {
 	// Perform initial check immediately
 	m.checkAllCanvases()
 
-	ticker := time.NewTicker(m.checkInterval)
+	ticker := m.clock.NewTicker(m.checkInterval)
 	defer ticker.Stop()
 
 	for {
@@ -142,7 +147,7 @@ func (m *CanvasHealthMonitor) Start(ctx context.Context) {
 		case <-ctx.Done():
 			m.logger.Printf("CanvasHealthMonitor: stopping due to context cancellation")
 			return
-		case <-ticker.C:
+		case <-ticker.C():
 			m.checkAllCanvases()
 		}
 	}
@@ -184,7 +189,7 @@ func (m *CanvasHealthMonitor) checkCanvas(canvas CanvasChecker) {
 		ID:         canvasID,
 		ServerURL:  canvas.GetServerURL(),
 		Connected:  connected,
-		LastUpdate: time.Now(),
+		LastUpdate: m.clock.Now(),
 	}
 
 	// Extract canvas name if available
@@ -246,7 +251,7 @@ func (m *CanvasHealthMonitor) UpdateCanvasMetrics(canvasID string, widgetCount i
 	status.WidgetCount = widgetCount
 	status.RequestsToday = requestsToday
 	status.SuccessRate = successRate
-	status.LastUpdate = time.Now()
+	status.LastUpdate = m.clock.Now()
 
 	m.store.UpdateCanvasStatus(status)
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"go_backend/core/clock"
 	"go_backend/metrics"
 )
 
@@ -20,6 +21,7 @@ type mockCanvasChecker struct {
 	info       map[string]interface{}
 	err        error
 	mu         sync.Mutex
+	checked    chan struct{} // Optional, receives every check
 }
 
 func newMockCanvasChecker(id, serverURL string, healthy bool) *mockCanvasChecker {
@@ -35,6 +37,9 @@ func newMockCanvasChecker(id, serverURL string, healthy bool) *mockCanvasChecker
 
 func (m *mockCanvasChecker) GetCanvasInfo() (map[string]interface{}, error) {
 	atomic.AddInt32(&m.checkCount, 1)
+	if m.checked != nil {
+		defer func() { m.checked <- struct{}{} }()
+	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -231,38 +236,48 @@ func TestCheckNow(t *testing.T) {
 
 func TestStartAndStop(t *testing.T) {
 	t.Run("performs periodic health checks", func(t *testing.T) {
-		store := metrics.NewMetricsStore(metrics.DefaultStoreConfig(), time.Now())
+		start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+		fake := clock.NewFake(start)
+		store := metrics.NewMetricsStore(metrics.DefaultStoreConfig(), start)
 		config := HealthMonitorConfig{
-			CheckInterval: 50 * time.Millisecond, // Short interval for testing
+			CheckInterval: time.Minute,
+			Clock:         fake,
 		}
 		monitor := NewCanvasHealthMonitor(store, config)
 
 		canvas := newMockCanvasChecker("canvas-1", "url1", true)
+		canvas.checked = make(chan struct{}, 1)
 		monitor.RegisterCanvas(canvas)
 
 		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
 
-		// Start monitor in background
+		// Start monitor in background; it checks once, then waits on the ticker
 		go monitor.Start(ctx)
+		<-canvas.checked
+		fake.BlockUntil(1)
 
-		// Wait for multiple check intervals
-		time.Sleep(200 * time.Millisecond)
-		cancel()
-
-		// Allow time for goroutine to exit
-		time.Sleep(20 * time.Millisecond)
+		for i := 0; i < 2; i++ {
+			fake.Advance(time.Minute)
+			<-canvas.checked
+		}
 
-		// Should have been checked multiple times (initial + ticker)
-		checkCount := canvas.GetCheckCount()
-		if checkCount < 2 {
-			t.Errorf("expected at least 2 checks, got %d", checkCount)
+		// Checked once at start and once per interval
+		if checkCount := canvas.GetCheckCount(); checkCount != 3 {
+			t.Errorf("expected 3 checks, got %d", checkCount)
+		}
+		status, _ := store.GetCanvasStatus("canvas-1")
+		if !status.LastUpdate.Equal(start.Add(2 * time.Minute)) {
+			t.Errorf("LastUpdate = %v, want the time of the last check", status.LastUpdate)
 		}
 	})
 
 	t.Run("stops on context cancellation", func(t *testing.T) {
-		store := metrics.NewMetricsStore(metrics.DefaultStoreConfig(), time.Now())
+		fake := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+		store := metrics.NewMetricsStore(metrics.DefaultStoreConfig(), fake.Now())
 		config := HealthMonitorConfig{
 			CheckInterval: 1 * time.Hour, // Long interval so we only get initial check
+			Clock:         fake,
 		}
 		monitor := NewCanvasHealthMonitor(store, config)
 
@@ -277,8 +292,8 @@ func TestStartAndStop(t *testing.T) {
 			close(done)
 		}()
 
-		// Give it time to start
-		time.Sleep(20 * time.Millisecond)
+		// Wait until it waits for the first tick
+		fake.BlockUntil(1)
 		cancel()
 
 		// Should exit promptly
@@ -0,0 +1,150 @@
+// Package webui provides the ImportAPI organism for document imports. This
+// file contains the REST handler that lays out an uploaded PDF or Word
+// document on a canvas.
+package webui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"go_backend/docimport"
+
+	"go.uber.org/zap"
+)
+
+// MaxImportBytes is the largest document that can be uploaded for import.
+const MaxImportBytes = 50 << 20
+
+// ImportAPI is an organism that imports documents onto canvases.
+//
+// Endpoints (requires authentication when auth is enabled):
+// - POST /api/import/document - Lay out the multipart "file" on a canvas (form fields: canvas_id, title, summarize=false)
+type ImportAPI struct {
+	importerFor func(canvasID string) *docimport.Importer
+	canvasIDs   []string
+	tempDir     string
+	logger      *zap.Logger
+}
+
+// NewImportAPI creates an ImportAPI. canvasIDs are the monitored canvases,
+// the only ones documents can be imported onto; the first is used when no
+// canvas_id is given. importerFor returns an importer writing to a canvas.
+// Uploads are kept under tempDir (default: the system temporary directory)
+// while they are imported.
+func NewImportAPI(importerFor func(canvasID string) *docimport.Importer, canvasIDs []string, tempDir string, logger *zap.Logger) *ImportAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &ImportAPI{importerFor: importerFor, canvasIDs: canvasIDs, tempDir: tempDir, logger: logger}
+}
+
+// HandleDocument handles POST /api/import/document requests.
+func (api *ImportAPI) HandleDocument(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, MaxImportBytes)
+	if err := r.ParseMultipartForm(8 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid upload (documents up to %d MB): %v", MaxImportBytes>>20, err))
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	canvasID := r.FormValue("canvas_id")
+	if canvasID == "" && len(api.canvasIDs) > 0 {
+		canvasID = api.canvasIDs[0]
+	}
+	if !api.monitored(canvasID) {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("canvas %q is not monitored", canvasID))
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "file is required")
+		return
+	}
+	defer file.Close()
+	name := filepath.Base(header.Filename)
+	if _, err := docimport.DetectFormat(name); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Keep the original name: it becomes the title of the uploaded PDF
+	path, cleanup, err := api.saveUpload(file, name)
+	if err != nil {
+		api.logger.Error("Failed to save upload", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to save upload")
+		return
+	}
+	defer cleanup()
+
+	result, err := api.importerFor(canvasID).Import(r.Context(), path, name, docimport.Options{
+		Title:         r.FormValue("title"),
+		SkipSummaries: r.FormValue("summarize") == "false",
+	})
+	switch {
+	case errors.Is(err, docimport.ErrNoContent):
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	case err != nil:
+		api.logger.Error("Document import failed", zap.String("canvas_id", canvasID), zap.String("file", name), zap.Error(err))
+		writeError(w, http.StatusBadGateway, "document import failed: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// saveUpload copies an upload to a temporary directory under its own name.
+func (api *ImportAPI) saveUpload(src io.Reader, name string) (string, func(), error) {
+	if api.tempDir != "" {
+		if err := os.MkdirAll(api.tempDir, 0755); err != nil {
+			return "", nil, err
+		}
+	}
+	dir, err := os.MkdirTemp(api.tempDir, "upload-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	path := filepath.Join(dir, name)
+	dst, err := os.Create(path)
+	if err == nil {
+		_, err = dst.ReadFrom(src)
+		if closeErr := dst.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return path, cleanup, nil
+}
+
+// RegisterRoutes registers the import route on mux. If protect is non-nil
+// it wraps the handler, since imports write to canvases.
+func (api *ImportAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	handler := api.HandleDocument
+	if protect != nil {
+		handler = protect(handler)
+	}
+	mux.HandleFunc("/api/import/document", handler)
+}
+
+// monitored reports whether canvasID is one of the monitored canvases.
+func (api *ImportAPI) monitored(canvasID string) bool {
+	for _, id := range api.canvasIDs {
+		if id == canvasID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package webui
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go_backend/docimport"
+)
+
+// importCanvas accepts every widget created on an empty canvas
+type importCanvas struct {
+	notes int
+}
+
+func (c *importCanvas) GetWidgets(subscribe bool) ([]map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (c *importCanvas) CreateAnchor(payload map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"id": "anchor-1"}, nil
+}
+
+func (c *importCanvas) CreateNote(payload map[string]interface{}) (map[string]interface{}, error) {
+	c.notes++
+	return map[string]interface{}{"id": "note"}, nil
+}
+
+func (c *importCanvas) CreateImage(filePath string, metadata map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"id": "image"}, nil
+}
+
+func (c *importCanvas) CreatePDF(filePath string, metadata map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"id": "pdf"}, nil
+}
+
+// importRequest builds a multipart upload of a one-paragraph Word document.
+func importRequest(t *testing.T, fileName, canvasID string) *http.Request {
+	t.Helper()
+	var docx bytes.Buffer
+	zw := zip.NewWriter(&docx)
+	w, _ := zw.Create("word/document.xml")
+	w.Write([]byte(`<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` +
+		`<w:p><w:r><w:t>Minutes of the planning meeting.</w:t></w:r></w:p></w:body></w:document>`))
+	zw.Close()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	if canvasID != "" {
+		mw.WriteField("canvas_id", canvasID)
+	}
+	mw.WriteField("summarize", "false")
+	part, _ := mw.CreateFormFile("file", fileName)
+	part.Write(docx.Bytes())
+	mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/import/document", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestImportAPIDocument(t *testing.T) {
+	canvas := &importCanvas{}
+	imported := ""
+	importerFor := func(canvasID string) *docimport.Importer {
+		imported = canvasID
+		return docimport.NewImporter(canvas, nil, t.TempDir(), nil)
+	}
+	mux := http.NewServeMux()
+	NewImportAPI(importerFor, []string{"canvas-1", "canvas-2"}, t.TempDir(), nil).RegisterRoutes(mux, nil)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, importRequest(t, "planning.docx", ""))
+	if rec.Code != http.StatusCreated || imported != "canvas-1" {
+		t.Fatalf("status = %d, imported onto %q: %s", rec.Code, imported, rec.Body.String())
+	}
+	var result docimport.Result
+	if err := json.NewDecoder(rec.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+	if result.AnchorID != "anchor-1" || result.Title != "planning" || result.Summarized || canvas.notes != 2 {
+		t.Errorf("result = %+v, %d notes", result, canvas.notes)
+	}
+
+	for _, tt := range []struct {
+		req  *http.Request
+		want int
+	}{
+		{importRequest(t, "planning.txt", ""), http.StatusBadRequest},
+		{importRequest(t, "planning.docx", "elsewhere"), http.StatusNotFound},
+		{httptest.NewRequest(http.MethodGet, "/api/import/document", nil), http.StatusMethodNotAllowed},
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, tt.req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %v: status = %d, want %d", tt.req.Method, tt.req.URL, rec.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Package webui provides the web-based user interface for CanvusLocalLLM.
+// This file contains the JSON response atoms shared by the dashboard APIs.
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ErrorResponse represents an error response.
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+}
+
+// writeJSON writes data as a JSON response with the given status code.
+// The status is already sent when data is encoded, so an encoding error,
+// usually a client that went away, is not reported.
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// writeError writes an ErrorResponse with the given status code.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, ErrorResponse{
+		Error:   http.StatusText(status),
+		Message: message,
+	})
+}
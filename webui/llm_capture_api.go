@@ -0,0 +1,158 @@
+// Package webui provides the LLMCaptureAPI organism for LLM troubleshooting.
+// This file contains the REST handlers that start and stop the capture
+// window and show the captured prompts and raw model responses.
+package webui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_backend/llmcapture"
+
+	"go.uber.org/zap"
+)
+
+// LLMCaptureUpdate is the body of PUT /api/llm-capture.
+type LLMCaptureUpdate struct {
+	// Active starts (true) or stops (false) capturing
+	Active bool `json:"active"`
+	// Minutes is how long capture runs (0 = 30, at most 240)
+	Minutes int `json:"minutes,omitempty"`
+	// Redact masks emails and phone numbers before captures are stored
+	Redact bool `json:"redact,omitempty"`
+}
+
+// LLMCaptureResponse is the response of GET and PUT /api/llm-capture.
+type LLMCaptureResponse struct {
+	Status   llmcapture.Status    `json:"status"`
+	Captures []llmcapture.Capture `json:"captures"`
+}
+
+// LLMCaptureAPI is an organism that controls the LLM capture mode.
+//
+// Endpoints (all require authentication when auth is enabled, since
+// captures hold full prompts):
+// - GET /api/llm-capture - Capture status and captures (filters: limit,
+// correlation_id, canvas_id, backend)
+// - PUT /api/llm-capture - Start or stop capturing
+// - DELETE /api/llm-capture - Delete all captures
+type LLMCaptureAPI struct {
+	recorder *llmcapture.Recorder
+	logger   *zap.Logger
+}
+
+// NewLLMCaptureAPI creates an LLMCaptureAPI for recorder.
+func NewLLMCaptureAPI(recorder *llmcapture.Recorder, logger *zap.Logger) *LLMCaptureAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &LLMCaptureAPI{recorder: recorder, logger: logger}
+}
+
+// HandleGet handles GET /api/llm-capture requests.
+func (api *LLMCaptureAPI) HandleGet(w http.ResponseWriter, r *http.Request) {
+	values := r.URL.Query()
+	query := llmcapture.Query{
+		CanvasID:      values.Get("canvas_id"),
+		CorrelationID: values.Get("correlation_id"),
+		Backend:       llmcapture.Backend(values.Get("backend")),
+	}
+	if query.Backend != "" && query.Backend != llmcapture.BackendLocal && query.Backend != llmcapture.BackendCloud {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown backend %q", query.Backend))
+		return
+	}
+	if text := values.Get("limit"); text != "" {
+		limit, err := strconv.Atoi(text)
+		if err != nil || limit < 1 || limit > 500 {
+			writeError(w, http.StatusBadRequest, "limit must be between 1 and 500")
+			return
+		}
+		query.Limit = limit
+	}
+
+	captures, err := api.recorder.List(r.Context(), query)
+	if err != nil {
+		api.logger.Error("Failed to list LLM captures", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to list captures")
+		return
+	}
+	if captures == nil {
+		captures = []llmcapture.Capture{}
+	}
+	writeJSON(w, http.StatusOK, LLMCaptureResponse{Status: api.recorder.Status(), Captures: captures})
+}
+
+// HandlePut handles PUT /api/llm-capture requests.
+func (api *LLMCaptureAPI) HandlePut(w http.ResponseWriter, r *http.Request) {
+	var update LLMCaptureUpdate
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid update: "+err.Error())
+		return
+	}
+	if update.Minutes < 0 || time.Duration(update.Minutes)*time.Minute > llmcapture.MaxWindow {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("minutes must be between 0 and %d", int(llmcapture.MaxWindow.Minutes())))
+		return
+	}
+
+	var status llmcapture.Status
+	var err error
+	if update.Active {
+		status, err = api.recorder.Start(r.Context(), time.Duration(update.Minutes)*time.Minute, update.Redact)
+	} else {
+		status, err = api.recorder.Stop(r.Context())
+	}
+	if errors.Is(err, llmcapture.ErrNoRedactor) {
+		writeError(w, http.StatusBadRequest, "redaction is unavailable")
+		return
+	}
+	if err != nil {
+		// The window changed; only pruning old captures failed
+		api.logger.Warn("Failed to prune LLM captures", zap.Error(err))
+	}
+
+	// Logged at warn so the change shows at every level
+	api.logger.Warn("LLM capture changed",
+		zap.Bool("active", status.Active),
+		zap.Bool("redact", status.Redact),
+	)
+	writeJSON(w, http.StatusOK, LLMCaptureResponse{Status: status, Captures: []llmcapture.Capture{}})
+}
+
+// HandleDelete handles DELETE /api/llm-capture requests.
+func (api *LLMCaptureAPI) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	deleted, err := api.recorder.Clear(r.Context())
+	if err != nil {
+		api.logger.Error("Failed to delete LLM captures", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to delete captures")
+		return
+	}
+	api.logger.Info("LLM captures deleted", zap.Int64("deleted", deleted))
+	writeJSON(w, http.StatusOK, map[string]int64{"deleted": deleted})
+}
+
+// RegisterRoutes registers the capture route on mux. If protect is non-nil
+// it wraps every handler.
+func (api *LLMCaptureAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			api.HandleGet(w, r)
+		case http.MethodPut:
+			api.HandlePut(w, r)
+		case http.MethodDelete:
+			api.HandleDelete(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}
+	if protect != nil {
+		handler = protect(handler)
+	}
+	mux.HandleFunc("/api/llm-capture", handler)
+}
@@ -0,0 +1,83 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go_backend/llmcapture"
+)
+
+// captureStorage keeps LLM captures in memory
+type captureStorage struct {
+	captures []llmcapture.Capture
+}
+
+func (s *captureStorage) InsertLLMCapture(ctx context.Context, c llmcapture.Capture) (int64, error) {
+	c.ID = int64(len(s.captures) + 1)
+	s.captures = append(s.captures, c)
+	return c.ID, nil
+}
+
+func (s *captureStorage) ListLLMCaptures(ctx context.Context, q llmcapture.Query) ([]llmcapture.Capture, error) {
+	var out []llmcapture.Capture
+	for i := len(s.captures) - 1; i >= 0 && len(out) < q.Limit; i-- {
+		if q.Backend == "" || s.captures[i].Backend == q.Backend {
+			out = append(out, s.captures[i])
+		}
+	}
+	return out, nil
+}
+
+func (s *captureStorage) DeleteLLMCaptures(ctx context.Context, before time.Time) (int64, error) {
+	deleted := int64(len(s.captures))
+	s.captures = nil
+	return deleted, nil
+}
+
+func TestLLMCaptureAPI(t *testing.T) {
+	storage := &captureStorage{}
+	recorder := llmcapture.NewRecorder(storage, nil)
+	mux := http.NewServeMux()
+	NewLLMCaptureAPI(recorder, nil).RegisterRoutes(mux, nil)
+	do := func(method, target, body string) (*httptest.ResponseRecorder, LLMCaptureResponse) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+		var resp LLMCaptureResponse
+		json.Unmarshal(rec.Body.Bytes(), &resp)
+		return rec, resp
+	}
+
+	if rec, resp := do(http.MethodPut, "/api/llm-capture", `{"active":true,"minutes":10}`); rec.Code != http.StatusOK || !resp.Status.Active {
+		t.Fatalf("start: %d %+v", rec.Code, resp.Status)
+	}
+	recorder.Record(context.Background(), llmcapture.Capture{Backend: llmcapture.BackendLocal, Request: "prompt"})
+	recorder.Record(context.Background(), llmcapture.Capture{Backend: llmcapture.BackendCloud, Request: "{}"})
+
+	rec, resp := do(http.MethodGet, "/api/llm-capture?backend=local", "")
+	if rec.Code != http.StatusOK || len(resp.Captures) != 1 || resp.Captures[0].Request != "prompt" {
+		t.Errorf("list: %d %+v", rec.Code, resp.Captures)
+	}
+
+	for _, tc := range []struct{ method, target, body string }{
+		{http.MethodPut, "/api/llm-capture", `{"active":true,"minutes":241}`},
+		{http.MethodPut, "/api/llm-capture", `{"active":true,"redact":true}`},
+		{http.MethodGet, "/api/llm-capture?limit=0", ""},
+		{http.MethodGet, "/api/llm-capture?backend=gpu", ""},
+	} {
+		if rec, _ := do(tc.method, tc.target, tc.body); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s %s %s: status = %d, want 400", tc.method, tc.target, tc.body, rec.Code)
+		}
+	}
+
+	if rec, resp := do(http.MethodPut, "/api/llm-capture", `{"active":false}`); rec.Code != http.StatusOK || resp.Status.Active {
+		t.Errorf("stop: %d %+v", rec.Code, resp.Status)
+	}
+	if rec, _ := do(http.MethodDelete, "/api/llm-capture", ""); rec.Code != http.StatusOK || len(storage.captures) != 0 {
+		t.Errorf("delete: %d, %d captures left", rec.Code, len(storage.captures))
+	}
+}
@@ -0,0 +1,136 @@
+// Package webui provides the LoggingAPI organism for runtime log control.
+// This file contains the REST handlers that show and change the log level,
+// per-package levels and temporary request/response body logging.
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go_backend/logging"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LoggingUpdate is the body of PUT /api/logging. Fields left out are not
+// changed.
+type LoggingUpdate struct {
+	// Level is the level of all loggers without a package level
+	Level *string `json:"level,omitempty"`
+	// Packages sets levels of named loggers, e.g. {"canvusapi": "debug"};
+	// an empty level makes the logger follow Level again
+	Packages map[string]string `json:"packages,omitempty"`
+	// BodyLoggingMinutes logs request/response bodies for this many
+	// minutes (at most 60); 0 turns body logging off
+	BodyLoggingMinutes *int `json:"body_logging_minutes,omitempty"`
+}
+
+// LoggingAPI is an organism that changes what is logged without a restart.
+//
+// Endpoints (both require authentication when auth is enabled):
+// - GET /api/logging - Current level, package levels and body logging
+// - PUT /api/logging - Apply the LoggingUpdate in the JSON body
+type LoggingAPI struct {
+	levels *logging.LevelControl
+	logger *zap.Logger
+}
+
+// NewLoggingAPI creates a LoggingAPI that changes levels.
+func NewLoggingAPI(levels *logging.LevelControl, logger *zap.Logger) *LoggingAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &LoggingAPI{levels: levels, logger: logger}
+}
+
+// HandleGet handles GET /api/logging requests.
+func (api *LoggingAPI) HandleGet(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, api.levels.State())
+}
+
+// HandlePut handles PUT /api/logging requests. The update is validated
+// before anything is changed.
+func (api *LoggingAPI) HandlePut(w http.ResponseWriter, r *http.Request) {
+	var update LoggingUpdate
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4<<10))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid update: "+err.Error())
+		return
+	}
+
+	var level zapcore.Level
+	if update.Level != nil {
+		var err error
+		if level, err = zapcore.ParseLevel(*update.Level); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown level %q", *update.Level))
+			return
+		}
+	}
+	packages := map[string]*zapcore.Level{}
+	for name, text := range update.Packages {
+		if name == "" {
+			writeError(w, http.StatusBadRequest, "package name is required")
+			return
+		}
+		if text == "" {
+			packages[name] = nil
+			continue
+		}
+		pkgLevel, err := zapcore.ParseLevel(text)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown level %q for %s", text, name))
+			return
+		}
+		packages[name] = &pkgLevel
+	}
+	if m := update.BodyLoggingMinutes; m != nil && (*m < 0 || time.Duration(*m)*time.Minute > logging.MaxBodyLogging) {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("body_logging_minutes must be between 0 and %d", int(logging.MaxBodyLogging.Minutes())))
+		return
+	}
+
+	if update.Level != nil {
+		api.levels.SetLevel(level)
+	}
+	for name, pkgLevel := range packages {
+		if pkgLevel == nil {
+			api.levels.ClearPackageLevel(name)
+		} else {
+			api.levels.SetPackageLevel(name, *pkgLevel)
+		}
+	}
+	if m := update.BodyLoggingMinutes; m != nil {
+		api.levels.SetBodyLogging(time.Duration(*m) * time.Minute)
+	}
+
+	state := api.levels.State()
+	// Logged at warn so the change shows at every level
+	api.logger.Warn("Log settings changed",
+		zap.String("level", state.Level),
+		zap.Any("packages", state.Packages),
+		zap.Bool("body_logging", state.BodyLogging),
+	)
+	writeJSON(w, http.StatusOK, state)
+}
+
+// RegisterRoutes registers the logging route on mux. If protect is non-nil
+// it wraps both handlers, since debug logs may contain canvas content.
+func (api *LoggingAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			api.HandleGet(w, r)
+		case http.MethodPut:
+			api.HandlePut(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	}
+	if protect != nil {
+		handler = protect(handler)
+	}
+	mux.HandleFunc("/api/logging", handler)
+}
@@ -0,0 +1,65 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_backend/logging"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLoggingAPI(t *testing.T) {
+	levels := logging.NewLevelControl(zapcore.InfoLevel)
+	mux := http.NewServeMux()
+	NewLoggingAPI(levels, nil).RegisterRoutes(mux, nil)
+	do := func(method, body string) (*httptest.ResponseRecorder, logging.LevelState) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, "/api/logging", strings.NewReader(body)))
+		var state logging.LevelState
+		json.NewDecoder(rec.Body).Decode(&state)
+		return rec, state
+	}
+
+	t.Run("update", func(t *testing.T) {
+		rec, state := do(http.MethodPut, `{"level":"warn","packages":{"canvusapi":"debug"},"body_logging_minutes":15}`)
+		if rec.Code != http.StatusOK || state.Level != "warn" || state.Packages["canvusapi"] != "debug" || !state.BodyLogging {
+			t.Fatalf("unexpected response: %d %+v", rec.Code, state)
+		}
+		if !levels.Enabled("canvusapi", zapcore.DebugLevel) || levels.Enabled("imagegen", zapcore.InfoLevel) {
+			t.Error("levels not applied")
+		}
+	})
+
+	t.Run("clear package and body logging", func(t *testing.T) {
+		rec, state := do(http.MethodPut, `{"packages":{"canvusapi":""},"body_logging_minutes":0}`)
+		if rec.Code != http.StatusOK || state.Level != "warn" || len(state.Packages) != 0 || state.BodyLogging {
+			t.Errorf("unexpected response: %d %+v", rec.Code, state)
+		}
+	})
+
+	t.Run("invalid updates change nothing", func(t *testing.T) {
+		for _, body := range []string{
+			`{"level":"loud"}`,
+			`{"level":"debug","packages":{"canvusapi":"loud"}}`,
+			`{"level":"debug","body_logging_minutes":61}`,
+			`{"colour":"red"}`,
+		} {
+			if rec, _ := do(http.MethodPut, body); rec.Code != http.StatusBadRequest {
+				t.Errorf("%s: status = %d, want 400", body, rec.Code)
+			}
+		}
+		if _, state := do(http.MethodGet, ""); state.Level != "warn" {
+			t.Errorf("level = %s, want unchanged", state.Level)
+		}
+	})
+
+	t.Run("rejects POST", func(t *testing.T) {
+		if rec, _ := do(http.MethodPost, "{}"); rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want 405", rec.Code)
+		}
+	})
+}
@@ -243,6 +243,12 @@ func (w *responseWriterWrapper) Flush() {
 	}
 }
 
+// Unwrap returns the underlying writer so http.ResponseController can reach
+// its deadlines and hijacking
+func (w *responseWriterWrapper) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // getClientIP extracts the client IP from the request
 // Checks X-Forwarded-For and X-Real-IP headers first for proxied requests
 func getClientIP(r *http.Request) string {
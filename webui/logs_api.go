@@ -0,0 +1,187 @@
+// Package webui provides the LogsAPI organism for downloading app.log.
+// This file contains the REST handlers that list the log file and its
+// rotated backups and serve them for download.
+package webui
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"go_backend/logging"
+
+	"go.uber.org/zap"
+)
+
+// defaultLogDownloadCount is the number of rotated files included, next to
+// the current log, when a download does not name a file or count.
+const defaultLogDownloadCount = 3
+
+// LogsResponse represents the JSON response for GET /api/logs.
+type LogsResponse struct {
+	Files []logging.LogFile `json:"files"`
+}
+
+// LogsAPI is an organism that serves the application log for support.
+//
+// Endpoints (both require authentication when auth is enabled):
+// - GET /api/logs - The log file and its rotated backups, newest first
+// - GET /api/logs/download - One file (?file=NAME), or a zip of the log and its latest backups (?count=N)
+type LogsAPI struct {
+	path   string
+	logger *zap.Logger
+}
+
+// NewLogsAPI creates a LogsAPI for the log file at path.
+func NewLogsAPI(path string, logger *zap.Logger) *LogsAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &LogsAPI{path: path, logger: logger}
+}
+
+// HandleList handles GET /api/logs requests.
+func (api *LogsAPI) HandleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	files, err := logging.LogFiles(api.path)
+	if err != nil {
+		api.logger.Error("Failed to list log files", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to list log files")
+		return
+	}
+	if files == nil {
+		files = []logging.LogFile{}
+	}
+	writeJSON(w, http.StatusOK, LogsResponse{Files: files})
+}
+
+// HandleDownload handles GET /api/logs/download requests. Only files
+// listed by GET /api/logs can be downloaded.
+func (api *LogsAPI) HandleDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	query := r.URL.Query()
+	count := defaultLogDownloadCount
+	if s := query.Get("count"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, "count must be a non-negative integer")
+			return
+		}
+		count = n
+	}
+
+	files, err := logging.LogFiles(api.path)
+	if err != nil {
+		api.logger.Error("Failed to list log files", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to list log files")
+		return
+	}
+
+	if name := query.Get("file"); name != "" {
+		for _, file := range files {
+			if file.Name == name {
+				api.serveFile(w, r, file)
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "log file not found")
+		return
+	}
+
+	// The current log comes first, followed by the newest backups
+	var selected []logging.LogFile
+	backups := 0
+	for _, file := range files {
+		if !file.Current {
+			if backups == count {
+				continue
+			}
+			backups++
+		}
+		selected = append(selected, file)
+	}
+	if len(selected) == 0 {
+		writeError(w, http.StatusNotFound, "no log files")
+		return
+	}
+
+	filename := fmt.Sprintf("logs-%s.zip", time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	zw := zip.NewWriter(w)
+	for _, file := range selected {
+		if err := addLogToZip(zw, file); err != nil {
+			// Headers are already sent, so the archive just ends early
+			api.logger.Warn("Log download interrupted", zap.String("file", file.Name), zap.Error(err))
+			break
+		}
+	}
+	if err := zw.Close(); err != nil {
+		api.logger.Debug("Log download interrupted", zap.Error(err))
+	}
+}
+
+// serveFile sends one log file as an attachment.
+func (api *LogsAPI) serveFile(w http.ResponseWriter, r *http.Request, file logging.LogFile) {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "log file not found")
+		return
+	}
+	defer f.Close()
+
+	contentType := "text/plain; charset=utf-8"
+	if file.Compressed {
+		contentType = "application/gzip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.Name))
+	http.ServeContent(w, r, file.Name, file.Modified, f)
+}
+
+// addLogToZip copies file into zw. Backups are already compressed, so they
+// are stored as they are.
+func addLogToZip(zw *zip.Writer, file logging.LogFile) error {
+	f, err := os.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	header := &zip.FileHeader{Name: file.Name, Method: zip.Deflate, Modified: file.Modified}
+	if file.Compressed {
+		header.Method = zip.Store
+	}
+	entry, err := zw.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(entry, f)
+	return err
+}
+
+// RegisterRoutes registers the log routes on mux. If protect is non-nil
+// it wraps every handler, since logs describe all canvas activity.
+func (api *LogsAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	list := api.HandleList
+	download := api.HandleDownload
+	if protect != nil {
+		list = protect(list)
+		download = protect(download)
+	}
+	mux.HandleFunc("/api/logs", list)
+	mux.HandleFunc("/api/logs/download", download)
+}
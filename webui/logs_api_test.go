@@ -0,0 +1,91 @@
+package webui
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLogsAPI(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"app.log":                            "current\n",
+		"app-2026-01-01T00-00-00.000.log.gz": "oldest",
+		"app-2026-01-02T00-00-00.000.log.gz": "older",
+		"app-2026-01-03T00-00-00.000.log":    "newest\n",
+		"secrets.env":                        "KEY=1",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(method, path string) *httptest.ResponseRecorder {
+		mux := http.NewServeMux()
+		NewLogsAPI(filepath.Join(dir, "app.log"), nil).RegisterRoutes(mux, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	t.Run("list", func(t *testing.T) {
+		rec := get(http.MethodGet, "/api/logs")
+		var body LogsResponse
+		json.NewDecoder(rec.Body).Decode(&body)
+		if rec.Code != http.StatusOK || len(body.Files) != 4 || !body.Files[0].Current {
+			t.Errorf("unexpected response: %d %+v", rec.Code, body)
+		}
+	})
+
+	t.Run("zip of the latest files", func(t *testing.T) {
+		rec := get(http.MethodGet, "/api/logs/download?count=2")
+		if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" {
+			t.Fatalf("unexpected response: %d %s", rec.Code, rec.Body.String())
+		}
+		zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"app.log", "app-2026-01-03T00-00-00.000.log", "app-2026-01-02T00-00-00.000.log.gz"}
+		if len(zr.File) != len(want) {
+			t.Fatalf("zip has %d files, want %v", len(zr.File), want)
+		}
+		for i, name := range want {
+			if zr.File[i].Name != name {
+				t.Errorf("zip file %d = %s, want %s", i, zr.File[i].Name, name)
+			}
+		}
+	})
+
+	t.Run("single file", func(t *testing.T) {
+		rec := get(http.MethodGet, "/api/logs/download?file=app-2026-01-03T00-00-00.000.log")
+		if rec.Code != http.StatusOK || rec.Body.String() != "newest\n" {
+			t.Errorf("unexpected response: %d %q", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("only log files", func(t *testing.T) {
+		for _, name := range []string{"secrets.env", "../app.log"} {
+			if rec := get(http.MethodGet, "/api/logs/download?file="+name); rec.Code != http.StatusNotFound {
+				t.Errorf("%s: status = %d, want 404", name, rec.Code)
+			}
+		}
+	})
+
+	t.Run("bad count", func(t *testing.T) {
+		if rec := get(http.MethodGet, "/api/logs/download?count=-1"); rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+	})
+
+	t.Run("rejects POST", func(t *testing.T) {
+		if rec := get(http.MethodPost, "/api/logs/download"); rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want 405", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,459 @@
+// Package webui provides the ModelCatalogAPI organism for browsing and
+// downloading models from the dashboard.
+// This file contains the REST handlers backing the Model Catalog widget.
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go_backend/core"
+	"go_backend/core/modelmanager"
+	"go_backend/metrics"
+
+	"go.uber.org/zap"
+)
+
+// Model download states reported by the catalog API.
+const (
+	DownloadStateDownloading = "downloading"
+	DownloadStateCompleted   = "completed"
+	DownloadStateFailed      = "failed"
+)
+
+// Errors returned by ModelCatalogAPI.StartDownload.
+var (
+	ErrUnknownModel       = errors.New("unknown model")
+	ErrDownloadInProgress = errors.New("download already in progress")
+)
+
+// ModelDownloader downloads catalog models and manages the model directory.
+// Implemented by modelmanager.ModelManager.
+type ModelDownloader interface {
+	// EnsureModel downloads the model if missing and returns its path.
+	EnsureModel(ctx context.Context, model modelmanager.ModelConfig, onProgress func(core.ProgressInfo)) (string, error)
+	// HasModel reports whether the model file is already present.
+	HasModel(model modelmanager.ModelConfig) bool
+	// ModelPath returns the local path of the model file.
+	ModelPath(model modelmanager.ModelConfig) string
+	// ModelDir returns the model directory.
+	ModelDir() string
+	// ListLocalModels lists the model files, marking those in inUse.
+	ListLocalModels(inUse []string) ([]modelmanager.LocalModel, error)
+	// DeleteLocalModel deletes an unused model file and returns the bytes freed.
+	DeleteLocalModel(filename string, inUse []string) (int64, error)
+}
+
+// ModelDownloadStatus is the progress of a catalog download.
+type ModelDownloadStatus struct {
+	State      string  `json:"state"`
+	Percent    float64 `json:"percent"`
+	Downloaded int64   `json:"downloaded_bytes"`
+	Total      int64   `json:"total_bytes"`
+	Speed      string  `json:"speed,omitempty"`
+	ETASecs    float64 `json:"eta_secs,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	Path       string  `json:"path,omitempty"`
+
+	// RestartRequired is set once a download updated the env configuration;
+	// the new model is loaded on the next start.
+	RestartRequired bool `json:"restart_required,omitempty"`
+}
+
+// CatalogModel is a catalog entry with its local state.
+type CatalogModel struct {
+	modelmanager.CatalogEntry
+
+	// Installed is true when the model file is in the model directory.
+	Installed bool `json:"installed"`
+	// Active is true when EnvVar currently points at this model.
+	Active bool `json:"active"`
+	// FitsVRAM reports whether MinVRAMMB fits the GPU (omitted if unknown).
+	FitsVRAM *bool `json:"fits_vram,omitempty"`
+	// Download is the progress of the current or last download.
+	Download *ModelDownloadStatus `json:"download,omitempty"`
+}
+
+// ModelCatalogResponse represents the JSON response for /api/models.
+type ModelCatalogResponse struct {
+	Models      []CatalogModel `json:"models"`
+	Count       int            `json:"count"`
+	GPUTotalMB  int64          `json:"gpu_total_mb,omitempty"`
+	EnvFilePath string         `json:"env_file,omitempty"`
+}
+
+// LocalModelsResponse represents the JSON response for GET /api/models/local.
+type LocalModelsResponse struct {
+	Models     []modelmanager.LocalModel `json:"models"`
+	Count      int                       `json:"count"`
+	TotalBytes int64                     `json:"total_bytes"`
+	// DiskFreeBytes is the free space in the model directory (omitted if unknown)
+	DiskFreeBytes int64  `json:"disk_free_bytes,omitempty"`
+	ModelDir      string `json:"model_dir"`
+}
+
+// DeleteModelResponse represents the JSON response for DELETE /api/models/local.
+type DeleteModelResponse struct {
+	Filename   string `json:"filename"`
+	FreedBytes int64  `json:"freed_bytes"`
+}
+
+// ModelDownloadRequest is the JSON body of POST /api/models/download.
+type ModelDownloadRequest struct {
+	ID string `json:"id"`
+}
+
+// ModelCatalogAPI is an organism that serves the model catalog and runs
+// downloads in the background. Completed downloads are wired into the
+// .env file through the entry's EnvVar.
+//
+// Endpoints:
+// - GET    /api/models                  - Catalog with install and download state
+// - POST   /api/models/download         - Start downloading a catalog entry
+// - GET    /api/models/local            - Model files on disk and free space
+// - DELETE /api/models/local?file=NAME  - Delete an unused model file
+type ModelCatalogAPI struct {
+	ctx          context.Context
+	catalog      *modelmanager.Catalog
+	downloader   ModelDownloader
+	gpuCollector *metrics.GPUCollector
+	envPath      string
+	logger       *zap.Logger
+
+	mu        sync.Mutex
+	downloads map[string]*ModelDownloadStatus
+}
+
+// NewModelCatalogAPI creates a ModelCatalogAPI.
+// Downloads run until ctx is cancelled. envPath is the .env file updated
+// after a download (empty disables env wiring). gpuCollector is optional.
+func NewModelCatalogAPI(
+	ctx context.Context,
+	catalog *modelmanager.Catalog,
+	downloader ModelDownloader,
+	gpuCollector *metrics.GPUCollector,
+	envPath string,
+	logger *zap.Logger,
+) *ModelCatalogAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &ModelCatalogAPI{
+		ctx:          ctx,
+		catalog:      catalog,
+		downloader:   downloader,
+		gpuCollector: gpuCollector,
+		envPath:      envPath,
+		logger:       logger,
+		downloads:    make(map[string]*ModelDownloadStatus),
+	}
+}
+
+// HandleCatalog handles GET /api/models requests.
+func (api *ModelCatalogAPI) HandleCatalog(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	writeJSON(w, http.StatusOK, api.Models())
+}
+
+// Models returns the catalog with the install and download state of each model.
+func (api *ModelCatalogAPI) Models() ModelCatalogResponse {
+	gpuTotalMB := api.gpuTotalMB()
+
+	models := make([]CatalogModel, 0, len(api.catalog.Models))
+	for _, entry := range api.catalog.Models {
+		cfg := entry.ModelConfig()
+		model := CatalogModel{
+			CatalogEntry: entry,
+			Installed:    api.downloader.HasModel(cfg),
+			Active:       entry.EnvVar != "" && samePath(os.Getenv(entry.EnvVar), api.downloader.ModelPath(cfg)),
+			Download:     api.downloadStatus(entry.ID),
+		}
+		if gpuTotalMB > 0 && entry.MinVRAMMB > 0 {
+			fits := entry.MinVRAMMB <= gpuTotalMB
+			model.FitsVRAM = &fits
+		}
+		models = append(models, model)
+	}
+
+	return ModelCatalogResponse{
+		Models:      models,
+		Count:       len(models),
+		GPUTotalMB:  gpuTotalMB,
+		EnvFilePath: api.envPath,
+	}
+}
+
+// HandleDownload handles POST /api/models/download requests.
+// Returns 202 with the download status; progress is reported by /api/models.
+func (api *ModelCatalogAPI) HandleDownload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req ModelDownloadRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	status, err := api.StartDownload(req.ID)
+	switch {
+	case errors.Is(err, ErrUnknownModel):
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	case errors.Is(err, ErrDownloadInProgress):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusAccepted, status)
+}
+
+// StartDownload starts downloading the catalog model id in the background.
+// Returns ErrUnknownModel or ErrDownloadInProgress.
+func (api *ModelCatalogAPI) StartDownload(id string) (ModelDownloadStatus, error) {
+	entry, ok := api.catalog.Get(id)
+	if !ok {
+		return ModelDownloadStatus{}, fmt.Errorf("%w: %s", ErrUnknownModel, id)
+	}
+	status, started := api.startDownload(entry)
+	if !started {
+		return status, ErrDownloadInProgress
+	}
+	return status, nil
+}
+
+// HandleLocalModels handles GET /api/models/local requests.
+func (api *ModelCatalogAPI) HandleLocalModels(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	resp, err := api.LocalModels()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// LocalModels lists the model files on disk and the free space.
+func (api *ModelCatalogAPI) LocalModels() (LocalModelsResponse, error) {
+	models, err := api.downloader.ListLocalModels(api.inUsePaths())
+	if err != nil {
+		return LocalModelsResponse{}, err
+	}
+	if models == nil {
+		models = []modelmanager.LocalModel{}
+	}
+
+	resp := LocalModelsResponse{
+		Models:   models,
+		Count:    len(models),
+		ModelDir: api.downloader.ModelDir(),
+	}
+	for _, m := range models {
+		resp.TotalBytes += m.SizeBytes
+	}
+	if info, err := core.GetDiskSpace(resp.ModelDir); err == nil {
+		resp.DiskFreeBytes = info.Free
+	}
+	return resp, nil
+}
+
+// HandleDeleteLocalModel handles DELETE /api/models/local?file=NAME requests.
+// Models in use by the current configuration or still downloading are kept.
+func (api *ModelCatalogAPI) HandleDeleteLocalModel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	resp, err := api.DeleteLocalModel(r.URL.Query().Get("file"))
+	switch {
+	case errors.Is(err, modelmanager.ErrInvalidModelFile):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, modelmanager.ErrModelInUse):
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	case errors.Is(err, os.ErrNotExist):
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// DeleteLocalModel deletes an unused model file. Errors wrap
+// modelmanager.ErrInvalidModelFile, modelmanager.ErrModelInUse or
+// os.ErrNotExist.
+func (api *ModelCatalogAPI) DeleteLocalModel(filename string) (DeleteModelResponse, error) {
+	freed, err := api.downloader.DeleteLocalModel(filename, api.inUsePaths())
+	if err != nil {
+		return DeleteModelResponse{}, err
+	}
+	api.logger.Info("Model file deleted", zap.String("file", filename), zap.Int64("freed_bytes", freed))
+	return DeleteModelResponse{Filename: filename, FreedBytes: freed}, nil
+}
+
+// RegisterRoutes registers the catalog routes on mux. If protect is
+// non-nil it wraps the handlers that change files (e.g., with authentication).
+func (api *ModelCatalogAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	download := api.HandleDownload
+	deleteLocal := api.HandleDeleteLocalModel
+	if protect != nil {
+		download = protect(download)
+		deleteLocal = protect(deleteLocal)
+	}
+	mux.HandleFunc("/api/models", api.HandleCatalog)
+	mux.HandleFunc("/api/models/download", download)
+	mux.HandleFunc("/api/models/local", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deleteLocal(w, r)
+			return
+		}
+		api.HandleLocalModels(w, r)
+	})
+}
+
+// inUsePaths returns the model paths that must not be deleted: the
+// configured models and the partial files of running downloads.
+func (api *ModelCatalogAPI) inUsePaths() []string {
+	paths := modelmanager.InUseModelPaths()
+
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	for id, status := range api.downloads {
+		if status.State != DownloadStateDownloading {
+			continue
+		}
+		if entry, ok := api.catalog.Get(id); ok {
+			path := api.downloader.ModelPath(entry.ModelConfig())
+			paths = append(paths, path, path+modelmanager.PartialSuffix)
+		}
+	}
+	return paths
+}
+
+// startDownload begins downloading entry in the background.
+// Returns false if a download of the entry is already running.
+func (api *ModelCatalogAPI) startDownload(entry modelmanager.CatalogEntry) (ModelDownloadStatus, bool) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	if current, ok := api.downloads[entry.ID]; ok && current.State == DownloadStateDownloading {
+		return *current, false
+	}
+
+	status := &ModelDownloadStatus{State: DownloadStateDownloading, Total: entry.SizeBytes}
+	api.downloads[entry.ID] = status
+	go api.runDownload(entry)
+	return *status, true
+}
+
+// runDownload downloads entry and wires its EnvVar on success.
+func (api *ModelCatalogAPI) runDownload(entry modelmanager.CatalogEntry) {
+	api.logger.Info("Model download started", zap.String("model", entry.ID), zap.String("url", entry.URL))
+
+	path, err := api.downloader.EnsureModel(api.ctx, entry.ModelConfig(), func(info core.ProgressInfo) {
+		api.updateDownload(entry.ID, func(s *ModelDownloadStatus) {
+			s.Percent = info.Percent
+			s.Downloaded = info.Downloaded
+			if info.Total > 0 {
+				s.Total = info.Total
+			}
+			s.Speed = info.SpeedFormatted
+			s.ETASecs = info.ETA.Seconds()
+		})
+	})
+	if err != nil {
+		api.logger.Error("Model download failed", zap.String("model", entry.ID), zap.Error(err))
+		api.updateDownload(entry.ID, func(s *ModelDownloadStatus) {
+			s.State = DownloadStateFailed
+			s.Error = err.Error()
+		})
+		return
+	}
+
+	restartRequired := false
+	if entry.EnvVar != "" && api.envPath != "" {
+		if err := core.SetEnvFileValue(api.envPath, entry.EnvVar, path); err != nil {
+			api.logger.Error("Failed to update env file",
+				zap.String("model", entry.ID), zap.String("env_var", entry.EnvVar), zap.Error(err))
+			api.updateDownload(entry.ID, func(s *ModelDownloadStatus) {
+				s.State = DownloadStateFailed
+				s.Path = path
+				s.Error = "downloaded, but failed to update " + api.envPath + ": " + err.Error()
+			})
+			return
+		}
+		os.Setenv(entry.EnvVar, path)
+		restartRequired = true
+	}
+
+	api.logger.Info("Model download completed",
+		zap.String("model", entry.ID), zap.String("path", path), zap.String("env_var", entry.EnvVar))
+	api.updateDownload(entry.ID, func(s *ModelDownloadStatus) {
+		s.State = DownloadStateCompleted
+		s.Percent = 100
+		s.ETASecs = 0
+		s.Path = path
+		s.RestartRequired = restartRequired
+	})
+}
+
+// updateDownload applies fn to the status of id under the lock.
+func (api *ModelCatalogAPI) updateDownload(id string, fn func(*ModelDownloadStatus)) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	if status, ok := api.downloads[id]; ok {
+		fn(status)
+	}
+}
+
+// downloadStatus returns a copy of the download status of id, or nil.
+func (api *ModelCatalogAPI) downloadStatus(id string) *ModelDownloadStatus {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	status, ok := api.downloads[id]
+	if !ok {
+		return nil
+	}
+	copied := *status
+	return &copied
+}
+
+// gpuTotalMB returns the total GPU memory in MB, or 0 if unknown.
+func (api *ModelCatalogAPI) gpuTotalMB() int64 {
+	if api.gpuCollector == nil || !api.gpuCollector.IsAvailable() {
+		return 0
+	}
+	return api.gpuCollector.GetCurrentMetrics().MemoryTotal / (1024 * 1024)
+}
+
+// samePath reports whether two file paths refer to the same location.
+func samePath(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return filepath.Clean(a) == filepath.Clean(b)
+	}
+	return absA == absB
+}
@@ -0,0 +1,318 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_backend/core"
+	"go_backend/core/modelmanager"
+)
+
+// fakeModelDownloader is a test implementation of ModelDownloader.
+// Downloads are simulated; the model directory is managed by a real
+// ModelManager.
+type fakeModelDownloader struct {
+	*modelmanager.ModelManager
+
+	dir     string
+	err     error
+	release chan struct{}
+
+	mu        sync.Mutex
+	installed map[string]bool
+}
+
+func newFakeModelDownloader(dir string) *fakeModelDownloader {
+	return &fakeModelDownloader{
+		ModelManager: modelmanager.NewModelManager(dir, nil),
+		dir:          dir,
+		installed:    make(map[string]bool),
+	}
+}
+
+func (f *fakeModelDownloader) EnsureModel(ctx context.Context, model modelmanager.ModelConfig, onProgress func(core.ProgressInfo)) (string, error) {
+	onProgress(core.ProgressInfo{Total: 100, Downloaded: 50, Percent: 50, SpeedFormatted: "1.0 MB/s"})
+	if f.release != nil {
+		<-f.release
+	}
+	if f.err != nil {
+		return "", f.err
+	}
+	f.mu.Lock()
+	f.installed[model.Filename] = true
+	f.mu.Unlock()
+	return f.ModelPath(model), nil
+}
+
+func (f *fakeModelDownloader) HasModel(model modelmanager.ModelConfig) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.installed[model.Filename]
+}
+
+func (f *fakeModelDownloader) ModelPath(model modelmanager.ModelConfig) string {
+	return filepath.Join(f.dir, model.Filename)
+}
+
+func testCatalog() *modelmanager.Catalog {
+	return &modelmanager.Catalog{
+		Version: 1,
+		Models: []modelmanager.CatalogEntry{
+			{
+				ID:        "test-text",
+				Name:      "Test Text",
+				Kind:      modelmanager.KindText,
+				URL:       "https://example.com/text.gguf",
+				Filename:  "text.gguf",
+				SizeBytes: 100,
+				EnvVar:    "TEST_CATALOG_MODEL_PATH",
+			},
+			{
+				ID:       "test-proj",
+				Name:     "Test Projector",
+				Kind:     modelmanager.KindVision,
+				URL:      "https://example.com/proj.gguf",
+				Filename: "proj.gguf",
+			},
+		},
+	}
+}
+
+func getCatalog(t *testing.T, api *ModelCatalogAPI) ModelCatalogResponse {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	api.HandleCatalog(rec, httptest.NewRequest(http.MethodGet, "/api/models", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /api/models status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var resp ModelCatalogResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	return resp
+}
+
+func postDownload(api *ModelCatalogAPI, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	api.HandleDownload(rec, httptest.NewRequest(http.MethodPost, "/api/models/download", strings.NewReader(body)))
+	return rec
+}
+
+// waitForDownloadState polls the catalog until the model reaches state.
+func waitForDownloadState(t *testing.T, api *ModelCatalogAPI, id, state string) CatalogModel {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, m := range getCatalog(t, api).Models {
+			if m.ID == id && m.Download != nil && m.Download.State == state {
+				return m
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("model %s did not reach state %q", id, state)
+	return CatalogModel{}
+}
+
+func TestHandleCatalog(t *testing.T) {
+	api := NewModelCatalogAPI(context.Background(), testCatalog(), newFakeModelDownloader(t.TempDir()), nil, "", nil)
+
+	resp := getCatalog(t, api)
+	if resp.Count != 2 || len(resp.Models) != 2 {
+		t.Fatalf("Count = %d, want 2", resp.Count)
+	}
+	m := resp.Models[0]
+	if m.ID != "test-text" || m.Installed || m.Active || m.Download != nil {
+		t.Errorf("unexpected model state: %+v", m)
+	}
+	if m.FitsVRAM != nil {
+		t.Error("FitsVRAM should be omitted without GPU metrics")
+	}
+
+	rec := httptest.NewRecorder()
+	api.HandleCatalog(rec, httptest.NewRequest(http.MethodPost, "/api/models", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST /api/models status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandleDownload_WiresEnvFile(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	if err := os.WriteFile(envPath, []byte("# settings\nTEST_CATALOG_MODEL_PATH=old.gguf\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_CATALOG_MODEL_PATH", "old.gguf")
+
+	downloader := newFakeModelDownloader(dir)
+	api := NewModelCatalogAPI(context.Background(), testCatalog(), downloader, nil, envPath, nil)
+
+	rec := postDownload(api, `{"id":"test-text"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusAccepted, rec.Body.String())
+	}
+
+	m := waitForDownloadState(t, api, "test-text", DownloadStateCompleted)
+	wantPath := filepath.Join(dir, "text.gguf")
+	if !m.Installed || !m.Active || !m.Download.RestartRequired || m.Download.Path != wantPath {
+		t.Errorf("unexpected model state after download: %+v %+v", m, m.Download)
+	}
+	if got := os.Getenv("TEST_CATALOG_MODEL_PATH"); got != wantPath {
+		t.Errorf("env = %q, want %q", got, wantPath)
+	}
+	data, err := os.ReadFile(envPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# settings\nTEST_CATALOG_MODEL_PATH=" + wantPath + "\n"; string(data) != want {
+		t.Errorf(".env = %q, want %q", data, want)
+	}
+}
+
+func TestHandleDownload_Errors(t *testing.T) {
+	downloader := newFakeModelDownloader(t.TempDir())
+	downloader.release = make(chan struct{})
+	api := NewModelCatalogAPI(context.Background(), testCatalog(), downloader, nil, "", nil)
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"invalid body", `not json`, http.StatusBadRequest},
+		{"unknown model", `{"id":"missing"}`, http.StatusNotFound},
+		{"first download", `{"id":"test-proj"}`, http.StatusAccepted},
+		{"already downloading", `{"id":"test-proj"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rec := postDownload(api, tt.body); rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		status := api.downloadStatus("test-proj")
+		if status.State == DownloadStateDownloading && status.Percent == 50 && status.Speed == "1.0 MB/s" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("progress not reported: %+v", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(downloader.release)
+	waitForDownloadState(t, api, "test-proj", DownloadStateCompleted)
+
+	rec := httptest.NewRecorder()
+	api.HandleDownload(rec, httptest.NewRequest(http.MethodGet, "/api/models/download", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestHandleDownload_Failure(t *testing.T) {
+	downloader := newFakeModelDownloader(t.TempDir())
+	downloader.err = errors.New("connection reset")
+	api := NewModelCatalogAPI(context.Background(), testCatalog(), downloader, nil, "", nil)
+
+	if rec := postDownload(api, `{"id":"test-text"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	m := waitForDownloadState(t, api, "test-text", DownloadStateFailed)
+	if m.Installed || !strings.Contains(m.Download.Error, "connection reset") {
+		t.Errorf("unexpected model state after failure: %+v %+v", m, m.Download)
+	}
+
+	// A failed download can be retried
+	downloader.err = nil
+	if rec := postDownload(api, `{"id":"test-text"}`); rec.Code != http.StatusAccepted {
+		t.Errorf("retry status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	waitForDownloadState(t, api, "test-text", DownloadStateCompleted)
+}
+
+func TestHandleLocalModels(t *testing.T) {
+	dir := t.TempDir()
+	for name, size := range map[string]int{"active.gguf": 30, "old.gguf": 20, "notes.txt": 1} {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("LLAMA_MODEL_PATH", filepath.Join(dir, "active.gguf"))
+	t.Setenv("SD_MODEL_PATH", "")
+
+	api := NewModelCatalogAPI(context.Background(), testCatalog(), newFakeModelDownloader(dir), nil, "", nil)
+	mux := http.NewServeMux()
+	api.RegisterRoutes(mux, nil)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/models/local", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var resp LocalModelsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Count != 2 || resp.TotalBytes != 50 || resp.ModelDir != dir {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if !resp.Models[0].InUse || resp.Models[1].InUse {
+		t.Errorf("in-use flags wrong: %+v", resp.Models)
+	}
+
+	tests := []struct {
+		name string
+		file string
+		want int
+	}{
+		{"in use", "active.gguf", http.StatusConflict},
+		{"invalid name", "../active.gguf", http.StatusBadRequest},
+		{"not a model", "notes.txt", http.StatusBadRequest},
+		{"unused", "old.gguf", http.StatusOK},
+		{"already deleted", "old.gguf", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/models/local?file="+tt.file, nil))
+			if rec.Code != tt.want {
+				t.Errorf("DELETE %s status = %d, want %d: %s", tt.file, rec.Code, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleDeleteLocalModel_KeepsActiveDownload(t *testing.T) {
+	dir := t.TempDir()
+	partial := filepath.Join(dir, "proj.gguf"+modelmanager.PartialSuffix)
+	if err := os.WriteFile(partial, []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	downloader := newFakeModelDownloader(dir)
+	downloader.release = make(chan struct{})
+	defer close(downloader.release)
+	api := NewModelCatalogAPI(context.Background(), testCatalog(), downloader, nil, "", nil)
+	if rec := postDownload(api, `{"id":"test-proj"}`); rec.Code != http.StatusAccepted {
+		t.Fatalf("download status = %d", rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	api.HandleDeleteLocalModel(rec, httptest.NewRequest(http.MethodDelete, "/api/models/local?file=proj.gguf.part", nil))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("DELETE status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+}
@@ -0,0 +1,147 @@
+// Package webui provides the PromptLibraryAPI organism for the prompt
+// library. This file contains the REST handlers that list, save and delete
+// the templates notes use with {{use:name}}.
+package webui
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go_backend/promptlib"
+
+	"go.uber.org/zap"
+)
+
+// maxPromptTemplateBody limits the size of a template update.
+const maxPromptTemplateBody = 64 << 10
+
+// PromptLibraryAPI is an organism that edits the prompt library.
+//
+// Endpoints:
+// - GET    /api/prompts         - Every template with the variables it needs
+// - GET    /api/prompts?name=X  - One template
+// - PUT    /api/prompts         - Save the template in the JSON body (requires auth)
+// - DELETE /api/prompts?name=X  - Delete a template (requires auth)
+type PromptLibraryAPI struct {
+	library *promptlib.Library
+	logger  *zap.Logger
+}
+
+// PromptTemplateEntry is a template and the variables a note must set.
+type PromptTemplateEntry struct {
+	promptlib.Template
+	Variables []string `json:"variables"`
+}
+
+// NewPromptLibraryAPI creates a PromptLibraryAPI. library is nil when the
+// database is disabled.
+func NewPromptLibraryAPI(library *promptlib.Library, logger *zap.Logger) *PromptLibraryAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &PromptLibraryAPI{library: library, logger: logger}
+}
+
+// HandleGet handles GET /api/prompts requests.
+func (api *PromptLibraryAPI) HandleGet(w http.ResponseWriter, r *http.Request) {
+	if name := r.URL.Query().Get("name"); name != "" {
+		t, ok := api.library.Get(name)
+		if !ok {
+			writeError(w, http.StatusNotFound, "prompt template not found")
+			return
+		}
+		writeJSON(w, http.StatusOK, newPromptTemplateEntry(t))
+		return
+	}
+
+	templates := api.library.List()
+	entries := make([]PromptTemplateEntry, 0, len(templates))
+	for _, t := range templates {
+		entries = append(entries, newPromptTemplateEntry(t))
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// HandlePut handles PUT /api/prompts requests. The body replaces any
+// template with the same name.
+func (api *PromptLibraryAPI) HandlePut(w http.ResponseWriter, r *http.Request) {
+	var t promptlib.Template
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxPromptTemplateBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&t); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid prompt template: "+err.Error())
+		return
+	}
+
+	saved, err := api.library.Put(r.Context(), t)
+	if err != nil {
+		if errors.Is(err, promptlib.ErrInvalidTemplate) {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		api.logger.Error("Failed to save prompt template", zap.String("name", t.Name), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to save prompt template")
+		return
+	}
+
+	api.logger.Info("Prompt template saved", zap.String("name", saved.Name))
+	writeJSON(w, http.StatusOK, newPromptTemplateEntry(saved))
+}
+
+// HandleDelete handles DELETE /api/prompts?name=X requests.
+func (api *PromptLibraryAPI) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if err := api.library.Delete(r.Context(), name); err != nil {
+		if errors.Is(err, promptlib.ErrUnknownTemplate) {
+			writeError(w, http.StatusNotFound, "prompt template not found")
+			return
+		}
+		api.logger.Error("Failed to delete prompt template", zap.String("name", name), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to delete prompt template")
+		return
+	}
+
+	api.logger.Info("Prompt template deleted", zap.String("name", name))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegisterRoutes registers the prompt library route on mux. If protect is
+// non-nil it wraps the handlers that change templates.
+func (api *PromptLibraryAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	put := api.HandlePut
+	del := api.HandleDelete
+	if protect != nil {
+		put = protect(put)
+		del = protect(del)
+	}
+	mux.HandleFunc("/api/prompts", func(w http.ResponseWriter, r *http.Request) {
+		if api.library == nil {
+			writeError(w, http.StatusNotFound, "prompt library is unavailable (database disabled)")
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			api.HandleGet(w, r)
+		case http.MethodPut:
+			put(w, r)
+		case http.MethodDelete:
+			del(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	})
+}
+
+// newPromptTemplateEntry lists the variables of t.
+func newPromptTemplateEntry(t promptlib.Template) PromptTemplateEntry {
+	vars := t.Variables()
+	if vars == nil {
+		vars = []string{}
+	}
+	return PromptTemplateEntry{Template: t, Variables: vars}
+}
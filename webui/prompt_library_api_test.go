@@ -0,0 +1,112 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_backend/promptlib"
+)
+
+// memoryPromptStorage keeps prompt templates in a map
+type memoryPromptStorage struct {
+	saved map[string]promptlib.Template
+}
+
+func (m *memoryPromptStorage) ListPromptTemplates(ctx context.Context) ([]promptlib.Template, error) {
+	var out []promptlib.Template
+	for _, t := range m.saved {
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+func (m *memoryPromptStorage) SavePromptTemplate(ctx context.Context, t promptlib.Template) error {
+	m.saved[t.Name] = t
+	return nil
+}
+
+func (m *memoryPromptStorage) DeletePromptTemplate(ctx context.Context, name string) error {
+	delete(m.saved, name)
+	return nil
+}
+
+func newTestPromptLibraryAPI(t *testing.T) (*http.ServeMux, *memoryPromptStorage) {
+	t.Helper()
+	storage := &memoryPromptStorage{saved: map[string]promptlib.Template{
+		"standup": {Name: "standup", Body: "Summarize the standup of {team}: {text}"},
+	}}
+	library, err := promptlib.NewLibrary(context.Background(), storage)
+	if err != nil {
+		t.Fatalf("NewLibrary failed: %v", err)
+	}
+	mux := http.NewServeMux()
+	NewPromptLibraryAPI(library, nil).RegisterRoutes(mux, nil)
+	return mux, storage
+}
+
+func TestPromptLibraryAPIList(t *testing.T) {
+	mux, _ := newTestPromptLibraryAPI(t)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/prompts", nil))
+	var list []PromptTemplateEntry
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil || len(list) != 1 {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+	if list[0].Name != "standup" || len(list[0].Variables) != 1 || list[0].Variables[0] != "team" {
+		t.Errorf("entry = %+v", list[0])
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/prompts?name=missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown template status = %d, want 404", rec.Code)
+	}
+}
+
+func TestPromptLibraryAPIPutAndDelete(t *testing.T) {
+	mux, storage := newTestPromptLibraryAPI(t)
+
+	body := `{"name": "weekly-review", "description": "Friday wrap-up", "body": "Review week {week}: {text}"}`
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/prompts", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("put status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if _, ok := storage.saved["weekly-review"]; !ok {
+		t.Error("template was not saved")
+	}
+
+	for _, bad := range []string{`{"name": "Bad Name", "body": "x"}`, `{"name": "ok", "body": "x", "bogus": 1}`} {
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/prompts", strings.NewReader(bad)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("put %s status = %d, want 400", bad, rec.Code)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/prompts?name=standup", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/api/prompts?name=standup", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("second delete status = %d, want 404", rec.Code)
+	}
+}
+
+func TestPromptLibraryAPIDisabled(t *testing.T) {
+	mux := http.NewServeMux()
+	NewPromptLibraryAPI(nil, nil).RegisterRoutes(mux, nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/prompts", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 without a database", rec.Code)
+	}
+}
@@ -0,0 +1,113 @@
+// Package webui provides the web-based user interface for CanvusLocalLLM.
+// This file contains the ReadinessTracker molecule used by /health/ready.
+//
+// Components that need startup work before serving requests (e.g., model
+// warmup) register as pending and are marked ready or failed when done.
+// The service reports ready once no component is pending; failed components
+// do not block readiness but are reported so the dashboard can flag them.
+package webui
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Readiness states for a component.
+const (
+	ReadinessPending = "pending"
+	ReadinessReady   = "ready"
+	ReadinessFailed  = "failed"
+)
+
+// ComponentReadiness describes the readiness of a single component.
+type ComponentReadiness struct {
+	Name     string        `json:"name"`
+	State    string        `json:"state"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration_ns,omitempty"`
+}
+
+// ReadinessTracker tracks startup readiness of named components.
+// Thread-safe: all methods may be called concurrently.
+type ReadinessTracker struct {
+	mu         sync.RWMutex
+	components map[string]*ComponentReadiness
+	started    map[string]time.Time
+}
+
+// NewReadinessTracker creates an empty tracker, which reports ready.
+func NewReadinessTracker() *ReadinessTracker {
+	return &ReadinessTracker{
+		components: make(map[string]*ComponentReadiness),
+		started:    make(map[string]time.Time),
+	}
+}
+
+// Add registers a component as pending.
+func (t *ReadinessTracker) Add(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.components[name] = &ComponentReadiness{Name: name, State: ReadinessPending}
+	t.started[name] = time.Now()
+}
+
+// MarkReady marks a component as ready.
+func (t *ReadinessTracker) MarkReady(name string) {
+	t.finish(name, ReadinessReady, nil)
+}
+
+// MarkFailed marks a component as failed with the given error.
+func (t *ReadinessTracker) MarkFailed(name string, err error) {
+	t.finish(name, ReadinessFailed, err)
+}
+
+func (t *ReadinessTracker) finish(name, state string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c, ok := t.components[name]
+	if !ok {
+		c = &ComponentReadiness{Name: name}
+		t.components[name] = c
+	}
+	c.State = state
+	if err != nil {
+		c.Error = err.Error()
+	}
+	if start, ok := t.started[name]; ok {
+		c.Duration = time.Since(start)
+	}
+}
+
+// IsReady returns true when no component is pending.
+// A nil tracker is always ready.
+func (t *ReadinessTracker) IsReady() bool {
+	if t == nil {
+		return true
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, c := range t.components {
+		if c.State == ReadinessPending {
+			return false
+		}
+	}
+	return true
+}
+
+// Components returns a snapshot of all components sorted by name.
+func (t *ReadinessTracker) Components() []ComponentReadiness {
+	if t == nil {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result := make([]ComponentReadiness, 0, len(t.components))
+	for _, c := range t.components {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
@@ -0,0 +1,50 @@
+package webui
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadinessTracker_Lifecycle(t *testing.T) {
+	tracker := NewReadinessTracker()
+	if !tracker.IsReady() {
+		t.Error("empty tracker should be ready")
+	}
+
+	tracker.Add("llama")
+	tracker.Add("stable-diffusion")
+	if tracker.IsReady() {
+		t.Error("tracker with pending components should not be ready")
+	}
+
+	tracker.MarkReady("llama")
+	if tracker.IsReady() {
+		t.Error("tracker should not be ready while stable-diffusion is pending")
+	}
+
+	tracker.MarkFailed("stable-diffusion", errors.New("out of VRAM"))
+	if !tracker.IsReady() {
+		t.Error("failed components should not block readiness")
+	}
+
+	components := tracker.Components()
+	if len(components) != 2 {
+		t.Fatalf("Components() len = %d, want 2", len(components))
+	}
+	if components[0].Name != "llama" || components[0].State != ReadinessReady {
+		t.Errorf("components[0] = %+v, want llama ready", components[0])
+	}
+	if components[1].State != ReadinessFailed || components[1].Error != "out of VRAM" {
+		t.Errorf("components[1] = %+v, want failed with error", components[1])
+	}
+}
+
+func TestReadinessTracker_Nil(t *testing.T) {
+	var tracker *ReadinessTracker
+	if !tracker.IsReady() {
+		t.Error("nil tracker should be ready")
+	}
+	if tracker.Components() != nil {
+		t.Error("nil tracker should have no components")
+	}
+}
@@ -8,7 +8,13 @@ import (
 	"net/http"
 	"time"
 
+	"go_backend/cluster"
+	"go_backend/instancelease"
 	"go_backend/metrics"
+	"go_backend/recovery"
+	"go_backend/streamstate"
+	"go_backend/tempfiles"
+	"go_backend/watchdog"
 	"go_backend/webui/static"
 
 	"go.uber.org/zap"
@@ -50,6 +56,7 @@ type WebUIServer struct {
 	dashboardAPI  *DashboardAPI
 	wsBroadcaster *WebSocketBroadcaster
 	staticHandler *StaticAssetHandler
+	readiness     *ReadinessTracker
 }
 
 // ServerConfig configures the WebUIServer.
@@ -92,7 +99,7 @@ func DefaultServerConfig() ServerConfig {
 		IdleTimeout:     120 * time.Second,
 		ShutdownTimeout: 30 * time.Second,
 		StaticConfig:    DefaultStaticAssetConfig(),
-		LogSkipPaths:    []string{"/health", "/api/status"},
+		LogSkipPaths:    []string{"/health", "/health/ready", "/api/status"},
 		VersionInfo: VersionInfo{
 			Version: "1.0.0",
 		},
@@ -133,8 +140,14 @@ func NewServer(
 	}
 	dashboardAPI := NewDashboardAPI(metricsStore, gpuCollector, apiConfig)
 
-	// Create WebSocket broadcaster
+	// Create WebSocket broadcaster; subscribing clients without a resume
+	// point are sent the current state
 	wsBroadcaster := NewWebSocketBroadcaster()
+	if metricsStore != nil {
+		wsBroadcaster.SetInitialStateProvider(func() InitialData {
+			return NewInitialData(metricsStore, apiConfig.DefaultLimit)
+		})
+	}
 
 	server := &WebUIServer{
 		mux:           mux,
@@ -172,6 +185,7 @@ func NewServer(
 func (s *WebUIServer) setupRoutes() {
 	// Health check endpoint (no auth required)
 	s.mux.HandleFunc("/health", s.handleHealth)
+	s.mux.HandleFunc("/health/ready", s.handleReady)
 
 	// Static assets
 	s.staticHandler.RegisterRoutes(s.mux)
@@ -186,6 +200,9 @@ func (s *WebUIServer) setupRoutes() {
 	// WebSocket endpoint
 	s.mux.HandleFunc("/ws", s.wsBroadcaster.HandleConnection)
 
+	// Server-Sent Events fallback for proxies that block WebSockets
+	s.mux.HandleFunc("/events", s.wsBroadcaster.HandleEvents)
+
 	// Auth routes (if enabled)
 	if s.authProvider != nil {
 		s.mux.HandleFunc("/login", s.authProvider.LoginHandler())
@@ -229,6 +246,201 @@ func (s *WebUIServer) handleHealth(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"status":"ok"}`))
 }
 
+// ReadyResponse represents the JSON response for /health/ready.
+type ReadyResponse struct {
+	Status     string               `json:"status"`
+	Components []ComponentReadiness `json:"components,omitempty"`
+}
+
+// handleReady handles readiness requests. It returns 503 until all
+// registered components (e.g., model warmup) have finished.
+func (s *WebUIServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	response := ReadyResponse{
+		Status:     "ready",
+		Components: s.readiness.Components(),
+	}
+	status := http.StatusOK
+	if !s.readiness.IsReady() {
+		response.Status = "warming_up"
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, status, response)
+}
+
+// SetReadiness sets the tracker reported by /health/ready and /api/status.
+// Without a tracker the server always reports ready.
+func (s *WebUIServer) SetReadiness(tracker *ReadinessTracker) {
+	s.readiness = tracker
+	s.dashboardAPI.SetReadiness(tracker)
+}
+
+// SetGPUHistory sets the persisted GPU history /api/gpu/history reads.
+func (s *WebUIServer) SetGPUHistory(history *metrics.GPUHistory) {
+	s.dashboardAPI.SetGPUHistory(history)
+}
+
+// SetTaskHistory sets the persisted task history /api/tasks pages through.
+func (s *WebUIServer) SetTaskHistory(history TaskHistory) {
+	s.dashboardAPI.SetTaskHistory(history)
+}
+
+// SetWatchdog sets the Canvus health watchdog reported by /api/status.
+func (s *WebUIServer) SetWatchdog(wd *watchdog.Watchdog) {
+	s.dashboardAPI.SetWatchdog(wd)
+}
+
+// SetInstanceLease sets the canvas lease reported by /api/status.
+func (s *WebUIServer) SetInstanceLease(lease *instancelease.Lease) {
+	s.dashboardAPI.SetInstanceLease(lease)
+}
+
+// SetCluster sets the cluster node reported by /api/status.
+func (s *WebUIServer) SetCluster(node *cluster.Node) {
+	s.dashboardAPI.SetCluster(node)
+}
+
+// SetStreamState sets the widget stream state machine reported by
+// /api/status.
+func (s *WebUIServer) SetStreamState(m *streamstate.Machine) {
+	s.dashboardAPI.SetStreamState(m)
+}
+
+// SetTempFiles sets the temp file manager whose usage /api/status reports.
+func (s *WebUIServer) SetTempFiles(m *tempfiles.TempFileManager) {
+	s.dashboardAPI.SetTempFiles(m)
+}
+
+// SetRecovery sets the startup recovery summary /api/status reports.
+func (s *WebUIServer) SetRecovery(summary *recovery.Summary) {
+	s.dashboardAPI.SetRecovery(summary)
+}
+
+// SetModelCatalog registers the model catalog endpoints.
+// Starting downloads requires authentication when auth is enabled.
+func (s *WebUIServer) SetModelCatalog(api *ModelCatalogAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetWebhooks registers the webhook inspection endpoints.
+// Sending test events requires authentication when auth is enabled.
+func (s *WebUIServer) SetWebhooks(api *WebhooksAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetArtifacts registers the artifact endpoints.
+// Deleting, re-uploading and re-generating artifacts requires authentication
+// when auth is enabled.
+func (s *WebUIServer) SetArtifacts(api *ArtifactsAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetAudit registers the audit log export and verify endpoints.
+// Both require authentication when auth is enabled.
+func (s *WebUIServer) SetAudit(api *AuditAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetSessions registers the session recording and replay endpoints.
+// All of them require authentication when auth is enabled.
+func (s *WebUIServer) SetSessions(api *SessionsAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetCanvasSettings registers the per-canvas settings endpoint.
+// Changing settings requires authentication when auth is enabled.
+func (s *WebUIServer) SetCanvasSettings(api *CanvasSettingsAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetPromptLibrary registers the prompt library endpoint.
+// Changing templates requires authentication when auth is enabled.
+func (s *WebUIServer) SetPromptLibrary(api *PromptLibraryAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetFewShot registers the few-shot examples endpoint.
+// Changing examples requires authentication when auth is enabled.
+func (s *WebUIServer) SetFewShot(api *FewShotAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetExport registers the canvas export endpoint.
+// Exporting requires authentication when auth is enabled.
+func (s *WebUIServer) SetExport(api *ExportAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetImport registers the document import endpoint.
+// Importing requires authentication when auth is enabled.
+func (s *WebUIServer) SetImport(api *ImportAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetChatOps registers the Slack and Teams endpoints. They are verified by
+// request signature rather than by login.
+func (s *WebUIServer) SetChatOps(api *ChatOpsAPI) {
+	api.RegisterRoutes(s.mux)
+}
+
+// SetFeatureFlags registers the feature flag endpoint.
+// Changing overrides requires authentication when auth is enabled.
+func (s *WebUIServer) SetFeatureFlags(api *FeatureFlagsAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetBuildInfo registers the build info endpoint.
+func (s *WebUIServer) SetBuildInfo(api *BuildInfoAPI) {
+	api.RegisterRoutes(s.mux)
+}
+
+// SetSLO registers the SLO status endpoint.
+func (s *WebUIServer) SetSLO(api *SLOAPI) {
+	api.RegisterRoutes(s.mux)
+}
+
+// SetCanvasPreview registers the canvas map endpoint.
+// It requires authentication when auth is enabled.
+func (s *WebUIServer) SetCanvasPreview(api *CanvasPreviewAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetTriggers registers the remote trigger endpoints.
+// Both require authentication when auth is enabled.
+func (s *WebUIServer) SetTriggers(api *TriggersAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetWidgetHistory registers the widget inspector endpoint.
+// It requires authentication when auth is enabled.
+func (s *WebUIServer) SetWidgetHistory(api *WidgetHistoryAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetTags registers the note tag endpoints.
+// They require authentication when auth is enabled.
+func (s *WebUIServer) SetTags(api *TagsAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetLogs registers the log list and download endpoints.
+// Both require authentication when auth is enabled.
+func (s *WebUIServer) SetLogs(api *LogsAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetLogging registers the runtime log level endpoint.
+// It requires authentication when auth is enabled.
+func (s *WebUIServer) SetLogging(api *LoggingAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
+// SetLLMCapture registers the LLM capture endpoint.
+// It requires authentication when auth is enabled.
+func (s *WebUIServer) SetLLMCapture(api *LLMCaptureAPI) {
+	api.RegisterRoutes(s.mux, s.ProtectHandlerFunc)
+}
+
 // Start begins listening for HTTP requests.
 // It starts the WebSocket broadcaster and the HTTP server.
 // This method blocks until the server is shut down.
@@ -115,6 +115,43 @@ func TestWebUIServer_HealthEndpoint(t *testing.T) {
 	}
 }
 
+func TestWebUIServer_ReadyEndpoint(t *testing.T) {
+	config := DefaultServerConfig()
+	store := &mockMetricsStore{}
+
+	server, err := NewServer(config, store, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	tracker := NewReadinessTracker()
+	tracker.Add("llama")
+	server.SetReadiness(tracker)
+
+	req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+	rr := httptest.NewRecorder()
+	server.mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("status while warming up = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+	if !strings.Contains(rr.Body.String(), "warming_up") {
+		t.Errorf("body = %q, want to contain 'warming_up'", rr.Body.String())
+	}
+
+	tracker.MarkReady("llama")
+
+	rr = httptest.NewRecorder()
+	server.mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("status after warmup = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if !strings.Contains(rr.Body.String(), `"status":"ready"`) {
+		t.Errorf("body = %q, want ready status", rr.Body.String())
+	}
+}
+
 func TestWebUIServer_RootRedirect(t *testing.T) {
 	config := DefaultServerConfig()
 	store := &mockMetricsStore{}
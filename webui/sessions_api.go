@@ -0,0 +1,197 @@
+// Package webui provides the SessionsAPI organism for recorded canvas
+// sessions. This file contains the REST handlers that list sessions, return
+// their events and replay them.
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"go_backend/sessions"
+
+	"go.uber.org/zap"
+)
+
+// maxReplayBody limits the size of a replay request.
+const maxReplayBody = 16 << 10
+
+// defaultSessionsLimit is the number of sessions listed when no limit is given.
+const defaultSessionsLimit = 50
+
+// SessionsAPI is an organism that exposes recorded sessions.
+//
+// Endpoints (all require authentication when auth is enabled):
+// - GET  /api/sessions               - Recorded sessions, newest first (?canvas_id=X&limit=N)
+// - GET  /api/sessions/events?id=X   - Triggers and responses of a session, oldest first
+// - POST /api/sessions/replay        - Replay a session's note prompts (JSON ReplayRequest)
+type SessionsAPI struct {
+	recorder  *sessions.Recorder
+	respond   sessions.RespondFunc
+	canvasFor func(canvasID string) sessions.Canvas
+	logger    *zap.Logger
+}
+
+// ReplayRequest is the body of POST /api/sessions/replay.
+type ReplayRequest struct {
+	SessionID string `json:"session_id"`
+	// CanvasID receives the replayed prompts and responses; empty only
+	// compares them
+	CanvasID string `json:"canvas_id"`
+	// Model answers the prompts instead of the live model (optional)
+	Model string `json:"model"`
+}
+
+// NewSessionsAPI creates a SessionsAPI. recorder is nil when recording is
+// disabled. respond answers replayed prompts, and canvasFor returns a
+// client for the canvas a replay writes to.
+func NewSessionsAPI(recorder *sessions.Recorder, respond sessions.RespondFunc, canvasFor func(canvasID string) sessions.Canvas, logger *zap.Logger) *SessionsAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &SessionsAPI{recorder: recorder, respond: respond, canvasFor: canvasFor, logger: logger}
+}
+
+// HandleList handles GET /api/sessions requests.
+func (api *SessionsAPI) HandleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !api.requireRecorder(w) {
+		return
+	}
+
+	limit := defaultSessionsLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+
+	list, err := api.recorder.Sessions(r.Context(), r.URL.Query().Get("canvas_id"), limit)
+	if err != nil {
+		api.logger.Error("Failed to list sessions", zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to list sessions")
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// HandleEvents handles GET /api/sessions/events?id=X requests.
+func (api *SessionsAPI) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !api.requireRecorder(w) {
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, "id is required")
+		return
+	}
+
+	events, ok := api.sessionEvents(w, r, id)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, events)
+}
+
+// HandleReplay handles POST /api/sessions/replay requests. The replay runs
+// while the request is open, so it ends if the client disconnects.
+func (api *SessionsAPI) HandleReplay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !api.requireRecorder(w) {
+		return
+	}
+
+	var req ReplayRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxReplayBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid replay request: "+err.Error())
+		return
+	}
+	if req.SessionID == "" {
+		writeError(w, http.StatusBadRequest, "session_id is required")
+		return
+	}
+	if api.respond == nil {
+		writeError(w, http.StatusServiceUnavailable, "no model is available to replay prompts")
+		return
+	}
+
+	events, ok := api.sessionEvents(w, r, req.SessionID)
+	if !ok {
+		return
+	}
+	opts := sessions.ReplayOptions{Model: req.Model}
+	if req.CanvasID != "" && api.canvasFor != nil {
+		opts.Canvas = api.canvasFor(req.CanvasID)
+	}
+
+	report, err := sessions.Replay(r.Context(), events, api.respond, opts)
+	if err != nil {
+		api.logger.Warn("Session replay interrupted", zap.String("session_id", req.SessionID), zap.Error(err))
+		writeError(w, http.StatusServiceUnavailable, "replay interrupted: "+err.Error())
+		return
+	}
+	api.logger.Info("Session replayed",
+		zap.String("session_id", req.SessionID),
+		zap.String("target_canvas_id", req.CanvasID),
+		zap.String("model", req.Model),
+		zap.Int("replayed", report.Replayed),
+		zap.Int("changed", report.Changed),
+		zap.Int("failed", report.Failed))
+	writeJSON(w, http.StatusOK, report)
+}
+
+// RegisterRoutes registers the session routes on mux. If protect is
+// non-nil it wraps every handler, since sessions hold prompt text.
+func (api *SessionsAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	list := api.HandleList
+	events := api.HandleEvents
+	replay := api.HandleReplay
+	if protect != nil {
+		list = protect(list)
+		events = protect(events)
+		replay = protect(replay)
+	}
+	mux.HandleFunc("/api/sessions", list)
+	mux.HandleFunc("/api/sessions/events", events)
+	mux.HandleFunc("/api/sessions/replay", replay)
+}
+
+// sessionEvents reads the events of session id, writing an error if there
+// are none.
+func (api *SessionsAPI) sessionEvents(w http.ResponseWriter, r *http.Request, id string) ([]sessions.Event, bool) {
+	events, err := api.recorder.Events(r.Context(), id)
+	if err != nil {
+		api.logger.Error("Failed to read session", zap.String("session_id", id), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to read session")
+		return nil, false
+	}
+	if len(events) == 0 {
+		writeError(w, http.StatusNotFound, "session not found")
+		return nil, false
+	}
+	return events, true
+}
+
+// requireRecorder writes an error if recording is disabled.
+func (api *SessionsAPI) requireRecorder(w http.ResponseWriter) bool {
+	if api.recorder == nil {
+		writeError(w, http.StatusNotFound, "session recording is disabled (set SESSION_RECORDING=true)")
+		return false
+	}
+	return true
+}
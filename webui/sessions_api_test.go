@@ -0,0 +1,138 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_backend/sessions"
+)
+
+// memorySessionStorage keeps session events in a slice
+type memorySessionStorage struct {
+	events []sessions.Event
+}
+
+func (m *memorySessionStorage) InsertSessionEvent(ctx context.Context, e sessions.Event) (int64, error) {
+	e.ID = int64(len(m.events) + 1)
+	m.events = append(m.events, e)
+	return e.ID, nil
+}
+
+func (m *memorySessionStorage) LastSessionEvent(ctx context.Context, canvasID string) (*sessions.Event, error) {
+	return nil, nil
+}
+
+func (m *memorySessionStorage) ListSessions(ctx context.Context, canvasID string, limit int) ([]sessions.Session, error) {
+	if len(m.events) == 0 {
+		return []sessions.Session{}, nil
+	}
+	return []sessions.Session{{ID: m.events[0].SessionID, CanvasID: m.events[0].CanvasID, Triggers: 1, Responses: 1}}, nil
+}
+
+func (m *memorySessionStorage) ListSessionEvents(ctx context.Context, sessionID string) ([]sessions.Event, error) {
+	var out []sessions.Event
+	for _, e := range m.events {
+		if e.SessionID == sessionID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// replayCanvas counts the notes written by a replay
+type replayCanvas struct {
+	notes int
+}
+
+func (c *replayCanvas) CreateNote(payload map[string]interface{}) (map[string]interface{}, error) {
+	c.notes++
+	return map[string]interface{}{}, nil
+}
+
+func newTestSessionsAPI(t *testing.T) (*SessionsAPI, string, *replayCanvas, *string) {
+	t.Helper()
+	recorder := sessions.NewRecorder(&memorySessionStorage{}, 0)
+	ctx := context.Background()
+	trigger, err := recorder.Record(ctx, sessions.Event{CanvasID: "canvas-1", Kind: sessions.KindTrigger, CorrelationID: "c1", Operation: sessions.OperationNote, Text: "hello?"})
+	if err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	recorder.Record(ctx, sessions.Event{CanvasID: "canvas-1", Kind: sessions.KindResponse, CorrelationID: "c1", Operation: sessions.OperationNote, Text: "hi"})
+
+	canvas := &replayCanvas{}
+	targetCanvas := new(string)
+	respond := func(ctx context.Context, prompt, model string) (string, error) {
+		return "hello from " + model, nil
+	}
+	canvasFor := func(canvasID string) sessions.Canvas {
+		*targetCanvas = canvasID
+		return canvas
+	}
+	return NewSessionsAPI(recorder, respond, canvasFor, nil), trigger.SessionID, canvas, targetCanvas
+}
+
+func TestSessionsAPIListAndEvents(t *testing.T) {
+	api, sessionID, _, _ := newTestSessionsAPI(t)
+
+	rec := httptest.NewRecorder()
+	api.HandleList(rec, httptest.NewRequest(http.MethodGet, "/api/sessions?canvas_id=canvas-1", nil))
+	var list []sessions.Session
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil || len(list) != 1 || list[0].ID != sessionID {
+		t.Fatalf("list = %+v, %v", list, err)
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleEvents(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/events?id="+sessionID, nil))
+	var events []sessions.Event
+	if err := json.NewDecoder(rec.Body).Decode(&events); err != nil || len(events) != 2 {
+		t.Fatalf("events = %+v, %v", events, err)
+	}
+
+	rec = httptest.NewRecorder()
+	api.HandleEvents(rec, httptest.NewRequest(http.MethodGet, "/api/sessions/events?id=missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("unknown session status = %d, want 404", rec.Code)
+	}
+}
+
+func TestSessionsAPIReplay(t *testing.T) {
+	api, sessionID, canvas, target := newTestSessionsAPI(t)
+
+	body := `{"session_id": "` + sessionID + `", "canvas_id": "fresh-canvas", "model": "gpt-new"}`
+	rec := httptest.NewRecorder()
+	api.HandleReplay(rec, httptest.NewRequest(http.MethodPost, "/api/sessions/replay", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var report sessions.ReplayReport
+	if err := json.NewDecoder(rec.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Replayed != 1 || report.Changed != 1 || report.Steps[0].Replayed != "hello from gpt-new" || report.Steps[0].Recorded != "hi" {
+		t.Errorf("report = %+v", report)
+	}
+	if *target != "fresh-canvas" || canvas.notes != 2 {
+		t.Errorf("wrote %d notes to %q", canvas.notes, *target)
+	}
+
+	for _, bad := range []string{`{}`, `{"session_id": "x", "bogus": 1}`} {
+		rec = httptest.NewRecorder()
+		api.HandleReplay(rec, httptest.NewRequest(http.MethodPost, "/api/sessions/replay", strings.NewReader(bad)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("replay %s status = %d, want 400", bad, rec.Code)
+		}
+	}
+}
+
+func TestSessionsAPIDisabled(t *testing.T) {
+	api := NewSessionsAPI(nil, nil, nil, nil)
+	rec := httptest.NewRecorder()
+	api.HandleList(rec, httptest.NewRequest(http.MethodGet, "/api/sessions", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404 when recording is disabled", rec.Code)
+	}
+}
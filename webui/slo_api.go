@@ -0,0 +1,57 @@
+// Package webui provides the SLOAPI organism for service level objectives.
+// This file contains the REST handler backing the dashboard SLO list.
+package webui
+
+import (
+	"net/http"
+
+	"go_backend/metrics"
+
+	"go.uber.org/zap"
+)
+
+// SLOResponse represents the JSON response for GET /api/slo.
+type SLOResponse struct {
+	Enabled  bool                `json:"enabled"`
+	Statuses []metrics.SLOStatus `json:"statuses"`
+}
+
+// SLOAPI is an organism that exposes the latest SLO evaluations.
+//
+// Endpoints:
+// - GET /api/slo - Burn rates and violation state of every SLO
+type SLOAPI struct {
+	monitor *metrics.SLOMonitor
+	logger  *zap.Logger
+}
+
+// NewSLOAPI creates an SLOAPI for monitor, which may be nil when no SLOs
+// are configured.
+func NewSLOAPI(monitor *metrics.SLOMonitor, logger *zap.Logger) *SLOAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &SLOAPI{monitor: monitor, logger: logger}
+}
+
+// HandleSLO handles GET /api/slo requests.
+func (api *SLOAPI) HandleSLO(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	statuses := api.monitor.Statuses()
+	if statuses == nil {
+		statuses = []metrics.SLOStatus{}
+	}
+	writeJSON(w, http.StatusOK, SLOResponse{
+		Enabled:  api.monitor.Enabled(),
+		Statuses: statuses,
+	})
+}
+
+// RegisterRoutes registers the SLO routes on mux.
+func (api *SLOAPI) RegisterRoutes(mux *http.ServeMux) {
+	mux.HandleFunc("/api/slo", api.HandleSLO)
+}
@@ -0,0 +1,53 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go_backend/metrics"
+)
+
+func TestSLOAPI_HandleSLO(t *testing.T) {
+	t.Run("disabled without monitor", func(t *testing.T) {
+		api := NewSLOAPI(nil, nil)
+
+		rec := httptest.NewRecorder()
+		api.HandleSLO(rec, httptest.NewRequest(http.MethodGet, "/api/slo", nil))
+
+		var resp SLOResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Code != http.StatusOK || resp.Enabled || resp.Statuses == nil {
+			t.Errorf("unexpected response: %d %+v", rec.Code, resp)
+		}
+	})
+
+	t.Run("lists evaluated SLOs", func(t *testing.T) {
+		store := metrics.NewMetricsStore(metrics.DefaultStoreConfig(), time.Now())
+		store.RecordTask(metrics.TaskRecord{Type: "note", Status: metrics.TaskStatusSuccess, Duration: time.Second})
+		slos, _ := metrics.ParseSLOs("note:errors<2%")
+		monitor := metrics.NewSLOMonitor(store, metrics.SLOConfig{SLOs: slos})
+		monitor.Check()
+
+		rec := httptest.NewRecorder()
+		NewSLOAPI(monitor, nil).HandleSLO(rec, httptest.NewRequest(http.MethodGet, "/api/slo", nil))
+
+		var resp SLOResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if !resp.Enabled || len(resp.Statuses) != 1 || resp.Statuses[0].SLO != "note:errors<2%" {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+	})
+
+	t.Run("rejects POST", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewSLOAPI(nil, nil).HandleSLO(rec, httptest.NewRequest(http.MethodPost, "/api/slo", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want 405", rec.Code)
+		}
+	})
+}
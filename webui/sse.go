@@ -0,0 +1,155 @@
+// Package webui provides the web-based user interface for CanvusLocalLLM.
+// This file contains the Server-Sent Events fallback of the WebSocketBroadcaster
+// for clients behind proxies that block WebSockets.
+package webui
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HandleEvents streams the broadcaster's messages as Server-Sent Events.
+//
+// Each event's data is a message with the same JSON schema as the WebSocket
+// stream. Query parameters replace the WebSocket subscribe request:
+//
+//	/events?topics=tasks,gpu&client_id=tab-1&last_seq=1042&epoch=1760601600000000000
+//
+// Without topics every topic is streamed. Broadcast messages carry an event
+// ID of the form "<epoch>-<seq>", so a browser EventSource reconnecting with
+// Last-Event-ID resumes like a WebSocket client sending last_seq.
+func (b *WebSocketBroadcaster) HandleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Streams outlive the server's write timeout; deadlines are set per write
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// Disable response buffering in nginx-style proxies
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		b.logger.Printf("Event stream from %s not supported: %v", r.RemoteAddr, err)
+		return
+	}
+
+	info := clientInfo{
+		connectedAt: time.Now(),
+		remoteAddr:  r.RemoteAddr,
+		send:        make(chan []byte, 256),
+		sub:         newSubscription(),
+	}
+	b.addStream(info, parseStreamRequest(r))
+	defer b.removeStream(info.send)
+
+	ping := time.NewTicker(b.pingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case data, ok := <-info.send:
+			if !ok {
+				return
+			}
+			rc.SetWriteDeadline(time.Now().Add(b.writeWait))
+			if err := writeEvent(w, b.epoch, data); err != nil {
+				b.logger.Printf("Event stream write error: %v", err)
+				return
+			}
+
+		case <-ping.C:
+			// A comment line keeps proxies from closing an idle stream
+			rc.SetWriteDeadline(time.Now().Add(b.writeWait))
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// addStream registers an event stream and queues its catch-up messages
+func (b *WebSocketBroadcaster) addStream(info clientInfo, req ClientRequest) {
+	b.historyMu.Lock()
+	defer b.historyMu.Unlock()
+	b.clientsMu.Lock()
+	defer b.clientsMu.Unlock()
+
+	b.streams[info.send] = info
+	b.catchUp(info, req)
+
+	b.logger.Printf("Event stream connected: %s (total: %d)", info.remoteAddr, len(b.clients)+len(b.streams))
+}
+
+// removeStream unregisters an event stream and closes its send channel
+func (b *WebSocketBroadcaster) removeStream(send chan []byte) {
+	b.clientsMu.Lock()
+	defer b.clientsMu.Unlock()
+
+	if info, ok := b.streams[send]; ok {
+		close(send)
+		delete(b.streams, send)
+		b.logger.Printf("Event stream disconnected: %s (total: %d)", info.remoteAddr, len(b.clients)+len(b.streams))
+	}
+}
+
+// parseStreamRequest builds the subscribe request of an event stream from
+// its query parameters and Last-Event-ID header
+func parseStreamRequest(r *http.Request) ClientRequest {
+	query := r.URL.Query()
+	req := ClientRequest{
+		Type:     RequestSubscribe,
+		ClientID: query.Get("client_id"),
+	}
+	for _, topic := range strings.Split(query.Get("topics"), ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			req.Topics = append(req.Topics, topic)
+		}
+	}
+	if len(req.Topics) == 0 {
+		req.Topics = []string{TopicAll}
+	}
+
+	req.LastSeq, _ = strconv.ParseUint(query.Get("last_seq"), 10, 64)
+	req.Epoch, _ = strconv.ParseInt(query.Get("epoch"), 10, 64)
+
+	// The browser's automatic reconnect sends the last event ID instead
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		epoch, seq, ok := strings.Cut(lastID, "-")
+		if ok {
+			req.Epoch, _ = strconv.ParseInt(epoch, 10, 64)
+			req.LastSeq, _ = strconv.ParseUint(seq, 10, 64)
+		}
+	}
+	return req
+}
+
+// writeEvent writes one message as an event, with an ID if it has a
+// sequence number
+func writeEvent(w http.ResponseWriter, epoch int64, data []byte) error {
+	var head struct {
+		Seq uint64 `json:"seq"`
+	}
+	if err := json.Unmarshal(data, &head); err == nil && head.Seq > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d-%d\n", epoch, head.Seq); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "data: %s\n\n", data)
+	return err
+}
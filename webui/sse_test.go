@@ -0,0 +1,153 @@
+package webui
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseEvent is a received Server-Sent Event
+type sseEvent struct {
+	id  string
+	msg wireMessage
+}
+
+// readSSEEvent reads the next event, skipping comment lines
+func readSSEEvent(t *testing.T, reader *bufio.Reader) sseEvent {
+	t.Helper()
+	var event sseEvent
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Failed to read event: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "" && event.msg.Type != "":
+			return event
+		case strings.HasPrefix(line, "id: "):
+			event.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.msg); err != nil {
+				t.Fatalf("Failed to decode event data: %v", err)
+			}
+		}
+	}
+}
+
+// openEventStream starts a broadcaster and opens an event stream on it
+func openEventStream(t *testing.T, b *WebSocketBroadcaster, query string, header http.Header) *bufio.Reader {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(b.HandleEvents))
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/events"+query, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Failed to open event stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q, want text/event-stream", ct)
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+func startBroadcaster(t *testing.T) *WebSocketBroadcaster {
+	t.Helper()
+	config := DefaultBroadcasterConfig()
+	config.Logger = &mockLogger{}
+	b := NewWebSocketBroadcasterWithConfig(config)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go b.Start(ctx)
+	return b
+}
+
+func TestHandleEvents_StreamsSubscribedTopics(t *testing.T) {
+	b := startBroadcaster(t)
+	reader := openEventStream(t, b, "?topics=gpu", nil)
+
+	if event := readSSEEvent(t, reader); event.msg.Type != MessageTypeSubscribed {
+		t.Fatalf("expected subscribed, got %s", event.msg.Type)
+	}
+
+	b.BroadcastTaskUpdate(TaskUpdateData{TaskID: "t1"})
+	b.BroadcastGPUUpdate(GPUUpdateData{Utilization: 42})
+
+	event := readSSEEvent(t, reader)
+	if event.msg.Type != MessageTypeGPUUpdate || event.msg.Seq != 2 {
+		t.Fatalf("expected gpu_update seq 2, got %s seq %d", event.msg.Type, event.msg.Seq)
+	}
+	if want := fmt.Sprintf("%d-2", b.epoch); event.id != want {
+		t.Errorf("event id = %q, want %q", event.id, want)
+	}
+	if b.ClientCount() != 1 {
+		t.Errorf("expected 1 client, got %d", b.ClientCount())
+	}
+}
+
+func TestHandleEvents_ResumeFromLastEventID(t *testing.T) {
+	b := startBroadcaster(t)
+	for _, id := range []string{"t1", "t2", "t3"} {
+		b.BroadcastTaskUpdate(TaskUpdateData{TaskID: id})
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	header := http.Header{"Last-Event-ID": {fmt.Sprintf("%d-1", b.epoch)}}
+	reader := openEventStream(t, b, "", header)
+
+	event := readSSEEvent(t, reader)
+	var sub SubscribedData
+	json.Unmarshal(event.msg.Data, &sub)
+	if event.msg.Type != MessageTypeSubscribed || sub.Replayed != 2 {
+		t.Fatalf("expected subscribed with 2 replayed, got %s %+v", event.msg.Type, sub)
+	}
+	for _, want := range []string{"t2", "t3"} {
+		if event := readSSEEvent(t, reader); !strings.Contains(string(event.msg.Data), want) {
+			t.Errorf("expected replay of %s, got %s", want, event.msg.Data)
+		}
+	}
+}
+
+func TestHandleEvents_MethodNotAllowed(t *testing.T) {
+	b := NewWebSocketBroadcaster()
+	w := httptest.NewRecorder()
+	b.HandleEvents(w, httptest.NewRequest(http.MethodPost, "/events", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", w.Code)
+	}
+}
+
+func TestParseStreamRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/events?topics=tasks,+canvas:c1&client_id=tab&last_seq=7&epoch=99", nil)
+	req := parseStreamRequest(r)
+	if len(req.Topics) != 2 || req.Topics[1] != "canvas:c1" {
+		t.Errorf("unexpected topics %v", req.Topics)
+	}
+	if req.ClientID != "tab" || req.LastSeq != 7 || req.Epoch != 99 {
+		t.Errorf("unexpected request %+v", req)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/events", nil)
+	r.Header.Set("Last-Event-ID", "12-34")
+	req = parseStreamRequest(r)
+	if len(req.Topics) != 1 || req.Topics[0] != TopicAll {
+		t.Errorf("expected all topics by default, got %v", req.Topics)
+	}
+	if req.Epoch != 12 || req.LastSeq != 34 {
+		t.Errorf("expected resume from Last-Event-ID, got %+v", req)
+	}
+}
@@ -6,6 +6,11 @@
  * - WebSocket connection for real-time updates
  * - UI rendering and event handling
  * - GPU metrics visualization
+ * - Model catalog downloads
+ * - Canvas map with live AI activity
+ * - Remote triggers
+ * - Widget AI history inspector
+ * - Generated image gallery
  */
 
 class DashboardApp {
@@ -14,7 +19,8 @@ class DashboardApp {
         this.ws = new WebSocketClient({
             reconnectInterval: 5000,
             maxReconnectInterval: 30000,
-            reconnectDecay: 1.5
+            reconnectDecay: 1.5,
+            topics: ['tasks', 'gpu', 'system', 'canvases', 'logs']
         });
 
         // State
@@ -22,10 +28,24 @@ class DashboardApp {
         this.canvases = [];
         this.tasks = [];
         this.metrics = null;
+        this.latencyAlerts = {};
         this.gpuMetrics = null;
         this.gpuHistory = [];
         this.activityLog = [];
         this.activityFilter = 'all';
+        this.models = [];
+        this.localModels = null;
+        this.modelPollTimer = null;
+        this.webhooks = null;
+        this.webhookPollTimer = null;
+        this.slo = null;
+        this.sloPollTimer = null;
+        this.canvasSettings = null;
+        this.featureFlags = null;
+        this.promptTemplates = [];
+        this.canvasMap = null; // last /api/canvas/preview, updated over WebSocket
+        this.galleryImages = [];
+        this.galleryLimit = 24; // raised by "Show more"
 
         // GPU chart (Chart.js)
         this.gpuChart = null;
@@ -34,6 +54,7 @@ class DashboardApp {
         this.gpuChartMemory = [];
         this.maxChartPoints = 720; // 1 hour at 5-second intervals (60 * 60 / 5 = 720)
         this.gpuAvailable = false;
+        this.gpuRange = 'live'; // 'live' or a /api/gpu/history range
 
         // DOM elements (cached after init)
         this.elements = {};
@@ -77,6 +98,7 @@ class DashboardApp {
             // Connection status
             connectionStatus: document.getElementById('connection-status'),
             versionInfo: document.getElementById('version-info'),
+            canvusAlertBanner: document.getElementById('canvus-alert-banner'),
 
             // System status
             systemHealthBadge: document.getElementById('system-health-badge'),
@@ -84,6 +106,10 @@ class DashboardApp {
             systemUptime: document.getElementById('system-uptime'),
             gpuAvailable: document.getElementById('gpu-available'),
             lastCheck: document.getElementById('last-check'),
+            tempFiles: document.getElementById('temp-files'),
+            lastStart: document.getElementById('last-start'),
+            monitorState: document.getElementById('monitor-state'),
+            clusterStatus: document.getElementById('cluster-status'),
 
             // GPU metrics
             gpuStatusBadge: document.getElementById('gpu-status-badge'),
@@ -99,6 +125,7 @@ class DashboardApp {
             gpuPowerBar: document.getElementById('gpu-power-bar'),
             gpuChart: document.getElementById('gpu-chart'),
             gpuChartContainer: document.getElementById('gpu-chart-container'),
+            gpuRange: document.getElementById('gpu-range'),
 
             // Canvas status
             canvasCountBadge: document.getElementById('canvas-count-badge'),
@@ -109,6 +136,8 @@ class DashboardApp {
             totalSuccess: document.getElementById('total-success'),
             totalErrors: document.getElementById('total-errors'),
             successRate: document.getElementById('success-rate'),
+            metricsFiltered: document.getElementById('metrics-filtered'),
+            metricsJSONRepair: document.getElementById('metrics-json-repair'),
             metricsByType: document.getElementById('metrics-by-type'),
 
             // Queue
@@ -120,6 +149,110 @@ class DashboardApp {
             activityFilter: document.getElementById('activity-filter'),
             clearActivityBtn: document.getElementById('clear-activity-btn'),
 
+            // Model catalog
+            modelCountBadge: document.getElementById('model-count-badge'),
+            modelNotice: document.getElementById('model-notice'),
+            modelList: document.getElementById('model-list'),
+            localModelList: document.getElementById('local-model-list'),
+            localModelsDisk: document.getElementById('local-models-disk'),
+
+            // Webhooks
+            webhookCountBadge: document.getElementById('webhook-count-badge'),
+            webhookNotice: document.getElementById('webhook-notice'),
+            webhookTargets: document.getElementById('webhook-targets'),
+            webhookDeliveries: document.getElementById('webhook-deliveries'),
+            webhookTestBtn: document.getElementById('webhook-test-btn'),
+
+            // Canvas settings
+            canvasSettingsSelect: document.getElementById('canvas-settings-select'),
+            canvasSettingsBadge: document.getElementById('canvas-settings-badge'),
+            canvasSettingsNotice: document.getElementById('canvas-settings-notice'),
+            canvasSettingsForm: document.getElementById('canvas-settings-form'),
+            canvasSettingsFeatures: document.getElementById('canvas-settings-features'),
+            canvasSettingsReset: document.getElementById('canvas-settings-reset'),
+            canvasSettingsError: document.getElementById('canvas-settings-error'),
+
+            // Feature flags
+            featureFlagsCanvas: document.getElementById('feature-flags-canvas'),
+            featureFlagsList: document.getElementById('feature-flags-list'),
+            featureFlagsError: document.getElementById('feature-flags-error'),
+
+            // Prompt library
+            promptLibraryCount: document.getElementById('prompt-library-count'),
+            promptLibraryNotice: document.getElementById('prompt-library-notice'),
+            promptLibraryList: document.getElementById('prompt-library-list'),
+            promptLibraryForm: document.getElementById('prompt-library-form'),
+            promptLibraryClear: document.getElementById('prompt-library-clear'),
+            promptLibraryError: document.getElementById('prompt-library-error'),
+            canvasExportForm: document.getElementById('canvas-export-form'),
+            canvasExportCanvas: document.getElementById('canvas-export-canvas'),
+            canvasExportError: document.getElementById('canvas-export-error'),
+            documentImportForm: document.getElementById('document-import-form'),
+            documentImportCanvas: document.getElementById('document-import-canvas'),
+            documentImportSubmit: document.getElementById('document-import-submit'),
+            documentImportStatus: document.getElementById('document-import-status'),
+            documentImportError: document.getElementById('document-import-error'),
+
+            // SLOs
+            sloList: document.getElementById('slo-list'),
+
+            // Canvas map
+            canvasMap: document.getElementById('canvas-map'),
+            canvasMapCanvas: document.getElementById('canvas-map-canvas'),
+            canvasMapBadge: document.getElementById('canvas-map-badge'),
+            canvasMapNotice: document.getElementById('canvas-map-notice'),
+
+            // Remote trigger
+            remoteTriggerForm: document.getElementById('remote-trigger-form'),
+            remoteSubmitForm: document.getElementById('remote-submit-form'),
+            remoteTriggerStatus: document.getElementById('remote-trigger-status'),
+            remoteTriggerError: document.getElementById('remote-trigger-error'),
+
+            // Widget inspector
+            widgetInspectorForm: document.getElementById('widget-inspector-form'),
+            widgetInspectorList: document.getElementById('widget-inspector-list'),
+            widgetInspectorCount: document.getElementById('widget-inspector-count'),
+            widgetInspectorError: document.getElementById('widget-inspector-error'),
+
+            // Logging
+            logLevelForm: document.getElementById('log-level-form'),
+            logPackageForm: document.getElementById('log-package-form'),
+            logPackageList: document.getElementById('log-package-list'),
+            logBodyForm: document.getElementById('log-body-form'),
+            logBodyStop: document.getElementById('log-body-stop'),
+            logBodyStatus: document.getElementById('log-body-status'),
+            logSettingsError: document.getElementById('log-settings-error'),
+
+            // LLM capture
+            llmCaptureForm: document.getElementById('llm-capture-form'),
+            llmCaptureStop: document.getElementById('llm-capture-stop'),
+            llmCaptureStatus: document.getElementById('llm-capture-status'),
+            llmCaptureFilter: document.getElementById('llm-capture-filter'),
+            llmCaptureRefresh: document.getElementById('llm-capture-refresh'),
+            llmCaptureClear: document.getElementById('llm-capture-clear'),
+            llmCaptureList: document.getElementById('llm-capture-list'),
+            llmCaptureError: document.getElementById('llm-capture-error'),
+
+            // Few-shot examples
+            fewShotTaskFilter: document.getElementById('fewshot-task-filter'),
+            fewShotCount: document.getElementById('fewshot-count'),
+            fewShotMax: document.getElementById('fewshot-max'),
+            fewShotNotice: document.getElementById('fewshot-notice'),
+            fewShotList: document.getElementById('fewshot-list'),
+            fewShotForm: document.getElementById('fewshot-form'),
+            fewShotTask: document.getElementById('fewshot-task'),
+            fewShotClear: document.getElementById('fewshot-clear'),
+            fewShotError: document.getElementById('fewshot-error'),
+
+            // Image gallery
+            gallerySearchForm: document.getElementById('gallery-search-form'),
+            galleryCount: document.getElementById('gallery-count'),
+            galleryNotice: document.getElementById('gallery-notice'),
+            galleryGrid: document.getElementById('gallery-grid'),
+            galleryMore: document.getElementById('gallery-more'),
+            galleryStatus: document.getElementById('gallery-status'),
+            galleryError: document.getElementById('gallery-error'),
+
             // Footer
             footerStatus: document.getElementById('footer-status'),
             footerVersion: document.getElementById('footer-version'),
@@ -139,6 +272,13 @@ class DashboardApp {
             });
         }
 
+        // GPU chart range
+        if (this.elements.gpuRange) {
+            this.elements.gpuRange.addEventListener('change', (e) => {
+                this.setGPURange(e.target.value);
+            });
+        }
+
         // Clear activity button
         if (this.elements.clearActivityBtn) {
             this.elements.clearActivityBtn.addEventListener('click', () => {
@@ -146,6 +286,219 @@ class DashboardApp {
                 this.renderActivityLog();
             });
         }
+
+        // Model download buttons
+        if (this.elements.modelList) {
+            this.elements.modelList.addEventListener('click', (e) => {
+                const btn = e.target.closest('[data-download]');
+                if (btn) this.downloadModel(btn.dataset.download);
+            });
+        }
+
+        // Model delete buttons
+        if (this.elements.localModelList) {
+            this.elements.localModelList.addEventListener('click', (e) => {
+                const btn = e.target.closest('[data-delete]');
+                if (btn) this.deleteModel(btn.dataset.delete);
+            });
+        }
+
+        // Webhook test button
+        if (this.elements.webhookTestBtn) {
+            this.elements.webhookTestBtn.addEventListener('click', () => this.testWebhooks());
+        }
+
+        // Canvas settings
+        if (this.elements.canvasSettingsSelect) {
+            this.elements.canvasSettingsSelect.addEventListener('change', () => this.renderCanvasSettingsForm());
+        }
+        if (this.elements.canvasSettingsForm) {
+            this.elements.canvasSettingsForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                this.saveCanvasSettings();
+            });
+        }
+        if (this.elements.canvasSettingsReset) {
+            this.elements.canvasSettingsReset.addEventListener('click', () => this.resetCanvasSettings());
+        }
+
+        // Feature flags
+        if (this.elements.featureFlagsCanvas) {
+            this.elements.featureFlagsCanvas.addEventListener('change', () => this.loadFeatureFlags());
+        }
+        if (this.elements.featureFlagsList) {
+            this.elements.featureFlagsList.addEventListener('change', (e) => {
+                const select = e.target.closest('[data-flag]');
+                if (select) this.setFeatureFlag(select.dataset.flag, select.value);
+            });
+        }
+
+        // Prompt library
+        if (this.elements.promptLibraryForm) {
+            this.elements.promptLibraryForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                this.savePromptTemplate();
+            });
+        }
+        if (this.elements.promptLibraryClear) {
+            this.elements.promptLibraryClear.addEventListener('click', () => this.elements.promptLibraryForm.reset());
+        }
+        if (this.elements.promptLibraryList) {
+            this.elements.promptLibraryList.addEventListener('click', (e) => {
+                const edit = e.target.closest('[data-edit-prompt]');
+                if (edit) this.editPromptTemplate(edit.dataset.editPrompt);
+                const del = e.target.closest('[data-delete-prompt]');
+                if (del) this.deletePromptTemplate(del.dataset.deletePrompt);
+            });
+        }
+
+        // Canvas export
+        if (this.elements.canvasExportForm) {
+            this.elements.canvasExportForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                this.downloadExport();
+            });
+        }
+
+        // Document import
+        if (this.elements.documentImportForm) {
+            this.elements.documentImportForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                this.importDocument();
+            });
+        }
+
+        // Canvas map
+        if (this.elements.canvasMapCanvas) {
+            this.elements.canvasMapCanvas.addEventListener('change', () => this.loadCanvasMap());
+        }
+        if (this.elements.canvasMap) {
+            this.elements.canvasMap.addEventListener('click', (e) => this.pickCanvasMapPoint(e));
+        }
+
+        // Remote trigger
+        if (this.elements.remoteTriggerForm) {
+            this.elements.remoteTriggerForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                this.sendRemoteTrigger();
+            });
+        }
+        if (this.elements.remoteSubmitForm) {
+            this.elements.remoteSubmitForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                this.submitRemoteWidget();
+            });
+        }
+
+        // Widget inspector
+        if (this.elements.widgetInspectorForm) {
+            this.elements.widgetInspectorForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                this.loadWidgetHistory(this.elements.widgetInspectorForm.elements.widget_id.value.trim());
+            });
+        }
+
+        // Logging
+        if (this.elements.logLevelForm) {
+            this.elements.logLevelForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                this.updateLogSettings({ level: this.elements.logLevelForm.elements.level.value });
+            });
+        }
+        if (this.elements.logPackageForm) {
+            this.elements.logPackageForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                const form = this.elements.logPackageForm.elements;
+                this.updateLogSettings({ packages: { [form.package.value.trim()]: form.level.value } });
+            });
+        }
+        if (this.elements.logPackageList) {
+            this.elements.logPackageList.addEventListener('click', (e) => {
+                const name = e.target.dataset.clearLogPackage;
+                if (name) this.updateLogSettings({ packages: { [name]: '' } });
+            });
+        }
+        if (this.elements.logBodyForm) {
+            this.elements.logBodyForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                const minutes = parseInt(this.elements.logBodyForm.elements.minutes.value, 10) || 0;
+                this.updateLogSettings({ body_logging_minutes: minutes });
+            });
+        }
+        if (this.elements.logBodyStop) {
+            this.elements.logBodyStop.addEventListener('click', () => this.updateLogSettings({ body_logging_minutes: 0 }));
+        }
+
+        // LLM capture
+        if (this.elements.llmCaptureForm) {
+            this.elements.llmCaptureForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                const form = this.elements.llmCaptureForm.elements;
+                this.updateLLMCapture({
+                    active: true,
+                    minutes: parseInt(form.minutes.value, 10) || 0,
+                    redact: form.redact.checked
+                });
+            });
+        }
+        if (this.elements.llmCaptureStop) {
+            this.elements.llmCaptureStop.addEventListener('click', () => this.updateLLMCapture({ active: false }));
+        }
+        if (this.elements.llmCaptureFilter) {
+            this.elements.llmCaptureFilter.addEventListener('submit', (e) => {
+                e.preventDefault();
+                this.loadLLMCaptures();
+            });
+        }
+        if (this.elements.llmCaptureRefresh) {
+            this.elements.llmCaptureRefresh.addEventListener('click', () => this.loadLLMCaptures());
+        }
+        if (this.elements.llmCaptureClear) {
+            this.elements.llmCaptureClear.addEventListener('click', () => this.clearLLMCaptures());
+        }
+
+        // Few-shot examples
+        if (this.elements.fewShotTaskFilter) {
+            this.elements.fewShotTaskFilter.addEventListener('change', () => this.renderFewShot());
+        }
+        if (this.elements.fewShotForm) {
+            this.elements.fewShotForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                this.saveFewShotExample();
+            });
+        }
+        if (this.elements.fewShotClear) {
+            this.elements.fewShotClear.addEventListener('click', () => this.resetFewShotForm());
+        }
+        if (this.elements.fewShotList) {
+            this.elements.fewShotList.addEventListener('click', (e) => {
+                const edit = e.target.closest('[data-edit-fewshot]');
+                if (edit) this.editFewShotExample(Number(edit.dataset.editFewshot));
+                const del = e.target.closest('[data-delete-fewshot]');
+                if (del) this.deleteFewShotExample(Number(del.dataset.deleteFewshot));
+            });
+        }
+
+        // Image gallery
+        if (this.elements.gallerySearchForm) {
+            this.elements.gallerySearchForm.addEventListener('submit', (e) => {
+                e.preventDefault();
+                this.galleryLimit = 24;
+                this.loadGallery();
+            });
+        }
+        if (this.elements.galleryMore) {
+            this.elements.galleryMore.addEventListener('click', () => {
+                this.galleryLimit += 24;
+                this.loadGallery();
+            });
+        }
+        if (this.elements.galleryGrid) {
+            this.elements.galleryGrid.addEventListener('click', (e) => {
+                const button = e.target.closest('[data-gallery-action]');
+                if (button) this.runGalleryAction(button.dataset.galleryAction, button.dataset.id);
+            });
+        }
     }
 
     /**
@@ -172,6 +525,9 @@ class DashboardApp {
             console.error('[Dashboard] WebSocket error:', error);
         });
 
+        // Updates were missed while disconnected; reload everything
+        this.ws.on('resync', () => this.loadInitialData());
+
         // Message type handlers
         this.ws.onMessage('status', (data) => this.handleStatusUpdate(data));
         this.ws.onMessage('canvas_status', (data) => this.handleCanvasUpdate(data));
@@ -180,6 +536,12 @@ class DashboardApp {
         this.ws.onMessage('task_error', (data) => this.handleTaskError(data));
         this.ws.onMessage('metrics', (data) => this.handleMetricsUpdate(data));
         this.ws.onMessage('gpu', (data) => this.handleGPUUpdate(data));
+        this.ws.onMessage('canvus_health', (msg) => this.handleCanvusHealth(msg.data || msg));
+        this.ws.onMessage('monitor_state', (msg) => this.handleMonitorState(msg.data || msg));
+        this.ws.onMessage('latency_alert', (msg) => this.handleLatencyAlert(msg.data || msg));
+        this.ws.onMessage('slo_alert', () => this.loadSLO());
+        this.ws.onMessage('canvas_preview', (msg) => this.handleCanvasPreview(msg.data || msg));
+        this.ws.onMessage('canvas_activity', (msg) => this.handleCanvasActivity(msg.data || msg));
     }
 
     /**
@@ -199,7 +561,7 @@ class DashboardApp {
             const [status, canvases, tasks, metrics, gpu] = await Promise.all([
                 this.fetchAPI('/api/status'),
                 this.fetchAPI('/api/canvases'),
-                this.fetchAPI('/api/tasks?limit=50'),
+                this.fetchAPI('/api/tasks?limit=50&source=memory'),
                 this.fetchAPI('/api/metrics'),
                 this.fetchAPI('/api/gpu?history=60')
             ]);
@@ -232,6 +594,18 @@ class DashboardApp {
                 this.renderGPU();
             }
 
+            await this.loadModelCatalog();
+            await this.loadWebhooks();
+            await this.loadSLO();
+            await this.loadCanvasSettings();
+            await this.loadFeatureFlags();
+            await this.loadPromptLibrary();
+            await this.loadCanvasMap();
+            await this.loadLogSettings();
+            await this.loadLLMCaptures();
+            await this.loadFewShot();
+            await this.loadGallery();
+
         } catch (error) {
             console.error('[Dashboard] Failed to load initial data:', error);
         }
@@ -244,12 +618,781 @@ class DashboardApp {
         try {
             const response = await fetch(endpoint);
             if (!response.ok) {
-                throw new Error(`HTTP ${response.status}`);
+                throw new Error(`HTTP ${response.status}`);
+            }
+            return await response.json();
+        } catch (error) {
+            console.error(`[Dashboard] API fetch failed: ${endpoint}`, error);
+            return null;
+        }
+    }
+
+    /**
+     * Load the model catalog and keep polling while downloads run
+     */
+    async loadModelCatalog() {
+        const [catalog, local] = await Promise.all([
+            this.fetchAPI('/api/models'),
+            this.fetchAPI('/api/models/local')
+        ]);
+        if (!catalog) return;
+
+        this.models = catalog.models || [];
+        this.localModels = local;
+        this.renderModelCatalog();
+        this.renderLocalModels();
+
+        clearTimeout(this.modelPollTimer);
+        if (this.models.some(m => m.download?.state === 'downloading')) {
+            this.modelPollTimer = setTimeout(() => this.loadModelCatalog(), 1000);
+        }
+    }
+
+    /**
+     * Start downloading a catalog model
+     */
+    async downloadModel(id) {
+        try {
+            const response = await fetch('/api/models/download', {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ id })
+            });
+            if (!response.ok && response.status !== 409) {
+                const body = await response.json().catch(() => ({}));
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+        } catch (error) {
+            console.error(`[Dashboard] Model download failed: ${id}`, error);
+        }
+        await this.loadModelCatalog();
+    }
+
+    /**
+     * Delete an unused model file after confirmation
+     */
+    async deleteModel(filename) {
+        if (!window.confirm(`Delete ${filename}? It will have to be downloaded again to be used.`)) return;
+
+        try {
+            const response = await fetch(`/api/models/local?file=${encodeURIComponent(filename)}`, { method: 'DELETE' });
+            if (!response.ok) {
+                const body = await response.json().catch(() => ({}));
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+        } catch (error) {
+            console.error(`[Dashboard] Model delete failed: ${filename}`, error);
+        }
+        await this.loadModelCatalog();
+    }
+
+    /**
+     * Load webhook targets and deliveries, refreshing every 30 seconds
+     */
+    async loadWebhooks() {
+        const webhooks = await this.fetchAPI('/api/webhooks');
+        if (webhooks) {
+            this.webhooks = webhooks;
+            this.renderWebhooks();
+        }
+
+        clearTimeout(this.webhookPollTimer);
+        this.webhookPollTimer = setTimeout(() => this.loadWebhooks(), 30000);
+    }
+
+    /**
+     * Load SLO burn rates, refreshing every 30 seconds
+     */
+    async loadSLO() {
+        const slo = await this.fetchAPI('/api/slo');
+        if (slo) {
+            this.slo = slo;
+            this.renderSLO();
+        }
+
+        clearTimeout(this.sloPollTimer);
+        this.sloPollTimer = setTimeout(() => this.loadSLO(), 30000);
+    }
+
+    /**
+     * Send a test event to every webhook target
+     */
+    async testWebhooks() {
+        const btn = this.elements.webhookTestBtn;
+        if (btn) btn.disabled = true;
+
+        try {
+            const response = await fetch('/api/webhooks/test', { method: 'POST' });
+            if (!response.ok) {
+                const body = await response.json().catch(() => ({}));
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+        } catch (error) {
+            console.error('[Dashboard] Webhook test failed', error);
+        }
+        await this.loadWebhooks();
+    }
+
+    /**
+     * Load the settings of every canvas
+     */
+    async loadCanvasSettings() {
+        const settings = await this.fetchAPI('/api/canvas-settings');
+        if (settings) {
+            this.canvasSettings = settings;
+            this.renderCanvasSettings();
+        } else if (this.elements.canvasSettingsNotice) {
+            this.elements.canvasSettingsNotice.hidden = false;
+            this.elements.canvasSettingsNotice.textContent = 'Canvas settings are unavailable.';
+        }
+    }
+
+    /**
+     * Save the form as the overrides of the selected canvas
+     */
+    async saveCanvasSettings() {
+        const form = this.elements.canvasSettingsForm;
+        const canvasID = this.elements.canvasSettingsSelect?.value;
+        if (!form || !canvasID) return;
+
+        const field = (name) => form.elements[name].value.trim();
+        const features = this.canvasSettings.features || [];
+        const body = {
+            canvas_id: canvasID,
+            disabled_features: features.filter(f => !form.elements[`feature-${f}`].checked),
+            allowed_models: field('allowed_models').split(',').map(m => m.trim()).filter(Boolean),
+            note_model: field('note_model'),
+            canvas_model: field('canvas_model'),
+            pdf_model: field('pdf_model'),
+            image_model: field('image_model'),
+            trigger_open: field('trigger_open'),
+            trigger_close: field('trigger_close'),
+            note_color: field('note_color'),
+            note_text_color: field('note_text_color'),
+            language: form.elements.language.value,
+            daily_task_limit: parseInt(field('daily_task_limit'), 10) || 0
+        };
+
+        await this.sendCanvasSettings('/api/canvas-settings', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(body)
+        });
+    }
+
+    /**
+     * Remove the overrides of the selected canvas
+     */
+    async resetCanvasSettings() {
+        const canvasID = this.elements.canvasSettingsSelect?.value;
+        if (!canvasID) return;
+        await this.sendCanvasSettings(`/api/canvas-settings?canvas_id=${encodeURIComponent(canvasID)}`, { method: 'DELETE' });
+    }
+
+    /**
+     * Send a settings change and show its error, if any
+     */
+    async sendCanvasSettings(endpoint, options) {
+        const errorEl = this.elements.canvasSettingsError;
+        if (errorEl) errorEl.hidden = true;
+
+        try {
+            const response = await fetch(endpoint, options);
+            if (!response.ok) {
+                const body = await response.json().catch(() => ({}));
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+        } catch (error) {
+            console.error('[Dashboard] Canvas settings update failed', error);
+            if (errorEl) {
+                errorEl.hidden = false;
+                errorEl.textContent = error.message;
+            }
+            return;
+        }
+        await this.loadCanvasSettings();
+    }
+
+    /**
+     * Load the feature flag states for the selected canvas
+     */
+    async loadFeatureFlags() {
+        const canvasID = this.elements.featureFlagsCanvas?.value || '';
+        const flags = await this.fetchAPI(`/api/feature-flags?canvas_id=${encodeURIComponent(canvasID)}`);
+        if (flags) {
+            this.featureFlags = flags;
+            this.renderFeatureFlags();
+        }
+    }
+
+    /**
+     * Turn a flag on or off for the selected canvas, or remove the override
+     */
+    async setFeatureFlag(flag, value) {
+        const canvasID = this.elements.featureFlagsCanvas?.value || '';
+        const errorEl = this.elements.featureFlagsError;
+        if (errorEl) errorEl.hidden = true;
+
+        const request = value === ''
+            ? fetch(`/api/feature-flags?flag=${encodeURIComponent(flag)}&canvas_id=${encodeURIComponent(canvasID)}`, { method: 'DELETE' })
+            : fetch('/api/feature-flags', {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify({ flag, canvas_id: canvasID, enabled: value === 'on' })
+            });
+
+        try {
+            const response = await request;
+            if (!response.ok) {
+                const body = await response.json().catch(() => ({}));
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+        } catch (error) {
+            console.error(`[Dashboard] Feature flag update failed: ${flag}`, error);
+            if (errorEl) {
+                errorEl.hidden = false;
+                errorEl.textContent = error.message;
+            }
+        }
+        await this.loadFeatureFlags();
+    }
+
+    /**
+     * Load the prompt templates
+     */
+    async loadPromptLibrary() {
+        const templates = await this.fetchAPI('/api/prompts');
+        if (templates) {
+            this.promptTemplates = templates;
+            this.renderPromptLibrary();
+        } else if (this.elements.promptLibraryNotice) {
+            this.elements.promptLibraryNotice.hidden = false;
+            this.elements.promptLibraryNotice.textContent = 'The prompt library is unavailable.';
+        }
+    }
+
+    /**
+     * Copy a template into the form for editing
+     */
+    editPromptTemplate(name) {
+        const form = this.elements.promptLibraryForm;
+        const template = this.promptTemplates.find(t => t.name === name);
+        if (!form || !template) return;
+        form.elements.name.value = template.name;
+        form.elements.description.value = template.description || '';
+        form.elements.body.value = template.body;
+    }
+
+    /**
+     * Save the form as a template, replacing any with the same name
+     */
+    async savePromptTemplate() {
+        const form = this.elements.promptLibraryForm;
+        if (!form) return;
+        const body = {
+            name: form.elements.name.value.trim(),
+            description: form.elements.description.value.trim(),
+            body: form.elements.body.value
+        };
+        const saved = await this.sendPromptLibrary('/api/prompts', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(body)
+        });
+        if (saved) form.reset();
+    }
+
+    /**
+     * Delete a template
+     */
+    async deletePromptTemplate(name) {
+        if (!confirm(`Delete the prompt template "${name}"?`)) return;
+        await this.sendPromptLibrary(`/api/prompts?name=${encodeURIComponent(name)}`, { method: 'DELETE' });
+    }
+
+    /**
+     * Send a prompt library change and show its error, if any
+     */
+    async sendPromptLibrary(endpoint, options) {
+        const errorEl = this.elements.promptLibraryError;
+        if (errorEl) errorEl.hidden = true;
+
+        try {
+            const response = await fetch(endpoint, options);
+            if (!response.ok) {
+                const body = await response.json().catch(() => ({}));
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+        } catch (error) {
+            console.error('[Dashboard] Prompt library update failed', error);
+            if (errorEl) {
+                errorEl.hidden = false;
+                errorEl.textContent = error.message;
+            }
+            return false;
+        }
+        await this.loadPromptLibrary();
+        return true;
+    }
+
+    /**
+     * Upload the document chosen in the import form and lay it out on the canvas
+     */
+    async importDocument() {
+        const form = this.elements.documentImportForm;
+        const statusEl = this.elements.documentImportStatus;
+        const errorEl = this.elements.documentImportError;
+        if (errorEl) errorEl.hidden = true;
+
+        const data = new FormData(form);
+        data.set('summarize', form.elements.summarize.checked ? 'true' : 'false');
+        if (this.elements.documentImportSubmit) this.elements.documentImportSubmit.disabled = true;
+        if (statusEl) {
+            statusEl.hidden = false;
+            statusEl.textContent = 'Importing...';
+        }
+
+        try {
+            const response = await fetch('/api/import/document', { method: 'POST', body: data });
+            const body = await response.json().catch(() => ({}));
+            if (!response.ok) {
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+            if (statusEl) {
+                statusEl.textContent = `Imported "${body.title}": ${body.sections} sections, ${body.figures} figures`;
+            }
+            form.reset();
+        } catch (error) {
+            console.error('[Dashboard] Document import failed', error);
+            if (statusEl) statusEl.hidden = true;
+            if (errorEl) {
+                errorEl.hidden = false;
+                errorEl.textContent = error.message;
+            }
+        } finally {
+            if (this.elements.documentImportSubmit) this.elements.documentImportSubmit.disabled = false;
+        }
+    }
+
+    /**
+     * Download the canvas export chosen in the export form
+     */
+    async downloadExport() {
+        const form = this.elements.canvasExportForm;
+        const errorEl = this.elements.canvasExportError;
+        if (errorEl) errorEl.hidden = true;
+
+        const params = new URLSearchParams(new FormData(form));
+        try {
+            const response = await fetch(`/api/export/canvas?${params}`);
+            if (!response.ok) {
+                const body = await response.json().catch(() => ({}));
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+            const disposition = response.headers.get('Content-Disposition') || '';
+            const match = disposition.match(/filename="([^"]+)"/);
+            const url = URL.createObjectURL(await response.blob());
+            const link = document.createElement('a');
+            link.href = url;
+            link.download = match ? match[1] : 'canvas-export';
+            link.click();
+            URL.revokeObjectURL(url);
+        } catch (error) {
+            console.error('[Dashboard] Canvas export failed', error);
+            if (errorEl) {
+                errorEl.hidden = false;
+                errorEl.textContent = error.message;
+            }
+        }
+    }
+
+    /**
+     * Load the map of the canvas selected in the canvas map widget
+     */
+    async loadCanvasMap() {
+        if (!this.elements.canvasMap) return;
+
+        const select = this.elements.canvasMapCanvas;
+        const canvasID = select ? select.value : '';
+        const preview = await this.fetchAPI('/api/canvas/preview' +
+            (canvasID ? `?canvas_id=${encodeURIComponent(canvasID)}` : ''));
+        if (!preview) {
+            this.canvasMap = null;
+            this.elements.canvasMap.innerHTML = '<div class="empty-state">Canvas map unavailable</div>';
+            return;
+        }
+
+        if (select && select.options.length === 0) {
+            (preview.canvases || []).forEach(id => select.add(new Option(id, id)));
+            select.value = preview.canvas_id;
+        }
+        this.canvasMap = preview;
+        this.renderCanvasMap();
+    }
+
+    /**
+     * Fill the remote trigger forms from a click on the canvas map: the
+     * clicked point becomes the note location, a clicked widget the widget
+     * to run
+     */
+    pickCanvasMapPoint(e) {
+        const svg = e.target.closest('svg');
+        const form = this.elements.remoteTriggerForm;
+        if (!svg || !form) return;
+
+        const point = svg.createSVGPoint();
+        point.x = e.clientX;
+        point.y = e.clientY;
+        const canvasPoint = point.matrixTransform(svg.getScreenCTM().inverse());
+        form.elements.x.value = Math.round(canvasPoint.x);
+        form.elements.y.value = Math.round(canvasPoint.y);
+
+        const widget = e.target.closest('[data-widget-id]');
+        if (widget && this.elements.remoteSubmitForm) {
+            this.elements.remoteSubmitForm.elements.widget_id.value = widget.dataset.widgetId;
+        }
+        if (widget && this.elements.widgetInspectorForm) {
+            this.elements.widgetInspectorForm.elements.widget_id.value = widget.dataset.widgetId;
+            this.loadWidgetHistory(widget.dataset.widgetId);
+        }
+    }
+
+    /**
+     * Create a trigger note from the remote trigger form
+     */
+    async sendRemoteTrigger() {
+        const form = this.elements.remoteTriggerForm;
+        const request = { prompt: form.elements.prompt.value };
+        if (form.elements.title.value) request.title = form.elements.title.value;
+        if (form.elements.x.value !== '' && form.elements.y.value !== '') {
+            request.x = Number(form.elements.x.value);
+            request.y = Number(form.elements.y.value);
+        }
+        const result = await this.postRemoteTrigger('/api/triggers', request);
+        if (result) {
+            this.showRemoteTriggerStatus(`Created note ${result.widget_id} at (${Math.round(result.x)}, ${Math.round(result.y)})`);
+            form.elements.prompt.value = '';
+        }
+    }
+
+    /**
+     * Run the prompt of the widget in the remote submit form
+     */
+    async submitRemoteWidget() {
+        const form = this.elements.remoteSubmitForm;
+        const result = await this.postRemoteTrigger('/api/triggers/submit', { widget_id: form.elements.widget_id.value.trim() });
+        if (result) {
+            this.showRemoteTriggerStatus(`Submitted widget ${result.widget_id}`);
+        }
+    }
+
+    async postRemoteTrigger(endpoint, request) {
+        const errorEl = this.elements.remoteTriggerError;
+        if (errorEl) errorEl.hidden = true;
+        if (this.elements.remoteTriggerStatus) this.elements.remoteTriggerStatus.hidden = true;
+
+        try {
+            const response = await fetch(endpoint, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(request)
+            });
+            const body = await response.json().catch(() => ({}));
+            if (!response.ok) {
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+            return body;
+        } catch (error) {
+            console.error('[Dashboard] Remote trigger failed', error);
+            if (errorEl) {
+                errorEl.hidden = false;
+                errorEl.textContent = error.message;
+            }
+            return null;
+        }
+    }
+
+    showRemoteTriggerStatus(text) {
+        const statusEl = this.elements.remoteTriggerStatus;
+        if (!statusEl) return;
+        statusEl.hidden = false;
+        statusEl.textContent = text;
+    }
+
+    /**
+     * Load the events and AI tasks of a widget into the inspector
+     */
+    async loadWidgetHistory(widgetID) {
+        const errorEl = this.elements.widgetInspectorError;
+        if (errorEl) errorEl.hidden = true;
+        if (!widgetID) return;
+
+        try {
+            const response = await fetch(`/api/widgets/${encodeURIComponent(widgetID)}/history`);
+            const body = await response.json().catch(() => ({}));
+            if (!response.ok) {
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+            this.renderWidgetHistory(body);
+        } catch (error) {
+            console.error('[Dashboard] Widget history failed', error);
+            if (errorEl) {
+                errorEl.hidden = false;
+                errorEl.textContent = error.message;
+            }
+        }
+    }
+
+    /**
+     * Load the runtime log settings
+     */
+    async loadLogSettings() {
+        const settings = await this.fetchAPI('/api/logging');
+        if (settings) {
+            this.renderLogSettings(settings);
+        }
+    }
+
+    /**
+     * Change log settings; fields left out of update are kept
+     */
+    async updateLogSettings(update) {
+        const errorEl = this.elements.logSettingsError;
+        if (errorEl) errorEl.hidden = true;
+
+        try {
+            const response = await fetch('/api/logging', {
+                method: 'PUT',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(update)
+            });
+            const body = await response.json().catch(() => ({}));
+            if (!response.ok) {
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+            this.renderLogSettings(body);
+        } catch (error) {
+            console.error('[Dashboard] Log settings update failed', error);
+            if (errorEl) {
+                errorEl.hidden = false;
+                errorEl.textContent = error.message;
+            }
+        }
+    }
+
+    /**
+     * Load the LLM capture status and the captures matching the filter
+     */
+    async loadLLMCaptures() {
+        const params = new URLSearchParams();
+        if (this.elements.llmCaptureFilter) {
+            const form = this.elements.llmCaptureFilter.elements;
+            if (form.correlation_id.value.trim()) params.set('correlation_id', form.correlation_id.value.trim());
+            if (form.backend.value) params.set('backend', form.backend.value);
+        }
+        const query = params.toString();
+        const data = await this.fetchAPI('/api/llm-capture' + (query ? `?${query}` : ''));
+        if (data) {
+            this.renderLLMCaptureStatus(data.status);
+            this.renderLLMCaptures(data.captures || []);
+        }
+    }
+
+    /**
+     * Start or stop capturing LLM requests
+     */
+    async updateLLMCapture(update) {
+        await this.sendLLMCapture('PUT', update);
+    }
+
+    /**
+     * Delete every stored LLM capture
+     */
+    async clearLLMCaptures() {
+        if (!confirm('Delete all LLM captures?')) return;
+        await this.sendLLMCapture('DELETE');
+    }
+
+    async sendLLMCapture(method, body) {
+        const errorEl = this.elements.llmCaptureError;
+        if (errorEl) errorEl.hidden = true;
+
+        try {
+            const response = await fetch('/api/llm-capture', {
+                method,
+                headers: { 'Content-Type': 'application/json' },
+                body: body ? JSON.stringify(body) : undefined
+            });
+            const result = await response.json().catch(() => ({}));
+            if (!response.ok) {
+                throw new Error(result.message || `HTTP ${response.status}`);
+            }
+            await this.loadLLMCaptures();
+        } catch (error) {
+            console.error('[Dashboard] LLM capture update failed', error);
+            if (errorEl) {
+                errorEl.hidden = false;
+                errorEl.textContent = error.message;
+            }
+        }
+    }
+
+    /**
+     * Load the few-shot examples and the task types that take them
+     */
+    async loadFewShot() {
+        const data = await this.fetchAPI('/api/fewshot');
+        if (!data) {
+            if (this.elements.fewShotNotice) {
+                this.elements.fewShotNotice.hidden = false;
+                this.elements.fewShotNotice.textContent = 'Few-shot examples are unavailable.';
+            }
+            return;
+        }
+        this.fewShotExamples = data.examples || [];
+        this.setElementText('fewShotMax', String(data.max_per_task));
+        this.renderFewShotTasks(data.tasks || []);
+        this.renderFewShot();
+    }
+
+    /**
+     * Copy an example into the form for editing
+     */
+    editFewShotExample(id) {
+        const form = this.elements.fewShotForm;
+        const example = (this.fewShotExamples || []).find(e => e.id === id);
+        if (!form || !example) return;
+        form.elements.id.value = String(example.id);
+        form.elements.task.value = example.task;
+        form.elements.input.value = example.input;
+        form.elements.output.value = example.output;
+        form.elements.disabled.checked = !!example.disabled;
+    }
+
+    resetFewShotForm() {
+        if (!this.elements.fewShotForm) return;
+        this.elements.fewShotForm.reset();
+        this.elements.fewShotForm.elements.id.value = '0';
+    }
+
+    /**
+     * Save the form as a new example, or over the example being edited
+     */
+    async saveFewShotExample() {
+        const form = this.elements.fewShotForm;
+        if (!form) return;
+        const body = {
+            id: parseInt(form.elements.id.value, 10) || 0,
+            task: form.elements.task.value,
+            input: form.elements.input.value,
+            output: form.elements.output.value,
+            disabled: form.elements.disabled.checked
+        };
+        const saved = await this.sendFewShot('/api/fewshot', {
+            method: 'PUT',
+            headers: { 'Content-Type': 'application/json' },
+            body: JSON.stringify(body)
+        });
+        if (saved) this.resetFewShotForm();
+    }
+
+    /**
+     * Delete an example
+     */
+    async deleteFewShotExample(id) {
+        if (!confirm('Delete this few-shot example?')) return;
+        await this.sendFewShot(`/api/fewshot?id=${id}`, { method: 'DELETE' });
+    }
+
+    /**
+     * Send a few-shot change and show its error, if any
+     */
+    async sendFewShot(endpoint, options) {
+        const errorEl = this.elements.fewShotError;
+        if (errorEl) errorEl.hidden = true;
+
+        try {
+            const response = await fetch(endpoint, options);
+            if (!response.ok) {
+                const body = await response.json().catch(() => ({}));
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+        } catch (error) {
+            console.error('[Dashboard] Few-shot update failed', error);
+            if (errorEl) {
+                errorEl.hidden = false;
+                errorEl.textContent = error.message;
+            }
+            return false;
+        }
+        await this.loadFewShot();
+        return true;
+    }
+
+    /**
+     * Load the generated images matching the gallery search
+     */
+    async loadGallery() {
+        const params = new URLSearchParams({ kind: 'image', limit: String(this.galleryLimit + 1) });
+        const query = this.elements.gallerySearchForm ? this.elements.gallerySearchForm.elements.q.value.trim() : '';
+        if (query) params.set('q', query);
+
+        const data = await this.fetchAPI(`/api/artifacts?${params}`);
+        const notice = this.elements.galleryNotice;
+        if (!data || !data.enabled) {
+            if (notice) {
+                notice.hidden = false;
+                notice.textContent = data ? 'Generated images are not kept. Set ARTIFACT_STORE to fill the gallery.' : 'The gallery is unavailable.';
+            }
+            this.galleryImages = [];
+        } else {
+            if (notice) notice.hidden = true;
+            this.galleryImages = data.artifacts || [];
+        }
+        this.renderGallery();
+    }
+
+    /**
+     * Re-generate or re-upload a gallery image at the location picked on
+     * the canvas map, if any
+     */
+    async runGalleryAction(action, id) {
+        const errorEl = this.elements.galleryError;
+        const statusEl = this.elements.galleryStatus;
+        if (errorEl) errorEl.hidden = true;
+        if (statusEl) statusEl.hidden = true;
+
+        const request = { id };
+        const form = this.elements.remoteTriggerForm;
+        if (form && form.elements.x.value !== '' && form.elements.y.value !== '') {
+            request.x = Number(form.elements.x.value);
+            request.y = Number(form.elements.y.value);
+        }
+        const endpoint = action === 'upload' ? '/api/artifacts/upload' : '/api/artifacts/regenerate';
+        if (action === 'same-seed') request.same_seed = true;
+
+        try {
+            const response = await fetch(endpoint, {
+                method: 'POST',
+                headers: { 'Content-Type': 'application/json' },
+                body: JSON.stringify(request)
+            });
+            const body = await response.json().catch(() => ({}));
+            if (!response.ok) {
+                throw new Error(body.message || `HTTP ${response.status}`);
+            }
+            if (statusEl) {
+                statusEl.hidden = false;
+                statusEl.textContent = action === 'upload'
+                    ? `Uploaded as widget ${body.widget_id}`
+                    : `Created trigger note ${body.widget_id}`;
             }
-            return await response.json();
         } catch (error) {
-            console.error(`[Dashboard] API fetch failed: ${endpoint}`, error);
-            return null;
+            console.error('[Dashboard] Gallery action failed', error);
+            if (errorEl) {
+                errorEl.hidden = false;
+                errorEl.textContent = error.message;
+            }
         }
     }
 
@@ -318,19 +1461,58 @@ class DashboardApp {
         this.updateGPUChart();
     }
 
+    handleCanvasPreview(preview) {
+        if (!this.canvasMap || preview.canvas_id !== this.canvasMap.canvas_id) return;
+        this.canvasMap = { ...preview, canvases: this.canvasMap.canvases };
+        this.renderCanvasMap();
+    }
+
+    handleCanvasActivity(activity) {
+        if (!this.canvasMap || activity.canvas_id !== this.canvasMap.canvas_id) return;
+        this.canvasMap.tasks = activity.tasks || [];
+        this.canvasMap.ai_widgets = activity.ai_widgets || [];
+        this.canvasMap.updated_at = activity.updated_at;
+        this.renderCanvasMap();
+    }
+
+    handleCanvusHealth(data) {
+        if (!this.status) this.status = {};
+        this.status.canvus = data;
+        this.renderCanvusBanner();
+    }
+
+    handleMonitorState(data) {
+        if (!this.status) this.status = {};
+        this.status.monitor = data;
+        this.renderMonitorState();
+    }
+
+    handleLatencyAlert(alert) {
+        if (alert.breached) {
+            this.latencyAlerts[alert.task_type] = alert;
+        } else {
+            delete this.latencyAlerts[alert.task_type];
+        }
+        this.renderMetrics();
+    }
+
     // Rendering methods
 
     renderStatus() {
         if (!this.status) return;
 
+        // Models are not available until startup warmup finishes
+        const health = this.status.ready === false ? 'warming-up' : this.status.health;
+        const healthText = health?.replace('-', ' ');
+
         // Health badge
-        const healthClass = this.getHealthClass(this.status.health);
-        this.setElementText('systemHealthBadge', this.status.health?.toUpperCase() || '--');
+        const healthClass = this.getHealthClass(health);
+        this.setElementText('systemHealthBadge', healthText?.toUpperCase() || '--');
         this.setElementClass('systemHealthBadge', `widget-badge badge-${healthClass}`);
 
         // Health value
-        this.setElementText('systemHealth', this.status.health || '--');
-        this.setElementClass('systemHealth', `status-value status-${this.status.health?.toLowerCase() || ''}`);
+        this.setElementText('systemHealth', healthText || '--');
+        this.setElementClass('systemHealth', `status-value status-${health?.toLowerCase() || ''}`);
 
         // Uptime
         this.setElementText('systemUptime', this.status.uptime || '--');
@@ -344,11 +1526,113 @@ class DashboardApp {
             this.setElementText('lastCheck', this.formatTime(lastCheck));
         }
 
+        // Temp file usage against the quota
+        const temp = this.status.temp_files;
+        if (temp) {
+            const quota = temp.quota_bytes ? ` / ${this.formatBytes(temp.quota_bytes)}` : '';
+            this.setElementText('tempFiles', `${temp.files} · ${this.formatBytes(temp.bytes)}${quota}`);
+        }
+
+        this.renderRecovery();
+
         // Version info
         if (this.status.version) {
             this.setElementText('versionInfo', `v${this.status.version}`);
             this.setElementText('footerVersion', `CanvusLocalLLM v${this.status.version}`);
         }
+
+        this.renderMonitorState();
+        this.renderClusterStatus();
+        this.renderCanvusBanner();
+    }
+
+    renderRecovery() {
+        const recovery = this.status?.recovery;
+        const el = this.elements.lastStart;
+        if (!recovery || !el) return;
+
+        // After a crash, show what the recovery pass did with the tasks it found
+        if (!recovery.unclean) {
+            el.textContent = 'clean';
+            el.className = 'status-value status-healthy';
+            el.title = '';
+            return;
+        }
+        el.textContent = `recovered · ${recovery.requeued} requeued · ${recovery.failed} failed`;
+        el.className = `status-value status-${recovery.failed ? 'degraded' : 'healthy'}`;
+        const lines = (recovery.tasks || [])
+            .map(t => `${t.task_type} ${t.widget_id}: ${t.action} (${t.reason})`);
+        if (recovery.temp_files) lines.push(`${recovery.temp_files} leftover temp files removed`);
+        el.title = lines.join('\n');
+    }
+
+    renderMonitorState() {
+        const monitor = this.status?.monitor;
+        const el = this.elements.monitorState;
+        if (!monitor || !el) return;
+
+        // Streaming is healthy, stopped is down, everything else in between
+        const classes = { streaming: 'healthy', stopped: 'unhealthy' };
+        const attempts = monitor.state === 'reconnecting' || monitor.state === 'connecting'
+            ? monitor.reconnects > 1 ? ` (attempt ${monitor.reconnects})` : ''
+            : '';
+        el.textContent = `${monitor.state}${attempts}`;
+        el.className = `status-value status-${classes[monitor.state] || 'degraded'}`;
+        el.title = monitor.reason || '';
+    }
+
+    renderClusterStatus() {
+        const cluster = this.status?.cluster;
+        const el = this.elements.clusterStatus;
+        if (!el) return;
+        if (!cluster) {
+            el.textContent = 'standalone';
+            el.title = '';
+            return;
+        }
+
+        const members = cluster.members || [];
+        const queued = Object.values(cluster.queued || {}).reduce((sum, n) => sum + n, 0);
+        const running = cluster.jobs?.running || 0;
+        el.textContent = `${members.length} nodes · ${queued} queued · ${running} running`;
+        el.className = `status-value status-${cluster.last_error ? 'degraded' : 'healthy'}`;
+        el.title = members
+            .map(m => `${m.id}${m.id === cluster.leader ? ' (leader)' : ''}: ${(m.roles || []).join(', ')}`
+                + ` · ${m.jobs?.completed || 0} done, ${m.jobs?.failed || 0} failed`)
+            .join('\n') + (cluster.last_error ? `\n${cluster.last_error}` : '');
+    }
+
+    renderCanvusBanner() {
+        const banner = this.elements.canvusAlertBanner;
+        if (!banner) return;
+
+        const canvus = this.status?.canvus;
+        if (!canvus || canvus.state === 'healthy') {
+            banner.hidden = true;
+            banner.innerHTML = '';
+            return;
+        }
+
+        const down = canvus.state === 'down';
+        const title = down
+            ? `Canvus server unreachable (${canvus.consecutive_failures} failed attempts)`
+            : 'Canvus server connection unstable, retrying';
+        const paused = canvus.processing_paused
+            ? '<div class="canvus-alert-paused">AI processing is paused until the server returns.</div>'
+            : '';
+        const error = canvus.last_error
+            ? `<div class="canvus-alert-error">${this.escapeHtml(this.truncate(canvus.last_error, 160))}</div>`
+            : '';
+        const hint = canvus.hint
+            ? `<div class="canvus-alert-hint">${this.escapeHtml(canvus.hint)}</div>`
+            : '';
+
+        banner.className = `canvus-alert ${down ? 'canvus-alert-down' : 'canvus-alert-degraded'}`;
+        banner.innerHTML = `
+            <div class="canvus-alert-title">${this.escapeHtml(title)}</div>
+            ${error}${hint}${paused}
+        `;
+        banner.hidden = false;
     }
 
     renderCanvases() {
@@ -385,22 +1669,63 @@ class DashboardApp {
         this.setElementText('totalErrors', this.formatNumber(this.metrics.total_errors || 0));
         this.setElementText('successRate', `${(this.metrics.success_rate || 0).toFixed(1)}%`);
 
+        // Responses changed by the output filter
+        if (this.elements.metricsFiltered) {
+            const filtered = this.metrics.filtered || {};
+            const masked = filtered.masked || 0;
+            const blocked = filtered.blocked || 0;
+            this.elements.metricsFiltered.hidden = masked + blocked === 0;
+            this.elements.metricsFiltered.textContent =
+                `Content filter: ${this.formatNumber(masked)} masked · ${this.formatNumber(blocked)} blocked`;
+        }
+
+        // Structured responses that needed repair
+        if (this.elements.metricsJSONRepair) {
+            const repair = this.metrics.json_repair || {};
+            const repaired = repair.repaired || 0;
+            const retried = repair.retried || 0;
+            const failed = repair.failed || 0;
+            this.elements.metricsJSONRepair.hidden = repaired + retried + failed === 0;
+            this.elements.metricsJSONRepair.textContent =
+                `JSON repair: ${this.formatNumber(repaired)} repaired · ${this.formatNumber(retried)} re-asked · ` +
+                `${this.formatNumber(failed)} unreadable of ${this.formatNumber(repaired + retried + failed + (repair.valid || 0))}`;
+        }
+
         // Metrics by type
         if (this.elements.metricsByType && this.metrics.by_type) {
-            const html = Object.entries(this.metrics.by_type).map(([type, stats]) => `
-                <div class="type-metric">
+            const html = Object.entries(this.metrics.by_type).map(([type, stats]) => {
+                const alert = this.latencyAlerts[type];
+                return `
+                <div class="type-metric${alert ? ' type-metric-slow' : ''}">
                     <div class="type-name">${this.formatTaskType(type)}</div>
                     <div class="type-stats">
                         <span>${stats.total_processed || 0} processed</span>
                         <span>${stats.total_errors || 0} errors</span>
                     </div>
+                    ${this.renderLatency(stats.latency, alert)}
                 </div>
-            `).join('');
+            `;
+            }).join('');
 
             this.elements.metricsByType.innerHTML = html || '<div class="empty-state">No type metrics</div>';
         }
     }
 
+    renderLatency(latency, alert) {
+        // Percentiles over the shortest window with samples (durations are in ns)
+        const stats = (latency || []).find(l => l.samples > 0);
+        if (!stats) return '';
+        const ms = (ns) => Math.round(ns / 1e6);
+        const title = alert ? ` title="p95 above ${this.formatDuration(ms(alert.threshold))}"` : '';
+        return `
+                    <div class="type-latency"${title}>
+                        <span>${stats.window}</span>
+                        <span>p50 ${this.formatDuration(ms(stats.p50))}</span>
+                        <span>p95 ${this.formatDuration(ms(stats.p95))}</span>
+                        <span>p99 ${this.formatDuration(ms(stats.p99))}</span>
+                    </div>`;
+    }
+
     renderGPU() {
         if (!this.gpuMetrics) {
             this.gpuAvailable = false;
@@ -423,14 +1748,16 @@ class DashboardApp {
 
         // Utilization
         const utilization = this.gpuMetrics.utilization || 0;
-        this.setElementText('gpuUtilization', `${utilization}%`);
+        this.setElementText('gpuUtilization', `${utilization.toFixed(0)}%`);
         this.setBarWidth('gpuUtilizationBar', utilization);
 
         // Memory
+        // Backend reports bytes
         const memUsed = this.gpuMetrics.memory_used || 0;
         const memTotal = this.gpuMetrics.memory_total || 1;
         const memPercent = (memUsed / memTotal) * 100;
-        this.setElementText('gpuMemory', `${memUsed} / ${memTotal} MB`);
+        const toMB = (bytes) => Math.round(bytes / (1024 * 1024));
+        this.setElementText('gpuMemory', `${toMB(memUsed)} / ${toMB(memTotal)} MB`);
         this.setBarWidth('gpuMemoryBar', memPercent);
 
         // Temperature
@@ -623,7 +1950,8 @@ class DashboardApp {
             this.initGPUChart();
         }
 
-        if (!this.gpuChart) return;
+        // Historical ranges are not extended live
+        if (!this.gpuChart || this.gpuRange !== 'live') return;
 
         // Add new data point
         const now = new Date();
@@ -682,6 +2010,565 @@ class DashboardApp {
         }
     }
 
+    /**
+     * Switch the GPU chart between live data and a persisted history range
+     */
+    async setGPURange(range) {
+        this.gpuRange = range;
+
+        if (range === 'live') {
+            const gpu = await this.fetchAPI(`/api/gpu?history=${this.maxChartPoints}`);
+            if (this.gpuRange !== 'live') return;
+            this.setGPUChartData((gpu && gpu.history) || [], (point) => this.formatChartTime(new Date(point.timestamp || point.time), false));
+            return;
+        }
+
+        const history = await this.fetchAPI(`/api/gpu/history?range=${encodeURIComponent(range)}`);
+        if (this.gpuRange !== range) return;
+        if (!history) {
+            this.showGPUChartUnavailable('GPU history unavailable');
+            if (this.gpuChart) {
+                this.gpuChart.destroy();
+                this.gpuChart = null;
+            }
+            return;
+        }
+        // Ranges longer than a day need the date on each label
+        const withDate = range !== '24h';
+        this.setGPUChartData(history.samples || [], (sample) => this.formatChartTime(new Date(sample.time), withDate));
+    }
+
+    /**
+     * Replace the GPU chart data with points labelled by labelFor
+     */
+    setGPUChartData(points, labelFor) {
+        this.gpuChartLabels.length = 0;
+        this.gpuChartUtilization.length = 0;
+        this.gpuChartMemory.length = 0;
+
+        points.forEach(point => {
+            this.gpuChartLabels.push(labelFor(point));
+            this.gpuChartUtilization.push(point.utilization || 0);
+            this.gpuChartMemory.push(point.memory_used && point.memory_total
+                ? (point.memory_used / point.memory_total) * 100
+                : 0);
+        });
+
+        if (!this.gpuChart && this.elements.gpuChart) {
+            const container = this.elements.gpuChartContainer;
+            if (container && !container.querySelector('#gpu-chart')) {
+                container.innerHTML = '<canvas id="gpu-chart" width="400" height="120"></canvas>';
+                this.elements.gpuChart = document.getElementById('gpu-chart');
+            }
+            this.initGPUChart();
+        }
+        if (this.gpuChart) {
+            this.gpuChart.update('none');
+        }
+    }
+
+    formatChartTime(time, withDate) {
+        const options = withDate
+            ? { month: 'short', day: 'numeric', hour: '2-digit', minute: '2-digit' }
+            : { hour: '2-digit', minute: '2-digit' };
+        return withDate ? time.toLocaleString([], options) : time.toLocaleTimeString([], options);
+    }
+
+    renderModelCatalog() {
+        if (!this.elements.modelList) return;
+
+        this.setElementText('modelCountBadge', this.models.length.toString());
+
+        const restart = this.models.filter(m => m.download?.restart_required);
+        if (this.elements.modelNotice) {
+            this.elements.modelNotice.hidden = restart.length === 0;
+            this.elements.modelNotice.textContent = restart.length
+                ? `Configuration updated for ${restart.map(m => m.name).join(', ')}. Restart to load the new model.`
+                : '';
+        }
+
+        if (this.models.length === 0) {
+            this.elements.modelList.innerHTML = '<tr class="empty-row"><td colspan="5" class="empty-state">No models in catalog</td></tr>';
+            return;
+        }
+
+        const html = this.models.map(model => `
+            <tr class="${model.active ? 'model-active' : ''}">
+                <td class="col-model">
+                    <div class="model-name">
+                        ${this.escapeHtml(model.name)}
+                        ${model.recommended ? '<span class="model-tag">Recommended</span>' : ''}
+                        ${model.active ? '<span class="model-tag model-tag-active">Active</span>' : ''}
+                    </div>
+                    <div class="model-description">${this.escapeHtml(model.description)}</div>
+                </td>
+                <td class="col-kind">${this.escapeHtml(model.kind)}</td>
+                <td class="col-size">${this.formatBytes(model.size_bytes)}</td>
+                <td class="col-vram ${model.fits_vram === false ? 'vram-insufficient' : ''}">
+                    ${model.min_vram_mb ? `${this.formatNumber(model.min_vram_mb)} MB` : '--'}
+                </td>
+                <td class="col-state">${this.renderModelState(model)}</td>
+            </tr>
+        `).join('');
+
+        this.elements.modelList.innerHTML = html;
+    }
+
+    renderLocalModels() {
+        if (!this.elements.localModelList || !this.localModels) return;
+
+        const local = this.localModels;
+        const free = local.disk_free_bytes ? ` · ${this.formatBytes(local.disk_free_bytes)} free` : '';
+        this.setElementText('localModelsDisk', `${this.formatBytes(local.total_bytes)} used${free}`);
+
+        const models = local.models || [];
+        if (models.length === 0) {
+            this.elements.localModelList.innerHTML = '<tr class="empty-row"><td colspan="3" class="empty-state">No downloaded models</td></tr>';
+            return;
+        }
+
+        const html = models.map(m => {
+            let state = `<button class="btn btn-sm" data-delete="${this.escapeHtml(m.filename)}">Delete</button>`;
+            if (m.in_use) {
+                state = '<span class="activity-status status-success">In use</span>';
+            } else if (m.partial) {
+                state = `<span class="activity-status status-processing">Partial</span> ${state}`;
+            }
+            return `
+                <tr>
+                    <td class="col-model">${this.escapeHtml(m.filename)}</td>
+                    <td class="col-size">${this.formatBytes(m.size_bytes)}</td>
+                    <td class="col-state">${state}</td>
+                </tr>
+            `;
+        }).join('');
+
+        this.elements.localModelList.innerHTML = html;
+    }
+
+    renderSLO() {
+        const list = this.elements.sloList;
+        if (!list || !this.slo) return;
+
+        const statuses = this.slo.statuses || [];
+        list.hidden = !this.slo.enabled || statuses.length === 0;
+        list.innerHTML = statuses.map(s => {
+            const burn = Math.max(s.short_burn_rate, s.long_burn_rate).toFixed(1);
+            const title = `Burn rate ${s.short_burn_rate.toFixed(2)}x (short), ${s.long_burn_rate.toFixed(2)}x (long) over ${s.samples} tasks`;
+            return `
+                <li class="slo-item ${s.violated ? 'slo-violated' : 'slo-ok'}" title="${this.escapeHtml(title)}">
+                    <span class="slo-name">${this.escapeHtml(s.slo)}${s.slo.startsWith('*:') ? ` (${this.escapeHtml(s.task_type)})` : ''}</span>
+                    <span class="slo-burn">${s.violated ? 'Violated' : 'OK'} · ${burn}x</span>
+                </li>
+            `;
+        }).join('');
+    }
+
+    renderWebhooks() {
+        if (!this.elements.webhookDeliveries || !this.webhooks) return;
+
+        const targets = this.webhooks.targets || [];
+        this.setElementText('webhookCountBadge', targets.length.toString());
+        if (this.elements.webhookTestBtn) {
+            this.elements.webhookTestBtn.disabled = !this.webhooks.enabled;
+        }
+
+        const notice = this.elements.webhookNotice;
+        if (notice) {
+            notice.hidden = this.webhooks.enabled;
+            notice.textContent = 'No webhook targets configured. Set WEBHOOK_URLS to send alerts to Slack, Teams or a JSON endpoint.';
+        }
+
+        if (this.elements.webhookTargets) {
+            this.elements.webhookTargets.innerHTML = targets.map(t => `
+                <li class="webhook-target">
+                    <span class="webhook-format">${this.escapeHtml(t.format)}</span>
+                    <span class="webhook-url">${this.escapeHtml(t.url)}</span>
+                    <span class="webhook-events">${this.escapeHtml((t.events || []).join(', '))}</span>
+                </li>
+            `).join('');
+        }
+
+        const deliveries = this.webhooks.deliveries || [];
+        if (deliveries.length === 0) {
+            this.elements.webhookDeliveries.innerHTML = '<tr class="empty-row"><td colspan="4" class="empty-state">No deliveries yet</td></tr>';
+            return;
+        }
+
+        this.elements.webhookDeliveries.innerHTML = deliveries.map(d => {
+            const attempts = d.attempts > 1 ? ` after ${d.attempts} attempts` : '';
+            const result = d.success
+                ? `<span class="activity-status status-success">Delivered${attempts}</span>`
+                : `<span class="activity-status status-error" title="${this.escapeHtml(d.error || '')}">Failed${attempts}</span>`;
+            return `
+                <tr>
+                    <td class="col-time">${this.formatTime(new Date(d.time))}</td>
+                    <td class="col-event" title="${this.escapeHtml(d.title || '')}">${this.escapeHtml(d.event)}</td>
+                    <td class="col-target">${this.escapeHtml(d.target)}</td>
+                    <td class="col-state">${result}</td>
+                </tr>
+            `;
+        }).join('');
+    }
+
+    renderFeatureFlags() {
+        if (!this.elements.featureFlagsList || !this.featureFlags) return;
+
+        const select = this.elements.featureFlagsCanvas;
+        if (select && select.options.length === 1) {
+            (this.featureFlags.canvases || []).forEach(id => select.add(new Option(id, id)));
+        }
+
+        // Only overrides made at the selected level can be edited here
+        const own = this.featureFlags.canvas_id ? 'canvas' : 'global';
+        const flags = this.featureFlags.flags || [];
+        if (flags.length === 0) {
+            this.elements.featureFlagsList.innerHTML = '<tr class="empty-row"><td colspan="3" class="empty-state">No feature flags</td></tr>';
+            return;
+        }
+
+        this.elements.featureFlagsList.innerHTML = flags.map(f => {
+            const override = f.source === own ? (f.enabled ? 'on' : 'off') : '';
+            const option = (value, label) =>
+                `<option value="${value}"${override === value ? ' selected' : ''}>${label}</option>`;
+            const state = f.enabled
+                ? '<span class="activity-status status-success">On</span>'
+                : '<span class="activity-status">Off</span>';
+            return `
+                <tr>
+                    <td title="${this.escapeHtml(f.description)}">${this.escapeHtml(f.name)}</td>
+                    <td>${state} <span class="widget-subtitle">(${this.escapeHtml(f.source)})</span></td>
+                    <td>
+                        <select class="select-sm" data-flag="${this.escapeHtml(f.name)}">
+                            ${option('', 'Inherit')}${option('on', 'On')}${option('off', 'Off')}
+                        </select>
+                    </td>
+                </tr>
+            `;
+        }).join('');
+    }
+
+    renderPromptLibrary() {
+        if (!this.elements.promptLibraryList) return;
+
+        this.setElementText('promptLibraryCount', String(this.promptTemplates.length));
+        if (this.promptTemplates.length === 0) {
+            this.elements.promptLibraryList.innerHTML = '<tr class="empty-row"><td colspan="4" class="empty-state">No prompt templates</td></tr>';
+            return;
+        }
+
+        this.elements.promptLibraryList.innerHTML = this.promptTemplates.map(t => {
+            const name = this.escapeHtml(t.name);
+            const vars = (t.variables || []).map(v => this.escapeHtml(v)).join(', ') || '—';
+            return `
+                <tr>
+                    <td class="webhook-url" title="${this.escapeHtml(t.body)}">{{use:${name}}}</td>
+                    <td>${this.escapeHtml(t.description || '')}</td>
+                    <td class="webhook-events">${vars}</td>
+                    <td>
+                        <button class="btn btn-sm" data-edit-prompt="${name}">Edit</button>
+                        <button class="btn btn-sm" data-delete-prompt="${name}">Delete</button>
+                    </td>
+                </tr>
+            `;
+        }).join('');
+    }
+
+    renderFewShotTasks(tasks) {
+        const options = tasks.map(t => `<option value="${this.escapeHtml(t)}">${this.escapeHtml(t)}</option>`).join('');
+        if (this.elements.fewShotTask && this.elements.fewShotTask.options.length === 0) {
+            this.elements.fewShotTask.innerHTML = options;
+        }
+        if (this.elements.fewShotTaskFilter && this.elements.fewShotTaskFilter.options.length === 1) {
+            this.elements.fewShotTaskFilter.insertAdjacentHTML('beforeend', options);
+        }
+    }
+
+    renderFewShot() {
+        if (!this.elements.fewShotList) return;
+
+        const task = this.elements.fewShotTaskFilter ? this.elements.fewShotTaskFilter.value : '';
+        const examples = (this.fewShotExamples || []).filter(e => !task || e.task === task);
+        this.setElementText('fewShotCount', String(examples.length));
+        if (examples.length === 0) {
+            this.elements.fewShotList.innerHTML = '<tr class="empty-row"><td colspan="4" class="empty-state">No few-shot examples</td></tr>';
+            return;
+        }
+
+        this.elements.fewShotList.innerHTML = examples.map(e => `
+            <tr>
+                <td>${this.escapeHtml(e.task)}${e.disabled ? ' (disabled)' : ''}</td>
+                <td title="${this.escapeHtml(e.input)}">${this.escapeHtml(this.truncate(e.input, 60))}</td>
+                <td class="webhook-url" title="${this.escapeHtml(e.output)}">${this.escapeHtml(this.truncate(e.output, 60))}</td>
+                <td>
+                    <button class="btn btn-sm" data-edit-fewshot="${e.id}">Edit</button>
+                    <button class="btn btn-sm" data-delete-fewshot="${e.id}">Delete</button>
+                </td>
+            </tr>
+        `).join('');
+    }
+
+    renderGallery() {
+        if (!this.elements.galleryGrid) return;
+
+        // One more than shown is loaded to tell whether there are more
+        const images = this.galleryImages.slice(0, this.galleryLimit);
+        if (this.elements.galleryMore) this.elements.galleryMore.hidden = this.galleryImages.length <= this.galleryLimit;
+        this.setElementText('galleryCount', String(images.length));
+        if (images.length === 0) {
+            this.elements.galleryGrid.innerHTML = '<div class="empty-state">No generated images</div>';
+            return;
+        }
+
+        this.elements.galleryGrid.innerHTML = images.map(a => {
+            const id = encodeURIComponent(a.id);
+            const g = a.generation;
+            const meta = [
+                a.width && a.height ? `${a.width}x${a.height}` : '',
+                g && g.seed !== undefined ? `seed ${g.seed}` : '',
+                g && g.steps ? `${g.steps} steps` : ''
+            ].filter(Boolean).join(' · ');
+            const image = a.thumbnail
+                ? `<a href="/api/artifacts/content?id=${id}" title="Download"><img src="/api/artifacts/thumbnail?id=${id}" alt="${this.escapeHtml(a.prompt || a.name)}" loading="lazy"></a>`
+                : `<div class="gallery-placeholder">${this.escapeHtml(a.name)}</div>`;
+            return `
+                <div class="gallery-item">
+                    ${image}
+                    <div class="gallery-prompt" title="${this.escapeHtml(a.prompt || '')}">${this.escapeHtml(this.truncate(a.prompt || '(no prompt)', 120))}</div>
+                    <div class="gallery-meta">${new Date(a.created_at).toLocaleString()}${meta ? ` · ${this.escapeHtml(meta)}` : ''}</div>
+                    <div class="gallery-actions">
+                        ${a.prompt ? `<button class="btn btn-sm" data-gallery-action="regenerate" data-id="${this.escapeHtml(a.id)}">Re-generate</button>` : ''}
+                        ${g && g.seed !== undefined ? `<button class="btn btn-sm" data-gallery-action="same-seed" data-id="${this.escapeHtml(a.id)}" title="Same prompt, seed, size and steps">Same seed</button>` : ''}
+                        <button class="btn btn-sm" data-gallery-action="upload" data-id="${this.escapeHtml(a.id)}">Re-upload</button>
+                    </div>
+                </div>
+            `;
+        }).join('');
+    }
+
+    renderWidgetHistory(history) {
+        if (!this.elements.widgetInspectorList) return;
+
+        const entries = history.entries || [];
+        this.setElementText('widgetInspectorCount', String(entries.length));
+        if (entries.length === 0) {
+            this.elements.widgetInspectorList.innerHTML = `<tr class="empty-row"><td colspan="4" class="empty-state">No AI history for ${this.escapeHtml(history.widget_id)}</td></tr>`;
+            return;
+        }
+
+        this.elements.widgetInspectorList.innerHTML = entries.map(e => {
+            let step, result, details;
+            if (e.kind === 'task') {
+                const statusClass = this.getStatusClass(e.status);
+                step = `${this.escapeHtml(this.formatTaskType(e.operation))}${e.model ? ` <span class="widget-subtitle">(${this.escapeHtml(e.model)})</span>` : ''}`;
+                result = `<span class="activity-status status-${statusClass}">${this.escapeHtml(e.status)}</span>` +
+                    ` <span class="activity-duration">${this.formatDuration(e.duration_ms)}</span>`;
+                details = `<div class="widget-inspector-text"><strong>Prompt:</strong> ${this.escapeHtml(e.prompt || '--')}</div>` +
+                    `<div class="widget-inspector-text"><strong>${e.error ? 'Error' : 'Response'}:</strong> ${this.escapeHtml(e.error || e.response || '--')}</div>`;
+            } else {
+                step = this.escapeHtml(e.widget_type ? `${e.widget_type} update` : 'Update');
+                result = `<span class="activity-status${e.event_type === 'triggered' ? ' status-success' : ''}">${this.escapeHtml(e.event_type)}</span>`;
+                details = `<div class="widget-inspector-text">${this.escapeHtml(e.preview || '--')}</div>`;
+            }
+            return `
+                <tr>
+                    <td class="col-time" title="${this.escapeHtml(e.correlation_id || '')}">${this.formatTime(new Date(e.time))}</td>
+                    <td class="col-event">${step}</td>
+                    <td class="col-state">${result}</td>
+                    <td class="col-details">${details}</td>
+                </tr>
+            `;
+        }).join('');
+    }
+
+    renderLogSettings(settings) {
+        if (this.elements.logLevelForm) {
+            this.elements.logLevelForm.elements.level.value = settings.level;
+        }
+        if (this.elements.logBodyStatus) {
+            this.elements.logBodyStatus.textContent = settings.body_logging
+                ? `Logging bodies until ${this.formatTime(new Date(settings.body_logging_until))}`
+                : 'Bodies are not logged';
+        }
+        if (!this.elements.logPackageList) return;
+
+        const names = Object.keys(settings.packages || {}).sort();
+        if (names.length === 0) {
+            this.elements.logPackageList.innerHTML = '<tr class="empty-row"><td colspan="3" class="empty-state">All packages use the global level</td></tr>';
+            return;
+        }
+        this.elements.logPackageList.innerHTML = names.map(name => `
+            <tr>
+                <td>${this.escapeHtml(name)}</td>
+                <td>${this.escapeHtml(settings.packages[name])}</td>
+                <td><button class="btn btn-sm" data-clear-log-package="${this.escapeHtml(name)}">Clear</button></td>
+            </tr>
+        `).join('');
+    }
+
+    renderLLMCaptureStatus(status) {
+        if (!this.elements.llmCaptureStatus || !status) return;
+        this.elements.llmCaptureStatus.textContent = status.active
+            ? `Capturing until ${this.formatTime(new Date(status.until))}${status.redact ? ' (masked)' : ''}`
+            : 'Capture is off';
+        if (this.elements.llmCaptureForm && !status.redact_available) {
+            this.elements.llmCaptureForm.elements.redact.disabled = true;
+        }
+    }
+
+    renderLLMCaptures(captures) {
+        if (!this.elements.llmCaptureList) return;
+
+        if (captures.length === 0) {
+            this.elements.llmCaptureList.innerHTML = '<tr class="empty-row"><td colspan="5" class="empty-state">No captures</td></tr>';
+            return;
+        }
+        this.elements.llmCaptureList.innerHTML = captures.map(c => {
+            const task = [c.operation, c.correlation_id, c.widget_id].filter(Boolean).join(' / ') || '--';
+            const backend = c.model ? `${c.backend} (${c.model})` : c.backend;
+            const error = c.error ? `<div class="model-error">${this.escapeHtml(c.error)}</div>` : '';
+            const masked = c.redacted ? ' (masked)' : '';
+            return `
+                <tr>
+                    <td>${this.formatTime(new Date(c.time))}</td>
+                    <td>${this.escapeHtml(task)}</td>
+                    <td>${this.escapeHtml(backend)}</td>
+                    <td>${c.duration_ms} ms</td>
+                    <td class="col-details">
+                        ${error}
+                        <details>
+                            <summary>Request${masked}</summary>
+                            <pre class="llm-capture-text">${this.escapeHtml(c.request)}</pre>
+                        </details>
+                        <details>
+                            <summary>Response${masked}</summary>
+                            <pre class="llm-capture-text">${this.escapeHtml(c.response)}</pre>
+                        </details>
+                    </td>
+                </tr>
+            `;
+        }).join('');
+    }
+
+    renderCanvasSettings() {
+        const select = this.elements.canvasSettingsSelect;
+        if (!select || !this.canvasSettings) return;
+
+        const canvases = this.canvasSettings.canvases || [];
+        const selected = select.value;
+        select.innerHTML = canvases.map(c => {
+            const label = c.monitored ? c.canvas_id : `${c.canvas_id} (not monitored)`;
+            return `<option value="${this.escapeHtml(c.canvas_id)}">${this.escapeHtml(label)}</option>`;
+        }).join('');
+        if (canvases.some(c => c.canvas_id === selected)) {
+            select.value = selected;
+        }
+        [this.elements.canvasExportCanvas, this.elements.documentImportCanvas].forEach(canvasSelect => {
+            if (canvasSelect && canvasSelect.options.length === 1) {
+                canvases.filter(c => c.monitored).forEach(c => canvasSelect.add(new Option(c.canvas_id, c.canvas_id)));
+            }
+        });
+
+        if (this.elements.canvasSettingsFeatures) {
+            this.elements.canvasSettingsFeatures.innerHTML = (this.canvasSettings.features || []).map(f => `
+                <label><input type="checkbox" name="feature-${this.escapeHtml(f)}"> ${this.escapeHtml(f.replace(/_/g, ' '))}</label>
+            `).join('');
+        }
+        const language = this.elements.canvasSettingsForm?.elements.language;
+        if (language && language.options.length === 1) {
+            (this.canvasSettings.languages || []).forEach(l => language.add(new Option(l, l)));
+        }
+        this.renderCanvasSettingsForm();
+    }
+
+    renderCanvasSettingsForm() {
+        const form = this.elements.canvasSettingsForm;
+        const select = this.elements.canvasSettingsSelect;
+        if (!form || !select || !this.canvasSettings) return;
+
+        const entry = (this.canvasSettings.canvases || []).find(c => c.canvas_id === select.value);
+        const settings = entry ? entry.settings : {};
+        const disabled = settings.disabled_features || [];
+
+        this.setElementText('canvasSettingsBadge', entry && entry.custom ? 'Custom' : 'Default');
+        (this.canvasSettings.features || []).forEach(f => {
+            form.elements[`feature-${f}`].checked = !disabled.includes(f);
+        });
+        form.elements.allowed_models.value = (settings.allowed_models || []).join(', ');
+        ['note_model', 'canvas_model', 'pdf_model', 'image_model', 'trigger_open', 'trigger_close',
+            'note_color', 'note_text_color', 'language'].forEach(name => {
+            form.elements[name].value = settings[name] || '';
+        });
+        form.elements.daily_task_limit.value = settings.daily_task_limit || '';
+    }
+
+    /**
+     * Draw the canvas map: every widget as a box, AI-written widgets
+     * highlighted and a pulsing ring on widgets with a task in progress
+     */
+    renderCanvasMap() {
+        const map = this.canvasMap;
+        if (!this.elements.canvasMap || !map) return;
+
+        const tasks = map.tasks || [];
+        const aiWidgets = map.ai_widgets || [];
+        this.setElementText('canvasMapBadge', String(tasks.length));
+
+        const notices = [];
+        const unplaced = tasks.filter(t => !t.placed).length;
+        if (unplaced > 0) notices.push(`${unplaced} task(s) not on the map`);
+        if (map.truncated) notices.push('large canvas: some widgets not shown');
+        this.setElementText('canvasMapNotice', notices.join(' · '));
+
+        const widgets = map.widgets || [];
+        if (widgets.length === 0) {
+            this.elements.canvasMap.innerHTML = '<div class="empty-state">Canvas is empty</div>';
+            return;
+        }
+
+        const b = map.bounds;
+        const pad = Math.max(b.width, b.height) * 0.02;
+        const viewBox = [b.x - pad, b.y - pad, b.width + 2 * pad, b.height + 2 * pad].join(' ');
+        const rect = (r, cls, title, id) =>
+            `<rect class="${cls}" x="${r.x}" y="${r.y}" width="${r.width}" height="${r.height}" vector-effect="non-scaling-stroke"` +
+            `${id ? ` data-widget-id="${this.escapeHtml(id)}"` : ''}>` +
+            `<title>${this.escapeHtml(title)}</title></rect>`;
+
+        const boxes = widgets.map(w =>
+            rect(w, `canvas-map-widget type-${this.escapeHtml(w.type.toLowerCase())}`, w.title ? `${w.type}: ${w.title}` : w.type, w.id));
+        const written = aiWidgets.filter(w => w.placed).map(w =>
+            rect(w, 'canvas-map-ai', `${w.widget_type} ${w.action}d by ${w.operation} at ${this.formatTime(new Date(w.time))}`, w.widget_id));
+        const rings = tasks.filter(t => t.placed).map(t => {
+            const radius = Math.max(t.width, t.height) / 2 + pad;
+            return `<circle class="canvas-map-task" cx="${t.x + t.width / 2}" cy="${t.y + t.height / 2}" r="${radius}" vector-effect="non-scaling-stroke">` +
+                `<title>${this.escapeHtml(this.formatTaskType(t.task_type))} in progress</title></circle>`;
+        });
+
+        this.elements.canvasMap.innerHTML =
+            `<svg viewBox="${viewBox}" preserveAspectRatio="xMidYMid meet">${boxes.join('')}${written.join('')}${rings.join('')}</svg>`;
+    }
+
+    renderModelState(model) {
+        const download = model.download;
+        if (download?.state === 'downloading') {
+            const percent = Math.max(0, download.percent || 0);
+            const eta = download.eta_secs ? ` · ${this.formatDuration(Math.round(download.eta_secs * 1000))} left` : '';
+            return `
+                <div class="metric-bar model-progress">
+                    <div class="metric-bar-fill" style="width: ${Math.min(100, percent)}%"></div>
+                </div>
+                <div class="model-progress-text">${percent.toFixed(1)}% · ${this.escapeHtml(download.speed || '--')}${eta}</div>
+            `;
+        }
+        if (model.installed) {
+            return '<span class="activity-status status-success">Installed</span>';
+        }
+        const error = download?.state === 'failed'
+            ? `<div class="model-error" title="${this.escapeHtml(download.error)}">${this.escapeHtml(this.truncate(download.error, 60))}</div>`
+            : '';
+        return `<button class="btn btn-sm" data-download="${this.escapeHtml(model.id)}">Download</button>${error}`;
+    }
+
     renderQueue() {
         if (!this.elements.queueList) return;
 
@@ -830,6 +2717,17 @@ class DashboardApp {
         return num.toString();
     }
 
+    formatBytes(bytes) {
+        if (!bytes) return '--';
+        const units = ['B', 'KB', 'MB', 'GB', 'TB'];
+        let i = 0;
+        while (bytes >= 1024 && i < units.length - 1) {
+            bytes /= 1024;
+            i++;
+        }
+        return `${bytes.toFixed(i === 0 ? 0 : 1)} ${units[i]}`;
+    }
+
     formatTime(date) {
         if (!date || !(date instanceof Date)) return '--';
         return date.toLocaleTimeString([], { hour: '2-digit', minute: '2-digit', second: '2-digit' });
@@ -855,7 +2753,7 @@ class DashboardApp {
     }
 
     getHealthClass(health) {
-        const map = { healthy: 'success', degraded: 'warning', unhealthy: 'error' };
+        const map = { healthy: 'success', degraded: 'warning', 'warming-up': 'warning', unhealthy: 'error' };
         return map[health?.toLowerCase()] || '';
     }
 
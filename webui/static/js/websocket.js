@@ -6,6 +6,9 @@
  * - Event-based message handling
  * - Connection state management
  * - Heartbeat/ping-pong support
+ * - Protocol v2: topic subscriptions, sequence numbers and acks so a
+ *   reconnect resumes without missed or duplicated updates
+ * - Server-Sent Events fallback when a proxy blocks WebSockets
  */
 
 class WebSocketClient {
@@ -17,6 +20,11 @@ class WebSocketClient {
      * @param {number} options.maxReconnectInterval - Max reconnect interval in ms (default: 30000)
      * @param {number} options.reconnectDecay - Exponential backoff multiplier (default: 1.5)
      * @param {number} options.maxReconnectAttempts - Max attempts before giving up (default: 0 = unlimited)
+     * @param {string[]} options.topics - Topics to subscribe to (default: none = protocol v1, receive everything)
+     * @param {string} options.clientId - ID used to resume after a page reload (default: per-tab ID)
+     * @param {number} options.ackInterval - How often to acknowledge processed messages in ms (default: 2000)
+     * @param {string} options.sseUrl - Server-Sent Events URL (default: auto-detect from location)
+     * @param {number} options.fallbackAfter - WebSocket attempts that never open before falling back to SSE (default: 2, 0 = never)
      */
     constructor(options = {}) {
         // Default WebSocket URL based on current location
@@ -24,10 +32,15 @@ class WebSocketClient {
         const defaultUrl = `${protocol}//${window.location.host}/ws`;
 
         this.url = options.url || defaultUrl;
+        this.sseUrl = options.sseUrl || `${window.location.protocol}//${window.location.host}/events`;
+        this.fallbackAfter = options.fallbackAfter !== undefined ? options.fallbackAfter : 2;
         this.reconnectInterval = options.reconnectInterval || 5000;
         this.maxReconnectInterval = options.maxReconnectInterval || 30000;
         this.reconnectDecay = options.reconnectDecay || 1.5;
         this.maxReconnectAttempts = options.maxReconnectAttempts || 0;
+        this.topics = options.topics || null;
+        this.clientId = options.clientId || this._defaultClientId();
+        this.ackInterval = options.ackInterval || 2000;
 
         // Internal state
         this.ws = null;
@@ -37,6 +50,16 @@ class WebSocketClient {
         this.isIntentionallyClosed = false;
         this.isConnected = false;
 
+        // Transport: 'websocket' or 'sse' once WebSockets are found blocked
+        this.transport = 'websocket';
+        this.failedOpens = 0;
+
+        // Protocol v2 state: last processed sequence number and the server
+        // run it belongs to
+        this.lastSeq = 0;
+        this.epoch = 0;
+        this.ackTimer = null;
+
         // Event handlers
         this.handlers = {
             open: [],
@@ -44,7 +67,8 @@ class WebSocketClient {
             error: [],
             message: [],
             reconnecting: [],
-            reconnectFailed: []
+            reconnectFailed: [],
+            resync: []
         };
 
         // Message type handlers
@@ -55,6 +79,7 @@ class WebSocketClient {
      * Connect to the WebSocket server
      */
     connect() {
+        // EventSource shares CONNECTING/OPEN values with WebSocket
         if (this.ws && (this.ws.readyState === WebSocket.CONNECTING || this.ws.readyState === WebSocket.OPEN)) {
             console.log('[WebSocket] Already connected or connecting');
             return;
@@ -70,9 +95,14 @@ class WebSocketClient {
     disconnect() {
         this.isIntentionallyClosed = true;
         this._clearReconnectTimer();
+        this._sendAck();
 
         if (this.ws) {
-            this.ws.close(1000, 'Client disconnect');
+            if (this.transport === 'sse') {
+                this.ws.close();
+            } else {
+                this.ws.close(1000, 'Client disconnect');
+            }
             this.ws = null;
         }
 
@@ -85,6 +115,10 @@ class WebSocketClient {
      * @returns {boolean} Whether the message was sent successfully
      */
     send(data) {
+        if (this.transport === 'sse') {
+            // Event streams are server-to-client only
+            return false;
+        }
         if (!this.ws || this.ws.readyState !== WebSocket.OPEN) {
             console.warn('[WebSocket] Cannot send - not connected');
             return false;
@@ -102,7 +136,7 @@ class WebSocketClient {
 
     /**
      * Register an event handler
-     * @param {string} event - Event name (open, close, error, message, reconnecting, reconnectFailed)
+     * @param {string} event - Event name (open, close, error, message, reconnecting, reconnectFailed, resync)
      * @param {Function} handler - Event handler function
      */
     on(event, handler) {
@@ -154,6 +188,10 @@ class WebSocketClient {
     getState() {
         if (!this.ws) return 'closed';
 
+        if (this.transport === 'sse') {
+            return ['connecting', 'open', 'closed'][this.ws.readyState] || 'unknown';
+        }
+
         switch (this.ws.readyState) {
             case WebSocket.CONNECTING: return 'connecting';
             case WebSocket.OPEN: return 'open';
@@ -170,6 +208,11 @@ class WebSocketClient {
      * @private
      */
     _createConnection() {
+        if (this.transport === 'sse') {
+            this._createEventSource();
+            return;
+        }
+
         console.log(`[WebSocket] Connecting to ${this.url}`);
 
         try {
@@ -186,17 +229,57 @@ class WebSocketClient {
         }
     }
 
+    /**
+     * Create a Server-Sent Events connection resuming from the last message
+     * @private
+     */
+    _createEventSource() {
+        const params = new URLSearchParams();
+        if (this.topics) params.set('topics', this.topics.join(','));
+        params.set('client_id', this.clientId);
+        if (this.lastSeq) {
+            params.set('last_seq', this.lastSeq);
+            params.set('epoch', this.epoch);
+        }
+        const url = `${this.sseUrl}?${params}`;
+        console.log(`[WebSocket] Connecting to event stream ${url}`);
+
+        this.ws = new EventSource(url);
+        this.ws.onopen = (event) => this._handleOpen(event);
+        this.ws.onmessage = (event) => this._handleMessage(event);
+        this.ws.onerror = (event) => {
+            // Reconnect through _scheduleReconnect with the current last_seq
+            // instead of the browser's automatic retry
+            this._handleError(event);
+            if (this.ws) {
+                this.ws.close();
+            }
+            this._handleClose({ code: 0, reason: 'event stream error' });
+        };
+    }
+
     /**
      * Handle WebSocket open event
      * @private
      */
     _handleOpen(event) {
-        console.log('[WebSocket] Connected');
+        console.log(`[WebSocket] Connected (${this.transport})`);
 
         this.isConnected = true;
+        this.failedOpens = 0;
         this.reconnectAttempts = 0;
         this.currentReconnectInterval = this.reconnectInterval;
 
+        if (this.topics && this.transport === 'websocket') {
+            this.send({
+                type: 'subscribe',
+                topics: this.topics,
+                client_id: this.clientId,
+                last_seq: this.lastSeq,
+                epoch: this.epoch
+            });
+        }
+
         this._emit('open', event);
     }
 
@@ -207,6 +290,15 @@ class WebSocketClient {
     _handleClose(event) {
         console.log(`[WebSocket] Closed: code=${event.code}, reason=${event.reason}`);
 
+        // A WebSocket that closes without ever opening is likely blocked by
+        // a proxy; switch to Server-Sent Events after fallbackAfter attempts
+        if (!this.isConnected && this.transport === 'websocket' && this.fallbackAfter > 0 &&
+            typeof EventSource !== 'undefined' && ++this.failedOpens >= this.fallbackAfter) {
+            console.warn('[WebSocket] WebSocket unavailable, falling back to Server-Sent Events');
+            this.transport = 'sse';
+            this.currentReconnectInterval = this.reconnectInterval;
+        }
+
         this.isConnected = false;
         this.ws = null;
 
@@ -240,6 +332,10 @@ class WebSocketClient {
             data = event.data;
         }
 
+        if (data && typeof data === 'object' && !this._trackSequence(data)) {
+            return;
+        }
+
         // Emit generic message event
         this._emit('message', data, event);
 
@@ -258,6 +354,83 @@ class WebSocketClient {
         }
     }
 
+    /**
+     * Track protocol v2 sequence numbers
+     * @private
+     * @returns {boolean} Whether the message should be handled (false for duplicates)
+     */
+    _trackSequence(data) {
+        if (data.type === 'subscribed' && data.data) {
+            this.epoch = data.data.epoch;
+            return true;
+        }
+        if (data.type === 'resync' && data.data) {
+            // Missed updates are gone; continue from the server's position
+            // and let the application reload its state
+            console.log(`[WebSocket] Resync from seq ${data.data.from_seq}`);
+            this.lastSeq = data.data.seq;
+            this._emit('resync', data.data);
+            return true;
+        }
+        if (!data.seq) {
+            return true;
+        }
+        if (data.seq <= this.lastSeq) {
+            return false;
+        }
+        this.lastSeq = data.seq;
+        this._scheduleAck();
+        return true;
+    }
+
+    /**
+     * Schedule an acknowledgement of the last processed message
+     * @private
+     */
+    _scheduleAck() {
+        if (this.ackTimer) {
+            return;
+        }
+        this.ackTimer = setTimeout(() => {
+            this.ackTimer = null;
+            this._sendAck();
+        }, this.ackInterval);
+    }
+
+    /**
+     * Acknowledge the last processed message
+     * @private
+     */
+    _sendAck() {
+        if (!this.topics || !this.lastSeq || !this.isConnected || this.transport === 'sse') {
+            return;
+        }
+        this.send({
+            type: 'ack',
+            client_id: this.clientId,
+            last_seq: this.lastSeq,
+            epoch: this.epoch
+        });
+    }
+
+    /**
+     * Get or create a per-tab client ID that survives page reloads
+     * @private
+     */
+    _defaultClientId() {
+        const key = 'canvus-ws-client-id';
+        try {
+            let id = window.sessionStorage.getItem(key);
+            if (!id) {
+                id = Math.random().toString(36).slice(2) + Date.now().toString(36);
+                window.sessionStorage.setItem(key, id);
+            }
+            return id;
+        } catch (e) {
+            return Math.random().toString(36).slice(2);
+        }
+    }
+
     /**
      * Schedule a reconnection attempt
      * @private
@@ -0,0 +1,135 @@
+// Package webui provides the TagsAPI organism for filtering canvases by the
+// tags of their notes. This file contains the REST handlers that list the
+// tags given by note auto-tagging and the notes that have a tag.
+package webui
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go_backend/notetags"
+
+	"go.uber.org/zap"
+)
+
+// Tagged note limits: notes returned when no limit is given, and the most
+// a request may ask for.
+const (
+	defaultTaggedNotesLimit = 50
+	maxTaggedNotesLimit     = 500
+)
+
+// NoteTags provides the stored note tags. Implemented by db.Repository.
+type NoteTags interface {
+	ListNoteTags(ctx context.Context, canvasID string) ([]notetags.TagCount, error)
+	FindTaggedNotes(ctx context.Context, canvasID, tag string, limit int) ([]notetags.TaggedNote, error)
+}
+
+// TagsResponse represents the JSON response for GET /api/tags.
+type TagsResponse struct {
+	CanvasID string              `json:"canvas_id,omitempty"`
+	Tags     []notetags.TagCount `json:"tags"`
+	Count    int                 `json:"count"`
+}
+
+// TaggedNotesResponse represents the JSON response for
+// GET /api/tags/{tag}/notes.
+type TaggedNotesResponse struct {
+	CanvasID string                `json:"canvas_id,omitempty"`
+	Tag      string                `json:"tag"`
+	Notes    []notetags.TaggedNote `json:"notes"`
+	Count    int                   `json:"count"`
+	Limit    int                   `json:"limit"`
+}
+
+// TagsAPI is an organism that serves the tags of notes.
+//
+// Endpoints:
+// - GET /api/tags - Tags with their note counts, most used first (?canvas_id=)
+// - GET /api/tags/{tag}/notes - Notes with a tag, most recently tagged first (?canvas_id=&limit=N)
+type TagsAPI struct {
+	tags   NoteTags
+	logger *zap.Logger
+}
+
+// NewTagsAPI creates a TagsAPI backed by tags.
+func NewTagsAPI(tags NoteTags, logger *zap.Logger) *TagsAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &TagsAPI{tags: tags, logger: logger}
+}
+
+// HandleTags handles GET /api/tags requests.
+func (api *TagsAPI) HandleTags(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	canvasID := strings.TrimSpace(r.URL.Query().Get("canvas_id"))
+	tags, err := api.tags.ListNoteTags(r.Context(), canvasID)
+	if err != nil {
+		api.logger.Error("Failed to read note tags", zap.String("canvas_id", canvasID), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to read tags")
+		return
+	}
+	if tags == nil {
+		tags = []notetags.TagCount{}
+	}
+	writeJSON(w, http.StatusOK, TagsResponse{CanvasID: canvasID, Tags: tags, Count: len(tags)})
+}
+
+// HandleTaggedNotes handles GET /api/tags/{tag}/notes requests.
+func (api *TagsAPI) HandleTaggedNotes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	tag := notetags.Normalize(r.PathValue("tag"))
+	if tag == "" {
+		writeError(w, http.StatusBadRequest, "tag is required")
+		return
+	}
+	limit := defaultTaggedNotesLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxTaggedNotesLimit)
+	}
+
+	canvasID := strings.TrimSpace(r.URL.Query().Get("canvas_id"))
+	notes, err := api.tags.FindTaggedNotes(r.Context(), canvasID, tag, limit)
+	if err != nil {
+		api.logger.Error("Failed to read tagged notes", zap.String("tag", tag), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to read tagged notes")
+		return
+	}
+	if notes == nil {
+		notes = []notetags.TaggedNote{}
+	}
+	writeJSON(w, http.StatusOK, TaggedNotesResponse{
+		CanvasID: canvasID,
+		Tag:      tag,
+		Notes:    notes,
+		Count:    len(notes),
+		Limit:    limit,
+	})
+}
+
+// RegisterRoutes registers the tag routes on mux. protect, if non-nil,
+// guards them, since excerpts show note text.
+func (api *TagsAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	tags, notes := api.HandleTags, api.HandleTaggedNotes
+	if protect != nil {
+		tags, notes = protect(tags), protect(notes)
+	}
+	mux.HandleFunc("/api/tags", tags)
+	mux.HandleFunc("/api/tags/{tag}/notes", notes)
+}
@@ -0,0 +1,89 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_backend/notetags"
+)
+
+// fakeNoteTags has the tag "budget" on canvas "c1".
+type fakeNoteTags struct {
+	err       error
+	lastLimit int
+}
+
+func (f *fakeNoteTags) ListNoteTags(ctx context.Context, canvasID string) ([]notetags.TagCount, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if canvasID != "" && canvasID != "c1" {
+		return nil, nil
+	}
+	return []notetags.TagCount{{Tag: "budget", Notes: 2}, {Tag: "roadmap", Notes: 1}}, nil
+}
+
+func (f *fakeNoteTags) FindTaggedNotes(ctx context.Context, canvasID, tag string, limit int) ([]notetags.TaggedNote, error) {
+	f.lastLimit = limit
+	if f.err != nil {
+		return nil, f.err
+	}
+	if tag != "budget" {
+		return nil, nil
+	}
+	return []notetags.TaggedNote{{CanvasID: "c1", NoteID: "n1", Tags: []string{"budget"}, ContentHash: "secret", Excerpt: "Budget"}}, nil
+}
+
+func TestTagsAPI(t *testing.T) {
+	serve := func(tags NoteTags, path string) *httptest.ResponseRecorder {
+		mux := http.NewServeMux()
+		NewTagsAPI(tags, nil).RegisterRoutes(mux, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	t.Run("tags", func(t *testing.T) {
+		var body TagsResponse
+		rec := serve(&fakeNoteTags{}, "/api/tags?canvas_id=c1")
+		json.NewDecoder(rec.Body).Decode(&body)
+		if rec.Code != http.StatusOK || body.Count != 2 || body.Tags[0].Tag != "budget" || body.CanvasID != "c1" {
+			t.Errorf("unexpected response: %d %+v", rec.Code, body)
+		}
+		rec = serve(&fakeNoteTags{}, "/api/tags?canvas_id=other")
+		if body := rec.Body.String(); rec.Code != http.StatusOK || !strings.Contains(body, `"tags":[]`) {
+			t.Errorf("empty canvas: %d %s", rec.Code, body)
+		}
+	})
+
+	t.Run("notes with a tag", func(t *testing.T) {
+		tags := &fakeNoteTags{}
+		rec := serve(tags, "/api/tags/%23Budget/notes?limit=1000")
+		var body TaggedNotesResponse
+		json.NewDecoder(rec.Body).Decode(&body)
+		// The tag is normalized and the limit capped
+		if rec.Code != http.StatusOK || body.Tag != "budget" || body.Count != 1 || tags.lastLimit != maxTaggedNotesLimit {
+			t.Errorf("unexpected response: %d %+v", rec.Code, body)
+		}
+		if body.Notes[0].ContentHash != "" {
+			t.Error("content hash exposed")
+		}
+	})
+
+	t.Run("bad requests", func(t *testing.T) {
+		if rec := serve(&fakeNoteTags{}, "/api/tags/budget/notes?limit=0"); rec.Code != http.StatusBadRequest {
+			t.Errorf("limit=0: status %d", rec.Code)
+		}
+		if rec := serve(&fakeNoteTags{}, "/api/tags/%23%23/notes"); rec.Code != http.StatusBadRequest {
+			t.Errorf("empty tag: status %d", rec.Code)
+		}
+		if rec := serve(&fakeNoteTags{err: errors.New("db down")}, "/api/tags"); rec.Code != http.StatusInternalServerError {
+			t.Errorf("storage error: status %d", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+// Package webui provides the TriggersAPI organism for remote triggers.
+// This file contains the REST handlers that start AI tasks on a canvas from
+// the dashboard.
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"go_backend/remotetrigger"
+
+	"go.uber.org/zap"
+)
+
+// maxTriggerBody limits the size of a trigger request.
+const maxTriggerBody = 64 << 10
+
+// TriggerInjector starts AI tasks on canvases.
+// Implemented by remotetrigger.Injector.
+type TriggerInjector interface {
+	Inject(ctx context.Context, req remotetrigger.Request) (*remotetrigger.Result, error)
+	Submit(ctx context.Context, canvasID, widgetID string) (*remotetrigger.Result, error)
+}
+
+// SubmitTriggerRequest is the JSON body of POST /api/triggers/submit.
+type SubmitTriggerRequest struct {
+	CanvasID string `json:"canvas_id,omitempty"`
+	WidgetID string `json:"widget_id"`
+}
+
+// TriggersAPI is an organism that drives canvases from the dashboard.
+//
+// Endpoints:
+// - POST /api/triggers        - Create a note with the prompt of the JSON body (requires auth)
+// - POST /api/triggers/submit - Run the prompt of an existing widget (requires auth)
+type TriggersAPI struct {
+	injector TriggerInjector
+	logger   *zap.Logger
+}
+
+// NewTriggersAPI creates a TriggersAPI backed by injector.
+func NewTriggersAPI(injector TriggerInjector, logger *zap.Logger) *TriggersAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &TriggersAPI{injector: injector, logger: logger}
+}
+
+// HandleInject handles POST /api/triggers requests.
+func (api *TriggersAPI) HandleInject(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req remotetrigger.Request
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTriggerBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid trigger: "+err.Error())
+		return
+	}
+	if (req.X == nil) != (req.Y == nil) {
+		writeError(w, http.StatusBadRequest, "set both x and y, or neither")
+		return
+	}
+
+	result, err := api.injector.Inject(r.Context(), req)
+	if err != nil {
+		api.writeTriggerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, result)
+}
+
+// HandleSubmit handles POST /api/triggers/submit requests.
+func (api *TriggersAPI) HandleSubmit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	var req SubmitTriggerRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxTriggerBody))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+	if req.WidgetID == "" {
+		writeError(w, http.StatusBadRequest, "widget_id is required")
+		return
+	}
+
+	result, err := api.injector.Submit(r.Context(), req.CanvasID, req.WidgetID)
+	if err != nil {
+		api.writeTriggerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, result)
+}
+
+// writeTriggerError maps injector errors to status codes.
+func (api *TriggersAPI) writeTriggerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, remotetrigger.ErrUnknownCanvas):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, remotetrigger.ErrEmptyPrompt), errors.Is(err, remotetrigger.ErrNoPrompt):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, remotetrigger.ErrProcessingPaused):
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		api.logger.Warn("Remote trigger failed", zap.Error(err))
+		writeError(w, http.StatusBadGateway, err.Error())
+	}
+}
+
+// RegisterRoutes registers the trigger routes on mux. protect, if non-nil,
+// guards both, since they start AI tasks.
+func (api *TriggersAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	inject := api.HandleInject
+	submit := api.HandleSubmit
+	if protect != nil {
+		inject = protect(inject)
+		submit = protect(submit)
+	}
+	mux.HandleFunc("/api/triggers", inject)
+	mux.HandleFunc("/api/triggers/submit", submit)
+}
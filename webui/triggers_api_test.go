@@ -0,0 +1,94 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go_backend/remotetrigger"
+)
+
+type fakeInjector struct {
+	injected  []remotetrigger.Request
+	submitted []string
+	err       error
+}
+
+func (f *fakeInjector) Inject(ctx context.Context, req remotetrigger.Request) (*remotetrigger.Result, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.injected = append(f.injected, req)
+	return &remotetrigger.Result{CanvasID: "c1", WidgetID: "n1", Text: "{{" + req.Prompt + "}}"}, nil
+}
+
+func (f *fakeInjector) Submit(ctx context.Context, canvasID, widgetID string) (*remotetrigger.Result, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.submitted = append(f.submitted, widgetID)
+	return &remotetrigger.Result{CanvasID: "c1", WidgetID: widgetID}, nil
+}
+
+func TestTriggersAPI(t *testing.T) {
+	post := func(injector *fakeInjector, path, body string) *httptest.ResponseRecorder {
+		api := NewTriggersAPI(injector, nil)
+		mux := http.NewServeMux()
+		api.RegisterRoutes(mux, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return rec
+	}
+
+	t.Run("creates a note", func(t *testing.T) {
+		injector := &fakeInjector{}
+		rec := post(injector, "/api/triggers", `{"prompt":"Draw a lighthouse","x":10,"y":20}`)
+		var result remotetrigger.Result
+		json.NewDecoder(rec.Body).Decode(&result)
+		if rec.Code != http.StatusCreated || result.WidgetID != "n1" {
+			t.Errorf("unexpected response: %d %+v", rec.Code, result)
+		}
+		if req := injector.injected[0]; req.Prompt != "Draw a lighthouse" || *req.X != 10 || *req.Y != 20 {
+			t.Errorf("request = %+v", req)
+		}
+	})
+
+	t.Run("submits a widget", func(t *testing.T) {
+		injector := &fakeInjector{}
+		rec := post(injector, "/api/triggers/submit", `{"widget_id":"w1"}`)
+		if rec.Code != http.StatusAccepted || len(injector.submitted) != 1 || injector.submitted[0] != "w1" {
+			t.Errorf("status = %d, submitted = %v", rec.Code, injector.submitted)
+		}
+	})
+
+	t.Run("rejects bad requests", func(t *testing.T) {
+		for _, tt := range []struct {
+			path, body string
+			err        error
+			want       int
+		}{
+			{"/api/triggers", `{"prompt":"x","x":1}`, nil, http.StatusBadRequest},
+			{"/api/triggers", `{"prompt":"x","color":"red"}`, nil, http.StatusBadRequest},
+			{"/api/triggers/submit", `{}`, nil, http.StatusBadRequest},
+			{"/api/triggers", `{"prompt":""}`, remotetrigger.ErrEmptyPrompt, http.StatusBadRequest},
+			{"/api/triggers/submit", `{"widget_id":"img"}`, remotetrigger.ErrNoPrompt, http.StatusBadRequest},
+			{"/api/triggers", `{"prompt":"x","canvas_id":"c9"}`, remotetrigger.ErrUnknownCanvas, http.StatusNotFound},
+			{"/api/triggers/submit", `{"widget_id":"w1"}`, remotetrigger.ErrProcessingPaused, http.StatusServiceUnavailable},
+		} {
+			if rec := post(&fakeInjector{err: tt.err}, tt.path, tt.body); rec.Code != tt.want {
+				t.Errorf("%s %s: status = %d, want %d", tt.path, tt.body, rec.Code, tt.want)
+			}
+		}
+	})
+
+	t.Run("rejects GET", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		NewTriggersAPI(&fakeInjector{}, nil).HandleInject(rec, httptest.NewRequest(http.MethodGet, "/api/triggers", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want 405", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,93 @@
+// Package webui provides the WebhooksAPI organism for inspecting and
+// testing outbound webhook deliveries from the dashboard.
+// This file contains the REST handlers backing the Webhooks widget.
+package webui
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go_backend/webhooks"
+
+	"go.uber.org/zap"
+)
+
+// webhookTestTimeout bounds POST /api/webhooks/test, covering every retry.
+const webhookTestTimeout = 60 * time.Second
+
+// WebhooksResponse represents the JSON response for GET /api/webhooks.
+type WebhooksResponse struct {
+	Enabled    bool                  `json:"enabled"`
+	Targets    []webhooks.TargetInfo `json:"targets"`
+	Deliveries []webhooks.Delivery   `json:"deliveries"`
+}
+
+// WebhookTestResponse represents the JSON response for POST /api/webhooks/test.
+type WebhookTestResponse struct {
+	Deliveries []webhooks.Delivery `json:"deliveries"`
+}
+
+// WebhooksAPI is an organism that exposes the webhook targets and the
+// recent delivery history.
+//
+// Endpoints:
+// - GET  /api/webhooks      - Targets (URLs redacted) and recent deliveries
+// - POST /api/webhooks/test - Send a test event to every target
+type WebhooksAPI struct {
+	dispatcher *webhooks.Dispatcher
+	logger     *zap.Logger
+}
+
+// NewWebhooksAPI creates a WebhooksAPI for dispatcher.
+func NewWebhooksAPI(dispatcher *webhooks.Dispatcher, logger *zap.Logger) *WebhooksAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &WebhooksAPI{dispatcher: dispatcher, logger: logger}
+}
+
+// HandleWebhooks handles GET /api/webhooks requests.
+func (api *WebhooksAPI) HandleWebhooks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, WebhooksResponse{
+		Enabled:    api.dispatcher.Enabled(),
+		Targets:    api.dispatcher.Targets(),
+		Deliveries: api.dispatcher.Deliveries(),
+	})
+}
+
+// HandleTest handles POST /api/webhooks/test requests.
+// Returns the outcome of the test delivery to each target.
+func (api *WebhooksAPI) HandleTest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !api.dispatcher.Enabled() {
+		writeError(w, http.StatusConflict, "no webhook targets configured (set WEBHOOK_URLS)")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), webhookTestTimeout)
+	defer cancel()
+
+	writeJSON(w, http.StatusOK, WebhookTestResponse{
+		Deliveries: api.dispatcher.Test(ctx),
+	})
+}
+
+// RegisterRoutes registers the webhook routes on mux. If protect is non-nil
+// it wraps the test handler (e.g., with authentication).
+func (api *WebhooksAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	test := api.HandleTest
+	if protect != nil {
+		test = protect(test)
+	}
+	mux.HandleFunc("/api/webhooks", api.HandleWebhooks)
+	mux.HandleFunc("/api/webhooks/test", test)
+}
@@ -0,0 +1,103 @@
+package webui
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go_backend/webhooks"
+)
+
+func TestWebhooksAPI_HandleWebhooks(t *testing.T) {
+	t.Run("disabled without targets", func(t *testing.T) {
+		api := NewWebhooksAPI(webhooks.New(webhooks.Config{}), nil)
+
+		rec := httptest.NewRecorder()
+		api.HandleWebhooks(rec, httptest.NewRequest(http.MethodGet, "/api/webhooks", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+		var resp WebhooksResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Enabled || len(resp.Targets) != 0 || resp.Deliveries == nil {
+			t.Errorf("unexpected response: %+v", resp)
+		}
+	})
+
+	t.Run("lists redacted targets", func(t *testing.T) {
+		d := webhooks.New(webhooks.Config{Targets: []webhooks.Target{
+			{URL: "https://hooks.slack.com/services/T/B/secret"},
+		}})
+		api := NewWebhooksAPI(d, nil)
+
+		rec := httptest.NewRecorder()
+		api.HandleWebhooks(rec, httptest.NewRequest(http.MethodGet, "/api/webhooks", nil))
+
+		var resp WebhooksResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if !resp.Enabled || len(resp.Targets) != 1 {
+			t.Fatalf("unexpected response: %+v", resp)
+		}
+		if resp.Targets[0].URL != "https://hooks.slack.com/…" || resp.Targets[0].Format != webhooks.FormatSlack {
+			t.Errorf("unexpected target: %+v", resp.Targets[0])
+		}
+	})
+
+	t.Run("rejects POST", func(t *testing.T) {
+		api := NewWebhooksAPI(nil, nil)
+		rec := httptest.NewRecorder()
+		api.HandleWebhooks(rec, httptest.NewRequest(http.MethodPost, "/api/webhooks", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want 405", rec.Code)
+		}
+	})
+}
+
+func TestWebhooksAPI_HandleTest(t *testing.T) {
+	t.Run("sends test event", func(t *testing.T) {
+		var received int
+		target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received++
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer target.Close()
+
+		api := NewWebhooksAPI(webhooks.New(webhooks.Config{
+			Targets: []webhooks.Target{{URL: target.URL}},
+		}), nil)
+
+		rec := httptest.NewRecorder()
+		api.HandleTest(rec, httptest.NewRequest(http.MethodPost, "/api/webhooks/test", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", rec.Code)
+		}
+		var resp WebhookTestResponse
+		json.NewDecoder(rec.Body).Decode(&resp)
+		if received != 1 || len(resp.Deliveries) != 1 || !resp.Deliveries[0].Success {
+			t.Errorf("received = %d, response = %+v", received, resp)
+		}
+	})
+
+	t.Run("conflict without targets", func(t *testing.T) {
+		api := NewWebhooksAPI(webhooks.New(webhooks.Config{}), nil)
+		rec := httptest.NewRecorder()
+		api.HandleTest(rec, httptest.NewRequest(http.MethodPost, "/api/webhooks/test", nil))
+		if rec.Code != http.StatusConflict {
+			t.Errorf("status = %d, want 409", rec.Code)
+		}
+	})
+
+	t.Run("rejects GET", func(t *testing.T) {
+		api := NewWebhooksAPI(nil, nil)
+		rec := httptest.NewRecorder()
+		api.HandleTest(rec, httptest.NewRequest(http.MethodGet, "/api/webhooks/test", nil))
+		if rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want 405", rec.Code)
+		}
+	})
+}
@@ -11,7 +11,10 @@ import (
 	"sync"
 	"time"
 
+	"go_backend/canvaspreview"
 	"go_backend/metrics"
+	"go_backend/streamstate"
+	"go_backend/watchdog"
 
 	"github.com/gorilla/websocket"
 )
@@ -21,15 +24,24 @@ import (
 //
 // It composes:
 //   - Message types (from ws_message.go atoms)
+//   - Topic subscriptions and replay buffer (from ws_protocol.go atoms)
 //   - Connection map for client management
 //   - Broadcast channel for message distribution
 //
+// Every broadcast message gets a sequence number and is kept in a replay
+// buffer. Protocol v2 clients subscribe to topics and resume from the last
+// sequence number they processed; protocol v1 clients receive everything.
+//
 // Thread-safe for concurrent client connections and message broadcasting.
 type WebSocketBroadcaster struct {
 	// clients maps WebSocket connections to their active status
 	clients map[*websocket.Conn]clientInfo
 
-	// clientsMu protects concurrent access to the clients map
+	// streams maps the send channels of Server-Sent Events clients to
+	// their metadata (see sse.go)
+	streams map[chan []byte]clientInfo
+
+	// clientsMu protects concurrent access to the clients and streams maps
 	clientsMu sync.RWMutex
 
 	// broadcast receives messages to send to all clients
@@ -56,6 +68,28 @@ type WebSocketBroadcaster struct {
 	// maxMessageSize is the maximum message size allowed from client
 	maxMessageSize int64
 
+	// historyMu serializes sequence numbering with replay so a subscribing
+	// client sees each message exactly once. Acquired before clientsMu.
+	historyMu sync.Mutex
+
+	// epoch identifies this broadcaster run; sequence numbers restart with it
+	epoch int64
+
+	// seq is the sequence number of the last broadcast message
+	seq uint64
+
+	// history holds the most recent broadcast messages for backfill
+	history replayBuffer
+
+	// acks maps client IDs to their last acknowledged sequence number
+	acks map[string]ackEntry
+
+	// ackRetention is how long acknowledgements of disconnected clients are kept
+	ackRetention time.Duration
+
+	// initialState builds the state sent to clients that cannot be backfilled
+	initialState func() InitialData
+
 	// logger for WebSocket operations
 	logger Logger
 }
@@ -70,6 +104,9 @@ type clientInfo struct {
 
 	// send is the channel for sending messages to this client
 	send chan []byte
+
+	// sub is the client's topic subscription
+	sub *subscription
 }
 
 // Logger interface for WebSocket logging
@@ -104,6 +141,14 @@ type BroadcasterConfig struct {
 	// ClientSendBufferSize is per-client send buffer (default: 256)
 	ClientSendBufferSize int
 
+	// ReplayBufferSize is the number of broadcast messages kept for
+	// reconnecting clients (default: 500)
+	ReplayBufferSize int
+
+	// AckRetention is how long a client ID's last acknowledgement is kept
+	// after it disconnects (default: 1h)
+	AckRetention time.Duration
+
 	// Logger for WebSocket operations (default: standard log)
 	Logger Logger
 }
@@ -117,6 +162,8 @@ func DefaultBroadcasterConfig() BroadcasterConfig {
 		MaxMessageSize:       512,
 		BroadcastBufferSize:  256,
 		ClientSendBufferSize: 256,
+		ReplayBufferSize:     500,
+		AckRetention:         time.Hour,
 		Logger:               &defaultLogger{},
 	}
 }
@@ -138,9 +185,16 @@ func NewWebSocketBroadcasterWithConfig(config BroadcasterConfig) *WebSocketBroad
 	if config.Logger == nil {
 		config.Logger = &defaultLogger{}
 	}
+	if config.ReplayBufferSize <= 0 {
+		config.ReplayBufferSize = 500
+	}
+	if config.AckRetention <= 0 {
+		config.AckRetention = time.Hour
+	}
 
 	return &WebSocketBroadcaster{
 		clients:        make(map[*websocket.Conn]clientInfo),
+		streams:        make(map[chan []byte]clientInfo),
 		broadcast:      make(chan WSMessage, config.BroadcastBufferSize),
 		register:       make(chan *websocket.Conn),
 		unregister:     make(chan *websocket.Conn),
@@ -148,6 +202,10 @@ func NewWebSocketBroadcasterWithConfig(config BroadcasterConfig) *WebSocketBroad
 		pongWait:       config.PongWait,
 		writeWait:      config.WriteWait,
 		maxMessageSize: config.MaxMessageSize,
+		epoch:          time.Now().UnixNano(),
+		history:        replayBuffer{capacity: config.ReplayBufferSize},
+		acks:           make(map[string]ackEntry),
+		ackRetention:   config.AckRetention,
 		logger:         config.Logger,
 		upgrader: websocket.Upgrader{
 			ReadBufferSize:  1024,
@@ -245,13 +303,22 @@ func (b *WebSocketBroadcaster) BroadcastMessage(msg WSMessage) {
 	}
 }
 
+// SetInitialStateProvider sets the function building the state sent to a
+// subscribing client that has nothing to resume from or missed more updates
+// than the replay buffer holds.
+func (b *WebSocketBroadcaster) SetInitialStateProvider(fn func() InitialData) {
+	b.historyMu.Lock()
+	defer b.historyMu.Unlock()
+	b.initialState = fn
+}
+
 // ClientCount returns the current number of connected clients.
 //
 // Thread-safe.
 func (b *WebSocketBroadcaster) ClientCount() int {
 	b.clientsMu.RLock()
 	defer b.clientsMu.RUnlock()
-	return len(b.clients)
+	return len(b.clients) + len(b.streams)
 }
 
 // Close gracefully shuts down the broadcaster.
@@ -270,6 +337,7 @@ func (b *WebSocketBroadcaster) addClient(conn *websocket.Conn) {
 		connectedAt: time.Now(),
 		remoteAddr:  conn.RemoteAddr().String(),
 		send:        make(chan []byte, 256),
+		sub:         newSubscription(),
 	}
 	b.clients[conn] = info
 
@@ -292,18 +360,30 @@ func (b *WebSocketBroadcaster) removeClient(conn *websocket.Conn) {
 	}
 }
 
-// broadcastToAll sends a message to all connected clients
+// broadcastToAll numbers a message, keeps it for replay and sends it to
+// every client subscribed to its topic
 func (b *WebSocketBroadcaster) broadcastToAll(msg WSMessage) {
+	b.historyMu.Lock()
+	defer b.historyMu.Unlock()
+
+	topic, canvasID := messageTopic(msg)
+	msg.Seq = b.seq + 1
+	msg.Topic = topic
 	data, err := json.Marshal(msg)
 	if err != nil {
 		b.logger.Printf("Failed to marshal broadcast message: %v", err)
 		return
 	}
+	b.seq = msg.Seq
+	b.history.add(replayEntry{seq: msg.Seq, topic: topic, canvasID: canvasID, data: data})
 
 	b.clientsMu.RLock()
 	defer b.clientsMu.RUnlock()
 
 	for conn, info := range b.clients {
+		if !info.sub.matches(topic, canvasID) {
+			continue
+		}
 		select {
 		case info.send <- data:
 			// Message queued
@@ -315,6 +395,20 @@ func (b *WebSocketBroadcaster) broadcastToAll(msg WSMessage) {
 			}(conn)
 		}
 	}
+
+	for send, info := range b.streams {
+		if !info.sub.matches(topic, canvasID) {
+			continue
+		}
+		select {
+		case info.send <- data:
+			// Message queued
+		default:
+			// Stream send buffer full, end the stream
+			b.logger.Printf("Event stream %s send buffer full, closing", info.remoteAddr)
+			go b.removeStream(send)
+		}
+	}
 }
 
 // sendToClient sends a message to a specific client
@@ -365,26 +459,215 @@ func (b *WebSocketBroadcaster) closeAllClients() {
 		conn.Close()
 		delete(b.clients, conn)
 	}
+	for send := range b.streams {
+		close(send)
+		delete(b.streams, send)
+	}
 
 	b.logger.Printf("All clients disconnected")
 }
 
-// readPump handles incoming messages from a client
-// Currently only handles pong messages and close
+// readPump handles incoming messages from a client: protocol v2 requests,
+// pongs and close
 func (b *WebSocketBroadcaster) readPump(conn *websocket.Conn) {
 	defer func() {
 		b.unregister <- conn
 	}()
 
 	for {
-		_, _, err := conn.ReadMessage()
+		_, message, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				b.logger.Printf("Unexpected close error: %v", err)
 			}
 			break
 		}
-		// Currently we don't process client messages, just keep connection alive
+		var req ClientRequest
+		if err := json.Unmarshal(message, &req); err != nil {
+			b.logger.Printf("Ignoring malformed client message: %v", err)
+			continue
+		}
+		b.handleRequest(conn, req)
+	}
+}
+
+// handleRequest processes a protocol v2 client request
+func (b *WebSocketBroadcaster) handleRequest(conn *websocket.Conn, req ClientRequest) {
+	switch req.Type {
+	case RequestSubscribe:
+		b.subscribe(conn, req)
+	case RequestUnsubscribe:
+		b.unsubscribe(conn, req)
+	case RequestAck:
+		b.ack(conn, req)
+	default:
+		b.logger.Printf("Ignoring unknown client request type=%s", req.Type)
+	}
+}
+
+// subscribe adds topics and brings the client up to date: missed messages
+// are replayed if still buffered, otherwise the client is told to resync
+// and sent the initial state.
+func (b *WebSocketBroadcaster) subscribe(conn *websocket.Conn, req ClientRequest) {
+	// historyMu blocks broadcasts until the client has caught up, so no
+	// message is both replayed and broadcast, or neither
+	b.historyMu.Lock()
+	defer b.historyMu.Unlock()
+	b.clientsMu.RLock()
+	defer b.clientsMu.RUnlock()
+
+	if info, ok := b.clients[conn]; ok {
+		b.catchUp(info, req)
+	}
+}
+
+// catchUp applies a subscribe request to a client and queues the
+// subscribed reply followed by the replayed messages, or a resync and the
+// initial state; historyMu and clientsMu must be held
+func (b *WebSocketBroadcaster) catchUp(info clientInfo, req ClientRequest) {
+	topics := info.sub.add(req.Topics)
+	if req.ClientID != "" {
+		info.sub.setClientID(req.ClientID)
+	}
+
+	// Resume from the client's last sequence number, or from its last
+	// acknowledgement if it lost its state (e.g., a page reload)
+	resumeFrom := req.LastSeq
+	if resumeFrom == 0 && req.ClientID != "" {
+		if acked, ok := b.acks[req.ClientID]; ok {
+			resumeFrom = acked.seq
+		}
+	}
+	// Sequence numbers from another server run, or missed messages that are
+	// no longer buffered, cannot be replayed
+	resync := resumeFrom > 0 && ((req.LastSeq > 0 && req.Epoch != b.epoch) ||
+		resumeFrom > b.seq || resumeFrom+1 < b.history.oldest())
+
+	var backfill [][]byte
+	replayed := 0
+	switch {
+	case resync:
+		backfill = append(backfill, b.resyncMessage(resumeFrom))
+		backfill = append(backfill, b.initialStateMessage()...)
+	case resumeFrom == 0:
+		backfill = b.initialStateMessage()
+	default:
+		for _, entry := range b.history.since(resumeFrom) {
+			if info.sub.matches(entry.topic, entry.canvasID) {
+				backfill = append(backfill, entry.data)
+			}
+		}
+		replayed = len(backfill)
+		if replayed >= cap(info.send) {
+			// Too many to queue at once
+			backfill = append([][]byte{b.resyncMessage(resumeFrom)}, b.initialStateMessage()...)
+			replayed = 0
+		}
+	}
+
+	b.queue(info, b.marshal(NewWSMessage(MessageTypeSubscribed, SubscribedData{
+		Protocol: ProtocolVersion,
+		Epoch:    b.epoch,
+		Topics:   topics,
+		Seq:      b.seq,
+		Replayed: replayed,
+	})))
+	for _, data := range backfill {
+		b.queue(info, data)
+	}
+}
+
+// unsubscribe removes topics
+func (b *WebSocketBroadcaster) unsubscribe(conn *websocket.Conn, req ClientRequest) {
+	b.historyMu.Lock()
+	defer b.historyMu.Unlock()
+	b.clientsMu.RLock()
+	defer b.clientsMu.RUnlock()
+
+	info, ok := b.clients[conn]
+	if !ok {
+		return
+	}
+	topics := info.sub.remove(req.Topics)
+	b.queue(info, b.marshal(NewWSMessage(MessageTypeSubscribed, SubscribedData{
+		Protocol: ProtocolVersion,
+		Epoch:    b.epoch,
+		Topics:   topics,
+		Seq:      b.seq,
+	})))
+}
+
+// ack records the client's last processed sequence number so a reconnect
+// without its own state resumes from there
+func (b *WebSocketBroadcaster) ack(conn *websocket.Conn, req ClientRequest) {
+	b.clientsMu.RLock()
+	info, ok := b.clients[conn]
+	b.clientsMu.RUnlock()
+	if !ok {
+		return
+	}
+	clientID := info.sub.getClientID()
+	if clientID == "" {
+		clientID = req.ClientID
+	}
+	if clientID == "" || (req.Epoch != 0 && req.Epoch != b.epoch) {
+		return
+	}
+
+	b.historyMu.Lock()
+	defer b.historyMu.Unlock()
+	now := time.Now()
+	if req.LastSeq <= b.seq {
+		b.acks[clientID] = ackEntry{seq: req.LastSeq, at: now}
+	}
+	for id, entry := range b.acks {
+		if now.Sub(entry.at) > b.ackRetention {
+			delete(b.acks, id)
+		}
+	}
+}
+
+// resyncMessage builds a resync message; historyMu must be held
+func (b *WebSocketBroadcaster) resyncMessage(fromSeq uint64) []byte {
+	return b.marshal(NewWSMessage(MessageTypeResync, ResyncData{
+		FromSeq:   fromSeq,
+		OldestSeq: b.history.oldest(),
+		Seq:       b.seq,
+	}))
+}
+
+// initialStateMessage builds the initial message if a provider is set;
+// historyMu must be held
+func (b *WebSocketBroadcaster) initialStateMessage() [][]byte {
+	if b.initialState == nil {
+		return nil
+	}
+	if data := b.marshal(NewInitialMessage(b.initialState())); data != nil {
+		return [][]byte{data}
+	}
+	return nil
+}
+
+// marshal encodes msg, logging failures
+func (b *WebSocketBroadcaster) marshal(msg WSMessage) []byte {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		b.logger.Printf("Failed to marshal message: %v", err)
+		return nil
+	}
+	return data
+}
+
+// queue sends data to a client without blocking; clientsMu must be held
+func (b *WebSocketBroadcaster) queue(info clientInfo, data []byte) {
+	if data == nil {
+		return
+	}
+	select {
+	case info.send <- data:
+		// Message queued
+	default:
+		b.logger.Printf("Client %s send buffer full", info.remoteAddr)
 	}
 }
 
@@ -448,6 +731,46 @@ func (b *WebSocketBroadcaster) BroadcastSystemStatus(data SystemStatusData) {
 	b.BroadcastMessage(NewSystemStatusMessage(data))
 }
 
+// BroadcastCanvusHealth broadcasts a Canvus server health change to all clients.
+//
+// Convenience method for canvus_health messages.
+func (b *WebSocketBroadcaster) BroadcastCanvusHealth(status watchdog.Status) {
+	b.BroadcastMessage(NewCanvusHealthMessage(status))
+}
+
+// BroadcastMonitorState broadcasts a widget stream state change to all clients.
+//
+// Convenience method for monitor_state messages.
+func (b *WebSocketBroadcaster) BroadcastMonitorState(status streamstate.Status) {
+	b.BroadcastMessage(NewMonitorStateMessage(status))
+}
+
+// BroadcastLatencyAlert broadcasts a latency threshold breach or recovery to all clients.
+//
+// Convenience method for latency_alert messages.
+func (b *WebSocketBroadcaster) BroadcastLatencyAlert(alert metrics.LatencyAlert) {
+	b.BroadcastMessage(NewLatencyAlertMessage(alert))
+}
+
+// BroadcastSLOAlert broadcasts an SLO violation or recovery to all clients.
+//
+// Convenience method for slo_alert messages.
+func (b *WebSocketBroadcaster) BroadcastSLOAlert(status metrics.SLOStatus) {
+	b.BroadcastMessage(NewSLOAlertMessage(status))
+}
+
+// BroadcastCanvasPreview broadcasts a canvas map with its AI activity.
+// Implements canvaspreview.Publisher.
+func (b *WebSocketBroadcaster) BroadcastCanvasPreview(preview canvaspreview.Preview) {
+	b.BroadcastMessage(NewCanvasPreviewMessage(preview))
+}
+
+// BroadcastCanvasActivity broadcasts the AI activity on a canvas map.
+// Implements canvaspreview.Publisher.
+func (b *WebSocketBroadcaster) BroadcastCanvasActivity(activity canvaspreview.Activity) {
+	b.BroadcastMessage(NewCanvasActivityMessage(activity))
+}
+
 // BroadcastError broadcasts an error message to all clients.
 //
 // Convenience method for error messages.
@@ -464,6 +787,7 @@ func (b *WebSocketBroadcaster) BroadcastTaskUpdateFromMetrics(data metrics.TaskB
 		TaskType: data.TaskType,
 		Status:   data.Status,
 		CanvasID: data.CanvasID,
+		WidgetID: data.WidgetID,
 		Duration: data.Duration,
 		Error:    data.Error,
 	})
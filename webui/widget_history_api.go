@@ -0,0 +1,184 @@
+// Package webui provides the WidgetHistoryAPI organism for the widget
+// inspector. This file contains the REST handler that combines a widget's
+// canvas events and AI processing history into one timeline.
+package webui
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_backend/db"
+
+	"go.uber.org/zap"
+)
+
+// Widget history limits: entries returned when no limit is given, and the
+// most a request may ask for.
+const (
+	defaultWidgetHistoryLimit = 50
+	maxWidgetHistoryLimit     = 200
+)
+
+// Kinds of widget history entries.
+const (
+	WidgetEntryEvent = "event"
+	WidgetEntryTask  = "task"
+)
+
+// WidgetHistory provides the recorded events and processing of a widget.
+// Implemented by db.Repository.
+type WidgetHistory interface {
+	QueryProcessingHistory(ctx context.Context, q db.ProcessingQuery) ([]db.ProcessingRecord, error)
+	QueryCanvasEventsByWidgetID(ctx context.Context, widgetID string, limit int) ([]db.CanvasEvent, error)
+}
+
+// WidgetHistoryEntry is one step in a widget's timeline: a canvas event,
+// such as the trigger being picked up or refused, or an AI task run for it.
+type WidgetHistoryEntry struct {
+	Kind     string    `json:"kind"`
+	Time     time.Time `json:"time"`
+	CanvasID string    `json:"canvas_id"`
+
+	// Canvas event fields
+	EventType  string `json:"event_type,omitempty"`
+	WidgetType string `json:"widget_type,omitempty"`
+	Preview    string `json:"preview,omitempty"`
+
+	// AI task fields
+	CorrelationID string `json:"correlation_id,omitempty"`
+	Operation     string `json:"operation,omitempty"`
+	Model         string `json:"model,omitempty"`
+	Prompt        string `json:"prompt,omitempty"`
+	Response      string `json:"response,omitempty"`
+	Status        string `json:"status,omitempty"`
+	Error         string `json:"error,omitempty"`
+	InputTokens   int    `json:"input_tokens,omitempty"`
+	OutputTokens  int    `json:"output_tokens,omitempty"`
+	DurationMS    int    `json:"duration_ms,omitempty"`
+}
+
+// WidgetHistoryResponse represents the JSON response for
+// GET /api/widgets/{id}/history.
+type WidgetHistoryResponse struct {
+	WidgetID string               `json:"widget_id"`
+	Entries  []WidgetHistoryEntry `json:"entries"`
+	Count    int                  `json:"count"`
+	Limit    int                  `json:"limit"`
+}
+
+// WidgetHistoryAPI is an organism that serves the dashboard's widget
+// inspector.
+//
+// Endpoints:
+// - GET /api/widgets/{id}/history - Events and AI tasks of a widget, newest first (?limit=N)
+type WidgetHistoryAPI struct {
+	history WidgetHistory
+	logger  *zap.Logger
+}
+
+// NewWidgetHistoryAPI creates a WidgetHistoryAPI backed by history.
+func NewWidgetHistoryAPI(history WidgetHistory, logger *zap.Logger) *WidgetHistoryAPI {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &WidgetHistoryAPI{history: history, logger: logger}
+}
+
+// HandleHistory handles GET /api/widgets/{id}/history requests.
+func (api *WidgetHistoryAPI) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	widgetID := strings.TrimSpace(r.PathValue("id"))
+	if widgetID == "" {
+		writeError(w, http.StatusBadRequest, "widget id is required")
+		return
+	}
+	limit := defaultWidgetHistoryLimit
+	if s := r.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxWidgetHistoryLimit)
+	}
+
+	records, err := api.history.QueryProcessingHistory(r.Context(), db.ProcessingQuery{WidgetID: widgetID, Limit: limit})
+	if err != nil {
+		api.logger.Error("Failed to read widget processing history", zap.String("widget_id", widgetID), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to read widget history")
+		return
+	}
+	events, err := api.history.QueryCanvasEventsByWidgetID(r.Context(), widgetID, limit)
+	if err != nil {
+		api.logger.Error("Failed to read widget events", zap.String("widget_id", widgetID), zap.Error(err))
+		writeError(w, http.StatusInternalServerError, "failed to read widget history")
+		return
+	}
+
+	entries := mergeWidgetHistory(records, events)
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	writeJSON(w, http.StatusOK, WidgetHistoryResponse{
+		WidgetID: widgetID,
+		Entries:  entries,
+		Count:    len(entries),
+		Limit:    limit,
+	})
+}
+
+// mergeWidgetHistory combines tasks and events into one timeline, newest
+// first. A task is recorded when it finishes, so at equal times it is
+// listed before the event that started it.
+func mergeWidgetHistory(records []db.ProcessingRecord, events []db.CanvasEvent) []WidgetHistoryEntry {
+	entries := make([]WidgetHistoryEntry, 0, len(records)+len(events))
+	for _, rec := range records {
+		entries = append(entries, WidgetHistoryEntry{
+			Kind:          WidgetEntryTask,
+			Time:          rec.CreatedAt,
+			CanvasID:      rec.CanvasID,
+			CorrelationID: rec.CorrelationID,
+			Operation:     rec.OperationType,
+			Model:         rec.ModelName,
+			Prompt:        rec.Prompt,
+			Response:      rec.Response,
+			Status:        rec.Status,
+			Error:         rec.ErrorMessage,
+			InputTokens:   rec.InputTokens,
+			OutputTokens:  rec.OutputTokens,
+			DurationMS:    rec.DurationMS,
+		})
+	}
+	for _, evt := range events {
+		entries = append(entries, WidgetHistoryEntry{
+			Kind:       WidgetEntryEvent,
+			Time:       evt.CreatedAt,
+			CanvasID:   evt.CanvasID,
+			EventType:  evt.EventType,
+			WidgetType: evt.WidgetType,
+			Preview:    evt.ContentPreview,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
+	})
+	return entries
+}
+
+// RegisterRoutes registers the widget history route on mux. protect, if
+// non-nil, guards it, since the history holds prompts and responses.
+func (api *WidgetHistoryAPI) RegisterRoutes(mux *http.ServeMux, protect func(http.HandlerFunc) http.HandlerFunc) {
+	handler := api.HandleHistory
+	if protect != nil {
+		handler = protect(handler)
+	}
+	mux.HandleFunc("/api/widgets/{id}/history", handler)
+}
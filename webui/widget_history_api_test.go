@@ -0,0 +1,105 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go_backend/db"
+)
+
+// fakeWidgetHistory returns fixed records and events for widget "n1".
+type fakeWidgetHistory struct {
+	err error
+}
+
+func (f *fakeWidgetHistory) QueryProcessingHistory(ctx context.Context, q db.ProcessingQuery) ([]db.ProcessingRecord, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if q.WidgetID != "n1" {
+		return nil, nil
+	}
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	return []db.ProcessingRecord{
+		{WidgetID: "n1", OperationType: "text_generation", Prompt: "second", Status: "error", ErrorMessage: "timeout", CreatedAt: base.Add(2 * time.Minute)},
+		{WidgetID: "n1", OperationType: "text_generation", Prompt: "first", Response: "hi", Status: "success", CreatedAt: base},
+	}, nil
+}
+
+func (f *fakeWidgetHistory) QueryCanvasEventsByWidgetID(ctx context.Context, widgetID string, limit int) ([]db.CanvasEvent, error) {
+	if widgetID != "n1" {
+		return nil, nil
+	}
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	return []db.CanvasEvent{
+		{WidgetID: "n1", EventType: "refused", ContentPreview: "budget", CreatedAt: base.Add(3 * time.Minute)},
+		{WidgetID: "n1", EventType: "triggered", ContentPreview: "{{first}}", CreatedAt: base},
+	}, nil
+}
+
+func TestWidgetHistoryAPI_HandleHistory(t *testing.T) {
+	get := func(history WidgetHistory, method, path string) (*httptest.ResponseRecorder, WidgetHistoryResponse) {
+		mux := http.NewServeMux()
+		NewWidgetHistoryAPI(history, nil).RegisterRoutes(mux, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		var body WidgetHistoryResponse
+		json.NewDecoder(rec.Body).Decode(&body)
+		return rec, body
+	}
+
+	t.Run("merges newest first", func(t *testing.T) {
+		rec, body := get(&fakeWidgetHistory{}, http.MethodGet, "/api/widgets/n1/history")
+		if rec.Code != http.StatusOK || body.WidgetID != "n1" || body.Count != 4 {
+			t.Fatalf("unexpected response: %d %+v", rec.Code, body)
+		}
+		var got []string
+		for _, e := range body.Entries {
+			got = append(got, e.Kind+":"+e.EventType+e.Prompt)
+		}
+		// The task finishing at the trigger's time is listed first
+		want := []string{"event:refused", "task:second", "task:first", "event:triggered"}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("entries = %v, want %v", got, want)
+			}
+		}
+		if body.Entries[1].Error != "timeout" {
+			t.Errorf("error = %q, want timeout", body.Entries[1].Error)
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		_, body := get(&fakeWidgetHistory{}, http.MethodGet, "/api/widgets/n1/history?limit=1")
+		if body.Count != 1 || body.Entries[0].EventType != "refused" {
+			t.Errorf("unexpected page: %+v", body)
+		}
+		if rec, _ := get(&fakeWidgetHistory{}, http.MethodGet, "/api/widgets/n1/history?limit=0"); rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+	})
+
+	t.Run("unknown widget is empty", func(t *testing.T) {
+		rec, body := get(&fakeWidgetHistory{}, http.MethodGet, "/api/widgets/other/history")
+		if rec.Code != http.StatusOK || body.Count != 0 || body.Entries == nil {
+			t.Errorf("unexpected response: %d %+v", rec.Code, body)
+		}
+	})
+
+	t.Run("storage failure", func(t *testing.T) {
+		if rec, _ := get(&fakeWidgetHistory{err: errors.New("locked")}, http.MethodGet, "/api/widgets/n1/history"); rec.Code != http.StatusInternalServerError {
+			t.Errorf("status = %d, want 500", rec.Code)
+		}
+	})
+
+	t.Run("rejects POST", func(t *testing.T) {
+		if rec, _ := get(&fakeWidgetHistory{}, http.MethodPost, "/api/widgets/n1/history"); rec.Code != http.StatusMethodNotAllowed {
+			t.Errorf("status = %d, want 405", rec.Code)
+		}
+	})
+}
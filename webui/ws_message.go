@@ -5,6 +5,11 @@ package webui
 import (
 	"encoding/json"
 	"time"
+
+	"go_backend/canvaspreview"
+	"go_backend/metrics"
+	"go_backend/streamstate"
+	"go_backend/watchdog"
 )
 
 // Message type constants for WebSocket communication.
@@ -33,6 +38,24 @@ const (
 
 	// MessageTypeInitial contains the initial state snapshot on connection.
 	MessageTypeInitial = "initial"
+
+	// MessageTypeCanvusHealth indicates the Canvus server connection health changed.
+	MessageTypeCanvusHealth = "canvus_health"
+
+	// MessageTypeMonitorState indicates the widget stream changed state.
+	MessageTypeMonitorState = "monitor_state"
+
+	// MessageTypeLatencyAlert indicates a task type's p95 latency crossed its threshold.
+	MessageTypeLatencyAlert = "latency_alert"
+
+	// MessageTypeSLOAlert indicates an SLO became violated or recovered.
+	MessageTypeSLOAlert = "slo_alert"
+
+	// MessageTypeCanvasPreview carries a canvas map with its AI activity.
+	MessageTypeCanvasPreview = "canvas_preview"
+
+	// MessageTypeCanvasActivity carries the AI activity on a canvas map.
+	MessageTypeCanvasActivity = "canvas_activity"
 )
 
 // WSMessage is the base structure for all WebSocket messages.
@@ -48,6 +71,12 @@ type WSMessage struct {
 
 	// Data contains the type-specific payload (decoded based on Type)
 	Data interface{} `json:"data,omitempty"`
+
+	// Seq is the broadcast sequence number (0 for direct replies)
+	Seq uint64 `json:"seq,omitempty"`
+
+	// Topic is the subscription topic of a broadcast message
+	Topic string `json:"topic,omitempty"`
 }
 
 // NewWSMessage creates a new WebSocket message with the current timestamp.
@@ -87,6 +116,9 @@ type TaskUpdateData struct {
 	// CanvasID identifies which canvas this task belongs to
 	CanvasID string `json:"canvas_id,omitempty"`
 
+	// WidgetID is the widget that triggered the task, if known
+	WidgetID string `json:"widget_id,omitempty"`
+
 	// Duration is how long the task took (only set on completion)
 	Duration time.Duration `json:"duration,omitempty"`
 
@@ -211,3 +243,88 @@ func NewPingMessage() WSMessage {
 func NewInitialMessage(data InitialData) WSMessage {
 	return NewWSMessage(MessageTypeInitial, data)
 }
+
+// NewInitialData builds the initial state snapshot from a metrics collector,
+// including up to recentTasks of the most recent tasks.
+func NewInitialData(store metrics.MetricsCollector, recentTasks int) InitialData {
+	system := store.GetSystemStatus()
+	taskMetrics := store.GetTaskMetrics()
+	data := InitialData{
+		System: SystemStatusData{
+			Status:         system.Health,
+			Uptime:         system.Uptime,
+			TotalProcessed: taskMetrics.TotalProcessed,
+			Version:        system.Version,
+		},
+		Canvases:    []CanvasUpdateData{},
+		RecentTasks: []TaskUpdateData{},
+	}
+	if taskMetrics.TotalProcessed > 0 {
+		data.System.ErrorRate = float64(taskMetrics.TotalErrors) / float64(taskMetrics.TotalProcessed) * 100
+	}
+
+	if gpu := store.GetGPUMetrics(); gpu.MemoryTotal > 0 {
+		data.GPU = &GPUUpdateData{
+			Utilization:   gpu.Utilization,
+			Temperature:   gpu.Temperature,
+			MemoryUsed:    gpu.MemoryUsed,
+			MemoryTotal:   gpu.MemoryTotal,
+			MemoryPercent: float64(gpu.MemoryUsed) / float64(gpu.MemoryTotal) * 100,
+		}
+	}
+
+	for _, canvas := range store.GetAllCanvasStatuses() {
+		data.Canvases = append(data.Canvases, CanvasUpdateData{
+			CanvasID:     canvas.ID,
+			Name:         canvas.Name,
+			Connected:    canvas.Connected,
+			WidgetCount:  canvas.WidgetCount,
+			LastActivity: canvas.LastUpdate,
+		})
+	}
+
+	for _, task := range store.GetRecentTasks(recentTasks) {
+		if task.Status == "processing" {
+			data.System.ActiveTasks++
+		}
+		data.RecentTasks = append(data.RecentTasks, TaskUpdateData{
+			TaskID:   task.ID,
+			TaskType: task.Type,
+			Status:   task.Status,
+			CanvasID: task.CanvasID,
+			Duration: task.Duration,
+			Error:    task.ErrorMsg,
+		})
+	}
+	return data
+}
+
+// NewCanvusHealthMessage creates a Canvus server health message.
+func NewCanvusHealthMessage(status watchdog.Status) WSMessage {
+	return NewWSMessage(MessageTypeCanvusHealth, status)
+}
+
+// NewMonitorStateMessage creates a widget stream state message.
+func NewMonitorStateMessage(status streamstate.Status) WSMessage {
+	return NewWSMessage(MessageTypeMonitorState, status)
+}
+
+// NewLatencyAlertMessage creates a latency threshold breach or recovery message.
+func NewLatencyAlertMessage(alert metrics.LatencyAlert) WSMessage {
+	return NewWSMessage(MessageTypeLatencyAlert, alert)
+}
+
+// NewSLOAlertMessage creates an SLO violation or recovery message.
+func NewSLOAlertMessage(status metrics.SLOStatus) WSMessage {
+	return NewWSMessage(MessageTypeSLOAlert, status)
+}
+
+// NewCanvasPreviewMessage creates a canvas map message.
+func NewCanvasPreviewMessage(preview canvaspreview.Preview) WSMessage {
+	return NewWSMessage(MessageTypeCanvasPreview, preview)
+}
+
+// NewCanvasActivityMessage creates a canvas AI activity message.
+func NewCanvasActivityMessage(activity canvaspreview.Activity) WSMessage {
+	return NewWSMessage(MessageTypeCanvasActivity, activity)
+}
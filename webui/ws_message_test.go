@@ -169,6 +169,10 @@ func TestMessageTypeConstants(t *testing.T) {
 		MessageTypePing,
 		MessageTypePong,
 		MessageTypeInitial,
+		MessageTypeCanvusHealth,
+		MessageTypeMonitorState,
+		MessageTypeLatencyAlert,
+		MessageTypeSLOAlert,
 	}
 
 	seen := make(map[string]bool)
@@ -0,0 +1,279 @@
+// Package webui provides the web-based user interface for CanvusLocalLLM.
+// This file contains the WebSocket protocol v2 atoms: topics, client
+// requests, subscriptions and the replay buffer used for backfill.
+package webui
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"go_backend/canvaspreview"
+)
+
+// ProtocolVersion is the WebSocket protocol version announced to clients.
+//
+// Version 1 clients never send anything and receive every message.
+// Version 2 clients send a subscribe request and then receive only their
+// topics, each message carrying a sequence number they acknowledge so a
+// reconnect resumes without missed or duplicated updates.
+const ProtocolVersion = 2
+
+// Topics a client can subscribe to.
+const (
+	// TopicTasks carries task_update messages
+	TopicTasks = "tasks"
+
+	// TopicGPU carries gpu_update messages
+	TopicGPU = "gpu"
+
+	// TopicLogs carries error messages
+	TopicLogs = "logs"
+
+	// TopicSystem carries system_status, canvus_health, monitor_state,
+	// latency_alert and slo_alert messages
+	TopicSystem = "system"
+
+	// TopicCanvases carries canvas_update, canvas_preview and
+	// canvas_activity messages for every canvas
+	TopicCanvases = "canvases"
+
+	// TopicCanvasPrefix subscribes to the task and canvas updates of one
+	// canvas, e.g. "canvas:3f2a..."
+	TopicCanvasPrefix = "canvas:"
+
+	// TopicAll subscribes to every topic
+	TopicAll = "*"
+)
+
+// Client request types (client to server).
+const (
+	// RequestSubscribe adds topics and optionally resumes from a sequence number
+	RequestSubscribe = "subscribe"
+
+	// RequestUnsubscribe removes topics
+	RequestUnsubscribe = "unsubscribe"
+
+	// RequestAck acknowledges every message up to a sequence number
+	RequestAck = "ack"
+)
+
+// Server reply types (server to client).
+const (
+	// MessageTypeSubscribed confirms a subscribe or unsubscribe request
+	MessageTypeSubscribed = "subscribed"
+
+	// MessageTypeResync tells the client updates were missed and it must
+	// reload its state (an initial message follows when available)
+	MessageTypeResync = "resync"
+)
+
+// ClientRequest is a message sent by a protocol v2 client.
+type ClientRequest struct {
+	// Type is RequestSubscribe, RequestUnsubscribe or RequestAck
+	Type string `json:"type"`
+
+	// Topics to add or remove
+	Topics []string `json:"topics,omitempty"`
+
+	// ClientID identifies the dashboard across reconnects
+	ClientID string `json:"client_id,omitempty"`
+
+	// LastSeq is the last sequence number the client processed (subscribe)
+	// or acknowledges (ack)
+	LastSeq uint64 `json:"last_seq,omitempty"`
+
+	// Epoch is the server epoch LastSeq belongs to; sequence numbers restart
+	// when the server restarts
+	Epoch int64 `json:"epoch,omitempty"`
+}
+
+// SubscribedData is the payload of a subscribed message.
+type SubscribedData struct {
+	// Protocol is the server protocol version
+	Protocol int `json:"protocol"`
+
+	// Epoch identifies this server run; resume requests from another epoch resync
+	Epoch int64 `json:"epoch"`
+
+	// Topics are the client's current subscriptions
+	Topics []string `json:"topics"`
+
+	// Seq is the latest sequence number at the time of subscribing
+	Seq uint64 `json:"seq"`
+
+	// Replayed is the number of missed messages sent again
+	Replayed int `json:"replayed"`
+}
+
+// ResyncData is the payload of a resync message.
+type ResyncData struct {
+	// FromSeq is the sequence number the client asked to resume from
+	FromSeq uint64 `json:"from_seq"`
+
+	// OldestSeq is the oldest sequence number still available
+	OldestSeq uint64 `json:"oldest_seq"`
+
+	// Seq is the latest sequence number; the client continues from it
+	Seq uint64 `json:"seq"`
+}
+
+// messageTopic returns the topic of msg and, for task and canvas updates,
+// the canvas it belongs to.
+func messageTopic(msg WSMessage) (topic, canvasID string) {
+	switch msg.Type {
+	case MessageTypeTaskUpdate:
+		if data, ok := msg.Data.(TaskUpdateData); ok {
+			canvasID = data.CanvasID
+		}
+		return TopicTasks, canvasID
+	case MessageTypeGPUUpdate:
+		return TopicGPU, ""
+	case MessageTypeCanvasUpdate:
+		if data, ok := msg.Data.(CanvasUpdateData); ok {
+			canvasID = data.CanvasID
+		}
+		return TopicCanvases, canvasID
+	case MessageTypeCanvasPreview:
+		if data, ok := msg.Data.(canvaspreview.Preview); ok {
+			canvasID = data.CanvasID
+		}
+		return TopicCanvases, canvasID
+	case MessageTypeCanvasActivity:
+		if data, ok := msg.Data.(canvaspreview.Activity); ok {
+			canvasID = data.CanvasID
+		}
+		return TopicCanvases, canvasID
+	case MessageTypeError:
+		return TopicLogs, ""
+	default:
+		return TopicSystem, ""
+	}
+}
+
+// validTopic reports whether topic can be subscribed to.
+func validTopic(topic string) bool {
+	switch topic {
+	case TopicTasks, TopicGPU, TopicLogs, TopicSystem, TopicCanvases, TopicAll:
+		return true
+	}
+	return strings.HasPrefix(topic, TopicCanvasPrefix) && len(topic) > len(TopicCanvasPrefix)
+}
+
+// subscription is the topic set of one client. Until the client sends a
+// subscribe request (protocol v1) it matches every message.
+type subscription struct {
+	mu       sync.RWMutex
+	active   bool
+	topics   map[string]bool
+	clientID string
+}
+
+// newSubscription creates an inactive subscription.
+func newSubscription() *subscription {
+	return &subscription{topics: make(map[string]bool)}
+}
+
+// add activates the subscription, subscribes to the valid topics and
+// returns the current topic list.
+func (s *subscription) add(topics []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = true
+	for _, topic := range topics {
+		if validTopic(topic) {
+			s.topics[topic] = true
+		}
+	}
+	return s.listLocked()
+}
+
+// remove unsubscribes from topics and returns the current topic list.
+func (s *subscription) remove(topics []string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, topic := range topics {
+		delete(s.topics, topic)
+	}
+	return s.listLocked()
+}
+
+// listLocked returns the topics; s.mu must be held.
+func (s *subscription) listLocked() []string {
+	list := make([]string, 0, len(s.topics))
+	for topic := range s.topics {
+		list = append(list, topic)
+	}
+	return list
+}
+
+// setClientID records the client ID used for acknowledgements.
+func (s *subscription) setClientID(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clientID = id
+}
+
+// getClientID returns the client ID ("" if not sent).
+func (s *subscription) getClientID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.clientID
+}
+
+// matches reports whether a message of topic and canvasID is delivered.
+func (s *subscription) matches(topic, canvasID string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.active || s.topics[TopicAll] || s.topics[topic] {
+		return true
+	}
+	return canvasID != "" && s.topics[TopicCanvasPrefix+canvasID]
+}
+
+// replayEntry is a broadcast message kept for backfill.
+type replayEntry struct {
+	seq      uint64
+	topic    string
+	canvasID string
+	data     []byte
+}
+
+// replayBuffer keeps the most recent broadcast messages, oldest first.
+// Guarded by WebSocketBroadcaster.historyMu.
+type replayBuffer struct {
+	entries  []replayEntry
+	capacity int
+}
+
+// add appends an entry, dropping the oldest beyond capacity.
+func (r *replayBuffer) add(entry replayEntry) {
+	r.entries = append(r.entries, entry)
+	if over := len(r.entries) - r.capacity; over > 0 {
+		r.entries = append(r.entries[:0], r.entries[over:]...)
+	}
+}
+
+// oldest returns the oldest buffered sequence number (0 if empty).
+func (r *replayBuffer) oldest() uint64 {
+	if len(r.entries) == 0 {
+		return 0
+	}
+	return r.entries[0].seq
+}
+
+// since returns the entries after seq.
+func (r *replayBuffer) since(seq uint64) []replayEntry {
+	for i, entry := range r.entries {
+		if entry.seq > seq {
+			return r.entries[i:]
+		}
+	}
+	return nil
+}
+
+// ackEntry is the last sequence number acknowledged by a client ID.
+type ackEntry struct {
+	seq uint64
+	at  time.Time
+}
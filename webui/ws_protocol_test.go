@@ -0,0 +1,286 @@
+package webui
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go_backend/canvaspreview"
+
+	"github.com/gorilla/websocket"
+)
+
+// wireMessage is a received WebSocket message with its raw payload
+type wireMessage struct {
+	Type  string          `json:"type"`
+	Seq   uint64          `json:"seq"`
+	Topic string          `json:"topic"`
+	Data  json.RawMessage `json:"data"`
+}
+
+func TestMessageTopic(t *testing.T) {
+	tests := []struct {
+		msg      WSMessage
+		topic    string
+		canvasID string
+	}{
+		{NewTaskUpdateMessage(TaskUpdateData{TaskID: "t", CanvasID: "c1"}), TopicTasks, "c1"},
+		{NewGPUUpdateMessage(GPUUpdateData{}), TopicGPU, ""},
+		{NewCanvasUpdateMessage(CanvasUpdateData{CanvasID: "c2"}), TopicCanvases, "c2"},
+		{NewCanvasPreviewMessage(canvaspreview.Preview{Activity: canvaspreview.Activity{CanvasID: "c3"}}), TopicCanvases, "c3"},
+		{NewCanvasActivityMessage(canvaspreview.Activity{CanvasID: "c4"}), TopicCanvases, "c4"},
+		{NewErrorMessage("E", "boom"), TopicLogs, ""},
+		{NewSystemStatusMessage(SystemStatusData{}), TopicSystem, ""},
+	}
+	for _, tt := range tests {
+		topic, canvasID := messageTopic(tt.msg)
+		if topic != tt.topic || canvasID != tt.canvasID {
+			t.Errorf("messageTopic(%s) = (%q, %q), want (%q, %q)", tt.msg.Type, topic, canvasID, tt.topic, tt.canvasID)
+		}
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	sub := newSubscription()
+	if !sub.matches(TopicGPU, "") {
+		t.Error("inactive subscription should match everything")
+	}
+
+	topics := sub.add([]string{TopicTasks, "canvas:c1", "bogus", "canvas:"})
+	if len(topics) != 2 {
+		t.Errorf("expected 2 valid topics, got %v", topics)
+	}
+	if sub.matches(TopicGPU, "") {
+		t.Error("should not match unsubscribed topic")
+	}
+	if !sub.matches(TopicTasks, "c9") {
+		t.Error("should match subscribed topic")
+	}
+	if !sub.matches(TopicCanvases, "c1") {
+		t.Error("should match subscribed canvas")
+	}
+	if sub.matches(TopicCanvases, "c2") {
+		t.Error("should not match other canvas")
+	}
+
+	sub.remove([]string{TopicTasks})
+	if sub.matches(TopicTasks, "c9") {
+		t.Error("should not match removed topic")
+	}
+
+	sub.add([]string{TopicAll})
+	if !sub.matches(TopicLogs, "") {
+		t.Error("wildcard should match every topic")
+	}
+}
+
+func TestReplayBuffer(t *testing.T) {
+	r := replayBuffer{capacity: 3}
+	if r.oldest() != 0 || r.since(0) != nil {
+		t.Error("empty buffer should have no entries")
+	}
+	for seq := uint64(1); seq <= 5; seq++ {
+		r.add(replayEntry{seq: seq})
+	}
+	if r.oldest() != 3 {
+		t.Errorf("oldest = %d, want 3", r.oldest())
+	}
+	if got := r.since(3); len(got) != 2 || got[0].seq != 4 {
+		t.Errorf("since(3) = %v, want seqs 4 and 5", got)
+	}
+	if got := r.since(5); len(got) != 0 {
+		t.Errorf("since(5) = %v, want none", got)
+	}
+}
+
+// startProtocolServer starts a broadcaster and a test server for it
+func startProtocolServer(t *testing.T, config BroadcasterConfig) (*WebSocketBroadcaster, string) {
+	t.Helper()
+	config.Logger = &mockLogger{}
+	b := NewWebSocketBroadcasterWithConfig(config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go b.Start(ctx)
+
+	server := httptest.NewServer(http.HandlerFunc(b.HandleConnection))
+	t.Cleanup(server.Close)
+	return b, "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+// dialAndSubscribe connects, sends req and returns the connection and the
+// subscribed reply
+func dialAndSubscribe(t *testing.T, wsURL string, req ClientRequest) (*websocket.Conn, SubscribedData) {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	req.Type = RequestSubscribe
+	if err := conn.WriteJSON(req); err != nil {
+		t.Fatalf("Failed to subscribe: %v", err)
+	}
+	msg := readWireMessage(t, conn)
+	if msg.Type != MessageTypeSubscribed {
+		t.Fatalf("expected subscribed, got %s", msg.Type)
+	}
+	var data SubscribedData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		t.Fatalf("Failed to decode subscribed data: %v", err)
+	}
+	return conn, data
+}
+
+func readWireMessage(t *testing.T, conn *websocket.Conn) wireMessage {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var msg wireMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("Failed to read message: %v", err)
+	}
+	return msg
+}
+
+func TestProtocol_TopicFilteringAndSequence(t *testing.T) {
+	b, wsURL := startProtocolServer(t, DefaultBroadcasterConfig())
+
+	conn, sub := dialAndSubscribe(t, wsURL, ClientRequest{Topics: []string{TopicGPU}})
+	if sub.Protocol != ProtocolVersion || len(sub.Topics) != 1 || sub.Topics[0] != TopicGPU {
+		t.Errorf("unexpected subscribed data: %+v", sub)
+	}
+
+	b.BroadcastTaskUpdate(TaskUpdateData{TaskID: "t1"})
+	b.BroadcastGPUUpdate(GPUUpdateData{Utilization: 42})
+
+	msg := readWireMessage(t, conn)
+	if msg.Type != MessageTypeGPUUpdate || msg.Topic != TopicGPU {
+		t.Fatalf("expected only the gpu update, got %s (%s)", msg.Type, msg.Topic)
+	}
+	if msg.Seq != 2 {
+		t.Errorf("expected seq 2 (task update was seq 1), got %d", msg.Seq)
+	}
+}
+
+func TestProtocol_ReplayOnReconnect(t *testing.T) {
+	b, wsURL := startProtocolServer(t, DefaultBroadcasterConfig())
+
+	conn, sub := dialAndSubscribe(t, wsURL, ClientRequest{Topics: []string{TopicTasks}})
+	b.BroadcastTaskUpdate(TaskUpdateData{TaskID: "t1"})
+	last := readWireMessage(t, conn)
+	conn.Close()
+
+	// Missed while disconnected
+	b.BroadcastTaskUpdate(TaskUpdateData{TaskID: "t2"})
+	b.BroadcastGPUUpdate(GPUUpdateData{})
+	b.BroadcastTaskUpdate(TaskUpdateData{TaskID: "t3"})
+	time.Sleep(50 * time.Millisecond)
+
+	conn, resumed := dialAndSubscribe(t, wsURL, ClientRequest{
+		Topics:  []string{TopicTasks},
+		LastSeq: last.Seq,
+		Epoch:   sub.Epoch,
+	})
+	if resumed.Replayed != 2 {
+		t.Errorf("expected 2 replayed messages, got %d", resumed.Replayed)
+	}
+	for _, want := range []string{"t2", "t3"} {
+		msg := readWireMessage(t, conn)
+		if msg.Type != MessageTypeTaskUpdate || !strings.Contains(string(msg.Data), want) {
+			t.Errorf("expected replay of %s, got %s %s", want, msg.Type, msg.Data)
+		}
+	}
+
+	// Live messages continue after the replay without duplicates
+	b.BroadcastTaskUpdate(TaskUpdateData{TaskID: "t4"})
+	if msg := readWireMessage(t, conn); msg.Seq != 5 {
+		t.Errorf("expected live seq 5, got %d", msg.Seq)
+	}
+}
+
+func TestProtocol_ResumeFromAck(t *testing.T) {
+	b, wsURL := startProtocolServer(t, DefaultBroadcasterConfig())
+
+	conn, sub := dialAndSubscribe(t, wsURL, ClientRequest{Topics: []string{TopicAll}, ClientID: "dash-1"})
+	b.BroadcastTaskUpdate(TaskUpdateData{TaskID: "t1"})
+	msg := readWireMessage(t, conn)
+	conn.WriteJSON(ClientRequest{Type: RequestAck, LastSeq: msg.Seq, Epoch: sub.Epoch})
+	time.Sleep(50 * time.Millisecond)
+	conn.Close()
+
+	b.BroadcastTaskUpdate(TaskUpdateData{TaskID: "t2"})
+	time.Sleep(50 * time.Millisecond)
+
+	// A reload loses LastSeq but keeps the client ID
+	conn, resumed := dialAndSubscribe(t, wsURL, ClientRequest{Topics: []string{TopicAll}, ClientID: "dash-1"})
+	if resumed.Replayed != 1 {
+		t.Errorf("expected 1 replayed message, got %d", resumed.Replayed)
+	}
+	if msg := readWireMessage(t, conn); !strings.Contains(string(msg.Data), "t2") {
+		t.Errorf("expected replay of t2, got %s", msg.Data)
+	}
+}
+
+func TestProtocol_ResyncWhenBufferExceeded(t *testing.T) {
+	config := DefaultBroadcasterConfig()
+	config.ReplayBufferSize = 2
+	b, wsURL := startProtocolServer(t, config)
+	b.SetInitialStateProvider(func() InitialData {
+		return InitialData{System: SystemStatusData{Status: "running"}}
+	})
+
+	_, sub := dialAndSubscribe(t, wsURL, ClientRequest{Topics: []string{TopicTasks}})
+	for i := 0; i < 5; i++ {
+		b.BroadcastTaskUpdate(TaskUpdateData{TaskID: "t"})
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	conn, _ := dialAndSubscribe(t, wsURL, ClientRequest{
+		Topics:  []string{TopicTasks},
+		LastSeq: 1,
+		Epoch:   sub.Epoch,
+	})
+	msg := readWireMessage(t, conn)
+	if msg.Type != MessageTypeResync {
+		t.Fatalf("expected resync, got %s", msg.Type)
+	}
+	var resync ResyncData
+	json.Unmarshal(msg.Data, &resync)
+	if resync.FromSeq != 1 || resync.OldestSeq != 4 || resync.Seq != 5 {
+		t.Errorf("unexpected resync data: %+v", resync)
+	}
+	if msg := readWireMessage(t, conn); msg.Type != MessageTypeInitial {
+		t.Errorf("expected initial after resync, got %s", msg.Type)
+	}
+}
+
+func TestProtocol_ResyncOnEpochChange(t *testing.T) {
+	_, wsURL := startProtocolServer(t, DefaultBroadcasterConfig())
+
+	conn, _ := dialAndSubscribe(t, wsURL, ClientRequest{
+		Topics:  []string{TopicTasks},
+		LastSeq: 1,
+		Epoch:   1,
+	})
+	if msg := readWireMessage(t, conn); msg.Type != MessageTypeResync {
+		t.Errorf("expected resync for a previous server run, got %s", msg.Type)
+	}
+}
+
+func TestProtocol_InitialStateOnFirstSubscribe(t *testing.T) {
+	b, wsURL := startProtocolServer(t, DefaultBroadcasterConfig())
+	b.SetInitialStateProvider(func() InitialData {
+		return InitialData{RecentTasks: []TaskUpdateData{{TaskID: "recent"}}}
+	})
+
+	conn, _ := dialAndSubscribe(t, wsURL, ClientRequest{Topics: []string{TopicTasks}})
+	msg := readWireMessage(t, conn)
+	if msg.Type != MessageTypeInitial || !strings.Contains(string(msg.Data), "recent") {
+		t.Errorf("expected initial state, got %s %s", msg.Type, msg.Data)
+	}
+}
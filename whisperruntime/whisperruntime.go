@@ -0,0 +1,191 @@
+// Package whisperruntime provides speech-to-text transcription with Whisper
+// models. Audio is sent to an OpenAI-compatible transcription endpoint
+// (POST {WHISPER_URL}/audio/transcriptions): a local whisper.cpp server
+// started with --inference-path /v1/audio/transcriptions, another local
+// Whisper server, or the OpenAI API. This file contains the settings and
+// the Client organism.
+package whisperruntime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go_backend/core"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Defaults.
+const (
+	DefaultModel        = "whisper-1"
+	DefaultTimeout      = 10 * time.Minute
+	DefaultMaxFileBytes = 25 << 20 // The OpenAI API limit
+)
+
+// ErrFileTooLarge is returned by Transcribe for audio over the size limit.
+var ErrFileTooLarge = errors.New("whisperruntime: audio file too large")
+
+// Config holds the transcription settings.
+type Config struct {
+	// URL is the base URL of the endpoint, e.g. http://127.0.0.1:8178/v1.
+	// Empty turns transcription off.
+	URL    string
+	APIKey string
+	Model  string
+	// Language is the ISO-639-1 language of the audio; empty detects it
+	Language     string
+	Timeout      time.Duration
+	MaxFileBytes int64
+}
+
+// ConfigFromEnv reads WHISPER_URL, WHISPER_API_KEY, WHISPER_MODEL,
+// WHISPER_LANGUAGE, WHISPER_TIMEOUT (seconds) and WHISPER_MAX_FILE_MB.
+// The API key defaults to apiKey, the OpenAI key of the server.
+func ConfigFromEnv(apiKey string) Config {
+	return Config{
+		URL:          strings.TrimRight(strings.TrimSpace(core.GetEnvOrDefault("WHISPER_URL", "")), "/"),
+		APIKey:       core.GetEnvOrDefault("WHISPER_API_KEY", apiKey),
+		Model:        core.GetEnvOrDefault("WHISPER_MODEL", DefaultModel),
+		Language:     strings.ToLower(strings.TrimSpace(core.GetEnvOrDefault("WHISPER_LANGUAGE", ""))),
+		Timeout:      core.ParseDurationEnv("WHISPER_TIMEOUT", int(DefaultTimeout/time.Second)),
+		MaxFileBytes: int64(core.ParseIntEnv("WHISPER_MAX_FILE_MB", DefaultMaxFileBytes>>20)) << 20,
+	}
+}
+
+// Segment is a timed part of a transcript.
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// Transcript is the text of a recording.
+type Transcript struct {
+	Text string
+	// Language is the language detected or given, if the endpoint reports it
+	Language string
+	// Duration of the recording, if the endpoint reports it
+	Duration time.Duration
+	// Segments are empty if the endpoint returns plain text
+	Segments []Segment
+}
+
+// Transcriber sends transcription requests. Implemented by openai.Client.
+type Transcriber interface {
+	CreateTranscription(ctx context.Context, request openai.AudioRequest) (openai.AudioResponse, error)
+}
+
+// Client transcribes audio files.
+//
+// Thread-Safety: Client is safe for concurrent use.
+type Client struct {
+	cfg         Config
+	transcriber Transcriber
+}
+
+// NewClient creates a Client sending requests with transcriber. It returns
+// nil if cfg.URL is empty; a nil Client transcribes nothing.
+func NewClient(cfg Config, transcriber Transcriber) *Client {
+	if cfg.URL == "" || transcriber == nil {
+		return nil
+	}
+	if cfg.Model == "" {
+		cfg.Model = DefaultModel
+	}
+	if cfg.MaxFileBytes <= 0 {
+		cfg.MaxFileBytes = DefaultMaxFileBytes
+	}
+	return &Client{cfg: cfg, transcriber: transcriber}
+}
+
+// Model returns the Whisper model name, or "" for a nil Client.
+func (c *Client) Model() string {
+	if c == nil {
+		return ""
+	}
+	return c.cfg.Model
+}
+
+// maxPromptRunes bounds the previous text sent with TranscribeAfter;
+// Whisper reads the last 224 tokens of a prompt.
+const maxPromptRunes = 600
+
+// Transcribe returns the transcript of the audio file at path. The file
+// name extension tells the endpoint the audio format.
+func (c *Client) Transcribe(ctx context.Context, path string) (*Transcript, error) {
+	return c.TranscribeAfter(ctx, path, "")
+}
+
+// TranscribeAfter is Transcribe for audio continuing a recording whose
+// text so far is previous, e.g. the next chunk of a live stream. The end
+// of previous helps the model with words cut at the start of the chunk and
+// keeps spelling consistent.
+func (c *Client) TranscribeAfter(ctx context.Context, path, previous string) (*Transcript, error) {
+	if c == nil {
+		return nil, errors.New("whisperruntime: transcription is not configured")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("whisperruntime: %w", err)
+	}
+	if info.Size() > c.cfg.MaxFileBytes {
+		return nil, fmt.Errorf("%w: %d MB, the limit is %d MB", ErrFileTooLarge, info.Size()>>20, c.cfg.MaxFileBytes>>20)
+	}
+
+	if c.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.cfg.Timeout)
+		defer cancel()
+	}
+	resp, err := c.transcriber.CreateTranscription(ctx, openai.AudioRequest{
+		Model:    c.cfg.Model,
+		FilePath: path,
+		Prompt:   promptTail(previous),
+		Language: c.cfg.Language,
+		Format:   openai.AudioResponseFormatVerboseJSON,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("whisperruntime: transcription failed: %w", err)
+	}
+	return toTranscript(resp, c.cfg.Language), nil
+}
+
+// toTranscript converts an endpoint response.
+func toTranscript(resp openai.AudioResponse, language string) *Transcript {
+	t := &Transcript{
+		Text:     strings.TrimSpace(resp.Text),
+		Language: resp.Language,
+		Duration: seconds(resp.Duration),
+	}
+	if t.Language == "" {
+		t.Language = language
+	}
+	for _, s := range resp.Segments {
+		text := strings.TrimSpace(s.Text)
+		if text == "" {
+			continue
+		}
+		t.Segments = append(t.Segments, Segment{Start: seconds(s.Start), End: seconds(s.End), Text: text})
+	}
+	if t.Duration == 0 && len(t.Segments) > 0 {
+		t.Duration = t.Segments[len(t.Segments)-1].End
+	}
+	return t
+}
+
+// promptTail returns the end of previous that fits in a prompt.
+func promptTail(previous string) string {
+	previous = strings.TrimSpace(previous)
+	if runes := []rune(previous); len(runes) > maxPromptRunes {
+		previous = string(runes[len(runes)-maxPromptRunes:])
+	}
+	return previous
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
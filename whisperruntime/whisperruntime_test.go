@@ -0,0 +1,101 @@
+package whisperruntime
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// fakeTranscriber returns a fixed verbose_json response
+type fakeTranscriber struct {
+	request openai.AudioRequest
+	err     error
+}
+
+func (f *fakeTranscriber) CreateTranscription(ctx context.Context, request openai.AudioRequest) (openai.AudioResponse, error) {
+	f.request = request
+	if f.err != nil {
+		return openai.AudioResponse{}, f.err
+	}
+	var resp openai.AudioResponse
+	err := json.Unmarshal([]byte(`{
+		"language": "english",
+		"text": " Hello there. Let's start. ",
+		"segments": [
+			{"start": 0, "end": 2.5, "text": " Hello there."},
+			{"start": 2.5, "end": 3, "text": " "},
+			{"start": 3, "end": 65.25, "text": " Let's start."}
+		]
+	}`), &resp)
+	return resp, err
+}
+
+func writeAudio(t *testing.T, size int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "memo.m4a")
+	if err := os.WriteFile(path, make([]byte, size), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTranscribe(t *testing.T) {
+	fake := &fakeTranscriber{}
+	client := NewClient(Config{URL: "http://127.0.0.1:8178/v1", Language: "en"}, fake)
+	path := writeAudio(t, 10)
+
+	transcript, err := client.Transcribe(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.request.Model != DefaultModel || fake.request.FilePath != path || fake.request.Language != "en" || fake.request.Format != openai.AudioResponseFormatVerboseJSON {
+		t.Errorf("request = %+v", fake.request)
+	}
+	// Blank segments are dropped and the duration comes from the last one
+	if transcript.Text != "Hello there. Let's start." || len(transcript.Segments) != 2 || transcript.Duration != 65250*time.Millisecond {
+		t.Errorf("Transcribe() = %+v", transcript)
+	}
+	if transcript.Segments[1].Start != 3*time.Second || transcript.Language != "english" {
+		t.Errorf("segments = %+v", transcript.Segments)
+	}
+
+	if fake.request.Prompt != "" {
+		t.Errorf("Transcribe() prompt = %q", fake.request.Prompt)
+	}
+	if _, err := client.TranscribeAfter(context.Background(), path, strings.Repeat("a", maxPromptRunes)+" Last words. "); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(fake.request.Prompt, "Last words.") || len([]rune(fake.request.Prompt)) != maxPromptRunes {
+		t.Errorf("TranscribeAfter() prompt = %q", fake.request.Prompt)
+	}
+
+	fake.err = errors.New("connection refused")
+	if _, err := client.Transcribe(context.Background(), path); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("Transcribe() error = %v", err)
+	}
+}
+
+func TestTranscribeLimits(t *testing.T) {
+	client := NewClient(Config{URL: "http://whisper", MaxFileBytes: 5}, &fakeTranscriber{})
+	if _, err := client.Transcribe(context.Background(), writeAudio(t, 6)); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("Transcribe(large) = %v, want ErrFileTooLarge", err)
+	}
+	if _, err := client.Transcribe(context.Background(), filepath.Join(t.TempDir(), "missing.mp3")); err == nil {
+		t.Error("Transcribe(missing) succeeded")
+	}
+
+	if NewClient(Config{}, &fakeTranscriber{}) != nil {
+		t.Error("NewClient() without a URL is not nil")
+	}
+	var off *Client
+	if _, err := off.Transcribe(context.Background(), "memo.mp3"); err == nil || off.Model() != "" {
+		t.Error("nil Client transcribed")
+	}
+}
@@ -0,0 +1,42 @@
+// Package widgettemplates provides reusable widget layouts for AI output.
+// This file contains the creation of instantiated templates on a canvas.
+package widgettemplates
+
+import "fmt"
+
+// NoteCreator creates notes. Implemented by canvusapi.Client.
+type NoteCreator interface {
+	CreateNote(payload map[string]interface{}) (map[string]interface{}, error)
+}
+
+// Created is a note created from a template.
+type Created struct {
+	Role Role
+	ID   string
+	Text string
+}
+
+// Create creates widgets on canvas in order. If one fails, the notes
+// created before it are returned with the error.
+func Create(canvas NoteCreator, widgets []Widget) ([]Created, error) {
+	created := make([]Created, 0, len(widgets))
+	for _, w := range widgets {
+		note, err := canvas.CreateNote(w.Payload)
+		if err != nil {
+			return created, fmt.Errorf("widgettemplates: failed to create %s note: %w", w.Role, err)
+		}
+		id, _ := note["id"].(string)
+		created = append(created, Created{Role: w.Role, ID: id, Text: w.Text})
+	}
+	return created, nil
+}
+
+// ID returns the ID of the note created for role, or "" if there is none.
+func ID(created []Created, role Role) string {
+	for _, c := range created {
+		if c.Role == role {
+			return c.ID
+		}
+	}
+	return ""
+}
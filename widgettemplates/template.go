@@ -0,0 +1,153 @@
+// Package widgettemplates provides reusable widget layouts for AI output.
+// A template is a stack of notes, such as a title, a body and a note of
+// source links, that handlers fill with content, so every response shares
+// the same colors, sizes and placement next to its trigger.
+//
+// Architecture (Atomic Design):
+//   - template.go: Template, Slot and Content atoms, the built-in templates and Instantiate
+//   - theme.go: Theme and Style atoms, and the theme of a configuration
+//   - create.go: Create, which creates the widgets of a template on a canvas
+package widgettemplates
+
+import (
+	"strings"
+
+	"go_backend/handlers"
+)
+
+// Role is the part of the content a slot holds.
+type Role string
+
+const (
+	RoleTitle   Role = "title"
+	RoleBody    Role = "body"
+	RoleSources Role = "sources"
+)
+
+// besideFactor places a template this many trigger widths from the left
+// edge of the trigger, leaving a tenth of its width as a gap.
+const besideFactor = 1.1
+
+// Slot is one note of a template.
+type Slot struct {
+	Role   Role
+	Height float64
+}
+
+// Template is a layout of notes stacked top to bottom, all Width wide with
+// Gap between them. Slots whose content is empty are left out and the
+// slots below move up.
+type Template struct {
+	Name  string
+	Width float64
+	Gap   float64
+	Slots []Slot
+}
+
+// Built-in templates.
+var (
+	// Response is a single note: AI answers, status and error notes
+	Response = Template{
+		Name:  "response",
+		Width: 300,
+		Gap:   10,
+		Slots: []Slot{{Role: RoleBody, Height: 150}},
+	}
+	// Report is a title note, a body note and a note of source links
+	Report = Template{
+		Name:  "report",
+		Width: 300,
+		Gap:   10,
+		Slots: []Slot{
+			{Role: RoleTitle, Height: 50},
+			{Role: RoleBody, Height: 300},
+			{Role: RoleSources, Height: 90},
+		},
+	}
+)
+
+// Source is a link shown in the sources note.
+type Source struct {
+	Title string
+	URL   string
+}
+
+// Content fills the slots of a template.
+type Content struct {
+	Title   string
+	Body    string
+	Sources []Source
+}
+
+// text returns the text of the slot with role, or "" if it has none.
+func (c Content) text(role Role) string {
+	switch role {
+	case RoleTitle:
+		return strings.TrimSpace(c.Title)
+	case RoleBody:
+		return c.Body
+	case RoleSources:
+		var lines []string
+		for _, s := range c.Sources {
+			title, url := strings.TrimSpace(s.Title), strings.TrimSpace(s.URL)
+			switch {
+			case title != "" && url != "":
+				lines = append(lines, "🔗 "+title+"\n"+url)
+			case url != "":
+				lines = append(lines, "🔗 "+url)
+			case title != "":
+				lines = append(lines, "🔗 "+title)
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+	return ""
+}
+
+// Widget is a note of an instantiated template, ready to be created.
+type Widget struct {
+	Role    Role
+	Text    string
+	Payload map[string]interface{}
+}
+
+// Beside returns where a template next to trigger starts: to its right,
+// aligned with its top.
+//
+// This is a pure function (atom) with no external dependencies.
+func Beside(trigger handlers.Rect) handlers.Location {
+	return handlers.Location{X: trigger.X + trigger.Width*besideFactor, Y: trigger.Y}
+}
+
+// Instantiate fills t with content at origin in the colors of theme and
+// returns the note payloads in slot order.
+//
+// This is a pure function (molecule) with no external dependencies.
+//
+// Example:
+//
+//	widgets := widgettemplates.Report.Instantiate(content, widgettemplates.Beside(bounds), theme)
+func (t Template) Instantiate(content Content, origin handlers.Location, theme Theme) []Widget {
+	var widgets []Widget
+	y := origin.Y
+	for _, slot := range t.Slots {
+		text := content.text(slot.Role)
+		if text == "" {
+			continue
+		}
+		style := theme.style(slot.Role)
+		widgets = append(widgets, Widget{
+			Role: slot.Role,
+			Text: text,
+			Payload: map[string]interface{}{
+				"text":             text,
+				"location":         handlers.LocationToMap(handlers.Location{X: origin.X, Y: y}),
+				"size":             handlers.SizeToMap(handlers.NoteSize{Width: t.Width, Height: slot.Height}),
+				"background_color": style.BackgroundColor,
+				"text_color":       style.TextColor,
+			},
+		})
+		y += slot.Height + t.Gap
+	}
+	return widgets
+}
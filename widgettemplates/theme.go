@@ -0,0 +1,79 @@
+// Package widgettemplates provides reusable widget layouts for AI output.
+// This file contains the colors of the notes.
+package widgettemplates
+
+import (
+	"strings"
+
+	"go_backend/core"
+	"go_backend/handlers"
+)
+
+// Status colors. Processing notes are dark red while the AI works, then
+// take the color of their result.
+const (
+	ProcessingColor     = "#8B0000" // Dark blood red
+	ProcessingTextColor = "#FFFFFF"
+	ErrorColor          = "#DC143C" // Crimson
+	ErrorTextColor      = "#FFFFFF"
+	WarningColor        = "#FFD700" // Gold
+	WarningTextColor    = "#000000"
+)
+
+// Style is the colors of a note.
+type Style struct {
+	BackgroundColor string
+	TextColor       string
+}
+
+// Theme is the colors of the notes of a template.
+type Theme struct {
+	Title   Style
+	Body    Style
+	Sources Style
+}
+
+// ThemeFromConfig returns the theme of the AI response note colors of cfg
+// (NOTE_COLOR and NOTE_TEXT_COLOR, or a canvas's own colors): the body in
+// those colors, the title with them swapped so it stands out, and the
+// sources slightly transparent.
+func ThemeFromConfig(cfg *core.Config) Theme {
+	body := Style{BackgroundColor: cfg.NoteColor, TextColor: cfg.NoteTextColor}
+	return Theme{
+		Title:   Style{BackgroundColor: body.TextColor, TextColor: body.BackgroundColor},
+		Body:    body,
+		Sources: Style{BackgroundColor: handlers.ReduceBackgroundOpacity(body.BackgroundColor), TextColor: body.TextColor},
+	}
+}
+
+// StatusStyle returns the style of a processing note showing text: an
+// error, warning or progress marker at its start picks the status color,
+// and any other text is a result in the body style.
+func (t Theme) StatusStyle(text string) Style {
+	switch {
+	case strings.HasPrefix(text, "❌"):
+		return Style{BackgroundColor: ErrorColor, TextColor: ErrorTextColor}
+	case strings.HasPrefix(text, "⚠️"):
+		return Style{BackgroundColor: WarningColor, TextColor: WarningTextColor}
+	case strings.HasPrefix(text, "⏳"):
+		return Style{BackgroundColor: ProcessingColor, TextColor: ProcessingTextColor}
+	}
+	return t.Body
+}
+
+// WithBody returns t with its body in style, e.g. an error note.
+func (t Theme) WithBody(style Style) Theme {
+	t.Body = style
+	return t
+}
+
+// style returns the style of the slot with role.
+func (t Theme) style(role Role) Style {
+	switch role {
+	case RoleTitle:
+		return t.Title
+	case RoleSources:
+		return t.Sources
+	}
+	return t.Body
+}
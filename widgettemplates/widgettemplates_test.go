@@ -0,0 +1,102 @@
+package widgettemplates
+
+import (
+	"errors"
+	"testing"
+
+	"go_backend/core"
+	"go_backend/handlers"
+)
+
+// fakeCanvas records created notes and fails after failAfter notes
+type fakeCanvas struct {
+	payloads  []map[string]interface{}
+	failAfter int
+}
+
+func (f *fakeCanvas) CreateNote(payload map[string]interface{}) (map[string]interface{}, error) {
+	if f.failAfter > 0 && len(f.payloads) == f.failAfter {
+		return nil, errors.New("canvas unavailable")
+	}
+	f.payloads = append(f.payloads, payload)
+	return map[string]interface{}{"id": payload["text"]}, nil
+}
+
+func TestReportInstantiate(t *testing.T) {
+	theme := ThemeFromConfig(&core.Config{NoteColor: "#FFFFFF", NoteTextColor: "#000000"})
+	content := Content{
+		Title: "Quarterly plan",
+		Body:  "Body text",
+		Sources: []Source{
+			{Title: "Roadmap", URL: "https://example.com/roadmap"},
+			{URL: "https://example.com/notes"},
+			{},
+		},
+	}
+	widgets := Report.Instantiate(content, Beside(handlers.Rect{X: 100, Y: 50, Width: 200, Height: 100}), theme)
+	if len(widgets) != 3 {
+		t.Fatalf("widgets = %d, want 3", len(widgets))
+	}
+
+	// Stacked top to bottom at the same x, to the right of the trigger
+	wantY := []float64{50, 50 + 50 + 10, 50 + 50 + 10 + 300 + 10}
+	for i, w := range widgets {
+		loc := w.Payload["location"].(map[string]float64)
+		if loc["x"] != 100+200*besideFactor || loc["y"] != wantY[i] {
+			t.Errorf("%s note at %v, want x %v y %v", w.Role, loc, 100+200*besideFactor, wantY[i])
+		}
+	}
+	if widgets[0].Payload["background_color"] != "#000000" || widgets[0].Payload["text_color"] != "#FFFFFF" {
+		t.Errorf("title colors = %v", widgets[0].Payload)
+	}
+	if widgets[1].Payload["background_color"] != "#FFFFFF" || widgets[2].Payload["background_color"] != "#FFFFFFBF" {
+		t.Errorf("body and sources colors = %v, %v", widgets[1].Payload["background_color"], widgets[2].Payload["background_color"])
+	}
+	if want := "🔗 Roadmap\nhttps://example.com/roadmap\n🔗 https://example.com/notes"; widgets[2].Text != want {
+		t.Errorf("sources = %q, want %q", widgets[2].Text, want)
+	}
+
+	// Without a title the body moves up
+	widgets = Report.Instantiate(Content{Body: "Only a body"}, handlers.Location{}, theme)
+	if len(widgets) != 1 || widgets[0].Role != RoleBody || widgets[0].Payload["location"].(map[string]float64)["y"] != 0 {
+		t.Errorf("body-only report = %+v", widgets)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	theme := ThemeFromConfig(&core.Config{NoteColor: "#FFFFFF", NoteTextColor: "#000000"})
+	widgets := Report.Instantiate(Content{Title: "T", Body: "B", Sources: []Source{{URL: "U"}}}, handlers.Location{}, theme)
+
+	canvas := &fakeCanvas{}
+	created, err := Create(canvas, widgets)
+	if err != nil || len(created) != 3 {
+		t.Fatalf("Create() = %+v, %v", created, err)
+	}
+	if ID(created, RoleBody) != "B" || ID(created, RoleTitle) != "T" || ID(created, Role("none")) != "" {
+		t.Errorf("IDs = %+v", created)
+	}
+
+	canvas = &fakeCanvas{failAfter: 1}
+	created, err = Create(canvas, widgets)
+	if err == nil || len(created) != 1 {
+		t.Errorf("Create(failing) = %+v, %v, want one note and an error", created, err)
+	}
+}
+
+func TestStatusStyle(t *testing.T) {
+	theme := ThemeFromConfig(&core.Config{NoteColor: "#112233", NoteTextColor: "#445566"})
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"❌ failed", ErrorColor},
+		{"⚠️ careful", WarningColor},
+		{"⏳ working", ProcessingColor},
+		{"Done", "#112233"},
+	}
+	for _, tt := range tests {
+		if got := theme.StatusStyle(tt.text).BackgroundColor; got != tt.want {
+			t.Errorf("StatusStyle(%q) = %s, want %s", tt.text, got, tt.want)
+		}
+	}
+}
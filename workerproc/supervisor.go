@@ -0,0 +1,312 @@
+package workerproc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ErrExited is returned by Start when the worker exits before it is ready.
+var ErrExited = errors.New("workerproc: worker exited before it was ready")
+
+// readyPoll is the interval between readiness checks while starting.
+const readyPoll = 500 * time.Millisecond
+
+// stableRun is how long a worker must run for the restart after its
+// crash to wait RestartDelay again instead of backing off further.
+const stableRun = time.Minute
+
+// Status reports the state of a supervised worker.
+type Status struct {
+	Name     string `json:"name"`
+	Running  bool   `json:"running"`
+	PID      int    `json:"pid,omitempty"`
+	Restarts int    `json:"restarts"`
+	// LastCrash is nil until the worker has crashed
+	LastCrash *Crash `json:"last_crash,omitempty"`
+}
+
+// run is one start of the worker process.
+type run struct {
+	cmd     *exec.Cmd
+	stdin   io.Closer
+	output  *tail
+	started time.Time
+	// exited is closed once err is set
+	exited chan struct{}
+	err    error
+}
+
+// Supervisor is an organism that keeps a worker process running. The
+// worker holds its standard input open and must exit when it closes,
+// which Stop uses to end it and which ends it if the server itself dies.
+//
+// Usage:
+//
+//	s := workerproc.New("gpu-worker", cfg, func() *exec.Cmd {
+//		return exec.Command(executable, "gpu-worker")
+//	}, logger)
+//	s.OnCrash(dispatcher.WorkerCrashed)
+//	if err := s.Start(ctx, ready); err != nil { ... }
+//	defer s.Stop(ctx)
+//
+// Thread-Safety: Supervisor is safe for concurrent use.
+type Supervisor struct {
+	name    string
+	cfg     Config
+	command func() *exec.Cmd
+	logger  *zap.Logger
+
+	mu        sync.Mutex
+	onCrash   func(Crash)
+	current   *run
+	restarts  int
+	lastCrash *Crash
+	stopping  bool
+	stop      chan struct{}
+}
+
+// New creates a Supervisor for the processes command returns. command is
+// called for every start and must return a new, unstarted command.
+func New(name string, cfg Config, command func() *exec.Cmd, logger *zap.Logger) *Supervisor {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	if cfg.RestartDelay <= 0 {
+		cfg.RestartDelay = DefaultRestartDelay
+	}
+	if cfg.MaxRestartDelay < cfg.RestartDelay {
+		cfg.MaxRestartDelay = cfg.RestartDelay
+	}
+	if cfg.StartTimeout <= 0 {
+		cfg.StartTimeout = DefaultStartTimeout
+	}
+	return &Supervisor{name: name, cfg: cfg, command: command, logger: logger, stop: make(chan struct{})}
+}
+
+// OnCrash sets a function called with every crash, before the restart.
+func (s *Supervisor) OnCrash(fn func(Crash)) {
+	s.mu.Lock()
+	s.onCrash = fn
+	s.mu.Unlock()
+}
+
+// Start starts the worker and waits until ready returns nil, then keeps
+// restarting it whenever it exits until Stop. It fails with ErrExited if
+// the worker exits first, or when Config.StartTimeout passes.
+func (s *Supervisor) Start(ctx context.Context, ready func(context.Context) error) error {
+	r, err := s.spawn()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.StartTimeout)
+	defer cancel()
+	for ready(ctx) != nil {
+		select {
+		case <-r.exited:
+			s.clear(r)
+			if output := r.output.Lines(5); output != "" {
+				return fmt.Errorf("%w: %s\n%s", ErrExited, describe(r), output)
+			}
+			return fmt.Errorf("%w: %s", ErrExited, describe(r))
+		case <-ctx.Done():
+			r.cmd.Process.Kill()
+			<-r.exited
+			s.clear(r)
+			return fmt.Errorf("workerproc: %s not ready: %w", s.name, ctx.Err())
+		case <-time.After(readyPoll):
+		}
+	}
+
+	s.logger.Info("Worker process ready",
+		zap.String("worker", s.name),
+		zap.Int("pid", r.cmd.Process.Pid),
+		zap.Duration("startup", time.Since(r.started)))
+	go s.supervise(r)
+	return nil
+}
+
+// Stop closes the worker's standard input and waits for it to exit until
+// ctx is done, then kills it. The worker is not restarted afterwards.
+func (s *Supervisor) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if s.stopping {
+		s.mu.Unlock()
+		return nil
+	}
+	s.stopping = true
+	close(s.stop)
+	r := s.current
+	s.mu.Unlock()
+	if r == nil {
+		return nil
+	}
+
+	r.stdin.Close()
+	select {
+	case <-r.exited:
+		return nil
+	case <-ctx.Done():
+		r.cmd.Process.Kill()
+		<-r.exited
+		return fmt.Errorf("workerproc: %s did not exit in time and was killed", s.name)
+	}
+}
+
+// Status returns the state of the worker.
+func (s *Supervisor) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := Status{Name: s.name, Restarts: s.restarts, LastCrash: s.lastCrash}
+	if r := s.current; r != nil {
+		select {
+		case <-r.exited:
+		default:
+			status.Running, status.PID = true, r.cmd.Process.Pid
+		}
+	}
+	return status
+}
+
+// spawn starts a new worker process.
+func (s *Supervisor) spawn() (*run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopping {
+		return nil, fmt.Errorf("workerproc: %s is stopping", s.name)
+	}
+
+	cmd := s.command()
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("workerproc: %s: %w", s.name, err)
+	}
+	r := &run{cmd: cmd, stdin: stdin, output: &tail{}, exited: make(chan struct{})}
+	if cmd.Stderr != nil {
+		cmd.Stderr = io.MultiWriter(cmd.Stderr, r.output)
+	} else {
+		cmd.Stderr = r.output
+	}
+	if err := cmd.Start(); err != nil {
+		stdin.Close()
+		return nil, fmt.Errorf("workerproc: start %s: %w", s.name, err)
+	}
+	r.started = time.Now()
+	go func() {
+		r.err = cmd.Wait()
+		close(r.exited)
+	}()
+	s.current = r
+	return r, nil
+}
+
+// clear forgets r if it is the current run.
+func (s *Supervisor) clear(r *run) {
+	s.mu.Lock()
+	if s.current == r {
+		s.current = nil
+	}
+	s.mu.Unlock()
+}
+
+// supervise restarts the worker each time it exits, backing off while it
+// keeps crashing, until Stop.
+func (s *Supervisor) supervise(r *run) {
+	delay := s.cfg.RestartDelay
+	for {
+		select {
+		case <-r.exited:
+		case <-s.stop:
+			return
+		}
+		if s.stopped() {
+			return
+		}
+
+		if time.Since(r.started) >= stableRun {
+			delay = s.cfg.RestartDelay
+		}
+		s.report(r)
+
+		select {
+		case <-time.After(delay):
+		case <-s.stop:
+			return
+		}
+		delay = min(delay*2, s.cfg.MaxRestartDelay)
+
+		next, err := s.spawn()
+		for err != nil {
+			if s.stopped() {
+				return
+			}
+			s.logger.Error("Failed to restart worker process",
+				zap.String("worker", s.name), zap.Error(err))
+			select {
+			case <-time.After(delay):
+			case <-s.stop:
+				return
+			}
+			delay = min(delay*2, s.cfg.MaxRestartDelay)
+			next, err = s.spawn()
+		}
+		s.logger.Info("Worker process restarted",
+			zap.String("worker", s.name),
+			zap.Int("pid", next.cmd.Process.Pid))
+		r = next
+	}
+}
+
+// stopped reports whether Stop was called.
+func (s *Supervisor) stopped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stopping
+}
+
+// report records and logs the crash of r and passes it to OnCrash.
+func (s *Supervisor) report(r *run) {
+	crash := Crash{
+		Name:     s.name,
+		PID:      r.cmd.Process.Pid,
+		ExitCode: r.cmd.ProcessState.ExitCode(),
+		Reason:   describe(r),
+		Output:   r.output.Lines(outputLines),
+		Uptime:   time.Since(r.started),
+		Time:     time.Now(),
+	}
+
+	s.mu.Lock()
+	s.restarts++
+	crash.Restarts = s.restarts
+	s.lastCrash = &crash
+	onCrash := s.onCrash
+	s.mu.Unlock()
+
+	s.logger.Error("Worker process crashed, restarting",
+		zap.String("worker", s.name),
+		zap.Int("pid", crash.PID),
+		zap.Int("exit_code", crash.ExitCode),
+		zap.String("reason", crash.Reason),
+		zap.Duration("uptime", crash.Uptime),
+		zap.Int("restarts", crash.Restarts),
+		zap.String("output", crash.Output))
+	if onCrash != nil {
+		onCrash(crash)
+	}
+}
+
+// describe returns how r ended.
+func describe(r *run) string {
+	if r.err != nil {
+		return r.err.Error()
+	}
+	return "exited unexpectedly"
+}
@@ -0,0 +1,159 @@
+// Package workerproc runs native runtimes in a child process of the same
+// binary and restarts it when it exits, so a crash inside
+// stable-diffusion.cpp or llama.cpp ends the worker instead of the canvas
+// monitor.
+//
+// This file contains the configuration and the crash report.
+package workerproc
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go_backend/core"
+)
+
+// Isolation modes.
+const (
+	// ModeOff runs the runtimes in the server process
+	ModeOff = "off"
+	// ModeProcess runs them in a supervised child process
+	ModeProcess = "process"
+)
+
+// Defaults.
+const (
+	DefaultAddr            = "127.0.0.1:8092"
+	DefaultRestartDelay    = 2 * time.Second
+	DefaultMaxRestartDelay = time.Minute
+	DefaultStartTimeout    = 2 * time.Minute
+)
+
+// ErrInvalidMode is returned for a GPU_ISOLATION value other than off or
+// process.
+var ErrInvalidMode = errors.New("workerproc: GPU_ISOLATION must be off or process")
+
+// Config configures the Supervisor.
+type Config struct {
+	// Mode is ModeOff or ModeProcess
+	Mode string
+
+	// Addr is the loopback address the worker listens on
+	Addr string
+
+	// Token authenticates the server to the worker; the server passes a
+	// NewToken to each worker it starts
+	Token string
+
+	// RestartDelay is the wait before the first restart; it doubles with
+	// each crash that follows shortly after a restart
+	RestartDelay time.Duration
+
+	// MaxRestartDelay caps the restart backoff
+	MaxRestartDelay time.Duration
+
+	// StartTimeout bounds the wait for the worker to become ready,
+	// including loading the model
+	StartTimeout time.Duration
+}
+
+// Enabled reports whether the runtimes run in a child process.
+func (c Config) Enabled() bool {
+	return c.Mode == ModeProcess
+}
+
+// ConfigFromEnv reads GPU_ISOLATION, GPU_WORKER_ADDR, GPU_WORKER_TOKEN,
+// GPU_WORKER_START_TIMEOUT and GPU_WORKER_MAX_RESTART_DELAY (seconds). An
+// invalid mode is returned as an error together with a config that keeps
+// the runtimes in process.
+func ConfigFromEnv() (Config, error) {
+	cfg := Config{
+		Mode:            strings.ToLower(strings.TrimSpace(core.GetEnvOrDefault("GPU_ISOLATION", ModeOff))),
+		Addr:            core.GetEnvOrDefault("GPU_WORKER_ADDR", DefaultAddr),
+		Token:           core.GetEnvOrDefault("GPU_WORKER_TOKEN", ""),
+		RestartDelay:    DefaultRestartDelay,
+		MaxRestartDelay: core.ParseDurationEnv("GPU_WORKER_MAX_RESTART_DELAY", int(DefaultMaxRestartDelay/time.Second)),
+		StartTimeout:    core.ParseDurationEnv("GPU_WORKER_START_TIMEOUT", int(DefaultStartTimeout/time.Second)),
+	}
+	switch cfg.Mode {
+	case ModeOff, ModeProcess:
+		return cfg, nil
+	case "":
+		cfg.Mode = ModeOff
+		return cfg, nil
+	}
+	mode := cfg.Mode
+	cfg.Mode = ModeOff
+	return cfg, fmt.Errorf("%w (got %q)", ErrInvalidMode, mode)
+}
+
+// NewToken returns a random bearer token for one worker, so other local
+// users cannot generate on its port.
+func NewToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Crash describes a worker that exited without being stopped.
+type Crash struct {
+	// Name of the worker
+	Name string `json:"name"`
+	PID  int    `json:"pid"`
+	// ExitCode is -1 when the worker was killed by a signal
+	ExitCode int `json:"exit_code"`
+	// Reason is how the worker ended, e.g. "signal: segmentation fault"
+	Reason string `json:"reason"`
+	// Output holds the last lines the worker wrote to stderr, where the Go
+	// runtime prints the stack of a native fault
+	Output string        `json:"output,omitempty"`
+	Uptime time.Duration `json:"uptime"`
+	// Restarts counts the restarts so far, including the one this crash
+	// causes
+	Restarts int       `json:"restarts"`
+	Time     time.Time `json:"time"`
+}
+
+// Summary returns a one-line description of the crash.
+func (c Crash) Summary() string {
+	return fmt.Sprintf("%s (pid %d) exited after %s: %s", c.Name, c.PID, c.Uptime.Round(time.Second), c.Reason)
+}
+
+// maxOutputBytes bounds the stderr kept per worker run.
+const maxOutputBytes = 16 << 10
+
+// outputLines is how many lines of stderr a Crash carries.
+const outputLines = 40
+
+// tail keeps the end of a worker's stderr.
+type tail struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// Write implements io.Writer.
+func (t *tail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > maxOutputBytes {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-maxOutputBytes:]...)
+	}
+	return len(p), nil
+}
+
+// Lines returns the last n lines written.
+func (t *tail) Lines(n int) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	lines := strings.Split(strings.TrimRight(string(t.buf), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
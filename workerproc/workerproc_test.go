@@ -0,0 +1,168 @@
+package workerproc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestMain runs the test binary as a worker when WORKERPROC_HELPER is set.
+func TestMain(m *testing.M) {
+	switch os.Getenv("WORKERPROC_HELPER") {
+	case "":
+		os.Exit(m.Run())
+	case "serve":
+		io.Copy(io.Discard, os.Stdin)
+		os.Exit(0)
+	case "crash":
+		fmt.Fprintln(os.Stderr, "fatal error: unexpected signal during runtime execution")
+		fmt.Fprintln(os.Stderr, "[signal SIGSEGV: segmentation violation]")
+		os.Exit(2)
+	case "crash-once":
+		marker := os.Getenv("WORKERPROC_MARKER")
+		if _, err := os.Stat(marker); err != nil {
+			os.WriteFile(marker, nil, 0o644)
+			time.Sleep(50 * time.Millisecond)
+			fmt.Fprintln(os.Stderr, "SIGSEGV: segmentation violation")
+			os.Exit(2)
+		}
+		io.Copy(io.Discard, os.Stdin)
+		os.Exit(0)
+	case "hang":
+		// Ignores its standard input
+		time.Sleep(time.Minute)
+		os.Exit(0)
+	}
+}
+
+// helper returns a command running the test binary as the given worker.
+func helper(mode string, env ...string) func() *exec.Cmd {
+	return func() *exec.Cmd {
+		cmd := exec.Command(os.Args[0], "-test.run=^$")
+		cmd.Env = append(os.Environ(), append(env, "WORKERPROC_HELPER="+mode)...)
+		return cmd
+	}
+}
+
+func ready(context.Context) error { return nil }
+
+func TestConfigFromEnv(t *testing.T) {
+	cfg, err := ConfigFromEnv()
+	if err != nil || cfg.Enabled() || cfg.Addr != DefaultAddr || cfg.StartTimeout != DefaultStartTimeout {
+		t.Errorf("ConfigFromEnv() = %+v, %v", cfg, err)
+	}
+
+	t.Setenv("GPU_ISOLATION", " Process ")
+	t.Setenv("GPU_WORKER_START_TIMEOUT", "300")
+	t.Setenv("GPU_WORKER_TOKEN", "abc123")
+	if cfg, err := ConfigFromEnv(); err != nil || !cfg.Enabled() || cfg.StartTimeout != 5*time.Minute || cfg.Token != "abc123" {
+		t.Errorf("ConfigFromEnv() = %+v, %v", cfg, err)
+	}
+
+	t.Setenv("GPU_ISOLATION", "container")
+	if cfg, err := ConfigFromEnv(); !errors.Is(err, ErrInvalidMode) || cfg.Enabled() {
+		t.Errorf("ConfigFromEnv() = %+v, %v, want ErrInvalidMode", cfg, err)
+	}
+}
+
+func TestTail(t *testing.T) {
+	var out tail
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(&out, "line %d\n", i)
+	}
+	if got := out.Lines(2); got != "line 98\nline 99" {
+		t.Errorf("Lines(2) = %q", got)
+	}
+	out.Write([]byte(strings.Repeat("x", maxOutputBytes+10)))
+	if got := out.Lines(1); len(got) != maxOutputBytes {
+		t.Errorf("Lines(1) kept %d bytes", len(got))
+	}
+}
+
+func TestSupervisorRestartsAfterCrash(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "crashed")
+	s := New("test-worker", Config{RestartDelay: 10 * time.Millisecond}, helper("crash-once", "WORKERPROC_MARKER="+marker), nil)
+
+	var mu sync.Mutex
+	var crashes []Crash
+	s.OnCrash(func(c Crash) {
+		mu.Lock()
+		crashes = append(crashes, c)
+		mu.Unlock()
+	})
+	if err := s.Start(context.Background(), ready); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+	for s.Status().Restarts == 0 || !s.Status().Running {
+		if time.Now().After(deadline) {
+			t.Fatalf("worker not restarted: %+v", s.Status())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	if len(crashes) != 1 {
+		t.Fatalf("crashes = %+v", crashes)
+	}
+	c := crashes[0]
+	mu.Unlock()
+	if c.ExitCode != 2 || c.Restarts != 1 || c.Name != "test-worker" || !strings.Contains(c.Output, "SIGSEGV") {
+		t.Errorf("crash = %+v", c)
+	}
+	if status := s.Status(); status.LastCrash == nil || status.PID == c.PID {
+		t.Errorf("Status() = %+v", status)
+	}
+
+	if err := s.Stop(context.Background()); err != nil {
+		t.Errorf("Stop() = %v", err)
+	}
+	if status := s.Status(); status.Running || status.Restarts != 1 {
+		t.Errorf("Status() after Stop = %+v", status)
+	}
+}
+
+func TestSupervisorStartFails(t *testing.T) {
+	notReady := func(context.Context) error { return errors.New("not listening") }
+
+	s := New("test-worker", Config{}, helper("crash"), nil)
+	err := s.Start(context.Background(), notReady)
+	if !errors.Is(err, ErrExited) || !strings.Contains(err.Error(), "segmentation violation") {
+		t.Errorf("Start() = %v, want ErrExited with output", err)
+	}
+	if s.Status().Running {
+		t.Error("worker running after failed start")
+	}
+
+	s = New("test-worker", Config{StartTimeout: 100 * time.Millisecond}, helper("serve"), nil)
+	if err := s.Start(context.Background(), notReady); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Start() = %v, want deadline exceeded", err)
+	}
+}
+
+func TestSupervisorStopKills(t *testing.T) {
+	s := New("test-worker", Config{}, helper("hang"), nil)
+	if err := s.Start(context.Background(), ready); err != nil {
+		t.Fatalf("Start() = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); err == nil {
+		t.Error("Stop() = nil for a worker ignoring its input")
+	}
+	if s.Status().Running {
+		t.Error("worker running after Stop")
+	}
+	if s.Status().Restarts != 0 {
+		t.Error("stopped worker was restarted")
+	}
+}